@@ -1,14 +1,24 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/dive/inspect"
+	"io/ioutil"
 	"os"
+	"runtime/debug"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/ui/script"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
 )
 
 // doAnalyzeCmd takes a docker image tag, digest, or id and displays the
@@ -34,6 +44,8 @@ func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 
 	initLogging()
 
+	checkConfig()
+
 	isCi, ciConfig, err := configureCi()
 
 	if err != nil {
@@ -41,10 +53,221 @@ func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	var sourceType dive.ImageSource
-	var imageStr string
+	profileKind, profilePath, err := configureProfile(cmd)
+	if err != nil {
+		fmt.Printf("profile error: %v\n", err)
+		os.Exit(1)
+	}
+
+	budget, err := configureBudget(cmd)
+	if err != nil {
+		fmt.Printf("budget configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = configureIgnore(cmd)
+	if err != nil {
+		fmt.Printf("ignore file error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sourceType, imageStr, sourceReason := detectImageSource(userImage)
+	logrus.Debugf("image source: %s://%s (%s)", sourceType, imageStr, sourceReason)
+
+	sourceType, err = discoverEngine(sourceType)
+	if err != nil {
+		fmt.Printf("cannot find a container engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = applyImageProfiles(cmd, imageStr, ciConfig)
+	if err != nil {
+		fmt.Printf("image settings error: %v\n", err)
+		os.Exit(1)
+	}
+
+	resolverOptions.Inspectors, err = inspect.Inspectors(viper.GetStringSlice("inspect.inspectors"))
+	if err != nil {
+		fmt.Printf("inspector configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	audit, err := cmd.Flags().GetBool("audit")
+	if err != nil {
+		logrus.Error("unable to get 'audit' option:", err)
+	}
+	if audit {
+		viper.Set("audit.enabled", true)
+	}
+	configureAudit()
+
+	err = configureIO(cmd)
+	if err != nil {
+		fmt.Printf("io configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	mapping, err := loadIDMapping()
+	if err != nil {
+		fmt.Printf("userns configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = configureBounds(cmd)
+	if err != nil {
+		fmt.Printf("bounds configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	configureLayerCache()
+
+	duplicates, err := cmd.Flags().GetBool("duplicates")
+	if err != nil {
+		logrus.Error("unable to get 'duplicates' option:", err)
+	}
+	if duplicates {
+		viper.Set("duplicates.enabled", true)
+	}
+
+	packages, err := cmd.Flags().GetBool("packages")
+	if err != nil {
+		logrus.Error("unable to get 'packages' option:", err)
+	}
+	if packages {
+		viper.Set("packages.enabled", true)
+	}
+
+	dependencies, err := cmd.Flags().GetBool("dependencies")
+	if err != nil {
+		logrus.Error("unable to get 'dependencies' option:", err)
+	}
+	if dependencies {
+		viper.Set("dependencies.enabled", true)
+	}
+
+	ignoreErrors, err := cmd.PersistentFlags().GetBool("ignore-errors")
+	if err != nil {
+		logrus.Error("unable to get 'ignore-errors' option:", err)
+	}
+
+	remoteReference, err := compareRemoteReference(compareRemote, sourceType, imageStr)
+	if err != nil {
+		fmt.Printf("cannot compare with the published image: %v\n", err)
+		os.Exit(1)
+	}
 
-	sourceType, imageStr = dive.DeriveImageSource(userImage)
+	signature, err := configureSignature(sourceType)
+	if err != nil {
+		fmt.Printf("signature verification error: %v\n", err)
+		os.Exit(1)
+	}
+
+	lazy, err := cmd.Flags().GetBool("lazy")
+	if err != nil {
+		logrus.Error("unable to get 'lazy' option:", err)
+	}
+
+	// the other images are opened in tabs of the UI
+	var tabs []runtime.TabImage
+	for _, userTab := range args[1:] {
+		if isCi || exportFile != "" || exportQuery != "" || remoteReference != "" {
+			fmt.Println("several images can only be opened in the UI (not with --ci, --json, --query or --compare-remote)")
+			os.Exit(1)
+		}
+		tabSource, tabImage, _ := detectImageSource(userTab)
+		tabSource, err = discoverEngine(tabSource)
+		if err != nil {
+			fmt.Printf("cannot find a container engine: %v\n", err)
+			os.Exit(1)
+		}
+		tabs = append(tabs, runtime.TabImage{Source: tabSource, Image: tabImage})
+	}
+
+	var steps script.Script
+	if scriptFile != "" {
+		steps, err = script.Load(scriptFile)
+		if err != nil {
+			fmt.Printf("script error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if (renderSnapshot != "" || scriptFile != "") && terminal.SnapshotOutput() == nil {
+		if isCi || exportFile != "" || exportQuery != "" || remoteReference != "" {
+			fmt.Println("--render-snapshot and --script render the UI (they cannot be given with --ci, --json, --query or --compare-remote)")
+			os.Exit(1)
+		}
+		os.Exit(renderHeadless(renderSnapshot))
+	}
+
+	if profileKind != "" {
+		if err := runtime.StartProfile(profileKind, profilePath); err != nil {
+			fmt.Printf("profile error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	runtime.Run(signalContext(), runtime.Options{
+		Ci:              isCi,
+		Source:          sourceType,
+		Image:           imageStr,
+		SourceReason:    sourceReason,
+		ExportFile:      exportFile,
+		Query:           exportQuery,
+		CiConfig:        ciConfig,
+		Budget:          budget,
+		History:         historyImages,
+		BaseImage:       baseImage,
+		CompareRemote:   remoteReference,
+		IgnoreErrors:    viper.GetBool("ignore-errors") || ignoreErrors,
+		Lazy:            viper.GetBool("lazy") || lazy,
+		Resolver:        resolverOptions,
+		Analysis:        analysisOptions,
+		IDMapping:       mapping,
+		Signature:       signature,
+		Vulnerabilities: viper.GetString("vulnerabilities.report"),
+		Tabs:            tabs,
+		Script:          steps,
+	})
+}
+
+// renderHeadless draws the UI on a pseudo terminal (running dive again, with the same arguments, which replays the
+// script if any) and writes its final screen to the given file ("-" for stdout, none to discard it), returning the exit
+// code.
+func renderHeadless(path string) int {
+	width, height, err := terminal.ParseSnapshotSize(viper.GetString("render.size"))
+	if err != nil {
+		fmt.Printf("render configuration error: %v\n", err)
+		return 1
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Printf("cannot render the UI: %v\n", err)
+		return 1
+	}
+
+	var snapshot bytes.Buffer
+	err = terminal.RenderHeadless(signalContext(), append([]string{executable}, os.Args[1:]...), width, height, &snapshot)
+	if err == nil {
+		switch path {
+		case "":
+		case "-":
+			_, err = os.Stdout.Write(snapshot.Bytes())
+		default:
+			err = ioutil.WriteFile(path, snapshot.Bytes(), 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot render the UI: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// detectImageSource tells where the image given by the user is read from (the --source default when the reference
+// does not tell), and why.
+func detectImageSource(userImage string) (dive.ImageSource, string, string) {
+	sourceType, imageStr, sourceReason := dive.DetectImageSource(userImage)
 
 	if sourceType == dive.SourceUnknown {
 		sourceStr := viper.GetString("source")
@@ -55,19 +278,162 @@ func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 		}
 
 		imageStr = userImage
+		sourceReason = strings.TrimSuffix("the --source default, "+sourceReason, ", ")
 	}
+	return sourceType, imageStr, sourceReason
+}
 
-	ignoreErrors, err := cmd.PersistentFlags().GetBool("ignore-errors")
+// the --compare-remote value (when given without a reference) that compares with the tag the image was fetched by
+const remoteSameTag = "auto"
+
+// compareRemoteReference resolves the published image to compare with (empty when not comparing).
+func compareRemoteReference(value string, sourceType dive.ImageSource, imageStr string) (string, error) {
+	if value != remoteSameTag {
+		return value, nil
+	}
+	if sourceType == dive.SourceDockerArchive {
+		return "", fmt.Errorf("an image archive has no tag, give the published image with --compare-remote=<reference>")
+	}
+	return imageStr, nil
+}
+
+// configureSignature reads how the cosign signature of the image is verified (nil when it is not).
+func configureSignature(sourceType dive.ImageSource) (*image.SignaturePolicy, error) {
+	if !viper.GetBool("signature.verify") {
+		return nil, nil
+	}
+	if sourceType == dive.SourceDockerArchive {
+		return nil, fmt.Errorf("the signature is verified in the registry, an image archive has no registry reference")
+	}
+	policy := &image.SignaturePolicy{
+		Key:      viper.GetString("signature.key"),
+		Identity: viper.GetString("signature.certificate-identity"),
+		Issuer:   viper.GetString("signature.certificate-oidc-issuer"),
+		Required: viper.GetBool("signature.required"),
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// configureIO sets the IO concurrency and throttling from the config, overridden by the flags given.
+func configureIO(cmd *cobra.Command) error {
+	for flag, key := range map[string]string{"io-concurrency": "io.concurrency", "io-bandwidth": "io.bandwidth", "io-iops": "io.iops"} {
+		if cmd.Flags().Changed(flag) {
+			viper.Set(key, cmd.Flags().Lookup(flag).Value.String())
+		}
+	}
+	limits, err := image.ParseIOLimits(viper.GetInt("io.concurrency"), viper.GetString("io.bandwidth"), viper.GetInt("io.iops"))
 	if err != nil {
-		logrus.Error("unable to get 'ignore-errors' option:", err)
+		return err
 	}
+	resolverOptions.IO = limits.NewLimiter()
+	return nil
+}
 
-	runtime.Run(runtime.Options{
-		Ci:           isCi,
-		Source:       sourceType,
-		Image:        imageStr,
-		ExportFile:   exportFile,
-		CiConfig:     ciConfig,
-		IgnoreErrors: viper.GetBool("ignore-errors") || ignoreErrors,
-	})
+// configureBounds sets the bounds the layer trees of each image are kept within from the config, overridden by the
+// flags given. The memory limit of the go runtime is set to the memory bound, so that it collects garbage harder as
+// the analysis gets close.
+func configureBounds(cmd *cobra.Command) error {
+	for flag, key := range map[string]string{"max-files": "limits.max-files", "max-memory": "limits.max-memory"} {
+		if cmd.Flags().Changed(flag) {
+			viper.Set(key, cmd.Flags().Lookup(flag).Value.String())
+		}
+	}
+	bounds, err := image.ParseAnalysisBounds(viper.GetInt("limits.max-files"), viper.GetString("limits.max-memory"))
+	if err != nil {
+		return err
+	}
+	resolverOptions.Bounds = bounds
+	if bounds.MaxMemory > 0 {
+		debug.SetMemoryLimit(int64(bounds.MaxMemory))
+	}
+	return nil
+}
+
+// loadIDMapping returns the user namespace mapping the file owners are shown with, so that layers written by a
+// rootless engine show the ids the files have within the container along with the ids stored in the layer.
+func loadIDMapping() (filetree.IDMapping, error) {
+	return filetree.LoadIDMapping(viper.GetString("userns.uid-map"), viper.GetString("userns.gid-map"))
+}
+
+// configureLayerCache enables reusing the parsed trees of the layers from earlier runs (see cache.layers). Without a
+// usable cache directory the layers are only kept in memory.
+func configureLayerCache() {
+	// the cached trees of a bounded analysis would lack the files it folded (and the others would not be bounded)
+	if !viper.GetBool("cache.layers") || resolverOptions.Bounds.Enabled() {
+		resolverOptions.LayerCache = nil
+		return
+	}
+	dir := viper.GetString("cache.dir")
+	if dir == "" {
+		var err error
+		dir, err = docker.DefaultLayerCacheDir()
+		if err != nil {
+			logrus.Debugf("unable to find the layer cache directory, keeping the layers in memory: %v", err)
+		}
+	}
+	cache := docker.NewLayerCache(dir)
+	resolverOptions.LayerCache = cache
+	go func() {
+		if removed, err := cache.Prune(); err != nil {
+			logrus.Debugf("unable to prune the layer cache: %v", err)
+		} else if removed > 0 {
+			logrus.Debugf("pruned %d unused layers from the layer cache", removed)
+		}
+	}()
+}
+
+// configureProfile reads the kind of profile written with --profile (none when it is not given) and the file it is
+// written to.
+func configureProfile(cmd *cobra.Command) (string, string, error) {
+	kind, err := cmd.Flags().GetString("profile")
+	if err != nil || kind == "" {
+		return "", "", err
+	}
+	valid := false
+	for _, candidate := range runtime.ProfileKinds {
+		valid = valid || kind == candidate
+	}
+	if !valid {
+		return "", "", fmt.Errorf("unknown profile %q (expected %s)", kind, strings.Join(runtime.ProfileKinds, ", "))
+	}
+	path, err := cmd.Flags().GetString("profile-path")
+	if err != nil {
+		return "", "", err
+	}
+	if path == "" {
+		path = runtime.DefaultProfilePath(kind)
+	}
+	return kind, path, nil
+}
+
+// the options every image is analyzed with, set up by the configure functions of each command
+var analysisOptions = image.DefaultAnalysisOptions()
+
+// configureIgnore loads the paths left out of the efficiency score, the wasted space reports and thus the CI rules.
+// The default file is skipped when it does not exist, while a file given with --ignore-file must exist.
+func configureIgnore(cmd *cobra.Command) error {
+	if _, err := os.Stat(ignoreFile); os.IsNotExist(err) && !cmd.Flags().Changed("ignore-file") {
+		return nil
+	}
+	list, err := filetree.LoadIgnoreList(ignoreFile)
+	if err != nil {
+		return err
+	}
+	if isCi {
+		fmt.Printf("  Using ignore file: %s (%d patterns)\n", ignoreFile, list.Len())
+	}
+	analysisOptions.Ignore = list
+	return nil
+}
+
+// configureAudit sets the permission audit policy from the config (the audit runs with every analysis, so that the
+// audit CI rules can be enforced without enabling the audit pane).
+func configureAudit() {
+	analysisOptions.Audit = image.AuditPolicy{
+		AppDirs:             viper.GetStringSlice("audit.app-dirs"),
+		AllowedCapabilities: viper.GetStringSlice("audit.allowed-capabilities"),
+	}
 }
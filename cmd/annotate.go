@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/annotation"
+)
+
+// annotateCmd represents the annotate command
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Adds reviewer comments on image paths to a JSON report (see --json), and lists or merges them, or writes the findings into a copy of the image.",
+	Long: `Adds reviewer comments on image paths to a JSON report (a --json export or a stored fleet report), so that a
+review of an image can be shared: a second reviewer lists the comments, responds to them and merges the reports of
+several reviewers back into one. With the oci subcommand the findings of the analysis are written as OCI annotations
+into a copy of the image instead.`,
+}
+
+var annotateAddCmd = &cobra.Command{
+	Use:   "add <report> <path> <comment>",
+	Short: "Comments on a path of the image.",
+	Args:  cobra.ExactArgs(3),
+	Run:   doAnnotateAddCmd,
+}
+
+var annotateReplyCmd = &cobra.Command{
+	Use:   "reply <report> <id> <comment>",
+	Short: "Responds to a comment (given by its ID, or a unique prefix of it).",
+	Args:  cobra.ExactArgs(3),
+	Run:   doAnnotateReplyCmd,
+}
+
+var annotateListCmd = &cobra.Command{
+	Use:   "list <report>",
+	Short: "Lists the comments of a report, grouped by thread.",
+	Args:  cobra.ExactArgs(1),
+	Run:   doAnnotateListCmd,
+}
+
+var annotateOciCmd = &cobra.Command{
+	Use:   "oci <image>",
+	Short: "Writes a copy of the image (an OCI layout) with the findings of each layer as OCI annotations.",
+	Long: `Analyzes the image and writes a copy of it as an OCI image layout, where the descriptor of every layer is annotated
+with its findings (the wasted bytes it is responsible for, its wasted paths and the files the permission audit
+flagged) and the image manifest with the efficiency score, wasted bytes and number of flagged files. Labels (--label) and
+annotations (--annotation) of your own, such as the provenance of the copy, are set on the image config and manifest.
+The layer blobs and (without labels) the config are copied as they are, so only the manifest (and thus the image
+digest) changes; the layout can be pushed with tools such as skopeo or oras for registries and other tools to read the findings from.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doAnnotateOciCmd,
+}
+
+var annotateMergeCmd = &cobra.Command{
+	Use:   "merge <report> <other report>...",
+	Short: "Merges the comments of the other reports into the first report.",
+	Args:  cobra.MinimumNArgs(2),
+	Run:   doAnnotateMergeCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+	annotateCmd.AddCommand(annotateAddCmd, annotateReplyCmd, annotateListCmd, annotateMergeCmd, annotateOciCmd)
+	for _, command := range []*cobra.Command{annotateAddCmd, annotateReplyCmd} {
+		command.Flags().String("author", "", "The name the comment is made under (defaults to the current user).")
+	}
+	annotateMergeCmd.Flags().StringP("output", "o", "", "Write the merged report to the given file instead of the first report.")
+	annotateOciCmd.Flags().StringP("output", "o", "", "the directory to write the OCI layout to (must not exist or be empty)")
+	annotateOciCmd.Flags().String("ref-name", "", "the name (tag) of the image within the layout")
+	annotateOciCmd.Flags().Int("limit", image.DefaultAnnotatedFiles, "the most paths listed per annotation (0 for all)")
+	annotateOciCmd.Flags().StringArray("label", nil, "a label (key=value) to set in the image config of the copy (may be repeated)")
+	annotateOciCmd.Flags().StringArray("annotation", nil, "an annotation (key=value) to set on the image manifest of the copy (may be repeated)")
+}
+
+// loadReport reads the report given on the command line, exiting when it cannot be read.
+func loadReport(reportPath string) *annotation.Report {
+	report, err := annotation.LoadReport(reportPath)
+	if err != nil {
+		fmt.Printf("cannot read report: %v\n", err)
+		os.Exit(1)
+	}
+	return report
+}
+
+// saveReport writes the report given on the command line, exiting when it cannot be written.
+func saveReport(report *annotation.Report, reportPath string) {
+	if err := report.Save(reportPath); err != nil {
+		fmt.Printf("cannot write report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// annotationAuthor is the --author given, or the current user.
+func annotationAuthor(cmd *cobra.Command) string {
+	if author, _ := cmd.Flags().GetString("author"); author != "" {
+		return author
+	}
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return "unknown"
+}
+
+// doAnnotateAddCmd implements the steps taken for the annotate add command
+func doAnnotateAddCmd(cmd *cobra.Command, args []string) {
+	report := loadReport(args[0])
+	added := annotation.New(path.Clean("/"+args[1]), annotationAuthor(cmd), args[2], "", time.Now())
+	report.Add(added)
+	saveReport(report, args[0])
+	fmt.Println(added.ID)
+}
+
+// doAnnotateReplyCmd implements the steps taken for the annotate reply command
+func doAnnotateReplyCmd(cmd *cobra.Command, args []string) {
+	report := loadReport(args[0])
+	parent, err := report.Find(args[1])
+	if err != nil {
+		fmt.Printf("cannot reply: %v\n", err)
+		os.Exit(1)
+	}
+	added := annotation.New(parent.Path, annotationAuthor(cmd), args[2], parent.ID, time.Now())
+	report.Add(added)
+	saveReport(report, args[0])
+	fmt.Println(added.ID)
+}
+
+// doAnnotateListCmd implements the steps taken for the annotate list command
+func doAnnotateListCmd(cmd *cobra.Command, args []string) {
+	report := loadReport(args[0])
+
+	threads := annotation.Threads(report.Annotations)
+	if len(threads) == 0 {
+		fmt.Println("No annotations")
+		return
+	}
+	for _, thread := range threads {
+		fmt.Println(thread.Path)
+		for _, comment := range append([]annotation.Annotation{thread.Annotation}, thread.Replies...) {
+			indent := "  "
+			if comment.ReplyTo != "" {
+				indent = "    "
+			}
+			fmt.Printf("%s[%s] %s (%s): %s\n", indent, comment.ID, comment.Author,
+				comment.Created.Format("2006-01-02 15:04"), strings.Join(strings.Fields(comment.Comment), " "))
+		}
+	}
+}
+
+// doAnnotateMergeCmd implements the steps taken for the annotate merge command
+func doAnnotateMergeCmd(cmd *cobra.Command, args []string) {
+	report := loadReport(args[0])
+	for _, other := range args[1:] {
+		report.Add(loadReport(other).Annotations...)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = args[0]
+	}
+	saveReport(report, output)
+	fmt.Printf("%d annotations\n", len(report.Annotations))
+}
+
+// doAnnotateOciCmd implements the steps taken for the annotate oci command
+func doAnnotateOciCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		fmt.Printf("unable to get 'output' option: %v\n", err)
+		os.Exit(1)
+	}
+	if output == "" {
+		fmt.Println("the directory to write is required (--output)")
+		os.Exit(1)
+	}
+	if entries, err := ioutil.ReadDir(output); err == nil && len(entries) > 0 {
+		fmt.Printf("cannot write to %s: the directory is not empty\n", output)
+		os.Exit(1)
+	}
+	refName, err := cmd.Flags().GetString("ref-name")
+	if err != nil {
+		fmt.Printf("unable to get 'ref-name' option: %v\n", err)
+		os.Exit(1)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		fmt.Printf("unable to get 'limit' option: %v\n", err)
+		os.Exit(1)
+	}
+	labels, err := cmd.Flags().GetStringArray("label")
+	if err != nil {
+		fmt.Printf("unable to get 'label' option: %v\n", err)
+		os.Exit(1)
+	}
+	extraAnnotations, err := cmd.Flags().GetStringArray("annotation")
+	if err != nil {
+		fmt.Printf("unable to get 'annotation' option: %v\n", err)
+		os.Exit(1)
+	}
+	edits, err := image.ParseLayoutEdits(labels, extraAnnotations)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := configureIgnore(cmd); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	configureAudit()
+
+	ctx := signalContext()
+	img, err := fetchImageArg(ctx, args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer img.Close()
+	if err := loadTrees(img, 0, len(img.Trees)-1); err != nil {
+		fmt.Printf("cannot read the file tree: %v\n", err)
+		os.Exit(1)
+	}
+	analysis, err := img.AnalyzeWithOptions(ctx, analysisOptions)
+	if err != nil {
+		fmt.Printf("cannot analyze image %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+
+	annotations, err := image.AnnotateAnalysis(analysis, limit)
+	if err != nil {
+		fmt.Printf("cannot annotate the image: %v\n", err)
+		os.Exit(1)
+	}
+	digest, err := image.WriteAnnotatedLayout(output, img, annotations, edits, refName)
+	if err != nil {
+		fmt.Printf("cannot write the OCI layout: %v\n", err)
+		os.Exit(1)
+	}
+
+	annotated := 0
+	for _, layer := range annotations.Layers {
+		if len(layer) > 0 {
+			annotated++
+		}
+	}
+	fmt.Printf("wrote %s (manifest %s): %d of %d layers annotated with findings\n", output, digest, annotated, len(annotations.Layers))
+}
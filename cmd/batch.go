@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/runtime/batch"
+)
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch -f <images file>",
+	Short: "Analyzes a list of images and evaluates each against the CI rules, with one combined exit code.",
+	Long: `Analyzes every image listed in the given file (one reference per line, blank lines and # comments are skipped,
+"-" reads the list from stdin), optionally several at a time with --parallel, and evaluates each analysis against the
+CI rules of --ci-config (when it exists). With --report-dir the report of every image and a roll-up summary are
+written there in the given format; the summary is printed either way. The exit code is 1 when any image fails the
+rules or cannot be analyzed.`,
+	Args: cobra.NoArgs,
+	Run:  doBatchCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+	batchCmd.Flags().StringP("file", "f", "", "the file listing the images to analyze, one per line (- for stdin)")
+	batchCmd.Flags().String("format", batch.FormatText, "the format of the reports and the summary: text or json")
+	batchCmd.Flags().Int("parallel", 1, "the number of images analyzed at the same time")
+	batchCmd.Flags().String("report-dir", "", "the directory the report of every image and the summary are written to")
+	batchCmd.Flags().StringVar(&ciConfigFile, "ci-config", ".dive-ci", "the yaml file driving the validation rules")
+}
+
+// doBatchCmd implements the steps taken for the batch command
+func doBatchCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		fmt.Printf("unable to get 'file' option: %v\n", err)
+		os.Exit(1)
+	}
+	if file == "" {
+		fmt.Println("no images file given (use -f)")
+		os.Exit(1)
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		fmt.Printf("unable to get 'format' option: %v\n", err)
+		os.Exit(1)
+	}
+	parallel, err := cmd.Flags().GetInt("parallel")
+	if err != nil {
+		fmt.Printf("unable to get 'parallel' option: %v\n", err)
+		os.Exit(1)
+	}
+	reportDir, err := cmd.Flags().GetString("report-dir")
+	if err != nil {
+		fmt.Printf("unable to get 'report-dir' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(ciConfigFile); !os.IsNotExist(err) {
+		if err := readCiConfig(ciConfigFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	var reader io.Reader = os.Stdin
+	if file != "-" {
+		list, err := os.Open(file)
+		if err != nil {
+			fmt.Printf("cannot open the images file: %v\n", err)
+			os.Exit(1)
+		}
+		defer list.Close()
+		reader = list
+	}
+	images, err := batch.ReadImageList(reader)
+	if err != nil {
+		fmt.Printf("cannot read the images file: %v\n", err)
+		os.Exit(1)
+	}
+
+	summary, err := batch.Run(signalContext(), images, batch.Options{
+		Parallel:  parallel,
+		ReportDir: reportDir,
+		Format:    format,
+		Rules:     ciConfig,
+		Fetch:     fetchImageArg,
+		Analysis:  analysisOptions,
+	})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if format == batch.FormatJSON {
+		bytes, err := summary.Marshal()
+		if err != nil {
+			fmt.Printf("cannot marshal the summary: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(bytes))
+	} else {
+		fmt.Print(summary.String())
+	}
+	if !summary.Pass() {
+		os.Exit(1)
+	}
+}
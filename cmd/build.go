@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
@@ -27,9 +30,15 @@ func doBuildCmd(cmd *cobra.Command, args []string) {
 	// todo: allow for an engine flag to be passed to dive but not the container engine
 	engine := viper.GetString("container-engine")
 
-	runtime.Run(runtime.Options{
+	sourceType, err := discoverEngine(dive.ParseImageSource(engine))
+	if err != nil {
+		fmt.Printf("cannot find a container engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	runtime.Run(signalContext(), runtime.Options{
 		Ci:         isCi,
-		Source:     dive.ParseImageSource(engine),
+		Source:     sourceType,
 		BuildArgs:  args,
 		ExportFile: exportFile,
 		CiConfig:   ciConfig,
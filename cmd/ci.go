@@ -6,8 +6,12 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/config"
 )
 
 func configureCi() (bool, *viper.Viper, error) {
@@ -21,13 +25,7 @@ func configureCi() (bool, *viper.Viper, error) {
 		if _, err := os.Stat(ciConfigFile); !os.IsNotExist(err) {
 			fmt.Printf("  Using CI config: %s\n", ciConfigFile)
 
-			fileBytes, err := ioutil.ReadFile(ciConfigFile)
-			if err != nil {
-				return isCi, nil, err
-			}
-
-			err = ciConfig.ReadConfig(bytes.NewBuffer(fileBytes))
-			if err != nil {
+			if err := readCiConfig(ciConfigFile); err != nil {
 				return isCi, nil, err
 			}
 		} else {
@@ -37,3 +35,41 @@ func configureCi() (bool, *viper.Viper, error) {
 
 	return isCi, ciConfig, nil
 }
+
+// readCiConfig validates the given CI config file and reads its rules into the CI config.
+func readCiConfig(path string) error {
+	ciConfig.SetConfigType("yaml")
+
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// unknown rules would otherwise never be evaluated
+	problems, err := config.Validate(path, fileBytes, config.CiSchema())
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for idx, problem := range problems {
+			messages[idx] = problem.String()
+		}
+		return fmt.Errorf("invalid CI config:\n  %s", strings.Join(messages, "\n  "))
+	}
+
+	return ciConfig.ReadConfig(bytes.NewBuffer(fileBytes))
+}
+
+// configureBudget loads the size budget file (in CI mode only). The default file is skipped when it does not exist,
+// while a file given with --budget must exist.
+func configureBudget(cmd *cobra.Command) (*ci.Budget, error) {
+	if !isCi {
+		return nil, nil
+	}
+	if _, err := os.Stat(budgetFile); os.IsNotExist(err) && !cmd.Flags().Changed("budget") {
+		return nil, nil
+	}
+	fmt.Printf("  Using budget file: %s\n", budgetFile)
+	return ci.LoadBudget(budgetFile)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+// how long completing image names and layers may wait on the container engine
+const completionTimeout = 3 * time.Second
+
+// completionCmd writes the shell completion scripts
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Writes the shell completion script of the given shell to stdout.",
+	Long: `Writes the shell completion script of the given shell to stdout. Along with the commands and flags, the script
+completes the names of the images stored by the container engine (for the image arguments, --base-image and --history)
+and the layer indices of the image given (for --layer).
+
+To load the completions in the current shell:
+
+  bash:  source <(dive completion bash)
+  zsh:   source <(dive completion zsh)
+  fish:  dive completion fish | source
+
+To load them in every session, write the script to the completion directory of the shell, e.g.
+/etc/bash_completion.d/dive, a directory in $fpath (as _dive) or ~/.config/fish/completions/dive.fish.`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	Run:                   doCompletionCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// doCompletionCmd implements the steps taken for the completion command
+func doCompletionCmd(cmd *cobra.Command, args []string) {
+	if err := writeCompletion(os.Stdout, args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// writeCompletion writes the completion script of the given shell (bash, zsh or fish).
+func writeCompletion(writer io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(writer, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(writer)
+	case "fish":
+		return rootCmd.GenFishCompletion(writer, true)
+	}
+	return fmt.Errorf("unsupported shell: %q (expected bash, zsh or fish)", shell)
+}
+
+// completionRequest indicates if dive was run to write a completion script or to complete a command line, whose
+// output is read by the shell (so nothing else may be written to stdout).
+func completionRequest() bool {
+	if len(os.Args) < 2 {
+		return false
+	}
+	switch os.Args[1] {
+	case completionCmd.Name(), cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+var completionConfig sync.Once
+
+// completeImages completes the image arguments of a command taking the given number of images with the names of
+// the images stored by the engine.
+func completeImages(images int) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= images {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeImageFlag(cmd, args, toComplete)
+	}
+}
+
+// completeImageFlag completes an image reference with the names of the images stored by the engine (or with the
+// files in the directory, for image archives).
+func completeImageFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// the config is not read before the completions are requested
+	completionConfig.Do(initConfig)
+
+	sourceType := dive.ParseImageSource(viper.GetString("source"))
+	if sourceType == dive.SourceDockerArchive {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	sourceType, err := discoverEngine(sourceType)
+	if err != nil || (sourceType != dive.SourceDockerEngine && sourceType != dive.SourcePodmanEngine) {
+		cobra.CompDebugln(fmt.Sprintf("no image names to complete: %v", err), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	names, err := docker.ListEngineImages(ctx, resolverOptions.EngineHost, resolverOptions.Network.CA)
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("unable to list the images: %v", err), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLayers completes a layer index with the layers of the image given as the first argument, described by
+// their (short) layer IDs.
+func completeLayers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completionConfig.Do(initConfig)
+
+	sourceType, imageStr, _ := dive.DetectImageSource(args[0])
+	if sourceType == dive.SourceUnknown {
+		sourceType = dive.ParseImageSource(viper.GetString("source"))
+	}
+	sourceType, err := discoverEngine(sourceType)
+	if err != nil || (sourceType != dive.SourceDockerEngine && sourceType != dive.SourcePodmanEngine) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	layers, err := docker.EngineImageLayers(ctx, imageStr, resolverOptions.EngineHost, resolverOptions.Network.CA)
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("unable to read the layers of %s: %v", imageStr, err), false)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	candidates := make([]string, 0, len(layers))
+	for idx, layer := range layers {
+		digest := strings.TrimPrefix(layer, "sha256:")
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		candidates = append(candidates, strconv.Itoa(idx)+"\t"+digest)
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeValues completes a flag with a fixed set of values.
+func completeValues(values ...string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerCompletions sets the dynamic completions of the commands and flags.
+func registerCompletions() {
+	rootCmd.ValidArgsFunction = completeImageFlag
+	treeCmd.ValidArgsFunction = completeImages(1)
+	reorderCmd.ValidArgsFunction = completeImages(1)
+	flattenCmd.ValidArgsFunction = completeImages(1)
+	diffCmd.ValidArgsFunction = completeImages(2)
+	reproCmd.ValidArgsFunction = completeImages(2)
+
+	for _, registration := range []struct {
+		cmd      *cobra.Command
+		flag     string
+		complete func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+	}{
+		{cmd: rootCmd, flag: "source", complete: completeValues(dive.ImageSources...)},
+		{cmd: rootCmd, flag: "engine", complete: completeValues(dive.EngineAuto, dive.SourceDockerEngine.String(), dive.SourcePodmanEngine.String())},
+		{cmd: rootCmd, flag: "base-image", complete: completeImageFlag},
+		{cmd: rootCmd, flag: "history", complete: completeImageFlag},
+		{cmd: treeCmd, flag: "layer", complete: completeLayers},
+		{cmd: treeCmd, flag: "format", complete: completeValues("text", "json", "ncdu")},
+		{cmd: diffCmd, flag: "format", complete: completeValues("text", "json")},
+	} {
+		if err := registration.cmd.RegisterFlagCompletionFunc(registration.flag, registration.complete); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
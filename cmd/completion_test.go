@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// fakeEngine serves the engine API calls made by the completions: a ping, the image list and the inspection of
+// alpine:latest.
+func fakeEngine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch {
+		case strings.HasSuffix(request.URL.Path, "/_ping"):
+			writer.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(request.URL.Path, "/images/json"):
+			writer.Write([]byte(`[
+				{"Id": "sha256:aaaaaaaaaaaaaaaaaaaaaaaa", "RepoTags": ["alpine:latest", "alpine:3.19"]},
+				{"Id": "sha256:0123456789abcdef0123", "RepoTags": ["<none>:<none>"]},
+				{"Id": "sha256:bbbbbbbbbbbbbbbbbbbbbbbb", "RepoTags": ["busybox:latest"]}
+			]`))
+		case strings.HasSuffix(request.URL.Path, "/images/alpine:latest/json"):
+			writer.Write([]byte(`{"Id": "sha256:aaaaaaaaaaaaaaaaaaaaaaaa", "RootFS": {"Type": "layers", "Layers": ["sha256:1111111111111111aaaa", "sha256:2222"]}}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	completionConfig.Do(initConfig)
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for shell, marker := range map[string]string{
+		"bash": "__start_dive",
+		"zsh":  "#compdef dive",
+		"fish": "complete -c dive",
+	} {
+		var script bytes.Buffer
+		if err := writeCompletion(&script, shell); err != nil {
+			t.Errorf("%s: unexpected error: %v", shell, err)
+			continue
+		}
+		if !strings.Contains(script.String(), marker) {
+			t.Errorf("%s: expected the script to contain %q", shell, marker)
+		}
+	}
+
+	if err := writeCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Errorf("expected an error for an unsupported shell")
+	}
+}
+
+func TestCompleteImages(t *testing.T) {
+	fakeEngine(t)
+
+	candidates, directive := completeImages(1)(rootCmd, nil, "alp")
+	if expected := []string{"alpine:3.19", "alpine:latest"}; !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("expected candidates %v, got %v", expected, candidates)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected no file completion, got %v", directive)
+	}
+
+	// the untagged images are completed by their short ID
+	candidates, _ = completeImageFlag(rootCmd, nil, "0123")
+	if expected := []string{"0123456789ab"}; !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("expected candidates %v, got %v", expected, candidates)
+	}
+
+	// every image is given already
+	if candidates, _ := completeImages(1)(rootCmd, []string{"alpine:latest"}, ""); len(candidates) != 0 {
+		t.Errorf("expected no candidates past the image arguments, got %v", candidates)
+	}
+
+	// the files are completed for image archives
+	viper.Set("source", "docker-archive")
+	defer viper.Set("source", "docker")
+	if candidates, directive := completeImageFlag(rootCmd, nil, ""); len(candidates) != 0 || directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("expected the files to be completed for an archive, got %v (%v)", candidates, directive)
+	}
+}
+
+func TestCompleteLayers(t *testing.T) {
+	fakeEngine(t)
+
+	candidates, directive := completeLayers(treeCmd, []string{"alpine:latest"}, "")
+	if expected := []string{"0\t111111111111", "1\t2222"}; !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("expected candidates %v, got %v", expected, candidates)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder {
+		t.Errorf("expected the layers to be kept in order, got %v", directive)
+	}
+
+	if candidates, _ := completeLayers(treeCmd, []string{"missing:latest"}, ""); len(candidates) != 0 {
+		t.Errorf("expected no candidates for an unknown image, got %v", candidates)
+	}
+	if candidates, _ := completeLayers(treeCmd, nil, ""); len(candidates) != 0 {
+		t.Errorf("expected no candidates without an image, got %v", candidates)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/config"
+)
+
+// configCmd groups the commands that work with the config files
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the dive config files.",
+}
+
+// configValidateCmd checks the config files against their schema
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Checks the config file (see --config), the CI config (see --ci-config) and the DIVE_* environment variables for unknown keys and invalid values.",
+	Args:  cobra.NoArgs,
+	Run:   doConfigValidateCmd,
+}
+
+// configInitCmd writes the default config file
+var configInitCmd = &cobra.Command{
+	Use:   "init [path]",
+	Short: "Writes a config file with every setting at its default value, documented (default ~/.config/dive/config.yaml, or - for stdout).",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   doConfigInitCmd,
+}
+
+var (
+	validateCiConfigFile string
+	initForce            bool
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configInitCmd)
+	configValidateCmd.Flags().StringVar(&validateCiConfigFile, "ci-config", ".dive-ci", "The CI config to check (skipped when it does not exist).")
+	configInitCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite the config file if it exists.")
+}
+
+// doConfigInitCmd implements the steps taken for the config init command
+func doConfigInitCmd(cmd *cobra.Command, args []string) {
+	if len(args) > 0 && args[0] == "-" {
+		os.Stdout.Write(config.DefaultConfig())
+		return
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		home, err := homedir.Dir()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		path = filepath.Join(home, ".config", "dive", "config.yaml")
+	}
+
+	if _, err := os.Stat(path); err == nil && !initForce {
+		fmt.Printf("%s already exists (use --force to overwrite it)\n", path)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("unable to create the config directory: %v\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(path, config.DefaultConfig(), 0644); err != nil {
+		fmt.Printf("unable to write the config file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("config written to %s\n", path)
+}
+
+// doConfigValidateCmd implements the steps taken for the config validate command
+func doConfigValidateCmd(cmd *cobra.Command, args []string) {
+	checked := 0
+	valid := true
+	for _, file := range []struct {
+		path   string
+		schema *config.Field
+	}{
+		{path: viper.ConfigFileUsed(), schema: config.DiveSchema()},
+		{path: validateCiConfigFile, schema: config.CiSchema()},
+	} {
+		if _, err := os.Stat(file.path); file.path == "" || os.IsNotExist(err) {
+			continue
+		}
+		checked++
+
+		problems, err := validateConfigFile(file.path, file.schema)
+		if err != nil {
+			fmt.Println(err)
+			valid = false
+			continue
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%s: valid\n", file.path)
+			continue
+		}
+		valid = false
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+	}
+
+	// the environment variables that override the settings are checked as well
+	environ := os.Environ()
+	problems := append(config.ValidateEnvironment(environ, config.DiveSchema()), config.UnknownEnvironment(environ, config.DiveSchema())...)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		valid = false
+	}
+
+	if checked == 0 {
+		fmt.Println("no config file found")
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// validateConfigFile checks the given config file against the schema (only yaml and json files are checked).
+func validateConfigFile(path string, schema *config.Field) ([]config.Problem, error) {
+	switch filepath.Ext(path) {
+	case "", ".yaml", ".yml", ".json":
+	default:
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Validate(path, content, schema)
+}
+
+// checkConfig validates the config file in use and the values of the DIVE_* environment variables (unknown keys and
+// invalid values are rejected, since they would otherwise be silently ignored), exiting with the problems found.
+func checkConfig() {
+	if problems := config.ValidateEnvironment(os.Environ(), config.DiveSchema()); len(problems) > 0 {
+		fmt.Println("invalid environment variables:")
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		os.Exit(1)
+	}
+
+	path := viper.ConfigFileUsed()
+	if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+		return
+	}
+	problems, err := validateConfigFile(path, config.DiveSchema())
+	if err != nil {
+		fmt.Printf("invalid config file: %v\n", err)
+		os.Exit(1)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("invalid config file %s:\n", path)
+		for _, problem := range problems {
+			fmt.Printf("  %s\n", problem)
+		}
+		os.Exit(1)
+	}
+}
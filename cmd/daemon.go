@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/runtime/daemon"
+)
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Runs dive as a long-running service, serving image analyses over a JSON-RPC 2.0 API (POST /rpc).",
+	Long: `Runs dive as a long-running service, serving image analyses over a JSON-RPC 2.0 API (POST /rpc).
+
+When the config (see --config) has a "fleet" section, the images listed there are re-analyzed periodically, evaluated
+against the CI rules and their reports stored, with the results served by the "fleet" method and a Prometheus
+metrics endpoint (GET /metrics). With "results.enabled" set, every analysis of the fleet is also recorded in the
+results database (see "dive query").`,
+	Args: cobra.NoArgs,
+	Run:  doDaemonCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().String("listen", "127.0.0.1:7878", "The address to serve the API on.")
+}
+
+// doDaemonCmd implements the steps taken for the daemon command
+func doDaemonCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	checkConfig()
+
+	if err := viper.BindPFlag("daemon.listen", cmd.Flags().Lookup("listen")); err != nil {
+		fmt.Printf("unable to bind 'listen' flag: %v\n", err)
+		os.Exit(1)
+	}
+
+	sourceStr := viper.GetString("source")
+	sourceType := dive.ParseImageSource(sourceStr)
+	if sourceType == dive.SourceUnknown {
+		fmt.Printf("unable to determine image source: %v\n", sourceStr)
+		os.Exit(1)
+	}
+
+	sourceType, err := discoverEngine(sourceType)
+	if err != nil {
+		fmt.Printf("cannot find a container engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	configureAudit()
+
+	err = configureIO(cmd)
+	if err != nil {
+		fmt.Printf("io configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	configureLayerCache()
+
+	server := daemon.NewServer(sourceType, resolverOptions, analysisOptions)
+
+	fleetConfig, err := daemon.NewFleetConfig(viper.GetViper(), ciConfig)
+	if err != nil {
+		fmt.Printf("invalid fleet configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if fleetConfig != nil {
+		fleet := daemon.NewFleet(server, fleetConfig)
+		if viper.GetBool("results.enabled") {
+			store, err := openResults()
+			if err != nil {
+				fmt.Printf("cannot open the results database: %v\n", err)
+				os.Exit(1)
+			}
+			defer store.Close()
+			fleet.SetResults(store)
+		}
+		server.SetFleet(fleet)
+		go fleet.Run(context.Background())
+	}
+
+	if err := server.ListenAndServe(viper.GetString("daemon.listen")); err != nil {
+		fmt.Printf("daemon failed: %v\n", err)
+		os.Exit(1)
+	}
+}
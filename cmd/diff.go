@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <previous image> <image>",
+	Short: "Compares the analyses of two images: the layers added and removed, the size deltas by path, and the efficiency delta.",
+	Long: `Compares the analyses of two images (e.g. the image built from the main branch and the one built from a pull
+request): the size, compressed size, wasted bytes and efficiency of both, the layers only one of them has (matched by
+diffID), and the size deltas of the final image by directory (summed up to --depth) and by file, the largest first.
+With --format json the diff is written as JSON for bots to summarize.`,
+	Args: cobra.ExactArgs(2),
+	Run:  doDiffCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().String("format", "text", "the output format: text or json")
+	diffCmd.Flags().Int("depth", image.DefaultDiffDepth, "the directory depth the size deltas are summed up to (e.g. 3 for /usr/lib/python3.11)")
+	diffCmd.Flags().Int("limit", 20, "the most directories and files listed (0 for all)")
+}
+
+// doDiffCmd implements the steps taken for the diff command
+func doDiffCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		fmt.Printf("unable to get 'format' option: %v\n", err)
+		os.Exit(1)
+	}
+	if format != "text" && format != "json" {
+		fmt.Printf("unknown format %q (expected text or json)\n", format)
+		os.Exit(1)
+	}
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		fmt.Printf("unable to get 'depth' option: %v\n", err)
+		os.Exit(1)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		fmt.Printf("unable to get 'limit' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := signalContext()
+	var analyses []*image.AnalysisResult
+	for _, arg := range args {
+		img, err := fetchImageArg(ctx, arg)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer img.Close()
+		analysis, err := img.AnalyzeWithOptions(ctx, analysisOptions)
+		if err != nil {
+			fmt.Printf("cannot analyze image %s: %v\n", arg, err)
+			os.Exit(1)
+		}
+		analyses = append(analyses, analysis)
+	}
+
+	diff := image.DiffAnalyses(analyses[0], analyses[1], depth)
+	if format == "text" {
+		fmt.Println(runtime.DiffReport(diff, limit))
+		return
+	}
+	bytes, err := export.NewDiff(diff, limit).Marshal()
+	if err != nil {
+		fmt.Printf("cannot marshal the diff: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(bytes))
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/doctor"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks the environment (container engines, sockets, terminal, directories, registry access) and suggests fixes for common setup problems.",
+	Args:  cobra.NoArgs,
+	Run:   doDoctorCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().String("registry", doctor.DefaultRegistryURL, "The registry API endpoint used to check connectivity.")
+}
+
+// doDoctorCmd implements the steps taken for the doctor command
+func doDoctorCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	registry, err := cmd.Flags().GetString("registry")
+	if err != nil {
+		fmt.Printf("unable to get 'registry' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	options := doctor.Options{
+		Engine:      viper.GetString("source"),
+		RegistryURL: registry,
+		Network:     resolverOptions.Network,
+	}
+	if viper.GetBool("log.enabled") {
+		options.LogPath = viper.GetString("log.path")
+	}
+
+	results := doctor.Run(context.Background(), doctor.DefaultChecks(options))
+	fmt.Println(doctor.Report(results))
+
+	if !doctor.Passed(results) {
+		os.Exit(1)
+	}
+}
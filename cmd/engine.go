@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// discoverEngine finds a reachable container engine for the given engine source, returning the source that should be
+// used to reach it (archives need no engine and are returned as is).
+func discoverEngine(sourceType dive.ImageSource) (dive.ImageSource, error) {
+	if sourceType != dive.SourceDockerEngine && sourceType != dive.SourcePodmanEngine {
+		return sourceType, nil
+	}
+
+	engine := viper.GetString("engine")
+	if engine == dive.EngineAuto && sourceType == dive.SourcePodmanEngine {
+		// podman was asked for explicitly
+		engine = dive.SourcePodmanEngine.String()
+	}
+
+	endpoint, err := dive.DiscoverEngine(context.Background(), engine, resolverOptions.Network)
+	if err != nil {
+		return dive.SourceUnknown, err
+	}
+	log.Debugf("using container engine: %s", endpoint)
+	resolverOptions.EngineHost = endpoint.Host
+
+	return endpoint.Source(), nil
+}
+
+// the options the images are fetched and parsed with, set up by the configure functions of each command
+var resolverOptions = image.DefaultResolverOptions()
+
+// fetchImageArg fetches the image named by a command argument, from the source its prefix names (e.g.
+// "docker-archive://image.tar") or the --source default.
+func fetchImageArg(ctx context.Context, arg string) (*image.Image, error) {
+	sourceType, imageStr, _ := dive.DetectImageSource(arg)
+	if sourceType == dive.SourceUnknown {
+		sourceType, imageStr = dive.ParseImageSource(viper.GetString("source")), arg
+		if sourceType == dive.SourceUnknown {
+			return nil, fmt.Errorf("unable to determine image source: %v", viper.GetString("source"))
+		}
+	}
+	sourceType, err := discoverEngine(sourceType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find a container engine: %v", err)
+	}
+	resolver, err := dive.GetImageResolver(sourceType, resolverOptions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine image provider: %v", err)
+	}
+	img, err := resolver.Fetch(ctx, imageStr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch image %s: %v", arg, err)
+	}
+	return img, nil
+}
+
+// configureRequests sets how the requests made to container engines and registries are made: their timeout and
+// retries, the pull policy, and the network settings (offline mode, registry TLS).
+func configureRequests() error {
+	policy, err := image.ParseOperationPolicy(viper.GetString("requests.timeout"), viper.GetInt("requests.retries"), viper.GetString("requests.backoff"))
+	if err != nil {
+		return err
+	}
+	resolverOptions.Operation = policy
+	resolverOptions.Network.Offline = viper.GetBool("offline")
+
+	pullPolicy, err := image.ParsePullPolicy(viper.GetString("pull.policy"))
+	if err != nil {
+		return err
+	}
+	resolverOptions.Pull = pullPolicy
+
+	registryOptions, err := image.ParseRegistryOptions(viper.GetStringSlice("registry.insecure"), viper.GetString("registry.client-cert"), viper.GetString("registry.client-key"))
+	if err != nil {
+		return err
+	}
+	resolverOptions.Registry = registryOptions
+
+	ca, err := image.LoadCABundle(viper.GetString("registry.ca"))
+	if err != nil {
+		return err
+	}
+	resolverOptions.Network.CA = ca
+	return nil
+}
+
+// signalContext returns a context that is canceled on the first interrupt (Ctrl-C) or termination signal, so that
+// fetches and analyses stop (removing their temporary files); a second signal exits right away.
+func signalContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// fingerprintCmd represents the fingerprint command
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint <image>",
+	Short: "Fingerprints the filesystem of an image, or pinpoints the paths that differ from another image.",
+	Long: `Fingerprints the filesystem of an image: every path gets a digest of its type, mode, owner and contents, and every
+directory a digest of the names and digests of its entries (merkle-style), so that the subtrees with the same digest
+hold the same files whatever the layers they came from (modification times are left out). The digests are listed down
+to --depth; --output saves the fingerprint of every path as JSON.
+
+With --compare (another image, or a fingerprint saved with --output, e.g. in another environment) the subtrees that
+match are skipped and the paths that differ are pinpointed: added, removed, or modified with what differs (contents,
+mode, owner, link target or type). The exit code is 1 when the filesystems differ, for deployment checks of images that
+claim to be the same version.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doFingerprintCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(fingerprintCmd)
+	fingerprintCmd.Flags().String("compare", "", "the image (or the fingerprint file written with --output) to compare the image with")
+	fingerprintCmd.Flags().StringP("output", "o", "", "save the fingerprint of every path to the given file (JSON), to compare with later on")
+	fingerprintCmd.Flags().Int("depth", 2, "the directory depth the subtree digests are listed down to (0 for every path)")
+	fingerprintCmd.Flags().Int("limit", 50, "the most paths listed when comparing (0 for all)")
+	fingerprintCmd.Flags().String("format", "text", "the output format: text or json")
+}
+
+// doFingerprintCmd implements the steps taken for the fingerprint command
+func doFingerprintCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	compare, err := cmd.Flags().GetString("compare")
+	if err != nil {
+		fmt.Printf("unable to get 'compare' option: %v\n", err)
+		os.Exit(1)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		fmt.Printf("unable to get 'output' option: %v\n", err)
+		os.Exit(1)
+	}
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		fmt.Printf("unable to get 'depth' option: %v\n", err)
+		os.Exit(1)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		fmt.Printf("unable to get 'limit' option: %v\n", err)
+		os.Exit(1)
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		fmt.Printf("unable to get 'format' option: %v\n", err)
+		os.Exit(1)
+	}
+	if format != "text" && format != "json" {
+		fmt.Printf("unknown format %q (expected text or json)\n", format)
+		os.Exit(1)
+	}
+
+	ctx := signalContext()
+	fingerprint, err := fingerprintImage(ctx, args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("cannot create %s: %v\n", output, err)
+			os.Exit(1)
+		}
+		err = fingerprint.Write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Printf("cannot save the fingerprint: %v\n", err)
+			os.Remove(output)
+			os.Exit(1)
+		}
+	}
+
+	if compare == "" {
+		if format == "json" {
+			if err := fingerprint.Write(os.Stdout); err != nil {
+				fmt.Printf("cannot write the fingerprint: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Println(runtime.FingerprintReport(fingerprint, depth))
+		return
+	}
+
+	previous, err := loadFingerprint(ctx, compare)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	drifts := image.CompareFingerprints(previous, fingerprint)
+	if format == "json" {
+		bytes, err := export.NewFingerprintDrift(previous, fingerprint, drifts, limit).Marshal()
+		if err != nil {
+			fmt.Printf("cannot marshal the comparison: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(bytes))
+	} else {
+		fmt.Println(runtime.FingerprintDriftReport(previous, fingerprint, drifts, limit))
+	}
+	if previous.Digest != fingerprint.Digest {
+		os.Exit(1)
+	}
+}
+
+// fingerprintImage fetches the image and fingerprints its filesystem.
+func fingerprintImage(ctx context.Context, arg string) (*image.Fingerprint, error) {
+	img, err := fetchImageArg(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+	fingerprint, err := image.NewFingerprint(arg, img.Trees)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fingerprint image %s: %v", arg, err)
+	}
+	return fingerprint, nil
+}
+
+// loadFingerprint reads the fingerprint saved in the given file, or fingerprints the image it names otherwise (image
+// archives are files too).
+func loadFingerprint(ctx context.Context, arg string) (*image.Fingerprint, error) {
+	if file, err := os.Open(arg); err == nil {
+		fingerprint, readErr := image.ReadFingerprint(file)
+		file.Close()
+		if readErr == nil {
+			return fingerprint, nil
+		}
+	}
+	return fingerprintImage(ctx, arg)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// flattenCmd represents the flatten command
+var flattenCmd = &cobra.Command{
+	Use:   "flatten <image>",
+	Short: "Writes the filesystem of the image, with all layers applied, as a single layer tar.",
+	Long: `Applies the layers of the image one on top of the other (the files removed by whiteouts are left out) and writes
+the resulting filesystem as the tar of a single layer: to debug the final rootfs, or to feed it to other scanners. With
+--path only the files beneath the given path (and the directories leading to it) are written.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doFlattenCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(flattenCmd)
+	flattenCmd.Flags().StringP("output", "o", "", "the tar file to write ('-' for stdout)")
+	flattenCmd.Flags().String("path", "/", "only write the files beneath the given path (e.g. /etc)")
+}
+
+// doFlattenCmd implements the steps taken for the flatten command
+func doFlattenCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		fmt.Printf("unable to get 'output' option: %v\n", err)
+		os.Exit(1)
+	}
+	if output == "" {
+		fmt.Println("the tar file to write is required (--output)")
+		os.Exit(1)
+	}
+	prefix, err := cmd.Flags().GetString("path")
+	if err != nil {
+		fmt.Printf("unable to get 'path' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	// the tar may be written to stdout, so report on stderr
+	img, err := fetchImageArg(signalContext(), args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer img.Close()
+
+	if len(img.Trees) == 0 {
+		fmt.Fprintln(os.Stderr, "the image has no layers")
+		os.Exit(1)
+	}
+	if err := loadTrees(img, 0, len(img.Trees)-1); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read the file tree: %v\n", err)
+		os.Exit(1)
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot create %s: %v\n", output, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	entries, err := image.Flatten(writer, img.Contents, img.Trees, prefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot flatten the image: %v\n", err)
+		if output != "-" {
+			os.Remove(output)
+		}
+		os.Exit(1)
+	}
+	if output != "-" {
+		fmt.Printf("wrote %d entries to %s\n", entries, output)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// applyImageProfiles overrides the configuration with every "images" stanza whose pattern matches the given image name.
+// When several patterns match, the longer (more specific) pattern wins. CI rules within a stanza are applied to the
+// CI configuration unless given explicitly on the command line.
+func applyImageProfiles(cmd *cobra.Command, imageStr string, ciConfig *viper.Viper) error {
+	profiles := viper.GetStringMap("images")
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	patterns := make([]string, 0, len(profiles))
+	for pattern := range profiles {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid image pattern %q: %w", pattern, err)
+		}
+		if matchesImage(pattern, imageStr) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) == len(patterns[j]) {
+			return patterns[i] < patterns[j]
+		}
+		return len(patterns[i]) < len(patterns[j])
+	})
+
+	for _, pattern := range patterns {
+		log.Debugf("applying image settings for %q", pattern)
+		settings := make(map[string]interface{})
+		flattenSettings("", profiles[pattern], settings)
+		for key, value := range settings {
+			if strings.HasPrefix(key, "rules.") {
+				if !ruleFlagChanged(cmd, strings.TrimPrefix(key, "rules.")) {
+					ciConfig.Set(key, value)
+				}
+				continue
+			}
+			viper.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// ruleFlagChanged indicates if the given CI rule was explicitly set on the command line (config keys are lowercased).
+func ruleFlagChanged(cmd *cobra.Command, rule string) bool {
+	changed := false
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if strings.EqualFold(flag.Name, rule) {
+			changed = true
+		}
+	})
+	return changed
+}
+
+// matchesImage indicates if the given pattern matches the image name, or the image name with any number of leading
+// path components removed (so "*/nginx*" matches "nginx:latest", "library/nginx" and "docker.io/library/nginx").
+func matchesImage(pattern, imageStr string) bool {
+	name := imageStr
+	if !strings.Contains(name, "/") {
+		// official images are implicitly within the "library" namespace
+		name = "library/" + name
+	}
+	for {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		idx := strings.Index(name, "/")
+		if idx < 0 {
+			return false
+		}
+		name = name[idx+1:]
+	}
+}
+
+// flattenSettings collects the leaves of the given (nested) settings into dotted config keys.
+func flattenSettings(prefix string, value interface{}, settings map[string]interface{}) {
+	switch nested := value.(type) {
+	case map[string]interface{}:
+		for key, child := range nested {
+			flattenSettings(prefix+strings.ToLower(key)+".", child, settings)
+		}
+	case map[interface{}]interface{}:
+		for key, child := range nested {
+			flattenSettings(prefix+strings.ToLower(fmt.Sprint(key))+".", child, settings)
+		}
+	default:
+		settings[strings.TrimSuffix(prefix, ".")] = value
+	}
+}
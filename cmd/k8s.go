@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/k8s"
+)
+
+// k8sCmd represents the k8s command
+var k8sCmd = &cobra.Command{
+	Use:   "k8s <pod|kind/name>",
+	Short: "Analyzes every image run by a kubernetes workload (pod, deployment, statefulset, daemonset, replicaset, job or cronjob), pulling with the workload's image pull secrets.",
+	Args:  cobra.ExactArgs(1),
+	Run:   doK8sCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(k8sCmd)
+	k8sCmd.Flags().StringP("namespace", "n", "", "The namespace of the workload (defaults to the kubeconfig namespace).")
+	k8sCmd.Flags().String("context", "", "The kubeconfig context to use.")
+	k8sCmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file (defaults to the kubectl default).")
+}
+
+// doK8sCmd implements the steps taken for the k8s command
+func doK8sCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	sourceType, err := discoverEngine(dive.SourceDockerEngine)
+	if err != nil {
+		fmt.Printf("cannot find a container engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	options := k8s.Options{
+		Workload: args[0],
+		Analyze: func(ctx context.Context, img string) (*image.AnalysisResult, error) {
+			return dive.Analyze(ctx, sourceType.String()+"://"+img)
+		},
+	}
+
+	var kubeconfig, kubeContext string
+	for name, value := range map[string]*string{
+		"namespace":  &options.Namespace,
+		"context":    &kubeContext,
+		"kubeconfig": &kubeconfig,
+	} {
+		*value, err = cmd.Flags().GetString(name)
+		if err != nil {
+			fmt.Printf("unable to get '%s' option: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+	options.Kubectl = k8s.NewKubectl(kubeconfig, kubeContext, resolverOptions.Network)
+
+	workload, results, err := k8s.Audit(context.Background(), options)
+	if err != nil {
+		fmt.Printf("cannot audit workload: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(k8s.Report(workload, results))
+
+	if !k8s.Passed(results) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive"
+)
+
+// openCmd represents the open command
+var openCmd = &cobra.Command{
+	Use:   "open <bundle>",
+	Short: "Shows an analysis bundle written by 'dive save-analysis' in the UI.",
+	Long: `Shows the analysis bundle written by 'dive save-analysis' in the UI, as if the image it was saved from was
+analyzed: no container engine or registry is needed. This is the same as 'dive bundle://<bundle>' (bundles given to dive
+without a prefix are recognized too), which also accepts the flags of the analysis (e.g. --ci or --json).`,
+	Args: cobra.ExactArgs(1),
+	Run:  doOpenCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
+
+// doOpenCmd implements the steps taken for the open command
+func doOpenCmd(cmd *cobra.Command, args []string) {
+	doAnalyzeCmd(rootCmd, []string{dive.SourceBundle.String() + "://" + args[0]})
+}
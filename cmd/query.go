@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/results"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query [SQL | canned query]",
+	Short: "Runs SQL against the analyses recorded in the results database (see results.enabled).",
+	Long: `Runs SQL against the analyses recorded in the results database, which every analysis is recorded into when
+"results.enabled" is set in the config. The database has a "runs" table (one row per analysis), a "layers" table and
+an "inefficient_files" table (both keyed by run_id). Instead of SQL, the name of a canned query can be given (see --list).`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  doQueryCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().Bool("list", false, "List the canned queries.")
+	queryCmd.Flags().String("db", "", "The results database to query (default is results.path, or dive/results.db within the user config directory).")
+}
+
+// openResults opens the configured results database.
+func openResults() (*results.Store, error) {
+	path, err := results.Path(viper.GetString("results.path"))
+	if err != nil {
+		return nil, err
+	}
+	return results.Open(path)
+}
+
+// doQueryCmd implements the steps taken for the query command
+func doQueryCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	if list, _ := cmd.Flags().GetBool("list"); list || len(args) == 0 {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, canned := range results.CannedQueries {
+			fmt.Fprintf(writer, "%s\t%s\n", canned.Name, canned.Description)
+		}
+		writer.Flush()
+		return
+	}
+
+	if db, _ := cmd.Flags().GetString("db"); db != "" {
+		viper.Set("results.path", db)
+	}
+	store, err := openResults()
+	if err != nil {
+		fmt.Printf("cannot open the results database: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	rows, err := store.Query(args[0])
+	if err != nil {
+		fmt.Printf("query failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(rows.Columns, "\t"))
+	for _, row := range rows.Values {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	writer.Flush()
+}
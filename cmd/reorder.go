@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime"
+)
+
+// reorderCmd represents the reorder command
+var reorderCmd = &cobra.Command{
+	Use:   "reorder <image>",
+	Short: "(experimental) Simulates reordering the build steps of an image and estimates the layer bytes reused from the build cache.",
+	Long: `Simulates building the layers of an image (on top of its base image) in another order and estimates the bytes
+rebuilt per build: a step is rebuilt when its own inputs changed (its instruction, or the files a COPY/ADD copies) or
+any step before it was rebuilt. The changes are replayed from previous versions of the image (--history); without
+them every COPY/ADD from the build context is assumed to change each build. Without --order the steps that change
+least often are moved first, as far as the steps do not overwrite each other's files.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doReorderCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(reorderCmd)
+	reorderCmd.Flags().String("order", "", "the layer indexes of the app layers in the order to simulate, comma separated (e.g. '3,1,2'); defaults to a suggested order")
+	reorderCmd.Flags().StringArray("history", nil, "previous versions of the image (oldest first, may be repeated) to replay the changes between")
+	reorderCmd.Flags().String("base-image", "", "the base image the image is built on, whose layers are not reordered (default: the first layer is the base image)")
+}
+
+// doReorderCmd implements the steps taken for the reorder command
+func doReorderCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	orderStr, err := cmd.Flags().GetString("order")
+	if err != nil {
+		fmt.Printf("unable to get 'order' option: %v\n", err)
+		os.Exit(1)
+	}
+	order, err := parseLayerOrder(orderStr)
+	if err != nil {
+		fmt.Printf("invalid order: %v\n", err)
+		os.Exit(1)
+	}
+	history, err := cmd.Flags().GetStringArray("history")
+	if err != nil {
+		fmt.Printf("unable to get 'history' option: %v\n", err)
+		os.Exit(1)
+	}
+	baseImageStr, err := cmd.Flags().GetString("base-image")
+	if err != nil {
+		fmt.Printf("unable to get 'base-image' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	sourceType, imageStr, _ := dive.DetectImageSource(args[0])
+	if sourceType == dive.SourceUnknown {
+		sourceType, imageStr = dive.ParseImageSource(viper.GetString("source")), args[0]
+		if sourceType == dive.SourceUnknown {
+			fmt.Printf("unable to determine image source: %v\n", viper.GetString("source"))
+			os.Exit(1)
+		}
+	}
+	sourceType, err = discoverEngine(sourceType)
+	if err != nil {
+		fmt.Printf("cannot find a container engine: %v\n", err)
+		os.Exit(1)
+	}
+	resolver, err := dive.GetImageResolver(sourceType, resolverOptions)
+	if err != nil {
+		fmt.Printf("cannot determine image provider: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := signalContext()
+	fetch := func(name string) *image.Image {
+		img, err := resolver.Fetch(ctx, name)
+		if err != nil {
+			fmt.Printf("cannot fetch image %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return img
+	}
+
+	img := fetch(imageStr)
+	defer img.Close()
+	if baseImageStr != "" {
+		baseImg := fetch(baseImageStr)
+		err = img.SetBase(baseImageStr, baseImg)
+		baseImg.Close()
+		if err != nil {
+			fmt.Printf("cannot use base image: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var previous []*image.Image
+	for _, name := range history {
+		previousImg := fetch(name)
+		defer previousImg.Close()
+		previous = append(previous, previousImg)
+	}
+
+	sim, err := image.SimulateReorder(img, previous, order)
+	if err != nil {
+		fmt.Printf("cannot simulate the order: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(runtime.ReorderReport(sim))
+}
+
+// parseLayerOrder parses a comma separated list of layer indexes (nil when empty).
+func parseLayerOrder(value string) ([]int, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var order []int
+	for _, field := range strings.Split(value, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("not a layer index: %q", field)
+		}
+		order = append(order, index)
+	}
+	return order, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime"
+)
+
+// reproCmd represents the repro command
+var reproCmd = &cobra.Command{
+	Use:   "repro <image> <image>",
+	Short: "Compares two builds of the same Dockerfile and explains why their layers differ.",
+	Long: `Compares two builds of the same Dockerfile layer by layer: layers with the same diffID are reproducible, the files
+of the other layers are compared by contents, mode and owner, and modification time. The sources of non-reproducibility
+found in the second build are listed as well: files with the build time as mtime, random data (seeds, machine ids,
+host keys), logs and caches recording the build, and python bytecode.`,
+	Args: cobra.ExactArgs(2),
+	Run:  doReproCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(reproCmd)
+}
+
+// doReproCmd implements the steps taken for the repro command
+func doReproCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	ctx := signalContext()
+	var images []*image.Image
+	for _, arg := range args {
+		img, err := fetchImageArg(ctx, arg)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer img.Close()
+		images = append(images, img)
+	}
+
+	comparison := image.CompareBuilds(images[0], images[1])
+	fmt.Println(runtime.ReproReport(comparison, image.FindReproducibilityIssues(images[1].Layers, images[1].Trees)))
+	if !comparison.Reproducible() {
+		os.Exit(1)
+	}
+}
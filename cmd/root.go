@@ -9,6 +9,14 @@ import (
 
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/inspect"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/config"
+	"github.com/wagoodman/dive/runtime/hook"
+	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+	"github.com/wagoodman/dive/utils"
 
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
@@ -21,19 +29,28 @@ var exportFile string
 var ciConfigFile string
 var ciConfig = viper.New()
 var isCi bool
+var historyImages []string
+var budgetFile string
+var ignoreFile string
+var baseImage string
+var exportQuery string
+var compareRemote string
+var renderSnapshot string
+var scriptFile string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "dive [IMAGE]",
+	Use:   "dive [IMAGE...]",
 	Short: "Docker Image Visualizer & Explorer",
 	Long: `This tool provides a way to discover and explore the contents of a docker image. Additionally the tool estimates
 the amount of wasted space and identifies the offending files from the image.`,
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.ArbitraryArgs,
 	Run:  doAnalyzeCmd,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
+	registerCompletions()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -48,17 +65,69 @@ func init() {
 func initCli() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.dive.yaml, ~/.config/dive/*.yaml, or $XDG_CONFIG_HOME/dive.yaml)")
 	rootCmd.PersistentFlags().String("source", "docker", "The container engine to fetch the image from. Allowed values: "+strings.Join(dive.ImageSources, ", "))
+	rootCmd.PersistentFlags().String("engine", dive.EngineAuto, "The container engine endpoint to use: auto (probe DOCKER_HOST, docker, Docker Desktop, colima, lima and podman sockets), docker, podman, or an engine address (e.g. unix:///path/to/docker.sock).")
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "display version number")
 	rootCmd.PersistentFlags().BoolP("ignore-errors", "i", false, "ignore image parsing errors and run the analysis anyway")
+	rootCmd.Flags().Bool("audit", false, "flag setuid/setgid binaries, world-writable files, files owned by root within the application directories and files granted capabilities (in an audit pane, or the CI output)")
+	rootCmd.Flags().Bool("packages", false, "read the package databases of the image (dpkg, apk and rpm) before the analysis, to show the package that installed the selected file and the size of every package (in the UI, or the CI output); without it the packages are read when first shown in the UI")
+	rootCmd.Flags().Bool("dependencies", false, "roll the final image up by node package, python distribution and Go or Rust binary, the largest first (in a dependencies pane, or the CI output)")
+	rootCmd.Flags().Bool("duplicates", false, "list the files stored at more than one path within the image layers (in a duplicates pane, or the CI output); with --lazy the layers are hashed in the background and the duplicates fill in as they are found")
+	rootCmd.PersistentFlags().Int("io-concurrency", 1, "the number of layers read at the same time, where the image source allows it (image archives on disk, and hashing lazy layers)")
+	rootCmd.PersistentFlags().String("io-bandwidth", "", "the most bytes read per second while reading images, e.g. '100Mbps' or '20MB/s' (default unlimited)")
+	rootCmd.PersistentFlags().Int("io-iops", 0, "the most reads per second while reading images (default unlimited)")
+	rootCmd.PersistentFlags().Duration("timeout", image.DefaultOperationTimeout, "how long a request to the container engine or a registry may take (for image downloads: until the download starts) before it is retried; 0 is unlimited")
+	rootCmd.PersistentFlags().Int("retries", image.DefaultOperationRetries, "how many times a failed request to the container engine or a registry is retried")
+	rootCmd.PersistentFlags().String("pull", string(image.PullMissing), "when the container engine pulls the image from its registry (showing the progress of every layer): missing (only when it is not available locally), always (before every analysis) or never (fail when it is not available locally)")
+	rootCmd.PersistentFlags().StringSlice("registry-insecure", nil, "registries (host[:port], or * for every registry) whose TLS certificate is not verified, reached over plain http when they do not serve TLS (for self-signed internal registries); may be repeated")
+	rootCmd.PersistentFlags().String("registry-ca", "", "a PEM bundle of CA certificates (e.g. of a proxy intercepting TLS) trusted in addition to the system ones by every network operation: registries, remote container engines, vulnerability scanners and cosign")
+	rootCmd.PersistentFlags().String("registry-cert", "", "the client certificate presented to registries that require mutual TLS (with --registry-key)")
+	rootCmd.PersistentFlags().String("registry-key", "", "the key of the registry client certificate")
+	rootCmd.PersistentFlags().Bool("offline", false, "guarantee that dive does not use the network (registries, remote engines, signature checks, scanner database updates): anything that would need it fails right away")
+	rootCmd.PersistentFlags().String("log-file", "./dive.log", "write the log to the given file (same as log.enabled and log.path in the config)")
+	rootCmd.PersistentFlags().String("log-level", log.InfoLevel.String(), "write the log with the given level: trace, debug, info, warn or error (trace logs every draw of the UI)")
+	rootCmd.PersistentFlags().String("log-format", logFormatText, "the format of the log: text or json (one object per line)")
+	rootCmd.Flags().Int("max-files", 0, "keep at most this many entries in the layer trees, folding the deep subtrees of the layers read once it is reached into summary directories (the sizes and the efficiency still count every file); 0 is unlimited")
+	rootCmd.Flags().String("max-memory", "", "keep the analysis within about this much memory (e.g. '512MB'), folding deep subtrees into summary directories as --max-files does once the layer trees would not fit")
+	rootCmd.Flags().Bool("lazy", false, "only read the layer metadata upfront and parse each layer when it is first selected (keeps memory low for images with many layers; the efficiency is not reported)")
+	rootCmd.Flags().Bool("verify-signature", false, "verify the cosign signature of the image (in its registry) before the analysis, with --key or keyless with --certificate-identity and --certificate-oidc-issuer; the outcome is shown in the UI and the CI output, and fails CI validation when signature.required is set (the default)")
+	rootCmd.Flags().String("key", "", "the public key (a path or a KMS URI) the image signature is verified with")
+	rootCmd.Flags().String("certificate-identity", "", "the identity (e.g. an email or a CI workflow URL) of keyless image signatures")
+	rootCmd.Flags().String("certificate-oidc-issuer", "", "the OIDC issuer of keyless image signatures (e.g. https://token.actions.githubusercontent.com)")
+	rootCmd.Flags().String("vulnerabilities", "", "map the vulnerabilities of a scanner report (a grype or trivy JSON file) onto the image, or run the scanner on the image with 'grype' or 'trivy'; the files of vulnerable packages are marked in the file tree and the layers show their CVE counts")
 	rootCmd.Flags().BoolVar(&isCi, "ci", false, "Skip the interactive TUI and validate against CI rules (same as env var CI=true)")
 	rootCmd.Flags().StringVarP(&exportFile, "json", "j", "", "Skip the interactive TUI and write the layer analysis statistics to a given file.")
+	rootCmd.Flags().StringVar(&exportQuery, "query", "", "Skip the interactive TUI and print the values selected from the --json export with a jq-like path (e.g. '.image.inefficientBytes', '.layer[].sizeBytes', '.layer | length') or a JSONPath (e.g. '$.layer[*].digestId').")
 	rootCmd.Flags().StringVar(&ciConfigFile, "ci-config", ".dive-ci", "If CI=true in the environment, use the given yaml to drive validation rules.")
+	rootCmd.Flags().StringVar(&budgetFile, "budget", ci.DefaultBudgetFile, "If CI=true in the environment, also validate the size budget declared in the given file (max image size, max size per path and forbidden paths), when it exists.")
+	rootCmd.Flags().StringVar(&ignoreFile, "ignore-file", filetree.DefaultIgnoreFile, "Leave the paths matching the patterns of the given file (gitignore syntax) out of the efficiency score, the wasted space reports and the CI rules, when it exists.")
+	rootCmd.Flags().StringVar(&baseImage, "base-image", "", "the base image the image is built on (fetched from the same source); its layers are accounted separately from the app layers (default: the first layer is the base image)")
+	rootCmd.Flags().StringVar(&compareRemote, "compare-remote", "", "Skip the interactive TUI and compare the image with the one published to its registry under the same tag (or the reference given with --compare-remote=<reference>): the size, layer and file deltas. Only the layers that differ are downloaded.")
+	rootCmd.Flags().Lookup("compare-remote").NoOptDefVal = remoteSameTag
+	rootCmd.Flags().StringVar(&renderSnapshot, "render-snapshot", "", "Skip the interactive TUI and write the first screen of the UI (the last one with --script) to the given file ('-' for stdout), drawn headless on a pseudo terminal (linux only): for golden-file tests and scripted screenshots.")
+	rootCmd.Flags().StringVar(&scriptFile, "script", "", "Skip the interactive TUI and replay the UI actions of the given script (one action per line, or a JSON list), drawn headless like --render-snapshot, which receives the final screen when given.")
+	rootCmd.Flags().String("profile", "", "write a profile of the run for 'go tool pprof' (cpu or mem) or 'go tool trace' (trace), along with the timings of the analysis pipeline (printed on exit), to attach to performance reports")
+	rootCmd.Flags().String("profile-path", "", "the file --profile writes to (default dive.cpu.pprof, dive.mem.pprof or dive.trace)")
+	rootCmd.Flags().Int("monthly-pulls", 0, "estimate the monthly registry egress of the image (and its cost, see --egress-price) given the number of times it is pulled a month")
+	rootCmd.Flags().Float64("egress-price", image.DefaultEgressPrice, "the price per GB transferred out of the registry, to estimate the cost of the monthly egress")
+	rootCmd.Flags().String("render-size", terminal.DefaultSnapshotSize, "the size of the pseudo terminal --render-snapshot draws the UI on, as <columns>x<rows>")
+	rootCmd.Flags().String("render-format", terminal.ScreenshotText, "the format of the --render-snapshot output: "+strings.Join(terminal.ScreenshotFormats, ", "))
+	rootCmd.Flags().StringArrayVar(&historyImages, "history", nil, "(only valid with --ci given) previous versions of the image (oldest first, may be repeated) used to suggest splitting large COPY/ADD layers.")
 
 	rootCmd.Flags().String("lowestEfficiency", "0.9", "(only valid with --ci given) lowest allowable image efficiency (as a ratio between 0-1), otherwise CI validation will fail.")
 	rootCmd.Flags().String("highestWastedBytes", "disabled", "(only valid with --ci given) highest allowable bytes wasted, otherwise CI validation will fail.")
+	rootCmd.Flags().String("highestAppWastedBytes", "disabled", "(only valid with --ci given) highest allowable bytes wasted by the app layers (the layers on top of the base image), otherwise CI validation will fail.")
 	rootCmd.Flags().String("highestUserWastedPercent", "0.1", "(only valid with --ci given) highest allowable percentage of bytes wasted (as a ratio between 0-1), otherwise CI validation will fail.")
-
-	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestUserWastedPercent"} {
+	rootCmd.Flags().String("highestFileCount", "disabled", "(only valid with --ci given) highest allowable number of files and directories in the image, otherwise CI validation will fail.")
+	rootCmd.Flags().String("highestLayerFileCount", "disabled", "(only valid with --ci given) highest allowable number of entries added by a single layer, otherwise CI validation will fail.")
+	rootCmd.Flags().String("forbidCorruptLayers", "true", "(only valid with --ci given) when true, CI validation will fail if a layer blob is truncated or does not match its digest (when false this is only a warning).")
+	rootCmd.Flags().String("forbidDuplicateArtifacts", "disabled", "(only valid with --ci given) when true, CI validation will fail if several versions of the same jar or Python package are in the same directory.")
+	rootCmd.Flags().String("forbidSetuidFiles", "disabled", "(only valid with --ci given) when true, CI validation will fail if there are setuid or setgid binaries in the image.")
+	rootCmd.Flags().String("forbidWorldWritableFiles", "disabled", "(only valid with --ci given) when true, CI validation will fail if there are world-writable files or directories (apart from sticky directories like /tmp) in the image.")
+	rootCmd.Flags().String("forbidRootOwnedAppFiles", "disabled", "(only valid with --ci given) when true, CI validation will fail if files within the application directories (audit.app-dirs) are owned by root.")
+	rootCmd.Flags().String("forbidUnexpectedCapabilities", "disabled", "(only valid with --ci given) when true, CI validation will fail if files are granted capabilities that are not in audit.allowed-capabilities.")
+	rootCmd.Flags().String("forbiddenContent", "disabled", "(only valid with --ci given) comma separated globs (e.g. '**/.git/**,**/*.pem,/var/cache/**'), CI validation will fail if files of the final image match any of them.")
+
+	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "highestFileCount", "highestLayerFileCount", "forbidCorruptLayers", "forbidDuplicateArtifacts", "forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities", "forbiddenContent"} {
 		if err := ciConfig.BindPFlag(fmt.Sprintf("rules.%s", key), rootCmd.Flags().Lookup(key)); err != nil {
 			log.Fatalf("Unable to bind '%s' flag: %v", key, err)
 		}
@@ -76,35 +145,171 @@ func initConfig() {
 	viper.SetDefault("log.level", log.InfoLevel.String())
 	viper.SetDefault("log.path", "./dive.log")
 	viper.SetDefault("log.enabled", false)
+	viper.SetDefault("log.format", logFormatText)
+	// keybindings: the profile the keybindings start from (see key.Profiles)
+	viper.SetDefault("keybinding.profile", key.DefaultProfile)
 	// keybindings: status view / global
 	viper.SetDefault("keybinding.quit", "ctrl+c")
 	viper.SetDefault("keybinding.toggle-view", "tab")
 	viper.SetDefault("keybinding.filter-files", "ctrl+f, ctrl+slash")
+	viper.SetDefault("keybinding.saved-filters", "ctrl+w")
+	viper.SetDefault("keybinding.screenshot", "ctrl+s")
+	viper.SetDefault("keybinding.show-metrics", "f12")
+	viper.SetDefault("keybinding.next-image", "ctrl+n")
+	viper.SetDefault("keybinding.pick-image", "ctrl+t")
+	viper.SetDefault("keybinding.compare-images", "ctrl+k")
 	// keybindings: layer view
 	viper.SetDefault("keybinding.compare-all", "ctrl+a")
+	viper.SetDefault("keybinding.compare-flattened", "ctrl+e")
+	viper.SetDefault("keybinding.compare-since-base", "ctrl+b")
 	viper.SetDefault("keybinding.compare-layer", "ctrl+l")
+	viper.SetDefault("keybinding.copy-digest", "ctrl+y")
+	viper.SetDefault("keybinding.copy-command", "c")
+	viper.SetDefault("keybinding.copy-image-id", "i")
+	viper.SetDefault("keybinding.toggle-doomed-files", "d")
+	viper.SetDefault("keybinding.toggle-empty-layers", "E")
+	viper.SetDefault("keybinding.select-layer-range", "v")
+	viper.SetDefault("keybinding.show-history", "h")
+	viper.SetDefault("keybinding.show-config", "I")
+	viper.SetDefault("keybinding.show-ownership", "O")
+	viper.SetDefault("keybinding.compare-side-by-side", "s")
 	// keybindings: filetree view
 	viper.SetDefault("keybinding.toggle-collapse-dir", "space")
 	viper.SetDefault("keybinding.toggle-collapse-all-dir", "ctrl+space")
+	viper.SetDefault("keybinding.collapse-all-dir", "-")
+	viper.SetDefault("keybinding.expand-all-dir", "+")
+	viper.SetDefault("keybinding.expand-depth", ">")
+	viper.SetDefault("keybinding.collapse-depth", "<")
+	viper.SetDefault("keybinding.collapse-siblings", "z")
+	viper.SetDefault("keybinding.go-to-path", ":, ctrl+g")
+	viper.SetDefault("keybinding.cycle-size-format", "S")
+	viper.SetDefault("keybinding.cycle-mtime-filter", "T")
+	viper.SetDefault("keybinding.toggle-file-counts", "#")
 	viper.SetDefault("keybinding.toggle-filetree-attributes", "ctrl+b")
 	viper.SetDefault("keybinding.toggle-added-files", "ctrl+a")
 	viper.SetDefault("keybinding.toggle-removed-files", "ctrl+r")
 	viper.SetDefault("keybinding.toggle-modified-files", "ctrl+m")
 	viper.SetDefault("keybinding.toggle-unmodified-files", "ctrl+u")
 	viper.SetDefault("keybinding.toggle-wrap-tree", "ctrl+p")
+	viper.SetDefault("keybinding.follow-link", "ctrl+o")
+	viper.SetDefault("keybinding.copy-path", "ctrl+y")
+	viper.SetDefault("keybinding.toggle-mark", "m")
+	viper.SetDefault("keybinding.next-mark", "n")
+	viper.SetDefault("keybinding.previous-mark", "N")
+	viper.SetDefault("keybinding.toggle-keep", "K")
+	viper.SetDefault("keybinding.export-keep-list", "W")
+	viper.SetDefault("keybinding.show-provenance", "p")
+	viper.SetDefault("keybinding.preview-file", "i")
+	viper.SetDefault("keybinding.view-file", "v")
+	viper.SetDefault("keybinding.edit-file", "e")
+	viper.SetDefault("keybinding.browse-archive", "enter")
+	viper.SetDefault("keybinding.show-pivot", "t")
+	viper.SetDefault("keybinding.show-packages", "P")
+	viper.SetDefault("keybinding.toggle-orphan-files", "o")
+	viper.SetDefault("keybinding.export-pivot", "s")
+	viper.SetDefault("keybinding.toggle-unchanged-rows", "u")
 	viper.SetDefault("keybinding.page-up", "pgup")
 	viper.SetDefault("keybinding.page-down", "pgdn")
+	// keybindings: layer and filetree views, unbound unless a profile binds them
+	viper.SetDefault("keybinding.cursor-up", "")
+	viper.SetDefault("keybinding.cursor-down", "")
+	viper.SetDefault("keybinding.cursor-left", "")
+	viper.SetDefault("keybinding.cursor-right", "")
+	viper.SetDefault("keybinding.cursor-top", "")
+	viper.SetDefault("keybinding.cursor-bottom", "")
+	viper.SetDefault("keybinding.search", "")
+	viper.SetDefault("keybinding.next-match", "")
+	viper.SetDefault("keybinding.previous-match", "")
+	viper.SetDefault("keybinding.expand-to-depth", "")
 
 	viper.SetDefault("diff.hide", "")
 
+	viper.SetDefault("results.enabled", false)
+	viper.SetDefault("results.path", "")
+
 	viper.SetDefault("layer.show-aggregated-changes", false)
+	viper.SetDefault("layer.show-empty-layers", true)
 
 	viper.SetDefault("filetree.collapse-dir", false)
 	viper.SetDefault("filetree.pane-width", 0.5)
 	viper.SetDefault("filetree.show-attributes", true)
+	viper.SetDefault("filetree.filter", "")
+	viper.SetDefault("filetree.saved-filters", map[string]string{
+		"secrets":         `(\.pem|\.key|\.p12|\.pfx|/id_rsa|/id_ed25519|/\.env|/\.netrc|/\.git-credentials)$`,
+		"caches":          `/(var/cache|var/lib/apt/lists|\.cache|\.npm|\.yarn-cache)(/|$)`,
+		"python-bytecode": `(\.py[co]$|/__pycache__(/|$))`,
+	})
+	viper.SetDefault("filetree.ignore-paths", []string{})
+	viper.SetDefault("filetree.size-format", string(filetree.SizeSI))
+	viper.SetDefault("filetree.show-file-counts", false)
+	viper.SetDefault("filetree.truncate", string(filetree.TruncateMiddle))
+
+	viper.SetDefault("preview.graphics", "auto")
+
+	viper.SetDefault("pivot.export-file", "dive-pivot.csv")
+
+	viper.SetDefault("slim.manifest-file", "dive-slim.json")
+	viper.SetDefault("slim.dockerfile", "")
+
+	viper.SetDefault("screenshot.format", terminal.ScreenshotSVG)
+	viper.SetDefault("screenshot.dir", ".")
+	viper.SetDefault("hooks.timeout", hook.DefaultTimeout.String())
+	viper.SetDefault("render.size", terminal.DefaultSnapshotSize)
+	viper.SetDefault("render.format", terminal.ScreenshotText)
+
+	viper.SetDefault("inspect.inspectors", inspect.DefaultNames)
+
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("audit.app-dirs", image.DefaultAuditAppDirs)
+	viper.SetDefault("audit.allowed-capabilities", []string{})
+
+	viper.SetDefault("duplicates.enabled", false)
+	viper.SetDefault("packages.enabled", false)
+	viper.SetDefault("dependencies.enabled", false)
+	viper.SetDefault("storage.enabled", false)
+	viper.SetDefault("compression.enabled", false)
+	viper.SetDefault("ownership.enabled", false)
+
+	viper.SetDefault("io.concurrency", 1)
+	viper.SetDefault("io.bandwidth", "")
+	viper.SetDefault("io.iops", 0)
+	viper.SetDefault("limits.max-files", 0)
+	viper.SetDefault("limits.max-memory", "")
+	viper.SetDefault("cache.layers", true)
+	viper.SetDefault("cache.dir", "")
+
+	viper.SetDefault("requests.timeout", image.DefaultOperationTimeout.String())
+	viper.SetDefault("requests.retries", image.DefaultOperationRetries)
+	viper.SetDefault("requests.backoff", image.DefaultOperationBackoff.String())
+
+	viper.SetDefault("userns.uid-map", filetree.IDMappingAuto)
+	viper.SetDefault("userns.gid-map", filetree.IDMappingAuto)
+
+	viper.SetDefault("pull.enabled", false)
+	viper.SetDefault("pull.bandwidth", image.DefaultPullBandwidth)
+	viper.SetDefault("pull.layer-latency", image.DefaultPullLayerLatency)
+	viper.SetDefault("pull.compare-bandwidths", []string{})
+	viper.SetDefault("pull.monthly-pulls", 0)
+	viper.SetDefault("pull.egress-price", image.DefaultEgressPrice)
+
+	viper.SetDefault("signature.verify", false)
+	viper.SetDefault("signature.key", "")
+	viper.SetDefault("signature.certificate-identity", "")
+	viper.SetDefault("signature.certificate-oidc-issuer", "")
+	viper.SetDefault("signature.required", true)
+
+	viper.SetDefault("vulnerabilities.report", "")
+
+	viper.SetDefault("ui.color", "auto")
+	viper.SetDefault("ui.glyphs", "auto")
+	viper.SetDefault("ui.diff-markers", "auto")
+	viper.SetDefault("ui.initial-view", "layer")
 
 	viper.SetDefault("container-engine", "docker")
 	viper.SetDefault("ignore-errors", false)
+	viper.SetDefault("lazy", false)
+	viper.SetDefault("offline", false)
+	viper.SetDefault("pull.policy", string(image.PullMissing))
 
 	err = viper.BindPFlag("source", rootCmd.PersistentFlags().Lookup("source"))
 	if err != nil {
@@ -112,9 +317,36 @@ func initConfig() {
 		os.Exit(1)
 	}
 
-	viper.SetEnvPrefix("DIVE")
-	// replace all - with _ when looking for matching environment variables
-	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	err = viper.BindPFlag("engine", rootCmd.PersistentFlags().Lookup("engine"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	for flag, key := range map[string]string{"timeout": "requests.timeout", "retries": "requests.retries", "offline": "offline", "pull": "pull.policy", "registry-insecure": "registry.insecure", "registry-ca": "registry.ca", "registry-cert": "registry.client-cert", "registry-key": "registry.client-key", "log-file": "log.path", "log-level": "log.level", "log-format": "log.format"} {
+		err = viper.BindPFlag(key, rootCmd.PersistentFlags().Lookup(flag))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	for flag, key := range map[string]string{"monthly-pulls": "pull.monthly-pulls", "egress-price": "pull.egress-price", "render-size": "render.size", "render-format": "render.format", "verify-signature": "signature.verify", "key": "signature.key", "certificate-identity": "signature.certificate-identity", "certificate-oidc-issuer": "signature.certificate-oidc-issuer", "vulnerabilities": "vulnerabilities.report"} {
+		err = viper.BindPFlag(key, rootCmd.Flags().Lookup(flag))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	// giving a log file or a log level writes the log
+	if logFlagsGiven() {
+		viper.Set("log.enabled", true)
+	}
+
+	viper.SetEnvPrefix(config.EnvPrefix)
+	// replace all . and - with _ when looking for matching environment variables
+	viper.SetEnvKeyReplacer(config.EnvKeyReplacer)
 	viper.AutomaticEnv()
 
 	// if config files are present, load them
@@ -126,48 +358,89 @@ func initConfig() {
 		viper.SetConfigFile(cfgFile)
 	}
 	err = viper.ReadInConfig()
-	if err == nil {
+	if err == nil && !completionRequest() {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	} else if cfgFile != "" {
 		fmt.Println(err)
 		os.Exit(0)
 	}
 
+	err = configureRequests()
+	if err != nil {
+		fmt.Printf("requests configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// the command line output is plain text under NO_COLOR (see https://no-color.org) or when colors are turned off
+	utils.EnableColor(os.Getenv("NO_COLOR") == "" && !strings.EqualFold(viper.GetString("ui.color"), "none"))
+
 	// set global defaults (for performance)
-	filetree.GlobalFileTreeCollapse = viper.GetBool("filetree.collapse-dir")
 }
 
-// initLogging sets up the logging object with a formatter and location
-func initLogging() {
-	var logFileObj *os.File
-	var err error
+// the formats of the log
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
 
-	if viper.GetBool("log.enabled") {
-		logFileObj, err = os.OpenFile(viper.GetString("log.path"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
-		log.SetOutput(logFileObj)
-	} else {
-		log.SetOutput(ioutil.Discard)
+// initLogging sets up the logging object with a formatter and location, exiting when the level or format is invalid
+func initLogging() {
+	if err := setupLogging(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+}
+
+// logFlagsGiven indicates if a log file or a log level was given on the command line, which writes the log.
+func logFlagsGiven() bool {
+	return rootCmd.PersistentFlags().Changed("log-file") || rootCmd.PersistentFlags().Changed("log-level")
+}
 
+// setupLogging sets up the logging object from the log.* config (and the --log-* flags bound to it). An invalid level
+// or format is an error, even when the log is not written; a log file that cannot be opened only disables the log.
+func setupLogging() error {
+	level, err := log.ParseLevel(viper.GetString("log.level"))
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+	var formatter log.Formatter
+	switch format := viper.GetString("log.format"); format {
+	case logFormatJSON:
+		formatter = new(log.JSONFormatter)
+	case logFormatText:
+		formatter = &log.TextFormatter{DisableTimestamp: true}
+	default:
+		return fmt.Errorf("invalid log format: %q (expected %s or %s)", format, logFormatText, logFormatJSON)
 	}
 
-	Formatter := new(log.TextFormatter)
-	Formatter.DisableTimestamp = true
-	log.SetFormatter(Formatter)
+	if !viper.GetBool("log.enabled") {
+		disableLogging()
+		return nil
+	}
 
-	level, err := log.ParseLevel(viper.GetString("log.level"))
+	logFileObj, err := os.OpenFile(viper.GetString("log.path"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		disableLogging()
+		return nil
 	}
-
+	log.SetOutput(logFileObj)
+	log.SetFormatter(formatter)
 	log.SetLevel(level)
+
 	log.Debug("Starting Dive...")
 	log.Debugf("config filepath: %s", viper.ConfigFileUsed())
 	for k, v := range viper.AllSettings() {
 		log.Debug("config value: ", k, " : ", v)
 	}
+	return nil
+}
+
+// disableLogging discards the log. The level is raised as well, so that no entry is formatted (the UI logs every
+// draw at the trace level).
+func disableLogging() {
+	log.SetOutput(ioutil.Discard)
+	log.SetLevel(log.PanicLevel)
 }
 
 // getDefaultCfgFile checks for config file in paths from xdg specs
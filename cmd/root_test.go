@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// setLogConfig sets the log.* config for the test, dropping it (and discarding the log) afterwards.
+func setLogConfig(t *testing.T, values map[string]interface{}) {
+	for key, value := range values {
+		key := key
+		viper.Set(key, value)
+		// a nil value is no value, so that the config and flags are read again
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+	t.Cleanup(disableLogging)
+}
+
+func TestSetupLogging(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		expected string
+	}{
+		{format: logFormatText, expected: `level=debug msg="layer parsed"`},
+		{format: logFormatJSON, expected: `"level":"debug","msg":"layer parsed"`},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dive.log")
+			setLogConfig(t, map[string]interface{}{"log.enabled": true, "log.path": path, "log.level": "debug", "log.format": test.format})
+
+			if err := setupLogging(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			log.Debug("layer parsed")
+			log.Trace("layer drawn")
+
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read the log: %v", err)
+			}
+			if !strings.Contains(string(content), test.expected) {
+				t.Errorf("expected the log to contain %q, got:\n%s", test.expected, content)
+			}
+			if strings.Contains(string(content), "layer drawn") {
+				t.Errorf("expected the entries below the level to be left out, got:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestSetupLoggingDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dive.log")
+	setLogConfig(t, map[string]interface{}{"log.enabled": false, "log.path": path, "log.level": "info", "log.format": logFormatText})
+
+	if err := setupLogging(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log.Error("not written")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no log file to be written, got %v", err)
+	}
+}
+
+func TestSetupLoggingInvalid(t *testing.T) {
+	for name, values := range map[string]map[string]interface{}{
+		"level":  {"log.level": "loud", "log.format": logFormatText},
+		"format": {"log.level": "info", "log.format": "xml"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dive.log")
+			values["log.enabled"] = true
+			values["log.path"] = path
+			setLogConfig(t, values)
+
+			if err := setupLogging(); err == nil {
+				t.Errorf("expected an error for an invalid %s", name)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("expected no log file to be written, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLogFlags(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	completionConfig.Do(initConfig)
+
+	flags := rootCmd.PersistentFlags()
+	t.Cleanup(func() {
+		for _, name := range []string{"log-file", "log-level", "log-format"} {
+			flag := flags.Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+
+	if logFlagsGiven() {
+		t.Errorf("expected no log flags to be given")
+	}
+	if err := flags.Set("log-format", logFormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the format alone does not write the log
+	if logFlagsGiven() {
+		t.Errorf("expected the log format not to write the log")
+	}
+	if err := flags.Set("log-level", "debug"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !logFlagsGiven() {
+		t.Errorf("expected the log level to write the log")
+	}
+
+	path := filepath.Join(t.TempDir(), "dive.log")
+	if err := flags.Set("log-file", path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, expected := range map[string]string{"log.path": path, "log.level": "debug", "log.format": logFormatJSON} {
+		if actual := viper.GetString(key); actual != expected {
+			t.Errorf("expected %s to be %q (from its flag), got %q", key, expected, actual)
+		}
+	}
+}
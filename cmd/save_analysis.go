@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/inspect"
+)
+
+// saveAnalysisCmd represents the save-analysis command
+var saveAnalysisCmd = &cobra.Command{
+	Use:   "save-analysis <image>",
+	Short: "Saves the analysis of an image as a bundle that 'dive open' shows in the UI without access to the image.",
+	Long: `Reads the image and saves what the UI shows (the layers, their file trees, the history and config, and the
+packages with --packages) as a compact analysis bundle: a CI job can keep it as an artifact that a developer later opens
+with 'dive open <bundle>' on a machine that has no access to the image. The file contents are not saved, so the views
+that read them (file previews, nested archives) are not available for a bundle.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doSaveAnalysisCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(saveAnalysisCmd)
+	saveAnalysisCmd.Flags().StringP("output", "o", "", "the bundle file to write (e.g. image.dive)")
+	saveAnalysisCmd.Flags().Bool("packages", false, "read the package databases of the image (dpkg, apk and rpm) into the bundle, since they cannot be read from the bundle later")
+}
+
+// doSaveAnalysisCmd implements the steps taken for the save-analysis command
+func doSaveAnalysisCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		fmt.Printf("unable to get 'output' option: %v\n", err)
+		os.Exit(1)
+	}
+	if output == "" {
+		fmt.Println("the bundle file to write is required (--output)")
+		os.Exit(1)
+	}
+	packages, err := cmd.Flags().GetBool("packages")
+	if err != nil {
+		fmt.Printf("unable to get 'packages' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	// the bundle holds what the inspectors found, as the UI shows it
+	inspectors, err := inspect.Inspectors(viper.GetStringSlice("inspect.inspectors"))
+	if err != nil {
+		fmt.Printf("inspector configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	resolverOptions.Inspectors = inspectors
+
+	img, err := fetchImageArg(signalContext(), args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer img.Close()
+
+	if packages || viper.GetBool("packages.enabled") {
+		img.Packages = image.ReadPackages(img.Trees, img.Contents)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		fmt.Printf("cannot create %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	err = image.WriteBundle(file, img, args[0])
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("cannot save the analysis: %v\n", err)
+		os.Remove(output)
+		os.Exit(1)
+	}
+
+	size := "?"
+	if info, err := os.Stat(output); err == nil {
+		size = humanize.Bytes(uint64(info.Size()))
+	}
+	fmt.Printf("saved the analysis of %s (%d layers) to %s (%s)\n", args[0], len(img.Layers), output, size)
+}
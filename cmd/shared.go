@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// sharedCmd represents the shared command
+var sharedCmd = &cobra.Command{
+	Use:   "shared <image> <image> [image...]",
+	Short: "Reports which layers a set of images share, the deduplicated storage, and which images would benefit from a common base.",
+	Long: `Compares the layers of a set of images (matched by diffID): which layers are shared by several images and which
+are unique to one, the size of every image on its own next to the deduplicated size a registry stores (every distinct
+layer once, compressed when the compressed size is known), and the common base (the leading layers most of the images
+start with). The images that are not built on the common base are listed with the bytes of their own layers that would
+be shared once rebased onto it. Only the layer metadata is read, the layer contents are not analyzed. With
+--format json the report is written as JSON.`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  doSharedCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(sharedCmd)
+	sharedCmd.Flags().String("format", "text", "the output format: text or json")
+	sharedCmd.Flags().Int("limit", 20, "the most layers listed (0 for all)")
+}
+
+// doSharedCmd implements the steps taken for the shared command
+func doSharedCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		fmt.Printf("unable to get 'format' option: %v\n", err)
+		os.Exit(1)
+	}
+	if format != "text" && format != "json" {
+		fmt.Printf("unknown format %q (expected text or json)\n", format)
+		os.Exit(1)
+	}
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		fmt.Printf("unable to get 'limit' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx := signalContext()
+	layers := make([][]*image.Layer, 0, len(args))
+	for _, arg := range args {
+		img, err := fetchImageArg(ctx, arg)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer img.Close()
+		layers = append(layers, img.Layers)
+	}
+
+	analysis := image.AnalyzeSharedLayers(args, layers)
+	if format == "text" {
+		fmt.Println(runtime.SharedReport(analysis, limit))
+		return
+	}
+	bytes, err := export.NewShared(analysis, limit).Marshal()
+	if err != nil {
+		fmt.Printf("cannot marshal the shared layer report: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(bytes))
+}
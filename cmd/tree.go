@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// treeCmd represents the tree command
+var treeCmd = &cobra.Command{
+	Use:   "tree <image>",
+	Short: "Prints the file tree of the image (or of a single layer) without the UI.",
+	Long: `Prints the file tree of the image, as all layers are stacked, to stdout: one path per line with its mode, owner and
+size, to grep or to paste into a ticket. With --layer only the changes of that layer (0 is the first) are listed, and
+with --depth the directories deeper than the given depth are collapsed. With --format json the tree is written as JSON
+(directory sizes are the sum of the files beneath them), and with --format ncdu in the export format of ncdu, to browse
+the image filesystem offline with 'ncdu -f <file>'.`,
+	Args: cobra.ExactArgs(1),
+	Run:  doTreeCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+	treeCmd.Flags().Int("layer", -1, "the index of the layer to list the changes of (0 is the first, the default lists the whole image)")
+	treeCmd.Flags().Int("depth", 0, "the directory depth listed (e.g. 2 for /usr/lib, 0 for all)")
+	treeCmd.Flags().String("format", "text", "the output format: text, json or ncdu")
+	treeCmd.Flags().Bool("attributes", true, "list the mode, owner and size of every path (text only)")
+}
+
+// doTreeCmd implements the steps taken for the tree command
+func doTreeCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	layer, err := cmd.Flags().GetInt("layer")
+	if err != nil {
+		fmt.Printf("unable to get 'layer' option: %v\n", err)
+		os.Exit(1)
+	}
+	depth, err := cmd.Flags().GetInt("depth")
+	if err != nil {
+		fmt.Printf("unable to get 'depth' option: %v\n", err)
+		os.Exit(1)
+	}
+	if depth < 0 {
+		fmt.Printf("invalid depth %d (expected 0 or more)\n", depth)
+		os.Exit(1)
+	}
+	outputFormat, err := cmd.Flags().GetString("format")
+	if err != nil {
+		fmt.Printf("unable to get 'format' option: %v\n", err)
+		os.Exit(1)
+	}
+	if outputFormat != "text" && outputFormat != "json" && outputFormat != "ncdu" {
+		fmt.Printf("unknown format %q (expected text, json or ncdu)\n", outputFormat)
+		os.Exit(1)
+	}
+	attributes, err := cmd.Flags().GetBool("attributes")
+	if err != nil {
+		fmt.Printf("unable to get 'attributes' option: %v\n", err)
+		os.Exit(1)
+	}
+
+	img, err := fetchImageArg(signalContext(), args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer img.Close()
+
+	if layer < -1 || layer >= len(img.Trees) {
+		fmt.Printf("invalid layer %d (the image has layers 0 to %d)\n", layer, len(img.Trees)-1)
+		os.Exit(1)
+	}
+	tree, err := imageTree(img, layer)
+	if err != nil {
+		fmt.Printf("cannot read the file tree: %v\n", err)
+		os.Exit(1)
+	}
+
+	if outputFormat == "ncdu" {
+		bytes, err := export.MarshalNcdu(tree, versionString(), time.Now())
+		if err != nil {
+			fmt.Printf("cannot marshal the file tree: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+	if outputFormat == "json" {
+		bytes, err := export.MarshalFileTreeDepth(tree, depth)
+		if err != nil {
+			fmt.Printf("cannot marshal the file tree: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(bytes))
+		return
+	}
+
+	capabilities, err := format.ResolveCapabilities(
+		format.DetectCapabilities(os.Getenv, goruntime.GOOS),
+		viper.GetString("ui.color"),
+		viper.GetString("ui.glyphs"),
+		viper.GetString("ui.diff-markers"),
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if capabilities.Colors == format.ColorNone {
+		// plain text: unlike the UI, the printed tree needs no text attributes to tell the cursor apart
+		color.NoColor = true
+	}
+
+	tree.CollapseDepth(depth)
+	fmt.Print(tree.Render(attributes, capabilities.RenderOptions(filetree.DefaultRenderOptions())))
+}
+
+// imageTree returns a copy of the tree of the given layer, or of all layers stacked when the layer is -1 (parsing
+// the layers of lazy images as needed).
+func imageTree(img *image.Image, layer int) (*filetree.FileTree, error) {
+	if len(img.Trees) == 0 {
+		return nil, fmt.Errorf("the image has no layers")
+	}
+	start, stop := layer, layer
+	if layer < 0 {
+		start, stop = 0, len(img.Trees)-1
+	}
+	if err := loadTrees(img, start, stop); err != nil {
+		return nil, err
+	}
+
+	if layer >= 0 {
+		return img.Trees[layer].Copy(), nil
+	}
+	tree, _, err := filetree.StackTreeRange(img.Trees, 0, stop)
+	return tree, err
+}
+
+// loadTrees parses the trees of the given layers (inclusive) that have not been loaded yet.
+func loadTrees(img *image.Image, start, stop int) error {
+	for idx := start; idx <= stop; idx++ {
+		if img.Trees[idx] != nil {
+			continue
+		}
+		if img.Loader == nil {
+			return fmt.Errorf("layer %d has not been loaded", idx)
+		}
+		tree, err := img.LoadTree(idx)
+		if err != nil {
+			return fmt.Errorf("unable to load layer %d: %v", idx, err)
+		}
+		img.Trees[idx] = tree
+	}
+	return nil
+}
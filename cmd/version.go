@@ -32,3 +32,11 @@ func SetVersion(v *Version) {
 func printVersion(cmd *cobra.Command, args []string) {
 	fmt.Printf("dive %s\n", version.Version)
 }
+
+// versionString returns the version of dive (empty when it has not been set, e.g. in tests).
+func versionString() string {
+	if version == nil {
+		return ""
+	}
+	return version.Version
+}
@@ -0,0 +1,34 @@
+package dive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// Analyze fetches and analyzes the given image, which may be prefixed with the source to fetch it from
+// (e.g. "podman://alpine:latest" or "docker-archive://image.tar"); image archives and OCI layout directories on disk
+// are recognized without a prefix, and the docker engine is used otherwise. This is the
+// entry point for embedding dive as a library: the image is fetched and analyzed with the default resolver and analysis
+// options, and the terminal is not touched beyond what the underlying container engine CLI does. The image is closed
+// before returning, as the result does not read from it.
+func Analyze(ctx context.Context, source string) (*image.AnalysisResult, error) {
+	sourceType, imageStr, _ := DetectImageSource(source)
+	if sourceType == SourceUnknown {
+		sourceType, imageStr = SourceDockerEngine, source
+	}
+
+	resolver, err := GetImageResolver(sourceType, image.DefaultResolverOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := resolver.Fetch(ctx, imageStr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch image: %w", err)
+	}
+	defer img.Close()
+
+	return img.AnalyzeContext(ctx)
+}
@@ -0,0 +1,24 @@
+package dive
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	result, err := Analyze(context.Background(), "docker-archive://../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	if len(result.Layers) != 14 {
+		t.Errorf("expected 14 layers, got %d", len(result.Layers))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Analyze(ctx, "docker-archive://../.data/test-docker-image.tar")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+}
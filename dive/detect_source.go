@@ -0,0 +1,33 @@
+package dive
+
+import (
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+// DetectImageSource derives the source of the image like DeriveImageSource and, when the image has no source prefix,
+// recognizes image archives, OCI layout directories and analysis bundles on disk by their contents. Along with the source and the image
+// it describes why the source was chosen (when neither applies the source is SourceUnknown, and the reason only notes
+// a digest the image is pinned to).
+func DetectImageSource(userImage string) (ImageSource, string, string) {
+	if source, imageStr := DeriveImageSource(userImage); source != SourceUnknown {
+		return source, imageStr, "given by the " + source.String() + ":// prefix" + digestNote(imageStr)
+	}
+	if image.IsBundle(userImage) {
+		return SourceBundle, userImage, "detected an analysis bundle"
+	}
+	if kind := docker.DetectArchive(userImage); kind != "" {
+		return SourceDockerArchive, userImage, "detected " + kind
+	}
+	return SourceUnknown, userImage, strings.TrimPrefix(digestNote(userImage), ", ")
+}
+
+// digestNote notes that the image reference is pinned by digest (e.g. "alpine@sha256:...").
+func digestNote(image string) string {
+	if idx := strings.LastIndex(image, "@"); idx >= 0 && strings.Contains(image[idx:], ":") {
+		return ", pinned to " + image[idx+1:]
+	}
+	return ""
+}
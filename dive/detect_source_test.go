@@ -0,0 +1,91 @@
+package dive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeriveImageSource(t *testing.T) {
+	cases := []struct {
+		image    string
+		source   ImageSource
+		imageStr string
+	}{
+		{image: "docker://alpine:latest", source: SourceDockerEngine, imageStr: "alpine:latest"},
+		{image: "docker://alpine@sha256:0123abcd", source: SourceDockerEngine, imageStr: "alpine@sha256:0123abcd"},
+		{image: "podman://localhost:5000/app:v1", source: SourcePodmanEngine, imageStr: "localhost:5000/app:v1"},
+		{image: "docker-archive://image.tar", source: SourceDockerArchive, imageStr: "image.tar"},
+		{image: "archive://-", source: SourceDockerArchive, imageStr: "-"},
+		{image: "ctr://docker.io/library/alpine:latest", source: SourceContainerd, imageStr: "docker.io/library/alpine:latest"},
+		{image: "containerd://docker.io/library/alpine:latest", source: SourceContainerd, imageStr: "docker.io/library/alpine:latest"},
+		{image: "registry://ghcr.io/org/app:1.0", source: SourceRegistry, imageStr: "ghcr.io/org/app:1.0"},
+		{image: "bundle://app.dive", source: SourceBundle, imageStr: "app.dive"},
+		{image: "alpine@sha256:0123abcd", source: SourceUnknown},
+		{image: "localhost:5000/app:v1", source: SourceUnknown},
+		{image: "docker:alpine", source: SourceUnknown},
+		// a name and its tag are not a source
+		{image: "archive:latest", source: SourceUnknown},
+		{image: "archive:-", source: SourceUnknown},
+		{image: "podman:1.0", source: SourceUnknown},
+		{image: "registry:2", source: SourceUnknown},
+		{image: "oci://image", source: SourceUnknown},
+	}
+
+	for _, test := range cases {
+		source, imageStr := DeriveImageSource(test.image)
+		if source != test.source || imageStr != test.imageStr {
+			t.Errorf("%s: expected %s %q, got %s %q", test.image, test.source, test.imageStr, source, imageStr)
+		}
+	}
+}
+
+func TestDetectImageSource(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "image.tar")
+	contents, err := ioutil.ReadFile("../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if err := ioutil.WriteFile(archive, contents, 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	layout := filepath.Join(dir, "layout")
+	if err := os.MkdirAll(layout, 0755); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(layout, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	bundle := filepath.Join(dir, "app.dive")
+	if err := ioutil.WriteFile(bundle, []byte("dive-analysis-bundle\n"), 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	notAnImage := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(notAnImage, []byte("not an image"), 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	cases := []struct {
+		image    string
+		source   ImageSource
+		imageStr string
+		reason   string
+	}{
+		{image: archive, source: SourceDockerArchive, imageStr: archive, reason: "detected an image archive"},
+		{image: layout, source: SourceDockerArchive, imageStr: layout, reason: "detected an OCI layout directory"},
+		{image: bundle, source: SourceBundle, imageStr: bundle, reason: "detected an analysis bundle"},
+		{image: "podman://alpine@sha256:0123abcd", source: SourcePodmanEngine, imageStr: "alpine@sha256:0123abcd", reason: "given by the podman:// prefix, pinned to sha256:0123abcd"},
+		{image: "alpine@sha256:0123abcd", source: SourceUnknown, imageStr: "alpine@sha256:0123abcd", reason: "pinned to sha256:0123abcd"},
+		{image: notAnImage, source: SourceUnknown, imageStr: notAnImage},
+		{image: "alpine", source: SourceUnknown, imageStr: "alpine"},
+	}
+
+	for _, test := range cases {
+		source, imageStr, reason := DetectImageSource(test.image)
+		if source != test.source || imageStr != test.imageStr || reason != test.reason {
+			t.Errorf("%s: expected %s %q (%s), got %s %q (%s)", test.image, test.source, test.imageStr, test.reason, source, imageStr, reason)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package dive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	// EngineAuto probes every known endpoint of every container engine
+	EngineAuto = "auto"
+	// the time given to each endpoint to answer a ping
+	engineProbeTimeout = 2 * time.Second
+)
+
+// EngineEndpoint is a way to reach a container engine from this machine.
+type EngineEndpoint struct {
+	// a human friendly description of the endpoint (e.g. "colima (default)")
+	Name string
+	// the engine serving the endpoint ("docker" or "podman")
+	Engine string
+	// the engine API address (e.g. "unix:///var/run/docker.sock"), empty for the podman CLI (which needs no service)
+	Host string
+}
+
+func (endpoint EngineEndpoint) String() string {
+	if endpoint.Host == "" {
+		return endpoint.Name
+	}
+	return fmt.Sprintf("%s (%s)", endpoint.Name, endpoint.Host)
+}
+
+// Source is the image source that fetches images through the endpoint. Podman sockets serve the docker API, so only the
+// podman CLI requires the podman source.
+func (endpoint EngineEndpoint) Source() ImageSource {
+	if endpoint.Host == "" && endpoint.Engine == SourcePodmanEngine.String() {
+		return SourcePodmanEngine
+	}
+	return SourceDockerEngine
+}
+
+// probeEngine checks that an engine API answers at the given host.
+var probeEngine = pingEngineHost
+
+// DiscoverEngine finds a reachable container engine. The engine is either "auto" (any engine), "docker" or "podman"
+// (only the endpoints of that engine), or an engine API address which is used as is. The endpoints are probed in
+// order: DOCKER_HOST and CONTAINER_HOST, the default docker socket, rootless docker, Docker Desktop, colima and lima
+// VMs, podman sockets (rootless, rootful and podman machines) and finally the podman CLI. Remote engines are not
+// reached when the network policy is offline.
+func DiscoverEngine(ctx context.Context, engine string, network image.NetworkPolicy) (EngineEndpoint, error) {
+	if strings.Contains(engine, "://") {
+		if remoteEngineHost(engine) {
+			if err := network.RequireNetwork("reaching the engine at " + engine); err != nil {
+				return EngineEndpoint{}, err
+			}
+		}
+		return EngineEndpoint{Name: "--engine", Engine: SourceDockerEngine.String(), Host: engine}, nil
+	}
+	if engine != EngineAuto && engine != SourceDockerEngine.String() && engine != SourcePodmanEngine.String() {
+		return EngineEndpoint{}, fmt.Errorf("unknown engine %q (expected auto, docker, podman or an engine address)", engine)
+	}
+
+	home, _ := os.UserHomeDir()
+	candidates := engineCandidates(os.Getenv, home, runtime.GOOS)
+
+	var failures []string
+	for _, candidate := range candidates {
+		if engine != EngineAuto && candidate.Engine != engine {
+			continue
+		}
+		if remoteEngineHost(candidate.Host) && network.Offline {
+			failures = append(failures, fmt.Sprintf("%s: %v", candidate, image.ErrOffline))
+			continue
+		}
+		if candidate.Host == "" {
+			if _, err := exec.LookPath("podman"); err != nil || runtime.GOOS != "linux" {
+				failures = append(failures, fmt.Sprintf("%s: podman executable not found", candidate.Name))
+				continue
+			}
+			return candidate, nil
+		}
+		if err := probeEngine(ctx, candidate.Host); err != nil {
+			logrus.Debugf("engine endpoint %s is not reachable: %v", candidate, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", candidate, describeEngineError(candidate, err)))
+			continue
+		}
+		return candidate, nil
+	}
+
+	return EngineEndpoint{}, fmt.Errorf("no container engine found (use --engine to pick one):\n  %s", strings.Join(failures, "\n  "))
+}
+
+// describeEngineError explains why an endpoint could not be used when the socket denied access to the current user,
+// which is how a rootful engine looks from a rootless setup.
+func describeEngineError(endpoint EngineEndpoint, err error) error {
+	if !errors.Is(err, syscall.EACCES) && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	if endpoint.Engine == SourcePodmanEngine.String() {
+		return fmt.Errorf("permission denied (the socket belongs to a rootful podman service, run dive as its user or use the rootless podman socket)")
+	}
+	return fmt.Errorf("permission denied (add the user to the docker group, or use a rootless engine socket under $XDG_RUNTIME_DIR)")
+}
+
+// engineCandidates lists the known engine endpoints in probing order, keeping only the sockets that exist.
+func engineCandidates(getenv func(string) string, home string, goos string) []EngineEndpoint {
+	var candidates []EngineEndpoint
+	seen := make(map[string]bool)
+
+	add := func(name, engine, host string) {
+		if seen[host] {
+			return
+		}
+		seen[host] = true
+		candidates = append(candidates, EngineEndpoint{Name: name, Engine: engine, Host: host})
+	}
+	addSocket := func(name, engine, socket string) {
+		if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			add(name, engine, "unix://"+socket)
+		}
+	}
+	addSockets := func(pattern, engine string, name func(socket string) string) {
+		sockets, _ := filepath.Glob(pattern)
+		for _, socket := range sockets {
+			addSocket(name(socket), engine, socket)
+		}
+	}
+
+	docker, podman := SourceDockerEngine.String(), SourcePodmanEngine.String()
+
+	if host := getenv("DOCKER_HOST"); host != "" {
+		add("DOCKER_HOST", docker, host)
+	}
+	if host := getenv("CONTAINER_HOST"); host != "" {
+		add("CONTAINER_HOST", podman, host)
+	}
+
+	if goos == "windows" {
+		add("docker (default pipe)", docker, "npipe:////./pipe/docker_engine")
+		return candidates
+	}
+
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" && goos == "linux" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+
+	addSocket("docker (default socket)", docker, "/var/run/docker.sock")
+	if runtimeDir != "" {
+		addSocket("rootless docker", docker, filepath.Join(runtimeDir, "docker.sock"))
+	}
+
+	if home != "" {
+		addSocket("Docker Desktop", docker, filepath.Join(home, ".docker", "run", "docker.sock"))
+		addSocket("Docker Desktop", docker, filepath.Join(home, ".docker", "desktop", "docker.sock"))
+
+		colimaHome := getenv("COLIMA_HOME")
+		if colimaHome == "" {
+			colimaHome = filepath.Join(home, ".colima")
+		}
+		// the default profile first, then any other profile
+		addSocket("colima (default)", docker, filepath.Join(colimaHome, "default", "docker.sock"))
+		addSockets(filepath.Join(colimaHome, "*", "docker.sock"), docker, func(socket string) string {
+			return fmt.Sprintf("colima (%s)", filepath.Base(filepath.Dir(socket)))
+		})
+
+		limaHome := getenv("LIMA_HOME")
+		if limaHome == "" {
+			limaHome = filepath.Join(home, ".lima")
+		}
+		addSockets(filepath.Join(limaHome, "*", "sock", "docker.sock"), docker, func(socket string) string {
+			return fmt.Sprintf("lima (%s)", filepath.Base(filepath.Dir(filepath.Dir(socket))))
+		})
+	}
+
+	if runtimeDir != "" {
+		addSocket("rootless podman", podman, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	addSocket("podman", podman, "/run/podman/podman.sock")
+	if home != "" {
+		machines := filepath.Join(home, ".local", "share", "containers", "podman", "machine")
+		podmanMachine := func(socket string) string {
+			return fmt.Sprintf("podman machine (%s)", filepath.Base(filepath.Dir(socket)))
+		}
+		addSockets(filepath.Join(machines, "*", "podman.sock"), podman, podmanMachine)
+		addSockets(filepath.Join(machines, "*", "*", "podman.sock"), podman, podmanMachine)
+	}
+
+	// the podman CLI works against the local storage without any service running
+	candidates = append(candidates, EngineEndpoint{Name: "podman CLI", Engine: podman})
+
+	return candidates
+}
+
+// remoteEngineHost indicates if the engine at the given host is reached over the network: tcp and ssh hosts other than
+// the loopback interface.
+func remoteEngineHost(host string) bool {
+	scheme, address, found := strings.Cut(host, "://")
+	if !found || (scheme != "tcp" && scheme != "ssh") {
+		return false
+	}
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		address = address[at+1:]
+	}
+	address = strings.SplitN(address, "/", 2)[0]
+	hostname := address
+	if name, _, err := net.SplitHostPort(address); err == nil {
+		hostname = name
+	}
+	if hostname == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(hostname)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// pingEngineHost checks that the docker (or docker compatible) API answers at the given host. Only unix sockets and
+// plain tcp hosts are probed, other hosts (tls, ssh, named pipes) are assumed to be reachable.
+func pingEngineHost(ctx context.Context, host string) error {
+	var dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	var baseURL string
+
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socket := strings.TrimPrefix(host, "unix://")
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+		baseURL = "http://localhost"
+	case strings.HasPrefix(host, "tcp://"):
+		if os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			return nil
+		}
+		baseURL = "http://" + strings.TrimPrefix(host, "tcp://")
+	default:
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineProbeTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/_ping", nil)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Transport: &http.Transport{DialContext: dial}}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected ping response: %s", response.Status)
+	}
+	return nil
+}
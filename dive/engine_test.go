@@ -0,0 +1,164 @@
+package dive
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// listenSocket creates a unix socket at the given path, answering engine pings.
+func listenSocket(t *testing.T, path string) func() {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/_ping" {
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	})}
+	go server.Serve(listener)
+	return func() { server.Close() }
+}
+
+func TestEngineCandidates(t *testing.T) {
+	home, err := ioutil.TempDir("", "dive-engine")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(home)
+
+	for _, socket := range []string{
+		filepath.Join(home, ".colima", "work", "docker.sock"),
+		filepath.Join(home, ".colima", "default", "docker.sock"),
+		filepath.Join(home, ".lima", "docker", "sock", "docker.sock"),
+		filepath.Join(home, "run", "podman", "podman.sock"),
+	} {
+		defer listenSocket(t, socket)()
+	}
+	// not a socket
+	if err := ioutil.WriteFile(filepath.Join(home, ".colima", "stale.sock"), nil, 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	env := map[string]string{
+		"DOCKER_HOST":     "tcp://build-host:2375",
+		"XDG_RUNTIME_DIR": filepath.Join(home, "run"),
+	}
+	candidates := engineCandidates(func(key string) string { return env[key] }, home, "linux")
+
+	var names []string
+	for _, candidate := range candidates {
+		// the host may have a docker daemon of its own
+		if candidate.Host != "unix:///var/run/docker.sock" && candidate.Host != "unix:///run/podman/podman.sock" {
+			names = append(names, candidate.Name)
+		}
+	}
+	expected := []string{"DOCKER_HOST", "colima (default)", "colima (work)", "lima (docker)", "rootless podman", "podman CLI"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected candidates %v, got %v", expected, names)
+	}
+	for idx := range expected {
+		if names[idx] != expected[idx] {
+			t.Errorf("candidate %d: expected %q, got %q", idx, expected[idx], names[idx])
+		}
+	}
+}
+
+func TestDiscoverEngine(t *testing.T) {
+	home, err := ioutil.TempDir("", "dive-engine")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(home)
+
+	colima := filepath.Join(home, ".colima", "default", "docker.sock")
+	defer listenSocket(t, colima)()
+
+	t.Setenv("HOME", home)
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("CONTAINER_HOST", "")
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(home, "run"))
+
+	// only the colima socket is reachable
+	defer func(probe func(context.Context, string) error) { probeEngine = probe }(probeEngine)
+	probeEngine = func(ctx context.Context, host string) error {
+		if host != "unix://"+colima {
+			return os.ErrNotExist
+		}
+		return pingEngineHost(ctx, host)
+	}
+
+	endpoint, err := DiscoverEngine(context.Background(), EngineAuto, image.NetworkPolicy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if endpoint.Name != "colima (default)" || endpoint.Source() != SourceDockerEngine {
+		t.Errorf("unexpected endpoint: %+v", endpoint)
+	}
+
+	endpoint, err = DiscoverEngine(context.Background(), "unix:///custom.sock", image.NetworkPolicy{})
+	if err != nil || endpoint.Host != "unix:///custom.sock" {
+		t.Errorf("expected the given address to be used as is, got %+v (%v)", endpoint, err)
+	}
+
+	if _, err := DiscoverEngine(context.Background(), "containerd", image.NetworkPolicy{}); err == nil {
+		t.Errorf("expected an error for an unknown engine")
+	}
+}
+
+func TestDescribeEngineError(t *testing.T) {
+	docker := EngineEndpoint{Name: "docker (default socket)", Engine: "docker", Host: "unix:///var/run/docker.sock"}
+	denied := &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.EACCES)}
+
+	if err := describeEngineError(docker, denied); !strings.Contains(err.Error(), "docker group") {
+		t.Errorf("expected the permission error to be explained, got %v", err)
+	}
+	podman := EngineEndpoint{Name: "podman", Engine: "podman", Host: "unix:///run/podman/podman.sock"}
+	if err := describeEngineError(podman, denied); !strings.Contains(err.Error(), "rootless podman socket") {
+		t.Errorf("expected the permission error to be explained, got %v", err)
+	}
+	if err := describeEngineError(docker, os.ErrNotExist); err != os.ErrNotExist {
+		t.Errorf("expected other errors to be kept as is, got %v", err)
+	}
+}
+
+func TestRemoteEngineHost(t *testing.T) {
+	for host, expected := range map[string]bool{
+		"unix:///var/run/docker.sock":  false,
+		"npipe:////./pipe/docker":      false,
+		"tcp://127.0.0.1:2375":         false,
+		"tcp://localhost:2375":         false,
+		"tcp://[::1]:2375":             false,
+		"tcp://10.0.0.5:2376":          true,
+		"tcp://build-host:2376":        true,
+		"ssh://user@build-host":        true,
+		"ssh://user@127.0.0.1:22/path": false,
+	} {
+		if actual := remoteEngineHost(host); actual != expected {
+			t.Errorf("%s: expected remote %v, got %v", host, expected, actual)
+		}
+	}
+}
+
+func TestDiscoverEngineOffline(t *testing.T) {
+	offline := image.NetworkPolicy{Offline: true}
+	if _, err := DiscoverEngine(context.Background(), "tcp://build-host:2376", offline); !errors.Is(err, image.ErrOffline) {
+		t.Errorf("expected a remote engine to be refused offline, got %v", err)
+	}
+	if _, err := DiscoverEngine(context.Background(), "unix:///custom.sock", offline); err != nil {
+		t.Errorf("expected a local engine to be used offline, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package filetree
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// the PAX record holding the file capabilities (the "security.capability" xattr) within a layer tarball
+const capabilityRecord = "SCHILY.xattr.security.capability"
+
+// the revisions of the vfs_cap_data structure (the upper byte of its first field)
+const (
+	capabilityRevisionMask = 0xFF000000
+	capabilityRevision1    = 0x01000000
+	capabilityRevision2    = 0x02000000
+	capabilityRevision3    = 0x03000000
+)
+
+// the linux capabilities, by bit number
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner", "cap_fsetid", "cap_kill", "cap_setgid",
+	"cap_setuid", "cap_setpcap", "cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast", "cap_net_admin",
+	"cap_net_raw", "cap_ipc_lock", "cap_ipc_owner", "cap_sys_module", "cap_sys_rawio", "cap_sys_chroot",
+	"cap_sys_ptrace", "cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice", "cap_sys_resource",
+	"cap_sys_time", "cap_sys_tty_config", "cap_mknod", "cap_lease", "cap_audit_write", "cap_audit_control",
+	"cap_setfcap", "cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm", "cap_block_suspend",
+	"cap_audit_read", "cap_perfmon", "cap_bpf", "cap_checkpoint_restore",
+}
+
+// parseCapabilities decodes the "security.capability" xattr into the names of the capabilities the file is granted
+// (permitted or inheritable), e.g. "cap_net_bind_service". Capabilities unknown to dive are named by number.
+func parseCapabilities(raw []byte) ([]string, error) {
+	if len(raw) < 4 {
+		return nil, fmt.Errorf("capability xattr is too short (%d bytes)", len(raw))
+	}
+	magic := binary.LittleEndian.Uint32(raw)
+
+	words := 0
+	switch magic & capabilityRevisionMask {
+	case capabilityRevision1:
+		words = 1
+	case capabilityRevision2, capabilityRevision3:
+		words = 2
+	default:
+		return nil, fmt.Errorf("unknown capability xattr revision 0x%08x", magic&capabilityRevisionMask)
+	}
+	if len(raw) < 4+words*8 {
+		return nil, fmt.Errorf("capability xattr is too short (%d bytes)", len(raw))
+	}
+
+	var granted uint64
+	for word := 0; word < words; word++ {
+		permitted := binary.LittleEndian.Uint32(raw[4+word*8:])
+		inheritable := binary.LittleEndian.Uint32(raw[8+word*8:])
+		granted |= uint64(permitted|inheritable) << (32 * uint(word))
+	}
+
+	var names []string
+	for bit := 0; bit < 64; bit++ {
+		if granted&(1<<uint(bit)) == 0 {
+			continue
+		}
+		if bit < len(capabilityNames) {
+			names = append(names, capabilityNames[bit])
+		} else {
+			names = append(names, fmt.Sprintf("cap_%d", bit))
+		}
+	}
+	return names, nil
+}
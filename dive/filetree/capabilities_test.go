@@ -0,0 +1,61 @@
+package filetree
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// capabilityXattr encodes a vfs_cap_data structure with the given permitted and inheritable words.
+func capabilityXattr(magic uint32, words ...uint32) string {
+	raw := make([]byte, 4+4*len(words))
+	binary.LittleEndian.PutUint32(raw, magic)
+	for idx, word := range words {
+		binary.LittleEndian.PutUint32(raw[4+4*idx:], word)
+	}
+	return string(raw)
+}
+
+func TestParseCapabilities(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		expected []string
+		err      bool
+	}{
+		{name: "revision 2", raw: capabilityXattr(0x02000001, 1<<10|1<<13, 0, 1<<7, 0), expected: []string{"cap_net_bind_service", "cap_net_raw", "cap_bpf"}},
+		{name: "revision 3", raw: capabilityXattr(0x03000000, 0, 1<<21, 0, 0, 0), expected: []string{"cap_sys_admin"}},
+		{name: "revision 1", raw: capabilityXattr(0x01000000, 1<<0, 0), expected: []string{"cap_chown"}},
+		{name: "unknown capability", raw: capabilityXattr(0x02000000, 0, 0, 1<<30, 0), expected: []string{"cap_62"}},
+		{name: "unknown revision", raw: capabilityXattr(0x04000000, 0, 0), err: true},
+		{name: "truncated", raw: capabilityXattr(0x02000000, 1), err: true},
+	}
+
+	for _, test := range cases {
+		actual, err := parseCapabilities([]byte(test.raw))
+		if test.err != (err != nil) {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestNewFileInfoFromTarHeaderCapabilities(t *testing.T) {
+	info := readTarFile(t, &tar.Header{
+		Name:       "usr/bin/ping",
+		Typeflag:   tar.TypeReg,
+		Mode:       0755,
+		PAXRecords: map[string]string{capabilityRecord: capabilityXattr(0x02000001, 1<<13, 0, 0, 0)},
+	}, "ping")
+
+	if !reflect.DeepEqual(info.Capabilities, []string{"cap_net_raw"}) {
+		t.Errorf("expected the capabilities to be read, got %v", info.Capabilities)
+	}
+	if copied := info.Copy(); !reflect.DeepEqual(copied.Capabilities, info.Capabilities) {
+		t.Errorf("expected the capabilities to be copied, got %v", copied.Capabilities)
+	}
+}
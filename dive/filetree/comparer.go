@@ -2,7 +2,12 @@ package filetree
 
 import (
 	"fmt"
+	"sync"
+	"time"
+
 	"github.com/sirupsen/logrus"
+
+	"github.com/wagoodman/dive/dive/metrics"
 )
 
 type TreeIndexKey struct {
@@ -29,21 +34,99 @@ func (index TreeIndexKey) String() string {
 	return fmt.Sprintf("Index(%d-%d:%d-%d)", index.bottomTreeStart, index.bottomTreeStop, index.topTreeStart, index.topTreeStop)
 }
 
+// TreeLoader parses the file tree of the layer at the given index. Along with the tree it may return what else is to be
+// updated once the layer is loaded (nil when nothing is), which is deferred until the owner of the comparer calls
+// ApplyLoaded: the comparer may load layers on another goroutine than the one its owner reads the layers on.
+type TreeLoader func(index int) (*FileTree, func(), error)
+
+// Comparer stacks and compares the layer trees of an image, caching the results. It may be used from several goroutines
+// (copies of a comparer share the same cache and lock).
 type Comparer struct {
+	lock       *sync.Mutex
 	refTrees   []*FileTree
+	loader     TreeLoader
 	trees      map[TreeIndexKey]*FileTree
 	pathErrors map[TreeIndexKey][]PathError
+	// the updates of the layers loaded and not applied yet (see TreeLoader)
+	loaded *loadedUpdates
+	// times the comparisons (nil when they are not timed)
+	recorder *metrics.Recorder
+}
+
+// loadedUpdates queues the updates of the loaded layers, with a lock of its own so that they can be applied while the
+// comparer is busy.
+type loadedUpdates struct {
+	lock    sync.Mutex
+	updates []func()
 }
 
 func NewComparer(refTrees []*FileTree) Comparer {
 	return Comparer{
+		lock:       &sync.Mutex{},
 		refTrees:   refTrees,
 		trees:      make(map[TreeIndexKey]*FileTree),
 		pathErrors: make(map[TreeIndexKey][]PathError),
+		loaded:     &loadedUpdates{},
+	}
+}
+
+// NewLazyComparer creates a comparer where nil reference trees are parsed with the given loader the first time a
+// comparison needs them (the loaded trees are stored back into refTrees).
+func NewLazyComparer(refTrees []*FileTree, loader TreeLoader) Comparer {
+	cmp := NewComparer(refTrees)
+	cmp.loader = loader
+	return cmp
+}
+
+// SetRecorder times the comparisons with the given recorder.
+func (cmp *Comparer) SetRecorder(recorder *metrics.Recorder) {
+	cmp.recorder = recorder
+}
+
+// load ensures all reference trees up to (and including) the given index have been parsed.
+func (cmp *Comparer) load(stop int) error {
+	if stop >= len(cmp.refTrees) {
+		return fmt.Errorf("invalid layer index given: %d of %d", stop, len(cmp.refTrees)-1)
+	}
+	for idx := 0; idx <= stop; idx++ {
+		if cmp.refTrees[idx] != nil {
+			continue
+		}
+		if cmp.loader == nil {
+			return fmt.Errorf("layer %d has not been loaded", idx)
+		}
+		tree, update, err := cmp.loader(idx)
+		if err != nil {
+			return fmt.Errorf("unable to load layer %d: %w", idx, err)
+		}
+		cmp.refTrees[idx] = tree
+		if update != nil {
+			cmp.loaded.lock.Lock()
+			cmp.loaded.updates = append(cmp.loaded.updates, update)
+			cmp.loaded.lock.Unlock()
+		}
+	}
+	return nil
+}
+
+// ApplyLoaded applies the updates of the layers loaded since it was last called (see TreeLoader) on the goroutine of
+// the caller, reporting whether there were any.
+func (cmp *Comparer) ApplyLoaded() bool {
+	cmp.loaded.lock.Lock()
+	updates := cmp.loaded.updates
+	cmp.loaded.updates = nil
+	cmp.loaded.lock.Unlock()
+
+	for _, update := range updates {
+		update()
 	}
+	return len(updates) > 0
 }
 
 func (cmp *Comparer) GetPathErrors(key TreeIndexKey) ([]PathError, error) {
+	cmp.lock.Lock()
+	defer cmp.lock.Unlock()
+
 	_, pathErrors, err := cmp.get(key)
 	if err != nil {
 		return nil, err
@@ -51,9 +134,20 @@ func (cmp *Comparer) GetPathErrors(key TreeIndexKey) ([]PathError, error) {
 	return pathErrors, nil
 }
 
+// Cached reports whether the tree of the given comparison has been computed already (so GetTree returns right away).
+func (cmp *Comparer) Cached(key TreeIndexKey) bool {
+	cmp.lock.Lock()
+	defer cmp.lock.Unlock()
+
+	_, exists := cmp.trees[key]
+	return exists
+}
+
 func (cmp *Comparer) GetTree(key TreeIndexKey) (*FileTree, error) {
 	//func (cmp *Comparer) GetTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) (*FileTree, []PathError, error) {
 	//key := TreeIndexKey{bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop}
+	cmp.lock.Lock()
+	defer cmp.lock.Unlock()
 
 	if value, exists := cmp.trees[key]; exists {
 		return value, nil
@@ -69,6 +163,15 @@ func (cmp *Comparer) GetTree(key TreeIndexKey) (*FileTree, error) {
 }
 
 func (cmp *Comparer) get(key TreeIndexKey) (*FileTree, []PathError, error) {
+	stop := key.bottomTreeStop
+	if key.topTreeStop > stop {
+		stop = key.topTreeStop
+	}
+	if err := cmp.load(stop); err != nil {
+		return nil, nil, err
+	}
+	defer cmp.recorder.Since("stack trees", key.String(), time.Now())
+
 	newTree, pathErrors, err := StackTreeRange(cmp.refTrees, key.bottomTreeStart, key.bottomTreeStop)
 	if err != nil {
 		return nil, nil, err
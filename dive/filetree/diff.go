@@ -30,6 +30,19 @@ func (diff DiffType) String() string {
 	}
 }
 
+// Marker is the symbol that tells the DiffType apart without relying on color (as in a unified diff).
+func (diff DiffType) Marker() string {
+	switch diff {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	case Modified:
+		return "~"
+	}
+	return " "
+}
+
 // merge two DiffTypes into a single result. Essentially, return the given value unless they two values differ,
 // in which case we can only determine that there is "a change".
 func (diff DiffType) merge(other DiffType) DiffType {
@@ -0,0 +1,160 @@
+package filetree
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// TruncateMiddle keeps the start and the end of a long name (e.g. its extension), eliding the middle.
+	TruncateMiddle TruncateMode = "middle"
+	// TruncateEnd keeps the start of a long name, eliding the end.
+	TruncateEnd TruncateMode = "end"
+	// TruncateNone leaves long names as they are (they run past the edge of the pane, or wrap).
+	TruncateNone TruncateMode = "none"
+)
+
+// TruncateMode is how names that do not fit the width they are given are shortened.
+type TruncateMode string
+
+// TruncateModes are the valid values of the filetree.truncate config.
+var TruncateModes = []string{string(TruncateMiddle), string(TruncateEnd), string(TruncateNone)}
+
+// isBidiControl reports the invisible characters that reorder the text around them (as with a "Trojan Source" file
+// name), which would scramble the columns of the whole line.
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061c', r == '\u200e', r == '\u200f':
+		return true
+	case r >= '\u202a' && r <= '\u202e':
+		return true
+	case r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// DisplayName makes a name (or a path) safe to show on a single terminal row: control characters, bidi controls and
+// invalid UTF-8 are escaped (as \n, \u202e or \xff), and the combining marks are composed with the character they
+// follow where possible, or dropped otherwise, since the UI draws every character in a cell of its own.
+func DisplayName(name string) string {
+	plain := true
+	for idx := 0; idx < len(name); idx++ {
+		if name[idx] < 0x20 || name[idx] >= 0x7f {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+
+	name = norm.NFC.String(name)
+	var sb strings.Builder
+	for len(name) > 0 {
+		r, size := utf8.DecodeRuneInString(name)
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&sb, `\x%02x`, name[0])
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x80 && unicode.IsControl(r):
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		case unicode.IsControl(r) || isBidiControl(r):
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		case runewidth.RuneWidth(r) == 0 && (unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)):
+			// a mark left over after composing, or a joiner: it would take the place of the character before it
+		default:
+			sb.WriteRune(r)
+		}
+		name = name[size:]
+	}
+	return sb.String()
+}
+
+// DisplayWidth is the number of terminal cells the text takes, as the UI lays it out (wide CJK characters and emoji
+// take two cells). Escape sequences (colors) take none.
+func DisplayWidth(text string) int {
+	width := 0
+	for idx := 0; idx < len(text); {
+		if text[idx] == '\x1b' {
+			idx = skipEscape(text, idx)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[idx:])
+		width += runewidth.RuneWidth(r)
+		idx += size
+	}
+	return width
+}
+
+// skipEscape returns the index just past the CSI sequence (e.g. a color) starting at the given index.
+func skipEscape(text string, idx int) int {
+	idx++
+	if idx < len(text) && text[idx] == '[' {
+		idx++
+		for idx < len(text) && (text[idx] < 0x40 || text[idx] > 0x7e) {
+			idx++
+		}
+	}
+	return idx + 1
+}
+
+// PadRight fills the text with spaces up to the given width (in terminal cells).
+func PadRight(text string, width int) string {
+	if pad := width - DisplayWidth(text); pad > 0 {
+		return text + strings.Repeat(" ", pad)
+	}
+	return text
+}
+
+func truncate(text string, width int, mode TruncateMode, ellipsis string) string {
+	if width <= 0 || mode == TruncateNone || DisplayWidth(text) <= width {
+		return text
+	}
+	if DisplayWidth(ellipsis) >= width {
+		return takeWidth([]rune(text), width)
+	}
+	room := width - DisplayWidth(ellipsis)
+
+	runes := []rune(text)
+	if mode == TruncateEnd {
+		return takeWidth(runes, room) + ellipsis
+	}
+
+	// the start gets the odd cell
+	tail := takeWidthFromEnd(runes, room/2)
+	head := takeWidth(runes, room-DisplayWidth(tail))
+	return head + ellipsis + tail
+}
+
+// takeWidth returns the leading runes that fit within the given width.
+func takeWidth(runes []rune, width int) string {
+	used := 0
+	for idx, r := range runes {
+		if used+runewidth.RuneWidth(r) > width {
+			return string(runes[:idx])
+		}
+		used += runewidth.RuneWidth(r)
+	}
+	return string(runes)
+}
+
+// takeWidthFromEnd returns the trailing runes that fit within the given width.
+func takeWidthFromEnd(runes []rune, width int) string {
+	used := 0
+	for idx := len(runes) - 1; idx >= 0; idx-- {
+		if used+runewidth.RuneWidth(runes[idx]) > width {
+			return string(runes[idx+1:])
+		}
+		used += runewidth.RuneWidth(runes[idx])
+	}
+	return string(runes)
+}
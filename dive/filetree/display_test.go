@@ -0,0 +1,102 @@
+package filetree
+
+import "testing"
+
+func TestDisplayName(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "plain.txt", expected: "plain.txt"},
+		{name: "line\nbreak", expected: `line\nbreak`},
+		{name: "esc\x1b[31m", expected: `esc\x1b[31m`},
+		{name: "invalid\xff", expected: `invalid\xff`},
+		{name: "txt.\u202eexe", expected: `txt.\u202eexe`},
+		{name: "cafe\u0301", expected: "caf\u00e9"},
+		{name: "日本語.txt", expected: "日本語.txt"},
+	}
+	for _, c := range cases {
+		if actual := DisplayName(c.name); actual != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected int
+	}{
+		{text: "abc", expected: 3},
+		{text: "日本語", expected: 6},
+		{text: "\x1b[32mabc\x1b[0m", expected: 3},
+	}
+	for _, c := range cases {
+		if actual := DisplayWidth(c.text); actual != c.expected {
+			t.Errorf("%q: expected %d, got %d", c.text, c.expected, actual)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		text     string
+		width    int
+		mode     TruncateMode
+		expected string
+	}{
+		{text: "short.txt", width: 20, mode: TruncateMiddle, expected: "short.txt"},
+		{text: "a-very-long-name.tar.gz", width: 12, mode: TruncateMiddle, expected: "a-very…ar.gz"},
+		{text: "a-very-long-name.tar.gz", width: 12, mode: TruncateEnd, expected: "a-very-long…"},
+		{text: "a-very-long-name.tar.gz", width: 12, mode: TruncateNone, expected: "a-very-long-name.tar.gz"},
+		{text: "a-very-long-name.tar.gz", width: 0, mode: TruncateMiddle, expected: "a-very-long-name.tar.gz"},
+		// wide characters are never split, so the result may be narrower than the width
+		{text: "日本語のファイル名", width: 10, mode: TruncateMiddle, expected: "日本…ル名"},
+		{text: "日本語のファイル名", width: 10, mode: TruncateEnd, expected: "日本語の…"},
+	}
+	for _, c := range cases {
+		actual := truncate(c.text, c.width, c.mode, UnicodeGlyphs.Ellipsis)
+		if actual != c.expected {
+			t.Errorf("%q (width %d, %s): expected %q, got %q", c.text, c.width, c.mode, c.expected, actual)
+		}
+		if c.width > 0 && c.mode != TruncateNone && DisplayWidth(actual) > c.width {
+			t.Errorf("%q (width %d, %s): %q is wider than the width", c.text, c.width, c.mode, actual)
+		}
+	}
+}
+
+func TestTruncateLink(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		width    int
+		expected string
+	}{
+		{name: "sh", target: "/bin/busybox", width: 40, expected: "sh → /bin/busybox"},
+		{name: "sh", target: "/bin/busybox", width: 0, expected: "sh → /bin/busybox"},
+		// the target is shortened, the arrow is kept as a whole
+		{name: "add-shell", target: "/usr/local/bin/busybox", width: 20, expected: "add-shell → /usr…box"},
+		// no room for the arrow and some of the target: the target is left out
+		{name: "add-shell", target: "/bin/busybox", width: 13, expected: "add-shell"},
+		{name: "a-very-long-name", target: "/bin/busybox", width: 12, expected: "a-very…-name"},
+	}
+	options := DefaultRenderOptions()
+	for _, c := range cases {
+		actual := options.truncateLink(c.name, c.target, c.width)
+		if actual != c.expected {
+			t.Errorf("%q -> %q (width %d): expected %q, got %q", c.name, c.target, c.width, c.expected, actual)
+		}
+		if c.width > 0 && DisplayWidth(actual) > c.width {
+			t.Errorf("%q -> %q (width %d): %q is wider than the width", c.name, c.target, c.width, actual)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	if actual := PadRight("日本", 6); actual != "日本  " {
+		t.Errorf("expected the padding to count the wide characters as two cells, got %q", actual)
+	}
+	if actual := PadRight("toolong", 4); actual != "toolong" {
+		t.Errorf("expected a wider text to be left as it is, got %q", actual)
+	}
+}
@@ -36,12 +36,17 @@ func (efs EfficiencySlice) Less(i, j int) bool {
 // 1. Files that are duplicated across layers discounts your score, weighted by file size
 // 2. Files that are removed discounts your score, weighted by the original file size
 func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
+	return EfficiencyIgnoring(trees, nil)
+}
+
+// EfficiencyIgnoring returns the score and file set as Efficiency does, leaving out the paths the ignore list matches
+// (e.g. content shipped on purpose that would otherwise be flagged on every build).
+func EfficiencyIgnoring(trees []*FileTree, ignore *IgnoreList) (float64, EfficiencySlice) {
 	efficiencyMap := make(map[string]*EfficiencyData)
 	inefficientMatches := make(EfficiencySlice, 0)
 	currentTree := 0
 
-	visitor := func(node *FileNode) error {
-		path := node.Path()
+	record := func(path string, node *FileNode, sizeBytes int64) *EfficiencyData {
 		if _, ok := efficiencyMap[path]; !ok {
 			efficiencyMap[path] = &EfficiencyData{
 				Path:              path,
@@ -51,59 +56,99 @@ func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
 		}
 		data := efficiencyMap[path]
 
-		// this node may have had children that were deleted, however, we won't explicitly list out every child, only
-		// the top-most parent with the cumulative size. These operations will need to be done on the full (stacked)
-		// tree.
-		// Note: whiteout files may also represent directories, so we need to find out if this was previously a file or dir.
-		var sizeBytes int64
+		data.CumulativeSize += sizeBytes
+		if data.minDiscoveredSize < 0 || sizeBytes < data.minDiscoveredSize {
+			data.minDiscoveredSize = sizeBytes
+		}
+		data.Nodes = append(data.Nodes, node)
 
-		if node.IsWhiteout() {
-			sizer := func(curNode *FileNode) error {
-				sizeBytes += curNode.Data.FileInfo.Size
-				return nil
-			}
-			stackedTree, failedPaths, err := StackTreeRange(trees, 0, currentTree-1)
-			if len(failedPaths) > 0 {
-				for _, path := range failedPaths {
-					logrus.Errorf(path.String())
-				}
-			}
-			if err != nil {
-				logrus.Errorf("unable to stack tree range: %+v", err)
-				return err
-			}
+		if len(data.Nodes) == 2 {
+			inefficientMatches = append(inefficientMatches, data)
+		}
+		return data
+	}
 
-			previousTreeNode, err := stackedTree.GetNode(node.Path())
-			if err != nil {
-				return err
+	// everything hidden beneath a deleted or replaced directory is wasted (just as the full size of a deleted file is),
+	// so the directory is listed along with the node it hides
+	recordHidden := func(path string, previousNode, node *FileNode, sizeBytes int64) {
+		if data, ok := efficiencyMap[path]; !ok || len(data.Nodes) == 0 {
+			record(path, previousNode, 0)
+		}
+		record(path, node, sizeBytes).minDiscoveredSize = 0
+	}
+
+	// a file listed twice already counts every copy of it, including the one a whiteout hides later on
+	counted := func(path string) bool {
+		data, ok := efficiencyMap[path]
+		return ok && len(data.Nodes) > 1
+	}
+
+	stackPrevious := func() (*FileTree, error) {
+		stackedTree, failedPaths, err := StackTreeRange(trees, 0, currentTree-1)
+		if len(failedPaths) > 0 {
+			for _, path := range failedPaths {
+				logrus.Errorf(path.String())
 			}
+		}
+		if err != nil {
+			logrus.Errorf("unable to stack tree range: %+v", err)
+		}
+		return stackedTree, err
+	}
 
-			if previousTreeNode.Data.FileInfo.IsDir {
-				err = previousTreeNode.VisitDepthChildFirst(sizer, nil)
-				if err != nil {
-					logrus.Errorf("unable to propagate whiteout dir: %+v", err)
-					return err
+	visitor := func(node *FileNode) error {
+		path := node.Path()
+		if ignore.Ignored(path, node.Data.FileInfo.IsDir) {
+			return nil
+		}
+
+		// the files a bounded analysis folded into a summary directory count as the files themselves
+		if folded := node.Data.FileInfo.Folded; len(folded) > 0 {
+			for _, file := range folded {
+				if !ignore.Ignored(file.Path, false) {
+					record(file.Path, node, file.Size)
 				}
 			}
+			return nil
+		}
 
-		} else {
-			sizeBytes = node.Data.FileInfo.Size
+		// this node may have had children that were deleted, however, we won't explicitly list out every child, only
+		// the top-most parent with the cumulative size. These operations will need to be done on the full (stacked)
+		// tree.
+		// Note: whiteout files may also represent directories, so we need to find out if this was previously a file or dir.
+		if !node.IsWhiteout() {
+			record(path, node, node.Data.FileInfo.Size)
+			return nil
 		}
 
-		data.CumulativeSize += sizeBytes
-		if data.minDiscoveredSize < 0 || sizeBytes < data.minDiscoveredSize {
-			data.minDiscoveredSize = sizeBytes
+		stackedTree, err := stackPrevious()
+		if err != nil {
+			return err
 		}
-		data.Nodes = append(data.Nodes, node)
 
-		if len(data.Nodes) == 2 {
-			inefficientMatches = append(inefficientMatches, data)
+		previousTreeNode, err := stackedTree.GetNode(path)
+		if err != nil {
+			summary := stackedTree.FoldedAncestor(path)
+			if summary == nil {
+				return err
+			}
+			if sizeBytes, isDir := summary.FoldedSize(path, counted); isDir {
+				recordHidden(path, summary, node, sizeBytes)
+			} else {
+				record(path, node, 0)
+			}
+			return nil
 		}
 
+		if previousTreeNode.Data.FileInfo.IsDir {
+			recordHidden(path, previousTreeNode, node, hiddenSize(previousTreeNode, nil, counted))
+		} else {
+			record(path, node, 0)
+		}
 		return nil
 	}
 	visitEvaluator := func(node *FileNode) bool {
-		return node.IsLeaf()
+		return node.IsLeaf() || len(node.Data.FileInfo.Folded) > 0
 	}
 	for idx, tree := range trees {
 		currentTree = idx
@@ -111,6 +156,33 @@ func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
 		if err != nil {
 			logrus.Errorf("unable to propagate ref tree: %+v", err)
 		}
+		if idx == 0 {
+			continue
+		}
+
+		// opaque directories hide whatever the lower layers had beneath them, less what this layer adds back
+		var stackedTree *FileTree
+		err = tree.VisitDepthParentFirst(func(node *FileNode) error {
+			if node.Data.Whiteout != WhiteoutOpaque || ignore.Ignored(node.Path(), true) {
+				return nil
+			}
+			if stackedTree == nil {
+				if stackedTree, err = stackPrevious(); err != nil {
+					return err
+				}
+			}
+			previousTreeNode, err := stackedTree.GetNode(node.Path())
+			if err != nil {
+				return nil
+			}
+			if sizeBytes := hiddenSize(previousTreeNode, node, counted); sizeBytes > 0 {
+				recordHidden(node.Path(), previousTreeNode, node, sizeBytes)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to propagate opaque directories: %+v", err)
+		}
 	}
 
 	// calculate the score
@@ -1,6 +1,7 @@
 package filetree
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -56,6 +57,23 @@ func TestEfficency(t *testing.T) {
 	}
 }
 
+func TestEfficiency_HiddenDirectory(t *testing.T) {
+	// the nginx config is listed twice already, so the deleted directory only adds the files hidden along with it
+	_, actualMatches := Efficiency(buildFoldTestTrees(t, 0))
+	expectedMatches := EfficiencySlice{
+		&EfficiencyData{Path: "/etc/nginx", CumulativeSize: 3500},
+		&EfficiencyData{Path: "/etc/nginx/nginx.conf", CumulativeSize: 7000},
+	}
+	if len(actualMatches) != len(expectedMatches) {
+		t.Fatalf("Expected to find %d inefficient paths, but found %d", len(expectedMatches), len(actualMatches))
+	}
+	for idx, expected := range expectedMatches {
+		if actual := actualMatches[idx]; expected.Path != actual.Path || expected.CumulativeSize != actual.CumulativeSize {
+			t.Errorf("Expected %s (%d) but got %s (%d)", expected.Path, expected.CumulativeSize, actual.Path, actual.CumulativeSize)
+		}
+	}
+}
+
 func TestEfficency_ScratchImage(t *testing.T) {
 	trees := make([]*FileTree, 3)
 	for idx := range trees {
@@ -78,3 +96,31 @@ func TestEfficency_ScratchImage(t *testing.T) {
 	}
 
 }
+
+func TestEfficiencyIgnoring(t *testing.T) {
+	trees := make([]*FileTree, 2)
+	for idx := range trees {
+		trees[idx] = NewFileTree()
+	}
+
+	_, _, err := trees[0].AddPath("/usr/share/zoneinfo/UTC", FileInfo{Size: 2000})
+	checkError(t, err, "could not setup test")
+	_, _, err = trees[0].AddPath("/etc/athing", FileInfo{Size: 1000})
+	checkError(t, err, "could not setup test")
+	_, _, err = trees[1].AddPath("/usr/share/zoneinfo/UTC", FileInfo{Size: 2000})
+	checkError(t, err, "could not setup test")
+
+	ignore, err := ParseIgnoreList(strings.NewReader("/usr/share/zoneinfo\n"))
+	checkError(t, err, "could not parse the ignore list")
+
+	if score, matches := Efficiency(trees); score == 1 || len(matches) != 1 {
+		t.Fatalf("expected the duplicated file to be flagged, got score %v and %d matches", score, len(matches))
+	}
+	score, matches := EfficiencyIgnoring(trees, ignore)
+	if score != 1 {
+		t.Errorf("expected a score of 1, got %v", score)
+	}
+	if len(matches) != 0 {
+		t.Errorf("expected no inefficient paths, got %d", len(matches))
+	}
+}
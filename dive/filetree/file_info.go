@@ -6,6 +6,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"os"
+	"time"
 )
 
 // FileInfo contains tar metadata for a specific FileNode
@@ -19,13 +20,61 @@ type FileInfo struct {
 	Uid      int
 	Gid      int
 	IsDir    bool
+	// what the content inspectors found out about the file (see Inspector)
+	Inspections []Inspection
+	// the capabilities granted to the file (e.g. "cap_net_bind_service")
+	Capabilities []string
+	// the extended attributes of the file by name (e.g. "security.capability", "user.mime_type"), with raw values
+	Xattrs map[string]string
+	// the PAX records of the tar header that are not represented by the fields above (e.g. ACLs), by key
+	PAXRecords map[string]string
+	// the device numbers of character and block devices
+	Devmajor int64
+	Devminor int64
+	// sparse files store only their data regions; Size is their logical size and StoredSize the size of the data
+	// regions (the space the file takes on disk, which the layer parser measures while reading the contents)
+	Sparse     bool
+	StoredSize int64
+	// the modification time recorded for the file (zero when unknown)
+	ModTime time.Time
+	// the files a bounded analysis folded into this directory instead of adding them to the tree (see FoldEntries),
+	// whose sizes the directory carries
+	Folded []FoldedFile
 }
 
 // NewFileInfoFromTarHeader extracts the metadata from a tar header and file contents and generates a new FileInfo object.
-func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path string) FileInfo {
+// The given inspectors that accept the file read its contents as well.
+func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path string, inspectors []Inspector) (FileInfo, error) {
 	var hash uint64
-	if header.Typeflag != tar.TypeDir {
-		hash = getHashFromReader(reader)
+	var inspections []Inspection
+	if accepting := acceptingInspectors(header, inspectors); len(accepting) > 0 {
+		var err error
+		hash, inspections, err = inspect(reader, path, accepting)
+		if err != nil {
+			return FileInfo{}, err
+		}
+	} else if header.Typeflag != tar.TypeDir {
+		var err error
+		hash, err = getHashFromReader(reader)
+		if err != nil {
+			return FileInfo{}, err
+		}
+	}
+
+	var capabilities []string
+	if raw, ok := header.PAXRecords[capabilityRecord]; ok {
+		var err error
+		capabilities, err = parseCapabilities([]byte(raw))
+		if err != nil {
+			logrus.Debugf("unable to read the capabilities of %s: %+v", path, err)
+		}
+	}
+	xattrs, records := splitPAXRecords(header.PAXRecords)
+
+	size := header.FileInfo().Size()
+	if header.Typeflag == tar.TypeLink {
+		// the contents (and size) of a hardlink belong to its target, count them only once
+		size = 0
 	}
 
 	return FileInfo{
@@ -33,12 +82,21 @@ func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path strin
 		TypeFlag: header.Typeflag,
 		Linkname: header.Linkname,
 		hash:     hash,
-		Size:     header.FileInfo().Size(),
+		Size:     size,
 		Mode:     header.FileInfo().Mode(),
 		Uid:      header.Uid,
 		Gid:      header.Gid,
 		IsDir:    header.FileInfo().IsDir(),
-	}
+
+		Inspections:  inspections,
+		Capabilities: capabilities,
+		Xattrs:       xattrs,
+		PAXRecords:   records,
+		Devmajor:     header.Devmajor,
+		Devminor:     header.Devminor,
+		Sparse:       IsSparse(header),
+		ModTime:      header.ModTime,
+	}, nil
 }
 
 func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
@@ -72,7 +130,10 @@ func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
 			logrus.Panic("unable to read file:", realPath)
 		}
 		defer file.Close()
-		hash = getHashFromReader(file)
+		hash, err = getHashFromReader(file)
+		if err != nil {
+			logrus.Panic("unable to hash file:", realPath, err)
+		}
 	}
 
 	return FileInfo{
@@ -83,9 +144,10 @@ func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
 		Size:     size,
 		Mode:     info.Mode(),
 		// todo: support UID/GID
-		Uid:   -1,
-		Gid:   -1,
-		IsDir: info.IsDir(),
+		Uid:     -1,
+		Gid:     -1,
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime(),
 	}
 }
 
@@ -104,13 +166,31 @@ func (data *FileInfo) Copy() *FileInfo {
 		Uid:      data.Uid,
 		Gid:      data.Gid,
 		IsDir:    data.IsDir,
+
+		Inspections:  data.Inspections,
+		Capabilities: data.Capabilities,
+		Xattrs:       data.Xattrs,
+		PAXRecords:   data.PAXRecords,
+		Devmajor:     data.Devmajor,
+		Devminor:     data.Devminor,
+		Sparse:       data.Sparse,
+		StoredSize:   data.StoredSize,
+		ModTime:      data.ModTime,
+		Folded:       data.Folded,
 	}
 }
 
+// ContentHash returns the hash of the file contents (zero for directories).
+func (data *FileInfo) ContentHash() uint64 {
+	return data.hash
+}
+
 // Compare determines the DiffType between two FileInfos based on the type and contents of each given FileInfo
 func (data *FileInfo) Compare(other FileInfo) DiffType {
 	if data.TypeFlag == other.TypeFlag {
 		if data.hash == other.hash &&
+			data.Devmajor == other.Devmajor &&
+			data.Devminor == other.Devminor &&
 			data.Mode == other.Mode &&
 			data.Uid == other.Uid &&
 			data.Gid == other.Gid {
@@ -120,14 +200,14 @@ func (data *FileInfo) Compare(other FileInfo) DiffType {
 	return Modified
 }
 
-func getHashFromReader(reader io.Reader) uint64 {
+func getHashFromReader(reader io.Reader) (uint64, error) {
 	h := xxhash.New()
 
 	buf := make([]byte, 1024)
 	for {
 		n, err := reader.Read(buf)
 		if err != nil && err != io.EOF {
-			logrus.Panic(err)
+			return 0, err
 		}
 		if n == 0 {
 			break
@@ -135,9 +215,9 @@ func getHashFromReader(reader io.Reader) uint64 {
 
 		_, err = h.Write(buf[:n])
 		if err != nil {
-			logrus.Panic(err)
+			return 0, err
 		}
 	}
 
-	return h.Sum64()
+	return h.Sum64(), nil
 }
@@ -1,20 +1,20 @@
 package filetree
 
 import (
-	"archive/tar"
 	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/phayes/permbits"
 )
 
 const (
 	AttributeFormat = "%s%s %11s %10s "
+	// FileCountFormat is the column of the number of files within each directory, shown after the attributes
+	FileCountFormat = "%9s "
 )
 
 var diffTypeColor = map[DiffType]*color.Color{
@@ -50,28 +50,42 @@ func NewNode(parent *FileNode, name string, data FileInfo) (node *FileNode) {
 	return node
 }
 
-// renderTreeLine returns a string representing this FileNode in the context of a greater ASCII tree.
-func (node *FileNode) renderTreeLine(spaces []bool, last bool, collapsed bool) string {
+// renderTreeLine returns a string representing this FileNode in the context of a greater ASCII tree. When given a
+// width, the name is shortened (see RenderOptions.Truncate) so the line fits within it.
+func (node *FileNode) renderTreeLine(spaces []bool, last bool, collapsed bool, width int, options RenderOptions) string {
 	var otherBranches string
 	for _, space := range spaces {
 		if space {
-			otherBranches += noBranchSpace
+			otherBranches += options.Glyphs.NoBranchSpace
 		} else {
-			otherBranches += branchSpace
+			otherBranches += options.Glyphs.BranchSpace
 		}
 	}
 
-	thisBranch := middleItem
+	thisBranch := options.Glyphs.MiddleItem
 	if last {
-		thisBranch = lastItem
+		thisBranch = options.Glyphs.LastItem
 	}
 
-	collapsedIndicator := uncollapsedItem
+	collapsedIndicator := options.Glyphs.UncollapsedItem
 	if collapsed {
-		collapsedIndicator = collapsedItem
+		collapsedIndicator = options.Glyphs.CollapsedItem
 	}
 
-	return otherBranches + thisBranch + collapsedIndicator + node.String() + newLine
+	var marker string
+	if options.DiffMarkers {
+		marker = options.diffColor(node.Data.DiffType).Sprint(node.Data.DiffType.Marker()) + " "
+	}
+
+	prefix := marker + otherBranches + thisBranch + collapsedIndicator
+	nameWidth := 0
+	if width > 0 {
+		nameWidth = width - DisplayWidth(prefix)
+		if nameWidth < 1 {
+			nameWidth = 1
+		}
+	}
+	return prefix + node.displayString(nameWidth, options) + newLine
 }
 
 // Copy duplicates the existing node relative to a new parent node.
@@ -79,9 +93,9 @@ func (node *FileNode) Copy(parent *FileNode) *FileNode {
 	newNode := NewNode(parent, node.Name, node.Data.FileInfo)
 	newNode.Data.ViewInfo = node.Data.ViewInfo
 	newNode.Data.DiffType = node.Data.DiffType
+	newNode.Data.Whiteout = node.Data.Whiteout
 	for name, child := range node.Children {
 		newNode.Children[name] = child.Copy(newNode)
-		child.Parent = newNode
 	}
 	return newNode
 }
@@ -123,33 +137,36 @@ func (node *FileNode) Remove() error {
 
 // String shows the filename formatted into the proper color (by DiffType), additionally indicating if it is a symlink.
 func (node *FileNode) String() string {
+	return node.displayString(0, DefaultRenderOptions())
+}
+
+// displayString renders the filename as String does, shortened to the given width (unless 0).
+func (node *FileNode) displayString(width int, options RenderOptions) string {
 	var display string
 	if node == nil {
 		return ""
 	}
 
-	display = node.Name
-	if node.Data.FileInfo.TypeFlag == tar.TypeSymlink || node.Data.FileInfo.TypeFlag == tar.TypeLink {
-		display += " → " + node.Data.FileInfo.Linkname
+	display = DisplayName(node.Name) + node.whiteoutAnnotation()
+	if node.IsLink() {
+		display = options.truncateLink(display, DisplayName(node.Data.FileInfo.Linkname), width)
+	} else {
+		display = options.Truncate(display, width)
 	}
-	return diffTypeColor[node.Data.DiffType].Sprint(display)
+	return options.diffColor(node.Data.DiffType).Sprint(display)
 }
 
 // MetadatString returns the FileNode metadata in a columnar string.
 func (node *FileNode) MetadataString() string {
+	return node.renderMetadataString(DefaultRenderOptions())
+}
+
+// renderMetadataString renders the FileNode metadata as MetadataString does, colored as the options say.
+func (node *FileNode) renderMetadataString(options RenderOptions) string {
 	if node == nil {
 		return ""
 	}
 
-	fileMode := permbits.FileMode(node.Data.FileInfo.Mode).String()
-	dir := "-"
-	if node.Data.FileInfo.IsDir {
-		dir = "d"
-	}
-	user := node.Data.FileInfo.Uid
-	group := node.Data.FileInfo.Gid
-	userGroup := fmt.Sprintf("%d:%d", user, group)
-
 	var sizeBytes int64
 
 	if node.IsLeaf() {
@@ -170,9 +187,47 @@ func (node *FileNode) MetadataString() string {
 		}
 	}
 
-	size := humanize.Bytes(uint64(sizeBytes))
+	return node.metadataString(sizeBytes, SizeSI, 0, options)
+}
+
+// EntryCounts counts the files and the directories beneath the FileNode (at any depth). As for the size of a
+// directory, the removed entries are left out, unless the FileNode is a removed directory.
+func (node *FileNode) EntryCounts() (files, dirs int) {
+	includeRemoved := node.Data.DiffType == Removed
+	var count func(*FileNode)
+	count = func(parent *FileNode) {
+		for _, child := range parent.Children {
+			if child.Data.DiffType == Removed && !includeRemoved {
+				continue
+			}
+			if child.Data.FileInfo.IsDir {
+				dirs++
+			} else {
+				files++
+			}
+			count(child)
+		}
+	}
+	count(node)
+	return files, dirs
+}
+
+// metadataString renders the FileNode metadata with the given (possibly precomputed) size, in the given format (see
+// FormatSize).
+func (node *FileNode) metadataString(sizeBytes int64, sizeFormat SizeFormat, sizeTotal int64, options RenderOptions) string {
+	fileMode := permbits.FileMode(node.Data.FileInfo.Mode).String()
+	kind := node.Data.FileInfo.TypeIndicator()
+	user := node.Data.FileInfo.Uid
+	group := node.Data.FileInfo.Gid
+	userGroup := fmt.Sprintf("%d:%d", user, group)
+
+	size := FormatSize(sizeBytes, sizeFormat, sizeTotal)
+	if node.Data.FileInfo.IsDevice() {
+		// devices have no size, show their device numbers instead (as ls does)
+		size = fmt.Sprintf("%d, %d", node.Data.FileInfo.Devmajor, node.Data.FileInfo.Devminor)
+	}
 
-	return diffTypeColor[node.Data.DiffType].Sprint(fmt.Sprintf(AttributeFormat, dir, fileMode, userGroup, size))
+	return options.diffColor(node.Data.DiffType).Sprint(fmt.Sprintf(AttributeFormat, kind, fileMode, userGroup, size))
 }
 
 // VisitDepthChildFirst iterates a tree depth-first (starting at this FileNode), evaluating the deepest depths first (visit on bubble up)
@@ -12,14 +12,8 @@ import (
 
 const (
 	newLine              = "\n"
-	noBranchSpace        = "    "
-	branchSpace          = "│   "
-	middleItem           = "├─"
-	lastItem             = "└─"
 	whiteoutPrefix       = ".wh."
 	doubleWhiteoutPrefix = ".wh..wh.."
-	uncollapsedItem      = "─ "
-	collapsedItem        = "⊕ "
 )
 
 // FileTree represents a set of files, directories, and their relations.
@@ -29,6 +23,9 @@ type FileTree struct {
 	FileSize uint64
 	Name     string
 	Id       uuid.UUID
+	// the number of entries of the layer folded into summary directories by a bounded analysis (see FoldEntries); a
+	// stacked tree counts those of the layers stacked onto it
+	Folded int
 }
 
 // NewFileTree creates an empty FileTree
@@ -52,16 +49,12 @@ type renderParams struct {
 	isLast        bool
 }
 
-// renderStringTreeBetween returns a string representing the given tree between the given rows. Since each node
-// is rendered on its own line, the returned string shows the visible nodes not affected by a collapsed parent.
-func (tree *FileTree) renderStringTreeBetween(startRow, stopRow int, showAttributes bool) string {
-	// generate a list of nodes to render
-	var params = make([]renderParams, 0)
-	var result string
-
+// visitRenderParams walks the visible nodes of the tree in display order, calling the visitor with the row index of
+// each node up to (and including) the given stop row. A negative stop row visits every visible node.
+func (tree *FileTree) visitRenderParams(stopRow int, visitor func(row int, params renderParams)) {
 	// visit from the front of the list
 	var paramsToVisit = []renderParams{{node: tree.Root, spaces: []bool{}, showCollapsed: false, isLast: false}}
-	for currentRow := 0; len(paramsToVisit) > 0 && currentRow <= stopRow; currentRow++ {
+	for currentRow := 0; len(paramsToVisit) > 0 && (stopRow < 0 || currentRow <= stopRow); currentRow++ {
 		// pop the first node
 		var currentParams renderParams
 		currentParams, paramsToVisit = paramsToVisit[0], paramsToVisit[1:]
@@ -111,20 +104,31 @@ func (tree *FileTree) renderStringTreeBetween(startRow, stopRow int, showAttribu
 			continue
 		}
 
-		// process the current node
-		if currentRow >= startRow && currentRow <= stopRow {
+		visitor(currentRow, currentParams)
+	}
+}
+
+// renderStringTreeBetween returns a string representing the given tree between the given rows. Since each node
+// is rendered on its own line, the returned string shows the visible nodes not affected by a collapsed parent.
+func (tree *FileTree) renderStringTreeBetween(startRow, stopRow int, showAttributes bool, options RenderOptions) string {
+	// generate a list of nodes to render
+	var params = make([]renderParams, 0)
+	var result string
+
+	tree.visitRenderParams(stopRow, func(row int, currentParams renderParams) {
+		if row >= startRow {
 			params = append(params, currentParams)
 		}
-	}
+	})
 
 	// render the result
 	for idx := range params {
 		currentParams := params[idx]
 
 		if showAttributes {
-			result += currentParams.node.MetadataString() + " "
+			result += currentParams.node.renderMetadataString(options) + " "
 		}
-		result += currentParams.node.renderTreeLine(currentParams.spaces, currentParams.isLast, currentParams.showCollapsed)
+		result += currentParams.node.renderTreeLine(currentParams.spaces, currentParams.isLast, currentParams.showCollapsed, 0, options)
 	}
 
 	return result
@@ -160,12 +164,90 @@ func (tree *FileTree) VisibleSize() int {
 
 // String returns the entire tree in an ASCII representation.
 func (tree *FileTree) String(showAttributes bool) string {
-	return tree.renderStringTreeBetween(0, tree.Size, showAttributes)
+	return tree.renderStringTreeBetween(0, tree.Size, showAttributes, DefaultRenderOptions())
+}
+
+// Render returns the entire tree as String does, drawn with the given options.
+func (tree *FileTree) Render(showAttributes bool, options RenderOptions) string {
+	return tree.renderStringTreeBetween(0, tree.Size, showAttributes, options)
 }
 
 // StringBetween returns a partial tree in an ASCII representation.
 func (tree *FileTree) StringBetween(start, stop int, showAttributes bool) string {
-	return tree.renderStringTreeBetween(start, stop, showAttributes)
+	return tree.renderStringTreeBetween(start, stop, showAttributes, DefaultRenderOptions())
+}
+
+// CollapseDepth collapses every directory at the given depth (1 is the top-level directories), such that rendering
+// the tree only shows the paths up to that depth. A depth of 0 or less leaves the tree as it is.
+func (tree *FileTree) CollapseDepth(depth int) {
+	if depth <= 0 {
+		return
+	}
+	collapseDepth(tree.Root, 0, depth)
+}
+
+func collapseDepth(node *FileNode, depth, limit int) {
+	for _, child := range node.Children {
+		if depth+1 >= limit {
+			child.Data.ViewInfo.Collapsed = len(child.Children) > 0
+			continue
+		}
+		collapseDepth(child, depth+1, limit)
+	}
+}
+
+// ExpandToDepth shows the paths up to the given depth (1 is the top-level paths): the directories above that depth are
+// expanded and the others are collapsed.
+func (tree *FileTree) ExpandToDepth(depth int) {
+	expandToDepth(tree.Root, 0, depth)
+}
+
+func expandToDepth(node *FileNode, depth, limit int) {
+	for _, child := range node.Children {
+		child.Data.ViewInfo.Collapsed = depth+1 >= limit && len(child.Children) > 0
+		expandToDepth(child, depth+1, limit)
+	}
+}
+
+// ExpandedDepth returns the depth up to which the paths are shown: the depth of the shallowest collapsed directory
+// (that is not within another collapsed directory), or the depth of the deepest path when no directory is collapsed.
+func (tree *FileTree) ExpandedDepth() int {
+	shown, deepest := -1, 0
+	var visit func(node *FileNode, depth int)
+	visit = func(node *FileNode, depth int) {
+		for _, child := range node.Children {
+			if depth+1 > deepest {
+				deepest = depth + 1
+			}
+			if child.Data.ViewInfo.Collapsed && len(child.Children) > 0 {
+				if shown < 0 || depth+1 < shown {
+					shown = depth + 1
+				}
+				continue
+			}
+			visit(child, depth+1)
+		}
+	}
+	visit(tree.Root, 0)
+	if shown < 0 {
+		return deepest
+	}
+	return shown
+}
+
+// MaxDepth returns the depth of the deepest path (0 for an empty tree).
+func (tree *FileTree) MaxDepth() int {
+	var deepest func(node *FileNode) int
+	deepest = func(node *FileNode) int {
+		depth := 0
+		for _, child := range node.Children {
+			if childDepth := deepest(child) + 1; childDepth > depth {
+				depth = childDepth
+			}
+		}
+		return depth
+	}
+	return deepest(tree.Root)
 }
 
 // Copy returns a copy of the given FileTree
@@ -173,6 +255,7 @@ func (tree *FileTree) Copy() *FileTree {
 	newTree := NewFileTree()
 	newTree.Size = tree.Size
 	newTree.FileSize = tree.FileSize
+	newTree.Folded = tree.Folded
 	newTree.Root = tree.Root.Copy(newTree.Root)
 
 	// update the tree pointers
@@ -206,14 +289,60 @@ func (tree *FileTree) VisitDepthParentFirst(visitor Visitor, evaluator VisitEval
 
 // Stack takes two trees and combines them together. This is done by "stacking" the given tree on top of the owning tree.
 func (tree *FileTree) Stack(upper *FileTree) (failed []PathError, stackErr error) {
+	// the summary directories of a bounded analysis are only looked for when there are some
+	folding := tree.Folded > 0 || upper.Folded > 0
+	tree.Folded += upper.Folded
+
+	// opaque directories hide everything beneath them in the lower layers (the upper layer re-adds what is kept)
+	stackErr = upper.VisitDepthParentFirst(func(node *FileNode) error {
+		if node.Data.Whiteout != WhiteoutOpaque {
+			return nil
+		}
+		if folding {
+			tree.unfoldPath(node.Path(), true)
+		}
+		lowerNode, err := tree.GetNode(node.Path())
+		if err != nil {
+			return nil
+		}
+		for _, child := range lowerNode.Children {
+			if err := child.Remove(); err != nil {
+				failed = append(failed, NewPathError(child.Path(), ActionRemove, err))
+			}
+		}
+		return nil
+	}, nil)
+	if stackErr != nil {
+		return failed, stackErr
+	}
+
 	graft := func(node *FileNode) error {
+		// the paths a bounded analysis folded into a summary directory of a lower layer are dropped from it instead
+		var summary *FileNode
+		if folding {
+			summary = tree.FoldedAncestor(node.Path())
+		}
 		if node.IsWhiteout() {
+			if folding {
+				tree.unfoldPath(node.Path(), true)
+			}
+			if summary != nil {
+				return nil
+			}
 			err := tree.RemovePath(node.Path())
 			if err != nil {
 				failed = append(failed, NewPathError(node.Path(), ActionAdd, err))
 			}
 		} else {
-			_, _, err := tree.AddPath(node.Path(), node.Data.FileInfo)
+			info := node.Data.FileInfo
+			if folding {
+				tree.unfoldPath(node.Path(), false)
+				tree.replaceFolded(info.Folded)
+				if lowerNode, err := tree.GetNode(node.Path()); err == nil {
+					info = mergeFolded(lowerNode.Data.FileInfo, info)
+				}
+			}
+			_, _, err := tree.AddPath(node.Path(), info)
 			if err != nil {
 				failed = append(failed, NewPathError(node.Path(), ActionRemove, err))
 			}
@@ -253,6 +382,13 @@ func (tree *FileTree) AddPath(filepath string, data FileInfo) (*FileNode, []*Fil
 		if name == "" {
 			continue
 		}
+		// an opaque marker is not a file, it hides the lower contents of the directory it is in
+		if name == opaqueWhiteout {
+			if idx == len(nodeNames)-1 {
+				node.Data.Whiteout = WhiteoutOpaque
+			}
+			return nil, addedNodes, nil
+		}
 		// find or create node
 		if node.Children[name] != nil {
 			node = node.Children[name]
@@ -306,8 +442,32 @@ func (tree *FileTree) CompareAndMark(upper *FileTree) ([]PathError, error) {
 	modifications := make([]compareMark, 0)
 	failed := make([]PathError, 0)
 
+	// opaque directories replace the lower contents: whatever the upper layer does not re-add has been removed
+	err := upper.VisitDepthParentFirst(func(upperNode *FileNode) error {
+		if upperNode.Data.Whiteout != WhiteoutOpaque {
+			return nil
+		}
+		lowerNode, err := tree.GetNode(upperNode.Path())
+		if err != nil {
+			return nil
+		}
+		lowerNode.Data.Whiteout = WhiteoutOpaque
+		if err := markReplaced(lowerNode, upperNode); err != nil {
+			failed = append(failed, NewPathError(upperNode.Path(), ActionRemove, err))
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return failed, err
+	}
+
 	graft := func(upperNode *FileNode) error {
 		if upperNode.IsWhiteout() {
+			if summary := tree.FoldedAncestor(upperNode.Path()); summary != nil {
+				// a bounded analysis folded the path into a summary directory, which the removal modifies
+				modifications = append(modifications, compareMark{lowerNode: summary, upperNode: upperNode, tentative: -1, final: Modified})
+				return nil
+			}
 			err := tree.markRemoved(upperNode.Path())
 			if err != nil {
 				failed = append(failed, NewPathError(upperNode.Path(), ActionRemove, err))
@@ -334,13 +494,17 @@ func (tree *FileTree) CompareAndMark(upper *FileTree) ([]PathError, error) {
 
 		// the file exists in the lower layer
 		lowerNode, _ := tree.GetNode(upperNode.Path())
+		if lowerNode.Data.Whiteout == WhiteoutDeleted {
+			// the path was deleted by a previous layer and has been re-added
+			lowerNode.Data.Whiteout = NotWhitedOut
+		}
 		diffType := lowerNode.compare(upperNode)
 		modifications = append(modifications, compareMark{lowerNode: lowerNode, upperNode: upperNode, tentative: diffType, final: -1})
 
 		return nil
 	}
 	// we must visit from the leaves upwards to ensure that diff types can be derived from and assigned to children
-	err := upper.VisitDepthChildFirst(graft, nil)
+	err = upper.VisitDepthChildFirst(graft, nil)
 	if err != nil {
 		return failed, err
 	}
@@ -371,6 +535,7 @@ func (tree *FileTree) markRemoved(path string) error {
 	if err != nil {
 		return err
 	}
+	node.Data.Whiteout = WhiteoutDeleted
 	return node.AssignDiffType(Removed)
 }
 
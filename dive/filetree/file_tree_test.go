@@ -1,7 +1,9 @@
 package filetree
 
 import (
+	"archive/tar"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -85,6 +87,63 @@ func TestString(t *testing.T) {
 
 }
 
+func TestStringDiffMarkers(t *testing.T) {
+	tree := NewFileTree()
+	tree.Root.AddChild("added", FileInfo{}).Data.DiffType = Added
+	tree.Root.AddChild("modified", FileInfo{}).Data.DiffType = Modified
+	tree.Root.AddChild("removed", FileInfo{}).Data.DiffType = Removed
+	tree.Root.AddChild("unmodified", FileInfo{})
+
+	rows := tree.VisibleRows()
+	options := DefaultRenderOptions()
+	options.DiffMarkers = true
+	rows.SetRenderOptions(options)
+
+	expected :=
+		`+ ├── added
+~ ├── modified
+- ├── removed
+  └── unmodified
+`
+	actual := rows.StringBetween(0, rows.Len()-1, false)
+
+	if expected != actual {
+		t.Errorf("Expected tree string:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
+	}
+}
+
+func TestCollapseDepth(t *testing.T) {
+	tree := NewFileTree()
+	for _, path := range []string{"/etc/hosts", "/usr/lib/libc.so", "/usr/lib/python3/os.py", "/usr/bin", "/README"} {
+		if _, _, err := tree.AddPath(path, FileInfo{}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	tree.CollapseDepth(2)
+	expected :=
+		`├── README
+├── etc
+│   └── hosts
+└── usr
+    ├── bin
+    └─⊕ lib
+`
+	if actual := tree.String(false); expected != actual {
+		t.Errorf("Expected tree string:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
+	}
+
+	tree.CollapseDepth(1)
+	expected =
+		`├── README
+├─⊕ etc
+└─⊕ usr
+`
+	if actual := tree.String(false); expected != actual {
+		t.Errorf("Expected tree string:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
+	}
+}
+
 func TestStringBetween(t *testing.T) {
 	tree := NewFileTree()
 	_, _, err := tree.AddPath("/etc/nginx/nginx.conf", FileInfo{})
@@ -125,6 +184,106 @@ func TestStringBetween(t *testing.T) {
 
 }
 
+func TestVisibleRows(t *testing.T) {
+	tree := NewFileTree()
+	for _, path := range []string{"/etc/nginx/nginx.conf", "/etc/nginx/public", "/var/run/systemd", "/var/run/bashful", "/tmp", "/tmp/nonsense"} {
+		_, _, err := tree.AddPath(path, FileInfo{Size: 10})
+		if err != nil {
+			t.Errorf("could not setup test: %v", err)
+		}
+	}
+	node, err := tree.GetNode("/var/run")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	node.Data.ViewInfo.Collapsed = true
+
+	rows := tree.VisibleRows()
+
+	// etc, nginx, nginx.conf, public, tmp, nonsense, var, run (collapsed)
+	if rows.Len() != 8 {
+		t.Errorf("expected 8 rows, got %d", rows.Len())
+	}
+	if rows.Node(7) != node || rows.Node(-1) != nil || rows.Node(rows.Len()) != nil {
+		t.Errorf("unexpected row nodes")
+	}
+	if count := rows.CountBetween(4, 100); count != 4 {
+		t.Errorf("expected 4 rows, got %d", count)
+	}
+
+	for _, bounds := range [][2]int{{0, 100}, {2, 4}, {-3, 1}, {5, 5}, {7, 9}} {
+		for _, showAttributes := range []bool{false, true} {
+			expected := tree.StringBetween(bounds[0], bounds[1], showAttributes)
+			// render twice to exercise the memoized lines
+			for pass := 0; pass < 2; pass++ {
+				if actual := rows.StringBetween(bounds[0], bounds[1], showAttributes); actual != expected {
+					t.Errorf("rows %v (attributes=%v): expected\n%s\ngot\n%s", bounds, showAttributes, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestVisibleRowsWidth(t *testing.T) {
+	tree := NewFileTree()
+	if _, _, err := tree.AddPath("/opt/a-very-long-release-name.tar.gz", FileInfo{}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	rows := tree.VisibleRows()
+	rows.SetWidth(20)
+	expected := "└── opt\n    └── a-very…ar.gz\n"
+	if actual := rows.StringBetween(0, 1, false); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+
+	rows.SetWidth(0)
+	if actual := rows.StringBetween(0, 1, false); actual != tree.StringBetween(0, 1, false) {
+		t.Errorf("expected the names whole without a width, got\n%s", actual)
+	}
+}
+
+func TestFileCounts(t *testing.T) {
+	tree := NewFileTree()
+	for _, path := range []string{"/etc", "/etc/ssl", "/etc/ssl/certs"} {
+		if _, _, err := tree.AddPath(path, FileInfo{IsDir: true}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	for _, path := range []string{"/etc/hosts", "/etc/ssl/openssl.cnf", "/etc/ssl/certs/a.pem", "/etc/ssl/certs/b.pem"} {
+		if _, _, err := tree.AddPath(path, FileInfo{Size: 10}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	removed, err := tree.GetNode("/etc/ssl/certs/b.pem")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	removed.Data.DiffType = Removed
+
+	etc, err := tree.GetNode("/etc")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if files, dirs := etc.EntryCounts(); files != 3 || dirs != 2 {
+		t.Errorf("expected 3 files and 2 directories, got %d and %d", files, dirs)
+	}
+
+	rows := tree.VisibleRows()
+	rows.SetShowFileCounts(true)
+	// etc, hosts, ssl, certs, ...
+	for row, expected := range map[int]string{0: "3", 1: "", 2: "2", 3: "1"} {
+		column := fmt.Sprintf(FileCountFormat, expected)
+		if line := rows.StringBetween(row, row, true); !strings.Contains(line, column) {
+			t.Errorf("row %d: expected the file count %q, got %q", row, expected, line)
+		}
+	}
+	rows.SetShowFileCounts(false)
+	if line := rows.StringBetween(0, 0, true); line != tree.StringBetween(0, 0, true) {
+		t.Errorf("expected no file count column, got %q", line)
+	}
+}
+
 func TestRejectPurelyRelativePath(t *testing.T) {
 	tree := NewFileTree()
 	_, _, err := tree.AddPath("./etc/nginx/nginx.conf", FileInfo{})
@@ -431,6 +590,14 @@ func TestCopy(t *testing.T) {
 		t.Errorf("Expected tree string:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
 	}
 
+	// the copy leaves the parents of the original nodes alone
+	original, err := tree.GetNode("/etc/nginx/public")
+	if err != nil {
+		t.Fatalf("could not find the original node: %v", err)
+	}
+	if original.Parent.Parent.Parent != tree.Root {
+		t.Errorf("expected the original node to stay within the original tree")
+	}
 }
 
 func TestCompareWithNoChanges(t *testing.T) {
@@ -729,6 +896,92 @@ func TestCompareWithRemoves(t *testing.T) {
 	}
 }
 
+func TestCompareWithOpaqueDir(t *testing.T) {
+	lowerTree := NewFileTree()
+	upperTree := NewFileTree()
+	lowerPaths := [...]string{"/etc", "/etc/nginx", "/etc/nginx/nginx.conf", "/etc/nginx/mime.types", "/etc/hosts"}
+	upperPaths := [...]string{"/etc", "/etc/nginx", "/etc/nginx/.wh..wh..opq", "/etc/nginx/nginx.conf"}
+
+	for _, value := range lowerPaths {
+		_, _, err := lowerTree.AddPath(value, FileInfo{Path: value, TypeFlag: 1, hash: 123, Size: 100})
+		if err != nil {
+			t.Errorf("could not setup test: %v", err)
+		}
+	}
+
+	for _, value := range upperPaths {
+		_, _, err := upperTree.AddPath(value, FileInfo{Path: value, TypeFlag: 1, hash: 456, Size: 100})
+		if err != nil {
+			t.Errorf("could not setup test: %v", err)
+		}
+	}
+
+	upperNode, err := upperTree.GetNode("/etc/nginx")
+	if err != nil {
+		t.Fatalf("could not get upper node: %v", err)
+	}
+	if upperNode.Data.Whiteout != WhiteoutOpaque {
+		t.Errorf("expected the opaque marker to be decoded onto its directory")
+	}
+	if _, err := upperTree.GetNode("/etc/nginx/.wh..wh..opq"); err == nil {
+		t.Errorf("expected the opaque marker not to be added as a file")
+	}
+
+	failedPaths, err := lowerTree.CompareAndMark(upperTree)
+	if err != nil {
+		t.Errorf("could not setup test: %v", err)
+	}
+	if len(failedPaths) > 0 {
+		t.Errorf("expected no filepath errors, got %d", len(failedPaths))
+	}
+
+	expected := map[string]DiffType{
+		"/etc":                  Modified,
+		"/etc/hosts":            Unmodified,
+		"/etc/nginx":            Modified,
+		"/etc/nginx/nginx.conf": Modified,
+		"/etc/nginx/mime.types": Removed,
+	}
+	for path, diffType := range expected {
+		node, err := lowerTree.GetNode(path)
+		if err != nil {
+			t.Fatalf("could not get node %q: %v", path, err)
+		}
+		if err := AssertDiffType(node, diffType); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+
+	node, _ := lowerTree.GetNode("/etc/nginx")
+	if node.Data.Whiteout != WhiteoutOpaque {
+		t.Errorf("expected the lower directory to be marked as replaced")
+	}
+	if annotation := node.whiteoutAnnotation(); annotation != " (directory replaced, hides 100 B)" {
+		t.Errorf("unexpected annotation: %q", annotation)
+	}
+
+	// stacking drops everything beneath the directory that the upper layer does not add back
+	stackedTree := NewFileTree()
+	for _, value := range lowerPaths {
+		_, _, err := stackedTree.AddPath(value, FileInfo{Path: value, TypeFlag: 1, hash: 123, Size: 100})
+		if err != nil {
+			t.Errorf("could not setup test: %v", err)
+		}
+	}
+	if _, err := stackedTree.Stack(upperTree); err != nil {
+		t.Errorf("could not stack trees: %v", err)
+	}
+	if _, err := stackedTree.GetNode("/etc/nginx/mime.types"); err == nil {
+		t.Errorf("expected the replaced file to be removed from the stacked tree")
+	}
+	if _, err := stackedTree.GetNode("/etc/nginx/nginx.conf"); err != nil {
+		t.Errorf("expected the re-added file to remain in the stacked tree")
+	}
+	if _, err := stackedTree.GetNode("/etc/hosts"); err != nil {
+		t.Errorf("expected files outside of the replaced directory to remain in the stacked tree")
+	}
+}
+
 func TestStackRange(t *testing.T) {
 	tree := NewFileTree()
 	_, _, err := tree.AddPath("/etc/nginx/nginx.conf", FileInfo{})
@@ -844,3 +1097,149 @@ func TestRemoveOnIterate(t *testing.T) {
 	}
 
 }
+
+func TestResolveLink(t *testing.T) {
+	tree := NewFileTree()
+	for _, value := range []string{"/usr/lib/libc.so.6", "/usr/bin/python3.8", "/etc/os-release"} {
+		if _, _, err := tree.AddPath(value, FileInfo{Path: value, TypeFlag: tar.TypeReg}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	links := map[string]FileInfo{
+		"/lib":                {TypeFlag: tar.TypeSymlink, Linkname: "usr/lib"},
+		"/usr/bin/python3":    {TypeFlag: tar.TypeSymlink, Linkname: "python3.8"},
+		"/usr/bin/python":     {TypeFlag: tar.TypeSymlink, Linkname: "/usr/bin/python3"},
+		"/usr/local/libc.so":  {TypeFlag: tar.TypeSymlink, Linkname: "../../lib/libc.so.6"},
+		"/usr/bin/python-hl":  {TypeFlag: tar.TypeLink, Linkname: "usr/bin/python3.8"},
+		"/usr/bin/dangling":   {TypeFlag: tar.TypeSymlink, Linkname: "missing"},
+		"/usr/bin/loop-start": {TypeFlag: tar.TypeSymlink, Linkname: "loop-end"},
+		"/usr/bin/loop-end":   {TypeFlag: tar.TypeSymlink, Linkname: "loop-start"},
+	}
+	for value, info := range links {
+		info.Path = value
+		if _, _, err := tree.AddPath(value, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	cases := map[string]string{
+		"/lib":               "/usr/lib",
+		"/usr/bin/python3":   "/usr/bin/python3.8",
+		"/usr/bin/python":    "/usr/bin/python3.8",
+		"/usr/local/libc.so": "/usr/lib/libc.so.6",
+		"/usr/bin/python-hl": "/usr/bin/python3.8",
+		"/usr/bin/dangling":  "",
+		"/usr/bin/loop-end":  "",
+	}
+
+	for link, expected := range cases {
+		node, err := tree.GetNode(link)
+		if err != nil {
+			t.Fatalf("could not find link %s: %v", link, err)
+		}
+		target, err := tree.ResolveLink(node)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, resolved to %s", link, target.Path())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to resolve: %v", link, err)
+			continue
+		}
+		if target.Path() != expected {
+			t.Errorf("%s: expected %s, got %s", link, expected, target.Path())
+		}
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	left := NewFileTree()
+	right := NewFileTree()
+	for _, path := range []string{"/etc/hosts", "/etc/passwd", "/usr/bin/sh"} {
+		if _, _, err := left.AddPath(path, FileInfo{Path: path, Mode: 0644}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	for _, path := range []string{"/etc/hosts", "/etc/passwd", "/opt/app"} {
+		if _, _, err := right.AddPath(path, FileInfo{Path: path, Mode: 0644}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	passwd, err := right.GetNode("/etc/passwd")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	passwd.Data.FileInfo.Mode = 0600
+	if _, _, err := right.AddPath("/usr/.wh.bin", FileInfo{Path: "/usr/.wh.bin"}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	expected := []struct {
+		path  string
+		depth int
+		diff  DiffType
+	}{
+		{"/etc", 0, Modified},
+		{"/etc/hosts", 1, Unmodified},
+		{"/etc/passwd", 1, Modified},
+		{"/opt", 0, Added},
+		{"/opt/app", 1, Added},
+		{"/usr", 0, Modified},
+		{"/usr/bin", 1, Removed},
+		{"/usr/bin/sh", 2, Removed},
+	}
+
+	rows := SideBySide(left, right)
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(rows))
+	}
+	for idx, row := range rows {
+		if row.Path != expected[idx].path || row.Depth != expected[idx].depth || row.Diff != expected[idx].diff {
+			t.Errorf("row %d: expected %s (depth %d, %v), got %s (depth %d, %v)", idx, expected[idx].path, expected[idx].depth, expected[idx].diff, row.Path, row.Depth, row.Diff)
+		}
+	}
+	if rows[3].Left != nil || rows[3].Right == nil || rows[6].Left == nil || rows[6].Right != nil {
+		t.Errorf("expected the entries of a single side to be missing on the other side")
+	}
+
+	if changed := ChangedRows(rows); len(changed) != 7 {
+		t.Errorf("expected 7 changed rows, got %d", len(changed))
+	}
+}
+
+func TestExpandToDepth(t *testing.T) {
+	tree := NewFileTree()
+	for _, path := range []string{"/etc/ssl/certs/ca.pem", "/etc/hosts", "/usr/bin/sh", "/tmp"} {
+		if _, _, err := tree.AddPath(path, FileInfo{Path: path}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if depth := tree.MaxDepth(); depth != 4 {
+		t.Errorf("expected a max depth of 4, got %d", depth)
+	}
+	if depth := tree.ExpandedDepth(); depth != 4 {
+		t.Errorf("expected every path to be shown, got depth %d", depth)
+	}
+
+	tree.ExpandToDepth(2)
+	collapsed := map[string]bool{"/etc": false, "/etc/ssl": true, "/etc/ssl/certs": true, "/usr": false, "/usr/bin": true, "/tmp": false}
+	for path, expected := range collapsed {
+		node, err := tree.GetNode(path)
+		if err != nil {
+			t.Fatalf("could not find %s: %v", path, err)
+		}
+		if node.Data.ViewInfo.Collapsed != expected {
+			t.Errorf("%s: expected collapsed=%v", path, expected)
+		}
+	}
+	if depth := tree.ExpandedDepth(); depth != 2 {
+		t.Errorf("expected the paths up to depth 2 to be shown, got %d", depth)
+	}
+
+	tree.ExpandToDepth(1)
+	if depth := tree.ExpandedDepth(); depth != 1 {
+		t.Errorf("expected the top-level paths to be shown, got %d", depth)
+	}
+}
@@ -0,0 +1,61 @@
+package filetree
+
+import (
+	"archive/tar"
+	"os"
+	"strings"
+)
+
+// the prefix of the PAX records that describe a sparse file (the tar reader reassembles the contents from them)
+const sparseRecordPrefix = "GNU.sparse."
+
+// IsSparse indicates if the tar entry is a sparse file, in the old GNU format or any of the GNU PAX formats.
+func IsSparse(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, sparseRecordPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDevice indicates if the file is a character or block device node.
+func (data *FileInfo) IsDevice() bool {
+	return data.TypeFlag == tar.TypeChar || data.TypeFlag == tar.TypeBlock
+}
+
+// TypeIndicator returns the character ls shows for the kind of file: "d" for directories, "c" and "b" for character
+// and block devices, "p" for FIFOs, "s" for sockets and "-" for everything else (links are shown with their target).
+func (data *FileInfo) TypeIndicator() string {
+	switch {
+	case data.IsDir:
+		return "d"
+	case data.TypeFlag == tar.TypeChar:
+		return "c"
+	case data.TypeFlag == tar.TypeBlock:
+		return "b"
+	case data.TypeFlag == tar.TypeFifo:
+		return "p"
+	case data.Mode&os.ModeSocket != 0:
+		return "s"
+	}
+	return "-"
+}
+
+// typeName describes the kinds of files that are not shown distinctly by their name alone (empty for the rest).
+func (data *FileInfo) typeName() string {
+	switch data.TypeIndicator() {
+	case "c":
+		return "character device"
+	case "b":
+		return "block device"
+	case "p":
+		return "FIFO"
+	case "s":
+		return "socket"
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package filetree
+
+import (
+	"archive/tar"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileInfoSpecialFiles(t *testing.T) {
+	cases := []struct {
+		header     *tar.Header
+		indicator  string
+		attributes []InspectionField
+	}{
+		{
+			header:    &tar.Header{Name: "dev/null", Typeflag: tar.TypeChar, Mode: 0666, Devmajor: 1, Devminor: 3},
+			indicator: "c",
+			attributes: []InspectionField{
+				{Name: "Type", Value: "character device"},
+				{Name: "Device", Value: "1, 3"},
+			},
+		},
+		{
+			header:    &tar.Header{Name: "dev/sda", Typeflag: tar.TypeBlock, Mode: 0660, Devmajor: 8, Devminor: 0},
+			indicator: "b",
+			attributes: []InspectionField{
+				{Name: "Type", Value: "block device"},
+				{Name: "Device", Value: "8, 0"},
+			},
+		},
+		{
+			header:     &tar.Header{Name: "run/initctl", Typeflag: tar.TypeFifo, Mode: 0600},
+			indicator:  "p",
+			attributes: []InspectionField{{Name: "Type", Value: "FIFO"}},
+		},
+		{
+			header:     &tar.Header{Name: "run/app.sock", Typeflag: tar.TypeReg, Mode: 0755 | 0140000},
+			indicator:  "s",
+			attributes: []InspectionField{{Name: "Type", Value: "socket"}},
+		},
+		{
+			header:    &tar.Header{Name: "etc", Typeflag: tar.TypeDir, Mode: 0755},
+			indicator: "d",
+		},
+		{
+			header:    &tar.Header{Name: "etc/hostname", Typeflag: tar.TypeReg, Mode: 0644},
+			indicator: "-",
+		},
+	}
+
+	for _, test := range cases {
+		info := readTarFile(t, test.header, "")
+		if actual := info.TypeIndicator(); actual != test.indicator {
+			t.Errorf("%s: expected type indicator %q, got %q", test.header.Name, test.indicator, actual)
+		}
+
+		var actual []InspectionField
+		if attributes := info.Attributes(IDMapping{}); attributes != nil {
+			actual = attributes.Fields
+		}
+		if !reflect.DeepEqual(actual, test.attributes) {
+			t.Errorf("%s: expected attributes %+v, got %+v", test.header.Name, test.attributes, actual)
+		}
+	}
+}
+
+func TestDeviceMetadataString(t *testing.T) {
+	tree := NewFileTree()
+	node, _, err := tree.AddPath("/dev/null", FileInfo{TypeFlag: tar.TypeChar, Mode: 0666 | os.ModeDevice | os.ModeCharDevice, Devmajor: 1, Devminor: 3})
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if actual := node.MetadataString(); !strings.HasPrefix(actual, "c") || !strings.Contains(actual, "1, 3") {
+		t.Errorf("expected the device type and numbers, got %q", actual)
+	}
+}
+
+func TestCompareDeviceNumbers(t *testing.T) {
+	lower := FileInfo{TypeFlag: tar.TypeChar, Devmajor: 1, Devminor: 3}
+	upper := FileInfo{TypeFlag: tar.TypeChar, Devmajor: 1, Devminor: 5}
+	if diff := lower.Compare(upper); diff != Modified {
+		t.Errorf("expected a device with other numbers to be modified, got %v", diff)
+	}
+}
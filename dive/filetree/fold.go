@@ -0,0 +1,246 @@
+package filetree
+
+import (
+	"archive/tar"
+	"os"
+	"path"
+	"strings"
+)
+
+// FoldedFile is a file a bounded analysis folded into a summary directory instead of adding it to the tree (see
+// FoldEntries). Only the path and the size are kept, which is what the sizes and the efficiency are computed from.
+type FoldedFile struct {
+	Path string
+	Size int64
+}
+
+// FoldEntries keeps the entries of a layer within the given number (as far as the top level directories allow):
+// the entries beneath the deepest directory depth that fits are folded into the directory at that depth, which
+// becomes a summary directory carrying their paths and sizes (see FoldedFile). Whiteouts (and their directories) are
+// never folded, as they hide the files of other layers. It returns the entries to add to the tree, and how many were folded.
+func FoldEntries(entries []FileInfo, maxEntries int) ([]FileInfo, int) {
+	if len(entries) <= maxEntries {
+		return entries, 0
+	}
+
+	// whiteouts are kept along with their directories, which tell whether what they hide was a file or a directory
+	pinned := make([]bool, len(entries))
+	whiteoutDirs := make(map[string]bool)
+	parents := make(map[string]bool)
+	for _, entry := range entries {
+		parents[path.Dir(path.Clean("/"+entry.Path))] = true
+		if isWhiteoutPath(entry.Path) {
+			for dir := path.Dir(path.Clean("/" + entry.Path)); dir != "/"; dir = path.Dir(dir) {
+				whiteoutDirs[dir] = true
+			}
+		}
+	}
+	depths := make([]int, len(entries))
+	maxDepth := 0
+	for idx, entry := range entries {
+		pinned[idx] = isWhiteoutPath(entry.Path) || whiteoutDirs[path.Clean("/"+entry.Path)]
+		depths[idx] = pathDepth(entry.Path)
+		if depths[idx] > maxDepth {
+			maxDepth = depths[idx]
+		}
+	}
+
+	// the deepest depth whose entries and summary directories fit, the top level directories otherwise
+	depth := 1
+	for candidate := maxDepth - 1; candidate > 1; candidate-- {
+		if foldedCount(entries, depths, pinned, candidate) <= maxEntries {
+			depth = candidate
+			break
+		}
+	}
+
+	kept := make([]FileInfo, 0, maxEntries)
+	summaries := make(map[string]int)
+	for idx, entry := range entries {
+		if depths[idx] <= depth || pinned[idx] {
+			kept = append(kept, entry)
+			if depths[idx] == depth {
+				summaries[path.Clean("/"+entry.Path)] = len(kept) - 1
+			}
+		}
+	}
+
+	folded := 0
+	positions := make(map[string]int)
+	for idx, entry := range entries {
+		if depths[idx] <= depth || pinned[idx] {
+			continue
+		}
+		folded++
+		ancestor := pathPrefix(entry.Path, depth)
+		summary, exists := summaries[ancestor]
+		if !exists {
+			kept = append(kept, FileInfo{Path: ancestor, TypeFlag: tar.TypeDir, IsDir: true, Mode: os.ModeDir | 0755})
+			summary = len(kept) - 1
+			summaries[ancestor] = summary
+		}
+		// empty directories are kept as folded files, as they are files of their own to the efficiency
+		filePath := path.Clean("/" + entry.Path)
+		if entry.IsDir && parents[filePath] {
+			continue
+		}
+		info := &kept[summary]
+		if position, exists := positions[filePath]; exists {
+			// the last entry of a path wins, as it does in the tree
+			info.Size -= info.Folded[position].Size
+			info.Folded[position].Size = entry.Size
+		} else {
+			positions[filePath] = len(info.Folded)
+			info.Folded = append(info.Folded, FoldedFile{Path: filePath, Size: entry.Size})
+		}
+		info.Size += entry.Size
+	}
+	return kept, folded
+}
+
+// foldedCount is the number of entries left once the entries beneath the given depth are folded.
+func foldedCount(entries []FileInfo, depths []int, pinned []bool, depth int) int {
+	count := 0
+	summaries := make(map[string]bool)
+	for idx, entry := range entries {
+		switch {
+		case depths[idx] < depth || pinned[idx]:
+			count++
+		case depths[idx] == depth:
+			summaries[path.Clean("/"+entry.Path)] = true
+		default:
+			summaries[pathPrefix(entry.Path, depth)] = true
+		}
+	}
+	return count + len(summaries)
+}
+
+// pathDepth is the number of elements of the path ("/usr/lib" is 2).
+func pathDepth(filePath string) int {
+	filePath = strings.Trim(path.Clean("/"+filePath), "/")
+	if filePath == "" {
+		return 0
+	}
+	return strings.Count(filePath, "/") + 1
+}
+
+// pathPrefix is the ancestor of the path at the given depth.
+func pathPrefix(filePath string, depth int) string {
+	names := strings.Split(strings.Trim(path.Clean("/"+filePath), "/"), "/")
+	if depth < len(names) {
+		names = names[:depth]
+	}
+	return "/" + strings.Join(names, "/")
+}
+
+// isWhiteoutPath indicates if the entry is a whiteout (or an opaque marker).
+func isWhiteoutPath(filePath string) bool {
+	return strings.HasPrefix(path.Base(filePath), whiteoutPrefix)
+}
+
+// FoldedAncestor returns the summary directory the given path was folded into, if any (nil when the path is in the
+// tree).
+func (tree *FileTree) FoldedAncestor(filePath string) *FileNode {
+	node := tree.Root
+	var summary *FileNode
+	for _, name := range strings.Split(strings.Trim(filePath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if len(node.Data.FileInfo.Folded) > 0 {
+			summary = node
+		}
+		child := node.Children[name]
+		if child == nil {
+			return summary
+		}
+		node = child
+	}
+	return nil
+}
+
+// FoldedSize sums the sizes of the files folded into the summary directory at the given path or beneath it, telling
+// if the path is a directory (files were folded beneath it). The files counted already (if counted is given) are
+// skipped.
+func (node *FileNode) FoldedSize(filePath string, counted func(path string) bool) (int64, bool) {
+	var sizeBytes int64
+	isDir := false
+	for _, file := range node.Data.FileInfo.Folded {
+		beneath := strings.HasPrefix(file.Path, filePath+"/")
+		if file.Path != filePath && !beneath {
+			continue
+		}
+		if beneath {
+			isDir = true
+		}
+		if counted == nil || !counted(file.Path) {
+			sizeBytes += file.Size
+		}
+	}
+	return sizeBytes, isDir
+}
+
+// unfold drops the files folded into the summary directory at the given path (and beneath it, unless only the path
+// itself is replaced), as a whiteout or a file of an upper layer does when the layers are stacked.
+func (node *FileNode) unfold(filePath string, beneath bool) {
+	info := &node.Data.FileInfo
+	// the folded files are shared with the tree of the layer, so they are copied rather than changed
+	var kept []FoldedFile
+	for _, file := range info.Folded {
+		if file.Path == filePath || (beneath && strings.HasPrefix(file.Path, filePath+"/")) {
+			info.Size -= file.Size
+			continue
+		}
+		kept = append(kept, file)
+	}
+	info.Folded = kept
+}
+
+// unfoldPath drops the files folded at the given path (and beneath it, unless only the path itself is replaced) from
+// the summary directories along the path.
+func (tree *FileTree) unfoldPath(filePath string, beneath bool) {
+	node := tree.Root
+	for _, name := range strings.Split(strings.Trim(filePath, "/"), "/") {
+		if len(node.Data.FileInfo.Folded) > 0 {
+			node.unfold(filePath, beneath)
+		}
+		if node = node.Children[name]; node == nil {
+			return
+		}
+	}
+	if len(node.Data.FileInfo.Folded) > 0 {
+		node.unfold(filePath, beneath)
+	}
+}
+
+// replaceFolded drops the files of the lower layers that the files folded into a summary directory of an upper layer
+// replace.
+func (tree *FileTree) replaceFolded(folded []FoldedFile) {
+	for _, file := range folded {
+		tree.unfoldPath(file.Path, false)
+		if node, err := tree.GetNode(file.Path); err == nil && node.IsLeaf() {
+			_ = node.Remove()
+		}
+	}
+}
+
+// mergeFolded keeps the files folded into a directory of a lower layer when an upper layer writes the directory
+// again, along with the files the upper layer folded into it (which replace the lower ones of the same paths).
+func mergeFolded(lower, upper FileInfo) FileInfo {
+	if len(lower.Folded) == 0 || !upper.IsDir {
+		return upper
+	}
+	replaced := make(map[string]bool, len(upper.Folded))
+	for _, file := range upper.Folded {
+		replaced[file.Path] = true
+	}
+	merged := append([]FoldedFile(nil), upper.Folded...)
+	for _, file := range lower.Folded {
+		if !replaced[file.Path] {
+			merged = append(merged, file)
+			upper.Size += file.Size
+		}
+	}
+	upper.Folded = merged
+	return upper
+}
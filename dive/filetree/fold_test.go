@@ -0,0 +1,148 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func foldTestLayers() [][]FileInfo {
+	return [][]FileInfo{
+		{
+			{Path: "/etc", IsDir: true},
+			{Path: "/etc/nginx", IsDir: true},
+			{Path: "/etc/nginx/nginx.conf", Size: 2000},
+			{Path: "/etc/nginx/public", Size: 3000},
+			{Path: "/etc/nginx/conf.d", IsDir: true},
+			{Path: "/etc/nginx/conf.d/default.conf", Size: 500},
+			{Path: "/usr/bin/tool", Size: 4000},
+		},
+		{
+			{Path: "/etc", IsDir: true},
+			{Path: "/etc/nginx", IsDir: true},
+			{Path: "/etc/nginx/nginx.conf", Size: 5000},
+			{Path: "/etc/athing", Size: 10000},
+		},
+		{
+			*BlankFileChangeInfo("/etc/.wh.nginx"),
+		},
+	}
+}
+
+func buildFoldTestTrees(t *testing.T, maxEntries int) []*FileTree {
+	var trees []*FileTree
+	for _, entries := range foldTestLayers() {
+		tree := NewFileTree()
+		if maxEntries > 0 {
+			entries, tree.Folded = FoldEntries(entries, maxEntries)
+		}
+		for _, entry := range entries {
+			_, _, err := tree.AddPath(entry.Path, entry)
+			checkError(t, err, "could not setup test")
+		}
+		trees = append(trees, tree)
+	}
+	return trees
+}
+
+func TestFoldEntries(t *testing.T) {
+	entries := foldTestLayers()[0]
+
+	kept, folded := FoldEntries(entries, len(entries))
+	if folded != 0 || len(kept) != len(entries) {
+		t.Fatalf("expected the entries to be kept as is, got %d entries (%d folded)", len(kept), folded)
+	}
+
+	kept, folded = FoldEntries(entries, 4)
+	if folded != 5 {
+		t.Errorf("expected 5 folded entries, got %d", folded)
+	}
+	var paths []string
+	for _, entry := range kept {
+		paths = append(paths, entry.Path)
+	}
+	if len(kept) != 3 {
+		t.Fatalf("expected 3 kept entries, got %v", paths)
+	}
+
+	summary := kept[1]
+	if summary.Path != "/etc/nginx" || len(summary.Folded) != 3 || summary.Size != 5500 {
+		t.Errorf("unexpected summary directory: %+v", summary)
+	}
+	// the missing directory of a folded file is added as its summary directory
+	if kept[2].Path != "/usr/bin" || !kept[2].IsDir || kept[2].Size != 4000 {
+		t.Errorf("unexpected summary directory: %+v", kept[2])
+	}
+}
+
+func TestFoldEntries_Whiteouts(t *testing.T) {
+	entries := []FileInfo{
+		{Path: "/var/lib/apt/lists/a", Size: 10},
+		{Path: "/var/lib/apt/lists/b", Size: 20},
+		{Path: "/var/lib/apt", IsDir: true},
+		*BlankFileChangeInfo("/var/lib/apt/.wh.cache"),
+	}
+
+	kept, folded := FoldEntries(entries, 1)
+	if folded != 2 {
+		t.Errorf("expected 2 folded entries, got %d", folded)
+	}
+	expected := map[string]bool{"/var/lib/apt": true, "/var/lib/apt/.wh.cache": true, "/var": true}
+	for _, entry := range kept {
+		if !expected[entry.Path] {
+			t.Errorf("unexpected kept entry %q", entry.Path)
+		}
+		delete(expected, entry.Path)
+	}
+	if len(expected) > 0 {
+		t.Errorf("expected the whiteout and its directory to be kept, missing %v", expected)
+	}
+}
+
+func TestFoldedStack(t *testing.T) {
+	full := buildFoldTestTrees(t, 0)
+	folded := buildFoldTestTrees(t, 2)
+
+	for idx := range full {
+		fullTree, _, err := StackTreeRange(full, 0, idx)
+		checkError(t, err, "could not stack the trees")
+		foldedTree, failed, err := StackTreeRange(folded, 0, idx)
+		checkError(t, err, "could not stack the folded trees")
+		if len(failed) > 0 {
+			t.Fatalf("layer %d: unexpected path errors: %v", idx, failed)
+		}
+		if fullTree.FileSize != foldedTree.FileSize {
+			t.Errorf("layer %d: expected the file size %d, got %d", idx, fullTree.FileSize, foldedTree.FileSize)
+		}
+		if foldedTree.Folded == 0 {
+			t.Errorf("layer %d: expected the stacked tree to be folded", idx)
+		}
+	}
+
+	foldedTree, _, _ := StackTreeRange(folded, 0, 2)
+	if summary := foldedTree.FoldedAncestor("/etc/nginx/nginx.conf"); summary != nil {
+		t.Errorf("expected the whiteout to drop the folded files, got %+v", summary.Data.FileInfo.Folded)
+	}
+	if summary := foldedTree.FoldedAncestor("/usr/bin/tool"); summary == nil || summary.Path() != "/usr" {
+		t.Errorf("expected the file to be folded into /usr, got %v", summary)
+	}
+}
+
+func TestEfficiency_Folded(t *testing.T) {
+	expectedScore, expectedMatches := Efficiency(buildFoldTestTrees(t, 0))
+	if len(expectedMatches) != 2 {
+		t.Fatalf("expected the nginx config and directory to be inefficient, found %d paths", len(expectedMatches))
+	}
+	for _, maxEntries := range []int{1, 2, 3} {
+		actualScore, actualMatches := Efficiency(buildFoldTestTrees(t, maxEntries))
+		if expectedScore != actualScore {
+			t.Errorf("max %d: expected score of %v but got %v", maxEntries, expectedScore, actualScore)
+		}
+		if len(expectedMatches) != len(actualMatches) {
+			t.Fatalf("max %d: expected %d inefficient paths, but found %d", maxEntries, len(expectedMatches), len(actualMatches))
+		}
+		for idx, match := range expectedMatches {
+			if match.Path != actualMatches[idx].Path || match.CumulativeSize != actualMatches[idx].CumulativeSize {
+				t.Errorf("max %d: expected %s (%d) but got %s (%d)", maxEntries, match.Path, match.CumulativeSize, actualMatches[idx].Path, actualMatches[idx].CumulativeSize)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package filetree
+
+import (
+	"path"
+	"strings"
+)
+
+// ValidateGlob checks every segment of a glob ("**" is always valid).
+func ValidateGlob(pattern string) error {
+	return validateSegments(strings.Split(strings.Trim(pattern, "/"), "/"))
+}
+
+// MatchGlob matches an absolute path against a glob anchored at the root of the image, where "**" stands for any
+// number of directories (including none) and every other segment is matched with path.Match.
+func MatchGlob(pattern, filePath string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(filePath, "/"), "/"))
+}
+
+func validateSegments(segments []string) error {
+	for _, segment := range segments {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchSegments matches the names of a path against the segments of a pattern, where "**" stands for any number of
+// names (including none) and every other segment is matched with path.Match.
+func matchSegments(segments, names []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == "**" {
+			for skipped := 0; skipped <= len(names); skipped++ {
+				if matchSegments(segments[1:], names[skipped:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(segments[0], names[0]); !matched {
+			return false
+		}
+		segments, names = segments[1:], names[1:]
+	}
+	return len(names) == 0
+}
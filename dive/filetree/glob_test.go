@@ -0,0 +1,30 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	table := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"**/.git/**", "/app/.git/config", true},
+		{"**/.git/**", "/.git/objects/pack/pack-1.pack", true},
+		{"**/.git/**", "/app/.github/workflows/ci.yaml", false},
+		{"**/*.pem", "/etc/ssl/private/server.pem", true},
+		{"**/*.pem", "/server.pem", true},
+		{"**/*.pem", "/etc/ssl/server.pem.bak", false},
+		{"**/id_rsa*", "/root/.ssh/id_rsa.pub", true},
+		{"/var/cache/**", "/var/cache/apt/archives/curl.deb", true},
+		{"/var/cache/**", "/usr/var/cache/apt", false},
+		{"/root/*.txt", "/root/saved.txt", true},
+		{"/root/*.txt", "/root/.data/saved.txt", false},
+	}
+	for _, test := range table {
+		if actual := MatchGlob(test.pattern, test.path); actual != test.expected {
+			t.Errorf("%s against %s: expected %v, got %v", test.pattern, test.path, test.expected, actual)
+		}
+	}
+}
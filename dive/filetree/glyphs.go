@@ -0,0 +1,39 @@
+package filetree
+
+// Glyphs are the characters used to draw the tree branches and node decorations.
+type Glyphs struct {
+	NoBranchSpace   string
+	BranchSpace     string
+	MiddleItem      string
+	LastItem        string
+	UncollapsedItem string
+	CollapsedItem   string
+	LinkArrow       string
+	// replaces what is left out of a name too long to show (see RenderOptions.Truncate)
+	Ellipsis string
+}
+
+var (
+	// UnicodeGlyphs draws the tree with box-drawing characters (the default).
+	UnicodeGlyphs = Glyphs{
+		NoBranchSpace:   "    ",
+		BranchSpace:     "│   ",
+		MiddleItem:      "├─",
+		LastItem:        "└─",
+		UncollapsedItem: "─ ",
+		CollapsedItem:   "⊕ ",
+		LinkArrow:       " → ",
+		Ellipsis:        "…",
+	}
+	// ASCIIGlyphs draws the tree for terminals (or fonts) without unicode support.
+	ASCIIGlyphs = Glyphs{
+		NoBranchSpace:   "    ",
+		BranchSpace:     "|   ",
+		MiddleItem:      "|-",
+		LastItem:        "`-",
+		UncollapsedItem: "- ",
+		CollapsedItem:   "+ ",
+		LinkArrow:       " -> ",
+		Ellipsis:        "...",
+	}
+)
@@ -0,0 +1,152 @@
+package filetree
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// the id mapping value that reads the subordinate ids of the current user from /etc/subuid and /etc/subgid
+const IDMappingAuto = "auto"
+
+// IDRange maps a contiguous range of ids within a user namespace onto ids on the host (as a line of /proc/<pid>/uid_map).
+type IDRange struct {
+	NamespaceID int
+	HostID      int
+	Size        int
+}
+
+// IDMapping is how a user namespace (e.g. the one of a rootless engine) shifts the ids of the files it writes. Layers
+// exported from the storage of a rootless engine may hold the shifted host ids (e.g. 100000) rather than the ids the
+// files have within the container (e.g. 0).
+type IDMapping struct {
+	Uids []IDRange
+	Gids []IDRange
+}
+
+// IsEmpty indicates if the mapping shifts no id.
+func (mapping IDMapping) IsEmpty() bool {
+	return len(mapping.Uids) == 0 && len(mapping.Gids) == 0
+}
+
+// ParseIDRanges parses comma separated "<namespace id>:<host id>:<size>" ranges (e.g. "0:100000:65536").
+func ParseIDRanges(value string) ([]IDRange, error) {
+	var ranges []IDRange
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.Split(field, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("expected <namespace id>:<host id>:<size>, given %q", field)
+		}
+		var numbers [3]int
+		for idx, part := range parts {
+			number, err := strconv.Atoi(part)
+			if err != nil || number < 0 {
+				return nil, fmt.Errorf("expected <namespace id>:<host id>:<size>, given %q", field)
+			}
+			numbers[idx] = number
+		}
+		if numbers[2] == 0 {
+			return nil, fmt.Errorf("the size of an id range must be at least 1, given %q", field)
+		}
+		ranges = append(ranges, IDRange{NamespaceID: numbers[0], HostID: numbers[1], Size: numbers[2]})
+	}
+	return ranges, nil
+}
+
+// SubordinateIDRanges returns the ranges a rootless engine maps the subordinate ids of a user onto, given the contents
+// of /etc/subuid (or /etc/subgid): the subordinate ids become the ids from 1 onwards within the namespace. The user's
+// own id (namespace id 0) is deliberately not mapped, as files owned by it are as likely to be owned by that id
+// within the image.
+func SubordinateIDRanges(contents []byte, names ...string) []IDRange {
+	var ranges []IDRange
+	namespaceID := 1
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		parts := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(parts) != 3 || !containsString(names, parts[0]) {
+			continue
+		}
+		start, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		size, err := strconv.Atoi(parts[2])
+		if err != nil || size < 1 {
+			continue
+		}
+		ranges = append(ranges, IDRange{NamespaceID: namespaceID, HostID: start, Size: size})
+		namespaceID += size
+	}
+	return ranges
+}
+
+// LoadIDMapping builds the mapping from the uid and gid ranges given (see ParseIDRanges), where "auto" reads the
+// subordinate ids of the current user and an empty value maps nothing.
+func LoadIDMapping(uidMap, gidMap string) (IDMapping, error) {
+	var mapping IDMapping
+	var err error
+	if mapping.Uids, err = loadIDRanges(uidMap, "/etc/subuid", currentUserNames()); err != nil {
+		return mapping, fmt.Errorf("invalid uid map: %v", err)
+	}
+	if mapping.Gids, err = loadIDRanges(gidMap, "/etc/subgid", currentUserNames()); err != nil {
+		return mapping, fmt.Errorf("invalid gid map: %v", err)
+	}
+	return mapping, nil
+}
+
+func loadIDRanges(value, subordinateFile string, names []string) ([]IDRange, error) {
+	if strings.TrimSpace(value) != IDMappingAuto {
+		return ParseIDRanges(value)
+	}
+	contents, err := ioutil.ReadFile(subordinateFile)
+	if err != nil {
+		// no subordinate ids, so no rootless engine to shift them
+		return nil, nil
+	}
+	return SubordinateIDRanges(contents, names...), nil
+}
+
+// currentUserNames lists the ways the subordinate id files may name the current user (by name and by id).
+func currentUserNames() []string {
+	names := []string{strconv.Itoa(os.Getuid())}
+	if current, err := user.Current(); err == nil {
+		names = append(names, current.Username)
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// toNamespace returns the namespace id of a host id, when the ranges map it.
+func toNamespace(ranges []IDRange, hostID int) (int, bool) {
+	for _, idRange := range ranges {
+		if hostID >= idRange.HostID && hostID < idRange.HostID+idRange.Size {
+			return idRange.NamespaceID + hostID - idRange.HostID, true
+		}
+	}
+	return hostID, false
+}
+
+// MappedOwner returns the owner of the file within the user namespace of the given id mapping, and whether the
+// mapping shifted either id (the ids are returned as they are otherwise).
+func (data *FileInfo) MappedOwner(mapping IDMapping) (uid, gid int, mapped bool) {
+	uid, uidMapped := toNamespace(mapping.Uids, data.Uid)
+	gid, gidMapped := toNamespace(mapping.Gids, data.Gid)
+	return uid, gid, uidMapped || gidMapped
+}
@@ -0,0 +1,71 @@
+package filetree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIDRanges(t *testing.T) {
+	ranges, err := ParseIDRanges("0:100000:65536, 65536:200000:10")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []IDRange{{NamespaceID: 0, HostID: 100000, Size: 65536}, {NamespaceID: 65536, HostID: 200000, Size: 10}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, got %v", expected, ranges)
+	}
+
+	if ranges, err := ParseIDRanges(""); err != nil || ranges != nil {
+		t.Errorf("expected no ranges, got %v (%v)", ranges, err)
+	}
+	for _, value := range []string{"0:100000", "a:1:2", "0:-1:2", "0:1:0"} {
+		if _, err := ParseIDRanges(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestSubordinateIDRanges(t *testing.T) {
+	contents := []byte("alice:100000:65536\nbob:165536:65536\n1000:231072:1000\n")
+	ranges := SubordinateIDRanges(contents, "1000", "alice")
+	expected := []IDRange{{NamespaceID: 1, HostID: 100000, Size: 65536}, {NamespaceID: 65537, HostID: 231072, Size: 1000}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, got %v", expected, ranges)
+	}
+}
+
+func TestMappedOwner(t *testing.T) {
+	mapping := IDMapping{
+		Uids: []IDRange{{NamespaceID: 1, HostID: 100000, Size: 65536}},
+		Gids: []IDRange{{NamespaceID: 1, HostID: 100000, Size: 65536}},
+	}
+
+	cases := []struct {
+		uid, gid                 int
+		expectedUID, expectedGID int
+		expectedMapped           bool
+	}{
+		{uid: 100000, gid: 100032, expectedUID: 1, expectedGID: 33, expectedMapped: true},
+		{uid: 1000, gid: 100000, expectedUID: 1000, expectedGID: 1, expectedMapped: true},
+		{uid: 0, gid: 0, expectedUID: 0, expectedGID: 0, expectedMapped: false},
+		{uid: 165536, gid: 0, expectedUID: 165536, expectedGID: 0, expectedMapped: false},
+	}
+	for _, test := range cases {
+		info := FileInfo{Uid: test.uid, Gid: test.gid}
+		uid, gid, mapped := info.MappedOwner(mapping)
+		if uid != test.expectedUID || gid != test.expectedGID || mapped != test.expectedMapped {
+			t.Errorf("%d:%d: expected %d:%d (%v), got %d:%d (%v)", test.uid, test.gid, test.expectedUID, test.expectedGID, test.expectedMapped, uid, gid, mapped)
+		}
+	}
+
+	attributes := (&FileInfo{Uid: 100000, Gid: 100000}).Attributes(mapping)
+	expected := &Inspection{Inspector: "attributes", Fields: []InspectionField{
+		{Name: "Owner", Value: "100000:100000 in the layer, 1:1 in the user namespace"},
+	}}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, attributes)
+	}
+	if attributes := (&FileInfo{Uid: 0, Gid: 0}).Attributes(mapping); attributes != nil {
+		t.Errorf("expected no attributes for an unmapped owner, got %+v", attributes)
+	}
+}
@@ -0,0 +1,120 @@
+package filetree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultIgnoreFile is the ignore file read from the working directory (when there is one).
+const DefaultIgnoreFile = ".diveignore"
+
+// IgnoreList holds the patterns of a .diveignore file (gitignore syntax): the paths they match are left out of the
+// efficiency score and the wasted space reports.
+type IgnoreList struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	text     string
+	segments []string
+	// a pattern starting with "!" includes again what an earlier pattern ignored
+	negate bool
+	// a pattern ending with "/" only matches directories (and thus everything beneath them)
+	dirOnly bool
+}
+
+// ParseIgnoreList reads the patterns of a .diveignore file, one per line: blank lines and lines starting with # are
+// skipped, a leading ! negates the pattern, a trailing / only matches directories, and * ? [] and ** are matched as in
+// a .gitignore file. A pattern with a slash (other than a trailing one) is anchored at the root of the image, others
+// match at any depth.
+func ParseIgnoreList(reader io.Reader) (*IgnoreList, error) {
+	list := &IgnoreList{}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		pattern := ignorePattern{text: text}
+		if strings.HasPrefix(text, "!") {
+			pattern.negate = true
+			text = text[1:]
+		} else if strings.HasPrefix(text, `\#`) || strings.HasPrefix(text, `\!`) {
+			text = text[1:]
+		}
+		if strings.HasSuffix(text, "/") {
+			pattern.dirOnly = true
+			text = strings.TrimRight(text, "/")
+		}
+		anchored := strings.Contains(text, "/")
+		text = strings.TrimPrefix(text, "/")
+		if text == "" {
+			return nil, fmt.Errorf("line %d: empty pattern %q", line, pattern.text)
+		}
+
+		pattern.segments = strings.Split(text, "/")
+		if !anchored {
+			pattern.segments = append([]string{"**"}, pattern.segments...)
+		}
+		if err := validateSegments(pattern.segments); err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %v", line, pattern.text, err)
+		}
+		list.patterns = append(list.patterns, pattern)
+	}
+	return list, scanner.Err()
+}
+
+// LoadIgnoreList reads the ignore file at the given path.
+func LoadIgnoreList(filePath string) (*IgnoreList, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the ignore file: %v", err)
+	}
+	defer file.Close()
+
+	list, err := ParseIgnoreList(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore file %s: %v", filePath, err)
+	}
+	return list, nil
+}
+
+// Len returns the number of patterns.
+func (list *IgnoreList) Len() int {
+	if list == nil {
+		return 0
+	}
+	return len(list.patterns)
+}
+
+// Ignored indicates if the path (absolute within the image) is ignored: the last pattern matching it decides, and
+// everything beneath an ignored directory is ignored (as with git, a file within it cannot be included again).
+func (list *IgnoreList) Ignored(filePath string, isDir bool) bool {
+	if list.Len() == 0 {
+		return false
+	}
+	names := strings.Split(strings.Trim(filePath, "/"), "/")
+	for depth := 1; depth < len(names); depth++ {
+		if list.matches(names[:depth], true) {
+			return true
+		}
+	}
+	return list.matches(names, isDir)
+}
+
+func (list *IgnoreList) matches(names []string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range list.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(pattern.segments, names) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
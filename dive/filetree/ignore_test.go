@@ -0,0 +1,62 @@
+package filetree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIgnoreList(t *testing.T) {
+	list, err := ParseIgnoreList(strings.NewReader(`
+# shipped on purpose
+/usr/share/zoneinfo
+*.pyc
+!keep.pyc
+cache/
+/opt/**/tmp
+\#literal
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Len() != 6 {
+		t.Errorf("expected 6 patterns, got %d", list.Len())
+	}
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{path: "/usr/share/zoneinfo", isDir: true, ignored: true},
+		{path: "/usr/share/zoneinfo/Europe/Paris", ignored: true},
+		{path: "/usr/local/share/zoneinfo/UTC", ignored: false},
+		{path: "/app/module.pyc", ignored: true},
+		{path: "/app/keep.pyc", ignored: false},
+		{path: "/var/cache", isDir: true, ignored: true},
+		{path: "/var/cache/apt/archives/lock", ignored: true},
+		// only directories match a pattern with a trailing slash
+		{path: "/etc/cache", ignored: false},
+		{path: "/opt/tmp/file", ignored: true},
+		{path: "/opt/app/build/tmp", isDir: true, ignored: true},
+		{path: "/tmp/file", ignored: false},
+		{path: "/#literal", ignored: true},
+	}
+	for _, test := range cases {
+		if actual := list.Ignored(test.path, test.isDir); actual != test.ignored {
+			t.Errorf("%s: expected ignored=%v, got %v", test.path, test.ignored, actual)
+		}
+	}
+
+	var none *IgnoreList
+	if none.Ignored("/usr/share/zoneinfo", true) {
+		t.Errorf("expected nothing to be ignored without a list")
+	}
+}
+
+func TestParseIgnoreList_Invalid(t *testing.T) {
+	for _, text := range []string{"/", "!", "file[.txt"} {
+		if _, err := ParseIgnoreList(strings.NewReader(text)); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package filetree
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/sirupsen/logrus"
+)
+
+// the largest file read into memory for the inspectors (larger files are not inspected)
+const maxInspectedBytes = 256 * 1024 * 1024
+
+// Inspector reads the contents of a kind of file (e.g. ELF binaries or jar files) while the layer trees are built, so
+// that what it finds can be shown along with the file.
+type Inspector interface {
+	// Name identifies the inspector (and the inspections it makes).
+	Name() string
+	// Accepts indicates if the file is of the kind the inspector reads, by the tar header alone (the contents are only
+	// read into memory for files that some inspector accepts).
+	Accepts(header *tar.Header) bool
+	// Inspect reads the file contents, returning nil when the file turns out not to be of the kind after all.
+	Inspect(path string, contents io.ReaderAt, size int64) (*Inspection, error)
+}
+
+// Inspection is what an inspector found out about a file.
+type Inspection struct {
+	Inspector string
+	Fields    []InspectionField
+}
+
+// InspectionField is a single finding of an inspection (e.g. "Stripped: no").
+type InspectionField struct {
+	Name  string
+	Value string
+}
+
+// Add appends a finding, skipping empty values.
+func (i *Inspection) Add(name, value string) {
+	if value == "" {
+		return
+	}
+	i.Fields = append(i.Fields, InspectionField{Name: name, Value: value})
+}
+
+// InspectorNames lists the names of the given inspectors (what the layer trees hold depends on the inspectors that run
+// while they are built).
+func InspectorNames(inspectors []Inspector) []string {
+	names := make([]string, 0, len(inspectors))
+	for _, inspector := range inspectors {
+		names = append(names, inspector.Name())
+	}
+	return names
+}
+
+// acceptingInspectors returns the inspectors (of the given ones) that read the file with the given header.
+func acceptingInspectors(header *tar.Header, inspectors []Inspector) []Inspector {
+	if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+		return nil
+	}
+	if header.Size <= 0 || header.Size > maxInspectedBytes {
+		return nil
+	}
+
+	var accepting []Inspector
+	for _, inspector := range inspectors {
+		if inspector.Accepts(header) {
+			accepting = append(accepting, inspector)
+		}
+	}
+	return accepting
+}
+
+// inspect reads the file contents into memory and runs the given inspectors over them, returning the contents hash
+// along with the inspections made. Inspectors that fail are logged and skipped.
+func inspect(reader io.Reader, path string, accepting []Inspector) (uint64, []Inspection, error) {
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	hash, err := getHashFromReader(bytes.NewReader(contents))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var inspections []Inspection
+	for _, inspector := range accepting {
+		inspection, err := inspector.Inspect(path, bytes.NewReader(contents), int64(len(contents)))
+		if err != nil {
+			logrus.Debugf("unable to inspect %s with the %s inspector: %+v", path, inspector.Name(), err)
+			continue
+		}
+		if inspection == nil {
+			continue
+		}
+		inspection.Inspector = inspector.Name()
+		inspections = append(inspections, *inspection)
+	}
+	return hash, inspections, nil
+}
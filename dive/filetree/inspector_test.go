@@ -0,0 +1,75 @@
+package filetree
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// lengthInspector reports the length of ".txt" files
+type lengthInspector struct{}
+
+func (lengthInspector) Name() string {
+	return "length"
+}
+
+func (lengthInspector) Accepts(header *tar.Header) bool {
+	return strings.HasSuffix(header.Name, ".txt")
+}
+
+func (lengthInspector) Inspect(path string, contents io.ReaderAt, size int64) (*Inspection, error) {
+	inspection := &Inspection{}
+	inspection.Add("Length", strings.Repeat("#", int(size)))
+	inspection.Add("Empty", "")
+	return inspection, nil
+}
+
+// readTarFile reads the file info of a tar holding the file, with the given inspectors.
+func readTarFile(t *testing.T, header *tar.Header, content string, inspectors ...Inspector) FileInfo {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	header.Size = int64(len(content))
+	if err := writer.WriteHeader(header); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	reader := tar.NewReader(&buffer)
+	read, err := reader.Next()
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	info, err := NewFileInfoFromTarHeader(reader, read, read.Name, inspectors)
+	if err != nil {
+		t.Fatalf("unable to read the file info: %v", err)
+	}
+	if rest, _ := ioutil.ReadAll(reader); len(rest) != 0 {
+		t.Errorf("expected the file to be read entirely, %d bytes are left", len(rest))
+	}
+	return info
+}
+
+func TestNewFileInfoFromTarHeaderInspections(t *testing.T) {
+	inspected := readTarFile(t, &tar.Header{Name: "notes.txt", Typeflag: tar.TypeReg, Mode: 0644}, "hello", lengthInspector{})
+	plain := readTarFile(t, &tar.Header{Name: "notes.md", Typeflag: tar.TypeReg, Mode: 0644}, "hello", lengthInspector{})
+
+	if inspected.hash != plain.hash {
+		t.Errorf("expected inspected files to be hashed the same, got %d and %d", inspected.hash, plain.hash)
+	}
+	if len(plain.Inspections) != 0 {
+		t.Errorf("expected no inspections, got %+v", plain.Inspections)
+	}
+	expected := []Inspection{{Inspector: "length", Fields: []InspectionField{{Name: "Length", Value: "#####"}}}}
+	if len(inspected.Inspections) != 1 || inspected.Inspections[0].Inspector != "length" ||
+		len(inspected.Inspections[0].Fields) != 1 || inspected.Inspections[0].Fields[0] != expected[0].Fields[0] {
+		t.Errorf("expected inspections %+v, got %+v", expected, inspected.Inspections)
+	}
+}
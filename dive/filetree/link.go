@@ -0,0 +1,69 @@
+package filetree
+
+import (
+	"archive/tar"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// the same limit as linux (MAXSYMLINKS) before giving up on a chain of symlinks
+const maxLinkHops = 40
+
+// IsLink indicates if the FileNode is a symlink or a hardlink.
+func (node *FileNode) IsLink() bool {
+	return node.Data.FileInfo.TypeFlag == tar.TypeSymlink || node.Data.FileInfo.TypeFlag == tar.TypeLink
+}
+
+// LinkTarget returns the absolute path the symlink or hardlink points to (without resolving any further links).
+func (node *FileNode) LinkTarget() string {
+	linkname := node.Data.FileInfo.Linkname
+	switch node.Data.FileInfo.TypeFlag {
+	case tar.TypeSymlink:
+		if !path.IsAbs(linkname) {
+			// relative symlinks are relative to the directory containing the link
+			linkname = path.Join(path.Dir(node.Path()), linkname)
+		}
+	case tar.TypeLink:
+		// hardlink names are relative to the root of the layer archive
+		linkname = "/" + linkname
+	default:
+		return ""
+	}
+	return path.Clean(linkname)
+}
+
+// ResolveLink finds the node that the given symlink or hardlink points to, following any symlinks along the way
+// (including symlinked parent directories, e.g. /lib -> /usr/lib).
+func (tree *FileTree) ResolveLink(node *FileNode) (*FileNode, error) {
+	if !node.IsLink() {
+		return nil, fmt.Errorf("not a link: %s", node.Path())
+	}
+	hops := 1
+	return tree.resolvePath(node.LinkTarget(), &hops)
+}
+
+// resolvePath walks the given absolute path from the root, substituting the target of every symlink encountered.
+func (tree *FileTree) resolvePath(target string, hops *int) (*FileNode, error) {
+	names := strings.Split(strings.Trim(path.Clean(target), "/"), "/")
+	current := tree.Root
+	for idx, name := range names {
+		if name == "" {
+			continue
+		}
+		child, exists := current.Children[name]
+		if !exists || child.IsWhiteout() {
+			return nil, fmt.Errorf("link target does not exist: %s", target)
+		}
+		if child.Data.FileInfo.TypeFlag == tar.TypeSymlink {
+			*hops++
+			if *hops > maxLinkHops {
+				return nil, fmt.Errorf("too many levels of symbolic links: %s", target)
+			}
+			remaining := append([]string{child.LinkTarget()}, names[idx+1:]...)
+			return tree.resolvePath(path.Join(remaining...), hops)
+		}
+		current = child
+	}
+	return current, nil
+}
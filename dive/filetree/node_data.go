@@ -1,12 +1,11 @@
 package filetree
 
-var GlobalFileTreeCollapse bool
-
 // NodeData is the payload for a FileNode
 type NodeData struct {
 	ViewInfo ViewInfo
 	FileInfo FileInfo
 	DiffType DiffType
+	Whiteout WhiteoutMark
 }
 
 // NewNodeData creates an empty NodeData struct for a FileNode
@@ -24,5 +23,6 @@ func (data *NodeData) Copy() *NodeData {
 		ViewInfo: *data.ViewInfo.Copy(),
 		FileInfo: *data.FileInfo.Copy(),
 		DiffType: data.DiffType,
+		Whiteout: data.Whiteout,
 	}
 }
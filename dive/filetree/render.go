@@ -0,0 +1,60 @@
+package filetree
+
+import "github.com/fatih/color"
+
+// RenderOptions are how the files of a tree are shown. They are held by the UI (which picks them for the terminal it
+// runs on) and given to what renders the trees, rather than set for the whole package.
+type RenderOptions struct {
+	// the user namespace the owners of the files are shown within, along with the ids stored in the layers (no
+	// mapping by default)
+	IDMapping IDMapping
+	// every line starts with the marker of the DiffType of its file (see DiffType.Marker), for terminals without
+	// colors and for readers who cannot tell the colors apart
+	DiffMarkers bool
+	// how the names that do not fit the width they are given are shortened
+	TruncateMode TruncateMode
+	// the characters the branches of the tree and the decorations of the files are drawn with
+	Glyphs Glyphs
+	// the colors the files are shown in, by DiffType (nil, or a missing DiffType, uses the default colors)
+	DiffColors map[DiffType]*color.Color
+}
+
+// DefaultRenderOptions are the options the trees are rendered with when none are given.
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{TruncateMode: TruncateMiddle, Glyphs: UnicodeGlyphs}
+}
+
+// Truncate shortens the (uncolored) text to the given width in terminal cells, replacing what is left out with an
+// ellipsis (as the truncate mode says). Wide characters are never split, so the result may be a cell narrower than the
+// width.
+func (options RenderOptions) Truncate(text string, width int) string {
+	return truncate(text, width, options.TruncateMode, options.Glyphs.Ellipsis)
+}
+
+// truncateLink shortens the name of a link followed by its target to the given width (unless 0). The target is
+// shortened first, never the arrow: when the name leaves no room for the arrow and some of the target, the target is
+// left out and the name alone is shortened.
+func (options RenderOptions) truncateLink(name, target string, width int) string {
+	arrow := options.Glyphs.LinkArrow
+	display := name + arrow + target
+	if width <= 0 || options.TruncateMode == TruncateNone || DisplayWidth(display) <= width {
+		return display
+	}
+	room := width - DisplayWidth(name) - DisplayWidth(arrow)
+	if room <= DisplayWidth(options.Glyphs.Ellipsis) {
+		return options.Truncate(name, width)
+	}
+	return name + arrow + options.Truncate(target, room)
+}
+
+// Colorize renders the given text in the color of the DiffType (as the file tree shows it).
+func (options RenderOptions) Colorize(diff DiffType, text string) string {
+	return options.diffColor(diff).Sprint(text)
+}
+
+func (options RenderOptions) diffColor(diff DiffType) *color.Color {
+	if c, ok := options.DiffColors[diff]; ok {
+		return c
+	}
+	return diffTypeColor[diff]
+}
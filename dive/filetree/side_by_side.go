@@ -0,0 +1,94 @@
+package filetree
+
+import "sort"
+
+// SideBySideRow is a line of two trees laid out next to each other: the entry at the same path on either side (nil
+// when a side has no such entry) and how the right side differs from the left side. A directory holding a difference
+// is Modified.
+type SideBySideRow struct {
+	Path  string
+	Name  string
+	Depth int
+	Left  *FileNode
+	Right *FileNode
+	Diff  DiffType
+}
+
+// SideBySide aligns the entries of two trees by path, depth first with the children of each directory sorted by name,
+// so that a row shows the same path on both sides. Whiteout markers are left out.
+func SideBySide(left, right *FileTree) []SideBySideRow {
+	var leftRoot, rightRoot *FileNode
+	if left != nil {
+		leftRoot = left.Root
+	}
+	if right != nil {
+		rightRoot = right.Root
+	}
+	var rows []SideBySideRow
+	sideBySideChildren(&rows, leftRoot, rightRoot, "", 0)
+	return rows
+}
+
+// sideBySideChildren appends the rows of the children of the given directories, returning whether any of them differs.
+func sideBySideChildren(rows *[]SideBySideRow, left, right *FileNode, parentPath string, depth int) bool {
+	names := make(map[string]struct{})
+	for _, node := range []*FileNode{left, right} {
+		if node == nil {
+			continue
+		}
+		for name, child := range node.Children {
+			if !child.IsWhiteout() {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changed := false
+	for _, name := range sorted {
+		row := SideBySideRow{
+			Path:  parentPath + "/" + name,
+			Name:  name,
+			Depth: depth,
+			Left:  sideBySideChild(left, name),
+			Right: sideBySideChild(right, name),
+		}
+		row.Diff = row.Left.compare(row.Right)
+
+		idx := len(*rows)
+		*rows = append(*rows, row)
+		if sideBySideChildren(rows, row.Left, row.Right, row.Path, depth+1) && row.Diff == Unmodified {
+			(*rows)[idx].Diff = Modified
+		}
+		if (*rows)[idx].Diff != Unmodified {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func sideBySideChild(parent *FileNode, name string) *FileNode {
+	if parent == nil {
+		return nil
+	}
+	child := parent.Children[name]
+	if child == nil || child.IsWhiteout() {
+		return nil
+	}
+	return child
+}
+
+// ChangedRows keeps the rows that differ (which includes the directories holding them).
+func ChangedRows(rows []SideBySideRow) []SideBySideRow {
+	var changed []SideBySideRow
+	for _, row := range rows {
+		if row.Diff != Unmodified {
+			changed = append(changed, row)
+		}
+	}
+	return changed
+}
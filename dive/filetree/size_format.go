@@ -0,0 +1,100 @@
+package filetree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// SizeFormat is how the size column of a tree shows the sizes.
+type SizeFormat string
+
+const (
+	// SizeSI shows decimal units (e.g. 1.2 MB), the default
+	SizeSI SizeFormat = "si"
+	// SizeIEC shows binary units (e.g. 1.1 MiB)
+	SizeIEC SizeFormat = "iec"
+	// SizeBytes shows the raw number of bytes
+	SizeBytes SizeFormat = "bytes"
+	// SizeLayerPercent shows the share of the size of the selected layer
+	SizeLayerPercent SizeFormat = "layer-percent"
+	// SizeImagePercent shows the share of the size of the image
+	SizeImagePercent SizeFormat = "image-percent"
+)
+
+// SizeFormats lists the size formats, in the order they are cycled through.
+var SizeFormats = []string{string(SizeSI), string(SizeIEC), string(SizeBytes), string(SizeLayerPercent), string(SizeImagePercent)}
+
+// ParseSizeFormat parses the name of a size format (an empty name is the default format).
+func ParseSizeFormat(value string) (SizeFormat, error) {
+	if value == "" {
+		return SizeSI, nil
+	}
+	for _, name := range SizeFormats {
+		if value == name {
+			return SizeFormat(name), nil
+		}
+	}
+	return SizeSI, fmt.Errorf("unknown size format %q (expected %s)", value, strings.Join(SizeFormats, ", "))
+}
+
+// Next returns the size format that follows this one (the first after the last).
+func (format SizeFormat) Next() SizeFormat {
+	for idx, name := range SizeFormats {
+		if string(format) == name {
+			return SizeFormat(SizeFormats[(idx+1)%len(SizeFormats)])
+		}
+	}
+	return SizeSI
+}
+
+// Label is the header of the size column.
+func (format SizeFormat) Label() string {
+	switch format {
+	case SizeBytes:
+		return "Bytes"
+	case SizeLayerPercent:
+		return "% Layer"
+	case SizeImagePercent:
+		return "% Image"
+	}
+	return "Size"
+}
+
+// Description tells how the sizes are shown.
+func (format SizeFormat) Description() string {
+	switch format {
+	case SizeIEC:
+		return "binary units"
+	case SizeBytes:
+		return "bytes"
+	case SizeLayerPercent:
+		return "percentage of the layer"
+	case SizeImagePercent:
+		return "percentage of the image"
+	}
+	return "decimal units"
+}
+
+// FormatSize renders the given size, the percentages relative to the given total (unknown when the total is not
+// positive).
+func FormatSize(size int64, format SizeFormat, total int64) string {
+	switch format {
+	case SizeIEC:
+		return humanize.IBytes(uint64(size))
+	case SizeBytes:
+		return strconv.FormatInt(size, 10)
+	case SizeLayerPercent, SizeImagePercent:
+		if total <= 0 {
+			return "-"
+		}
+		percent := 100 * float64(size) / float64(total)
+		if size > 0 && percent < 0.1 {
+			return "<0.1%"
+		}
+		return fmt.Sprintf("%.1f%%", percent)
+	}
+	return humanize.Bytes(uint64(size))
+}
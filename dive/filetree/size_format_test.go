@@ -0,0 +1,47 @@
+package filetree
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	cases := []struct {
+		size     int64
+		format   SizeFormat
+		total    int64
+		expected string
+	}{
+		{size: 1200000, format: SizeSI, expected: "1.2 MB"},
+		{size: 1200000, format: "", expected: "1.2 MB"},
+		{size: 1200000, format: SizeIEC, expected: "1.1 MiB"},
+		{size: 1200000, format: SizeBytes, expected: "1200000"},
+		{size: 250, format: SizeLayerPercent, total: 1000, expected: "25.0%"},
+		{size: 1, format: SizeImagePercent, total: 1000000, expected: "<0.1%"},
+		{size: 0, format: SizeImagePercent, total: 1000000, expected: "0.0%"},
+		{size: 250, format: SizeLayerPercent, total: 0, expected: "-"},
+	}
+	for _, c := range cases {
+		if actual := FormatSize(c.size, c.format, c.total); actual != c.expected {
+			t.Errorf("%d as %q of %d: expected %q, got %q", c.size, c.format, c.total, c.expected, actual)
+		}
+	}
+}
+
+func TestParseSizeFormat(t *testing.T) {
+	for _, name := range SizeFormats {
+		format, err := ParseSizeFormat(name)
+		if err != nil || string(format) != name {
+			t.Errorf("%s: unexpected result %q (%v)", name, format, err)
+		}
+	}
+	if _, err := ParseSizeFormat("kb"); err == nil {
+		t.Errorf("expected an error for an unknown size format")
+	}
+
+	// cycling goes through every format and back
+	format := SizeSI
+	for range SizeFormats {
+		format = format.Next()
+	}
+	if format != SizeSI {
+		t.Errorf("expected to cycle back to %q, got %q", SizeSI, format)
+	}
+}
@@ -0,0 +1,81 @@
+package filetree
+
+import "fmt"
+
+// TreeSnapshot is a copy of a tree that can be stored (e.g. with encoding/gob) and turned back into the tree it was
+// taken from, to keep parsed layers around between sessions.
+type TreeSnapshot struct {
+	Name     string
+	FileSize uint64
+	// the whiteout mark of the root (an opaque marker at the top of the layer)
+	RootWhiteout WhiteoutMark
+	// every node, each one after its parent
+	Nodes []SnapshotNode
+}
+
+// SnapshotNode is a node of a TreeSnapshot.
+type SnapshotNode struct {
+	// the index of the parent within TreeSnapshot.Nodes (-1 for the children of the root)
+	Parent   int
+	Name     string
+	Info     FileInfo
+	Hash     uint64
+	Whiteout WhiteoutMark
+}
+
+// Snapshot copies the tree into a TreeSnapshot (the view state, such as collapsed directories, is left out).
+func (tree *FileTree) Snapshot() (*TreeSnapshot, error) {
+	snapshot := &TreeSnapshot{
+		Name:         tree.Name,
+		FileSize:     tree.FileSize,
+		RootWhiteout: tree.Root.Data.Whiteout,
+		Nodes:        make([]SnapshotNode, 0, tree.Size),
+	}
+	indexes := make(map[*FileNode]int, tree.Size)
+	err := tree.VisitDepthParentFirst(func(node *FileNode) error {
+		parent := -1
+		if node.Parent != tree.Root {
+			parent = indexes[node.Parent]
+		}
+		indexes[node] = len(snapshot.Nodes)
+		snapshot.Nodes = append(snapshot.Nodes, SnapshotNode{
+			Parent:   parent,
+			Name:     node.Name,
+			Info:     node.Data.FileInfo,
+			Hash:     node.Data.FileInfo.hash,
+			Whiteout: node.Data.Whiteout,
+		})
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Tree rebuilds the tree the snapshot was taken from (as a new tree, with an ID of its own).
+func (snapshot *TreeSnapshot) Tree() (*FileTree, error) {
+	tree := NewFileTree()
+	tree.Name = snapshot.Name
+	tree.FileSize = snapshot.FileSize
+	tree.Root.Data.Whiteout = snapshot.RootWhiteout
+
+	nodes := make([]*FileNode, len(snapshot.Nodes))
+	for idx, entry := range snapshot.Nodes {
+		parent := tree.Root
+		if entry.Parent >= 0 {
+			if entry.Parent >= idx {
+				return nil, fmt.Errorf("node %d (%s) is listed before its parent", idx, entry.Name)
+			}
+			parent = nodes[entry.Parent]
+		}
+		node := parent.AddChild(entry.Name, entry.Info)
+		if node == nil {
+			return nil, fmt.Errorf("could not add node %d (%s)", idx, entry.Name)
+		}
+		node.Data.FileInfo.hash = entry.Hash
+		node.Data.Whiteout = entry.Whiteout
+		nodes[idx] = node
+	}
+	return tree, nil
+}
@@ -0,0 +1,59 @@
+package filetree
+
+import "testing"
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tree := NewFileTree()
+	tree.Name = "layer.tar"
+	tree.FileSize = 42
+	for path, info := range map[string]FileInfo{
+		"/etc/nginx/nginx.conf": {Path: "/etc/nginx/nginx.conf", Size: 40, Mode: 0644, hash: 1234},
+		"/etc/nginx/public":     {Path: "/etc/nginx/public", Mode: 0755, IsDir: true},
+		"/usr/bin/app":          {Path: "/usr/bin/app", Size: 2, Inspections: []Inspection{{Inspector: "elf", Fields: []InspectionField{{Name: "Stripped", Value: "no"}}}}},
+		"/var/.wh..wh..opq":     {},
+		"/.wh..wh..opq":         {},
+	} {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("unable to add %s: %v", path, err)
+		}
+	}
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		t.Fatalf("unable to take a snapshot: %v", err)
+	}
+	rebuilt, err := snapshot.Tree()
+	if err != nil {
+		t.Fatalf("unable to rebuild the tree: %v", err)
+	}
+
+	if rebuilt.Name != tree.Name || rebuilt.FileSize != tree.FileSize || rebuilt.Size != tree.Size {
+		t.Errorf("expected %s (%d bytes, %d nodes), got %s (%d bytes, %d nodes)", tree.Name, tree.FileSize, tree.Size, rebuilt.Name, rebuilt.FileSize, rebuilt.Size)
+	}
+	if expected, actual := tree.String(true), rebuilt.String(true); expected != actual {
+		t.Errorf("expected the tree:\n%s\ngot:\n%s", expected, actual)
+	}
+	if rebuilt.Id == tree.Id {
+		t.Errorf("expected the rebuilt tree to have an id of its own")
+	}
+
+	node, err := rebuilt.GetNode("/etc/nginx/nginx.conf")
+	if err != nil || node.Data.FileInfo.hash != 1234 || node.Data.FileInfo.Path != "/etc/nginx/nginx.conf" {
+		t.Errorf("expected the file info to be kept, got %+v (%v)", node, err)
+	}
+	node, err = rebuilt.GetNode("/usr/bin/app")
+	if err != nil || len(node.Data.FileInfo.Inspections) != 1 || node.Data.FileInfo.Inspections[0].Fields[0].Value != "no" {
+		t.Errorf("expected the inspections to be kept, got %+v (%v)", node, err)
+	}
+	node, err = rebuilt.GetNode("/var")
+	if err != nil || node.Data.Whiteout != WhiteoutOpaque || rebuilt.Root.Data.Whiteout != WhiteoutOpaque {
+		t.Errorf("expected the opaque markers to be kept")
+	}
+}
+
+func TestSnapshotTreeOutOfOrder(t *testing.T) {
+	snapshot := &TreeSnapshot{Nodes: []SnapshotNode{{Parent: 1, Name: "child"}, {Parent: -1, Name: "parent"}}}
+	if _, err := snapshot.Tree(); err == nil {
+		t.Errorf("expected a node listed before its parent to fail")
+	}
+}
@@ -0,0 +1,64 @@
+package filetree
+
+import (
+	"archive/tar"
+	"os"
+	"strings"
+)
+
+// TarHeader rebuilds the tar header of the entry under the given name (relative to the root of the archive). The
+// contents of a sparse file are written in full, and hardlinks (like directories and links) carry no contents.
+func (data *FileInfo) TarHeader(name string) *tar.Header {
+	header := &tar.Header{
+		Typeflag: data.TypeFlag,
+		Name:     name,
+		Linkname: data.Linkname,
+		Mode:     tarMode(data.Mode),
+		Uid:      data.Uid,
+		Gid:      data.Gid,
+		ModTime:  data.ModTime,
+		Devmajor: data.Devmajor,
+		Devminor: data.Devminor,
+		Format:   tar.FormatPAX,
+	}
+	switch {
+	case data.IsDir:
+		header.Typeflag = tar.TypeDir
+		if !strings.HasSuffix(header.Name, "/") {
+			header.Name += "/"
+		}
+	case data.TypeFlag == tar.TypeReg || data.TypeFlag == tar.TypeRegA:
+		header.Typeflag = tar.TypeReg
+		header.Size = data.Size
+	}
+	if header.Typeflag == tar.TypeLink {
+		// hardlinks name their target relative to the root of the archive, like the entries do
+		header.Linkname = strings.TrimPrefix(header.Linkname, "/")
+	}
+
+	if len(data.Xattrs) > 0 || len(data.PAXRecords) > 0 {
+		header.PAXRecords = make(map[string]string)
+		for key, value := range data.PAXRecords {
+			header.PAXRecords[key] = value
+		}
+		for name, value := range data.Xattrs {
+			header.PAXRecords[xattrRecordPrefix+name] = value
+		}
+	}
+	return header
+}
+
+// tarMode converts the mode of an entry back to the mode bits of a tar header.
+func tarMode(mode os.FileMode) int64 {
+	bits := int64(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
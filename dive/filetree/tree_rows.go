@@ -0,0 +1,238 @@
+package filetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// TreeRows is a flattened index of the visible lines of a tree. The index is built once, while lines (and directory
+// sizes) are only rendered when requested and then memoized, so drawing a window of a tree with millions of nodes
+// only costs as much as the window itself. The index must be rebuilt whenever the tree (or its view state) changes.
+type TreeRows struct {
+	rows []renderParams
+	// memoized rendered lines, indexed by [showAttributes][row]
+	lines [2][]string
+	// memoized directory sizes: all descendants, and only descendants that were not removed
+	allSizes  map[*FileNode]int64
+	keptSizes map[*FileNode]int64
+	// memoized numbers of files beneath directories: all descendants, and only descendants that were not removed
+	allFiles  map[*FileNode]int
+	keptFiles map[*FileNode]int
+	// how the sizes are shown, the percentages relative to sizeTotal
+	sizeFormat SizeFormat
+	sizeTotal  int64
+	// show the number of files within each directory after the attributes (see FileCountFormat)
+	showFileCounts bool
+	// the width (in terminal cells) the lines are fitted within, by shortening the names (0 leaves them whole)
+	width int
+	// how the lines are drawn
+	options RenderOptions
+}
+
+// VisibleRows indexes every visible node of the tree in display order.
+func (tree *FileTree) VisibleRows() *TreeRows {
+	rows := &TreeRows{
+		allSizes:  make(map[*FileNode]int64),
+		keptSizes: make(map[*FileNode]int64),
+		allFiles:  make(map[*FileNode]int),
+		keptFiles: make(map[*FileNode]int),
+		options:   DefaultRenderOptions(),
+	}
+	tree.visitRenderParams(-1, func(row int, params renderParams) {
+		rows.rows = append(rows.rows, params)
+	})
+	rows.lines[0] = make([]string, len(rows.rows))
+	rows.lines[1] = make([]string, len(rows.rows))
+	return rows
+}
+
+// SetSizeFormat shows the sizes in the given format, the percentages relative to the given total (see FormatSize).
+func (rows *TreeRows) SetSizeFormat(format SizeFormat, total int64) {
+	if format == rows.sizeFormat && total == rows.sizeTotal {
+		return
+	}
+	rows.sizeFormat, rows.sizeTotal = format, total
+	rows.lines[1] = make([]string, len(rows.rows))
+}
+
+// SetShowFileCounts shows (or hides) the number of files within each directory, along with the attributes.
+func (rows *TreeRows) SetShowFileCounts(show bool) {
+	if show == rows.showFileCounts {
+		return
+	}
+	rows.showFileCounts = show
+	rows.lines[1] = make([]string, len(rows.rows))
+}
+
+// SetWidth fits the lines within the given width (in terminal cells) by shortening the names that do not fit (see
+// RenderOptions.Truncate); 0 leaves the names whole.
+func (rows *TreeRows) SetWidth(width int) {
+	if width == rows.width {
+		return
+	}
+	rows.width = width
+	rows.lines[0] = make([]string, len(rows.rows))
+	rows.lines[1] = make([]string, len(rows.rows))
+}
+
+// SetRenderOptions draws the lines with the given options (rendering them again).
+func (rows *TreeRows) SetRenderOptions(options RenderOptions) {
+	rows.options = options
+	rows.lines[0] = make([]string, len(rows.rows))
+	rows.lines[1] = make([]string, len(rows.rows))
+}
+
+// Len is the number of visible rows.
+func (rows *TreeRows) Len() int {
+	return len(rows.rows)
+}
+
+// Node returns the node displayed on the given row (or nil if there is no such row).
+func (rows *TreeRows) Node(row int) *FileNode {
+	if row < 0 || row >= len(rows.rows) {
+		return nil
+	}
+	return rows.rows[row].node
+}
+
+// CountBetween returns the number of rows within the given (inclusive) range.
+func (rows *TreeRows) CountBetween(start, stop int) int {
+	if start < 0 {
+		start = 0
+	}
+	if stop >= len(rows.rows) {
+		stop = len(rows.rows) - 1
+	}
+	if stop < start {
+		return 0
+	}
+	return stop - start + 1
+}
+
+// StringBetween renders the rows within the given (inclusive) range, the same as FileTree.StringBetween would.
+func (rows *TreeRows) StringBetween(start, stop int, showAttributes bool) string {
+	if start < 0 {
+		start = 0
+	}
+	var sb strings.Builder
+	for row := start; row <= stop && row < len(rows.rows); row++ {
+		sb.WriteString(rows.line(row, showAttributes))
+	}
+	return sb.String()
+}
+
+func (rows *TreeRows) line(row int, showAttributes bool) string {
+	memoIdx := 0
+	if showAttributes {
+		memoIdx = 1
+	}
+	if line := rows.lines[memoIdx][row]; line != "" {
+		return line
+	}
+
+	params := rows.rows[row]
+	var line string
+	if showAttributes {
+		line = params.node.metadataString(rows.size(params.node), rows.sizeFormat, rows.sizeTotal, rows.options) + " "
+		if rows.showFileCounts {
+			line += rows.fileCountString(params.node)
+		}
+	}
+	width := 0
+	if rows.width > 0 {
+		width = rows.width - DisplayWidth(line)
+		if width < 1 {
+			width = 1
+		}
+	}
+	line += params.node.renderTreeLine(params.spaces, params.isLast, params.showCollapsed, width, rows.options)
+
+	rows.lines[memoIdx][row] = line
+	return line
+}
+
+// size returns the same size FileNode.MetadataString reports, without revisiting subtrees that were already summed.
+func (rows *TreeRows) size(node *FileNode) int64 {
+	if node.IsLeaf() {
+		return node.Data.FileInfo.Size
+	}
+	// a removed directory shows the accumulated size of the removed files
+	if node.Data.DiffType == Removed {
+		return rows.allSize(node)
+	}
+	return rows.keptSize(node)
+}
+
+// fileCountString renders the file count column of the given node (blank for anything but a directory).
+func (rows *TreeRows) fileCountString(node *FileNode) string {
+	if !node.Data.FileInfo.IsDir {
+		return fmt.Sprintf(FileCountFormat, "")
+	}
+	files := rows.keptFileCount(node)
+	if node.Data.DiffType == Removed {
+		files = rows.allFileCount(node)
+	}
+	return rows.options.diffColor(node.Data.DiffType).Sprint(fmt.Sprintf(FileCountFormat, humanize.Comma(int64(files))))
+}
+
+func (rows *TreeRows) allFileCount(node *FileNode) int {
+	if count, exists := rows.allFiles[node]; exists {
+		return count
+	}
+	var count int
+	for _, child := range node.Children {
+		if !child.Data.FileInfo.IsDir {
+			count++
+		}
+		count += rows.allFileCount(child)
+	}
+	rows.allFiles[node] = count
+	return count
+}
+
+func (rows *TreeRows) keptFileCount(node *FileNode) int {
+	if count, exists := rows.keptFiles[node]; exists {
+		return count
+	}
+	var count int
+	for _, child := range node.Children {
+		if child.Data.DiffType == Removed {
+			continue
+		}
+		if !child.Data.FileInfo.IsDir {
+			count++
+		}
+		count += rows.keptFileCount(child)
+	}
+	rows.keptFiles[node] = count
+	return count
+}
+
+func (rows *TreeRows) allSize(node *FileNode) int64 {
+	if size, exists := rows.allSizes[node]; exists {
+		return size
+	}
+	size := node.Data.FileInfo.Size
+	for _, child := range node.Children {
+		size += rows.allSize(child)
+	}
+	rows.allSizes[node] = size
+	return size
+}
+
+func (rows *TreeRows) keptSize(node *FileNode) int64 {
+	if size, exists := rows.keptSizes[node]; exists {
+		return size
+	}
+	var size int64
+	if node.Data.DiffType != Removed {
+		size = node.Data.FileInfo.Size
+	}
+	for _, child := range node.Children {
+		size += rows.keptSize(child)
+	}
+	rows.keptSizes[node] = size
+	return size
+}
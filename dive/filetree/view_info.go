@@ -9,7 +9,7 @@ type ViewInfo struct {
 // NewViewInfo creates a default ViewInfo
 func NewViewInfo() (view *ViewInfo) {
 	return &ViewInfo{
-		Collapsed: GlobalFileTreeCollapse,
+		Collapsed: false,
 		Hidden:    false,
 	}
 }
@@ -0,0 +1,113 @@
+package filetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	NotWhitedOut WhiteoutMark = iota
+	// WhiteoutDeleted marks a node that was removed by a whiteout file (".wh.<name>") in an upper layer
+	WhiteoutDeleted
+	// WhiteoutOpaque marks a directory whose lower contents were replaced (an opaque ".wh..wh..opq" marker)
+	WhiteoutOpaque
+)
+
+// the marker placed in a directory to hide everything beneath the same directory in the lower layers
+const opaqueWhiteout = doubleWhiteoutPrefix + "opq"
+
+// WhiteoutMark explains why a node is (or the contents of a node are) hidden by an upper layer.
+type WhiteoutMark int
+
+// whiteoutAnnotation describes how the node was affected by a whiteout (and how many bytes of the lower layers it
+// hides), or an empty string if it was not.
+func (node *FileNode) whiteoutAnnotation() string {
+	switch node.Data.Whiteout {
+	case WhiteoutDeleted:
+		return " (deleted)"
+	case WhiteoutOpaque:
+		var replaced bool
+		var hiddenBytes int64
+		for _, child := range node.Children {
+			if child.Data.DiffType != Removed {
+				continue
+			}
+			replaced = true
+			_ = child.VisitDepthChildFirst(func(curNode *FileNode) error {
+				hiddenBytes += curNode.Data.FileInfo.Size
+				return nil
+			}, nil)
+		}
+		if !replaced {
+			// nothing beneath the directory was hidden (e.g. the marker is in the lowest layer)
+			return ""
+		}
+		return fmt.Sprintf(" (directory replaced, hides %s)", humanize.Bytes(uint64(hiddenBytes)))
+	default:
+		return ""
+	}
+}
+
+// markReplaced removes every node beneath the given lower directory which is not present beneath the given (opaque)
+// upper directory.
+func markReplaced(lower, upper *FileNode) error {
+	for name, lowerChild := range lower.Children {
+		upperChild, exists := upper.Children[name]
+		if !exists {
+			if err := lowerChild.AssignDiffType(Removed); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := markReplaced(lowerChild, upperChild); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hiddenSize sums the sizes of the files beneath the given node of a lower layer that are hidden by a whiteout, skipping
+// files which are re-added within the given upper directory (if any) and files whose hidden copy is counted already
+// (if counted is given).
+func hiddenSize(lower *FileNode, upper *FileNode, counted func(path string) bool) int64 {
+	var sizeBytes int64
+	addFolded := func(node *FileNode, beneath string) {
+		for _, file := range node.Data.FileInfo.Folded {
+			if beneath != "" && !strings.HasPrefix(file.Path, beneath+"/") {
+				continue
+			}
+			if counted != nil && counted(file.Path) {
+				continue
+			}
+			if upper != nil {
+				if _, err := upper.Tree.GetNode(file.Path); err == nil {
+					continue
+				}
+			}
+			sizeBytes += file.Size
+		}
+	}
+	// a bounded analysis may have folded files beneath the node into a summary directory above it
+	for ancestor := lower.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		addFolded(ancestor, lower.Path())
+	}
+	_ = lower.VisitDepthChildFirst(func(node *FileNode) error {
+		addFolded(node, "")
+		if node.Data.FileInfo.IsDir {
+			return nil
+		}
+		if counted != nil && counted(node.Path()) {
+			return nil
+		}
+		if upper != nil {
+			if _, err := upper.Tree.GetNode(node.Path()); err == nil {
+				return nil
+			}
+		}
+		sizeBytes += node.Data.FileInfo.Size
+		return nil
+	}, nil)
+	return sizeBytes
+}
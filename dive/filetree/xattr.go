@@ -0,0 +1,99 @@
+package filetree
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+)
+
+// the prefix of the PAX records holding extended attributes (as written by GNU tar, bsdtar and the docker daemon)
+const xattrRecordPrefix = "SCHILY.xattr."
+
+// the PAX records that the tar header fields already represent
+var headerRecords = map[string]bool{
+	"path": true, "linkpath": true, "size": true, "uid": true, "gid": true, "uname": true, "gname": true,
+	"mtime": true, "atime": true, "ctime": true,
+}
+
+// splitPAXRecords separates the extended attributes (by name, e.g. "user.mime_type") from the remaining PAX records
+// that the tar header fields do not represent (e.g. ACLs). Either is nil when there are none.
+func splitPAXRecords(records map[string]string) (xattrs map[string]string, other map[string]string) {
+	for key, value := range records {
+		switch {
+		case strings.HasPrefix(key, sparseRecordPrefix):
+			// represented by FileInfo.Sparse and FileInfo.StoredSize
+		case strings.HasPrefix(key, xattrRecordPrefix):
+			if xattrs == nil {
+				xattrs = make(map[string]string)
+			}
+			xattrs[strings.TrimPrefix(key, xattrRecordPrefix)] = value
+		case !headerRecords[key]:
+			if other == nil {
+				other = make(map[string]string)
+			}
+			other[key] = value
+		}
+	}
+	return xattrs, other
+}
+
+// FormatXattrValue renders an extended attribute value as text when it is printable, otherwise as hex (e.g. the
+// binary "security.capability" value).
+func FormatXattrValue(value string) string {
+	printable := utf8.ValidString(value)
+	for _, r := range value {
+		if !printable || !unicode.IsPrint(r) {
+			printable = false
+			break
+		}
+	}
+	if printable {
+		return value
+	}
+	return "0x" + hex.EncodeToString([]byte(value))
+}
+
+// Attributes presents the kind of special files (devices, FIFOs and sockets), the sizes of sparse files and the
+// capabilities, extended attributes and PAX records of the file like the findings of an inspector, so that they can
+// be shown along with them, as well as the owner within the user namespace when the given id mapping shifts it (nil
+// when the file has none).
+func (data *FileInfo) Attributes(mapping IDMapping) *Inspection {
+	kind := data.typeName()
+	uid, gid, mapped := data.MappedOwner(mapping)
+	if kind == "" && !data.Sparse && !mapped && len(data.Capabilities) == 0 && len(data.Xattrs) == 0 && len(data.PAXRecords) == 0 {
+		return nil
+	}
+	attributes := &Inspection{Inspector: "attributes"}
+	attributes.Add("Type", kind)
+	if mapped {
+		attributes.Add("Owner", fmt.Sprintf("%d:%d in the layer, %d:%d in the user namespace", data.Uid, data.Gid, uid, gid))
+	}
+	if data.IsDevice() {
+		attributes.Add("Device", fmt.Sprintf("%d, %d", data.Devmajor, data.Devminor))
+	}
+	if data.Sparse {
+		attributes.Add("Sparse", fmt.Sprintf("%s logical, %s on disk", humanize.Bytes(uint64(data.Size)), humanize.Bytes(uint64(data.StoredSize))))
+	}
+	attributes.Add("Capabilities", strings.Join(data.Capabilities, ", "))
+	for _, name := range sortedKeys(data.Xattrs) {
+		attributes.Add("xattr "+name, FormatXattrValue(data.Xattrs[name]))
+	}
+	for _, key := range sortedKeys(data.PAXRecords) {
+		attributes.Add("pax "+key, FormatXattrValue(data.PAXRecords[key]))
+	}
+	return attributes
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
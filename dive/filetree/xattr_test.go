@@ -0,0 +1,59 @@
+package filetree
+
+import (
+	"archive/tar"
+	"reflect"
+	"testing"
+)
+
+func TestNewFileInfoFromTarHeaderXattrs(t *testing.T) {
+	info := readTarFile(t, &tar.Header{
+		Name:     "usr/bin/ping",
+		Typeflag: tar.TypeReg,
+		Mode:     0755,
+		Format:   tar.FormatPAX,
+		PAXRecords: map[string]string{
+			capabilityRecord:                 capabilityXattr(0x02000001, 1<<13, 0, 0, 0),
+			"SCHILY.xattr.user.mime_type":    "application/x-executable",
+			"SCHILY.acl.access":              "user::rwx,group::r-x,other::r-x",
+			"SCHILY.xattr.trusted.overlay.x": "y",
+		},
+	}, "ping")
+
+	expectedXattrs := map[string]string{
+		"security.capability": capabilityXattr(0x02000001, 1<<13, 0, 0, 0),
+		"user.mime_type":      "application/x-executable",
+		"trusted.overlay.x":   "y",
+	}
+	if !reflect.DeepEqual(info.Xattrs, expectedXattrs) {
+		t.Errorf("expected xattrs %v, got %v", expectedXattrs, info.Xattrs)
+	}
+	expectedRecords := map[string]string{"SCHILY.acl.access": "user::rwx,group::r-x,other::r-x"}
+	if !reflect.DeepEqual(info.PAXRecords, expectedRecords) {
+		t.Errorf("expected PAX records %v, got %v", expectedRecords, info.PAXRecords)
+	}
+	if copied := info.Copy(); !reflect.DeepEqual(copied.Xattrs, info.Xattrs) || !reflect.DeepEqual(copied.PAXRecords, info.PAXRecords) {
+		t.Errorf("expected the xattrs and PAX records to be copied")
+	}
+
+	expected := &Inspection{Inspector: "attributes", Fields: []InspectionField{
+		{Name: "Capabilities", Value: "cap_net_raw"},
+		{Name: "xattr security.capability", Value: "0x0100000200200000000000000000000000000000"},
+		{Name: "xattr trusted.overlay.x", Value: "y"},
+		{Name: "xattr user.mime_type", Value: "application/x-executable"},
+		{Name: "pax SCHILY.acl.access", Value: "user::rwx,group::r-x,other::r-x"},
+	}}
+	if actual := info.Attributes(IDMapping{}); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected attributes:\n%+v\ngot:\n%+v", expected, actual)
+	}
+}
+
+func TestFileInfoAttributesWithoutXattrs(t *testing.T) {
+	info := readTarFile(t, &tar.Header{Name: "etc/hostname", Typeflag: tar.TypeReg, Mode: 0644}, "host")
+	if info.Xattrs != nil || info.PAXRecords != nil {
+		t.Errorf("expected no xattrs or PAX records, got %v and %v", info.Xattrs, info.PAXRecords)
+	}
+	if attributes := info.Attributes(IDMapping{}); attributes != nil {
+		t.Errorf("expected no attributes, got %+v", attributes)
+	}
+}
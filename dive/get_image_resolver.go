@@ -3,9 +3,9 @@ package dive
 import (
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/containerd"
 	"github.com/wagoodman/dive/dive/image/docker"
 	"github.com/wagoodman/dive/dive/image/podman"
-	"net/url"
 	"strings"
 )
 
@@ -14,14 +14,17 @@ const (
 	SourceDockerEngine
 	SourcePodmanEngine
 	SourceDockerArchive
+	SourceContainerd
+	SourceRegistry
+	SourceBundle
 )
 
 type ImageSource int
 
-var ImageSources = []string{SourceDockerEngine.String(), SourcePodmanEngine.String(), SourceDockerArchive.String()}
+var ImageSources = []string{SourceDockerEngine.String(), SourcePodmanEngine.String(), SourceDockerArchive.String(), SourceContainerd.String(), SourceRegistry.String(), SourceBundle.String()}
 
 func (r ImageSource) String() string {
-	return [...]string{"unknown", "docker", "podman", "docker-archive"}[r]
+	return [...]string{"unknown", "docker", "podman", "docker-archive", "containerd", "registry", "bundle"}[r]
 }
 
 func ParseImageSource(r string) ImageSource {
@@ -32,43 +35,50 @@ func ParseImageSource(r string) ImageSource {
 		return SourcePodmanEngine
 	case SourceDockerArchive.String():
 		return SourceDockerArchive
-	case "docker-tar":
+	case "docker-tar", "archive":
 		return SourceDockerArchive
+	case SourceContainerd.String(), "ctr":
+		return SourceContainerd
+	case SourceRegistry.String():
+		return SourceRegistry
+	case SourceBundle.String():
+		return SourceBundle
 	default:
 		return SourceUnknown
 	}
 }
 
+// DeriveImageSource splits the source prefix from the image (e.g. "podman://alpine:latest"), returning SourceUnknown
+// when there is no known prefix. The image may be pinned by digest (e.g. "docker://alpine@sha256:..."). Only a prefix
+// followed by "://" is a source, so that a name and its tag (e.g. "archive:latest") are never mistaken for one.
 func DeriveImageSource(image string) (ImageSource, string) {
-	u, err := url.Parse(image)
-	if err != nil {
+	scheme, imageSource, found := strings.Cut(image, "://")
+	if !found {
 		return SourceUnknown, ""
 	}
 
-	imageSource := strings.TrimPrefix(image, u.Scheme+"://")
-
-	switch u.Scheme {
-	case SourceDockerEngine.String():
-		return SourceDockerEngine, imageSource
-	case SourcePodmanEngine.String():
-		return SourcePodmanEngine, imageSource
-	case SourceDockerArchive.String():
-		return SourceDockerArchive, imageSource
-	case "docker-tar":
-		return SourceDockerArchive, imageSource
-
+	source := ParseImageSource(scheme)
+	if source == SourceUnknown {
+		return SourceUnknown, ""
 	}
-	return SourceUnknown, ""
+	return source, imageSource
 }
 
-func GetImageResolver(r ImageSource) (image.Resolver, error) {
+// GetImageResolver creates the resolver of the given source, which fetches and parses images with the given options.
+func GetImageResolver(r ImageSource, options image.ResolverOptions) (image.Resolver, error) {
 	switch r {
 	case SourceDockerEngine:
-		return docker.NewResolverFromEngine(), nil
+		return docker.NewResolverFromEngine(options), nil
 	case SourcePodmanEngine:
-		return podman.NewResolverFromEngine(), nil
+		return podman.NewResolverFromEngine(options), nil
 	case SourceDockerArchive:
-		return docker.NewResolverFromArchive(), nil
+		return docker.NewResolverFromArchive(options), nil
+	case SourceContainerd:
+		return containerd.NewResolverFromEngine(options), nil
+	case SourceRegistry:
+		return docker.NewResolverFromRegistry(options), nil
+	case SourceBundle:
+		return image.NewResolverFromBundle(), nil
 	}
 
 	return nil, fmt.Errorf("unable to determine image resolver")
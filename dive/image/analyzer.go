@@ -2,6 +2,7 @@ package image
 
 import (
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/metrics"
 )
 
 type Analyzer interface {
@@ -9,12 +10,64 @@ type Analyzer interface {
 }
 
 type AnalysisResult struct {
+	// the digest of the image config, as shown by "docker images" (empty when unknown)
+	ImageID           string
 	Layers            []*Layer
 	RefTrees          []*filetree.FileTree
 	Efficiency        float64
 	SizeBytes         uint64
+	CompressedBytes   uint64  // the sum of the known compressed layer sizes (what a registry stores and transfers)
 	UserSizeByes      uint64  // this is all bytes except for the base image
-	WastedUserPercent float64 // = wasted-bytes/user-size-bytes
+	WastedUserPercent float64 // = app-wasted-bytes/user-size-bytes
 	WastedBytes       uint64
-	Inefficiencies    filetree.EfficiencySlice
+	// the wasted bytes the app layers are responsible for (all but the waste within the base layers alone)
+	AppWastedBytes uint64
+	// the base image layers, accounted separately from the app layers
+	Base           *BaseImage
+	Inefficiencies filetree.EfficiencySlice
+	// the efficiency score decomposed into the kinds of waste (nil for lazy images)
+	Breakdown    *EfficiencyBreakdown
+	Storage      *StorageOverhead
+	Deprecations []Deprecation
+	// the config history, including the instructions that made no filesystem changes
+	History []HistoryEntry
+	// the runtime configuration (env, labels, entrypoint...) recorded in the image config
+	Config *ImageConfig
+	// the manifest annotations and attached artifacts found in the registry (nil for other image sources)
+	Attestations *Attestations
+	// the outcome of the cosign signature verification (nil when the signature was not verified)
+	Signature *SignatureVerification
+	// the vulnerabilities a scanner found, mapped onto the files and layers (nil when no scanner report was given)
+	Vulnerabilities *VulnerabilityReport
+	// the packages installed in the image and the files they own (nil unless the package databases were read)
+	Packages *Packages
+	// libraries with several versions side by side in the final image
+	DuplicateArtifacts []DuplicateArtifact
+	// web assets that are likely not needed in production
+	AssetBloat *AssetBloat
+	// docs, man pages, locales and python bytecode that are rarely needed at runtime
+	Prunable *PrunableContent
+	// files that make rebuilding the image produce other layers (build timestamps, random data, build records)
+	Reproducibility *Reproducibility
+	// GPU libraries, duplicate ML framework installs and model weights
+	ML *MLAnalysis
+	// conda environments and package caches
+	Conda *CondaAnalysis
+	// the node packages, python distributions and Go and Rust binaries of the final image, the largest first
+	Dependencies *Dependencies
+	// the compression ratio of every layer and the content that is compressed twice
+	Compression *CompressionAnalysis
+	// the remote URLs fetched by the layers (with ADD, curl or wget) and what became of the downloaded files
+	Downloads []RemoteDownload
+	// setuid binaries, world-writable files, root owned application files and files granted capabilities
+	Audit *Audit
+	// the bytes of every layer and of the final image by owner, showing how much of the image is owned by root
+	Ownership *Ownership
+	// the files stored at more than one path, found as the layers are hashed (nil unless duplicate detection is enabled)
+	DuplicateContent *DuplicateFinder
+	// reads the file contents of the layers (nil when they are not available)
+	Contents ContentReader
+	// the timings of the analysis, along with the UI stages shown with it (nil when they are not timed)
+	Metrics *metrics.Recorder
+	Partial bool // layer contents are loaded on demand, so efficiency and storage figures are unavailable
 }
@@ -0,0 +1,190 @@
+package image
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of packaged libraries that are checked for duplicate versions
+const (
+	ArtifactJar   = "jar"
+	ArtifactWheel = "wheel"
+)
+
+// ArtifactVersion is one version of a packaged library within the final image filesystem.
+type ArtifactVersion struct {
+	Version   string
+	Path      string
+	SizeBytes uint64
+	// the index of the layer that added this version
+	Layer int
+}
+
+// DuplicateArtifact is a library (a jar or a Python distribution) that has several versions side by side in the same
+// directory of the final image, which is usually the result of copying the output of a build stage over another.
+type DuplicateArtifact struct {
+	Kind     string
+	Name     string
+	Dir      string
+	Versions []ArtifactVersion
+}
+
+// artifactFile is a versioned library file (or Python metadata directory) that is visible in the image.
+type artifactFile struct {
+	kind    string
+	name    string
+	version string
+	size    uint64
+	layer   int
+}
+
+// FindDuplicateArtifacts lists the jars and Python distributions present in more than one version within a directory
+// of the final image (after every layer and whiteout has been applied).
+func FindDuplicateArtifacts(trees []*filetree.FileTree) []DuplicateArtifact {
+	artifacts := make(map[string]*artifactFile)
+
+	forget := func(dir string) {
+		for existing := range artifacts {
+			if existing == dir || strings.HasPrefix(existing, dir+"/") {
+				delete(artifacts, existing)
+			}
+		}
+	}
+
+	for layer, tree := range trees {
+		err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+			nodePath := node.Path()
+
+			if node.IsWhiteout() {
+				forget(nodePath)
+				return nil
+			}
+			if node.Data.Whiteout == filetree.WhiteoutOpaque {
+				// the lower contents of the directory are replaced, the contents of this layer are visited next
+				forget(nodePath)
+			}
+
+			if kind, name, version, ok := parseArtifact(node.Name, node.Data.FileInfo.IsDir || len(node.Children) > 0); ok {
+				artifacts[nodePath] = &artifactFile{
+					kind:    kind,
+					name:    name,
+					version: version,
+					size:    uint64(node.Data.FileInfo.Size),
+					layer:   layer,
+				}
+				return nil
+			}
+
+			// the size of a Python distribution is the size of its metadata directory
+			if metadata := distributionMetadataDir(node); metadata != nil {
+				if artifact, exists := artifacts[metadata.Path()]; exists && !node.Data.FileInfo.IsDir {
+					artifact.size += uint64(node.Data.FileInfo.Size)
+				}
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to find duplicate artifacts: %+v", err)
+		}
+	}
+
+	groups := make(map[string]*DuplicateArtifact)
+	for artifactPath, artifact := range artifacts {
+		dir := path.Dir(artifactPath)
+		key := artifact.kind + ":" + dir + ":" + artifact.name
+		group, exists := groups[key]
+		if !exists {
+			group = &DuplicateArtifact{Kind: artifact.kind, Name: artifact.name, Dir: dir}
+			groups[key] = group
+		}
+		group.Versions = append(group.Versions, ArtifactVersion{
+			Version:   artifact.version,
+			Path:      artifactPath,
+			SizeBytes: artifact.size,
+			Layer:     artifact.layer,
+		})
+	}
+
+	duplicates := make([]DuplicateArtifact, 0)
+	for _, group := range groups {
+		if !hasMultipleVersions(group.Versions) {
+			continue
+		}
+		sort.Slice(group.Versions, func(i, j int) bool {
+			return group.Versions[i].Path < group.Versions[j].Path
+		})
+		duplicates = append(duplicates, *group)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Dir == duplicates[j].Dir {
+			return duplicates[i].Name < duplicates[j].Name
+		}
+		return duplicates[i].Dir < duplicates[j].Dir
+	})
+	return duplicates
+}
+
+func hasMultipleVersions(versions []ArtifactVersion) bool {
+	for _, version := range versions[1:] {
+		if version.Version != versions[0].Version {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArtifact extracts the library name and version from a jar ("guava-31.1-jre.jar"), a wheel
+// ("requests-2.31.0-py3-none-any.whl") or an installed Python distribution ("requests-2.31.0.dist-info").
+func parseArtifact(name string, isDir bool) (kind, artifact, version string, ok bool) {
+	switch {
+	case !isDir && strings.HasSuffix(name, ".jar"):
+		if artifact, version, ok = splitVersion(strings.TrimSuffix(name, ".jar")); !ok {
+			return "", "", "", false
+		}
+		return ArtifactJar, artifact, version, true
+	case !isDir && strings.HasSuffix(name, ".whl"):
+		parts := strings.Split(strings.TrimSuffix(name, ".whl"), "-")
+		if len(parts) < 2 {
+			return "", "", "", false
+		}
+		return ArtifactWheel, normalizeDistribution(parts[0]), parts[1], true
+	case isDir && (strings.HasSuffix(name, ".dist-info") || strings.HasSuffix(name, ".egg-info")):
+		parts := strings.Split(strings.TrimSuffix(strings.TrimSuffix(name, ".dist-info"), ".egg-info"), "-")
+		if len(parts) < 2 {
+			return "", "", "", false
+		}
+		return ArtifactWheel, normalizeDistribution(parts[0]), parts[1], true
+	}
+	return "", "", "", false
+}
+
+// splitVersion splits a maven style "<artifact>-<version>" file name at the first dash followed by a digit.
+func splitVersion(name string) (string, string, bool) {
+	for idx := 0; idx < len(name)-1; idx++ {
+		if name[idx] == '-' && unicode.IsDigit(rune(name[idx+1])) {
+			return name[:idx], name[idx+1:], idx > 0
+		}
+	}
+	return "", "", false
+}
+
+// normalizeDistribution normalizes a Python distribution name (PEP 503), since wheels and installed metadata spell
+// the same distribution differently.
+func normalizeDistribution(name string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(name))
+}
+
+// distributionMetadataDir returns the installed Python distribution metadata directory the node is within (if any).
+func distributionMetadataDir(node *filetree.FileNode) *filetree.FileNode {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if strings.HasSuffix(parent.Name, ".dist-info") || strings.HasSuffix(parent.Name, ".egg-info") {
+			return parent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindDuplicateArtifacts(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	site := "/usr/lib/python3/site-packages"
+	add(trees[0], "/app/libs/guava-31.1-jre.jar", filetree.FileInfo{Size: 3000})
+	add(trees[0], "/app/libs/commons-lang3-3.12.0.jar", filetree.FileInfo{Size: 500})
+	add(trees[0], site+"/requests-2.30.0.dist-info", filetree.FileInfo{IsDir: true})
+	add(trees[0], site+"/requests-2.30.0.dist-info/METADATA", filetree.FileInfo{Size: 40})
+	add(trees[0], site+"/Flask-2.0.0.dist-info", filetree.FileInfo{IsDir: true})
+	add(trees[1], "/app/libs/guava-32.0-jre.jar", filetree.FileInfo{Size: 3100})
+	add(trees[1], "/app/libs/commons-lang3-3.12.0.jar", filetree.FileInfo{Size: 500})
+	add(trees[1], "/opt/other/guava-30.0-jre.jar", filetree.FileInfo{Size: 2900})
+	add(trees[1], site+"/requests-2.31.0.dist-info", filetree.FileInfo{IsDir: true})
+	add(trees[1], site+"/requests-2.31.0.dist-info/METADATA", filetree.FileInfo{Size: 50})
+	add(trees[1], site+"/flask-2.1.0.dist-info", filetree.FileInfo{IsDir: true})
+	// the older flask is removed again
+	add(trees[2], site+"/.wh.Flask-2.0.0.dist-info", filetree.FileInfo{})
+
+	duplicates := FindDuplicateArtifacts(trees)
+
+	if len(duplicates) != 2 {
+		t.Fatalf("expected 2 duplicate artifacts, got %d: %+v", len(duplicates), duplicates)
+	}
+
+	guava := duplicates[0]
+	if guava.Kind != ArtifactJar || guava.Name != "guava" || guava.Dir != "/app/libs" {
+		t.Errorf("expected guava in /app/libs, got %+v", guava)
+	}
+	if len(guava.Versions) != 2 || guava.Versions[0].Version != "31.1-jre" || guava.Versions[1].Version != "32.0-jre" {
+		t.Fatalf("expected guava 31.1-jre and 32.0-jre, got %+v", guava.Versions)
+	}
+	if guava.Versions[0].Layer != 0 || guava.Versions[1].Layer != 1 || guava.Versions[1].SizeBytes != 3100 {
+		t.Errorf("unexpected guava version details: %+v", guava.Versions)
+	}
+
+	requests := duplicates[1]
+	if requests.Kind != ArtifactWheel || requests.Name != "requests" || requests.Dir != site {
+		t.Errorf("expected requests in %s, got %+v", site, requests)
+	}
+	if len(requests.Versions) != 2 || requests.Versions[0].SizeBytes != 40 || requests.Versions[1].SizeBytes != 50 {
+		t.Errorf("unexpected requests version details: %+v", requests.Versions)
+	}
+}
+
+func TestParseArtifact(t *testing.T) {
+	table := map[string]struct {
+		name    string
+		isDir   bool
+		kind    string
+		version string
+		ok      bool
+	}{
+		"spring-core-5.3.9.jar":             {"spring-core", false, ArtifactJar, "5.3.9", true},
+		"app.jar":                           {"", false, "", "", false},
+		"PyYAML-6.0-cp311-linux_x86_64.whl": {"pyyaml", false, ArtifactWheel, "6.0", true},
+		"zope.interface-6.0.dist-info":      {"zope_interface", true, ArtifactWheel, "6.0", true},
+		"setuptools-68.0.0-py3.11.egg-info": {"setuptools", true, ArtifactWheel, "68.0.0", true},
+		"requests-2.31.0.dist-info":         {"", false, "", "", false},
+	}
+
+	for name, test := range table {
+		kind, artifact, version, ok := parseArtifact(name, test.isDir)
+		if ok != test.ok || kind != test.kind || artifact != test.name || version != test.version {
+			t.Errorf("%s: expected (%q, %q, %q, %v), got (%q, %q, %q, %v)", name, test.kind, test.name, test.version, test.ok, kind, artifact, version, ok)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of web asset bloat that are detected
+const (
+	AssetSourceMap        = "source map"
+	AssetStaleBundle      = "stale bundle"
+	AssetCompressedTwin   = "compressed twin"
+	AssetLegacyFontFormat = "legacy font format"
+)
+
+// a content hash within a bundle name, as written by webpack, vite, parcel et al. ("main.3f9a2c1b.js", "app-4ad0e8f2.css")
+var hashedBundlePattern = regexp.MustCompile(`^(.+?)[.-]([0-9a-f]{8,20})((?:\.chunk)?\.(?:m?js|css))$`)
+
+// assets that are commonly precompressed next to the original by asset pipelines
+var compressibleAssetExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".html": true, ".svg": true, ".json": true, ".wasm": true,
+	".ttf": true, ".otf": true, ".eot": true, ".txt": true, ".xml": true,
+}
+
+// the font formats that woff2 (supported by every current browser) supersedes
+var legacyFontExtensions = map[string]bool{".ttf": true, ".otf": true, ".woff": true, ".eot": true, ".svg": true}
+
+// AssetFinding is a web asset in the final image that is likely not needed in production.
+type AssetFinding struct {
+	Kind             string
+	Path             string
+	ReclaimableBytes uint64
+	// the index of the layer that added the asset
+	Layer  int
+	Detail string
+}
+
+// AssetBloat summarizes the web asset bloat found in the final image of a frontend image.
+type AssetBloat struct {
+	Findings         []AssetFinding
+	ReclaimableBytes uint64
+}
+
+// visibleFile is a file in the final image filesystem.
+type visibleFile struct {
+	size  uint64
+	layer int
+	hash  uint64
+	// what the content inspectors found out about the file
+	inspections []filetree.Inspection
+}
+
+// visibleFiles lists the files of the final image filesystem (after every layer and whiteout has been applied).
+func visibleFiles(trees []*filetree.FileTree) map[string]visibleFile {
+	files := make(map[string]visibleFile)
+
+	forget := func(dir string) {
+		for existing := range files {
+			if existing == dir || strings.HasPrefix(existing, dir+"/") {
+				delete(files, existing)
+			}
+		}
+	}
+
+	for layer, tree := range trees {
+		err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+			nodePath := node.Path()
+			switch {
+			case node.IsWhiteout():
+				forget(nodePath)
+			case node.Data.Whiteout == filetree.WhiteoutOpaque:
+				// the lower contents of the directory are replaced, the contents of this layer are visited next
+				forget(nodePath)
+			case !node.Data.FileInfo.IsDir && len(node.Children) == 0:
+				files[nodePath] = visibleFile{size: uint64(node.Data.FileInfo.Size), layer: layer, hash: node.Data.FileInfo.ContentHash(), inspections: node.Data.FileInfo.Inspections}
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to list the image files: %+v", err)
+		}
+	}
+	return files
+}
+
+// FindAssetBloat detects web asset patterns that waste space in production images: source maps, stale hashed bundles
+// left behind by earlier layers, uncompressed assets next to their precompressed twins and fonts shipped in legacy
+// formats next to woff2. The paths of the ignore list (which may be nil) are left out.
+func FindAssetBloat(trees []*filetree.FileTree, ignore *filetree.IgnoreList) *AssetBloat {
+	files := reportedFiles(trees, ignore)
+	result := &AssetBloat{Findings: make([]AssetFinding, 0)}
+
+	add := func(finding AssetFinding) {
+		result.Findings = append(result.Findings, finding)
+		result.ReclaimableBytes += finding.ReclaimableBytes
+	}
+
+	// bundles by directory and name (without the content hash)
+	bundles := make(map[string][]string)
+	// fonts by directory and name (without the extension)
+	fonts := make(map[string][]string)
+
+	for filePath, file := range files {
+		ext := path.Ext(filePath)
+		base := strings.TrimSuffix(filePath, ext)
+
+		switch {
+		case ext == ".map":
+			add(AssetFinding{Kind: AssetSourceMap, Path: filePath, ReclaimableBytes: file.size, Layer: file.layer, Detail: "source maps are not needed to serve the application"})
+			continue
+		case ext == ".woff2":
+			fonts[base] = append(fonts[base], filePath)
+		case legacyFontExtensions[ext]:
+			fonts[base] = append(fonts[base], filePath)
+		}
+
+		if match := hashedBundlePattern.FindStringSubmatch(path.Base(filePath)); match != nil {
+			key := path.Join(path.Dir(filePath), match[1]+match[3])
+			bundles[key] = append(bundles[key], filePath)
+		}
+
+		if compressibleAssetExtensions[ext] {
+			var twins []string
+			for _, suffix := range []string{".gz", ".br", ".zst"} {
+				if _, exists := files[filePath+suffix]; exists {
+					twins = append(twins, suffix)
+				}
+			}
+			if len(twins) > 0 {
+				add(AssetFinding{Kind: AssetCompressedTwin, Path: filePath, ReclaimableBytes: file.size, Layer: file.layer,
+					Detail: fmt.Sprintf("precompressed as %s (serve it with on-the-fly decompression as the fallback)", strings.Join(twins, ", "))})
+			}
+		}
+	}
+
+	for _, paths := range bundles {
+		// only the bundle added by the last layer is current, earlier layers left the others behind
+		newest := paths[0]
+		for _, candidate := range paths[1:] {
+			if files[candidate].layer > files[newest].layer || (files[candidate].layer == files[newest].layer && candidate > newest) {
+				newest = candidate
+			}
+		}
+		for _, candidate := range paths {
+			if files[candidate].layer == files[newest].layer {
+				continue
+			}
+			add(AssetFinding{Kind: AssetStaleBundle, Path: candidate, ReclaimableBytes: files[candidate].size, Layer: files[candidate].layer,
+				Detail: fmt.Sprintf("superseded by %s (layer %d)", path.Base(newest), files[newest].layer)})
+		}
+	}
+
+	for base, paths := range fonts {
+		woff2 := base + ".woff2"
+		if _, exists := files[woff2]; !exists {
+			continue
+		}
+		for _, candidate := range paths {
+			if candidate == woff2 {
+				continue
+			}
+			add(AssetFinding{Kind: AssetLegacyFontFormat, Path: candidate, ReclaimableBytes: files[candidate].size, Layer: files[candidate].layer,
+				Detail: fmt.Sprintf("every current browser supports %s", path.Base(woff2))})
+		}
+	}
+
+	sort.Slice(result.Findings, func(i, j int) bool {
+		if result.Findings[i].ReclaimableBytes == result.Findings[j].ReclaimableBytes {
+			return result.Findings[i].Path < result.Findings[j].Path
+		}
+		return result.Findings[i].ReclaimableBytes > result.Findings[j].ReclaimableBytes
+	})
+	return result
+}
@@ -0,0 +1,53 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindAssetBloat(t *testing.T) {
+	trees := make([]*filetree.FileTree, 2)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/srv/www/main.3f9a2c1b.js", 1000)
+	add(trees[0], "/srv/www/vendor.0a1b2c3d.js", 700)
+	add(trees[1], "/srv/www/main.77e0d4a1.js", 1100)
+	add(trees[1], "/srv/www/main.77e0d4a1.js.map", 4000)
+	add(trees[1], "/srv/www/styles.css", 300)
+	add(trees[1], "/srv/www/styles.css.gz", 80)
+	add(trees[1], "/srv/www/fonts/inter.woff2", 90)
+	add(trees[1], "/srv/www/fonts/inter.ttf", 250)
+	add(trees[1], "/srv/www/fonts/mono.ttf", 200)
+	// the old vendor bundle was removed
+	add(trees[1], "/srv/www/.wh.vendor.0a1b2c3d.js", 0)
+
+	bloat := FindAssetBloat(trees, nil)
+
+	expected := []AssetFinding{
+		{Kind: AssetSourceMap, Path: "/srv/www/main.77e0d4a1.js.map", ReclaimableBytes: 4000, Layer: 1},
+		{Kind: AssetStaleBundle, Path: "/srv/www/main.3f9a2c1b.js", ReclaimableBytes: 1000, Layer: 0},
+		{Kind: AssetCompressedTwin, Path: "/srv/www/styles.css", ReclaimableBytes: 300, Layer: 1},
+		{Kind: AssetLegacyFontFormat, Path: "/srv/www/fonts/inter.ttf", ReclaimableBytes: 250, Layer: 1},
+	}
+
+	if len(bloat.Findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(bloat.Findings), bloat.Findings)
+	}
+	for idx, finding := range bloat.Findings {
+		if finding.Kind != expected[idx].Kind || finding.Path != expected[idx].Path || finding.ReclaimableBytes != expected[idx].ReclaimableBytes || finding.Layer != expected[idx].Layer {
+			t.Errorf("finding %d: expected %+v, got %+v", idx, expected[idx], finding)
+		}
+	}
+	if bloat.ReclaimableBytes != 5550 {
+		t.Errorf("expected 5550 reclaimable bytes, got %d", bloat.ReclaimableBytes)
+	}
+}
@@ -0,0 +1,93 @@
+package image
+
+import (
+	"strings"
+)
+
+// the kinds of artifacts attached to an image
+const (
+	AttestationProvenance = "provenance"
+	AttestationSBOM       = "sbom"
+	AttestationSignature  = "signature"
+	AttestationVEX        = "vex"
+	// any other in-toto attestation or referring artifact
+	AttestationOther = "attestation"
+)
+
+// where an attached artifact was found
+const (
+	// the OCI referrers API (or the referrers tag schema of registries without it)
+	AttachedByReferrers = "referrers"
+	// an attestation manifest within the image index (as pushed by BuildKit)
+	AttachedInIndex = "image index"
+	// a tag named after the image digest (e.g. "sha256-<hex>.sig", as pushed by cosign)
+	AttachedByTag = "cosign tag"
+)
+
+// Attestation is an artifact attached to the image in its registry: SLSA provenance, an SBOM, a signature or another
+// attestation about the image.
+type Attestation struct {
+	Kind string
+	// the artifact type, or the media type of the artifact manifest when it has none
+	ArtifactType string
+	// the in-toto predicate type of attestations (e.g. "https://slsa.dev/provenance/v0.2")
+	PredicateType string
+	Digest        string
+	Size          uint64
+	Source        string
+	Annotations   map[string]string
+}
+
+// Attestations are the artifacts attached to the image, and the annotations of its manifest, as found in the registry
+// (nil for images read from other sources).
+type Attestations struct {
+	// the annotations of the image manifest (e.g. "org.opencontainers.image.source")
+	Annotations map[string]string
+	Artifacts   []Attestation
+}
+
+// Count returns the number of artifacts of the given kind.
+func (a *Attestations) Count(kind string) int {
+	if a == nil {
+		return 0
+	}
+	count := 0
+	for _, artifact := range a.Artifacts {
+		if artifact.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// ClassifyAttestation derives the kind of an attached artifact from its artifact type (or media type) and in-toto
+// predicate type.
+func ClassifyAttestation(artifactType, predicateType string) string {
+	predicate := strings.ToLower(predicateType)
+	switch {
+	case strings.Contains(predicate, "slsa.dev/provenance"):
+		return AttestationProvenance
+	case strings.Contains(predicate, "spdx") || strings.Contains(predicate, "cyclonedx") || strings.Contains(predicate, "syft"):
+		return AttestationSBOM
+	case strings.Contains(predicate, "openvex"):
+		return AttestationVEX
+	case strings.Contains(predicate, "cosign/sign"):
+		// sigstore bundles holding a plain signature of the image
+		return AttestationSignature
+	case predicate != "":
+		return AttestationOther
+	}
+
+	artifact := strings.ToLower(artifactType)
+	switch {
+	case strings.Contains(artifact, "signature") || strings.Contains(artifact, ".sig") || strings.Contains(artifact, "notary"):
+		return AttestationSignature
+	case strings.Contains(artifact, "spdx") || strings.Contains(artifact, "cyclonedx") || strings.Contains(artifact, "syft") || strings.Contains(artifact, "sbom"):
+		return AttestationSBOM
+	case strings.Contains(artifact, "openvex"):
+		return AttestationVEX
+	case strings.Contains(artifact, "provenance"):
+		return AttestationProvenance
+	}
+	return AttestationOther
+}
@@ -0,0 +1,28 @@
+package image
+
+import "testing"
+
+func TestClassifyAttestation(t *testing.T) {
+	cases := []struct {
+		artifactType  string
+		predicateType string
+		expected      string
+	}{
+		{"application/vnd.in-toto+json", "https://slsa.dev/provenance/v0.2", AttestationProvenance},
+		{"application/vnd.in-toto+json", "https://spdx.dev/Document", AttestationSBOM},
+		{"application/vnd.in-toto+json", "https://cyclonedx.org/bom", AttestationSBOM},
+		{"application/vnd.in-toto+json", "https://openvex.dev/ns", AttestationVEX},
+		{"application/vnd.in-toto+json", "https://example.com/test-results", AttestationOther},
+		{"application/vnd.dev.sigstore.bundle.v0.3+json", "https://sigstore.dev/cosign/sign/v1", AttestationSignature},
+		{"application/vnd.dev.cosign.artifact.sig.v1+json", "", AttestationSignature},
+		{"application/vnd.cncf.notary.signature", "", AttestationSignature},
+		{"application/spdx+json", "", AttestationSBOM},
+		{"application/vnd.cyclonedx+json", "", AttestationSBOM},
+		{"application/vnd.example.unknown", "", AttestationOther},
+	}
+	for _, test := range cases {
+		if actual := ClassifyAttestation(test.artifactType, test.predicateType); actual != test.expected {
+			t.Errorf("%s (%s): expected %q, got %q", test.artifactType, test.predicateType, test.expected, actual)
+		}
+	}
+}
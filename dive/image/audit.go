@@ -0,0 +1,212 @@
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of permission and ownership findings, in the order they are reported
+const (
+	AuditSetuid        = "setuid"
+	AuditSetgid        = "setgid"
+	AuditWorldWritable = "world-writable"
+	AuditRootOwned     = "root-owned"
+	AuditCapabilities  = "capabilities"
+)
+
+// AuditKinds lists the kinds of findings in the order they are reported.
+var AuditKinds = []string{AuditSetuid, AuditSetgid, AuditWorldWritable, AuditRootOwned, AuditCapabilities}
+
+// DefaultAuditAppDirs are the directories applications are commonly installed into, where files owned by root are
+// flagged (the application can neither update them nor should it run as root to do so).
+var DefaultAuditAppDirs = []string{"/app", "/home", "/opt", "/srv", "/usr/src/app", "/var/www"}
+
+// AuditPolicy decides which files of the final image are flagged by the permission audit.
+type AuditPolicy struct {
+	// files owned by root within these directories are flagged
+	AppDirs []string
+	// the capabilities files may be granted without being flagged (e.g. "cap_net_bind_service")
+	AllowedCapabilities []string
+}
+
+// AuditFinding is a file of the final image with risky permissions or ownership.
+type AuditFinding struct {
+	Kind string
+	Path string
+	// the index of the layer the file (in its final state) comes from
+	Layer  int
+	Detail string
+}
+
+// Audit lists the permission and ownership findings of the final image.
+type Audit struct {
+	Findings []AuditFinding
+}
+
+// Count returns the number of findings of the given kind.
+func (a *Audit) Count(kind string) int {
+	if a == nil {
+		return 0
+	}
+	count := 0
+	for _, finding := range a.Findings {
+		if finding.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Paths returns the paths of the findings of the given kind.
+func (a *Audit) Paths(kind string) []string {
+	var paths []string
+	if a == nil {
+		return paths
+	}
+	for _, finding := range a.Findings {
+		if finding.Kind == kind {
+			paths = append(paths, finding.Path)
+		}
+	}
+	return paths
+}
+
+// auditedEntry is a file or directory of the final image filesystem.
+type auditedEntry struct {
+	info  filetree.FileInfo
+	layer int
+}
+
+// auditedEntries lists the files and directories of the final image filesystem (after every layer and whiteout has
+// been applied). Directories that are only implied by the paths beneath them keep the entry of a lower layer.
+func auditedEntries(trees []*filetree.FileTree) map[string]auditedEntry {
+	entries := make(map[string]auditedEntry)
+
+	forget := func(dir string, keepDir bool) {
+		for existing := range entries {
+			if (!keepDir && existing == dir) || strings.HasPrefix(existing, dir+"/") {
+				delete(entries, existing)
+			}
+		}
+	}
+
+	for layer, tree := range trees {
+		err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+			nodePath := node.Path()
+			switch {
+			case node.IsWhiteout():
+				forget(nodePath, false)
+				return nil
+			case node.Data.Whiteout == filetree.WhiteoutOpaque:
+				// the lower contents of the directory are replaced, the contents of this layer are visited next
+				forget(nodePath, true)
+			}
+			if node.Data.FileInfo.TypeFlag == 0 {
+				// an intermediate directory that is not within the layer tarball
+				return nil
+			}
+			entries[nodePath] = auditedEntry{info: node.Data.FileInfo, layer: layer}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to list the image files for the audit: %+v", err)
+		}
+	}
+	return entries
+}
+
+// unixMode returns the permission bits of the mode as chmod takes them (e.g. 4755).
+func unixMode(mode os.FileMode) os.FileMode {
+	unix := mode.Perm()
+	if mode&os.ModeSetuid != 0 {
+		unix |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		unix |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		unix |= 01000
+	}
+	return unix
+}
+
+// withinDirs indicates if the path is within (or is) one of the given directories.
+func withinDirs(filePath string, dirs []string) (string, bool) {
+	for _, dir := range dirs {
+		dir = path.Clean("/" + dir)
+		if filePath == dir || strings.HasPrefix(filePath, strings.TrimSuffix(dir, "/")+"/") {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// AuditPermissions flags the files of the final image with risky permissions or ownership: setuid and setgid
+// binaries, world-writable files and directories (apart from sticky directories like /tmp), files owned by root
+// within the application directories, and files granted capabilities that the policy does not allow.
+func AuditPermissions(trees []*filetree.FileTree, policy AuditPolicy) *Audit {
+	allowed := make(map[string]bool)
+	for _, capability := range policy.AllowedCapabilities {
+		allowed[strings.ToLower(capability)] = true
+	}
+
+	result := &Audit{Findings: make([]AuditFinding, 0)}
+	for filePath, entry := range auditedEntries(trees) {
+		info := entry.info
+		owner := fmt.Sprintf("%d:%d", info.Uid, info.Gid)
+		add := func(kind, detail string) {
+			result.Findings = append(result.Findings, AuditFinding{Kind: kind, Path: filePath, Layer: entry.layer, Detail: detail})
+		}
+
+		if info.TypeFlag == tar.TypeSymlink {
+			// the permissions of a link are those of its target
+			continue
+		}
+		regular := !info.IsDir && (info.TypeFlag == tar.TypeReg || info.TypeFlag == tar.TypeRegA || info.TypeFlag == tar.TypeLink)
+
+		if regular && info.Mode&os.ModeSetuid != 0 {
+			add(AuditSetuid, fmt.Sprintf("mode %04o, runs as %d", unixMode(info.Mode), info.Uid))
+		}
+		if regular && info.Mode&os.ModeSetgid != 0 {
+			add(AuditSetgid, fmt.Sprintf("mode %04o, runs as group %d", unixMode(info.Mode), info.Gid))
+		}
+		if info.Mode.Perm()&0002 != 0 && !(info.IsDir && info.Mode&os.ModeSticky != 0) {
+			add(AuditWorldWritable, fmt.Sprintf("mode %04o, owned by %s", unixMode(info.Mode), owner))
+		}
+		if regular && info.Uid == 0 {
+			if dir, ok := withinDirs(filePath, policy.AppDirs); ok {
+				add(AuditRootOwned, fmt.Sprintf("owned by %s within %s", owner, dir))
+			}
+		}
+
+		var unexpected []string
+		for _, capability := range info.Capabilities {
+			if !allowed[capability] {
+				unexpected = append(unexpected, capability)
+			}
+		}
+		if len(unexpected) > 0 {
+			add(AuditCapabilities, strings.Join(unexpected, ", "))
+		}
+	}
+
+	order := make(map[string]int)
+	for idx, kind := range AuditKinds {
+		order[kind] = idx
+	}
+	sort.Slice(result.Findings, func(i, j int) bool {
+		left, right := result.Findings[i], result.Findings[j]
+		if left.Kind != right.Kind {
+			return order[left.Kind] < order[right.Kind]
+		}
+		return left.Path < right.Path
+	})
+	return result
+}
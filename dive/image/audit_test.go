@@ -0,0 +1,65 @@
+package image
+
+import (
+	"archive/tar"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAuditPermissions(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if info.TypeFlag == 0 {
+			info.TypeFlag = tar.TypeReg
+		}
+		if info.TypeFlag == tar.TypeDir {
+			info.IsDir = true
+		}
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/usr/bin/passwd", filetree.FileInfo{Mode: 0755 | os.ModeSetuid})
+	add(trees[0], "/usr/bin/wall", filetree.FileInfo{Mode: 0755 | os.ModeSetgid, Gid: 5})
+	add(trees[0], "/usr/bin/su", filetree.FileInfo{Mode: 0755 | os.ModeSetuid})
+	add(trees[0], "/tmp", filetree.FileInfo{TypeFlag: tar.TypeDir, Mode: 0777 | os.ModeDir | os.ModeSticky})
+	add(trees[0], "/usr/bin/ping", filetree.FileInfo{Mode: 0755, Capabilities: []string{"cap_net_raw"}})
+	add(trees[0], "/usr/bin/serve", filetree.FileInfo{Mode: 0755, Capabilities: []string{"cap_net_bind_service", "cap_sys_admin"}})
+	add(trees[0], "/usr/bin/sh", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Mode: 0777 | os.ModeSymlink, Linkname: "dash"})
+
+	add(trees[1], "/app/server.js", filetree.FileInfo{Mode: 0644})
+	add(trees[1], "/app/data", filetree.FileInfo{TypeFlag: tar.TypeDir, Mode: 0777 | os.ModeDir, Uid: 1000, Gid: 1000})
+	add(trees[1], "/app/config.json", filetree.FileInfo{Mode: 0666, Uid: 1000, Gid: 1000})
+	// the setuid bit was dropped from su in a later layer, and passwd was removed
+	add(trees[2], "/usr/bin/su", filetree.FileInfo{Mode: 0755})
+	add(trees[2], "/usr/bin/.wh.passwd", filetree.FileInfo{})
+
+	audit := AuditPermissions(trees, AuditPolicy{AppDirs: DefaultAuditAppDirs, AllowedCapabilities: []string{"CAP_NET_BIND_SERVICE"}})
+
+	expected := []AuditFinding{
+		{Kind: AuditSetgid, Path: "/usr/bin/wall", Layer: 0, Detail: "mode 2755, runs as group 5"},
+		{Kind: AuditWorldWritable, Path: "/app/config.json", Layer: 1, Detail: "mode 0666, owned by 1000:1000"},
+		{Kind: AuditWorldWritable, Path: "/app/data", Layer: 1, Detail: "mode 0777, owned by 1000:1000"},
+		{Kind: AuditRootOwned, Path: "/app/server.js", Layer: 1, Detail: "owned by 0:0 within /app"},
+		{Kind: AuditCapabilities, Path: "/usr/bin/ping", Layer: 0, Detail: "cap_net_raw"},
+		{Kind: AuditCapabilities, Path: "/usr/bin/serve", Layer: 0, Detail: "cap_sys_admin"},
+	}
+	if !reflect.DeepEqual(audit.Findings, expected) {
+		t.Errorf("expected findings:\n%+v\ngot:\n%+v", expected, audit.Findings)
+	}
+
+	if count := audit.Count(AuditWorldWritable); count != 2 {
+		t.Errorf("expected 2 world-writable findings, got %d", count)
+	}
+	if paths := audit.Paths(AuditCapabilities); !reflect.DeepEqual(paths, []string{"/usr/bin/ping", "/usr/bin/serve"}) {
+		t.Errorf("unexpected capability paths: %v", paths)
+	}
+}
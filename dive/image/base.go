@@ -0,0 +1,84 @@
+package image
+
+import (
+	"fmt"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// BaseImage is the boundary between the layers of the base image and the layers built on top of it (the app layers).
+type BaseImage struct {
+	// the image the layers were matched against (empty when only the first layer is assumed to be the base)
+	Image string
+	// the number of leading layers that belong to the base image
+	Layers    int
+	SizeBytes uint64
+	// the bytes wasted within the base layers alone, which the app layers cannot do anything about
+	WastedBytes uint64
+}
+
+// MatchBaseLayers returns the number of leading layers that are the same as the leading layers of the base image,
+// compared by DiffID (or by digest when the DiffID is not known).
+func MatchBaseLayers(layers []*Layer, baseLayers []*Layer) int {
+	count := 0
+	for count < len(layers) && count < len(baseLayers) && sameLayer(layers[count], baseLayers[count]) {
+		count++
+	}
+	return count
+}
+
+func sameLayer(layer, other *Layer) bool {
+	if layer.DiffID != "" && other.DiffID != "" {
+		return layer.DiffID == other.DiffID
+	}
+	return layer.Digest != "" && layer.Digest == other.Digest
+}
+
+// SetBase marks the leading layers shared with the given base image as the base layers (the analysis then accounts
+// for them separately). An error is returned when the image is not built on the base image.
+func (img *Image) SetBase(name string, base *Image) error {
+	count := MatchBaseLayers(img.Layers, base.Layers)
+	if count == 0 {
+		return fmt.Errorf("image is not built on %s (no layers in common)", name)
+	}
+	img.Base = &BaseImage{Image: name, Layers: count}
+	return nil
+}
+
+// baseImage returns the base boundary to account with, assuming the first layer is the base when none was set.
+func (img *Image) baseImage() *BaseImage {
+	base := BaseImage{Layers: 1}
+	if img.Base != nil {
+		base = *img.Base
+	}
+	if base.Layers > len(img.Layers) {
+		base.Layers = len(img.Layers)
+	}
+
+	base.SizeBytes = 0
+	for _, layer := range img.Layers[:base.Layers] {
+		base.SizeBytes += layer.Size
+	}
+	return &base
+}
+
+// baseWastedBytes is the space wasted within the given base layers alone.
+func baseWastedBytes(trees []*filetree.FileTree, layers int, ignore *filetree.IgnoreList) uint64 {
+	if layers < 2 || layers > len(trees) {
+		return 0
+	}
+	_, inefficiencies := filetree.EfficiencyIgnoring(trees[:layers], ignore)
+	var wastedBytes uint64
+	for _, file := range inefficiencies {
+		wastedBytes += uint64(file.CumulativeSize)
+	}
+	return wastedBytes
+}
+
+// wastedPercent is the share of the app bytes that are wasted (0 when the image has no app layers).
+func wastedPercent(wastedBytes, userSizeBytes uint64) float64 {
+	if userSizeBytes == 0 {
+		return 0
+	}
+	return float64(wastedBytes) / float64(userSizeBytes)
+}
@@ -0,0 +1,96 @@
+package image
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestMatchBaseLayers(t *testing.T) {
+	layers := []*Layer{{DiffID: "sha256:a"}, {DiffID: "sha256:b"}, {DiffID: "sha256:c"}}
+
+	table := map[string]struct {
+		base     []*Layer
+		expected int
+	}{
+		"same base":       {[]*Layer{{DiffID: "sha256:a"}, {DiffID: "sha256:b"}}, 2},
+		"different base":  {[]*Layer{{DiffID: "sha256:x"}}, 0},
+		"diverging base":  {[]*Layer{{DiffID: "sha256:a"}, {DiffID: "sha256:x"}, {DiffID: "sha256:c"}}, 1},
+		"same image":      {layers, 3},
+		"digest fallback": {[]*Layer{{Digest: "sha256:a"}}, 0},
+	}
+
+	for name, test := range table {
+		if actual := MatchBaseLayers(layers, test.base); actual != test.expected {
+			t.Errorf("%s: expected %d base layers, got %d", name, test.expected, actual)
+		}
+	}
+
+	byDigest := []*Layer{{Digest: "sha256:1"}, {Digest: "sha256:2"}}
+	if actual := MatchBaseLayers(byDigest, []*Layer{{Digest: "sha256:1"}}); actual != 1 {
+		t.Errorf("expected the layers to be matched by digest, got %d base layers", actual)
+	}
+}
+
+func TestAnalyzeBaseAccounting(t *testing.T) {
+	trees := make([]*filetree.FileTree, 4)
+	layers := make([]*Layer, len(trees))
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+		layers[idx] = &Layer{Index: idx, DiffID: string(rune('a' + idx)), Size: 100}
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	// the base overwrites its own file, the app overwrites a base file
+	add(trees[0], "/etc/os-release", 10)
+	add(trees[1], "/etc/os-release", 20)
+	add(trees[2], "/app/main", 40)
+	add(trees[3], "/etc/os-release", 30)
+
+	img := &Image{Trees: trees, Layers: layers}
+	if err := img.SetBase("base", &Image{Layers: layers[:2]}); err != nil {
+		t.Fatalf("unable to set base: %v", err)
+	}
+
+	analysis, err := img.Analyze()
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+
+	if analysis.Base.Image != "base" || analysis.Base.Layers != 2 || analysis.Base.SizeBytes != 200 {
+		t.Errorf("unexpected base: %+v", analysis.Base)
+	}
+	if analysis.UserSizeByes != 200 {
+		t.Errorf("expected 200 app bytes, got %d", analysis.UserSizeByes)
+	}
+	if analysis.WastedBytes != 60 || analysis.Base.WastedBytes != 30 || analysis.AppWastedBytes != 30 {
+		t.Errorf("unexpected wasted bytes: total=%d base=%d app=%d", analysis.WastedBytes, analysis.Base.WastedBytes, analysis.AppWastedBytes)
+	}
+
+	if err := img.SetBase("other", &Image{Layers: []*Layer{{DiffID: "x"}}}); err == nil {
+		t.Errorf("expected an error for a base image without layers in common")
+	}
+
+	// the ignore list only applies to the analysis it is given to
+	ignore, err := filetree.ParseIgnoreList(strings.NewReader("/etc/os-release\n"))
+	if err != nil {
+		t.Fatalf("unable to parse the ignore list: %v", err)
+	}
+	ignored, err := img.AnalyzeWithOptions(context.Background(), AnalysisOptions{Ignore: ignore})
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	if ignored.WastedBytes != 0 || ignored.Base.WastedBytes != 0 {
+		t.Errorf("expected the ignored file not to be wasted: total=%d base=%d", ignored.WastedBytes, ignored.Base.WastedBytes)
+	}
+	if analysis, err = img.Analyze(); err != nil || analysis.WastedBytes != 60 {
+		t.Errorf("expected another analysis to leave the file in, got %+v (%v)", analysis, err)
+	}
+}
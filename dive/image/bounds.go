@@ -0,0 +1,97 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the memory an entry of a layer tree is estimated to take, along with the copies the comparisons of the layers make
+const boundsBytesPerEntry = 2 * 1024
+
+// AnalysisBounds bounds the memory the layer trees take, so that the analysis degrades gracefully on small CI runners
+// instead of being killed: once the entries of the layers reach the bounds, the deep subtrees of the layers read next
+// are folded into summary directories (see filetree.FoldEntries). The sizes and the efficiency still count every file.
+// Each image is bounded on its own (see NewBudget).
+type AnalysisBounds struct {
+	// the most entries kept in the layer trees, across the layers (0 is unlimited)
+	MaxFiles int
+	// the memory the analysis should stay within (0 is unlimited): the entries are bounded by an estimate of what fits
+	// (the application may also set the memory limit of the go runtime to it, see debug.SetMemoryLimit)
+	MaxMemory uint64
+}
+
+// ParseAnalysisBounds builds the bounds from a number of entries (0 is unlimited) and a memory size (e.g. "512MB",
+// empty or "0" is unlimited).
+func ParseAnalysisBounds(maxFiles int, maxMemory string) (AnalysisBounds, error) {
+	bounds := AnalysisBounds{MaxFiles: maxFiles}
+	if maxFiles < 0 {
+		return bounds, fmt.Errorf("the most files must not be negative: %d", maxFiles)
+	}
+	if maxMemory = strings.TrimSpace(maxMemory); maxMemory != "" && maxMemory != "0" {
+		memory, err := humanize.ParseBytes(maxMemory)
+		if err != nil {
+			return bounds, fmt.Errorf("invalid memory size %q: %v", maxMemory, err)
+		}
+		bounds.MaxMemory = memory
+	}
+	return bounds, nil
+}
+
+// Enabled indicates if the analysis is bounded at all.
+func (b AnalysisBounds) Enabled() bool {
+	return b.MaxFiles > 0 || b.MaxMemory > 0
+}
+
+// Entries is the most entries kept in the layer trees: the smaller of MaxFiles and the entries estimated to fit
+// within MaxMemory (0 is unlimited).
+func (b AnalysisBounds) Entries() int {
+	entries := b.MaxFiles
+	if b.MaxMemory > 0 {
+		fitting := int(b.MaxMemory / boundsBytesPerEntry)
+		if fitting < 1 {
+			fitting = 1
+		}
+		if entries == 0 || fitting < entries {
+			entries = fitting
+		}
+	}
+	return entries
+}
+
+// NewBudget creates the room the layer trees of one image have within the bounds (nil when the analysis is not
+// bounded), which the layers of the image draw down as they are read.
+func (b AnalysisBounds) NewBudget() *EntryBudget {
+	if !b.Enabled() {
+		return nil
+	}
+	return &EntryBudget{remaining: b.Entries()}
+}
+
+// EntryBudget is the room the layer trees of an image still have within the bounds of the analysis. A nil budget is
+// unbounded. It is safe for concurrent use, as layers may be read at the same time (see IOLimits).
+type EntryBudget struct {
+	lock sync.Mutex
+	// the entries the layers read so far still have room for
+	remaining int
+}
+
+// Fold keeps the entries of a layer within what the budget has room for, folding the deep subtrees of the layer into
+// summary directories once the layers read before it took the room (the entries are returned as is when the budget
+// is nil). It returns the entries to add to the tree, and how many were folded.
+func (b *EntryBudget) Fold(entries []filetree.FileInfo) ([]filetree.FileInfo, int) {
+	if b == nil {
+		return entries, 0
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	kept, folded := filetree.FoldEntries(entries, b.remaining)
+	b.remaining -= len(kept)
+	if b.remaining < 0 {
+		b.remaining = 0
+	}
+	return kept, folded
+}
@@ -0,0 +1,77 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestParseAnalysisBounds(t *testing.T) {
+	cases := []struct {
+		name      string
+		maxFiles  int
+		maxMemory string
+		expected  AnalysisBounds
+		entries   int
+		wantErr   bool
+	}{
+		{name: "unbounded", expected: AnalysisBounds{}, entries: 0},
+		{name: "zero memory", maxMemory: "0", expected: AnalysisBounds{}, entries: 0},
+		{name: "files", maxFiles: 100, expected: AnalysisBounds{MaxFiles: 100}, entries: 100},
+		{name: "memory", maxMemory: "1MiB", expected: AnalysisBounds{MaxMemory: 1 << 20}, entries: 512},
+		{name: "smaller of both", maxFiles: 100, maxMemory: "1MiB", expected: AnalysisBounds{MaxFiles: 100, MaxMemory: 1 << 20}, entries: 100},
+		{name: "negative files", maxFiles: -1, wantErr: true},
+		{name: "invalid memory", maxMemory: "lots", wantErr: true},
+	}
+	for _, c := range cases {
+		actual, err := ParseAnalysisBounds(c.maxFiles, c.maxMemory)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if actual != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.name, c.expected, actual)
+		}
+		if actual.Entries() != c.entries {
+			t.Errorf("%s: expected %d entries, got %d", c.name, c.entries, actual.Entries())
+		}
+		if actual.Enabled() != (c.entries > 0) {
+			t.Errorf("%s: unexpected enabled state %v", c.name, actual.Enabled())
+		}
+	}
+}
+
+func TestEntryBudget_Fold(t *testing.T) {
+	entries := []filetree.FileInfo{
+		{Path: "/app", IsDir: true},
+		{Path: "/app/a.txt", Size: 10},
+		{Path: "/app/b.txt", Size: 20},
+	}
+
+	unbounded := AnalysisBounds{}.NewBudget()
+	kept, folded := unbounded.Fold(entries)
+	if folded != 0 || len(kept) != len(entries) {
+		t.Errorf("expected an unbounded analysis to keep every entry, got %d (%d folded)", len(kept), folded)
+	}
+
+	bounds := AnalysisBounds{MaxFiles: 4}
+	budget := bounds.NewBudget()
+	if kept, folded = budget.Fold(entries); folded != 0 {
+		t.Errorf("expected the first layer to fit, got %d folded", folded)
+	}
+	// the first layer took 3 of the 4 entries, so the second one is folded into its top level directories
+	kept, folded = budget.Fold(entries)
+	if folded != 2 || len(kept) != 1 || kept[0].Size != 30 {
+		t.Errorf("expected the second layer to be folded into /app, got %+v (%d folded)", kept, folded)
+	}
+
+	// another image has a budget of its own
+	if kept, folded = bounds.NewBudget().Fold(entries); folded != 0 || len(kept) != len(entries) {
+		t.Errorf("expected the layer of another image to fit, got %d (%d folded)", len(kept), folded)
+	}
+}
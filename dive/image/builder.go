@@ -0,0 +1,22 @@
+package image
+
+// the tools that layers can be fingerprinted as created by (see Layer.Builder)
+const (
+	BuilderDocker   = "docker build"
+	BuilderBuildKit = "buildkit"
+	BuilderKaniko   = "kaniko"
+	BuilderBuildah  = "buildah"
+	BuilderBazel    = "bazel rules_docker"
+	BuilderKo       = "ko"
+	BuilderJib      = "jib"
+	BuilderRulesOCI = "bazel rules_oci"
+)
+
+// the roles that layers of builders that do not record commands (jib, ko and bazel) play within the image (see Layer.Role)
+const (
+	RoleDependencies = "dependencies"
+	RoleResources    = "resources"
+	RoleClasses      = "classes"
+	RoleBinary       = "application binary"
+	RoleFiles        = "files"
+)
@@ -0,0 +1,201 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const (
+	// bundleMagic starts every analysis bundle (followed by the gzip compressed bundle)
+	bundleMagic = "dive-analysis-bundle\n"
+	// bundleVersion is bumped whenever the bundle changes shape, bundles of other versions are refused
+	bundleVersion = 1
+)
+
+// bundle is the contents of an analysis bundle: the image metadata and layer trees, without the file contents.
+type bundle struct {
+	Version int
+	// the image the bundle was saved from (e.g. "alpine:latest") and when
+	Reference string
+	Created   time.Time
+
+	ID              string
+	Layers          []*Layer
+	Trees           []*filetree.TreeSnapshot
+	Deprecations    []Deprecation
+	History         []HistoryEntry
+	Config          *ImageConfig
+	Attestations    *Attestations
+	Signature       *SignatureVerification
+	Vulnerabilities *VulnerabilityReport
+	Packages        *Packages
+	Base            *BaseImage
+}
+
+// BundleInfo describes the image an analysis bundle was saved from.
+type BundleInfo struct {
+	Reference string
+	Created   time.Time
+}
+
+// WriteBundle saves the analysis of the image (its layers, trees and metadata, but not the file contents) as an
+// analysis bundle that ReadBundle opens without access to the image. The trees of a lazy image must all be loaded.
+func WriteBundle(writer io.Writer, img *Image, reference string) error {
+	if img.IsLazy() {
+		return fmt.Errorf("the layers of the image are loaded on demand, fetch the image without --lazy")
+	}
+
+	contents := bundle{
+		Version:         bundleVersion,
+		Reference:       reference,
+		Created:         time.Now().UTC(),
+		ID:              img.ID,
+		Deprecations:    img.Deprecations,
+		History:         img.History,
+		Config:          img.Config,
+		Attestations:    img.Attestations,
+		Signature:       img.Signature,
+		Vulnerabilities: img.Vulnerabilities,
+		Packages:        img.Packages,
+		Base:            img.Base,
+	}
+	for _, tree := range img.Trees {
+		snapshot, err := tree.Snapshot()
+		if err != nil {
+			return fmt.Errorf("unable to save the tree of %s: %v", tree.Name, err)
+		}
+		contents.Trees = append(contents.Trees, snapshot)
+	}
+	for _, layer := range img.Layers {
+		// the tree is saved along with the other trees
+		saved := *layer
+		saved.Tree = nil
+		contents.Layers = append(contents.Layers, &saved)
+	}
+
+	if _, err := io.WriteString(writer, bundleMagic); err != nil {
+		return err
+	}
+	compressed, err := gzip.NewWriterLevel(writer, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(compressed).Encode(contents); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// ReadBundle opens an analysis bundle written by WriteBundle. The file contents of the image are not available.
+func ReadBundle(reader io.Reader) (*Image, BundleInfo, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(len(bundleMagic))
+	if err != nil || string(magic) != bundleMagic {
+		return nil, BundleInfo{}, fmt.Errorf("not an analysis bundle")
+	}
+	if _, err := buffered.Discard(len(bundleMagic)); err != nil {
+		return nil, BundleInfo{}, err
+	}
+	decompressed, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, BundleInfo{}, fmt.Errorf("unable to read the analysis bundle: %v", err)
+	}
+	defer decompressed.Close()
+
+	var contents bundle
+	if err := gob.NewDecoder(decompressed).Decode(&contents); err != nil {
+		return nil, BundleInfo{}, fmt.Errorf("unable to read the analysis bundle: %v", err)
+	}
+	if contents.Version != bundleVersion {
+		return nil, BundleInfo{}, fmt.Errorf("the analysis bundle has version %d, this version of dive reads version %d", contents.Version, bundleVersion)
+	}
+
+	img := &Image{
+		ID:              contents.ID,
+		Layers:          contents.Layers,
+		Deprecations:    contents.Deprecations,
+		History:         contents.History,
+		Config:          contents.Config,
+		Attestations:    contents.Attestations,
+		Signature:       contents.Signature,
+		Vulnerabilities: contents.Vulnerabilities,
+		Packages:        contents.Packages,
+		Base:            contents.Base,
+	}
+	for _, snapshot := range contents.Trees {
+		tree, err := snapshot.Tree()
+		if err != nil {
+			return nil, BundleInfo{}, fmt.Errorf("unable to read the tree of %s: %v", snapshot.Name, err)
+		}
+		img.Trees = append(img.Trees, tree)
+	}
+	if len(img.Trees) != len(img.Layers) {
+		return nil, BundleInfo{}, fmt.Errorf("the analysis bundle has %d layers but %d trees", len(img.Layers), len(img.Trees))
+	}
+	for idx, layer := range img.Layers {
+		layer.Tree = img.Trees[idx]
+	}
+	if img.Packages != nil {
+		// the index of the files by owner is not saved
+		img.Packages.owners = make(map[string]*InstalledPackage)
+		for _, pkg := range img.Packages.Packages {
+			for _, file := range pkg.Files {
+				img.Packages.owners[path.Clean("/"+file)] = pkg
+			}
+		}
+	}
+	return img, BundleInfo{Reference: contents.Reference, Created: contents.Created}, nil
+}
+
+// IsBundle indicates if the file at the given path is an analysis bundle.
+func IsBundle(filePath string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(bundleMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, []byte(bundleMagic))
+}
+
+type bundleResolver struct{}
+
+// NewResolverFromBundle reads images from the analysis bundles written by WriteBundle (given by their path).
+func NewResolverFromBundle() *bundleResolver {
+	return &bundleResolver{}
+}
+
+func (r *bundleResolver) Fetch(ctx context.Context, filePath string) (*Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, info, err := ReadBundle(file)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("opening the analysis of %s saved at %s", info.Reference, info.Created.Format(time.RFC3339))
+	return img, ctx.Err()
+}
+
+func (r *bundleResolver) Build(ctx context.Context, options []string) (*Image, error) {
+	return nil, fmt.Errorf("an analysis bundle cannot be built, save one with 'dive save-analysis'")
+}
@@ -0,0 +1,75 @@
+package image
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	trees := packageTrees(t, []string{"/etc/os-release", "/usr/bin/app"}, []string{"/usr/bin/app", "/tmp/build.log"})
+	layers := []*Layer{
+		{Id: "base", Index: 0, Command: "ADD rootfs.tar /", Size: trees[0].FileSize, Tree: trees[0], Digest: "sha256:base"},
+		{Id: "app", Index: 1, Command: "COPY app /usr/bin/app", Size: trees[1].FileSize, Tree: trees[1], Corruption: []string{"the layer tar is truncated"}},
+	}
+	pkg := &InstalledPackage{Manager: "dpkg", Name: "base-files", Version: "12", Files: []string{"/etc/os-release"}, Layer: 0}
+	img := &Image{
+		ID:       "sha256:config",
+		Trees:    trees,
+		Layers:   layers,
+		History:  []HistoryEntry{{CreatedBy: "ADD rootfs.tar /"}, {CreatedBy: "COPY app /usr/bin/app", LayerIndex: 1}},
+		Config:   &ImageConfig{User: "app", Env: []string{"PATH=/usr/bin"}},
+		Packages: &Packages{Packages: []*InstalledPackage{pkg}, owners: map[string]*InstalledPackage{"/etc/os-release": pkg}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, img, "app:latest"); err != nil {
+		t.Fatalf("unable to write bundle: %v", err)
+	}
+	opened, info, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("unable to read bundle: %v", err)
+	}
+
+	if info.Reference != "app:latest" || info.Created.IsZero() {
+		t.Errorf("expected the bundle to describe app:latest, got %+v", info)
+	}
+	if opened.ID != img.ID || opened.Config.User != "app" || len(opened.History) != 2 || opened.Contents != nil {
+		t.Errorf("expected the image metadata to be kept, got %+v", opened)
+	}
+	for idx, layer := range opened.Layers {
+		if layer.Tree != opened.Trees[idx] || layer.Command != layers[idx].Command || layer.Digest != layers[idx].Digest {
+			t.Errorf("layer %d: expected %+v, got %+v", idx, layers[idx], layer)
+		}
+		if expected, actual := trees[idx].String(true), opened.Trees[idx].String(true); expected != actual {
+			t.Errorf("layer %d: expected the tree:\n%s\ngot:\n%s", idx, expected, actual)
+		}
+	}
+	if len(opened.Layers[1].Corruption) != 1 {
+		t.Errorf("expected the layer problems to be kept")
+	}
+	if owner := opened.Packages.Owner("etc/os-release"); owner == nil || owner.Name != "base-files" {
+		t.Errorf("expected the package owners to be kept, got %+v", owner)
+	}
+
+	original, err := img.Analyze()
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	reopened, err := opened.Analyze()
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	if original.Efficiency != reopened.Efficiency || original.WastedBytes != reopened.WastedBytes || len(original.Inefficiencies) != len(reopened.Inefficiencies) {
+		t.Errorf("expected the same analysis, got %v/%d wasted vs %v/%d wasted", original.Efficiency, original.WastedBytes, reopened.Efficiency, reopened.WastedBytes)
+	}
+}
+
+func TestReadBundleRejectsOtherFiles(t *testing.T) {
+	if _, _, err := ReadBundle(strings.NewReader("not a bundle")); err == nil {
+		t.Errorf("expected a file that is not a bundle to fail")
+	}
+	if _, _, err := ReadBundle(strings.NewReader(bundleMagic + "not gzip")); err == nil {
+		t.Errorf("expected a damaged bundle to fail")
+	}
+}
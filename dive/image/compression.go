@@ -0,0 +1,181 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of compression findings that are detected
+const (
+	CompressionArchiveExtracted = "archive kept after extraction"
+	CompressionRecompressed     = "recompressed content"
+)
+
+const (
+	// archives smaller than this are not worth reporting when kept next to their extraction
+	compressionMinArchiveBytes = 1024 * 1024
+	// layers with less already-compressed content than this are not worth reporting
+	compressionMinRecompressedBytes = 10 * 1024 * 1024
+	// the share of already-compressed content above which a layer is reported
+	compressionRecompressedShare = 0.5
+)
+
+// the archive suffixes, longest first so that ".tar.gz" is preferred over ".gz"
+var archiveSuffixes = []string{".tar.gz", ".tar.xz", ".tar.bz2", ".tar.zst", ".tgz", ".txz", ".tbz2", ".tar", ".zip"}
+
+// the extensions of files that are already compressed, so that gzipping the layer barely shrinks them
+var precompressedExtensions = map[string]bool{
+	".gz": true, ".tgz": true, ".xz": true, ".txz": true, ".bz2": true, ".tbz2": true, ".zst": true, ".lz4": true,
+	".lzma": true, ".br": true, ".zip": true, ".7z": true, ".rar": true, ".jar": true, ".war": true, ".ear": true,
+	".whl": true, ".egg": true, ".nupkg": true, ".deb": true, ".rpm": true, ".apk": true, ".png": true, ".jpg": true,
+	".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".mp3": true, ".mp4": true, ".webm": true, ".mkv": true,
+	".ogg": true, ".woff": true, ".woff2": true,
+}
+
+// LayerCompression is how well a single layer compresses.
+type LayerCompression struct {
+	Layer     int
+	SizeBytes uint64
+	// the size of the layer when compressed (0 when not known)
+	CompressedBytes uint64
+	// the size of the files within the layer that are already compressed (archives, packages, images, ...)
+	PrecompressedBytes uint64
+}
+
+// Ratio is the uncompressed size divided by the compressed size (0 when the compressed size is not known).
+func (l LayerCompression) Ratio() float64 {
+	if l.CompressedBytes == 0 || l.SizeBytes == 0 {
+		return 0
+	}
+	return float64(l.SizeBytes) / float64(l.CompressedBytes)
+}
+
+// PrecompressedShare is the fraction of the layer made of already-compressed files.
+func (l LayerCompression) PrecompressedShare() float64 {
+	if l.SizeBytes == 0 {
+		return 0
+	}
+	return float64(l.PrecompressedBytes) / float64(l.SizeBytes)
+}
+
+// CompressionFinding is content that is compressed twice or stored both compressed and uncompressed.
+type CompressionFinding struct {
+	Kind string
+	// the archive (for kept archives) or the largest already-compressed file in the layer (for recompressed content)
+	Path      string
+	Layer     int
+	SizeBytes uint64
+	Detail    string
+	// how to avoid the finding
+	Hint string
+}
+
+// CompressionAnalysis summarizes how well the image layers compress.
+type CompressionAnalysis struct {
+	Layers   []LayerCompression
+	Findings []CompressionFinding
+}
+
+// AnalyzeCompression reports the compression ratio of every layer and flags archives that are kept next to their
+// extracted contents (stored once compressed and once uncompressed) as well as layers mostly made of already-compressed
+// content, which gains nothing from the layer being compressed again.
+func AnalyzeCompression(layers []*Layer, trees []*filetree.FileTree) *CompressionAnalysis {
+	result := &CompressionAnalysis{
+		Layers:   make([]LayerCompression, 0, len(layers)),
+		Findings: make([]CompressionFinding, 0),
+	}
+
+	for _, layer := range layers {
+		layerCompression := LayerCompression{Layer: layer.Index, SizeBytes: layer.Size, CompressedBytes: layer.CompressedSize}
+
+		var largestPath string
+		var largestSize uint64
+		if layer.Index < len(trees) && trees[layer.Index] != nil {
+			err := trees[layer.Index].VisitDepthParentFirst(func(node *filetree.FileNode) error {
+				if node.IsWhiteout() || node.Data.FileInfo.IsDir || len(node.Children) > 0 {
+					return nil
+				}
+				if !isPrecompressed(node.Path()) {
+					return nil
+				}
+				size := uint64(node.Data.FileInfo.Size)
+				layerCompression.PrecompressedBytes += size
+				if size > largestSize {
+					largestPath, largestSize = node.Path(), size
+				}
+				return nil
+			}, nil)
+			if err != nil {
+				logrus.Errorf("unable to list the files of layer %d: %+v", layer.Index, err)
+			}
+		}
+
+		if layerCompression.PrecompressedBytes >= compressionMinRecompressedBytes && layerCompression.PrecompressedShare() >= compressionRecompressedShare {
+			result.Findings = append(result.Findings, CompressionFinding{
+				Kind:      CompressionRecompressed,
+				Path:      largestPath,
+				Layer:     layer.Index,
+				SizeBytes: layerCompression.PrecompressedBytes,
+				Detail:    fmt.Sprintf("%.0f%% of the layer is already compressed and barely shrinks when the layer is compressed again", layerCompression.PrecompressedShare()*100),
+				Hint:      "extract archives in the layer that adds them, or fetch media and packages at runtime instead of shipping them",
+			})
+		}
+		result.Layers = append(result.Layers, layerCompression)
+	}
+
+	files := visibleFiles(trees)
+	dirs := make(map[string]bool)
+	for filePath := range files {
+		for dir := path.Dir(filePath); dir != "/" && dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	for filePath, file := range files {
+		if file.size < compressionMinArchiveBytes {
+			continue
+		}
+		stem, ok := archiveStem(filePath)
+		if !ok || !dirs[stem] {
+			continue
+		}
+		result.Findings = append(result.Findings, CompressionFinding{
+			Kind:      CompressionArchiveExtracted,
+			Path:      filePath,
+			Layer:     file.layer,
+			SizeBytes: file.size,
+			Detail:    fmt.Sprintf("extracted to %s, so the contents are stored twice", stem),
+			Hint:      "remove the archive in the same RUN instruction that extracts it, or extract it in a build stage",
+		})
+	}
+
+	sort.Slice(result.Findings, func(i, j int) bool {
+		if result.Findings[i].SizeBytes == result.Findings[j].SizeBytes {
+			return result.Findings[i].Path < result.Findings[j].Path
+		}
+		return result.Findings[i].SizeBytes > result.Findings[j].SizeBytes
+	})
+	return result
+}
+
+// isPrecompressed indicates if the given file is (most likely) already compressed, judging by its extension.
+func isPrecompressed(filePath string) bool {
+	return precompressedExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// archiveStem returns the directory an archive is commonly extracted to ("/opt/node-v18.tar.gz" is extracted to
+// "/opt/node-v18").
+func archiveStem(filePath string) (string, bool) {
+	lower := strings.ToLower(filePath)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(filePath) > len(suffix) {
+			return filePath[:len(filePath)-len(suffix)], true
+		}
+	}
+	return "", false
+}
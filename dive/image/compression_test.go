@@ -0,0 +1,86 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAnalyzeCompression(t *testing.T) {
+	const mb = 1024 * 1024
+
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/bin/sh", 1*mb)
+	// an archive kept next to its extraction
+	add(trees[1], "/opt/node-v18.tar.gz", 30*mb)
+	add(trees[1], "/opt/node-v18/bin/node", 80*mb)
+	// a small archive is not worth reporting
+	add(trees[1], "/opt/tiny.zip", 1024)
+	add(trees[1], "/opt/tiny/readme", 10)
+	// a layer mostly made of already-compressed content
+	add(trees[2], "/srv/media/intro.mp4", 40*mb)
+	add(trees[2], "/srv/media/poster.PNG", 5*mb)
+	add(trees[2], "/srv/index.html", 1*mb)
+
+	layers := []*Layer{
+		{Index: 0, Size: 1 * mb, CompressedSize: mb / 2},
+		{Index: 1, Size: 110*mb + 1034, CompressedSize: 60 * mb},
+		{Index: 2, Size: 46 * mb},
+	}
+
+	analysis := AnalyzeCompression(layers, trees)
+
+	expectedLayers := []LayerCompression{
+		{Layer: 0, SizeBytes: 1 * mb, CompressedBytes: mb / 2},
+		{Layer: 1, SizeBytes: 110*mb + 1034, CompressedBytes: 60 * mb, PrecompressedBytes: 30*mb + 1024},
+		{Layer: 2, SizeBytes: 46 * mb, PrecompressedBytes: 45 * mb},
+	}
+	if !reflect.DeepEqual(analysis.Layers, expectedLayers) {
+		t.Errorf("expected layers %+v, got %+v", expectedLayers, analysis.Layers)
+	}
+
+	if ratio := analysis.Layers[0].Ratio(); ratio != 2 {
+		t.Errorf("expected a ratio of 2, got %v", ratio)
+	}
+	if ratio := analysis.Layers[2].Ratio(); ratio != 0 {
+		t.Errorf("expected an unknown ratio, got %v", ratio)
+	}
+
+	if len(analysis.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", analysis.Findings)
+	}
+	recompressed := analysis.Findings[0]
+	if recompressed.Kind != CompressionRecompressed || recompressed.Layer != 2 || recompressed.Path != "/srv/media/intro.mp4" || recompressed.SizeBytes != 45*mb {
+		t.Errorf("unexpected recompressed finding: %+v", recompressed)
+	}
+	archive := analysis.Findings[1]
+	if archive.Kind != CompressionArchiveExtracted || archive.Layer != 1 || archive.Path != "/opt/node-v18.tar.gz" || archive.SizeBytes != 30*mb {
+		t.Errorf("unexpected archive finding: %+v", archive)
+	}
+}
+
+func TestArchiveStem(t *testing.T) {
+	cases := map[string]string{
+		"/opt/node-v18.tar.gz": "/opt/node-v18",
+		"/opt/jdk.TGZ":         "/opt/jdk",
+		"/tmp/src.zip":         "/tmp/src",
+		"/var/log/syslog.gz":   "",
+	}
+	for filePath, expected := range cases {
+		stem, ok := archiveStem(filePath)
+		if ok != (expected != "") || stem != expected {
+			t.Errorf("%s: expected stem %q, got %q (%v)", filePath, expected, stem, ok)
+		}
+	}
+}
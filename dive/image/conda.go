@@ -0,0 +1,198 @@
+package image
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// CondaPackage is a package installed within a conda environment (as recorded in its conda-meta directory).
+type CondaPackage struct {
+	Name    string
+	Version string
+	Build   string
+}
+
+// CondaEnvironment is a conda environment (the base installation or a named environment) within the final image.
+type CondaEnvironment struct {
+	Prefix   string
+	Name     string
+	Packages []CondaPackage
+	// the size of the environment, excluding nested environments and package caches
+	SizeBytes uint64
+	// the index of the last layer that changed the environment
+	Layer int
+}
+
+// CondaPackageCache is a conda package cache directory: the downloaded package archives and their extracted copies,
+// which are not needed once the packages are installed ("conda clean --all").
+type CondaPackageCache struct {
+	Path      string
+	Packages  int
+	SizeBytes uint64
+	// the index of the last layer that changed the cache
+	Layer int
+}
+
+// CondaAnalysis lists the conda environments and package caches within the final image.
+type CondaAnalysis struct {
+	Environments  []CondaEnvironment
+	PackageCaches []CondaPackageCache
+}
+
+// Empty indicates if no conda content was found.
+func (analysis *CondaAnalysis) Empty() bool {
+	return len(analysis.Environments) == 0 && len(analysis.PackageCaches) == 0
+}
+
+// FindCondaEnvironments finds the conda environments (with their packages and sizes) and the conda package caches
+// within the final image (after every layer and whiteout has been applied).
+func FindCondaEnvironments(trees []*filetree.FileTree) *CondaAnalysis {
+	files := visibleFiles(trees)
+	result := &CondaAnalysis{
+		Environments:  make([]CondaEnvironment, 0),
+		PackageCaches: make([]CondaPackageCache, 0),
+	}
+
+	prefixes := condaPrefixes(files)
+	caches := condaPackageCaches(files)
+
+	environments := make(map[string]*CondaEnvironment)
+	for prefix := range prefixes {
+		environments[prefix] = &CondaEnvironment{Prefix: prefix, Name: condaEnvironmentName(prefix), Packages: make([]CondaPackage, 0)}
+	}
+	cacheResults := make(map[string]*CondaPackageCache)
+	cachedPackages := make(map[string]map[string]bool)
+	for cache := range caches {
+		cacheResults[cache] = &CondaPackageCache{Path: cache}
+		cachedPackages[cache] = make(map[string]bool)
+	}
+
+	for filePath, file := range files {
+		if cache := owningDir(filePath, caches); cache != "" {
+			result := cacheResults[cache]
+			result.SizeBytes += file.size
+			if file.layer > result.Layer {
+				result.Layer = file.layer
+			}
+			if relative := strings.TrimPrefix(filePath, cache+"/"); isCachedCondaPackage(relative) {
+				// an archive and its extracted copy are the same package
+				name := strings.TrimSuffix(strings.TrimSuffix(strings.SplitN(relative, "/", 2)[0], ".conda"), ".tar.bz2")
+				cachedPackages[cache][name] = true
+			}
+			continue
+		}
+
+		prefix := owningDir(filePath, prefixes)
+		if prefix == "" {
+			continue
+		}
+		environment := environments[prefix]
+		environment.SizeBytes += file.size
+		if file.layer > environment.Layer {
+			environment.Layer = file.layer
+		}
+		if path.Dir(filePath) == path.Join(prefix, "conda-meta") {
+			if condaPackage, ok := parseCondaPackage(path.Base(filePath)); ok {
+				environment.Packages = append(environment.Packages, condaPackage)
+			}
+		}
+	}
+
+	for _, environment := range environments {
+		sort.Slice(environment.Packages, func(i, j int) bool {
+			return environment.Packages[i].Name < environment.Packages[j].Name
+		})
+		result.Environments = append(result.Environments, *environment)
+	}
+	sort.Slice(result.Environments, func(i, j int) bool {
+		return result.Environments[i].Prefix < result.Environments[j].Prefix
+	})
+
+	for cachePath, cache := range cacheResults {
+		cache.Packages = len(cachedPackages[cachePath])
+		result.PackageCaches = append(result.PackageCaches, *cache)
+	}
+	sort.Slice(result.PackageCaches, func(i, j int) bool {
+		return result.PackageCaches[i].Path < result.PackageCaches[j].Path
+	})
+
+	return result
+}
+
+// condaPrefixes finds the conda environment prefixes: the directories holding a conda-meta directory.
+func condaPrefixes(files map[string]visibleFile) map[string]bool {
+	prefixes := make(map[string]bool)
+	for filePath := range files {
+		if dir := path.Dir(filePath); path.Base(dir) == "conda-meta" {
+			prefixes[path.Dir(dir)] = true
+		}
+	}
+	return prefixes
+}
+
+// condaPackageCaches finds the conda package cache directories: "pkgs" directories holding package archives
+// ("*.conda", "*.tar.bz2"), extracted packages ("<package>/info/index.json") or the cache index ("urls.txt").
+func condaPackageCaches(files map[string]visibleFile) map[string]bool {
+	caches := make(map[string]bool)
+	for filePath := range files {
+		idx := strings.LastIndex(filePath, "/pkgs/")
+		for idx >= 0 {
+			cache := filePath[:idx+len("/pkgs")]
+			relative := filePath[len(cache)+1:]
+			if relative == "urls.txt" || isCachedCondaPackage(relative) {
+				caches[cache] = true
+				break
+			}
+			idx = strings.LastIndex(filePath[:idx], "/pkgs/")
+		}
+	}
+	return caches
+}
+
+// owningDir returns the deepest of the given directories that holds the file (if any), so that nested environments
+// ("/opt/conda/envs/app" within "/opt/conda") own their own files.
+func owningDir(filePath string, dirs map[string]bool) string {
+	for dir := path.Dir(filePath); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if dirs[dir] {
+			return dir
+		}
+	}
+	return ""
+}
+
+// isCachedCondaPackage indicates if the path (relative to a package cache) is a package archive or the index of an
+// extracted package.
+func isCachedCondaPackage(relative string) bool {
+	if !strings.Contains(relative, "/") {
+		return strings.HasSuffix(relative, ".conda") || strings.HasSuffix(relative, ".tar.bz2")
+	}
+	return strings.HasSuffix(relative, "/info/index.json") && strings.Count(relative, "/") == 2
+}
+
+// condaEnvironmentName names the environment as conda does: "base" for the installation itself, the directory name for
+// environments within "envs".
+func condaEnvironmentName(prefix string) string {
+	if path.Base(path.Dir(prefix)) == "envs" {
+		return path.Base(prefix)
+	}
+	return "base"
+}
+
+// parseCondaPackage extracts the package from a conda-meta record name ("numpy-1.26.0-py310h5f9d8c6_0.json").
+func parseCondaPackage(name string) (CondaPackage, bool) {
+	if !strings.HasSuffix(name, ".json") {
+		return CondaPackage{}, false
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".json"), "-")
+	if len(parts) < 3 {
+		return CondaPackage{}, false
+	}
+	return CondaPackage{
+		Name:    strings.Join(parts[:len(parts)-2], "-"),
+		Version: parts[len(parts)-2],
+		Build:   parts[len(parts)-1],
+	}, true
+}
@@ -0,0 +1,67 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindCondaEnvironments(t *testing.T) {
+	trees := make([]*filetree.FileTree, 2)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/opt/conda/conda-meta/history", 10)
+	add(trees[0], "/opt/conda/conda-meta/python-3.10.13-h955ad1f_0.json", 20)
+	add(trees[0], "/opt/conda/conda-meta/ca-certificates-2023.08.22-h06a4308_0.json", 20)
+	add(trees[0], "/opt/conda/bin/python3.10", 5000)
+	add(trees[0], "/opt/conda/pkgs/urls.txt", 10)
+	add(trees[0], "/opt/conda/pkgs/python-3.10.13-h955ad1f_0.conda", 3000)
+	add(trees[0], "/opt/conda/pkgs/python-3.10.13-h955ad1f_0/info/index.json", 50)
+	add(trees[0], "/opt/conda/pkgs/python-3.10.13-h955ad1f_0/bin/python3.10", 5000)
+	add(trees[1], "/opt/conda/envs/app/conda-meta/numpy-1.26.0-py310h5f9d8c6_0.json", 20)
+	add(trees[1], "/opt/conda/envs/app/lib/libopenblas.so", 7000)
+	// a leftover cache of an environment that was removed
+	add(trees[1], "/root/.conda/pkgs/numpy-1.26.0-py310h5f9d8c6_0.tar.bz2", 900)
+
+	analysis := FindCondaEnvironments(trees)
+
+	expectedEnvironments := []CondaEnvironment{
+		{
+			Prefix: "/opt/conda",
+			Name:   "base",
+			Packages: []CondaPackage{
+				{Name: "ca-certificates", Version: "2023.08.22", Build: "h06a4308_0"},
+				{Name: "python", Version: "3.10.13", Build: "h955ad1f_0"},
+			},
+			SizeBytes: 5050,
+			Layer:     0,
+		},
+		{
+			Prefix:    "/opt/conda/envs/app",
+			Name:      "app",
+			Packages:  []CondaPackage{{Name: "numpy", Version: "1.26.0", Build: "py310h5f9d8c6_0"}},
+			SizeBytes: 7020,
+			Layer:     1,
+		},
+	}
+	if !reflect.DeepEqual(analysis.Environments, expectedEnvironments) {
+		t.Errorf("expected environments %+v, got %+v", expectedEnvironments, analysis.Environments)
+	}
+
+	expectedCaches := []CondaPackageCache{
+		{Path: "/opt/conda/pkgs", Packages: 1, SizeBytes: 8060, Layer: 0},
+		{Path: "/root/.conda/pkgs", Packages: 1, SizeBytes: 900, Layer: 1},
+	}
+	if !reflect.DeepEqual(analysis.PackageCaches, expectedCaches) {
+		t.Errorf("expected caches %+v, got %+v", expectedCaches, analysis.PackageCaches)
+	}
+}
@@ -0,0 +1,86 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/wagoodman/dive/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// runCtrCmd runs a given containerd CLI command in the current tty
+func runCtrCmd(ctx context.Context, cmdStr string, args ...string) error {
+	if !isCtrClientBinaryAvailable() {
+		return fmt.Errorf("cannot find ctr client executable")
+	}
+
+	allArgs := utils.CleanArgs(append([]string{cmdStr}, args...))
+
+	cmd := ctrCommand(ctx, allArgs...)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	return cmd.Run()
+}
+
+// outputCtrCmd runs a given containerd CLI command, returning what it wrote to stdout.
+func outputCtrCmd(ctx context.Context, args ...string) ([]byte, error) {
+	if !isCtrClientBinaryAvailable() {
+		return nil, fmt.Errorf("cannot find ctr client executable")
+	}
+
+	cmd := ctrCommand(ctx, utils.CleanArgs(args)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		message := string(bytes.TrimSpace(stderr.Bytes()))
+		return nil, fmt.Errorf("ctr %v: %v: %s%s", args, err, message, permissionHint(message))
+	}
+	return output, nil
+}
+
+// streamCtrCmd runs a given containerd CLI command, returning its stdout as it is written. Closing the reader waits
+// for the command to exit (reporting how it failed, if it did).
+func streamCtrCmd(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	if !isCtrClientBinaryAvailable() {
+		return nil, fmt.Errorf("cannot find ctr client executable")
+	}
+
+	cmd := ctrCommand(ctx, utils.CleanArgs(args)...)
+	cmd.Stderr = os.Stderr
+
+	reader, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandReader{Reader: reader, cmd: cmd}, nil
+}
+
+type commandReader struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func (r *commandReader) Close() error {
+	// drain the output so that the command is not blocked writing it
+	_, _ = io.Copy(ioutil.Discard, r.Reader)
+	return r.cmd.Wait()
+}
+
+func isCtrClientBinaryAvailable() bool {
+	_, err := exec.LookPath("ctr")
+	return err == nil
+}
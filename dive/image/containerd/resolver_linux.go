@@ -0,0 +1,79 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+	"runtime"
+	"strings"
+)
+
+type resolver struct {
+	options image.ResolverOptions
+}
+
+// NewResolverFromEngine creates a resolver fetching images from the containerd image store with the ctr CLI (the
+// namespace is taken from CONTAINERD_NAMESPACE, e.g. "k8s.io" for the images of a kubernetes node).
+func NewResolverFromEngine(options image.ResolverOptions) *resolver {
+	return &resolver{options: options}
+}
+
+func (r *resolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	return nil, fmt.Errorf("build option not supported for containerd resolver")
+}
+
+func (r *resolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	available, err := isImageAvailable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pull, err := r.options.Pull.ShouldPull(id, available, r.options.Network)
+	if err != nil {
+		return nil, err
+	}
+	if pull {
+		// containerd only pulls fully qualified references (e.g. docker.io/library/alpine:latest)
+		if !available {
+			fmt.Println("Image not available locally. Trying to pull '" + id + "'...")
+		}
+		if err := runCtrCmd(ctx, "images", "pull", "--platform", platform, id); err != nil {
+			return nil, err
+		}
+	}
+
+	// only the content of the local platform is exported, other platforms of an index are usually not pulled
+	reader, err := streamCtrCmd(ctx, "images", "export", "--platform", platform, "-", id)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := docker.ReadImageArchive(ctx, r.options.IO.Throttle(reader), 0, r.options)
+	closeErr := reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("unable to export image '%s': %v", id, closeErr)
+	}
+	return archive.ToImage()
+}
+
+// isImageAvailable indicates if the image is within the containerd image store.
+func isImageAvailable(ctx context.Context, id string) (bool, error) {
+	output, err := outputCtrCmd(ctx, "images", "list", "--quiet")
+	if err != nil {
+		return false, err
+	}
+	for _, name := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(name) == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+type resolver struct {
+	options image.ResolverOptions
+}
+
+func NewResolverFromEngine(options image.ResolverOptions) *resolver {
+	return &resolver{options: options}
+}
+
+func (r *resolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	return nil, fmt.Errorf("unsupported platform")
+}
+
+func (r *resolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+	return nil, fmt.Errorf("unsupported platform")
+}
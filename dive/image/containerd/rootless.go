@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// the socket of a rootful containerd (and of a rootless one, within its mount namespace)
+const rootfulSocket = "/run/containerd/containerd.sock"
+
+// ctrCommand creates the command running the containerd CLI with the given arguments. A rootless containerd (as set up
+// by containerd-rootless.sh) listens within a mount namespace of its own, so when it is running and the rootful socket
+// is not usable, ctr is run within the namespaces of the rootless containerd instead (as nerdctl does).
+func ctrCommand(ctx context.Context, args ...string) *exec.Cmd {
+	name, prefix := ctrInvocation(os.Getenv, os.Geteuid(), socketAccessible, ioutil.ReadFile)
+	cmd := exec.CommandContext(ctx, name, append(prefix, args...)...)
+	cmd.Env = os.Environ()
+	return cmd
+}
+
+// ctrInvocation returns the executable and the leading arguments that run ctr against the containerd the user can
+// reach.
+func ctrInvocation(getenv func(string) string, euid int, accessible func(string) bool, readFile func(string) ([]byte, error)) (string, []string) {
+	if getenv("CONTAINERD_ADDRESS") != "" || euid == 0 || accessible(rootfulSocket) {
+		return "ctr", nil
+	}
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		return "ctr", nil
+	}
+	contents, err := readFile(filepath.Join(runtimeDir, "containerd-rootless", "child_pid"))
+	if err != nil {
+		return "ctr", nil
+	}
+	pid := strings.TrimSpace(string(contents))
+	if pid == "" {
+		return "ctr", nil
+	}
+	return "nsenter", []string{"-U", "--preserve-credentials", "-m", "-n", "-t", pid, "ctr"}
+}
+
+// socketAccessible indicates if the socket exists and the current user may connect to it.
+func socketAccessible(socket string) bool {
+	return syscall.Access(socket, 0x2) == nil
+}
+
+// permissionHint explains a ctr failure caused by the containerd socket denying access to the current user (empty for
+// any other failure).
+func permissionHint(stderr string) string {
+	if !strings.Contains(strings.ToLower(stderr), "permission denied") {
+		return ""
+	}
+	return " (the containerd socket is owned by root: run dive as root, set CONTAINERD_ADDRESS, or start a rootless containerd with containerd-rootless-setuptool.sh)"
+}
@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCtrInvocation(t *testing.T) {
+	childPID := func(path string) ([]byte, error) {
+		if path != "/run/user/1000/containerd-rootless/child_pid" {
+			return nil, fmt.Errorf("no such file: %s", path)
+		}
+		return []byte("4242\n"), nil
+	}
+	noFile := func(path string) ([]byte, error) { return nil, fmt.Errorf("no such file: %s", path) }
+	accessible := func(string) bool { return true }
+	denied := func(string) bool { return false }
+
+	cases := []struct {
+		name       string
+		env        map[string]string
+		euid       int
+		accessible func(string) bool
+		readFile   func(string) ([]byte, error)
+		expected   []string
+	}{
+		{name: "rootful socket", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}, euid: 1000, accessible: accessible, readFile: childPID, expected: []string{"ctr"}},
+		{name: "root", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}, euid: 0, accessible: denied, readFile: childPID, expected: []string{"ctr"}},
+		{name: "explicit address", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000", "CONTAINERD_ADDRESS": "/tmp/containerd.sock"}, euid: 1000, accessible: denied, readFile: childPID, expected: []string{"ctr"}},
+		{name: "rootless", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}, euid: 1000, accessible: denied, readFile: childPID, expected: []string{"nsenter", "-U", "--preserve-credentials", "-m", "-n", "-t", "4242", "ctr"}},
+		{name: "no rootless containerd", env: map[string]string{"XDG_RUNTIME_DIR": "/run/user/1000"}, euid: 1000, accessible: denied, readFile: noFile, expected: []string{"ctr"}},
+	}
+
+	for _, test := range cases {
+		name, prefix := ctrInvocation(func(key string) string { return test.env[key] }, test.euid, test.accessible, test.readFile)
+		if actual := append([]string{name}, prefix...); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
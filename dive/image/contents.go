@@ -0,0 +1,143 @@
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the most symbolic links followed when opening a file (as with the linux ELOOP limit)
+const maxLinkDepth = 40
+
+// ContentReader reads the contents of the files within the image layers.
+type ContentReader interface {
+	// OpenFile opens the given path as written by the layer at the given index.
+	OpenFile(layer int, filePath string) (io.ReadCloser, error)
+}
+
+// FileLayer returns the last layer (up to and including the given layer) that wrote the given path, which is where
+// the contents of the path are seen from that layer. False is returned when the path does not exist at that layer.
+func FileLayer(trees []*filetree.FileTree, upTo int, filePath string) (int, *filetree.FileNode, bool) {
+	filePath = path.Clean("/" + filePath)
+	if upTo >= len(trees) {
+		upTo = len(trees) - 1
+	}
+	for idx := upTo; idx >= 0; idx-- {
+		tree := trees[idx]
+		if tree == nil {
+			continue
+		}
+		node, err := tree.GetNode(filePath)
+		if err == nil && node != nil && node != tree.Root {
+			return idx, node, true
+		}
+		if deletesPath(tree, filePath) {
+			return 0, nil, false
+		}
+	}
+	return 0, nil, false
+}
+
+// OpenFile opens the file at the given path as seen from the given layer, following symbolic links.
+func OpenFile(contents ContentReader, trees []*filetree.FileTree, upTo int, filePath string) (io.ReadCloser, error) {
+	if contents == nil {
+		return nil, fmt.Errorf("the file contents are not available for this image")
+	}
+
+	filePath = path.Clean("/" + filePath)
+	for depth := 0; depth <= maxLinkDepth; depth++ {
+		layer, node, exists := FileLayer(trees, upTo, filePath)
+		if !exists {
+			return nil, fmt.Errorf("'%s' does not exist at layer %d", filePath, upTo)
+		}
+
+		info := node.Data.FileInfo
+		switch {
+		case info.IsDir || len(node.Children) > 0:
+			return nil, fmt.Errorf("'%s' is a directory", filePath)
+		case info.TypeFlag == tar.TypeSymlink:
+			target := info.Linkname
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(filePath), target)
+			}
+			filePath = path.Clean(target)
+		default:
+			return contents.OpenFile(layer, filePath)
+		}
+	}
+	return nil, fmt.Errorf("too many levels of symbolic links opening '%s'", filePath)
+}
+
+// ContentWalker is implemented by the content readers that can read several files of a layer in a single pass (rather
+// than reading the layer again for every file).
+type ContentWalker interface {
+	// WalkFiles calls the visitor with the contents of the regular files of the layer at the given index that are
+	// among the given paths.
+	WalkFiles(layer int, paths map[string]bool, visitor func(filePath string, reader io.Reader) error) error
+}
+
+// BlobReader is implemented by the content readers that can read the layer blobs and the image config as the image
+// stores them (so that the image can be copied without rebuilding its layers).
+type BlobReader interface {
+	// OpenLayerBlob opens the blob of the layer at the given index as stored (compressed when the layer is stored
+	// compressed), returning the OCI media type of the blob.
+	OpenLayerBlob(layer int) (io.ReadCloser, string, error)
+	// ConfigBlob returns the image config as stored.
+	ConfigBlob() ([]byte, error)
+}
+
+// ReadFiles reads the given files as seen from the top layer, reading each layer only once when the content reader
+// is a ContentWalker. Files that do not exist or cannot be read are left out.
+func ReadFiles(contents ContentReader, trees []*filetree.FileTree, paths []string, visitor func(filePath string, reader io.Reader) error) error {
+	if contents == nil {
+		return fmt.Errorf("the file contents are not available for this image")
+	}
+
+	// the regular files are read from the layer that last wrote them, the others (links) are opened one by one
+	pending := make(map[string]bool)
+	byLayer := make(map[int]map[string]bool)
+	walker, canWalk := contents.(ContentWalker)
+	for _, filePath := range paths {
+		filePath = path.Clean("/" + filePath)
+		layer, node, exists := FileLayer(trees, len(trees)-1, filePath)
+		if !exists {
+			continue
+		}
+		pending[filePath] = true
+		if canWalk && node.Data.FileInfo.TypeFlag == tar.TypeReg {
+			if byLayer[layer] == nil {
+				byLayer[layer] = make(map[string]bool)
+			}
+			byLayer[layer][filePath] = true
+		}
+	}
+
+	for layer, layerPaths := range byLayer {
+		err := walker.WalkFiles(layer, layerPaths, func(filePath string, reader io.Reader) error {
+			if !pending[filePath] {
+				return nil
+			}
+			delete(pending, filePath)
+			return visitor(filePath, reader)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for filePath := range pending {
+		reader, err := OpenFile(contents, trees, len(trees)-1, filePath)
+		if err != nil {
+			continue
+		}
+		err = visitor(filePath, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
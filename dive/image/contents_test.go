@@ -0,0 +1,74 @@
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// layerContents reads every file as "<layer>:<path>"
+type layerContents struct{}
+
+func (layerContents) OpenFile(layer int, filePath string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(fmt.Sprintf("%d:%s", layer, filePath))), nil
+}
+
+func TestOpenFile(t *testing.T) {
+	trees := make([]*filetree.FileTree, 4)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc/app.conf", filetree.FileInfo{Size: 10})
+	add(trees[0], "/etc/current.conf", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "app.conf"})
+	add(trees[1], "/etc/app.conf", filetree.FileInfo{Size: 20})
+	add(trees[2], "/etc/.wh.app.conf", filetree.FileInfo{})
+	add(trees[3], "/etc/loop", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "/etc/loop"})
+
+	cases := []struct {
+		upTo     int
+		path     string
+		expected string
+	}{
+		{upTo: 0, path: "/etc/app.conf", expected: "0:/etc/app.conf"},
+		{upTo: 1, path: "etc/app.conf", expected: "1:/etc/app.conf"},
+		// the link is resolved from the given layer, which sees the later version of the target
+		{upTo: 1, path: "/etc/current.conf", expected: "1:/etc/app.conf"},
+		{upTo: 2, path: "/etc/app.conf"},
+		{upTo: 3, path: "/etc/current.conf"},
+		{upTo: 3, path: "/etc"},
+		{upTo: 3, path: "/etc/loop"},
+	}
+
+	for _, test := range cases {
+		reader, err := OpenFile(layerContents{}, trees, test.upTo, test.path)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("%s at layer %d: expected an error", test.path, test.upTo)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s at layer %d: unexpected error: %v", test.path, test.upTo, err)
+		}
+		actual, _ := ioutil.ReadAll(reader)
+		if string(actual) != test.expected {
+			t.Errorf("%s at layer %d: expected %q, got %q", test.path, test.upTo, test.expected, actual)
+		}
+	}
+
+	if _, err := OpenFile(nil, trees, 0, "/etc/app.conf"); err == nil {
+		t.Errorf("expected an error without contents")
+	}
+}
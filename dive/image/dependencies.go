@@ -0,0 +1,238 @@
+package image
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the ecosystems of the dependencies found
+const (
+	EcosystemNode   = "node"
+	EcosystemPython = "python"
+	EcosystemGo     = "go"
+	EcosystemRust   = "rust"
+)
+
+// the directories python distributions are installed into
+var pythonPackageDirs = map[string]bool{"site-packages": true, "dist-packages": true}
+
+// Dependency is a dependency of a language runtime within the final image: a package within node_modules, a python
+// distribution within site-packages, or a Go or Rust binary (whose dependencies are linked in).
+type Dependency struct {
+	Ecosystem string
+	Name      string
+	// the version, when the file names tell (python distributions, and the toolchain of binaries)
+	Version string
+	// the directory of the package (or the binary)
+	Path      string
+	SizeBytes uint64
+	Files     int
+	// the largest sections of binaries (e.g. "text 8.1 MB, rodata 2.0 MB")
+	Sections string
+	// the index of the last layer that changed the dependency
+	Layer int
+}
+
+// Label names the dependency with its version (e.g. "requests 2.31.0").
+func (d Dependency) Label() string {
+	return strings.TrimSpace(d.Name + " " + d.Version)
+}
+
+// Dependencies are the language runtime dependencies within the final image, the largest first.
+type Dependencies struct {
+	Dependencies []Dependency
+}
+
+// Empty indicates if no dependencies were found.
+func (d *Dependencies) Empty() bool {
+	return d == nil || len(d.Dependencies) == 0
+}
+
+// SizeBytes returns the size of the dependencies of the given ecosystem (all of them when no ecosystem is given).
+func (d *Dependencies) SizeBytes(ecosystem string) uint64 {
+	var size uint64
+	for _, dependency := range d.Dependencies {
+		if ecosystem == "" || dependency.Ecosystem == ecosystem {
+			size += dependency.SizeBytes
+		}
+	}
+	return size
+}
+
+// Ecosystems returns the ecosystems that have dependencies, the largest first.
+func (d *Dependencies) Ecosystems() []string {
+	var ecosystems []string
+	seen := make(map[string]bool)
+	for _, dependency := range d.Dependencies {
+		if !seen[dependency.Ecosystem] {
+			seen[dependency.Ecosystem] = true
+			ecosystems = append(ecosystems, dependency.Ecosystem)
+		}
+	}
+	sort.SliceStable(ecosystems, func(i, j int) bool {
+		return d.SizeBytes(ecosystems[i]) > d.SizeBytes(ecosystems[j])
+	})
+	return ecosystems
+}
+
+// FindDependencies rolls the final image up by language runtime dependency: the packages within node_modules (nested
+// node_modules are packages of their own), the python distributions within site-packages (the files of a distribution
+// are found by its top-level name, e.g. "requests/" for "requests-2.31.0.dist-info", other top-level names are
+// listed by themselves), and the Go and Rust binaries with their section sizes (as found by the ELF inspector).
+func FindDependencies(trees []*filetree.FileTree) *Dependencies {
+	files := visibleFiles(trees)
+	found := make(map[string]*Dependency)
+	add := func(key string, dependency Dependency, file visibleFile) {
+		existing, exists := found[key]
+		if !exists {
+			existing = &dependency
+			found[key] = existing
+		}
+		existing.SizeBytes += file.size
+		existing.Files++
+		if file.layer > existing.Layer {
+			existing.Layer = file.layer
+		}
+	}
+
+	distributions := pythonDistributions(files)
+	for filePath, file := range files {
+		if root, name := nodePackage(filePath); root != "" {
+			add(root, Dependency{Ecosystem: EcosystemNode, Name: name, Path: root}, file)
+			continue
+		}
+		if siteDir, top := pythonTopLevel(filePath); siteDir != "" {
+			key := path.Join(siteDir, normalizePythonName(top))
+			dependency, exists := distributions[key]
+			if !exists {
+				dependency = Dependency{Ecosystem: EcosystemPython, Name: pythonModuleName(top), Path: path.Join(siteDir, top)}
+			}
+			add(key, dependency, file)
+			continue
+		}
+		if dependency, ok := binaryDependency(filePath, file); ok {
+			add(filePath, dependency, file)
+		}
+	}
+
+	result := &Dependencies{Dependencies: make([]Dependency, 0, len(found))}
+	for _, dependency := range found {
+		result.Dependencies = append(result.Dependencies, *dependency)
+	}
+	sort.Slice(result.Dependencies, func(i, j int) bool {
+		if result.Dependencies[i].SizeBytes != result.Dependencies[j].SizeBytes {
+			return result.Dependencies[i].SizeBytes > result.Dependencies[j].SizeBytes
+		}
+		return result.Dependencies[i].Path < result.Dependencies[j].Path
+	})
+	return result
+}
+
+// nodePackage returns the directory and name of the package within the deepest node_modules holding the file (e.g.
+// "/app/node_modules/@types/node" and "@types/node"). Files of node_modules itself (e.g. ".bin" and the hidden
+// lockfile) belong to no package.
+func nodePackage(filePath string) (string, string) {
+	idx := strings.LastIndex(filePath, "/node_modules/")
+	if idx < 0 {
+		return "", ""
+	}
+	dir := filePath[:idx+len("/node_modules")]
+	parts := strings.Split(filePath[len(dir)+1:], "/")
+	if len(parts) < 2 || strings.HasPrefix(parts[0], ".") {
+		return "", ""
+	}
+	name := parts[0]
+	if strings.HasPrefix(name, "@") {
+		if len(parts) < 3 {
+			return "", ""
+		}
+		name += "/" + parts[1]
+	}
+	return path.Join(dir, name), name
+}
+
+// pythonTopLevel returns the site-packages directory holding the file and the top-level name of the file within it
+// (e.g. "/usr/lib/python3/dist-packages" and "requests").
+func pythonTopLevel(filePath string) (string, string) {
+	parts := strings.Split(filePath, "/")
+	for idx := len(parts) - 2; idx > 0; idx-- {
+		if pythonPackageDirs[parts[idx]] {
+			return strings.Join(parts[:idx+1], "/"), parts[idx+1]
+		}
+	}
+	return "", ""
+}
+
+// pythonDistributions finds the installed python distributions ("<name>-<version>.dist-info" or ".egg-info"), by
+// site-packages directory and normalized name.
+func pythonDistributions(files map[string]visibleFile) map[string]Dependency {
+	distributions := make(map[string]Dependency)
+	for filePath := range files {
+		siteDir, top := pythonTopLevel(filePath)
+		if siteDir == "" || !(strings.HasSuffix(top, ".dist-info") || strings.HasSuffix(top, ".egg-info")) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(strings.TrimSuffix(top, ".dist-info"), ".egg-info"), "-", 3)
+		dependency := Dependency{Ecosystem: EcosystemPython, Name: parts[0], Path: path.Join(siteDir, top)}
+		if len(parts) > 1 {
+			dependency.Version = parts[1]
+		}
+		// the metadata and the package share the key, so that the metadata is counted with the package
+		distributions[path.Join(siteDir, normalizePythonName(top))] = dependency
+	}
+	return distributions
+}
+
+// normalizePythonName reduces a top-level name of site-packages to the name of the distribution it belongs to:
+// "requests-2.31.0.dist-info", "requests", "numpy.libs" and "_cffi_backend.cpython-311-x86_64-linux-gnu.so" become
+// "requests", "requests", "numpy" and "_cffi_backend".
+func normalizePythonName(top string) string {
+	name := top
+	if strings.HasSuffix(name, ".dist-info") || strings.HasSuffix(name, ".egg-info") {
+		name = strings.SplitN(name, "-", 2)[0]
+	}
+	name = pythonModuleName(name)
+	return strings.ToLower(strings.Replace(strings.Replace(name, "-", "_", -1), ".", "_", -1))
+}
+
+// pythonModuleName strips the extension (and the ABI tag of extension modules) from a top-level name.
+func pythonModuleName(top string) string {
+	for _, suffix := range []string{".libs", ".data", ".py", ".pth"} {
+		top = strings.TrimSuffix(top, suffix)
+	}
+	if idx := strings.Index(top, ".cpython-"); idx > 0 {
+		top = top[:idx]
+	}
+	if idx := strings.Index(top, ".abi3"); idx > 0 {
+		top = top[:idx]
+	}
+	return strings.TrimSuffix(top, ".so")
+}
+
+// binaryDependency describes a Go or Rust binary, as found by the ELF inspector.
+func binaryDependency(filePath string, file visibleFile) (Dependency, bool) {
+	for _, inspection := range file.inspections {
+		if inspection.Inspector != "elf" {
+			continue
+		}
+		var dependency Dependency
+		for _, field := range inspection.Fields {
+			switch field.Name {
+			case "Go":
+				dependency.Ecosystem, dependency.Version = EcosystemGo, strings.SplitN(field.Value, " ", 2)[0]
+			case "Rust":
+				dependency.Ecosystem, dependency.Version = EcosystemRust, strings.SplitN(field.Value, " ", 2)[0]
+			case "Sections":
+				dependency.Sections = field.Value
+			}
+		}
+		if dependency.Ecosystem != "" {
+			dependency.Name, dependency.Path = path.Base(filePath), filePath
+			return dependency, true
+		}
+	}
+	return Dependency{}, false
+}
@@ -0,0 +1,79 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindDependencies(t *testing.T) {
+	trees := make([]*filetree.FileTree, 2)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64, inspections ...filetree.Inspection) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size, Inspections: inspections}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/app/node_modules/.bin/tsc", 10)
+	add(trees[0], "/app/node_modules/typescript/package.json", 100)
+	add(trees[0], "/app/node_modules/typescript/lib/tsc.js", 9000)
+	add(trees[1], "/app/node_modules/@types/node/index.d.ts", 400)
+	add(trees[1], "/app/node_modules/@types/node/node_modules/undici-types/index.d.ts", 300)
+	add(trees[0], "/usr/lib/python3/dist-packages/requests-2.31.0.dist-info/METADATA", 50)
+	add(trees[0], "/usr/lib/python3/dist-packages/requests/__init__.py", 2000)
+	add(trees[0], "/usr/lib/python3/dist-packages/six.py", 700)
+	add(trees[0], "/usr/lib/python3/dist-packages/_cffi_backend.cpython-311-x86_64-linux-gnu.so", 600)
+	add(trees[0], "/usr/lib/python3/dist-packages/cffi-1.16.0.dist-info/RECORD", 40)
+	add(trees[1], "/usr/local/bin/app", 8000, filetree.Inspection{Inspector: "elf", Fields: []filetree.InspectionField{
+		{Name: "Sections", Value: "text 5.0 kB"},
+		{Name: "Go", Value: "go1.22.1 example.com/app"},
+	}})
+	add(trees[1], "/usr/local/bin/rg", 5000, filetree.Inspection{Inspector: "elf", Fields: []filetree.InspectionField{
+		{Name: "Rust", Value: "1.76.0 (07dca489a 2024-02-04)"},
+	}})
+	add(trees[1], "/usr/bin/ls", 3000, filetree.Inspection{Inspector: "elf"})
+
+	dependencies := FindDependencies(trees)
+
+	expected := []Dependency{
+		{Ecosystem: EcosystemNode, Name: "typescript", Path: "/app/node_modules/typescript", SizeBytes: 9100, Files: 2},
+		{Ecosystem: EcosystemGo, Name: "app", Version: "go1.22.1", Path: "/usr/local/bin/app", SizeBytes: 8000, Files: 1, Sections: "text 5.0 kB", Layer: 1},
+		{Ecosystem: EcosystemRust, Name: "rg", Version: "1.76.0", Path: "/usr/local/bin/rg", SizeBytes: 5000, Files: 1, Layer: 1},
+		{Ecosystem: EcosystemPython, Name: "requests", Version: "2.31.0", Path: "/usr/lib/python3/dist-packages/requests-2.31.0.dist-info", SizeBytes: 2050, Files: 2},
+		{Ecosystem: EcosystemPython, Name: "six", Path: "/usr/lib/python3/dist-packages/six.py", SizeBytes: 700, Files: 1},
+		{Ecosystem: EcosystemPython, Name: "cffi", Version: "1.16.0", Path: "/usr/lib/python3/dist-packages/cffi-1.16.0.dist-info", SizeBytes: 40, Files: 1},
+		{Ecosystem: EcosystemPython, Name: "_cffi_backend", Path: "/usr/lib/python3/dist-packages/_cffi_backend.cpython-311-x86_64-linux-gnu.so", SizeBytes: 600, Files: 1},
+		{Ecosystem: EcosystemNode, Name: "@types/node", Path: "/app/node_modules/@types/node", SizeBytes: 400, Files: 1, Layer: 1},
+		{Ecosystem: EcosystemNode, Name: "undici-types", Path: "/app/node_modules/@types/node/node_modules/undici-types", SizeBytes: 300, Files: 1, Layer: 1},
+	}
+	actual := dependencies.Dependencies
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d dependencies, got %+v", len(expected), actual)
+	}
+	byPath := make(map[string]Dependency)
+	for _, dependency := range actual {
+		byPath[dependency.Path] = dependency
+	}
+	for _, dependency := range expected {
+		if !reflect.DeepEqual(byPath[dependency.Path], dependency) {
+			t.Errorf("expected %+v, got %+v", dependency, byPath[dependency.Path])
+		}
+	}
+	for idx := 1; idx < len(actual); idx++ {
+		if actual[idx].SizeBytes > actual[idx-1].SizeBytes {
+			t.Errorf("expected the largest dependencies first, got %+v", actual)
+		}
+	}
+
+	if size := dependencies.SizeBytes(EcosystemPython); size != 3390 {
+		t.Errorf("expected 3390 bytes of python dependencies, got %d", size)
+	}
+	if ecosystems := dependencies.Ecosystems(); !reflect.DeepEqual(ecosystems, []string{EcosystemNode, EcosystemGo, EcosystemRust, EcosystemPython}) {
+		t.Errorf("unexpected ecosystems %v", ecosystems)
+	}
+}
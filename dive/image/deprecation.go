@@ -0,0 +1,13 @@
+package image
+
+// Deprecation is a legacy aspect of an image that registries and container engines are dropping support for.
+type Deprecation struct {
+	// Feature names the legacy aspect of the image (e.g. "MAINTAINER instruction")
+	Feature string
+	// Detail explains what was found and how to modernize the image
+	Detail string
+}
+
+func (d Deprecation) String() string {
+	return d.Feature + ": " + d.Detail
+}
@@ -0,0 +1,184 @@
+package image
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// the directory depth the size deltas are summed up to by default (e.g. "/usr/lib/python3.11")
+const DefaultDiffDepth = 3
+
+// FileDelta is a path of the final image that differs between two images, with the change going from the previous
+// image to the current one (PathAdded, PathModified or PathDeleted).
+type FileDelta struct {
+	Path   string
+	Change string
+	// the size in the current image and in the previous image (0 when the path is not there)
+	Bytes         uint64
+	PreviousBytes uint64
+}
+
+// SizeDelta is how much the path grew (or shrank, when negative) from the previous image to the current one.
+func (delta FileDelta) SizeDelta() int64 {
+	return int64(delta.Bytes) - int64(delta.PreviousBytes)
+}
+
+// AnalysisDiff is the difference between the analyses of two images (e.g. the image built from the main branch and
+// the one built from a pull request), going from the previous image to the current one.
+type AnalysisDiff struct {
+	SizeBytes               uint64
+	PreviousSizeBytes       uint64
+	CompressedBytes         uint64
+	PreviousCompressedBytes uint64
+	WastedBytes             uint64
+	PreviousWastedBytes     uint64
+	Efficiency              float64
+	PreviousEfficiency      float64
+	// the layers that only one of the images has (matched by diffID, or digest), in image order
+	AddedLayers   []*Layer
+	RemovedLayers []*Layer
+	// the size deltas summed up by directory (down to the diff depth), and the files that differ, the largest first
+	Directories []FileDelta
+	Files       []FileDelta
+}
+
+// SizeDelta is how much the image grew (or shrank, when negative).
+func (diff *AnalysisDiff) SizeDelta() int64 {
+	return int64(diff.SizeBytes) - int64(diff.PreviousSizeBytes)
+}
+
+// DiffAnalyses compares the analysis of an image with the analysis of a previous version of it. The size deltas of
+// the files are summed up by directory down to the given depth (files above it count towards their own directory).
+func DiffAnalyses(previous, current *AnalysisResult, depth int) *AnalysisDiff {
+	if depth < 1 {
+		depth = DefaultDiffDepth
+	}
+	diff := &AnalysisDiff{
+		SizeBytes:               current.SizeBytes,
+		PreviousSizeBytes:       previous.SizeBytes,
+		CompressedBytes:         current.CompressedBytes,
+		PreviousCompressedBytes: previous.CompressedBytes,
+		WastedBytes:             current.WastedBytes,
+		PreviousWastedBytes:     previous.WastedBytes,
+		Efficiency:              current.Efficiency,
+		PreviousEfficiency:      previous.Efficiency,
+		AddedLayers:             unmatchedLayers(current.Layers, previous.Layers),
+		RemovedLayers:           unmatchedLayers(previous.Layers, current.Layers),
+	}
+
+	currentFiles, previousFiles := visibleFiles(current.RefTrees), visibleFiles(previous.RefTrees)
+	diff.Files = compareVisibleFiles(currentFiles, previousFiles)
+	diff.Directories = compareDirectories(currentFiles, previousFiles, depth)
+	return diff
+}
+
+// unmatchedLayers returns the layers that have no counterpart among the other layers (each layer matches once).
+func unmatchedLayers(layers, others []*Layer) []*Layer {
+	used := make([]bool, len(others))
+	var unmatched []*Layer
+	for _, layer := range layers {
+		matched := false
+		for idx, other := range others {
+			if !used[idx] && sameLayer(layer, other) {
+				used[idx], matched = true, true
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, layer)
+		}
+	}
+	return unmatched
+}
+
+// compareVisibleFiles lists the files that were added, modified (by size or contents) or deleted going from the
+// previous files to the current ones, the largest size changes first.
+func compareVisibleFiles(current, previous map[string]visibleFile) []FileDelta {
+	var deltas []FileDelta
+	for filePath, file := range current {
+		before, exists := previous[filePath]
+		switch {
+		case !exists:
+			deltas = append(deltas, FileDelta{Path: filePath, Change: PathAdded, Bytes: file.size})
+		case before.size != file.size || before.hash != file.hash:
+			deltas = append(deltas, FileDelta{Path: filePath, Change: PathModified, Bytes: file.size, PreviousBytes: before.size})
+		}
+	}
+	for filePath, file := range previous {
+		if _, exists := current[filePath]; !exists {
+			deltas = append(deltas, FileDelta{Path: filePath, Change: PathDeleted, PreviousBytes: file.size})
+		}
+	}
+	sortDeltas(deltas)
+	return deltas
+}
+
+// compareDirectories sums up the file sizes of both images by directory (cut at the given depth) and lists the
+// directories whose size changed, the largest changes first.
+func compareDirectories(current, previous map[string]visibleFile, depth int) []FileDelta {
+	sizes := make(map[string]*FileDelta)
+	sum := func(files map[string]visibleFile, isCurrent bool) {
+		for filePath, file := range files {
+			dir := directoryAtDepth(filePath, depth)
+			delta, exists := sizes[dir]
+			if !exists {
+				delta = &FileDelta{Path: dir}
+				sizes[dir] = delta
+			}
+			if isCurrent {
+				delta.Bytes += file.size
+			} else {
+				delta.PreviousBytes += file.size
+			}
+		}
+	}
+	sum(current, true)
+	sum(previous, false)
+
+	var deltas []FileDelta
+	for _, delta := range sizes {
+		switch {
+		case delta.Bytes == delta.PreviousBytes:
+			continue
+		case delta.PreviousBytes == 0:
+			delta.Change = PathAdded
+		case delta.Bytes == 0:
+			delta.Change = PathDeleted
+		default:
+			delta.Change = PathModified
+		}
+		deltas = append(deltas, *delta)
+	}
+	sortDeltas(deltas)
+	return deltas
+}
+
+// directoryAtDepth returns the directory of the file, cut at the given depth (e.g. "/usr/lib/python3.11" for
+// "/usr/lib/python3.11/site-packages/x.py" at depth 3).
+func directoryAtDepth(filePath string, depth int) string {
+	dir := path.Dir(filePath)
+	parts := strings.Split(strings.TrimPrefix(dir, "/"), "/")
+	if dir == "/" || len(parts) <= depth {
+		return dir
+	}
+	return "/" + strings.Join(parts[:depth], "/")
+}
+
+// sortDeltas sorts the deltas by the size of the change, the largest first.
+func sortDeltas(deltas []FileDelta) {
+	sort.Slice(deltas, func(i, j int) bool {
+		left, right := absInt64(deltas[i].SizeDelta()), absInt64(deltas[j].SizeDelta())
+		if left == right {
+			return deltas[i].Path < deltas[j].Path
+		}
+		return left > right
+	})
+}
+
+func absInt64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
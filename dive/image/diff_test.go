@@ -0,0 +1,74 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestDiffAnalyses(t *testing.T) {
+	newTree := func(files map[string]int64) *filetree.FileTree {
+		tree := filetree.NewFileTree()
+		for path, size := range files {
+			if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		return tree
+	}
+	base := newTree(map[string]int64{"/bin/sh": 1000})
+	previous := &AnalysisResult{
+		SizeBytes:  1600,
+		Efficiency: 0.9,
+		RefTrees: []*filetree.FileTree{base, newTree(map[string]int64{
+			"/usr/lib/python3.11/site-packages/old/x.py": 500,
+			"/app/main.py": 100,
+		})},
+		Layers: []*Layer{{Index: 0, DiffID: "sha256:base"}, {Index: 1, DiffID: "sha256:deps1", Size: 600}},
+	}
+	current := &AnalysisResult{
+		SizeBytes:  4100,
+		Efficiency: 0.95,
+		RefTrees: []*filetree.FileTree{base, newTree(map[string]int64{
+			"/usr/lib/python3.11/site-packages/new/y.py": 3000,
+			"/app/main.py": 100,
+		})},
+		Layers: []*Layer{{Index: 0, DiffID: "sha256:base"}, {Index: 1, DiffID: "sha256:deps2", Size: 3100}},
+	}
+
+	diff := DiffAnalyses(previous, current, 3)
+
+	if diff.SizeDelta() != 2500 {
+		t.Errorf("expected a size delta of 2500, got %d", diff.SizeDelta())
+	}
+	if len(diff.AddedLayers) != 1 || diff.AddedLayers[0].DiffID != "sha256:deps2" ||
+		len(diff.RemovedLayers) != 1 || diff.RemovedLayers[0].DiffID != "sha256:deps1" {
+		t.Errorf("unexpected layers: added %+v, removed %+v", diff.AddedLayers, diff.RemovedLayers)
+	}
+
+	expectedDirs := []FileDelta{{Path: "/usr/lib/python3.11", Change: PathModified, Bytes: 3000, PreviousBytes: 500}}
+	if !reflect.DeepEqual(diff.Directories, expectedDirs) {
+		t.Errorf("expected directories:\n%+v\ngot:\n%+v", expectedDirs, diff.Directories)
+	}
+	expectedFiles := []FileDelta{
+		{Path: "/usr/lib/python3.11/site-packages/new/y.py", Change: PathAdded, Bytes: 3000},
+		{Path: "/usr/lib/python3.11/site-packages/old/x.py", Change: PathDeleted, PreviousBytes: 500},
+	}
+	if !reflect.DeepEqual(diff.Files, expectedFiles) {
+		t.Errorf("expected files:\n%+v\ngot:\n%+v", expectedFiles, diff.Files)
+	}
+}
+
+func TestDirectoryAtDepth(t *testing.T) {
+	cases := map[string]string{
+		"/usr/lib/python3.11/site-packages/x.py": "/usr/lib/python3.11",
+		"/usr/lib/libc.so":                       "/usr/lib",
+		"/main":                                  "/",
+	}
+	for filePath, expected := range cases {
+		if actual := directoryAtDepth(filePath, 3); actual != expected {
+			t.Errorf("%s: expected %s, got %s", filePath, expected, actual)
+		}
+	}
+}
@@ -1,31 +1,212 @@
 package docker
 
 import (
+	"bufio"
+	"context"
 	"fmt"
-	"github.com/wagoodman/dive/dive/image"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/wagoodman/dive/dive/image"
 )
 
-type archiveResolver struct{}
+// the archive path that reads the archive from stdin
+const stdinArchive = "-"
+
+type archiveResolver struct {
+	options image.ResolverOptions
+}
+
+func NewResolverFromArchive(options image.ResolverOptions) *archiveResolver {
+	return &archiveResolver{options: options}
+}
 
-func NewResolverFromArchive() *archiveResolver {
-	return &archiveResolver{}
+func (r *archiveResolver) Fetch(ctx context.Context, path string) (*image.Image, error) {
+	img, err := readArchive(ctx, path, r.options)
+	if err != nil {
+		return nil, err
+	}
+	result, err := img.ToImage()
+	if err != nil {
+		return nil, err
+	}
+	// the file contents are not kept while parsing, so the archive is read again when a file is first opened
+	if path != stdinArchive {
+		result.Contents = newArchiveContents(func() (*LazyImageArchive, error) {
+			return openLazyArchive(context.Background(), path, r.options)
+		})
+	}
+	return result, nil
 }
 
-func (r *archiveResolver) Fetch(path string) (*image.Image, error) {
-	reader, err := os.Open(path)
+// readArchive parses the image archive at the given path. When several layers may be parsed at the same time (see
+// image.IOLimits) an uncompressed archive on disk is indexed first, so that its layers can be read independently;
+// any other archive is read as a stream, one layer after another.
+func readArchive(ctx context.Context, path string, options image.ResolverOptions) (*ImageArchive, error) {
+	if path != stdinArchive && options.IO.Concurrency() > 1 && isPlainArchive(path) {
+		indexed, err := NewLazyImageArchive(ctx, path, false, options)
+		if err != nil {
+			return nil, err
+		}
+		return indexed.load(ctx)
+	}
+
+	reader, err := openArchive(path, options.IO)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
 
-	img, err := NewImageArchive(reader)
+	// the size of a compressed or split archive is not the size read from it
+	var size uint64
+	if path != stdinArchive && isPlainArchive(path) {
+		if info, err := os.Stat(path); err == nil {
+			size = uint64(info.Size())
+		}
+	}
+	return ReadImageArchive(ctx, reader, size, options)
+}
+
+// FetchLazy indexes an uncompressed archive on disk in place; any other archive (compressed, split or read from stdin)
+// is spooled to a temporary archive first.
+func (r *archiveResolver) FetchLazy(ctx context.Context, path string) (*image.Image, error) {
+	archive, err := openLazyArchive(ctx, path, r.options)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := archive.ToImage()
 	if err != nil {
+		archive.Close()
 		return nil, err
 	}
-	return img.ToImage()
+	return img, nil
+}
+
+// openLazyArchive indexes the archive at the given path, spooling it to a temporary archive first unless it is an
+// uncompressed archive on disk.
+func openLazyArchive(ctx context.Context, path string, options image.ResolverOptions) (*LazyImageArchive, error) {
+	if isPlainArchive(path) {
+		return NewLazyImageArchive(ctx, path, false, options)
+	}
+
+	reader, err := openArchive(path, options.IO)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return spoolArchive(ctx, reader, 0, options)
 }
 
-func (r *archiveResolver) Build(args []string) (*image.Image, error) {
+func (r *archiveResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
 	return nil, fmt.Errorf("build option not supported for docker archive resolver")
 }
+
+// openArchive opens the image archive at the given path for reading, decompressing a gzip or zstd compressed archive.
+// The path "-" reads the archive from stdin, a directory (e.g. an OCI layout) is read as if it was archived, and when
+// the path does not exist the parts written by split (named "<path>aa", "<path>ab" and so on) are read in order. The
+// archive is read within the IO limits of the limiter.
+func openArchive(path string, limiter *image.IOLimiter) (io.ReadCloser, error) {
+	var reader io.ReadCloser
+	switch {
+	case path == stdinArchive:
+		reader = os.Stdin
+	case isDirectory(path):
+		reader = tarDirectory(path)
+	case fileExists(path):
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		reader = file
+	default:
+		parts, err := archiveParts(path)
+		if err != nil {
+			return nil, err
+		}
+		reader = parts
+	}
+
+	buffered := bufio.NewReader(limiter.Throttle(reader))
+	magic, _ := buffered.Peek(blobMagicLength)
+	decompressed, err := decompress(buffered, sniffFormat(magic))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return &archiveReader{Reader: decompressed, closers: []io.Closer{decompressed, reader}}, nil
+}
+
+// isPlainArchive indicates if the path is an uncompressed archive on disk (which can be indexed in place).
+func isPlainArchive(path string) bool {
+	if isDirectory(path) {
+		return false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, blobMagicLength)
+	n, _ := io.ReadFull(file, magic)
+	format := sniffFormat(magic[:n])
+	return !format.isCompressed()
+}
+
+// archiveParts concatenates the parts of an archive that was split into several files.
+func archiveParts(path string) (io.ReadCloser, error) {
+	names, err := filepath.Glob(path + "*")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("could not find the archive '%s' (or parts of it)", path)
+	}
+	// split names the parts such that they sort in order
+	sort.Strings(names)
+
+	readers := make([]io.Reader, 0, len(names))
+	closers := make([]io.Closer, 0, len(names))
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			for _, closer := range closers {
+				closer.Close()
+			}
+			return nil, err
+		}
+		readers = append(readers, file)
+		closers = append(closers, file)
+	}
+	return &archiveReader{Reader: io.MultiReader(readers...), closers: closers}, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// archiveReader reads an archive made of (or wrapped by) several readers that must all be closed.
+type archiveReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *archiveReader) Close() error {
+	var firstErr error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
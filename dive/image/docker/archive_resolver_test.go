@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// writeOCILayoutArchive writes an OCI archive (an index.json instead of the docker manifest.json) where the index
+// references a multi-platform index which references the image manifest.
+func writeOCILayoutArchive(t *testing.T) string {
+	files := map[string][]byte{
+		"oci-layout":                 []byte(`{"imageLayoutVersion":"1.0.0"}`),
+		"index.json":                 []byte(`{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:platforms","size":1}]}`),
+		"blobs/sha256/platforms":     []byte(`{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:manifest","size":1}]}`),
+		"blobs/sha256/manifest":      []byte(`{"schemaVersion":2,"config":{"digest":"sha256:config"},"layers":[{"mediaType":"` + mediaTypeLayerZstd + `","digest":"sha256:zstd","size":1}]}`),
+		"blobs/sha256/config":        []byte(`{"history":[{"created_by":"COPY . / # buildkit"}],"rootfs":{"type":"layers","diff_ids":["sha256:1"]}}`),
+		"blobs/sha256/zstd":          zstdBytes(t, tarBytes(t, "etc/os-release")),
+		"blobs/sha256/not-a-layer-1": []byte("an artifact that is neither json nor a layer"),
+	}
+	return writeArchive(t, files, []string{"oci-layout", "blobs/sha256/zstd", "blobs/sha256/not-a-layer-1", "blobs/sha256/config", "blobs/sha256/manifest", "blobs/sha256/platforms", "index.json"})
+}
+
+func Test_ArchiveResolverFormats(t *testing.T) {
+	plain := writeOCILayoutArchive(t)
+	contents, err := ioutil.ReadFile(plain)
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+
+	compressed := plain + ".gz"
+	if err := ioutil.WriteFile(compressed, gzipBytes(t, contents), 0644); err != nil {
+		t.Fatalf("unable to write archive: %v", err)
+	}
+
+	// as written by "split -b <size> image.tar image.tar.part-"
+	split := plain + ".part-"
+	half := len(contents) / 2
+	if err := ioutil.WriteFile(split+"aa", contents[:half], 0644); err != nil {
+		t.Fatalf("unable to write archive part: %v", err)
+	}
+	if err := ioutil.WriteFile(split+"ab", contents[half:], 0644); err != nil {
+		t.Fatalf("unable to write archive part: %v", err)
+	}
+
+	// as extracted with "tar -xf image.tar -C image"
+	directory := filepath.Join(t.TempDir(), "image")
+	reader := tar.NewReader(bytes.NewReader(contents))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unable to read archive: %v", err)
+		}
+		target := filepath.Join(directory, header.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatalf("unable to extract archive: %v", err)
+		}
+		entry, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("unable to read archive: %v", err)
+		}
+		if err := ioutil.WriteFile(target, entry, 0644); err != nil {
+			t.Fatalf("unable to extract archive: %v", err)
+		}
+	}
+
+	for path, expected := range map[string]string{
+		plain:                                  "an image archive",
+		compressed:                             "a gzip compressed image archive",
+		directory:                              "an OCI layout directory",
+		split:                                  "",
+		filepath.Dir(plain):                    "",
+		filepath.Join(directory, "index.json"): "",
+	} {
+		if actual := DetectArchive(path); actual != expected {
+			t.Errorf("expected %s to be detected as %q, got %q", path, expected, actual)
+		}
+	}
+
+	resolver := NewResolverFromArchive(image.ResolverOptions{})
+	for name, path := range map[string]string{"plain": plain, "compressed": compressed, "split": split, "directory": directory} {
+		eager, err := resolver.Fetch(context.Background(), path)
+		if err != nil {
+			t.Fatalf("%s: unable to fetch the image: %v", name, err)
+		}
+		if len(eager.Trees) != 1 {
+			t.Fatalf("%s: expected 1 layer, got %d", name, len(eager.Trees))
+		}
+		if node, err := eager.Trees[0].GetNode("/etc/os-release"); err != nil || node == nil {
+			t.Errorf("%s: expected /etc/os-release in the layer", name)
+		}
+
+		lazy, err := resolver.FetchLazy(context.Background(), path)
+		if err != nil {
+			t.Fatalf("%s: unable to fetch the lazy image: %v", name, err)
+		}
+		tree, err := lazy.LoadTree(0)
+		if err != nil {
+			t.Fatalf("%s: unable to load the lazy layer: %v", name, err)
+		}
+		if node, err := tree.GetNode("/etc/os-release"); err != nil || node == nil {
+			t.Errorf("%s: expected /etc/os-release in the lazy layer", name)
+		}
+		if err := lazy.Close(); err != nil {
+			t.Errorf("%s: unable to close the lazy image: %v", name, err)
+		}
+	}
+
+	if _, err := os.Stat(plain); err != nil {
+		t.Errorf("expected the original archive to be kept: %v", err)
+	}
+}
+
+func TestReadArchiveConcurrently(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	streamed, err := readArchive(context.Background(), path, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+
+	limiter := image.IOLimits{Concurrency: 4, ReadsPerSecond: 1000000}.NewLimiter()
+	concurrent, err := readArchive(context.Background(), path, image.ResolverOptions{IO: limiter})
+	if err != nil {
+		t.Fatalf("unable to read archive concurrently: %v", err)
+	}
+
+	if !reflect.DeepEqual(concurrent.blobs, streamed.blobs) {
+		t.Errorf("expected the layer blobs to match the streamed archive:\n%+v\ngot:\n%+v", streamed.blobs, concurrent.blobs)
+	}
+	if len(concurrent.layerMap) != len(streamed.layerMap) {
+		t.Fatalf("expected %d layers, got %d", len(streamed.layerMap), len(concurrent.layerMap))
+	}
+	for name, expected := range streamed.layerMap {
+		actual, exists := concurrent.layerMap[name]
+		if !exists {
+			t.Errorf("expected layer %s", name)
+			continue
+		}
+		if actual.Size != expected.Size || actual.FileSize != expected.FileSize {
+			t.Errorf("layer %s: expected %d files (%d bytes), got %d files (%d bytes)", name, expected.Size, expected.FileSize, actual.Size, actual.FileSize)
+		}
+	}
+}
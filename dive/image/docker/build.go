@@ -1,11 +1,12 @@
 package docker
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 )
 
-func buildImageFromCli(buildArgs []string) (string, error) {
+func buildImageFromCli(ctx context.Context, host string, buildArgs []string) (string, error) {
 	iidfile, err := ioutil.TempFile("/tmp", "dive.*.iid")
 	if err != nil {
 		return "", err
@@ -13,7 +14,7 @@ func buildImageFromCli(buildArgs []string) (string, error) {
 	defer os.Remove(iidfile.Name())
 
 	allArgs := append([]string{"--iidfile", iidfile.Name()}, buildArgs...)
-	err = runDockerCmd("build", allArgs...)
+	err = runDockerCmd(ctx, host, "build", allArgs...)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	// BuildKit marks the history entries of Dockerfile instructions with this comment
+	buildKitComment = "buildkit.dockerfile.v0"
+	// the label buildah stamps onto every image it commits
+	buildahLabel = "io.buildah.version"
+	// rules_docker pins all timestamps to the epoch for reproducibility
+	bazelCreated = "1970-01-01T00:00:00Z"
+)
+
+// builderFingerprint identifies the tool that created each history entry of an image config.
+type builderFingerprint struct {
+	history []historyEntry
+	// the first history entry created by buildah (-1 when the image was not committed by buildah)
+	buildahStart int
+	// the tool that created layers without a history entry
+	withoutHistory string
+}
+
+func newBuilderFingerprint(cfg config) builderFingerprint {
+	fingerprint := builderFingerprint{
+		history:      cfg.History,
+		buildahStart: -1,
+	}
+
+	// rules_oci records no history at all (and pins the creation time like rules_docker)
+	if len(cfg.History) == 0 && cfg.Created == bazelCreated {
+		fingerprint.withoutHistory = image.BuilderRulesOCI
+	}
+
+	if _, exists := cfg.Container.Labels[buildahLabel]; exists {
+		// buildah records the base image as the comment of the first entry it adds; without that marker
+		// assume the whole history is buildah's
+		fingerprint.buildahStart = 0
+		for idx, entry := range cfg.History {
+			if strings.HasPrefix(entry.Comment, "FROM ") {
+				fingerprint.buildahStart = idx
+			}
+		}
+	}
+
+	return fingerprint
+}
+
+// identify returns the tool that created the given history entry (empty when unknown).
+func (f builderFingerprint) identify(histIdx int) string {
+	if histIdx < 0 || histIdx >= len(f.history) {
+		return f.withoutHistory
+	}
+	entry := f.history[histIdx]
+	author := strings.ToLower(entry.Author)
+	createdBy := strings.TrimSpace(entry.CreatedBy)
+
+	switch {
+	case entry.Comment == buildKitComment || strings.HasSuffix(createdBy, "# buildkit"):
+		return image.BuilderBuildKit
+	case author == "kaniko":
+		return image.BuilderKaniko
+	case author == "ko" || strings.HasPrefix(createdBy, "ko build") || strings.HasPrefix(createdBy, "ko publish"):
+		return image.BuilderKo
+	case author == "jib" || strings.HasPrefix(createdBy, "jib-"):
+		return image.BuilderJib
+	case author == "bazel" || strings.HasPrefix(createdBy, "bazel build") || entry.Created == bazelCreated:
+		return image.BuilderBazel
+	case f.buildahStart >= 0 && histIdx >= f.buildahStart:
+		return image.BuilderBuildah
+	case strings.HasPrefix(createdBy, "/bin/sh -c ") || strings.HasPrefix(createdBy, "|"):
+		// the classic builder runs instructions through the shell (build args are prefixed as "|<count> ARG=...")
+		return image.BuilderDocker
+	}
+	return ""
+}
+
+// layerRole determines the role a layer plays for builders that do not record meaningful commands (jib, ko and bazel),
+// from the history comment when the builder describes the layer, otherwise from the layer contents (when parsed).
+// Layers of other builders have no role.
+func layerRole(builder string, entry historyEntry, tree *filetree.FileTree) string {
+	comment := strings.ToLower(strings.TrimSpace(entry.Comment))
+
+	switch builder {
+	case image.BuilderJib:
+		// jib names each layer after what it holds ("dependencies", "snapshot dependencies", "resources", "classes"...)
+		if comment != "" {
+			return comment
+		}
+	case image.BuilderKo:
+		switch {
+		case strings.Contains(comment, "kodata"):
+			return image.RoleResources
+		case strings.Contains(comment, "go build output") || strings.Contains(comment, "binary"):
+			return image.RoleBinary
+		}
+	case image.BuilderBazel, image.BuilderRulesOCI:
+	default:
+		return ""
+	}
+	return roleFromContents(tree)
+}
+
+// roleFromContents guesses the role of a layer from the kinds of files it adds.
+func roleFromContents(tree *filetree.FileTree) string {
+	if tree == nil {
+		return ""
+	}
+
+	var files, jars, classes, executables int
+	err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		info := node.Data.FileInfo
+		if info.IsDir || len(node.Children) > 0 || node.IsWhiteout() {
+			return nil
+		}
+		files++
+		switch path.Ext(node.Name) {
+		case ".jar":
+			jars++
+		case ".class":
+			classes++
+		}
+		if info.Mode&0111 != 0 {
+			executables++
+		}
+		return nil
+	}, nil)
+	if err != nil || files == 0 {
+		return ""
+	}
+
+	switch {
+	case jars*2 >= files:
+		return image.RoleDependencies
+	case classes*2 >= files:
+		return image.RoleClasses
+	case executables == 1 && files <= 2:
+		return image.RoleBinary
+	default:
+		return image.RoleFiles
+	}
+}
+
+// roleCommand labels a layer by the tool that built it and its role, keeping whatever the tool recorded as the command.
+func roleCommand(builder, role, createdBy string) string {
+	if createdBy == "" || createdBy == "(missing)" {
+		return fmt.Sprintf("%s: %s", builder, role)
+	}
+	return fmt.Sprintf("%s: %s (%s)", builder, role, createdBy)
+}
@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func Test_BuilderFingerprint(t *testing.T) {
+	table := map[string]struct {
+		entry    historyEntry
+		labels   map[string]string
+		expected string
+	}{
+		"classic":        {historyEntry{CreatedBy: "/bin/sh -c apk add curl"}, nil, image.BuilderDocker},
+		"classic-args":   {historyEntry{CreatedBy: "|1 VERSION=1.0 /bin/sh -c make"}, nil, image.BuilderDocker},
+		"buildkit":       {historyEntry{CreatedBy: "RUN /bin/sh -c apk add curl # buildkit", Comment: buildKitComment}, nil, image.BuilderBuildKit},
+		"buildkit-nocmt": {historyEntry{CreatedBy: "COPY . /app # buildkit"}, nil, image.BuilderBuildKit},
+		"kaniko":         {historyEntry{CreatedBy: "RUN apk add curl", Author: "kaniko"}, nil, image.BuilderKaniko},
+		"ko":             {historyEntry{CreatedBy: "ko build ko://example.com/app", Author: "ko"}, nil, image.BuilderKo},
+		"jib":            {historyEntry{CreatedBy: "jib-maven-plugin:3.4.0", Author: "Jib", Comment: "classes"}, nil, image.BuilderJib},
+		"bazel":          {historyEntry{CreatedBy: "bazel build ...", Author: "Bazel", Created: bazelCreated}, nil, image.BuilderBazel},
+		"buildah":        {historyEntry{CreatedBy: "/bin/sh -c dnf install -y curl"}, map[string]string{buildahLabel: "1.33.0"}, image.BuilderBuildah},
+		"unknown":        {historyEntry{CreatedBy: "something else"}, nil, ""},
+	}
+
+	for name, test := range table {
+		cfg := config{
+			History:   []historyEntry{test.entry},
+			Container: containerConfig{Labels: test.labels},
+		}
+		actual := newBuilderFingerprint(cfg).identify(0)
+		if actual != test.expected {
+			t.Errorf("%s: expected builder %q, got %q", name, test.expected, actual)
+		}
+	}
+}
+
+func Test_BuilderFingerprintBuildahBase(t *testing.T) {
+	cfg := config{
+		History: []historyEntry{
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+			{CreatedBy: "/bin/sh -c dnf install -y curl", Comment: "FROM docker.io/library/fedora:latest"},
+		},
+		Container: containerConfig{Labels: map[string]string{buildahLabel: "1.33.0"}},
+	}
+	fingerprint := newBuilderFingerprint(cfg)
+
+	if actual := fingerprint.identify(0); actual != image.BuilderDocker {
+		t.Errorf("expected the base layer to be built by %q, got %q", image.BuilderDocker, actual)
+	}
+	if actual := fingerprint.identify(1); actual != image.BuilderBuildah {
+		t.Errorf("expected the committed layer to be built by %q, got %q", image.BuilderBuildah, actual)
+	}
+	if actual := fingerprint.identify(-1); actual != "" {
+		t.Errorf("expected no builder for a missing history entry, got %q", actual)
+	}
+}
+
+func Test_LayerRole(t *testing.T) {
+	jars := filetree.NewFileTree()
+	for _, name := range []string{"/app/libs/guava.jar", "/app/libs/jackson.jar", "/app/libs/README"} {
+		if _, _, err := jars.AddPath(name, filetree.FileInfo{Path: name, Mode: 0644}); err != nil {
+			t.Fatalf("unable to add path: %v", err)
+		}
+	}
+	binary := filetree.NewFileTree()
+	if _, _, err := binary.AddPath("/app/server", filetree.FileInfo{Path: "/app/server", Mode: 0755}); err != nil {
+		t.Fatalf("unable to add path: %v", err)
+	}
+
+	table := map[string]struct {
+		builder  string
+		entry    historyEntry
+		tree     *filetree.FileTree
+		expected string
+	}{
+		"jib-comment":     {image.BuilderJib, historyEntry{Comment: "classes"}, nil, image.RoleClasses},
+		"jib-contents":    {image.BuilderJib, historyEntry{}, jars, image.RoleDependencies},
+		"ko-kodata":       {image.BuilderKo, historyEntry{Comment: "kodata contents, at $KO_DATA_PATH"}, nil, image.RoleResources},
+		"ko-binary":       {image.BuilderKo, historyEntry{Comment: "go build output, at /ko-app/app"}, nil, image.RoleBinary},
+		"bazel-contents":  {image.BuilderBazel, historyEntry{}, binary, image.RoleBinary},
+		"rules-oci":       {image.BuilderRulesOCI, historyEntry{}, jars, image.RoleDependencies},
+		"unparsed":        {image.BuilderRulesOCI, historyEntry{}, nil, ""},
+		"docker-no-roles": {image.BuilderDocker, historyEntry{Comment: "classes"}, jars, ""},
+	}
+
+	for name, test := range table {
+		actual := layerRole(test.builder, test.entry, test.tree)
+		if actual != test.expected {
+			t.Errorf("%s: expected role %q, got %q", name, test.expected, actual)
+		}
+	}
+
+	rulesOCI := newBuilderFingerprint(config{Created: bazelCreated})
+	if actual := rulesOCI.identify(-1); actual != image.BuilderRulesOCI {
+		t.Errorf("expected layers without history to be built by %q, got %q", image.BuilderRulesOCI, actual)
+	}
+	if actual := roleCommand(image.BuilderRulesOCI, image.RoleBinary, "(missing)"); actual != "bazel rules_oci: application binary" {
+		t.Errorf("unexpected role command: %q", actual)
+	}
+}
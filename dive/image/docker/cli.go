@@ -1,22 +1,27 @@
 package docker
 
 import (
+	"context"
 	"fmt"
 	"github.com/wagoodman/dive/utils"
 	"os"
 	"os/exec"
 )
 
-// runDockerCmd runs a given Docker command in the current tty
-func runDockerCmd(cmdStr string, args ...string) error {
+// runDockerCmd runs a given Docker command in the current tty, against the engine at the given host (DOCKER_HOST when
+// empty)
+func runDockerCmd(ctx context.Context, host string, cmdStr string, args ...string) error {
 	if !isDockerClientBinaryAvailable() {
 		return fmt.Errorf("cannot find docker client executable")
 	}
 
 	allArgs := utils.CleanArgs(append([]string{cmdStr}, args...))
 
-	cmd := exec.Command("docker", allArgs...)
+	cmd := exec.CommandContext(ctx, "docker", allArgs...)
 	cmd.Env = os.Environ()
+	if host != "" {
+		cmd.Env = append(cmd.Env, "DOCKER_HOST="+host)
+	}
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
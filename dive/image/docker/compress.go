@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	formatTar blobFormat = iota
+	formatGzip
+	formatZstd
+	formatJSON
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// the number of leading bytes needed to tell the blob formats apart
+const blobMagicLength = 4
+
+// blobFormat is how a blob within an image archive is encoded. OCI layout archives name blobs by digest only, so the
+// format is sniffed from the contents rather than derived from the file name.
+type blobFormat int
+
+// sniffFormat determines the blob format from the leading bytes of the blob. Note that eStargz layers are gzip and
+// zstd:chunked layers are zstd (their tables of contents are skipped when the layer is read).
+func sniffFormat(magic []byte) blobFormat {
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return formatGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		return formatZstd
+	case bytes.HasPrefix(bytes.TrimLeft(magic, " \t\r\n"), []byte("{")):
+		return formatJSON
+	default:
+		return formatTar
+	}
+}
+
+// isCompressed indicates if the blob is a compressed layer tar.
+func (f blobFormat) isCompressed() bool {
+	return f == formatGzip || f == formatZstd
+}
+
+// ociMediaType is the OCI media type of a layer blob of the format.
+func (f blobFormat) ociMediaType() string {
+	switch f {
+	case formatGzip:
+		return "application/vnd.oci.image.layer.v1.tar+gzip"
+	case formatZstd:
+		return "application/vnd.oci.image.layer.v1.tar+zstd"
+	}
+	return "application/vnd.oci.image.layer.v1.tar"
+}
+
+// isLayerBlob indicates if the archive entry may hold a layer tar: either by its extension or by being a blob of an
+// OCI layout archive.
+func isLayerBlob(name string) bool {
+	for _, suffix := range []string{".tar", ".tar.gz", "tgz", ".tar.zst"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(name, "blobs/")
+}
+
+// isEStargzMetadata indicates if the layer entry is the table of contents or a prefetch landmark that eStargz adds to
+// a layer (these are not part of the image filesystem).
+func isEStargzMetadata(name string) bool {
+	switch name {
+	case "stargz.index.json", ".prefetch.landmark", ".no.prefetch.landmark":
+		return true
+	}
+	return false
+}
+
+// decompress wraps the given layer blob with a reader of the layer tar.
+func decompress(reader io.Reader, format blobFormat) (io.ReadCloser, error) {
+	switch format {
+	case formatGzip:
+		return gzip.NewReader(reader)
+	case formatZstd:
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return ioutil.NopCloser(reader), nil
+	}
+}
+
+// byteCounter is a writer that only counts the bytes written to it.
+type byteCounter struct {
+	count uint64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.count += uint64(len(p))
+	return len(p), nil
+}
+
+// compressionCounter measures the size of a stream once gzipped, the same way a layer is compressed when it is pushed
+// to a registry (and what a registry stores and transfers).
+type compressionCounter struct {
+	counter byteCounter
+	gz      *gzip.Writer
+}
+
+func newCompressionCounter() *compressionCounter {
+	c := &compressionCounter{}
+	c.gz = gzip.NewWriter(&c.counter)
+	return c
+}
+
+func (c *compressionCounter) Write(p []byte) (int, error) {
+	return c.gz.Write(p)
+}
+
+// Size flushes the compressed stream and returns its total size.
+func (c *compressionCounter) Size() (uint64, error) {
+	if err := c.gz.Close(); err != nil {
+		return 0, err
+	}
+	return c.counter.count, nil
+}
@@ -0,0 +1,155 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// tarBytes builds a tar with a file for each given name (the contents are the name).
+func tarBytes(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for _, name := range names {
+		err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(name)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("unable to write tar header: %v", err)
+		}
+		if _, err := writer.Write([]byte(name)); err != nil {
+			t.Fatalf("unable to write tar contents: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unable to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, contents []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(contents); err != nil {
+		t.Fatalf("unable to gzip: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unable to gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, contents []byte) []byte {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("unable to create zstd encoder: %v", err)
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(contents, nil)
+}
+
+// writeOCIArchive writes an image archive in the OCI layout (blobs named by digest only, without extensions) with a
+// zstd layer and an eStargz layer.
+func writeOCIArchive(t *testing.T) string {
+	files := map[string][]byte{
+		"manifest.json": []byte(`[{"Config":"blobs/sha256/config","Layers":["blobs/sha256/zstd","blobs/sha256/estargz"]}]`),
+		"blobs/sha256/config": []byte(`{"history":[{"created_by":"ADD rootfs.tar / # buildkit"},{"created_by":"COPY app /app # buildkit"}],` +
+			`"rootfs":{"type":"layers","diff_ids":["sha256:1","sha256:2"]}}`),
+		"blobs/sha256/zstd":    zstdBytes(t, tarBytes(t, "etc/os-release", "bin/sh")),
+		"blobs/sha256/estargz": gzipBytes(t, tarBytes(t, ".prefetch.landmark", "app/main", "stargz.index.json")),
+	}
+
+	return writeArchive(t, files, []string{"blobs/sha256/zstd", "blobs/sha256/estargz", "blobs/sha256/config", "manifest.json"})
+}
+
+// writeArchive writes an image archive with the given files (in the given order) to a temporary file.
+func writeArchive(t *testing.T, files map[string][]byte, order []string) string {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for _, name := range order {
+		err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatalf("unable to write archive header: %v", err)
+		}
+		if _, err := writer.Write(files[name]); err != nil {
+			t.Fatalf("unable to write archive contents: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unable to close archive: %v", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unable to write archive: %v", err)
+	}
+	return path
+}
+
+var (
+	compressedLayerPaths = [][]string{
+		{"/etc/os-release", "/bin/sh"},
+		{"/app/main"},
+	}
+	compressedLayerMediaTypes = []string{mediaTypeLayerZstd, mediaTypeLayerGzip}
+)
+
+func checkCompressedLayer(t *testing.T, name string, idx int, tree *filetree.FileTree, mediaType string) {
+	if mediaType != compressedLayerMediaTypes[idx] {
+		t.Errorf("%s layer %d: expected media type %q, got %q", name, idx, compressedLayerMediaTypes[idx], mediaType)
+	}
+	for _, expected := range compressedLayerPaths[idx] {
+		if node, err := tree.GetNode(expected); err != nil || node == nil {
+			t.Errorf("%s layer %d: expected %q in the layer", name, idx, expected)
+		}
+	}
+	for _, metadata := range []string{"/stargz.index.json", "/.prefetch.landmark"} {
+		if node, _ := tree.GetNode(metadata); node != nil {
+			t.Errorf("%s layer %d: did not expect the eStargz metadata %q in the layer", name, idx, metadata)
+		}
+	}
+}
+
+func Test_CompressedLayerFormats(t *testing.T) {
+	path := writeOCIArchive(t)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open archive: %v", err)
+	}
+	defer file.Close()
+
+	archive, err := NewImageArchive(file)
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	eager, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	for idx := range compressedLayerPaths {
+		lazyTree, err := lazy.LoadTree(idx)
+		if err != nil {
+			t.Fatalf("layer %d: unable to load lazy tree: %v", idx, err)
+		}
+
+		checkCompressedLayer(t, "eager", idx, eager.Trees[idx], eager.Layers[idx].MediaType)
+		checkCompressedLayer(t, "lazy", idx, lazyTree, lazy.Layers[idx].MediaType)
+	}
+}
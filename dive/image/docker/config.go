@@ -2,12 +2,41 @@ package docker
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
 )
 
 type config struct {
-	History []historyEntry `json:"history"`
-	RootFs  rootFs         `json:"rootfs"`
+	Created   string          `json:"created"`
+	History   []historyEntry  `json:"history"`
+	RootFs    rootFs          `json:"rootfs"`
+	Container containerConfig `json:"config"`
+}
+
+type containerConfig struct {
+	Labels       map[string]string   `json:"Labels"`
+	User         string              `json:"User"`
+	WorkingDir   string              `json:"WorkingDir"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	Cmd          []string            `json:"Cmd"`
+	Env          []string            `json:"Env"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	Volumes      map[string]struct{} `json:"Volumes"`
+	StopSignal   string              `json:"StopSignal"`
+	Healthcheck  *healthConfig       `json:"Healthcheck"`
+}
+
+// healthConfig is the healthcheck of the image config (the durations are in nanoseconds).
+type healthConfig struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval"`
+	Timeout     int64    `json:"Timeout"`
+	StartPeriod int64    `json:"StartPeriod"`
+	Retries     int      `json:"Retries"`
 }
 
 type rootFs struct {
@@ -21,14 +50,15 @@ type historyEntry struct {
 	Created    string `json:"created"`
 	Author     string `json:"author"`
 	CreatedBy  string `json:"created_by"`
+	Comment    string `json:"comment"`
 	EmptyLayer bool   `json:"empty_layer"`
 }
 
-func newConfig(configBytes []byte) config {
+func newConfig(configBytes []byte) (config, error) {
 	var imageConfig config
 	err := json.Unmarshal(configBytes, &imageConfig)
 	if err != nil {
-		logrus.Panic(err)
+		return config{}, fmt.Errorf("unable to parse image config: %v", err)
 	}
 
 	layerIdx := 0
@@ -36,10 +66,80 @@ func newConfig(configBytes []byte) config {
 		if imageConfig.History[idx].EmptyLayer {
 			imageConfig.History[idx].ID = "<missing>"
 		} else {
+			if layerIdx >= len(imageConfig.RootFs.DiffIds) {
+				return config{}, fmt.Errorf("image config history references more layers than it has")
+			}
 			imageConfig.History[idx].ID = imageConfig.RootFs.DiffIds[layerIdx]
 			layerIdx++
 		}
 	}
 
-	return imageConfig
+	return imageConfig, nil
+}
+
+// newHistory converts the config history, pairing the entries that are not empty with the layers in order (like
+// newLayers). Layers without a history entry are listed at the end as "(missing)".
+func newHistory(cfg config, layerCount int) []image.HistoryEntry {
+	history := make([]image.HistoryEntry, 0, len(cfg.History))
+	layerIdx := 0
+	for _, entry := range cfg.History {
+		converted := image.HistoryEntry{
+			Author:     entry.Author,
+			CreatedBy:  entry.CreatedBy,
+			Comment:    entry.Comment,
+			EmptyLayer: entry.EmptyLayer,
+			LayerIndex: -1,
+		}
+		if entry.Created != "" {
+			created, err := time.Parse(time.RFC3339Nano, entry.Created)
+			if err != nil {
+				logrus.Debugf("unable to parse history creation time %q: %+v", entry.Created, err)
+			}
+			converted.Created = created
+		}
+		if !entry.EmptyLayer && layerIdx < layerCount {
+			converted.LayerIndex = layerIdx
+			layerIdx++
+		}
+		history = append(history, converted)
+	}
+	for ; layerIdx < layerCount; layerIdx++ {
+		history = append(history, image.HistoryEntry{CreatedBy: "(missing)", LayerIndex: layerIdx})
+	}
+	return history
+}
+
+// newImageConfig converts the runtime configuration of the image config.
+func newImageConfig(cfg config) *image.ImageConfig {
+	container := cfg.Container
+	result := &image.ImageConfig{
+		User:         container.User,
+		WorkingDir:   container.WorkingDir,
+		Entrypoint:   container.Entrypoint,
+		Cmd:          container.Cmd,
+		Env:          container.Env,
+		Labels:       container.Labels,
+		ExposedPorts: sortedKeys(container.ExposedPorts),
+		Volumes:      sortedKeys(container.Volumes),
+		StopSignal:   container.StopSignal,
+	}
+	if health := container.Healthcheck; health != nil && len(health.Test) > 0 {
+		result.Healthcheck = &image.Healthcheck{
+			Test:        health.Test,
+			Interval:    time.Duration(health.Interval),
+			Timeout:     time.Duration(health.Timeout),
+			StartPeriod: time.Duration(health.StartPeriod),
+			Retries:     health.Retries,
+		}
+	}
+	return result
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NewHistory(t *testing.T) {
+	cfg := config{
+		History: []historyEntry{
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / ", Created: "2024-01-02T03:04:05Z"},
+			{CreatedBy: "/bin/sh -c #(nop)  CMD [\"sh\"]", EmptyLayer: true},
+			{CreatedBy: "/bin/sh -c apk add curl"},
+			{CreatedBy: "/bin/sh -c #(nop)  ENV PATH=/app", EmptyLayer: true},
+		},
+	}
+
+	history := newHistory(cfg, 3)
+	expected := []struct {
+		command    string
+		layerIndex int
+		empty      bool
+	}{
+		{"ADD file:abc in /", 0, false},
+		{`CMD ["sh"]`, -1, true},
+		{"apk add curl", 1, false},
+		{"ENV PATH=/app", -1, true},
+		{"(missing)", 2, false},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), history)
+	}
+	for idx, entry := range history {
+		if entry.Command() != expected[idx].command || entry.LayerIndex != expected[idx].layerIndex || entry.EmptyLayer != expected[idx].empty {
+			t.Errorf("entry %d: unexpected %+v (command %q)", idx, entry, entry.Command())
+		}
+	}
+	if !history[0].Created.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected creation time: %s", history[0].Created)
+	}
+}
+
+func Test_NewImageConfig(t *testing.T) {
+	cfg, err := newConfig([]byte(`{
+		"config": {
+			"User": "nginx",
+			"Env": ["PATH=/usr/bin"],
+			"Entrypoint": ["/docker-entrypoint.sh"],
+			"Cmd": ["nginx", "-g", "daemon off;"],
+			"ExposedPorts": {"443/tcp": {}, "80/tcp": {}},
+			"Labels": {"maintainer": "NGINX"},
+			"Healthcheck": {"Test": ["CMD-SHELL", "curl -f http://localhost/"], "Interval": 30000000000, "Retries": 3}
+		},
+		"rootfs": {"type": "layers", "diff_ids": []}
+	}`))
+	if err != nil {
+		t.Fatalf("unable to parse config: %v", err)
+	}
+
+	config := newImageConfig(cfg)
+	if config.User != "nginx" || len(config.Cmd) != 3 || config.Labels["maintainer"] != "NGINX" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if len(config.ExposedPorts) != 2 || config.ExposedPorts[0] != "443/tcp" || config.ExposedPorts[1] != "80/tcp" {
+		t.Errorf("expected sorted ports, got %+v", config.ExposedPorts)
+	}
+	if config.Healthcheck == nil || config.Healthcheck.Interval != 30*time.Second || config.Healthcheck.Retries != 3 {
+		t.Errorf("unexpected healthcheck: %+v", config.Healthcheck)
+	}
+}
@@ -0,0 +1,234 @@
+package docker
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// OpenFile opens the given path as written by the layer at the given index, reading the layer tar from the archive on
+// disk. Hardlinks are resolved within the layer.
+func (img *LazyImageArchive) OpenFile(index int, filePath string) (io.ReadCloser, error) {
+	if index < 0 || index >= len(img.manifest.LayerTarPaths) {
+		return nil, fmt.Errorf("invalid layer index given: %d of %d", index, len(img.manifest.LayerTarPaths)-1)
+	}
+	entry, exists := img.entries[img.manifest.LayerTarPaths[index]]
+	if !exists {
+		return nil, fmt.Errorf("the contents of layer %d are not within the archive", index)
+	}
+
+	name := layerFileName(filePath)
+	// a hardlink refers to an earlier entry of the same layer, so the layer is read again for the link target
+	for depth := 0; depth <= 1; depth++ {
+		reader, err := img.openLayer(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		tarReader := tar.NewReader(reader)
+		var header *tar.Header
+		for {
+			header, err = tarReader.Next()
+			if err != nil || layerFileName(header.Name) == name {
+				break
+			}
+		}
+		if err == io.EOF {
+			err = fmt.Errorf("'%s' is not within layer %d", filePath, index)
+		}
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			return &archiveReader{Reader: tarReader, closers: []io.Closer{reader}}, nil
+		case tar.TypeLink:
+			reader.Close()
+			name = layerFileName(header.Linkname)
+		default:
+			reader.Close()
+			return nil, fmt.Errorf("'%s' is not a regular file", filePath)
+		}
+	}
+	return nil, fmt.Errorf("unable to resolve the hardlink '%s'", filePath)
+}
+
+// WalkFiles reads the layer tar at the given index once, calling the visitor with the contents of the regular files
+// among the given paths.
+func (img *LazyImageArchive) WalkFiles(index int, paths map[string]bool, visitor func(filePath string, reader io.Reader) error) error {
+	if index < 0 || index >= len(img.manifest.LayerTarPaths) {
+		return fmt.Errorf("invalid layer index given: %d of %d", index, len(img.manifest.LayerTarPaths)-1)
+	}
+	entry, exists := img.entries[img.manifest.LayerTarPaths[index]]
+	if !exists {
+		return fmt.Errorf("the contents of layer %d are not within the archive", index)
+	}
+
+	reader, err := img.openLayer(entry)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	remaining := len(paths)
+	tarReader := tar.NewReader(reader)
+	for remaining > 0 {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		filePath := "/" + layerFileName(header.Name)
+		if !paths[filePath] || (header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA) {
+			continue
+		}
+		remaining--
+		if err := visitor(filePath, tarReader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openLayer opens the layer tar of the given entry for reading (decompressed).
+func (img *LazyImageArchive) openLayer(entry layerEntry) (io.ReadCloser, error) {
+	file, err := os.Open(img.path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(entry.offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var reader io.Reader = io.LimitReader(file, entry.size)
+	if entry.format.isCompressed() && !entry.symlink {
+		decompressed, err := decompress(reader, entry.format)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &archiveReader{Reader: decompressed, closers: []io.Closer{decompressed, file}}, nil
+	}
+	return &archiveReader{Reader: reader, closers: []io.Closer{file}}, nil
+}
+
+// OpenLayerBlob opens the blob of the layer at the given index as stored in the archive (without decompressing it),
+// returning its OCI media type.
+func (img *LazyImageArchive) OpenLayerBlob(index int) (io.ReadCloser, string, error) {
+	if index < 0 || index >= len(img.manifest.LayerTarPaths) {
+		return nil, "", fmt.Errorf("invalid layer index given: %d of %d", index, len(img.manifest.LayerTarPaths)-1)
+	}
+	name := img.manifest.LayerTarPaths[index]
+	entry, exists := img.entries[name]
+	if exists && entry.symlink {
+		// docker archives link the tars of the layers stored more than once
+		entry, exists = img.entries[path.Join(path.Dir(name), entry.link)]
+	}
+	if !exists || entry.symlink {
+		return nil, "", fmt.Errorf("the blob of layer %d is not within the archive", index)
+	}
+
+	file, err := os.Open(img.path)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := file.Seek(entry.offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, "", err
+	}
+	return &archiveReader{Reader: io.LimitReader(file, entry.size), closers: []io.Closer{file}}, entry.format.ociMediaType(), nil
+}
+
+// ConfigBlob returns the image config as stored in the archive.
+func (img *LazyImageArchive) ConfigBlob() ([]byte, error) {
+	if len(img.configBytes) == 0 {
+		return nil, fmt.Errorf("could not find image config")
+	}
+	return img.configBytes, nil
+}
+
+// layerFileName normalizes a path within a layer tar (or within the image) for comparison.
+func layerFileName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// archiveContents reads file contents from an image archive that is only indexed (and spooled to disk, if needed)
+// when a file is first opened, since fully parsed images do not keep their archive around.
+type archiveContents struct {
+	open func() (*LazyImageArchive, error)
+	// the archive is closed along with the contents (false for lazy images, where the image loader owns the archive)
+	owned bool
+
+	lock    sync.Mutex
+	archive *LazyImageArchive
+}
+
+// newArchiveContents creates a content reader that indexes an archive with the given function when first needed.
+func newArchiveContents(open func() (*LazyImageArchive, error)) *archiveContents {
+	return &archiveContents{open: open, owned: true}
+}
+
+func (c *archiveContents) OpenFile(layer int, filePath string) (io.ReadCloser, error) {
+	archive, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return archive.OpenFile(layer, filePath)
+}
+
+func (c *archiveContents) WalkFiles(layer int, paths map[string]bool, visitor func(filePath string, reader io.Reader) error) error {
+	archive, err := c.load()
+	if err != nil {
+		return err
+	}
+	return archive.WalkFiles(layer, paths, visitor)
+}
+
+func (c *archiveContents) OpenLayerBlob(layer int) (io.ReadCloser, string, error) {
+	archive, err := c.load()
+	if err != nil {
+		return nil, "", err
+	}
+	return archive.OpenLayerBlob(layer)
+}
+
+func (c *archiveContents) ConfigBlob() ([]byte, error) {
+	archive, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	return archive.ConfigBlob()
+}
+
+// load indexes the archive the first time it is needed.
+func (c *archiveContents) load() (*LazyImageArchive, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.archive == nil {
+		archive, err := c.open()
+		if err != nil {
+			return nil, err
+		}
+		c.archive = archive
+	}
+	return c.archive, nil
+}
+
+// Close removes the archive if it was spooled to a temporary file.
+func (c *archiveContents) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.archive == nil || !c.owned {
+		return nil
+	}
+	return c.archive.Close()
+}
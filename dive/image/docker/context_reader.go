@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"context"
+	"io"
+)
+
+type contextReader struct {
+	ctx    context.Context
+	reader io.ReadCloser
+}
+
+// NewContextReader wraps the given reader such that reads fail with the context error once the context is done, which
+// allows abandoning the (potentially long) parsing of an image archive.
+func NewContextReader(ctx context.Context, reader io.ReadCloser) io.ReadCloser {
+	return &contextReader{
+		ctx:    ctx,
+		reader: reader,
+	}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+func (r *contextReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// the classic (pre-BuildKit) builder records metadata-only instructions as no-op shell commands
+const legacyBuilderPrefix = "/bin/sh -c #(nop) "
+
+var maintainerPattern = regexp.MustCompile(`(^|#\(nop\)\s*)MAINTAINER\s`)
+
+// findDeprecations inspects the image config and archive layout for legacy features that registries and engines are
+// dropping support for.
+func findDeprecations(cfg config, layerCount int, legacyLayout bool) []image.Deprecation {
+	deprecations := make([]image.Deprecation, 0)
+
+	if len(cfg.RootFs.DiffIds) == 0 && layerCount > 0 {
+		deprecations = append(deprecations, image.Deprecation{
+			Feature: "schema1 manifest",
+			Detail:  "the image config has no rootfs diff IDs, so the image was likely converted from a schema1 manifest (schema1 is deprecated and no longer accepted by most registries); rebuild and push the image",
+		})
+	}
+
+	var maintainers, legacyBuilder, historyLayers int
+	for _, entry := range cfg.History {
+		if maintainerPattern.MatchString(entry.CreatedBy) {
+			maintainers++
+		}
+		if strings.HasPrefix(entry.CreatedBy, legacyBuilderPrefix) {
+			legacyBuilder++
+		}
+		if !entry.EmptyLayer {
+			historyLayers++
+		}
+	}
+
+	if maintainers > 0 {
+		deprecations = append(deprecations, image.Deprecation{
+			Feature: "MAINTAINER instruction",
+			Detail:  fmt.Sprintf("used %d time(s); MAINTAINER is deprecated, use LABEL org.opencontainers.image.authors instead", maintainers),
+		})
+	}
+
+	if legacyBuilder > 0 {
+		deprecations = append(deprecations, image.Deprecation{
+			Feature: "legacy builder history",
+			Detail:  fmt.Sprintf("%d history entries were recorded by the legacy builder (\"#(nop)\" commands), which is deprecated in favor of BuildKit", legacyBuilder),
+		})
+	}
+
+	if len(cfg.History) > 0 && historyLayers != layerCount {
+		deprecations = append(deprecations, image.Deprecation{
+			Feature: "legacy builder history",
+			Detail:  fmt.Sprintf("the history describes %d layers but the image has %d, so layer commands may be misattributed", historyLayers, layerCount),
+		})
+	}
+
+	if legacyLayout {
+		deprecations = append(deprecations, image.Deprecation{
+			Feature: "v1 layer format",
+			Detail:  "the image archive uses the legacy v1 layout (a VERSION and json file per layer); current engines save images in the OCI layout",
+		})
+	}
+
+	return deprecations
+}
+
+// isLegacyLayerFile indicates if the archive entry is part of the v1 per-layer metadata (<layer>/VERSION or <layer>/json).
+func isLegacyLayerFile(name string) bool {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	if len(parts) != 2 {
+		return false
+	}
+	return parts[1] == "VERSION" || parts[1] == "json"
+}
@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// the magic of a tar header (POSIX and GNU), at its offset within the header
+var tarMagic = []byte("ustar")
+
+const tarMagicOffset = 257
+
+// DetectArchive describes the image archive (a tar, optionally gzip or zstd compressed) or the image layout
+// directory at the given path, recognized by its contents. Empty is returned when the path is neither (e.g. when it
+// does not exist).
+func DetectArchive(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		switch {
+		case fileExists(filepath.Join(path, "oci-layout")) || fileExists(filepath.Join(path, "index.json")):
+			return "an OCI layout directory"
+		case fileExists(filepath.Join(path, "manifest.json")):
+			return "an extracted image archive"
+		}
+		return ""
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(blobMagicLength)
+	format := sniffFormat(magic)
+	decompressed, err := decompress(buffered, format)
+	if err != nil {
+		return ""
+	}
+	defer decompressed.Close()
+
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+	if _, err := io.ReadFull(decompressed, header); err != nil || !bytes.Equal(header[tarMagicOffset:], tarMagic) {
+		return ""
+	}
+	switch format {
+	case formatGzip:
+		return "a gzip compressed image archive"
+	case formatZstd:
+		return "a zstd compressed image archive"
+	}
+	return "an image archive"
+}
+
+// tarDirectory streams the files beneath the directory as a tar archive, so that an OCI layout directory (or an
+// extracted image archive) is read like the archive.
+func tarDirectory(root string) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		archive := tar.NewWriter(writer)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			name, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(name)
+			if err := archive.WriteHeader(header); err != nil {
+				return err
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(archive, file)
+			return err
+		})
+		if err == nil {
+			err = archive.Close()
+		}
+		// the reader sees the error (or the end of the archive)
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
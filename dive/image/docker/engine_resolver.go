@@ -1,62 +1,277 @@
 package docker
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
-	"golang.org/x/net/context"
 )
 
-type engineResolver struct{}
+type engineResolver struct {
+	options image.ResolverOptions
+}
 
-func NewResolverFromEngine() *engineResolver {
-	return &engineResolver{}
+func NewResolverFromEngine(options image.ResolverOptions) *engineResolver {
+	return &engineResolver{options: options}
 }
 
-func (r *engineResolver) Fetch(id string) (*image.Image, error) {
+func (r *engineResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+
+	reader, size, err := r.fetchArchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	img, err := ReadImageArchive(ctx, reader, size, r.options)
+	if err != nil {
+		return nil, err
+	}
+	result, err := img.ToImage()
+	if err != nil {
+		return nil, err
+	}
+	// the file contents are not kept while parsing, so the image is saved again when a file is first opened
+	result.Contents = newArchiveContents(func() (*LazyImageArchive, error) {
+		reader, size, err := r.fetchArchive(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return spoolArchive(context.Background(), reader, size, r.options)
+	})
+	return result, nil
+}
 
-	reader, err := r.fetchArchive(id)
+// FetchLazy saves the image to a temporary archive on disk, which is indexed so that layers can be parsed on demand.
+func (r *engineResolver) FetchLazy(ctx context.Context, id string) (*image.Image, error) {
+	reader, size, err := r.fetchArchive(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
 
-	img, err := NewImageArchive(reader)
+	return fetchLazyFromReader(ctx, reader, size, r.options)
+}
+
+// fetchLazyFromReader spools the image archive to a temporary file on disk, which is indexed so that layers can be
+// parsed on demand (the file is removed when the image is closed).
+func fetchLazyFromReader(ctx context.Context, reader io.Reader, size uint64, options image.ResolverOptions) (*image.Image, error) {
+	img, err := spoolArchive(ctx, reader, size, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := img.ToImage()
+	if err != nil {
+		img.Close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// spoolArchive writes the image archive to a temporary file on disk and indexes it (the file is removed when the
+// archive is closed). The bytes written are reported to the progress bus of the context, against the size of the
+// archive (0 when unknown). The layers of the archive are parsed with the given options.
+func spoolArchive(ctx context.Context, reader io.Reader, size uint64, options image.ResolverOptions) (*LazyImageArchive, error) {
+	archive, err := ioutil.TempFile("", "dive.*.tar")
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	tracker := image.NewProgressTracker(ctx, image.StageFetching)
+	tracker.SetTotals(size, 0, 0)
+	defer tracker.Finish()
+	_, err = io.Copy(archive, NewContextReader(ctx, ioutil.NopCloser(tracker.Reader(reader))))
+	if err == nil {
+		err = archive.Close()
+	}
 	if err != nil {
+		os.Remove(archive.Name())
 		return nil, err
 	}
-	return img.ToImage()
+
+	img, err := NewLazyImageArchive(ctx, archive.Name(), true, options)
+	if err != nil {
+		os.Remove(archive.Name())
+		return nil, err
+	}
+	return img, nil
 }
 
-func (r *engineResolver) Build(args []string) (*image.Image, error) {
-	id, err := buildImageFromCli(args)
+func (r *engineResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	// the engine may pull (or check) the base images of the build
+	if err := r.options.Network.RequireNetwork("building an image"); err != nil {
+		return nil, err
+	}
+	id, err := buildImageFromCli(ctx, r.options.EngineHost, args)
 	if err != nil {
 		return nil, err
 	}
-	return r.Fetch(id)
+	return r.Fetch(ctx, id)
+}
+
+// fetchArchive saves the image from the engine (pulling it first when it is not available locally), returning the
+// archive stream and its estimated size (the size of the image, 0 when unknown).
+func (r *engineResolver) fetchArchive(ctx context.Context, id string) (io.ReadCloser, uint64, error) {
+	dockerClient, err := newEngineClient(r.options.EngineHost, r.options.Network.CA)
+	if err != nil {
+		return nil, 0, err
+	}
+	var size int64
+	inspect := func(ctx context.Context) error {
+		info, _, err := dockerClient.ImageInspectWithRaw(ctx, id)
+		if client.IsErrNotFound(err) {
+			return image.Permanent(err)
+		}
+		size = info.Size
+		return err
+	}
+	err = r.options.Operation.Retry(ctx, "inspecting "+id, inspect)
+	if ctx.Err() != nil {
+		return nil, 0, ctx.Err()
+	}
+	pull, policyErr := r.options.Pull.ShouldPull(id, err == nil, r.options.Network)
+	if policyErr != nil {
+		return nil, 0, policyErr
+	}
+	if pull {
+		if err != nil {
+			fmt.Println("Image not available locally. Trying to pull '" + id + "'...")
+		}
+		if err := r.pullImage(ctx, dockerClient, id); err != nil {
+			return nil, 0, err
+		}
+		if err := r.options.Operation.Retry(ctx, "inspecting "+id, inspect); err != nil {
+			// the size is only used to estimate the progress
+			size = 0
+		}
+	}
+
+	var readCloser io.ReadCloser
+	err = r.options.Operation.Retry(ctx, "saving "+id, func(ctx context.Context) error {
+		readCloser, err = dockerClient.ImageSave(ctx, []string{id})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if size < 0 {
+		size = 0
+	}
+	return &archiveReader{Reader: r.options.IO.Throttle(readCloser), closers: []io.Closer{readCloser}}, uint64(size), nil
+}
+
+// pullMessage is a message of the progress stream of an image pull (see the engine API).
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress *struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
 }
 
-func (r *engineResolver) fetchArchive(id string) (io.ReadCloser, error) {
-	var err error
-	var dockerClient *client.Client
+// pullImage pulls the image with the engine API (authenticating with the docker CLI credentials of its registry),
+// reporting the progress of every layer to the progress bus of the context.
+func (r *engineResolver) pullImage(ctx context.Context, dockerClient *client.Client, id string) error {
+	options := types.ImagePullOptions{RegistryAuth: encodedRegistryAuth(id)}
+	var stream io.ReadCloser
+	err := r.options.Operation.Retry(ctx, "pulling "+id, func(ctx context.Context) error {
+		var err error
+		stream, err = dockerClient.ImagePull(ctx, id, options)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to pull '%s': %v", id, err)
+	}
+	defer stream.Close()
+	return readPullProgress(image.NewProgressTracker(ctx, image.StagePulling), id, stream)
+}
+
+// readPullProgress reports the layers of a pull progress stream to the tracker, until the pull is over.
+func readPullProgress(tracker *image.ProgressTracker, id string, stream io.Reader) error {
+	defer tracker.Finish()
+	decoder := json.NewDecoder(stream)
+	for {
+		var message pullMessage
+		err := decoder.Decode(&message)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read the pull progress of '%s': %v", id, err)
+		}
+		if message.Error != nil {
+			return fmt.Errorf("unable to pull '%s': %s", id, message.Error.Message)
+		}
+		// the messages about the image as a whole (e.g. "Pulling from library/alpine" for the tag) are not layers
+		if message.ID == "" || strings.HasPrefix(message.Status, "Pulling from") {
+			continue
+		}
+		layer := image.LayerProgress{ID: message.ID, Status: message.Status}
+		if message.Progress != nil && message.Progress.Current > 0 {
+			layer.Current = uint64(message.Progress.Current)
+			if message.Progress.Total > 0 {
+				layer.Total = uint64(message.Progress.Total)
+			}
+		}
+		tracker.SetLayer(layer)
+	}
+}
 
-	// pull the engineResolver if it does not exist
-	ctx := context.Background()
+// encodedRegistryAuth encodes the credentials of the registry of the image for the engine API (empty when there are
+// none, e.g. for public images).
+func encodedRegistryAuth(id string) string {
+	ref, err := ParseRemoteReference(id)
+	if err != nil {
+		return ""
+	}
+	credentials := loadRegistryCredentials(ref.Registry)
+	if credentials.username == "" && credentials.identityToken == "" {
+		return ""
+	}
+	auth, err := json.Marshal(types.AuthConfig{
+		Username:      credentials.username,
+		Password:      credentials.password,
+		IdentityToken: credentials.identityToken,
+		ServerAddress: ref.Registry,
+	})
+	if err != nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString(auth)
+}
 
-	host := os.Getenv("DOCKER_HOST")
+// newEngineClient creates a docker API client configured from the environment (DOCKER_HOST, DOCKER_TLS_VERIFY, ...),
+// reaching the engine at the given host instead of DOCKER_HOST (unless empty) and trusting the given CA bundle.
+func newEngineClient(host string, ca image.CABundle) (*client.Client, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
 	var clientOpts []client.Opt
 
 	switch strings.Split(host, ":")[0] {
 	case "ssh":
 		helper, err := connhelper.GetConnectionHelper(host)
 		if err != nil {
-			fmt.Println("docker host", err)
+			return nil, fmt.Errorf("unable to connect to docker host %q: %v", host, err)
 		}
 		clientOpts = append(clientOpts, func(c *client.Client) error {
 			httpClient := &http.Client{
@@ -75,28 +290,87 @@ func (r *engineResolver) fetchArchive(id string) (io.ReadCloser, error) {
 			os.Setenv("DOCKER_CERT_PATH", "~/.docker")
 		}
 
-		clientOpts = append(clientOpts, client.FromEnv)
+		clientOpts = append(clientOpts, client.FromEnv, func(c *client.Client) error {
+			// remote engines (DOCKER_TLS_VERIFY) may be reached through a proxy intercepting TLS
+			ca.Trust(c.HTTPClient().Transport)
+			return nil
+		})
+		if host != "" {
+			clientOpts = append(clientOpts, client.WithHost(host))
+		}
 	}
 
 	clientOpts = append(clientOpts, client.WithAPIVersionNegotiation())
-	dockerClient, err = client.NewClientWithOpts(clientOpts...)
+	return client.NewClientWithOpts(clientOpts...)
+}
+
+// PingEngine checks that the docker engine API is reachable at the given host (DOCKER_HOST when empty), returning the
+// engine API version.
+func PingEngine(ctx context.Context, host string, ca image.CABundle) (string, error) {
+	dockerClient, err := newEngineClient(host, ca)
+	if err != nil {
+		return "", err
+	}
+	defer dockerClient.Close()
+
+	ping, err := dockerClient.Ping(ctx)
+	if err != nil {
+		return "", err
+	}
+	return ping.APIVersion, nil
+}
+
+// ListEngineImages returns the names (repository:tag) of the images stored by the engine at the given host, sorted.
+// Images without a name are listed by their short ID.
+func ListEngineImages(ctx context.Context, host string, ca image.CABundle) ([]string, error) {
+	dockerClient, err := newEngineClient(host, ca)
 	if err != nil {
 		return nil, err
 	}
-	_, _, err = dockerClient.ImageInspectWithRaw(ctx, id)
+	defer dockerClient.Close()
+
+	summaries, err := dockerClient.ImageList(ctx, types.ImageListOptions{})
 	if err != nil {
-		// don't use the API, the CLI has more informative output
-		fmt.Println("Handler not available locally. Trying to pull '" + id + "'...")
-		err = runDockerCmd("pull", id)
-		if err != nil {
-			return nil, err
+		return nil, err
+	}
+	var names []string
+	for _, summary := range summaries {
+		named := false
+		for _, tag := range summary.RepoTags {
+			if tag != "<none>:<none>" {
+				names = append(names, tag)
+				named = true
+			}
+		}
+		if !named {
+			names = append(names, shortImageID(summary.ID))
 		}
 	}
+	sort.Strings(names)
+	return names, nil
+}
 
-	readCloser, err := dockerClient.ImageSave(ctx, []string{id})
+// EngineImageLayers returns the IDs (diff IDs) of the layers of the given image stored by the engine at the given
+// host, the first layer first (the image is not pulled).
+func EngineImageLayers(ctx context.Context, id, host string, ca image.CABundle) ([]string, error) {
+	dockerClient, err := newEngineClient(host, ca)
 	if err != nil {
 		return nil, err
 	}
+	defer dockerClient.Close()
 
-	return readCloser, nil
+	info, _, err := dockerClient.ImageInspectWithRaw(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return info.RootFS.Layers, nil
+}
+
+// shortImageID abbreviates an image ID the way the docker CLI lists it (12 hex digits, without the algorithm).
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
 }
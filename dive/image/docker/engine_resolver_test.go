@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestReadPullProgress(t *testing.T) {
+	var last image.ProgressEvent
+	ctx := image.WithProgress(context.Background(), func(event image.ProgressEvent) {
+		if !event.Done {
+			last = event
+		}
+	})
+
+	stream := `{"status":"Pulling from library/alpine","id":"latest"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"a1"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":512,"total":2048},"id":"a1"}
+{"status":"Pull complete","progressDetail":{},"id":"b2"}
+{"status":"Digest: sha256:0123"}
+`
+	if err := readPullProgress(image.NewProgressTracker(ctx, image.StagePulling), "alpine", strings.NewReader(stream)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []image.LayerProgress{
+		{ID: "a1", Status: "Downloading", Current: 512, Total: 2048},
+		{ID: "b2", Status: "Pull complete"},
+	}
+	if last.Stage != image.StagePulling || !reflect.DeepEqual(last.Layers, expected) {
+		t.Errorf("expected the layers %+v, got %+v", expected, last.Layers)
+	}
+
+	failed := `{"status":"Pulling fs layer","progressDetail":{},"id":"a1"}
+{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized: authentication required"}
+`
+	err := readPullProgress(nil, "private/app", strings.NewReader(failed))
+	if err == nil || !strings.Contains(err.Error(), "authentication required") {
+		t.Errorf("expected the pull error to be returned, got %v", err)
+	}
+}
+
+func TestNewEngineClient_Host(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///var/run/from-env.sock")
+
+	dockerClient, err := newEngineClient("unix:///tmp/colima/docker.sock", image.CABundle{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer dockerClient.Close()
+	if host := dockerClient.DaemonHost(); host != "unix:///tmp/colima/docker.sock" {
+		t.Errorf("expected the given host, got %q", host)
+	}
+
+	fromEnv, err := newEngineClient("", image.CABundle{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer fromEnv.Close()
+	if host := fromEnv.DaemonHost(); host != "unix:///var/run/from-env.sock" {
+		t.Errorf("expected DOCKER_HOST, got %q", host)
+	}
+}
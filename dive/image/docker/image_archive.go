@@ -2,27 +2,71 @@ package docker
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/metrics"
 )
 
 type ImageArchive struct {
 	manifest manifest
 	config   config
 	layerMap map[string]*filetree.FileTree
+	blobs    map[string]blob
+	// blobs that could not be read as layers (these may be other artifacts within an OCI layout)
+	unreadable map[string]error
+	// the archive has the v1 per-layer metadata files
+	legacyLayout bool
 }
 
 func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
+	return readImageArchive(tarFile, nil, layerParser{})
+}
+
+// ReadImageArchive parses the image archive read from the reader like NewImageArchive, stopping once the context is
+// done, with the given options (e.g. the bounds of the analysis). The bytes read and the layers parsed are reported
+// to the progress bus of the context, against the size of the archive (0 when unknown).
+func ReadImageArchive(ctx context.Context, reader io.Reader, size uint64, options image.ResolverOptions) (*ImageArchive, error) {
+	tracker := image.NewProgressTracker(ctx, image.StageFetching)
+	tracker.SetTotals(size, 0, 0)
+	defer tracker.Finish()
+	return readImageArchive(NewContextReader(ctx, ioutil.NopCloser(tracker.Reader(reader))), tracker, newLayerParser(ctx, options))
+}
+
+// layerParser parses the layer tars of one image with the options of its resolver. The zero value parses layers
+// without bounds.
+type layerParser struct {
+	// the room the layer trees of the image have left (nil when the analysis is not bounded)
+	budget *image.EntryBudget
+	// the cache the parsed layers are reused from (nil parses every layer)
+	cache image.LayerCache
+	// the inspectors that read the contents of the files
+	inspectors []filetree.Inspector
+	// times the parsing of the layers (nil when it is not timed)
+	recorder *metrics.Recorder
+}
+
+// newLayerParser creates the parser of the layers of an image, which has a budget of its own within the bounds. The
+// layers are timed with the recorder of the context (if any).
+func newLayerParser(ctx context.Context, options image.ResolverOptions) layerParser {
+	return layerParser{budget: options.Bounds.NewBudget(), cache: options.LayerCache, inspectors: options.Inspectors, recorder: metrics.FromContext(ctx)}
+}
+
+func readImageArchive(tarFile io.ReadCloser, tracker *image.ProgressTracker, parser layerParser) (*ImageArchive, error) {
 	img := &ImageArchive{
-		layerMap: make(map[string]*filetree.FileTree),
+		layerMap:   make(map[string]*filetree.FileTree),
+		blobs:      make(map[string]blob),
+		unreadable: make(map[string]error),
 	}
 
 	tarReader := tar.NewReader(tarFile)
@@ -39,119 +83,229 @@ func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 		}
 
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return img, err
 		}
 
 		name := header.Name
 
 		// some layer tars can be relative layer symlinks to other layer tars
-		if header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeReg {
+		if header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeReg {
+			continue
+		}
 
-			if strings.HasSuffix(name, ".tar") {
-				currentLayer++
-				layerReader := tar.NewReader(tarReader)
-				tree, err := processLayerTar(name, layerReader)
+		switch {
+		case isLegacyLayerFile(name):
+			img.legacyLayout = true
+		case strings.HasSuffix(name, ".json") || strings.HasPrefix(name, "sha256:"):
+			fileBuffer, err := ioutil.ReadAll(tarReader)
+			if err != nil {
+				return img, err
+			}
+			jsonFiles[name] = fileBuffer
+		case isLayerBlob(name):
+			contents := bufio.NewReader(tarReader)
+			// a short (or empty) blob yields fewer magic bytes, which is fine for sniffing
+			magic, _ := contents.Peek(blobMagicLength)
+			format := sniffFormat(magic)
+
+			if format == formatJSON {
+				// OCI layout archives store the image config alongside the layers
+				fileBuffer, err := ioutil.ReadAll(contents)
 				if err != nil {
 					return img, err
 				}
+				jsonFiles[name] = fileBuffer
+				continue
+			}
 
-				// add the layer to the image
+			currentLayer++
+			if tree, layerBlob, exists := parser.cachedLayer(name); exists {
+				// an unchanged layer of an earlier build: the tar reader skips the rest of the blob
 				img.layerMap[tree.Name] = tree
+				img.blobs[tree.Name] = layerBlob
+				tracker.LayerDone(tree.Size)
+				continue
+			}
+			tree, layerBlob, err := parser.processLayerBlob(name, contents, format, uint64(header.Size))
+			if err != nil && strings.HasPrefix(name, "blobs/") {
+				// only fail if the manifest turns out to reference the blob
+				img.unreadable[name] = err
+				continue
+			}
+			if err != nil {
+				return img, err
+			}
 
-			} else if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, "tgz") {
-				currentLayer++
-
-				// Add gzip reader
-				gz, err := gzip.NewReader(tarReader)
-				if err != nil {
-					return img, err
-				}
+			parser.cacheLayer(name, tree, layerBlob)
 
-				// Add tar reader
-				layerReader := tar.NewReader(gz)
+			// add the layer to the image
+			img.layerMap[tree.Name] = tree
+			img.blobs[tree.Name] = layerBlob
+			tracker.LayerDone(tree.Size)
+		}
+	}
 
-				// Process layer
-				tree, err := processLayerTar(name, layerReader)
-				if err != nil {
-					return img, err
-				}
+	var err error
+	img.manifest, img.config, err = readArchiveMetadata(jsonFiles)
+	if err != nil {
+		return img, err
+	}
 
-				// add the layer to the image
-				img.layerMap[tree.Name] = tree
+	return img, nil
+}
 
-			} else if strings.HasSuffix(name, ".json") || strings.HasPrefix(name, "sha256:") {
-				fileBuffer, err := ioutil.ReadAll(tarReader)
-				if err != nil {
-					return img, err
-				}
-				jsonFiles[name] = fileBuffer
-			}
+// processLayerBlob parses the layer tar within the given blob. Layers that are stored uncompressed are recompressed
+// to find the size a registry would store.
+func (p layerParser) processLayerBlob(name string, contents io.Reader, format blobFormat, size uint64) (*filetree.FileTree, blob, error) {
+	layerBlob := blob{size: size, format: format}
+	blobDigest, tarDigest := sha256.New(), sha256.New()
+	contents = io.TeeReader(contents, blobDigest)
+
+	if !format.isCompressed() {
+		// an uncompressed blob is the layer tar itself
+		compressed := newCompressionCounter()
+		contents = io.TeeReader(contents, compressed)
+		tree, err := p.readLayerTar(name, contents, &layerBlob.integrity)
+		if err != nil {
+			return nil, layerBlob, err
 		}
+		layerBlob.compressedSize, err = drainCompressed(contents, compressed)
+		layerBlob.integrity.verify(name, contents, contents, blobDigest, blobDigest)
+		return tree, layerBlob, err
 	}
 
-	manifestContent, exists := jsonFiles["manifest.json"]
-	if !exists {
-		return img, fmt.Errorf("could not find image manifest")
+	reader, err := decompress(contents, format)
+	if err != nil {
+		return nil, layerBlob, err
 	}
+	defer reader.Close()
 
-	img.manifest = newManifest(manifestContent)
-
-	configContent, exists := jsonFiles[img.manifest.ConfigPath]
-	if !exists {
-		return img, fmt.Errorf("could not find image config")
+	layerBlob.compressedSize = size
+	layerTar := io.TeeReader(reader, tarDigest)
+	tree, err := p.readLayerTar(name, layerTar, &layerBlob.integrity)
+	if err != nil {
+		return nil, layerBlob, err
 	}
+	layerBlob.integrity.verify(name, contents, layerTar, blobDigest, tarDigest)
+	return tree, layerBlob, nil
+}
 
-	img.config = newConfig(configContent)
+// readLayerTar parses the given layer tar, recording a truncated (or otherwise damaged) tar as a problem with the
+// integrity of the layer instead of failing, so the entries read until then are still shown.
+func (p layerParser) readLayerTar(name string, contents io.Reader, integrity *layerIntegrity) (*filetree.FileTree, error) {
+	defer p.recorder.Since("parse layer", name, time.Now())
+	tree, err := p.processLayerTar(name, contents, &integrity.warnings)
+	if errors.Is(err, errLayerTruncated) {
+		integrity.problems = append(integrity.problems, err.Error())
+		return tree, nil
+	}
+	return tree, err
+}
 
-	return img, nil
+// drainCompressed reads the remainder of a layer tar (the tar reader stops at the end-of-archive marker, but any
+// trailing padding is still part of the layer blob) and returns the compressed size of the whole layer.
+func drainCompressed(contents io.Reader, compressed *compressionCounter) (uint64, error) {
+	if _, err := io.Copy(ioutil.Discard, contents); err != nil {
+		return 0, err
+	}
+	return compressed.Size()
 }
 
-func processLayerTar(name string, reader *tar.Reader) (*filetree.FileTree, error) {
+// processLayerTar parses the given layer tar into a tree. Malformed entries are skipped and recorded in the given
+// warnings (which may be nil).
+func (p layerParser) processLayerTar(name string, contents io.Reader, warnings *image.ParseWarnings) (*filetree.FileTree, error) {
 	tree := filetree.NewFileTree()
 	tree.Name = name
 
-	fileInfos, err := getFileList(reader)
+	fileInfos, err := getFileList(&countingReader{reader: contents}, warnings, p.inspectors)
+	var truncated error
 	if err != nil {
-		return nil, err
+		if !isCorrupt(err) {
+			return nil, err
+		}
+		truncated = fmt.Errorf("%w after %d entries (%v)", errLayerTruncated, len(fileInfos), err)
 	}
-
+	fileInfos = normalizeWindowsLayer(fileInfos)
 	for _, element := range fileInfos {
 		tree.FileSize += uint64(element.Size)
+	}
+	// the sizes are those of every entry, even when the deep subtrees are folded to bound the analysis
+	fileInfos, tree.Folded = p.budget.Fold(fileInfos)
 
+	for _, element := range fileInfos {
 		_, _, err := tree.AddPath(element.Path, element)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return tree, nil
+	return tree, truncated
+}
+
+// countingReader counts the bytes read from a layer tar, which measures the data stored for each (sparse) file.
+type countingReader struct {
+	reader io.Reader
+	count  int64
 }
 
-func getFileList(tarReader *tar.Reader) ([]filetree.FileInfo, error) {
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// the largest size an entry of a layer tar may declare (larger entries are skipped as malformed)
+const maxEntrySize = 1 << 40
+
+// getFileList reads the entries of a layer tar. Entries that are malformed (see image.ParseWarningHeader et al.) are
+// skipped and recorded in the given warnings, rather than failing the whole layer: only a tar whose very first header
+// is malformed (which is not a layer tar at all) fails. The given inspectors read the contents of the files they accept.
+func getFileList(contents *countingReader, warnings *image.ParseWarnings, inspectors []filetree.Inspector) ([]filetree.FileInfo, error) {
 	var files []filetree.FileInfo
+	tarReader := tar.NewReader(contents)
 
-	for {
+	for entries := 0; ; entries++ {
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return nil, err
+			if isCorrupt(err) || entries == 0 {
+				// the entries read so far are kept for a truncated tar (see processLayerTar)
+				return files, err
+			}
+			// the tar reader cannot find the next entry past a malformed header
+			warnings.Add(image.ParseWarningHeader, "", fmt.Sprintf("%v (the rest of the layer after %d entries could not be read)", err, entries))
+			break
 		}
 
 		// always ensure relative path notations are not parsed as part of the filename
 		name := path.Clean(header.Name)
-		if name == "." {
+		if name == "." || isEStargzMetadata(name) {
+			continue
+		}
+		if name == ".." || strings.HasPrefix(name, "../") {
+			warnings.Add(image.ParseWarningTraversal, header.Name, "the name leads outside of the image filesystem")
+			continue
+		}
+		if header.Size > maxEntrySize && !filetree.IsSparse(header) {
+			warnings.Add(image.ParseWarningSize, header.Name, fmt.Sprintf("%d bytes declared", header.Size))
 			continue
 		}
 
 		switch header.Typeflag {
-		case tar.TypeXGlobalHeader:
-			return nil, fmt.Errorf("unexptected tar file: (XGlobalHeader): type=%v name=%s", header.Typeflag, name)
-		case tar.TypeXHeader:
-			return nil, fmt.Errorf("unexptected tar file (XHeader): type=%v name=%s", header.Typeflag, name)
+		case tar.TypeXGlobalHeader, tar.TypeXHeader:
+			warnings.Add(image.ParseWarningType, header.Name, fmt.Sprintf("unexpected PAX header entry (type %q)", header.Typeflag))
 		default:
-			files = append(files, filetree.NewFileInfoFromTarHeader(tarReader, header, name))
+			start := contents.count
+			info, err := filetree.NewFileInfoFromTarHeader(tarReader, header, name, inspectors)
+			if err != nil {
+				return files, err
+			}
+			if info.Sparse {
+				// the contents were read entirely, which reads only the data regions (not the holes) from the tar
+				info.StoredSize = contents.count - start
+			}
+			files = append(files, info)
 		}
 	}
 	return files, nil
@@ -161,51 +315,85 @@ func (img *ImageArchive) ToImage() (*image.Image, error) {
 	trees := make([]*filetree.FileTree, 0)
 
 	// build the content tree
-	for _, treeName := range img.manifest.LayerTarPaths {
+	for idx, treeName := range img.manifest.LayerTarPaths {
 		tr, exists := img.layerMap[treeName]
 		if exists {
 			trees = append(trees, tr)
 			continue
 		}
+		if missing, ok := missingLayer(img.config, img.manifest, idx); ok {
+			// show the layer metadata even though the contents cannot be read
+			tr = filetree.NewFileTree()
+			tr.Name = treeName
+			img.blobs[treeName] = missing
+			trees = append(trees, tr)
+			continue
+		}
+		if err, unreadable := img.unreadable[treeName]; unreadable {
+			return nil, fmt.Errorf("unable to read layer '%s': %w", treeName, err)
+		}
 		return nil, fmt.Errorf("could not find '%s' in parsed layers", treeName)
 	}
 
-	// build the layers array
+	sizes := make([]uint64, len(trees))
+	blobs := make([]blob, len(trees))
+	for idx, tree := range trees {
+		sizes[idx] = tree.FileSize
+		blobs[idx] = img.blobs[tree.Name]
+	}
+
+	return &image.Image{
+		Trees:        trees,
+		Layers:       newLayers(img.config, img.manifest.LayerTarPaths, sizes, blobs, trees),
+		Deprecations: findDeprecations(img.config, len(trees), img.legacyLayout),
+		History:      newHistory(img.config, len(trees)),
+		Config:       newImageConfig(img.config),
+		ID:           img.manifest.ConfigDigest,
+	}, nil
+
+}
+
+// newLayers builds the layers array from the layer tar paths (in manifest order), pairing each with its history entry.
+// The trees may be nil when the layer contents have not been parsed yet.
+func newLayers(cfg config, names []string, sizes []uint64, blobs []blob, trees []*filetree.FileTree) []*image.Layer {
 	layers := make([]*image.Layer, 0)
 
 	// note that the engineResolver config stores images in reverse chronological order, so iterate backwards through layers
 	// as you iterate chronologically through history (ignoring history items that have no layer contents)
 	// Note: history is not required metadata in a docker image!
+	builders := newBuilderFingerprint(cfg)
 	histIdx := 0
-	for idx, tree := range trees {
+	for idx, name := range names {
 		// ignore empty layers, we are only observing layers with content
 		historyObj := historyEntry{
 			CreatedBy: "(missing)",
 		}
-		for nextHistIdx := histIdx; nextHistIdx < len(img.config.History); nextHistIdx++ {
-			if !img.config.History[nextHistIdx].EmptyLayer {
+		builderHistIdx := -1
+		for nextHistIdx := histIdx; nextHistIdx < len(cfg.History); nextHistIdx++ {
+			if !cfg.History[nextHistIdx].EmptyLayer {
 				histIdx = nextHistIdx
 				break
 			}
 		}
-		if histIdx < len(img.config.History) && !img.config.History[histIdx].EmptyLayer {
-			historyObj = img.config.History[histIdx]
+		if histIdx < len(cfg.History) && !cfg.History[histIdx].EmptyLayer {
+			historyObj = cfg.History[histIdx]
+			builderHistIdx = histIdx
 			histIdx++
 		}
 
-		historyObj.Size = tree.FileSize
+		historyObj.Size = sizes[idx]
 
+		builder := builders.identify(builderHistIdx)
 		dockerLayer := layer{
 			history: historyObj,
 			index:   idx,
-			tree:    tree,
+			name:    name,
+			blob:    blobs[idx],
+			builder: builder,
+			role:    layerRole(builder, historyObj, trees[idx]),
+			tree:    trees[idx],
 		}
 		layers = append(layers, dockerLayer.ToLayer())
 	}
-
-	return &image.Image{
-		Trees:  trees,
-		Layers: layers,
-	}, nil
-
+	return layers
 }
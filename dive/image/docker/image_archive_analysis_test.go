@@ -14,7 +14,7 @@ func Test_Analysis(t *testing.T) {
 		wastedPercent float64
 		path          string
 	}{
-		"docker-image": {0.9844212134184309, 1220598, 66237, 32025, 0.4834911001404049, "../../../.data/test-docker-image.tar"},
+		"docker-image": {0.9791198554526761, 1220598, 66237, 38430, 0.5801893201684859, "../../../.data/test-docker-image.tar"},
 	}
 
 	for name, test := range table {
@@ -41,3 +41,48 @@ func Test_Analysis(t *testing.T) {
 		}
 	}
 }
+
+func Test_LayerMetadata(t *testing.T) {
+	result := TestAnalysisFromArchive(t, "../../../.data/test-docker-image.tar")
+
+	// the config of a saved image is named after its digest
+	if expected := "sha256:75ae28b8ebf89b203319069ddcbecdf5c3838503bbebc0d0d85e4b8589c5de3a"; result.ImageID != expected {
+		t.Errorf("expected image ID %q, got %q", expected, result.ImageID)
+	}
+
+	for _, layer := range result.Layers {
+		if layer.MediaType != mediaTypeLayer {
+			t.Errorf("layer %d: expected media type %q, got %q", layer.Index, mediaTypeLayer, layer.MediaType)
+		}
+		if layer.DiffID == "" || layer.Digest != layer.DiffID {
+			t.Errorf("layer %d: expected the uncompressed blob digest to match the diffID, got digest=%q diffID=%q", layer.Index, layer.Digest, layer.DiffID)
+		}
+		if layer.Created.IsZero() {
+			t.Errorf("layer %d: expected a creation time", layer.Index)
+		}
+		if layer.CompressedSize == 0 || layer.CompressedSize > layer.BlobSize {
+			t.Errorf("layer %d: expected the recompressed size (%d bytes) to be no larger than the layer tar (%d bytes)", layer.Index, layer.CompressedSize, layer.BlobSize)
+		}
+		if layer.BlobSize < layer.Size {
+			t.Errorf("layer %d: expected the layer tar (%d bytes) to be no smaller than its contents (%d bytes)", layer.Index, layer.BlobSize, layer.Size)
+		}
+	}
+}
+
+func Test_Deprecations(t *testing.T) {
+	result := TestAnalysisFromArchive(t, "../../../.data/test-docker-image.tar")
+
+	features := make(map[string]bool)
+	for _, deprecation := range result.Deprecations {
+		features[deprecation.Feature] = true
+	}
+
+	for _, expected := range []string{"legacy builder history", "v1 layer format"} {
+		if !features[expected] {
+			t.Errorf("expected a %q deprecation, got %v", expected, result.Deprecations)
+		}
+	}
+	if features["schema1 manifest"] {
+		t.Errorf("did not expect a schema1 deprecation, got %v", result.Deprecations)
+	}
+}
@@ -0,0 +1,184 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// rawTarHeader encodes a ustar header block by hand (the tar writer refuses to write PAX and sparse headers itself).
+func rawTarHeader(name string, typeflag byte, size int) []byte {
+	block := make([]byte, 512)
+	copy(block[0:100], name)
+	copy(block[100:108], "0000644\x00")
+	copy(block[108:116], "0000000\x00")
+	copy(block[116:124], "0000000\x00")
+	copy(block[124:136], fmt.Sprintf("%011o\x00", size))
+	copy(block[136:148], "00000000000\x00")
+	block[156] = typeflag
+	copy(block[257:265], "ustar\x0000")
+
+	copy(block[148:156], "        ")
+	var sum int
+	for _, b := range block {
+		sum += int(b)
+	}
+	copy(block[148:156], fmt.Sprintf("%06o\x00 ", sum))
+	return block
+}
+
+// padTarBlock pads the data to a whole number of tar blocks.
+func padTarBlock(data string) []byte {
+	padded := []byte(data)
+	if rest := len(padded) % 512; rest != 0 {
+		padded = append(padded, make([]byte, 512-rest)...)
+	}
+	return padded
+}
+
+// paxRecord encodes a PAX record, which is prefixed by its own length.
+func paxRecord(key, value string) string {
+	record := " " + key + "=" + value + "\n"
+	size := len(record)
+	for len(fmt.Sprint(size))+len(record) != size {
+		size = len(fmt.Sprint(size)) + len(record)
+	}
+	return fmt.Sprint(size) + record
+}
+
+func TestProcessLayerTarSparseFile(t *testing.T) {
+	records := paxRecord("GNU.sparse.major", "1") +
+		paxRecord("GNU.sparse.minor", "0") +
+		paxRecord("GNU.sparse.name", "var/lib/disk.img") +
+		paxRecord("GNU.sparse.realsize", "1048576")
+	// a single data region of 4 bytes at an offset of 8 KiB (the sparse map precedes the data, padded to a block)
+	data := string(padTarBlock("1\n8192\n4\n")) + "data"
+
+	var layer bytes.Buffer
+	layer.Write(rawTarHeader("PaxHeaders/disk.img", 'x', len(records)))
+	layer.Write(padTarBlock(records))
+	layer.Write(rawTarHeader("GNUSparseFile.0/disk.img", '0', len(data)))
+	layer.Write(padTarBlock(data))
+	layer.Write(make([]byte, 1024))
+
+	tree, err := (layerParser{}).processLayerTar("layer.tar", &layer, nil)
+	if err != nil {
+		t.Fatalf("unable to process the layer: %v", err)
+	}
+	node, err := tree.GetNode("/var/lib/disk.img")
+	if err != nil || node == nil {
+		t.Fatalf("expected the sparse file to be in the tree:\n%s", tree.String(false))
+	}
+
+	info := node.Data.FileInfo
+	if !info.Sparse {
+		t.Errorf("expected the file to be sparse")
+	}
+	if info.Size != 1048576 {
+		t.Errorf("expected a logical size of 1048576 bytes, got %d", info.Size)
+	}
+	if info.StoredSize != 4 {
+		t.Errorf("expected the 4 bytes of the data region to be stored, got %d", info.StoredSize)
+	}
+	if len(info.PAXRecords) != 0 {
+		t.Errorf("expected the sparse records not to be listed, got %v", info.PAXRecords)
+	}
+	if fields := info.Attributes(filetree.IDMapping{}).Fields; len(fields) != 1 || !strings.HasPrefix(fields[0].Value, "1.0 MB logical") {
+		t.Errorf("expected the sparse sizes to be shown, got %+v", fields)
+	}
+}
+
+func TestReadImageArchive_Progress(t *testing.T) {
+	contents, err := ioutil.ReadFile("../../../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	var events []image.ProgressEvent
+	ctx := image.WithProgress(context.Background(), func(event image.ProgressEvent) {
+		events = append(events, event)
+	})
+	archive, err := ReadImageArchive(ctx, bytes.NewReader(contents), uint64(len(contents)), image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to read the archive: %v", err)
+	}
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert the archive: %v", err)
+	}
+
+	last := events[len(events)-1]
+	if !last.Done || last.BytesRead != uint64(len(contents)) || last.BytesTotal != uint64(len(contents)) || last.LayersDone != len(img.Layers) {
+		t.Errorf("unexpected last event: %+v", last)
+	}
+	var files int
+	for _, tree := range img.Trees {
+		files += tree.Size
+	}
+	if last.FilesParsed != files {
+		t.Errorf("expected %d files parsed, got %d", files, last.FilesParsed)
+	}
+}
+
+func TestProcessLayerTarMalformedEntries(t *testing.T) {
+	garbage := bytes.Repeat([]byte("x"), 512)
+	records := paxRecord("comment", "built by hand")
+
+	var layer bytes.Buffer
+	layer.Write(rawTarHeader("etc/ok", '0', 2))
+	layer.Write(padTarBlock("ok"))
+	layer.Write(rawTarHeader("../../etc/passwd", '0', 4))
+	layer.Write(padTarBlock("root"))
+	layer.Write(rawTarHeader("pax_global_header", 'g', len(records)))
+	layer.Write(padTarBlock(records))
+	layer.Write(rawTarHeader("etc/after-global", '0', 0))
+	layer.Write(garbage)
+	layer.Write(rawTarHeader("etc/unreachable", '0', 0))
+	layer.Write(make([]byte, 1024))
+
+	warnings := &image.ParseWarnings{}
+	tree, err := (layerParser{}).processLayerTar("layer.tar", &layer, warnings)
+	if err != nil {
+		t.Fatalf("unable to process the layer: %v", err)
+	}
+	for _, expected := range []string{"/etc/ok", "/etc/after-global"} {
+		if node, err := tree.GetNode(expected); err != nil || node == nil {
+			t.Errorf("expected %q to be in the tree:\n%s", expected, tree.String(false))
+		}
+	}
+	if node, _ := tree.GetNode("/etc/unreachable"); node != nil {
+		t.Errorf("did not expect the entries past the malformed header to be read")
+	}
+	if expected := "1 malformed header, 1 path traversal, 1 unsupported entry"; warnings.Summary() != expected {
+		t.Errorf("expected the warnings %q, got %q (%v)", expected, warnings.Summary(), warnings.Warnings)
+	}
+	if warnings.Warnings[0].Name != "../../etc/passwd" {
+		t.Errorf("expected the skipped name to be kept as given, got %q", warnings.Warnings[0].Name)
+	}
+
+	// the size of the entry is encoded in base 256, as it does not fit the octal field
+	var absurd bytes.Buffer
+	writer := tar.NewWriter(&absurd)
+	if err := writer.WriteHeader(&tar.Header{Name: "var/huge", Typeflag: tar.TypeReg, Size: 2 << 40, Format: tar.FormatGNU}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	warnings = &image.ParseWarnings{}
+	if _, err := (layerParser{}).processLayerTar("layer.tar", bytes.NewReader(absurd.Bytes()), warnings); !errors.Is(err, errLayerTruncated) {
+		t.Errorf("expected the layer to end within the skipped entry, got %v", err)
+	}
+	if warnings.Counts[image.ParseWarningSize] != 1 {
+		t.Errorf("expected the entry to be skipped for its size, got %v", warnings.Warnings)
+	}
+
+	if _, err := (layerParser{}).processLayerTar("layer.tar", bytes.NewReader(garbage), warnings); err == nil {
+		t.Errorf("expected an error for a blob that is not a tar")
+	}
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// errLayerTruncated marks a layer tar that ends early (or whose compressed stream is corrupt): the entries read until
+// then are still returned.
+var errLayerTruncated = errors.New("the layer tar is truncated")
+
+// layerIntegrity is the outcome of verifying a layer blob while it is read.
+type layerIntegrity struct {
+	// the digests of the blob and of the layer tar within it as read (empty when not computed)
+	digest string
+	diffID string
+	// the problems found reading the blob (a truncated tar, a blob digest that does not match its name)
+	problems []string
+	// the malformed entries of the layer tar that were skipped
+	warnings image.ParseWarnings
+}
+
+// isCorrupt indicates if reading a layer tar failed because its contents are incomplete or damaged (rather than because
+// they are not a layer tar at all).
+func isCorrupt(err error) bool {
+	var corruptInput flate.CorruptInputError
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, gzip.ErrChecksum) || errors.As(err, &corruptInput)
+}
+
+// digestOf formats the sha256 digest computed by the given hash.
+func digestOf(digester hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(digester.Sum(nil))
+}
+
+// expectedDigest is the digest a layer blob is stored under: the name of a registry blob, or the file name of a blob
+// within an OCI layout (empty for the layer tars of a docker save, which are named by their v1 layer ID).
+func expectedDigest(name string) string {
+	if isDigest(name) {
+		return name
+	}
+	dir, file := path.Split(name)
+	if strings.HasSuffix(dir, "blobs/sha256/") && isDigest("sha256:"+file) {
+		return "sha256:" + file
+	}
+	return ""
+}
+
+// isDigest indicates if the value is a sha256 digest (the placeholder digests of some tools are not verified).
+func isDigest(value string) bool {
+	if !strings.HasPrefix(value, "sha256:") || len(value) != len("sha256:")+sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(strings.TrimPrefix(value, "sha256:"))
+	return err == nil
+}
+
+// verify reads the remainder of the blob (and of the layer tar within it) so the digests cover their whole contents,
+// then checks the blob digest against the digest the blob is named by. The layer tar digest is checked against the
+// diffID of the image config once the blob is matched to a layer (see corruption).
+func (i *layerIntegrity) verify(name string, blob, layerTar io.Reader, blobDigest, tarDigest hash.Hash) {
+	if len(i.problems) > 0 {
+		// the digests of a truncated blob are meaningless
+		return
+	}
+	if _, err := io.Copy(ioutil.Discard, layerTar); err != nil {
+		i.problems = append(i.problems, fmt.Sprintf("unable to read the rest of the layer tar: %v", err))
+		return
+	}
+	if _, err := io.Copy(ioutil.Discard, blob); err != nil {
+		i.problems = append(i.problems, fmt.Sprintf("unable to read the rest of the blob: %v", err))
+		return
+	}
+
+	i.digest, i.diffID = digestOf(blobDigest), digestOf(tarDigest)
+	if expected := expectedDigest(name); expected != "" && expected != i.digest {
+		i.problems = append(i.problems, fmt.Sprintf("the blob digest %s does not match %s", i.digest, expected))
+	}
+}
+
+// corruption lists the problems found with a layer blob, including a layer tar that does not match the diffID the
+// image config lists for the layer.
+func (i layerIntegrity) corruption(diffID string) []string {
+	problems := append([]string(nil), i.problems...)
+	if len(problems) == 0 && i.diffID != "" && isDigest(diffID) && i.diffID != diffID {
+		problems = append(problems, fmt.Sprintf("the layer tar digest %s does not match the diffID %s", i.diffID, diffID))
+	}
+	return problems
+}
+
+// parseWarnings returns the malformed entries skipped while parsing the layer tar (nil when there were none).
+func (i layerIntegrity) parseWarnings() *image.ParseWarnings {
+	if i.warnings.Len() == 0 {
+		return nil
+	}
+	warnings := i.warnings
+	return &warnings
+}
@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func sha256Digest(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeCorruptArchive writes an OCI layout archive with an intact layer, a truncated layer, a layer that does not match
+// its diffID and a blob that does not match its digest.
+func writeCorruptArchive(t *testing.T) string {
+	intact := tarBytes(t, "etc/os-release")
+	// the tar ends within the contents of its second file
+	truncated := tarBytes(t, "etc/one", "etc/two")[:3*512+2]
+	mismatched := tarBytes(t, "app/main")
+	damaged := tarBytes(t, "app/lib")
+
+	blobName := func(digest string) string {
+		return "blobs/sha256/" + strings.TrimPrefix(digest, "sha256:")
+	}
+	names := []string{
+		blobName(sha256Digest(gzipBytes(t, intact))),
+		blobName(sha256Digest(truncated)),
+		blobName(sha256Digest(mismatched)),
+		blobName(sha256Digest([]byte("other"))),
+	}
+	diffIDs := []string{sha256Digest(intact), sha256Digest(tarBytes(t, "etc/one", "etc/two")), sha256Digest([]byte("other")), sha256Digest(damaged)}
+
+	files := map[string][]byte{
+		"manifest.json": []byte(fmt.Sprintf(`[{"Config":"blobs/sha256/config","Layers":["%s"]}]`, strings.Join(names, `","`))),
+		"blobs/sha256/config": []byte(fmt.Sprintf(`{"history":[{"created_by":"ADD rootfs.tar /"},{"created_by":"COPY . /etc"},{"created_by":"COPY app /app"},{"created_by":"COPY lib /app"}],`+
+			`"rootfs":{"type":"layers","diff_ids":["%s"]}}`, strings.Join(diffIDs, `","`))),
+		names[0]: gzipBytes(t, intact),
+		names[1]: truncated,
+		names[2]: mismatched,
+		names[3]: damaged,
+	}
+	return writeArchive(t, files, append(names, "blobs/sha256/config", "manifest.json"))
+}
+
+func checkCorruption(t *testing.T, name string, layers []*image.Layer) {
+	expected := []string{"", "the layer tar is truncated after 1 entries", "does not match the diffID", "does not match " + sha256Digest([]byte("other"))}
+	for idx, layer := range layers {
+		problems := strings.Join(layer.Corruption, "; ")
+		if expected[idx] == "" && problems != "" || !strings.Contains(problems, expected[idx]) {
+			t.Errorf("%s layer %d: expected the problem %q, got %q", name, idx, expected[idx], problems)
+		}
+	}
+	if node, err := layers[1].Tree.GetNode("/etc/one"); err != nil || node == nil {
+		t.Errorf("%s: expected the entries before the end of the truncated layer to be kept", name)
+	}
+}
+
+func TestLayerIntegrity(t *testing.T) {
+	path := writeCorruptArchive(t)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open archive: %v", err)
+	}
+	defer file.Close()
+
+	archive, err := NewImageArchive(file)
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	eager, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	checkCorruption(t, "eager", eager.Layers)
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	for idx := range lazy.Layers {
+		if _, err := lazy.LoadTree(idx); err != nil {
+			t.Fatalf("layer %d: unable to load lazy tree: %v", idx, err)
+		}
+	}
+	checkCorruption(t, "lazy", lazy.Layers)
+}
+
+func TestLayerIntegrityTestImage(t *testing.T) {
+	archive, err := TestLoadArchive("../../../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	for _, layer := range img.Layers {
+		if len(layer.Corruption) > 0 {
+			t.Errorf("layer %d: expected an intact layer, got %v", layer.Index, layer.Corruption)
+		}
+	}
+}
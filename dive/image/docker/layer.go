@@ -1,30 +1,99 @@
 package docker
 
 import (
-	"github.com/wagoodman/dive/dive/image"
 	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
 
 	"github.com/wagoodman/dive/dive/filetree"
 )
 
+const (
+	mediaTypeLayer     = "application/vnd.docker.image.rootfs.diff.tar"
+	mediaTypeLayerGzip = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	mediaTypeLayerZstd = "application/vnd.oci.image.layer.v1.tar+zstd"
+
+	mediaTypeOCILayer     = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeOCILayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// blob describes how a layer tar is stored within an image archive.
+type blob struct {
+	size uint64
+	// the size of the blob once compressed (0 when not yet known)
+	compressedSize uint64
+	format         blobFormat
+	// the media type and digest of a layer that is not within the archive (see missingLayer)
+	mediaType string
+	digest    string
+	// why the layer contents could not be read (empty when they were)
+	unavailable string
+	// the digests of the blob as read and the problems found verifying them
+	integrity layerIntegrity
+}
+
 // Layer represents a Docker image layer and metadata
 type layer struct {
 	history historyEntry
 	index   int
+	name    string
+	blob    blob
+	builder string
+	role    string
 	tree    *filetree.FileTree
 }
 
 // String represents a layer in a columnar format.
 func (l *layer) ToLayer() *image.Layer {
-	id := strings.Split(l.tree.Name, "/")[0]
+	id := strings.Split(l.name, "/")[0]
+
+	mediaType := mediaTypeLayer
+	// an uncompressed blob is addressed by the digest of its contents (the diffID)
+	digest := l.history.ID
+	switch l.blob.format {
+	case formatGzip:
+		mediaType = mediaTypeLayerGzip
+		digest = ""
+	case formatZstd:
+		mediaType = mediaTypeLayerZstd
+		digest = ""
+	}
+	if l.blob.mediaType != "" {
+		mediaType = l.blob.mediaType
+		digest = l.blob.digest
+	}
+
+	created, err := time.Parse(time.RFC3339Nano, l.history.Created)
+	if err != nil && l.history.Created != "" {
+		logrus.Debugf("unable to parse layer creation time %q: %+v", l.history.Created, err)
+	}
+
+	command := strings.TrimPrefix(l.history.CreatedBy, "/bin/sh -c ")
+	if l.role != "" {
+		command = roleCommand(l.builder, l.role, command)
+	}
+
 	return &image.Layer{
 		Id:      id,
 		Index:   l.index,
-		Command: strings.TrimPrefix(l.history.CreatedBy, "/bin/sh -c "),
+		Command: command,
 		Size:    l.history.Size,
 		Tree:    l.tree,
 		// todo: query docker api for tags
-		Names:  []string{"(unavailable)"},
-		Digest: l.history.ID,
+		Names:          []string{"(unavailable)"},
+		Digest:         digest,
+		DiffID:         l.history.ID,
+		MediaType:      mediaType,
+		Created:        created,
+		Author:         l.history.Author,
+		Builder:        l.builder,
+		Role:           l.role,
+		BlobSize:       l.blob.size,
+		CompressedSize: l.blob.compressedSize,
+		Unavailable:    l.blob.unavailable,
+		Corruption:     l.blob.integrity.corruption(l.history.ID),
+		ParseWarnings:  l.blob.integrity.parseWarnings(),
 	}
 }
@@ -0,0 +1,245 @@
+package docker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	// layerCacheVersion is bumped whenever the cached entries change shape (or what the parser puts in the trees), so
+	// the entries of older versions are never read.
+	layerCacheVersion = 1
+	// layerCacheMemoryEntries is the most layers kept in memory (the oldest are dropped first).
+	layerCacheMemoryEntries = 64
+	// layerCacheMaxAge is how long an unused entry is kept on disk.
+	layerCacheMaxAge = 30 * 24 * time.Hour
+)
+
+// LayerCache keeps the parsed trees of layer blobs by digest (in memory and on disk), so that opening a new build of
+// an image only parses the layers that changed since the last build (see image.ResolverOptions).
+type LayerCache struct {
+	dir    string
+	lock   sync.Mutex
+	memory map[string][]byte
+	order  []string
+}
+
+// cachedLayer is an entry of the cache.
+type cachedLayer struct {
+	Tree           *filetree.TreeSnapshot
+	Size           uint64
+	CompressedSize uint64
+	Format         blobFormat
+	Digest         string
+	DiffID         string
+	Warnings       image.ParseWarnings
+}
+
+// NewLayerCache creates a cache that stores its entries within the given directory (or only in memory when empty).
+func NewLayerCache(dir string) *LayerCache {
+	return &LayerCache{dir: dir, memory: make(map[string][]byte)}
+}
+
+// DefaultLayerCacheDir is the layer cache directory within the user cache directory.
+func DefaultLayerCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "dive", "layers"), nil
+}
+
+// layerCacheKey names the entry of the blob with the given name: only blobs named by their digest can be cached. What
+// the trees hold depends on the inspectors that run while parsing them, so these are part of the key.
+func layerCacheKey(name string, inspectors []filetree.Inspector) string {
+	digest := expectedDigest(name)
+	if digest == "" {
+		return ""
+	}
+	hasher := sha256.New()
+	hasher.Write([]byte(strings.Join(append([]string{digest}, filetree.InspectorNames(inspectors)...), "\x00")))
+	return fmt.Sprintf("%s.v%d", hex.EncodeToString(hasher.Sum(nil)), layerCacheVersion)
+}
+
+func (c *LayerCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Load returns the entry stored under the key, from memory or else from disk.
+func (c *LayerCache) Load(key string) ([]byte, bool) {
+	c.lock.Lock()
+	content, exists := c.memory[key]
+	c.lock.Unlock()
+	if !exists && c.dir != "" {
+		var err error
+		content, err = ioutil.ReadFile(c.path(key))
+		if err != nil {
+			return nil, false
+		}
+		now := time.Now()
+		_ = os.Chtimes(c.path(key), now, now)
+		c.remember(key, content)
+	}
+	return content, content != nil
+}
+
+// Store keeps the entry in memory and on disk.
+func (c *LayerCache) Store(key string, content []byte) {
+	c.remember(key, content)
+
+	if c.dir == "" {
+		return
+	}
+	if err := c.write(key, content); err != nil {
+		logrus.Debugf("unable to cache the layer %s: %v", key, err)
+	}
+}
+
+// cachedLayer returns a new copy of the tree parsed from the blob with the given name, along with what was found
+// reading the blob, if the cache of the parser holds the blob.
+func (p layerParser) cachedLayer(name string) (*filetree.FileTree, blob, bool) {
+	if p.cache == nil {
+		return nil, blob{}, false
+	}
+	key := layerCacheKey(name, p.inspectors)
+	if key == "" {
+		return nil, blob{}, false
+	}
+	content, exists := p.cache.Load(key)
+	if !exists {
+		return nil, blob{}, false
+	}
+
+	var entry cachedLayer
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&entry); err != nil {
+		logrus.Debugf("unable to read the cached layer %s: %v", name, err)
+		return nil, blob{}, false
+	}
+	tree, err := entry.Tree.Tree()
+	if err != nil {
+		logrus.Debugf("unable to read the cached layer %s: %v", name, err)
+		return nil, blob{}, false
+	}
+	tree.Name = name
+
+	layerBlob := blob{size: entry.Size, compressedSize: entry.CompressedSize, format: entry.Format}
+	layerBlob.integrity.digest, layerBlob.integrity.diffID = entry.Digest, entry.DiffID
+	layerBlob.integrity.warnings = entry.Warnings
+	logrus.Debugf("reusing the cached layer %s", name)
+	return tree, layerBlob, true
+}
+
+// cacheLayer stores the tree parsed from the blob with the given name in the cache of the parser. Blobs that could not
+// be verified are not stored, since they may be parsed differently once they are complete. Failing to store a layer is
+// only logged.
+func (p layerParser) cacheLayer(name string, tree *filetree.FileTree, layerBlob blob) {
+	if p.cache == nil {
+		return
+	}
+	key := layerCacheKey(name, p.inspectors)
+	if key == "" || tree == nil || len(layerBlob.integrity.problems) > 0 || layerBlob.integrity.digest == "" {
+		return
+	}
+
+	snapshot, err := tree.Snapshot()
+	if err != nil {
+		logrus.Debugf("unable to cache the layer %s: %v", name, err)
+		return
+	}
+	entry := cachedLayer{
+		Tree:           snapshot,
+		Size:           layerBlob.size,
+		CompressedSize: layerBlob.compressedSize,
+		Format:         layerBlob.format,
+		Digest:         layerBlob.integrity.digest,
+		DiffID:         layerBlob.integrity.diffID,
+		Warnings:       layerBlob.integrity.warnings,
+	}
+	var content bytes.Buffer
+	if err := gob.NewEncoder(&content).Encode(entry); err != nil {
+		logrus.Debugf("unable to cache the layer %s: %v", name, err)
+		return
+	}
+	p.cache.Store(key, content.Bytes())
+}
+
+// remember keeps the entry in memory, dropping the oldest entries beyond layerCacheMemoryEntries.
+func (c *LayerCache) remember(key string, content []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, exists := c.memory[key]; !exists {
+		c.order = append(c.order, key)
+	}
+	c.memory[key] = content
+	for len(c.order) > layerCacheMemoryEntries {
+		delete(c.memory, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// write stores the entry on disk through a temporary file, so that concurrent runs never read a partial entry.
+func (c *LayerCache) write(key string, content []byte) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return nil
+}
+
+// Prune removes the entries on disk that were not used within layerCacheMaxAge, returning how many were removed.
+func (c *LayerCache) Prune() (int, error) {
+	if c.dir == "" {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-layerCacheMaxAge)
+	removed := 0
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
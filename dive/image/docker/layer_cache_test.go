@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// writeLayerArchive writes an OCI layout archive with the given layer blobs, named by the given digests.
+func writeLayerArchive(t *testing.T, digests []string, blobs [][]byte, diffIDs []string) string {
+	files := map[string][]byte{}
+	var names []string
+	var history []string
+	for idx, digest := range digests {
+		name := "blobs/sha256/" + strings.TrimPrefix(digest, "sha256:")
+		files[name] = blobs[idx]
+		names = append(names, name)
+		history = append(history, fmt.Sprintf(`{"created_by":"RUN step %d"}`, idx))
+	}
+	files["manifest.json"] = []byte(fmt.Sprintf(`[{"Config":"blobs/sha256/config","Layers":["%s"]}]`, strings.Join(names, `","`)))
+	files["blobs/sha256/config"] = []byte(fmt.Sprintf(`{"history":[%s],"rootfs":{"type":"layers","diff_ids":["%s"]}}`,
+		strings.Join(history, ","), strings.Join(diffIDs, `","`)))
+	return writeArchive(t, files, append(names, "blobs/sha256/config", "manifest.json"))
+}
+
+// readTestImage reads the archive with the layer cache.
+func readTestImage(t *testing.T, path string, cache image.LayerCache) *image.Image {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open archive: %v", err)
+	}
+	defer file.Close()
+
+	archive, err := ReadImageArchive(context.Background(), file, 0, image.ResolverOptions{LayerCache: cache})
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	return img
+}
+
+func TestLayerCacheReusesUnchangedLayers(t *testing.T) {
+	dir := t.TempDir()
+	base := tarBytes(t, "etc/os-release")
+	first := gzipBytes(t, base)
+	second := tarBytes(t, "app/v1")
+	img := readTestImage(t, writeLayerArchive(t,
+		[]string{sha256Digest(first), sha256Digest(second)},
+		[][]byte{first, second},
+		[]string{sha256Digest(base), sha256Digest(second)}), NewLayerCache(dir))
+	for idx, layer := range img.Layers {
+		if len(layer.Corruption) > 0 {
+			t.Fatalf("layer %d: unexpected problems %v", idx, layer.Corruption)
+		}
+	}
+
+	// a new process: the layers can only come from the disk
+	// the first blob is named by the digest of the first build but holds other contents, so finding the tree of the
+	// first build shows that it was not parsed again
+	rebuilt := gzipBytes(t, tarBytes(t, "etc/other"))
+	changed := tarBytes(t, "app/v2")
+	img = readTestImage(t, writeLayerArchive(t,
+		[]string{sha256Digest(first), sha256Digest(changed)},
+		[][]byte{rebuilt, changed},
+		[]string{sha256Digest(base), sha256Digest(changed)}), NewLayerCache(dir))
+
+	if node, err := img.Trees[0].GetNode("/etc/os-release"); err != nil || node == nil {
+		t.Errorf("expected the unchanged layer to be reused from the cache")
+	}
+	if node, err := img.Trees[1].GetNode("/app/v2"); err != nil || node == nil {
+		t.Errorf("expected the changed layer to be parsed")
+	}
+	if len(img.Layers[0].Corruption) > 0 {
+		t.Errorf("expected the cached layer to keep its verified digests, got the problems %v", img.Layers[0].Corruption)
+	}
+	if img.Layers[0].Size != img.Trees[0].FileSize || img.Trees[0].Size != 2 {
+		t.Errorf("expected the cached tree to keep its sizes, got %d bytes and %d nodes", img.Trees[0].FileSize, img.Trees[0].Size)
+	}
+}
+
+func TestLayerCacheSkipsDamagedLayers(t *testing.T) {
+	cache := NewLayerCache("")
+
+	path := writeCorruptArchive(t)
+	checkCorruption(t, "uncached", readTestImage(t, path, cache).Layers)
+
+	// the truncated layer and the blob that does not match its digest are parsed again every time
+	if len(cache.memory) != 2 {
+		t.Errorf("expected 2 cached layers, got %d", len(cache.memory))
+	}
+	checkCorruption(t, "cached", readTestImage(t, path, cache).Layers)
+}
@@ -0,0 +1,346 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// layerEntry locates a layer tar within an image archive on disk.
+type layerEntry struct {
+	offset  int64
+	size    int64
+	format  blobFormat
+	symlink bool
+	// the target of a symlinked layer tar (relative to the link)
+	link string
+}
+
+// LazyImageArchive indexes the layer tars within an image archive on disk without reading their contents, so that
+// each layer tree can be parsed when it is first needed (keeping memory low for images with many layers).
+type LazyImageArchive struct {
+	path      string
+	temporary bool
+	manifest  manifest
+	config    config
+	// the image config as stored in the archive
+	configBytes []byte
+	entries     map[string]layerEntry
+	layers      []*image.Layer
+	// the archive has the v1 per-layer metadata files
+	legacyLayout bool
+	// parses the layers loaded for the image (within the bounds of its analysis)
+	parser layerParser
+	// the IO limits the layers are read within
+	limiter *image.IOLimiter
+}
+
+// NewLazyImageArchive indexes the image archive at the given path, whose layers are parsed with the given options.
+// When temporary is set the archive is removed once the image is closed.
+func NewLazyImageArchive(ctx context.Context, path string, temporary bool, options image.ResolverOptions) (*LazyImageArchive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img := &LazyImageArchive{
+		path:      path,
+		temporary: temporary,
+		entries:   make(map[string]layerEntry),
+		parser:    newLayerParser(ctx, options),
+		limiter:   options.IO,
+	}
+
+	// the tar reader seeks over the entry contents that are not read, so only the headers and json files are read here
+	tarReader := tar.NewReader(file)
+
+	jsonFiles := make(map[string][]byte)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := header.Name
+
+		if header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch {
+		case isLegacyLayerFile(name):
+			img.legacyLayout = true
+		case strings.HasSuffix(name, ".json") || strings.HasPrefix(name, "sha256:"):
+			fileBuffer, err := ioutil.ReadAll(tarReader)
+			if err != nil {
+				return nil, err
+			}
+			jsonFiles[name] = fileBuffer
+		case isLayerBlob(name):
+			offset, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+
+			// a short (or empty) blob yields fewer magic bytes, which is fine for sniffing
+			magic := make([]byte, blobMagicLength)
+			n, err := io.ReadFull(tarReader, magic)
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, err
+			}
+			format := sniffFormat(magic[:n])
+
+			if format == formatJSON {
+				// OCI layout archives store the image config alongside the layers
+				fileBuffer, err := ioutil.ReadAll(io.MultiReader(bytes.NewReader(magic[:n]), tarReader))
+				if err != nil {
+					return nil, err
+				}
+				jsonFiles[name] = fileBuffer
+				continue
+			}
+
+			img.entries[name] = layerEntry{
+				offset:  offset,
+				size:    header.Size,
+				format:  format,
+				symlink: header.Typeflag == tar.TypeSymlink,
+				link:    header.Linkname,
+			}
+		}
+	}
+
+	img.manifest, img.config, err = readArchiveMetadata(jsonFiles)
+	if err != nil {
+		return nil, err
+	}
+	img.configBytes = jsonFiles[img.manifest.ConfigPath]
+
+	return img, nil
+}
+
+// ToImage creates an image where only the layer metadata is populated, the layer trees are parsed by LoadLayer. Until a
+// layer is loaded its size is the (possibly compressed) size of the layer tar.
+func (img *LazyImageArchive) ToImage() (*image.Image, error) {
+	names := img.manifest.LayerTarPaths
+	sizes := make([]uint64, len(names))
+	blobs := make([]blob, len(names))
+
+	for idx, name := range names {
+		entry, exists := img.entries[name]
+		if !exists {
+			missing, ok := missingLayer(img.config, img.manifest, idx)
+			if !ok {
+				return nil, fmt.Errorf("could not find '%s' in parsed layers", name)
+			}
+			blobs[idx] = missing
+			continue
+		}
+		sizes[idx] = uint64(entry.size)
+		blobs[idx] = blob{size: uint64(entry.size), format: entry.format}
+		if entry.format.isCompressed() {
+			blobs[idx].compressedSize = uint64(entry.size)
+		}
+	}
+
+	trees := make([]*filetree.FileTree, len(names))
+	img.layers = newLayers(img.config, names, sizes, blobs, trees)
+
+	return &image.Image{
+		Trees:        trees,
+		Layers:       img.layers,
+		Loader:       img,
+		Deprecations: findDeprecations(img.config, len(names), img.legacyLayout),
+		History:      newHistory(img.config, len(names)),
+		Config:       newImageConfig(img.config),
+		ID:           img.manifest.ConfigDigest,
+		// the image loader closes the archive
+		Contents: &archiveContents{archive: img},
+	}, nil
+}
+
+// LoadLayer parses the contents of the layer at the given index, along with what they tell about the layer: the size of
+// the contents, the integrity of the blob and the role of bazel layers. The layer itself is not updated (see
+// image.LoadedLayer.Apply), as the layers may be shown while the layer is loaded.
+func (img *LazyImageArchive) LoadLayer(index int) (image.LoadedLayer, error) {
+	tree, compressedSize, measured, integrity, err := img.parseTree(index, img.parser, true)
+	if err != nil {
+		return image.LoadedLayer{}, err
+	}
+
+	loaded := image.LoadedLayer{Tree: tree}
+	if img.layers == nil {
+		return loaded, nil
+	}
+
+	// only the layer being loaded is read (and only the loaded layer is updated later on)
+	layer := img.layers[index]
+	loaded.Size, loaded.CompressedSize = layer.Size, layer.CompressedSize
+	loaded.Role, loaded.Command = layer.Role, layer.Command
+	if _, exists := img.entries[img.manifest.LayerTarPaths[index]]; exists {
+		loaded.Size = tree.FileSize
+	}
+	loaded.Corruption = integrity.corruption(layer.DiffID)
+	loaded.ParseWarnings = integrity.parseWarnings()
+	if measured {
+		loaded.CompressedSize = compressedSize
+	}
+	// the roles of bazel layers are only known once the contents are parsed
+	if role := layerRole(layer.Builder, historyEntry{}, tree); layer.Role == "" && role != "" {
+		loaded.Role = role
+		loaded.Command = roleCommand(layer.Builder, role, layer.Command)
+	}
+	return loaded, nil
+}
+
+// ParseTree parses the contents of the layer at the given index without updating the image, so it is safe to call
+// while the layers are being loaded. The tree is not kept, so it is not bounded (nor does it take from the room the
+// bounds leave for the layers that are loaded).
+func (img *LazyImageArchive) ParseTree(index int) (*filetree.FileTree, error) {
+	parser := img.parser
+	parser.budget = nil
+	tree, _, _, _, err := img.parseTree(index, parser, false)
+	return tree, err
+}
+
+// parseTree parses the contents of the layer at the given index with the given parser, verifying the digests of the
+// blob as it is read. When measure is set the compressed size of layers that are stored uncompressed is measured as
+// well (measured reports if it was).
+func (img *LazyImageArchive) parseTree(index int, parser layerParser, measure bool) (tree *filetree.FileTree, compressedSize uint64, measured bool, integrity layerIntegrity, err error) {
+	if index < 0 || index >= len(img.manifest.LayerTarPaths) {
+		return nil, 0, false, integrity, fmt.Errorf("invalid layer index given: %d of %d", index, len(img.manifest.LayerTarPaths)-1)
+	}
+	name := img.manifest.LayerTarPaths[index]
+	entry, exists := img.entries[name]
+	if !exists {
+		// the contents of the layer are not within the archive (see ToImage)
+		tree = filetree.NewFileTree()
+		tree.Name = name
+		return tree, 0, false, integrity, nil
+	}
+
+	file, err := os.Open(img.path)
+	if err != nil {
+		return nil, 0, false, integrity, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(entry.offset, io.SeekStart); err != nil {
+		return nil, 0, false, integrity, err
+	}
+
+	var reader io.Reader = img.limiter.Throttle(io.LimitReader(file, entry.size))
+	blobDigest, tarDigest := sha256.New(), sha256.New()
+	if !entry.symlink {
+		reader = io.TeeReader(reader, blobDigest)
+	}
+	blobReader := reader
+	var compressed *compressionCounter
+	if entry.format.isCompressed() && !entry.symlink {
+		decompressed, err := decompress(reader, entry.format)
+		if err != nil {
+			return nil, 0, false, integrity, err
+		}
+		defer decompressed.Close()
+		reader = io.TeeReader(decompressed, tarDigest)
+	} else if !entry.symlink {
+		// the blob is the layer tar itself
+		tarDigest = blobDigest
+		if measure {
+			// the layer is stored uncompressed, so recompress it to find the size a registry would store
+			compressed = newCompressionCounter()
+			reader = io.TeeReader(reader, compressed)
+		}
+	}
+
+	tree, err = parser.readLayerTar(name, reader, &integrity)
+	if err != nil {
+		return nil, 0, false, integrity, err
+	}
+
+	if compressed != nil {
+		if compressedSize, err = drainCompressed(reader, compressed); err != nil {
+			return nil, 0, false, integrity, err
+		}
+		measured = true
+	}
+	if !entry.symlink {
+		integrity.verify(name, blobReader, reader, blobDigest, tarDigest)
+	}
+
+	return tree, compressedSize, measured, integrity, nil
+}
+
+// load parses every layer of the archive, as many at a time as the IO limits allow, into the same image archive that
+// reading the archive as a stream yields.
+func (img *LazyImageArchive) load(ctx context.Context) (*ImageArchive, error) {
+	names := img.manifest.LayerTarPaths
+	trees := make([]*filetree.FileTree, len(names))
+	blobs := make([]blob, len(names))
+
+	err := img.limiter.ForEachLayer(len(names), func(index int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, exists := img.entries[names[index]]
+		if !exists {
+			// the contents of the layer are not within the archive (see ImageArchive.ToImage)
+			return nil
+		}
+		tree, compressedSize, measured, integrity, err := img.parseTree(index, img.parser, true)
+		if err != nil {
+			return err
+		}
+		trees[index] = tree
+		blobs[index] = blob{size: uint64(entry.size), format: entry.format, compressedSize: compressedSize, integrity: integrity}
+		if entry.format.isCompressed() || !measured {
+			blobs[index].compressedSize = uint64(entry.size)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &ImageArchive{
+		manifest:     img.manifest,
+		config:       img.config,
+		layerMap:     make(map[string]*filetree.FileTree),
+		blobs:        make(map[string]blob),
+		unreadable:   make(map[string]error),
+		legacyLayout: img.legacyLayout,
+	}
+	for idx, tree := range trees {
+		if tree != nil {
+			archive.layerMap[tree.Name] = tree
+			archive.blobs[tree.Name] = blobs[idx]
+		}
+	}
+	return archive, nil
+}
+
+// Close removes the archive if it was spooled to a temporary file.
+func (img *LazyImageArchive) Close() error {
+	if img.temporary {
+		return os.Remove(img.path)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestLazyImageArchive(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	eagerArchive, err := TestLoadArchive(path)
+	if err != nil {
+		t.Fatalf("unable to load archive: %v", err)
+	}
+	eager, err := eagerArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	defer lazy.Close()
+
+	if !lazy.IsLazy() {
+		t.Fatalf("expected a lazy image")
+	}
+	if len(lazy.Layers) != len(eager.Layers) {
+		t.Fatalf("expected %d layers, got %d", len(eager.Layers), len(lazy.Layers))
+	}
+
+	for idx, tree := range lazy.Trees {
+		if tree != nil {
+			t.Fatalf("expected layer %d to be unloaded", idx)
+		}
+	}
+
+	for idx, expected := range eager.Layers {
+		actual := lazy.Layers[idx]
+		if actual.Id != expected.Id || actual.Command != expected.Command {
+			t.Errorf("layer %d: expected %s (%s), got %s (%s)", idx, expected.Id, expected.Command, actual.Id, actual.Command)
+		}
+
+		tree, err := lazy.LoadTree(idx)
+		if err != nil {
+			t.Fatalf("unable to load layer %d: %v", idx, err)
+		}
+		if tree.Size != eager.Trees[idx].Size || tree.FileSize != eager.Trees[idx].FileSize {
+			t.Errorf("layer %d: expected %d files (%d bytes), got %d files (%d bytes)", idx, eager.Trees[idx].Size, eager.Trees[idx].FileSize, tree.Size, tree.FileSize)
+		}
+		if actual.Size != expected.Size {
+			t.Errorf("layer %d: expected loaded size %d, got %d", idx, expected.Size, actual.Size)
+		}
+	}
+}
+
+func TestLazyImageArchive_Bounds(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	unbounded, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	first, err := unbounded.ParseTree(0)
+	if err != nil {
+		t.Fatalf("unable to parse layer: %v", err)
+	}
+
+	// the first layer just fits, which leaves (next to) no room for the layers after it
+	options := image.ResolverOptions{Bounds: image.AnalysisBounds{MaxFiles: first.Size}}
+	for run := 0; run < 2; run++ {
+		archive, err := NewLazyImageArchive(context.Background(), path, false, options)
+		if err != nil {
+			t.Fatalf("unable to index archive: %v", err)
+		}
+		if _, err := archive.ToImage(); err != nil {
+			t.Fatalf("unable to convert to image: %v", err)
+		}
+		// parsing the layers (e.g. to hash the duplicate files) does not take from the room of the loaded layers
+		for idx := range archive.manifest.LayerTarPaths {
+			tree, err := archive.ParseTree(idx)
+			if err != nil {
+				t.Fatalf("unable to parse layer %d: %v", idx, err)
+			}
+			if tree.Folded != 0 {
+				t.Errorf("run %d: expected the parsed layer %d to be unbounded, got %d folded", run, idx, tree.Folded)
+			}
+		}
+
+		// each image has a budget of its own
+		loaded, err := archive.LoadLayer(0)
+		if err != nil {
+			t.Fatalf("unable to load layer: %v", err)
+		}
+		if loaded.Tree.Folded != 0 || loaded.Tree.Size != first.Size {
+			t.Errorf("run %d: expected the first layer to fit, got %d files (%d folded)", run, loaded.Tree.Size, loaded.Tree.Folded)
+		}
+		var folded int
+		for idx := 1; idx < len(archive.manifest.LayerTarPaths); idx++ {
+			loaded, err = archive.LoadLayer(idx)
+			if err != nil {
+				t.Fatalf("unable to load layer %d: %v", idx, err)
+			}
+			folded += loaded.Tree.Folded
+		}
+		if folded == 0 {
+			t.Errorf("run %d: expected the layers after the first to be folded", run)
+		}
+	}
+}
+
+func TestLazyComparer(t *testing.T) {
+	lazyArchive, err := NewLazyImageArchive(context.Background(), "../../../.data/test-docker-image.tar", false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	img, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	comparer := img.Comparer()
+	if _, err := comparer.GetTree(filetree.NewTreeIndexKey(0, 2, 3, 3)); err != nil {
+		t.Fatalf("unable to get tree: %v", err)
+	}
+
+	for idx, tree := range img.Trees {
+		if idx <= 3 && tree == nil {
+			t.Errorf("expected layer %d to be loaded", idx)
+		}
+		if idx > 3 && tree != nil {
+			t.Errorf("expected layer %d to remain unloaded", idx)
+		}
+	}
+}
+
+// the UI computes the trees of a lazy image on a goroutine of its own while it shows the layers (run with -race)
+func TestLazyComparer_Concurrent(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	eagerArchive, err := TestLoadArchive(path)
+	if err != nil {
+		t.Fatalf("unable to load archive: %v", err)
+	}
+	eager, err := eagerArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	defer lazy.Close()
+
+	comparer := lazy.Comparer()
+	last := len(lazy.Layers) - 1
+	done := make(chan error)
+	go func() {
+		for idx := 0; idx <= last; idx++ {
+			if _, err := comparer.GetTree(filetree.NewTreeIndexKey(0, idx, idx, idx)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	// the layers are shown (as the layer and details panes do) while they are loaded
+	var shown uint64
+	for computing := true; computing; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unable to get tree: %v", err)
+			}
+			computing = false
+		default:
+		}
+		for _, layer := range lazy.Layers {
+			shown += layer.Size + uint64(len(layer.Command)+len(layer.Role)+len(layer.Corruption))
+			if layer.Tree != nil && layer.ParseWarnings != nil {
+				shown++
+			}
+		}
+	}
+	if shown == 0 {
+		t.Fatalf("expected the layers to be shown")
+	}
+
+	if !comparer.ApplyLoaded() {
+		t.Fatalf("expected the loaded layers to be applied")
+	}
+	for idx, expected := range eager.Layers {
+		actual := lazy.Layers[idx]
+		if actual.Tree == nil || actual.Size != expected.Size {
+			t.Errorf("layer %d: expected the loaded size %d, got %d", idx, expected.Size, actual.Size)
+		}
+	}
+	if comparer.ApplyLoaded() {
+		t.Errorf("expected the loaded layers to be applied once")
+	}
+}
+
+func TestLazyDuplicateContent(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	eagerArchive, err := TestLoadArchive(path)
+	if err != nil {
+		t.Fatalf("unable to load archive: %v", err)
+	}
+	eager, err := eagerArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	expected := eager.FindDuplicateContent(context.Background(), nil).Result()
+	if !expected.Complete() {
+		t.Fatalf("expected the duplicates of a loaded image to be complete")
+	}
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	done := make(chan *image.DuplicateContent, 2)
+	finder := lazy.FindDuplicateContent(context.Background(), image.IOLimits{Concurrency: 2}.NewLimiter())
+	finder.AddListener(func(result *image.DuplicateContent) {
+		if result.Complete() {
+			done <- result
+		}
+	})
+	actual := <-done
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected the lazy result to match the loaded image: %d duplicates (%d bytes), got %d duplicates (%d bytes)", len(expected.Duplicates), expected.WastedBytes, len(actual.Duplicates), actual.WastedBytes)
+	}
+	for idx, tree := range lazy.Trees {
+		if tree != nil {
+			t.Errorf("expected layer %d to remain unloaded", idx)
+		}
+	}
+}
+
+func TestLazyImageArchiveOpenFile(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	archive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+
+	cases := []struct {
+		layer int
+		path  string
+		size  int64
+		err   bool
+	}{
+		{layer: 1, path: "/somefile.txt", size: 6405},
+		// a hardlink is read from the file it links to
+		{layer: 0, path: "/bin/acpid", size: 1075464},
+		{layer: 0, path: "/bin", err: true},
+		{layer: 1, path: "/missing.txt", err: true},
+	}
+
+	for _, test := range cases {
+		reader, err := archive.OpenFile(test.layer, test.path)
+		if test.err {
+			if err == nil {
+				reader.Close()
+				t.Errorf("%s: expected an error", test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unable to open: %v", test.path, err)
+		}
+		size, err := io.Copy(ioutil.Discard, reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("%s: unable to read: %v", test.path, err)
+		}
+		if size != test.size {
+			t.Errorf("%s: expected %d bytes, got %d", test.path, test.size, size)
+		}
+	}
+}
+
+func TestLazyImageArchiveOpenLayerBlob(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	archive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	// the layers are stored uncompressed, so the digest of each blob is its diffID
+	for idx, layer := range img.Layers {
+		reader, mediaType, err := archive.OpenLayerBlob(idx)
+		if err != nil {
+			t.Fatalf("layer %d: unable to open: %v", idx, err)
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("layer %d: unable to read: %v", idx, err)
+		}
+		if digest := fmt.Sprintf("sha256:%x", hash.Sum(nil)); digest != layer.DiffID {
+			t.Errorf("layer %d: expected the blob digest %s, got %s", idx, layer.DiffID, digest)
+		}
+		if mediaType != "application/vnd.oci.image.layer.v1.tar" {
+			t.Errorf("layer %d: unexpected media type %q", idx, mediaType)
+		}
+	}
+	if _, _, err := archive.OpenLayerBlob(len(img.Layers)); err == nil {
+		t.Errorf("expected an error for a missing layer")
+	}
+
+	config, err := archive.ConfigBlob()
+	if err != nil {
+		t.Fatalf("unable to read the config: %v", err)
+	}
+	if digest := fmt.Sprintf("sha256:%x", sha256.Sum256(config)); digest != img.ID {
+		t.Errorf("expected the config digest %s, got %s", img.ID, digest)
+	}
+}
+
+func TestLazyImageArchiveWalkFiles(t *testing.T) {
+	path := "../../../.data/test-docker-image.tar"
+
+	archive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+
+	sizes := make(map[string]int64)
+	err = archive.WalkFiles(1, map[string]bool{"/somefile.txt": true, "/missing.txt": true}, func(filePath string, reader io.Reader) error {
+		size, err := io.Copy(ioutil.Discard, reader)
+		sizes[filePath] = size
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to walk the layer: %v", err)
+	}
+	if !reflect.DeepEqual(sizes, map[string]int64{"/somefile.txt": 6405}) {
+		t.Errorf("unexpected files read: %v", sizes)
+	}
+}
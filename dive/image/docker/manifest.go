@@ -1,21 +1,134 @@
 package docker
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"github.com/sirupsen/logrus"
+	"fmt"
+	"strings"
 )
 
 type manifest struct {
 	ConfigPath    string   `json:"Config"`
 	RepoTags      []string `json:"RepoTags"`
 	LayerTarPaths []string `json:"Layers"`
+	// layers that may not be within the archive, keyed by diffID
+	LayerSources map[string]layerSource `json:"LayerSources"`
+	// the digest of the image config, which is the image ID
+	ConfigDigest string `json:"-"`
 }
 
-func newManifest(manifestBytes []byte) manifest {
-	var manifest []manifest
-	err := json.Unmarshal(manifestBytes, &manifest)
+func newManifest(manifestBytes []byte) (manifest, error) {
+	var manifests []manifest
+	err := json.Unmarshal(manifestBytes, &manifests)
 	if err != nil {
-		logrus.Panic(err)
+		return manifest{}, fmt.Errorf("unable to parse image manifest: %v", err)
 	}
-	return manifest[0]
+	if len(manifests) == 0 {
+		return manifest{}, fmt.Errorf("image manifest has no entries")
+	}
+	return manifests[0], nil
+}
+
+// ociDescriptor references a blob within an OCI layout.
+type ociDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         uint64            `json:"size"`
+	URLs         []string          `json:"urls"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// ociDocument is either an OCI index (or docker manifest list) or an image manifest.
+type ociDocument struct {
+	Manifests   []ociDescriptor   `json:"manifests"`
+	Config      ociDescriptor     `json:"config"`
+	Layers      []ociDescriptor   `json:"layers"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// blobPath is the location of the blob with the given digest within an OCI layout.
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+// newManifestFromOCILayout derives the image manifest of an OCI archive (which has no docker manifest.json) by
+// following the index to the first image manifest. The layer descriptors are returned alongside, since the layer
+// sources are keyed by diffID (which are only known from the config, see readArchiveMetadata).
+func newManifestFromOCILayout(jsonFiles map[string][]byte) (manifest, []ociDescriptor, error) {
+	content, exists := jsonFiles["index.json"]
+	if !exists {
+		return manifest{}, nil, fmt.Errorf("could not find image manifest")
+	}
+
+	// indexes may be nested (e.g. a multi-platform index within the index of the layout)
+	for depth := 0; depth < 3; depth++ {
+		var document ociDocument
+		if err := json.Unmarshal(content, &document); err != nil {
+			return manifest{}, nil, fmt.Errorf("unable to parse OCI layout: %v", err)
+		}
+
+		if len(document.Manifests) == 0 {
+			if document.Config.Digest == "" {
+				return manifest{}, nil, fmt.Errorf("OCI layout has no image manifest")
+			}
+			result := manifest{ConfigPath: blobPath(document.Config.Digest)}
+			for _, layer := range document.Layers {
+				result.LayerTarPaths = append(result.LayerTarPaths, blobPath(layer.Digest))
+			}
+			return result, document.Layers, nil
+		}
+
+		path := blobPath(document.Manifests[0].Digest)
+		if content, exists = jsonFiles[path]; !exists {
+			return manifest{}, nil, fmt.Errorf("could not find OCI manifest '%s'", path)
+		}
+	}
+	return manifest{}, nil, fmt.Errorf("OCI layout indexes are nested too deep")
+}
+
+// readArchiveMetadata finds the manifest and config among the json files of an image archive, which is either a docker
+// archive (with a manifest.json) or an OCI archive (with an index.json).
+func readArchiveMetadata(jsonFiles map[string][]byte) (manifest, config, error) {
+	var m manifest
+	var layers []ociDescriptor
+	var err error
+
+	if manifestContent, exists := jsonFiles["manifest.json"]; exists {
+		m, err = newManifest(manifestContent)
+	} else {
+		m, layers, err = newManifestFromOCILayout(jsonFiles)
+	}
+	if err != nil {
+		return manifest{}, config{}, err
+	}
+
+	configContent, exists := jsonFiles[m.ConfigPath]
+	if !exists {
+		return manifest{}, config{}, fmt.Errorf("could not find image config")
+	}
+
+	cfg, err := newConfig(configContent)
+	if err != nil {
+		return manifest{}, config{}, err
+	}
+	configDigest := sha256.Sum256(configContent)
+	m.ConfigDigest = "sha256:" + hex.EncodeToString(configDigest[:])
+
+	if len(layers) > 0 {
+		m.LayerSources = make(map[string]layerSource)
+		for idx, layer := range layers {
+			if idx < len(cfg.RootFs.DiffIds) {
+				m.LayerSources[cfg.RootFs.DiffIds[idx]] = layerSource{
+					MediaType: layer.MediaType,
+					Size:      layer.Size,
+					Digest:    layer.Digest,
+					URLs:      layer.URLs,
+				}
+			}
+		}
+	}
+
+	return m, cfg, nil
 }
@@ -0,0 +1,147 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	// the annotations BuildKit sets on the attestation manifests within an image index
+	annotationReferenceType      = "vnd.docker.reference.type"
+	annotationReferenceDigest    = "vnd.docker.reference.digest"
+	referenceAttestationManifest = "attestation-manifest"
+	// the predicate type of the in-toto statement in an attestation layer (BuildKit and the referrers of sigstore)
+	annotationInTotoPredicateType   = "in-toto.io/predicate-type"
+	annotationSigstorePredicateType = "dev.sigstore.bundle.predicateType"
+	// the predicate type of the layers of cosign attestation tags
+	annotationCosignPredicateType = "predicateType"
+)
+
+// the tags cosign pushes the artifacts of an image under (after "sha256-<hex>") and the kind of their layers
+var cosignTagSuffixes = []struct {
+	suffix string
+	kind   string
+}{
+	{suffix: ".sig", kind: image.AttestationSignature},
+	{suffix: ".att", kind: ""},
+	{suffix: ".sbom", kind: image.AttestationSBOM},
+}
+
+// fetchDocument reads and parses a manifest (or index) of the repository.
+func (c *registryClient) fetchDocument(ctx context.Context, path string, accept ...string) (ociDocument, error) {
+	var document ociDocument
+	response, err := c.get(ctx, path, accept...)
+	if err != nil {
+		return document, err
+	}
+	content, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return document, err
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return document, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	return document, nil
+}
+
+// fetchAttestations looks up the artifacts attached to the image manifest: the attestation manifests BuildKit adds
+// to the image index, the referrers of the manifest (with the OCI referrers API, or the tag schema as fallback) and
+// the tags cosign pushes. The lookups are best effort, artifacts that cannot be read are logged and left out.
+func fetchAttestations(ctx context.Context, client *registryClient, manifest resolvedManifest) *image.Attestations {
+	attestations := &image.Attestations{Annotations: manifest.document.Annotations}
+
+	for _, entry := range manifest.index {
+		if entry.Annotations[annotationReferenceType] != referenceAttestationManifest || entry.Annotations[annotationReferenceDigest] != manifest.digest {
+			continue
+		}
+		document, err := client.fetchDocument(ctx, "manifests/"+entry.Digest, mediaTypeOCIManifest, mediaTypeManifest)
+		if err != nil {
+			logrus.Debugf("unable to read the attestation manifest %s: %+v", entry.Digest, err)
+			continue
+		}
+		for _, layer := range document.Layers {
+			predicate := layer.Annotations[annotationInTotoPredicateType]
+			attestations.Artifacts = append(attestations.Artifacts, image.Attestation{
+				Kind:          image.ClassifyAttestation(layer.MediaType, predicate),
+				ArtifactType:  layer.MediaType,
+				PredicateType: predicate,
+				Digest:        layer.Digest,
+				Size:          layer.Size,
+				Source:        image.AttachedInIndex,
+				Annotations:   layer.Annotations,
+			})
+		}
+	}
+
+	for _, referrer := range client.fetchReferrers(ctx, manifest.digest) {
+		predicate := referrer.Annotations[annotationSigstorePredicateType]
+		if predicate == "" {
+			predicate = referrer.Annotations[annotationInTotoPredicateType]
+		}
+		artifactType := referrer.ArtifactType
+		if artifactType == "" {
+			artifactType = referrer.MediaType
+		}
+		attestations.Artifacts = append(attestations.Artifacts, image.Attestation{
+			Kind:          image.ClassifyAttestation(artifactType, predicate),
+			ArtifactType:  artifactType,
+			PredicateType: predicate,
+			Digest:        referrer.Digest,
+			Size:          referrer.Size,
+			Source:        image.AttachedByReferrers,
+			Annotations:   referrer.Annotations,
+		})
+	}
+
+	for _, tag := range cosignTagSuffixes {
+		path := "manifests/" + digestTag(manifest.digest) + tag.suffix
+		document, err := client.fetchDocument(ctx, path, mediaTypeOCIManifest, mediaTypeManifest)
+		if err != nil {
+			logrus.Debugf("no %s artifacts: %+v", tag.suffix, err)
+			continue
+		}
+		for _, layer := range document.Layers {
+			kind, predicate := tag.kind, layer.Annotations[annotationCosignPredicateType]
+			if kind == "" {
+				kind = image.ClassifyAttestation(layer.MediaType, predicate)
+			}
+			attestations.Artifacts = append(attestations.Artifacts, image.Attestation{
+				Kind:          kind,
+				ArtifactType:  layer.MediaType,
+				PredicateType: predicate,
+				Digest:        layer.Digest,
+				Size:          layer.Size,
+				Source:        image.AttachedByTag,
+				Annotations:   layer.Annotations,
+			})
+		}
+	}
+	return attestations
+}
+
+// fetchReferrers lists the manifests referring to the given manifest digest, from the OCI referrers API or, for
+// registries without it, the index pushed under the referrers tag.
+func (c *registryClient) fetchReferrers(ctx context.Context, digest string) []ociDescriptor {
+	index, err := c.fetchDocument(ctx, "referrers/"+digest, mediaTypeOCIIndex)
+	if err != nil {
+		logrus.Debugf("no referrers API: %+v", err)
+		index, err = c.fetchDocument(ctx, "manifests/"+digestTag(digest), mediaTypeOCIIndex)
+		if err != nil {
+			logrus.Debugf("no referrers tag: %+v", err)
+			return nil
+		}
+	}
+	return index.Manifests
+}
+
+// digestTag returns the tag artifacts of a manifest are pushed under without the referrers API ("sha256-<hex>").
+func digestTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1)
+}
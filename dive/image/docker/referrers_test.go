@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestFetchAttestations(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/org/app/manifests/sha256:attestations":
+			w.Write([]byte(`{"layers":[` +
+				`{"mediaType":"application/vnd.in-toto+json","digest":"sha256:slsa","size":10,"annotations":{"in-toto.io/predicate-type":"https://slsa.dev/provenance/v0.2"}},` +
+				`{"mediaType":"application/vnd.in-toto+json","digest":"sha256:spdx","size":20,"annotations":{"in-toto.io/predicate-type":"https://spdx.dev/Document"}}]}`))
+		case "/v2/org/app/manifests/sha256-image":
+			// the referrers tag schema, as the registry has no referrers API
+			w.Write([]byte(`{"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/vnd.dev.sigstore.bundle.v0.3+json",` +
+				`"digest":"sha256:bundle","size":30,"annotations":{"dev.sigstore.bundle.predicateType":"https://sigstore.dev/cosign/sign/v1"}}]}`))
+		case "/v2/org/app/manifests/sha256-image.sig":
+			w.Write([]byte(`{"layers":[{"mediaType":"application/vnd.dev.cosign.simplesigning.v1+json","digest":"sha256:sig","size":40}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ref, err := ParseRemoteReference(strings.TrimPrefix(server.URL, "http://") + "/org/app")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	manifest := resolvedManifest{
+		document: ociDocument{Annotations: map[string]string{"org.opencontainers.image.source": "https://github.com/org/app"}},
+		digest:   "sha256:image",
+		index: []ociDescriptor{
+			{Digest: "sha256:image"},
+			{Digest: "sha256:attestations", Annotations: map[string]string{annotationReferenceType: referenceAttestationManifest, annotationReferenceDigest: "sha256:image"}},
+			{Digest: "sha256:unrelated", Annotations: map[string]string{annotationReferenceType: referenceAttestationManifest, annotationReferenceDigest: "sha256:other"}},
+		},
+	}
+
+	client, err := newRegistryClient(ref, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	attestations := fetchAttestations(context.Background(), client, manifest)
+
+	if attestations.Annotations["org.opencontainers.image.source"] != "https://github.com/org/app" {
+		t.Errorf("expected the manifest annotations, got %+v", attestations.Annotations)
+	}
+	expected := []struct {
+		kind, digest, source string
+	}{
+		{kind: image.AttestationProvenance, digest: "sha256:slsa", source: image.AttachedInIndex},
+		{kind: image.AttestationSBOM, digest: "sha256:spdx", source: image.AttachedInIndex},
+		{kind: image.AttestationSignature, digest: "sha256:bundle", source: image.AttachedByReferrers},
+		{kind: image.AttestationSignature, digest: "sha256:sig", source: image.AttachedByTag},
+	}
+	if len(attestations.Artifacts) != len(expected) {
+		t.Fatalf("expected %d artifacts, got %+v", len(expected), attestations.Artifacts)
+	}
+	for idx, artifact := range attestations.Artifacts {
+		if artifact.Kind != expected[idx].kind || artifact.Digest != expected[idx].digest || artifact.Source != expected[idx].source {
+			t.Errorf("artifact %d: expected %+v, got %+v", idx, expected[idx], artifact)
+		}
+	}
+}
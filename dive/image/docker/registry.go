@@ -0,0 +1,662 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+)
+
+// RemoteReference names an image within a registry.
+type RemoteReference struct {
+	// the registry host (e.g. "registry-1.docker.io" or "ghcr.io")
+	Registry   string
+	Repository string
+	// the tag, or the digest (e.g. "sha256:...") when the reference is pinned to one
+	Tag string
+}
+
+// String renders the reference as it would be given to docker pull.
+func (ref RemoteReference) String() string {
+	registry := ref.Registry
+	if registry == dockerHubRegistry {
+		registry = dockerHubDomain
+	}
+	if strings.Contains(ref.Tag, ":") {
+		return registry + "/" + ref.Repository + "@" + ref.Tag
+	}
+	return registry + "/" + ref.Repository + ":" + ref.Tag
+}
+
+// ParseRemoteReference parses an image name the way docker pull does: the registry defaults to Docker Hub (where
+// single component repositories are official images under "library/") and the tag defaults to "latest".
+func ParseRemoteReference(name string) (RemoteReference, error) {
+	var ref RemoteReference
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ref, fmt.Errorf("no image name given")
+	}
+
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		ref.Tag = name[idx+1:]
+		name = name[:idx]
+		if !strings.Contains(ref.Tag, ":") {
+			return ref, fmt.Errorf("invalid digest %q", ref.Tag)
+		}
+	}
+	// a colon after the last slash separates the tag (a colon before it is the registry port)
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		if ref.Tag == "" {
+			ref.Tag = name[idx+1:]
+		}
+		name = name[:idx]
+	}
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Registry, ref.Repository = dockerHubDomain, name
+	}
+	if ref.Registry == dockerHubDomain || ref.Registry == "index.docker.io" {
+		ref.Registry = dockerHubRegistry
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return ref, fmt.Errorf("invalid repository name %q", name)
+	}
+	return ref, nil
+}
+
+// registryCredentials are the credentials to authenticate to a registry with (empty for anonymous access).
+type registryCredentials struct {
+	username string
+	password string
+	// an identity token, exchanged for an access token by the token server
+	identityToken string
+}
+
+// registryClient reads manifests and blobs of a repository with the registry HTTP API (v2), authenticating with a
+// bearer token (or basic auth) when the registry asks for it.
+type registryClient struct {
+	client      *http.Client
+	ref         RemoteReference
+	scheme      string
+	credentials registryCredentials
+	// the Authorization header value, once the registry asked for one
+	authorization string
+	// the certificate of the registry is not verified, and it may not serve TLS at all (see image.RegistryOptions)
+	insecure bool
+	// the timeout and retries of the requests
+	policy image.OperationPolicy
+	// whether the registry may be reached (offline mode)
+	network image.NetworkPolicy
+}
+
+// newRegistryClient creates the client of the repository of the reference, whose requests are made with the given
+// options.
+func newRegistryClient(ref RemoteReference, resolverOptions image.ResolverOptions) (*registryClient, error) {
+	scheme := "https"
+	if host := strings.Split(ref.Registry, ":")[0]; host == "localhost" || host == "127.0.0.1" {
+		// local registries usually do not serve TLS (as docker allows for them)
+		scheme = "http"
+	}
+	options := resolverOptions.Registry
+	client, err := registryHTTPClient(ref.Registry, options, resolverOptions.Network.CA)
+	if err != nil {
+		return nil, err
+	}
+	return &registryClient{
+		client:      client,
+		ref:         ref,
+		scheme:      scheme,
+		credentials: loadRegistryCredentials(ref.Registry),
+		insecure:    options.IsInsecure(ref.Registry),
+		policy:      resolverOptions.Operation,
+		network:     resolverOptions.Network,
+	}, nil
+}
+
+// get requests a path of the repository (e.g. "manifests/latest"), authenticating once when the registry asks for it.
+// The caller closes the body of the response.
+func (c *registryClient) get(ctx context.Context, path string, accept ...string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := c.do(ctx, "fetching "+path, func() (*http.Request, error) {
+			endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", c.scheme, c.ref.Registry, c.ref.Repository, path)
+			request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(accept) > 0 {
+				request.Header.Set("Accept", strings.Join(accept, ", "))
+			}
+			if c.authorization != "" {
+				request.Header.Set("Authorization", c.authorization)
+			}
+			return request, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := response.Header.Get("WWW-Authenticate")
+			response.Body.Close()
+			if err := c.authenticate(ctx, challenge); err != nil {
+				return nil, fmt.Errorf("unable to authenticate to %s: %v", c.ref.Registry, err)
+			}
+			continue
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return nil, fmt.Errorf("unexpected response from %s for %s: %s", c.ref.Registry, path, response.Status)
+		}
+		return response, nil
+	}
+}
+
+// do sends the request built by newRequest (for every attempt, as a body is consumed by a request), retrying when the
+// request fails or the registry is temporarily unavailable (see image.OperationPolicy).
+func (c *registryClient) do(ctx context.Context, name string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	if err := c.network.RequireNetwork(name + " from " + c.ref.Registry); err != nil {
+		return nil, err
+	}
+	var response *http.Response
+	err := c.policy.Retry(ctx, name+" from "+c.ref.Registry, func(ctx context.Context) error {
+		request, err := newRequest()
+		if err != nil {
+			return image.Permanent(err)
+		}
+		response, err = c.client.Do(request.WithContext(ctx))
+		if err != nil && c.fallBackToHTTP(request, err) {
+			response, err = c.client.Do(request.WithContext(ctx))
+		}
+		if err != nil {
+			return err
+		}
+		if response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests {
+			response.Body.Close()
+			return fmt.Errorf("unexpected response: %s", response.Status)
+		}
+		return nil
+	})
+	return response, err
+}
+
+// fallBackToHTTP switches an insecure registry that answered a TLS handshake with plain http over to http, as docker
+// does for insecure registries, rewriting the failed request to be sent again.
+func (c *registryClient) fallBackToHTTP(request *http.Request, err error) bool {
+	if !c.insecure || c.scheme != "https" || request.URL.Host != c.ref.Registry || !isPlainHTTPServer(err) {
+		return false
+	}
+	logrus.Warnf("the insecure registry %s does not serve TLS, falling back to http", c.ref.Registry)
+	c.scheme = "http"
+	request.URL.Scheme = "http"
+	return true
+}
+
+// authenticate answers the challenge of the registry: a bearer token is requested from the token server the
+// challenge names (with the credentials, when there are any), or the credentials are sent as basic auth.
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.credentials.username == "" {
+			return fmt.Errorf("the registry requires credentials (docker login %s)", c.ref.Registry)
+		}
+		c.authorization = "Basic " + basicAuth(c.credentials.username, c.credentials.password)
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	if c.credentials.identityToken != "" {
+		// identity tokens are exchanged for an access token with the OAuth2 refresh token grant
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", c.credentials.identityToken)
+		query.Set("client_id", "dive")
+	} else {
+		realm.RawQuery = query.Encode()
+	}
+	response, err := c.do(ctx, "requesting a token", func() (*http.Request, error) {
+		if c.credentials.identityToken != "" {
+			request, err := http.NewRequest(http.MethodPost, realm.String(), strings.NewReader(query.Encode()))
+			if err != nil {
+				return nil, err
+			}
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return request, nil
+		}
+		request, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.credentials.username != "" {
+			request.SetBasicAuth(c.credentials.username, c.credentials.password)
+		}
+		return request, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("token server responded %s", response.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return fmt.Errorf("unable to read token: %v", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("token server returned no token")
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge splits a WWW-Authenticate header (e.g. `Bearer realm="...",service="..."`) into its scheme and
+// parameters.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	challenge = strings.TrimSpace(challenge)
+	idx := strings.Index(challenge, " ")
+	if idx < 0 {
+		return challenge, params
+	}
+	scheme, rest := challenge[:idx], challenge[idx+1:]
+	for len(rest) > 0 {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+// dockerCLIConfig is the subset of a docker CLI config.json that holds registry credentials.
+type dockerCLIConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// loadRegistryCredentials reads the credentials docker login stored for the registry, from the docker CLI config (in
+// $DOCKER_CONFIG or ~/.docker) or the credential helper it names. Anonymous access is assumed when there are none.
+func loadRegistryCredentials(registry string) registryCredentials {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return registryCredentials{}
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return registryCredentials{}
+	}
+	var cfg dockerCLIConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		logrus.Debugf("unable to parse the docker CLI config: %+v", err)
+		return registryCredentials{}
+	}
+	return cfg.credentials(registry, runCredentialHelper)
+}
+
+// the server names docker login stores the credentials of a registry under (Docker Hub has a legacy name)
+func credentialKeys(registry string) []string {
+	if registry == dockerHubRegistry {
+		return []string{"https://index.docker.io/v1/", "index.docker.io", dockerHubDomain, dockerHubRegistry}
+	}
+	return []string{registry, "https://" + registry, "http://" + registry}
+}
+
+func (cfg dockerCLIConfig) credentials(registry string, helper func(name, server string) (registryCredentials, error)) registryCredentials {
+	keys := credentialKeys(registry)
+	for _, key := range keys {
+		if name, exists := cfg.CredHelpers[key]; exists {
+			return helperCredentials(helper, name, key)
+		}
+	}
+	for _, key := range keys {
+		auth, exists := cfg.Auths[key]
+		if !exists {
+			continue
+		}
+		var credentials registryCredentials
+		credentials.identityToken = auth.IdentityToken
+		if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+			if parts := strings.SplitN(string(decoded), ":", 2); len(parts) == 2 {
+				credentials.username, credentials.password = parts[0], parts[1]
+			}
+		}
+		if credentials.username != "" || credentials.identityToken != "" {
+			return credentials
+		}
+	}
+	if cfg.CredsStore != "" {
+		return helperCredentials(helper, cfg.CredsStore, keys[0])
+	}
+	return registryCredentials{}
+}
+
+func helperCredentials(helper func(name, server string) (registryCredentials, error), name, server string) registryCredentials {
+	credentials, err := helper(name, server)
+	if err != nil {
+		logrus.Debugf("no credentials from docker-credential-%s for %s: %+v", name, server, err)
+		return registryCredentials{}
+	}
+	return credentials
+}
+
+// runCredentialHelper asks a docker credential helper (e.g. docker-credential-desktop) for the credentials of a server.
+func runCredentialHelper(name, server string) (registryCredentials, error) {
+	command := exec.Command("docker-credential-"+name, "get")
+	command.Stdin = strings.NewReader(server)
+	output, err := command.Output()
+	if err != nil {
+		return registryCredentials{}, err
+	}
+	var result struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return registryCredentials{}, err
+	}
+	// helpers return identity tokens with this placeholder user name
+	if result.Username == "<token>" {
+		return registryCredentials{identityToken: result.Secret}, nil
+	}
+	return registryCredentials{username: result.Username, password: result.Secret}, nil
+}
+
+// resolvedManifest is the image manifest a reference resolved to.
+type resolvedManifest struct {
+	document ociDocument
+	digest   string
+	// the entries of the index the manifest was picked from (empty when the reference names an image manifest)
+	index []ociDescriptor
+}
+
+// fetchManifest reads the image manifest of the reference. Manifest lists (and OCI indexes) are resolved to the
+// manifest of the platform of this machine (as docker pull does), or the first one when it has none.
+func (c *registryClient) fetchManifest(ctx context.Context) (resolvedManifest, error) {
+	reference := c.ref.Tag
+	var index []ociDescriptor
+	for depth := 0; depth < 3; depth++ {
+		response, err := c.get(ctx, "manifests/"+reference, mediaTypeManifest, mediaTypeOCIManifest, mediaTypeManifestList, mediaTypeOCIIndex)
+		if err != nil {
+			return resolvedManifest{}, err
+		}
+		content, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return resolvedManifest{}, err
+		}
+		digest := response.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			sum := sha256.Sum256(content)
+			digest = "sha256:" + hex.EncodeToString(sum[:])
+		}
+
+		var document struct {
+			ociDocument
+			Manifests []struct {
+				ociDescriptor
+				Platform struct {
+					Architecture string `json:"architecture"`
+					OS           string `json:"os"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		if err := json.Unmarshal(content, &document); err != nil {
+			return resolvedManifest{}, fmt.Errorf("unable to parse manifest: %v", err)
+		}
+		if len(document.Manifests) == 0 {
+			if document.Config.Digest == "" {
+				return resolvedManifest{}, fmt.Errorf("manifest of %s has no image config", c.ref)
+			}
+			return resolvedManifest{document: document.ociDocument, digest: digest, index: index}, nil
+		}
+
+		index = index[:0]
+		for _, entry := range document.Manifests {
+			index = append(index, entry.ociDescriptor)
+		}
+		reference = document.Manifests[0].Digest
+		for _, entry := range document.Manifests {
+			if entry.Platform.OS == "linux" && entry.Platform.Architecture == runtime.GOARCH {
+				reference = entry.Digest
+				break
+			}
+		}
+	}
+	return resolvedManifest{}, fmt.Errorf("manifest lists of %s are nested too deep", c.ref)
+}
+
+// fetchBlob reads a blob of the repository. The caller closes the reader.
+func (c *registryClient) fetchBlob(ctx context.Context, digest string) (io.ReadCloser, error) {
+	response, err := c.get(ctx, "blobs/"+digest)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// FetchRemoteImage reads the image published under the reference from its registry, without pulling it into an
+// engine. Only the manifest and config are read for layers the local image already has (matched by diffID), whose
+// trees are reused; the other layer blobs are downloaded and parsed with the given options. The ID of the image is the
+// manifest digest.
+func FetchRemoteImage(ctx context.Context, name string, local *image.Image, options image.ResolverOptions) (*image.Image, error) {
+	if err := options.Network.RequireNetwork(fmt.Sprintf("reading '%s' from its registry", name)); err != nil {
+		return nil, err
+	}
+	ref, err := ParseRemoteReference(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newRegistryClient(ref, options)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := fetchRemoteImage(ctx, client, local, newLayerParser(ctx, options))
+	return img, err
+}
+
+// FetchRegistryImage reads the image published under the reference from its registry like FetchRemoteImage
+// (downloading every layer), along with the annotations of its manifest and the artifacts attached to it (provenance,
+// SBOMs, signatures).
+func FetchRegistryImage(ctx context.Context, name string, options image.ResolverOptions) (*image.Image, error) {
+	if err := options.Network.RequireNetwork(fmt.Sprintf("reading '%s' from its registry", name)); err != nil {
+		return nil, err
+	}
+	ref, err := ParseRemoteReference(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newRegistryClient(ref, options)
+	if err != nil {
+		return nil, err
+	}
+	img, manifest, err := fetchRemoteImage(ctx, client, nil, newLayerParser(ctx, options))
+	if err != nil {
+		return nil, err
+	}
+	img.Attestations = fetchAttestations(ctx, client, manifest)
+	return img, nil
+}
+
+// fetchRemoteImage reads the image of the client reference, reusing the trees of the local layers (when given).
+func fetchRemoteImage(ctx context.Context, client *registryClient, local *image.Image, parser layerParser) (*image.Image, resolvedManifest, error) {
+	ref := client.ref
+	manifest, err := client.fetchManifest(ctx)
+	if err != nil {
+		return nil, manifest, err
+	}
+	document, manifestDigest := manifest.document, manifest.digest
+	configReader, err := client.fetchBlob(ctx, document.Config.Digest)
+	if err != nil {
+		return nil, manifest, fmt.Errorf("unable to read image config: %v", err)
+	}
+	configContent, err := ioutil.ReadAll(configReader)
+	configReader.Close()
+	if err != nil {
+		return nil, manifest, fmt.Errorf("unable to read image config: %v", err)
+	}
+	cfg, err := newConfig(configContent)
+	if err != nil {
+		return nil, manifest, err
+	}
+	if len(cfg.RootFs.DiffIds) != len(document.Layers) {
+		return nil, manifest, fmt.Errorf("the manifest of %s has %d layers, its config %d", ref, len(document.Layers), len(cfg.RootFs.DiffIds))
+	}
+
+	localTrees := make(map[string]*filetree.FileTree)
+	if local != nil {
+		for idx, layer := range local.Layers {
+			if layer.DiffID != "" && idx < len(local.Trees) && local.Trees[idx] != nil {
+				localTrees[layer.DiffID] = local.Trees[idx]
+			}
+		}
+	}
+
+	names := make([]string, len(document.Layers))
+	sizes := make([]uint64, len(document.Layers))
+	blobs := make([]blob, len(document.Layers))
+	trees := make([]*filetree.FileTree, len(document.Layers))
+	for idx, descriptor := range document.Layers {
+		names[idx] = descriptor.Digest
+		layerBlob := blob{size: descriptor.Size, mediaType: descriptor.MediaType, digest: descriptor.Digest}
+		if tree, exists := localTrees[cfg.RootFs.DiffIds[idx]]; exists {
+			layerBlob.compressedSize = descriptor.Size
+			trees[idx], blobs[idx], sizes[idx] = tree, layerBlob, tree.FileSize
+			continue
+		}
+		if image.IsAttestationMediaType(descriptor.MediaType) {
+			// a document about the image rather than files: listed, but not downloaded
+			layerBlob.unavailable = unavailableAttestation
+			trees[idx], blobs[idx] = filetree.NewFileTree(), layerBlob
+			trees[idx].Name = descriptor.Digest
+			continue
+		}
+
+		logrus.Debugf("downloading layer %d of %s (%s)", idx, ref, descriptor.Digest)
+		tree, parsed, err := fetchLayer(ctx, client, descriptor, parser)
+		if err != nil {
+			return nil, manifest, fmt.Errorf("unable to read layer %s: %v", descriptor.Digest, err)
+		}
+		layerBlob.format, layerBlob.compressedSize = parsed.format, parsed.compressedSize
+		trees[idx], blobs[idx], sizes[idx] = tree, layerBlob, tree.FileSize
+	}
+
+	return &image.Image{
+		ID:      manifestDigest,
+		Trees:   trees,
+		Layers:  newLayers(cfg, names, sizes, blobs, trees),
+		History: newHistory(cfg, len(names)),
+		Config:  newImageConfig(cfg),
+	}, manifest, nil
+}
+
+// fetchLayer downloads and parses a layer blob, sniffing its compression like the image archives do. Layers parsed
+// before are taken from the layer cache of the parser instead.
+func fetchLayer(ctx context.Context, client *registryClient, descriptor ociDescriptor, parser layerParser) (*filetree.FileTree, blob, error) {
+	if tree, layerBlob, exists := parser.cachedLayer(descriptor.Digest); exists {
+		return tree, layerBlob, nil
+	}
+
+	reader, err := client.fetchBlob(ctx, descriptor.Digest)
+	if err != nil {
+		return nil, blob{}, err
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	magic, _ := buffered.Peek(blobMagicLength)
+	format := sniffFormat(magic)
+	if format == formatJSON {
+		return nil, blob{}, fmt.Errorf("blob is not a layer")
+	}
+	tree, layerBlob, err := parser.processLayerBlob(descriptor.Digest, buffered, format, descriptor.Size)
+	if err == nil {
+		parser.cacheLayer(descriptor.Digest, tree, layerBlob)
+	}
+	return tree, layerBlob, err
+}
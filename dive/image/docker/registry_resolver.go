@@ -0,0 +1,26 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// registryResolver reads images straight from their registry, without a container engine.
+type registryResolver struct {
+	options image.ResolverOptions
+}
+
+func NewResolverFromRegistry(options image.ResolverOptions) *registryResolver {
+	return &registryResolver{options: options}
+}
+
+// Fetch downloads the image from its registry, along with the manifest annotations and attached artifacts.
+func (r *registryResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+	return FetchRegistryImage(ctx, id, r.options)
+}
+
+func (r *registryResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	return nil, fmt.Errorf("build option not supported for registry resolver")
+}
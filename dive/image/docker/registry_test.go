@@ -0,0 +1,240 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestParseRemoteReference(t *testing.T) {
+	cases := map[string]RemoteReference{
+		"alpine":                            {Registry: dockerHubRegistry, Repository: "library/alpine", Tag: "latest"},
+		"wagoodman/dive:v0.9":               {Registry: dockerHubRegistry, Repository: "wagoodman/dive", Tag: "v0.9"},
+		"docker.io/library/nginx:1.25":      {Registry: dockerHubRegistry, Repository: "library/nginx", Tag: "1.25"},
+		"ghcr.io/org/app:prod":              {Registry: "ghcr.io", Repository: "org/app", Tag: "prod"},
+		"localhost:5000/app":                {Registry: "localhost:5000", Repository: "app", Tag: "latest"},
+		"registry.example.com/app@sha256:1": {Registry: "registry.example.com", Repository: "app", Tag: "sha256:1"},
+	}
+	for name, expected := range cases {
+		actual, err := ParseRemoteReference(name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, actual)
+		}
+	}
+
+	for _, name := range []string{"", "Upper/Case", "app@latest"} {
+		if _, err := ParseRemoteReference(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`)
+	expected := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull",
+	}
+	if scheme != "Bearer" || fmt.Sprint(params) != fmt.Sprint(expected) {
+		t.Errorf("unexpected challenge: %s %+v", scheme, params)
+	}
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	var cfg dockerCLIConfig
+	content := `{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth + `"}},"credHelpers":{"gcr.io":"gcloud"},"credsStore":"desktop"}`
+	if err := json.Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatalf("unable to parse config: %v", err)
+	}
+	helper := func(name, server string) (registryCredentials, error) {
+		return registryCredentials{username: name, password: server}, nil
+	}
+
+	if actual := cfg.credentials(dockerHubRegistry, helper); actual.username != "user" || actual.password != "secret" {
+		t.Errorf("expected the Docker Hub credentials from the auths, got %+v", actual)
+	}
+	if actual := cfg.credentials("gcr.io", helper); actual.username != "gcloud" || actual.password != "gcr.io" {
+		t.Errorf("expected the credentials of the registry helper, got %+v", actual)
+	}
+	if actual := cfg.credentials("ghcr.io", helper); actual.username != "desktop" {
+		t.Errorf("expected the credentials of the credentials store, got %+v", actual)
+	}
+}
+
+func TestFetchRemoteImage(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	sharedLayer := gzipBytes(t, tarBytes(t, "bin/sh"))
+	appLayer := gzipBytes(t, tarBytes(t, "app/main", "app/config"))
+	configContent := []byte(`{"history":[{"created_by":"ADD rootfs /"},{"created_by":"/bin/sh -c #(nop) COPY dir:1 in /app"}],` +
+		`"rootfs":{"type":"layers","diff_ids":["sha256:shared","sha256:app"]}}`)
+	manifestContent, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeManifest,
+		"config":        map[string]interface{}{"mediaType": "application/vnd.docker.container.image.v1+json", "digest": "sha256:config", "size": len(configContent)},
+		"layers": []map[string]interface{}{
+			{"mediaType": mediaTypeLayerGzip, "digest": "sha256:sharedblob", "size": len(sharedLayer)},
+			{"mediaType": mediaTypeLayerGzip, "digest": "sha256:appblob", "size": len(appLayer)},
+		},
+	})
+	indexContent := []byte(`{"schemaVersion":2,"mediaType":"` + mediaTypeManifestList + `","manifests":[` +
+		`{"digest":"sha256:other","platform":{"os":"windows","architecture":"amd64"}},` +
+		`{"digest":"sha256:linux","platform":{"os":"linux","architecture":"` + runtime.GOARCH + `"}}]}`)
+
+	var requested []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:org/app:pull" {
+				t.Errorf("unexpected token scope: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"token":"abc"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requested = append(requested, strings.TrimPrefix(r.URL.Path, "/v2/org/app/"))
+		switch r.URL.Path {
+		case "/v2/org/app/manifests/latest":
+			w.Write(indexContent)
+		case "/v2/org/app/manifests/sha256:linux":
+			w.Header().Set("Docker-Content-Digest", "sha256:linux")
+			w.Write(manifestContent)
+		case "/v2/org/app/blobs/sha256:config":
+			w.Write(configContent)
+		case "/v2/org/app/blobs/sha256:appblob":
+			w.Write(appLayer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	localTree := filetree.NewFileTree()
+	if _, _, err := localTree.AddPath("/bin/sh", filetree.FileInfo{Size: 6}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	local := &image.Image{
+		Trees:  []*filetree.FileTree{localTree},
+		Layers: []*image.Layer{{Index: 0, DiffID: "sha256:shared"}},
+	}
+
+	name := strings.TrimPrefix(server.URL, "http://") + "/org/app"
+	remote, err := FetchRemoteImage(context.Background(), name, local, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to fetch remote image: %v", err)
+	}
+
+	// the shared layer is not downloaded
+	expectedRequests := []string{"manifests/latest", "manifests/sha256:linux", "blobs/sha256:config", "blobs/sha256:appblob"}
+	if fmt.Sprint(requested) != fmt.Sprint(expectedRequests) {
+		t.Errorf("expected requests %v, got %v", expectedRequests, requested)
+	}
+	if remote.ID != "sha256:linux" || len(remote.Layers) != 2 || len(remote.Trees) != 2 {
+		t.Fatalf("unexpected remote image: %+v", remote)
+	}
+	if remote.Trees[0] != localTree {
+		t.Errorf("expected the local tree of the shared layer to be reused")
+	}
+	if _, err := remote.Trees[1].GetNode("/app/config"); err != nil {
+		t.Errorf("expected the downloaded layer to be parsed: %v", err)
+	}
+	layer := remote.Layers[1]
+	if layer.Digest != "sha256:appblob" || layer.DiffID != "sha256:app" || layer.CompressedSize != uint64(len(appLayer)) || layer.Command != "#(nop) COPY dir:1 in /app" {
+		t.Errorf("unexpected layer metadata: %+v", layer)
+	}
+}
+
+func TestRegistryClient_RetriesUnavailable(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	attempts := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/v2/org/app/manifests/latest" && attempts[r.URL.Path] == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/v2/org/app/manifests/latest":
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ref, err := ParseRemoteReference(strings.TrimPrefix(server.URL, "http://") + "/org/app")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	client, err := newRegistryClient(ref, image.ResolverOptions{Operation: image.OperationPolicy{Retries: 2}})
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	response, err := client.get(context.Background(), "manifests/latest")
+	if err != nil {
+		t.Fatalf("expected the unavailable registry to be retried: %v", err)
+	}
+	response.Body.Close()
+	if attempts["/v2/org/app/manifests/latest"] != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts["/v2/org/app/manifests/latest"])
+	}
+
+	// a missing manifest is not retried
+	if _, err := client.get(context.Background(), "manifests/missing"); err == nil {
+		t.Errorf("expected an error for a missing manifest")
+	}
+	if attempts["/v2/org/app/manifests/missing"] != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts["/v2/org/app/manifests/missing"])
+	}
+}
+
+func TestRegistryClient_Offline(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	offline := image.ResolverOptions{Network: image.NetworkPolicy{Offline: true}}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	name := strings.TrimPrefix(server.URL, "http://") + "/org/app"
+	if _, err := FetchRegistryImage(context.Background(), name, offline); !errors.Is(err, image.ErrOffline) {
+		t.Errorf("expected the registry fetch to be refused offline, got %v", err)
+	}
+	ref, err := ParseRemoteReference(name)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	client, err := newRegistryClient(ref, offline)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if _, err := client.get(context.Background(), "manifests/latest"); !errors.Is(err, image.ErrOffline) {
+		t.Errorf("expected the registry request to be refused offline, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no request to reach the registry, got %d", requests)
+	}
+}
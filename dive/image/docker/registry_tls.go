@@ -0,0 +1,101 @@
+package docker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// registryCertsDirs lists the directories holding a certs.d directory per registry, as docker reads them: the
+// ca certificates (*.crt) and the client certificate and key (*.cert and *.key) of the registry.
+var registryCertsDirs = func() []string {
+	dirs := []string{"/etc/docker/certs.d"}
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return dirs
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	return append([]string{filepath.Join(dir, "certs.d")}, dirs...)
+}
+
+// registryHTTPClient builds the client used for the registry: trusting the CA certificates of the registry (and the
+// given custom CA bundle), presenting a client certificate when there is one, and not verifying the certificate of
+// insecure registries. The default client (which goes through the proxy of the environment as well) is used when none
+// of these apply.
+func registryHTTPClient(registry string, options image.RegistryOptions, ca image.CABundle) (*http.Client, error) {
+	config := &tls.Config{InsecureSkipVerify: options.IsInsecure(registry)}
+	customized := config.InsecureSkipVerify || ca.Path != ""
+
+	var caFiles []string
+	certFile, keyFile := options.CertFile, options.KeyFile
+	for _, dir := range registryCertsDirs() {
+		files, err := ioutil.ReadDir(filepath.Join(dir, registry))
+		if err != nil {
+			continue
+		}
+		names := make([]string, 0, len(files))
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			filePath := filepath.Join(dir, registry, name)
+			switch {
+			case strings.HasSuffix(name, ".crt"):
+				caFiles = append(caFiles, filePath)
+			case strings.HasSuffix(name, ".cert") && certFile == "":
+				// the key is named after the certificate (e.g. client.cert and client.key)
+				keyPath := strings.TrimSuffix(filePath, ".cert") + ".key"
+				if _, err := os.Stat(keyPath); err != nil {
+					return nil, fmt.Errorf("the client certificate %s has no key (%s)", filePath, keyPath)
+				}
+				certFile, keyFile = filePath, keyPath
+			}
+		}
+	}
+
+	if len(caFiles) > 0 {
+		var certificates [][]byte
+		for _, caFile := range caFiles {
+			contents, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read the CA certificates of %s: %v", registry, err)
+			}
+			if !x509.NewCertPool().AppendCertsFromPEM(contents) {
+				return nil, fmt.Errorf("no CA certificate found in %s", caFile)
+			}
+			certificates = append(certificates, contents)
+		}
+		config.RootCAs = ca.RootCAs(certificates...)
+		customized = true
+	}
+	if certFile != "" {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the client certificate of %s: %v", registry, err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+		customized = true
+	}
+
+	if !customized {
+		return http.DefaultClient, nil
+	}
+	return &http.Client{Transport: ca.NewHTTPTransport(config)}, nil
+}
+
+// isPlainHTTPServer indicates if the request failed because the server answered a TLS handshake with plain http.
+func isPlainHTTPServer(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "server gave HTTP response to HTTPS client")
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// writeClientCertificate writes a self-signed client certificate and its key to the directory (as client.cert and
+// client.key).
+func writeClientCertificate(t *testing.T, dir string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dive"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, "client.cert"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}))
+	writeFile(t, filepath.Join(dir, "client.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}))
+}
+
+func writeFile(t *testing.T, path string, contents []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+}
+
+// getManifest requests a manifest from the registry server over https.
+func getManifest(t *testing.T, server *httptest.Server, options image.RegistryOptions) error {
+	ref, err := ParseRemoteReference(strings.TrimPrefix(strings.TrimPrefix(server.URL, "https://"), "http://") + "/org/app")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	client, err := newRegistryClient(ref, image.ResolverOptions{Registry: options})
+	if err != nil {
+		return err
+	}
+	// the loopback registry of the test is otherwise reached over plain http
+	client.scheme = "https"
+	response, err := client.get(context.Background(), "manifests/latest")
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func TestRegistryClient_TLS(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	certsDir := t.TempDir()
+	defer func(dirs func() []string) { registryCertsDirs = dirs }(registryCertsDirs)
+	registryCertsDirs = func() []string { return []string{certsDir} }
+
+	clientCertificates := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCertificates = len(r.TLS.PeerCertificates)
+		fmt.Fprint(w, `{}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	if err := getManifest(t, server, image.RegistryOptions{}); err == nil {
+		t.Errorf("expected the self-signed certificate to be refused")
+	}
+
+	if err := getManifest(t, server, image.RegistryOptions{Insecure: []string{registry}}); err != nil {
+		t.Errorf("expected the certificate of an insecure registry not to be verified: %v", err)
+	}
+
+	// the certificates of the certs.d directory of the registry
+	writeFile(t, filepath.Join(certsDir, registry, "ca.crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	writeClientCertificate(t, filepath.Join(certsDir, registry))
+	if err := getManifest(t, server, image.RegistryOptions{}); err != nil {
+		t.Errorf("expected the CA certificate of the registry to be trusted: %v", err)
+	}
+	if clientCertificates != 1 {
+		t.Errorf("expected the client certificate to be presented, got %d certificates", clientCertificates)
+	}
+}
+
+func TestRegistryClient_InsecureFallsBackToHTTP(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	defer func(dirs func() []string) { registryCertsDirs = dirs }(registryCertsDirs)
+	registryCertsDirs = func() []string { return nil }
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	if err := getManifest(t, server, image.RegistryOptions{Insecure: []string{"*"}}); err != nil {
+		t.Errorf("expected the insecure registry to be reached over http: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"path"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	mediaTypeForeignLayer    = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+	mediaTypeOCIForeignLayer = "application/vnd.oci.image.layer.nondistributable.v1.tar+gzip"
+)
+
+// why the contents of attestation layers are not read: they hold a document about the image (see
+// image.IsAttestationMediaType)
+const unavailableAttestation = "attestation (a document about the image rather than files)"
+
+// the top level directories of a Windows layer
+const (
+	windowsFilesDir         = "Files"
+	windowsRegistryHivesDir = "Hives"
+	windowsUtilityVMDir     = "UtilityVM"
+)
+
+// layerSource describes a layer that is not (necessarily) within the archive, such as the foreign base layers of
+// Windows images that are pulled from their own URLs.
+type layerSource struct {
+	MediaType string   `json:"mediaType"`
+	Size      uint64   `json:"size"`
+	Digest    string   `json:"digest"`
+	URLs      []string `json:"urls"`
+}
+
+// missingLayer describes why the contents of a layer listed in the manifest are absent from the archive, which is only
+// expected for foreign layers and layers of media types that cannot be read. The returned blob carries the layer
+// metadata that is known regardless.
+func missingLayer(cfg config, m manifest, idx int) (blob, bool) {
+	if idx >= len(cfg.RootFs.DiffIds) {
+		return blob{}, false
+	}
+	source, exists := m.LayerSources[cfg.RootFs.DiffIds[idx]]
+	if !exists {
+		return blob{}, false
+	}
+
+	missing := blob{
+		size:           source.Size,
+		compressedSize: source.Size,
+		mediaType:      source.MediaType,
+		digest:         source.Digest,
+	}
+	switch source.MediaType {
+	case mediaTypeLayer, mediaTypeLayerGzip, mediaTypeLayerZstd, mediaTypeOCILayer, mediaTypeOCILayerGzip:
+		// a regular layer should always be within the archive
+		return blob{}, false
+	case mediaTypeForeignLayer, mediaTypeOCIForeignLayer:
+		missing.unavailable = "foreign layer (the contents are not distributed with the image)"
+	default:
+		if image.IsAttestationMediaType(source.MediaType) {
+			missing.unavailable = unavailableAttestation
+		} else {
+			missing.unavailable = "unsupported media type"
+		}
+	}
+	return missing, true
+}
+
+// isWindowsLayer indicates if the layer uses the Windows layer layout, where the filesystem is beneath "Files" and the
+// registry hives and utility VM are alongside it.
+func isWindowsLayer(fileInfos []filetree.FileInfo) bool {
+	if len(fileInfos) == 0 {
+		return false
+	}
+	hasFiles := false
+	for _, info := range fileInfos {
+		switch topLevelDir(windowsPath(info.Path)) {
+		case windowsFilesDir:
+			hasFiles = true
+		case windowsRegistryHivesDir, windowsUtilityVMDir:
+		default:
+			return false
+		}
+	}
+	return hasFiles
+}
+
+// normalizeWindowsLayer maps the entries of a Windows layer onto the container filesystem: the contents of "Files"
+// become the root (C:\), paths use forward slashes like every other layer, and the registry hives and utility VM
+// (which are not part of the container filesystem) are dropped.
+func normalizeWindowsLayer(fileInfos []filetree.FileInfo) []filetree.FileInfo {
+	if !isWindowsLayer(fileInfos) {
+		return fileInfos
+	}
+
+	normalized := make([]filetree.FileInfo, 0, len(fileInfos))
+	for _, info := range fileInfos {
+		name := windowsPath(info.Path)
+		if topLevelDir(name) != windowsFilesDir || name == windowsFilesDir {
+			continue
+		}
+		info.Path = strings.TrimPrefix(name, windowsFilesDir+"/")
+		if info.Linkname != "" {
+			info.Linkname = strings.TrimPrefix(windowsPath(info.Linkname), windowsFilesDir+"/")
+		}
+		normalized = append(normalized, info)
+	}
+	return normalized
+}
+
+// windowsPath converts a Windows path to use forward slashes.
+func windowsPath(name string) string {
+	return path.Clean(strings.ReplaceAll(name, "\\", "/"))
+}
+
+func topLevelDir(name string) string {
+	return strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)[0]
+}
@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// writeWindowsArchive writes a Windows image archive with a foreign base layer (absent from the archive) and a layer
+// in the Windows layout (with backslash paths, as written by some Windows tooling).
+func writeWindowsArchive(t *testing.T) string {
+	files := map[string][]byte{
+		"manifest.json": []byte(`[{"Config":"config.json","Layers":["base/layer.tar","app/layer.tar"],"LayerSources":{"sha256:base":{` +
+			`"mediaType":"` + mediaTypeForeignLayer + `","size":1234,"digest":"sha256:blob","urls":["https://mcr.microsoft.com/v2/blob"]}}}]`),
+		"config.json": []byte(`{"os":"windows","history":[{"created_by":"Apply image 10.0.17763.1"},{"created_by":"cmd /S /C copy app.exe C:\\\\app"}],` +
+			`"rootfs":{"type":"layers","diff_ids":["sha256:base","sha256:app"]}}`),
+		"app/layer.tar": tarBytes(t, "Files/app/app.exe", `Files\app\config.ini`, "Hives/Software_Delta", "UtilityVM/Files/boot.wim"),
+	}
+	return writeArchive(t, files, []string{"app/layer.tar", "config.json", "manifest.json"})
+}
+
+func checkForeignLayer(t *testing.T, name string, layer *image.Layer) {
+	if layer.MediaType != mediaTypeForeignLayer || layer.Digest != "sha256:blob" || layer.CompressedSize != 1234 {
+		t.Errorf("%s: expected the foreign layer metadata, got media type %q, digest %q and size %d", name, layer.MediaType, layer.Digest, layer.CompressedSize)
+	}
+	if layer.Unavailable == "" {
+		t.Errorf("%s: expected the foreign layer contents to be unavailable", name)
+	}
+}
+
+func checkWindowsLayer(t *testing.T, name string, tree *filetree.FileTree) {
+	for _, expected := range []string{"/app/app.exe", "/app/config.ini"} {
+		if node, err := tree.GetNode(expected); err != nil || node == nil {
+			t.Errorf("%s: expected %q in the layer", name, expected)
+		}
+	}
+	for _, unexpected := range []string{"/Files", "/Hives", "/UtilityVM"} {
+		if node, _ := tree.GetNode(unexpected); node != nil {
+			t.Errorf("%s: did not expect %q in the layer", name, unexpected)
+		}
+	}
+}
+
+func Test_WindowsImage(t *testing.T) {
+	path := writeWindowsArchive(t)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open archive: %v", err)
+	}
+	defer file.Close()
+
+	archive, err := NewImageArchive(file)
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	eager, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	lazyArchive, err := NewLazyImageArchive(context.Background(), path, false, image.ResolverOptions{})
+	if err != nil {
+		t.Fatalf("unable to index archive: %v", err)
+	}
+	lazy, err := lazyArchive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	if _, err := lazy.LoadTree(0); err != nil {
+		t.Fatalf("unable to load the foreign layer: %v", err)
+	}
+	lazyTree, err := lazy.LoadTree(1)
+	if err != nil {
+		t.Fatalf("unable to load lazy tree: %v", err)
+	}
+
+	checkForeignLayer(t, "eager", eager.Layers[0])
+	checkForeignLayer(t, "lazy", lazy.Layers[0])
+	checkWindowsLayer(t, "eager", eager.Trees[1])
+	checkWindowsLayer(t, "lazy", lazyTree)
+}
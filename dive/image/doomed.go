@@ -0,0 +1,100 @@
+package image
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// DoomedFile is a file written by a layer that a later layer deletes or overwrites: the layer still stores it (and
+// every pull transfers it), although the final image never shows it.
+type DoomedFile struct {
+	Path      string
+	SizeBytes uint64
+	// the layer that wrote the file
+	Layer int
+	// the later layer that deletes or overwrites the file, and how (PathDeleted or PathModified)
+	DoomedBy int
+	Change   string
+}
+
+// DoomedFiles lists the doomed files of every layer (the "added, then removed later" anti-pattern).
+type DoomedFiles struct {
+	// the doomed files of each layer, by path
+	Files []map[string]DoomedFile
+	// the bytes of the doomed files of each layer
+	Bytes []uint64
+}
+
+// FindDoomedFiles finds, for every layer, the files that a later layer deletes (with a whiteout or an opaque
+// directory) or overwrites. Layers that are not loaded yet (lazy images) neither doom files nor have their files
+// doomed.
+func FindDoomedFiles(trees []*filetree.FileTree) *DoomedFiles {
+	doomed := &DoomedFiles{Files: make([]map[string]DoomedFile, len(trees)), Bytes: make([]uint64, len(trees))}
+	for idx, tree := range trees {
+		doomed.Files[idx] = make(map[string]DoomedFile)
+		if tree == nil {
+			continue
+		}
+		err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+			if node.Data.FileInfo.IsDir || len(node.Children) > 0 || node.IsWhiteout() {
+				return nil
+			}
+			filePath := node.Path()
+			for later := idx + 1; later < len(trees); later++ {
+				change := laterChange(trees[later], filePath)
+				if change == "" {
+					continue
+				}
+				sizeBytes := uint64(node.Data.FileInfo.Size)
+				doomed.Files[idx][filePath] = DoomedFile{Path: filePath, SizeBytes: sizeBytes, Layer: idx, DoomedBy: later, Change: change}
+				doomed.Bytes[idx] += sizeBytes
+				break
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to find the doomed files of layer %d: %+v", idx, err)
+		}
+	}
+	return doomed
+}
+
+// laterChange returns how the layer tree changes a path written by an earlier layer: PathModified when it writes the
+// path again, PathDeleted when it deletes the path (or one of its parents), empty when it leaves the path alone.
+func laterChange(tree *filetree.FileTree, filePath string) string {
+	if tree == nil {
+		return ""
+	}
+	if node, err := tree.GetNode(filePath); err == nil && node != nil && node != tree.Root {
+		return PathModified
+	}
+	if deletesPath(tree, filePath) {
+		return PathDeleted
+	}
+	return ""
+}
+
+// LayerBytes returns the bytes of the doomed files of the layer.
+func (doomed *DoomedFiles) LayerBytes(layer int) uint64 {
+	if doomed == nil || layer < 0 || layer >= len(doomed.Bytes) {
+		return 0
+	}
+	return doomed.Bytes[layer]
+}
+
+// Pending returns the files visible at the given layer (as written by that layer or an earlier one) that a layer
+// after it deletes or overwrites, by path.
+func (doomed *DoomedFiles) Pending(layer int) map[string]DoomedFile {
+	pending := make(map[string]DoomedFile)
+	if doomed == nil {
+		return pending
+	}
+	for idx := 0; idx <= layer && idx < len(doomed.Files); idx++ {
+		for filePath, file := range doomed.Files[idx] {
+			if file.DoomedBy > layer {
+				pending[filePath] = file
+			}
+		}
+	}
+	return pending
+}
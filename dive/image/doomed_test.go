@@ -0,0 +1,66 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindDoomedFiles(t *testing.T) {
+	trees := make([]*filetree.FileTree, 4)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc/config", 100)
+	add(trees[0], "/bin/tool", 5000)
+	add(trees[1], "/tmp/build/archive.tar.gz", 9000)
+	add(trees[1], "/tmp/build/src/main.c", 300)
+	add(trees[1], "/etc/config", 120)
+	// the build directory is removed, and the config written again
+	add(trees[2], "/tmp/.wh.build", 0)
+	add(trees[2], "/etc/config", 130)
+	add(trees[3], "/app/run", 40)
+
+	doomed := FindDoomedFiles(trees)
+
+	expected := []map[string]DoomedFile{
+		{"/etc/config": {Path: "/etc/config", SizeBytes: 100, Layer: 0, DoomedBy: 1, Change: PathModified}},
+		{
+			"/etc/config":               {Path: "/etc/config", SizeBytes: 120, Layer: 1, DoomedBy: 2, Change: PathModified},
+			"/tmp/build/archive.tar.gz": {Path: "/tmp/build/archive.tar.gz", SizeBytes: 9000, Layer: 1, DoomedBy: 2, Change: PathDeleted},
+			"/tmp/build/src/main.c":     {Path: "/tmp/build/src/main.c", SizeBytes: 300, Layer: 1, DoomedBy: 2, Change: PathDeleted},
+		},
+		{},
+		{},
+	}
+	if !reflect.DeepEqual(doomed.Files, expected) {
+		t.Errorf("expected doomed files:\n%+v\ngot:\n%+v", expected, doomed.Files)
+	}
+	for layer, expectedBytes := range []uint64{100, 9420, 0, 0} {
+		if actual := doomed.LayerBytes(layer); actual != expectedBytes {
+			t.Errorf("layer %d: expected %d doomed bytes, got %d", layer, expectedBytes, actual)
+		}
+	}
+
+	// at layer 1 the config written by layer 0 is already replaced, the one written by layer 1 is pending
+	pending := doomed.Pending(1)
+	if len(pending) != 3 || pending["/etc/config"].Layer != 1 {
+		t.Errorf("unexpected pending files at layer 1: %+v", pending)
+	}
+	if pending := doomed.Pending(2); len(pending) != 0 {
+		t.Errorf("expected no pending files at the layer removing them, got %+v", pending)
+	}
+
+	var missing *DoomedFiles
+	if missing.LayerBytes(0) != 0 || len(missing.Pending(0)) != 0 {
+		t.Errorf("expected no doomed files without an analysis")
+	}
+}
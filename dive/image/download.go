@@ -0,0 +1,306 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// what became of a downloaded file
+const (
+	// the file is in the final image
+	DownloadPresent = "present"
+	// a later layer deleted the file, which is still stored (and pulled) with the layer that downloaded it
+	DownloadDeleted = "deleted"
+	// the download was piped to another command (e.g. "curl ... | tar -xz"), so no file was written
+	DownloadPiped = "piped"
+	// the file is not in the layer (it was deleted within the same layer, or written to a path that is not known)
+	DownloadNotKept = "not kept"
+	// the layer is not loaded yet (lazy images)
+	DownloadUnverified = "unverified"
+)
+
+// RemoteDownload is a remote URL fetched by a layer, with ADD or with curl/wget within a RUN instruction.
+type RemoteDownload struct {
+	Layer int
+	URL   string
+	// how the layer fetched the URL: "ADD", "curl" or "wget"
+	Tool string
+	// the file the download was written to, within the layer (empty when there is none)
+	Path      string
+	SizeBytes uint64
+	Status    string
+	// the layer that deleted the file (when deleted)
+	DeletedBy int
+	// why the URL is a supply-chain risk: it names no version and is not verified against a checksum, or it refers to
+	// a moving version (empty when the URL is pinned)
+	Unpinned string
+}
+
+// WastedBytes is the size of the downloaded file when a later layer deleted it.
+func (download RemoteDownload) WastedBytes() uint64 {
+	if download.Status == DownloadDeleted {
+		return download.SizeBytes
+	}
+	return 0
+}
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s"'<>|;&()\\]+`)
+	// the separators between the commands of a RUN instruction
+	shellSeparatorPattern = regexp.MustCompile(`&&|\|\||;|\||\n`)
+	// the instruction verifies what it downloaded
+	checksumPattern = regexp.MustCompile(`\b(?:sha(?:1|224|256|384|512)sum|shasum|md5sum|b2sum)\b|--checksum[= ]|\bgpg\s+(?:--batch\s+)?--verify\b|\bcosign\s+verify`)
+	// a version, commit or digest within the URL (or a build argument standing for one)
+	versionPattern = regexp.MustCompile(`\d+\.\d+|[/_.-]v\d+|\b[0-9a-f]{40}\b|sha256[:-][0-9a-f]{64}|\$\{?[A-Za-z_]*VERSION`)
+	// a version that moves as new releases are published
+	movingVersionPattern = regexp.MustCompile(`(?i)(?:^|[/_.=-])(latest|master|main|head|nightly|snapshot|stable|current)(?:$|[/_.?&#-])`)
+)
+
+// FindRemoteDownloads lists the remote URLs every layer fetched, and what became of the downloaded files in the final
+// image (a file deleted by a later layer is wasted, as the layer that downloaded it still holds it).
+func FindRemoteDownloads(layers []*Layer, trees []*filetree.FileTree) []RemoteDownload {
+	var downloads []RemoteDownload
+	for idx, layer := range layers {
+		var tree *filetree.FileTree
+		if idx < len(trees) {
+			tree = trees[idx]
+		}
+		for _, pending := range layerDownloads(layer) {
+			download := pending.RemoteDownload
+			locateDownload(&download, pending.output, layers, trees, tree)
+			downloads = append(downloads, download)
+		}
+	}
+	return downloads
+}
+
+// pendingDownload is a download found in an instruction, before it is located within the layer.
+type pendingDownload struct {
+	RemoteDownload
+	// the file written, as given in the instruction (possibly relative to the working directory)
+	output string
+}
+
+// layerDownloads finds the URLs fetched by the instruction of the layer.
+func layerDownloads(layer *Layer) []pendingDownload {
+	command := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(layer.Command), "# buildkit"))
+	command = strings.TrimSpace(strings.TrimPrefix(command, "#(nop)"))
+	verified := checksumPattern.MatchString(command)
+
+	var downloads []pendingDownload
+	if strings.HasPrefix(command, "ADD ") {
+		fields := strings.Fields(command)
+		destination := fields[len(fields)-1]
+		for _, field := range fields[1 : len(fields)-1] {
+			url := urlPattern.FindString(field)
+			if url == "" || strings.HasPrefix(field, "--") {
+				continue
+			}
+			output := destination
+			if strings.HasSuffix(destination, "/") {
+				output = destination + urlBaseName(url)
+			}
+			downloads = append(downloads, newPendingDownload(layer, url, "ADD", output, verified))
+		}
+		return downloads
+	}
+
+	command = strings.TrimSpace(strings.TrimPrefix(command, "RUN "))
+	separators := shellSeparatorPattern.FindAllStringIndex(command, -1)
+	start := 0
+	for idx := 0; idx <= len(separators); idx++ {
+		end, piped := len(command), false
+		if idx < len(separators) {
+			end = separators[idx][0]
+			piped = command[separators[idx][0]:separators[idx][1]] == "|"
+		}
+		segment := command[start:end]
+		if idx < len(separators) {
+			start = separators[idx][1]
+		}
+
+		tool := downloadTool(segment)
+		if tool == "" {
+			continue
+		}
+		for _, url := range urlPattern.FindAllString(segment, -1) {
+			output := downloadOutput(tool, strings.Fields(segment), url)
+			if output == "-" || (output == "" && piped) {
+				download := newPendingDownload(layer, url, tool, "", verified)
+				download.Status = DownloadPiped
+				downloads = append(downloads, download)
+				continue
+			}
+			downloads = append(downloads, newPendingDownload(layer, url, tool, output, verified))
+		}
+	}
+	return downloads
+}
+
+func newPendingDownload(layer *Layer, url, tool, output string, verified bool) pendingDownload {
+	url = strings.TrimRight(url, ".,")
+	download := pendingDownload{
+		RemoteDownload: RemoteDownload{Layer: layer.Index, URL: url, Tool: tool},
+		output:         output,
+	}
+	if !verified {
+		download.Unpinned = unpinnedReason(url)
+	}
+	return download
+}
+
+// downloadTool returns the tool a shell command downloads with (empty when it is not curl or wget).
+func downloadTool(segment string) string {
+	for _, field := range strings.Fields(segment) {
+		switch path.Base(field) {
+		case "curl", "wget":
+			return path.Base(field)
+		}
+	}
+	return ""
+}
+
+// downloadOutput returns the file curl or wget writes the URL to: "-" for stdout, empty when the command does not
+// write a file (curl without an output) and the downloaded file name otherwise (possibly relative).
+func downloadOutput(tool string, fields []string, url string) string {
+	var output, directory string
+	for idx, field := range fields {
+		next := ""
+		if idx+1 < len(fields) {
+			next = fields[idx+1]
+		}
+		switch {
+		case field == ">" || field == "1>":
+			output = next
+		case strings.HasPrefix(field, ">") && !strings.HasPrefix(field, ">>"):
+			output = strings.TrimPrefix(field, ">")
+		case tool == "curl" && (field == "--output" || field == "-o"):
+			output = next
+		case tool == "curl" && (field == "--remote-name" || field == "-O"):
+			output = urlBaseName(url)
+		case tool == "curl" && isShortFlags(field):
+			// combined short flags, e.g. "-fsSLo file" or "-fsSLO"
+			if strings.HasSuffix(field, "o") {
+				output = next
+			} else if strings.HasSuffix(field, "O") {
+				output = urlBaseName(url)
+			}
+		case tool == "wget" && strings.HasPrefix(field, "--output-document="):
+			output = strings.TrimPrefix(field, "--output-document=")
+		case tool == "wget" && (field == "--output-document" || field == "-O"):
+			output = next
+		case tool == "wget" && isShortFlags(field) && strings.Contains(field, "O"):
+			// combined short flags, e.g. "-qO-" or "-qO file"
+			if output = field[strings.Index(field, "O")+1:]; output == "" {
+				output = next
+			}
+		case tool == "wget" && strings.HasPrefix(field, "--directory-prefix="):
+			directory = strings.TrimPrefix(field, "--directory-prefix=")
+		case tool == "wget" && (field == "--directory-prefix" || field == "-P"):
+			directory = next
+		}
+	}
+	if output == "" && tool == "wget" {
+		output = urlBaseName(url)
+		if directory != "" {
+			output = path.Join(directory, output)
+		}
+	}
+	return output
+}
+
+func isShortFlags(field string) bool {
+	return len(field) > 1 && field[0] == '-' && field[1] != '-'
+}
+
+// urlBaseName returns the file name a URL is saved as by default.
+func urlBaseName(url string) string {
+	if idx := strings.IndexAny(url, "?#"); idx >= 0 {
+		url = url[:idx]
+	}
+	return path.Base(strings.SplitN(url, "://", 2)[1])
+}
+
+// unpinnedReason explains why the URL may serve different content over time (empty when it names a version).
+func unpinnedReason(url string) string {
+	if match := movingVersionPattern.FindStringSubmatch(url); match != nil {
+		return fmt.Sprintf("refers to a moving version (%s) and is not verified against a checksum", match[1])
+	}
+	if !versionPattern.MatchString(url) {
+		return "names no version and is not verified against a checksum"
+	}
+	return ""
+}
+
+// locateDownload finds the downloaded file within the layer and follows it up to the final image.
+func locateDownload(download *RemoteDownload, output string, layers []*Layer, trees []*filetree.FileTree, tree *filetree.FileTree) {
+	if download.Status == DownloadPiped {
+		return
+	}
+	if tree == nil {
+		download.Status = DownloadUnverified
+		return
+	}
+
+	node := findDownloadedFile(tree, output, urlBaseName(download.URL))
+	if node == nil {
+		download.Status = DownloadNotKept
+		return
+	}
+	download.Path = node.Path()
+	download.SizeBytes = uint64(node.Data.FileInfo.Size)
+
+	download.Status = DownloadPresent
+	provenance := TracePath(layers, trees, download.Path)
+	for _, change := range provenance.Changes {
+		if change.Layer > download.Layer && change.Change == PathDeleted {
+			download.Status = DownloadDeleted
+			download.DeletedBy = change.Layer
+			break
+		}
+	}
+	if download.Status == DownloadDeleted && provenance.Present {
+		// deleted, then written again by a later layer
+		download.Status = DownloadPresent
+	}
+}
+
+// findDownloadedFile finds the file written by the download within the layer tree: the given output path when it is
+// absolute, otherwise the files whose path ends with it (the working directory is not recorded).
+func findDownloadedFile(tree *filetree.FileTree, output, baseName string) *filetree.FileNode {
+	if output == "" {
+		return nil
+	}
+	if path.IsAbs(output) {
+		node, err := tree.GetNode(output)
+		if err != nil || node == nil {
+			return nil
+		}
+		if node.Data.FileInfo.IsDir {
+			// "ADD <url> /opt" and "wget -P /opt" save the file within the directory
+			if child, exists := node.Children[baseName]; exists && !child.Data.FileInfo.IsDir {
+				return child
+			}
+			return nil
+		}
+		return node
+	}
+	var candidates []*filetree.FileNode
+	suffix := "/" + strings.TrimPrefix(path.Clean(output), "/")
+	_ = tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if !node.Data.FileInfo.IsDir && strings.HasSuffix(node.Path(), suffix) {
+			candidates = append(candidates, node)
+		}
+		return nil
+	}, nil)
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Path() < candidates[j].Path() })
+	return candidates[0]
+}
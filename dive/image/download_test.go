@@ -0,0 +1,97 @@
+package image
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindRemoteDownloads(t *testing.T) {
+	commands := []string{
+		"#(nop) ADD https://example.com/tools/jq-1.7.1-linux-amd64 /usr/local/bin/jq",
+		"RUN /bin/sh -c curl -fsSLo /tmp/node.tar.gz https://nodejs.org/dist/latest/node-linux-x64.tar.gz && tar -xzf /tmp/node.tar.gz -C /opt # buildkit",
+		"RUN /bin/sh -c wget -P /opt https://example.com/app.jar && curl -sSL https://get.example.com/install.sh | sh # buildkit",
+		"wget -qO- https://example.com/releases/v2/tool.tgz | tar -xz && rm -rf /tmp/node.tar.gz",
+		"curl -fsSL -o /tmp/helm.tgz https://get.helm.sh/helm-v3.14.0-linux-amd64.tar.gz && echo \"abc  /tmp/helm.tgz\" | sha256sum -c && tar -xzf /tmp/helm.tgz && rm /tmp/helm.tgz",
+	}
+	trees := make([]*filetree.FileTree, len(commands))
+	layers := make([]*Layer, len(commands))
+	for idx, command := range commands {
+		trees[idx] = filetree.NewFileTree()
+		layers[idx] = &Layer{Index: idx, Command: command}
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64, isDir bool) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size, IsDir: isDir}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	add(trees[0], "/usr/local/bin/jq", 100, false)
+	add(trees[1], "/tmp/node.tar.gz", 300, false)
+	add(trees[2], "/opt", 0, true)
+	add(trees[2], "/opt/app.jar", 50, false)
+	add(trees[3], "/tmp/.wh.node.tar.gz", 0, false)
+
+	expected := []RemoteDownload{
+		{Layer: 0, URL: "https://example.com/tools/jq-1.7.1-linux-amd64", Tool: "ADD", Path: "/usr/local/bin/jq", SizeBytes: 100, Status: DownloadPresent},
+		{Layer: 1, URL: "https://nodejs.org/dist/latest/node-linux-x64.tar.gz", Tool: "curl", Path: "/tmp/node.tar.gz", SizeBytes: 300, Status: DownloadDeleted, DeletedBy: 3, Unpinned: "refers to a moving version (latest) and is not verified against a checksum"},
+		{Layer: 2, URL: "https://example.com/app.jar", Tool: "wget", Path: "/opt/app.jar", SizeBytes: 50, Status: DownloadPresent, Unpinned: "names no version and is not verified against a checksum"},
+		{Layer: 2, URL: "https://get.example.com/install.sh", Tool: "curl", Status: DownloadPiped, Unpinned: "names no version and is not verified against a checksum"},
+		{Layer: 3, URL: "https://example.com/releases/v2/tool.tgz", Tool: "wget", Status: DownloadPiped},
+		{Layer: 4, URL: "https://get.helm.sh/helm-v3.14.0-linux-amd64.tar.gz", Tool: "curl", Status: DownloadNotKept},
+	}
+	actual := FindRemoteDownloads(layers, trees)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected downloads:\n%+v\ngot:\n%+v", expected, actual)
+	}
+	if wasted := actual[1].WastedBytes(); wasted != 300 {
+		t.Errorf("expected 300 wasted bytes, got %d", wasted)
+	}
+
+	// lazy images have not loaded their layers yet
+	lazy := FindRemoteDownloads(layers[:1], make([]*filetree.FileTree, 1))
+	if len(lazy) != 1 || lazy[0].Status != DownloadUnverified {
+		t.Errorf("expected an unverified download, got %+v", lazy)
+	}
+}
+
+func TestDownloadOutput(t *testing.T) {
+	table := []struct {
+		tool     string
+		command  string
+		expected string
+	}{
+		{"curl", "curl -fsSL https://example.com/a.tgz", ""},
+		{"curl", "curl -fsSLO https://example.com/a.tgz?token=1", "a.tgz"},
+		{"curl", "curl --output /tmp/a.tgz https://example.com/a.tgz", "/tmp/a.tgz"},
+		{"curl", "curl -fsSL https://example.com/a.tgz > /tmp/a.tgz", "/tmp/a.tgz"},
+		{"wget", "wget https://example.com/a.tgz", "a.tgz"},
+		{"wget", "wget -q --output-document=/tmp/b.tgz https://example.com/a.tgz", "/tmp/b.tgz"},
+		{"wget", "wget -qO /tmp/b.tgz https://example.com/a.tgz", "/tmp/b.tgz"},
+		{"wget", "wget --directory-prefix=/opt https://example.com/a.tgz", "/opt/a.tgz"},
+	}
+	for _, test := range table {
+		url := urlPattern.FindString(test.command)
+		if actual := downloadOutput(test.tool, strings.Fields(test.command), url); actual != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.command, test.expected, actual)
+		}
+	}
+}
+
+func TestUnpinnedReason(t *testing.T) {
+	table := map[string]bool{
+		"https://example.com/tool-1.2.3.tgz":                   false,
+		"https://github.com/org/repo/archive/v2.tar.gz":        false,
+		"https://example.com/${TOOL_VERSION}/tool.tgz":         false,
+		"https://example.com/tool.tgz":                         true,
+		"https://github.com/org/repo/archive/main.tar.gz":      true,
+		"https://example.com/releases/latest/download/x-1.2.3": true,
+	}
+	for url, unpinned := range table {
+		if actual := unpinnedReason(url) != ""; actual != unpinned {
+			t.Errorf("%s: expected unpinned=%v, got %v", url, unpinned, actual)
+		}
+	}
+}
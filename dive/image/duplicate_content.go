@@ -0,0 +1,196 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// ContentCopy is one of the paths a file with duplicate contents is stored at.
+type ContentCopy struct {
+	Path string
+	// the index of the first layer that stored the file at this path
+	Layer int
+}
+
+// ContentDuplicate is a file whose contents are stored at more than one path within the image layers.
+type ContentDuplicate struct {
+	SizeBytes uint64
+	Copies    []ContentCopy
+}
+
+// WastedBytes is the size of every copy but one.
+func (d ContentDuplicate) WastedBytes() uint64 {
+	return d.SizeBytes * uint64(len(d.Copies)-1)
+}
+
+// DuplicateContent lists the files stored at more than one path within the image layers. While the layers are still
+// being hashed only the duplicates within the hashed layers are known (see Complete).
+type DuplicateContent struct {
+	// sorted by wasted bytes (largest first)
+	Duplicates []ContentDuplicate
+	// the bytes that keeping a single copy of each file would save
+	WastedBytes  uint64
+	LayersHashed int
+	LayersTotal  int
+}
+
+// Complete indicates if every layer has been hashed.
+func (d *DuplicateContent) Complete() bool {
+	return d.LayersHashed >= d.LayersTotal
+}
+
+// contentKey identifies file contents (the size guards against hash collisions between files of different sizes).
+type contentKey struct {
+	hash uint64
+	size int64
+}
+
+// DuplicateFinder collects the file contents of the image layers as they are hashed, so that the duplicates found so
+// far can be reported before every layer is hashed. It is safe for concurrent use.
+type DuplicateFinder struct {
+	lock      sync.Mutex
+	total     int
+	hashed    int
+	contents  map[contentKey]map[string]int
+	listeners []func(*DuplicateContent)
+}
+
+// NewDuplicateFinder creates a finder for an image with the given number of layers.
+func NewDuplicateFinder(layers int) *DuplicateFinder {
+	return &DuplicateFinder{
+		total:    layers,
+		contents: make(map[contentKey]map[string]int),
+	}
+}
+
+// AddListener registers a function that is called with the result so far, and then with the (partial) result each
+// time a layer has been hashed (from the goroutine hashing the layers).
+func (f *DuplicateFinder) AddListener(listener func(*DuplicateContent)) {
+	f.lock.Lock()
+	f.listeners = append(f.listeners, listener)
+	result := f.result()
+	f.lock.Unlock()
+
+	listener(result)
+}
+
+// Add records the regular files of the given layer tree. Files with the same contents at the same path (a file that is
+// rewritten by a later layer) are not duplicates, and empty files are ignored.
+func (f *DuplicateFinder) Add(layer int, tree *filetree.FileTree) {
+	f.lock.Lock()
+	err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		info := node.Data.FileInfo
+		if node.IsWhiteout() || info.IsDir || info.Size <= 0 {
+			return nil
+		}
+		if info.TypeFlag != tar.TypeReg && info.TypeFlag != tar.TypeRegA {
+			return nil
+		}
+		key := contentKey{hash: info.ContentHash(), size: info.Size}
+		paths, ok := f.contents[key]
+		if !ok {
+			paths = make(map[string]int)
+			f.contents[key] = paths
+		}
+		// the layers may be hashed out of order, keep the lowest layer that stored the path
+		if existing, exists := paths[node.Path()]; !exists || layer < existing {
+			paths[node.Path()] = layer
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		logrus.Errorf("unable to hash the contents of layer %d: %+v", layer, err)
+	}
+	f.hashed++
+	result := f.result()
+	listeners := f.listeners
+	f.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener(result)
+	}
+}
+
+// Result returns the duplicates found in the layers hashed so far.
+func (f *DuplicateFinder) Result() *DuplicateContent {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.result()
+}
+
+func (f *DuplicateFinder) result() *DuplicateContent {
+	result := &DuplicateContent{
+		Duplicates:   make([]ContentDuplicate, 0),
+		LayersHashed: f.hashed,
+		LayersTotal:  f.total,
+	}
+	for key, paths := range f.contents {
+		if len(paths) < 2 {
+			continue
+		}
+		duplicate := ContentDuplicate{SizeBytes: uint64(key.size)}
+		for filePath, layer := range paths {
+			duplicate.Copies = append(duplicate.Copies, ContentCopy{Path: filePath, Layer: layer})
+		}
+		sort.Slice(duplicate.Copies, func(i, j int) bool {
+			return duplicate.Copies[i].Path < duplicate.Copies[j].Path
+		})
+		result.Duplicates = append(result.Duplicates, duplicate)
+		result.WastedBytes += duplicate.WastedBytes()
+	}
+	sort.Slice(result.Duplicates, func(i, j int) bool {
+		left, right := result.Duplicates[i], result.Duplicates[j]
+		if left.WastedBytes() != right.WastedBytes() {
+			return left.WastedBytes() > right.WastedBytes()
+		}
+		return left.Copies[0].Path < right.Copies[0].Path
+	})
+	return result
+}
+
+// FindDuplicateContent hashes the layers for files stored at more than one path. The layers of an image that is fully
+// loaded are hashed before returning; the layers of a lazy image are parsed (again, without updating the image) in
+// the background, as many at a time as the limiter allows, until every layer is hashed or the context is cancelled.
+func (img *Image) FindDuplicateContent(ctx context.Context, limiter *IOLimiter) *DuplicateFinder {
+	if !img.IsLazy() {
+		finder := NewDuplicateFinder(len(img.Trees))
+		for idx, tree := range img.Trees {
+			finder.Add(idx, tree)
+		}
+		return finder
+	}
+
+	finder := NewDuplicateFinder(len(img.Layers))
+	parser, ok := img.Loader.(LayerParser)
+	if !ok {
+		logrus.Warn("the image source does not support hashing the layers in the background")
+		return finder
+	}
+	tracker := NewProgressTracker(ctx, StageHashing)
+	tracker.SetTotals(0, len(img.Layers), 0)
+	go func() {
+		defer tracker.Finish()
+		err := limiter.ForEachLayer(len(img.Layers), func(index int) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tree, err := parser.ParseTree(index)
+			if err != nil {
+				return fmt.Errorf("unable to hash the contents of layer %d: %w", index, err)
+			}
+			finder.Add(index, tree)
+			tracker.LayerDone(tree.Size)
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			logrus.Errorf("%+v", err)
+		}
+	}()
+	return finder
+}
@@ -0,0 +1,108 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// layerTree builds a layer tree from the given file contents (by path), hashing the contents as a layer tarball would.
+func layerTree(t *testing.T, files map[string]string) *filetree.FileTree {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for path, contents := range files {
+		header := &tar.Header{Name: path, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents))}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if _, err := writer.Write([]byte(contents)); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	tree := filetree.NewFileTree()
+	reader := tar.NewReader(&buf)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			break
+		}
+		info, err := filetree.NewFileInfoFromTarHeader(reader, header, "/"+header.Name, nil)
+		if err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if _, _, err := tree.AddPath(info.Path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	return tree
+}
+
+func TestDuplicateFinder(t *testing.T) {
+	trees := []*filetree.FileTree{
+		layerTree(t, map[string]string{
+			"usr/lib/libfoo.so": "the foo library",
+			"etc/config":        "settings",
+			"etc/empty":         "",
+		}),
+		layerTree(t, map[string]string{
+			"app/vendor/libfoo.so": "the foo library",
+			"app/empty":            "",
+			// rewriting a file at the same path is not a duplicate
+			"etc/config": "settings",
+		}),
+		layerTree(t, map[string]string{
+			"opt/libfoo.so":  "the foo library",
+			"app/config.bak": "settings",
+		}),
+	}
+
+	finder := NewDuplicateFinder(len(trees))
+	var progress []int
+	finder.AddListener(func(result *DuplicateContent) {
+		progress = append(progress, len(result.Duplicates))
+	})
+
+	finder.Add(0, trees[0])
+	if result := finder.Result(); len(result.Duplicates) != 0 || result.Complete() {
+		t.Fatalf("expected no duplicates within an incomplete first layer, got %+v", result)
+	}
+
+	finder.Add(1, trees[1])
+	partial := finder.Result()
+	if partial.Complete() || partial.LayersHashed != 2 || partial.LayersTotal != 3 {
+		t.Errorf("expected 2 of 3 layers hashed, got %d of %d", partial.LayersHashed, partial.LayersTotal)
+	}
+	expectedPartial := []ContentDuplicate{
+		{SizeBytes: 15, Copies: []ContentCopy{{Path: "/app/vendor/libfoo.so", Layer: 1}, {Path: "/usr/lib/libfoo.so", Layer: 0}}},
+	}
+	if !reflect.DeepEqual(partial.Duplicates, expectedPartial) {
+		t.Errorf("expected partial duplicates:\n%+v\ngot:\n%+v", expectedPartial, partial.Duplicates)
+	}
+
+	finder.Add(2, trees[2])
+	result := finder.Result()
+	if !result.Complete() {
+		t.Errorf("expected the result to be complete")
+	}
+	expected := []ContentDuplicate{
+		{SizeBytes: 15, Copies: []ContentCopy{{Path: "/app/vendor/libfoo.so", Layer: 1}, {Path: "/opt/libfoo.so", Layer: 2}, {Path: "/usr/lib/libfoo.so", Layer: 0}}},
+		{SizeBytes: 8, Copies: []ContentCopy{{Path: "/app/config.bak", Layer: 2}, {Path: "/etc/config", Layer: 0}}},
+	}
+	if !reflect.DeepEqual(result.Duplicates, expected) {
+		t.Errorf("expected duplicates:\n%+v\ngot:\n%+v", expected, result.Duplicates)
+	}
+	if result.WastedBytes != 15*2+8 {
+		t.Errorf("expected %d wasted bytes, got %d", 15*2+8, result.WastedBytes)
+	}
+
+	if !reflect.DeepEqual(progress, []int{0, 0, 1, 2}) {
+		t.Errorf("expected the listener to be called when added and after each layer, got %v", progress)
+	}
+}
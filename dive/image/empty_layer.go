@@ -0,0 +1,115 @@
+package image
+
+import (
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the reasons a layer adds no bytes to the image filesystem (see Layer.EmptyReason)
+const (
+	// the layer holds an attestation (e.g. an in-toto statement or an SBOM) about the image rather than files
+	EmptyAttestation = "attestation"
+	// the layer tar has no entries (e.g. a RUN instruction that changed nothing)
+	EmptyNoChanges = "no changes"
+	// the layer only creates directories (e.g. WORKDIR)
+	EmptyDirectories = "directories only"
+	// the layer only removes files added by the layers below
+	EmptyRemovals = "removals only"
+	// the layer only adds empty files (alongside directories and removals)
+	EmptyFiles = "empty files only"
+)
+
+// the media types of the layers that hold metadata about the image rather than a filesystem diff
+var attestationMediaTypes = []string{
+	"application/vnd.in-toto+json",
+	"application/vnd.dsse.envelope.v1+json",
+	"application/vnd.dev.cosign.simplesigning.v1+json",
+	"application/spdx+json",
+	"application/vnd.cyclonedx+json",
+	"application/vnd.syft+json",
+}
+
+// IsAttestationMediaType indicates if a layer of the given media type holds an attestation or another document about
+// the image instead of files.
+func IsAttestationMediaType(mediaType string) bool {
+	for _, candidate := range attestationMediaTypes {
+		if mediaType == candidate {
+			return true
+		}
+	}
+	return strings.HasPrefix(mediaType, "application/vnd.in-toto.")
+}
+
+// EmptyReason describes why the layer adds no bytes to the image filesystem (see EmptyNoChanges et al.), or returns
+// an empty string when it does, or when its contents have not been read (yet).
+func (l *Layer) EmptyReason() string {
+	if IsAttestationMediaType(l.MediaType) {
+		return EmptyAttestation
+	}
+	if l.Size > 0 || l.Tree == nil || l.Unavailable != "" {
+		return ""
+	}
+
+	var directories, removals, files int
+	err := l.Tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		switch {
+		case node == l.Tree.Root:
+		case node.IsWhiteout():
+			removals++
+		case node.Data.FileInfo.IsDir:
+			directories++
+		default:
+			files++
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return ""
+	}
+
+	// the directories of a layer that removes files are (mostly) the parents of the removed files
+	switch {
+	case files > 0:
+		return EmptyFiles
+	case removals > 0:
+		return EmptyRemovals
+	case directories > 0:
+		return EmptyDirectories
+	}
+	return EmptyNoChanges
+}
+
+// HistoryRow is a row of the image timeline as `docker history` lists it: a layer, or an instruction of the history
+// that made no filesystem changes (such as ENV, LABEL or ARG) between the layers.
+type HistoryRow struct {
+	// the index of the layer (-1 for the instructions without a layer)
+	Layer int
+	// the instruction without a layer (nil for layers)
+	Entry *HistoryEntry
+}
+
+// HistoryRows interleaves the layers with the instructions of the history that have no layer, in the order the
+// instructions ran. The layers keep their indices (and their order): the instructions without a layer are rows of
+// their own, which are left out unless withEmpty is set.
+func HistoryRows(layerCount int, history []HistoryEntry, withEmpty bool) []HistoryRow {
+	rows := make([]HistoryRow, 0, layerCount)
+	next := 0
+	for idx := range history {
+		entry := &history[idx]
+		switch {
+		case entry.EmptyLayer:
+			if withEmpty {
+				rows = append(rows, HistoryRow{Layer: -1, Entry: entry})
+			}
+		case entry.LayerIndex >= next:
+			for ; next <= entry.LayerIndex && next < layerCount; next++ {
+				rows = append(rows, HistoryRow{Layer: next})
+			}
+		}
+	}
+	for ; next < layerCount; next++ {
+		rows = append(rows, HistoryRow{Layer: next})
+	}
+	return rows
+}
@@ -0,0 +1,73 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestLayerEmptyReason(t *testing.T) {
+	tree := func(paths map[string]filetree.FileInfo) *filetree.FileTree {
+		result := filetree.NewFileTree()
+		for path, info := range paths {
+			if _, _, err := result.AddPath(path, info); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		return result
+	}
+
+	cases := []struct {
+		name     string
+		layer    Layer
+		expected string
+	}{
+		{name: "files", layer: Layer{Size: 10, Tree: tree(map[string]filetree.FileInfo{"/app/run": {Size: 10}})}, expected: ""},
+		{name: "not read", layer: Layer{}, expected: ""},
+		{name: "no changes", layer: Layer{Tree: filetree.NewFileTree()}, expected: EmptyNoChanges},
+		{name: "directories", layer: Layer{Tree: tree(map[string]filetree.FileInfo{"/app": {IsDir: true}})}, expected: EmptyDirectories},
+		{name: "removals", layer: Layer{Tree: tree(map[string]filetree.FileInfo{"/root": {IsDir: true}, "/root/.wh.example": {}})}, expected: EmptyRemovals},
+		{name: "empty files", layer: Layer{Tree: tree(map[string]filetree.FileInfo{"/app/.keep": {}})}, expected: EmptyFiles},
+		{name: "attestation", layer: Layer{MediaType: "application/vnd.in-toto+json", Size: 10}, expected: EmptyAttestation},
+	}
+	for _, c := range cases {
+		if actual := c.layer.EmptyReason(); actual != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestHistoryRows(t *testing.T) {
+	history := []HistoryEntry{
+		{CreatedBy: "ADD rootfs.tar.gz /", LayerIndex: 0},
+		{CreatedBy: "CMD [\"sh\"]", EmptyLayer: true, LayerIndex: -1},
+		{CreatedBy: "ENV PATH=/app", EmptyLayer: true, LayerIndex: -1},
+		{CreatedBy: "COPY app /app", LayerIndex: 1},
+		{CreatedBy: "LABEL version=1", EmptyLayer: true, LayerIndex: -1},
+	}
+
+	layers := func(rows []HistoryRow) []int {
+		var indexes []int
+		for _, row := range rows {
+			indexes = append(indexes, row.Layer)
+		}
+		return indexes
+	}
+
+	if actual := layers(HistoryRows(3, history, false)); !reflect.DeepEqual(actual, []int{0, 1, 2}) {
+		t.Errorf("expected only the layers, got %v", actual)
+	}
+
+	rows := HistoryRows(3, history, true)
+	if actual := layers(rows); !reflect.DeepEqual(actual, []int{0, -1, -1, 1, -1, 2}) {
+		t.Fatalf("expected the instructions without a layer between the layers, got %v", actual)
+	}
+	if rows[2].Entry.Command() != "ENV PATH=/app" {
+		t.Errorf("unexpected instruction: %+v", rows[2].Entry)
+	}
+
+	if actual := layers(HistoryRows(2, nil, true)); !reflect.DeepEqual(actual, []int{0, 1}) {
+		t.Errorf("expected the layers without a history, got %v", actual)
+	}
+}
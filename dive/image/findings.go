@@ -0,0 +1,172 @@
+package image
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the prefix of the annotations dive writes (reverse domain notation, as the OCI image spec recommends)
+const AnnotationPrefix = "com.github.wagoodman.dive."
+
+const (
+	// on the image manifest
+	AnnotationEfficiency        = AnnotationPrefix + "efficiency"
+	AnnotationWastedUserPercent = AnnotationPrefix + "wasted-user-percent"
+	AnnotationFlaggedCount      = AnnotationPrefix + "flagged-count"
+	// on the image manifest (for the whole image) and on the layer descriptors
+	AnnotationWastedBytes = AnnotationPrefix + "wasted-bytes"
+	// on the layer descriptors
+	AnnotationWastedFiles  = AnnotationPrefix + "wasted-files"
+	AnnotationFlaggedFiles = AnnotationPrefix + "flagged-files"
+)
+
+// DefaultAnnotatedFiles is the most paths listed in a single annotation, which keeps the manifest small.
+const DefaultAnnotatedFiles = 20
+
+// LayerFindings are the findings of the analysis attributed to a layer.
+type LayerFindings struct {
+	// the wasted bytes the layer is responsible for: the bytes it writes that are duplicated, overwritten or removed,
+	// and the bytes of the lower layers it hides by removing or replacing a directory
+	WastedBytes uint64
+	// the wasted paths the layer writes (or removes), the largest first
+	WastedFiles []string
+	// the permission audit findings of the files the final image has from the layer
+	Flagged []AuditFinding
+}
+
+// AnalyzeLayerFindings attributes the wasted bytes and the audit findings of the analysis to the layers. The wasted
+// bytes of the layers add up to the wasted bytes of the image.
+func AnalyzeLayerFindings(analysis *AnalysisResult) []LayerFindings {
+	findings := make([]LayerFindings, len(analysis.Layers))
+	layerOf := make(map[*filetree.FileTree]int)
+	for idx, tree := range analysis.RefTrees {
+		layerOf[tree] = idx
+	}
+
+	wastedPaths := make([]map[string]int64, len(findings))
+	addWaste := func(layer int, filePath string, sizeBytes int64) {
+		if layer < 0 || layer >= len(findings) {
+			return
+		}
+		findings[layer].WastedBytes += uint64(sizeBytes)
+		if wastedPaths[layer] == nil {
+			wastedPaths[layer] = make(map[string]int64)
+		}
+		wastedPaths[layer][filePath] += sizeBytes
+	}
+
+	for _, data := range analysis.Inefficiencies {
+		var attributed int64
+		last := -1
+		for _, node := range data.Nodes {
+			layer, known := layerOf[node.Tree]
+			if !known {
+				// a node of the stacked lower layers (hidden by a removed directory)
+				continue
+			}
+			last = layer
+			if !node.IsWhiteout() {
+				addWaste(layer, data.Path, node.Data.FileInfo.Size)
+				attributed += node.Data.FileInfo.Size
+			}
+		}
+		// what is left is hidden by the layer that removed or replaced the path
+		if remaining := data.CumulativeSize - attributed; remaining > 0 {
+			addWaste(last, data.Path, remaining)
+		}
+	}
+
+	for layer, paths := range wastedPaths {
+		for filePath := range paths {
+			findings[layer].WastedFiles = append(findings[layer].WastedFiles, filePath)
+		}
+		sort.Slice(findings[layer].WastedFiles, func(i, j int) bool {
+			left, right := findings[layer].WastedFiles[i], findings[layer].WastedFiles[j]
+			if paths[left] != paths[right] {
+				return paths[left] > paths[right]
+			}
+			return left < right
+		})
+	}
+
+	if analysis.Audit != nil {
+		for _, finding := range analysis.Audit.Findings {
+			if finding.Layer >= 0 && finding.Layer < len(findings) {
+				findings[finding.Layer].Flagged = append(findings[finding.Layer].Flagged, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// ImageAnnotations are the findings of an analysis as OCI annotations, for the image manifest and for the descriptor of
+// every layer, so that registries and other tools can read them from the image itself.
+type ImageAnnotations struct {
+	Manifest map[string]string
+	// by layer index (a layer without findings has no annotations)
+	Layers []map[string]string
+}
+
+// flaggedFile is a flagged file as listed in an annotation.
+type flaggedFile struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+// AnnotateAnalysis derives the annotations of the image manifest and layer descriptors from the analysis, listing at
+// most the given number of paths per annotation (0 for all). Lists of paths are JSON arrays.
+func AnnotateAnalysis(analysis *AnalysisResult, limit int) (*ImageAnnotations, error) {
+	findings := AnalyzeLayerFindings(analysis)
+	result := &ImageAnnotations{
+		Manifest: map[string]string{
+			AnnotationEfficiency:        strconv.FormatFloat(analysis.Efficiency, 'f', 4, 64),
+			AnnotationWastedBytes:       strconv.FormatUint(analysis.WastedBytes, 10),
+			AnnotationWastedUserPercent: strconv.FormatFloat(analysis.WastedUserPercent, 'f', 4, 64),
+		},
+		Layers: make([]map[string]string, len(findings)),
+	}
+
+	flaggedCount := 0
+	for layer, finding := range findings {
+		annotations := make(map[string]string)
+		if finding.WastedBytes > 0 {
+			annotations[AnnotationWastedBytes] = strconv.FormatUint(finding.WastedBytes, 10)
+			value, err := json.Marshal(limitPaths(finding.WastedFiles, limit))
+			if err != nil {
+				return nil, err
+			}
+			annotations[AnnotationWastedFiles] = string(value)
+		}
+		if len(finding.Flagged) > 0 {
+			flagged := make([]flaggedFile, 0, len(finding.Flagged))
+			for _, file := range finding.Flagged {
+				if limit > 0 && len(flagged) == limit {
+					break
+				}
+				flagged = append(flagged, flaggedFile{Path: file.Path, Kind: file.Kind})
+			}
+			value, err := json.Marshal(flagged)
+			if err != nil {
+				return nil, err
+			}
+			annotations[AnnotationFlaggedFiles] = string(value)
+			flaggedCount += len(finding.Flagged)
+		}
+		if len(annotations) > 0 {
+			result.Layers[layer] = annotations
+		}
+	}
+	result.Manifest[AnnotationFlaggedCount] = strconv.Itoa(flaggedCount)
+	return result, nil
+}
+
+// limitPaths returns the first paths, up to the limit (0 for all).
+func limitPaths(paths []string, limit int) []string {
+	if limit > 0 && len(paths) > limit {
+		return paths[:limit]
+	}
+	return paths
+}
@@ -0,0 +1,69 @@
+package image
+
+import (
+	"archive/tar"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAnnotateAnalysis(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	layers := make([]*Layer, len(trees))
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+		layers[idx] = &Layer{Index: idx}
+	}
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{TypeFlag: tar.TypeReg, Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc/app.conf", 100)
+	add(trees[0], "/var/cache/apt/pkgcache.bin", 4000)
+	// overwritten by the next layer
+	add(trees[1], "/etc/app.conf", 300)
+	add(trees[1], "/app/server", 1000)
+	// removes the cache written by the first layer
+	add(trees[2], "/var/cache/apt/.wh.pkgcache.bin", 0)
+
+	efficiency, inefficiencies := filetree.Efficiency(trees)
+	analysis := &AnalysisResult{
+		Layers:         layers,
+		RefTrees:       trees,
+		Efficiency:     efficiency,
+		Inefficiencies: inefficiencies,
+		WastedBytes:    4400,
+		Audit: &Audit{Findings: []AuditFinding{
+			{Kind: AuditRootOwned, Path: "/app/server", Layer: 1},
+		}},
+	}
+
+	findings := AnalyzeLayerFindings(analysis)
+	expected := []LayerFindings{
+		{WastedBytes: 4100, WastedFiles: []string{"/var/cache/apt/pkgcache.bin", "/etc/app.conf"}},
+		{WastedBytes: 300, WastedFiles: []string{"/etc/app.conf"}, Flagged: analysis.Audit.Findings},
+		{},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("expected findings:\n%+v\ngot:\n%+v", expected, findings)
+	}
+
+	annotations, err := AnnotateAnalysis(analysis, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if annotations.Manifest[AnnotationWastedBytes] != "4400" || annotations.Manifest[AnnotationFlaggedCount] != "1" {
+		t.Errorf("unexpected manifest annotations: %+v", annotations.Manifest)
+	}
+	expectedLayers := []map[string]string{
+		{AnnotationWastedBytes: "4100", AnnotationWastedFiles: `["/var/cache/apt/pkgcache.bin"]`},
+		{AnnotationWastedBytes: "300", AnnotationWastedFiles: `["/etc/app.conf"]`, AnnotationFlaggedFiles: `[{"path":"/app/server","kind":"root-owned"}]`},
+		nil,
+	}
+	if !reflect.DeepEqual(annotations.Layers, expectedLayers) {
+		t.Errorf("expected layer annotations:\n%+v\ngot:\n%+v", expectedLayers, annotations.Layers)
+	}
+}
@@ -0,0 +1,309 @@
+package image
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of paths of a fingerprint
+const (
+	FingerprintDir      = "dir"
+	FingerprintFile     = "file"
+	FingerprintSymlink  = "symlink"
+	FingerprintHardlink = "hardlink"
+	FingerprintDevice   = "device"
+	FingerprintFifo     = "fifo"
+)
+
+// the differences found between the fingerprints of a path (see FingerprintDrift)
+const (
+	DriftType    = "type"
+	DriftContent = "contents"
+	DriftLink    = "link target"
+	DriftMode    = "mode"
+	DriftOwner   = "owner"
+)
+
+// Fingerprint is a stable fingerprint of the filesystem of an image: every path gets a digest of its metadata (type,
+// mode and owner) and contents, and every directory a digest of its metadata and of the names and digests of its
+// entries, merkle-style. Two subtrees with the same digest hold the same files, whatever the layers they came from
+// and whenever they were written (modification times are left out).
+type Fingerprint struct {
+	// the image the fingerprint was taken of (as given)
+	Image string `json:"image"`
+	// the digest of the root directory, which stands for the whole filesystem
+	Digest string `json:"digest"`
+	// every path of the filesystem, sorted
+	Entries []FingerprintEntry `json:"entries"`
+}
+
+// FingerprintEntry is the fingerprint of a path of the image filesystem.
+type FingerprintEntry struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Digest string `json:"digest"`
+	// the hash of the contents of files, or the target of links (empty for directories)
+	Content string `json:"content,omitempty"`
+	Mode    string `json:"mode"`
+	Uid     int    `json:"uid"`
+	Gid     int    `json:"gid"`
+	// the size of files, or of the files within directories
+	Size int64 `json:"size"`
+	// the number of files within directories
+	Files int `json:"files,omitempty"`
+}
+
+// FingerprintDrift is a path whose fingerprint differs between two images (see CompareFingerprints), going from the
+// previous image to the current one (PathAdded, PathModified or PathDeleted).
+type FingerprintDrift struct {
+	Path   string
+	Change string
+	// what differs for modified paths (DriftContent et al.)
+	Reasons []string
+	// the size in the current image and in the previous image (0 when the path is not there)
+	Bytes         int64
+	PreviousBytes int64
+}
+
+// NewFingerprint fingerprints the filesystem of the image (the layers stacked, without the files removed by
+// whiteouts). Every layer tree must be loaded.
+func NewFingerprint(imageName string, trees []*filetree.FileTree) (*Fingerprint, error) {
+	if len(trees) == 0 {
+		return nil, fmt.Errorf("the image has no layers")
+	}
+	for idx, tree := range trees {
+		if tree == nil {
+			return nil, fmt.Errorf("layer %d has not been loaded", idx)
+		}
+	}
+	stacked, _, err := filetree.StackTreeRange(trees, 0, len(trees)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := &Fingerprint{Image: imageName, Entries: make([]FingerprintEntry, 0)}
+	root := fingerprintNode(stacked.Root, "/", &fingerprint.Entries)
+	fingerprint.Digest = root.Digest
+	sort.Slice(fingerprint.Entries, func(i, j int) bool {
+		return fingerprint.Entries[i].Path < fingerprint.Entries[j].Path
+	})
+	return fingerprint, nil
+}
+
+// fingerprintNode fingerprints the node (and its children, first), adding their entries to the given list.
+func fingerprintNode(node *filetree.FileNode, nodePath string, entries *[]FingerprintEntry) FingerprintEntry {
+	info := node.Data.FileInfo
+	entry := FingerprintEntry{
+		Path: nodePath,
+		Type: fingerprintType(info),
+		Mode: info.Mode.Perm().String(),
+		Uid:  info.Uid,
+		Gid:  info.Gid,
+	}
+	if info.Mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) != 0 {
+		entry.Mode = info.Mode.String()
+	}
+
+	hash := sha256.New()
+	if entry.Type == FingerprintDir || len(node.Children) > 0 || nodePath == "/" {
+		// the root, and the directories the layers only hold entries of, have no metadata of their own
+		entry.Type = FingerprintDir
+		if nodePath == "/" || !info.IsDir {
+			entry.Mode, entry.Uid, entry.Gid = "", 0, 0
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00%d:%d\x00", entry.Type, entry.Mode, entry.Uid, entry.Gid)
+
+		names := make([]string, 0, len(node.Children))
+		for name, child := range node.Children {
+			if !child.IsWhiteout() {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := fingerprintNode(node.Children[name], path.Join(nodePath, name), entries)
+			fmt.Fprintf(hash, "%s\x00%s\x00", name, child.Digest)
+			entry.Size += child.Size
+			if child.Type == FingerprintDir {
+				entry.Files += child.Files
+			} else {
+				entry.Files++
+			}
+		}
+	} else {
+		switch entry.Type {
+		case FingerprintSymlink, FingerprintHardlink:
+			entry.Content = info.Linkname
+		case FingerprintDevice:
+			entry.Content = fmt.Sprintf("%d:%d", info.Devmajor, info.Devminor)
+		case FingerprintFile:
+			entry.Content = fmt.Sprintf("xxh64:%016x", info.ContentHash())
+			entry.Size = info.Size
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00%d:%d\x00%d\x00%s", entry.Type, entry.Mode, entry.Uid, entry.Gid, entry.Size, entry.Content)
+	}
+	entry.Digest = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+	*entries = append(*entries, entry)
+	return entry
+}
+
+// fingerprintType is the kind of path the file info describes (FingerprintFile et al.).
+func fingerprintType(info filetree.FileInfo) string {
+	switch {
+	case info.IsDir:
+		return FingerprintDir
+	case info.TypeFlag == tar.TypeSymlink:
+		return FingerprintSymlink
+	case info.TypeFlag == tar.TypeLink:
+		return FingerprintHardlink
+	case info.TypeFlag == tar.TypeChar || info.TypeFlag == tar.TypeBlock:
+		return FingerprintDevice
+	case info.TypeFlag == tar.TypeFifo:
+		return FingerprintFifo
+	}
+	return FingerprintFile
+}
+
+// Subtrees returns the directories down to the given depth (1 for the top level directories, 0 for every entry), root
+// first. The files are listed above that depth only, their digests are part of their directory ones.
+func (f *Fingerprint) Subtrees(depth int) []FingerprintEntry {
+	var entries []FingerprintEntry
+	for _, entry := range f.Entries {
+		entryDepth := pathDepth(entry.Path)
+		if depth <= 0 || entryDepth < depth || (entryDepth == depth && entry.Type == FingerprintDir) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// pathDepth is the number of elements of the path ("/" is 0, "/usr/lib" is 2).
+func pathDepth(filePath string) int {
+	if filePath == "/" {
+		return 0
+	}
+	return strings.Count(filePath, "/")
+}
+
+// Write saves the fingerprint as JSON, to be compared later on (see ReadFingerprint).
+func (f *Fingerprint) Write(writer io.Writer) error {
+	content, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(content, '\n'))
+	return err
+}
+
+// ReadFingerprint loads a fingerprint saved with Fingerprint.Write.
+func ReadFingerprint(reader io.Reader) (*Fingerprint, error) {
+	var fingerprint Fingerprint
+	if err := json.NewDecoder(reader).Decode(&fingerprint); err != nil {
+		return nil, fmt.Errorf("unable to read the fingerprint: %v", err)
+	}
+	if !strings.HasPrefix(fingerprint.Digest, "sha256:") || len(fingerprint.Entries) == 0 {
+		return nil, fmt.Errorf("not a fingerprint (expected the output of 'dive fingerprint --output')")
+	}
+	return &fingerprint, nil
+}
+
+// CompareFingerprints pinpoints the paths that differ between two fingerprints: the subtrees with the same digest are
+// skipped, the directories that differ are descended into, and the paths only one image has are reported as a whole.
+// The drifts are sorted by path.
+func CompareFingerprints(previous, current *Fingerprint) []FingerprintDrift {
+	drifts := make([]FingerprintDrift, 0)
+	if previous.Digest == current.Digest {
+		return drifts
+	}
+	before, after := indexFingerprint(previous), indexFingerprint(current)
+
+	var compare func(dir string)
+	compare = func(dir string) {
+		names := make(map[string]bool)
+		for _, name := range before.children[dir] {
+			names[name] = true
+		}
+		for _, name := range after.children[dir] {
+			names[name] = true
+		}
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+
+		for _, name := range sorted {
+			childPath := path.Join(dir, name)
+			was, inBefore := before.entries[childPath]
+			now, inAfter := after.entries[childPath]
+			switch {
+			case !inBefore:
+				drifts = append(drifts, FingerprintDrift{Path: childPath, Change: PathAdded, Bytes: now.Size})
+			case !inAfter:
+				drifts = append(drifts, FingerprintDrift{Path: childPath, Change: PathDeleted, PreviousBytes: was.Size})
+			case was.Digest == now.Digest:
+			case was.Type == FingerprintDir && now.Type == FingerprintDir:
+				if reasons := driftReasons(was, now); len(reasons) > 0 {
+					drifts = append(drifts, FingerprintDrift{Path: childPath, Change: PathModified, Reasons: reasons, Bytes: now.Size, PreviousBytes: was.Size})
+				}
+				compare(childPath)
+			default:
+				drifts = append(drifts, FingerprintDrift{Path: childPath, Change: PathModified, Reasons: driftReasons(was, now), Bytes: now.Size, PreviousBytes: was.Size})
+			}
+		}
+	}
+	compare("/")
+	return drifts
+}
+
+// driftReasons lists what differs between two fingerprints of a path (the contents of directories aside).
+func driftReasons(was, now FingerprintEntry) []string {
+	var reasons []string
+	if was.Type != now.Type {
+		return []string{DriftType}
+	}
+	if was.Content != now.Content || was.Size != now.Size {
+		switch was.Type {
+		case FingerprintDir:
+		case FingerprintSymlink, FingerprintHardlink:
+			reasons = append(reasons, DriftLink)
+		default:
+			reasons = append(reasons, DriftContent)
+		}
+	}
+	if was.Mode != now.Mode {
+		reasons = append(reasons, DriftMode)
+	}
+	if was.Uid != now.Uid || was.Gid != now.Gid {
+		reasons = append(reasons, DriftOwner)
+	}
+	return reasons
+}
+
+// fingerprintIndex looks the entries of a fingerprint up by path, and the names within each directory.
+type fingerprintIndex struct {
+	entries  map[string]FingerprintEntry
+	children map[string][]string
+}
+
+func indexFingerprint(fingerprint *Fingerprint) fingerprintIndex {
+	index := fingerprintIndex{entries: make(map[string]FingerprintEntry), children: make(map[string][]string)}
+	for _, entry := range fingerprint.Entries {
+		index.entries[entry.Path] = entry
+		if entry.Path != "/" {
+			dir := path.Dir(entry.Path)
+			index.children[dir] = append(index.children[dir], path.Base(entry.Path))
+		}
+	}
+	return index
+}
@@ -0,0 +1,84 @@
+package image
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := map[string]string{
+		"etc/config":      "settings",
+		"usr/lib/libfoo":  "the foo library",
+		"usr/lib/libbar":  "the bar library",
+		"usr/share/notes": "notes",
+	}
+	fingerprint := func(name string, trees ...*filetree.FileTree) *Fingerprint {
+		result, err := NewFingerprint(name, trees)
+		if err != nil {
+			t.Fatalf("could not fingerprint: %v", err)
+		}
+		return result
+	}
+
+	previous := fingerprint("previous", layerTree(t, base))
+	// the same files spread over other layers have the same fingerprint
+	split := fingerprint("split", layerTree(t, map[string]string{"etc/config": "settings", "usr/lib/libfoo": "the foo library"}),
+		layerTree(t, map[string]string{"usr/lib/libbar": "the bar library", "usr/share/notes": "notes"}))
+	if previous.Digest != split.Digest {
+		t.Errorf("expected the same digest whatever the layers, got %s and %s", previous.Digest, split.Digest)
+	}
+
+	var paths []string
+	for _, entry := range previous.Subtrees(1) {
+		paths = append(paths, entry.Path)
+	}
+	if expected := []string{"/", "/etc", "/usr"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected subtrees %v, got %v", expected, paths)
+	}
+
+	var saved bytes.Buffer
+	if err := previous.Write(&saved); err != nil {
+		t.Fatalf("could not write the fingerprint: %v", err)
+	}
+	read, err := ReadFingerprint(&saved)
+	if err != nil {
+		t.Fatalf("could not read the fingerprint: %v", err)
+	}
+	if !reflect.DeepEqual(read, previous) {
+		t.Errorf("expected the fingerprint to be read back as written")
+	}
+
+	changedTree := layerTree(t, map[string]string{
+		"etc/config":      "settings",
+		"usr/lib/libfoo":  "the new foo library",
+		"usr/lib/libbar":  "the bar library",
+		"usr/lib/libbaz":  "the baz library",
+		"usr/share/notes": "notes",
+	})
+	changedTree.Root.Children["etc"].Children["config"].Data.FileInfo.Mode = 0600
+	changed := fingerprint("current", changedTree)
+
+	expected := []FingerprintDrift{
+		{Path: "/etc/config", Change: PathModified, Reasons: []string{DriftMode}, Bytes: 8, PreviousBytes: 8},
+		{Path: "/usr/lib/libbaz", Change: PathAdded, Bytes: 15},
+		{Path: "/usr/lib/libfoo", Change: PathModified, Reasons: []string{DriftContent}, Bytes: 19, PreviousBytes: 15},
+	}
+	if actual := CompareFingerprints(previous, changed); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected drifts:\n%+v\ngot:\n%+v", expected, actual)
+	}
+
+	removed := fingerprint("removed", layerTree(t, map[string]string{"etc/config": "settings"}))
+	expected = []FingerprintDrift{
+		{Path: "/usr", Change: PathDeleted, PreviousBytes: 35},
+	}
+	if actual := CompareFingerprints(previous, removed); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected drifts:\n%+v\ngot:\n%+v", expected, actual)
+	}
+
+	if actual := CompareFingerprints(previous, split); len(actual) != 0 {
+		t.Errorf("expected no drift, got %+v", actual)
+	}
+}
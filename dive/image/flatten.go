@@ -0,0 +1,149 @@
+package image
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// Flatten writes the filesystem of the image (the layers stacked, without the files removed by whiteouts) to the given
+// writer as the tar of a single layer. When a path prefix is given only the entries beneath it (and the directories
+// leading to it) are written. Every layer tree must be loaded. The number of entries written is returned.
+func Flatten(writer io.Writer, contents ContentReader, trees []*filetree.FileTree, prefix string) (int, error) {
+	if contents == nil {
+		return 0, fmt.Errorf("the file contents are not available for this image")
+	}
+	if len(trees) == 0 {
+		return 0, fmt.Errorf("the image has no layers")
+	}
+	for idx, tree := range trees {
+		if tree == nil {
+			return 0, fmt.Errorf("layer %d has not been loaded", idx)
+		}
+	}
+
+	stacked, _, err := filetree.StackTreeRange(trees, 0, len(trees)-1)
+	if err != nil {
+		return 0, err
+	}
+	prefix = path.Clean("/" + prefix)
+	if prefix != "/" {
+		if _, err := stacked.GetNode(prefix); err != nil {
+			return 0, fmt.Errorf("'%s' does not exist in the image", prefix)
+		}
+	}
+
+	archive := tar.NewWriter(writer)
+	written := 0
+	writeEntry := func(filePath string, info filetree.FileInfo, reader io.Reader) error {
+		if err := archive.WriteHeader(info.TarHeader(strings.TrimPrefix(filePath, "/"))); err != nil {
+			return fmt.Errorf("unable to write '%s': %v", filePath, err)
+		}
+		if reader != nil {
+			copied, err := io.CopyN(archive, reader, info.Size)
+			if err != nil {
+				return fmt.Errorf("unable to write the contents of '%s' (%d of %d bytes read): %v", filePath, copied, info.Size, err)
+			}
+		}
+		written++
+		return nil
+	}
+
+	// the directories, links and devices are written first (the parents before their children), then the regular
+	// files by the layer that last wrote them (reading each layer once), and the hardlinks once their targets exist
+	files := make(map[string]filetree.FileInfo)
+	byLayer := make(map[int]map[string]bool)
+	var hardlinks []string
+	err = stacked.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		filePath := node.Path()
+		if node.IsWhiteout() || !withinPrefix(filePath, prefix) {
+			return nil
+		}
+		info := node.Data.FileInfo
+		if len(node.Children) > 0 && !info.IsDir {
+			// a directory the layers only hold entries of
+			info.IsDir, info.Mode = true, os.ModeDir|0755
+		}
+
+		switch {
+		case info.IsDir:
+			return writeEntry(filePath, info, nil)
+		case info.TypeFlag == tar.TypeLink:
+			files[filePath] = info
+			hardlinks = append(hardlinks, filePath)
+		case info.TypeFlag == tar.TypeReg || info.TypeFlag == tar.TypeRegA:
+			layer, _, _ := FileLayer(trees, len(trees)-1, filePath)
+			if byLayer[layer] == nil {
+				byLayer[layer] = make(map[string]bool)
+			}
+			byLayer[layer][filePath] = true
+			files[filePath] = info
+		default:
+			return writeEntry(filePath, info, nil)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return written, err
+	}
+
+	layers := make([]int, 0, len(byLayer))
+	for layer := range byLayer {
+		layers = append(layers, layer)
+	}
+	sort.Ints(layers)
+	walker, canWalk := contents.(ContentWalker)
+	for _, layer := range layers {
+		pending := byLayer[layer]
+		if canWalk {
+			err := walker.WalkFiles(layer, pending, func(filePath string, reader io.Reader) error {
+				if !pending[filePath] {
+					return nil
+				}
+				delete(pending, filePath)
+				return writeEntry(filePath, files[filePath], reader)
+			})
+			if err != nil {
+				return written, err
+			}
+		}
+
+		remaining := make([]string, 0, len(pending))
+		for filePath := range pending {
+			remaining = append(remaining, filePath)
+		}
+		sort.Strings(remaining)
+		for _, filePath := range remaining {
+			reader, err := contents.OpenFile(layer, filePath)
+			if err != nil {
+				return written, fmt.Errorf("unable to read '%s' from layer %d: %v", filePath, layer, err)
+			}
+			err = writeEntry(filePath, files[filePath], reader)
+			reader.Close()
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+
+	for _, filePath := range hardlinks {
+		if err := writeEntry(filePath, files[filePath], nil); err != nil {
+			return written, err
+		}
+	}
+	return written, archive.Close()
+}
+
+// withinPrefix indicates if the path is the given prefix, is beneath it, or is a directory leading to it.
+func withinPrefix(filePath, prefix string) bool {
+	if prefix == "/" || filePath == prefix {
+		return true
+	}
+	return strings.HasPrefix(filePath, prefix+"/") || strings.HasPrefix(prefix, filePath+"/")
+}
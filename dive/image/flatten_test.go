@@ -0,0 +1,100 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFlatten(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	// layerContents reads every file as "<layer>:<path>"
+	file := func(layer int, path string) filetree.FileInfo {
+		return filetree.FileInfo{TypeFlag: tar.TypeReg, Mode: 0644, Size: int64(len(fmt.Sprintf("%d:%s", layer, path)))}
+	}
+	dir := filetree.FileInfo{TypeFlag: tar.TypeDir, IsDir: true, Mode: os.ModeDir | 0755}
+
+	add(trees[0], "/etc", dir)
+	add(trees[0], "/etc/app.conf", file(0, "/etc/app.conf"))
+	add(trees[0], "/etc/old.conf", file(0, "/etc/old.conf"))
+	add(trees[0], "/usr", dir)
+	add(trees[0], "/usr/bin", dir)
+	add(trees[0], "/usr/bin/app", filetree.FileInfo{TypeFlag: tar.TypeReg, Mode: 0755 | os.ModeSetuid, Size: int64(len("0:/usr/bin/app"))})
+	add(trees[1], "/etc", dir)
+	add(trees[1], "/etc/app.conf", file(1, "/etc/app.conf"))
+	add(trees[1], "/etc/current.conf", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "app.conf"})
+	add(trees[2], "/etc", dir)
+	add(trees[2], "/etc/.wh.old.conf", filetree.FileInfo{})
+	add(trees[2], "/usr/bin/app-link", filetree.FileInfo{TypeFlag: tar.TypeLink, Linkname: "usr/bin/app"})
+
+	read := func(archive []byte) (names []string, contents map[string]string, modes map[string]int64) {
+		contents, modes = make(map[string]string), make(map[string]int64)
+		reader := tar.NewReader(bytes.NewReader(archive))
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				return names, contents, modes
+			}
+			if err != nil {
+				t.Fatalf("unable to read the flattened tar: %v", err)
+			}
+			names = append(names, header.Name)
+			modes[header.Name] = header.Mode
+			if header.Typeflag == tar.TypeReg {
+				body, _ := ioutil.ReadAll(reader)
+				contents[header.Name] = string(body)
+			}
+		}
+	}
+
+	var archive bytes.Buffer
+	written, err := Flatten(&archive, layerContents{}, trees, "")
+	if err != nil {
+		t.Fatalf("unable to flatten: %v", err)
+	}
+	names, contents, modes := read(archive.Bytes())
+
+	expectedNames := []string{"etc/", "etc/current.conf", "usr/", "usr/bin/", "usr/bin/app", "etc/app.conf", "usr/bin/app-link"}
+	if !reflect.DeepEqual(names, expectedNames) || written != len(expectedNames) {
+		t.Errorf("expected entries %v, got %v (%d written)", expectedNames, names, written)
+	}
+	if contents["etc/app.conf"] != "1:/etc/app.conf" {
+		t.Errorf("expected the contents of the last layer, got %q", contents["etc/app.conf"])
+	}
+	if modes["usr/bin/app"] != 04755 {
+		t.Errorf("expected the setuid mode to be kept, got %o", modes["usr/bin/app"])
+	}
+
+	archive.Reset()
+	if _, err := Flatten(&archive, layerContents{}, trees, "/usr/bin"); err != nil {
+		t.Fatalf("unable to flatten: %v", err)
+	}
+	names, _, _ = read(archive.Bytes())
+	expectedNames = []string{"usr/", "usr/bin/", "usr/bin/app", "usr/bin/app-link"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("expected entries %v beneath the prefix, got %v", expectedNames, names)
+	}
+
+	if _, err := Flatten(ioutil.Discard, layerContents{}, trees, "/etc/old.conf"); err == nil {
+		t.Errorf("expected an error flattening a removed path")
+	}
+	if _, err := Flatten(ioutil.Discard, nil, trees, ""); err == nil {
+		t.Errorf("expected an error without the file contents")
+	}
+}
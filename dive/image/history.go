@@ -0,0 +1,38 @@
+package image
+
+import (
+	"strings"
+	"time"
+)
+
+// HistoryEntry is an instruction of the image config history, including the instructions that made no filesystem
+// changes (such as ENV, LABEL or EXPOSE), which have no layer.
+type HistoryEntry struct {
+	Created   time.Time
+	Author    string
+	CreatedBy string
+	Comment   string
+	// the instruction made no filesystem changes
+	EmptyLayer bool
+	// the index of the layer the instruction produced (-1 for empty layers)
+	LayerIndex int
+}
+
+// Command returns the instruction without the shell prefix (e.g. "ENV PATH=/app" for
+// "/bin/sh -c #(nop)  ENV PATH=/app").
+func (entry HistoryEntry) Command() string {
+	command := strings.TrimPrefix(entry.CreatedBy, "/bin/sh -c ")
+	command = strings.TrimPrefix(command, "#(nop) ")
+	return strings.Join(strings.Fields(command), " ")
+}
+
+// EmptyLayerCount returns the number of instructions in the history that made no filesystem changes.
+func EmptyLayerCount(history []HistoryEntry) int {
+	count := 0
+	for _, entry := range history {
+		if entry.EmptyLayer {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,20 @@
+package image
+
+import (
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// reportedFiles lists the files of the final image the wasted space reports cover (those not in the ignore list, which
+// may be nil).
+func reportedFiles(trees []*filetree.FileTree, ignore *filetree.IgnoreList) map[string]visibleFile {
+	files := visibleFiles(trees)
+	if ignore.Len() == 0 {
+		return files
+	}
+	for filePath := range files {
+		if ignore.Ignored(filePath, false) {
+			delete(files, filePath)
+		}
+	}
+	return files
+}
@@ -1,24 +1,153 @@
 package image
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/metrics"
 )
 
 type Image struct {
+	// ID is the digest of the image config (empty when unknown)
+	ID     string
 	Trees  []*filetree.FileTree
 	Layers []*Layer
+	// Loader parses layer trees on demand; when set, Trees only holds the layers that have been loaded so far
+	Loader LayerLoader
+	// Deprecations are the legacy features found in the image metadata
+	Deprecations []Deprecation
+	// the config history, including the instructions that made no filesystem changes
+	History []HistoryEntry
+	// the runtime configuration (env, labels, entrypoint...) recorded in the image config
+	Config *ImageConfig
+	// the manifest annotations and attached artifacts (provenance, SBOMs, signatures) found in the registry
+	Attestations *Attestations
+	// the outcome of the cosign signature verification (nil when the signature was not verified)
+	Signature *SignatureVerification
+	// the vulnerabilities a scanner found, mapped onto the files and layers (nil when no scanner report was given)
+	Vulnerabilities *VulnerabilityReport
+	// the packages installed in the image and the files they own (nil unless the package databases were read)
+	Packages *Packages
+	// Base is the base image the image is built on (when not set, the first layer is assumed to be the base image)
+	Base *BaseImage
+	// Contents reads the file contents of the layers (nil when they are not available)
+	Contents ContentReader
+}
+
+// LayerLoader parses the contents of individual layers after the image metadata has been read.
+type LayerLoader interface {
+	// LoadLayer parses the contents of the layer at the given index, along with what they tell about the layer. The
+	// layer is left as is (see LoadedLayer.Apply), so that layers can be loaded while others read the layers.
+	LoadLayer(index int) (LoadedLayer, error)
+	Close() error
+}
+
+// LayerParser parses the contents of individual layers without updating the image (so that layers can be parsed in
+// the background while the LayerLoader is in use).
+type LayerParser interface {
+	ParseTree(index int) (*filetree.FileTree, error)
+}
+
+// IsLazy indicates if the layer trees are parsed on demand (in which case only the layer metadata is known upfront).
+func (img *Image) IsLazy() bool {
+	return img.Loader != nil
+}
+
+// Close releases any resources held for on-demand layer loading (and for reading file contents).
+func (img *Image) Close() error {
+	var err error
+	if closer, ok := img.Contents.(io.Closer); ok {
+		err = closer.Close()
+	}
+	if img.Loader != nil {
+		if loaderErr := img.Loader.Close(); loaderErr != nil {
+			err = loaderErr
+		}
+	}
+	return err
+}
+
+// Comparer creates a tree comparer over the image layers, loading layer trees on demand for lazy images. The layers the
+// comparer loads are updated with what their contents tell once ApplyLoaded is called on the comparer.
+func (img *Image) Comparer() filetree.Comparer {
+	if img.IsLazy() {
+		return filetree.NewLazyComparer(img.Trees, func(index int) (*filetree.FileTree, func(), error) {
+			loaded, err := img.Loader.LoadLayer(index)
+			if err != nil {
+				return nil, nil, err
+			}
+			return loaded.Tree, func() { loaded.Apply(img.Layers[index]) }, nil
+		})
+	}
+	return filetree.NewComparer(img.Trees)
+}
+
+// LoadTree parses the tree of the layer at the given index of a lazy image, updating the layer with what its contents
+// tell (e.g. the size of the contents).
+func (img *Image) LoadTree(index int) (*filetree.FileTree, error) {
+	if img.Loader == nil {
+		return nil, fmt.Errorf("layer %d has not been loaded", index)
+	}
+	loaded, err := img.Loader.LoadLayer(index)
+	if err != nil {
+		return nil, err
+	}
+	loaded.Apply(img.Layers[index])
+	return loaded.Tree, nil
 }
 
 func (img *Image) Analyze() (*AnalysisResult, error) {
+	return img.AnalyzeContext(context.Background())
+}
+
+// AnalysisOptions are the settings of an analysis. They are given to each analysis, so that the analyses of different
+// images (e.g. those opened in tabs or requested from the daemon) do not share them.
+type AnalysisOptions struct {
+	// the paths (e.g. from a .diveignore file) left out of the efficiency score and the wasted space reports (nil
+	// leaves out none)
+	Ignore *filetree.IgnoreList
+	// the policy of the permission audit
+	Audit AuditPolicy
+}
+
+// DefaultAnalysisOptions are the options of an analysis unless configured otherwise: nothing is ignored, and the audit
+// flags the files owned by root within DefaultAuditAppDirs (with no allowed capabilities).
+func DefaultAnalysisOptions() AnalysisOptions {
+	return AnalysisOptions{Audit: AuditPolicy{AppDirs: DefaultAuditAppDirs}}
+}
+
+// AnalyzeContext analyzes the image like Analyze (with DefaultAnalysisOptions), stopping between the analysis stages
+// (returning the context error) once the context is done. The stages done are reported to the progress bus of the
+// context.
+func (img *Image) AnalyzeContext(ctx context.Context) (*AnalysisResult, error) {
+	return img.AnalyzeWithOptions(ctx, DefaultAnalysisOptions())
+}
+
+// AnalyzeWithOptions analyzes the image like AnalyzeContext, with the given options.
+func (img *Image) AnalyzeWithOptions(ctx context.Context, options AnalysisOptions) (*AnalysisResult, error) {
+	recorder := metrics.FromContext(ctx)
+	if img.IsLazy() {
+		result := img.analyzeMetadata()
+		result.Metrics = recorder
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	efficiency, inefficiencies := filetree.Efficiency(img.Trees)
-	var sizeBytes, userSizeBytes uint64
+	tracker := NewProgressTracker(ctx, StageAnalyzing)
+	defer tracker.Finish()
+	defer recorder.Since("analyze image", "", time.Now())
 
-	for i, v := range img.Layers {
+	efficiency, inefficiencies := filetree.EfficiencyIgnoring(img.Trees, options.Ignore)
+	var sizeBytes, compressedBytes uint64
+
+	for _, v := range img.Layers {
 		sizeBytes += v.Size
-		if i != 0 {
-			userSizeBytes += v.Size
-		}
+		compressedBytes += v.CompressedSize
 	}
 
 	var wastedBytes uint64
@@ -26,14 +155,99 @@ func (img *Image) Analyze() (*AnalysisResult, error) {
 		wastedBytes += uint64(file.CumulativeSize)
 	}
 
-	return &AnalysisResult{
+	base := img.baseImage()
+	base.WastedBytes = baseWastedBytes(img.Trees, base.Layers, options.Ignore)
+	userSizeBytes := sizeBytes - base.SizeBytes
+	// waste within the base layers is not caused by the app layers (replacing or deleting base files is)
+	appWastedBytes := wastedBytes
+	if base.WastedBytes < appWastedBytes {
+		appWastedBytes -= base.WastedBytes
+	} else {
+		appWastedBytes = 0
+	}
+
+	result := &AnalysisResult{
+		ImageID:           img.ID,
+		Contents:          img.Contents,
 		Layers:            img.Layers,
 		RefTrees:          img.Trees,
 		Efficiency:        efficiency,
 		UserSizeByes:      userSizeBytes,
 		SizeBytes:         sizeBytes,
+		CompressedBytes:   compressedBytes,
 		WastedBytes:       wastedBytes,
-		WastedUserPercent: float64(wastedBytes) / float64(userSizeBytes),
+		AppWastedBytes:    appWastedBytes,
+		WastedUserPercent: wastedPercent(appWastedBytes, userSizeBytes),
+		Base:              base,
 		Inefficiencies:    inefficiencies,
-	}, nil
+		Deprecations:      img.Deprecations,
+		History:           img.History,
+		Config:            img.Config,
+		Attestations:      img.Attestations,
+		Signature:         img.Signature,
+		Vulnerabilities:   img.Vulnerabilities,
+		Packages:          img.Packages,
+		Metrics:           recorder,
+	}
+
+	// every stage walks the layer trees, the context is checked in between
+	stages := []func(){
+		func() { result.Breakdown = ScoreEfficiency(img.Trees, inefficiencies, sizeBytes, options.Ignore) },
+		func() { result.Storage = EstimateStorageOverhead(img.Trees, sizeBytes) },
+		func() { result.DuplicateArtifacts = FindDuplicateArtifacts(img.Trees) },
+		func() { result.AssetBloat = FindAssetBloat(img.Trees, options.Ignore) },
+		func() { result.Prunable = FindPrunableContent(img.Trees, options.Ignore) },
+		func() { result.Reproducibility = FindReproducibilityIssues(img.Layers, img.Trees) },
+		func() { result.ML = AnalyzeML(img.Trees) },
+		func() { result.Conda = FindCondaEnvironments(img.Trees) },
+		func() { result.Dependencies = FindDependencies(img.Trees) },
+		func() { result.Compression = AnalyzeCompression(img.Layers, img.Trees) },
+		func() { result.Downloads = FindRemoteDownloads(img.Layers, img.Trees) },
+		func() { result.Audit = AuditPermissions(img.Trees, options.Audit) },
+		func() { result.Ownership = AnalyzeOwnership(img.Trees) },
+	}
+	// the efficiency is the first step
+	tracker.SetTotals(0, 0, len(stages)+1)
+	tracker.StepDone()
+	for _, stage := range stages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stage()
+		tracker.StepDone()
+	}
+	return result, nil
+}
+
+// analyzeMetadata summarizes a lazy image from the layer metadata alone; the efficiency and storage figures require
+// every layer tree, so they are left empty.
+func (img *Image) analyzeMetadata() *AnalysisResult {
+	var sizeBytes, compressedBytes uint64
+	for _, v := range img.Layers {
+		sizeBytes += v.Size
+		compressedBytes += v.CompressedSize
+	}
+	base := img.baseImage()
+
+	return &AnalysisResult{
+		ImageID:      img.ID,
+		Contents:     img.Contents,
+		Layers:       img.Layers,
+		RefTrees:     img.Trees,
+		UserSizeByes: sizeBytes - base.SizeBytes,
+		SizeBytes:    sizeBytes,
+		Base:         base,
+		// only the layers stored compressed are known upfront, the rest are measured as they are loaded
+		CompressedBytes: compressedBytes,
+		Deprecations:    img.Deprecations,
+		History:         img.History,
+		Config:          img.Config,
+		Attestations:    img.Attestations,
+		Signature:       img.Signature,
+		Vulnerabilities: img.Vulnerabilities,
+		Packages:        img.Packages,
+		// the downloaded files are only looked for within the layers already loaded
+		Downloads: FindRemoteDownloads(img.Layers, img.Trees),
+		Partial:   true,
+	}
 }
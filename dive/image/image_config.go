@@ -0,0 +1,153 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the sections of the image config settings
+const (
+	ConfigUser        = "user"
+	ConfigWorkingDir  = "workdir"
+	ConfigEntrypoint  = "entrypoint"
+	ConfigCmd         = "cmd"
+	ConfigStopSignal  = "stop signal"
+	ConfigHealthcheck = "healthcheck"
+	ConfigPort        = "port"
+	ConfigVolume      = "volume"
+	ConfigEnv         = "env"
+	ConfigLabel       = "label"
+)
+
+// ImageConfig is the runtime configuration recorded in the image config (what "docker inspect" shows under Config).
+type ImageConfig struct {
+	User       string
+	WorkingDir string
+	Entrypoint []string
+	Cmd        []string
+	// the environment variables as "KEY=value", in the order they are set
+	Env    []string
+	Labels map[string]string
+	// the exposed ports (e.g. "8080/tcp") and the volumes, sorted
+	ExposedPorts []string
+	Volumes      []string
+	StopSignal   string
+	// nil when the image does not configure a healthcheck
+	Healthcheck *Healthcheck
+}
+
+// Healthcheck is the command the engine runs to check that a container is healthy.
+type Healthcheck struct {
+	// the command, with its form first (e.g. ["CMD-SHELL", "curl -f http://localhost/"]; ["NONE"] disables the check)
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// String renders the healthcheck like a Dockerfile instruction (e.g. "CMD-SHELL curl -f http://localhost/ (every 30s,
+// timeout 5s, 3 retries)").
+func (h *Healthcheck) String() string {
+	result := strings.Join(h.Test, " ")
+	var options []string
+	if h.Interval > 0 {
+		options = append(options, "every "+h.Interval.String())
+	}
+	if h.Timeout > 0 {
+		options = append(options, "timeout "+h.Timeout.String())
+	}
+	if h.StartPeriod > 0 {
+		options = append(options, "start period "+h.StartPeriod.String())
+	}
+	if h.Retries > 0 {
+		options = append(options, fmt.Sprintf("%d retries", h.Retries))
+	}
+	if len(options) > 0 {
+		result += " (" + strings.Join(options, ", ") + ")"
+	}
+	return result
+}
+
+// ConfigEntry is a single setting of the image config, such as an environment variable or a label.
+type ConfigEntry struct {
+	Section string
+	// the name of the variable or label (empty for settings that are not named, such as the user)
+	Key   string
+	Value string
+}
+
+// Entries lists the settings of the config: the user, workdir, entrypoint, cmd, stop signal, healthcheck, ports,
+// volumes, the environment variables in order and the labels sorted by name. Unset settings are left out.
+func (c *ImageConfig) Entries() []ConfigEntry {
+	if c == nil {
+		return nil
+	}
+	var entries []ConfigEntry
+	add := func(section, key, value string) {
+		if value != "" {
+			entries = append(entries, ConfigEntry{Section: section, Key: key, Value: value})
+		}
+	}
+	add(ConfigUser, "", c.User)
+	add(ConfigWorkingDir, "", c.WorkingDir)
+	add(ConfigEntrypoint, "", formatCommand(c.Entrypoint))
+	add(ConfigCmd, "", formatCommand(c.Cmd))
+	add(ConfigStopSignal, "", c.StopSignal)
+	if c.Healthcheck != nil {
+		add(ConfigHealthcheck, "", c.Healthcheck.String())
+	}
+	for _, port := range c.ExposedPorts {
+		add(ConfigPort, "", port)
+	}
+	for _, volume := range c.Volumes {
+		add(ConfigVolume, "", volume)
+	}
+	for _, variable := range c.Env {
+		name, value := variable, ""
+		if idx := strings.Index(variable, "="); idx >= 0 {
+			name, value = variable[:idx], variable[idx+1:]
+		}
+		entries = append(entries, ConfigEntry{Section: ConfigEnv, Key: name, Value: value})
+	}
+	var names []string
+	for name := range c.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entries = append(entries, ConfigEntry{Section: ConfigLabel, Key: name, Value: c.Labels[name]})
+	}
+	return entries
+}
+
+// FilterConfigEntries returns the entries whose section, key or value contains the query (ignoring case).
+func FilterConfigEntries(entries []ConfigEntry, query string) []ConfigEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return entries
+	}
+	var filtered []ConfigEntry
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(entry.Section), query) ||
+			strings.Contains(strings.ToLower(entry.Key), query) ||
+			strings.Contains(strings.ToLower(entry.Value), query) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// formatCommand renders an exec form command as a JSON-like list (e.g. ["nginx", "-g", "daemon off;"]).
+func formatCommand(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(command))
+	for idx, arg := range command {
+		quoted[idx] = fmt.Sprintf("%q", arg)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
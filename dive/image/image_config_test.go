@@ -0,0 +1,53 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestImageConfig_Entries(t *testing.T) {
+	config := &ImageConfig{
+		User:         "app",
+		Entrypoint:   []string{"/docker-entrypoint.sh"},
+		Cmd:          []string{"nginx", "-g", "daemon off;"},
+		Env:          []string{"PATH=/usr/bin", "NGINX_VERSION=1.25.3", "EMPTY"},
+		Labels:       map[string]string{"maintainer": "NGINX", "org.opencontainers.image.version": "1.25.3"},
+		ExposedPorts: []string{"80/tcp"},
+		StopSignal:   "SIGQUIT",
+		Healthcheck: &Healthcheck{
+			Test:     []string{"CMD-SHELL", "curl -f http://localhost/"},
+			Interval: 30 * time.Second,
+			Retries:  3,
+		},
+	}
+
+	expected := []ConfigEntry{
+		{ConfigUser, "", "app"},
+		{ConfigEntrypoint, "", `["/docker-entrypoint.sh"]`},
+		{ConfigCmd, "", `["nginx", "-g", "daemon off;"]`},
+		{ConfigStopSignal, "", "SIGQUIT"},
+		{ConfigHealthcheck, "", "CMD-SHELL curl -f http://localhost/ (every 30s, 3 retries)"},
+		{ConfigPort, "", "80/tcp"},
+		{ConfigEnv, "PATH", "/usr/bin"},
+		{ConfigEnv, "NGINX_VERSION", "1.25.3"},
+		{ConfigEnv, "EMPTY", ""},
+		{ConfigLabel, "maintainer", "NGINX"},
+		{ConfigLabel, "org.opencontainers.image.version", "1.25.3"},
+	}
+	entries := config.Entries()
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("expected entries:\n%+v\ngot:\n%+v", expected, entries)
+	}
+
+	filtered := FilterConfigEntries(entries, "1.25")
+	if len(filtered) != 2 || filtered[0].Key != "NGINX_VERSION" || filtered[1].Key != "org.opencontainers.image.version" {
+		t.Errorf("unexpected entries matching the version: %+v", filtered)
+	}
+	if filtered := FilterConfigEntries(entries, "LABEL"); len(filtered) != 2 {
+		t.Errorf("expected the labels to match their section, got %+v", filtered)
+	}
+	if (*ImageConfig)(nil).Entries() != nil {
+		t.Errorf("expected no entries without a config")
+	}
+}
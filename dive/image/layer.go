@@ -2,12 +2,15 @@ package image
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/dustin/go-humanize"
 	"github.com/wagoodman/dive/dive/filetree"
 )
 
 const (
-	LayerFormat = "%7s  %s"
+	LayerFormat = "%7s  %10s  %s"
 )
 
 type Layer struct {
@@ -17,7 +20,54 @@ type Layer struct {
 	Size    uint64
 	Tree    *filetree.FileTree
 	Names   []string
-	Digest  string
+	// the digest of the layer blob as stored in the image (empty when unknown)
+	Digest string
+	// the digest of the uncompressed layer contents (from the image config rootfs)
+	DiffID    string
+	MediaType string
+	Created   time.Time
+	Author    string
+	// the tool that (most likely) created the layer, see BuilderDocker et al. (empty when it cannot be determined)
+	Builder string
+	// the semantic role of the layer for builders that do not record commands, see RoleDependencies et al. (empty when
+	// the layer has a meaningful command)
+	Role string
+	// the size of the layer blob as stored in the image (compressed when the media type is compressed)
+	BlobSize uint64
+	// the size of the layer when compressed, as stored and transferred by a registry (0 when not yet known)
+	CompressedSize uint64
+	// why the layer contents could not be read, such as foreign layers of Windows images (empty when they were read)
+	Unavailable string
+	// the problems found verifying the layer blob against its digests, such as a truncated tar or a diffID mismatch
+	// (empty when the layer is intact). The tree of a truncated layer holds the entries read before the tar ended.
+	Corruption []string
+	// the malformed entries of the layer tar that were skipped while parsing it (nil when there were none)
+	ParseWarnings *ParseWarnings
+}
+
+// LoadedLayer is what parsing the contents of a layer loaded on demand tells about the layer (see LayerLoader).
+type LoadedLayer struct {
+	Tree *filetree.FileTree
+	// the size of the layer contents (the size of the layer as is when its contents are not within the image)
+	Size uint64
+	// the compressed size of the layer, measured for layers stored uncompressed
+	CompressedSize uint64
+	Corruption     []string
+	ParseWarnings  *ParseWarnings
+	// the role of the layer (found from the contents for builders that do not record commands), and its command
+	Role    string
+	Command string
+}
+
+// Apply updates the layer with what its contents tell.
+func (l LoadedLayer) Apply(layer *Layer) {
+	layer.Tree = l.Tree
+	layer.Size = l.Size
+	layer.CompressedSize = l.CompressedSize
+	layer.Corruption = l.Corruption
+	layer.ParseWarnings = l.ParseWarnings
+	layer.Role = l.Role
+	layer.Command = l.Command
 }
 
 func (l *Layer) ShortId() string {
@@ -31,13 +81,37 @@ func (l *Layer) ShortId() string {
 	return id
 }
 
+// TransferSize is the number of bytes a registry transfers for the layer: the compressed size when known, otherwise the
+// uncompressed size.
+func (l *Layer) TransferSize() uint64 {
+	if l.CompressedSize > 0 {
+		return l.CompressedSize
+	}
+	return l.Size
+}
+
+// compressedSizeString is the compressed layer size, or a placeholder when it is not known (yet).
+func (l *Layer) compressedSizeString() string {
+	if l.CompressedSize == 0 {
+		return "-"
+	}
+	return humanize.Bytes(l.CompressedSize)
+}
+
+// IsCompressed indicates if the layer blob is stored compressed.
+func (l *Layer) IsCompressed() bool {
+	return strings.HasSuffix(l.MediaType, "gzip") || strings.HasSuffix(l.MediaType, "zstd")
+}
+
 func (l *Layer) String() string {
 	if l.Index == 0 {
 		return fmt.Sprintf(LayerFormat,
 			humanize.Bytes(l.Size),
+			l.compressedSizeString(),
 			"FROM "+l.ShortId())
 	}
 	return fmt.Sprintf(LayerFormat,
 		humanize.Bytes(l.Size),
+		l.compressedSizeString(),
 		l.Command)
 }
@@ -0,0 +1,10 @@
+package image
+
+// LayerCache stores the parsed layers of images by the digest of their blobs (see docker.NewLayerCache), so that
+// fetching a new build of an image only parses the layers that changed since the last build.
+type LayerCache interface {
+	// Load returns the entry stored under the key, if there is one.
+	Load(key string) ([]byte, bool)
+	// Store keeps the entry under the key (failing to store it is only logged).
+	Store(key string, entry []byte)
+}
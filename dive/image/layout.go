@@ -0,0 +1,238 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociConfigMediaType   = "application/vnd.oci.image.config.v1+json"
+	// the annotation naming the image within an OCI layout (the tag)
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+// layoutDescriptor references a blob of an OCI layout.
+type layoutDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type layoutManifest struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	MediaType     string             `json:"mediaType"`
+	Config        layoutDescriptor   `json:"config"`
+	Layers        []layoutDescriptor `json:"layers"`
+	Annotations   map[string]string  `json:"annotations,omitempty"`
+}
+
+type layoutIndex struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Manifests     []layoutDescriptor `json:"manifests"`
+}
+
+// LayoutEdits are the labels and annotations to set on the copy of an image, e.g. the provenance of the copy
+// (dive.optimized=true, the digest of the report it was made from).
+type LayoutEdits struct {
+	// set in the image config, replacing the labels of the same name
+	Labels map[string]string
+	// set on the image manifest, replacing the annotations of the same name (including the findings)
+	Annotations map[string]string
+}
+
+// ParseLayoutEdits reads the labels and annotations to set, each given as key=value.
+func ParseLayoutEdits(labels, annotations []string) (LayoutEdits, error) {
+	var edits LayoutEdits
+	var err error
+	if edits.Labels, err = parseKeyValues("label", labels); err != nil {
+		return LayoutEdits{}, err
+	}
+	if edits.Annotations, err = parseKeyValues("annotation", annotations); err != nil {
+		return LayoutEdits{}, err
+	}
+	return edits, nil
+}
+
+// parseKeyValues reads the given key=value pairs (nil when there are none).
+func parseKeyValues(kind string, pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid %s %q (expected key=value)", kind, pair)
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values, nil
+}
+
+// WriteAnnotatedLayout copies the image into an OCI image layout at the given directory, adding the annotations to the
+// image manifest (along with the annotations the manifest had in the registry) and to the layer descriptors. The layer
+// blobs are copied as stored, so the layers keep their digests. The config is copied as stored too, unless the edits set
+// labels, so that only the manifest (and thus the image digest) changes. The annotations of the edits are set after the
+// findings. The image is named refName within the layout (unless empty). The manifest digest is returned.
+func WriteAnnotatedLayout(dir string, img *Image, annotations *ImageAnnotations, edits LayoutEdits, refName string) (string, error) {
+	blobs, ok := img.Contents.(BlobReader)
+	if !ok {
+		return "", fmt.Errorf("the layer blobs are not available for this image")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755); err != nil {
+		return "", err
+	}
+
+	configBytes, err := blobs.ConfigBlob()
+	if err != nil {
+		return "", fmt.Errorf("unable to read the image config: %v", err)
+	}
+	if len(edits.Labels) > 0 {
+		if configBytes, err = setConfigLabels(configBytes, edits.Labels); err != nil {
+			return "", fmt.Errorf("unable to set the labels of the image config: %v", err)
+		}
+	}
+	configDescriptor, err := writeLayoutBlob(dir, configBytes, ociConfigMediaType)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := layoutManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Config:        configDescriptor,
+		Layers:        make([]layoutDescriptor, 0, len(img.Layers)),
+		Annotations:   make(map[string]string),
+	}
+	if img.Attestations != nil {
+		for key, value := range img.Attestations.Annotations {
+			manifest.Annotations[key] = value
+		}
+	}
+	if annotations != nil {
+		for key, value := range annotations.Manifest {
+			manifest.Annotations[key] = value
+		}
+	}
+	for key, value := range edits.Annotations {
+		manifest.Annotations[key] = value
+	}
+
+	for idx := range img.Layers {
+		descriptor, err := copyLayerBlob(dir, blobs, idx)
+		if err != nil {
+			return "", fmt.Errorf("unable to copy layer %d: %v", idx, err)
+		}
+		if annotations != nil && idx < len(annotations.Layers) {
+			descriptor.Annotations = annotations.Layers[idx]
+		}
+		manifest.Layers = append(manifest.Layers, descriptor)
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	manifestDescriptor, err := writeLayoutBlob(dir, manifestBytes, ociManifestMediaType)
+	if err != nil {
+		return "", err
+	}
+	if refName != "" {
+		manifestDescriptor.Annotations = map[string]string{ociRefNameAnnotation: refName}
+	}
+
+	indexBytes, err := json.Marshal(layoutIndex{SchemaVersion: 2, Manifests: []layoutDescriptor{manifestDescriptor}})
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.json"), indexBytes, 0644); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		return "", err
+	}
+	return manifestDescriptor.Digest, nil
+}
+
+// setConfigLabels sets the labels in the given image config, keeping every other field of the config (the fields are
+// written in order of their name).
+func setConfigLabels(configBytes []byte, labels map[string]string) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, err
+	}
+	runConfig := make(map[string]json.RawMessage)
+	if raw, ok := config["config"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &runConfig); err != nil {
+			return nil, err
+		}
+	}
+	merged := make(map[string]string)
+	if raw, ok := runConfig["Labels"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+
+	var err error
+	if runConfig["Labels"], err = json.Marshal(merged); err != nil {
+		return nil, err
+	}
+	if config["config"], err = json.Marshal(runConfig); err != nil {
+		return nil, err
+	}
+	return json.Marshal(config)
+}
+
+// writeLayoutBlob writes the given bytes as a blob of the layout.
+func writeLayoutBlob(dir string, content []byte, mediaType string) (layoutDescriptor, error) {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	if err := ioutil.WriteFile(filepath.Join(dir, "blobs", "sha256", digest), content, 0644); err != nil {
+		return layoutDescriptor{}, err
+	}
+	return layoutDescriptor{MediaType: mediaType, Digest: "sha256:" + digest, Size: int64(len(content))}, nil
+}
+
+// copyLayerBlob copies the blob of the layer at the given index into the layout, naming it by the digest found while
+// copying it.
+func copyLayerBlob(dir string, blobs BlobReader, layer int) (layoutDescriptor, error) {
+	reader, mediaType, err := blobs.OpenLayerBlob(layer)
+	if err != nil {
+		return layoutDescriptor{}, err
+	}
+	defer reader.Close()
+
+	file, err := ioutil.TempFile(filepath.Join(dir, "blobs", "sha256"), ".blob-*")
+	if err != nil {
+		return layoutDescriptor{}, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return layoutDescriptor{}, err
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if err := os.Rename(file.Name(), filepath.Join(dir, "blobs", "sha256", digest)); err != nil {
+		os.Remove(file.Name())
+		return layoutDescriptor{}, err
+	}
+	return layoutDescriptor{MediaType: mediaType, Digest: "sha256:" + digest, Size: size}, nil
+}
@@ -0,0 +1,184 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// blobContents stores the blob of every layer as its index (e.g. "layer 0"), gzip compressed after the first layer.
+type blobContents struct {
+	layerContents
+}
+
+func (blobContents) OpenLayerBlob(layer int) (io.ReadCloser, string, error) {
+	mediaType := "application/vnd.oci.image.layer.v1.tar"
+	if layer > 0 {
+		mediaType += "+gzip"
+	}
+	return ioutil.NopCloser(strings.NewReader(fmt.Sprintf("layer %d", layer))), mediaType, nil
+}
+
+func (blobContents) ConfigBlob() ([]byte, error) {
+	return []byte(`{"rootfs":{"type":"layers"}}`), nil
+}
+
+func TestWriteAnnotatedLayout(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "layout")
+	img := &Image{
+		Layers:       []*Layer{{Index: 0}, {Index: 1}},
+		Contents:     blobContents{},
+		Attestations: &Attestations{Annotations: map[string]string{"org.opencontainers.image.source": "https://example.com/app"}},
+	}
+	annotations := &ImageAnnotations{
+		Manifest: map[string]string{AnnotationWastedBytes: "300"},
+		Layers:   []map[string]string{nil, {AnnotationWastedBytes: "300"}},
+	}
+
+	digest, err := WriteAnnotatedLayout(dir, img, annotations, LayoutEdits{}, "v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	readJSON := func(name string, value interface{}) []byte {
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unable to read %s: %v", name, err)
+		}
+		if err := json.Unmarshal(content, value); err != nil {
+			t.Fatalf("unable to parse %s: %v", name, err)
+		}
+		return content
+	}
+	blobDigest := func(content string) string {
+		return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+	}
+
+	var index layoutIndex
+	readJSON("index.json", &index)
+	if len(index.Manifests) != 1 || index.Manifests[0].Digest != digest || index.Manifests[0].Annotations[ociRefNameAnnotation] != "v1" {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+
+	var manifest layoutManifest
+	content := readJSON(filepath.Join("blobs", "sha256", strings.TrimPrefix(digest, "sha256:")), &manifest)
+	if actual := blobDigest(string(content)); actual != digest {
+		t.Errorf("expected the manifest digest %s, got %s", digest, actual)
+	}
+	if manifest.Config.Digest != blobDigest(`{"rootfs":{"type":"layers"}}`) || manifest.Config.MediaType != ociConfigMediaType {
+		t.Errorf("unexpected config descriptor: %+v", manifest.Config)
+	}
+	expectedLayers := []layoutDescriptor{
+		{MediaType: "application/vnd.oci.image.layer.v1.tar", Digest: blobDigest("layer 0"), Size: 7},
+		{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: blobDigest("layer 1"), Size: 7, Annotations: map[string]string{AnnotationWastedBytes: "300"}},
+	}
+	if !reflect.DeepEqual(manifest.Layers, expectedLayers) {
+		t.Errorf("expected layers:\n%+v\ngot:\n%+v", expectedLayers, manifest.Layers)
+	}
+	expectedAnnotations := map[string]string{"org.opencontainers.image.source": "https://example.com/app", AnnotationWastedBytes: "300"}
+	if !reflect.DeepEqual(manifest.Annotations, expectedAnnotations) {
+		t.Errorf("expected manifest annotations %+v, got %+v", expectedAnnotations, manifest.Annotations)
+	}
+
+	blob, err := ioutil.ReadFile(filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(blobDigest("layer 1"), "sha256:")))
+	if err != nil || string(blob) != "layer 1" {
+		t.Errorf("expected the layer blob to be copied as is, got %q (%v)", blob, err)
+	}
+
+	if _, err := WriteAnnotatedLayout(dir, &Image{Contents: layerContents{}}, annotations, LayoutEdits{}, ""); err == nil {
+		t.Errorf("expected an error without the layer blobs")
+	}
+}
+
+func TestWriteAnnotatedLayoutEdits(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "layout")
+	img := &Image{Layers: []*Layer{{Index: 0}}, Contents: blobContents{}}
+	annotations := &ImageAnnotations{
+		Manifest: map[string]string{AnnotationWastedBytes: "300"},
+		Layers:   []map[string]string{nil},
+	}
+	edits := LayoutEdits{
+		Labels:      map[string]string{"dive.optimized": "true"},
+		Annotations: map[string]string{"dive.report": "sha256:abc", AnnotationWastedBytes: "0"},
+	}
+
+	digest, err := WriteAnnotatedLayout(dir, img, annotations, edits, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	readBlob := func(digest string, value interface{}) {
+		content, err := ioutil.ReadFile(filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:")))
+		if err != nil {
+			t.Fatalf("unable to read blob %s: %v", digest, err)
+		}
+		if err := json.Unmarshal(content, value); err != nil {
+			t.Fatalf("unable to parse blob %s: %v", digest, err)
+		}
+	}
+
+	var manifest layoutManifest
+	readBlob(digest, &manifest)
+	expectedAnnotations := map[string]string{"dive.report": "sha256:abc", AnnotationWastedBytes: "0"}
+	if !reflect.DeepEqual(manifest.Annotations, expectedAnnotations) {
+		t.Errorf("expected manifest annotations %+v, got %+v", expectedAnnotations, manifest.Annotations)
+	}
+
+	var config map[string]interface{}
+	readBlob(manifest.Config.Digest, &config)
+	expectedConfig := map[string]interface{}{
+		"rootfs": map[string]interface{}{"type": "layers"},
+		"config": map[string]interface{}{"Labels": map[string]interface{}{"dive.optimized": "true"}},
+	}
+	if !reflect.DeepEqual(config, expectedConfig) {
+		t.Errorf("expected config %+v, got %+v", expectedConfig, config)
+	}
+}
+
+func TestSetConfigLabels(t *testing.T) {
+	config := `{"architecture":"amd64","config":{"Env":["PATH=/bin"],"Labels":{"maintainer":"me","version":"1"}}}`
+	actual, err := setConfigLabels([]byte(config), map[string]string{"version": "2", "dive.optimized": "true"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"architecture":"amd64","config":{"Env":["PATH=/bin"],"Labels":{"dive.optimized":"true","maintainer":"me","version":"2"}}}`
+	if string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+
+	if _, err := setConfigLabels([]byte("not json"), map[string]string{"a": "b"}); err == nil {
+		t.Errorf("expected an error for an invalid config")
+	}
+}
+
+func TestParseLayoutEdits(t *testing.T) {
+	edits, err := ParseLayoutEdits([]string{"dive.optimized=true", "empty="}, []string{"dive.report=sha256:a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := LayoutEdits{
+		Labels:      map[string]string{"dive.optimized": "true", "empty": ""},
+		Annotations: map[string]string{"dive.report": "sha256:a=b"},
+	}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("expected %+v, got %+v", expected, edits)
+	}
+
+	if edits, err := ParseLayoutEdits(nil, nil); err != nil || edits.Labels != nil || edits.Annotations != nil {
+		t.Errorf("expected no edits, got %+v (%v)", edits, err)
+	}
+	for _, invalid := range []string{"no-value", "=value"} {
+		if _, err := ParseLayoutEdits([]string{invalid}, nil); err == nil {
+			t.Errorf("expected an error for the label %q", invalid)
+		}
+		if _, err := ParseLayoutEdits(nil, []string{invalid}); err == nil {
+			t.Errorf("expected an error for the annotation %q", invalid)
+		}
+	}
+}
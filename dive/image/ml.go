@@ -0,0 +1,305 @@
+package image
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the GPU library families that are detected
+const (
+	GPUFamilyCUDA     = "CUDA"
+	GPUFamilyCuDNN    = "cuDNN"
+	GPUFamilyNCCL     = "NCCL"
+	GPUFamilyTensorRT = "TensorRT"
+)
+
+// the package managers a framework can be installed with
+const (
+	ManagerPip        = "pip"
+	ManagerConda      = "conda"
+	ManagerCondaCache = "conda package cache"
+)
+
+// a shared or static CUDA library ("libcudnn_ops_infer.so.8.9.2", "libcublasLt.so.12", "libcudart_static.a")
+var gpuLibraryPattern = regexp.MustCompile(`^(lib(?:cudart|cublas|cublasLt|cudnn(?:_[a-z_]+)?|cufft|cufftw|curand|cusparse|cusparseLt|cusolver|cusolverMg|cupti|nccl|nvrtc|nvrtc-builtins|nvJitLink|nvjpeg|npp[a-z]*|nvToolsExt|nvinfer(?:_[a-z_]+)?|nvonnxparser|nvparsers))(?:_static\.a|\.a|\.so((?:\.[0-9]+)*))$`)
+
+// the frameworks checked for duplicate installs, by their normalized Python distribution name
+var mlFrameworks = map[string]struct {
+	name    string
+	modules []string
+}{
+	"torch":            {name: "torch", modules: []string{"torch", "torchgen", "functorch"}},
+	"tensorflow":       {name: "tensorflow", modules: []string{"tensorflow"}},
+	"tensorflow_cpu":   {name: "tensorflow", modules: []string{"tensorflow"}},
+	"tensorflow_gpu":   {name: "tensorflow", modules: []string{"tensorflow"}},
+	"tf_nightly":       {name: "tensorflow", modules: []string{"tensorflow"}},
+	"jaxlib":           {name: "jaxlib", modules: []string{"jaxlib"}},
+	"onnxruntime":      {name: "onnxruntime", modules: []string{"onnxruntime"}},
+	"onnxruntime_gpu":  {name: "onnxruntime", modules: []string{"onnxruntime"}},
+	"paddlepaddle":     {name: "paddlepaddle", modules: []string{"paddle"}},
+	"paddlepaddle_gpu": {name: "paddlepaddle", modules: []string{"paddle"}},
+	"mxnet":            {name: "mxnet", modules: []string{"mxnet"}},
+	"tensorrt":         {name: "tensorrt", modules: []string{"tensorrt"}},
+}
+
+// the model weight formats, by file extension
+var modelExtensions = map[string]string{
+	".safetensors": "safetensors",
+	".pt":          "pytorch",
+	".pth":         "pytorch",
+	".ckpt":        "checkpoint",
+	".onnx":        "onnx",
+	".h5":          "keras",
+	".keras":       "keras",
+	".tflite":      "tflite",
+	".gguf":        "gguf",
+	".ggml":        "ggml",
+	".engine":      "tensorrt",
+	".mlmodel":     "coreml",
+}
+
+// GPULibraryCopy is one copy of a GPU library within the final image filesystem.
+type GPULibraryCopy struct {
+	Path      string
+	Version   string
+	SizeBytes uint64
+	// the index of the layer that added the copy
+	Layer int
+}
+
+// GPULibrary is a CUDA, cuDNN, NCCL or TensorRT library along with every copy of it in the final image.
+type GPULibrary struct {
+	Family    string
+	Name      string
+	Copies    []GPULibraryCopy
+	SizeBytes uint64
+}
+
+// RedundantBytes is the size of every copy but the largest one. Several copies are usually the libraries of a CUDA
+// base image next to the ones bundled with a framework wheel (torch pulls its own "nvidia-*" packages).
+func (library GPULibrary) RedundantBytes() uint64 {
+	var largest uint64
+	for _, libraryCopy := range library.Copies {
+		if libraryCopy.SizeBytes > largest {
+			largest = libraryCopy.SizeBytes
+		}
+	}
+	return library.SizeBytes - largest
+}
+
+// FrameworkInstall is one installation of an ML framework (a Python site-packages directory holding it).
+type FrameworkInstall struct {
+	Manager   string
+	Version   string
+	Dir       string
+	SizeBytes uint64
+	// the index of the layer that added the install
+	Layer int
+}
+
+// DuplicateFramework is an ML framework installed in more than one location of the final image (e.g. both with pip
+// and with conda).
+type DuplicateFramework struct {
+	Name     string
+	Installs []FrameworkInstall
+}
+
+// ModelFile is a file of model weights within the final image.
+type ModelFile struct {
+	Path      string
+	Format    string
+	SizeBytes uint64
+	// the index of the layer that added the file
+	Layer int
+}
+
+// MLAnalysis summarizes the contents specific to GPU/ML images, which usually make up most of their size.
+type MLAnalysis struct {
+	GPULibraries        []GPULibrary
+	GPUBytes            uint64
+	DuplicateFrameworks []DuplicateFramework
+	ModelFiles          []ModelFile
+	ModelBytes          uint64
+}
+
+// Empty indicates if no GPU/ML specific content was found.
+func (analysis *MLAnalysis) Empty() bool {
+	return len(analysis.GPULibraries) == 0 && len(analysis.DuplicateFrameworks) == 0 && len(analysis.ModelFiles) == 0
+}
+
+// AnalyzeML finds the GPU libraries, the ML frameworks installed more than once and the model weights within the
+// final image (after every layer and whiteout has been applied).
+func AnalyzeML(trees []*filetree.FileTree) *MLAnalysis {
+	files := visibleFiles(trees)
+	result := &MLAnalysis{
+		GPULibraries:        make([]GPULibrary, 0),
+		DuplicateFrameworks: make([]DuplicateFramework, 0),
+		ModelFiles:          make([]ModelFile, 0),
+	}
+
+	libraries := make(map[string]*GPULibrary)
+	for filePath, file := range files {
+		name := path.Base(filePath)
+
+		// symlinks (libfoo.so -> libfoo.so.1) have no size and are not copies
+		if match := gpuLibraryPattern.FindStringSubmatch(name); match != nil && file.size > 0 {
+			library, exists := libraries[match[1]]
+			if !exists {
+				library = &GPULibrary{Family: gpuFamily(match[1]), Name: match[1]}
+				libraries[match[1]] = library
+			}
+			library.Copies = append(library.Copies, GPULibraryCopy{
+				Path:      filePath,
+				Version:   strings.TrimPrefix(match[2], "."),
+				SizeBytes: file.size,
+				Layer:     file.layer,
+			})
+			library.SizeBytes += file.size
+			result.GPUBytes += file.size
+			continue
+		}
+
+		if format := modelFormat(filePath); format != "" {
+			result.ModelFiles = append(result.ModelFiles, ModelFile{Path: filePath, Format: format, SizeBytes: file.size, Layer: file.layer})
+			result.ModelBytes += file.size
+		}
+	}
+
+	for _, library := range libraries {
+		sort.Slice(library.Copies, func(i, j int) bool {
+			return library.Copies[i].Path < library.Copies[j].Path
+		})
+		result.GPULibraries = append(result.GPULibraries, *library)
+	}
+	sort.Slice(result.GPULibraries, func(i, j int) bool {
+		if result.GPULibraries[i].SizeBytes == result.GPULibraries[j].SizeBytes {
+			return result.GPULibraries[i].Name < result.GPULibraries[j].Name
+		}
+		return result.GPULibraries[i].SizeBytes > result.GPULibraries[j].SizeBytes
+	})
+	sort.Slice(result.ModelFiles, func(i, j int) bool {
+		if result.ModelFiles[i].SizeBytes == result.ModelFiles[j].SizeBytes {
+			return result.ModelFiles[i].Path < result.ModelFiles[j].Path
+		}
+		return result.ModelFiles[i].SizeBytes > result.ModelFiles[j].SizeBytes
+	})
+
+	result.DuplicateFrameworks = findDuplicateFrameworks(files)
+
+	return result
+}
+
+// findDuplicateFrameworks lists the frameworks that have installed distribution metadata in more than one
+// site-packages directory.
+func findDuplicateFrameworks(files map[string]visibleFile) []DuplicateFramework {
+	prefixes := condaPrefixes(files)
+
+	installs := make(map[string]map[string]*FrameworkInstall)
+	for filePath := range files {
+		metadataDir := path.Dir(filePath)
+		_, distribution, version, ok := parseArtifact(path.Base(metadataDir), true)
+		if !ok {
+			continue
+		}
+		framework, known := mlFrameworks[distribution]
+		if !known {
+			continue
+		}
+
+		dir := path.Dir(metadataDir)
+		if installs[framework.name] == nil {
+			installs[framework.name] = make(map[string]*FrameworkInstall)
+		}
+		if _, exists := installs[framework.name][dir]; exists {
+			continue
+		}
+
+		install := &FrameworkInstall{Manager: packageManager(dir, prefixes), Version: version, Dir: dir}
+		// the install is made of its metadata and its top level modules
+		prefixes := []string{metadataDir + "/"}
+		for _, module := range framework.modules {
+			prefixes = append(prefixes, path.Join(dir, module)+"/")
+		}
+		for candidate, file := range files {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(candidate, prefix) {
+					install.SizeBytes += file.size
+					if file.layer > install.Layer {
+						install.Layer = file.layer
+					}
+					break
+				}
+			}
+		}
+		installs[framework.name][dir] = install
+	}
+
+	duplicates := make([]DuplicateFramework, 0)
+	for name, byDir := range installs {
+		if len(byDir) < 2 {
+			continue
+		}
+		duplicate := DuplicateFramework{Name: name}
+		for _, install := range byDir {
+			duplicate.Installs = append(duplicate.Installs, *install)
+		}
+		sort.Slice(duplicate.Installs, func(i, j int) bool {
+			return duplicate.Installs[i].Dir < duplicate.Installs[j].Dir
+		})
+		duplicates = append(duplicates, duplicate)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].Name < duplicates[j].Name
+	})
+	return duplicates
+}
+
+// packageManager guesses how the given site-packages directory was populated: directories within a conda environment
+// (or the extracted packages of its cache) belong to conda, everything else to pip.
+func packageManager(sitePackages string, condaPrefixes map[string]bool) string {
+	idx := strings.Index(sitePackages, "/lib/python")
+	if idx < 0 {
+		return ManagerPip
+	}
+	prefix := sitePackages[:idx]
+	if condaPrefixes[prefix] {
+		return ManagerConda
+	}
+	// "<conda root>/pkgs/<package>-<version>-<build>/lib/python3.x/site-packages"
+	if cache := path.Dir(prefix); path.Base(cache) == "pkgs" && condaPrefixes[path.Dir(cache)] {
+		return ManagerCondaCache
+	}
+	return ManagerPip
+}
+
+func gpuFamily(library string) string {
+	switch {
+	case strings.HasPrefix(library, "libcudnn"):
+		return GPUFamilyCuDNN
+	case strings.HasPrefix(library, "libnccl"):
+		return GPUFamilyNCCL
+	case strings.HasPrefix(library, "libnvinfer"), strings.HasPrefix(library, "libnvonnxparser"), strings.HasPrefix(library, "libnvparsers"):
+		return GPUFamilyTensorRT
+	default:
+		return GPUFamilyCUDA
+	}
+}
+
+// modelFormat returns the format of the model weights in the given file (if any).
+func modelFormat(filePath string) string {
+	name := path.Base(filePath)
+	switch {
+	case strings.HasPrefix(name, "pytorch_model") && strings.HasSuffix(name, ".bin"):
+		// hugging face weights (possibly sharded: "pytorch_model-00001-of-00002.bin")
+		return "pytorch"
+	case name == "flax_model.msgpack":
+		return "flax"
+	case name == "saved_model.pb", strings.HasPrefix(name, "variables.data-"):
+		return "tensorflow saved model"
+	}
+	return modelExtensions[path.Ext(name)]
+}
@@ -0,0 +1,95 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAnalyzeML(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	// a CUDA base image with a conda environment
+	add(trees[0], "/usr/local/cuda/lib64/libcudart.so.12.1.105", 700)
+	add(trees[0], "/usr/lib/x86_64-linux-gnu/libcudnn_ops_infer.so.8.9.2", 9000)
+	add(trees[0], "/usr/lib/x86_64-linux-gnu/libcudnn_ops_infer.so.8", 0)
+	add(trees[0], "/opt/conda/conda-meta/pytorch-2.1.0-py3.10_cuda12.1_0.json", 10)
+	add(trees[0], "/opt/conda/lib/python3.10/site-packages/torch-2.1.0.dist-info/METADATA", 50)
+	add(trees[0], "/opt/conda/lib/python3.10/site-packages/torch/lib/libtorch_cuda.so", 5000)
+	add(trees[0], "/opt/conda/pkgs/pytorch-2.1.0-py3.10_cuda12.1_0/lib/python3.10/site-packages/torch-2.1.0.dist-info/METADATA", 50)
+	add(trees[0], "/opt/conda/pkgs/pytorch-2.1.0-py3.10_cuda12.1_0/lib/python3.10/site-packages/torch/lib/libtorch_cuda.so", 5000)
+	// the application layer installs torch again with pip, bundling its own cuDNN
+	add(trees[1], "/usr/local/lib/python3.10/dist-packages/torch-2.2.0.dist-info/METADATA", 60)
+	add(trees[1], "/usr/local/lib/python3.10/dist-packages/torch/lib/libtorch_cuda.so", 6000)
+	add(trees[1], "/usr/local/lib/python3.10/dist-packages/nvidia/cudnn/lib/libcudnn_ops_infer.so.8", 8000)
+	add(trees[1], "/models/llama/model-00001-of-00002.safetensors", 40000)
+	add(trees[1], "/models/bert/pytorch_model.bin", 20000)
+	add(trees[1], "/models/bert/vocab.txt", 200)
+	// the conda package cache is cleaned
+	add(trees[2], "/opt/conda/.wh.pkgs", 0)
+	add(trees[2], "/models/bert/.wh.pytorch_model.bin", 0)
+
+	analysis := AnalyzeML(trees)
+
+	if len(analysis.GPULibraries) != 2 {
+		t.Fatalf("expected 2 GPU libraries, got %+v", analysis.GPULibraries)
+	}
+	cudnn := analysis.GPULibraries[0]
+	if cudnn.Name != "libcudnn_ops_infer" || cudnn.Family != GPUFamilyCuDNN || len(cudnn.Copies) != 2 {
+		t.Errorf("unexpected library: %+v", cudnn)
+	}
+	if cudnn.RedundantBytes() != 8000 {
+		t.Errorf("expected 8000 redundant bytes, got %d", cudnn.RedundantBytes())
+	}
+	if cudnn.Copies[0].Version != "8.9.2" {
+		t.Errorf("expected version 8.9.2, got %q", cudnn.Copies[0].Version)
+	}
+	if analysis.GPULibraries[1].Family != GPUFamilyCUDA || analysis.GPUBytes != 17700 {
+		t.Errorf("unexpected GPU libraries: %+v (%d bytes)", analysis.GPULibraries, analysis.GPUBytes)
+	}
+
+	if len(analysis.DuplicateFrameworks) != 1 {
+		t.Fatalf("expected 1 duplicate framework, got %+v", analysis.DuplicateFrameworks)
+	}
+	expected := []FrameworkInstall{
+		{Manager: ManagerConda, Version: "2.1.0", Dir: "/opt/conda/lib/python3.10/site-packages", SizeBytes: 5050, Layer: 0},
+		{Manager: ManagerPip, Version: "2.2.0", Dir: "/usr/local/lib/python3.10/dist-packages", SizeBytes: 6060, Layer: 1},
+	}
+	torch := analysis.DuplicateFrameworks[0]
+	if torch.Name != "torch" || len(torch.Installs) != len(expected) {
+		t.Fatalf("unexpected framework: %+v", torch)
+	}
+	for idx, install := range torch.Installs {
+		if install != expected[idx] {
+			t.Errorf("install %d: expected %+v, got %+v", idx, expected[idx], install)
+		}
+	}
+
+	if len(analysis.ModelFiles) != 1 || analysis.ModelFiles[0].Format != "safetensors" || analysis.ModelBytes != 40000 {
+		t.Errorf("unexpected model files: %+v", analysis.ModelFiles)
+	}
+}
+
+func TestPackageManager(t *testing.T) {
+	condaPrefixes := map[string]bool{"/opt/conda": true}
+	cases := map[string]string{
+		"/opt/conda/lib/python3.10/site-packages":                             ManagerConda,
+		"/opt/conda/pkgs/pytorch-2.1.0-py3.10_0/lib/python3.10/site-packages": ManagerCondaCache,
+		"/usr/local/lib/python3.10/site-packages":                             ManagerPip,
+		"/app/.venv/lib/python3.11/site-packages":                             ManagerPip,
+	}
+	for dir, expected := range cases {
+		if actual := packageManager(dir, condaPrefixes); actual != expected {
+			t.Errorf("%s: expected %q, got %q", dir, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package image
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the buckets the modification times of the files fall in, relative to the creation of the layer that wrote them
+const (
+	// written while the layer was built
+	MtimeBuilt = "built"
+	// older than the build of the layer, e.g. files copied with the timestamps they had in the build context
+	MtimeStale = "stale"
+	// the creation time of the layer or the unix epoch, as reproducible builds (SOURCE_DATE_EPOCH) set them
+	MtimeClamped = "clamped"
+	// later than the creation of the layer (the clock of the builder was off, or the times were set on purpose)
+	MtimeFuture = "future"
+	// the layer has no creation time to compare with
+	MtimeUnknown = "unknown"
+)
+
+// MtimeBuckets lists the buckets of modification times, in the order they are shown.
+var MtimeBuckets = []string{MtimeBuilt, MtimeStale, MtimeClamped, MtimeFuture, MtimeUnknown}
+
+// MtimeDescription describes the files of the given bucket.
+func MtimeDescription(bucket string) string {
+	switch bucket {
+	case MtimeBuilt:
+		return "written during the build"
+	case MtimeStale:
+		return "older than the build"
+	case MtimeClamped:
+		return "with clamped times"
+	case MtimeFuture:
+		return "newer than the layer"
+	case MtimeUnknown:
+		return "of layers without a creation time"
+	}
+	return ""
+}
+
+// MtimeBucket tells which bucket the modification time of a file falls in, given the creation time of the layer that
+// wrote it. The files written within an hour of the creation of the layer are taken to be written by its build.
+func MtimeBucket(modTime, created time.Time) string {
+	switch {
+	case modTime.Unix() == 0 || (!created.IsZero() && modTime.Unix() == created.Unix()):
+		return MtimeClamped
+	case created.IsZero() || modTime.IsZero():
+		return MtimeUnknown
+	case modTime.Before(created.Add(-reproBuildWindow)):
+		return MtimeStale
+	case modTime.After(created.Add(reproBuildWindow)):
+		return MtimeFuture
+	}
+	return MtimeBuilt
+}
+
+// MtimeGroup counts the files of a layer whose modification times fall in a bucket.
+type MtimeGroup struct {
+	Bucket    string
+	Files     int
+	SizeBytes uint64
+}
+
+// MtimeGroups groups the files the layer writes by the bucket their modification times fall in (see MtimeBucket),
+// in MtimeBuckets order, leaving out the buckets without files (nil when the tree of the layer is not loaded).
+func (l *Layer) MtimeGroups() []MtimeGroup {
+	if l.Tree == nil {
+		return nil
+	}
+	groups := make(map[string]*MtimeGroup)
+	visitLayerFiles(l.Tree, func(node *filetree.FileNode) {
+		bucket := MtimeBucket(node.Data.FileInfo.ModTime, l.Created)
+		group, exists := groups[bucket]
+		if !exists {
+			group = &MtimeGroup{Bucket: bucket}
+			groups[bucket] = group
+		}
+		group.Files++
+		group.SizeBytes += uint64(node.Data.FileInfo.Size)
+	})
+
+	var result []MtimeGroup
+	for _, bucket := range MtimeBuckets {
+		if group, exists := groups[bucket]; exists {
+			result = append(result, *group)
+		}
+	}
+	return result
+}
+
+// FileMtimes tells the bucket the modification time of every file of the image falls in, relative to the creation
+// of the layer that last wrote it.
+type FileMtimes struct {
+	// the buckets of the files each layer writes, by path
+	layers []map[string]string
+}
+
+// NewFileMtimes buckets the modification times of the files of every layer (the layers whose trees are not loaded
+// have none).
+func NewFileMtimes(layers []*Layer) *FileMtimes {
+	mtimes := &FileMtimes{layers: make([]map[string]string, len(layers))}
+	for idx, layer := range layers {
+		if layer.Tree != nil {
+			mtimes.layers[idx] = layerMtimes(layer.Tree, layer.Created)
+		}
+	}
+	return mtimes
+}
+
+// Bucket returns the bucket of the file as of the given layer: the one of the last layer up to it that wrote the file
+// (empty when none did, or for directories).
+func (m *FileMtimes) Bucket(layer int, filePath string) string {
+	if layer >= len(m.layers) {
+		layer = len(m.layers) - 1
+	}
+	for idx := layer; idx >= 0; idx-- {
+		if bucket, exists := m.layers[idx][filePath]; exists {
+			return bucket
+		}
+	}
+	return ""
+}
+
+// layerMtimes buckets the modification times of the files of the layer tree, by path.
+func layerMtimes(tree *filetree.FileTree, created time.Time) map[string]string {
+	buckets := make(map[string]string)
+	visitLayerFiles(tree, func(node *filetree.FileNode) {
+		buckets[node.Path()] = MtimeBucket(node.Data.FileInfo.ModTime, created)
+	})
+	return buckets
+}
+
+// visitLayerFiles visits the files of the layer tree (neither the directories nor the whiteouts).
+func visitLayerFiles(tree *filetree.FileTree, visit func(node *filetree.FileNode)) {
+	err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if node.Data.FileInfo.IsDir || len(node.Children) > 0 || node.IsWhiteout() {
+			return nil
+		}
+		visit(node)
+		return nil
+	}, nil)
+	if err != nil {
+		logrus.Errorf("unable to bucket the modification times of the files: %+v", err)
+	}
+}
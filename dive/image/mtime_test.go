@@ -0,0 +1,77 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestMtimeBucket(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		modTime  time.Time
+		created  time.Time
+		expected string
+	}{
+		{name: "during the build", modTime: created.Add(-10 * time.Minute), created: created, expected: MtimeBuilt},
+		{name: "copied", modTime: created.AddDate(-1, 0, 0), created: created, expected: MtimeStale},
+		{name: "layer creation time", modTime: created, created: created, expected: MtimeClamped},
+		{name: "epoch", modTime: time.Unix(0, 0), created: created, expected: MtimeClamped},
+		{name: "future", modTime: created.Add(48 * time.Hour), created: created, expected: MtimeFuture},
+		{name: "no creation time", modTime: created, expected: MtimeUnknown},
+	}
+	for _, c := range cases {
+		if actual := MtimeBucket(c.modTime, c.created); actual != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestFileMtimes(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tree := func(files map[string]filetree.FileInfo) *filetree.FileTree {
+		result := filetree.NewFileTree()
+		for path, info := range files {
+			if _, _, err := result.AddPath(path, info); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		return result
+	}
+	layers := []*Layer{
+		{Index: 0, Created: created, Tree: tree(map[string]filetree.FileInfo{
+			"/app/vendor.js": {Size: 10, ModTime: created.AddDate(0, -3, 0)},
+			"/app/main.js":   {Size: 5, ModTime: created.AddDate(0, -3, 0)},
+		})},
+		{Index: 1, Created: created.Add(time.Hour * 24), Tree: tree(map[string]filetree.FileInfo{
+			"/app/main.js":       {Size: 7, ModTime: created.Add(time.Hour * 24)},
+			"/app/dist/index.js": {Size: 20, ModTime: created.Add(time.Hour * 23)},
+		})},
+	}
+
+	expected := []MtimeGroup{{Bucket: MtimeBuilt, Files: 1, SizeBytes: 20}, {Bucket: MtimeClamped, Files: 1, SizeBytes: 7}}
+	if actual := layers[1].MtimeGroups(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected groups %+v, got %+v", expected, actual)
+	}
+
+	mtimes := NewFileMtimes(layers)
+	lookups := []struct {
+		layer    int
+		path     string
+		expected string
+	}{
+		{layer: 0, path: "/app/main.js", expected: MtimeStale},
+		{layer: 1, path: "/app/main.js", expected: MtimeClamped},
+		{layer: 1, path: "/app/vendor.js", expected: MtimeStale},
+		{layer: 0, path: "/app/dist/index.js", expected: ""},
+		{layer: 1, path: "/app", expected: ""},
+	}
+	for _, lookup := range lookups {
+		if actual := mtimes.Bucket(lookup.layer, lookup.path); actual != lookup.expected {
+			t.Errorf("%s as of layer %d: expected %q, got %q", lookup.path, lookup.layer, lookup.expected, actual)
+		}
+	}
+}
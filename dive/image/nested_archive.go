@@ -0,0 +1,256 @@
+package image
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the largest archive read into memory to be browsed (the contents of nested archives are kept in memory)
+const maxNestedArchiveBytes = 512 * 1024 * 1024
+
+// the formats of nested archives
+const (
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// the archive formats told apart by the file name (gzip compression is also detected by the contents)
+var nestedArchiveFormats = map[string]string{
+	".tar": ArchiveTar,
+	".tgz": ArchiveTarGz,
+	".gz":  ArchiveTarGz,
+	".zip": ArchiveZip,
+	".jar": ArchiveZip,
+	".war": ArchiveZip,
+	".ear": ArchiveZip,
+	".whl": ArchiveZip,
+	".egg": ArchiveZip,
+	".apk": ArchiveZip,
+	".aar": ArchiveZip,
+}
+
+// NestedArchive is an archive file within the image (or within another archive) that can be browsed like a directory.
+// The archive is kept in memory, so that the archives nested within it can be opened in turn.
+type NestedArchive struct {
+	Name   string
+	Format string
+	// the archive contents, with the size of every file as extracted
+	Tree *filetree.FileTree
+	// the size of the archive file itself, and of every file within it
+	SizeBytes      uint64
+	ExtractedBytes uint64
+	Entries        int
+
+	contents []byte
+}
+
+// IsNestedArchive indicates if the file name is that of an archive that can be browsed.
+func IsNestedArchive(name string) bool {
+	return nestedArchiveFormat(name) != ""
+}
+
+// nestedArchiveFormat returns the format of the archive with the given name (empty if the file is not an archive).
+func nestedArchiveFormat(name string) string {
+	name = strings.ToLower(path.Base(name))
+	if strings.HasSuffix(name, ".tar.gz") {
+		return ArchiveTarGz
+	}
+	if strings.HasSuffix(name, ".gz") {
+		// only gzipped tarballs are archives (other gzipped files are not)
+		return ""
+	}
+	return nestedArchiveFormats[path.Ext(name)]
+}
+
+// ReadNestedArchive reads the archive with the given name into memory and lists its contents.
+func ReadNestedArchive(name string, reader io.Reader) (*NestedArchive, error) {
+	format := nestedArchiveFormat(name)
+	if format == "" {
+		return nil, fmt.Errorf("'%s' is not a tar or zip archive", name)
+	}
+
+	contents, err := ioutil.ReadAll(io.LimitReader(reader, maxNestedArchiveBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > maxNestedArchiveBytes {
+		return nil, fmt.Errorf("'%s' is larger than %d MB", name, maxNestedArchiveBytes/1024/1024)
+	}
+	// a tarball that is not compressed despite its name (or the other way around)
+	if format != ArchiveZip {
+		format = ArchiveTar
+		if bytes.HasPrefix(contents, []byte{0x1f, 0x8b}) {
+			format = ArchiveTarGz
+		}
+	}
+
+	archive := &NestedArchive{
+		Name:      name,
+		Format:    format,
+		Tree:      filetree.NewFileTree(),
+		SizeBytes: uint64(len(contents)),
+		contents:  contents,
+	}
+	archive.Tree.Name = path.Base(name)
+
+	if format == ArchiveZip {
+		err = archive.readZip()
+	} else {
+		err = archive.readTar()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read '%s': %v", name, err)
+	}
+	return archive, nil
+}
+
+// entryPath normalizes the path of an archive entry (e.g. "./lib/" or "lib") to an absolute path (e.g. "/lib").
+func entryPath(name string) string {
+	return path.Clean("/" + strings.TrimPrefix(name, "./"))
+}
+
+func (archive *NestedArchive) add(name string, info filetree.FileInfo) error {
+	entry := entryPath(name)
+	if entry == "/" {
+		return nil
+	}
+	info.Path = entry
+	if _, _, err := archive.Tree.AddPath(entry, info); err != nil {
+		return err
+	}
+	if !info.IsDir {
+		archive.Entries++
+		archive.ExtractedBytes += uint64(info.Size)
+	}
+	return nil
+}
+
+func (archive *NestedArchive) readZip() error {
+	reader, err := zip.NewReader(bytes.NewReader(archive.contents), int64(len(archive.contents)))
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		isDir := strings.HasSuffix(file.Name, "/")
+		typeFlag := byte(tar.TypeReg)
+		if isDir {
+			typeFlag = tar.TypeDir
+		}
+		info := filetree.FileInfo{
+			TypeFlag: typeFlag,
+			Size:     int64(file.UncompressedSize64),
+			Mode:     file.Mode(),
+			IsDir:    isDir,
+		}
+		if isDir {
+			info.Size = 0
+		}
+		if err := archive.add(file.Name, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (archive *NestedArchive) readTar() error {
+	reader, closer, err := archive.tarReader()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		info := filetree.FileInfo{
+			TypeFlag: header.Typeflag,
+			Linkname: header.Linkname,
+			Size:     header.Size,
+			Mode:     header.FileInfo().Mode(),
+			Uid:      header.Uid,
+			Gid:      header.Gid,
+			IsDir:    header.FileInfo().IsDir(),
+		}
+		if info.IsDir {
+			info.Size = 0
+		}
+		if err := archive.add(header.Name, info); err != nil {
+			return err
+		}
+	}
+}
+
+// tarReader reads the tarball from the start (through gzip if it is compressed).
+func (archive *NestedArchive) tarReader() (*tar.Reader, io.Closer, error) {
+	if archive.Format == ArchiveTarGz {
+		decompressed, err := gzip.NewReader(bytes.NewReader(archive.contents))
+		if err != nil {
+			return nil, nil, err
+		}
+		return tar.NewReader(decompressed), decompressed, nil
+	}
+	return tar.NewReader(bytes.NewReader(archive.contents)), ioutil.NopCloser(nil), nil
+}
+
+// OpenFile opens the file at the given path within the archive (e.g. to read an archive nested within it).
+func (archive *NestedArchive) OpenFile(filePath string) (io.ReadCloser, error) {
+	filePath = entryPath(filePath)
+
+	if archive.Format == ArchiveZip {
+		reader, err := zip.NewReader(bytes.NewReader(archive.contents), int64(len(archive.contents)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range reader.File {
+			if entryPath(file.Name) == filePath && !strings.HasSuffix(file.Name, "/") {
+				return file.Open()
+			}
+		}
+		return nil, fmt.Errorf("'%s' does not exist in '%s'", filePath, archive.Name)
+	}
+
+	reader, closer, err := archive.tarReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			closer.Close()
+			return nil, err
+		}
+		if entryPath(header.Name) == filePath && (header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA) {
+			return &archiveEntryReader{Reader: reader, closer: closer}, nil
+		}
+	}
+	closer.Close()
+	return nil, fmt.Errorf("'%s' does not exist in '%s'", filePath, archive.Name)
+}
+
+// archiveEntryReader reads a single tarball entry, releasing the decompressor when closed.
+type archiveEntryReader struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (r *archiveEntryReader) Close() error {
+	return r.closer.Close()
+}
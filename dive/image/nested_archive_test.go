@@ -0,0 +1,172 @@
+package image
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+type archiveEntry struct {
+	name    string
+	content string
+}
+
+func makeTarball(t *testing.T, compress bool, entries ...archiveEntry) []byte {
+	var buffer bytes.Buffer
+	var out io.Writer = &buffer
+	var gzipWriter *gzip.Writer
+	if compress {
+		gzipWriter = gzip.NewWriter(&buffer)
+		out = gzipWriter
+	}
+	writer := tar.NewWriter(out)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(entry.content))}
+		if entry.name[len(entry.name)-1] == '/' {
+			header = &tar.Header{Name: entry.name, Typeflag: tar.TypeDir, Mode: 0755}
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func makeZipArchive(t *testing.T, entries ...archiveEntry) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		file, err := writer.Create(entry.name)
+		if err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if _, err := file.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestIsNestedArchive(t *testing.T) {
+	cases := map[string]bool{
+		"/app/app.jar":            true,
+		"/opt/dist/release.TGZ":   true,
+		"/opt/dist/node.tar.gz":   true,
+		"/srv/site.zip":           true,
+		"/var/log/messages.1.gz":  false,
+		"/usr/bin/tar":            false,
+		"/usr/share/doc/zip.html": false,
+	}
+	for name, expected := range cases {
+		if actual := IsNestedArchive(name); actual != expected {
+			t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		}
+	}
+}
+
+func TestReadNestedArchiveZip(t *testing.T) {
+	// a fat jar with a nested jar (read again from the outer jar)
+	inner := makeZipArchive(t, archiveEntry{"org/lib/Util.class", "util"})
+	outer := makeZipArchive(t,
+		archiveEntry{"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n"},
+		archiveEntry{"BOOT-INF/lib/", ""},
+		archiveEntry{"BOOT-INF/lib/util.jar", string(inner)},
+	)
+
+	archive, err := ReadNestedArchive("/app/app.jar", bytes.NewReader(outer))
+	if err != nil {
+		t.Fatalf("unable to read the archive: %v", err)
+	}
+	if archive.Format != ArchiveZip || archive.Entries != 2 || archive.SizeBytes != uint64(len(outer)) {
+		t.Errorf("unexpected archive summary: %s, %d entries, %d bytes", archive.Format, archive.Entries, archive.SizeBytes)
+	}
+	if expected := uint64(len("Manifest-Version: 1.0\n") + len(inner)); archive.ExtractedBytes != expected {
+		t.Errorf("expected %d extracted bytes, got %d", expected, archive.ExtractedBytes)
+	}
+	node, err := archive.Tree.GetNode("/BOOT-INF/lib/util.jar")
+	if err != nil || node == nil || node.Data.FileInfo.Size != int64(len(inner)) {
+		t.Fatalf("expected the nested jar in the tree, got %v (%v)", node, err)
+	}
+
+	reader, err := archive.OpenFile("/BOOT-INF/lib/util.jar")
+	if err != nil {
+		t.Fatalf("unable to open the nested jar: %v", err)
+	}
+	defer reader.Close()
+	nested, err := ReadNestedArchive("util.jar", reader)
+	if err != nil {
+		t.Fatalf("unable to read the nested jar: %v", err)
+	}
+	if _, err := nested.Tree.GetNode("/org/lib/Util.class"); err != nil {
+		t.Errorf("expected the class within the nested jar: %v", err)
+	}
+
+	if _, err := archive.OpenFile("/BOOT-INF/lib"); err == nil {
+		t.Errorf("expected an error opening a directory")
+	}
+}
+
+func TestReadNestedArchiveTarball(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		tarball := makeTarball(t, compress,
+			archiveEntry{"./", ""},
+			archiveEntry{"./node/", ""},
+			archiveEntry{"./node/bin/node", "binary"},
+			archiveEntry{"./node/README.md", "readme"},
+		)
+
+		// the compression is detected by the contents rather than the name
+		archive, err := ReadNestedArchive("/opt/node.tar.gz", bytes.NewReader(tarball))
+		if err != nil {
+			t.Fatalf("unable to read the archive: %v", err)
+		}
+		expectedFormat := ArchiveTar
+		if compress {
+			expectedFormat = ArchiveTarGz
+		}
+		if archive.Format != expectedFormat || archive.Entries != 2 || archive.ExtractedBytes != 12 {
+			t.Errorf("unexpected archive summary: %s, %d entries, %d bytes", archive.Format, archive.Entries, archive.ExtractedBytes)
+		}
+
+		reader, err := archive.OpenFile("node/README.md")
+		if err != nil {
+			t.Fatalf("unable to open a file within the archive: %v", err)
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil || string(content) != "readme" {
+			t.Errorf("expected the file contents, got %q (%v)", content, err)
+		}
+
+		if _, err := archive.OpenFile("/node/missing"); err == nil {
+			t.Errorf("expected an error opening a missing file")
+		}
+	}
+}
+
+func TestReadNestedArchiveInvalid(t *testing.T) {
+	if _, err := ReadNestedArchive("/app/notes.txt", bytes.NewReader([]byte("notes"))); err == nil {
+		t.Errorf("expected an error for a file that is not an archive")
+	}
+	if _, err := ReadNestedArchive("/app/app.jar", bytes.NewReader([]byte("not a zip"))); err == nil {
+		t.Errorf("expected an error for a corrupt archive")
+	}
+}
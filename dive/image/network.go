@@ -0,0 +1,117 @@
+package image
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// CABundle is a custom bundle of CA certificates (e.g. the CA of a proxy intercepting TLS) trusted in addition to the
+// system ones, for every network operation: registries, remote container engines, and the tools dive runs (see
+// ToolEnvironment). The zero bundle trusts the system CA certificates only.
+type CABundle struct {
+	// the path of the PEM bundle (empty when there is none)
+	Path string
+	// the certificates it holds
+	certificates [][]byte
+}
+
+// LoadCABundle reads the CA certificates of the given PEM bundle. An empty path is the zero bundle.
+func LoadCABundle(path string) (CABundle, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return CABundle{}, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return CABundle{}, fmt.Errorf("unable to read the CA bundle: %v", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(contents) {
+		return CABundle{}, fmt.Errorf("no CA certificate found in %s", path)
+	}
+	return CABundle{Path: path, certificates: [][]byte{contents}}, nil
+}
+
+// RootCAs returns the system CA certificates along with those of the bundle, and the given PEM encoded certificates
+// (nil when there are none of either, so that the system ones are used).
+func (bundle CABundle) RootCAs(extra ...[]byte) *x509.CertPool {
+	certificates := append(append([][]byte(nil), bundle.certificates...), extra...)
+	if len(certificates) == 0 {
+		return nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, contents := range certificates {
+		pool.AppendCertsFromPEM(contents)
+	}
+	return pool
+}
+
+// NewHTTPTransport returns a transport for network operations: it goes through the proxy the environment names
+// (HTTPS_PROXY, HTTP_PROXY and NO_PROXY, upper or lower case) and trusts the bundle. The TLS config is cloned, so that
+// callers can add to it (e.g. a client certificate).
+func (bundle CABundle) NewHTTPTransport(config *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if config.RootCAs == nil {
+		config.RootCAs = bundle.RootCAs()
+	}
+	transport.TLSClientConfig = config
+	return transport
+}
+
+// Trust adds the bundle to the certificates trusted by the transport (when it is an *http.Transport that uses TLS),
+// e.g. for the transport of a container engine client configured from the environment.
+func (bundle CABundle) Trust(transport http.RoundTripper) {
+	httpTransport, ok := transport.(*http.Transport)
+	if !ok || httpTransport.TLSClientConfig == nil || bundle.Path == "" {
+		return
+	}
+	if httpTransport.TLSClientConfig.RootCAs == nil {
+		httpTransport.TLSClientConfig.RootCAs = bundle.RootCAs()
+		return
+	}
+	for _, contents := range bundle.certificates {
+		httpTransport.TLSClientConfig.RootCAs.AppendCertsFromPEM(contents)
+	}
+}
+
+// ProxyFor describes the proxy requests to the URL go through (empty when they are sent directly).
+func ProxyFor(target string) string {
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return ""
+	}
+	proxy, err := http.ProxyFromEnvironment(request)
+	if err != nil || proxy == nil {
+		return ""
+	}
+	redacted := *proxy
+	if redacted.User != nil {
+		redacted.User = url.User(redacted.User.Username())
+	}
+	return redacted.String()
+}
+
+// ToolEnvironment returns the environment of the tools dive runs that reach the network (scanners, cosign): the
+// environment of dive (including its proxy settings), pointing SSL_CERT_FILE at the bundle unless it is set already.
+func (bundle CABundle) ToolEnvironment(extra ...string) []string {
+	env := append(os.Environ(), extra...)
+	if bundle.Path != "" && os.Getenv("SSL_CERT_FILE") == "" {
+		env = append(env, "SSL_CERT_FILE="+bundle.Path)
+	}
+	return env
+}
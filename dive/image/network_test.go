@@ -0,0 +1,45 @@
+package image
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	client := &http.Client{Transport: CABundle{}.NewHTTPTransport(nil)}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatalf("expected the certificate of the server not to be trusted")
+	}
+
+	ca, err := LoadCABundle(bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client = &http.Client{Transport: ca.NewHTTPTransport(nil)}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the CA bundle to be trusted: %v", err)
+	}
+	response.Body.Close()
+
+	t.Setenv("SSL_CERT_FILE", "")
+	if env := strings.Join(ca.ToolEnvironment("EXTRA=1"), "\n"); !strings.Contains(env, "SSL_CERT_FILE="+bundle) || !strings.Contains(env, "EXTRA=1") {
+		t.Errorf("expected the tools to trust the CA bundle, got %s", env)
+	}
+
+	if _, err := LoadCABundle(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Errorf("expected a missing bundle to fail")
+	}
+}
@@ -0,0 +1,26 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOffline is the failure of an operation that needs the network while offline mode is enabled (see NetworkPolicy).
+var ErrOffline = errors.New("network access is disabled (offline mode)")
+
+// NetworkPolicy decides if operations may reach the network. In offline mode every operation that would reach the
+// network (registries, pulls and builds by a container engine, signature verification, vulnerability database
+// updates) fails right away instead of being attempted. The operations that do reach the network trust the CA bundle.
+type NetworkPolicy struct {
+	Offline bool
+	CA      CABundle
+}
+
+// RequireNetwork fails with ErrOffline when offline mode is enabled, naming the operation that would need the network
+// (e.g. "pulling 'alpine:latest'"). The failure is permanent, so it is never retried.
+func (policy NetworkPolicy) RequireNetwork(operation string) error {
+	if !policy.Offline {
+		return nil
+	}
+	return Permanent(fmt.Errorf("%s requires network access: %w", operation, ErrOffline))
+}
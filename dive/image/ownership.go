@@ -0,0 +1,117 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// OwnerUsage is the number of files (and their bytes) owned by a user and group.
+type OwnerUsage struct {
+	Uid       int
+	Gid       int
+	Files     int
+	SizeBytes uint64
+}
+
+// Owner returns the owner as chown takes it (e.g. "1000:1000").
+func (usage OwnerUsage) Owner() string {
+	return fmt.Sprintf("%d:%d", usage.Uid, usage.Gid)
+}
+
+// Root indicates if the files are owned by root.
+func (usage OwnerUsage) Root() bool {
+	return usage.Uid == 0
+}
+
+// OwnershipSummary groups the bytes of a set of files by their owner, the owners with the most bytes first.
+type OwnershipSummary struct {
+	Owners    []OwnerUsage
+	Files     int
+	SizeBytes uint64
+	// the bytes of the files owned by root
+	RootBytes uint64
+}
+
+// RootPercent is the share of the bytes owned by root (0 when there are no bytes).
+func (summary *OwnershipSummary) RootPercent() float64 {
+	if summary == nil || summary.SizeBytes == 0 {
+		return 0
+	}
+	return float64(summary.RootBytes) / float64(summary.SizeBytes)
+}
+
+// Ownership breaks the bytes of the image down by owner, for the files each layer adds or modifies and for the final
+// image filesystem, showing how much of the image is owned by root.
+type Ownership struct {
+	// by layer index
+	Layers []*OwnershipSummary
+	Image  *OwnershipSummary
+}
+
+// ownershipTally accumulates the files of an OwnershipSummary by owner.
+type ownershipTally map[[2]int]*OwnerUsage
+
+func (tally ownershipTally) add(info filetree.FileInfo) {
+	owner := [2]int{info.Uid, info.Gid}
+	usage, exists := tally[owner]
+	if !exists {
+		usage = &OwnerUsage{Uid: info.Uid, Gid: info.Gid}
+		tally[owner] = usage
+	}
+	usage.Files++
+	usage.SizeBytes += uint64(info.Size)
+}
+
+func (tally ownershipTally) summary() *OwnershipSummary {
+	summary := &OwnershipSummary{Owners: make([]OwnerUsage, 0, len(tally))}
+	for _, usage := range tally {
+		summary.Owners = append(summary.Owners, *usage)
+		summary.Files += usage.Files
+		summary.SizeBytes += usage.SizeBytes
+		if usage.Root() {
+			summary.RootBytes += usage.SizeBytes
+		}
+	}
+	sort.Slice(summary.Owners, func(i, j int) bool {
+		left, right := summary.Owners[i], summary.Owners[j]
+		if left.SizeBytes != right.SizeBytes {
+			return left.SizeBytes > right.SizeBytes
+		}
+		if left.Uid != right.Uid {
+			return left.Uid < right.Uid
+		}
+		return left.Gid < right.Gid
+	})
+	return summary
+}
+
+// AnalyzeOwnership groups the files (not the directories) of every layer and of the final image by their owner.
+func AnalyzeOwnership(trees []*filetree.FileTree) *Ownership {
+	result := &Ownership{Layers: make([]*OwnershipSummary, len(trees))}
+	for layer, tree := range trees {
+		tally := make(ownershipTally)
+		err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+			info := node.Data.FileInfo
+			if !node.IsWhiteout() && !info.IsDir && info.TypeFlag != 0 && len(node.Children) == 0 {
+				tally.add(info)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to list the layer files by owner: %+v", err)
+		}
+		result.Layers[layer] = tally.summary()
+	}
+
+	tally := make(ownershipTally)
+	for _, entry := range auditedEntries(trees) {
+		if !entry.info.IsDir {
+			tally.add(entry.info)
+		}
+	}
+	result.Image = tally.summary()
+	return result
+}
@@ -0,0 +1,66 @@
+package image
+
+import (
+	"archive/tar"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAnalyzeOwnership(t *testing.T) {
+	trees := make([]*filetree.FileTree, 2)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if info.TypeFlag == 0 {
+			info.TypeFlag = tar.TypeReg
+		}
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/usr/bin/sh", filetree.FileInfo{Size: 600})
+	add(trees[0], "/usr/bin/ls", filetree.FileInfo{Size: 300})
+	add(trees[0], "/var/mail/app", filetree.FileInfo{Size: 100, Gid: 8})
+	add(trees[1], "/app/server", filetree.FileInfo{Size: 1000, Uid: 1000, Gid: 1000})
+	add(trees[1], "/app", filetree.FileInfo{TypeFlag: tar.TypeDir, IsDir: true, Uid: 1000, Gid: 1000})
+	// ls was removed, and sh replaced by a smaller one owned by the app user
+	add(trees[1], "/usr/bin/.wh.ls", filetree.FileInfo{})
+	add(trees[1], "/usr/bin/sh", filetree.FileInfo{Size: 200, Uid: 1000, Gid: 1000})
+
+	ownership := AnalyzeOwnership(trees)
+
+	expectedLayers := []*OwnershipSummary{
+		{
+			Owners:    []OwnerUsage{{Uid: 0, Gid: 0, Files: 2, SizeBytes: 900}, {Uid: 0, Gid: 8, Files: 1, SizeBytes: 100}},
+			Files:     3,
+			SizeBytes: 1000,
+			RootBytes: 1000,
+		},
+		{
+			Owners:    []OwnerUsage{{Uid: 1000, Gid: 1000, Files: 2, SizeBytes: 1200}},
+			Files:     2,
+			SizeBytes: 1200,
+		},
+	}
+	if !reflect.DeepEqual(ownership.Layers, expectedLayers) {
+		t.Errorf("expected layers:\n%+v\n%+v\ngot:\n%+v\n%+v", expectedLayers[0], expectedLayers[1], ownership.Layers[0], ownership.Layers[1])
+	}
+
+	expectedImage := &OwnershipSummary{
+		Owners:    []OwnerUsage{{Uid: 1000, Gid: 1000, Files: 2, SizeBytes: 1200}, {Uid: 0, Gid: 8, Files: 1, SizeBytes: 100}},
+		Files:     3,
+		SizeBytes: 1300,
+		RootBytes: 100,
+	}
+	if !reflect.DeepEqual(ownership.Image, expectedImage) {
+		t.Errorf("expected image:\n%+v\ngot:\n%+v", expectedImage, ownership.Image)
+	}
+	if percent := ownership.Image.RootPercent(); percent != 100.0/1300 {
+		t.Errorf("unexpected root percent: %v", percent)
+	}
+}
@@ -0,0 +1,560 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	// registers the sqlite driver the rpm database is read with
+	_ "modernc.org/sqlite"
+)
+
+// the package managers whose databases are read
+const (
+	PackageManagerDpkg = "dpkg"
+	PackageManagerApk  = "apk"
+	PackageManagerRpm  = "rpm"
+)
+
+const (
+	dpkgStatus  = "/var/lib/dpkg/status"
+	dpkgInfoDir = "/var/lib/dpkg/info"
+	apkDatabase = "/lib/apk/db/installed"
+)
+
+// the sqlite rpm databases (rpm 4.16 and later), and the Berkeley DB and ndb databases of older rpm versions, which
+// are not read
+var (
+	rpmDatabases       = []string{"/var/lib/rpm/rpmdb.sqlite", "/usr/lib/sysimage/rpm/rpmdb.sqlite"}
+	rpmLegacyDatabases = []string{"/var/lib/rpm/Packages", "/var/lib/rpm/Packages.db", "/usr/lib/sysimage/rpm/Packages", "/usr/lib/sysimage/rpm/Packages.db"}
+)
+
+// the rpm header tags and types read
+const (
+	rpmTagName       = 1000
+	rpmTagVersion    = 1001
+	rpmTagRelease    = 1002
+	rpmTagEpoch      = 1003
+	rpmTagDirIndexes = 1116
+	rpmTagBaseNames  = 1117
+	rpmTagDirNames   = 1118
+
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// InstalledPackage is a package the package database of the image lists, with the files it installed.
+type InstalledPackage struct {
+	Manager string
+	Name    string
+	Version string
+	// the files (not directories) of the package that are in the image
+	Files []string
+	// the size of those files
+	SizeBytes uint64
+	// the last layer that wrote files of the package (-1 when none of its files are in the image)
+	Layer int
+}
+
+// String describes the package (e.g. "openssl 3.0.2-0ubuntu1 (dpkg)").
+func (p *InstalledPackage) String() string {
+	return fmt.Sprintf("%s %s (%s)", p.Name, p.Version, p.Manager)
+}
+
+// the directories of the package databases and package manager metadata, whose files are not orphans
+var packageMetadataDirs = []string{"/var/lib/dpkg", "/lib/apk/db", "/var/lib/rpm", "/usr/lib/sysimage/rpm"}
+
+// OrphanFile is a file of the image that no installed package owns (e.g. an application artifact, a leftover of the
+// build or a binary downloaded by hand).
+type OrphanFile struct {
+	Path      string
+	SizeBytes uint64
+	// the layer that last wrote the file
+	Layer int
+}
+
+// Packages are the packages installed in the image and the files they own.
+type Packages struct {
+	// the packages, the largest first
+	Packages []*InstalledPackage
+	// the files no package owns, the largest first (none when the image has no package database)
+	Orphans []OrphanFile
+	// the package that installed each file, by path
+	owners map[string]*InstalledPackage
+}
+
+// Owner returns the package that installed the given file (nil when no package did).
+func (p *Packages) Owner(filePath string) *InstalledPackage {
+	if p == nil {
+		return nil
+	}
+	return p.owners[path.Clean("/"+filePath)]
+}
+
+// Find returns the package of the given manager with the given name (nil when it is not installed).
+func (p *Packages) Find(manager, name string) *InstalledPackage {
+	if p == nil {
+		return nil
+	}
+	for _, pkg := range p.Packages {
+		if pkg.Manager == manager && pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// SizeBytes returns the size of all files owned by a package.
+func (p *Packages) SizeBytes() uint64 {
+	var size uint64
+	for _, pkg := range p.Packages {
+		size += pkg.SizeBytes
+	}
+	return size
+}
+
+// OrphanBytes returns the size of the files no package owns.
+func (p *Packages) OrphanBytes() uint64 {
+	var size uint64
+	for _, orphan := range p.Orphans {
+		size += orphan.SizeBytes
+	}
+	return size
+}
+
+// Managers returns the package managers that installed packages, in order.
+func (p *Packages) Managers() []string {
+	var managers []string
+	seen := make(map[string]bool)
+	for _, pkg := range p.Packages {
+		if !seen[pkg.Manager] {
+			seen[pkg.Manager] = true
+			managers = append(managers, pkg.Manager)
+		}
+	}
+	sort.Strings(managers)
+	return managers
+}
+
+// ReadPackages reads the package databases of the image (dpkg, apk and the sqlite database of rpm) as seen from the
+// top layer, and attributes the files of the image to the packages that installed them. Databases that cannot be
+// read are logged and left out.
+func ReadPackages(trees []*filetree.FileTree, contents ContentReader) *Packages {
+	packages := &Packages{owners: make(map[string]*InstalledPackage)}
+	if contents == nil || len(trees) == 0 {
+		return packages
+	}
+
+	var installed []*InstalledPackage
+	for _, read := range []func([]*filetree.FileTree, ContentReader) ([]*InstalledPackage, error){readDpkgPackages, readApkPackages, readRpmPackages} {
+		found, err := read(trees, contents)
+		if err != nil {
+			logrus.Debugf("unable to read a package database: %+v", err)
+		}
+		installed = append(installed, found...)
+	}
+
+	last := len(trees) - 1
+	for _, pkg := range installed {
+		files := pkg.Files
+		pkg.Files, pkg.Layer = nil, -1
+		for _, file := range files {
+			file = path.Clean("/" + file)
+			idx, node, exists := FileLayer(trees, last, file)
+			if !exists || node.Data.FileInfo.IsDir || len(node.Children) > 0 {
+				continue
+			}
+			// files listed by more than one package (e.g. shared config) stay with the first
+			if _, claimed := packages.owners[file]; claimed {
+				continue
+			}
+			packages.owners[file] = pkg
+			pkg.Files = append(pkg.Files, file)
+			pkg.SizeBytes += uint64(node.Data.FileInfo.Size)
+			if idx > pkg.Layer {
+				pkg.Layer = idx
+			}
+		}
+		packages.Packages = append(packages.Packages, pkg)
+	}
+	sort.SliceStable(packages.Packages, func(i, j int) bool {
+		if packages.Packages[i].SizeBytes != packages.Packages[j].SizeBytes {
+			return packages.Packages[i].SizeBytes > packages.Packages[j].SizeBytes
+		}
+		return packages.Packages[i].Name < packages.Packages[j].Name
+	})
+
+	if len(packages.Packages) > 0 {
+		packages.Orphans = findOrphans(trees, packages.owners)
+	}
+	return packages
+}
+
+// findOrphans lists the files of the image (as seen from the top layer) that none of the given owners installed,
+// leaving out the package databases themselves.
+func findOrphans(trees []*filetree.FileTree, owners map[string]*InstalledPackage) []OrphanFile {
+	var orphans []OrphanFile
+	seen := make(map[string]bool)
+	for idx := len(trees) - 1; idx >= 0; idx-- {
+		if trees[idx] == nil {
+			continue
+		}
+		_ = trees[idx].VisitDepthParentFirst(func(node *filetree.FileNode) error {
+			filePath := node.Path()
+			if seen[filePath] || node.IsWhiteout() || node.Data.FileInfo.IsDir || len(node.Children) > 0 {
+				return nil
+			}
+			seen[filePath] = true
+			if _, owned := owners[filePath]; owned || isPackageMetadata(filePath) {
+				return nil
+			}
+			// the file may be deleted by a later layer
+			if layer, _, exists := FileLayer(trees, len(trees)-1, filePath); !exists || layer != idx {
+				return nil
+			}
+			orphans = append(orphans, OrphanFile{Path: filePath, SizeBytes: uint64(node.Data.FileInfo.Size), Layer: idx})
+			return nil
+		}, nil)
+	}
+	sort.SliceStable(orphans, func(i, j int) bool {
+		if orphans[i].SizeBytes != orphans[j].SizeBytes {
+			return orphans[i].SizeBytes > orphans[j].SizeBytes
+		}
+		return orphans[i].Path < orphans[j].Path
+	})
+	return orphans
+}
+
+// isPackageMetadata tells if the file is within the package databases.
+func isPackageMetadata(filePath string) bool {
+	for _, dir := range packageMetadataDirs {
+		if strings.HasPrefix(filePath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// exists tells if the given file is in the image (as seen from the top layer).
+func exists(trees []*filetree.FileTree, filePath string) bool {
+	_, _, found := FileLayer(trees, len(trees)-1, filePath)
+	return found
+}
+
+// readDpkgPackages reads the installed packages from the dpkg status file, and their files from the lists in the
+// dpkg info directory (named after the package, with the architecture for multi-arch packages).
+func readDpkgPackages(trees []*filetree.FileTree, contents ContentReader) ([]*InstalledPackage, error) {
+	if !exists(trees, dpkgStatus) {
+		return nil, nil
+	}
+	var packages []*InstalledPackage
+	err := ReadFiles(contents, trees, []string{dpkgStatus}, func(_ string, reader io.Reader) error {
+		packages = parseDpkgStatus(reader)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the list files in the info directory of any layer (a whited-out list is left out by ReadFiles)
+	byList := make(map[string]*InstalledPackage)
+	byName := make(map[string]*InstalledPackage)
+	for _, pkg := range packages {
+		byName[pkg.Name] = pkg
+	}
+	for _, tree := range trees {
+		if tree == nil {
+			continue
+		}
+		dir, err := tree.GetNode(dpkgInfoDir)
+		if err != nil || dir == nil {
+			continue
+		}
+		for child := range dir.Children {
+			if !strings.HasSuffix(child, ".list") {
+				continue
+			}
+			name := strings.TrimSuffix(child, ".list")
+			if idx := strings.Index(name, ":"); idx >= 0 {
+				name = name[:idx]
+			}
+			if pkg, installed := byName[name]; installed {
+				byList[path.Join(dpkgInfoDir, child)] = pkg
+			}
+		}
+	}
+
+	lists := make([]string, 0, len(byList))
+	for list := range byList {
+		lists = append(lists, list)
+	}
+	sort.Strings(lists)
+	err = ReadFiles(contents, trees, lists, func(list string, reader io.Reader) error {
+		pkg := byList[list]
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && line != "/." {
+				pkg.Files = append(pkg.Files, path.Clean(line))
+			}
+		}
+		return nil
+	})
+	return packages, err
+}
+
+// parseDpkgStatus reads the installed packages (and their versions) from the paragraphs of the dpkg status file.
+func parseDpkgStatus(reader io.Reader) []*InstalledPackage {
+	var packages []*InstalledPackage
+	var name, version, status string
+	flush := func() {
+		if name != "" && strings.HasSuffix(status, " installed") {
+			packages = append(packages, &InstalledPackage{Manager: PackageManagerDpkg, Name: name, Version: version})
+		}
+		name, version, status = "", "", ""
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "Package:"):
+			name = strings.TrimSpace(line[len("Package:"):])
+		case strings.HasPrefix(line, "Version:"):
+			version = strings.TrimSpace(line[len("Version:"):])
+		case strings.HasPrefix(line, "Status:"):
+			status = strings.TrimSpace(line[len("Status:"):])
+		}
+	}
+	flush()
+	return packages
+}
+
+// readApkPackages reads the installed packages and their files from the apk database.
+func readApkPackages(trees []*filetree.FileTree, contents ContentReader) ([]*InstalledPackage, error) {
+	if !exists(trees, apkDatabase) {
+		return nil, nil
+	}
+	var packages []*InstalledPackage
+	err := ReadFiles(contents, trees, []string{apkDatabase}, func(_ string, reader io.Reader) error {
+		packages = parseApkDatabase(reader)
+		return nil
+	})
+	return packages, err
+}
+
+// parseApkDatabase reads the packages of the apk database: a paragraph per package, with its name (P:), version (V:),
+// and its directories (F:) each followed by the files within them (R:).
+func parseApkDatabase(reader io.Reader) []*InstalledPackage {
+	var packages []*InstalledPackage
+	var pkg *InstalledPackage
+	var dir string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			pkg, dir = nil, ""
+		case strings.HasPrefix(line, "P:"):
+			pkg = &InstalledPackage{Manager: PackageManagerApk, Name: line[2:]}
+			packages = append(packages, pkg)
+		case strings.HasPrefix(line, "V:") && pkg != nil:
+			pkg.Version = line[2:]
+		case strings.HasPrefix(line, "F:"):
+			dir = line[2:]
+		case strings.HasPrefix(line, "R:") && pkg != nil:
+			pkg.Files = append(pkg.Files, path.Join("/", dir, line[2:]))
+		}
+	}
+	return packages
+}
+
+// readRpmPackages reads the installed packages and their files from the headers in the sqlite rpm database (which is
+// copied to a temporary file to be opened).
+func readRpmPackages(trees []*filetree.FileTree, contents ContentReader) ([]*InstalledPackage, error) {
+	for _, database := range rpmLegacyDatabases {
+		if exists(trees, database) {
+			logrus.Debugf("the rpm database %s is not in the sqlite format, which is the only one read", database)
+		}
+	}
+
+	var database string
+	for _, candidate := range rpmDatabases {
+		if exists(trees, candidate) {
+			database = candidate
+			break
+		}
+	}
+	if database == "" {
+		return nil, nil
+	}
+
+	file, err := ioutil.TempFile("", "dive-rpmdb.*.sqlite")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	err = ReadFiles(contents, trees, []string{database}, func(_ string, reader io.Reader) error {
+		_, err := io.Copy(file, reader)
+		return err
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readRpmDatabase(file.Name())
+}
+
+// readRpmDatabase reads the package headers from the Packages table of a sqlite rpm database.
+func readRpmDatabase(databasePath string) ([]*InstalledPackage, error) {
+	db, err := sql.Open("sqlite", databasePath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT blob FROM Packages")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the rpm database: %v", err)
+	}
+	defer rows.Close()
+
+	var packages []*InstalledPackage
+	for rows.Next() {
+		var blob []byte
+		if err := rows.Scan(&blob); err != nil {
+			return nil, err
+		}
+		pkg, err := parseRpmHeader(blob)
+		if err != nil {
+			logrus.Debugf("unable to parse an rpm header: %+v", err)
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, rows.Err()
+}
+
+// rpmHeader holds the tags of an rpm header blob: the number of index entries and the size of the data store, the
+// index entries (tag, type, offset and count) and the data store they point into.
+type rpmHeader struct {
+	entries map[int32]rpmHeaderEntry
+	data    []byte
+}
+
+type rpmHeaderEntry struct {
+	kind   uint32
+	offset int32
+	count  uint32
+}
+
+// parseRpmHeader reads the name, version and files of a package from its rpm header blob.
+func parseRpmHeader(blob []byte) (*InstalledPackage, error) {
+	if len(blob) < 8 {
+		return nil, fmt.Errorf("the header is truncated")
+	}
+	indexCount := binary.BigEndian.Uint32(blob[0:4])
+	dataLength := binary.BigEndian.Uint32(blob[4:8])
+	dataStart := uint64(8) + uint64(indexCount)*16
+	if dataStart+uint64(dataLength) > uint64(len(blob)) {
+		return nil, fmt.Errorf("the header is truncated")
+	}
+
+	header := rpmHeader{entries: make(map[int32]rpmHeaderEntry), data: blob[dataStart : dataStart+uint64(dataLength)]}
+	for idx := uint32(0); idx < indexCount; idx++ {
+		entry := blob[8+idx*16 : 8+idx*16+16]
+		header.entries[int32(binary.BigEndian.Uint32(entry[0:4]))] = rpmHeaderEntry{
+			kind:   binary.BigEndian.Uint32(entry[4:8]),
+			offset: int32(binary.BigEndian.Uint32(entry[8:12])),
+			count:  binary.BigEndian.Uint32(entry[12:16]),
+		}
+	}
+
+	pkg := &InstalledPackage{Manager: PackageManagerRpm, Name: header.string(rpmTagName)}
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("the header has no package name")
+	}
+	pkg.Version = header.string(rpmTagVersion)
+	if release := header.string(rpmTagRelease); release != "" {
+		pkg.Version += "-" + release
+	}
+	if epoch := header.int32s(rpmTagEpoch); len(epoch) == 1 && epoch[0] != 0 {
+		pkg.Version = fmt.Sprintf("%d:%s", epoch[0], pkg.Version)
+	}
+
+	baseNames, dirNames, dirIndexes := header.strings(rpmTagBaseNames), header.strings(rpmTagDirNames), header.int32s(rpmTagDirIndexes)
+	for idx, baseName := range baseNames {
+		if idx >= len(dirIndexes) || dirIndexes[idx] < 0 || int(dirIndexes[idx]) >= len(dirNames) {
+			break
+		}
+		pkg.Files = append(pkg.Files, dirNames[dirIndexes[idx]]+baseName)
+	}
+	return pkg, nil
+}
+
+// strings returns the strings of a tag (a single string for string tags).
+func (h rpmHeader) strings(tag int32) []string {
+	entry, exists := h.entries[tag]
+	if !exists || entry.offset < 0 || int(entry.offset) > len(h.data) {
+		return nil
+	}
+	switch entry.kind {
+	case rpmTypeString, rpmTypeStringArray, rpmTypeI18NString:
+	default:
+		return nil
+	}
+	count := entry.count
+	if entry.kind == rpmTypeString {
+		count = 1
+	}
+
+	var values []string
+	data := h.data[entry.offset:]
+	for idx := uint32(0); idx < count; idx++ {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			break
+		}
+		values = append(values, string(data[:end]))
+		data = data[end+1:]
+	}
+	return values
+}
+
+// string returns the (first) string of a tag.
+func (h rpmHeader) string(tag int32) string {
+	if values := h.strings(tag); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// int32s returns the integers of a tag.
+func (h rpmHeader) int32s(tag int32) []int32 {
+	entry, exists := h.entries[tag]
+	if !exists || entry.kind != rpmTypeInt32 || entry.offset < 0 || uint64(entry.offset)+uint64(entry.count)*4 > uint64(len(h.data)) {
+		return nil
+	}
+	values := make([]int32, entry.count)
+	for idx := range values {
+		start := int(entry.offset) + idx*4
+		values[idx] = int32(binary.BigEndian.Uint32(h.data[start : start+4]))
+	}
+	return values
+}
@@ -0,0 +1,206 @@
+package image
+
+import (
+	"archive/tar"
+	"database/sql"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// walkContents reads the files from a map of paths to contents, counting the passes over the layers
+type walkContents struct {
+	mapContents
+	walks int
+}
+
+func (contents *walkContents) WalkFiles(layer int, paths map[string]bool, visitor func(filePath string, reader io.Reader) error) error {
+	contents.walks++
+	for filePath := range paths {
+		if err := visitor(filePath, strings.NewReader(contents.mapContents[filePath])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rpmHeaderBlob builds an rpm header blob with the given string, string array and int32 array tags.
+func rpmHeaderBlob(strs map[int32]string, arrays map[int32][]string, ints map[int32][]int32) []byte {
+	var index, data []byte
+	addEntry := func(tag int32, kind uint32, count int) {
+		entry := make([]byte, 16)
+		binary.BigEndian.PutUint32(entry[0:4], uint32(tag))
+		binary.BigEndian.PutUint32(entry[4:8], kind)
+		binary.BigEndian.PutUint32(entry[8:12], uint32(len(data)))
+		binary.BigEndian.PutUint32(entry[12:16], uint32(count))
+		index = append(index, entry...)
+	}
+	for tag, value := range ints {
+		addEntry(tag, rpmTypeInt32, len(value))
+		for _, number := range value {
+			data = binary.BigEndian.AppendUint32(data, uint32(number))
+		}
+	}
+	for tag, value := range strs {
+		addEntry(tag, rpmTypeString, 1)
+		data = append(append(data, value...), 0)
+	}
+	for tag, value := range arrays {
+		addEntry(tag, rpmTypeStringArray, len(value))
+		for _, item := range value {
+			data = append(append(data, item...), 0)
+		}
+	}
+	blob := binary.BigEndian.AppendUint32(nil, uint32(len(index)/16))
+	blob = binary.BigEndian.AppendUint32(blob, uint32(len(data)))
+	return append(append(blob, index...), data...)
+}
+
+func packageTrees(t *testing.T, layers ...[]string) []*filetree.FileTree {
+	var trees []*filetree.FileTree
+	for _, paths := range layers {
+		tree := filetree.NewFileTree()
+		for _, path := range paths {
+			if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: 100, TypeFlag: tar.TypeReg}); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		trees = append(trees, tree)
+	}
+	return trees
+}
+
+func TestReadPackages_Dpkg(t *testing.T) {
+	trees := packageTrees(t,
+		[]string{"/var/lib/dpkg/status", "/var/lib/dpkg/info/libc6:amd64.list", "/var/lib/dpkg/info/removed.list", "/lib/libc.so.6", "/etc/ld.so.conf", "/opt/tool", "/app/server"},
+		[]string{"/var/lib/dpkg/status", "/var/lib/dpkg/info/curl.list", "/usr/bin/curl", "/usr/bin/unowned", "/opt/.wh.tool", "/app/server"},
+	)
+	contents := &walkContents{mapContents: mapContents{
+		"/var/lib/dpkg/status": "Package: libc6\nStatus: install ok installed\nArchitecture: amd64\nVersion: 2.36-9\n\n" +
+			"Package: curl\nStatus: install ok installed\nVersion: 7.88.1-10\n\n" +
+			"Package: removed\nStatus: deinstall ok config-files\nVersion: 1.0\n",
+		"/var/lib/dpkg/info/libc6:amd64.list": "/.\n/lib\n/lib/libc.so.6\n/etc/ld.so.conf\n/usr/share/doc/libc6/gone\n",
+		"/var/lib/dpkg/info/curl.list":        "/.\n/usr\n/usr/bin\n/usr/bin/curl\n",
+	}}
+
+	packages := ReadPackages(trees, contents)
+
+	if len(packages.Packages) != 2 {
+		t.Fatalf("expected 2 packages, got %v", packages.Packages)
+	}
+	libc := packages.Find(PackageManagerDpkg, "libc6")
+	if libc == nil || libc.Version != "2.36-9" || libc.SizeBytes != 200 || libc.Layer != 0 || strings.Join(libc.Files, ",") != "/lib/libc.so.6,/etc/ld.so.conf" {
+		t.Errorf("unexpected package: %+v", libc)
+	}
+	if packages.Packages[0] != libc || packages.SizeBytes() != 300 {
+		t.Errorf("expected the largest package first, got %v", packages.Packages)
+	}
+	if owner := packages.Owner("/usr/bin/curl"); owner == nil || owner.String() != "curl 7.88.1-10 (dpkg)" || owner.Layer != 1 {
+		t.Errorf("unexpected owner: %+v", owner)
+	}
+	for _, path := range []string{"/usr/bin/unowned", "/usr/bin", "/var/lib/dpkg/status"} {
+		if owner := packages.Owner(path); owner != nil {
+			t.Errorf("expected %s not to be owned, got %v", path, owner)
+		}
+	}
+	// the files no package installed, leaving out the package database and the deleted files
+	if !reflect.DeepEqual(packages.Orphans, []OrphanFile{{Path: "/app/server", SizeBytes: 100, Layer: 1}, {Path: "/usr/bin/unowned", SizeBytes: 100, Layer: 1}}) || packages.OrphanBytes() != 200 {
+		t.Errorf("unexpected orphans: %+v", packages.Orphans)
+	}
+	// the status is read from the top layer, and both lists from the layers that wrote them
+	if contents.walks != 3 {
+		t.Errorf("expected a pass per layer read, got %d", contents.walks)
+	}
+}
+
+func TestReadPackages_Apk(t *testing.T) {
+	trees := packageTrees(t, []string{"/lib/apk/db/installed", "/bin/busybox", "/etc/securetty", "/lib/ld-musl-x86_64.so.1"})
+	contents := mapContents{
+		"/lib/apk/db/installed": "P:busybox\nV:1.36.1-r0\nF:bin\nR:busybox\nF:etc\nR:securetty\n\nP:musl\nV:1.2.4-r2\nF:lib\nR:ld-musl-x86_64.so.1\n",
+	}
+
+	packages := ReadPackages(trees, contents)
+
+	busybox := packages.Find(PackageManagerApk, "busybox")
+	if busybox == nil || busybox.Version != "1.36.1-r0" || strings.Join(busybox.Files, ",") != "/bin/busybox,/etc/securetty" {
+		t.Errorf("unexpected package: %+v", busybox)
+	}
+	if owner := packages.Owner("/lib/ld-musl-x86_64.so.1"); owner == nil || owner.Name != "musl" {
+		t.Errorf("unexpected owner: %+v", owner)
+	}
+	if len(packages.Orphans) != 0 {
+		t.Errorf("expected no orphans, got %+v", packages.Orphans)
+	}
+	if managers := packages.Managers(); strings.Join(managers, ",") != "apk" {
+		t.Errorf("unexpected managers: %v", managers)
+	}
+}
+
+func TestParseRpmHeader(t *testing.T) {
+	blob := rpmHeaderBlob(
+		map[int32]string{rpmTagName: "openssl-libs", rpmTagVersion: "3.0.7", rpmTagRelease: "27.el9"},
+		map[int32][]string{rpmTagDirNames: {"/usr/lib64/", "/etc/pki/tls/"}, rpmTagBaseNames: {"libssl.so.3", "libcrypto.so.3", "openssl.cnf"}},
+		map[int32][]int32{rpmTagEpoch: {1}, rpmTagDirIndexes: {0, 0, 1}},
+	)
+
+	pkg, err := parseRpmHeader(blob)
+	if err != nil {
+		t.Fatalf("unable to parse header: %v", err)
+	}
+	if pkg.Name != "openssl-libs" || pkg.Version != "1:3.0.7-27.el9" || pkg.Manager != PackageManagerRpm {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+	if strings.Join(pkg.Files, ",") != "/usr/lib64/libssl.so.3,/usr/lib64/libcrypto.so.3,/etc/pki/tls/openssl.cnf" {
+		t.Errorf("unexpected files: %v", pkg.Files)
+	}
+
+	if _, err := parseRpmHeader(blob[:20]); err == nil {
+		t.Errorf("expected an error for a truncated header")
+	}
+}
+
+func TestReadRpmDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dive-rpmdb-test")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	databasePath := filepath.Join(dir, "rpmdb.sqlite")
+
+	db, err := sql.Open("sqlite", databasePath)
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	_, err = db.Exec("CREATE TABLE Packages (hnum INTEGER PRIMARY KEY AUTOINCREMENT, blob BLOB NOT NULL)")
+	for _, name := range []string{"bash", "coreutils"} {
+		if err == nil {
+			blob := rpmHeaderBlob(map[int32]string{rpmTagName: name, rpmTagVersion: "1.0", rpmTagRelease: "1"}, map[int32][]string{rpmTagDirNames: {"/usr/bin/"}, rpmTagBaseNames: {name}}, map[int32][]int32{rpmTagDirIndexes: {0}})
+			_, err = db.Exec("INSERT INTO Packages (blob) VALUES (?)", blob)
+		}
+	}
+	db.Close()
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	packages, err := readRpmDatabase(databasePath)
+	if err != nil {
+		t.Fatalf("unable to read the database: %v", err)
+	}
+	var names []string
+	for _, pkg := range packages {
+		names = append(names, pkg.String()+" "+strings.Join(pkg.Files, ","))
+	}
+	sort.Strings(names)
+	if strings.Join(names, "; ") != "bash 1.0-1 (rpm) /usr/bin/bash; coreutils 1.0-1 (rpm) /usr/bin/coreutils" {
+		t.Errorf("unexpected packages: %v", names)
+	}
+}
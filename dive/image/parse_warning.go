@@ -0,0 +1,92 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+)
+
+// the kinds of layer tar entries that are skipped while parsing a layer
+const (
+	// a header that cannot be decoded (the rest of the layer tar cannot be read past it)
+	ParseWarningHeader = "malformed header"
+	// a name that leads outside of the root of the image filesystem (e.g. "../etc/passwd")
+	ParseWarningTraversal = "path traversal"
+	// a size no file of an image could have
+	ParseWarningSize = "absurd size"
+	// an entry type that has no place in a layer tar (e.g. a global PAX header)
+	ParseWarningType = "unsupported entry"
+)
+
+// the most skipped entries that are described for each layer (all of them are counted)
+const maxParseWarnings = 100
+
+// ParseWarning describes a layer tar entry that was skipped while parsing the layer.
+type ParseWarning struct {
+	Kind string
+	// the name of the entry as given by the layer tar (empty when the header could not be read)
+	Name   string
+	Detail string
+}
+
+func (w ParseWarning) String() string {
+	if w.Name == "" {
+		return fmt.Sprintf("%s: %s", w.Kind, w.Detail)
+	}
+	return fmt.Sprintf("%s: '%s' (%s)", w.Kind, w.Name, w.Detail)
+}
+
+// ParseWarnings collects the entries of a layer tar that were skipped while parsing it, rather than failing the whole
+// analysis. Only the first entries are described, all of them are counted by kind.
+type ParseWarnings struct {
+	Warnings []ParseWarning
+	Counts   map[string]int
+	Total    int
+}
+
+// Add records a skipped entry. Adding to a nil collection does nothing.
+func (w *ParseWarnings) Add(kind, name, detail string) {
+	if w == nil {
+		return
+	}
+	if w.Counts == nil {
+		w.Counts = make(map[string]int)
+	}
+	w.Counts[kind]++
+	w.Total++
+	if len(w.Warnings) < maxParseWarnings {
+		w.Warnings = append(w.Warnings, ParseWarning{Kind: kind, Name: name, Detail: detail})
+	}
+}
+
+// Len is the number of entries skipped (0 for a nil collection).
+func (w *ParseWarnings) Len() int {
+	if w == nil {
+		return 0
+	}
+	return w.Total
+}
+
+// Summary lists the counts by kind, e.g. "2 path traversal, 1 malformed header".
+func (w *ParseWarnings) Summary() string {
+	if w.Len() == 0 {
+		return ""
+	}
+	kinds := make([]string, 0, len(w.Counts))
+	for kind := range w.Counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if w.Counts[kinds[i]] != w.Counts[kinds[j]] {
+			return w.Counts[kinds[i]] > w.Counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	summary := ""
+	for idx, kind := range kinds {
+		if idx > 0 {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%d %s", w.Counts[kind], kind)
+	}
+	return summary
+}
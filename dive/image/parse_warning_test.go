@@ -0,0 +1,30 @@
+package image
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseWarnings(t *testing.T) {
+	var unset *ParseWarnings
+	unset.Add(ParseWarningSize, "var/huge", "2199023255552 bytes declared")
+	if unset.Len() != 0 || unset.Summary() != "" {
+		t.Errorf("expected a nil collection to stay empty")
+	}
+
+	warnings := &ParseWarnings{}
+	for idx := 0; idx < maxParseWarnings+5; idx++ {
+		warnings.Add(ParseWarningTraversal, fmt.Sprintf("../%d", idx), "the name leads outside of the image filesystem")
+	}
+	warnings.Add(ParseWarningHeader, "", "archive/tar: invalid tar header")
+
+	if warnings.Len() != maxParseWarnings+6 || len(warnings.Warnings) != maxParseWarnings {
+		t.Errorf("expected %d warnings counted and %d described, got %d and %d", maxParseWarnings+6, maxParseWarnings, warnings.Len(), len(warnings.Warnings))
+	}
+	if expected := "105 path traversal, 1 malformed header"; warnings.Summary() != expected {
+		t.Errorf("expected the summary %q, got %q", expected, warnings.Summary())
+	}
+	if expected := "path traversal: '../0' (the name leads outside of the image filesystem)"; warnings.Warnings[0].String() != expected {
+		t.Errorf("expected %q, got %q", expected, warnings.Warnings[0].String())
+	}
+}
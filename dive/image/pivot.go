@@ -0,0 +1,110 @@
+package image
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// PivotCell is the state of a file at a layer: the change the layer made to it (empty when the layer did not touch
+// it), and its size when it exists at that layer.
+type PivotCell struct {
+	Change    string
+	Present   bool
+	SizeBytes uint64
+}
+
+// PivotRow is a file along with its state at every layer.
+type PivotRow struct {
+	Path  string
+	Cells []PivotCell
+}
+
+// Pivot is a cross-section of the image: a set of files (rows) by the layers (columns).
+type Pivot struct {
+	Layers []*Layer
+	Rows   []PivotRow
+	// some layers are not loaded yet (lazy images), so their changes are missing
+	Partial bool
+}
+
+// BuildPivot traces the given files through every layer.
+func BuildPivot(layers []*Layer, trees []*filetree.FileTree, paths []string) *Pivot {
+	pivot := &Pivot{Layers: layers, Rows: make([]PivotRow, 0, len(paths))}
+	for _, filePath := range paths {
+		provenance := TracePath(layers, trees, filePath)
+		pivot.Partial = pivot.Partial || provenance.Partial
+
+		row := PivotRow{Path: provenance.Path, Cells: make([]PivotCell, len(trees))}
+		changes := provenance.Changes
+		var current PivotCell
+		for idx := range row.Cells {
+			current.Change = ""
+			if len(changes) > 0 && changes[0].Layer == pivotLayerIndex(layers, idx) {
+				current.Change = changes[0].Change
+				current.Present = changes[0].Change != PathDeleted
+				current.SizeBytes = changes[0].SizeBytes
+				changes = changes[1:]
+			}
+			row.Cells[idx] = current
+		}
+		pivot.Rows = append(pivot.Rows, row)
+	}
+	return pivot
+}
+
+// pivotLayerIndex returns the index reported for the layer at the given position (as TracePath reports it).
+func pivotLayerIndex(layers []*Layer, idx int) int {
+	if idx < len(layers) {
+		return layers[idx].Index
+	}
+	return idx
+}
+
+// WriteCSV writes the pivot with a row per file and a column per layer. A cell holds the change the layer made to the
+// file followed by its size in bytes (e.g. "added 1024"), only the size when the file is carried over unchanged, and
+// nothing when the file does not exist at that layer.
+func (p *Pivot) WriteCSV(writer io.Writer) error {
+	out := csv.NewWriter(writer)
+
+	header := []string{"path"}
+	columns := len(p.Layers)
+	if len(p.Rows) > 0 {
+		columns = len(p.Rows[0].Cells)
+	}
+	for idx := 0; idx < columns; idx++ {
+		column := fmt.Sprintf("layer %d", pivotLayerIndex(p.Layers, idx))
+		if idx < len(p.Layers) {
+			if command := strings.Join(strings.Fields(p.Layers[idx].Command), " "); command != "" {
+				column += ": " + command
+			}
+		}
+		header = append(header, column)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range p.Rows {
+		record := []string{row.Path}
+		for _, cell := range row.Cells {
+			var value []string
+			if cell.Change != "" {
+				value = append(value, cell.Change)
+			}
+			if cell.Present {
+				value = append(value, strconv.FormatUint(cell.SizeBytes, 10))
+			}
+			record = append(record, strings.Join(value, " "))
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,60 @@
+package image
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestBuildPivot(t *testing.T) {
+	trees := make([]*filetree.FileTree, 4)
+	layers := make([]*Layer, len(trees))
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+		layers[idx] = &Layer{Index: idx, Command: "RUN  step " + string(rune('a'+idx))}
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc/app.conf", 10)
+	add(trees[2], "/etc/app.conf", 20)
+	add(trees[1], "/tmp/cache.db", 300)
+	add(trees[3], "/tmp/.wh.cache.db", 0)
+
+	pivot := BuildPivot(layers, trees, []string{"/etc/app.conf", "tmp/cache.db"})
+
+	expected := []PivotRow{
+		{Path: "/etc/app.conf", Cells: []PivotCell{
+			{Change: PathAdded, Present: true, SizeBytes: 10},
+			{Present: true, SizeBytes: 10},
+			{Change: PathModified, Present: true, SizeBytes: 20},
+			{Present: true, SizeBytes: 20},
+		}},
+		{Path: "/tmp/cache.db", Cells: []PivotCell{
+			{},
+			{Change: PathAdded, Present: true, SizeBytes: 300},
+			{Present: true, SizeBytes: 300},
+			{Change: PathDeleted},
+		}},
+	}
+	if !reflect.DeepEqual(pivot.Rows, expected) {
+		t.Errorf("expected rows %+v, got %+v", expected, pivot.Rows)
+	}
+
+	var buffer bytes.Buffer
+	if err := pivot.WriteCSV(&buffer); err != nil {
+		t.Fatalf("unable to write the csv: %v", err)
+	}
+	expectedCSV := "path,layer 0: RUN step a,layer 1: RUN step b,layer 2: RUN step c,layer 3: RUN step d\n" +
+		"/etc/app.conf,added 10,10,modified 20,20\n" +
+		"/tmp/cache.db,,added 300,300,deleted\n"
+	if buffer.String() != expectedCSV {
+		t.Errorf("expected csv:\n%s\ngot:\n%s", expectedCSV, buffer.String())
+	}
+}
@@ -1,13 +1,15 @@
+//go:build linux
 // +build linux
 
 package podman
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 )
 
-func buildImageFromCli(buildArgs []string) (string, error) {
+func buildImageFromCli(ctx context.Context, buildArgs []string) (string, error) {
 	iidfile, err := ioutil.TempFile("/tmp", "dive.*.iid")
 	if err != nil {
 		return "", err
@@ -15,7 +17,7 @@ func buildImageFromCli(buildArgs []string) (string, error) {
 	defer os.Remove(iidfile.Name())
 
 	allArgs := append([]string{"--iidfile", iidfile.Name()}, buildArgs...)
-	err = runPodmanCmd("build", allArgs...)
+	err = runPodmanCmd(ctx, "build", allArgs...)
 	if err != nil {
 		return "", err
 	}
@@ -1,8 +1,10 @@
+//go:build linux
 // +build linux
 
 package podman
 
 import (
+	"context"
 	"fmt"
 	"github.com/wagoodman/dive/utils"
 	"io"
@@ -11,14 +13,14 @@ import (
 )
 
 // runPodmanCmd runs a given Podman command in the current tty
-func runPodmanCmd(cmdStr string, args ...string) error {
+func runPodmanCmd(ctx context.Context, cmdStr string, args ...string) error {
 	if !isPodmanClientBinaryAvailable() {
 		return fmt.Errorf("cannot find podman client executable")
 	}
 
 	allArgs := utils.CleanArgs(append([]string{cmdStr}, args...))
 
-	cmd := exec.Command("podman", allArgs...)
+	cmd := exec.CommandContext(ctx, "podman", allArgs...)
 	cmd.Env = os.Environ()
 
 	cmd.Stdout = os.Stdout
@@ -28,12 +30,12 @@ func runPodmanCmd(cmdStr string, args ...string) error {
 	return cmd.Run()
 }
 
-func streamPodmanCmd(args ...string) (error, io.Reader) {
+func streamPodmanCmd(ctx context.Context, args ...string) (error, io.Reader) {
 	if !isPodmanClientBinaryAvailable() {
 		return fmt.Errorf("cannot find podman client executable"), nil
 	}
 
-	cmd := exec.Command("podman", utils.CleanArgs(args)...)
+	cmd := exec.CommandContext(ctx, "podman", utils.CleanArgs(args)...)
 	cmd.Env = os.Environ()
 
 	reader, writer, err := os.Pipe()
@@ -1,30 +1,36 @@
 package podman
 
 import (
+	"context"
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/dive/image/docker"
-	"io/ioutil"
 )
 
-type resolver struct{}
+type resolver struct {
+	options image.ResolverOptions
+}
 
-func NewResolverFromEngine() *resolver {
-	return &resolver{}
+func NewResolverFromEngine(options image.ResolverOptions) *resolver {
+	return &resolver{options: options}
 }
 
-func (r *resolver) Build(args []string) (*image.Image, error) {
-	id, err := buildImageFromCli(args)
+func (r *resolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	// the engine may pull (or check) the base images of the build
+	if err := r.options.Network.RequireNetwork("building an image"); err != nil {
+		return nil, err
+	}
+	id, err := buildImageFromCli(ctx, args)
 	if err != nil {
 		return nil, err
 	}
-	return r.Fetch(id)
+	return r.Fetch(ctx, id)
 }
 
-func (r *resolver) Fetch(id string) (*image.Image, error) {
+func (r *resolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
 	// todo: add podman fetch attempt via varlink first...
 
-	img, err := r.resolveFromDockerArchive(id)
+	img, err := r.resolveFromDockerArchive(ctx, id)
 	if err == nil {
 		return img, err
 	}
@@ -32,13 +38,28 @@ func (r *resolver) Fetch(id string) (*image.Image, error) {
 	return nil, fmt.Errorf("unable to resolve image '%s': %+v", id, err)
 }
 
-func (r *resolver) resolveFromDockerArchive(id string) (*image.Image, error) {
-	err, reader := streamPodmanCmd("image", "save", id)
+func (r *resolver) resolveFromDockerArchive(ctx context.Context, id string) (*image.Image, error) {
+	// podman image exists fails (with exit status 1) when the image is not in the local store
+	available := runPodmanCmd(ctx, "image", "exists", id) == nil
+	pull, err := r.options.Pull.ShouldPull(id, available, r.options.Network)
+	if err != nil {
+		return nil, err
+	}
+	if pull {
+		if !available {
+			fmt.Println("Image not available locally. Trying to pull '" + id + "'...")
+		}
+		if err := runPodmanCmd(ctx, "pull", id); err != nil {
+			return nil, err
+		}
+	}
+
+	err, reader := streamPodmanCmd(ctx, "image", "save", id)
 	if err != nil {
 		return nil, err
 	}
 
-	img, err := docker.NewImageArchive(ioutil.NopCloser(reader))
+	img, err := docker.ReadImageArchive(ctx, r.options.IO.Throttle(reader), 0, r.options)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,306 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ProgressStage is the part of a long operation a progress event is about.
+type ProgressStage string
+
+const (
+	// pulling the image into the container engine from its registry, layer by layer
+	StagePulling ProgressStage = "pulling"
+	// reading the image from its source, parsing the layers as they are read
+	StageFetching ProgressStage = "fetching"
+	// analyzing the parsed layers
+	StageAnalyzing ProgressStage = "analyzing"
+	// hashing the file contents of lazy layers in the background
+	StageHashing ProgressStage = "hashing"
+)
+
+// how often progress is reported while bytes are read (layers and steps are reported as soon as they are done)
+const progressInterval = 100 * time.Millisecond
+
+// ProgressEvent is a snapshot of the progress of a stage. Totals are 0 when they are not known upfront.
+type ProgressEvent struct {
+	Stage       ProgressStage
+	BytesRead   uint64
+	BytesTotal  uint64
+	LayersDone  int
+	LayersTotal int
+	FilesParsed int
+	// the analysis steps done, out of the total
+	StepsDone  int
+	StepsTotal int
+	Elapsed    time.Duration
+	// the estimated time left, from the rate so far (0 when it cannot be estimated)
+	ETA time.Duration
+	// the progress of every layer, in the order the layers were first reported (only while pulling)
+	Layers []LayerProgress
+	// the stage is over (the last event of the stage)
+	Done bool
+}
+
+// LayerProgress is the progress of a single layer of a pull (e.g. "Downloading", 12 MB of 30 MB).
+type LayerProgress struct {
+	ID      string
+	Status  string
+	Current uint64
+	// 0 when not known
+	Total uint64
+}
+
+// String renders the layer progress on a single line (e.g. "2f4b0a1c3d9e: Downloading 12 MB / 30 MB").
+func (layer LayerProgress) String() string {
+	line := fmt.Sprintf("%s: %s", layer.ID, layer.Status)
+	switch {
+	case layer.Total > 0:
+		line += fmt.Sprintf(" %s / %s", humanize.Bytes(layer.Current), humanize.Bytes(layer.Total))
+	case layer.Current > 0:
+		line += " " + humanize.Bytes(layer.Current)
+	}
+	return line
+}
+
+// String renders the event on a single line (e.g. "fetching: 120 MB / 300 MB, 3 layers, 12034 files, ETA 14s").
+func (event ProgressEvent) String() string {
+	var parts []string
+	switch {
+	case event.BytesTotal > 0:
+		parts = append(parts, fmt.Sprintf("%s / %s", humanize.Bytes(event.BytesRead), humanize.Bytes(event.BytesTotal)))
+	case event.BytesRead > 0:
+		parts = append(parts, humanize.Bytes(event.BytesRead))
+	}
+	switch {
+	case event.LayersTotal > 0:
+		parts = append(parts, fmt.Sprintf("%d/%d layers", event.LayersDone, event.LayersTotal))
+	case event.LayersDone > 0:
+		parts = append(parts, fmt.Sprintf("%d layers", event.LayersDone))
+	}
+	if event.FilesParsed > 0 {
+		parts = append(parts, fmt.Sprintf("%d files", event.FilesParsed))
+	}
+	if event.StepsTotal > 0 {
+		parts = append(parts, fmt.Sprintf("step %d/%d", event.StepsDone, event.StepsTotal))
+	}
+	if event.ETA > 0 {
+		parts = append(parts, "ETA "+event.ETA.Round(time.Second).String())
+	}
+	if len(parts) == 0 {
+		return string(event.Stage)
+	}
+	return string(event.Stage) + ": " + strings.Join(parts, ", ")
+}
+
+// ProgressFunc is called with every progress event (from the goroutine doing the work, so it should return quickly).
+type ProgressFunc func(ProgressEvent)
+
+// ProgressBus delivers the progress events of the operations given its context (see WithProgressBus) to every
+// subscribed listener.
+type ProgressBus struct {
+	lock      sync.Mutex
+	listeners map[int]ProgressFunc
+	nextID    int
+}
+
+func NewProgressBus() *ProgressBus {
+	return &ProgressBus{listeners: make(map[int]ProgressFunc)}
+}
+
+// Subscribe registers a listener, returning the function that unsubscribes it.
+func (bus *ProgressBus) Subscribe(listener ProgressFunc) func() {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	id := bus.nextID
+	bus.nextID++
+	bus.listeners[id] = listener
+	return func() {
+		bus.lock.Lock()
+		defer bus.lock.Unlock()
+		delete(bus.listeners, id)
+	}
+}
+
+func (bus *ProgressBus) publish(event ProgressEvent) {
+	bus.lock.Lock()
+	listeners := make([]ProgressFunc, 0, len(bus.listeners))
+	for _, listener := range bus.listeners {
+		listeners = append(listeners, listener)
+	}
+	bus.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+type progressBusKey struct{}
+
+// WithProgressBus returns a context that reports the progress of the operations given it to the bus.
+func WithProgressBus(ctx context.Context, bus *ProgressBus) context.Context {
+	return context.WithValue(ctx, progressBusKey{}, bus)
+}
+
+// WithProgress returns a context that reports the progress of the operations given it (fetching and analyzing images)
+// to the listener, e.g. for library users to show their own progress:
+//
+//	ctx := image.WithProgress(ctx, func(event image.ProgressEvent) { log.Println(event) })
+//	analysis, err := dive.Analyze(ctx, "alpine:latest")
+func WithProgress(ctx context.Context, listener ProgressFunc) context.Context {
+	bus := NewProgressBus()
+	bus.Subscribe(listener)
+	return WithProgressBus(ctx, bus)
+}
+
+// ProgressTracker accumulates the progress of a stage and publishes it to the bus of the context it was created with.
+// A nil tracker (there is no bus) ignores every update, so that callers need not check.
+type ProgressTracker struct {
+	bus       *ProgressBus
+	lock      sync.Mutex
+	event     ProgressEvent
+	started   time.Time
+	published time.Time
+}
+
+// NewProgressTracker starts tracking a stage, returning nil when the context has no progress bus.
+func NewProgressTracker(ctx context.Context, stage ProgressStage) *ProgressTracker {
+	bus, ok := ctx.Value(progressBusKey{}).(*ProgressBus)
+	if !ok || bus == nil {
+		return nil
+	}
+	return &ProgressTracker{bus: bus, event: ProgressEvent{Stage: stage}, started: time.Now()}
+}
+
+// SetTotals sets the totals the stage is estimated against (0 leaves a total unknown).
+func (t *ProgressTracker) SetTotals(bytes uint64, layers, steps int) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.event.BytesTotal, t.event.LayersTotal, t.event.StepsTotal = bytes, layers, steps
+}
+
+// AddBytes records bytes read, publishing the progress at most every progressInterval.
+func (t *ProgressTracker) AddBytes(count int) {
+	if t == nil || count <= 0 {
+		return
+	}
+	t.update(func(event *ProgressEvent) { event.BytesRead += uint64(count) }, false)
+}
+
+// LayerDone records a parsed (or hashed) layer with the given number of files.
+func (t *ProgressTracker) LayerDone(files int) {
+	t.update(func(event *ProgressEvent) {
+		event.LayersDone++
+		event.FilesParsed += files
+	}, true)
+}
+
+// StepDone records a finished step.
+func (t *ProgressTracker) StepDone() {
+	t.update(func(event *ProgressEvent) { event.StepsDone++ }, true)
+}
+
+// Finish publishes the last event of the stage.
+func (t *ProgressTracker) Finish() {
+	t.update(func(event *ProgressEvent) { event.Done = true }, true)
+}
+
+// SetLayer records the progress of a layer (added when first reported), publishing the progress at most every
+// progressInterval unless the status of the layer changed.
+func (t *ProgressTracker) SetLayer(progress LayerProgress) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	changed := true
+	for idx := range t.event.Layers {
+		if t.event.Layers[idx].ID == progress.ID {
+			changed = t.event.Layers[idx].Status != progress.Status
+			t.event.Layers[idx] = progress
+			break
+		}
+	}
+	if changed && !t.hasLayer(progress.ID) {
+		t.event.Layers = append(t.event.Layers, progress)
+	}
+	t.lock.Unlock()
+	t.update(func(*ProgressEvent) {}, changed)
+}
+
+// hasLayer indicates if the progress of the layer was reported before (the lock must be held).
+func (t *ProgressTracker) hasLayer(id string) bool {
+	for _, layer := range t.event.Layers {
+		if layer.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Reader wraps the reader such that the bytes read through it are recorded.
+func (t *ProgressTracker) Reader(reader io.Reader) io.Reader {
+	if t == nil {
+		return reader
+	}
+	return &progressReader{reader: reader, tracker: t}
+}
+
+func (t *ProgressTracker) update(change func(*ProgressEvent), force bool) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	change(&t.event)
+	now := time.Now()
+	if !force && now.Sub(t.published) < progressInterval {
+		t.lock.Unlock()
+		return
+	}
+	t.published = now
+	event := t.event
+	event.Layers = append([]LayerProgress(nil), t.event.Layers...)
+	t.lock.Unlock()
+
+	event.Elapsed = now.Sub(t.started)
+	if !event.Done {
+		event.ETA = estimateRemaining(event)
+	}
+	t.bus.publish(event)
+}
+
+// estimateRemaining extrapolates the rate so far to the bytes, layers or steps left (the first total known).
+func estimateRemaining(event ProgressEvent) time.Duration {
+	var done, total float64
+	switch {
+	case event.BytesTotal > 0:
+		done, total = float64(event.BytesRead), float64(event.BytesTotal)
+	case event.LayersTotal > 0:
+		done, total = float64(event.LayersDone), float64(event.LayersTotal)
+	case event.StepsTotal > 0:
+		done, total = float64(event.StepsDone), float64(event.StepsTotal)
+	}
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return time.Duration(float64(event.Elapsed) * (total - done) / done)
+}
+
+type progressReader struct {
+	reader  io.Reader
+	tracker *ProgressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.tracker.AddBytes(n)
+	return n, err
+}
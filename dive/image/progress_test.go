@@ -0,0 +1,103 @@
+package image
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressTracker_WithoutBus(t *testing.T) {
+	tracker := NewProgressTracker(context.Background(), StageFetching)
+	if tracker != nil {
+		t.Fatalf("expected no tracker without a progress bus")
+	}
+	// every update is ignored
+	tracker.SetTotals(10, 1, 0)
+	tracker.LayerDone(3)
+	tracker.Finish()
+	if _, err := ioutil.ReadAll(tracker.Reader(strings.NewReader("contents"))); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestProgressTracker(t *testing.T) {
+	var events []ProgressEvent
+	ctx := WithProgress(context.Background(), func(event ProgressEvent) {
+		events = append(events, event)
+	})
+
+	tracker := NewProgressTracker(ctx, StageFetching)
+	tracker.SetTotals(16, 0, 0)
+	if _, err := ioutil.ReadAll(tracker.Reader(strings.NewReader("first layer!"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tracker.LayerDone(120)
+	tracker.LayerDone(30)
+	tracker.Finish()
+
+	if len(events) < 3 {
+		t.Fatalf("expected at least 3 events, got %+v", events)
+	}
+	last := events[len(events)-1]
+	if !last.Done || last.Stage != StageFetching || last.BytesRead != 12 || last.BytesTotal != 16 || last.LayersDone != 2 || last.FilesParsed != 150 {
+		t.Errorf("unexpected last event: %+v", last)
+	}
+	if last.ETA != 0 {
+		t.Errorf("expected no ETA once done, got %s", last.ETA)
+	}
+}
+
+func TestProgressBus_Unsubscribe(t *testing.T) {
+	bus := NewProgressBus()
+	var first, second int
+	unsubscribe := bus.Subscribe(func(ProgressEvent) { first++ })
+	bus.Subscribe(func(ProgressEvent) { second++ })
+
+	tracker := NewProgressTracker(WithProgressBus(context.Background(), bus), StageAnalyzing)
+	tracker.StepDone()
+	unsubscribe()
+	tracker.StepDone()
+
+	if first != 1 || second != 2 {
+		t.Errorf("expected 1 and 2 events, got %d and %d", first, second)
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	cases := []struct {
+		name     string
+		event    ProgressEvent
+		expected time.Duration
+	}{
+		{"bytes", ProgressEvent{BytesRead: 25, BytesTotal: 100, LayersDone: 1, LayersTotal: 2, Elapsed: 10 * time.Second}, 30 * time.Second},
+		{"layers", ProgressEvent{LayersDone: 1, LayersTotal: 3, Elapsed: 10 * time.Second}, 20 * time.Second},
+		{"steps", ProgressEvent{StepsDone: 3, StepsTotal: 4, Elapsed: 3 * time.Second}, time.Second},
+		{"nothing done", ProgressEvent{BytesTotal: 100, Elapsed: 10 * time.Second}, 0},
+		{"no totals", ProgressEvent{BytesRead: 100, Elapsed: 10 * time.Second}, 0},
+	}
+	for _, test := range cases {
+		if actual := estimateRemaining(test.event); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestProgressEvent_String(t *testing.T) {
+	event := ProgressEvent{
+		Stage:       StageFetching,
+		BytesRead:   120 * 1000 * 1000,
+		BytesTotal:  300 * 1000 * 1000,
+		LayersDone:  3,
+		FilesParsed: 12034,
+		ETA:         14*time.Second + 300*time.Millisecond,
+	}
+	expected := "fetching: 120 MB / 300 MB, 3 layers, 12034 files, ETA 14s"
+	if actual := event.String(); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual := (ProgressEvent{Stage: StageAnalyzing, StepsDone: 2, StepsTotal: 12}).String(); actual != "analyzing: step 2/12" {
+		t.Errorf("unexpected rendering: %q", actual)
+	}
+}
@@ -0,0 +1,123 @@
+package image
+
+import (
+	"path"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the ways a layer can change a path
+const (
+	PathAdded    = "added"
+	PathModified = "modified"
+	PathDeleted  = "deleted"
+)
+
+// PathChange is a layer that added, modified or deleted a path.
+type PathChange struct {
+	Layer  int
+	Change string
+	// the size of the path as written by the layer (0 when deleted)
+	SizeBytes uint64
+	Command   string
+}
+
+// PathProvenance lists every layer that touched a path, in layer order.
+type PathProvenance struct {
+	Path    string
+	Changes []PathChange
+	// the path exists in the final image (it may have been deleted by a later layer)
+	Present bool
+	// some layers are not loaded yet (lazy images), so their changes are missing
+	Partial bool
+}
+
+// FirstAdded returns the first layer that added the path (false if no layer did).
+func (p *PathProvenance) FirstAdded() (PathChange, bool) {
+	for _, change := range p.Changes {
+		if change.Change == PathAdded {
+			return change, true
+		}
+	}
+	return PathChange{}, false
+}
+
+// LastAdded returns the last layer that added the path, after it was deleted by an earlier layer (false if no layer
+// did).
+func (p *PathProvenance) LastAdded() (PathChange, bool) {
+	for idx := len(p.Changes) - 1; idx >= 0; idx-- {
+		if p.Changes[idx].Change == PathAdded {
+			return p.Changes[idx], true
+		}
+	}
+	return PathChange{}, false
+}
+
+// LastChanged returns the last layer that added, modified or deleted the path (false if no layer did).
+func (p *PathProvenance) LastChanged() (PathChange, bool) {
+	if len(p.Changes) == 0 {
+		return PathChange{}, false
+	}
+	return p.Changes[len(p.Changes)-1], true
+}
+
+// TracePath reports every layer that added, modified or deleted the given path. A path is deleted by a whiteout of
+// the path (or of one of its parents), or by an opaque parent directory the layer does not add the path back to.
+func TracePath(layers []*Layer, trees []*filetree.FileTree, filePath string) *PathProvenance {
+	filePath = path.Clean("/" + filePath)
+	provenance := &PathProvenance{Path: filePath, Changes: make([]PathChange, 0)}
+
+	for idx, tree := range trees {
+		if tree == nil {
+			provenance.Partial = true
+			continue
+		}
+
+		change := PathChange{Layer: idx}
+		if idx < len(layers) {
+			change.Layer = layers[idx].Index
+			change.Command = layers[idx].Command
+		}
+
+		node, err := tree.GetNode(filePath)
+		switch {
+		case err == nil && node != nil && node != tree.Root:
+			change.Change = PathModified
+			if !provenance.Present {
+				change.Change = PathAdded
+			}
+			change.SizeBytes = uint64(node.Data.FileInfo.Size)
+			provenance.Present = true
+		case provenance.Present && deletesPath(tree, filePath):
+			change.Change = PathDeleted
+			provenance.Present = false
+		default:
+			continue
+		}
+		provenance.Changes = append(provenance.Changes, change)
+	}
+	return provenance
+}
+
+// deletesPath indicates if the layer tree deletes the given path (or one of its parents), either with a whiteout or
+// with an opaque directory.
+func deletesPath(tree *filetree.FileTree, filePath string) bool {
+	for current := filePath; current != "/"; current = path.Dir(current) {
+		whiteout, err := tree.GetNode(path.Join(path.Dir(current), ".wh."+path.Base(current)))
+		if err == nil && whiteout != nil {
+			return true
+		}
+	}
+
+	node := tree.Root
+	for _, name := range strings.Split(strings.Trim(filePath, "/"), "/") {
+		if node.Data.Whiteout == filetree.WhiteoutOpaque {
+			return true
+		}
+		if node = node.Children[name]; node == nil {
+			return false
+		}
+	}
+	return false
+}
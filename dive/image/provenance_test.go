@@ -0,0 +1,78 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestTracePath(t *testing.T) {
+	trees := make([]*filetree.FileTree, 6)
+	layers := make([]*Layer, len(trees))
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+		layers[idx] = &Layer{Index: idx, Command: "step " + string(rune('a'+idx))}
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc/hostname", 10)
+	add(trees[1], "/etc/secret.key", 100)
+	add(trees[2], "/etc/secret.key", 120)
+	add(trees[3], "/etc/.wh.secret.key", 0)
+	// added back and then removed along with its directory
+	add(trees[4], "/etc/secret.key", 50)
+	add(trees[5], "/.wh.etc", 0)
+
+	provenance := TracePath(layers, trees, "etc/secret.key")
+
+	expected := []PathChange{
+		{Layer: 1, Change: PathAdded, SizeBytes: 100, Command: "step b"},
+		{Layer: 2, Change: PathModified, SizeBytes: 120, Command: "step c"},
+		{Layer: 3, Change: PathDeleted, Command: "step d"},
+		{Layer: 4, Change: PathAdded, SizeBytes: 50, Command: "step e"},
+		{Layer: 5, Change: PathDeleted, Command: "step f"},
+	}
+	if !reflect.DeepEqual(provenance.Changes, expected) {
+		t.Errorf("expected changes %+v, got %+v", expected, provenance.Changes)
+	}
+	if provenance.Path != "/etc/secret.key" || provenance.Present || provenance.Partial {
+		t.Errorf("unexpected provenance: %+v", provenance)
+	}
+	if first, ok := provenance.FirstAdded(); !ok || first.Layer != 1 {
+		t.Errorf("unexpected first added layer: %+v", first)
+	}
+	if last, ok := provenance.LastChanged(); !ok || last.Layer != 5 {
+		t.Errorf("unexpected last changed layer: %+v", last)
+	}
+}
+
+func TestTracePathOpaqueDirectory(t *testing.T) {
+	trees := []*filetree.FileTree{filetree.NewFileTree(), filetree.NewFileTree(), nil}
+	layers := []*Layer{{Index: 0}, {Index: 1}, {Index: 2}}
+
+	if _, _, err := trees[0].AddPath("/app/config.json", filetree.FileInfo{Size: 10}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	if _, _, err := trees[1].AddPath("/app/.wh..wh..opq", filetree.FileInfo{}); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	provenance := TracePath(layers, trees, "/app/config.json")
+
+	expected := []PathChange{
+		{Layer: 0, Change: PathAdded, SizeBytes: 10},
+		{Layer: 1, Change: PathDeleted},
+	}
+	if !reflect.DeepEqual(provenance.Changes, expected) {
+		t.Errorf("expected changes %+v, got %+v", expected, provenance.Changes)
+	}
+	if !provenance.Partial {
+		t.Errorf("expected the provenance to be partial (a layer is not loaded)")
+	}
+}
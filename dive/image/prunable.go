@@ -0,0 +1,159 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of content that can be pruned from an image without affecting what it runs
+const (
+	PrunableDocs           = "docs"
+	PrunableManPages       = "man pages"
+	PrunableLocales        = "locales"
+	PrunablePythonBytecode = "python bytecode"
+)
+
+// the directories holding prunable content, by kind
+var prunableDirectories = []struct {
+	kind string
+	dirs []string
+}{
+	{kind: PrunableDocs, dirs: []string{"/usr/share/doc", "/usr/local/share/doc", "/usr/share/info", "/usr/share/gtk-doc"}},
+	{kind: PrunableManPages, dirs: []string{"/usr/share/man", "/usr/local/share/man"}},
+	{kind: PrunableLocales, dirs: []string{"/usr/share/locale", "/usr/local/share/locale"}},
+}
+
+// PrunableGroup is the content of a kind that can be pruned from the final image.
+type PrunableGroup struct {
+	Kind string
+	// the directories holding the content, the largest first (for python bytecode, the packages holding it)
+	Paths     []string
+	Files     int
+	SizeBytes uint64
+	// the index of the last layer that added any of the files, where the cleanup belongs
+	Layer int
+}
+
+// PrunableContent is the documentation, man pages, translations and python bytecode within the final image, which
+// are rarely needed at runtime.
+type PrunableContent struct {
+	Groups    []PrunableGroup
+	SizeBytes uint64
+}
+
+// Empty indicates if there is nothing to prune.
+func (content *PrunableContent) Empty() bool {
+	return len(content.Groups) == 0
+}
+
+// FindPrunableContent measures the prunable content within the final image (after every layer and whiteout has been
+// applied). Copyright files within the doc directories are kept out of the figures, as distributions require them, as
+// are the paths of the ignore list (which may be nil).
+func FindPrunableContent(trees []*filetree.FileTree, ignore *filetree.IgnoreList) *PrunableContent {
+	files := reportedFiles(trees, ignore)
+	groups := make(map[string]*PrunableGroup)
+	dirs := make(map[string]map[string]uint64)
+
+	add := func(kind, dir string, file visibleFile) {
+		group, exists := groups[kind]
+		if !exists {
+			group = &PrunableGroup{Kind: kind}
+			groups[kind] = group
+			dirs[kind] = make(map[string]uint64)
+		}
+		group.Files++
+		group.SizeBytes += file.size
+		if file.layer > group.Layer {
+			group.Layer = file.layer
+		}
+		dirs[kind][dir] += file.size
+	}
+
+	for filePath, file := range files {
+		if kind, dir := prunableKind(filePath); kind != "" {
+			add(kind, dir, file)
+		}
+	}
+
+	result := &PrunableContent{Groups: make([]PrunableGroup, 0)}
+	for _, kind := range []string{PrunableDocs, PrunableManPages, PrunableLocales, PrunablePythonBytecode} {
+		group, exists := groups[kind]
+		if !exists {
+			continue
+		}
+		for dir := range dirs[kind] {
+			group.Paths = append(group.Paths, dir)
+		}
+		// the largest directories first
+		sort.Slice(group.Paths, func(i, j int) bool {
+			left, right := dirs[kind][group.Paths[i]], dirs[kind][group.Paths[j]]
+			if left == right {
+				return group.Paths[i] < group.Paths[j]
+			}
+			return left > right
+		})
+		result.Groups = append(result.Groups, *group)
+		result.SizeBytes += group.SizeBytes
+	}
+	return result
+}
+
+// prunableKind returns the kind of prunable content the file is, and the directory it is accounted to (the kind is
+// empty when the file is needed).
+func prunableKind(filePath string) (string, string) {
+	name := path.Base(filePath)
+	if strings.Contains(filePath, "/__pycache__/") || strings.HasSuffix(name, ".pyc") || strings.HasSuffix(name, ".pyo") {
+		if idx := strings.Index(filePath, "/__pycache__/"); idx >= 0 {
+			return PrunablePythonBytecode, filePath[:idx]
+		}
+		return PrunablePythonBytecode, path.Dir(filePath)
+	}
+	for _, entry := range prunableDirectories {
+		for _, dir := range entry.dirs {
+			if !strings.HasPrefix(filePath, dir+"/") {
+				continue
+			}
+			if entry.kind == PrunableDocs && name == "copyright" {
+				return "", ""
+			}
+			return entry.kind, dir
+		}
+	}
+	return "", ""
+}
+
+// CleanupSnippet returns the shell commands removing the prunable content that was found, to append to the RUN
+// instruction that added the files (empty when there is none). Run as an instruction of its own, the commands would
+// only hide the files, which the earlier layers still hold.
+func (content *PrunableContent) CleanupSnippet() string {
+	var commands []string
+	for _, group := range content.Groups {
+		switch group.Kind {
+		case PrunableDocs:
+			// the copyright files are kept, as distributions require them
+			commands = append(commands, fmt.Sprintf("find %s -depth -type f ! -name copyright -delete", strings.Join(sortedPaths(group.Paths), " ")))
+		case PrunableManPages, PrunableLocales:
+			var globs []string
+			for _, dir := range sortedPaths(group.Paths) {
+				globs = append(globs, dir+"/*")
+			}
+			commands = append(commands, "rm -rf "+strings.Join(globs, " "))
+		case PrunablePythonBytecode:
+			commands = append(commands, `find / -xdev -depth \( -name __pycache__ -o -name '*.py[co]' \) -exec rm -rf {} +`)
+		}
+	}
+	if len(commands) == 0 {
+		return ""
+	}
+	return "&& " + strings.Join(commands, " \\\n&& ")
+}
+
+func sortedPaths(paths []string) []string {
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,58 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindPrunableContent(t *testing.T) {
+	trees := make([]*filetree.FileTree, 2)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/usr/share/doc/curl/changelog.gz", 3000)
+	add(trees[0], "/usr/share/doc/curl/copyright", 500)
+	add(trees[0], "/usr/share/man/man1/curl.1.gz", 800)
+	add(trees[0], "/usr/share/locale/de/LC_MESSAGES/curl.mo", 400)
+	add(trees[1], "/usr/share/locale/fr/LC_MESSAGES/app.mo", 300)
+	add(trees[1], "/usr/lib/python3.11/json/__pycache__/decoder.cpython-311.pyc", 900)
+	add(trees[1], "/usr/lib/python3.11/json/decoder.py", 1200)
+	add(trees[1], "/app/__pycache__/main.cpython-311.pyc", 100)
+	add(trees[1], "/opt/legacy/module.pyc", 50)
+	// the man pages were removed by a later layer
+	add(trees[1], "/usr/share/man/man1/.wh.curl.1.gz", 0)
+
+	content := FindPrunableContent(trees, nil)
+
+	expected := []PrunableGroup{
+		{Kind: PrunableDocs, Paths: []string{"/usr/share/doc"}, Files: 1, SizeBytes: 3000, Layer: 0},
+		{Kind: PrunableLocales, Paths: []string{"/usr/share/locale"}, Files: 2, SizeBytes: 700, Layer: 1},
+		{Kind: PrunablePythonBytecode, Paths: []string{"/usr/lib/python3.11/json", "/app", "/opt/legacy"}, Files: 3, SizeBytes: 1050, Layer: 1},
+	}
+	if !reflect.DeepEqual(content.Groups, expected) {
+		t.Errorf("expected groups:\n%+v\ngot:\n%+v", expected, content.Groups)
+	}
+	if content.SizeBytes != 4750 {
+		t.Errorf("expected 4750 prunable bytes, got %d", content.SizeBytes)
+	}
+
+	expectedSnippet := "&& find /usr/share/doc -depth -type f ! -name copyright -delete \\\n" +
+		"&& rm -rf /usr/share/locale/* \\\n" +
+		`&& find / -xdev -depth \( -name __pycache__ -o -name '*.py[co]' \) -exec rm -rf {} +`
+	if snippet := content.CleanupSnippet(); snippet != expectedSnippet {
+		t.Errorf("expected snippet:\n%s\ngot:\n%s", expectedSnippet, snippet)
+	}
+
+	if empty := FindPrunableContent([]*filetree.FileTree{filetree.NewFileTree()}, nil); !empty.Empty() || empty.CleanupSnippet() != "" {
+		t.Errorf("expected nothing to prune, got %+v", empty)
+	}
+}
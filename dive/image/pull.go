@@ -0,0 +1,254 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// a typical office/CI uplink
+	DefaultPullBandwidth = "100Mbps"
+	// the per-layer round trip cost (registry auth, manifest/blob redirects, extraction setup)
+	DefaultPullLayerLatency = "100ms"
+	// the price per GB transferred out of a registry, typical of cloud egress
+	DefaultEgressPrice = 0.09
+)
+
+// BandwidthProfile describes the network used to estimate how long an image takes to pull.
+type BandwidthProfile struct {
+	BytesPerSecond uint64
+	LayerLatency   time.Duration
+}
+
+// String renders the profile in bits per second, which is how link speeds are usually quoted.
+func (p BandwidthProfile) String() string {
+	return fmt.Sprintf("%sbps, %s per layer", strings.TrimSuffix(humanize.SI(float64(p.BytesPerSecond*8), ""), " "), p.LayerLatency)
+}
+
+// ParseBandwidthProfile builds a profile from a bandwidth (e.g. "100Mbps", "1Gbps", "12MB/s" or "12MB") and a per-layer
+// latency duration (e.g. "100ms"). Empty values fall back to the defaults.
+func ParseBandwidthProfile(bandwidth, latency string) (BandwidthProfile, error) {
+	var profile BandwidthProfile
+
+	if strings.TrimSpace(bandwidth) == "" {
+		bandwidth = DefaultPullBandwidth
+	}
+	if strings.TrimSpace(latency) == "" {
+		latency = DefaultPullLayerLatency
+	}
+
+	bytesPerSecond, err := parseBandwidth(bandwidth)
+	if err != nil {
+		return profile, err
+	}
+	if bytesPerSecond == 0 {
+		return profile, fmt.Errorf("bandwidth must be greater than zero: %q", bandwidth)
+	}
+	profile.BytesPerSecond = bytesPerSecond
+
+	profile.LayerLatency, err = time.ParseDuration(strings.TrimSpace(latency))
+	if err != nil {
+		return profile, fmt.Errorf("invalid layer latency %q: %v", latency, err)
+	}
+	if profile.LayerLatency < 0 {
+		return profile, fmt.Errorf("layer latency must not be negative: %q", latency)
+	}
+
+	return profile, nil
+}
+
+// ParseBandwidthProfiles builds a profile for each of the given bandwidths, all with the given per-layer latency.
+func ParseBandwidthProfiles(bandwidths []string, latency string) ([]BandwidthProfile, error) {
+	profiles := make([]BandwidthProfile, 0, len(bandwidths))
+	for _, bandwidth := range bandwidths {
+		profile, err := ParseBandwidthProfile(bandwidth, latency)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func parseBandwidth(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+
+	// bits per second (e.g. 100Mbps), SI multiples only
+	if strings.HasSuffix(value, "bps") {
+		number := strings.TrimSpace(strings.TrimSuffix(value, "bps"))
+		multiplier := 1.0
+		if len(number) > 0 {
+			switch number[len(number)-1] {
+			case 'k', 'K':
+				multiplier = 1e3
+			case 'm', 'M':
+				multiplier = 1e6
+			case 'g', 'G':
+				multiplier = 1e9
+			}
+			if multiplier != 1.0 {
+				number = number[:len(number)-1]
+			}
+		}
+		bits, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || bits < 0 {
+			return 0, fmt.Errorf("invalid bandwidth %q", value)
+		}
+		return uint64(bits * multiplier / 8), nil
+	}
+
+	// bytes per second (e.g. 12MB/s or 12MB)
+	bytesPerSecond, err := humanize.ParseBytes(strings.TrimSuffix(value, "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q: %v", value, err)
+	}
+	return bytesPerSecond, nil
+}
+
+// LayerPullEstimate is the time spent on a single layer when pulling with and without the base image layers cached.
+type LayerPullEstimate struct {
+	Index int
+	Cold  time.Duration
+	Warm  time.Duration
+}
+
+// PullEstimate is the estimated time to pull an image. A cold pull fetches every layer, a warm pull assumes the layers
+// shared with the base image are already present on the host (as they usually are on build agents and nodes running
+// similar images).
+type PullEstimate struct {
+	Profile BandwidthProfile
+	Layers  []LayerPullEstimate
+	Cold    time.Duration
+	Warm    time.Duration
+}
+
+// EstimatePullTime estimates per-layer and total pull times for the given layers. Layers are assumed to be fetched one
+// after another over the full bandwidth. Layers are transferred compressed, so the compressed sizes are used where known
+// (falling back to the uncompressed size, which makes the result an upper bound). The given number of leading layers
+// belong to the base image (at least the first layer is assumed to).
+func EstimatePullTime(layers []*Layer, profile BandwidthProfile, baseLayers int) *PullEstimate {
+	result := &PullEstimate{
+		Profile: profile,
+		Layers:  make([]LayerPullEstimate, 0, len(layers)),
+	}
+
+	for _, layer := range layers {
+		estimate := LayerPullEstimate{
+			Index: layer.Index,
+			Cold:  profile.transferTime(layer.TransferSize()),
+		}
+		if !cachedLayer(layer, baseLayers) {
+			estimate.Warm = estimate.Cold
+		}
+		result.Cold += estimate.Cold
+		result.Warm += estimate.Warm
+		result.Layers = append(result.Layers, estimate)
+	}
+
+	return result
+}
+
+// PullCost gathers the pull time estimates at the configured bandwidths and the monthly registry egress of an image.
+type PullCost struct {
+	// the estimate at the main bandwidth, followed by the estimates at the bandwidths compared with it
+	Estimates []*PullEstimate
+	// nil when the number of pulls a month is not known
+	Egress *EgressEstimate
+}
+
+// EstimatePullCost estimates the pull time of the layers at every given bandwidth, and their monthly egress when the
+// number of pulls a month is given. The given number of leading layers belong to the base image.
+func EstimatePullCost(layers []*Layer, baseLayers int, profiles []BandwidthProfile, pulls int, pricePerGB float64) *PullCost {
+	result := &PullCost{Estimates: make([]*PullEstimate, 0, len(profiles))}
+	for _, profile := range profiles {
+		result.Estimates = append(result.Estimates, EstimatePullTime(layers, profile, baseLayers))
+	}
+	if pulls > 0 {
+		result.Egress = EstimateEgress(layers, baseLayers, pulls, pricePerGB)
+	}
+	return result
+}
+
+// Main returns the estimate at the main bandwidth (nil when there is none).
+func (c *PullCost) Main() *PullEstimate {
+	if c == nil || len(c.Estimates) == 0 {
+		return nil
+	}
+	return c.Estimates[0]
+}
+
+// cachedLayer indicates if the layer belongs to the base image, which a warm pull finds on the host already.
+func cachedLayer(layer *Layer, baseLayers int) bool {
+	return layer.Index == 0 || layer.Index < baseLayers
+}
+
+// EgressEstimate is the registry egress of an image pulled a number of times a month, and what it costs.
+type EgressEstimate struct {
+	// the pulls a month, and the price per GB (10^9 bytes) transferred
+	Pulls      int
+	PricePerGB float64
+	// the bytes a cold pull transfers (every layer), and a warm pull (without the layers of the base image)
+	ColdBytes uint64
+	WarmBytes uint64
+}
+
+// EstimateEgress estimates the monthly registry egress of the layers, from their transfer sizes. The given number of
+// leading layers belong to the base image (at least the first layer is assumed to).
+func EstimateEgress(layers []*Layer, baseLayers, pulls int, pricePerGB float64) *EgressEstimate {
+	result := &EgressEstimate{Pulls: pulls, PricePerGB: pricePerGB}
+	for _, layer := range layers {
+		result.ColdBytes += layer.TransferSize()
+		if !cachedLayer(layer, baseLayers) {
+			result.WarmBytes += layer.TransferSize()
+		}
+	}
+	return result
+}
+
+// MonthlyBytes is the bytes transferred a month, when every pull is cold or when every pull is warm.
+func (e *EgressEstimate) MonthlyBytes(warm bool) uint64 {
+	if warm {
+		return e.WarmBytes * uint64(e.Pulls)
+	}
+	return e.ColdBytes * uint64(e.Pulls)
+}
+
+// MonthlyCost is the cost of the monthly egress, when every pull is cold or when every pull is warm.
+func (e *EgressEstimate) MonthlyCost(warm bool) float64 {
+	return float64(e.MonthlyBytes(warm)) / 1e9 * e.PricePerGB
+}
+
+// Layer returns the estimate for the layer with the given index.
+func (p *PullEstimate) Layer(index int) (LayerPullEstimate, bool) {
+	for _, estimate := range p.Layers {
+		if estimate.Index == index {
+			return estimate, true
+		}
+	}
+	return LayerPullEstimate{}, false
+}
+
+func (p BandwidthProfile) transferTime(sizeBytes uint64) time.Duration {
+	if p.BytesPerSecond == 0 {
+		return p.LayerLatency
+	}
+	seconds := float64(sizeBytes) / float64(p.BytesPerSecond)
+	return p.LayerLatency + time.Duration(math.Round(seconds*float64(time.Second)))
+}
+
+// FormatPullDuration renders durations at a precision that is meaningful for pull times (e.g. "340ms", "12.4s", "2m3s").
+func FormatPullDuration(duration time.Duration) string {
+	switch {
+	case duration < time.Second:
+		return duration.Round(time.Millisecond).String()
+	case duration < time.Minute:
+		return duration.Round(100 * time.Millisecond).String()
+	default:
+		return duration.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,62 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PullPolicy decides when a container engine pulls the image to analyze from its registry.
+type PullPolicy string
+
+const (
+	// pull the image only when the engine does not have it (the default)
+	PullMissing PullPolicy = "missing"
+	// pull the image before every analysis, so that a tag is analyzed as it is published
+	PullAlways PullPolicy = "always"
+	// never pull, the image has to be available locally
+	PullNever PullPolicy = "never"
+)
+
+// PullPolicies lists the supported pull policies.
+var PullPolicies = []PullPolicy{PullMissing, PullAlways, PullNever}
+
+// ParsePullPolicy returns the policy of the given name (empty is the default policy).
+func ParsePullPolicy(name string) (PullPolicy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return PullMissing, nil
+	}
+	for _, policy := range PullPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown pull policy %q (expected missing, always or never)", name)
+}
+
+// ShouldPull decides if the image (which the engine has locally or not) is pulled before it is read. An image that
+// is missing with the never policy is an error, as is any pull when the network policy is offline.
+func (policy PullPolicy) ShouldPull(id string, available bool, network NetworkPolicy) (bool, error) {
+	pull := false
+	switch policy {
+	case PullAlways:
+		pull = true
+	case PullNever:
+		if !available {
+			return false, Permanent(fmt.Errorf("image '%s' is not available locally (and the pull policy is never)", id))
+		}
+	default:
+		pull = !available
+	}
+	if !pull {
+		return false, nil
+	}
+	operation := fmt.Sprintf("pulling '%s'", id)
+	if !available {
+		operation += " (it is not available locally)"
+	}
+	if err := network.RequireNetwork(operation); err != nil {
+		return false, err
+	}
+	return true, nil
+}
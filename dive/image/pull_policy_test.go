@@ -0,0 +1,47 @@
+package image
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPullPolicy_ShouldPull(t *testing.T) {
+	cases := []struct {
+		policy    PullPolicy
+		available bool
+		pull      bool
+		fails     bool
+	}{
+		{policy: PullMissing, available: true, pull: false},
+		{policy: PullMissing, available: false, pull: true},
+		{policy: PullAlways, available: true, pull: true},
+		{policy: PullAlways, available: false, pull: true},
+		{policy: PullNever, available: true, pull: false},
+		{policy: PullNever, available: false, fails: true},
+	}
+	for _, test := range cases {
+		pull, err := test.policy.ShouldPull("alpine:latest", test.available, NetworkPolicy{})
+		if (err != nil) != test.fails || pull != test.pull {
+			t.Errorf("%s (available %v): expected pull %v (fails %v), got %v (%v)", test.policy, test.available, test.pull, test.fails, pull, err)
+		}
+	}
+
+	offline := NetworkPolicy{Offline: true}
+	if _, err := PullAlways.ShouldPull("alpine:latest", true, offline); !errors.Is(err, ErrOffline) {
+		t.Errorf("expected pulling to be refused offline, got %v", err)
+	}
+	if pull, err := PullMissing.ShouldPull("alpine:latest", true, offline); err != nil || pull {
+		t.Errorf("expected a local image to be used offline, got %v (%v)", pull, err)
+	}
+}
+
+func TestParsePullPolicy(t *testing.T) {
+	for name, expected := range map[string]PullPolicy{"": PullMissing, "missing": PullMissing, "Always": PullAlways, " never ": PullNever} {
+		if policy, err := ParsePullPolicy(name); err != nil || policy != expected {
+			t.Errorf("%q: expected %s, got %s (%v)", name, expected, policy, err)
+		}
+	}
+	if _, err := ParsePullPolicy("sometimes"); err == nil {
+		t.Errorf("expected an unknown policy to fail")
+	}
+}
@@ -0,0 +1,126 @@
+package image
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthProfile(t *testing.T) {
+	cases := []struct {
+		bandwidth string
+		latency   string
+		expected  BandwidthProfile
+		err       bool
+	}{
+		{bandwidth: "", latency: "", expected: BandwidthProfile{BytesPerSecond: 12500000, LayerLatency: 100 * time.Millisecond}},
+		{bandwidth: "1Gbps", latency: "0s", expected: BandwidthProfile{BytesPerSecond: 125000000}},
+		{bandwidth: "800kbps", latency: "1s", expected: BandwidthProfile{BytesPerSecond: 100000, LayerLatency: time.Second}},
+		{bandwidth: "10MB/s", latency: "50ms", expected: BandwidthProfile{BytesPerSecond: 10000000, LayerLatency: 50 * time.Millisecond}},
+		{bandwidth: "1MiB", latency: "50ms", expected: BandwidthProfile{BytesPerSecond: 1048576, LayerLatency: 50 * time.Millisecond}},
+		{bandwidth: "fast", latency: "50ms", err: true},
+		{bandwidth: "0bps", latency: "50ms", err: true},
+		{bandwidth: "100Mbps", latency: "soon", err: true},
+		{bandwidth: "100Mbps", latency: "-1s", err: true},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseBandwidthProfile(test.bandwidth, test.latency)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected error for %q/%q", test.bandwidth, test.latency)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q/%q: %v", test.bandwidth, test.latency, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("expected %+v for %q/%q, got %+v", test.expected, test.bandwidth, test.latency, actual)
+		}
+	}
+}
+
+func TestEstimatePullTime(t *testing.T) {
+	profile := BandwidthProfile{BytesPerSecond: 1000, LayerLatency: 100 * time.Millisecond}
+	layers := []*Layer{
+		{Index: 0, Size: 5000},
+		{Index: 1, Size: 500},
+		{Index: 2, Size: 0},
+	}
+
+	estimate := EstimatePullTime(layers, profile, 0)
+
+	if estimate.Cold != 5*time.Second+500*time.Millisecond+300*time.Millisecond {
+		t.Errorf("unexpected cold pull time: %v", estimate.Cold)
+	}
+	if estimate.Warm != 500*time.Millisecond+200*time.Millisecond {
+		t.Errorf("unexpected warm pull time: %v", estimate.Warm)
+	}
+
+	layer, ok := estimate.Layer(1)
+	if !ok || layer.Cold != 600*time.Millisecond || layer.Warm != 600*time.Millisecond {
+		t.Errorf("unexpected layer estimate: %+v", layer)
+	}
+	layer, ok = estimate.Layer(0)
+	if !ok || layer.Warm != 0 {
+		t.Errorf("expected a cached base layer: %+v", layer)
+	}
+	if _, ok := estimate.Layer(3); ok {
+		t.Errorf("expected no estimate for a missing layer")
+	}
+}
+
+func TestEstimatePullTime_BaseLayers(t *testing.T) {
+	profile := BandwidthProfile{BytesPerSecond: 1000}
+	layers := []*Layer{
+		{Index: 0, Size: 5000},
+		{Index: 1, CompressedSize: 2000, Size: 3000},
+		{Index: 2, Size: 500},
+	}
+
+	estimate := EstimatePullTime(layers, profile, 2)
+	if estimate.Cold != 7500*time.Millisecond {
+		t.Errorf("unexpected cold pull time: %v", estimate.Cold)
+	}
+	if estimate.Warm != 500*time.Millisecond {
+		t.Errorf("expected the base layers to be cached, got a warm pull time of %v", estimate.Warm)
+	}
+}
+
+func TestEstimateEgress(t *testing.T) {
+	layers := []*Layer{
+		{Index: 0, Size: 5000},
+		{Index: 1, CompressedSize: 2000, Size: 3000},
+		{Index: 2, Size: 500},
+	}
+
+	egress := EstimateEgress(layers, 2, 1000000, 0.1)
+	if egress.ColdBytes != 7500 || egress.WarmBytes != 500 {
+		t.Errorf("unexpected bytes per pull: %d cold, %d warm", egress.ColdBytes, egress.WarmBytes)
+	}
+	if bytes := egress.MonthlyBytes(false); bytes != 7500000000 {
+		t.Errorf("unexpected monthly bytes: %d", bytes)
+	}
+	if cost := egress.MonthlyCost(false); math.Abs(cost-0.75) > 1e-9 {
+		t.Errorf("unexpected cold monthly cost: %v", cost)
+	}
+	if cost := egress.MonthlyCost(true); math.Abs(cost-0.05) > 1e-9 {
+		t.Errorf("unexpected warm monthly cost: %v", cost)
+	}
+}
+
+func TestFormatPullDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		340*time.Millisecond + 400*time.Microsecond:          "340ms",
+		12*time.Second + 440*time.Millisecond:                "12.4s",
+		2*time.Minute + 3*time.Second + 400*time.Millisecond: "2m3s",
+		0: "0s",
+	}
+	for duration, expected := range cases {
+		if actual := FormatPullDuration(duration); actual != expected {
+			t.Errorf("expected %q for %v, got %q", expected, duration, actual)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RegistryOptions configures the TLS connections made to registries when images are read straight from them (the
+// registry source, --compare-remote). The certificates docker finds in certs.d directories and the custom CA bundle
+// (see NetworkPolicy) are used as well.
+type RegistryOptions struct {
+	// the registries (host[:port], "*" for every registry) whose certificate is not verified, and which are reached
+	// over plain http when they do not serve TLS (e.g. internal registries with a self-signed certificate)
+	Insecure []string
+	// the client certificate and key presented to registries that require mutual TLS
+	CertFile string
+	KeyFile  string
+}
+
+// ParseRegistryOptions builds the options, checking that a client certificate is given along with its key.
+func ParseRegistryOptions(insecure []string, certFile, keyFile string) (RegistryOptions, error) {
+	options := RegistryOptions{CertFile: strings.TrimSpace(certFile), KeyFile: strings.TrimSpace(keyFile)}
+	if (options.CertFile == "") != (options.KeyFile == "") {
+		return options, fmt.Errorf("a registry client certificate needs both the certificate and its key")
+	}
+	for _, registry := range insecure {
+		if registry = strings.TrimSpace(registry); registry != "" {
+			options.Insecure = append(options.Insecure, registry)
+		}
+	}
+	return options, nil
+}
+
+// IsInsecure indicates if the certificate of the registry (host[:port]) is not verified.
+func (options RegistryOptions) IsInsecure(registry string) bool {
+	for _, insecure := range options.Insecure {
+		if insecure == "*" || strings.EqualFold(insecure, registry) {
+			return true
+		}
+	}
+	return false
+}
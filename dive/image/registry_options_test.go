@@ -0,0 +1,16 @@
+package image
+
+import "testing"
+
+func TestParseRegistryOptions(t *testing.T) {
+	options, err := ParseRegistryOptions([]string{" registry.internal:5000 ", ""}, "", "")
+	if err != nil || !options.IsInsecure("registry.internal:5000") || options.IsInsecure("ghcr.io") {
+		t.Errorf("unexpected options %+v (%v)", options, err)
+	}
+	if !(RegistryOptions{Insecure: []string{"*"}}).IsInsecure("ghcr.io") {
+		t.Errorf("expected every registry to be insecure with *")
+	}
+	if _, err := ParseRegistryOptions(nil, "client.cert", ""); err == nil {
+		t.Errorf("expected a client certificate without a key to fail")
+	}
+}
@@ -0,0 +1,100 @@
+package image
+
+// how a layer of the local image relates to the published image
+const (
+	// the layer is the same as the layer at the same position of the published image (a pull reuses it)
+	LayerShared = "shared"
+	// the layer is only in the local image (a pull of the local build transfers it)
+	LayerLocalOnly = "local only"
+	// the layer is only in the published image (the local build no longer has it)
+	LayerRemoteOnly = "published only"
+)
+
+// RemoteLayerDelta is a layer of either image, after the leading layers both images share.
+type RemoteLayerDelta struct {
+	// the index of the layer within its image
+	Index   int
+	Status  string
+	Command string
+	Digest  string
+	// the uncompressed size, and the compressed size as transferred by a registry
+	SizeBytes     uint64
+	TransferBytes uint64
+}
+
+// RemoteComparison is the difference between a local build and the image published under the same tag (or another
+// reference) in a registry.
+type RemoteComparison struct {
+	// the reference and manifest digest of the published image
+	Reference string
+	Digest    string
+	// the uncompressed size of each image, and the bytes a registry transfers to pull it
+	LocalSizeBytes      uint64
+	RemoteSizeBytes     uint64
+	LocalTransferBytes  uint64
+	RemoteTransferBytes uint64
+	// the number of leading layers both images share, and their uncompressed size
+	SharedLayers    int
+	SharedSizeBytes uint64
+	// the layers after the shared ones: the local ones first, then the published ones
+	Layers []RemoteLayerDelta
+	// the files of the final image that differ, by path
+	Files []FileDelta
+}
+
+// Identical indicates if the local build has exactly the layers of the published image.
+func (comparison *RemoteComparison) Identical() bool {
+	return len(comparison.Layers) == 0
+}
+
+// PullBytes is the number of bytes a host that pulled the published image transfers to pull the local build: the
+// layers it does not have yet.
+func (comparison *RemoteComparison) PullBytes() uint64 {
+	var total uint64
+	for _, layer := range comparison.Layers {
+		if layer.Status == LayerLocalOnly {
+			total += layer.TransferBytes
+		}
+	}
+	return total
+}
+
+// CompareRemote compares a local image with the published image fetched from a registry (see
+// docker.FetchRemoteImage). The layers are compared like a layer cache would (the leading layers with the same diffID
+// are shared), the files by the final image filesystem of each image.
+func CompareRemote(local, remote *Image, reference string) *RemoteComparison {
+	comparison := &RemoteComparison{Reference: reference, Digest: remote.ID}
+	for _, layer := range local.Layers {
+		comparison.LocalSizeBytes += layer.Size
+		comparison.LocalTransferBytes += layer.TransferSize()
+	}
+	for _, layer := range remote.Layers {
+		comparison.RemoteSizeBytes += layer.Size
+		comparison.RemoteTransferBytes += layer.TransferSize()
+	}
+
+	comparison.SharedLayers = MatchBaseLayers(local.Layers, remote.Layers)
+	for _, layer := range local.Layers[:comparison.SharedLayers] {
+		comparison.SharedSizeBytes += layer.Size
+	}
+	for _, layer := range local.Layers[comparison.SharedLayers:] {
+		comparison.Layers = append(comparison.Layers, newRemoteLayerDelta(layer, LayerLocalOnly))
+	}
+	for _, layer := range remote.Layers[comparison.SharedLayers:] {
+		comparison.Layers = append(comparison.Layers, newRemoteLayerDelta(layer, LayerRemoteOnly))
+	}
+
+	comparison.Files = compareVisibleFiles(visibleFiles(local.Trees), visibleFiles(remote.Trees))
+	return comparison
+}
+
+func newRemoteLayerDelta(layer *Layer, status string) RemoteLayerDelta {
+	return RemoteLayerDelta{
+		Index:         layer.Index,
+		Status:        status,
+		Command:       layer.Command,
+		Digest:        layer.Digest,
+		SizeBytes:     layer.Size,
+		TransferBytes: layer.TransferSize(),
+	}
+}
@@ -0,0 +1,72 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestCompareRemote(t *testing.T) {
+	newTree := func(files map[string]int64) *filetree.FileTree {
+		tree := filetree.NewFileTree()
+		for path, size := range files {
+			if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		return tree
+	}
+
+	base := newTree(map[string]int64{"/bin/sh": 1000, "/etc/os-release": 100})
+	deps := newTree(map[string]int64{"/app/lib/dep.so": 5000})
+	localApp := newTree(map[string]int64{"/app/main": 300, "/app/new.txt": 20})
+	remoteApp := newTree(map[string]int64{"/app/main": 250, "/app/old.txt": 10})
+
+	local := &Image{
+		Trees: []*filetree.FileTree{base, deps, localApp},
+		Layers: []*Layer{
+			{Index: 0, DiffID: "sha256:base", Size: 1100, CompressedSize: 500},
+			{Index: 1, DiffID: "sha256:deps", Size: 5000, CompressedSize: 2000},
+			{Index: 2, DiffID: "sha256:app2", Size: 320, CompressedSize: 200, Command: "COPY . /app"},
+		},
+	}
+	remote := &Image{
+		ID:    "sha256:manifest",
+		Trees: []*filetree.FileTree{base, deps, remoteApp},
+		Layers: []*Layer{
+			{Index: 0, DiffID: "sha256:base", Size: 1100, CompressedSize: 500},
+			{Index: 1, DiffID: "sha256:deps", Size: 5000, CompressedSize: 2000},
+			{Index: 2, DiffID: "sha256:app1", Size: 260, CompressedSize: 150, Command: "COPY . /app"},
+		},
+	}
+
+	comparison := CompareRemote(local, remote, "registry.example.com/app:latest")
+
+	if comparison.SharedLayers != 2 || comparison.SharedSizeBytes != 6100 {
+		t.Errorf("expected 2 shared layers of 6100 bytes, got %d of %d bytes", comparison.SharedLayers, comparison.SharedSizeBytes)
+	}
+	if comparison.LocalSizeBytes != 6420 || comparison.RemoteSizeBytes != 6360 {
+		t.Errorf("unexpected sizes: %d local, %d published", comparison.LocalSizeBytes, comparison.RemoteSizeBytes)
+	}
+	if comparison.PullBytes() != 200 {
+		t.Errorf("expected 200 bytes to pull, got %d", comparison.PullBytes())
+	}
+	if comparison.Identical() || len(comparison.Layers) != 2 ||
+		comparison.Layers[0].Status != LayerLocalOnly || comparison.Layers[1].Status != LayerRemoteOnly {
+		t.Errorf("unexpected layer deltas: %+v", comparison.Layers)
+	}
+
+	expected := []FileDelta{
+		{Path: "/app/main", Change: PathModified, Bytes: 300, PreviousBytes: 250},
+		{Path: "/app/new.txt", Change: PathAdded, Bytes: 20},
+		{Path: "/app/old.txt", Change: PathDeleted, PreviousBytes: 10},
+	}
+	if !reflect.DeepEqual(comparison.Files, expected) {
+		t.Errorf("expected file deltas:\n%+v\ngot:\n%+v", expected, comparison.Files)
+	}
+
+	if same := CompareRemote(local, local, "app"); !same.Identical() || len(same.Files) != 0 {
+		t.Errorf("expected no deltas comparing an image with itself, got %+v", same)
+	}
+}
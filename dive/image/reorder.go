@@ -0,0 +1,285 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// ReorderStep is a build step of the image (an app layer, the base layers are never reordered).
+type ReorderStep struct {
+	Layer     int
+	Command   string
+	SizeBytes uint64
+	// the simulated rebuilds in which the inputs of the step itself changed (its instruction or, for COPY/ADD, the
+	// copied files), busting its cache whatever the steps before it are
+	Changes int
+}
+
+// ReorderConflict is a step moved ahead of an earlier step whose files it overwrites or names in its instruction, so
+// it most likely depends on the earlier step.
+type ReorderConflict struct {
+	Layer     int
+	DependsOn int
+	// the first file of the earlier step the step depends on
+	Path string
+}
+
+// ReorderSimulation estimates the layer bytes rebuilt (and pulled) per build, with the current order of the build
+// steps and with the steps reordered. A step is rebuilt when its own inputs changed or any step before it was rebuilt.
+type ReorderSimulation struct {
+	// the app steps in the current order
+	Steps []ReorderStep
+	// the layer indexes of the app steps in the simulated order
+	Order []int
+	// the number of rebuilds simulated (the transitions between the versions of the image)
+	Rebuilds int
+	// set when there is no tag history to simulate, assuming every COPY/ADD from the build context changes each build
+	Estimated bool
+	// the average bytes rebuilt per build, with the current and the simulated order
+	CurrentBytes   uint64
+	ReorderedBytes uint64
+	Conflicts      []ReorderConflict
+}
+
+// SavedBytes is the average number of bytes per build that are reused from the cache with the simulated order and
+// rebuilt with the current order (0 when the simulated order does not do better).
+func (sim *ReorderSimulation) SavedBytes() uint64 {
+	if sim.ReorderedBytes >= sim.CurrentBytes {
+		return 0
+	}
+	return sim.CurrentBytes - sim.ReorderedBytes
+}
+
+// SimulateReorder simulates building the app layers of the image in the given order (layer indexes), replaying the
+// changes between the given previous versions of the image (oldest first). Without an order the steps that change
+// least often are moved ahead of the ones that change more often, as far as the steps do not overwrite each other's
+// files. Without previous versions every COPY/ADD from the build context is assumed to change each build.
+func SimulateReorder(current *Image, history []*Image, order []int) (*ReorderSimulation, error) {
+	base := current.baseImage().Layers
+	layers := current.Layers[base:]
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("the image has no layers on top of the base image to reorder")
+	}
+
+	sim := &ReorderSimulation{Steps: make([]ReorderStep, len(layers))}
+	for idx, layer := range layers {
+		sim.Steps[idx] = ReorderStep{Layer: layer.Index, Command: layer.Command, SizeBytes: layer.Size}
+	}
+
+	changes := stepChanges(current, history, layers)
+	if len(history) == 0 {
+		sim.Estimated = true
+	}
+	sim.Rebuilds = len(changes)
+	for _, changed := range changes {
+		for idx := range changed {
+			if changed[idx] {
+				sim.Steps[idx].Changes++
+			}
+		}
+	}
+
+	dependencies := stepDependencies(layers)
+
+	positions := make([]int, len(layers))
+	if order == nil {
+		positions = suggestedOrder(sim.Steps, dependencies)
+	} else {
+		if len(order) != len(layers) {
+			return nil, fmt.Errorf("the order must list each of the %d app layers (%d to %d) once, given %d", len(layers), base, len(current.Layers)-1, len(order))
+		}
+		seen := make(map[int]bool)
+		for idx, layerIndex := range order {
+			if layerIndex < base || layerIndex >= len(current.Layers) || seen[layerIndex] {
+				return nil, fmt.Errorf("the order must list each of the app layers (%d to %d) once, given %v", base, len(current.Layers)-1, order)
+			}
+			seen[layerIndex] = true
+			positions[idx] = layerIndex - base
+		}
+	}
+
+	currentOrder := make([]int, len(layers))
+	for idx := range currentOrder {
+		currentOrder[idx] = idx
+	}
+	sim.CurrentBytes = rebuiltBytes(sim.Steps, changes, currentOrder)
+	sim.ReorderedBytes = rebuiltBytes(sim.Steps, changes, positions)
+
+	placed := make(map[int]bool)
+	for _, step := range positions {
+		placed[step] = true
+		for _, dependency := range dependencies[step] {
+			if !placed[dependency.step] {
+				sim.Conflicts = append(sim.Conflicts, ReorderConflict{Layer: step + base, DependsOn: dependency.step + base, Path: dependency.path})
+			}
+		}
+	}
+
+	sim.Order = make([]int, len(positions))
+	for idx, step := range positions {
+		sim.Order[idx] = step + base
+	}
+	return sim, nil
+}
+
+// stepChanges lists, per simulated rebuild, which steps had their own inputs changed.
+func stepChanges(current *Image, history []*Image, layers []*Layer) [][]bool {
+	if len(history) == 0 {
+		changed := make([]bool, len(layers))
+		for idx, layer := range layers {
+			changed[idx] = isContextCopyCommand(layer.Command)
+		}
+		return [][]bool{changed}
+	}
+
+	versions := append(append([]*Image{}, history...), current)
+	changes := make([][]bool, 0, len(versions)-1)
+	for version := 1; version < len(versions); version++ {
+		changed := make([]bool, len(layers))
+		for idx, layer := range layers {
+			before := matchingLayer(layer, versions[version-1].Layers)
+			after := matchingLayer(layer, versions[version].Layers)
+			switch {
+			case before == nil || after == nil:
+				// the instruction itself changed
+				changed[idx] = true
+			case isCopyCommand(layer.Command):
+				changed[idx] = !sameFiles(layerFiles(before), layerFiles(after))
+			}
+		}
+		changes = append(changes, changed)
+	}
+	return changes
+}
+
+// isContextCopyCommand indicates if the instruction copies files from the build context (rather than from another
+// stage or image).
+func isContextCopyCommand(command string) bool {
+	return isCopyCommand(command) && !strings.Contains(command, "--from")
+}
+
+func sameFiles(before, after map[string]filetree.FileInfo) bool {
+	if len(before) != len(after) {
+		return false
+	}
+	for filePath, info := range after {
+		previous, exists := before[filePath]
+		if !exists || previous.Compare(info) != filetree.Unmodified {
+			return false
+		}
+	}
+	return true
+}
+
+type stepDependency struct {
+	step int
+	path string
+}
+
+// stepDependencies lists, per step, the earlier steps that wrote any of the files the step overwrites or removes, or
+// names in its instruction (e.g. "cp /app/config.yaml /etc" or "rm -rf /app/cache").
+func stepDependencies(layers []*Layer) [][]stepDependency {
+	files := make([]map[string]filetree.FileInfo, len(layers))
+	for idx, layer := range layers {
+		files[idx] = layerFiles(layer)
+	}
+
+	dependencies := make([][]stepDependency, len(layers))
+	for later := range layers {
+		for earlier := 0; earlier < later; earlier++ {
+			var shared []string
+			for filePath := range files[earlier] {
+				if touchesPath(layers[later].Command, files[later], filePath) {
+					shared = append(shared, filePath)
+				}
+			}
+			if len(shared) > 0 {
+				sort.Strings(shared)
+				dependencies[later] = append(dependencies[later], stepDependency{step: earlier, path: shared[0]})
+			}
+		}
+	}
+	return dependencies
+}
+
+// touchesPath indicates if a step (given its instruction and files) overwrites or removes the file, or the directory
+// holding it, or names either in its instruction.
+func touchesPath(command string, files map[string]filetree.FileInfo, filePath string) bool {
+	for current := filePath; current != "/" && current != "."; current = path.Dir(current) {
+		if _, exists := files[current]; exists {
+			return true
+		}
+		if mentionsPath(command, current) || mentionsPath(command, current+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsPath indicates if the instruction names the path (as a whole, not as the prefix of a longer path).
+func mentionsPath(command, filePath string) bool {
+	for offset := 0; ; {
+		idx := strings.Index(command[offset:], filePath)
+		if idx < 0 {
+			return false
+		}
+		end := offset + idx + len(filePath)
+		if end == len(command) || strings.ContainsRune(" \t\"';&|)", rune(command[end])) {
+			return true
+		}
+		offset = end
+	}
+}
+
+// suggestedOrder places the steps that change least often first, keeping each step after the steps it depends on
+// (and otherwise in the current order).
+func suggestedOrder(steps []ReorderStep, dependencies [][]stepDependency) []int {
+	order := make([]int, 0, len(steps))
+	placed := make([]bool, len(steps))
+	for len(order) < len(steps) {
+		next := -1
+		for idx := range steps {
+			if placed[idx] || !dependenciesPlaced(dependencies[idx], placed) {
+				continue
+			}
+			if next < 0 || steps[idx].Changes < steps[next].Changes {
+				next = idx
+			}
+		}
+		placed[next] = true
+		order = append(order, next)
+	}
+	return order
+}
+
+func dependenciesPlaced(dependencies []stepDependency, placed []bool) bool {
+	for _, dependency := range dependencies {
+		if !placed[dependency.step] {
+			return false
+		}
+	}
+	return true
+}
+
+// rebuiltBytes is the average bytes rebuilt per build when the steps are built in the given order: every step from
+// the first one whose inputs changed onwards.
+func rebuiltBytes(steps []ReorderStep, changes [][]bool, order []int) uint64 {
+	if len(changes) == 0 {
+		return 0
+	}
+	var total uint64
+	for _, changed := range changes {
+		busted := false
+		for _, step := range order {
+			busted = busted || changed[step]
+			if busted {
+				total += steps[step].SizeBytes
+			}
+		}
+	}
+	return total / uint64(len(changes))
+}
@@ -0,0 +1,89 @@
+package image
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestSimulateReorder(t *testing.T) {
+	// builds an image with a base layer, a "COPY . /app" layer of the app sources (which change per version, simulated
+	// with the file mode), a dependency install and a step that changes the copied sources
+	newVersion := func(sourceMode os.FileMode) *Image {
+		trees := []*filetree.FileTree{filetree.NewFileTree(), filetree.NewFileTree(), filetree.NewFileTree(), filetree.NewFileTree()}
+		add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+			if _, _, err := tree.AddPath(path, info); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		add(trees[0], "/bin", filetree.FileInfo{IsDir: true})
+		add(trees[0], "/bin/sh", filetree.FileInfo{Size: 100})
+		add(trees[1], "/app", filetree.FileInfo{IsDir: true})
+		add(trees[1], "/app/src", filetree.FileInfo{IsDir: true})
+		add(trees[1], "/app/src/main.py", filetree.FileInfo{Size: 1000, Mode: sourceMode})
+		add(trees[2], "/usr/lib/python/deps.so", filetree.FileInfo{Size: 9000})
+		add(trees[3], "/app", filetree.FileInfo{IsDir: true})
+		add(trees[3], "/app/src", filetree.FileInfo{IsDir: true})
+		add(trees[3], "/app/src/main.py", filetree.FileInfo{Size: 10, Mode: 0755})
+
+		return &Image{
+			Trees: trees,
+			Layers: []*Layer{
+				{Index: 0, Size: 100, Tree: trees[0]},
+				{Index: 1, Size: 1000, Tree: trees[1], Command: "COPY . /app # buildkit"},
+				{Index: 2, Size: 9000, Tree: trees[2], Command: "RUN pip install -r /tmp/requirements.txt"},
+				{Index: 3, Size: 10, Tree: trees[3], Command: "RUN chmod +x /app/src/main.py"},
+			},
+		}
+	}
+	current := newVersion(0700)
+
+	estimated, err := SimulateReorder(current, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !estimated.Estimated || estimated.Rebuilds != 1 {
+		t.Errorf("expected a single estimated rebuild, got %+v", estimated)
+	}
+	// the dependency install moves ahead of the sources, the chmod stays after the sources it changes
+	if !reflect.DeepEqual(estimated.Order, []int{2, 1, 3}) {
+		t.Errorf("expected the suggested order [2 1 3], got %v", estimated.Order)
+	}
+	if estimated.CurrentBytes != 10010 || estimated.ReorderedBytes != 1010 || estimated.SavedBytes() != 9000 {
+		t.Errorf("expected 10010 -> 1010 bytes rebuilt, got %d -> %d", estimated.CurrentBytes, estimated.ReorderedBytes)
+	}
+	if len(estimated.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", estimated.Conflicts)
+	}
+
+	// the sources changed in two of the three rebuilds, the dependencies in one
+	history := []*Image{newVersion(0600), newVersion(0600), newVersion(0644)}
+	history[1].Layers[2].Command = "RUN pip install -r /tmp/requirements.txt --no-cache-dir"
+	replayed, err := SimulateReorder(current, history, []int{3, 2, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Estimated || replayed.Rebuilds != 3 {
+		t.Errorf("expected 3 replayed rebuilds, got %+v", replayed)
+	}
+	changes := []int{replayed.Steps[0].Changes, replayed.Steps[1].Changes, replayed.Steps[2].Changes}
+	if !reflect.DeepEqual(changes, []int{2, 2, 0}) {
+		t.Errorf("expected the step changes [2 2 0], got %v", changes)
+	}
+	// the rebuilds changed the dependencies, then the sources and dependencies, then the sources
+	if replayed.CurrentBytes != (9010+10010+10010)/3 || replayed.ReorderedBytes != (10000+10000+1000)/3 {
+		t.Errorf("unexpected bytes rebuilt: %d -> %d", replayed.CurrentBytes, replayed.ReorderedBytes)
+	}
+	expectedConflicts := []ReorderConflict{{Layer: 3, DependsOn: 1, Path: "/app/src/main.py"}}
+	if !reflect.DeepEqual(replayed.Conflicts, expectedConflicts) {
+		t.Errorf("expected conflicts %+v, got %+v", expectedConflicts, replayed.Conflicts)
+	}
+
+	for _, order := range [][]int{{1, 2}, {0, 1, 2}, {1, 1, 2}, {1, 2, 4}} {
+		if _, err := SimulateReorder(current, nil, order); err == nil {
+			t.Errorf("expected an error for the order %v", order)
+		}
+	}
+}
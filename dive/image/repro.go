@@ -0,0 +1,303 @@
+package image
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the sources of non-reproducibility, which make two builds of the same Dockerfile differ
+const (
+	// the files carry the time of the build as their modification time
+	ReproTimestamps = "build timestamps"
+	// the files hold data generated at random (seeds, machine ids, host keys)
+	ReproRandomData = "random data"
+	// logs, caches and package manager state that record when (or in what order) the build ran
+	ReproBuildRecords = "build records"
+	// python bytecode records the modification time of its source file
+	ReproBytecode = "bytecode mtimes"
+)
+
+// the files written within this window before (or after) the layer was created are taken to be written by the build
+const reproBuildWindow = time.Hour
+
+// the files that hold random data or build records, by kind
+var reproArtifacts = []struct {
+	kind     string
+	prefixes []string
+	names    []string
+	detail   string
+}{
+	{
+		kind:     ReproRandomData,
+		prefixes: []string{"/etc/ssh/ssh_host_"},
+		names:    []string{"/etc/machine-id", "/var/lib/dbus/machine-id", "/var/lib/systemd/random-seed", "/root/.rnd"},
+		detail:   "generated at random during the build; generate it when the container starts instead",
+	},
+	{
+		kind:     ReproBuildRecords,
+		prefixes: []string{"/var/log/", "/var/lib/apt/lists/", "/var/cache/apt/", "/var/cache/yum/", "/var/cache/dnf/", "/var/lib/rpm/__db.", "/root/.cache/", "/root/.npm/_logs/"},
+		names:    []string{"/var/cache/ldconfig/aux-cache", "/var/cache/debconf/config.dat-old", "/var/cache/debconf/templates.dat-old", "/var/lib/dpkg/status-old", "/etc/ld.so.cache~"},
+		detail:   "records the time (or order) of the build; remove it within the layer that writes it",
+	},
+}
+
+// ReproFinding is a source of non-reproducibility within a layer.
+type ReproFinding struct {
+	Kind  string
+	Layer int
+	// the files involved, sorted
+	Paths     []string
+	SizeBytes uint64
+	Detail    string
+}
+
+// Reproducibility lists the sources of non-reproducibility found in the image layers.
+type Reproducibility struct {
+	Findings []ReproFinding
+}
+
+// Empty indicates if nothing in the layers keeps the build from being reproducible.
+func (repro *Reproducibility) Empty() bool {
+	return len(repro.Findings) == 0
+}
+
+// FindReproducibilityIssues flags the files of every layer that make rebuilding the same Dockerfile produce other
+// layers: files with the time of the build as their modification time (files clamped to the layer creation time, as
+// SOURCE_DATE_EPOCH builds do, are fine), files holding random data, logs and caches recording the build, and python
+// bytecode. Layers without a known creation time are only checked for the known files.
+func FindReproducibilityIssues(layers []*Layer, trees []*filetree.FileTree) *Reproducibility {
+	repro := &Reproducibility{Findings: make([]ReproFinding, 0)}
+	for idx, tree := range trees {
+		if tree == nil {
+			continue
+		}
+		var created time.Time
+		if idx < len(layers) {
+			created = layers[idx].Created
+		}
+
+		findings := make(map[string]*ReproFinding)
+		add := func(kind, detail, filePath string, size uint64) {
+			finding, exists := findings[kind]
+			if !exists {
+				finding = &ReproFinding{Kind: kind, Layer: idx, Detail: detail}
+				findings[kind] = finding
+			}
+			finding.Paths = append(finding.Paths, filePath)
+			finding.SizeBytes += size
+		}
+
+		err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+			info := node.Data.FileInfo
+			if info.IsDir || len(node.Children) > 0 || node.IsWhiteout() {
+				return nil
+			}
+			filePath := node.Path()
+			size := uint64(info.Size)
+			if kind, detail := reproArtifact(filePath); kind != "" {
+				add(kind, detail, filePath, size)
+			}
+			if name := path.Base(filePath); strings.HasSuffix(name, ".pyc") {
+				add(ReproBytecode, "compile with --invalidation-mode checked-hash (or unchecked-hash), or set SOURCE_DATE_EPOCH", filePath, size)
+			}
+			if writtenByBuild(info.ModTime, created) {
+				add(ReproTimestamps, "set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)", filePath, size)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to check the reproducibility of layer %d: %+v", idx, err)
+		}
+
+		for _, kind := range []string{ReproTimestamps, ReproRandomData, ReproBuildRecords, ReproBytecode} {
+			if finding, exists := findings[kind]; exists {
+				sort.Strings(finding.Paths)
+				repro.Findings = append(repro.Findings, *finding)
+			}
+		}
+	}
+	return repro
+}
+
+// reproArtifact returns the kind of non-reproducible artifact the file is, and what to do about it (empty otherwise).
+func reproArtifact(filePath string) (string, string) {
+	for _, artifact := range reproArtifacts {
+		for _, name := range artifact.names {
+			if filePath == name {
+				return artifact.kind, artifact.detail
+			}
+		}
+		for _, prefix := range artifact.prefixes {
+			if strings.HasPrefix(filePath, prefix) {
+				return artifact.kind, artifact.detail
+			}
+		}
+	}
+	return "", ""
+}
+
+// writtenByBuild indicates if the modification time is the time of the build: close to the creation of the layer,
+// but not clamped to it.
+func writtenByBuild(modTime, created time.Time) bool {
+	if modTime.IsZero() || created.IsZero() || modTime.Unix() == created.Unix() {
+		return false
+	}
+	return modTime.After(created.Add(-reproBuildWindow)) && modTime.Before(created.Add(reproBuildWindow))
+}
+
+// how a file differs between two builds
+const (
+	// only the modification time differs
+	ReproMtimeOnly = "mtime only"
+	// the contents differ
+	ReproContent = "content"
+	// the mode or owner differ
+	ReproMetadata = "metadata"
+)
+
+// ReproDifference is a file of a layer that differs between two builds (Change is ReproMtimeOnly, ReproContent,
+// ReproMetadata, or PathAdded/PathDeleted when the second build added or lost the file).
+type ReproDifference struct {
+	Path   string
+	Change string
+	// the known source of non-reproducibility the file is (see ReproRandomData et al.), empty when there is none
+	Cause string
+}
+
+// ReproLayerDiff compares a layer of two builds.
+type ReproLayerDiff struct {
+	Index   int
+	Command string
+	// the layers have the same diffID
+	Identical bool
+	// the layer is only in one of the builds
+	Missing     bool
+	Differences []ReproDifference
+}
+
+// MtimeOnly indicates if only the modification times of the files differ (clamping them would make the layer
+// reproducible).
+func (diff ReproLayerDiff) MtimeOnly() bool {
+	if diff.Identical || diff.Missing || len(diff.Differences) == 0 {
+		return false
+	}
+	for _, difference := range diff.Differences {
+		if difference.Change != ReproMtimeOnly {
+			return false
+		}
+	}
+	return true
+}
+
+// ReproComparison compares the layers of two builds of the same Dockerfile.
+type ReproComparison struct {
+	Layers []ReproLayerDiff
+}
+
+// Reproducible indicates if both builds have the same layers.
+func (comparison *ReproComparison) Reproducible() bool {
+	for _, layer := range comparison.Layers {
+		if !layer.Identical {
+			return false
+		}
+	}
+	return true
+}
+
+// IdenticalLayers is the number of layers both builds share.
+func (comparison *ReproComparison) IdenticalLayers() int {
+	count := 0
+	for _, layer := range comparison.Layers {
+		if layer.Identical {
+			count++
+		}
+	}
+	return count
+}
+
+// CompareBuilds compares two builds of the same Dockerfile layer by layer: layers with the same diffID are identical,
+// the files of the other layers are compared by contents, metadata and modification time.
+func CompareBuilds(first, second *Image) *ReproComparison {
+	comparison := &ReproComparison{}
+	count := len(first.Layers)
+	if len(second.Layers) > count {
+		count = len(second.Layers)
+	}
+	for idx := 0; idx < count; idx++ {
+		if idx >= len(first.Layers) || idx >= len(second.Layers) {
+			layer := first.Layers
+			if idx >= len(first.Layers) {
+				layer = second.Layers
+			}
+			comparison.Layers = append(comparison.Layers, ReproLayerDiff{Index: idx, Command: layer[idx].Command, Missing: true})
+			continue
+		}
+		diff := ReproLayerDiff{Index: idx, Command: second.Layers[idx].Command}
+		if sameLayer(first.Layers[idx], second.Layers[idx]) {
+			diff.Identical = true
+		} else if idx < len(first.Trees) && idx < len(second.Trees) {
+			diff.Differences = compareLayerFiles(first.Trees[idx], second.Trees[idx])
+		}
+		comparison.Layers = append(comparison.Layers, diff)
+	}
+	return comparison
+}
+
+// compareLayerFiles lists the files that differ between two versions of a layer, sorted by path.
+func compareLayerFiles(first, second *filetree.FileTree) []ReproDifference {
+	firstFiles, secondFiles := layerEntries(first), layerEntries(second)
+	var differences []ReproDifference
+	add := func(filePath, change string) {
+		cause, _ := reproArtifact(filePath)
+		if cause == "" && strings.HasSuffix(filePath, ".pyc") {
+			cause = ReproBytecode
+		}
+		differences = append(differences, ReproDifference{Path: filePath, Change: change, Cause: cause})
+	}
+
+	for filePath, info := range secondFiles {
+		previous, exists := firstFiles[filePath]
+		switch {
+		case !exists:
+			add(filePath, PathAdded)
+		case previous.ContentHash() != info.ContentHash() || previous.Size != info.Size || previous.Linkname != info.Linkname:
+			add(filePath, ReproContent)
+		case previous.Mode != info.Mode || previous.Uid != info.Uid || previous.Gid != info.Gid:
+			add(filePath, ReproMetadata)
+		case !previous.ModTime.Equal(info.ModTime):
+			add(filePath, ReproMtimeOnly)
+		}
+	}
+	for filePath := range firstFiles {
+		if _, exists := secondFiles[filePath]; !exists {
+			add(filePath, PathDeleted)
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+	return differences
+}
+
+// layerEntries lists the entries of a layer (files, directories and whiteouts) by path.
+func layerEntries(tree *filetree.FileTree) map[string]filetree.FileInfo {
+	files := make(map[string]filetree.FileInfo)
+	if tree == nil {
+		return files
+	}
+	err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if node != tree.Root {
+			files[node.Path()] = node.Data.FileInfo
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		logrus.Errorf("unable to list the layer files: %+v", err)
+	}
+	return files
+}
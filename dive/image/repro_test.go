@@ -0,0 +1,101 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestFindReproducibilityIssues(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sourceTime := created.Add(-30 * 24 * time.Hour)
+
+	base := filetree.NewFileTree()
+	app := filetree.NewFileTree()
+	add := func(tree *filetree.FileTree, path string, modTime time.Time) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: 10, ModTime: modTime}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	add(base, "/bin/sh", sourceTime)
+	add(base, "/etc/machine-id", sourceTime)
+	add(app, "/app/main.py", sourceTime)
+	add(app, "/app/__pycache__/main.cpython-312.pyc", created.Add(-time.Minute))
+	add(app, "/var/log/dpkg.log", created.Add(-2*time.Minute))
+	// clamped to the layer creation time (SOURCE_DATE_EPOCH)
+	add(app, "/app/clamped.txt", created)
+
+	layers := []*Layer{{Index: 0}, {Index: 1, Created: created}}
+	repro := FindReproducibilityIssues(layers, []*filetree.FileTree{base, app})
+
+	type summary struct {
+		Kind  string
+		Layer int
+		Paths []string
+	}
+	var actual []summary
+	for _, finding := range repro.Findings {
+		actual = append(actual, summary{Kind: finding.Kind, Layer: finding.Layer, Paths: finding.Paths})
+	}
+	expected := []summary{
+		{Kind: ReproRandomData, Layer: 0, Paths: []string{"/etc/machine-id"}},
+		{Kind: ReproTimestamps, Layer: 1, Paths: []string{"/app/__pycache__/main.cpython-312.pyc", "/var/log/dpkg.log"}},
+		{Kind: ReproBuildRecords, Layer: 1, Paths: []string{"/var/log/dpkg.log"}},
+		{Kind: ReproBytecode, Layer: 1, Paths: []string{"/app/__pycache__/main.cpython-312.pyc"}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected findings:\n%+v\ngot:\n%+v", expected, actual)
+	}
+}
+
+func TestCompareBuilds(t *testing.T) {
+	first, second := filetree.NewFileTree(), filetree.NewFileTree()
+	add := func(tree *filetree.FileTree, path string, size int64, modTime time.Time) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size, ModTime: modTime}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	early, late := time.Unix(1000, 0), time.Unix(2000, 0)
+	add(first, "/app/bin", 100, early)
+	add(second, "/app/bin", 100, late)
+	add(first, "/etc/machine-id", 33, early)
+	add(second, "/etc/machine-id", 34, early)
+	add(second, "/app/extra", 1, early)
+
+	mtimeFirst, mtimeSecond := filetree.NewFileTree(), filetree.NewFileTree()
+	add(mtimeFirst, "/opt/tool", 10, early)
+	add(mtimeSecond, "/opt/tool", 10, late)
+
+	base := filetree.NewFileTree()
+	one := &Image{
+		Trees:  []*filetree.FileTree{base, first, mtimeFirst},
+		Layers: []*Layer{{Index: 0, DiffID: "sha256:base"}, {Index: 1, DiffID: "sha256:a1"}, {Index: 2, DiffID: "sha256:b1"}},
+	}
+	two := &Image{
+		Trees:  []*filetree.FileTree{base, second, mtimeSecond},
+		Layers: []*Layer{{Index: 0, DiffID: "sha256:base"}, {Index: 1, DiffID: "sha256:a2"}, {Index: 2, DiffID: "sha256:b2"}},
+	}
+
+	comparison := CompareBuilds(one, two)
+	if comparison.Reproducible() || comparison.IdenticalLayers() != 1 || len(comparison.Layers) != 3 {
+		t.Fatalf("unexpected comparison: %+v", comparison)
+	}
+
+	expected := []ReproDifference{
+		{Path: "/app/bin", Change: ReproMtimeOnly},
+		{Path: "/app/extra", Change: PathAdded},
+		{Path: "/etc/machine-id", Change: ReproContent, Cause: ReproRandomData},
+	}
+	if actual := comparison.Layers[1].Differences; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected differences:\n%+v\ngot:\n%+v", expected, actual)
+	}
+	if comparison.Layers[1].MtimeOnly() || !comparison.Layers[2].MtimeOnly() {
+		t.Errorf("expected only the second layer to differ by mtime alone")
+	}
+
+	if !CompareBuilds(one, one).Reproducible() {
+		t.Errorf("expected a build to be reproducible against itself")
+	}
+}
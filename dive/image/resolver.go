@@ -1,6 +1,52 @@
 package image
 
+import (
+	"context"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// Resolver fetches (or builds) an image from a specific source. Implementations should stop work and return the
+// context error as soon as the given context is done.
 type Resolver interface {
-	Fetch(id string) (*Image, error)
-	Build(options []string) (*Image, error)
+	Fetch(ctx context.Context, id string) (*Image, error)
+	Build(ctx context.Context, options []string) (*Image, error)
+}
+
+// LazyResolver is implemented by resolvers that can fetch only the image metadata upfront, deferring the parsing of
+// each layer's contents until it is first needed (see Image.Loader).
+type LazyResolver interface {
+	FetchLazy(ctx context.Context, id string) (*Image, error)
+}
+
+// ResolverOptions are the settings a resolver fetches and parses images with. They are given to the resolver when it
+// is created, so that the images fetched by different resolvers (e.g. embedded analyses or daemon requests) do not
+// share them. See DefaultResolverOptions for the defaults.
+type ResolverOptions struct {
+	// the bounds the layer trees of each image are kept within (each image has a budget of its own)
+	Bounds AnalysisBounds
+	// the timeout and retries of the requests made to container engines and registries
+	Operation OperationPolicy
+	// whether the network may be used (offline mode)
+	Network NetworkPolicy
+	// when the container engines pull the images to analyze (an empty policy is PullMissing)
+	Pull PullPolicy
+	// the TLS options of the connections made to registries
+	Registry RegistryOptions
+	// the engine API address the docker client and CLI reach the engine at (empty uses DOCKER_HOST, or the default
+	// socket)
+	EngineHost string
+	// the limiter the images are read with (nil parses one layer at a time without throttling), shared by the images of
+	// every resolver created with it so that the limits apply to all of them together
+	IO *IOLimiter
+	// the cache the parsed layers are reused from (nil parses every layer)
+	LayerCache LayerCache
+	// the inspectors that read the contents of the files while the layer trees are built (see inspect.Inspectors)
+	Inspectors []filetree.Inspector
+}
+
+// DefaultResolverOptions are the options images are fetched with unless configured otherwise: the analysis is not
+// bounded, requests are made with DefaultOperationPolicy and images are pulled when missing.
+func DefaultResolverOptions() ResolverOptions {
+	return ResolverOptions{Operation: DefaultOperationPolicy(), Pull: PullMissing}
 }
@@ -0,0 +1,203 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OperationPolicy bounds the requests made to container engines and registries (inspecting and saving images,
+// fetching manifests and blobs), so that a hung engine socket or registry fails the fetch instead of blocking forever.
+type OperationPolicy struct {
+	// how long an attempt may take until it returns (a stream, such as a saved image, only has to start within it);
+	// 0 is unlimited
+	Timeout time.Duration
+	// how many times a failed attempt is retried (failures marked as permanent are not)
+	Retries int
+	// the delay before the first retry, doubled for every further retry
+	Backoff time.Duration
+}
+
+// the default operation policy
+const (
+	DefaultOperationTimeout = time.Minute
+	DefaultOperationRetries = 2
+	DefaultOperationBackoff = time.Second
+)
+
+// DefaultOperationPolicy is the policy requests are made with unless configured otherwise (see the defaults above).
+func DefaultOperationPolicy() OperationPolicy {
+	return OperationPolicy{
+		Timeout: DefaultOperationTimeout,
+		Retries: DefaultOperationRetries,
+		Backoff: DefaultOperationBackoff,
+	}
+}
+
+// ParseOperationPolicy builds the policy from a timeout and a backoff (e.g. "30s", "0" is unlimited and no delay) and
+// a number of retries.
+func ParseOperationPolicy(timeout string, retries int, backoff string) (OperationPolicy, error) {
+	policy := OperationPolicy{Retries: retries}
+	if retries < 0 {
+		return policy, fmt.Errorf("retries must not be negative: %d", retries)
+	}
+	var err error
+	if policy.Timeout, err = parseNonNegativeDuration("timeout", timeout); err != nil {
+		return policy, err
+	}
+	if policy.Backoff, err = parseNonNegativeDuration("backoff", backoff); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+func parseNonNegativeDuration(name, value string) (time.Duration, error) {
+	if value = strings.TrimSpace(value); value == "" || value == "0" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q (expected a duration, e.g. 30s)", name, value)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s must not be negative: %s", name, value)
+	}
+	return duration, nil
+}
+
+// permanentError is a failure that retrying would not change (e.g. an image that does not exist).
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error of an attempt as one that is not retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry runs the operation with the policy: every attempt gets a context that is canceled when the attempt does not
+// return within the timeout, and failed attempts are retried with an increasing delay. Retrying stops as soon as the
+// given context is done (e.g. the user pressed Ctrl-C). The context of the successful attempt stays valid after Retry
+// returns, so that the operation may return a stream that is read afterwards.
+func (policy OperationPolicy) Retry(ctx context.Context, name string, operation func(ctx context.Context) error) error {
+	return policy.retry(ctx, name, realClock{}, operation)
+}
+
+// retry runs the operation like Retry, with the timeouts and delays measured by the given clock.
+func (policy OperationPolicy) retry(ctx context.Context, name string, clock clock, operation func(ctx context.Context) error) error {
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := attemptOperation(ctx, policy.Timeout, clock, operation)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= policy.Retries {
+			if attempt > 0 {
+				return fmt.Errorf("%s failed after %d attempts: %v", name, attempt+1, err)
+			}
+			return err
+		}
+
+		logrus.Warnf("%s failed (retrying in %s): %v", name, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clock.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attemptOperation runs a single attempt, canceling its context when it does not return within the timeout. An attempt
+// that returns successfully wins over a timeout firing as it returns, and keeps its context (see Retry); the context
+// of any other attempt is released.
+func attemptOperation(ctx context.Context, timeout time.Duration, clock clock, operation func(ctx context.Context) error) (err error) {
+	if timeout <= 0 {
+		return operation(ctx)
+	}
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	var lock sync.Mutex
+	returned, timedOut := false, false
+	stop := clock.AfterFunc(timeout, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		if !returned {
+			timedOut = true
+			cancel()
+		}
+	})
+	err = operation(attemptCtx)
+	stop()
+
+	lock.Lock()
+	returned = true
+	expired := timedOut
+	lock.Unlock()
+
+	if err != nil && expired {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
+
+// clock measures the timeouts and delays of the retries and the pace of the IO limits (see realClock), so that tests can
+// control time.
+type clock interface {
+	// AfterFunc calls f once the duration has passed, unless the returned func is called first (which reports whether
+	// it stopped the call, like time.Timer.Stop).
+	AfterFunc(d time.Duration, f func()) func() bool
+	// After delivers on the returned channel once the duration has passed.
+	After(d time.Duration) <-chan time.Time
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep pauses the calling goroutine for the duration.
+	Sleep(d time.Duration)
+}
+
+// realClock is the clock of the time package.
+type realClock struct{}
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
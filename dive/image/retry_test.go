@@ -0,0 +1,205 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose timers only fire when the test calls fire, and whose time only passes when it is asked to
+// sleep or wait.
+type fakeClock struct {
+	timers []*fakeTimer
+	now    time.Time
+	slept  time.Duration
+}
+
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	timer := &fakeTimer{f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		wasPending := !timer.stopped
+		timer.stopped = true
+		return wasPending
+	}
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	return time.After(0)
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// Sleep advances the time of the clock instead of pausing, recording the pause.
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.slept += d
+	c.now = c.now.Add(d)
+}
+
+// fire runs the timers that were neither stopped nor fired yet.
+func (c *fakeClock) fire() {
+	for _, timer := range c.timers {
+		if !timer.stopped {
+			timer.stopped = true
+			timer.f()
+		}
+	}
+}
+
+func TestRetry_RetriesTransientFailures(t *testing.T) {
+	policy := OperationPolicy{Retries: 2}
+
+	attempts := 0
+	err := policy.Retry(context.Background(), "fetching", func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("expected success on the third attempt, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = policy.Retry(context.Background(), "fetching", func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection reset")
+	})
+	if err == nil || err.Error() != "fetching failed after 3 attempts: connection reset" || attempts != 3 {
+		t.Errorf("unexpected result after %d attempts: %v", attempts, err)
+	}
+}
+
+func TestRetry_Permanent(t *testing.T) {
+	notFound := errors.New("not found")
+	attempts := 0
+	err := OperationPolicy{Retries: 2}.Retry(context.Background(), "inspecting", func(ctx context.Context) error {
+		attempts++
+		return Permanent(notFound)
+	})
+	if err != notFound || attempts != 1 {
+		t.Errorf("expected the permanent error without retrying, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetry_Timeout(t *testing.T) {
+	clock := &fakeClock{}
+	attempts := 0
+	err := OperationPolicy{Timeout: 10 * time.Millisecond, Retries: 1}.retry(context.Background(), "saving", clock, func(ctx context.Context) error {
+		attempts++
+		// a hung engine socket only returns once the request is canceled
+		clock.fire()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err == nil || err.Error() != "saving failed after 2 attempts: timed out after 10ms" || attempts != 2 {
+		t.Errorf("unexpected result after %d attempts: %v", attempts, err)
+	}
+}
+
+func TestRetry_KeepsContextOfSuccessfulAttempt(t *testing.T) {
+	clock := &fakeClock{}
+	var attemptCtx context.Context
+	err := OperationPolicy{Timeout: 10 * time.Millisecond}.retry(context.Background(), "saving", clock, func(ctx context.Context) error {
+		attemptCtx = ctx
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.fire()
+	if attemptCtx.Err() != nil {
+		t.Errorf("expected the context of the successful attempt to stay valid (a stream may still be read)")
+	}
+}
+
+func TestRetry_CompletedAttemptWinsOverTimeout(t *testing.T) {
+	clock := &fakeClock{}
+	attempts := 0
+	err := OperationPolicy{Timeout: 10 * time.Millisecond, Retries: 1}.retry(context.Background(), "saving", clock, func(ctx context.Context) error {
+		attempts++
+		// the timeout fires just as the attempt completes
+		clock.fire()
+		return nil
+	})
+	if err != nil || attempts != 1 {
+		t.Errorf("expected the completed attempt to succeed, got %v after %d attempts", err, attempts)
+	}
+
+	// a timer firing once the attempt returned (too late to be stopped) leaves its context alone
+	var attemptCtx context.Context
+	clock = &fakeClock{}
+	err = OperationPolicy{Timeout: 10 * time.Millisecond}.retry(context.Background(), "saving", clock, func(ctx context.Context) error {
+		attemptCtx = ctx
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.timers[0].f()
+	if attemptCtx.Err() != nil {
+		t.Errorf("expected the context of the successful attempt to stay valid")
+	}
+}
+
+func TestRetry_ReleasesContextOfFailedAttempt(t *testing.T) {
+	var attemptCtx context.Context
+	err := OperationPolicy{Timeout: time.Minute}.Retry(context.Background(), "fetching", func(ctx context.Context) error {
+		attemptCtx = ctx
+		return errors.New("connection reset")
+	})
+	if err == nil {
+		t.Fatalf("expected the attempt to fail")
+	}
+	if attemptCtx.Err() == nil {
+		t.Errorf("expected the context of the failed attempt to be released")
+	}
+}
+
+func TestRetry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := OperationPolicy{Retries: 5, Backoff: time.Hour}.Retry(ctx, "fetching", func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return errors.New("connection reset")
+	})
+	if err != context.Canceled || attempts != 1 {
+		t.Errorf("expected to stop once canceled, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestParseOperationPolicy(t *testing.T) {
+	policy, err := ParseOperationPolicy("30s", 3, "0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy != (OperationPolicy{Timeout: 30 * time.Second, Retries: 3}) {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+
+	for _, invalid := range []struct {
+		timeout string
+		retries int
+		backoff string
+	}{
+		{"soon", 0, ""},
+		{"-1s", 0, ""},
+		{"", -1, ""},
+		{"", 0, "-5s"},
+	} {
+		if _, err := ParseOperationPolicy(invalid.timeout, invalid.retries, invalid.backoff); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
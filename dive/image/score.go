@@ -0,0 +1,135 @@
+package image
+
+import (
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the kinds of waste the efficiency breakdown accounts for
+const (
+	// earlier copies of files that a later layer overwrote
+	ScoreDuplicated = "duplicated files"
+	// files that a later layer deleted
+	ScoreRemovedLater = "removed later"
+	// package manager and build caches left in the final image
+	ScoreCacheDirectories = "cache directories"
+	// documentation, man pages and translations left in the final image
+	ScoreDocsAndLocales = "docs and locales"
+)
+
+// the directories holding package manager and build caches (any ".cache" directory is a cache as well)
+var cacheDirectories = []string{
+	"/var/cache",
+	"/var/lib/apt/lists",
+	"/root/.npm/_cacache",
+	"/root/.cargo/registry/cache",
+	"/go/pkg/mod/cache",
+	"/root/go/pkg/mod/cache",
+}
+
+// the directories holding documentation and translations
+var docDirectories = []string{
+	"/usr/share/doc",
+	"/usr/share/man",
+	"/usr/share/info",
+	"/usr/share/gtk-doc",
+	"/usr/share/locale",
+	"/usr/local/share/doc",
+	"/usr/local/share/man",
+}
+
+// ScoreContribution is a kind of waste lowering the efficiency score.
+type ScoreContribution struct {
+	Name  string
+	Bytes uint64
+	// the number of paths contributing
+	Files int
+	// the share of the score lost to this kind of waste (0-1)
+	Penalty float64
+}
+
+// EfficiencyBreakdown explains the efficiency score as the share of the image bytes lost to each kind of waste.
+// Unlike the efficiency score, which only accounts for files duplicated or removed across layers, caches and docs left
+// in the final image are waste as well.
+type EfficiencyBreakdown struct {
+	Score float64
+	// the bytes of every layer, which the contributions are a share of
+	TotalBytes    uint64
+	Contributions []ScoreContribution
+}
+
+// WastedBytes is the sum of the bytes of every contribution.
+func (breakdown *EfficiencyBreakdown) WastedBytes() uint64 {
+	var wastedBytes uint64
+	for _, contribution := range breakdown.Contributions {
+		wastedBytes += contribution.Bytes
+	}
+	return wastedBytes
+}
+
+// ScoreEfficiency decomposes the waste of the image into named contributions (always in the same order, including the
+// ones without any bytes), given the inefficiencies found across the layers and the total size of the layers. The paths
+// of the ignore list (which may be nil) are left out.
+func ScoreEfficiency(trees []*filetree.FileTree, inefficiencies filetree.EfficiencySlice, totalBytes uint64, ignore *filetree.IgnoreList) *EfficiencyBreakdown {
+	files := reportedFiles(trees, ignore)
+	contributions := map[string]*ScoreContribution{
+		ScoreDuplicated:       {Name: ScoreDuplicated},
+		ScoreRemovedLater:     {Name: ScoreRemovedLater},
+		ScoreCacheDirectories: {Name: ScoreCacheDirectories},
+		ScoreDocsAndLocales:   {Name: ScoreDocsAndLocales},
+	}
+	add := func(name string, sizeBytes uint64) {
+		contributions[name].Bytes += sizeBytes
+		contributions[name].Files++
+	}
+
+	for _, inefficiency := range inefficiencies {
+		cumulative := uint64(inefficiency.CumulativeSize)
+		final, exists := files[inefficiency.Path]
+		switch {
+		case !exists:
+			// deleted files, and the contents hidden by deleted (or replaced) directories
+			add(ScoreRemovedLater, cumulative)
+		case cumulative > final.size:
+			add(ScoreDuplicated, cumulative-final.size)
+		}
+	}
+
+	for filePath, file := range files {
+		switch {
+		case isCachePath(filePath):
+			add(ScoreCacheDirectories, file.size)
+		case isUnder(filePath, docDirectories):
+			add(ScoreDocsAndLocales, file.size)
+		}
+	}
+
+	breakdown := &EfficiencyBreakdown{Score: 1, TotalBytes: totalBytes}
+	for _, name := range []string{ScoreDuplicated, ScoreRemovedLater, ScoreCacheDirectories, ScoreDocsAndLocales} {
+		contribution := contributions[name]
+		if totalBytes > 0 {
+			contribution.Penalty = float64(contribution.Bytes) / float64(totalBytes)
+		}
+		breakdown.Score -= contribution.Penalty
+		breakdown.Contributions = append(breakdown.Contributions, *contribution)
+	}
+	if breakdown.Score < 0 {
+		breakdown.Score = 0
+	}
+	return breakdown
+}
+
+// isCachePath indicates if the file is within a package manager or build cache directory.
+func isCachePath(filePath string) bool {
+	return strings.Contains(filePath, "/.cache/") || isUnder(filePath, cacheDirectories)
+}
+
+func isUnder(filePath string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(filePath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package image
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestScoreEfficiency(t *testing.T) {
+	trees := []*filetree.FileTree{filetree.NewFileTree(), filetree.NewFileTree(), filetree.NewFileTree()}
+	add := func(tree *filetree.FileTree, path string, size int64) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: size}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	add(trees[0], "/app/server", 400)
+	add(trees[0], "/usr/share/doc/curl/README", 20)
+	add(trees[0], "/usr/share/locale/de/LC_MESSAGES/curl.mo", 30)
+	add(trees[1], "/app/server", 300)
+	add(trees[1], "/tmp/build.tar", 100)
+	add(trees[1], "/var/cache/apt/pkgcache.bin", 50)
+	add(trees[1], "/root/.cache/pip/wheel.whl", 25)
+	add(trees[2], "/tmp/.wh.build.tar", 0)
+
+	_, inefficiencies := filetree.Efficiency(trees)
+	breakdown := ScoreEfficiency(trees, inefficiencies, 1000, nil)
+
+	expected := []ScoreContribution{
+		{Name: ScoreDuplicated, Bytes: 400, Files: 1, Penalty: 0.4},
+		{Name: ScoreRemovedLater, Bytes: 100, Files: 1, Penalty: 0.1},
+		{Name: ScoreCacheDirectories, Bytes: 75, Files: 2, Penalty: 0.075},
+		{Name: ScoreDocsAndLocales, Bytes: 50, Files: 2, Penalty: 0.05},
+	}
+	if !reflect.DeepEqual(breakdown.Contributions, expected) {
+		t.Errorf("expected contributions:\n%+v\ngot:\n%+v", expected, breakdown.Contributions)
+	}
+	if math.Abs(breakdown.Score-0.375) > 1e-9 {
+		t.Errorf("expected a score of 0.375, got %v", breakdown.Score)
+	}
+	if wasted := breakdown.WastedBytes(); wasted != 625 {
+		t.Errorf("expected 625 wasted bytes, got %d", wasted)
+	}
+
+	empty := ScoreEfficiency([]*filetree.FileTree{filetree.NewFileTree()}, nil, 0, nil)
+	if empty.Score != 1 || len(empty.Contributions) != 4 {
+		t.Errorf("expected a perfect score for an empty image, got %+v", empty)
+	}
+}
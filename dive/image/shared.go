@@ -0,0 +1,199 @@
+package image
+
+import (
+	"sort"
+	"strings"
+)
+
+// SharedLayer is a distinct layer of a set of images, along with the images that have it.
+type SharedLayer struct {
+	DiffID  string
+	Digest  string
+	Command string
+	// the bytes a registry stores for the layer (compressed when known)
+	SizeBytes uint64
+	// the indexes of the images that have the layer (within SharedAnalysis.Images)
+	Images []int
+	key    string
+}
+
+// Shared indicates if more than one image has the layer.
+func (layer SharedLayer) Shared() bool {
+	return len(layer.Images) > 1
+}
+
+// SharedImage sums up the layers of an image of the set.
+type SharedImage struct {
+	Name   string
+	Layers int
+	// the bytes a registry stores for the layers of the image (compressed when known)
+	SizeBytes uint64
+	// the bytes of the layers the image shares with other images of the set, and of those only it has
+	SharedBytes uint64
+	UniqueBytes uint64
+}
+
+// RebaseCandidate is an image that is not built on the common base of the set, and the bytes of its own layers that
+// stand where the common base layers are (which would be shared once it is rebased onto the common base).
+type RebaseCandidate struct {
+	Image int
+	// the number of leading layers the image has in common with the common base
+	MatchingLayers int
+	SavedBytes     uint64
+}
+
+// SharedAnalysis tells which layers a set of images share (as a registry stores every distinct layer once), and
+// which images would benefit from being rebased onto the base most of them are built on.
+type SharedAnalysis struct {
+	Images []SharedImage
+	// the distinct layers, the ones shared by the most images first (then the largest)
+	Layers []SharedLayer
+	// the bytes of the layers of every image, as if no layer was shared
+	TotalBytes uint64
+	// the bytes of the distinct layers, which is what a registry stores
+	DeduplicatedBytes uint64
+	// the leading layers most of the images are built on (nil when no two images have a leading layer in common)
+	CommonBase []SharedLayer
+	// the images built on the common base
+	CommonBaseImages []int
+	// the images that could be rebased onto the common base, the largest savings first
+	Rebase []RebaseCandidate
+}
+
+// SavedBytes is the bytes saved by storing the shared layers once.
+func (analysis *SharedAnalysis) SavedBytes() uint64 {
+	return analysis.TotalBytes - analysis.DeduplicatedBytes
+}
+
+// layerKey identifies a layer across images: by DiffID, or by digest (or ID) when the DiffID is not known.
+func layerKey(layer *Layer) string {
+	switch {
+	case layer.DiffID != "":
+		return layer.DiffID
+	case layer.Digest != "":
+		return layer.Digest
+	}
+	return layer.Id
+}
+
+// AnalyzeSharedLayers compares the layers of the given images (by name), from their layer metadata alone.
+func AnalyzeSharedLayers(names []string, images [][]*Layer) *SharedAnalysis {
+	result := &SharedAnalysis{Images: make([]SharedImage, len(images))}
+
+	index := make(map[string]int)
+	for imageIdx, layers := range images {
+		seen := make(map[string]bool)
+		for _, layer := range layers {
+			key := layerKey(layer)
+			result.TotalBytes += layer.TransferSize()
+			if seen[key] {
+				// the same layer twice within an image is stored once
+				continue
+			}
+			seen[key] = true
+
+			layerIdx, exists := index[key]
+			if !exists {
+				layerIdx = len(result.Layers)
+				index[key] = layerIdx
+				result.Layers = append(result.Layers, SharedLayer{DiffID: layer.DiffID, Digest: layer.Digest, Command: layer.Command, SizeBytes: layer.TransferSize(), key: key})
+				result.DeduplicatedBytes += layer.TransferSize()
+			}
+			result.Layers[layerIdx].Images = append(result.Layers[layerIdx].Images, imageIdx)
+		}
+	}
+
+	for imageIdx, layers := range images {
+		summary := SharedImage{Name: names[imageIdx], Layers: len(layers)}
+		for _, layer := range layers {
+			summary.SizeBytes += layer.TransferSize()
+			if result.Layers[index[layerKey(layer)]].Shared() {
+				summary.SharedBytes += layer.TransferSize()
+			} else {
+				summary.UniqueBytes += layer.TransferSize()
+			}
+		}
+		result.Images[imageIdx] = summary
+	}
+
+	result.CommonBase, result.CommonBaseImages, result.Rebase = commonBase(images, result.Layers, index)
+
+	sort.SliceStable(result.Layers, func(i, j int) bool {
+		left, right := result.Layers[i], result.Layers[j]
+		if len(left.Images) != len(right.Images) {
+			return len(left.Images) > len(right.Images)
+		}
+		return left.SizeBytes > right.SizeBytes
+	})
+	return result
+}
+
+// commonBase finds the leading layers shared by the most images (the longest such prefix), and the images that are not
+// built on them.
+func commonBase(images [][]*Layer, layers []SharedLayer, index map[string]int) ([]SharedLayer, []int, []RebaseCandidate) {
+	type prefix struct {
+		length int
+		images []int
+	}
+	prefixes := make(map[string]*prefix)
+	var best *prefix
+	var bestKey string
+	for imageIdx, imageLayers := range images {
+		var keys []string
+		for length, layer := range imageLayers {
+			keys = append(keys, layerKey(layer))
+			key := strings.Join(keys, "/")
+			found, exists := prefixes[key]
+			if !exists {
+				found = &prefix{length: length + 1}
+				prefixes[key] = found
+			}
+			found.images = append(found.images, imageIdx)
+
+			better := best == nil || len(found.images) > len(best.images) ||
+				(len(found.images) == len(best.images) && found.length > best.length)
+			if len(found.images) > 1 && better {
+				best, bestKey = found, key
+			}
+		}
+	}
+	if best == nil {
+		return nil, nil, nil
+	}
+
+	base := make([]SharedLayer, 0, best.length)
+	for _, key := range strings.Split(bestKey, "/") {
+		base = append(base, layers[index[key]])
+	}
+
+	onBase := make(map[int]bool)
+	for _, imageIdx := range best.images {
+		onBase[imageIdx] = true
+	}
+	var candidates []RebaseCandidate
+	for imageIdx, imageLayers := range images {
+		if onBase[imageIdx] {
+			continue
+		}
+		candidate := RebaseCandidate{Image: imageIdx}
+		for candidate.MatchingLayers < len(imageLayers) && candidate.MatchingLayers < len(base) &&
+			layerKey(imageLayers[candidate.MatchingLayers]) == base[candidate.MatchingLayers].key {
+			candidate.MatchingLayers++
+		}
+		// the layers of the image standing where the rest of the common base is, which only this image stores (the top
+		// layer is the application itself, which stays)
+		for position := candidate.MatchingLayers; position < len(imageLayers)-1 && position < len(base); position++ {
+			layer := layers[index[layerKey(imageLayers[position])]]
+			if !layer.Shared() {
+				candidate.SavedBytes += layer.SizeBytes
+			}
+		}
+		if candidate.SavedBytes > 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].SavedBytes > candidates[j].SavedBytes
+	})
+	return base, best.images, candidates
+}
@@ -0,0 +1,63 @@
+package image
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeSharedLayers(t *testing.T) {
+	alpine := &Layer{DiffID: "sha256:alpine", Size: 3000, CompressedSize: 1000, Command: "ADD alpine"}
+	runtime := &Layer{DiffID: "sha256:runtime", Size: 2000}
+	debian := &Layer{DiffID: "sha256:debian", Size: 5000}
+	images := [][]*Layer{
+		{alpine, runtime, {DiffID: "sha256:api", Size: 100}},
+		{alpine, runtime, {DiffID: "sha256:worker", Size: 200}},
+		{alpine, {DiffID: "sha256:old-runtime", Size: 1500}, {DiffID: "sha256:cron", Size: 50}},
+		{debian, {DiffID: "sha256:legacy", Size: 300}},
+	}
+
+	analysis := AnalyzeSharedLayers([]string{"api", "worker", "cron", "legacy"}, images)
+
+	if analysis.TotalBytes != 3100+3200+2550+5300 {
+		t.Errorf("unexpected total bytes: %d", analysis.TotalBytes)
+	}
+	if analysis.DeduplicatedBytes != 1000+2000+100+200+1500+50+5000+300 {
+		t.Errorf("unexpected deduplicated bytes: %d", analysis.DeduplicatedBytes)
+	}
+	if analysis.SavedBytes() != analysis.TotalBytes-analysis.DeduplicatedBytes {
+		t.Errorf("unexpected saved bytes: %d", analysis.SavedBytes())
+	}
+
+	first := analysis.Layers[0]
+	if first.DiffID != "sha256:alpine" || !reflect.DeepEqual(first.Images, []int{0, 1, 2}) || first.SizeBytes != 1000 {
+		t.Errorf("expected the alpine layer shared by three images first, got %+v", first)
+	}
+
+	expectedImage := SharedImage{Name: "cron", Layers: 3, SizeBytes: 2550, SharedBytes: 1000, UniqueBytes: 1550}
+	if analysis.Images[2] != expectedImage {
+		t.Errorf("expected %+v, got %+v", expectedImage, analysis.Images[2])
+	}
+
+	// the first layer is shared by three images, the first two layers only by two
+	if len(analysis.CommonBase) != 1 || analysis.CommonBase[0].DiffID != "sha256:alpine" {
+		t.Errorf("unexpected common base: %+v", analysis.CommonBase)
+	}
+	if !reflect.DeepEqual(analysis.CommonBaseImages, []int{0, 1, 2}) {
+		t.Errorf("unexpected common base images: %v", analysis.CommonBaseImages)
+	}
+	expectedRebase := []RebaseCandidate{{Image: 3, MatchingLayers: 0, SavedBytes: 5000}}
+	if !reflect.DeepEqual(analysis.Rebase, expectedRebase) {
+		t.Errorf("expected rebase candidates %+v, got %+v", expectedRebase, analysis.Rebase)
+	}
+}
+
+func TestAnalyzeSharedLayers_NothingShared(t *testing.T) {
+	images := [][]*Layer{
+		{{Digest: "sha256:one", Size: 10}},
+		{{Digest: "sha256:two", Size: 20}},
+	}
+	analysis := AnalyzeSharedLayers([]string{"one", "two"}, images)
+	if analysis.SavedBytes() != 0 || analysis.CommonBase != nil || analysis.Rebase != nil {
+		t.Errorf("expected nothing shared, got %+v", analysis)
+	}
+}
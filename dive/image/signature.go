@@ -0,0 +1,160 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SignaturePolicy selects how the cosign signature of an image is verified: with the public key the image was signed
+// with, or keyless (with the identity and OIDC issuer of the certificate the signature was made with).
+type SignaturePolicy struct {
+	// the public key (a path, or a KMS URI cosign accepts), empty for keyless verification
+	Key string
+	// the identity (e.g. an email or a CI workflow URL) and OIDC issuer of keyless signatures
+	Identity string
+	Issuer   string
+	// the CI checks fail when the signature cannot be verified (otherwise it is a warning)
+	Required bool
+}
+
+// Validate checks that the policy names a key, or both the identity and the issuer of keyless signatures.
+func (p SignaturePolicy) Validate() error {
+	if p.Key != "" {
+		return nil
+	}
+	if p.Identity == "" || p.Issuer == "" {
+		return fmt.Errorf("keyless verification needs the certificate identity and OIDC issuer (or give the key the image was signed with)")
+	}
+	return nil
+}
+
+// Method describes how the signature is verified (e.g. "key cosign.pub").
+func (p SignaturePolicy) Method() string {
+	if p.Key != "" {
+		return "key " + p.Key
+	}
+	return fmt.Sprintf("keyless, %s from %s", p.Identity, p.Issuer)
+}
+
+// the cosign verify arguments of the policy
+func (p SignaturePolicy) arguments(reference string) []string {
+	args := []string{"verify", "--output", "json"}
+	if p.Key != "" {
+		args = append(args, "--key", p.Key)
+	} else {
+		args = append(args, "--certificate-identity", p.Identity, "--certificate-oidc-issuer", p.Issuer)
+	}
+	return append(args, reference)
+}
+
+// VerifiedSignature is a signature cosign found valid.
+type VerifiedSignature struct {
+	// the manifest digest the signature was made for
+	Digest string
+	// the identity and OIDC issuer of the signing certificate (keyless signatures only)
+	Subject string
+	Issuer  string
+}
+
+// SignatureVerification is the result of verifying the cosign signature of an image.
+type SignatureVerification struct {
+	Reference  string
+	Method     string
+	Verified   bool
+	Signatures []VerifiedSignature
+	// why the verification failed (empty when verified)
+	Error string
+}
+
+// Summary describes the outcome in a line (e.g. "verified (key cosign.pub)").
+func (v *SignatureVerification) Summary() string {
+	if v.Verified {
+		return fmt.Sprintf("verified (%s)", v.Method)
+	}
+	return fmt.Sprintf("not verified (%s): %s", v.Method, v.Error)
+}
+
+// VerifySignature checks the cosign signature of the image in its registry by running "cosign verify". The outcome
+// is returned rather than an error, so that it can be reported along with the analysis.
+func VerifySignature(ctx context.Context, reference string, policy SignaturePolicy, network NetworkPolicy) *SignatureVerification {
+	return verifySignature(ctx, reference, policy, network, func(ctx context.Context, args []string) ([]byte, error) {
+		return runCosign(ctx, args, network.CA)
+	})
+}
+
+func verifySignature(ctx context.Context, reference string, policy SignaturePolicy, network NetworkPolicy, run func(context.Context, []string) ([]byte, error)) *SignatureVerification {
+	verification := &SignatureVerification{Reference: reference, Method: policy.Method()}
+	if err := policy.Validate(); err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+	// cosign reads the signatures from the registry (and keyless verification reaches the transparency log)
+	if err := network.RequireNetwork("verifying the signature of '" + reference + "'"); err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+
+	output, err := run(ctx, policy.arguments(reference))
+	if err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+	signatures, err := parseCosignVerification(output)
+	if err != nil {
+		verification.Error = err.Error()
+		return verification
+	}
+	verification.Verified, verification.Signatures = true, signatures
+	return verification
+}
+
+// runCosign runs the cosign CLI (trusting the CA bundle), returning its output, or the reason it gave for failing.
+func runCosign(ctx context.Context, args []string, ca CABundle) ([]byte, error) {
+	var stderr bytes.Buffer
+	command := exec.CommandContext(ctx, "cosign", args...)
+	command.Env = ca.ToolEnvironment()
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("cosign is not installed (see https://docs.sigstore.dev/cosign/system_config/installation/)")
+	}
+	if err != nil {
+		// the last line cosign writes is the reason the verification failed
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if reason := strings.TrimPrefix(lines[len(lines)-1], "Error: "); reason != "" {
+			return nil, fmt.Errorf("%s", reason)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// parseCosignVerification reads the signatures cosign verify prints (one simple signing payload per valid signature).
+func parseCosignVerification(output []byte) ([]VerifiedSignature, error) {
+	var payloads []struct {
+		Critical struct {
+			Image struct {
+				Digest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+		Optional map[string]interface{} `json:"optional"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(output), &payloads); err != nil {
+		return nil, fmt.Errorf("unable to parse the cosign output: %v", err)
+	}
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("no valid signatures found")
+	}
+	signatures := make([]VerifiedSignature, len(payloads))
+	for idx, payload := range payloads {
+		signatures[idx].Digest = payload.Critical.Image.Digest
+		signatures[idx].Subject, _ = payload.Optional["Subject"].(string)
+		signatures[idx].Issuer, _ = payload.Optional["Issuer"].(string)
+	}
+	return signatures, nil
+}
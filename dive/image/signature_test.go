@@ -0,0 +1,46 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	output := `[{"critical":{"identity":{"docker-reference":"ghcr.io/org/app"},"image":{"docker-manifest-digest":"sha256:abc"},"type":"cosign container image signature"},` +
+		`"optional":{"Issuer":"https://token.actions.githubusercontent.com","Subject":"https://github.com/org/app/.github/workflows/release.yml@refs/tags/v1"}}]`
+
+	var args []string
+	run := func(ctx context.Context, given []string) ([]byte, error) {
+		args = given
+		return []byte(output), nil
+	}
+	policy := SignaturePolicy{Identity: "https://github.com/org/app/.github/workflows/release.yml@refs/tags/v1", Issuer: "https://token.actions.githubusercontent.com"}
+	verification := verifySignature(context.Background(), "ghcr.io/org/app:v1", policy, NetworkPolicy{}, run)
+
+	if !verification.Verified || len(verification.Signatures) != 1 {
+		t.Fatalf("expected a verified signature, got %+v", verification)
+	}
+	signature := verification.Signatures[0]
+	if signature.Digest != "sha256:abc" || signature.Issuer != policy.Issuer || signature.Subject != policy.Identity {
+		t.Errorf("unexpected signature: %+v", signature)
+	}
+	expectedArgs := "verify --output json --certificate-identity " + policy.Identity + " --certificate-oidc-issuer " + policy.Issuer + " ghcr.io/org/app:v1"
+	if strings.Join(args, " ") != expectedArgs {
+		t.Errorf("expected cosign %s, got %v", expectedArgs, args)
+	}
+
+	failing := func(ctx context.Context, given []string) ([]byte, error) {
+		return nil, fmt.Errorf("no matching signatures")
+	}
+	verification = verifySignature(context.Background(), "ghcr.io/org/app:v1", SignaturePolicy{Key: "cosign.pub"}, NetworkPolicy{}, failing)
+	if verification.Verified || verification.Summary() != "not verified (key cosign.pub): no matching signatures" {
+		t.Errorf("unexpected verification: %s", verification.Summary())
+	}
+
+	verification = verifySignature(context.Background(), "ghcr.io/org/app:v1", SignaturePolicy{Identity: "me@example.com"}, NetworkPolicy{}, run)
+	if verification.Verified || !strings.Contains(verification.Error, "OIDC issuer") {
+		t.Errorf("expected keyless verification without an issuer to fail, got %+v", verification)
+	}
+}
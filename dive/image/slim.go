@@ -0,0 +1,160 @@
+package image
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// SlimPlan slims the filesystem of an image down to the paths the user chose to keep: the paths kept and the top-most
+// paths holding nothing kept (which can be excluded as a whole).
+type SlimPlan struct {
+	// the kept paths that exist, without the paths within a kept directory (sorted)
+	Keep []string `json:"keep"`
+	// the top-most paths that hold nothing kept (sorted)
+	Exclude       []string `json:"exclude"`
+	KeptBytes     uint64   `json:"keptBytes"`
+	ExcludedBytes uint64   `json:"excludedBytes"`
+	// the symbolic links kept whose targets are not kept (they would dangle in the slimmed image)
+	DanglingLinks []string `json:"danglingLinks,omitempty"`
+}
+
+// PlanSlim splits the given tree (the filesystem of the image) into the given paths to keep and the paths to exclude.
+// Kept paths that do not exist in the tree are left out.
+func PlanSlim(tree *filetree.FileTree, keep []string) *SlimPlan {
+	plan := &SlimPlan{Keep: make([]string, 0), Exclude: make([]string, 0)}
+
+	cleaned := make([]string, 0, len(keep))
+	for _, kept := range keep {
+		kept = path.Clean("/" + kept)
+		if node, err := tree.GetNode(kept); err == nil && node != nil && node != tree.Root {
+			cleaned = append(cleaned, kept)
+		}
+	}
+	sort.Strings(cleaned)
+	for _, kept := range cleaned {
+		if !isKept(plan.Keep, kept) {
+			plan.Keep = append(plan.Keep, kept)
+		}
+	}
+
+	var visit func(node *filetree.FileNode)
+	visit = func(node *filetree.FileNode) {
+		names := make([]string, 0, len(node.Children))
+		for name := range node.Children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := node.Children[name]
+			if child.IsWhiteout() {
+				continue
+			}
+			childPath := child.Path()
+			switch {
+			case isKept(plan.Keep, childPath):
+				plan.KeptBytes += subtreeSize(child)
+				plan.DanglingLinks = append(plan.DanglingLinks, danglingLinks(child, plan.Keep)...)
+			case holdsKept(plan.Keep, childPath):
+				visit(child)
+			default:
+				plan.Exclude = append(plan.Exclude, childPath)
+				plan.ExcludedBytes += subtreeSize(child)
+			}
+		}
+	}
+	visit(tree.Root)
+	sort.Strings(plan.Exclude)
+	return plan
+}
+
+// isKept indicates if the path is one of the kept paths or is within one of them.
+func isKept(keep []string, filePath string) bool {
+	for _, kept := range keep {
+		if filePath == kept || strings.HasPrefix(filePath, kept+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsKept indicates if one of the kept paths is within the given directory.
+func holdsKept(keep []string, dir string) bool {
+	for _, kept := range keep {
+		if strings.HasPrefix(kept, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// subtreeSize sums the sizes of the files at or beneath the given node.
+func subtreeSize(node *filetree.FileNode) uint64 {
+	var size uint64
+	err := node.VisitDepthChildFirst(func(curNode *filetree.FileNode) error {
+		if !curNode.IsWhiteout() {
+			size += uint64(curNode.Data.FileInfo.Size)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// danglingLinks lists the symbolic links at or beneath the given node whose targets are not kept.
+func danglingLinks(node *filetree.FileNode, keep []string) []string {
+	var links []string
+	_ = node.VisitDepthChildFirst(func(curNode *filetree.FileNode) error {
+		info := curNode.Data.FileInfo
+		if info.TypeFlag != tar.TypeSymlink {
+			return nil
+		}
+		target := info.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(curNode.Path()), target)
+		}
+		if !isKept(keep, path.Clean(target)) {
+			links = append(links, curNode.Path())
+		}
+		return nil
+	}, nil)
+	sort.Strings(links)
+	return links
+}
+
+// WriteManifest writes the plan as JSON, naming the image it was made for.
+func (plan *SlimPlan) WriteManifest(writer io.Writer, imageName string) error {
+	manifest := struct {
+		Image string `json:"image"`
+		*SlimPlan
+	}{Image: imageName, SlimPlan: plan}
+	content, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, string(content))
+	return err
+}
+
+// WriteDockerfile writes the stages of a multi-stage Dockerfile that copy the kept paths out of the given image onto an
+// empty image.
+func (plan *SlimPlan) WriteDockerfile(writer io.Writer, imageName string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# the %d paths kept in dive (%s, %s excluded)\n", len(plan.Keep), humanize.Bytes(plan.KeptBytes), humanize.Bytes(plan.ExcludedBytes))
+	fmt.Fprintf(&sb, "FROM %s AS dive-source\n\n", imageName)
+	fmt.Fprintln(&sb, "FROM scratch")
+	for _, kept := range plan.Keep {
+		fmt.Fprintf(&sb, "COPY --from=dive-source %s %s\n", kept, kept)
+	}
+	_, err := io.WriteString(writer, sb.String())
+	return err
+}
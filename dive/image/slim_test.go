@@ -0,0 +1,61 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestPlanSlim(t *testing.T) {
+	tree := filetree.NewFileTree()
+	add := func(path string, info filetree.FileInfo) {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	add("/app/server", filetree.FileInfo{Size: 100})
+	add("/app/config/app.yaml", filetree.FileInfo{Size: 10})
+	add("/app/docs/README.md", filetree.FileInfo{Size: 5})
+	add("/etc/ssl/certs/ca.pem", filetree.FileInfo{Size: 20})
+	add("/etc/ssl/cert.pem", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "certs/ca.pem"})
+	add("/etc/passwd", filetree.FileInfo{Size: 7})
+	add("/usr/bin/tool", filetree.FileInfo{Size: 1000})
+	add("/usr/lib/libc.so", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "/lib/libc.so.6"})
+
+	plan := PlanSlim(tree, []string{"/app/server", "/app/config", "/app/config/app.yaml", "etc/ssl/cert.pem", "/usr/lib/libc.so", "/missing"})
+
+	if expected := []string{"/app/config", "/app/server", "/etc/ssl/cert.pem", "/usr/lib/libc.so"}; !reflect.DeepEqual(plan.Keep, expected) {
+		t.Errorf("expected to keep %v, got %v", expected, plan.Keep)
+	}
+	if expected := []string{"/app/docs", "/etc/passwd", "/etc/ssl/certs", "/usr/bin"}; !reflect.DeepEqual(plan.Exclude, expected) {
+		t.Errorf("expected to exclude %v, got %v", expected, plan.Exclude)
+	}
+	if plan.KeptBytes != 110 || plan.ExcludedBytes != 1032 {
+		t.Errorf("expected 110 bytes kept and 1032 excluded, got %d and %d", plan.KeptBytes, plan.ExcludedBytes)
+	}
+	if expected := []string{"/etc/ssl/cert.pem", "/usr/lib/libc.so"}; !reflect.DeepEqual(plan.DanglingLinks, expected) {
+		t.Errorf("expected the dangling links %v, got %v", expected, plan.DanglingLinks)
+	}
+
+	var dockerfile bytes.Buffer
+	if err := plan.WriteDockerfile(&dockerfile, "my-app:latest"); err != nil {
+		t.Fatalf("unable to write the Dockerfile: %v", err)
+	}
+	for _, line := range []string{"FROM my-app:latest AS dive-source", "FROM scratch", "COPY --from=dive-source /app/config /app/config"} {
+		if !strings.Contains(dockerfile.String(), line+"\n") {
+			t.Errorf("expected the Dockerfile to contain %q, got:\n%s", line, dockerfile.String())
+		}
+	}
+
+	var manifest bytes.Buffer
+	if err := plan.WriteManifest(&manifest, "my-app:latest"); err != nil {
+		t.Fatalf("unable to write the manifest: %v", err)
+	}
+	if !strings.Contains(manifest.String(), `"image": "my-app:latest"`) || !strings.Contains(manifest.String(), `"excludedBytes": 1032`) {
+		t.Errorf("unexpected manifest:\n%s", manifest.String())
+	}
+}
@@ -0,0 +1,259 @@
+package image
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const (
+	// a COPY/ADD layer must make up at least this ratio of the user bytes (all layers but the base) to be considered
+	splitDominantLayerRatio = 0.5
+	// content groups that changed in at most this ratio of the tag history transitions are considered stable
+	splitStableChangeRatio = 0.25
+)
+
+// matches the content digest docker includes in COPY/ADD history entries (e.g. "COPY dir:3a2b...e1 in /app")
+var copySourceDigestPattern = regexp.MustCompile(`\b(file|dir|multi):[0-9a-f]+`)
+
+// SplitGroup is a directory (or file) directly beneath the common root of a COPY/ADD layer.
+type SplitGroup struct {
+	Path      string
+	SizeBytes uint64
+	Changes   int
+}
+
+// SplitAdvice suggests splitting a dominant COPY/ADD layer into a rarely-changing and a frequently-changing layer,
+// based on how the layer contents changed across previous versions (tags) of the image.
+type SplitAdvice struct {
+	LayerIndex  int
+	Command     string
+	SizeBytes   uint64
+	Transitions int
+	// the number of transitions where anything in the layer changed (the layer cache was busted)
+	LayerChanges int
+	// the number of transitions where any stable group changed (the stable layer cache would have been busted)
+	StableChanges int
+	Stable        []SplitGroup
+	Volatile      []SplitGroup
+	StableBytes   uint64
+	VolatileBytes uint64
+}
+
+// CurrentCacheHitRatio is the fraction of builds (across the tag history) that reused the layer from cache.
+func (advice *SplitAdvice) CurrentCacheHitRatio() float64 {
+	return 1 - float64(advice.LayerChanges)/float64(advice.Transitions)
+}
+
+// SplitCacheHitRatio is the fraction of builds that would have reused the stable layer had the layer been split.
+func (advice *SplitAdvice) SplitCacheHitRatio() float64 {
+	return 1 - float64(advice.StableChanges)/float64(advice.Transitions)
+}
+
+// BytesPerUpdate is the average number of layer bytes that must be rebuilt and pulled per new version, before and
+// after splitting the layer.
+func (advice *SplitAdvice) BytesPerUpdate() (current, split uint64) {
+	transitions := float64(advice.Transitions)
+	current = uint64(float64(advice.LayerChanges) / transitions * float64(advice.SizeBytes))
+	split = uint64(float64(advice.StableChanges) / transitions * float64(advice.StableBytes))
+	split += uint64(float64(advice.LayerChanges) / transitions * float64(advice.VolatileBytes))
+	return current, split
+}
+
+// AdviseLayerSplit looks for a COPY/ADD layer that dominates the image size and, using the given previous versions of
+// the image (oldest first), checks if the layer mixes rarely-changing and frequently-changing content. Nil is returned
+// when there is no such layer or there is nothing to gain from splitting it.
+func AdviseLayerSplit(current *Image, history []*Image) *SplitAdvice {
+	if len(history) == 0 {
+		return nil
+	}
+
+	layer := dominantCopyLayer(current.Layers)
+	if layer == nil {
+		return nil
+	}
+
+	versions := make([]map[string]filetree.FileInfo, 0, len(history)+1)
+	for _, previous := range history {
+		versions = append(versions, layerFiles(matchingLayer(layer, previous.Layers)))
+	}
+	currentFiles := layerFiles(layer)
+	versions = append(versions, currentFiles)
+
+	root := commonRoot(currentFiles)
+	groups := make(map[string]*SplitGroup)
+	for filePath, info := range currentFiles {
+		key := groupKey(root, filePath)
+		group, exists := groups[key]
+		if !exists {
+			group = &SplitGroup{Path: key}
+			groups[key] = group
+		}
+		group.SizeBytes += uint64(info.Size)
+	}
+
+	advice := &SplitAdvice{
+		LayerIndex:  layer.Index,
+		Command:     layer.Command,
+		SizeBytes:   layer.Size,
+		Transitions: len(versions) - 1,
+	}
+
+	changedPerTransition := make([]map[string]bool, advice.Transitions)
+	for idx := 1; idx < len(versions); idx++ {
+		changed := changedGroups(root, versions[idx-1], versions[idx])
+		changedPerTransition[idx-1] = changed
+		if len(changed) > 0 {
+			advice.LayerChanges++
+		}
+		for key := range changed {
+			if group, exists := groups[key]; exists {
+				group.Changes++
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if float64(group.Changes)/float64(advice.Transitions) <= splitStableChangeRatio {
+			advice.Stable = append(advice.Stable, *group)
+			advice.StableBytes += group.SizeBytes
+		} else {
+			advice.Volatile = append(advice.Volatile, *group)
+			advice.VolatileBytes += group.SizeBytes
+		}
+	}
+
+	if len(advice.Stable) == 0 || len(advice.Volatile) == 0 || advice.LayerChanges == 0 {
+		return nil
+	}
+
+	for _, changed := range changedPerTransition {
+		for _, group := range advice.Stable {
+			if changed[group.Path] {
+				advice.StableChanges++
+				break
+			}
+		}
+	}
+
+	sortSplitGroups(advice.Stable)
+	sortSplitGroups(advice.Volatile)
+
+	return advice
+}
+
+func dominantCopyLayer(layers []*Layer) *Layer {
+	var userBytes uint64
+	for _, layer := range layers {
+		if layer.Index != 0 {
+			userBytes += layer.Size
+		}
+	}
+	if userBytes == 0 {
+		return nil
+	}
+
+	var dominant *Layer
+	for _, layer := range layers {
+		if layer.Index == 0 || !isCopyCommand(layer.Command) {
+			continue
+		}
+		if float64(layer.Size)/float64(userBytes) >= splitDominantLayerRatio && (dominant == nil || layer.Size > dominant.Size) {
+			dominant = layer
+		}
+	}
+	return dominant
+}
+
+func isCopyCommand(command string) bool {
+	command = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "#(nop)"))
+	return strings.HasPrefix(command, "COPY ") || strings.HasPrefix(command, "ADD ")
+}
+
+func normalizeCopyCommand(command string) string {
+	return copySourceDigestPattern.ReplaceAllString(strings.TrimSpace(command), "$1")
+}
+
+// matchingLayer finds the layer in a previous version of the image built from the same instruction.
+func matchingLayer(layer *Layer, candidates []*Layer) *Layer {
+	command := normalizeCopyCommand(layer.Command)
+	if layer.Index < len(candidates) && normalizeCopyCommand(candidates[layer.Index].Command) == command {
+		return candidates[layer.Index]
+	}
+	for _, candidate := range candidates {
+		if normalizeCopyCommand(candidate.Command) == command {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func layerFiles(layer *Layer) map[string]filetree.FileInfo {
+	files := make(map[string]filetree.FileInfo)
+	if layer == nil || layer.Tree == nil {
+		return files
+	}
+	_ = layer.Tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if !node.Data.FileInfo.IsDir {
+			files[node.Path()] = node.Data.FileInfo
+		}
+		return nil
+	}, nil)
+	return files
+}
+
+// commonRoot is the deepest directory containing every given path (the COPY destination, in most cases).
+func commonRoot(files map[string]filetree.FileInfo) string {
+	var root string
+	first := true
+	for filePath := range files {
+		dir := path.Dir(filePath)
+		if first {
+			root, first = dir, false
+			continue
+		}
+		for root != "/" && dir != root && !strings.HasPrefix(dir, root+"/") {
+			root = path.Dir(root)
+		}
+	}
+	if root == "" {
+		return "/"
+	}
+	return root
+}
+
+func groupKey(root, filePath string) string {
+	relative := strings.TrimPrefix(strings.TrimPrefix(filePath, root), "/")
+	if idx := strings.Index(relative, "/"); idx >= 0 {
+		relative = relative[:idx]
+	}
+	return path.Join(root, relative)
+}
+
+func changedGroups(root string, before, after map[string]filetree.FileInfo) map[string]bool {
+	changed := make(map[string]bool)
+	for filePath, info := range after {
+		previous, exists := before[filePath]
+		if !exists || previous.Compare(info) != filetree.Unmodified {
+			changed[groupKey(root, filePath)] = true
+		}
+	}
+	for filePath := range before {
+		if _, exists := after[filePath]; !exists {
+			changed[groupKey(root, filePath)] = true
+		}
+	}
+	return changed
+}
+
+func sortSplitGroups(groups []SplitGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].SizeBytes == groups[j].SizeBytes {
+			return groups[i].Path < groups[j].Path
+		}
+		return groups[i].SizeBytes > groups[j].SizeBytes
+	})
+}
@@ -0,0 +1,74 @@
+package image
+
+import (
+	"os"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestAdviseLayerSplit(t *testing.T) {
+	// builds an image with a base layer and a dominant "COPY . /app" layer, where the app sources change per version
+	// (simulated with the file mode) and the vendored dependencies do not
+	newVersion := func(sourceMode, vendorMode os.FileMode, digest string) *Image {
+		base := filetree.NewFileTree()
+		app := filetree.NewFileTree()
+
+		add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+			if _, _, err := tree.AddPath(path, info); err != nil {
+				t.Fatalf("could not setup test: %v", err)
+			}
+		}
+		add(base, "/bin", filetree.FileInfo{IsDir: true})
+		add(base, "/bin/sh", filetree.FileInfo{Size: 100})
+		add(app, "/app", filetree.FileInfo{IsDir: true})
+		add(app, "/app/vendor", filetree.FileInfo{IsDir: true})
+		add(app, "/app/vendor/lib.so", filetree.FileInfo{Size: 9000, Mode: vendorMode})
+		add(app, "/app/src", filetree.FileInfo{IsDir: true})
+		add(app, "/app/src/main.py", filetree.FileInfo{Size: 1000, Mode: sourceMode})
+
+		return &Image{
+			Trees: []*filetree.FileTree{base, app},
+			Layers: []*Layer{
+				{Index: 0, Size: 100, Tree: base},
+				{Index: 1, Size: 10000, Tree: app, Command: "COPY dir:" + digest + " in /app"},
+			},
+		}
+	}
+
+	history := []*Image{
+		newVersion(0600, 0600, "aa11"),
+		newVersion(0644, 0600, "bb22"),
+		newVersion(0655, 0600, "cc33"),
+		newVersion(0666, 0600, "dd44"),
+	}
+	current := newVersion(0700, 0644, "ee55")
+
+	advice := AdviseLayerSplit(current, history)
+	if advice == nil {
+		t.Fatalf("expected split advice")
+	}
+
+	if advice.LayerIndex != 1 || advice.Transitions != 4 || advice.LayerChanges != 4 || advice.StableChanges != 1 {
+		t.Errorf("unexpected advice: %+v", advice)
+	}
+	if len(advice.Stable) != 1 || advice.Stable[0].Path != "/app/vendor" || advice.StableBytes != 9000 {
+		t.Errorf("unexpected stable groups: %+v", advice.Stable)
+	}
+	if len(advice.Volatile) != 1 || advice.Volatile[0].Path != "/app/src" || advice.VolatileBytes != 1000 {
+		t.Errorf("unexpected volatile groups: %+v", advice.Volatile)
+	}
+	if advice.CurrentCacheHitRatio() != 0 || advice.SplitCacheHitRatio() != 0.75 {
+		t.Errorf("unexpected cache hit ratios: %v -> %v", advice.CurrentCacheHitRatio(), advice.SplitCacheHitRatio())
+	}
+	if before, after := advice.BytesPerUpdate(); before != 10000 || after != 3250 {
+		t.Errorf("unexpected bytes per update: %d -> %d", before, after)
+	}
+
+	if AdviseLayerSplit(current, nil) != nil {
+		t.Errorf("expected no advice without history")
+	}
+	if AdviseLayerSplit(history[0], history[:1]) != nil {
+		t.Errorf("expected no advice for an unchanged layer")
+	}
+}
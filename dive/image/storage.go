@@ -0,0 +1,169 @@
+package image
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const (
+	// the overlay2 driver refuses to mount more lower directories than this (docker reports "max depth exceeded")
+	MaxOverlayLayerDepth = 125
+	// layer depths beyond this noticeably slow down lookups and mounts on overlayfs
+	RecommendedOverlayLayerDepth = 64
+	// a conservative per-entry inode cost (ext4 and xfs both default to 256-512 byte inodes)
+	EstimatedInodeSizeBytes = 256
+)
+
+const (
+	StorageFriendly StorageRating = iota
+	StorageFair
+	StorageUnfriendly
+)
+
+// StorageRating is a coarse verdict on how well an image's layering suits a union filesystem at runtime.
+type StorageRating int
+
+func (rating StorageRating) String() string {
+	switch rating {
+	case StorageFriendly:
+		return "good"
+	case StorageFair:
+		return "fair"
+	case StorageUnfriendly:
+		return "poor"
+	default:
+		return fmt.Sprintf("%d", int(rating))
+	}
+}
+
+// CopyUpCandidate is a file that was rewritten by more than one layer. Files that are mutated at build time are
+// usually mutated at runtime too, and each mutation triggers a full copy-up of the file into the container layer.
+type CopyUpCandidate struct {
+	Path      string
+	Revisions int
+	SizeBytes uint64
+}
+
+// StorageOverhead estimates the runtime cost of the image layering on an overlay-style storage driver.
+type StorageOverhead struct {
+	LayerDepth   int
+	TotalEntries int
+	// the entries of each layer (in layer order), whiteouts included
+	LayerEntries     []int
+	UniqueEntries    int
+	ShadowedEntries  int
+	MetadataBytes    uint64
+	CopyUpBytes      uint64
+	CopyUpCandidates []CopyUpCandidate
+	Rating           StorageRating
+	Reasons          []string
+}
+
+// EstimateStorageOverhead derives inode/metadata overhead and copy-up costs from the given layer trees.
+func EstimateStorageOverhead(trees []*filetree.FileTree, sizeBytes uint64) *StorageOverhead {
+	result := &StorageOverhead{
+		LayerDepth:       len(trees),
+		LayerEntries:     make([]int, len(trees)),
+		CopyUpCandidates: make([]CopyUpCandidate, 0),
+		Reasons:          make([]string, 0),
+	}
+
+	revisions := make(map[string]*CopyUpCandidate)
+	paths := make(map[string]struct{})
+
+	for idx, tree := range trees {
+		err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+			result.TotalEntries++
+			result.LayerEntries[idx]++
+			path := node.Path()
+
+			if node.IsWhiteout() {
+				// a whiteout may hide an entire directory, so forget everything beneath it too
+				for existing := range paths {
+					if existing == path || strings.HasPrefix(existing, path+"/") {
+						delete(paths, existing)
+						delete(revisions, existing)
+					}
+				}
+				return nil
+			}
+			paths[path] = struct{}{}
+
+			if node.Data.FileInfo.IsDir {
+				return nil
+			}
+			candidate, exists := revisions[path]
+			if !exists {
+				candidate = &CopyUpCandidate{Path: path}
+				revisions[path] = candidate
+			}
+			candidate.Revisions++
+			candidate.SizeBytes = uint64(node.Data.FileInfo.Size)
+			return nil
+		}, nil)
+		if err != nil {
+			logrus.Errorf("unable to estimate storage overhead: %+v", err)
+		}
+	}
+
+	result.UniqueEntries = len(paths)
+	result.ShadowedEntries = result.TotalEntries - result.UniqueEntries
+	result.MetadataBytes = uint64(result.TotalEntries) * EstimatedInodeSizeBytes
+
+	for _, candidate := range revisions {
+		if candidate.Revisions > 1 {
+			result.CopyUpCandidates = append(result.CopyUpCandidates, *candidate)
+			result.CopyUpBytes += candidate.SizeBytes
+		}
+	}
+	sort.Slice(result.CopyUpCandidates, func(i, j int) bool {
+		if result.CopyUpCandidates[i].SizeBytes == result.CopyUpCandidates[j].SizeBytes {
+			return result.CopyUpCandidates[i].Path < result.CopyUpCandidates[j].Path
+		}
+		return result.CopyUpCandidates[i].SizeBytes > result.CopyUpCandidates[j].SizeBytes
+	})
+
+	result.rate(sizeBytes)
+
+	return result
+}
+
+// LargestLayer returns the index of the layer with the most entries and its entry count (-1 and 0 without layers).
+func (s *StorageOverhead) LargestLayer() (int, int) {
+	largest, entries := -1, 0
+	for idx, count := range s.LayerEntries {
+		if largest < 0 || count > entries {
+			largest, entries = idx, count
+		}
+	}
+	return largest, entries
+}
+
+// rate assigns the overall rating, noting every reason that contributed to a downgrade.
+func (s *StorageOverhead) rate(sizeBytes uint64) {
+	s.Rating = StorageFriendly
+	downgrade := func(rating StorageRating, reason string) {
+		if rating > s.Rating {
+			s.Rating = rating
+		}
+		s.Reasons = append(s.Reasons, reason)
+	}
+
+	if s.LayerDepth > MaxOverlayLayerDepth {
+		downgrade(StorageUnfriendly, fmt.Sprintf("layer depth %d exceeds the overlay2 limit of %d", s.LayerDepth, MaxOverlayLayerDepth))
+	} else if s.LayerDepth > RecommendedOverlayLayerDepth {
+		downgrade(StorageFair, fmt.Sprintf("layer depth %d is above the recommended %d", s.LayerDepth, RecommendedOverlayLayerDepth))
+	}
+
+	if s.TotalEntries > 0 && float64(s.ShadowedEntries)/float64(s.TotalEntries) > 0.5 {
+		downgrade(StorageFair, fmt.Sprintf("%d of %d layer entries are shadowed by later layers", s.ShadowedEntries, s.TotalEntries))
+	}
+
+	if sizeBytes > 0 && float64(s.CopyUpBytes)/float64(sizeBytes) > 0.1 {
+		downgrade(StorageFair, "more than 10% of the image is made of files rewritten across layers (likely copy-up at runtime)")
+	}
+}
@@ -0,0 +1,57 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestEstimateStorageOverhead(t *testing.T) {
+	trees := make([]*filetree.FileTree, 3)
+	for idx := range trees {
+		trees[idx] = filetree.NewFileTree()
+	}
+
+	add := func(tree *filetree.FileTree, path string, info filetree.FileInfo) {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	add(trees[0], "/etc", filetree.FileInfo{IsDir: true})
+	add(trees[0], "/etc/big.db", filetree.FileInfo{Size: 1000})
+	add(trees[0], "/tmp", filetree.FileInfo{IsDir: true})
+	add(trees[0], "/tmp/scratch", filetree.FileInfo{Size: 10})
+	add(trees[1], "/etc", filetree.FileInfo{IsDir: true})
+	add(trees[1], "/etc/big.db", filetree.FileInfo{Size: 2000})
+	add(trees[1], "/tmp", filetree.FileInfo{IsDir: true})
+	add(trees[1], "/tmp/scratch", filetree.FileInfo{Size: 10})
+	add(trees[2], "/.wh.tmp", filetree.FileInfo{})
+
+	storage := EstimateStorageOverhead(trees, 3020)
+
+	if storage.LayerDepth != 3 {
+		t.Errorf("expected layer depth 3, got %d", storage.LayerDepth)
+	}
+	if storage.TotalEntries != 9 {
+		t.Errorf("expected 9 entries, got %d", storage.TotalEntries)
+	}
+	if len(storage.LayerEntries) != 3 || storage.LayerEntries[0] != 4 || storage.LayerEntries[1] != 4 || storage.LayerEntries[2] != 1 {
+		t.Errorf("expected 4, 4 and 1 entries per layer, got %v", storage.LayerEntries)
+	}
+	if largest, entries := storage.LargestLayer(); largest != 0 || entries != 4 {
+		t.Errorf("expected layer 0 with 4 entries to be the largest, got layer %d with %d", largest, entries)
+	}
+	if storage.UniqueEntries != 2 {
+		t.Errorf("expected 2 unique entries, got %d", storage.UniqueEntries)
+	}
+	if len(storage.CopyUpCandidates) != 1 || storage.CopyUpCandidates[0].Path != "/etc/big.db" {
+		t.Fatalf("expected only /etc/big.db as a copy-up candidate, got %+v", storage.CopyUpCandidates)
+	}
+	if storage.CopyUpBytes != 2000 {
+		t.Errorf("expected 2000 copy-up bytes, got %d", storage.CopyUpBytes)
+	}
+	if storage.Rating != StorageFair {
+		t.Errorf("expected a fair rating, got %v (%v)", storage.Rating, storage.Reasons)
+	}
+}
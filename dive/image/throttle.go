@@ -0,0 +1,180 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IOLimits bounds the IO done while reading images, so that dive can run on shared build servers (or against busy
+// engines and registries) without starving other workloads.
+type IOLimits struct {
+	// the number of layers parsed at the same time, where the image source allows it (1 parses them one by one)
+	Concurrency int
+	// the most bytes read per second, across every image reader (0 is unlimited)
+	BytesPerSecond uint64
+	// the most reads per second, across every image reader (0 is unlimited)
+	ReadsPerSecond int
+}
+
+// ParseIOLimits builds the limits from a concurrency, a bandwidth (e.g. "100Mbps" or "12MB/s", empty or "0" is
+// unlimited) and a number of reads per second (0 is unlimited).
+func ParseIOLimits(concurrency int, bandwidth string, readsPerSecond int) (IOLimits, error) {
+	limits := IOLimits{Concurrency: concurrency, ReadsPerSecond: readsPerSecond}
+	if concurrency < 1 {
+		return limits, fmt.Errorf("concurrency must be at least 1: %d", concurrency)
+	}
+	if readsPerSecond < 0 {
+		return limits, fmt.Errorf("reads per second must not be negative: %d", readsPerSecond)
+	}
+	if bandwidth = strings.TrimSpace(bandwidth); bandwidth != "" && bandwidth != "0" {
+		bytesPerSecond, err := parseBandwidth(bandwidth)
+		if err != nil {
+			return limits, err
+		}
+		limits.BytesPerSecond = bytesPerSecond
+	}
+	return limits, nil
+}
+
+// IOLimiter holds the readers it throttles to the IO limits together (see ResolverOptions.IO). A nil limiter parses
+// one layer at a time without throttling.
+type IOLimiter struct {
+	concurrency int
+	// shared by every throttled reader, so that the limits apply to all of them together
+	bytes *tokenBucket
+	reads *tokenBucket
+}
+
+// NewLimiter creates a limiter holding its readers to the limits (one layer at a time when the concurrency is unset).
+func (limits IOLimits) NewLimiter() *IOLimiter {
+	return limits.newLimiter(realClock{})
+}
+
+func (limits IOLimits) newLimiter(clock clock) *IOLimiter {
+	if limits.Concurrency < 1 {
+		limits.Concurrency = 1
+	}
+	return &IOLimiter{
+		concurrency: limits.Concurrency,
+		bytes:       newTokenBucket(float64(limits.BytesPerSecond), clock),
+		reads:       newTokenBucket(float64(limits.ReadsPerSecond), clock),
+	}
+}
+
+// Concurrency returns the number of layers parsed at the same time.
+func (l *IOLimiter) Concurrency() int {
+	if l == nil {
+		return 1
+	}
+	return l.concurrency
+}
+
+// Throttle wraps the reader such that the reads of every reader throttled by the limiter together stay within the IO
+// limits (the reader is returned as is when there are no limits).
+func (l *IOLimiter) Throttle(reader io.Reader) io.Reader {
+	if l == nil || (l.bytes == nil && l.reads == nil) {
+		return reader
+	}
+	return &throttledReader{reader: reader, bytes: l.bytes, reads: l.reads}
+}
+
+type throttledReader struct {
+	reader io.Reader
+	bytes  *tokenBucket
+	reads  *tokenBucket
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	r.reads.wait(1)
+	n, err := r.reader.Read(p)
+	// the bytes are accounted once they are read, holding back the next read instead
+	r.bytes.wait(float64(n))
+	return n, err
+}
+
+// ForEachLayer calls the function for every layer index, running as many calls at the same time as the IO limits
+// allow, and returns the first error (the remaining layers are skipped once a call fails).
+func (l *IOLimiter) ForEachLayer(count int, fn func(index int) error) error {
+	concurrency := l.Concurrency()
+	if concurrency > count {
+		concurrency = count
+	}
+
+	indexes := make(chan int)
+	errs := make(chan error, concurrency)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := fn(index); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+	for index := 0; index < count && err == nil; index++ {
+		select {
+		case indexes <- index:
+		case err = <-errs:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	close(errs)
+	if err == nil {
+		err = <-errs
+	}
+	return err
+}
+
+// tokenBucket paces an activity to a rate, allowing bursts of up to a second worth of it.
+type tokenBucket struct {
+	lock   sync.Mutex
+	clock  clock
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a bucket for the given rate per second, measured by the clock (nil when the rate is
+// unlimited).
+func newTokenBucket(rate float64, clock clock) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{clock: clock, rate: rate, tokens: rate, last: clock.Now()}
+}
+
+// wait takes the given amount from the bucket, sleeping for as long as the bucket is overdrawn.
+func (b *tokenBucket) wait(amount float64) {
+	if b == nil || amount <= 0 {
+		return
+	}
+	b.clock.Sleep(b.take(amount, b.clock.Now()))
+}
+
+// take takes the given amount from the bucket at the given time, returning how long to wait until it is paid for.
+func (b *tokenBucket) take(amount float64, now time.Time) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	b.tokens -= amount
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
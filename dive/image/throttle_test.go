@@ -0,0 +1,149 @@
+package image
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseIOLimits(t *testing.T) {
+	cases := []struct {
+		concurrency    int
+		bandwidth      string
+		readsPerSecond int
+		expected       IOLimits
+		err            bool
+	}{
+		{concurrency: 1, expected: IOLimits{Concurrency: 1}},
+		{concurrency: 4, bandwidth: "0", readsPerSecond: 200, expected: IOLimits{Concurrency: 4, ReadsPerSecond: 200}},
+		{concurrency: 2, bandwidth: "20MB/s", expected: IOLimits{Concurrency: 2, BytesPerSecond: 20000000}},
+		{concurrency: 1, bandwidth: "100Mbps", expected: IOLimits{Concurrency: 1, BytesPerSecond: 12500000}},
+		{concurrency: 0, err: true},
+		{concurrency: 1, readsPerSecond: -1, err: true},
+		{concurrency: 1, bandwidth: "fast", err: true},
+	}
+
+	for _, test := range cases {
+		actual, err := ParseIOLimits(test.concurrency, test.bandwidth, test.readsPerSecond)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error for %d, %q, %d", test.concurrency, test.bandwidth, test.readsPerSecond)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %d, %q, %d: %v", test.concurrency, test.bandwidth, test.readsPerSecond, err)
+		}
+		if actual != test.expected {
+			t.Errorf("expected %+v, got %+v", test.expected, actual)
+		}
+	}
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	if newTokenBucket(0, realClock{}) != nil {
+		t.Fatalf("expected no bucket for an unlimited rate")
+	}
+
+	start := time.Now()
+	bucket := &tokenBucket{rate: 100, tokens: 100, last: start}
+
+	// a second worth of tokens can be taken at once...
+	if wait := bucket.take(100, start); wait != 0 {
+		t.Errorf("expected no wait within the burst, got %v", wait)
+	}
+	// ...after which taking more waits for them to be refilled
+	if wait := bucket.take(50, start); wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms, got %v", wait)
+	}
+	// the overdraft is paid back over time
+	if wait := bucket.take(0, start.Add(500*time.Millisecond)); wait != 0 {
+		t.Errorf("expected no wait once the overdraft is paid back, got %v", wait)
+	}
+	// and an idle bucket never holds more than a second worth of tokens
+	if wait := bucket.take(150, start.Add(time.Minute)); wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms beyond the burst, got %v", wait)
+	}
+}
+
+func TestIOLimiter_Throttle(t *testing.T) {
+	var unlimited *IOLimiter
+	reader := strings.NewReader("layer")
+	if unlimited.Throttle(reader) != reader || (IOLimits{Concurrency: 2}).NewLimiter().Throttle(reader) != reader {
+		t.Errorf("expected the reader to be returned as is without limits")
+	}
+
+	clock := &fakeClock{}
+	limiter := IOLimits{Concurrency: 1, BytesPerSecond: 100}.newLimiter(clock)
+	throttled := limiter.Throttle(strings.NewReader(strings.Repeat("x", 300)))
+	chunk := make([]byte, 100)
+	for read := 0; read < 300; {
+		n, err := throttled.Read(chunk)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read += n
+	}
+	// a second worth of bytes is read right away, the rest at 100 bytes per second
+	if clock.slept != 2*time.Second {
+		t.Errorf("expected the reads to be paced over 2s, got %v", clock.slept)
+	}
+}
+
+func TestIOLimiter_ForEachLayer(t *testing.T) {
+	limiter := IOLimits{Concurrency: 3}.NewLimiter()
+
+	var lock sync.Mutex
+	var running, mostRunning int
+	// the calls only return once as many are running as the limiter allows
+	allRunning := make(chan struct{})
+	var once sync.Once
+	visited := make([]bool, 10)
+	err := limiter.ForEachLayer(len(visited), func(index int) error {
+		lock.Lock()
+		running++
+		if running > mostRunning {
+			mostRunning = running
+		}
+		if running == 3 {
+			once.Do(func() { close(allRunning) })
+		}
+		visited[index] = true
+		lock.Unlock()
+
+		<-allRunning
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for index, ok := range visited {
+		if !ok {
+			t.Errorf("expected layer %d to be visited", index)
+		}
+	}
+	if mostRunning != 3 {
+		t.Errorf("expected 3 layers at the same time, got %d", mostRunning)
+	}
+
+	failure := errors.New("unreadable layer")
+	err = limiter.ForEachLayer(10, func(index int) error {
+		if index == 2 {
+			return failure
+		}
+		return nil
+	})
+	if err != failure {
+		t.Errorf("expected the layer error, got %v", err)
+	}
+
+	if err := limiter.ForEachLayer(0, func(index int) error { return failure }); err != nil {
+		t.Errorf("expected no error without layers, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the severities of vulnerabilities, the least severe first
+var severityOrder = []string{"unknown", "negligible", "low", "medium", "high", "critical"}
+
+// the package databases scanners report as the location of OS packages (they hold every package, so they are not
+// attributed to any of them)
+var packageDatabases = map[string]bool{
+	"/var/lib/dpkg/status":               true,
+	"/lib/apk/db/installed":              true,
+	"/var/lib/rpm/Packages":              true,
+	"/var/lib/rpm/Packages.db":           true,
+	"/var/lib/rpm/rpmdb.sqlite":          true,
+	"/usr/lib/sysimage/rpm/Packages":     true,
+	"/usr/lib/sysimage/rpm/rpmdb.sqlite": true,
+}
+
+// the package managers of the package types scanners report
+var packageManagers = map[string]string{
+	"deb": PackageManagerDpkg,
+	"apk": PackageManagerApk,
+	"rpm": PackageManagerRpm,
+}
+
+// Vulnerability is a vulnerability a scanner found in a package.
+type Vulnerability struct {
+	ID       string
+	Severity string
+	// the version the vulnerability is fixed in (empty when there is no fix)
+	FixedIn string
+}
+
+// VulnerablePackage is a package of the image with known vulnerabilities.
+type VulnerablePackage struct {
+	Name    string
+	Version string
+	// the kind of package (e.g. "deb", "apk", "java-archive", "python")
+	Type string
+	// the paths the scanner found the package at (e.g. a jar, or the lock file of the application)
+	Locations []string
+	// the diff ID of the layer the scanner found the package in (empty when not reported)
+	LayerDiffID     string
+	Vulnerabilities []Vulnerability
+}
+
+// Severity returns the highest severity of the vulnerabilities of the package.
+func (p *VulnerablePackage) Severity() string {
+	highest := severityOrder[0]
+	for _, vulnerability := range p.Vulnerabilities {
+		if SeverityRank(vulnerability.Severity) > SeverityRank(highest) {
+			highest = strings.ToLower(vulnerability.Severity)
+		}
+	}
+	return highest
+}
+
+// String summarizes the package (e.g. "openssl 3.0.2: 3 CVEs, critical").
+func (p *VulnerablePackage) String() string {
+	noun := "CVEs"
+	if len(p.Vulnerabilities) == 1 {
+		noun = "CVE"
+	}
+	return fmt.Sprintf("%s %s: %d %s, %s", p.Name, p.Version, len(p.Vulnerabilities), noun, p.Severity())
+}
+
+// SeverityRank orders the severities (0 for unknown severities, up to 5 for critical).
+func SeverityRank(severity string) int {
+	severity = strings.ToLower(severity)
+	for rank, name := range severityOrder {
+		if name == severity {
+			return rank
+		}
+	}
+	return 0
+}
+
+// VulnerabilityReport maps the vulnerabilities a scanner found onto the image: the files of the vulnerable packages
+// and the layers that added them.
+type VulnerabilityReport struct {
+	// the scanner the report was made with (e.g. "grype" or "trivy")
+	Scanner  string
+	Packages []*VulnerablePackage
+	// the vulnerable package each path of the image belongs to (the files of OS packages, as listed in the package
+	// database, and the locations of the other packages)
+	Files map[string]*VulnerablePackage
+	// the number of vulnerabilities of the packages each layer added, and the highest severity among them
+	LayerCounts     []int
+	LayerSeverities []string
+}
+
+// Count returns the number of vulnerabilities found (the same vulnerability in two packages counts twice).
+func (r *VulnerabilityReport) Count() int {
+	if r == nil {
+		return 0
+	}
+	count := 0
+	for _, pkg := range r.Packages {
+		count += len(pkg.Vulnerabilities)
+	}
+	return count
+}
+
+// CountBySeverity returns the number of vulnerabilities of each severity.
+func (r *VulnerabilityReport) CountBySeverity() map[string]int {
+	counts := make(map[string]int)
+	if r == nil {
+		return counts
+	}
+	for _, pkg := range r.Packages {
+		for _, vulnerability := range pkg.Vulnerabilities {
+			counts[severityOrder[SeverityRank(vulnerability.Severity)]]++
+		}
+	}
+	return counts
+}
+
+// SeverityNames returns the severities, the most severe first.
+func SeverityNames() []string {
+	names := make([]string, len(severityOrder))
+	for idx, name := range severityOrder {
+		names[len(severityOrder)-1-idx] = name
+	}
+	return names
+}
+
+// ParseVulnerabilityReport reads the JSON report of a vulnerability scanner: grype ("grype <image> -o json") or trivy
+// ("trivy image --format json <image>"), detected from the contents.
+func ParseVulnerabilityReport(content []byte) (*VulnerabilityReport, error) {
+	var document struct {
+		Matches json.RawMessage `json:"matches"`
+		Results json.RawMessage `json:"Results"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("unable to parse the vulnerability report: %v", err)
+	}
+	switch {
+	case document.Matches != nil:
+		return parseGrypeReport(content)
+	case document.Results != nil:
+		return parseTrivyReport(content)
+	}
+	return nil, fmt.Errorf("unknown vulnerability report format (expected the JSON output of grype or trivy)")
+}
+
+// packageSet merges the vulnerabilities of the same package (scanners report each vulnerability on its own).
+type packageSet struct {
+	packages []*VulnerablePackage
+	byKey    map[string]*VulnerablePackage
+}
+
+func (set *packageSet) add(pkg VulnerablePackage, vulnerability Vulnerability) {
+	if set.byKey == nil {
+		set.byKey = make(map[string]*VulnerablePackage)
+	}
+	key := strings.Join([]string{pkg.Type, pkg.Name, pkg.Version, strings.Join(pkg.Locations, ",")}, "\x00")
+	existing, exists := set.byKey[key]
+	if !exists {
+		existing = &pkg
+		set.byKey[key] = existing
+		set.packages = append(set.packages, existing)
+	}
+	for _, known := range existing.Vulnerabilities {
+		if known.ID == vulnerability.ID {
+			return
+		}
+	}
+	existing.Vulnerabilities = append(existing.Vulnerabilities, vulnerability)
+}
+
+func parseGrypeReport(content []byte) (*VulnerabilityReport, error) {
+	var document struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+				Fix      struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name      string `json:"name"`
+				Version   string `json:"version"`
+				Type      string `json:"type"`
+				Locations []struct {
+					Path    string `json:"path"`
+					LayerID string `json:"layerID"`
+				} `json:"locations"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("unable to parse the grype report: %v", err)
+	}
+
+	var set packageSet
+	for _, match := range document.Matches {
+		pkg := VulnerablePackage{Name: match.Artifact.Name, Version: match.Artifact.Version, Type: match.Artifact.Type}
+		for _, location := range match.Artifact.Locations {
+			pkg.Locations = append(pkg.Locations, path.Clean("/"+location.Path))
+			if pkg.LayerDiffID == "" {
+				pkg.LayerDiffID = location.LayerID
+			}
+		}
+		set.add(pkg, Vulnerability{
+			ID:       match.Vulnerability.ID,
+			Severity: strings.ToLower(match.Vulnerability.Severity),
+			FixedIn:  strings.Join(match.Vulnerability.Fix.Versions, ", "),
+		})
+	}
+	return &VulnerabilityReport{Scanner: "grype", Packages: set.packages}, nil
+}
+
+func parseTrivyReport(content []byte) (*VulnerabilityReport, error) {
+	var document struct {
+		Results []struct {
+			Target          string `json:"Target"`
+			Class           string `json:"Class"`
+			Type            string `json:"Type"`
+			Vulnerabilities []struct {
+				VulnerabilityID  string `json:"VulnerabilityID"`
+				PkgName          string `json:"PkgName"`
+				PkgPath          string `json:"PkgPath"`
+				InstalledVersion string `json:"InstalledVersion"`
+				FixedVersion     string `json:"FixedVersion"`
+				Severity         string `json:"Severity"`
+				Layer            struct {
+					DiffID string `json:"DiffID"`
+				} `json:"Layer"`
+			} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("unable to parse the trivy report: %v", err)
+	}
+
+	var set packageSet
+	for _, result := range document.Results {
+		for _, found := range result.Vulnerabilities {
+			pkg := VulnerablePackage{Name: found.PkgName, Version: found.InstalledVersion, Type: result.Type, LayerDiffID: found.Layer.DiffID}
+			switch {
+			case found.PkgPath != "":
+				pkg.Locations = []string{path.Clean("/" + found.PkgPath)}
+			case result.Class != "os-pkgs" && result.Target != "":
+				// language packages without a path of their own are found in the lock file named by the target
+				pkg.Locations = []string{path.Clean("/" + result.Target)}
+			}
+			if result.Class == "os-pkgs" {
+				pkg.Type = osPackageType(result.Type)
+			}
+			set.add(pkg, Vulnerability{ID: found.VulnerabilityID, Severity: strings.ToLower(found.Severity), FixedIn: found.FixedVersion})
+		}
+	}
+	return &VulnerabilityReport{Scanner: "trivy", Packages: set.packages}, nil
+}
+
+// osPackageType returns the package type of the packages of the given distribution (as trivy names them).
+func osPackageType(distribution string) string {
+	switch distribution {
+	case "debian", "ubuntu":
+		return "deb"
+	case "alpine", "wolfi", "chainguard":
+		return "apk"
+	}
+	return "rpm"
+}
+
+// Map attributes the vulnerable packages to the files and layers of the image. The files of OS packages are read
+// from the package databases (dpkg, apk and rpm) when the file contents are available, otherwise only the locations the
+// scanner reported are known.
+func (r *VulnerabilityReport) Map(layers []*Layer, trees []*filetree.FileTree, installed *Packages) {
+	r.Files = make(map[string]*VulnerablePackage)
+	r.LayerCounts = make([]int, len(trees))
+	r.LayerSeverities = make([]string, len(trees))
+	last := len(trees) - 1
+
+	for _, pkg := range r.Packages {
+		var files []string
+		for _, location := range pkg.Locations {
+			if !packageDatabases[location] {
+				files = append(files, location)
+			}
+		}
+		if owned := installed.Find(packageManagers[pkg.Type], pkg.Name); owned != nil {
+			files = append(files, owned.Files...)
+		}
+
+		// the package is attributed to the layer that last wrote its files (where the vulnerable version came from)
+		layer := -1
+		for _, file := range files {
+			idx, node, exists := FileLayer(trees, last, file)
+			if !exists || node.Data.FileInfo.IsDir || len(node.Children) > 0 {
+				continue
+			}
+			if _, claimed := r.Files[file]; !claimed {
+				r.Files[file] = pkg
+			}
+			if idx > layer {
+				layer = idx
+			}
+		}
+		if layer < 0 {
+			layer = layerOfDiffID(layers, pkg.LayerDiffID)
+		}
+		if layer < 0 || layer >= len(trees) {
+			continue
+		}
+		r.LayerCounts[layer] += len(pkg.Vulnerabilities)
+		if SeverityRank(pkg.Severity()) > SeverityRank(r.LayerSeverities[layer]) {
+			r.LayerSeverities[layer] = pkg.Severity()
+		}
+	}
+	sort.SliceStable(r.Packages, func(i, j int) bool {
+		return SeverityRank(r.Packages[i].Severity()) > SeverityRank(r.Packages[j].Severity())
+	})
+}
+
+func layerOfDiffID(layers []*Layer, diffID string) int {
+	if diffID == "" {
+		return -1
+	}
+	for idx, layer := range layers {
+		if layer.DiffID == diffID {
+			return idx
+		}
+	}
+	return -1
+}
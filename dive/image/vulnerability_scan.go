@@ -0,0 +1,101 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// the vulnerability scanners that can be run on the image
+const (
+	ScannerGrype = "grype"
+	ScannerTrivy = "trivy"
+)
+
+// ScanVulnerabilities runs a vulnerability scanner (grype or trivy) on the image and reads its report. The source is
+// the image source the image is read from (e.g. "docker", "podman", "docker-archive" or "registry"), so that the
+// scanner reads the same image. In offline mode the scanner does not update its database.
+func ScanVulnerabilities(ctx context.Context, scanner, source, reference string, network NetworkPolicy) (*VulnerabilityReport, error) {
+	args, err := scannerArguments(scanner, source, reference)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	if network.Offline {
+		if args, env, err = offlineScannerArguments(scanner, args, reference, network); err != nil {
+			return nil, err
+		}
+	}
+	var stderr bytes.Buffer
+	command := exec.CommandContext(ctx, scanner, args...)
+	command.Env = network.CA.ToolEnvironment(env...)
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is not installed", scanner)
+	}
+	if err != nil {
+		if reason := strings.TrimSpace(stderr.String()); reason != "" {
+			lines := strings.Split(reason, "\n")
+			return nil, fmt.Errorf("%s failed: %s", scanner, lines[len(lines)-1])
+		}
+		return nil, fmt.Errorf("%s failed: %v", scanner, err)
+	}
+	return ParseVulnerabilityReport(output)
+}
+
+// scannerArguments returns the arguments that make the scanner read the image from the given source, with a JSON report.
+func scannerArguments(scanner, source, reference string) ([]string, error) {
+	switch scanner {
+	case ScannerGrype:
+		var scheme string
+		switch source {
+		case "docker", "podman":
+			scheme = source
+		case "docker-archive":
+			scheme = "docker-archive"
+			if info, err := os.Stat(reference); err == nil && info.IsDir() {
+				scheme = "oci-dir"
+			}
+		default:
+			scheme = "registry"
+		}
+		return []string{scheme + ":" + reference, "-o", "json", "-q"}, nil
+	case ScannerTrivy:
+		args := []string{"image", "--format", "json", "--quiet"}
+		switch source {
+		case "docker", "podman":
+			return append(args, "--image-src", source, reference), nil
+		case "docker-archive":
+			return append(args, "--input", reference), nil
+		}
+		return append(args, "--image-src", "remote", reference), nil
+	}
+	return nil, fmt.Errorf("unknown vulnerability scanner %q (expected %s or %s)", scanner, ScannerGrype, ScannerTrivy)
+}
+
+// offlineScannerArguments keeps the scanner from reaching the network in offline mode: the vulnerability database is
+// not updated (the one installed is used) and no update check is made. The arguments and the environment to add are
+// returned, or an error when the scanner would read the image from its registry.
+func offlineScannerArguments(scanner string, args []string, reference string, network NetworkPolicy) ([]string, []string, error) {
+	switch scanner {
+	case ScannerGrype:
+		if strings.HasPrefix(args[0], "registry:") {
+			return nil, nil, network.RequireNetwork(fmt.Sprintf("scanning '%s' from its registry", reference))
+		}
+		return args, []string{"GRYPE_DB_AUTO_UPDATE=false", "GRYPE_CHECK_FOR_APP_UPDATE=false"}, nil
+	case ScannerTrivy:
+		for idx := range args {
+			if args[idx] == "--image-src" && idx+1 < len(args) && args[idx+1] == "remote" {
+				return nil, nil, network.RequireNetwork(fmt.Sprintf("scanning '%s' from its registry", reference))
+			}
+		}
+		offline := []string{args[0], "--skip-db-update", "--skip-java-db-update", "--offline-scan", "--skip-version-check"}
+		return append(offline, args[1:]...), nil, nil
+	}
+	return args, nil, nil
+}
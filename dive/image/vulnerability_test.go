@@ -0,0 +1,134 @@
+package image
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// mapContents reads the files from a map of paths to contents
+type mapContents map[string]string
+
+func (contents mapContents) OpenFile(layer int, filePath string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(contents[filePath])), nil
+}
+
+func TestParseVulnerabilityReport_Grype(t *testing.T) {
+	report, err := ParseVulnerabilityReport([]byte(`{"matches":[
+		{"vulnerability":{"id":"CVE-2023-0001","severity":"High","fix":{"versions":["3.0.8"]}},
+		 "artifact":{"name":"openssl","version":"3.0.2","type":"deb","locations":[{"path":"/var/lib/dpkg/status","layerID":"sha256:base"}]}},
+		{"vulnerability":{"id":"CVE-2023-0002","severity":"Critical","fix":{"versions":[]}},
+		 "artifact":{"name":"openssl","version":"3.0.2","type":"deb","locations":[{"path":"/var/lib/dpkg/status","layerID":"sha256:base"}]}},
+		{"vulnerability":{"id":"CVE-2022-0003","severity":"Medium","fix":{"versions":[]}},
+		 "artifact":{"name":"guava","version":"30.0","type":"java-archive","locations":[{"path":"/app/lib/guava-30.0.jar","layerID":"sha256:app"}]}}
+	]}`))
+	if err != nil {
+		t.Fatalf("unable to parse report: %v", err)
+	}
+	if report.Scanner != "grype" || len(report.Packages) != 2 || report.Count() != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	openssl := report.Packages[0]
+	if openssl.Severity() != "critical" || openssl.Vulnerabilities[0].FixedIn != "3.0.8" || openssl.String() != "openssl 3.0.2: 2 CVEs, critical" {
+		t.Errorf("unexpected package: %+v", openssl)
+	}
+
+	trees := []*filetree.FileTree{filetree.NewFileTree(), filetree.NewFileTree()}
+	add := func(tree *filetree.FileTree, path string) {
+		if _, _, err := tree.AddPath(path, filetree.FileInfo{Size: 10}); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	add(trees[0], "/var/lib/dpkg/status")
+	add(trees[0], "/var/lib/dpkg/info/openssl:amd64.list")
+	add(trees[0], "/usr/bin/openssl")
+	add(trees[1], "/app/lib/guava-30.0.jar")
+	layers := []*Layer{{Index: 0, DiffID: "sha256:base"}, {Index: 1, DiffID: "sha256:app"}}
+	contents := mapContents{"/var/lib/dpkg/status": "Package: openssl\nStatus: install ok installed\nVersion: 3.0.2\n", "/var/lib/dpkg/info/openssl:amd64.list": "/.\n/usr\n/usr/bin\n/usr/bin/openssl\n/usr/share/doc/openssl/gone\n"}
+
+	report.Map(layers, trees, ReadPackages(trees, contents))
+
+	if report.Files["/usr/bin/openssl"] != openssl || report.Files["/app/lib/guava-30.0.jar"] == nil {
+		t.Errorf("expected the package files to be vulnerable, got %v", report.Files)
+	}
+	for _, path := range []string{"/var/lib/dpkg/status", "/usr/bin", "/usr/share/doc/openssl/gone"} {
+		if _, exists := report.Files[path]; exists {
+			t.Errorf("expected %s not to be attributed to a package", path)
+		}
+	}
+	if report.LayerCounts[0] != 2 || report.LayerCounts[1] != 1 || report.LayerSeverities[0] != "critical" || report.LayerSeverities[1] != "medium" {
+		t.Errorf("unexpected layer counts: %v %v", report.LayerCounts, report.LayerSeverities)
+	}
+}
+
+func TestParseVulnerabilityReport_Trivy(t *testing.T) {
+	report, err := ParseVulnerabilityReport([]byte(`{"Results":[
+		{"Target":"alpine 3.18","Class":"os-pkgs","Type":"alpine","Vulnerabilities":[
+			{"VulnerabilityID":"CVE-2023-0001","PkgName":"busybox","InstalledVersion":"1.36.1-r0","FixedVersion":"1.36.1-r1","Severity":"HIGH","Layer":{"DiffID":"sha256:base"}}]},
+		{"Target":"app/package-lock.json","Class":"lang-pkgs","Type":"npm","Vulnerabilities":[
+			{"VulnerabilityID":"CVE-2023-0002","PkgName":"lodash","InstalledVersion":"4.17.20","Severity":"LOW","Layer":{"DiffID":"sha256:app"}}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("unable to parse report: %v", err)
+	}
+	if report.Scanner != "trivy" || len(report.Packages) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if busybox := report.Packages[0]; busybox.Type != "apk" || busybox.LayerDiffID != "sha256:base" || busybox.Vulnerabilities[0].Severity != "high" {
+		t.Errorf("unexpected package: %+v", busybox)
+	}
+	if lodash := report.Packages[1]; len(lodash.Locations) != 1 || lodash.Locations[0] != "/app/package-lock.json" {
+		t.Errorf("expected the lock file as the location, got %+v", lodash)
+	}
+
+	if _, err := ParseVulnerabilityReport([]byte(`{"other":[]}`)); err == nil {
+		t.Errorf("expected an error for an unknown report")
+	}
+}
+
+func TestScannerArguments(t *testing.T) {
+	cases := []struct {
+		scanner, source, reference string
+		expected                   string
+	}{
+		{scanner: ScannerGrype, source: "docker", reference: "app:v1", expected: "docker:app:v1 -o json -q"},
+		{scanner: ScannerGrype, source: "registry", reference: "ghcr.io/org/app:v1", expected: "registry:ghcr.io/org/app:v1 -o json -q"},
+		{scanner: ScannerTrivy, source: "podman", reference: "app:v1", expected: "image --format json --quiet --image-src podman app:v1"},
+		{scanner: ScannerTrivy, source: "docker-archive", reference: "app.tar", expected: "image --format json --quiet --input app.tar"},
+		{scanner: ScannerTrivy, source: "containerd", reference: "docker.io/library/app:v1", expected: "image --format json --quiet --image-src remote docker.io/library/app:v1"},
+	}
+	for _, test := range cases {
+		args, err := scannerArguments(test.scanner, test.source, test.reference)
+		if err != nil || strings.Join(args, " ") != test.expected {
+			t.Errorf("%s %s: expected %q, got %q (%v)", test.scanner, test.source, test.expected, strings.Join(args, " "), err)
+		}
+	}
+	if _, err := scannerArguments("clair", "docker", "app:v1"); err == nil {
+		t.Errorf("expected an error for an unknown scanner")
+	}
+}
+
+func TestOfflineScannerArguments(t *testing.T) {
+	offline := NetworkPolicy{Offline: true}
+	args, env, err := offlineScannerArguments(ScannerGrype, []string{"docker:app:v1", "-o", "json", "-q"}, "app:v1", offline)
+	if err != nil || strings.Join(env, " ") != "GRYPE_DB_AUTO_UPDATE=false GRYPE_CHECK_FOR_APP_UPDATE=false" || len(args) != 4 {
+		t.Errorf("unexpected grype arguments %q and environment %q (%v)", args, env, err)
+	}
+	args, _, err = offlineScannerArguments(ScannerTrivy, []string{"image", "--format", "json", "--quiet", "--input", "app.tar"}, "app.tar", offline)
+	if expected := "image --skip-db-update --skip-java-db-update --offline-scan --skip-version-check --format json --quiet --input app.tar"; err != nil || strings.Join(args, " ") != expected {
+		t.Errorf("expected %q, got %q (%v)", expected, strings.Join(args, " "), err)
+	}
+
+	for scanner, args := range map[string][]string{
+		ScannerGrype: {"registry:ghcr.io/org/app:v1", "-o", "json", "-q"},
+		ScannerTrivy: {"image", "--format", "json", "--quiet", "--image-src", "remote", "ghcr.io/org/app:v1"},
+	} {
+		if _, _, err := offlineScannerArguments(scanner, args, "ghcr.io/org/app:v1", offline); !errors.Is(err, ErrOffline) {
+			t.Errorf("%s: expected scanning from the registry to fail offline, got %v", scanner, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package inspect
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the attributes of a jar manifest that are reported, in order
+var manifestAttributes = []string{"Implementation-Title", "Implementation-Version", "Main-Class", "Start-Class", "Created-By", "Build-Jdk"}
+
+// the largest metadata file (e.g. a manifest) read from an archive
+const maxMetadataBytes = 1024 * 1024
+
+// ArchiveInspector reads the zip based package archives of Java (jar, war and ear files) and Python (wheel and egg
+// files): their size once extracted, the package name and version from their metadata and (for Java) the bytecode
+// version the classes were compiled for.
+type ArchiveInspector struct{}
+
+func (i *ArchiveInspector) Name() string {
+	return "archive"
+}
+
+// Accepts the package archives by their extension.
+func (i *ArchiveInspector) Accepts(header *tar.Header) bool {
+	return archiveKind(header.Name) != ""
+}
+
+func (i *ArchiveInspector) Inspect(filePath string, contents io.ReaderAt, size int64) (*filetree.Inspection, error) {
+	reader, err := zip.NewReader(contents, size)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &filetree.Inspection{}
+	var uncompressed uint64
+	for _, file := range reader.File {
+		uncompressed += file.UncompressedSize64
+	}
+	inspection.Add("Entries", strconv.Itoa(len(reader.File)))
+	inspection.Add("Extracted", humanize.Bytes(uncompressed))
+
+	switch archiveKind(filePath) {
+	case "java":
+		inspectJar(inspection, reader)
+	case "python":
+		inspectWheel(inspection, reader)
+	}
+	return inspection, nil
+}
+
+// archiveKind returns the ecosystem of a package archive ("java" or "python", empty for other files).
+func archiveKind(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jar", ".war", ".ear":
+		return "java"
+	case ".whl", ".egg":
+		return "python"
+	}
+	return ""
+}
+
+func inspectJar(inspection *filetree.Inspection, reader *zip.Reader) {
+	var nested int
+	var classVersion string
+	var manifest map[string]string
+	for _, file := range reader.File {
+		name := file.Name
+		switch {
+		case name == "META-INF/MANIFEST.MF":
+			manifest = readArchiveHeaders(file)
+		case strings.HasSuffix(name, ".jar"):
+			nested++
+		case classVersion == "" && strings.HasSuffix(name, ".class") && !strings.HasSuffix(name, "module-info.class"):
+			classVersion = readClassVersion(file)
+		}
+	}
+
+	for _, attribute := range manifestAttributes {
+		inspection.Add(attribute, manifest[attribute])
+	}
+	inspection.Add("Java target", classVersion)
+	if nested > 0 {
+		inspection.Add("Nested jars", strconv.Itoa(nested))
+	}
+}
+
+func inspectWheel(inspection *filetree.Inspection, reader *zip.Reader) {
+	var metadata, wheel map[string]string
+	for _, file := range reader.File {
+		dir, name := path.Split(file.Name)
+		if !strings.HasSuffix(dir, ".dist-info/") && !strings.HasSuffix(dir, "EGG-INFO/") {
+			continue
+		}
+		switch name {
+		case "METADATA", "PKG-INFO":
+			metadata = readArchiveHeaders(file)
+		case "WHEEL":
+			wheel = readArchiveHeaders(file)
+		}
+	}
+
+	inspection.Add("Name", metadata["Name"])
+	inspection.Add("Version", metadata["Version"])
+	inspection.Add("Requires-Python", metadata["Requires-Python"])
+	inspection.Add("Tag", wheel["Tag"])
+	if purelib, ok := wheel["Root-Is-Purelib"]; ok {
+		native := "yes"
+		if strings.EqualFold(purelib, "true") {
+			native = "no"
+		}
+		inspection.Add("Native code", native)
+	}
+}
+
+// readArchiveHeaders reads a file of "Name: value" headers (a jar manifest or Python package metadata), where lines
+// starting with a space continue the previous value. Only the first value of repeated headers is kept.
+func readArchiveHeaders(file *zip.File) map[string]string {
+	headers := make(map[string]string)
+	reader, err := file.Open()
+	if err != nil {
+		return headers
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(io.LimitReader(reader, maxMetadataBytes))
+	var last string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			// the headers end at the first blank line (the package description or the per-entry sections follow)
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if last != "" {
+				headers[last] += strings.TrimLeft(line, " \t")
+			}
+			continue
+		}
+		separator := strings.Index(line, ":")
+		if separator < 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:separator])
+		if _, exists := headers[name]; exists {
+			last = ""
+			continue
+		}
+		headers[name] = strings.TrimSpace(line[separator+1:])
+		last = name
+	}
+	return headers
+}
+
+// readClassVersion reads the Java version a class file was compiled for from its major version.
+func readClassVersion(file *zip.File) string {
+	reader, err := file.Open()
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	header, err := ioutil.ReadAll(io.LimitReader(reader, 8))
+	if err != nil || len(header) < 8 || binary.BigEndian.Uint32(header) != 0xCAFEBABE {
+		return ""
+	}
+	major := int(binary.BigEndian.Uint16(header[6:]))
+	switch {
+	case major < 45:
+		return ""
+	case major <= 48:
+		return fmt.Sprintf("1.%d", major-44)
+	}
+	return fmt.Sprintf("%d (class version %d)", major-44, major)
+}
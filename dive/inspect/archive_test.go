@@ -0,0 +1,124 @@
+package inspect
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// makeZip creates a zip archive with the given files.
+func makeZip(t *testing.T, files map[string][]byte) *bytes.Reader {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+		if _, err := file.Write(content); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	return bytes.NewReader(buffer.Bytes())
+}
+
+func fields(inspection *filetree.Inspection) map[string]string {
+	result := make(map[string]string)
+	for _, field := range inspection.Fields {
+		result[field.Name] = field.Value
+	}
+	return result
+}
+
+func TestArchiveInspectorAccepts(t *testing.T) {
+	inspector := &ArchiveInspector{}
+	cases := map[string]bool{
+		"/app/app.jar":                        true,
+		"/opt/server/ROOT.WAR":                true,
+		"/wheels/six-1.16.0-py2.py3-none.whl": true,
+		"/app/app.zip":                        false,
+		"/app/jar":                            false,
+	}
+	for name, expected := range cases {
+		if actual := inspector.Accepts(&tar.Header{Name: name}); actual != expected {
+			t.Errorf("%s: expected accepted=%v, got %v", name, expected, actual)
+		}
+	}
+}
+
+func TestArchiveInspectorJar(t *testing.T) {
+	class := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 61, 0}
+	archive := makeZip(t, map[string][]byte{
+		"META-INF/MANIFEST.MF":       []byte("Manifest-Version: 1.0\r\nImplementation-Title: app\r\nMain-Class: org.springframework.boot.loader.Jar\r\n Launcher\r\nBuild-Jdk: 17.0.2\r\n\r\nName: ignored\r\nMain-Class: ignored\r\n"),
+		"BOOT-INF/classes/App.class": class,
+		"BOOT-INF/lib/dep-1.0.jar":   []byte("nested"),
+		"BOOT-INF/lib/other-2.0.jar": []byte("nested"),
+		"BOOT-INF/classes/app.yaml":  []byte("server: {}"),
+	})
+
+	inspection, err := (&ArchiveInspector{}).Inspect("/app/app.jar", archive, archive.Size())
+	if err != nil {
+		t.Fatalf("unable to inspect: %v", err)
+	}
+
+	expected := map[string]string{
+		"Entries":              "5",
+		"Extracted":            "198 B",
+		"Implementation-Title": "app",
+		"Main-Class":           "org.springframework.boot.loader.JarLauncher",
+		"Build-Jdk":            "17.0.2",
+		"Java target":          "17 (class version 61)",
+		"Nested jars":          "2",
+	}
+	actual := fields(inspection)
+	if len(actual) != len(expected) {
+		t.Errorf("expected fields %v, got %v", expected, actual)
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("%s: expected %q, got %q", name, value, actual[name])
+		}
+	}
+}
+
+func TestArchiveInspectorWheel(t *testing.T) {
+	archive := makeZip(t, map[string][]byte{
+		"six.py":                             []byte("# six"),
+		"six-1.16.0.dist-info/METADATA":      []byte("Metadata-Version: 2.1\nName: six\nVersion: 1.16.0\nRequires-Python: >=2.7\n\nSix is a compatibility library.\nName: ignored\n"),
+		"six-1.16.0.dist-info/WHEEL":         []byte("Wheel-Version: 1.0\nRoot-Is-Purelib: true\nTag: py2-none-any\nTag: py3-none-any\n"),
+		"six-1.16.0.dist-info/top_level.txt": []byte("six\n"),
+	})
+
+	inspection, err := (&ArchiveInspector{}).Inspect("/wheels/six-1.16.0-py2.py3-none-any.whl", archive, archive.Size())
+	if err != nil {
+		t.Fatalf("unable to inspect: %v", err)
+	}
+
+	expected := map[string]string{
+		"Entries":         "4",
+		"Name":            "six",
+		"Version":         "1.16.0",
+		"Requires-Python": ">=2.7",
+		"Tag":             "py2-none-any",
+		"Native code":     "no",
+	}
+	actual := fields(inspection)
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("%s: expected %q, got %q", name, value, actual[name])
+		}
+	}
+}
+
+func TestArchiveInspectorInvalid(t *testing.T) {
+	contents := bytes.NewReader([]byte("not a zip file"))
+	if _, err := (&ArchiveInspector{}).Inspect("/app/app.jar", contents, contents.Size()); err == nil {
+		t.Errorf("expected an error for an invalid archive")
+	}
+}
@@ -0,0 +1,170 @@
+package inspect
+
+import (
+	"archive/tar"
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+var elfMagic = []byte(elf.ELFMAG)
+
+// the most sections listed in the section sizes (the largest first, the debug sections are summed up separately)
+const maxElfSections = 4
+
+// ElfInspector reads the header of ELF binaries and shared libraries: the architecture, whether they are linked
+// statically, whether their symbols (and debug info) have been stripped, the largest sections, and the toolchain of Go
+// and Rust binaries.
+type ElfInspector struct{}
+
+func (i *ElfInspector) Name() string {
+	return "elf"
+}
+
+// Accepts executables and shared libraries (by name, since libraries are not always executable).
+func (i *ElfInspector) Accepts(header *tar.Header) bool {
+	if header.Mode&0111 != 0 {
+		return true
+	}
+	name := path.Base(header.Name)
+	return strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.")
+}
+
+func (i *ElfInspector) Inspect(filePath string, contents io.ReaderAt, size int64) (*filetree.Inspection, error) {
+	magic := make([]byte, len(elfMagic))
+	if _, err := contents.ReadAt(magic, 0); err != nil || !bytes.Equal(magic, elfMagic) {
+		// an executable script (or any other file) rather than a binary
+		return nil, nil
+	}
+
+	file, err := elf.NewFile(contents)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	inspection := &filetree.Inspection{}
+	inspection.Add("Type", elfType(file))
+	inspection.Add("Arch", strings.TrimPrefix(file.Machine.String(), "EM_"))
+
+	interpreter := elfInterpreter(file)
+	libraries, _ := file.ImportedLibraries()
+	switch {
+	case interpreter == "" && len(libraries) == 0:
+		inspection.Add("Linking", "static")
+	case interpreter != "":
+		inspection.Add("Linking", "dynamic ("+interpreter+")")
+	default:
+		inspection.Add("Linking", "dynamic")
+	}
+	inspection.Add("Needs", strings.Join(libraries, ", "))
+
+	stripped := "yes"
+	if file.Section(".symtab") != nil {
+		stripped = "no"
+	}
+	inspection.Add("Stripped", stripped)
+
+	var debugBytes uint64
+	for _, section := range file.Sections {
+		if strings.HasPrefix(section.Name, ".debug_") || strings.HasPrefix(section.Name, ".zdebug_") {
+			debugBytes += section.Size
+		}
+	}
+	if debugBytes > 0 {
+		inspection.Add("Debug info", humanize.Bytes(debugBytes))
+	}
+	inspection.Add("Sections", elfSections(file))
+
+	if info, err := buildinfo.Read(contents); err == nil {
+		inspection.Add("Go", strings.TrimSpace(info.GoVersion+" "+info.Path))
+	} else {
+		inspection.Add("Rust", rustVersion(file))
+	}
+	return inspection, nil
+}
+
+// elfSections lists the largest sections stored in the file (e.g. "text 8.1 MB, rodata 2.0 MB"), leaving out the debug
+// sections.
+func elfSections(file *elf.File) string {
+	var sections []*elf.Section
+	for _, section := range file.Sections {
+		if section.Type == elf.SHT_NOBITS || section.Size == 0 || section.Name == "" ||
+			strings.HasPrefix(section.Name, ".debug_") || strings.HasPrefix(section.Name, ".zdebug_") {
+			continue
+		}
+		sections = append(sections, section)
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].Size > sections[j].Size
+	})
+
+	var sizes []string
+	for idx, section := range sections {
+		if idx == maxElfSections {
+			break
+		}
+		sizes = append(sizes, strings.TrimPrefix(section.Name, ".")+" "+humanize.Bytes(section.Size))
+	}
+	return strings.Join(sizes, ", ")
+}
+
+// rustVersion returns the version of the rustc compiler that built the binary, as recorded in the .comment section
+// (empty when the binary was not built by rustc).
+func rustVersion(file *elf.File) string {
+	section := file.Section(".comment")
+	if section == nil {
+		return ""
+	}
+	comment, err := section.Data()
+	if err != nil {
+		return ""
+	}
+	for _, entry := range bytes.Split(comment, []byte{0}) {
+		if version := string(entry); strings.HasPrefix(version, "rustc version ") {
+			return strings.TrimPrefix(version, "rustc version ")
+		}
+	}
+	return ""
+}
+
+func elfType(file *elf.File) string {
+	switch file.Type {
+	case elf.ET_EXEC:
+		return "executable"
+	case elf.ET_DYN:
+		// position independent executables are shared objects with an interpreter (or an entry point)
+		if elfInterpreter(file) != "" {
+			return "executable (PIE)"
+		}
+		return "shared library"
+	case elf.ET_REL:
+		return "object file"
+	case elf.ET_CORE:
+		return "core dump"
+	}
+	return strings.TrimPrefix(file.Type.String(), "ET_")
+}
+
+// elfInterpreter returns the dynamic linker requested by the binary (empty for static binaries and libraries).
+func elfInterpreter(file *elf.File) string {
+	for _, program := range file.Progs {
+		if program.Type != elf.PT_INTERP {
+			continue
+		}
+		interpreter, err := ioutil.ReadAll(program.Open())
+		if err != nil {
+			return ""
+		}
+		return strings.TrimRight(string(interpreter), "\x00")
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package inspect
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestElfInspectorAccepts(t *testing.T) {
+	inspector := &ElfInspector{}
+	cases := []struct {
+		header   tar.Header
+		expected bool
+	}{
+		{header: tar.Header{Name: "/bin/sh", Mode: 0755}, expected: true},
+		{header: tar.Header{Name: "/usr/lib/libc.so.6", Mode: 0644}, expected: true},
+		{header: tar.Header{Name: "/usr/lib/libz.so", Mode: 0644}, expected: true},
+		{header: tar.Header{Name: "/etc/passwd", Mode: 0644}, expected: false},
+	}
+	for _, test := range cases {
+		if actual := inspector.Accepts(&test.header); actual != test.expected {
+			t.Errorf("%s: expected accepted=%v, got %v", test.header.Name, test.expected, actual)
+		}
+	}
+}
+
+func TestElfInspectorScript(t *testing.T) {
+	contents := bytes.NewReader([]byte("#!/bin/sh\necho hello\n"))
+	inspection, err := (&ElfInspector{}).Inspect("/usr/bin/hello", contents, contents.Size())
+	if err != nil {
+		t.Fatalf("unable to inspect: %v", err)
+	}
+	if inspection != nil {
+		t.Errorf("expected no inspection of a script, got %+v", inspection)
+	}
+}
+
+func TestElfInspectorBinary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the test binary is only an ELF binary on linux")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Skipf("unable to find the test binary: %v", err)
+	}
+	binary, err := ioutil.ReadFile(executable)
+	if err != nil {
+		t.Skipf("unable to read the test binary: %v", err)
+	}
+
+	contents := bytes.NewReader(binary)
+	inspection, err := (&ElfInspector{}).Inspect(executable, contents, contents.Size())
+	if err != nil {
+		t.Fatalf("unable to inspect: %v", err)
+	}
+	actual := fields(inspection)
+	if !strings.HasPrefix(actual["Type"], "executable") {
+		t.Errorf("expected an executable, got %q", actual["Type"])
+	}
+	if actual["Arch"] == "" || actual["Linking"] == "" || actual["Stripped"] == "" {
+		t.Errorf("expected the architecture, linking and stripping to be reported, got %v", actual)
+	}
+	if !strings.Contains(actual["Sections"], "text ") {
+		t.Errorf("expected the section sizes, got %q", actual["Sections"])
+	}
+	if actual["Rust"] != "" {
+		t.Errorf("expected no rust version for a Go binary, got %q", actual["Rust"])
+	}
+	if !strings.HasPrefix(actual["Go"], runtime.Version()) {
+		t.Errorf("expected the Go version %q, got %q", runtime.Version(), actual["Go"])
+	}
+}
+
+func TestInspectors(t *testing.T) {
+	if _, err := Inspectors([]string{"elf", "nope"}); err == nil || !strings.Contains(err.Error(), "archive, elf") {
+		t.Errorf("expected an error listing the inspectors, got %v", err)
+	}
+	inspectors, err := Inspectors(DefaultNames)
+	if err != nil || len(inspectors) != 2 || inspectors[0].Name() != Builtins["elf"].Name() {
+		t.Errorf("unable to find the default inspectors: %v (%v)", inspectors, err)
+	}
+	if inspectors, err := Inspectors(nil); err != nil || len(inspectors) != 0 {
+		t.Errorf("expected no inspectors, got %v (%v)", inspectors, err)
+	}
+}
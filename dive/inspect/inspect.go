@@ -0,0 +1,41 @@
+package inspect
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// Builtins are the inspectors shipped with dive, by name.
+var Builtins = map[string]filetree.Inspector{
+	"elf":     &ElfInspector{},
+	"archive": &ArchiveInspector{},
+}
+
+// DefaultNames are the inspectors enabled when none are configured.
+var DefaultNames = []string{"elf", "archive"}
+
+// Inspectors returns the named built-in inspectors, to run while the layer trees are built (see
+// image.ResolverOptions).
+func Inspectors(names []string) ([]filetree.Inspector, error) {
+	enabled := make([]filetree.Inspector, 0, len(names))
+	for _, name := range names {
+		inspector, ok := Builtins[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown inspector %q (available: %s)", name, strings.Join(builtinNames(), ", "))
+		}
+		enabled = append(enabled, inspector)
+	}
+	return enabled, nil
+}
+
+func builtinNames() []string {
+	names := make([]string, 0, len(Builtins))
+	for name := range Builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSamples is the number of the slowest samples kept for every stage.
+const maxSamples = 20
+
+// Stage sums up the time spent in one stage of the analysis pipeline (e.g. parsing layers, stacking trees, rendering).
+type Stage struct {
+	Name  string
+	Count int
+	Total time.Duration
+	Max   time.Duration
+	// the slowest samples that name what they timed (e.g. the layer parsed), the slowest first
+	Samples []Sample
+}
+
+// Sample is a single timing of a stage.
+type Sample struct {
+	Detail   string
+	Duration time.Duration
+}
+
+// Average is the mean duration of the stage.
+func (s Stage) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// Recorder sums up the timings of the stages of the analysis pipeline. A nil recorder records nothing, so the stages
+// are only timed for the analyses given a recorder through their context (see WithRecorder): long running processes
+// (and library users) do not accumulate timings they never read.
+type Recorder struct {
+	lock   sync.Mutex
+	stages map[string]*Stage
+	order  []string
+}
+
+// NewRecorder creates a recorder with no timings.
+func NewRecorder() *Recorder {
+	return &Recorder{stages: make(map[string]*Stage)}
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a copy of the context that carries the recorder the stages run within it are timed with.
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// FromContext returns the recorder carried by the context (nil when there is none).
+func FromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+// Observe records that the given stage took the given time. The detail names what was timed (e.g. the layer), and is
+// kept along with the slowest timings of the stage (unless empty).
+func (r *Recorder) Observe(stage, detail string, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	entry, exists := r.stages[stage]
+	if !exists {
+		entry = &Stage{Name: stage}
+		r.stages[stage] = entry
+		r.order = append(r.order, stage)
+	}
+	entry.Count++
+	entry.Total += duration
+	if duration > entry.Max {
+		entry.Max = duration
+	}
+	if detail == "" {
+		return
+	}
+	entry.Samples = append(entry.Samples, Sample{Detail: detail, Duration: duration})
+	sort.SliceStable(entry.Samples, func(i, j int) bool {
+		return entry.Samples[i].Duration > entry.Samples[j].Duration
+	})
+	if len(entry.Samples) > maxSamples {
+		entry.Samples = entry.Samples[:maxSamples]
+	}
+}
+
+// Since records the time elapsed since the given start, e.g. defer recorder.Since("parse layer", name, time.Now()).
+func (r *Recorder) Since(stage, detail string, start time.Time) {
+	r.Observe(stage, detail, time.Since(start))
+}
+
+// Stages returns a copy of the timings recorded, in the order the stages were first seen.
+func (r *Recorder) Stages() []Stage {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result := make([]Stage, 0, len(r.order))
+	for _, name := range r.order {
+		stage := *r.stages[name]
+		stage.Samples = append([]Sample(nil), stage.Samples...)
+		result = append(result, stage)
+	}
+	return result
+}
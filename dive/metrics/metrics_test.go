@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Observe("parse layer", "a", 2*time.Millisecond)
+	recorder.Observe("parse layer", "b", 6*time.Millisecond)
+	recorder.Observe("render file tree", "", time.Millisecond)
+
+	stages := recorder.Stages()
+	if len(stages) != 2 || stages[0].Name != "parse layer" || stages[1].Name != "render file tree" {
+		t.Fatalf("unexpected stages: %+v", stages)
+	}
+	parse := stages[0]
+	if parse.Count != 2 || parse.Total != 8*time.Millisecond || parse.Max != 6*time.Millisecond || parse.Average() != 4*time.Millisecond {
+		t.Errorf("unexpected parse stage: %+v", parse)
+	}
+	if len(parse.Samples) != 2 || parse.Samples[0].Detail != "b" {
+		t.Errorf("expected the slowest sample first, got %+v", parse.Samples)
+	}
+	if len(stages[1].Samples) != 0 {
+		t.Errorf("expected no samples without a detail, got %+v", stages[1].Samples)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	recorder := FromContext(ctx)
+	if recorder != nil {
+		t.Fatalf("expected no recorder, got %+v", recorder)
+	}
+	// a missing recorder records nothing
+	recorder.Observe("parse layer", "ignored", time.Second)
+	if stages := recorder.Stages(); len(stages) != 0 {
+		t.Errorf("expected no timings without a recorder, got %+v", stages)
+	}
+
+	recorder = NewRecorder()
+	FromContext(WithRecorder(ctx, recorder)).Observe("parse layer", "a", time.Second)
+	if stages := recorder.Stages(); len(stages) != 1 || stages[0].Count != 1 {
+		t.Errorf("expected the timing on the recorder of the context, got %+v", stages)
+	}
+}
@@ -0,0 +1,176 @@
+package annotation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the key the annotations are kept under within a JSON report
+const reportKey = "annotations"
+
+// Annotation is a reviewer comment on a path of the image, either starting a thread or responding to one.
+type Annotation struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`
+	Author  string    `json:"author"`
+	Created time.Time `json:"created"`
+	Comment string    `json:"comment"`
+	// the annotation this responds to (empty when it starts a thread)
+	ReplyTo string `json:"replyTo,omitempty"`
+}
+
+// New creates an annotation, identified by a digest of its contents (so the same annotation merged from several
+// reports is only kept once).
+func New(path, author, comment, replyTo string, created time.Time) Annotation {
+	created = created.UTC().Round(0)
+	digest := sha256.Sum256([]byte(strings.Join([]string{path, author, comment, replyTo, created.Format(time.RFC3339Nano)}, "\x00")))
+	return Annotation{
+		ID:      hex.EncodeToString(digest[:])[:12],
+		Path:    path,
+		Author:  author,
+		Created: created,
+		Comment: comment,
+		ReplyTo: replyTo,
+	}
+}
+
+// Merge combines the annotations of several reviewers, keeping each annotation once, ordered by creation.
+func Merge(sets ...[]Annotation) []Annotation {
+	seen := make(map[string]bool)
+	merged := make([]Annotation, 0)
+	for _, set := range sets {
+		for _, annotation := range set {
+			if seen[annotation.ID] {
+				continue
+			}
+			seen[annotation.ID] = true
+			merged = append(merged, annotation)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Created.Equal(merged[j].Created) {
+			return merged[i].ID < merged[j].ID
+		}
+		return merged[i].Created.Before(merged[j].Created)
+	})
+	return merged
+}
+
+// Thread is an annotation along with every response to it (and to the responses), in order of creation.
+type Thread struct {
+	Annotation
+	Replies []Annotation
+}
+
+// Threads groups the annotations into threads, ordered by path and creation. Responses to annotations that are not
+// given start a thread of their own.
+func Threads(annotations []Annotation) []Thread {
+	annotations = Merge(annotations)
+
+	byID := make(map[string]Annotation)
+	for _, annotation := range annotations {
+		byID[annotation.ID] = annotation
+	}
+	root := func(annotation Annotation) string {
+		for depth := 0; annotation.ReplyTo != "" && depth < len(annotations); depth++ {
+			parent, ok := byID[annotation.ReplyTo]
+			if !ok {
+				break
+			}
+			annotation = parent
+		}
+		return annotation.ID
+	}
+
+	threads := make([]Thread, 0)
+	index := make(map[string]int)
+	for _, annotation := range annotations {
+		if root(annotation) == annotation.ID {
+			index[annotation.ID] = len(threads)
+			threads = append(threads, Thread{Annotation: annotation, Replies: make([]Annotation, 0)})
+		}
+	}
+	for _, annotation := range annotations {
+		if rootID := root(annotation); rootID != annotation.ID {
+			threads[index[rootID]].Replies = append(threads[index[rootID]].Replies, annotation)
+		}
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].Path < threads[j].Path
+	})
+	return threads
+}
+
+// Report is a JSON report (a --json export or a stored fleet report) that annotations are added to. The rest of the
+// report is kept as is.
+type Report struct {
+	fields      map[string]json.RawMessage
+	Annotations []Annotation
+}
+
+// LoadReport reads the JSON report at the given path along with its annotations.
+func LoadReport(path string) (*Report, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal(content, &report.fields); err != nil {
+		return nil, fmt.Errorf("unable to parse report '%s': %v", path, err)
+	}
+	if report.fields == nil {
+		report.fields = make(map[string]json.RawMessage)
+	}
+	if raw, ok := report.fields[reportKey]; ok {
+		if err := json.Unmarshal(raw, &report.Annotations); err != nil {
+			return nil, fmt.Errorf("unable to parse the annotations of report '%s': %v", path, err)
+		}
+	}
+	return report, nil
+}
+
+// Find returns the annotation with the given ID (or unique ID prefix).
+func (r *Report) Find(id string) (Annotation, error) {
+	var found []Annotation
+	for _, annotation := range r.Annotations {
+		if strings.HasPrefix(annotation.ID, id) {
+			found = append(found, annotation)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Annotation{}, fmt.Errorf("no annotation with ID '%s'", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Annotation{}, fmt.Errorf("annotation ID '%s' is ambiguous", id)
+	}
+}
+
+// Add appends the given annotations (merging them with the existing ones).
+func (r *Report) Add(annotations ...Annotation) {
+	r.Annotations = Merge(r.Annotations, annotations)
+}
+
+// Save writes the report with its annotations to the given path.
+func (r *Report) Save(path string) error {
+	raw, err := json.Marshal(Merge(r.Annotations))
+	if err != nil {
+		return err
+	}
+	r.fields[reportKey] = raw
+
+	content, err := json.MarshalIndent(r.fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
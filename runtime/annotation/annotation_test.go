@@ -0,0 +1,76 @@
+package annotation
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeAndThreads(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	secret := New("/etc/secret.key", "alice", "why is this shipped?", "", start)
+	cache := New("/var/cache/apt", "alice", "clean this up", "", start.Add(time.Minute))
+	reply := New(secret.Path, "bob", "removed in the next build", secret.ID, start.Add(time.Hour))
+	followUp := New(secret.Path, "alice", "thanks", reply.ID, start.Add(2*time.Hour))
+
+	// both reviewers have the original comments
+	merged := Merge([]Annotation{secret, cache}, []Annotation{followUp, secret, cache, reply})
+	if len(merged) != 4 {
+		t.Fatalf("expected 4 annotations, got %d: %+v", len(merged), merged)
+	}
+	for idx, expected := range []Annotation{secret, cache, reply, followUp} {
+		if merged[idx].ID != expected.ID {
+			t.Errorf("annotation %d: expected %q, got %q", idx, expected.Comment, merged[idx].Comment)
+		}
+	}
+
+	threads := Threads(merged)
+	if len(threads) != 2 {
+		t.Fatalf("expected 2 threads, got %d", len(threads))
+	}
+	if threads[0].ID != secret.ID || len(threads[0].Replies) != 2 || threads[0].Replies[1].ID != followUp.ID {
+		t.Errorf("unexpected thread: %+v", threads[0])
+	}
+	if threads[1].ID != cache.ID || len(threads[1].Replies) != 0 {
+		t.Errorf("unexpected thread: %+v", threads[1])
+	}
+}
+
+func TestReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := ioutil.WriteFile(path, []byte(`{"layer": [], "image": {"sizeBytes": 10}}`), 0644); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	report, err := LoadReport(path)
+	if err != nil {
+		t.Fatalf("unable to load report: %v", err)
+	}
+	comment := New("/etc/secret.key", "alice", "why is this shipped?", "", time.Now())
+	report.Add(comment)
+	if err := report.Save(path); err != nil {
+		t.Fatalf("unable to save report: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read report: %v", err)
+	}
+	if !strings.Contains(string(content), `"sizeBytes": 10`) {
+		t.Errorf("expected the rest of the report to be kept, got %s", content)
+	}
+
+	report, err = LoadReport(path)
+	if err != nil {
+		t.Fatalf("unable to load report: %v", err)
+	}
+	found, err := report.Find(comment.ID[:6])
+	if err != nil || found.Comment != comment.Comment {
+		t.Errorf("expected to find the comment by an ID prefix, got %+v: %v", found, err)
+	}
+	if _, err := report.Find("zzz"); err == nil {
+		t.Errorf("expected an error for an unknown ID")
+	}
+}
@@ -0,0 +1,24 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// duplicateArtifactReport lists the libraries that have several versions side by side in the image, with the size of
+// each version and the layer that added it.
+func duplicateArtifactReport(duplicates []image.DuplicateArtifact) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Duplicate Library Versions:"))
+	for _, duplicate := range duplicates {
+		fmt.Fprintf(&sb, "  %s %s in %s:\n", duplicate.Kind, duplicate.Name, duplicate.Dir)
+		for _, version := range duplicate.Versions {
+			fmt.Fprintf(&sb, "    %-16s %10s  layer %d  %s\n", version.Version, humanize.Bytes(version.SizeBytes), version.Layer, version.Path)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of findings listed in the report (the largest first)
+const assetReportMaxFindings = 10
+
+// assetReport renders the web asset bloat found in the image along with the bytes that could be reclaimed.
+func assetReport(bloat *image.AssetBloat) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Web Asset Bloat:"))
+	fmt.Fprintf(&sb, "  reclaimable: %s in %d files\n", humanize.Bytes(bloat.ReclaimableBytes), len(bloat.Findings))
+
+	for idx, finding := range bloat.Findings {
+		if idx >= assetReportMaxFindings {
+			fmt.Fprintf(&sb, "    ...and %d more\n", len(bloat.Findings)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "    %10s  %-18s  layer %d  %s: %s\n", humanize.Bytes(finding.ReclaimableBytes), finding.Kind, finding.Layer, finding.Path, finding.Detail)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of findings listed in the report for each kind
+const auditReportMaxFindings = 10
+
+// auditReport renders the permission and ownership findings of the image, grouped by kind.
+func auditReport(audit *image.Audit) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Permission Audit:"))
+	if len(audit.Findings) == 0 {
+		fmt.Fprintln(&sb, "  no findings")
+	}
+
+	for _, kind := range image.AuditKinds {
+		count := audit.Count(kind)
+		if count == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s: %d files\n", kind, count)
+		listed := 0
+		for _, finding := range audit.Findings {
+			if finding.Kind != kind {
+				continue
+			}
+			if listed >= auditReportMaxFindings {
+				fmt.Fprintf(&sb, "    ...and %d more\n", count-listed)
+				break
+			}
+			fmt.Fprintf(&sb, "    layer %d  %s: %s\n", finding.Layer, finding.Path, finding.Detail)
+			listed++
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
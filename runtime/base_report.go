@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// baseReport renders the size and waste of the base image layers apart from the app layers built on top of them.
+func baseReport(analysis *image.AnalysisResult) string {
+	base := analysis.Base
+	name := base.Image
+	if name == "" {
+		name = "(assumed to be the first layer, use --base-image to set)"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Base Image:"))
+	fmt.Fprintf(&sb, "  image: %s\n", name)
+	fmt.Fprintf(&sb, "  baseLayers: %d of %d\n", base.Layers, len(analysis.Layers))
+	fmt.Fprintf(&sb, "  baseSize: %d bytes (%s)\n", base.SizeBytes, humanize.Bytes(base.SizeBytes))
+	fmt.Fprintf(&sb, "  baseWastedBytes: %d bytes (%s)\n", base.WastedBytes, humanize.Bytes(base.WastedBytes))
+	fmt.Fprintf(&sb, "  appSize: %d bytes (%s)\n", analysis.UserSizeByes, humanize.Bytes(analysis.UserSizeByes))
+	fmt.Fprintf(&sb, "  appWastedBytes: %d bytes (%s)\n", analysis.AppWastedBytes, humanize.Bytes(analysis.AppWastedBytes))
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,248 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the report formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// the name of the roll-up summary written next to the reports of the images
+const summaryName = "summary"
+
+// FetchFunc fetches the image of a single image reference.
+type FetchFunc func(ctx context.Context, image string) (*image.Image, error)
+
+// Options configure a batch run.
+type Options struct {
+	// the number of images analyzed at the same time (1 when not positive)
+	Parallel int
+	// where the report of every image and the summary are written (nothing is written when empty)
+	ReportDir string
+	Format    string
+	// the CI rules every analysis is evaluated against
+	Rules *viper.Viper
+	Fetch FetchFunc
+	// the options every image is analyzed with
+	Analysis image.AnalysisOptions
+}
+
+// ImageResult is the outcome of the analysis of a single image.
+type ImageResult struct {
+	Image       string            `json:"image"`
+	Error       string            `json:"error,omitempty"`
+	Pass        bool              `json:"pass"`
+	Policy      []ci.PolicyResult `json:"policy"`
+	SizeBytes   uint64            `json:"sizeBytes"`
+	WastedBytes uint64            `json:"inefficientBytes"`
+	Efficiency  float64           `json:"efficiencyScore"`
+	// the report written for the image (empty when reports are not written)
+	Report string `json:"report,omitempty"`
+}
+
+// Summary is the roll-up of a batch run, with the images in the order they were listed.
+type Summary struct {
+	Total   int           `json:"total"`
+	Passed  int           `json:"passed"`
+	Failed  int           `json:"failed"`
+	Errored int           `json:"errored"`
+	Images  []ImageResult `json:"images"`
+}
+
+// Pass indicates if every image was analyzed and passed the CI rules.
+func (summary *Summary) Pass() bool {
+	return summary.Failed == 0 && summary.Errored == 0
+}
+
+// ReadImageList reads the image references listed one per line, skipping blank lines and # comments.
+func ReadImageList(reader io.Reader) ([]string, error) {
+	var images []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		images = append(images, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Run analyzes every image, evaluates it against the CI rules and writes its report, then writes the summary. An
+// image that cannot be analyzed is recorded as errored and does not stop the run.
+func Run(ctx context.Context, images []string, options Options) (*Summary, error) {
+	if options.Format != FormatText && options.Format != FormatJSON {
+		return nil, fmt.Errorf("unknown format %q (expected %s or %s)", options.Format, FormatText, FormatJSON)
+	}
+	if options.ReportDir != "" {
+		if err := os.MkdirAll(options.ReportDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	parallel := options.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]ImageResult, len(images))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < parallel; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				results[idx] = analyze(ctx, images[idx], options)
+			}
+		}()
+	}
+	for idx := range images {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	summary := &Summary{Total: len(images), Images: results}
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			summary.Errored++
+		case result.Pass:
+			summary.Passed++
+		default:
+			summary.Failed++
+		}
+	}
+
+	if options.ReportDir != "" {
+		content, err := summary.render(options.Format)
+		if err != nil {
+			return summary, err
+		}
+		path := filepath.Join(options.ReportDir, summaryName+"."+options.Format)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func analyze(ctx context.Context, name string, options Options) ImageResult {
+	result := ImageResult{Image: name}
+	img, err := options.Fetch(ctx, name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer img.Close()
+	analysis, err := img.AnalyzeWithOptions(ctx, options.Analysis)
+	if err != nil {
+		result.Error = fmt.Sprintf("cannot analyze image %s: %v", name, err)
+		return result
+	}
+
+	evaluator := ci.NewCiEvaluator(options.Rules)
+	result.Pass = evaluator.Evaluate(analysis)
+	result.Policy = evaluator.Policy()
+	result.SizeBytes = analysis.SizeBytes
+	result.WastedBytes = analysis.WastedBytes
+	result.Efficiency = analysis.Efficiency
+
+	if options.ReportDir == "" {
+		return result
+	}
+	var content []byte
+	if options.Format == FormatJSON {
+		content, err = json.MarshalIndent(struct {
+			Image    string            `json:"image"`
+			Pass     bool              `json:"pass"`
+			Policy   []ci.PolicyResult `json:"policy"`
+			Analysis interface{}       `json:"analysis"`
+		}{name, result.Pass, result.Policy, export.NewExport(analysis)}, "", "  ")
+	} else {
+		content = []byte(textReport(name, analysis, evaluator))
+	}
+	if err == nil {
+		path := filepath.Join(options.ReportDir, reportName(name, options.Format))
+		if err = ioutil.WriteFile(path, content, 0644); err == nil {
+			result.Report = path
+		}
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("unable to write the report: %v", err)
+	}
+	return result
+}
+
+// reportName is the file name of the report of an image (e.g. "registry.example.com_app_1.2-17f9947b.json").
+func reportName(image, format string) string {
+	name := utils.ReferenceFileName(image)
+	if name == summaryName {
+		// the summary is never overwritten by an image report
+		name = "_" + name
+	}
+	return name + "." + format
+}
+
+func textReport(name string, analysis *image.AnalysisResult, evaluator *ci.CiEvaluator) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Image: "+name))
+	fmt.Fprintf(&sb, "  efficiency: %2.4f %%\n", analysis.Efficiency*100)
+	fmt.Fprintf(&sb, "  wastedBytes: %d bytes (%s)\n", analysis.WastedBytes, humanize.Bytes(analysis.WastedBytes))
+	fmt.Fprintf(&sb, "  userWastedPercent: %2.4f %%\n", analysis.WastedUserPercent*100)
+	sb.WriteString(evaluator.Report())
+	return sb.String()
+}
+
+// render writes the summary in the given format.
+func (summary *Summary) render(format string) ([]byte, error) {
+	if format == FormatJSON {
+		return json.MarshalIndent(summary, "", "  ")
+	}
+	return []byte(summary.String()), nil
+}
+
+// Marshal writes the summary as JSON.
+func (summary *Summary) Marshal() ([]byte, error) {
+	return summary.render(FormatJSON)
+}
+
+// String renders the summary as a table with one image per line.
+func (summary *Summary) String() string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Batch Summary:"))
+	for _, result := range summary.Images {
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(&sb, "  %-5s  %s: %s\n", "ERROR", result.Image, result.Error)
+		case result.Pass:
+			fmt.Fprintf(&sb, "  %-5s  %s (efficiency %2.2f %%, wasted %s)\n", "PASS", result.Image, result.Efficiency*100, humanize.Bytes(result.WastedBytes))
+		default:
+			fmt.Fprintf(&sb, "  %-5s  %s (efficiency %2.2f %%, wasted %s)\n", "FAIL", result.Image, result.Efficiency*100, humanize.Bytes(result.WastedBytes))
+		}
+	}
+	fmt.Fprintf(&sb, "Total:%d Passed:%d Failed:%d Errored:%d\n", summary.Total, summary.Passed, summary.Failed, summary.Errored)
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func testRules(lowestEfficiency string) *viper.Viper {
+	rules := viper.New()
+	rules.Set("rules.lowestEfficiency", lowestEfficiency)
+	rules.Set("rules.highestWastedBytes", "disabled")
+	rules.Set("rules.highestAppWastedBytes", "disabled")
+	rules.Set("rules.highestUserWastedPercent", "disabled")
+	rules.Set("rules.highestFileCount", "disabled")
+	rules.Set("rules.highestLayerFileCount", "disabled")
+	rules.Set("rules.forbidCorruptLayers", "disabled")
+	rules.Set("rules.forbidDuplicateArtifacts", "disabled")
+	rules.Set("rules.forbidSetuidFiles", "disabled")
+	rules.Set("rules.forbidWorldWritableFiles", "disabled")
+	rules.Set("rules.forbidRootOwnedAppFiles", "disabled")
+	rules.Set("rules.forbidUnexpectedCapabilities", "disabled")
+	return rules
+}
+
+// testFetch loads the test image for every reference, apart from those starting with "missing".
+func testFetch(ctx context.Context, name string) (*image.Image, error) {
+	if strings.HasPrefix(name, "missing") {
+		return nil, fmt.Errorf("cannot fetch image %s: not found", name)
+	}
+	archive, err := docker.TestLoadArchive("../../.data/test-docker-image.tar")
+	if err != nil {
+		return nil, err
+	}
+	return archive.ToImage()
+}
+
+func TestReadImageList(t *testing.T) {
+	images, err := ReadImageList(strings.NewReader(`
+# nightly audit
+registry.example.com/app:1.2
+
+  alpine:3.19
+# registry.example.com/old:1.0
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"registry.example.com/app:1.2", "alpine:3.19"}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("expected %v, got %v", expected, images)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dive-batch")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	images := []string{"registry.example.com/app:1.2", "missing:latest", "alpine:3.19"}
+	summary, err := Run(context.Background(), images, Options{
+		Parallel:  2,
+		ReportDir: dir,
+		Format:    FormatJSON,
+		Rules:     testRules("0.9"),
+		Fetch:     testFetch,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if summary.Total != 3 || summary.Passed != 2 || summary.Failed != 0 || summary.Errored != 1 || summary.Pass() {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	for idx, result := range summary.Images {
+		if result.Image != images[idx] {
+			t.Errorf("expected image %d to be %s, got %s", idx, images[idx], result.Image)
+		}
+	}
+	if summary.Images[1].Error == "" || summary.Images[1].Report != "" {
+		t.Errorf("expected the missing image to be errored without a report: %+v", summary.Images[1])
+	}
+
+	report := summary.Images[0].Report
+	if report != filepath.Join(dir, "registry.example.com_app_1.2-17f9947b.json") {
+		t.Errorf("unexpected report path: %s", report)
+	}
+	content, err := ioutil.ReadFile(report)
+	if err != nil {
+		t.Fatalf("unable to read the report: %v", err)
+	}
+	var stored struct {
+		Image    string                 `json:"image"`
+		Pass     bool                   `json:"pass"`
+		Analysis map[string]interface{} `json:"analysis"`
+	}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		t.Fatalf("unable to parse the report: %v", err)
+	}
+	if stored.Image != images[0] || !stored.Pass || stored.Analysis["layer"] == nil {
+		t.Errorf("unexpected report: %s", content)
+	}
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		t.Fatalf("unable to read the summary: %v", err)
+	}
+	var storedSummary Summary
+	if err := json.Unmarshal(content, &storedSummary); err != nil {
+		t.Fatalf("unable to parse the summary: %v", err)
+	}
+	if !reflect.DeepEqual(&storedSummary, summary) {
+		t.Errorf("expected the stored summary to be %+v, got %+v", summary, storedSummary)
+	}
+}
+
+func TestRun_Failed(t *testing.T) {
+	summary, err := Run(context.Background(), []string{"app:1.2"}, Options{
+		Format: FormatText,
+		Rules:  testRules("0.999"),
+		Fetch:  testFetch,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Failed != 1 || summary.Pass() || summary.Images[0].Report != "" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if !strings.Contains(summary.String(), "FAIL   app:1.2") {
+		t.Errorf("expected the image to fail in:\n%s", summary.String())
+	}
+}
+
+func TestRun_UnknownFormat(t *testing.T) {
+	if _, err := Run(context.Background(), nil, Options{Format: "yaml", Fetch: testFetch}); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestReportName(t *testing.T) {
+	cases := map[string]string{
+		"registry.example.com/team/app:1.2": "registry.example.com_team_app_1.2-465eb3d0.json",
+		"app@sha256:abc":                    "app_sha256_abc-17470fe1.json",
+		"a/b:c":                             "a_b_c-3b07f80c.json",
+		"a_b:c":                             "a_b_c-b42d7d0b.json",
+		"a_b_c":                             "a_b_c.json",
+		"summary":                           "_summary.json",
+	}
+	for name, expected := range cases {
+		if actual := reportName(name, FormatJSON); actual != expected {
+			t.Errorf("expected %s for %s, got %s", expected, name, actual)
+		}
+	}
+}
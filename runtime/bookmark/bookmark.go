@@ -0,0 +1,95 @@
+package bookmark
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Store persists the paths marked in the UI for each image, so that the marks survive across sessions and can be
+// included in exported reports.
+type Store struct {
+	path string
+}
+
+type document struct {
+	// the marked paths, keyed by image reference
+	Images map[string][]string `json:"images"`
+}
+
+// NewStore creates a store backed by the given file (which is created on the first save).
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath is the bookmarks file within the user configuration directory.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "dive", "bookmarks.json"), nil
+}
+
+// NewDefaultStore creates a store backed by the default bookmarks file.
+func NewDefaultStore() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(path), nil
+}
+
+// Load returns the marked paths of the given image (sorted).
+func (s *Store) Load(image string) ([]string, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Images[image], nil
+}
+
+// Save replaces the marked paths of the given image (an image without marks is forgotten).
+func (s *Store) Save(image string, paths []string) error {
+	doc, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		delete(doc.Images, image)
+	} else {
+		sorted := append([]string(nil), paths...)
+		sort.Strings(sorted)
+		doc.Images[image] = sorted
+	}
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, content, 0644)
+}
+
+func (s *Store) read() (*document, error) {
+	doc := &document{}
+	content, err := ioutil.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(content, doc); err != nil {
+			return nil, err
+		}
+	}
+	if doc.Images == nil {
+		doc.Images = make(map[string][]string)
+	}
+	return doc, nil
+}
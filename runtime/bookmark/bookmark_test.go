@@ -0,0 +1,49 @@
+package bookmark
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dive-bookmarks")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "nested", "bookmarks.json"))
+
+	paths, err := store.Load("alpine:latest")
+	if err != nil || len(paths) != 0 {
+		t.Fatalf("expected no bookmarks before the first save, got %v (%v)", paths, err)
+	}
+
+	if err := store.Save("alpine:latest", []string{"/etc/passwd", "/bin/sh"}); err != nil {
+		t.Fatalf("unable to save: %v", err)
+	}
+	if err := store.Save("nginx:latest", []string{"/etc/nginx/nginx.conf"}); err != nil {
+		t.Fatalf("unable to save: %v", err)
+	}
+
+	paths, err = store.Load("alpine:latest")
+	if err != nil {
+		t.Fatalf("unable to load: %v", err)
+	}
+	if expected := []string{"/bin/sh", "/etc/passwd"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+
+	if err := store.Save("alpine:latest", nil); err != nil {
+		t.Fatalf("unable to save: %v", err)
+	}
+	if paths, _ = store.Load("alpine:latest"); len(paths) != 0 {
+		t.Errorf("expected the bookmarks to be removed, got %v", paths)
+	}
+	if paths, _ = store.Load("nginx:latest"); len(paths) != 1 {
+		t.Errorf("expected the bookmarks of other images to be kept, got %v", paths)
+	}
+}
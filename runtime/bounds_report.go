@@ -0,0 +1,26 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// boundsReport renders how many entries of each layer a bounded analysis folded into summary directories (empty when
+// none were).
+func boundsReport(layers []*image.Layer) string {
+	var sb strings.Builder
+	for _, layer := range layers {
+		if layer.Tree == nil || layer.Tree.Folded == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  layer %d: %d entries folded into summary directories\n", layer.Index, layer.Tree.Folded)
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	sb.WriteString("  the sizes and the efficiency count every file; the folded files are not listed in the trees")
+	return utils.TitleFormat("Bounded Analysis:") + "\n" + sb.String()
+}
@@ -0,0 +1,213 @@
+package ci
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBudgetFile is the budget file read from the working directory (when there is one) in CI mode.
+const DefaultBudgetFile = "dive.yaml"
+
+// the budget line subject that limits the size of the whole image
+const budgetImage = "image"
+
+// Budget is the size budget a team declares for an image in a budget file, as a list of lines:
+//
+//	budget:
+//	  - image <= 500MB
+//	  - /usr/lib <= 200MB
+//	  - forbid /root/.ssh
+//
+// Each line is evaluated as a CI rule of its own.
+type Budget struct {
+	Lines []BudgetLine
+}
+
+// BudgetLine limits the size of the image or of the files beneath a path, or forbids a path.
+type BudgetLine struct {
+	// the line as written in the budget file
+	Text string
+	// the path the line applies to ("image" for the whole image)
+	Path      string
+	MaxBytes  uint64
+	Forbidden bool
+}
+
+// LoadBudget reads the budget file at the given path.
+func LoadBudget(budgetPath string) (*Budget, error) {
+	contents, err := ioutil.ReadFile(budgetPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBudget(budgetPath, contents)
+}
+
+// ParseBudget parses the contents of a budget file (the name is only used to point at invalid lines).
+func ParseBudget(name string, contents []byte) (*Budget, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	budget := &Budget{}
+	if len(document.Content) == 0 {
+		return budget, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping with a budget list", name, root.Line)
+	}
+	for idx := 0; idx+1 < len(root.Content); idx += 2 {
+		key, value := root.Content[idx], root.Content[idx+1]
+		if key.Value != "budget" {
+			return nil, fmt.Errorf("%s:%d: unknown key %q (expected budget)", name, key.Line, key.Value)
+		}
+		if value.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s:%d: expected a list of budget lines", name, value.Line)
+		}
+
+		seen := make(map[string]bool)
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s:%d: expected a budget line (e.g. \"/usr/lib <= 200MB\" or \"forbid /root/.ssh\")", name, item.Line)
+			}
+			line, err := parseBudgetLine(item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", name, item.Line, err)
+			}
+			if seen[line.Text] {
+				return nil, fmt.Errorf("%s:%d: duplicate budget line %q", name, item.Line, line.Text)
+			}
+			seen[line.Text] = true
+			budget.Lines = append(budget.Lines, line)
+		}
+	}
+	return budget, nil
+}
+
+// parseBudgetLine parses "image <= <size>", "<path> <= <size>" or "forbid <path>".
+func parseBudgetLine(text string) (BudgetLine, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	if forbidden := strings.TrimPrefix(text, "forbid "); forbidden != text {
+		if !strings.HasPrefix(forbidden, "/") {
+			return BudgetLine{}, fmt.Errorf("expected an absolute path to forbid, given %q", forbidden)
+		}
+		cleaned := path.Clean(forbidden)
+		return BudgetLine{Text: "forbid " + cleaned, Path: cleaned, Forbidden: true}, nil
+	}
+
+	subject, size, found := strings.Cut(text, "<=")
+	if !found {
+		return BudgetLine{}, fmt.Errorf("expected \"<path> <= <size>\" or \"forbid <path>\", given %q", text)
+	}
+	subject, size = strings.TrimSpace(subject), strings.TrimSpace(size)
+	if subject != budgetImage {
+		if !strings.HasPrefix(subject, "/") {
+			return BudgetLine{}, fmt.Errorf("expected \"image\" or an absolute path, given %q", subject)
+		}
+		subject = path.Clean(subject)
+	}
+	maxBytes, err := humanize.ParseBytes(size)
+	if err != nil {
+		return BudgetLine{}, fmt.Errorf("invalid size %q: %v", size, err)
+	}
+	return BudgetLine{Text: subject + " <= " + size, Path: subject, MaxBytes: maxBytes}, nil
+}
+
+// Rules returns a CI rule per budget line (none when there is no budget).
+func (budget *Budget) Rules() []CiRule {
+	if budget == nil {
+		return nil
+	}
+	sizes := &finalSizes{}
+	rules := make([]CiRule, len(budget.Lines))
+	for idx, line := range budget.Lines {
+		rules[idx] = &budgetRule{line: line, sizes: sizes}
+	}
+	return rules
+}
+
+type budgetRule struct {
+	line BudgetLine
+	// shared by the rules of a budget, so that the final image is only assembled once
+	sizes *finalSizes
+}
+
+func (rule *budgetRule) Key() string {
+	return "budget " + rule.line.Text
+}
+
+func (rule *budgetRule) Configuration() string {
+	return rule.line.Text
+}
+
+// Validate does nothing, the line was validated while the budget file was parsed.
+func (rule *budgetRule) Validate() error {
+	return nil
+}
+
+func (rule *budgetRule) Evaluate(analysis *image.AnalysisResult) (RuleStatus, string) {
+	line := rule.line
+	if line.Forbidden {
+		for idx, tree := range analysis.RefTrees {
+			if node, err := tree.GetNode(line.Path); err == nil && node != nil && !node.IsWhiteout() {
+				// removing the path in a later layer does not remove it from the layer that added it
+				return RuleFailed, fmt.Sprintf("%s is in layer %d", line.Path, idx)
+			}
+		}
+		return RulePassed, ""
+	}
+
+	sizeBytes := analysis.SizeBytes
+	if line.Path != budgetImage {
+		var err error
+		sizeBytes, err = rule.sizes.of(analysis, line.Path)
+		if err != nil {
+			return RuleFailed, fmt.Sprintf("unable to assemble the final image: %v", err)
+		}
+	}
+	if sizeBytes > line.MaxBytes {
+		return RuleFailed, fmt.Sprintf("%s is %s (%s over budget)", line.Path, humanize.Bytes(sizeBytes), humanize.Bytes(sizeBytes-line.MaxBytes))
+	}
+	return RulePassed, fmt.Sprintf("%s is %s", line.Path, humanize.Bytes(sizeBytes))
+}
+
+// finalSizes measures paths within the final image (the layers stacked on top of each other).
+type finalSizes struct {
+	analysis *image.AnalysisResult
+	tree     *filetree.FileTree
+}
+
+// of returns the size of the files beneath the path in the final image (0 when there is no such path).
+func (sizes *finalSizes) of(analysis *image.AnalysisResult, filePath string) (uint64, error) {
+	if len(analysis.RefTrees) == 0 {
+		return 0, nil
+	}
+	if sizes.analysis != analysis {
+		tree, err := finalTree(analysis)
+		if err != nil {
+			return 0, err
+		}
+		sizes.analysis, sizes.tree = analysis, tree
+	}
+
+	node, err := sizes.tree.GetNode(filePath)
+	if err != nil || node == nil {
+		return 0, nil
+	}
+	var sizeBytes uint64
+	err = node.VisitDepthChildFirst(func(child *filetree.FileNode) error {
+		if !child.Data.FileInfo.IsDir {
+			sizeBytes += uint64(child.Data.FileInfo.Size)
+		}
+		return nil
+	}, nil)
+	return sizeBytes, err
+}
@@ -0,0 +1,79 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func TestParseBudget(t *testing.T) {
+	budget, err := ParseBudget("dive.yaml", []byte("budget:\n  - image <= 500MB\n  - /usr/lib/  <=  200MB\n  - forbid /root/.ssh\n"))
+	if err != nil {
+		t.Fatalf("unable to parse the budget: %v", err)
+	}
+	expected := []BudgetLine{
+		{Text: "image <= 500MB", Path: "image", MaxBytes: 500000000},
+		{Text: "/usr/lib <= 200MB", Path: "/usr/lib", MaxBytes: 200000000},
+		{Text: "forbid /root/.ssh", Path: "/root/.ssh", Forbidden: true},
+	}
+	if !reflect.DeepEqual(budget.Lines, expected) {
+		t.Errorf("expected lines %+v, got %+v", expected, budget.Lines)
+	}
+
+	table := map[string]string{
+		"budget:\n  - usr/lib <= 1MB\n":                 "dive.yaml:2: expected \"image\" or an absolute path",
+		"budget:\n  - /usr/lib <= lots\n":               "dive.yaml:2: invalid size",
+		"budget:\n  - /usr/lib < 1MB\n":                 "dive.yaml:2: expected \"<path> <= <size>\"",
+		"budget:\n  - forbid .ssh\n":                    "dive.yaml:2: expected an absolute path to forbid",
+		"budget:\n  - image <= 1MB\n  - image <= 1MB\n": "dive.yaml:3: duplicate budget line",
+		"rules:\n  - image <= 1MB\n":                    "dive.yaml:1: unknown key \"rules\"",
+		"budget: image <= 1MB\n":                        "dive.yaml:1: expected a list of budget lines",
+	}
+	for contents, expectedErr := range table {
+		if _, err := ParseBudget("dive.yaml", []byte(contents)); err == nil || !strings.HasPrefix(err.Error(), expectedErr) {
+			t.Errorf("%q: expected error %q, got %v", contents, expectedErr, err)
+		}
+	}
+}
+
+func TestBudgetRules(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	budget, err := ParseBudget("dive.yaml", []byte("budget:\n  - image <= 1MB\n  - /root <= 1kB\n  - /etc <= 1MB\n  - forbid /root/example\n  - forbid /root/.ssh\n"))
+	if err != nil {
+		t.Fatalf("unable to parse the budget: %v", err)
+	}
+
+	ciConfig := viper.New()
+	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "highestFileCount", "highestLayerFileCount", "forbidCorruptLayers", "forbidDuplicateArtifacts", "forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities"} {
+		ciConfig.SetDefault("rules."+key, "disabled")
+	}
+	evaluator := NewCiEvaluator(ciConfig)
+	evaluator.Rules = append(evaluator.Rules, budget.Rules()...)
+	if evaluator.Evaluate(result) {
+		t.Errorf("expected the evaluation to fail")
+	}
+
+	expected := map[string]RuleResult{
+		"budget image <= 1MB":         {status: RuleFailed, message: "image is 1.2 MB (221 kB over budget)"},
+		"budget /root <= 1kB":         {status: RuleFailed, message: "/root is 21 kB (20 kB over budget)"},
+		"budget /etc <= 1MB":          {status: RulePassed, message: "/etc is 1.0 kB"},
+		"budget forbid /root/example": {status: RuleFailed, message: "/root/example is in layer 2"},
+		"budget forbid /root/.ssh":    {status: RulePassed},
+	}
+	for key, expectedResult := range expected {
+		if actual := evaluator.Results[key]; actual != expectedResult {
+			t.Errorf("%s: expected %v, got %v", key, expectedResult, actual)
+		}
+	}
+}
+
+func TestNoBudgetRules(t *testing.T) {
+	var budget *Budget
+	if rules := budget.Rules(); rules != nil {
+		t.Errorf("expected no rules without a budget, got %v", rules)
+	}
+}
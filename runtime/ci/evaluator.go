@@ -10,8 +10,6 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
-
-	"github.com/logrusorgru/aurora"
 )
 
 type CiEvaluator struct {
@@ -173,17 +171,40 @@ func (ci *CiEvaluator) Report() string {
 	}
 
 	if ci.Misconfigured {
-		fmt.Fprintln(&sb, aurora.Red("CI Misconfigured"))
+		fmt.Fprintln(&sb, utils.Color.Red("CI Misconfigured"))
 
 	} else {
 		summary := fmt.Sprintf("Result:%s [Total:%d] [Passed:%d] [Failed:%d] [Warn:%d] [Skipped:%d]", status, ci.Tally.Total, ci.Tally.Pass, ci.Tally.Fail, ci.Tally.Warn, ci.Tally.Skip)
 		if ci.Pass {
-			fmt.Fprintln(&sb, aurora.Green(summary))
+			fmt.Fprintln(&sb, utils.Color.Green(summary))
 		} else if ci.Pass && ci.Tally.Warn > 0 {
-			fmt.Fprintln(&sb, aurora.Blue(summary))
+			fmt.Fprintln(&sb, utils.Color.Blue(summary))
 		} else {
-			fmt.Fprintln(&sb, aurora.Red(summary))
+			fmt.Fprintln(&sb, utils.Color.Red(summary))
 		}
 	}
 	return sb.String()
 }
+
+// PolicyResult is the outcome of a single CI rule for an image.
+type PolicyResult struct {
+	Rule    string `json:"rule"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Policy returns the outcome of every evaluated rule, ordered by rule.
+func (ci *CiEvaluator) Policy() []PolicyResult {
+	rules := make([]string, 0, len(ci.Results))
+	for name := range ci.Results {
+		rules = append(rules, name)
+	}
+	sort.Strings(rules)
+
+	policy := make([]PolicyResult, 0, len(rules))
+	for _, name := range rules {
+		result := ci.Results[name]
+		policy = append(policy, PolicyResult{Rule: name, Status: result.Status().Name(), Message: result.Message()})
+	}
+	return policy
+}
@@ -1,6 +1,7 @@
 package ci
 
 import (
+	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/dive/image/docker"
 	"strings"
 	"testing"
@@ -15,22 +16,36 @@ func Test_Evaluator(t *testing.T) {
 	table := map[string]struct {
 		efficiency     string
 		wastedBytes    string
+		appWasted      string
 		wastedPercent  string
+		duplicates     string
+		audit          string
+		content        string
+		files          string
 		expectedPass   bool
 		expectedResult map[string]RuleStatus
 	}{
-		"allFail":           {"0.99", "1B", "0.01", false, map[string]RuleStatus{"lowestEfficiency": RuleFailed, "highestWastedBytes": RuleFailed, "highestUserWastedPercent": RuleFailed}},
-		"allPass":           {"0.9", "50kB", "0.5", true, map[string]RuleStatus{"lowestEfficiency": RulePassed, "highestWastedBytes": RulePassed, "highestUserWastedPercent": RulePassed}},
-		"allDisabled":       {"disabled", "disabled", "disabled", true, map[string]RuleStatus{"lowestEfficiency": RuleDisabled, "highestWastedBytes": RuleDisabled, "highestUserWastedPercent": RuleDisabled}},
-		"misconfiguredHigh": {"1.1", "1BB", "10", false, map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleMisconfigured, "highestUserWastedPercent": RuleMisconfigured}},
-		"misconfiguredLow":  {"-9", "-1BB", "-0.1", false, map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleMisconfigured, "highestUserWastedPercent": RuleMisconfigured}},
+		"allFail":           {"0.99", "1B", "1B", "0.01", "true", "true", "/root/**", "10", false, map[string]RuleStatus{"lowestEfficiency": RuleFailed, "highestWastedBytes": RuleFailed, "highestAppWastedBytes": RuleFailed, "highestFileCount": RuleFailed, "highestLayerFileCount": RuleFailed, "highestUserWastedPercent": RuleFailed, "forbidCorruptLayers": RulePassed, "forbidDuplicateArtifacts": RulePassed, "forbidSetuidFiles": RulePassed, "forbidWorldWritableFiles": RulePassed, "forbidRootOwnedAppFiles": RulePassed, "forbidUnexpectedCapabilities": RulePassed, "forbiddenContent": RuleFailed}},
+		"allPass":           {"0.9", "50kB", "50kB", "0.7", "true", "true", "**/*.pem", "1000", true, map[string]RuleStatus{"lowestEfficiency": RulePassed, "highestWastedBytes": RulePassed, "highestAppWastedBytes": RulePassed, "highestFileCount": RulePassed, "highestLayerFileCount": RulePassed, "highestUserWastedPercent": RulePassed, "forbidCorruptLayers": RulePassed, "forbidDuplicateArtifacts": RulePassed, "forbidSetuidFiles": RulePassed, "forbidWorldWritableFiles": RulePassed, "forbidRootOwnedAppFiles": RulePassed, "forbidUnexpectedCapabilities": RulePassed, "forbiddenContent": RulePassed}},
+		"allDisabled":       {"disabled", "disabled", "disabled", "disabled", "disabled", "disabled", "disabled", "disabled", true, map[string]RuleStatus{"lowestEfficiency": RuleDisabled, "highestWastedBytes": RuleDisabled, "highestAppWastedBytes": RuleDisabled, "highestFileCount": RuleDisabled, "highestLayerFileCount": RuleDisabled, "highestUserWastedPercent": RuleDisabled, "forbidCorruptLayers": RuleDisabled, "forbidDuplicateArtifacts": RuleDisabled, "forbidSetuidFiles": RuleDisabled, "forbidWorldWritableFiles": RuleDisabled, "forbidRootOwnedAppFiles": RuleDisabled, "forbidUnexpectedCapabilities": RuleDisabled, "forbiddenContent": RuleDisabled}},
+		"misconfiguredHigh": {"1.1", "1BB", "1BB", "10", "maybe", "maybe", "[", "many", false, map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleMisconfigured, "highestAppWastedBytes": RuleMisconfigured, "highestFileCount": RuleMisconfigured, "highestLayerFileCount": RuleMisconfigured, "highestUserWastedPercent": RuleMisconfigured, "forbidCorruptLayers": RuleMisconfigured, "forbidDuplicateArtifacts": RuleMisconfigured, "forbidSetuidFiles": RuleMisconfigured, "forbidWorldWritableFiles": RuleMisconfigured, "forbidRootOwnedAppFiles": RuleMisconfigured, "forbidUnexpectedCapabilities": RuleMisconfigured, "forbiddenContent": RuleMisconfigured}},
+		"misconfiguredLow":  {"-9", "-1BB", "-1BB", "-0.1", "-1", "-1", "/root/[-", "-1", false, map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleMisconfigured, "highestAppWastedBytes": RuleMisconfigured, "highestFileCount": RuleMisconfigured, "highestLayerFileCount": RuleMisconfigured, "highestUserWastedPercent": RuleMisconfigured, "forbidCorruptLayers": RuleMisconfigured, "forbidDuplicateArtifacts": RuleMisconfigured, "forbidSetuidFiles": RuleMisconfigured, "forbidWorldWritableFiles": RuleMisconfigured, "forbidRootOwnedAppFiles": RuleMisconfigured, "forbidUnexpectedCapabilities": RuleMisconfigured, "forbiddenContent": RuleMisconfigured}},
 	}
 
 	for name, test := range table {
 		ciConfig := viper.New()
 		ciConfig.SetDefault("rules.lowestEfficiency", test.efficiency)
 		ciConfig.SetDefault("rules.highestWastedBytes", test.wastedBytes)
+		ciConfig.SetDefault("rules.highestAppWastedBytes", test.appWasted)
 		ciConfig.SetDefault("rules.highestUserWastedPercent", test.wastedPercent)
+		ciConfig.SetDefault("rules.highestFileCount", test.files)
+		ciConfig.SetDefault("rules.highestLayerFileCount", test.files)
+		ciConfig.SetDefault("rules.forbidCorruptLayers", test.duplicates)
+		ciConfig.SetDefault("rules.forbidDuplicateArtifacts", test.duplicates)
+		for _, key := range []string{"forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities"} {
+			ciConfig.SetDefault("rules."+key, test.audit)
+		}
+		ciConfig.SetDefault("rules.forbiddenContent", test.content)
 
 		evaluator := NewCiEvaluator(ciConfig)
 
@@ -56,3 +71,115 @@ func Test_Evaluator(t *testing.T) {
 	}
 
 }
+
+func Test_EvaluatorDuplicateArtifacts(t *testing.T) {
+	result := &image.AnalysisResult{
+		DuplicateArtifacts: []image.DuplicateArtifact{
+			{Kind: image.ArtifactJar, Name: "guava", Dir: "/app/libs", Versions: []image.ArtifactVersion{{Version: "31.1-jre"}, {Version: "32.0-jre"}}},
+		},
+	}
+
+	for value, expected := range map[string]RuleStatus{"true": RuleFailed, "false": RulePassed} {
+		ciConfig := viper.New()
+		for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "highestFileCount", "highestLayerFileCount", "forbidCorruptLayers", "forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities"} {
+			ciConfig.SetDefault("rules."+key, "disabled")
+		}
+		ciConfig.SetDefault("rules.forbidDuplicateArtifacts", value)
+
+		evaluator := NewCiEvaluator(ciConfig)
+		evaluator.Evaluate(result)
+
+		actual := evaluator.Results["forbidDuplicateArtifacts"]
+		if actual.status != expected {
+			t.Errorf("forbidDuplicateArtifacts=%s: expected %v, got %v: %v", value, expected, actual.status, actual)
+		}
+	}
+}
+
+func Test_EvaluatorAudit(t *testing.T) {
+	result := &image.AnalysisResult{
+		Audit: &image.Audit{Findings: []image.AuditFinding{
+			{Kind: image.AuditSetgid, Path: "/usr/bin/wall", Detail: "mode 2755, runs as group 5"},
+			{Kind: image.AuditCapabilities, Path: "/usr/bin/ping", Detail: "cap_net_raw"},
+		}},
+	}
+
+	ciConfig := viper.New()
+	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "highestFileCount", "highestLayerFileCount", "forbidCorruptLayers", "forbidDuplicateArtifacts"} {
+		ciConfig.SetDefault("rules."+key, "disabled")
+	}
+	ciConfig.SetDefault("rules.forbidSetuidFiles", "true")
+	ciConfig.SetDefault("rules.forbidWorldWritableFiles", "true")
+	ciConfig.SetDefault("rules.forbidRootOwnedAppFiles", "disabled")
+	ciConfig.SetDefault("rules.forbidUnexpectedCapabilities", "false")
+
+	evaluator := NewCiEvaluator(ciConfig)
+	if evaluator.Evaluate(result) {
+		t.Errorf("expected the evaluation to fail")
+	}
+
+	expected := map[string]RuleResult{
+		"forbidSetuidFiles":            {status: RuleFailed, message: "setuid or setgid binaries are in the image: /usr/bin/wall"},
+		"forbidWorldWritableFiles":     {status: RulePassed},
+		"forbidRootOwnedAppFiles":      {status: RuleDisabled, message: "rule disabled"},
+		"forbidUnexpectedCapabilities": {status: RulePassed},
+	}
+	for key, expectedResult := range expected {
+		if actual := evaluator.Results[key]; actual != expectedResult {
+			t.Errorf("%s: expected %+v, got %+v", key, expectedResult, actual)
+		}
+	}
+}
+
+func Test_EvaluatorFileCount(t *testing.T) {
+	result := &image.AnalysisResult{
+		Storage: &image.StorageOverhead{UniqueEntries: 1500, LayerEntries: []int{1200, 40, 900}},
+	}
+
+	ciConfig := viper.New()
+	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "forbidCorruptLayers", "forbidDuplicateArtifacts", "forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities"} {
+		ciConfig.SetDefault("rules."+key, "disabled")
+	}
+	ciConfig.SetDefault("rules.highestFileCount", "2000")
+	ciConfig.SetDefault("rules.highestLayerFileCount", "800")
+
+	evaluator := NewCiEvaluator(ciConfig)
+	if evaluator.Evaluate(result) {
+		t.Errorf("expected the evaluation to fail")
+	}
+
+	expected := map[string]RuleResult{
+		"highestFileCount":      {status: RulePassed},
+		"highestLayerFileCount": {status: RuleFailed, message: "too many files in a layer (threshold=800): layer 0 (1200 files), layer 2 (900 files)"},
+	}
+	for key, expectedResult := range expected {
+		if actual := evaluator.Results[key]; actual != expectedResult {
+			t.Errorf("%s: expected %+v, got %+v", key, expectedResult, actual)
+		}
+	}
+}
+
+func Test_EvaluatorCorruptLayers(t *testing.T) {
+	result := &image.AnalysisResult{
+		Layers: []*image.Layer{
+			{Index: 0},
+			{Index: 1, Corruption: []string{"the layer tar is truncated after 3 entries (unexpected EOF)"}},
+		},
+	}
+
+	for value, expected := range map[string]RuleStatus{"true": RuleFailed, "false": RuleWarning} {
+		ciConfig := viper.New()
+		for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestAppWastedBytes", "highestUserWastedPercent", "highestFileCount", "highestLayerFileCount", "forbidDuplicateArtifacts", "forbidSetuidFiles", "forbidWorldWritableFiles", "forbidRootOwnedAppFiles", "forbidUnexpectedCapabilities"} {
+			ciConfig.SetDefault("rules."+key, "disabled")
+		}
+		ciConfig.SetDefault("rules.forbidCorruptLayers", value)
+
+		evaluator := NewCiEvaluator(ciConfig)
+		evaluator.Evaluate(result)
+
+		expectedResult := RuleResult{status: expected, message: "layers failed verification, their contents may be incomplete: layer 1 (the layer tar is truncated after 3 entries (unexpected EOF))"}
+		if actual := evaluator.Results["forbidCorruptLayers"]; actual != expectedResult {
+			t.Errorf("forbidCorruptLayers=%s: expected %+v, got %+v", value, expectedResult, actual)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// the rule failing when files of the final image match any of the configured globs
+const forbiddenContentKey = "forbiddenContent"
+
+// forbiddenContentValue reads the globs of the rule, given either as a list or as a single comma separated value.
+func forbiddenContentValue(config *viper.Viper) string {
+	key := fmt.Sprintf("rules.%s", forbiddenContentKey)
+	if list, ok := config.Get(key).([]interface{}); ok {
+		patterns := make([]string, len(list))
+		for idx, pattern := range list {
+			patterns[idx] = fmt.Sprint(pattern)
+		}
+		return strings.Join(patterns, ",")
+	}
+	return config.GetString(key)
+}
+
+// newForbiddenContentRule fails if files of the final image match any of the configured globs (e.g. "**/.git/**",
+// "**/*.pem" or "/var/cache/**"), naming the layer that introduced each of them.
+func newForbiddenContentRule(config *viper.Viper) CiRule {
+	return newGenericCiRule(
+		forbiddenContentKey,
+		forbiddenContentValue(config),
+		func(value string) error {
+			for _, pattern := range contentPatterns(value) {
+				if err := filetree.ValidateGlob(pattern); err != nil {
+					return fmt.Errorf("invalid config value ('%v'): %v", pattern, err)
+				}
+			}
+			return nil
+		},
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			patterns := contentPatterns(value)
+			if len(patterns) == 0 || len(analysis.RefTrees) == 0 {
+				return RulePassed, ""
+			}
+			tree, err := finalTree(analysis)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("unable to assemble the final image: %v", err)
+			}
+
+			var paths []string
+			err = tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+				if node.Data.FileInfo.IsDir {
+					return nil
+				}
+				for _, pattern := range patterns {
+					if filetree.MatchGlob(pattern, node.Path()) {
+						paths = append(paths, node.Path())
+						break
+					}
+				}
+				return nil
+			}, nil)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("unable to walk the final image: %v", err)
+			}
+			if len(paths) == 0 {
+				return RulePassed, ""
+			}
+
+			sort.Strings(paths)
+			listed := make([]string, 0, auditRuleMaxPaths+1)
+			for idx, filePath := range paths {
+				if idx == auditRuleMaxPaths {
+					listed = append(listed, fmt.Sprintf("...and %d more", len(paths)-auditRuleMaxPaths))
+					break
+				}
+				listed = append(listed, fmt.Sprintf("%s (layer %d)", filePath, introducedIn(analysis, filePath)))
+			}
+			return RuleFailed, fmt.Sprintf("files matching the forbidden content are in the image: %s", strings.Join(listed, ", "))
+		},
+	)
+}
+
+// contentPatterns splits the configured globs (none when the value is empty).
+func contentPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// introducedIn returns the layer that added the file of the final image (the last time it was added, when it was
+// deleted and added back).
+func introducedIn(analysis *image.AnalysisResult, filePath string) int {
+	if change, ok := image.TracePath(analysis.Layers, analysis.RefTrees, filePath).LastAdded(); ok {
+		return change.Layer
+	}
+	return -1
+}
+
+// finalTree stacks the layers of the image on top of each other.
+func finalTree(analysis *image.AnalysisResult) (*filetree.FileTree, error) {
+	tree, _, err := filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+	return tree, err
+}
@@ -0,0 +1,68 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func TestIntroducedIn(t *testing.T) {
+	layers := [][]string{
+		{"/app/.git/config", "/app/key.pem", "/app/cache/index"},
+		{"/app/.git/config", "/app/.wh.key.pem"},
+		{"/app/key.pem", "/app/cache/.wh..wh..opq", "/app/cache/other"},
+		{"/app/cache/index"},
+	}
+	trees := make([]*filetree.FileTree, len(layers))
+	for idx, paths := range layers {
+		trees[idx] = filetree.NewFileTree()
+		for _, filePath := range paths {
+			if _, _, err := trees[idx].AddPath(filePath, filetree.FileInfo{Path: filePath}); err != nil {
+				t.Fatalf("unable to add %s: %v", filePath, err)
+			}
+		}
+	}
+
+	expected := map[string]int{
+		// modified in a later layer, still introduced by the first one
+		"/app/.git/config": 0,
+		// removed, then added again
+		"/app/key.pem": 2,
+		// hidden by a directory replaced with an opaque whiteout, then added again
+		"/app/cache/index": 3,
+	}
+	for filePath, layer := range expected {
+		if actual := introducedIn(&image.AnalysisResult{RefTrees: trees}, filePath); actual != layer {
+			t.Errorf("%s: expected layer %d, got %d", filePath, layer, actual)
+		}
+	}
+}
+
+func TestForbiddenContentRule(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		value           interface{}
+		expectedStatus  RuleStatus
+		expectedMessage string
+	}{
+		"list":   {[]interface{}{"/root/*.txt", "**/.saved.txt"}, RuleFailed, "files matching the forbidden content are in the image: /root/.saved.txt (layer 8), /root/saved.txt (layer 7)"},
+		"commas": {"**/*.pem, **/id_rsa*", RulePassed, ""},
+		"empty":  {"", RulePassed, ""},
+	}
+	for name, test := range table {
+		config := viper.New()
+		config.Set("rules.forbiddenContent", test.value)
+		status, message := newForbiddenContentRule(config).Evaluate(result)
+		if status != test.expectedStatus || message != test.expectedMessage {
+			t.Errorf("%s: expected %v %q, got %v %q", name, test.expectedStatus, test.expectedMessage, status, message)
+		}
+	}
+
+	if status, _ := newForbiddenContentRule(viper.New()).Evaluate(&image.AnalysisResult{}); status != RulePassed {
+		t.Errorf("expected an image without layers to pass, got %v", status)
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 
 	"github.com/dustin/go-humanize"
-	"github.com/logrusorgru/aurora"
+	"github.com/wagoodman/dive/utils"
 )
 
 const (
@@ -42,6 +43,16 @@ type RuleResult struct {
 	message string
 }
 
+// Status is the outcome of the rule.
+func (result RuleResult) Status() RuleStatus {
+	return result.status
+}
+
+// Message explains the outcome of the rule (empty when there is nothing to add).
+func (result RuleResult) Message() string {
+	return result.message
+}
+
 func newGenericCiRule(key string, configValue string, validator func(string) error, evaluator func(*image.AnalysisResult, string) (RuleStatus, string)) *GenericCiRule {
 	return &GenericCiRule{
 		key:             key,
@@ -72,17 +83,37 @@ func (status RuleStatus) String() string {
 	case RulePassed:
 		return "PASS"
 	case RuleFailed:
-		return aurora.Bold(aurora.Inverse(aurora.Red("FAIL"))).String()
+		return utils.Color.Bold(utils.Color.Inverse(utils.Color.Red("FAIL"))).String()
 	case RuleWarning:
-		return aurora.Blue("WARN").String()
+		return utils.Color.Blue("WARN").String()
 	case RuleDisabled:
-		return aurora.Blue("SKIP").String()
+		return utils.Color.Blue("SKIP").String()
 	case RuleMisconfigured:
-		return aurora.Bold(aurora.Inverse(aurora.Red("MISCONFIGURED"))).String()
+		return utils.Color.Bold(utils.Color.Inverse(utils.Color.Red("MISCONFIGURED"))).String()
 	case RuleConfigured:
 		return "CONFIGURED   "
 	default:
-		return aurora.Inverse("Unknown").String()
+		return utils.Color.Inverse("Unknown").String()
+	}
+}
+
+// Name is the plain (uncolored) name of the status, as used in machine readable output.
+func (status RuleStatus) Name() string {
+	switch status {
+	case RulePassed:
+		return "pass"
+	case RuleFailed:
+		return "fail"
+	case RuleWarning:
+		return "warn"
+	case RuleDisabled:
+		return "skip"
+	case RuleMisconfigured:
+		return "misconfigured"
+	case RuleConfigured:
+		return "configured"
+	default:
+		return "unknown"
 	}
 }
 
@@ -137,6 +168,29 @@ func loadCiRules(config *viper.Viper) []CiRule {
 		},
 	))
 
+	ruleKey = "highestAppWastedBytes"
+	rules = append(rules, newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		func(value string) error {
+			_, err := humanize.ParseBytes(value)
+			if err != nil {
+				return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+			}
+			return nil
+		},
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			highestAppWastedBytes, err := humanize.ParseBytes(value)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			if analysis.AppWastedBytes > highestAppWastedBytes {
+				return RuleFailed, fmt.Sprintf("too many bytes wasted by the app layers (app-wasted-bytes=%v > threshold=%v)", analysis.AppWastedBytes, highestAppWastedBytes)
+			}
+			return RulePassed, ""
+		},
+	))
+
 	ruleKey = "highestUserWastedPercent"
 	rules = append(rules, newGenericCiRule(
 		ruleKey,
@@ -164,5 +218,199 @@ func loadCiRules(config *viper.Viper) []CiRule {
 		},
 	))
 
+	ruleKey = "highestFileCount"
+	rules = append(rules, newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		validateFileCount,
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			highestFileCount, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			if analysis.Storage == nil {
+				return RuleWarning, "the file count of the image is unknown"
+			}
+			if uint64(analysis.Storage.UniqueEntries) > highestFileCount {
+				return RuleFailed, fmt.Sprintf("too many files in the image (file-count=%v > threshold=%v)", analysis.Storage.UniqueEntries, highestFileCount)
+			}
+			return RulePassed, ""
+		},
+	))
+
+	ruleKey = "highestLayerFileCount"
+	rules = append(rules, newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		validateFileCount,
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			highestLayerFileCount, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			if analysis.Storage == nil {
+				return RuleWarning, "the file counts of the layers are unknown"
+			}
+			var layers []string
+			for idx, entries := range analysis.Storage.LayerEntries {
+				if uint64(entries) > highestLayerFileCount {
+					layers = append(layers, fmt.Sprintf("layer %d (%d files)", idx, entries))
+				}
+			}
+			if len(layers) > 0 {
+				return RuleFailed, fmt.Sprintf("too many files in a layer (threshold=%v): %s", highestLayerFileCount, strings.Join(layers, ", "))
+			}
+			return RulePassed, ""
+		},
+	))
+
+	ruleKey = "forbidCorruptLayers"
+	rules = append(rules, newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		func(value string) error {
+			_, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+			}
+			return nil
+		},
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			forbidCorruptLayers, err := strconv.ParseBool(value)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			var layers []string
+			for _, layer := range analysis.Layers {
+				if len(layer.Corruption) > 0 {
+					layers = append(layers, fmt.Sprintf("layer %d (%s)", layer.Index, strings.Join(layer.Corruption, "; ")))
+				}
+			}
+			if len(layers) == 0 {
+				return RulePassed, ""
+			}
+			message := fmt.Sprintf("layers failed verification, their contents may be incomplete: %s", strings.Join(layers, ", "))
+			if !forbidCorruptLayers {
+				return RuleWarning, message
+			}
+			return RuleFailed, message
+		},
+	))
+
+	ruleKey = "forbidDuplicateArtifacts"
+	rules = append(rules, newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		func(value string) error {
+			_, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+			}
+			return nil
+		},
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			forbidDuplicateArtifacts, err := strconv.ParseBool(value)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			if forbidDuplicateArtifacts && len(analysis.DuplicateArtifacts) > 0 {
+				names := make([]string, len(analysis.DuplicateArtifacts))
+				for idx, duplicate := range analysis.DuplicateArtifacts {
+					names[idx] = fmt.Sprintf("%s (%s)", duplicate.Name, duplicate.Dir)
+				}
+				return RuleFailed, fmt.Sprintf("several versions of the same library are in the image: %s", strings.Join(names, ", "))
+			}
+			return RulePassed, ""
+		},
+	))
+
+	rules = append(rules,
+		newAuditRule(config, "forbidSetuidFiles", "setuid or setgid binaries are in the image", image.AuditSetuid, image.AuditSetgid),
+		newAuditRule(config, "forbidWorldWritableFiles", "world-writable files are in the image", image.AuditWorldWritable),
+		newAuditRule(config, "forbidRootOwnedAppFiles", "files owned by root are within the application directories", image.AuditRootOwned),
+		newAuditRule(config, "forbidUnexpectedCapabilities", "files are granted capabilities that are not allowed", image.AuditCapabilities),
+		newForbiddenContentRule(config),
+	)
+
 	return rules
 }
+
+// validateFileCount checks the threshold of a file count rule (a number of files and directories).
+func validateFileCount(value string) error {
+	_, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+	}
+	return nil
+}
+
+// the most paths named in the message of a failed audit rule
+const auditRuleMaxPaths = 5
+
+// newAuditRule fails (when configured as true) if the permission audit found files of the given kinds.
+func newAuditRule(config *viper.Viper, ruleKey, failure string, kinds ...string) CiRule {
+	return newGenericCiRule(
+		ruleKey,
+		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		func(value string) error {
+			_, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+			}
+			return nil
+		},
+		func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+			forbid, err := strconv.ParseBool(value)
+			if err != nil {
+				return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+			}
+			if !forbid {
+				return RulePassed, ""
+			}
+			var paths []string
+			for _, kind := range kinds {
+				paths = append(paths, analysis.Audit.Paths(kind)...)
+			}
+			if len(paths) == 0 {
+				return RulePassed, ""
+			}
+			listed := paths
+			if len(listed) > auditRuleMaxPaths {
+				listed = append(listed[:auditRuleMaxPaths:auditRuleMaxPaths], fmt.Sprintf("...and %d more", len(paths)-auditRuleMaxPaths))
+			}
+			return RuleFailed, fmt.Sprintf("%s: %s", failure, strings.Join(listed, ", "))
+		},
+	)
+}
+
+// RuleKeys returns the keys of the CI rules, as given within the "rules" section of the CI config.
+func RuleKeys() []string {
+	rules := loadCiRules(viper.New())
+	keys := make([]string, len(rules))
+	for idx, rule := range rules {
+		keys[idx] = rule.Key()
+	}
+	return keys
+}
+
+// IsListRule indicates if the rule takes a list of values (given as a list, or as a single comma separated value).
+func IsListRule(key string) bool {
+	return strings.EqualFold(key, forbiddenContentKey)
+}
+
+// ValidateRule checks a configured rule value the same way the rule does before the image is evaluated (the rule key
+// is not case sensitive, and "disabled" is valid for every rule).
+func ValidateRule(key, value string) error {
+	config := viper.New()
+	config.Set("rules."+key, value)
+	for _, rule := range loadCiRules(config) {
+		if !strings.EqualFold(rule.Key(), key) {
+			continue
+		}
+		if rule.Configuration() == "disabled" {
+			return nil
+		}
+		return rule.Validate()
+	}
+	return fmt.Errorf("unknown rule %q", key)
+}
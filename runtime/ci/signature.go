@@ -0,0 +1,45 @@
+package ci
+
+import (
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// SignatureRule checks the outcome of the cosign signature verification: an image whose signature could not be
+// verified fails the CI checks when the signature is required, and is a warning otherwise.
+func SignatureRule(required bool) CiRule {
+	return &signatureRule{required: required}
+}
+
+type signatureRule struct {
+	required bool
+}
+
+func (rule *signatureRule) Key() string {
+	return "signature"
+}
+
+func (rule *signatureRule) Configuration() string {
+	if rule.required {
+		return "required"
+	}
+	return "optional"
+}
+
+// Validate does nothing, the verification policy was checked before the image was fetched.
+func (rule *signatureRule) Validate() error {
+	return nil
+}
+
+func (rule *signatureRule) Evaluate(analysis *image.AnalysisResult) (RuleStatus, string) {
+	var failed RuleStatus = RuleWarning
+	if rule.required {
+		failed = RuleFailed
+	}
+	if analysis.Signature == nil {
+		return failed, "the signature was not verified"
+	}
+	if !analysis.Signature.Verified {
+		return failed, analysis.Signature.Error
+	}
+	return RulePassed, ""
+}
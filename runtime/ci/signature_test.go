@@ -0,0 +1,31 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestSignatureRule(t *testing.T) {
+	verified := &image.AnalysisResult{Signature: &image.SignatureVerification{Verified: true}}
+	unverified := &image.AnalysisResult{Signature: &image.SignatureVerification{Error: "no matching signatures"}}
+
+	cases := []struct {
+		name     string
+		required bool
+		analysis *image.AnalysisResult
+		status   RuleStatus
+		message  string
+	}{
+		{name: "verified", required: true, analysis: verified, status: RulePassed},
+		{name: "required", required: true, analysis: unverified, status: RuleFailed, message: "no matching signatures"},
+		{name: "optional", required: false, analysis: unverified, status: RuleWarning, message: "no matching signatures"},
+		{name: "not verified", required: true, analysis: &image.AnalysisResult{}, status: RuleFailed, message: "the signature was not verified"},
+	}
+	for _, test := range cases {
+		status, message := SignatureRule(test.required).Evaluate(test.analysis)
+		if status != test.status || message != test.message {
+			t.Errorf("%s: expected %v %q, got %v %q", test.name, test.status, test.message, status, message)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of findings listed in the report (the largest first)
+const compressionReportMaxFindings = 10
+
+// compressionReport renders the compression ratio of every layer, followed by the content that is compressed twice
+// (with how to avoid it).
+func compressionReport(analysis *image.CompressionAnalysis) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Layer Compression:"))
+
+	fmt.Fprintf(&sb, "    %8s  %10s  %6s  %13s  %s\n", "Size", "Compressed", "Ratio", "Precompressed", "Layer")
+	for _, layer := range analysis.Layers {
+		compressed, ratio := "-", "-"
+		if layer.CompressedBytes > 0 {
+			compressed = humanize.Bytes(layer.CompressedBytes)
+		}
+		if layer.Ratio() > 0 {
+			ratio = fmt.Sprintf("%.1fx", layer.Ratio())
+		}
+		fmt.Fprintf(&sb, "    %8s  %10s  %6s  %13s  %d\n", humanize.Bytes(layer.SizeBytes), compressed, ratio, fmt.Sprintf("%.0f%%", layer.PrecompressedShare()*100), layer.Layer)
+	}
+
+	for idx, finding := range analysis.Findings {
+		if idx >= compressionReportMaxFindings {
+			fmt.Fprintf(&sb, "  ...and %d more\n", len(analysis.Findings)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "  %s: %s (%s, layer %d)\n", finding.Kind, finding.Path, humanize.Bytes(finding.SizeBytes), finding.Layer)
+		fmt.Fprintf(&sb, "    %s\n", finding.Detail)
+		fmt.Fprintf(&sb, "    hint: %s\n", finding.Hint)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
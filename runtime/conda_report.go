@@ -0,0 +1,35 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of packages listed for each environment in the report
+const condaReportMaxPackages = 10
+
+// condaReport renders the conda environments (with their packages) and the package caches found in the image.
+func condaReport(analysis *image.CondaAnalysis) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Conda Environments:"))
+
+	for _, environment := range analysis.Environments {
+		fmt.Fprintf(&sb, "  %-10s %10s  %d packages  layer %d  %s\n", environment.Name, humanize.Bytes(environment.SizeBytes), len(environment.Packages), environment.Layer, environment.Prefix)
+		for idx, condaPackage := range environment.Packages {
+			if idx >= condaReportMaxPackages {
+				fmt.Fprintf(&sb, "    ...and %d more\n", len(environment.Packages)-idx)
+				break
+			}
+			fmt.Fprintf(&sb, "    %s %s (%s)\n", condaPackage.Name, condaPackage.Version, condaPackage.Build)
+		}
+	}
+
+	for _, cache := range analysis.PackageCaches {
+		fmt.Fprintf(&sb, "  package cache %10s  %d packages  layer %d  %s (run \"conda clean --all\" in the layer that installs the packages)\n", humanize.Bytes(cache.SizeBytes), cache.Packages, cache.Layer, cache.Path)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
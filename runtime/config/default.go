@@ -0,0 +1,15 @@
+package config
+
+import (
+	// the default config file is embedded
+	_ "embed"
+)
+
+//go:embed default.yaml
+var defaultConfig []byte
+
+// DefaultConfig returns a commented config file with every setting at its default value (as written by
+// "dive config init").
+func DefaultConfig() []byte {
+	return append([]byte(nil), defaultConfig...)
+}
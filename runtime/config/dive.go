@@ -0,0 +1,379 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/inspect"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/hook"
+	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+)
+
+// the configurable keybindings (toggle-unchanged-files is the former name of toggle-unmodified-files)
+var keybindings = []string{
+	"quit", "toggle-view", "filter-files", "saved-filters", "screenshot", "show-metrics", "next-image", "pick-image", "compare-images",
+	"compare-all", "compare-flattened", "compare-since-base", "compare-layer", "copy-digest", "copy-command", "copy-image-id",
+	"toggle-doomed-files", "toggle-empty-layers", "select-layer-range", "show-history", "show-config", "show-ownership", "compare-side-by-side",
+	"toggle-collapse-dir", "toggle-collapse-all-dir", "collapse-all-dir", "expand-all-dir", "expand-depth",
+	"collapse-depth", "collapse-siblings", "go-to-path", "cycle-size-format", "cycle-mtime-filter", "toggle-file-counts", "toggle-filetree-attributes", "toggle-added-files",
+	"toggle-removed-files", "toggle-modified-files", "toggle-unmodified-files", "toggle-unchanged-files",
+	"toggle-wrap-tree", "follow-link", "copy-path", "toggle-mark", "next-mark", "previous-mark", "toggle-keep",
+	"export-keep-list", "show-provenance",
+	"preview-file", "view-file", "edit-file", "browse-archive", "show-pivot", "show-packages", "toggle-orphan-files",
+	"export-pivot", "toggle-unchanged-rows", "page-up", "page-down",
+	"cursor-up", "cursor-down", "cursor-left", "cursor-right", "cursor-top", "cursor-bottom", "search", "next-match",
+	"previous-match", "expand-to-depth",
+}
+
+// DiveSchema describes the dive config file (e.g. ~/.dive.yaml).
+func DiveSchema() *Field {
+	fields := settingsFields()
+
+	// the settings of the images matching each pattern, including their CI rules
+	profile := settingsFields()
+	profile["rules"] = rulesSection()
+	fields["images"] = &Field{Kind: Entries, Elem: &Field{Kind: Section, Fields: profile}}
+
+	fields["daemon"] = section(map[string]*Field{
+		"listen": {Kind: String},
+	})
+	fields["fleet"] = section(map[string]*Field{
+		"report-dir": {Kind: String},
+		"interval":   {Kind: Duration},
+		"images": {Kind: Items, Elem: &Field{
+			Kind:      Section,
+			Shorthand: "image",
+			Fields: map[string]*Field{
+				"image":  {Kind: String},
+				"source": {Kind: String, Check: checkSource},
+			},
+		}},
+		"rules": rulesSection(),
+	})
+	return section(fields)
+}
+
+// CiSchema describes the CI config file (e.g. .dive-ci).
+func CiSchema() *Field {
+	return section(map[string]*Field{
+		"rules":         rulesSection(),
+		"ignore-errors": {Kind: Bool},
+	})
+}
+
+// hooksSection describes the commands run on each event (a single command or a list of commands).
+func hooksSection() *Field {
+	fields := map[string]*Field{
+		"timeout": {Kind: Duration},
+	}
+	for _, event := range hook.Events {
+		fields[event] = &Field{Kind: List}
+	}
+	return section(fields)
+}
+
+// settingsFields describes the settings that can also be given per image.
+func settingsFields() map[string]*Field {
+	bindings := make(map[string]*Field, len(keybindings))
+	for _, name := range keybindings {
+		bindings[name] = &Field{Kind: String, Check: key.ValidateKeys}
+	}
+	bindings["profile"] = &Field{Kind: String, Values: key.ProfileNames()}
+
+	inspectors := make([]string, 0, len(inspect.Builtins))
+	for name := range inspect.Builtins {
+		inspectors = append(inspectors, name)
+	}
+	sort.Strings(inspectors)
+
+	return map[string]*Field{
+		"source":           {Kind: String, Check: checkSource},
+		"engine":           {Kind: String},
+		"container-engine": {Kind: String},
+		"ignore-errors":    {Kind: Bool},
+		"lazy":             {Kind: Bool},
+		"offline":          {Kind: Bool},
+		"log": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+			"path":    {Kind: String},
+			"level":   {Kind: String, Check: checkLogLevel},
+			"format":  {Kind: String, Values: []string{"text", "json"}},
+		}),
+		"keybinding": section(bindings),
+		"diff": section(map[string]*Field{
+			"hide": {Kind: List, Values: []string{"added", "removed", "modified", "unmodified"}},
+		}),
+		"filetree": section(map[string]*Field{
+			"collapse-dir":     {Kind: Bool},
+			"pane-width":       {Kind: Number, Check: checkPaneWidth},
+			"show-attributes":  {Kind: Bool},
+			"filter":           {Kind: String, Check: checkRegex},
+			"saved-filters":    {Kind: Entries, Elem: &Field{Kind: String, Check: checkRegex}},
+			"ignore-paths":     {Kind: List},
+			"size-format":      {Kind: String, Values: filetree.SizeFormats},
+			"show-file-counts": {Kind: Bool},
+			"truncate":         {Kind: String, Values: filetree.TruncateModes},
+		}),
+		"layer": section(map[string]*Field{
+			"show-aggregated-changes": {Kind: Bool},
+			"show-empty-layers":       {Kind: Bool},
+		}),
+		"preview": section(map[string]*Field{
+			"graphics": {Kind: String, Values: []string{"auto", "kitty", "sixel", "none"}},
+		}),
+		"pivot": section(map[string]*Field{
+			"export-file": {Kind: String},
+		}),
+		"slim": section(map[string]*Field{
+			"manifest-file": {Kind: String},
+			"dockerfile":    {Kind: String},
+		}),
+		"screenshot": section(map[string]*Field{
+			"format": {Kind: String, Values: terminal.ScreenshotFormats},
+			"dir":    {Kind: String},
+		}),
+		"render": section(map[string]*Field{
+			"size":   {Kind: String, Check: checkSnapshotSize},
+			"format": {Kind: String, Values: terminal.ScreenshotFormats},
+		}),
+		"inspect": section(map[string]*Field{
+			"inspectors": {Kind: List, Values: inspectors},
+		}),
+		"audit": section(map[string]*Field{
+			"enabled":              {Kind: Bool},
+			"app-dirs":             {Kind: List},
+			"allowed-capabilities": {Kind: List},
+		}),
+		"duplicates": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"io": section(map[string]*Field{
+			"concurrency": {Kind: Number, Check: checkConcurrency},
+			"bandwidth":   {Kind: String, Check: checkIOBandwidth},
+			"iops":        {Kind: Number, Check: checkIOPS},
+		}),
+		"limits": section(map[string]*Field{
+			"max-files":  {Kind: Number, Check: checkMaxFiles},
+			"max-memory": {Kind: String, Check: checkMaxMemory},
+		}),
+		"cache": section(map[string]*Field{
+			"layers": {Kind: Bool},
+			"dir":    {Kind: String},
+		}),
+		"requests": section(map[string]*Field{
+			"timeout": {Kind: Duration, Check: checkRequestTimeout},
+			"retries": {Kind: Number, Check: checkRequestRetries},
+			"backoff": {Kind: Duration, Check: checkRequestBackoff},
+		}),
+		"registry": section(map[string]*Field{
+			"insecure":    {Kind: List},
+			"ca":          {Kind: String},
+			"client-cert": {Kind: String},
+			"client-key":  {Kind: String},
+		}),
+		"userns": section(map[string]*Field{
+			"uid-map": {Kind: String, Check: checkIDMap},
+			"gid-map": {Kind: String, Check: checkIDMap},
+		}),
+		"pull": section(map[string]*Field{
+			"enabled":            {Kind: Bool},
+			"bandwidth":          {Kind: String, Check: checkBandwidth},
+			"layer-latency":      {Kind: String, Check: checkLayerLatency},
+			"policy":             {Kind: String, Values: pullPolicyNames()},
+			"compare-bandwidths": {Kind: List, Check: checkBandwidth},
+			"monthly-pulls":      {Kind: Number, Check: checkMonthlyPulls},
+			"egress-price":       {Kind: Number, Check: checkEgressPrice},
+		}),
+		"results": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+			"path":    {Kind: String},
+		}),
+		"hooks": hooksSection(),
+		"signature": section(map[string]*Field{
+			"verify":                  {Kind: Bool},
+			"key":                     {Kind: String},
+			"certificate-identity":    {Kind: String},
+			"certificate-oidc-issuer": {Kind: String},
+			"required":                {Kind: Bool},
+		}),
+		"packages": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"dependencies": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"storage": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"compression": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"ownership": section(map[string]*Field{
+			"enabled": {Kind: Bool},
+		}),
+		"vulnerabilities": section(map[string]*Field{
+			"report": {Kind: String},
+		}),
+		"ui": section(map[string]*Field{
+			"color":        {Kind: String, Values: []string{"auto", "none", "8", "256", "truecolor", "24bit"}},
+			"glyphs":       {Kind: String, Values: []string{"auto", "unicode", "ascii"}},
+			"diff-markers": {Kind: String, Values: []string{"auto", "always", "never"}},
+			"initial-view": {Kind: String, Values: []string{"layer", "filetree"}},
+		}),
+	}
+}
+
+// rulesSection describes the CI rules, checked the same way the rules check their configuration.
+func rulesSection() *Field {
+	fields := make(map[string]*Field)
+	for _, key := range ci.RuleKeys() {
+		key := key
+		kind := String
+		if ci.IsListRule(key) {
+			// every value of the list is checked on its own
+			kind = List
+		}
+		fields[key] = &Field{Kind: kind, Check: func(value string) error {
+			return ci.ValidateRule(key, value)
+		}}
+	}
+	return section(fields)
+}
+
+func section(fields map[string]*Field) *Field {
+	return &Field{Kind: Section, Fields: fields}
+}
+
+func checkSource(value string) error {
+	if dive.ParseImageSource(value) == dive.SourceUnknown {
+		return fmt.Errorf("unknown image source %q (expected %s)", value, orList(dive.ImageSources))
+	}
+	return nil
+}
+
+func checkLogLevel(value string) error {
+	_, err := logrus.ParseLevel(value)
+	return err
+}
+
+func checkPaneWidth(value string) error {
+	width, _ := strconv.ParseFloat(value, 64)
+	if width <= 0 || width >= 1 {
+		return fmt.Errorf("the pane width is a ratio of the screen width (0 < value < 1), given %s", value)
+	}
+	return nil
+}
+
+func checkRegex(value string) error {
+	_, err := regexp.Compile(value)
+	return err
+}
+
+func checkSnapshotSize(value string) error {
+	_, _, err := terminal.ParseSnapshotSize(value)
+	return err
+}
+
+func pullPolicyNames() []string {
+	names := make([]string, 0, len(image.PullPolicies))
+	for _, policy := range image.PullPolicies {
+		names = append(names, string(policy))
+	}
+	return names
+}
+
+func checkBandwidth(value string) error {
+	_, err := image.ParseBandwidthProfile(value, image.DefaultPullLayerLatency)
+	return err
+}
+
+func checkConcurrency(value string) error {
+	concurrency, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("the concurrency is a whole number, given %s", value)
+	}
+	_, err = image.ParseIOLimits(concurrency, "", 0)
+	return err
+}
+
+func checkIOBandwidth(value string) error {
+	_, err := image.ParseIOLimits(1, value, 0)
+	return err
+}
+
+func checkMaxFiles(value string) error {
+	maxFiles, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("the most files is a whole number, given %s", value)
+	}
+	_, err = image.ParseAnalysisBounds(maxFiles, "")
+	return err
+}
+
+func checkMaxMemory(value string) error {
+	_, err := image.ParseAnalysisBounds(0, value)
+	return err
+}
+
+func checkIOPS(value string) error {
+	iops, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("the reads per second is a whole number, given %s", value)
+	}
+	_, err = image.ParseIOLimits(1, "", iops)
+	return err
+}
+
+func checkRequestTimeout(value string) error {
+	_, err := image.ParseOperationPolicy(value, 0, "")
+	return err
+}
+
+func checkRequestRetries(value string) error {
+	retries, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("the retries is a whole number, given %s", value)
+	}
+	_, err = image.ParseOperationPolicy("", retries, "")
+	return err
+}
+
+func checkRequestBackoff(value string) error {
+	_, err := image.ParseOperationPolicy("", 0, value)
+	return err
+}
+
+func checkIDMap(value string) error {
+	if value == filetree.IDMappingAuto {
+		return nil
+	}
+	_, err := filetree.ParseIDRanges(value)
+	return err
+}
+
+func checkMonthlyPulls(value string) error {
+	if pulls, err := strconv.Atoi(value); err != nil || pulls < 0 {
+		return fmt.Errorf("the pulls a month is a whole number (0 or more), given %s", value)
+	}
+	return nil
+}
+
+func checkEgressPrice(value string) error {
+	if price, err := strconv.ParseFloat(value, 64); err != nil || price < 0 {
+		return fmt.Errorf("the egress price is a price per GB (0 or more), given %s", value)
+	}
+	return nil
+}
+
+func checkLayerLatency(value string) error {
+	_, err := image.ParseBandwidthProfile(image.DefaultPullBandwidth, value)
+	return err
+}
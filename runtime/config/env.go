@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override settings.
+const EnvPrefix = "DIVE"
+
+// EnvKeyReplacer turns a key into the matching part of its environment variable name ("filetree.pane-width" is given
+// with DIVE_FILETREE_PANE_WIDTH).
+var EnvKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// EnvName returns the environment variable that overrides the given key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(EnvKeyReplacer.Replace(key))
+}
+
+// ValidateEnvironment checks the values of the environment variables (as given by os.Environ) that override settings
+// against the schema. Only settings taking a single value or a list can be given this way.
+func ValidateEnvironment(environ []string, schema *Field) []Problem {
+	fields, keys := envFields(schema)
+	var problems []Problem
+	for name, value := range envVariables(environ) {
+		if field, known := fields[name]; known {
+			v := &validator{file: name, schema: schema}
+			v.check(keys[name], &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, field)
+			problems = append(problems, v.problems...)
+		}
+	}
+	sortProblems(problems)
+	return problems
+}
+
+// UnknownEnvironment returns the environment variables with the settings prefix that match no setting (and so are
+// ignored), e.g. misspelled names. These are not rejected when dive starts, since the prefix is also used by others
+// (e.g. DIVE_VERSION in install scripts).
+func UnknownEnvironment(environ []string, schema *Field) []Problem {
+	fields, _ := envFields(schema)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []Problem
+	for name := range envVariables(environ) {
+		if _, known := fields[name]; !known {
+			problems = append(problems, Problem{File: name, Message: "unknown setting" + suggestEnv(name, names)})
+		}
+	}
+	sortProblems(problems)
+	return problems
+}
+
+// envFields finds the field (and key) of every environment variable that overrides a setting.
+func envFields(schema *Field) (map[string]*Field, map[string]string) {
+	fields := make(map[string]*Field)
+	keys := make(map[string]string)
+	collectKeys("", schema, func(key string, field *Field) {
+		switch field.Kind {
+		case Section, Entries, Items:
+			return
+		}
+		fields[EnvName(key)] = field
+		keys[EnvName(key)] = key
+	})
+	return fields, keys
+}
+
+// envVariables returns the environment variables with the settings prefix.
+func envVariables(environ []string) map[string]string {
+	variables := make(map[string]string)
+	for _, variable := range environ {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[0], EnvPrefix+"_") {
+			variables[parts[0]] = parts[1]
+		}
+	}
+	return variables
+}
+
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].File < problems[j].File
+	})
+}
+
+// suggestEnv points out the environment variable that was probably meant.
+func suggestEnv(name string, names []string) string {
+	// a third of the name (after the prefix) may be misspelled
+	best, bestDistance := "", (len(name)-len(EnvPrefix)-1)/3+1
+	for _, candidate := range names {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEnvName(t *testing.T) {
+	if name := EnvName("filetree.pane-width"); name != "DIVE_FILETREE_PANE_WIDTH" {
+		t.Errorf("unexpected environment variable %q", name)
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	environ := []string{
+		"HOME=/root",
+		"DIVE_FILETREE_PANE_WIDTH=1.5",
+		"DIVE_LOG_LEVEL=info",
+		"DIVE_LOG_LEVL=debug",
+		"DIVE_VERSION=0.12.0",
+		"DIVE_DIFF_HIDE=added,deleted",
+	}
+
+	assertProblems(t, ValidateEnvironment(environ, DiveSchema()), []string{
+		`DIVE_DIFF_HIDE: diff.hide: unknown value "deleted" (expected added, removed, modified or unmodified)`,
+		`DIVE_FILETREE_PANE_WIDTH: filetree.pane-width: the pane width is a ratio of the screen width (0 < value < 1), given 1.5`,
+	})
+	assertProblems(t, UnknownEnvironment(environ, DiveSchema()), []string{
+		`DIVE_LOG_LEVL: unknown setting (did you mean DIVE_LOG_LEVEL?)`,
+		`DIVE_VERSION: unknown setting`,
+	})
+}
+
+func TestDefaultConfig(t *testing.T) {
+	problems, err := Validate("default.yaml", DefaultConfig(), DiveSchema())
+	if err != nil {
+		t.Fatalf("unable to validate: %v", err)
+	}
+	assertProblems(t, problems, nil)
+
+	// every setting is documented within the default config (but the former name of a keybinding)
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(DefaultConfig(), &document); err != nil {
+		t.Fatalf("unable to read the default config: %v", err)
+	}
+	collectKeys("", section(settingsFields()), func(key string, field *Field) {
+		if key == "keybinding.toggle-unchanged-files" {
+			return
+		}
+		var value interface{} = document
+		for _, name := range strings.Split(key, ".") {
+			values, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			if value, ok = values[name]; !ok {
+				t.Errorf("expected %q to be in the default config", key)
+				return
+			}
+		}
+	})
+}
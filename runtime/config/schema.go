@@ -0,0 +1,358 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the type of value a config key takes.
+type Kind int
+
+const (
+	// String takes any single value (numbers and booleans are read as strings).
+	String Kind = iota
+	Bool
+	Number
+	Duration
+	// List takes a list of strings, or a single string of values separated by commas or spaces.
+	List
+	// Section takes the nested keys given by Fields.
+	Section
+	// Entries takes keys chosen by the user (e.g. image patterns), each with a value described by Elem.
+	Entries
+	// Items takes a list of values described by Elem.
+	Items
+)
+
+// Field describes the value of a config key.
+type Field struct {
+	Kind Kind
+	// the accepted values of a string (or of every value of a list), empty for any value
+	Values []string
+	// checks a string value further (e.g. that a size can be parsed)
+	Check func(value string) error
+	// the keys of a section
+	Fields map[string]*Field
+	// the value of every entry or item
+	Elem *Field
+	// the key of a section that can also be given as a single value (e.g. an image name instead of an image section)
+	Shorthand string
+}
+
+// Problem is an unknown key or an invalid value within a config file (or an environment variable, which has no line),
+// along with where it was given.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.Key == "":
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.Line == 0:
+		// an environment variable
+		return fmt.Sprintf("%s: %s: %s", p.File, p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", p.File, p.Line, p.Column, p.Key, p.Message)
+}
+
+// the plain words that the config reader (YAML 1.1) reads as booleans
+var yamlBooleans = map[string]bool{"y": true, "yes": true, "on": true, "n": true, "no": true, "off": true}
+
+// Validate checks the given yaml config against the schema, returning every unknown key and invalid value (in the
+// order they are given). Keys are not case sensitive, as with the config reader. An error is returned when the file
+// is not valid yaml.
+func Validate(file string, content []byte, schema *Field) ([]Problem, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(document.Content) == 0 {
+		// an empty file
+		return nil, nil
+	}
+	v := &validator{file: file, schema: schema}
+	v.check("", document.Content[0], schema)
+	return v.problems, nil
+}
+
+type validator struct {
+	file     string
+	schema   *Field
+	problems []Problem
+}
+
+func (v *validator) report(node *yaml.Node, key, message string, args ...interface{}) {
+	if key == "" {
+		key = "(top level)"
+	}
+	v.problems = append(v.problems, Problem{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Key:     key,
+		Message: fmt.Sprintf(message, args...),
+	})
+}
+
+func (v *validator) check(key string, node *yaml.Node, field *Field) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	// an empty value leaves the key unset
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	switch field.Kind {
+	case Section:
+		if node.Kind == yaml.ScalarNode && field.Shorthand != "" {
+			v.check(key, node, field.Fields[field.Shorthand])
+			return
+		}
+		v.checkMapping(key, node, func(name string, keyNode, valueNode *yaml.Node) {
+			child, canonical := lookup(field.Fields, name)
+			if child == nil {
+				v.report(keyNode, join(key, name), "unknown key%s", v.suggest(key, name, field.Fields))
+				return
+			}
+			v.check(join(key, canonical), valueNode, child)
+		})
+	case Entries:
+		v.checkMapping(key, node, func(name string, keyNode, valueNode *yaml.Node) {
+			v.check(join(key, name), valueNode, field.Elem)
+		})
+	case Items:
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, key, "expected a list, got %s", describe(node))
+			return
+		}
+		for idx, item := range node.Content {
+			v.check(fmt.Sprintf("%s[%d]", key, idx), item, field.Elem)
+		}
+	case List:
+		switch node.Kind {
+		case yaml.ScalarNode:
+			for _, value := range strings.FieldsFunc(node.Value, func(r rune) bool { return r == ',' || r == ' ' }) {
+				v.checkValue(key, node, field, value)
+			}
+		case yaml.SequenceNode:
+			for idx, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					v.report(item, fmt.Sprintf("%s[%d]", key, idx), "expected a single value, got %s", describe(item))
+					continue
+				}
+				v.checkValue(fmt.Sprintf("%s[%d]", key, idx), item, field, item.Value)
+			}
+		default:
+			v.report(node, key, "expected a list, got %s", describe(node))
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.report(node, key, "expected %s, got %s", expected(field.Kind), describe(node))
+			return
+		}
+		v.checkScalar(key, node, field)
+	}
+}
+
+// checkMapping calls the visitor with every key of a section, following merged sections ("<<: *anchor").
+func (v *validator) checkMapping(key string, node *yaml.Node, visitor func(name string, keyNode, valueNode *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		v.report(node, key, "expected a section of keys, got %s", describe(node))
+		return
+	}
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		keyNode, valueNode := node.Content[idx], node.Content[idx+1]
+		if keyNode.Tag == "!!merge" {
+			merged := valueNode
+			if merged.Kind == yaml.AliasNode {
+				merged = merged.Alias
+			}
+			v.checkMapping(key, merged, visitor)
+			continue
+		}
+		visitor(keyNode.Value, keyNode, valueNode)
+	}
+}
+
+func (v *validator) checkScalar(key string, node *yaml.Node, field *Field) {
+	value := node.Value
+	switch field.Kind {
+	case Bool:
+		if node.Tag == "!!bool" || yamlBooleans[strings.ToLower(value)] {
+			return
+		}
+		if _, err := strconv.ParseBool(value); err != nil {
+			v.report(node, key, "expected true or false, got %q", value)
+		}
+	case Number:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			v.report(node, key, "expected a number, got %q", value)
+			return
+		}
+		v.checkValue(key, node, field, value)
+	case Duration:
+		if node.Tag == "!!int" {
+			return
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			v.report(node, key, "expected a duration (e.g. 30s or 5m), got %q", value)
+		}
+	default:
+		v.checkValue(key, node, field, value)
+	}
+}
+
+// checkValue checks a single value against the accepted values of the field.
+func (v *validator) checkValue(key string, node *yaml.Node, field *Field, value string) {
+	if len(field.Values) > 0 {
+		accepted := false
+		for _, candidate := range field.Values {
+			if strings.EqualFold(candidate, value) {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			v.report(node, key, "unknown value %q (expected %s)", value, orList(field.Values))
+			return
+		}
+	}
+	if field.Check != nil {
+		if err := field.Check(value); err != nil {
+			v.report(node, key, "%v", err)
+		}
+	}
+}
+
+// suggest points out the key that was probably meant: a similarly spelled key within the same section, or the same
+// key within another section.
+func (v *validator) suggest(section, name string, fields map[string]*Field) string {
+	candidates := make([]string, 0, len(fields))
+	for candidate := range fields {
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+
+	// a third of the key may be misspelled
+	best, bestDistance := "", len(name)/3+1
+	for _, candidate := range candidates {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf(" (did you mean %q?)", join(section, best))
+	}
+
+	var elsewhere []string
+	collectKeys("", v.schema, func(key string, _ *Field) {
+		if parts := strings.Split(key, "."); strings.EqualFold(parts[len(parts)-1], name) {
+			elsewhere = append(elsewhere, key)
+		}
+	})
+	if len(elsewhere) > 0 {
+		sort.Strings(elsewhere)
+		return fmt.Sprintf(" (did you mean %q?)", elsewhere[0])
+	}
+	return ""
+}
+
+// collectKeys visits the keys of every section of the schema (not the keys chosen by the user).
+func collectKeys(prefix string, field *Field, visitor func(key string, field *Field)) {
+	for name, child := range field.Fields {
+		key := join(prefix, name)
+		visitor(key, child)
+		collectKeys(key, child, visitor)
+	}
+}
+
+// lookup finds the field of the given key (not case sensitive), along with its name as given in the schema.
+func lookup(fields map[string]*Field, name string) (*Field, string) {
+	if field, ok := fields[name]; ok {
+		return field, name
+	}
+	for candidate, field := range fields {
+		if strings.EqualFold(candidate, name) {
+			return field, candidate
+		}
+	}
+	return nil, ""
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func expected(kind Kind) string {
+	switch kind {
+	case Bool:
+		return "true or false"
+	case Number:
+		return "a number"
+	case Duration:
+		return "a duration"
+	}
+	return "a single value"
+}
+
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a section of keys"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+func orList(values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
+
+// editDistance is the number of single character edits between two strings (the Levenshtein distance).
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func problemStrings(problems []Problem) []string {
+	result := make([]string, len(problems))
+	for idx, problem := range problems {
+		result[idx] = problem.String()
+	}
+	return result
+}
+
+func assertProblems(t *testing.T, actual []Problem, expected []string) {
+	t.Helper()
+	lines := problemStrings(actual)
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected problems:\n  %s\ngot:\n  %s", strings.Join(expected, "\n  "), strings.Join(lines, "\n  "))
+	}
+}
+
+func TestValidateDiveConfig(t *testing.T) {
+	content := `
+log:
+  enabled: yes
+  level: loud
+keybinding:
+  quit: ctrl+c
+  toggle-view: ctrl+nope
+Filetree:
+  colapse-dir: true
+  pane-width: 1.5
+  show-attributes: maybe
+  ignore-paths: [/var/cache]
+diff:
+  hide: added, deleted
+pane-width: 0.3
+ui:
+  color: [auto]
+  initial-view: sidebar
+images:
+  "*/nginx*":
+    filetree:
+      collapse-dir: true
+    rules:
+      lowestEfficiency: 2
+      lowestEficiency: 0.9
+fleet:
+  interval: 5 minutes
+  images:
+    - alpine:latest
+    - image: busybox
+      source: tarball
+`
+	problems, err := Validate(".dive.yaml", []byte(content), DiveSchema())
+	if err != nil {
+		t.Fatalf("unable to validate: %v", err)
+	}
+	assertProblems(t, problems, []string{
+		`.dive.yaml:4:10: log.level: not a valid logrus Level: "loud"`,
+		`.dive.yaml:7:16: keybinding.toggle-view: could not parse keybinding 'ctrl+nope' from request 'ctrl+nope': unsupported keybinding: KeyCtrlNope`,
+		`.dive.yaml:9:3: filetree.colapse-dir: unknown key (did you mean "filetree.collapse-dir"?)`,
+		`.dive.yaml:10:15: filetree.pane-width: the pane width is a ratio of the screen width (0 < value < 1), given 1.5`,
+		`.dive.yaml:11:20: filetree.show-attributes: expected true or false, got "maybe"`,
+		`.dive.yaml:14:9: diff.hide: unknown value "deleted" (expected added, removed, modified or unmodified)`,
+		`.dive.yaml:15:1: pane-width: unknown key (did you mean "filetree.pane-width"?)`,
+		`.dive.yaml:17:10: ui.color: expected a single value, got a list`,
+		`.dive.yaml:18:17: ui.initial-view: unknown value "sidebar" (expected layer or filetree)`,
+		`.dive.yaml:24:25: images.*/nginx*.rules.lowestEfficiency: lowestEfficiency config value is outside allowed range (0-1), given '2'`,
+		`.dive.yaml:25:7: images.*/nginx*.rules.lowestEficiency: unknown key (did you mean "images.*/nginx*.rules.lowestEfficiency"?)`,
+		`.dive.yaml:27:13: fleet.interval: expected a duration (e.g. 30s or 5m), got "5 minutes"`,
+		`.dive.yaml:31:15: fleet.images[1].source: unknown image source "tarball" (expected docker, podman, docker-archive, containerd, registry or bundle)`,
+	})
+}
+
+func TestValidateCiConfig(t *testing.T) {
+	content := `
+rules:
+  lowestEfficiency: 0.95
+  highestWastedBytes: 20MB
+  highestUserWastedPercent: disabled
+  forbidDuplicateArtifacts: true
+  higestWastedBytes: 10MB
+  forbiddenContent:
+    - "**/.git/**"
+    - "/var/cache/[**"
+`
+	problems, err := Validate(".dive-ci", []byte(content), CiSchema())
+	if err != nil {
+		t.Fatalf("unable to validate: %v", err)
+	}
+	assertProblems(t, problems, []string{
+		`.dive-ci:7:3: rules.higestWastedBytes: unknown key (did you mean "rules.highestWastedBytes"?)`,
+		`.dive-ci:10:7: rules.forbiddenContent[1]: invalid config value ('/var/cache/[**'): syntax error in pattern`,
+	})
+}
+
+func TestValidateValidConfig(t *testing.T) {
+	content := `
+defaults: &defaults
+  collapse-dir: true
+ignore-errors: false
+filetree:
+  <<: *defaults
+  filter: "^/etc"
+log:
+  path:
+keybinding:
+  toggle-mark: m
+  filter-files: ctrl+f, ctrl+slash
+inspect:
+  inspectors: []
+`
+	problems, err := Validate(".dive.yaml", []byte(content), DiveSchema())
+	if err != nil {
+		t.Fatalf("unable to validate: %v", err)
+	}
+	// the anchor itself is not a known key, though the merged keys are
+	assertProblems(t, problems, []string{
+		`.dive.yaml:2:1: defaults: unknown key`,
+	})
+
+	if problems, err := Validate(".dive.yaml", nil, DiveSchema()); err != nil || len(problems) != 0 {
+		t.Errorf("expected an empty config to be valid, got %v (%v)", problems, err)
+	}
+}
+
+func TestValidateInvalidYaml(t *testing.T) {
+	if _, err := Validate(".dive.yaml", []byte("log:\n  level: [info\n"), DiveSchema()); err == nil {
+		t.Errorf("expected an error for invalid yaml")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "abc", 3},
+		{"collapse-dir", "colapse-dir", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range cases {
+		if actual := editDistance(test.a, test.b); actual != test.expected {
+			t.Errorf("%s -> %s: expected %d, got %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/runtime/results"
+	"github.com/wagoodman/dive/utils"
+)
+
+// how often the fleet is re-analyzed when the config does not say
+const defaultFleetInterval = time.Hour
+
+// FleetConfig is the set of images the daemon re-analyzes periodically, as given in the "fleet" section of the config:
+//
+//	fleet:
+//	  interval: 6h
+//	  report-dir: /var/lib/dive/reports
+//	  images:
+//	    - registry.example.com/app:latest
+//	    - image: alpine:3.19
+//	      source: podman
+//	  rules:
+//	    lowestEfficiency: 0.95
+type FleetConfig struct {
+	Interval time.Duration
+	// where the report of every run is stored (reports are not stored when empty)
+	ReportDir string
+	Images    []imageParams
+	// the CI rules every analysis is evaluated against
+	Rules *viper.Viper
+}
+
+// NewFleetConfig reads the "fleet" section of the given config (nil when there is none). The rules given in the
+// section override the given rules (the CI rules the command line was configured with).
+func NewFleetConfig(config *viper.Viper, rules *viper.Viper) (*FleetConfig, error) {
+	if !config.IsSet("fleet") {
+		return nil, nil
+	}
+
+	fleet := &FleetConfig{
+		Interval:  defaultFleetInterval,
+		ReportDir: config.GetString("fleet.report-dir"),
+		Rules:     rules,
+	}
+	if config.IsSet("fleet.interval") {
+		fleet.Interval = config.GetDuration("fleet.interval")
+		if fleet.Interval <= 0 {
+			return nil, fmt.Errorf("invalid fleet interval: %q", config.GetString("fleet.interval"))
+		}
+	}
+
+	entries, ok := config.Get("fleet.images").([]interface{})
+	if !ok || len(entries) == 0 {
+		return nil, fmt.Errorf("no fleet images configured")
+	}
+	for idx, entry := range entries {
+		switch value := entry.(type) {
+		case string:
+			fleet.Images = append(fleet.Images, imageParams{Image: value})
+		case map[string]interface{}, map[interface{}]interface{}:
+			fields := cast.ToStringMapString(value)
+			if fields["image"] == "" {
+				return nil, fmt.Errorf("fleet image %d has no 'image' given", idx)
+			}
+			fleet.Images = append(fleet.Images, imageParams{Image: fields["image"], Source: fields["source"]})
+		default:
+			return nil, fmt.Errorf("invalid fleet image %d: %v", idx, entry)
+		}
+	}
+
+	for key, value := range config.GetStringMapString("fleet.rules") {
+		fleet.Rules.Set("rules."+key, value)
+	}
+	return fleet, nil
+}
+
+// FleetStatus is the outcome of the last analysis of a fleet image.
+type FleetStatus struct {
+	Image       string            `json:"image"`
+	AnalyzedAt  time.Time         `json:"analyzedAt"`
+	Error       string            `json:"error,omitempty"`
+	Pass        bool              `json:"pass"`
+	Policy      []ci.PolicyResult `json:"policy"`
+	SizeBytes   uint64            `json:"sizeBytes"`
+	WastedBytes uint64            `json:"inefficientBytes"`
+	Efficiency  float64           `json:"efficiencyScore"`
+	// the report stored for the last analysis (empty when reports are not stored)
+	Report string `json:"report,omitempty"`
+	// the number of runs the image could not be analyzed in
+	Errors int `json:"errors"`
+}
+
+// Fleet re-analyzes the configured images periodically, evaluates them against the CI rules and stores the reports.
+// The analyses are kept by the server, so they are also served by the RPC API.
+type Fleet struct {
+	server   *Server
+	config   *FleetConfig
+	lock     sync.Mutex
+	statuses map[string]*FleetStatus
+	now      func() time.Time
+	// where every analysis is recorded (nil when not recorded)
+	results *results.Store
+}
+
+// NewFleet creates the scheduler for the given fleet; the server serves its results (see Server.SetFleet).
+func NewFleet(server *Server, config *FleetConfig) *Fleet {
+	return &Fleet{
+		server:   server,
+		config:   config,
+		statuses: make(map[string]*FleetStatus),
+		now:      time.Now,
+	}
+}
+
+// SetResults records every analysis of the fleet in the given results database (see "dive query").
+func (f *Fleet) SetResults(store *results.Store) {
+	f.results = store
+}
+
+// Run analyzes the fleet right away and then at every interval, until the context is done.
+func (f *Fleet) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		f.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce analyzes every image of the fleet once.
+func (f *Fleet) RunOnce(ctx context.Context) {
+	for _, params := range f.config.Images {
+		if ctx.Err() != nil {
+			return
+		}
+		f.analyze(ctx, params)
+	}
+}
+
+func (f *Fleet) analyze(ctx context.Context, params imageParams) {
+	f.lock.Lock()
+	status, exists := f.statuses[params.Image]
+	if !exists {
+		status = &FleetStatus{Image: params.Image}
+		f.statuses[params.Image] = status
+	}
+	f.lock.Unlock()
+
+	analyzedAt := f.now()
+	entry, rpcErr := f.server.analyze(ctx, params, true)
+	if rpcErr != nil {
+		logrus.Errorf("unable to analyze %s: %s", params.Image, rpcErr.Message)
+		f.lock.Lock()
+		status.AnalyzedAt = analyzedAt
+		status.Error = rpcErr.Message
+		status.Pass = false
+		status.Errors++
+		f.lock.Unlock()
+		return
+	}
+	analysis := entry.analysis
+
+	evaluator := ci.NewCiEvaluator(f.config.Rules)
+	pass := evaluator.Evaluate(analysis)
+
+	policy := evaluator.Policy()
+
+	if f.results != nil {
+		if err := f.results.Record(params.Image, analyzedAt, analysis); err != nil {
+			logrus.Errorf("unable to record the analysis of %s: %+v", params.Image, err)
+		}
+	}
+
+	report, err := f.storeReport(params.Image, analyzedAt, pass, policy, export.NewExport(analysis))
+	if err != nil {
+		logrus.Errorf("unable to store the report of %s: %+v", params.Image, err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	status.AnalyzedAt = analyzedAt
+	status.Error = ""
+	status.Pass = pass
+	status.Policy = policy
+	status.SizeBytes = analysis.SizeBytes
+	status.WastedBytes = analysis.WastedBytes
+	status.Efficiency = analysis.Efficiency
+	status.Report = report
+}
+
+// storeReport writes the analysis and the policy results to <report-dir>/<image>/<time>.json and returns the path.
+func (f *Fleet) storeReport(image string, analyzedAt time.Time, pass bool, policy []ci.PolicyResult, analysis interface{}) (string, error) {
+	if f.config.ReportDir == "" {
+		return "", nil
+	}
+
+	dir := filepath.Join(f.config.ReportDir, utils.ReferenceFileName(image))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	content, err := json.MarshalIndent(struct {
+		Image      string            `json:"image"`
+		AnalyzedAt time.Time         `json:"analyzedAt"`
+		Pass       bool              `json:"pass"`
+		Policy     []ci.PolicyResult `json:"policy"`
+		Analysis   interface{}       `json:"analysis"`
+	}{image, analyzedAt, pass, policy, analysis}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, analyzedAt.UTC().Format("20060102T150405Z")+".json")
+	return path, ioutil.WriteFile(path, content, 0644)
+}
+
+// Statuses returns the outcome of the last analysis of every image analyzed so far, ordered by image.
+func (f *Fleet) Statuses() []FleetStatus {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	statuses := make([]FleetStatus, 0, len(f.statuses))
+	for _, status := range f.statuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Image < statuses[j].Image
+	})
+	return statuses
+}
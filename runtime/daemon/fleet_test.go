@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func testRules() *viper.Viper {
+	rules := viper.New()
+	rules.Set("rules.lowestEfficiency", "0.9")
+	rules.Set("rules.highestWastedBytes", "disabled")
+	rules.Set("rules.highestAppWastedBytes", "disabled")
+	rules.Set("rules.highestUserWastedPercent", "0.1")
+	rules.Set("rules.highestFileCount", "disabled")
+	rules.Set("rules.highestLayerFileCount", "disabled")
+	rules.Set("rules.forbidCorruptLayers", "disabled")
+	rules.Set("rules.forbidDuplicateArtifacts", "disabled")
+	rules.Set("rules.forbidSetuidFiles", "disabled")
+	rules.Set("rules.forbidWorldWritableFiles", "disabled")
+	rules.Set("rules.forbidRootOwnedAppFiles", "disabled")
+	rules.Set("rules.forbidUnexpectedCapabilities", "disabled")
+	return rules
+}
+
+func TestNewFleetConfig(t *testing.T) {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	err := config.ReadConfig(bytes.NewBufferString(`
+fleet:
+  interval: 30m
+  report-dir: /tmp/reports
+  images:
+    - registry.example.com/app:latest
+    - image: alpine:3.19
+      source: podman
+  rules:
+    highestUserWastedPercent: disabled
+`))
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	fleet, err := NewFleetConfig(config, testRules())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fleet.Interval != 30*time.Minute || fleet.ReportDir != "/tmp/reports" {
+		t.Errorf("unexpected fleet config: %+v", fleet)
+	}
+	expected := []imageParams{{Image: "registry.example.com/app:latest"}, {Image: "alpine:3.19", Source: "podman"}}
+	if len(fleet.Images) != len(expected) || fleet.Images[0] != expected[0] || fleet.Images[1] != expected[1] {
+		t.Errorf("expected images %+v, got %+v", expected, fleet.Images)
+	}
+	if value := fleet.Rules.GetString("rules.highestUserWastedPercent"); value != "disabled" {
+		t.Errorf("expected the fleet rules to override the CI rules, got %q", value)
+	}
+
+	fleet, err = NewFleetConfig(viper.New(), testRules())
+	if fleet != nil || err != nil {
+		t.Errorf("expected no fleet without a fleet section, got %+v (%v)", fleet, err)
+	}
+
+	config = viper.New()
+	config.Set("fleet.interval", "1h")
+	if _, err = NewFleetConfig(config, testRules()); err == nil {
+		t.Errorf("expected an error for a fleet without images")
+	}
+}
+
+func TestFleetRunOnce(t *testing.T) {
+	reportDir, err := ioutil.TempDir("", "dive-fleet")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(reportDir)
+
+	server := testServer()
+	fleet := NewFleet(server, &FleetConfig{
+		Interval:  time.Hour,
+		ReportDir: reportDir,
+		Images:    []imageParams{{Image: "dive-example"}},
+		Rules:     testRules(),
+	})
+	fleet.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	server.SetFleet(fleet)
+
+	fleet.RunOnce(context.Background())
+
+	statuses := fleet.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 status, got %+v", statuses)
+	}
+	status := statuses[0]
+	if status.Pass || status.Error != "" || status.WastedBytes != 38430 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	var failed []string
+	for _, result := range status.Policy {
+		if result.Status == "fail" {
+			failed = append(failed, result.Rule)
+		}
+	}
+	if len(failed) != 1 || failed[0] != "highestUserWastedPercent" {
+		t.Errorf("unexpected failed rules: %v", failed)
+	}
+	if !strings.HasSuffix(status.Report, "dive-example/20240102T030405Z.json") {
+		t.Errorf("unexpected report path: %q", status.Report)
+	}
+	if _, err := os.Stat(status.Report); err != nil {
+		t.Errorf("expected the report to be stored: %v", err)
+	}
+
+	// the fleet analysis is served by the API...
+	response := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"fleet"}`)
+	if results, ok := response["result"].([]interface{}); !ok || len(results) != 1 {
+		t.Errorf("unexpected fleet result: %v", response)
+	}
+
+	// ...and as metrics
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := recorder.Body.String()
+	for _, line := range []string{
+		`dive_image_inefficient_bytes{image="dive-example"} 38430`,
+		`dive_image_policy_pass{image="dive-example"} 0`,
+		`dive_image_last_run_timestamp_seconds{image="dive-example"} 1704164645`,
+		`dive_image_analysis_errors_total{image="dive-example"} 0`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("expected metric %q in:\n%s", line, metrics)
+		}
+	}
+}
+
+func TestServer_NoFleet(t *testing.T) {
+	server := testServer()
+
+	response := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"fleet"}`)
+	rpcErr := response["error"].(map[string]interface{})
+	if int(rpcErr["code"].(float64)) != codeInvalidRequest {
+		t.Errorf("unexpected error code: %v", rpcErr["code"])
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected no metrics endpoint, got %d", recorder.Code)
+	}
+}
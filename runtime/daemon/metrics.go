@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// serveMetrics writes the outcome of the last fleet analyses in the Prometheus text exposition format.
+func (s *Server) serveMetrics(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(writer, s.fleet.Statuses())
+}
+
+func writeMetrics(writer io.Writer, statuses []FleetStatus) {
+	gauge := func(name, help string, value func(FleetStatus) (float64, bool)) {
+		fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, status := range statuses {
+			if v, ok := value(status); ok {
+				fmt.Fprintf(writer, "%s{image=%s} %s\n", name, strconv.Quote(status.Image), strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+	}
+	analyzed := func(status FleetStatus) bool {
+		return status.Policy != nil
+	}
+
+	gauge("dive_image_efficiency", "The efficiency score of the image (0-1).", func(status FleetStatus) (float64, bool) {
+		return status.Efficiency, analyzed(status)
+	})
+	gauge("dive_image_size_bytes", "The total size of the image layers.", func(status FleetStatus) (float64, bool) {
+		return float64(status.SizeBytes), analyzed(status)
+	})
+	gauge("dive_image_inefficient_bytes", "The bytes wasted by files duplicated or removed across layers.", func(status FleetStatus) (float64, bool) {
+		return float64(status.WastedBytes), analyzed(status)
+	})
+	gauge("dive_image_policy_pass", "Whether the image passed the CI rules in the last run (1) or not (0).", func(status FleetStatus) (float64, bool) {
+		if status.Pass {
+			return 1, true
+		}
+		return 0, true
+	})
+	gauge("dive_image_last_run_timestamp_seconds", "When the image was last analyzed (or attempted).", func(status FleetStatus) (float64, bool) {
+		return float64(status.AnalyzedAt.Unix()), !status.AnalyzedAt.IsZero()
+	})
+
+	name := "dive_image_analysis_errors_total"
+	fmt.Fprintf(writer, "# HELP %s The number of runs the image could not be analyzed in.\n# TYPE %s counter\n", name, name)
+	for _, status := range statuses {
+		fmt.Fprintf(writer, "%s{image=%s} %d\n", name, strconv.Quote(status.Image), status.Errors)
+	}
+}
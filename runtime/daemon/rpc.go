@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSON-RPC 2.0 error codes (see https://www.jsonrpc.org/specification#error_object)
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	// implementation-defined server error: the image could not be fetched or analyzed
+	codeAnalysisError = -32000
+)
+
+const rpcVersion = "2.0"
+
+type rpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func newRpcError(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// imageParams identifies an image to analyze, using the same source notation as the CLI (e.g. "podman://alpine").
+type imageParams struct {
+	Image  string `json:"image"`
+	Source string `json:"source"`
+}
+
+// layerTreeParams selects the tree for a single layer of an image, either as the changes within that layer or
+// aggregated with all layers beneath it.
+type layerTreeParams struct {
+	imageParams
+	Layer      int  `json:"layer"`
+	Aggregated bool `json:"aggregated"`
+}
+
+// pathHistoryParams selects a path of an image to report the layers that added, modified or deleted it.
+type pathHistoryParams struct {
+	imageParams
+	Path string `json:"path"`
+}
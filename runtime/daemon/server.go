@@ -0,0 +1,281 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// Server exposes the analysis engine over a JSON-RPC 2.0 API (POST requests to /rpc). Analyses are kept in memory
+// so that following requests for the same image (e.g. browsing per-layer trees) do not re-fetch the image.
+type Server struct {
+	defaultSource dive.ImageSource
+	lock          sync.Mutex
+	analyses      map[string]*analysisEntry
+	resolve       func(dive.ImageSource) (image.Resolver, error)
+	// the options every image is analyzed with
+	analysisOptions image.AnalysisOptions
+	// the images analyzed periodically (nil when the daemon only serves requests)
+	fleet *Fleet
+}
+
+type analysisEntry struct {
+	// the image analyzed, closed when the entry is replaced
+	image    *image.Image
+	analysis *image.AnalysisResult
+	// the comparer memoizes trees, and may be used by concurrent requests
+	cache filetree.Comparer
+}
+
+// NewServer creates a server that fetches images from the given source unless a request specifies otherwise, with the
+// given options, and analyzes them with the given analysis options.
+func NewServer(defaultSource dive.ImageSource, options image.ResolverOptions, analysisOptions image.AnalysisOptions) *Server {
+	return &Server{
+		defaultSource: defaultSource,
+		analyses:      make(map[string]*analysisEntry),
+		resolve: func(source dive.ImageSource) (image.Resolver, error) {
+			return dive.GetImageResolver(source, options)
+		},
+		analysisOptions: analysisOptions,
+	}
+}
+
+// SetFleet serves the results of the given fleet: the "fleet" method and the /metrics endpoint.
+func (s *Server) SetFleet(fleet *Fleet) {
+	s.fleet = fleet
+}
+
+// Handler returns the HTTP handler serving the RPC endpoint (and the metrics endpoint when a fleet is analyzed).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rpc", s.serveRpc)
+	if s.fleet != nil {
+		mux.HandleFunc("/metrics", s.serveMetrics)
+	}
+	return mux
+}
+
+// ListenAndServe blocks serving the RPC API on the given address.
+func (s *Server) ListenAndServe(address string) error {
+	logrus.Infof("serving dive API on %s", address)
+	return http.ListenAndServe(address, s.Handler())
+}
+
+func (s *Server) serveRpc(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rpcReq rpcRequest
+	response := rpcResponse{Version: rpcVersion}
+
+	if err := json.NewDecoder(request.Body).Decode(&rpcReq); err != nil {
+		response.Error = newRpcError(codeParseError, "unable to parse request: %v", err)
+	} else if rpcReq.Version != rpcVersion || rpcReq.Method == "" {
+		response.ID = rpcReq.ID
+		response.Error = newRpcError(codeInvalidRequest, "not a JSON-RPC %s request", rpcVersion)
+	} else {
+		response.ID = rpcReq.ID
+		result, err := s.dispatch(request.Context(), rpcReq.Method, rpcReq.Params)
+		if err != nil {
+			response.Error = err
+		} else {
+			response.Result = result
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(&response); err != nil {
+		logrus.Errorf("unable to write rpc response: %+v", err)
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, method string, rawParams json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "analyze":
+		var params imageParams
+		if err := decodeParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		entry, err := s.analyze(ctx, params, false)
+		if err != nil {
+			return nil, err
+		}
+		return export.NewExport(entry.analysis), nil
+
+	case "layerTree":
+		var params layerTreeParams
+		if err := decodeParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		entry, err := s.analyze(ctx, params.imageParams, false)
+		if err != nil {
+			return nil, err
+		}
+		return layerTree(entry, params.Layer, params.Aggregated)
+
+	case "pathHistory":
+		var params pathHistoryParams
+		if err := decodeParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		if params.Path == "" {
+			return nil, newRpcError(codeInvalidParams, "no path given")
+		}
+		entry, err := s.analyze(ctx, params.imageParams, false)
+		if err != nil {
+			return nil, err
+		}
+		return export.NewPathProvenance(image.TracePath(entry.analysis.Layers, entry.analysis.RefTrees, params.Path)), nil
+
+	case "refresh":
+		var params imageParams
+		if err := decodeParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		entry, err := s.analyze(ctx, params, true)
+		if err != nil {
+			return nil, err
+		}
+		return export.NewExport(entry.analysis), nil
+
+	case "images":
+		return s.cachedImages(), nil
+
+	case "fleet":
+		if s.fleet == nil {
+			return nil, newRpcError(codeInvalidRequest, "no fleet is configured (see --config)")
+		}
+		return s.fleet.Statuses(), nil
+	}
+	return nil, newRpcError(codeMethodNotFound, "unknown method %q", method)
+}
+
+func decodeParams(rawParams json.RawMessage, params interface{}) *rpcError {
+	if len(rawParams) == 0 {
+		return newRpcError(codeInvalidParams, "missing params")
+	}
+	if err := json.Unmarshal(rawParams, params); err != nil {
+		return newRpcError(codeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// sourceAndImage resolves the image source in the same way the CLI does: an explicit scheme on the image wins,
+// then the "source" param, then the server default.
+func (s *Server) sourceAndImage(params imageParams) (dive.ImageSource, string, *rpcError) {
+	if params.Image == "" {
+		return dive.SourceUnknown, "", newRpcError(codeInvalidParams, "no image given")
+	}
+
+	sourceType, imageStr := dive.DeriveImageSource(params.Image)
+	if sourceType != dive.SourceUnknown {
+		return sourceType, imageStr, nil
+	}
+
+	sourceType = s.defaultSource
+	if params.Source != "" {
+		sourceType = dive.ParseImageSource(params.Source)
+		if sourceType == dive.SourceUnknown {
+			return dive.SourceUnknown, "", newRpcError(codeInvalidParams, "unable to determine image source: %v", params.Source)
+		}
+	}
+	return sourceType, params.Image, nil
+}
+
+func (s *Server) analyze(ctx context.Context, params imageParams, refresh bool) (*analysisEntry, *rpcError) {
+	sourceType, imageStr, rpcErr := s.sourceAndImage(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	key := sourceType.String() + "://" + imageStr
+
+	s.lock.Lock()
+	entry, exists := s.analyses[key]
+	s.lock.Unlock()
+	if exists && !refresh {
+		return entry, nil
+	}
+
+	resolver, err := s.resolve(sourceType)
+	if err != nil {
+		return nil, newRpcError(codeInternalError, "cannot determine image provider: %v", err)
+	}
+
+	logrus.Infof("analyzing %s", key)
+	img, err := resolver.Fetch(ctx, imageStr)
+	if err != nil {
+		return nil, newRpcError(codeAnalysisError, "cannot fetch image: %v", err)
+	}
+
+	analysis, err := img.AnalyzeWithOptions(ctx, s.analysisOptions)
+	if err != nil {
+		img.Close()
+		return nil, newRpcError(codeAnalysisError, "cannot analyze image: %v", err)
+	}
+
+	entry = &analysisEntry{
+		image:    img,
+		analysis: analysis,
+		cache:    filetree.NewComparer(analysis.RefTrees),
+	}
+
+	s.lock.Lock()
+	previous := s.analyses[key]
+	s.analyses[key] = entry
+	s.lock.Unlock()
+
+	// a refresh (e.g. by the fleet) replaces the analysis, releasing what the previous image holds (such as a spooled
+	// archive)
+	if previous != nil {
+		if err := previous.image.Close(); err != nil {
+			logrus.Warnf("unable to close the previous image of %s: %v", key, err)
+		}
+	}
+
+	return entry, nil
+}
+
+func (s *Server) cachedImages() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	images := make([]string, 0, len(s.analyses))
+	for key := range s.analyses {
+		images = append(images, key)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// layerTree returns the file tree for the given layer, using the same layer comparison boundaries as the TUI.
+func layerTree(entry *analysisEntry, layer int, aggregated bool) (interface{}, *rpcError) {
+	if layer < 0 || layer >= len(entry.analysis.RefTrees) {
+		return nil, newRpcError(codeInvalidParams, "invalid layer index given: %d of %d", layer, len(entry.analysis.RefTrees)-1)
+	}
+
+	var key filetree.TreeIndexKey
+	switch {
+	case layer == 0:
+		key = filetree.NewTreeIndexKey(0, 0, 0, 0)
+	case aggregated:
+		key = filetree.NewTreeIndexKey(0, 0, 1, layer)
+	default:
+		key = filetree.NewTreeIndexKey(0, layer-1, layer, layer)
+	}
+
+	tree, err := entry.cache.GetTree(key)
+	if err != nil {
+		return nil, newRpcError(codeInternalError, "unable to build layer tree: %v", err)
+	}
+	return export.NewFileTree(tree), nil
+}
@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+type testResolver struct{}
+
+func (r *testResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+	archive, err := docker.TestLoadArchive("../../.data/test-docker-image.tar")
+	if err != nil {
+		return nil, err
+	}
+	return archive.ToImage()
+}
+
+func (r *testResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func testServer() *Server {
+	server := NewServer(dive.SourceDockerEngine, image.ResolverOptions{}, image.DefaultAnalysisOptions())
+	server.resolve = func(dive.ImageSource) (image.Resolver, error) {
+		return &testResolver{}, nil
+	}
+	return server
+}
+
+func call(t *testing.T, server *Server, body string) map[string]interface{} {
+	request := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to decode response %q: %v", recorder.Body.String(), err)
+	}
+	return response
+}
+
+func TestServer_Analyze(t *testing.T) {
+	server := testServer()
+
+	response := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"analyze","params":{"image":"dive-example"}}`)
+	if response["error"] != nil {
+		t.Fatalf("unexpected error: %v", response["error"])
+	}
+	result := response["result"].(map[string]interface{})
+	layers := result["layer"].([]interface{})
+	if len(layers) != 14 {
+		t.Errorf("expected 14 layers, got %d", len(layers))
+	}
+
+	response = call(t, server, `{"jsonrpc":"2.0","id":2,"method":"images"}`)
+	images := response["result"].([]interface{})
+	if len(images) != 1 || images[0] != "docker://dive-example" {
+		t.Errorf("unexpected cached images: %v", images)
+	}
+}
+
+func TestServer_LayerTree(t *testing.T) {
+	server := testServer()
+
+	response := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"layerTree","params":{"image":"dive-example","layer":0}}`)
+	if response["error"] != nil {
+		t.Fatalf("unexpected error: %v", response["error"])
+	}
+	nodes := response["result"].([]interface{})
+	if len(nodes) == 0 {
+		t.Errorf("expected a populated tree")
+	}
+
+	response = call(t, server, `{"jsonrpc":"2.0","id":2,"method":"layerTree","params":{"image":"dive-example","layer":99}}`)
+	rpcErr := response["error"].(map[string]interface{})
+	if int(rpcErr["code"].(float64)) != codeInvalidParams {
+		t.Errorf("unexpected error code: %v", rpcErr["code"])
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	server := testServer()
+
+	cases := map[string]int{
+		`not json`: codeParseError,
+		`{"jsonrpc":"1.0","id":1,"method":"analyze"}`:                codeInvalidRequest,
+		`{"jsonrpc":"2.0","id":1,"method":"nope"}`:                   codeMethodNotFound,
+		`{"jsonrpc":"2.0","id":1,"method":"analyze"}`:                codeInvalidParams,
+		`{"jsonrpc":"2.0","id":1,"method":"analyze","params":{}}`:    codeInvalidParams,
+		`{"jsonrpc":"2.0","id":1,"method":"analyze","params":"bad"}`: codeInvalidParams,
+	}
+
+	for body, expected := range cases {
+		response := call(t, server, body)
+		rpcErr, ok := response["error"].(map[string]interface{})
+		if !ok {
+			t.Errorf("expected error for %q", body)
+			continue
+		}
+		if int(rpcErr["code"].(float64)) != expected {
+			t.Errorf("expected code %d for %q, got %v", expected, body, rpcErr["code"])
+		}
+	}
+}
+
+func TestServer_PathHistory(t *testing.T) {
+	server := testServer()
+
+	response := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"pathHistory","params":{"image":"dive-example","path":"/root/saved.txt"}}`)
+	if response["error"] != nil {
+		t.Fatalf("unexpected error: %v", response["error"])
+	}
+	result := response["result"].(map[string]interface{})
+	if result["present"] != true {
+		t.Errorf("expected the path to be present")
+	}
+	changes := result["changes"].([]interface{})
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	first := changes[0].(map[string]interface{})
+	if first["change"] != "added" || first["layer"] != float64(7) {
+		t.Errorf("unexpected first change: %v", first)
+	}
+
+	response = call(t, server, `{"jsonrpc":"2.0","id":2,"method":"pathHistory","params":{"image":"dive-example"}}`)
+	rpcErr := response["error"].(map[string]interface{})
+	if int(rpcErr["code"].(float64)) != codeInvalidParams {
+		t.Errorf("unexpected error code: %v", rpcErr["code"])
+	}
+}
+
+// closingResolver counts the images it fetched that were closed.
+type closingResolver struct {
+	testResolver
+	closed int
+}
+
+// trackedContents counts the closing of the image it reads the files of.
+type trackedContents struct {
+	image.ContentReader
+	resolver *closingResolver
+}
+
+func (c trackedContents) Close() error {
+	c.resolver.closed++
+	return nil
+}
+
+func (r *closingResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
+	img, err := r.testResolver.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	img.Contents = trackedContents{ContentReader: img.Contents, resolver: r}
+	return img, nil
+}
+
+func TestServer_RefreshClosesImage(t *testing.T) {
+	server := testServer()
+	resolver := &closingResolver{}
+	server.resolve = func(dive.ImageSource) (image.Resolver, error) {
+		return resolver, nil
+	}
+
+	params := imageParams{Image: "dive-example"}
+	if _, err := server.analyze(context.Background(), params, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := server.analyze(context.Background(), params, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolver.closed != 0 {
+		t.Errorf("expected the cached image to be kept open, %d closed", resolver.closed)
+	}
+
+	if _, err := server.analyze(context.Background(), params, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolver.closed != 1 {
+		t.Errorf("expected the replaced image to be closed, %d closed", resolver.closed)
+	}
+
+	// an image that cannot be analyzed is closed as well
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := server.analyze(ctx, imageParams{Image: "other"}, false); err == nil {
+		t.Fatalf("expected an error for a cancelled analysis")
+	}
+	if resolver.closed != 2 {
+		t.Errorf("expected the image that failed to be closed, %d closed", resolver.closed)
+	}
+}
@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of dependencies listed in the report
+const dependenciesReportMaxEntries = 20
+
+// dependenciesReport renders the size of the language runtime dependencies by ecosystem, and the largest of them.
+func dependenciesReport(dependencies *image.Dependencies) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Dependencies:"))
+
+	if dependencies.Empty() {
+		fmt.Fprintln(&sb, "  No node packages, python distributions or Go and Rust binaries found")
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+	for _, ecosystem := range dependencies.Ecosystems() {
+		fmt.Fprintf(&sb, "  %-8s %10s\n", ecosystem, humanize.Bytes(dependencies.SizeBytes(ecosystem)))
+	}
+	for idx, dependency := range dependencies.Dependencies {
+		if idx >= dependenciesReportMaxEntries {
+			fmt.Fprintf(&sb, "  ...and %d more\n", len(dependencies.Dependencies)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "  %10s  %-6s  layer %d  %s (%s)", humanize.Bytes(dependency.SizeBytes), dependency.Ecosystem, dependency.Layer, dependency.Label(), dependency.Path)
+		if dependency.Sections != "" {
+			fmt.Fprintf(&sb, ": %s", dependency.Sections)
+		}
+		fmt.Fprintln(&sb)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
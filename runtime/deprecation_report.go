@@ -0,0 +1,19 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// deprecationReport renders the legacy image features that should be modernized before registries drop support.
+func deprecationReport(deprecations []image.Deprecation) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Deprecation Warnings:"))
+	for _, deprecation := range deprecations {
+		fmt.Fprintf(&sb, "  WARN: %s\n", deprecation)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
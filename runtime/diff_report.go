@@ -0,0 +1,49 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// DiffReport renders the diff of two analyses, listing at most limit directories and files (all of them when the
+// limit is 0).
+func DiffReport(diff *image.AnalysisDiff, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Image Diff:"))
+	fmt.Fprintf(&sb, "  size: %s -> %s (%s)\n", humanize.Bytes(diff.PreviousSizeBytes), humanize.Bytes(diff.SizeBytes), signedBytes(diff.SizeDelta()))
+	fmt.Fprintf(&sb, "  compressed: %s -> %s (%s)\n", humanize.Bytes(diff.PreviousCompressedBytes), humanize.Bytes(diff.CompressedBytes),
+		signedBytes(int64(diff.CompressedBytes)-int64(diff.PreviousCompressedBytes)))
+	fmt.Fprintf(&sb, "  wastedBytes: %s -> %s (%s)\n", humanize.Bytes(diff.PreviousWastedBytes), humanize.Bytes(diff.WastedBytes),
+		signedBytes(int64(diff.WastedBytes)-int64(diff.PreviousWastedBytes)))
+	fmt.Fprintf(&sb, "  efficiency: %2.4f %% -> %2.4f %% (%+2.4f %%)\n", diff.PreviousEfficiency*100, diff.Efficiency*100, (diff.Efficiency-diff.PreviousEfficiency)*100)
+
+	fmt.Fprintf(&sb, "  layers: %d added, %d removed\n", len(diff.AddedLayers), len(diff.RemovedLayers))
+	for _, layer := range diff.AddedLayers {
+		fmt.Fprintf(&sb, "    + layer %-3d  %10s  %s\n", layer.Index, humanize.Bytes(layer.Size), layer.Command)
+	}
+	for _, layer := range diff.RemovedLayers {
+		fmt.Fprintf(&sb, "    - layer %-3d  %10s  %s\n", layer.Index, humanize.Bytes(layer.Size), layer.Command)
+	}
+
+	for _, section := range []struct {
+		title  string
+		deltas []image.FileDelta
+	}{{"directories", diff.Directories}, {"files", diff.Files}} {
+		fmt.Fprintf(&sb, "  %s: %d changed\n", section.title, len(section.deltas))
+		deltas := section.deltas
+		if limit > 0 && len(deltas) > limit {
+			deltas = deltas[:limit]
+		}
+		for _, delta := range deltas {
+			fmt.Fprintf(&sb, "    %10s  %-8s  %s\n", signedBytes(delta.SizeDelta()), delta.Change, delta.Path)
+		}
+		if len(section.deltas) > len(deltas) {
+			fmt.Fprintf(&sb, "    ... and %d more\n", len(section.deltas)-len(deltas))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,277 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+const (
+	DefaultRegistryURL  = "https://registry-1.docker.io/v2/"
+	defaultDockerSocket = "/var/run/docker.sock"
+	networkCheckTimeout = 5 * time.Second
+)
+
+// DefaultChecks returns all checks relevant for the given options.
+func DefaultChecks(options Options) []Check {
+	if options.RegistryURL == "" {
+		options.RegistryURL = DefaultRegistryURL
+	}
+	return []Check{
+		engineClientCheck("docker", options.Engine == "docker"),
+		engineClientCheck("podman", options.Engine == "podman"),
+		dockerSocketCheck,
+		dockerEngineCheck(options.Engine == "docker", options.Network.CA),
+		terminalCheck,
+		cacheDirCheck,
+		logFileCheck(options.LogPath),
+		registryCheck(options.RegistryURL, options.Network),
+	}
+}
+
+func engineClientCheck(name string, required bool) Check {
+	return func(ctx context.Context) Result {
+		result := Result{Name: name + " client"}
+		if name == "podman" && runtime.GOOS != "linux" {
+			result.Status = CheckSkipped
+			result.Message = "podman is only supported on linux"
+			return result
+		}
+
+		path, err := exec.LookPath(name)
+		if err == nil {
+			result.Status = CheckPassed
+			result.Message = path
+			return result
+		}
+
+		result.Message = fmt.Sprintf("'%s' executable not found in PATH", name)
+		result.Fix = fmt.Sprintf("install %s (or add it to your PATH)", name)
+		if required {
+			result.Status = CheckFailed
+		} else {
+			result.Status = CheckWarning
+			result.Fix += fmt.Sprintf(", only needed with '--source %s'", name)
+		}
+		return result
+	}
+}
+
+func dockerSocketCheck(ctx context.Context) Result {
+	result := Result{Name: "docker socket"}
+
+	host := os.Getenv("DOCKER_HOST")
+	socket := defaultDockerSocket
+	switch {
+	case host == "":
+	case strings.HasPrefix(host, "unix://"):
+		socket = strings.TrimPrefix(host, "unix://")
+	default:
+		result.Status = CheckSkipped
+		result.Message = fmt.Sprintf("DOCKER_HOST=%s is not a unix socket", host)
+		return result
+	}
+
+	if runtime.GOOS == "windows" {
+		result.Status = CheckSkipped
+		result.Message = "docker is reached over a named pipe on windows"
+		return result
+	}
+
+	if _, err := os.Stat(socket); err != nil {
+		result.Status = CheckWarning
+		result.Message = fmt.Sprintf("%s does not exist", socket)
+		result.Fix = "start the docker daemon, or point DOCKER_HOST at the engine socket (e.g. unix://$HOME/.colima/docker.sock)"
+		return result
+	}
+
+	conn, err := (&net.Dialer{Timeout: networkCheckTimeout}).DialContext(ctx, "unix", socket)
+	if err != nil {
+		result.Status = CheckFailed
+		result.Message = fmt.Sprintf("cannot connect to %s: %v", socket, err)
+		if os.IsPermission(err) || strings.Contains(err.Error(), "permission denied") {
+			result.Fix = "add your user to the 'docker' group ('sudo usermod -aG docker $USER', then log in again)"
+		} else {
+			result.Fix = "make sure the docker daemon is running"
+		}
+		return result
+	}
+	conn.Close()
+
+	result.Status = CheckPassed
+	result.Message = socket
+	return result
+}
+
+func dockerEngineCheck(required bool, ca image.CABundle) Check {
+	return func(ctx context.Context) Result {
+		result := Result{Name: "docker engine"}
+
+		ctx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+		defer cancel()
+
+		version, err := docker.PingEngine(ctx, "", ca)
+		if err != nil {
+			result.Message = fmt.Sprintf("engine API is not reachable: %v", err)
+			result.Fix = "start the docker daemon, or check DOCKER_HOST / DOCKER_CERT_PATH / DOCKER_TLS_VERIFY"
+			if required {
+				result.Status = CheckFailed
+			} else {
+				result.Status = CheckWarning
+			}
+			return result
+		}
+
+		result.Status = CheckPassed
+		result.Message = "API version " + version
+		return result
+	}
+}
+
+func terminalCheck(ctx context.Context) Result {
+	result := Result{Name: "terminal"}
+
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		result.Status = CheckWarning
+		result.Message = "stdout is not a terminal, the UI cannot be shown"
+		result.Fix = "run dive from an interactive terminal, or use '--ci' / '--json' for non-interactive use"
+		return result
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case term == "" && runtime.GOOS != "windows":
+		result.Status = CheckWarning
+		result.Message = "TERM is not set"
+		result.Fix = "export TERM=xterm-256color"
+	case term == "dumb":
+		result.Status = CheckWarning
+		result.Message = "TERM=dumb does not support the cursor movement the UI needs"
+		result.Fix = "export TERM=xterm-256color"
+	default:
+		result.Status = CheckPassed
+		result.Message = fmt.Sprintf("TERM=%s", term)
+	}
+	return result
+}
+
+func cacheDirCheck(ctx context.Context) Result {
+	result := Result{Name: "cache directory"}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		result.Status = CheckWarning
+		result.Message = fmt.Sprintf("unable to determine the user cache directory: %v", err)
+		result.Fix = "set XDG_CACHE_HOME (or HOME) to a writable directory"
+		return result
+	}
+
+	dir := filepath.Join(cacheDir, "dive")
+	if err := checkWritableDir(dir); err != nil {
+		result.Status = CheckWarning
+		result.Message = fmt.Sprintf("%s is not writable: %v", dir, err)
+		result.Fix = fmt.Sprintf("fix the permissions of %s", dir)
+		return result
+	}
+
+	result.Status = CheckPassed
+	result.Message = dir
+	return result
+}
+
+func logFileCheck(logPath string) Check {
+	return func(ctx context.Context) Result {
+		result := Result{Name: "log file"}
+		if logPath == "" {
+			result.Status = CheckSkipped
+			result.Message = "logging is disabled"
+			return result
+		}
+
+		dir := filepath.Dir(logPath)
+		if err := checkWritableDir(dir); err != nil {
+			result.Status = CheckFailed
+			result.Message = fmt.Sprintf("cannot write %s: %v", logPath, err)
+			result.Fix = "change 'log.path' in the config to a writable location"
+			return result
+		}
+
+		result.Status = CheckPassed
+		result.Message = logPath
+		return result
+	}
+}
+
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(dir, ".dive-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+func registryCheck(registryURL string, network image.NetworkPolicy) Check {
+	return func(ctx context.Context) Result {
+		result := Result{Name: "registry connectivity"}
+		if network.Offline {
+			result.Status = CheckSkipped
+			result.Message = "offline mode, the network is not used"
+			return result
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, networkCheckTimeout)
+		defer cancel()
+
+		request, err := http.NewRequest(http.MethodGet, registryURL, nil)
+		if err != nil {
+			result.Status = CheckFailed
+			result.Message = fmt.Sprintf("invalid registry url: %v", err)
+			return result
+		}
+
+		client := &http.Client{Transport: network.CA.NewHTTPTransport(nil)}
+		response, err := client.Do(request.WithContext(ctx))
+		if err != nil {
+			result.Status = CheckWarning
+			result.Message = fmt.Sprintf("cannot reach %s: %v", registryURL, err)
+			result.Fix = "check your network connection and proxy settings (HTTPS_PROXY / NO_PROXY); images that are not present locally cannot be pulled"
+			if urlErr, ok := err.(*url.Error); ok && urlErr.Timeout() {
+				result.Message = fmt.Sprintf("timed out reaching %s", registryURL)
+			}
+			return result
+		}
+		response.Body.Close()
+
+		// the registry API root answers 401 to anonymous clients, which still proves connectivity
+		if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusUnauthorized {
+			result.Status = CheckWarning
+			result.Message = fmt.Sprintf("unexpected response from %s: %s", registryURL, response.Status)
+			result.Fix = "check for an intercepting proxy or firewall"
+			return result
+		}
+
+		result.Status = CheckPassed
+		result.Message = registryURL
+		if proxy := image.ProxyFor(registryURL); proxy != "" {
+			result.Message += " (through the proxy " + proxy + ")"
+		}
+		return result
+	}
+}
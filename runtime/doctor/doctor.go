@@ -0,0 +1,108 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+const (
+	CheckPassed CheckStatus = iota
+	CheckWarning
+	CheckFailed
+	CheckSkipped
+)
+
+// CheckStatus is the outcome of a single environment check.
+type CheckStatus int
+
+func (status CheckStatus) String() string {
+	switch status {
+	case CheckPassed:
+		return "PASS"
+	case CheckWarning:
+		return utils.Color.Blue("WARN").String()
+	case CheckFailed:
+		return utils.Color.Bold(utils.Color.Inverse(utils.Color.Red("FAIL"))).String()
+	case CheckSkipped:
+		return utils.Color.Blue("SKIP").String()
+	default:
+		return utils.Color.Inverse("Unknown").String()
+	}
+}
+
+// Result is the outcome of a check, along with a suggested fix when the check did not pass.
+type Result struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+	Fix     string
+}
+
+// Check inspects a single aspect of the environment.
+type Check func(ctx context.Context) Result
+
+// Options describes the environment dive is expected to run in.
+type Options struct {
+	// the configured container engine ("docker" or "podman")
+	Engine string
+	// the log file path, if logging is enabled
+	LogPath string
+	// the registry endpoint to check connectivity against
+	RegistryURL string
+	// whether the network may be used (the registry is not checked offline)
+	Network image.NetworkPolicy
+}
+
+// Run executes every check in order.
+func Run(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, check(ctx))
+	}
+	return results
+}
+
+// Passed indicates if none of the given results failed (warnings are allowed).
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == CheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// Report renders the results along with the suggested fixes.
+func Report(results []Result) string {
+	var sb strings.Builder
+	var failed, warned int
+
+	fmt.Fprintln(&sb, utils.TitleFormat("Environment:"))
+	for _, result := range results {
+		fmt.Fprintf(&sb, "  %s: %s: %s\n", result.Status, result.Name, result.Message)
+		if result.Fix != "" && (result.Status == CheckFailed || result.Status == CheckWarning) {
+			fmt.Fprintf(&sb, "        fix: %s\n", result.Fix)
+		}
+		switch result.Status {
+		case CheckFailed:
+			failed++
+		case CheckWarning:
+			warned++
+		}
+	}
+
+	summary := fmt.Sprintf("Result: [Total:%d] [Failed:%d] [Warn:%d]", len(results), failed, warned)
+	switch {
+	case failed > 0:
+		fmt.Fprint(&sb, utils.Color.Red(summary))
+	case warned > 0:
+		fmt.Fprint(&sb, utils.Color.Blue(summary))
+	default:
+		fmt.Fprint(&sb, utils.Color.Green(summary))
+	}
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+package doctor
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lunixbochs/vtclean"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	cases := map[string]struct {
+		status   int
+		expected CheckStatus
+	}{
+		"anonymous":    {status: http.StatusUnauthorized, expected: CheckPassed},
+		"open":         {status: http.StatusOK, expected: CheckPassed},
+		"intercepted":  {status: http.StatusForbidden, expected: CheckWarning},
+		"server-error": {status: http.StatusBadGateway, expected: CheckWarning},
+	}
+
+	for name, test := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.WriteHeader(test.status)
+		}))
+
+		result := registryCheck(server.URL, image.NetworkPolicy{})(context.Background())
+		if result.Status != test.expected {
+			t.Errorf("%s: expected status %v, got %v (%s)", name, test.expected, result.Status, result.Message)
+		}
+		server.Close()
+	}
+}
+
+func TestLogFileCheck(t *testing.T) {
+	if result := logFileCheck("")(context.Background()); result.Status != CheckSkipped {
+		t.Errorf("expected a skipped check, got %v", result.Status)
+	}
+
+	dir, err := ioutil.TempDir("", "dive-doctor")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if result := logFileCheck(filepath.Join(dir, "nested", "dive.log"))(context.Background()); result.Status != CheckPassed {
+		t.Errorf("expected a passed check, got %v (%s)", result.Status, result.Message)
+	}
+}
+
+func TestReport(t *testing.T) {
+	results := Run(context.Background(), []Check{
+		func(ctx context.Context) Result {
+			return Result{Name: "first", Status: CheckPassed, Message: "ok", Fix: "not shown"}
+		},
+		func(ctx context.Context) Result {
+			return Result{Name: "second", Status: CheckFailed, Message: "broken", Fix: "repair it"}
+		},
+	})
+
+	if Passed(results) {
+		t.Errorf("expected failure")
+	}
+
+	expected := "Environment:\n  PASS: first: ok\n  FAIL: second: broken\n        fix: repair it\nResult: [Total:2] [Failed:1] [Warn:0]"
+	if actual := vtclean.Clean(Report(results), false); actual != expected {
+		t.Errorf("unexpected report:\n%s", actual)
+	}
+	if !strings.Contains(Report(results[:1]), "[Failed:0]") {
+		t.Errorf("expected no failures")
+	}
+}
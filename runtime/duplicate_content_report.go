@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of duplicated files listed in the report
+const duplicateContentReportMaxFiles = 10
+
+// duplicateContentReport renders the files stored at more than one path, largest waste first.
+func duplicateContentReport(duplicates *image.DuplicateContent) string {
+	var sb strings.Builder
+	title := "Duplicate Content:"
+	if !duplicates.Complete() {
+		title = fmt.Sprintf("Duplicate Content (%d of %d layers hashed):", duplicates.LayersHashed, duplicates.LayersTotal)
+	}
+	fmt.Fprintln(&sb, utils.TitleFormat(title))
+	if len(duplicates.Duplicates) == 0 {
+		fmt.Fprintln(&sb, "  no duplicate files")
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+
+	fmt.Fprintf(&sb, "  %d files stored more than once, %s wasted\n", len(duplicates.Duplicates), humanize.Bytes(duplicates.WastedBytes))
+	for idx, duplicate := range duplicates.Duplicates {
+		if idx >= duplicateContentReportMaxFiles {
+			fmt.Fprintf(&sb, "  ...and %d more\n", len(duplicates.Duplicates)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "  %s x %d (%s wasted):\n", humanize.Bytes(duplicate.SizeBytes), len(duplicate.Copies), humanize.Bytes(duplicate.WastedBytes()))
+		for _, stored := range duplicate.Copies {
+			fmt.Fprintf(&sb, "    layer %d  %s\n", stored.Layer, stored.Path)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
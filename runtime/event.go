@@ -1,5 +1,11 @@
 package runtime
 
+import (
+	"sync"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
 type eventChannel chan event
 
 type event struct {
@@ -7,6 +13,8 @@ type event struct {
 	stderr      string
 	err         error
 	errorOnExit bool
+	// the progress shown on the status line (see Run)
+	status *image.ProgressEvent
 }
 
 func (ec eventChannel) message(msg string) {
@@ -15,6 +23,36 @@ func (ec eventChannel) message(msg string) {
 	}
 }
 
+// progress reports progress on stderr (keeping stdout for the output of the run).
+func (ec eventChannel) progress(msg string) {
+	ec <- event{
+		stderr: msg,
+	}
+}
+
+// followProgress sends the progress published to the bus as status line events, until the returned function is called
+// (which clears the status line; no event is sent once it returns, so the channel may be closed afterwards).
+func (ec eventChannel) followProgress(bus *image.ProgressBus) func() {
+	var lock sync.Mutex
+	stopped := false
+	unsubscribe := bus.Subscribe(func(progress image.ProgressEvent) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !stopped {
+			ec <- event{status: &progress}
+		}
+	})
+	return func() {
+		unsubscribe()
+		lock.Lock()
+		defer lock.Unlock()
+		if !stopped {
+			stopped = true
+			ec <- event{status: &image.ProgressEvent{Done: true}}
+		}
+	}
+}
+
 func (ec eventChannel) exitWithError(err error) {
 	ec <- event{
 		err:         err,
@@ -0,0 +1,69 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// attestations are the manifest annotations and the artifacts attached to the image in its registry.
+type attestations struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Artifacts   []attestation     `json:"artifacts"`
+}
+
+type attestation struct {
+	Kind          string `json:"kind"`
+	ArtifactType  string `json:"artifactType"`
+	PredicateType string `json:"predicateType,omitempty"`
+	Digest        string `json:"digest"`
+	SizeBytes     uint64 `json:"sizeBytes"`
+	Source        string `json:"source"`
+}
+
+func newAttestations(found *diveImage.Attestations) *attestations {
+	if found == nil {
+		return nil
+	}
+	result := &attestations{Annotations: found.Annotations, Artifacts: make([]attestation, len(found.Artifacts))}
+	for idx, artifact := range found.Artifacts {
+		result.Artifacts[idx] = attestation{
+			Kind:          artifact.Kind,
+			ArtifactType:  artifact.ArtifactType,
+			PredicateType: artifact.PredicateType,
+			Digest:        artifact.Digest,
+			SizeBytes:     artifact.Size,
+			Source:        artifact.Source,
+		}
+	}
+	return result
+}
+
+// signature is the outcome of the cosign signature verification.
+type signature struct {
+	Reference  string              `json:"reference"`
+	Method     string              `json:"method"`
+	Verified   bool                `json:"verified"`
+	Error      string              `json:"error,omitempty"`
+	Signatures []verifiedSignature `json:"signatures,omitempty"`
+}
+
+type verifiedSignature struct {
+	Digest  string `json:"digest"`
+	Subject string `json:"subject,omitempty"`
+	Issuer  string `json:"issuer,omitempty"`
+}
+
+func newSignature(verification *diveImage.SignatureVerification) *signature {
+	if verification == nil {
+		return nil
+	}
+	result := &signature{
+		Reference: verification.Reference,
+		Method:    verification.Method,
+		Verified:  verification.Verified,
+		Error:     verification.Error,
+	}
+	for _, found := range verification.Signatures {
+		result.Signatures = append(result.Signatures, verifiedSignature{Digest: found.Digest, Subject: found.Subject, Issuer: found.Issuer})
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package export
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// fileAttributes are the capabilities, extended attributes and PAX records of a file added by a layer (binary xattr
+// values are hex encoded, see filetree.FormatXattrValue).
+type fileAttributes struct {
+	Path         string            `json:"path"`
+	Capabilities []string          `json:"capabilities,omitempty"`
+	Xattrs       map[string]string `json:"xattrs,omitempty"`
+	PAXRecords   map[string]string `json:"paxRecords,omitempty"`
+}
+
+// newFileAttributes lists the files of the layer tree that have extended attributes or PAX records (nil when the
+// tree is not loaded).
+func newFileAttributes(tree *filetree.FileTree) []fileAttributes {
+	if tree == nil {
+		return nil
+	}
+	var files []fileAttributes
+	err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		info := node.Data.FileInfo
+		if len(info.Xattrs) == 0 && len(info.PAXRecords) == 0 {
+			return nil
+		}
+		files = append(files, fileAttributes{
+			Path:         node.Path(),
+			Capabilities: info.Capabilities,
+			Xattrs:       formatXattrs(info.Xattrs),
+			PAXRecords:   formatXattrs(info.PAXRecords),
+		})
+		return nil
+	}, nil)
+	if err != nil {
+		logrus.Errorf("unable to list the file attributes: %+v", err)
+	}
+	return files
+}
+
+// formatXattrs renders the values so that they can be serialized as text.
+func formatXattrs(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	formatted := make(map[string]string, len(values))
+	for name, value := range values {
+		formatted[name] = filetree.FormatXattrValue(value)
+	}
+	return formatted
+}
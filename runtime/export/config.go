@@ -0,0 +1,57 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// imageConfig is the runtime configuration recorded in the image config.
+type imageConfig struct {
+	User         string            `json:"user,omitempty"`
+	WorkingDir   string            `json:"workingDir,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty"`
+	Cmd          []string          `json:"cmd,omitempty"`
+	Env          []string          `json:"env,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	ExposedPorts []string          `json:"exposedPorts,omitempty"`
+	Volumes      []string          `json:"volumes,omitempty"`
+	StopSignal   string            `json:"stopSignal,omitempty"`
+	Healthcheck  *healthcheck      `json:"healthcheck,omitempty"`
+}
+
+type healthcheck struct {
+	Test        []string `json:"test"`
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	StartPeriod string   `json:"startPeriod,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+}
+
+func newImageConfig(config *diveImage.ImageConfig) *imageConfig {
+	if config == nil {
+		return nil
+	}
+	result := &imageConfig{
+		User:         config.User,
+		WorkingDir:   config.WorkingDir,
+		Entrypoint:   config.Entrypoint,
+		Cmd:          config.Cmd,
+		Env:          config.Env,
+		Labels:       config.Labels,
+		ExposedPorts: config.ExposedPorts,
+		Volumes:      config.Volumes,
+		StopSignal:   config.StopSignal,
+	}
+	if health := config.Healthcheck; health != nil {
+		result.Healthcheck = &healthcheck{Test: health.Test, Retries: health.Retries}
+		if health.Interval > 0 {
+			result.Healthcheck.Interval = health.Interval.String()
+		}
+		if health.Timeout > 0 {
+			result.Healthcheck.Timeout = health.Timeout.String()
+		}
+		if health.StartPeriod > 0 {
+			result.Healthcheck.StartPeriod = health.StartPeriod.String()
+		}
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"encoding/json"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// diffExport is the structured diff of two analyses, going from the previous image to the current one.
+type diffExport struct {
+	Image  imageDiff   `json:"image"`
+	Layers layersDiff  `json:"layers"`
+	Paths  []pathDelta `json:"paths"`
+	Files  []pathDelta `json:"files"`
+	// the number of directories and files that differ, including those beyond the listed ones
+	PathCount int `json:"pathCount"`
+	FileCount int `json:"fileCount"`
+}
+
+type imageDiff struct {
+	SizeBytes        byteDelta  `json:"sizeBytes"`
+	CompressedBytes  byteDelta  `json:"compressedBytes"`
+	InefficientBytes byteDelta  `json:"inefficientBytes"`
+	EfficiencyScore  scoreDelta `json:"efficiencyScore"`
+}
+
+type byteDelta struct {
+	Before uint64 `json:"before"`
+	After  uint64 `json:"after"`
+	Delta  int64  `json:"delta"`
+}
+
+type scoreDelta struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+type layersDiff struct {
+	Added   []layerReference `json:"added"`
+	Removed []layerReference `json:"removed"`
+}
+
+type layerReference struct {
+	Index     int    `json:"index"`
+	DigestID  string `json:"digestId"`
+	DiffID    string `json:"diffId"`
+	SizeBytes uint64 `json:"sizeBytes"`
+	Command   string `json:"command"`
+}
+
+type pathDelta struct {
+	Path              string `json:"path"`
+	Change            string `json:"change"`
+	SizeBytes         uint64 `json:"sizeBytes"`
+	PreviousSizeBytes uint64 `json:"previousSizeBytes"`
+	DeltaBytes        int64  `json:"deltaBytes"`
+}
+
+// NewDiff builds the export of the diff of two analyses, listing at most limit directories and files (all of them
+// when the limit is 0).
+func NewDiff(diff *diveImage.AnalysisDiff, limit int) *diffExport {
+	data := diffExport{
+		Image: imageDiff{
+			SizeBytes:        newByteDelta(diff.PreviousSizeBytes, diff.SizeBytes),
+			CompressedBytes:  newByteDelta(diff.PreviousCompressedBytes, diff.CompressedBytes),
+			InefficientBytes: newByteDelta(diff.PreviousWastedBytes, diff.WastedBytes),
+			EfficiencyScore: scoreDelta{
+				Before: diff.PreviousEfficiency,
+				After:  diff.Efficiency,
+				Delta:  diff.Efficiency - diff.PreviousEfficiency,
+			},
+		},
+		Layers: layersDiff{
+			Added:   newLayerReferences(diff.AddedLayers),
+			Removed: newLayerReferences(diff.RemovedLayers),
+		},
+		Paths:     newPathDeltas(diff.Directories, limit),
+		Files:     newPathDeltas(diff.Files, limit),
+		PathCount: len(diff.Directories),
+		FileCount: len(diff.Files),
+	}
+	return &data
+}
+
+func newByteDelta(before, after uint64) byteDelta {
+	return byteDelta{Before: before, After: after, Delta: int64(after) - int64(before)}
+}
+
+func newLayerReferences(layers []*diveImage.Layer) []layerReference {
+	references := make([]layerReference, len(layers))
+	for idx, layer := range layers {
+		references[idx] = layerReference{
+			Index:     layer.Index,
+			DigestID:  layer.Digest,
+			DiffID:    layer.DiffID,
+			SizeBytes: layer.Size,
+			Command:   layer.Command,
+		}
+	}
+	return references
+}
+
+func newPathDeltas(deltas []diveImage.FileDelta, limit int) []pathDelta {
+	if limit > 0 && len(deltas) > limit {
+		deltas = deltas[:limit]
+	}
+	paths := make([]pathDelta, len(deltas))
+	for idx, delta := range deltas {
+		paths[idx] = pathDelta{
+			Path:              delta.Path,
+			Change:            delta.Change,
+			SizeBytes:         delta.Bytes,
+			PreviousSizeBytes: delta.PreviousBytes,
+			DeltaBytes:        delta.SizeDelta(),
+		}
+	}
+	return paths
+}
+
+func (exp *diffExport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(&exp, "", "  ")
+}
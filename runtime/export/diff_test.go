@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+func Test_DiffExport(t *testing.T) {
+	diff := &diveImage.AnalysisDiff{
+		SizeBytes:          4100,
+		PreviousSizeBytes:  1600,
+		Efficiency:         0.95,
+		PreviousEfficiency: 0.9,
+		AddedLayers:        []*diveImage.Layer{{Index: 1, DiffID: "sha256:deps2", Size: 3100, Command: "RUN pip install -r requirements.txt"}},
+		Directories: []diveImage.FileDelta{
+			{Path: "/usr/lib/python3.11", Change: diveImage.PathModified, Bytes: 3000, PreviousBytes: 500},
+			{Path: "/app", Change: diveImage.PathModified, Bytes: 90, PreviousBytes: 100},
+		},
+	}
+
+	payload, err := NewDiff(diff, 1).Marshal()
+	if err != nil {
+		t.Fatalf("unable to export diff: %v", err)
+	}
+
+	var decoded struct {
+		Image struct {
+			SizeBytes struct {
+				Delta int64 `json:"delta"`
+			} `json:"sizeBytes"`
+		} `json:"image"`
+		Layers struct {
+			Added   []layerReference `json:"added"`
+			Removed []layerReference `json:"removed"`
+		} `json:"layers"`
+		Paths     []pathDelta `json:"paths"`
+		PathCount int         `json:"pathCount"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unable to parse export: %v\n%s", err, payload)
+	}
+	if decoded.Image.SizeBytes.Delta != 2500 {
+		t.Errorf("expected a size delta of 2500, got %d", decoded.Image.SizeBytes.Delta)
+	}
+	if len(decoded.Layers.Added) != 1 || decoded.Layers.Added[0].DiffID != "sha256:deps2" || decoded.Layers.Removed == nil {
+		t.Errorf("unexpected layers: %+v", decoded.Layers)
+	}
+	// the paths are limited, the count is not
+	if len(decoded.Paths) != 1 || decoded.Paths[0].DeltaBytes != 2500 || decoded.PathCount != 2 {
+		t.Errorf("unexpected paths: %+v (count %d)", decoded.Paths, decoded.PathCount)
+	}
+}
@@ -14,21 +14,65 @@ func NewExport(analysis *diveImage.AnalysisResult) *export {
 	data := export{
 		Layer: make([]layer, len(analysis.Layers)),
 		Image: image{
-			InefficientFiles: make([]fileReference, len(analysis.Inefficiencies)),
-			SizeBytes:        analysis.SizeBytes,
-			EfficiencyScore:  analysis.Efficiency,
-			InefficientBytes: analysis.WastedBytes,
+			InefficientFiles:    make([]fileReference, len(analysis.Inefficiencies)),
+			SizeBytes:           analysis.SizeBytes,
+			CompressedBytes:     analysis.CompressedBytes,
+			EfficiencyScore:     analysis.Efficiency,
+			InefficientBytes:    analysis.WastedBytes,
+			AppSizeBytes:        analysis.UserSizeByes,
+			AppInefficientBytes: analysis.AppWastedBytes,
+			Config:              newImageConfig(analysis.Config),
+			Attestations:        newAttestations(analysis.Attestations),
+			Signature:           newSignature(analysis.Signature),
+			Packages:            newPackages(analysis.Packages),
+			Ownership:           newOwnership(analysis.Ownership),
 		},
 	}
 
 	// export layers in order
 	for idx, curLayer := range analysis.Layers {
 		data.Layer[idx] = layer{
-			Index:     curLayer.Index,
-			ID:        curLayer.Id,
-			DigestID:  curLayer.Digest,
-			SizeBytes: curLayer.Size,
-			Command:   curLayer.Command,
+			Index:               curLayer.Index,
+			ID:                  curLayer.Id,
+			DigestID:            curLayer.Digest,
+			SizeBytes:           curLayer.Size,
+			CompressedSizeBytes: curLayer.CompressedSize,
+			Builder:             curLayer.Builder,
+			Command:             curLayer.Command,
+			Corruption:          curLayer.Corruption,
+			Empty:               curLayer.EmptyReason(),
+		}
+		if curLayer.ParseWarnings != nil {
+			for _, warning := range curLayer.ParseWarnings.Warnings {
+				data.Layer[idx].ParseWarnings = append(data.Layer[idx].ParseWarnings, warning.String())
+			}
+		}
+		if idx < len(analysis.Storage.LayerEntries) {
+			data.Layer[idx].Entries = analysis.Storage.LayerEntries[idx]
+		}
+		if idx < len(analysis.RefTrees) {
+			data.Layer[idx].Attributes = newFileAttributes(analysis.RefTrees[idx])
+		}
+	}
+
+	if analysis.Breakdown != nil {
+		breakdown := &efficiencyBreakdown{
+			Score:         analysis.Breakdown.Score,
+			WastedBytes:   analysis.Breakdown.WastedBytes(),
+			Contributions: make([]scoreContribution, len(analysis.Breakdown.Contributions)),
+		}
+		for idx, contribution := range analysis.Breakdown.Contributions {
+			breakdown.Contributions[idx] = scoreContribution(contribution)
+		}
+		data.Image.EfficiencyBreakdown = breakdown
+	}
+
+	if analysis.Base != nil {
+		data.Image.Base = base{
+			Image:            analysis.Base.Image,
+			Layers:           analysis.Base.Layers,
+			SizeBytes:        analysis.Base.SizeBytes,
+			InefficientBytes: analysis.Base.WastedBytes,
 		}
 	}
 
@@ -43,9 +87,41 @@ func NewExport(analysis *diveImage.AnalysisResult) *export {
 		}
 	}
 
+	// add the runtime storage estimates
+	data.Image.Storage = storage{
+		Rating:           analysis.Storage.Rating.String(),
+		LayerDepth:       analysis.Storage.LayerDepth,
+		TotalEntries:     analysis.Storage.TotalEntries,
+		UniqueEntries:    analysis.Storage.UniqueEntries,
+		ShadowedEntries:  analysis.Storage.ShadowedEntries,
+		MetadataBytes:    analysis.Storage.MetadataBytes,
+		CopyUpBytes:      analysis.Storage.CopyUpBytes,
+		CopyUpCandidates: make([]copyUpCandidate, len(analysis.Storage.CopyUpCandidates)),
+		Reasons:          analysis.Storage.Reasons,
+	}
+	for idx, candidate := range analysis.Storage.CopyUpCandidates {
+		data.Image.Storage.CopyUpCandidates[idx] = copyUpCandidate{
+			Path:      candidate.Path,
+			Revisions: candidate.Revisions,
+			SizeBytes: candidate.SizeBytes,
+		}
+	}
+
 	return &data
 }
 
+// WithBookmarks includes the paths marked in the UI.
+func (exp *export) WithBookmarks(paths []string) *export {
+	exp.Image.Bookmarks = paths
+	return exp
+}
+
+// WithPullCost includes the estimated pull times and the monthly egress.
+func (exp *export) WithPullCost(cost *diveImage.PullCost) *export {
+	exp.Image.Pull = newPull(cost)
+	return exp
+}
+
 func (exp *export) Marshal() ([]byte, error) {
 	return json.MarshalIndent(&exp, "", "  ")
 }
@@ -1,9 +1,13 @@
 package export
 
 import (
+	"archive/tar"
+	"reflect"
+	"testing"
+
 	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image/docker"
-	"testing"
 )
 
 func Test_Export(t *testing.T) {
@@ -22,104 +26,179 @@ func Test_Export(t *testing.T) {
       "id": "28cfe03618aa2e914e81fdd90345245c15f4478e35252c06ca52d238fd3cc694",
       "digestId": "sha256:23bc2b70b2014dec0ac22f27bb93e9babd08cdd6f1115d0c955b9ff22b382f5a",
       "sizeBytes": 1154361,
-      "command": "#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in / "
+      "compressedSizeBytes": 738725,
+      "builder": "docker build",
+      "command": "#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in / ",
+      "entries": 415
     },
     {
       "index": 1,
       "id": "1871059774abe6914075e4a919b778fa1561f577d620ae52438a9635e6241936",
       "digestId": "sha256:a65b7d7ac139a0e4337bc3c73ce511f937d6140ef61a0108f7d4b8aab8d67274",
       "sizeBytes": 6405,
-      "command": "#(nop) ADD file:139c3708fb6261126453e34483abd8bf7b26ed16d952fd976994d68e72d93be2 in /somefile.txt "
+      "compressedSizeBytes": 2540,
+      "builder": "docker build",
+      "command": "#(nop) ADD file:139c3708fb6261126453e34483abd8bf7b26ed16d952fd976994d68e72d93be2 in /somefile.txt ",
+      "entries": 1
     },
     {
       "index": 2,
       "id": "49fe2a475548bfa4d493fc796fce41f30704e3d4cbff3e45dd3e06f463236d1d",
       "digestId": "sha256:93e208d471756ffbac88cf9c25feb442007f221d3bd73231e27b747a0a68927c",
       "sizeBytes": 0,
-      "command": "mkdir -p /root/example/really/nested"
+      "compressedSizeBytes": 154,
+      "builder": "docker build",
+      "command": "mkdir -p /root/example/really/nested",
+      "entries": 4,
+      "empty": "directories only"
     },
     {
       "index": 3,
       "id": "80cd2ca1ffc89962b9349c80280c2bc551acbd11e09b16badb0669f8e2369020",
       "digestId": "sha256:4abad3abe3cb99ad7a492a9d9f6b3d66287c1646843c74128bbbec4f7be5aa9e",
       "sizeBytes": 6405,
-      "command": "cp /somefile.txt /root/example/somefile1.txt"
+      "compressedSizeBytes": 2612,
+      "builder": "docker build",
+      "command": "cp /somefile.txt /root/example/somefile1.txt",
+      "entries": 3
     },
     {
       "index": 4,
       "id": "c99e2f8d3f6282668f0d30dc1db5e67a51d7a1dcd7ff6ddfa0f90760836778ec",
       "digestId": "sha256:14c9a6ffcb6a0f32d1035f97373b19608e2d307961d8be156321c3f1c1504cbf",
       "sizeBytes": 6405,
-      "command": "chmod 444 /root/example/somefile1.txt"
+      "compressedSizeBytes": 2612,
+      "builder": "docker build",
+      "command": "chmod 444 /root/example/somefile1.txt",
+      "entries": 3
     },
     {
       "index": 5,
       "id": "5eca617bdc3bc06134fe957a30da4c57adb7c340a6d749c8edc4c15861c928d7",
       "digestId": "sha256:778fb5770ef466f314e79cc9dc418eba76bfc0a64491ce7b167b76aa52c736c4",
       "sizeBytes": 6405,
-      "command": "cp /somefile.txt /root/example/somefile2.txt"
+      "compressedSizeBytes": 2613,
+      "builder": "docker build",
+      "command": "cp /somefile.txt /root/example/somefile2.txt",
+      "entries": 3
     },
     {
       "index": 6,
       "id": "f07c3eb887572395408f8e11a07af945e4da5f02b3188bb06b93fad713ca0b99",
       "digestId": "sha256:f275b8a31a71deb521cc048e6021e2ff6fa52bedb25c9b7bbe129a0195ddca5f",
       "sizeBytes": 6405,
-      "command": "cp /somefile.txt /root/example/somefile3.txt"
+      "compressedSizeBytes": 2614,
+      "builder": "docker build",
+      "command": "cp /somefile.txt /root/example/somefile3.txt",
+      "entries": 3
     },
     {
       "index": 7,
       "id": "461885fc22589158dee3c5b9f01cc41c87805439f58b4399d733b51aa305cbf9",
       "digestId": "sha256:dd1effc5eb19894c3e9b57411c98dd1cf30fa1de4253c7fae53c9cea67267d83",
       "sizeBytes": 6405,
-      "command": "mv /root/example/somefile3.txt /root/saved.txt"
+      "compressedSizeBytes": 2642,
+      "builder": "docker build",
+      "command": "mv /root/example/somefile3.txt /root/saved.txt",
+      "entries": 4
     },
     {
       "index": 8,
       "id": "a10327f68ffed4afcba78919052809a8f774978a6b87fc117d39c53c4842f72c",
       "digestId": "sha256:8d1869a0a066cdd12e48d648222866e77b5e2814f773bb3bd8774ab4052f0f1d",
       "sizeBytes": 6405,
-      "command": "cp /root/saved.txt /root/.saved.txt"
+      "compressedSizeBytes": 2581,
+      "builder": "docker build",
+      "command": "cp /root/saved.txt /root/.saved.txt",
+      "entries": 2
     },
     {
       "index": 9,
       "id": "f2fc54e25cb7966dc9732ec671a77a1c5c104e732bd15ad44a2dc1ac42368f84",
       "digestId": "sha256:bc2e36423fa31a97223fd421f22c35466220fa160769abf697b8eb58c896b468",
       "sizeBytes": 0,
-      "command": "rm -rf /root/example/"
+      "compressedSizeBytes": 133,
+      "builder": "docker build",
+      "command": "rm -rf /root/example/",
+      "entries": 2,
+      "empty": "removals only"
     },
     {
       "index": 10,
       "id": "aad36d0b05e71c7e6d4dfe0ca9ed6be89e2e0d8995dafe83438299a314e91071",
       "digestId": "sha256:7f648d45ee7b6de2292162fba498b66cbaaf181da9004fcceef824c72dbae445",
       "sizeBytes": 2187,
-      "command": "#(nop) ADD dir:7ec14b81316baa1a31c38c97686a8f030c98cba2035c968412749e33e0c4427e in /root/.data/ "
+      "compressedSizeBytes": 1299,
+      "builder": "docker build",
+      "command": "#(nop) ADD dir:7ec14b81316baa1a31c38c97686a8f030c98cba2035c968412749e33e0c4427e in /root/.data/ ",
+      "entries": 4
     },
     {
       "index": 11,
       "id": "3d4ad907517a021d86a4102d2764ad2161e4818bbd144e41d019bfc955434181",
       "digestId": "sha256:a4b8f95f266d5c063c9a9473c45f2f85ddc183e37941b5e6b6b9d3c00e8e0457",
       "sizeBytes": 6405,
-      "command": "cp /root/saved.txt /tmp/saved.again1.txt"
+      "compressedSizeBytes": 2581,
+      "builder": "docker build",
+      "command": "cp /root/saved.txt /tmp/saved.again1.txt",
+      "entries": 2
     },
     {
       "index": 12,
       "id": "81b1b002d4b4c1325a9cad9990b5277e7f29f79e0f24582344c0891178f95905",
       "digestId": "sha256:22a44d45780a541e593a8862d80f3e14cb80b6bf76aa42ce68dc207a35bf3a4a",
       "sizeBytes": 6405,
-      "command": "cp /root/saved.txt /root/.data/saved.again2.txt"
+      "compressedSizeBytes": 2614,
+      "builder": "docker build",
+      "command": "cp /root/saved.txt /root/.data/saved.again2.txt",
+      "entries": 3
     },
     {
       "index": 13,
       "id": "cfb35bb5c127d848739be5ca726057e6e2c77b2849f588e7aebb642c0d3d4b7b",
       "digestId": "sha256:ba689cac6a98c92d121fa5c9716a1bab526b8bb1fd6d43625c575b79e97300c5",
       "sizeBytes": 6405,
-      "command": "chmod +x /root/saved.txt"
+      "compressedSizeBytes": 2589,
+      "builder": "docker build",
+      "command": "chmod +x /root/saved.txt",
+      "entries": 2
     }
   ],
   "image": {
     "sizeBytes": 1220598,
-    "inefficientBytes": 32025,
-    "efficiencyScore": 0.9844212134184309,
+    "compressedSizeBytes": 766309,
+    "inefficientBytes": 38430,
+    "efficiencyScore": 0.9791198554526761,
+    "efficiencyBreakdown": {
+      "score": 0.9737628604995257,
+      "wastedBytes": 32025,
+      "contributions": [
+        {
+          "name": "duplicated files",
+          "bytes": 6405,
+          "files": 1,
+          "penalty": 0.005247427900094872
+        },
+        {
+          "name": "removed later",
+          "bytes": 25620,
+          "files": 3,
+          "penalty": 0.020989711600379487
+        },
+        {
+          "name": "cache directories",
+          "bytes": 0,
+          "files": 0,
+          "penalty": 0
+        },
+        {
+          "name": "docs and locales",
+          "bytes": 0,
+          "files": 0,
+          "penalty": 0
+        }
+      ]
+    },
     "fileReference": [
       {
         "count": 2,
@@ -131,12 +210,250 @@ func Test_Export(t *testing.T) {
         "sizeBytes": 12810,
         "file": "/root/example/somefile1.txt"
       },
+      {
+        "count": 2,
+        "sizeBytes": 6405,
+        "file": "/root/example"
+      },
       {
         "count": 2,
         "sizeBytes": 6405,
         "file": "/root/example/somefile3.txt"
       }
-    ]
+    ],
+    "storage": {
+      "rating": "good",
+      "layerDepth": 14,
+      "layerEntries": 451,
+      "uniqueEntries": 423,
+      "shadowedEntries": 28,
+      "estimatedMetadataBytes": 115456,
+      "copyUpBytes": 6405,
+      "copyUpCandidates": [
+        {
+          "file": "/root/saved.txt",
+          "revisions": 2,
+          "sizeBytes": 6405
+        }
+      ],
+      "notes": []
+    },
+    "base": {
+      "image": "",
+      "layers": 1,
+      "sizeBytes": 1154361,
+      "inefficientBytes": 0
+    },
+    "appSizeBytes": 66237,
+    "appInefficientBytes": 38430,
+    "config": {
+      "cmd": [
+        "sh"
+      ],
+      "env": [
+        "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+      ]
+    },
+    "ownership": {
+      "image": {
+        "files": 405,
+        "sizeBytes": 1188573,
+        "rootBytes": 1188573,
+        "rootPercent": 1,
+        "owners": [
+          {
+            "uid": 0,
+            "gid": 0,
+            "files": 405,
+            "sizeBytes": 1188573
+          }
+        ]
+      },
+      "layers": [
+        {
+          "files": 398,
+          "sizeBytes": 1154361,
+          "rootBytes": 1154361,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 398,
+              "sizeBytes": 1154361
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 0,
+          "sizeBytes": 0,
+          "rootBytes": 0,
+          "rootPercent": 0,
+          "owners": []
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 0,
+          "sizeBytes": 0,
+          "rootBytes": 0,
+          "rootPercent": 0,
+          "owners": []
+        },
+        {
+          "files": 2,
+          "sizeBytes": 2187,
+          "rootBytes": 2187,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 2,
+              "sizeBytes": 2187
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        },
+        {
+          "files": 1,
+          "sizeBytes": 6405,
+          "rootBytes": 6405,
+          "rootPercent": 1,
+          "owners": [
+            {
+              "uid": 0,
+              "gid": 0,
+              "files": 1,
+              "sizeBytes": 6405
+            }
+          ]
+        }
+      ]
+    }
   }
 }`
 	actualResult := string(payload)
@@ -147,3 +464,36 @@ func Test_Export(t *testing.T) {
 		t.Errorf("Test_Export: unexpected export result:\n%v", dmp.DiffPrettyText(diffs))
 	}
 }
+
+func Test_ExportAttributes(t *testing.T) {
+	tree := filetree.NewFileTree()
+	infos := map[string]filetree.FileInfo{
+		"/usr/bin/ping": {
+			TypeFlag:     tar.TypeReg,
+			Capabilities: []string{"cap_net_raw"},
+			Xattrs:       map[string]string{"security.capability": "\x01\x00\x00\x02", "user.origin": "apk"},
+		},
+		"/etc/shadow": {TypeFlag: tar.TypeReg, PAXRecords: map[string]string{"SCHILY.acl.access": "user::rw-"}},
+		"/etc/hosts":  {TypeFlag: tar.TypeReg},
+	}
+	for path, info := range infos {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	expected := []fileAttributes{
+		{Path: "/etc/shadow", PAXRecords: map[string]string{"SCHILY.acl.access": "user::rw-"}},
+		{
+			Path:         "/usr/bin/ping",
+			Capabilities: []string{"cap_net_raw"},
+			Xattrs:       map[string]string{"security.capability": "0x01000002", "user.origin": "apk"},
+		},
+	}
+	if actual := newFileAttributes(tree); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected attributes:\n%+v\ngot:\n%+v", expected, actual)
+	}
+	if actual := newFileAttributes(nil); actual != nil {
+		t.Errorf("expected no attributes for an unloaded layer, got %+v", actual)
+	}
+}
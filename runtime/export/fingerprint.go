@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/json"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// fingerprintDriftExport is the structured comparison of the fingerprints of two images, going from the previous image
+// to the current one.
+type fingerprintDriftExport struct {
+	Previous  fingerprintReference `json:"previous"`
+	Current   fingerprintReference `json:"current"`
+	Identical bool                 `json:"identical"`
+	Paths     []pathDrift          `json:"paths"`
+	// the number of paths that differ, including those beyond the listed ones
+	PathCount int `json:"pathCount"`
+}
+
+type fingerprintReference struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+}
+
+type pathDrift struct {
+	Path              string   `json:"path"`
+	Change            string   `json:"change"`
+	Reasons           []string `json:"reasons,omitempty"`
+	SizeBytes         int64    `json:"sizeBytes"`
+	PreviousSizeBytes int64    `json:"previousSizeBytes"`
+}
+
+// NewFingerprintDrift builds the export of the paths that differ between the fingerprints of two images, listing at
+// most limit paths (all of them when the limit is 0).
+func NewFingerprintDrift(previous, current *diveImage.Fingerprint, drifts []diveImage.FingerprintDrift, limit int) *fingerprintDriftExport {
+	data := fingerprintDriftExport{
+		Previous:  fingerprintReference{Image: previous.Image, Digest: previous.Digest},
+		Current:   fingerprintReference{Image: current.Image, Digest: current.Digest},
+		Identical: previous.Digest == current.Digest,
+		Paths:     make([]pathDrift, 0),
+		PathCount: len(drifts),
+	}
+	if limit > 0 && len(drifts) > limit {
+		drifts = drifts[:limit]
+	}
+	for _, drift := range drifts {
+		data.Paths = append(data.Paths, pathDrift{
+			Path:              drift.Path,
+			Change:            drift.Change,
+			Reasons:           drift.Reasons,
+			SizeBytes:         drift.Bytes,
+			PreviousSizeBytes: drift.PreviousBytes,
+		})
+	}
+	return &data
+}
+
+func (exp *fingerprintDriftExport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(&exp, "", "  ")
+}
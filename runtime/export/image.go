@@ -1,8 +1,51 @@
 package export
 
 type image struct {
-	SizeBytes        uint64          `json:"sizeBytes"`
-	InefficientBytes uint64          `json:"inefficientBytes"`
-	EfficiencyScore  float64         `json:"efficiencyScore"`
-	InefficientFiles []fileReference `json:"fileReference"`
+	SizeBytes        uint64  `json:"sizeBytes"`
+	CompressedBytes  uint64  `json:"compressedSizeBytes"`
+	InefficientBytes uint64  `json:"inefficientBytes"`
+	EfficiencyScore  float64 `json:"efficiencyScore"`
+	// the efficiency score decomposed into the kinds of waste
+	EfficiencyBreakdown *efficiencyBreakdown `json:"efficiencyBreakdown,omitempty"`
+	InefficientFiles    []fileReference      `json:"fileReference"`
+	Storage             storage              `json:"storage"`
+	Base                base                 `json:"base"`
+	// the size and waste of the layers on top of the base image
+	AppSizeBytes        uint64 `json:"appSizeBytes"`
+	AppInefficientBytes uint64 `json:"appInefficientBytes"`
+	// the paths marked in the UI
+	Bookmarks []string `json:"bookmarks,omitempty"`
+	// the env, labels, entrypoint... recorded in the image config
+	Config *imageConfig `json:"config,omitempty"`
+	// the provenance, SBOMs and signatures attached in the registry (registry source only)
+	Attestations *attestations `json:"attestations,omitempty"`
+	// the outcome of the cosign signature verification (when verified with --verify-signature)
+	Signature *signature `json:"signature,omitempty"`
+	// the installed packages and the files no package installed (when the package databases were read with --packages)
+	Packages *packages `json:"packages,omitempty"`
+	// the bytes of the final image and of every layer by owner
+	Ownership *ownership `json:"ownership,omitempty"`
+	// the estimated pull times at the configured bandwidths, and the monthly egress (when the pulls are configured)
+	Pull *pull `json:"pull,omitempty"`
+}
+
+type base struct {
+	// the image the layers were matched against (empty when the first layer is assumed to be the base)
+	Image            string `json:"image"`
+	Layers           int    `json:"layers"`
+	SizeBytes        uint64 `json:"sizeBytes"`
+	InefficientBytes uint64 `json:"inefficientBytes"`
+}
+
+type efficiencyBreakdown struct {
+	Score         float64             `json:"score"`
+	WastedBytes   uint64              `json:"wastedBytes"`
+	Contributions []scoreContribution `json:"contributions"`
+}
+
+type scoreContribution struct {
+	Name    string  `json:"name"`
+	Bytes   uint64  `json:"bytes"`
+	Files   int     `json:"files"`
+	Penalty float64 `json:"penalty"`
 }
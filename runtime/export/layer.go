@@ -1,9 +1,21 @@
 package export
 
 type layer struct {
-	Index     int    `json:"index"`
-	ID        string `json:"id"`
-	DigestID  string `json:"digestId"`
-	SizeBytes uint64 `json:"sizeBytes"`
-	Command   string `json:"command"`
+	Index               int    `json:"index"`
+	ID                  string `json:"id"`
+	DigestID            string `json:"digestId"`
+	SizeBytes           uint64 `json:"sizeBytes"`
+	CompressedSizeBytes uint64 `json:"compressedSizeBytes"`
+	Builder             string `json:"builder,omitempty"`
+	Command             string `json:"command"`
+	// the entries (files, directories and whiteouts) of the layer
+	Entries int `json:"entries"`
+	// why the layer adds no bytes to the image filesystem, e.g. "directories only" (omitted when it does)
+	Empty string `json:"empty,omitempty"`
+	// the problems found verifying the layer blob against its digests (omitted when the layer is intact)
+	Corruption []string `json:"corruption,omitempty"`
+	// the malformed entries of the layer tar that were skipped while parsing it (omitted when there were none)
+	ParseWarnings []string `json:"parseWarnings,omitempty"`
+	// the files added by the layer that have extended attributes or PAX records
+	Attributes []fileAttributes `json:"attributes,omitempty"`
 }
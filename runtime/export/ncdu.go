@@ -0,0 +1,174 @@
+package export
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// the version of the ncdu export format written (1.2 includes the uid, gid, mode and mtime of every entry)
+const (
+	ncduMajorVersion = 1
+	ncduMinorVersion = 2
+)
+
+// ncduEntry is the information ncdu keeps of a file or directory (directories are written as an array of their own
+// entry followed by the entries beneath them).
+type ncduEntry struct {
+	Name string `json:"name"`
+	// the apparent size and the disk usage (the stored size of sparse files)
+	Asize uint64 `json:"asize,omitempty"`
+	Dsize uint64 `json:"dsize,omitempty"`
+	// hardlinks share the inode number of their target and are counted only once
+	Ino    uint64 `json:"ino,omitempty"`
+	Hlnkc  bool   `json:"hlnkc,omitempty"`
+	Notreg bool   `json:"notreg,omitempty"`
+	Uid    int    `json:"uid"`
+	Gid    int    `json:"gid"`
+	Mode   uint32 `json:"mode"`
+	Mtime  int64  `json:"mtime,omitempty"`
+}
+
+type ncduMetadata struct {
+	Progname  string `json:"progname"`
+	Progver   string `json:"progver"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ncduBuilder converts a tree into ncdu entries, tracking the files that are hardlinked.
+type ncduBuilder struct {
+	tree   *filetree.FileTree
+	inodes map[*filetree.FileNode]uint64
+}
+
+// NewNcdu converts the given tree (e.g. the stacked tree of all layers) into ncdu's JSON export format, such that the
+// image filesystem can be browsed with `ncdu -f <file>`. Removed files (whiteouts) are left out.
+func NewNcdu(tree *filetree.FileTree, version string, timestamp time.Time) []interface{} {
+	builder := ncduBuilder{tree: tree, inodes: make(map[*filetree.FileNode]uint64)}
+	builder.linkInodes()
+
+	root := append([]interface{}{ncduEntry{Name: "/", Mode: 0040755}}, builder.entries(tree.Root)...)
+	return []interface{}{
+		ncduMajorVersion,
+		ncduMinorVersion,
+		ncduMetadata{Progname: "dive", Progver: version, Timestamp: timestamp.Unix()},
+		root,
+	}
+}
+
+// MarshalNcdu returns the ncdu JSON export of the given tree (see NewNcdu).
+func MarshalNcdu(tree *filetree.FileTree, version string, timestamp time.Time) ([]byte, error) {
+	return json.Marshal(NewNcdu(tree, version, timestamp))
+}
+
+// linkInodes numbers the hardlinks and their targets, giving a hardlink the number of its target.
+func (b *ncduBuilder) linkInodes() {
+	var next uint64
+	_ = b.tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		if node.Data.FileInfo.TypeFlag != tar.TypeLink || node.IsWhiteout() {
+			return nil
+		}
+		target, err := b.tree.ResolveLink(node)
+		if err != nil {
+			return nil
+		}
+		ino, exists := b.inodes[target]
+		if !exists {
+			next++
+			ino = next
+			b.inodes[target] = ino
+		}
+		b.inodes[node] = ino
+		return nil
+	}, nil)
+}
+
+func (b *ncduBuilder) entries(parent *filetree.FileNode) []interface{} {
+	var names []string
+	for name := range parent.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		child := parent.Children[name]
+		if child.IsWhiteout() {
+			continue
+		}
+		entry := b.entry(child)
+		if child.Data.FileInfo.IsDir {
+			entries = append(entries, append([]interface{}{entry}, b.entries(child)...))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (b *ncduBuilder) entry(node *filetree.FileNode) ncduEntry {
+	info := node.Data.FileInfo
+	entry := ncduEntry{
+		Name:  node.Name,
+		Asize: uint64(info.Size),
+		Dsize: uint64(info.Size),
+		Uid:   info.Uid,
+		Gid:   info.Gid,
+		Mode:  ncduMode(info),
+	}
+	if info.Sparse {
+		entry.Dsize = uint64(info.StoredSize)
+	}
+	if !info.ModTime.IsZero() {
+		entry.Mtime = info.ModTime.Unix()
+	}
+	if ino, linked := b.inodes[node]; linked {
+		entry.Ino, entry.Hlnkc = ino, true
+		if info.TypeFlag == tar.TypeLink {
+			// the contents of a hardlink are those of its target (ncdu counts them once by inode)
+			if target, err := b.tree.ResolveLink(node); err == nil {
+				targetInfo := target.Data.FileInfo
+				entry.Asize, entry.Dsize = uint64(targetInfo.Size), uint64(targetInfo.Size)
+				if targetInfo.Sparse {
+					entry.Dsize = uint64(targetInfo.StoredSize)
+				}
+			}
+		}
+	} else {
+		entry.Notreg = !info.IsDir && info.TypeFlag != tar.TypeReg
+	}
+	return entry
+}
+
+// ncduMode returns the unix st_mode of the file (the file type and permission bits).
+func ncduMode(info filetree.FileInfo) uint32 {
+	mode := uint32(info.Mode.Perm())
+	if info.Mode&os.ModeSetuid != 0 {
+		mode |= 04000
+	}
+	if info.Mode&os.ModeSetgid != 0 {
+		mode |= 02000
+	}
+	if info.Mode&os.ModeSticky != 0 {
+		mode |= 01000
+	}
+	switch {
+	case info.IsDir || info.TypeFlag == tar.TypeDir:
+		mode |= 0040000
+	case info.TypeFlag == tar.TypeSymlink:
+		mode |= 0120000
+	case info.TypeFlag == tar.TypeChar:
+		mode |= 0020000
+	case info.TypeFlag == tar.TypeBlock:
+		mode |= 0060000
+	case info.TypeFlag == tar.TypeFifo:
+		mode |= 0010000
+	default:
+		mode |= 0100000
+	}
+	return mode
+}
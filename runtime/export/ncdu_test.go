@@ -0,0 +1,47 @@
+package export
+
+import (
+	"archive/tar"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func Test_MarshalNcdu(t *testing.T) {
+	tree := filetree.NewFileTree()
+	mtime := time.Unix(1700000000, 0)
+	infos := []struct {
+		path string
+		info filetree.FileInfo
+	}{
+		{"/usr", filetree.FileInfo{TypeFlag: tar.TypeDir, IsDir: true, Mode: os.ModeDir | 0755}},
+		{"/usr/bin", filetree.FileInfo{TypeFlag: tar.TypeDir, IsDir: true, Mode: os.ModeDir | 0755}},
+		{"/usr/bin/busybox", filetree.FileInfo{TypeFlag: tar.TypeReg, Size: 800, Mode: 0755, ModTime: mtime}},
+		{"/usr/bin/sh", filetree.FileInfo{TypeFlag: tar.TypeLink, Linkname: "usr/bin/busybox", Mode: 0755}},
+		{"/usr/lib", filetree.FileInfo{TypeFlag: tar.TypeSymlink, Linkname: "/lib", Mode: os.ModeSymlink | 0777}},
+		{"/var", filetree.FileInfo{TypeFlag: tar.TypeDir, IsDir: true, Mode: os.ModeDir | 0755}},
+		{"/var/.wh.cache", filetree.FileInfo{TypeFlag: tar.TypeReg}},
+	}
+	for _, item := range infos {
+		if _, _, err := tree.AddPath(item.path, item.info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	payload, err := MarshalNcdu(tree, "v1.0.0", time.Unix(1710000000, 0))
+	if err != nil {
+		t.Fatalf("unable to marshal: %v", err)
+	}
+	expected := `[1,2,{"progname":"dive","progver":"v1.0.0","timestamp":1710000000},[{"name":"/","uid":0,"gid":0,"mode":16877},` +
+		`[{"name":"usr","uid":0,"gid":0,"mode":16877},` +
+		`[{"name":"bin","uid":0,"gid":0,"mode":16877},` +
+		`{"name":"busybox","asize":800,"dsize":800,"ino":1,"hlnkc":true,"uid":0,"gid":0,"mode":33261,"mtime":1700000000},` +
+		`{"name":"sh","asize":800,"dsize":800,"ino":1,"hlnkc":true,"uid":0,"gid":0,"mode":33261}],` +
+		`{"name":"lib","notreg":true,"uid":0,"gid":0,"mode":41471}],` +
+		`[{"name":"var","uid":0,"gid":0,"mode":16877}]]]`
+	if string(payload) != expected {
+		t.Errorf("unexpected ncdu export:\n%s\nexpected:\n%s", payload, expected)
+	}
+}
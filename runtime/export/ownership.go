@@ -0,0 +1,54 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// ownership is the bytes of the final image and of every layer by owner.
+type ownership struct {
+	Image  ownershipSummary   `json:"image"`
+	Layers []ownershipSummary `json:"layers"`
+}
+
+type ownershipSummary struct {
+	Files       int          `json:"files"`
+	SizeBytes   uint64       `json:"sizeBytes"`
+	RootBytes   uint64       `json:"rootBytes"`
+	RootPercent float64      `json:"rootPercent"`
+	Owners      []ownerUsage `json:"owners"`
+}
+
+type ownerUsage struct {
+	Uid       int    `json:"uid"`
+	Gid       int    `json:"gid"`
+	Files     int    `json:"files"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
+
+func newOwnership(found *diveImage.Ownership) *ownership {
+	if found == nil {
+		return nil
+	}
+	result := &ownership{
+		Image:  newOwnershipSummary(found.Image),
+		Layers: make([]ownershipSummary, len(found.Layers)),
+	}
+	for idx, layer := range found.Layers {
+		result.Layers[idx] = newOwnershipSummary(layer)
+	}
+	return result
+}
+
+func newOwnershipSummary(summary *diveImage.OwnershipSummary) ownershipSummary {
+	result := ownershipSummary{
+		Files:       summary.Files,
+		SizeBytes:   summary.SizeBytes,
+		RootBytes:   summary.RootBytes,
+		RootPercent: summary.RootPercent(),
+		Owners:      make([]ownerUsage, len(summary.Owners)),
+	}
+	for idx, owner := range summary.Owners {
+		result.Owners[idx] = ownerUsage{Uid: owner.Uid, Gid: owner.Gid, Files: owner.Files, SizeBytes: owner.SizeBytes}
+	}
+	return result
+}
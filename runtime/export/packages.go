@@ -0,0 +1,57 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// packages are the packages installed in the image, and the files no package installed.
+type packages struct {
+	Managers    []string           `json:"managers"`
+	SizeBytes   uint64             `json:"sizeBytes"`
+	Packages    []installedPackage `json:"packages"`
+	OrphanBytes uint64             `json:"orphanBytes"`
+	Orphans     []orphanFile       `json:"orphans"`
+}
+
+type installedPackage struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Manager   string `json:"manager"`
+	SizeBytes uint64 `json:"sizeBytes"`
+	Files     int    `json:"files"`
+	// the last layer that wrote files of the package (-1 when none of its files are in the image)
+	Layer int `json:"layer"`
+}
+
+type orphanFile struct {
+	Path      string `json:"path"`
+	SizeBytes uint64 `json:"sizeBytes"`
+	Layer     int    `json:"layer"`
+}
+
+func newPackages(found *diveImage.Packages) *packages {
+	if found == nil {
+		return nil
+	}
+	result := &packages{
+		Managers:    found.Managers(),
+		SizeBytes:   found.SizeBytes(),
+		Packages:    make([]installedPackage, len(found.Packages)),
+		OrphanBytes: found.OrphanBytes(),
+		Orphans:     make([]orphanFile, len(found.Orphans)),
+	}
+	for idx, pkg := range found.Packages {
+		result.Packages[idx] = installedPackage{
+			Name:      pkg.Name,
+			Version:   pkg.Version,
+			Manager:   pkg.Manager,
+			SizeBytes: pkg.SizeBytes,
+			Files:     len(pkg.Files),
+			Layer:     pkg.Layer,
+		}
+	}
+	for idx, orphan := range found.Orphans {
+		result.Orphans[idx] = orphanFile(orphan)
+	}
+	return result
+}
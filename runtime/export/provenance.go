@@ -0,0 +1,38 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+type pathChange struct {
+	Layer     int    `json:"layer"`
+	Change    string `json:"change"`
+	SizeBytes uint64 `json:"sizeBytes"`
+	Command   string `json:"command"`
+}
+
+type pathProvenance struct {
+	Path    string       `json:"path"`
+	Present bool         `json:"present"`
+	Partial bool         `json:"partial,omitempty"`
+	Changes []pathChange `json:"changes"`
+}
+
+// NewPathProvenance converts the layers that touched a path into a serializable structure.
+func NewPathProvenance(provenance *diveImage.PathProvenance) *pathProvenance {
+	data := pathProvenance{
+		Path:    provenance.Path,
+		Present: provenance.Present,
+		Partial: provenance.Partial,
+		Changes: make([]pathChange, len(provenance.Changes)),
+	}
+	for idx, change := range provenance.Changes {
+		data.Changes[idx] = pathChange{
+			Layer:     change.Layer,
+			Change:    change.Change,
+			SizeBytes: change.SizeBytes,
+			Command:   change.Command,
+		}
+	}
+	return &data
+}
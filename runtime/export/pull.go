@@ -0,0 +1,58 @@
+package export
+
+import (
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// pull is the estimated pull time at every configured bandwidth, and the monthly registry egress.
+type pull struct {
+	Estimates []pullEstimate `json:"estimates"`
+	Egress    *egress        `json:"egress,omitempty"`
+}
+
+type pullEstimate struct {
+	BytesPerSecond uint64  `json:"bytesPerSecond"`
+	LayerLatency   float64 `json:"layerLatencySeconds"`
+	ColdSeconds    float64 `json:"coldSeconds"`
+	// the time to pull the layers that are not shared with the base image
+	WarmSeconds float64 `json:"warmSeconds"`
+}
+
+type egress struct {
+	Pulls            int     `json:"pullsPerMonth"`
+	PricePerGB       float64 `json:"pricePerGB"`
+	ColdBytes        uint64  `json:"coldBytesPerPull"`
+	WarmBytes        uint64  `json:"warmBytesPerPull"`
+	ColdMonthlyBytes uint64  `json:"coldBytesPerMonth"`
+	WarmMonthlyBytes uint64  `json:"warmBytesPerMonth"`
+	ColdMonthlyCost  float64 `json:"coldCostPerMonth"`
+	WarmMonthlyCost  float64 `json:"warmCostPerMonth"`
+}
+
+func newPull(cost *diveImage.PullCost) *pull {
+	if cost == nil {
+		return nil
+	}
+	result := &pull{Estimates: make([]pullEstimate, len(cost.Estimates))}
+	for idx, estimate := range cost.Estimates {
+		result.Estimates[idx] = pullEstimate{
+			BytesPerSecond: estimate.Profile.BytesPerSecond,
+			LayerLatency:   estimate.Profile.LayerLatency.Seconds(),
+			ColdSeconds:    estimate.Cold.Seconds(),
+			WarmSeconds:    estimate.Warm.Seconds(),
+		}
+	}
+	if found := cost.Egress; found != nil {
+		result.Egress = &egress{
+			Pulls:            found.Pulls,
+			PricePerGB:       found.PricePerGB,
+			ColdBytes:        found.ColdBytes,
+			WarmBytes:        found.WarmBytes,
+			ColdMonthlyBytes: found.MonthlyBytes(false),
+			WarmMonthlyBytes: found.MonthlyBytes(true),
+			ColdMonthlyCost:  found.MonthlyCost(false),
+			WarmMonthlyCost:  found.MonthlyCost(true),
+		}
+	}
+	return result
+}
@@ -0,0 +1,296 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// queryStep selects values from a JSON value: a field, an array element, every element (or field value), or one of
+// the functions (length, keys).
+type queryStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	iterate  bool
+	function string
+}
+
+// Query selects values from a JSON payload with a jq-like path (e.g. ".image.inefficientBytes", ".layer[0].sizeBytes",
+// ".layer[].command", ".layer | length") or a JSONPath (e.g. "$.layer[*].digestId"). Every selected value is rendered
+// on its own line: strings as is, everything else as JSON. Fields that do not exist select null (as with jq).
+func Query(payload []byte, expression string) ([]string, error) {
+	steps, err := parseQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	// keep integers (e.g. byte counts) exact
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("unable to parse the payload: %v", err)
+	}
+
+	values := []interface{}{document}
+	for _, step := range steps {
+		var selected []interface{}
+		for _, value := range values {
+			next, err := step.apply(value)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, next...)
+		}
+		values = selected
+	}
+
+	lines := make([]string, 0, len(values))
+	for _, value := range values {
+		line, err := renderQueryValue(value)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func (s queryStep) apply(value interface{}) ([]interface{}, error) {
+	switch {
+	case s.function == "length":
+		switch typed := value.(type) {
+		case []interface{}:
+			return []interface{}{json.Number(strconv.Itoa(len(typed)))}, nil
+		case map[string]interface{}:
+			return []interface{}{json.Number(strconv.Itoa(len(typed)))}, nil
+		case string:
+			return []interface{}{json.Number(strconv.Itoa(len([]rune(typed))))}, nil
+		case nil:
+			return []interface{}{json.Number("0")}, nil
+		}
+		return nil, fmt.Errorf("%s has no length", describeQueryValue(value))
+	case s.function == "keys":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s has no keys", describeQueryValue(value))
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, len(keys))
+		for idx, key := range keys {
+			result[idx] = key
+		}
+		return []interface{}{result}, nil
+	case s.iterate:
+		switch typed := value.(type) {
+		case []interface{}:
+			return typed, nil
+		case map[string]interface{}:
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			result := make([]interface{}, len(keys))
+			for idx, key := range keys {
+				result[idx] = typed[key]
+			}
+			return result, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", describeQueryValue(value))
+	case s.isIndex:
+		if value == nil {
+			return []interface{}{nil}, nil
+		}
+		array, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot index %s with a number", describeQueryValue(value))
+		}
+		index := s.index
+		if index < 0 {
+			index += len(array)
+		}
+		if index < 0 || index >= len(array) {
+			return []interface{}{nil}, nil
+		}
+		return []interface{}{array[index]}, nil
+	default:
+		if value == nil {
+			return []interface{}{nil}, nil
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot select the field %q of %s", s.field, describeQueryValue(value))
+		}
+		return []interface{}{object[s.field]}, nil
+	}
+}
+
+// parseQuery splits a query into its steps.
+func parseQuery(expression string) ([]queryStep, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	var steps []queryStep
+	for _, part := range splitQueryPipes(expression) {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "length", "keys":
+			steps = append(steps, queryStep{function: part})
+			continue
+		}
+		pathSteps, err := parseQueryPath(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %q: %v", expression, err)
+		}
+		steps = append(steps, pathSteps...)
+	}
+	return steps, nil
+}
+
+// splitQueryPipes splits a query on the pipes that are not within a quoted field name.
+func splitQueryPipes(expression string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for idx, char := range expression {
+		switch {
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote == 0 && (char == '"' || char == '\''):
+			quote = char
+		case quote == 0 && char == '|':
+			parts = append(parts, expression[start:idx])
+			start = idx + 1
+		}
+	}
+	return append(parts, expression[start:])
+}
+
+// parseQueryPath reads a path such as ".layer[0].sizeBytes" (or "$.layer[0].sizeBytes", "$['layer'][*]").
+func parseQueryPath(path string) ([]queryStep, error) {
+	if strings.HasPrefix(path, "$") {
+		path = path[1:]
+		if path == "" {
+			return nil, nil
+		}
+	} else if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("a path starts with '.' (or '$' for a JSONPath)")
+	}
+
+	var steps []queryStep
+	for pos := 0; pos < len(path); {
+		switch path[pos] {
+		case '.':
+			pos++
+			if pos >= len(path) || path[pos] == '[' {
+				// "." selects the value itself
+				continue
+			}
+			if path[pos] == '"' {
+				field, end, err := readQuotedField(path, pos)
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, queryStep{field: field})
+				pos = end
+				continue
+			}
+			if path[pos] == '*' {
+				steps = append(steps, queryStep{iterate: true})
+				pos++
+				continue
+			}
+			end := pos
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			steps = append(steps, queryStep{field: path[pos:end]})
+			pos = end
+		case '[':
+			closing := strings.IndexByte(path[pos:], ']')
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated '['")
+			}
+			inner := strings.TrimSpace(path[pos+1 : pos+closing])
+			switch {
+			case inner == "" || inner == "*":
+				steps = append(steps, queryStep{iterate: true})
+				pos += closing + 1
+			case inner[0] == '"' || inner[0] == '\'':
+				field, end, err := readQuotedField(path, pos+1)
+				if err != nil {
+					return nil, err
+				}
+				if end >= len(path) || path[end] != ']' {
+					return nil, fmt.Errorf("expected ']' after %q", field)
+				}
+				steps = append(steps, queryStep{field: field})
+				pos = end + 1
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid array index %q", inner)
+				}
+				steps = append(steps, queryStep{index: index, isIndex: true})
+				pos += closing + 1
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", path[pos:])
+		}
+	}
+	return steps, nil
+}
+
+// readQuotedField reads the quoted field name starting at the given position, returning it along with the position
+// after the closing quote.
+func readQuotedField(path string, pos int) (string, int, error) {
+	quote := path[pos]
+	end := strings.IndexByte(path[pos+1:], quote)
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated quote")
+	}
+	return path[pos+1 : pos+1+end], pos + end + 2, nil
+}
+
+// renderQueryValue renders a selected value: strings as is (as with "jq -r"), everything else as JSON.
+func renderQueryValue(value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case json.Number:
+		return typed.String(), nil
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	}
+	rendered, err := json.MarshalIndent(value, "", "  ")
+	return string(rendered), err
+}
+
+func describeQueryValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	payload := []byte(`{
+  "layer": [
+    {"index": 0, "sizeBytes": 1154361, "command": "ADD file in /"},
+    {"index": 1, "sizeBytes": 6405, "command": "ADD file in /somefile.txt"}
+  ],
+  "image": {"sizeBytes": 1220064, "efficiencyScore": 0.9844, "odd|name": true, "base": null}
+}`)
+
+	cases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: ".image.sizeBytes", expected: []string{"1220064"}},
+		{query: "$.image.efficiencyScore", expected: []string{"0.9844"}},
+		{query: ".layer[1].command", expected: []string{"ADD file in /somefile.txt"}},
+		{query: ".layer[-1].index", expected: []string{"1"}},
+		{query: ".layer[].sizeBytes", expected: []string{"1154361", "6405"}},
+		{query: "$.layer[*].index", expected: []string{"0", "1"}},
+		{query: "$['image']['sizeBytes']", expected: []string{"1220064"}},
+		{query: `.image["odd|name"]`, expected: []string{"true"}},
+		{query: ".layer | length", expected: []string{"2"}},
+		{query: ".image | keys", expected: []string{"[\n  \"base\",\n  \"efficiencyScore\",\n  \"odd|name\",\n  \"sizeBytes\"\n]"}},
+		{query: ".layer[0]", expected: []string{"{\n  \"command\": \"ADD file in /\",\n  \"index\": 0,\n  \"sizeBytes\": 1154361\n}"}},
+		// missing fields and elements select null, as with jq
+		{query: ".image.missing", expected: []string{"null"}},
+		{query: ".image.base.image", expected: []string{"null"}},
+		{query: ".layer[5]", expected: []string{"null"}},
+	}
+
+	for _, test := range cases {
+		actual, err := Query(payload, test.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %q, got %q", test.query, test.expected, actual)
+		}
+	}
+
+	for _, query := range []string{"", "image", ".layer[x]", ".layer[0", ".image.sizeBytes[0]", ".image.sizeBytes[]", ".image | keys | length | keys"} {
+		if _, err := Query(payload, query); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
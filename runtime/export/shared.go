@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/json"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// sharedExport tells which layers a set of images share, and which images could be rebased onto their common base.
+type sharedExport struct {
+	Images            []sharedImage `json:"images"`
+	Layers            []sharedLayer `json:"layers"`
+	TotalBytes        uint64        `json:"totalBytes"`
+	DeduplicatedBytes uint64        `json:"deduplicatedBytes"`
+	SavedBytes        uint64        `json:"savedBytes"`
+	// the number of distinct layers, including those beyond the listed ones
+	LayerCount int         `json:"layerCount"`
+	CommonBase *commonBase `json:"commonBase,omitempty"`
+}
+
+type sharedImage struct {
+	Name        string `json:"name"`
+	Layers      int    `json:"layers"`
+	SizeBytes   uint64 `json:"sizeBytes"`
+	SharedBytes uint64 `json:"sharedBytes"`
+	UniqueBytes uint64 `json:"uniqueBytes"`
+}
+
+type sharedLayer struct {
+	DigestID  string `json:"digestId"`
+	DiffID    string `json:"diffId"`
+	SizeBytes uint64 `json:"sizeBytes"`
+	Command   string `json:"command"`
+	// the names of the images that have the layer
+	Images []string `json:"images"`
+}
+
+type commonBase struct {
+	Layers []sharedLayer    `json:"layers"`
+	Images []string         `json:"images"`
+	Rebase []rebaseProposal `json:"rebase"`
+}
+
+type rebaseProposal struct {
+	Image          string `json:"image"`
+	MatchingLayers int    `json:"matchingLayers"`
+	SavedBytes     uint64 `json:"savedBytes"`
+}
+
+// NewShared builds the export of the shared layer analysis of a set of images, listing at most limit layers (all of
+// them when the limit is 0).
+func NewShared(analysis *diveImage.SharedAnalysis, limit int) *sharedExport {
+	names := func(indexes []int) []string {
+		result := make([]string, len(indexes))
+		for idx, imageIdx := range indexes {
+			result[idx] = analysis.Images[imageIdx].Name
+		}
+		return result
+	}
+	layers := func(shared []diveImage.SharedLayer) []sharedLayer {
+		result := make([]sharedLayer, len(shared))
+		for idx, layer := range shared {
+			result[idx] = sharedLayer{DigestID: layer.Digest, DiffID: layer.DiffID, SizeBytes: layer.SizeBytes, Command: layer.Command, Images: names(layer.Images)}
+		}
+		return result
+	}
+
+	listed := analysis.Layers
+	if limit > 0 && len(listed) > limit {
+		listed = listed[:limit]
+	}
+	data := sharedExport{
+		Images:            make([]sharedImage, len(analysis.Images)),
+		Layers:            layers(listed),
+		TotalBytes:        analysis.TotalBytes,
+		DeduplicatedBytes: analysis.DeduplicatedBytes,
+		SavedBytes:        analysis.SavedBytes(),
+		LayerCount:        len(analysis.Layers),
+	}
+	for idx, summary := range analysis.Images {
+		data.Images[idx] = sharedImage{Name: summary.Name, Layers: summary.Layers, SizeBytes: summary.SizeBytes, SharedBytes: summary.SharedBytes, UniqueBytes: summary.UniqueBytes}
+	}
+	if len(analysis.CommonBase) > 0 {
+		data.CommonBase = &commonBase{
+			Layers: layers(analysis.CommonBase),
+			Images: names(analysis.CommonBaseImages),
+			Rebase: make([]rebaseProposal, len(analysis.Rebase)),
+		}
+		for idx, candidate := range analysis.Rebase {
+			data.CommonBase.Rebase[idx] = rebaseProposal{Image: analysis.Images[candidate.Image].Name, MatchingLayers: candidate.MatchingLayers, SavedBytes: candidate.SavedBytes}
+		}
+	}
+	return &data
+}
+
+func (exp *sharedExport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(&exp, "", "  ")
+}
@@ -0,0 +1,19 @@
+package export
+
+type storage struct {
+	Rating           string            `json:"rating"`
+	LayerDepth       int               `json:"layerDepth"`
+	TotalEntries     int               `json:"layerEntries"`
+	UniqueEntries    int               `json:"uniqueEntries"`
+	ShadowedEntries  int               `json:"shadowedEntries"`
+	MetadataBytes    uint64            `json:"estimatedMetadataBytes"`
+	CopyUpBytes      uint64            `json:"copyUpBytes"`
+	CopyUpCandidates []copyUpCandidate `json:"copyUpCandidates"`
+	Reasons          []string          `json:"notes"`
+}
+
+type copyUpCandidate struct {
+	Path      string `json:"file"`
+	Revisions int    `json:"revisions"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/phayes/permbits"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+type fileNode struct {
+	Path      string      `json:"path"`
+	Name      string      `json:"name"`
+	SizeBytes uint64      `json:"sizeBytes"`
+	Mode      string      `json:"mode"`
+	Uid       int         `json:"uid"`
+	Gid       int         `json:"gid"`
+	IsDir     bool        `json:"isDir"`
+	Linkname  string      `json:"linkName,omitempty"`
+	DiffType  string      `json:"diffType"`
+	Children  []*fileNode `json:"children,omitempty"`
+
+	Capabilities []string `json:"capabilities,omitempty"`
+	// xattr values are hex encoded when not printable (see filetree.FormatXattrValue)
+	Xattrs     map[string]string `json:"xattrs,omitempty"`
+	PAXRecords map[string]string `json:"paxRecords,omitempty"`
+}
+
+// NewFileTree converts the given tree into a nested, serializable structure. Directory sizes are the sum of all
+// non-removed files beneath them.
+func NewFileTree(tree *filetree.FileTree) []*fileNode {
+	return NewFileTreeDepth(tree, 0)
+}
+
+// NewFileTreeDepth converts the given tree like NewFileTree, leaving out the paths deeper than the given depth (1 is the
+// top-level directories, 0 is unlimited). Directory sizes still include everything beneath them.
+func NewFileTreeDepth(tree *filetree.FileTree, depth int) []*fileNode {
+	nodes, _ := newFileNodes(tree.Root, depth)
+	return nodes
+}
+
+func newFileNodes(parent *filetree.FileNode, depth int) ([]*fileNode, uint64) {
+	var names []string
+	for name := range parent.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var total uint64
+	nodes := make([]*fileNode, 0, len(names))
+	for _, name := range names {
+		child := parent.Children[name]
+		node := &fileNode{
+			Path:      child.Path(),
+			Name:      child.Name,
+			SizeBytes: uint64(child.Data.FileInfo.Size),
+			Mode:      permbits.FileMode(child.Data.FileInfo.Mode).String(),
+			Uid:       child.Data.FileInfo.Uid,
+			Gid:       child.Data.FileInfo.Gid,
+			IsDir:     child.Data.FileInfo.IsDir,
+			Linkname:  child.Data.FileInfo.Linkname,
+			DiffType:  child.Data.DiffType.String(),
+
+			Capabilities: child.Data.FileInfo.Capabilities,
+			Xattrs:       formatXattrs(child.Data.FileInfo.Xattrs),
+			PAXRecords:   formatXattrs(child.Data.FileInfo.PAXRecords),
+		}
+		if len(child.Children) > 0 {
+			node.Children, node.SizeBytes = newFileNodes(child, depth-1)
+			if depth == 1 {
+				node.Children = nil
+			}
+		}
+		if child.Data.DiffType != filetree.Removed {
+			total += node.SizeBytes
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, total
+}
+
+// MarshalFileTree returns the JSON representation of the given tree.
+func MarshalFileTree(tree *filetree.FileTree) ([]byte, error) {
+	return MarshalFileTreeDepth(tree, 0)
+}
+
+// MarshalFileTreeDepth returns the JSON representation of the given tree up to the given depth (see NewFileTreeDepth).
+func MarshalFileTreeDepth(tree *filetree.FileTree, depth int) ([]byte, error) {
+	return json.MarshalIndent(NewFileTreeDepth(tree, depth), "", "  ")
+}
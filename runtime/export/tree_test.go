@@ -0,0 +1,38 @@
+package export
+
+import (
+	"archive/tar"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func Test_NewFileTreeDepth(t *testing.T) {
+	tree := filetree.NewFileTree()
+	infos := map[string]filetree.FileInfo{
+		"/usr/lib/libc.so": {TypeFlag: tar.TypeReg, Size: 100},
+		"/usr/lib/libm.so": {TypeFlag: tar.TypeReg, Size: 20},
+		"/usr/bin/sh":      {TypeFlag: tar.TypeReg, Size: 3},
+	}
+	for path, info := range infos {
+		if _, _, err := tree.AddPath(path, info); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	nodes := NewFileTreeDepth(tree, 2)
+	if len(nodes) != 1 || nodes[0].Path != "/usr" || nodes[0].SizeBytes != 123 {
+		t.Fatalf("unexpected top-level nodes: %+v", nodes)
+	}
+	usr := nodes[0].Children
+	if len(usr) != 2 || usr[0].Path != "/usr/bin" || usr[1].Path != "/usr/lib" {
+		t.Fatalf("unexpected children of /usr: %+v", usr)
+	}
+	if usr[1].Children != nil || usr[1].SizeBytes != 120 {
+		t.Errorf("expected /usr/lib without children and with the size of its files, got %+v", usr[1])
+	}
+
+	if all := NewFileTreeDepth(tree, 0); len(all[0].Children[1].Children) != 2 {
+		t.Errorf("expected every path without a depth, got %+v", all[0].Children[1])
+	}
+}
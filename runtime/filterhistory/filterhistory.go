@@ -0,0 +1,99 @@
+package filterhistory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// MaxEntries is the most filters remembered (the oldest are forgotten first).
+const MaxEntries = 50
+
+// Store persists the path filters typed in the UI, so that they can be recalled in later sessions.
+type Store struct {
+	path string
+}
+
+type document struct {
+	// the filters, the most recent first
+	Filters []string `json:"filters"`
+}
+
+// NewStore creates a store backed by the given file (which is created on the first save).
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath is the filter history file within the user configuration directory.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "dive", "filter-history.json"), nil
+}
+
+// NewDefaultStore creates a store backed by the default filter history file.
+func NewDefaultStore() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(path), nil
+}
+
+// Load returns the remembered filters, the most recent first.
+func (s *Store) Load() ([]string, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Filters, nil
+}
+
+// Add remembers the given filter as the most recent one (moving it to the front if it was already remembered), and
+// returns the remembered filters.
+func (s *Store) Add(filter string) ([]string, error) {
+	doc, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	doc.Filters = Prepend(doc.Filters, filter)
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, err
+	}
+	return doc.Filters, ioutil.WriteFile(s.path, content, 0644)
+}
+
+// Prepend puts the filter in front of the (most recent first) filters, dropping its earlier occurrence and the filters
+// beyond MaxEntries.
+func Prepend(filters []string, filter string) []string {
+	result := []string{filter}
+	for _, existing := range filters {
+		if existing != filter && len(result) < MaxEntries {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
+
+func (s *Store) read() (*document, error) {
+	doc := &document{}
+	content, err := ioutil.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(content, doc); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
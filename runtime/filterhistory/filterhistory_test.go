@@ -0,0 +1,52 @@
+package filterhistory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dive-filter-history")
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "nested", "filter-history.json"))
+
+	filters, err := store.Load()
+	if err != nil || len(filters) != 0 {
+		t.Fatalf("expected no filters before the first save, got %v (%v)", filters, err)
+	}
+
+	for _, filter := range []string{`\.pyc$`, "etc/nginx", `\.pyc$`} {
+		if _, err := store.Add(filter); err != nil {
+			t.Fatalf("unable to save: %v", err)
+		}
+	}
+
+	filters, err = NewStore(filepath.Join(dir, "nested", "filter-history.json")).Load()
+	if err != nil {
+		t.Fatalf("unable to load: %v", err)
+	}
+	if expected := []string{`\.pyc$`, "etc/nginx"}; !reflect.DeepEqual(filters, expected) {
+		t.Errorf("expected %v, got %v", expected, filters)
+	}
+}
+
+func TestPrepend(t *testing.T) {
+	var filters []string
+	for idx := 0; idx < MaxEntries+5; idx++ {
+		filters = Prepend(filters, fmt.Sprintf("filter-%d", idx))
+	}
+	if len(filters) != MaxEntries {
+		t.Fatalf("expected %d filters, got %d", MaxEntries, len(filters))
+	}
+	if filters[0] != fmt.Sprintf("filter-%d", MaxEntries+4) || filters[MaxEntries-1] != "filter-5" {
+		t.Errorf("expected the most recent filters first, got %v", filters)
+	}
+}
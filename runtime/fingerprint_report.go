@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// FingerprintReport renders the fingerprint of an image: the digest of the whole filesystem, then the digest of every
+// subtree down to the given depth (with the number of files and bytes within).
+func FingerprintReport(fingerprint *image.Fingerprint, depth int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Fingerprint:"))
+	fmt.Fprintf(&sb, "  image: %s\n", fingerprint.Image)
+	fmt.Fprintf(&sb, "  digest: %s\n", fingerprint.Digest)
+	fmt.Fprintf(&sb, "  paths: %d\n", len(fingerprint.Entries))
+	for _, entry := range fingerprint.Subtrees(depth) {
+		if entry.Path == "/" {
+			continue
+		}
+		files := ""
+		if entry.Type == image.FingerprintDir {
+			files = fmt.Sprintf("%d files", entry.Files)
+		}
+		fmt.Fprintf(&sb, "    %s  %10s  %10s  %s\n", shortDigest(entry.Digest), files, humanize.Bytes(uint64(entry.Size)), entry.Path)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// FingerprintDriftReport renders the paths that differ between the fingerprints of two images, listing at most limit
+// paths (all of them when the limit is 0).
+func FingerprintDriftReport(previous, current *image.Fingerprint, drifts []image.FingerprintDrift, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Fingerprint Drift:"))
+	fmt.Fprintf(&sb, "  images: %s -> %s\n", previous.Image, current.Image)
+	fmt.Fprintf(&sb, "  digest: %s -> %s\n", shortDigest(previous.Digest), shortDigest(current.Digest))
+	if len(drifts) == 0 {
+		fmt.Fprint(&sb, "  the filesystems are identical")
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "  paths: %d differ\n", len(drifts))
+	shown := drifts
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, drift := range shown {
+		line := fmt.Sprintf("    %-8s  %10s  %s", drift.Change, signedBytes(drift.Bytes-drift.PreviousBytes), drift.Path)
+		if len(drift.Reasons) > 0 {
+			line += " (" + strings.Join(drift.Reasons, ", ") + ")"
+		}
+		fmt.Fprintln(&sb, line)
+	}
+	if len(drifts) > len(shown) {
+		fmt.Fprintf(&sb, "    ... and %d more\n", len(drifts)-len(shown))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// shortDigest abbreviates a digest to its first 12 hex digits, as engines show image ids.
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
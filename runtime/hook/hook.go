@@ -0,0 +1,165 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// the events hooks are run on
+const (
+	// the image has been analyzed (in every mode: UI, CI, export)
+	AnalysisComplete = "analysis-complete"
+	// a layer has been selected in the UI
+	LayerSelected = "layer-selected"
+	// a file of the image has been extracted to be opened in the pager or editor
+	FileExtracted = "file-extracted"
+)
+
+// Events lists the events hooks can be configured for (as hooks.<event> in the config).
+var Events = []string{AnalysisComplete, LayerSelected, FileExtracted}
+
+// DefaultTimeout is how long a hook may run before it is stopped, unless hooks.timeout says otherwise.
+const DefaultTimeout = 30 * time.Second
+
+// Payload is the JSON document a hook reads on stdin.
+type Payload struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Image string    `json:"image"`
+	// the details of the event (see the payloads of each event, e.g. LayerPayload)
+	Data interface{} `json:"data"`
+}
+
+// AnalysisPayload details the analysis-complete event.
+type AnalysisPayload struct {
+	ImageID     string  `json:"imageId,omitempty"`
+	Layers      int     `json:"layers"`
+	SizeBytes   uint64  `json:"sizeBytes"`
+	WastedBytes uint64  `json:"wastedBytes"`
+	Efficiency  float64 `json:"efficiency"`
+}
+
+// LayerPayload details the layer-selected event.
+type LayerPayload struct {
+	Index     int    `json:"index"`
+	Digest    string `json:"digest"`
+	Command   string `json:"command"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
+
+// FilePayload details the file-extracted event.
+type FilePayload struct {
+	// the path of the file within the image, and the layer it was extracted as of
+	Path  string `json:"path"`
+	Layer int    `json:"layer"`
+	// where the file was extracted to (removed once the hooks are done with it)
+	ExtractedPath string `json:"extractedPath"`
+}
+
+// the hooks running, waited for before dive exits
+var running sync.WaitGroup
+
+// Commands returns the commands configured for the event: a single command, or a list of commands.
+func Commands(event string) []string {
+	var commands []string
+	switch value := viper.Get("hooks." + event).(type) {
+	case string:
+		commands = append(commands, value)
+	case []string:
+		commands = append(commands, value...)
+	case []interface{}:
+		for _, command := range value {
+			commands = append(commands, fmt.Sprint(command))
+		}
+	}
+
+	result := commands[:0]
+	for _, command := range commands {
+		if command = strings.TrimSpace(command); command != "" {
+			result = append(result, command)
+		}
+	}
+	return result
+}
+
+// Fire runs the commands configured for the event in the background, each given the payload as JSON on stdin (and the
+// event name in $DIVE_HOOK_EVENT). Their output is logged, as are their failures, which do not affect dive. The
+// returned channel is closed once they are done.
+func Fire(event, image string, data interface{}) <-chan struct{} {
+	done := make(chan struct{})
+	commands := Commands(event)
+	if len(commands) == 0 {
+		close(done)
+		return done
+	}
+
+	payload, err := json.Marshal(Payload{Event: event, Time: time.Now().UTC(), Image: image, Data: data})
+	if err != nil {
+		logrus.Errorf("unable to encode the %s hook payload: %+v", event, err)
+		close(done)
+		return done
+	}
+
+	timeout := viper.GetDuration("hooks.timeout")
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		defer close(done)
+		for _, command := range commands {
+			if err := run(event, command, payload, timeout); err != nil {
+				logrus.Warnf("the %s hook %q failed: %v", event, command, err)
+			}
+		}
+	}()
+	return done
+}
+
+// Wait waits for the hooks running to finish (each is stopped once it runs for longer than its timeout).
+func Wait() {
+	running.Wait()
+}
+
+func run(event, command string, payload []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), "DIVE_HOOK_EVENT="+event)
+	cmd.Stdin = bytes.NewReader(payload)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	// the processes the hook started may hold on to its output once it is stopped
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if text := strings.TrimSpace(output.String()); text != "" {
+		logrus.Debugf("the %s hook %q wrote: %s", event, command, text)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("stopped after %s", timeout)
+	}
+	return err
+}
+
+// shellCommand runs the command with the shell of the platform, so that hooks can use pipes and variables.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,72 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestCommands(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set("hooks.analysis-complete", "notify-send dive done")
+	viper.Set("hooks.layer-selected", []interface{}{"echo one", " ", "echo two"})
+	if actual := Commands(AnalysisComplete); !reflect.DeepEqual(actual, []string{"notify-send dive done"}) {
+		t.Errorf("expected the single command, got %v", actual)
+	}
+	if actual := Commands(LayerSelected); !reflect.DeepEqual(actual, []string{"echo one", "echo two"}) {
+		t.Errorf("expected both commands, got %v", actual)
+	}
+	if actual := Commands(FileExtracted); len(actual) != 0 {
+		t.Errorf("expected no commands, got %v", actual)
+	}
+}
+
+func TestFire(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	defer viper.Reset()
+
+	output := filepath.Join(t.TempDir(), "payload.json")
+	viper.Set("hooks.layer-selected", fmt.Sprintf(`test "$DIVE_HOOK_EVENT" = layer-selected && cat > %q`, output))
+	<-Fire(LayerSelected, "alpine:latest", LayerPayload{Index: 2, Digest: "sha256:abc"})
+
+	contents, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected the hook to write the payload: %v", err)
+	}
+	var payload struct {
+		Payload
+		Data LayerPayload `json:"data"`
+	}
+	if err := json.Unmarshal(contents, &payload); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload.Event != LayerSelected || payload.Image != "alpine:latest" || payload.Data.Index != 2 || payload.Data.Digest != "sha256:abc" {
+		t.Errorf("unexpected payload: %s", contents)
+	}
+}
+
+func TestFire_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	defer viper.Reset()
+
+	viper.Set("hooks.analysis-complete", "sleep 10")
+	viper.Set("hooks.timeout", "100ms")
+	started := time.Now()
+	Fire(AnalysisComplete, "alpine:latest", AnalysisPayload{})
+	Wait()
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("expected the hook to be stopped after its timeout, it ran for %s", elapsed)
+	}
+}
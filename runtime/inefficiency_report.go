@@ -0,0 +1,29 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/utils"
+)
+
+// inefficiencyReport renders the files wasting space, most wasteful first (the same listing the CI results are
+// reported with).
+func inefficiencyReport(inefficiencies filetree.EfficiencySlice) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Inefficient Files:"))
+
+	template := "%5s  %12s  %-s\n"
+	fmt.Fprintf(&sb, template, "Count", "Wasted Space", "File Path")
+	if len(inefficiencies) == 0 {
+		fmt.Fprintln(&sb, "None")
+	}
+	for idx := len(inefficiencies) - 1; idx >= 0; idx-- {
+		data := inefficiencies[idx]
+		fmt.Fprintf(&sb, template, strconv.Itoa(len(data.Nodes)), humanize.Bytes(uint64(data.CumulativeSize)), data.Path)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
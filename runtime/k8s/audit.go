@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// Analyzer fetches and analyzes a single image.
+type Analyzer func(ctx context.Context, image string) (*image.AnalysisResult, error)
+
+// Options describes the workload to audit.
+type Options struct {
+	// the workload reference ("<pod>" or "<kind>/<name>")
+	Workload string
+	// the namespace of the workload (the kubeconfig namespace is used when empty)
+	Namespace string
+	Kubectl   Kubectl
+	Analyze   Analyzer
+}
+
+// ImageResult is the analysis of one image run by the workload.
+type ImageResult struct {
+	Image    string
+	Analysis *image.AnalysisResult
+	Err      error
+}
+
+// Audit analyzes every image run by the workload. The images are pulled with the workload's image pull secrets: the
+// credentials are written to a temporary docker config which the container engine clients pick up from the
+// environment for the duration of the audit.
+func Audit(ctx context.Context, options Options) (*Workload, []ImageResult, error) {
+	workload, err := ResolveWorkload(ctx, options.Kubectl, options.Workload, options.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(workload.PullSecrets) > 0 {
+		auths, err := pullSecretAuths(ctx, options.Kubectl, options.Namespace, workload.PullSecrets)
+		if err != nil {
+			return nil, nil, err
+		}
+		dir, err := writeDockerConfig(auths)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to write registry credentials: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		restore := setEnv(map[string]string{
+			"DOCKER_CONFIG":      dir,
+			"REGISTRY_AUTH_FILE": filepath.Join(dir, "config.json"),
+		})
+		defer restore()
+	}
+
+	results := make([]ImageResult, 0, len(workload.Images))
+	for _, img := range workload.Images {
+		logrus.Debugf("analyzing %s", img)
+		analysis, err := options.Analyze(ctx, img)
+		results = append(results, ImageResult{Image: img, Analysis: analysis, Err: err})
+	}
+	return workload, results, nil
+}
+
+// setEnv sets the given environment variables, returning a function that restores their previous values.
+func setEnv(values map[string]string) func() {
+	previous := make(map[string]*string)
+	for key, value := range values {
+		if existing, exists := os.LookupEnv(key); exists {
+			previous[key] = &existing
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, value := range previous {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+	}
+}
+
+// Passed indicates if every image of the workload could be analyzed.
+func Passed(results []ImageResult) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Report renders a summary line for every image run by the workload.
+func Report(workload *Workload, results []ImageResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s/%s runs %d image(s):\n", workload.Kind, workload.Namespace, workload.Name, len(results))
+
+	for _, result := range results {
+		fmt.Fprintf(&sb, "  %s\n", result.Image)
+		if result.Err != nil {
+			fmt.Fprintf(&sb, "    error: %v\n", result.Err)
+			continue
+		}
+		analysis := result.Analysis
+		fmt.Fprintf(&sb, "    size: %s  layers: %d  efficiency: %2.4f %%  wasted: %s (%2.4f %%)\n",
+			humanize.Bytes(analysis.SizeBytes),
+			len(analysis.Layers),
+			100.0*analysis.Efficiency,
+			humanize.Bytes(analysis.WastedBytes),
+			100.0*analysis.WastedUserPercent)
+	}
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// fakeKubectl answers "get <object>" commands from the given objects (keyed by "<kind>/<name>").
+func fakeKubectl(objects map[string]string) Kubectl {
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		for idx, arg := range args {
+			if arg == "get" && idx+1 < len(args) {
+				if object, exists := objects[args[idx+1]]; exists {
+					return []byte(object), nil
+				}
+				return nil, fmt.Errorf("%s not found", args[idx+1])
+			}
+		}
+		return nil, fmt.Errorf("unexpected command: %v", args)
+	}
+}
+
+func encode(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+var testObjects = map[string]string{
+	"deployment/web": `{
+		"kind": "Deployment",
+		"metadata": {"name": "web", "namespace": "prod"},
+		"spec": {"template": {"spec": {
+			"serviceAccountName": "web",
+			"imagePullSecrets": [{"name": "registry"}],
+			"initContainers": [{"image": "busybox:1.36"}],
+			"containers": [{"image": "registry.example.com/web:1.2"}, {"image": "busybox:1.36"}]
+		}}}
+	}`,
+	"cronjob/backup": `{
+		"kind": "CronJob",
+		"metadata": {"name": "backup", "namespace": "prod"},
+		"spec": {"jobTemplate": {"spec": {"template": {"spec": {
+			"containers": [{"image": "backup:2"}]
+		}}}}}
+	}`,
+	"pod/api": `{
+		"kind": "Pod",
+		"metadata": {"name": "api", "namespace": "prod"},
+		"spec": {"containers": [{"image": "api:3"}]}
+	}`,
+	"serviceaccount/web":     `{"imagePullSecrets": [{"name": "registry"}, {"name": "legacy"}]}`,
+	"serviceaccount/default": `{}`,
+	"secret/registry": `{"data": {".dockerconfigjson": "` +
+		encode(`{"auths": {"registry.example.com": {"auth": "cHJvZDpzZWNyZXQ="}}}`) + `"}}`,
+	"secret/legacy": `{"data": {".dockercfg": "` +
+		encode(`{"registry.example.com": {"auth": "b2xkOnNlY3JldA=="}, "legacy.example.com": {"auth": "b2xkOnNlY3JldA=="}}`) + `"}}`,
+}
+
+func TestResolveWorkload(t *testing.T) {
+	cases := map[string]struct {
+		ref         string
+		images      []string
+		pullSecrets []string
+	}{
+		"deployment": {
+			ref:         "deployment/web",
+			images:      []string{"busybox:1.36", "registry.example.com/web:1.2"},
+			pullSecrets: []string{"registry", "legacy"},
+		},
+		"cronjob": {
+			ref:    "CronJob/backup",
+			images: []string{"backup:2"},
+		},
+		"bare pod name": {
+			ref:    "api",
+			images: []string{"api:3"},
+		},
+	}
+
+	for name, test := range cases {
+		workload, err := ResolveWorkload(context.Background(), fakeKubectl(testObjects), test.ref, "prod")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !reflect.DeepEqual(workload.Images, test.images) {
+			t.Errorf("%s: expected images %v, got %v", name, test.images, workload.Images)
+		}
+		if !reflect.DeepEqual(workload.PullSecrets, test.pullSecrets) {
+			t.Errorf("%s: expected pull secrets %v, got %v", name, test.pullSecrets, workload.PullSecrets)
+		}
+	}
+
+	if _, err := ResolveWorkload(context.Background(), fakeKubectl(testObjects), "deployment/", "prod"); err == nil {
+		t.Errorf("expected an error for a workload without a name")
+	}
+}
+
+func TestPullSecretAuths(t *testing.T) {
+	auths, err := pullSecretAuths(context.Background(), fakeKubectl(testObjects), "prod", []string{"registry", "legacy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 registries, got %d", len(auths))
+	}
+	// the first secret wins
+	if !strings.Contains(string(auths["registry.example.com"]), "cHJvZDpzZWNyZXQ=") {
+		t.Errorf("unexpected credentials for registry.example.com: %s", auths["registry.example.com"])
+	}
+	if _, exists := auths["legacy.example.com"]; !exists {
+		t.Errorf("expected credentials from the legacy secret")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	previous, wasSet := os.LookupEnv("DOCKER_CONFIG")
+
+	var analyzed []string
+	analyze := func(ctx context.Context, img string) (*image.AnalysisResult, error) {
+		analyzed = append(analyzed, img)
+		config, err := ioutil.ReadFile(filepath.Join(os.Getenv("DOCKER_CONFIG"), "config.json"))
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(string(config), "registry.example.com") {
+			return nil, fmt.Errorf("missing credentials: %s", config)
+		}
+		return &image.AnalysisResult{SizeBytes: 1000, Efficiency: 1}, nil
+	}
+
+	workload, results, err := Audit(context.Background(), Options{
+		Workload:  "deployment/web",
+		Namespace: "prod",
+		Kubectl:   fakeKubectl(testObjects),
+		Analyze:   analyze,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Passed(results) {
+		t.Errorf("expected every image to be analyzed: %+v", results)
+	}
+	if !reflect.DeepEqual(analyzed, workload.Images) {
+		t.Errorf("expected %v to be analyzed, got %v", workload.Images, analyzed)
+	}
+
+	current, isSet := os.LookupEnv("DOCKER_CONFIG")
+	if isSet != wasSet || current != previous {
+		t.Errorf("expected DOCKER_CONFIG to be restored, got %q", current)
+	}
+
+	report := Report(workload, results)
+	if !strings.Contains(report, "Deployment prod/web runs 2 image(s)") || !strings.Contains(report, "registry.example.com/web:1.2") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
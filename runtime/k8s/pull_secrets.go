@@ -0,0 +1,87 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// dockerConfig is the subset of a docker CLI config.json that holds registry credentials.
+type dockerConfig struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+// pullSecretAuths reads the registry credentials from the given image pull secrets (of either the
+// kubernetes.io/dockerconfigjson or the legacy kubernetes.io/dockercfg type). When several secrets hold credentials for
+// the same registry, the first one wins (as it does for the kubelet).
+func pullSecretAuths(ctx context.Context, kubectl Kubectl, namespace string, names []string) (map[string]json.RawMessage, error) {
+	auths := make(map[string]json.RawMessage)
+
+	for _, name := range names {
+		output, err := kubectl(ctx, namespaceArgs(namespace, "get", "secret/"+name, "-o", "json")...)
+		if err != nil {
+			return nil, err
+		}
+
+		var secret struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal(output, &secret); err != nil {
+			return nil, fmt.Errorf("unable to parse secret/%s: %v", name, err)
+		}
+
+		var secretAuths map[string]json.RawMessage
+		if encoded, exists := secret.Data[".dockerconfigjson"]; exists {
+			var config dockerConfig
+			if err := decodeSecretJSON(encoded, &config); err != nil {
+				return nil, fmt.Errorf("unable to read secret/%s: %v", name, err)
+			}
+			secretAuths = config.Auths
+		} else if encoded, exists := secret.Data[".dockercfg"]; exists {
+			// the legacy format is the auths map itself
+			if err := decodeSecretJSON(encoded, &secretAuths); err != nil {
+				return nil, fmt.Errorf("unable to read secret/%s: %v", name, err)
+			}
+		} else {
+			return nil, fmt.Errorf("secret/%s does not hold registry credentials", name)
+		}
+
+		for registry, auth := range secretAuths {
+			if _, exists := auths[registry]; !exists {
+				auths[registry] = auth
+			}
+		}
+	}
+	return auths, nil
+}
+
+func decodeSecretJSON(encoded string, value interface{}) error {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, value)
+}
+
+// writeDockerConfig writes the credentials to a docker CLI config directory (for use as DOCKER_CONFIG), which the
+// caller must remove.
+func writeDockerConfig(auths map[string]json.RawMessage) (string, error) {
+	dir, err := ioutil.TempDir("", "dive-k8s-")
+	if err != nil {
+		return "", err
+	}
+
+	content, err := json.Marshal(dockerConfig{Auths: auths})
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "config.json"), content, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
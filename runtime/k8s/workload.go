@@ -0,0 +1,217 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// Kubectl runs a kubectl command and returns its output.
+type Kubectl func(ctx context.Context, args ...string) ([]byte, error)
+
+// NewKubectl creates a runner for the kubectl CLI, which reads the cluster configuration from the kubeconfig (the given
+// file and context, or the kubectl defaults when empty). The cluster is not reached when the network policy is offline.
+func NewKubectl(kubeconfig, kubeContext string, network image.NetworkPolicy) Kubectl {
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		// the cluster is reached over the network
+		if err := network.RequireNetwork("kubectl " + strings.Join(args, " ")); err != nil {
+			return nil, err
+		}
+		if _, err := exec.LookPath("kubectl"); err != nil {
+			return nil, fmt.Errorf("cannot find kubectl executable")
+		}
+
+		var globalArgs []string
+		if kubeconfig != "" {
+			globalArgs = append(globalArgs, "--kubeconfig", kubeconfig)
+		}
+		if kubeContext != "" {
+			globalArgs = append(globalArgs, "--context", kubeContext)
+		}
+
+		cmd := exec.CommandContext(ctx, "kubectl", append(globalArgs, args...)...)
+		cmd.Env = os.Environ()
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("kubectl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		}
+		return output, nil
+	}
+}
+
+// Workload is a pod or a pod controller along with the images it runs.
+type Workload struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// the container images (init containers first), without duplicates
+	Images []string
+	// the names of the secrets used to pull the images
+	PullSecrets []string
+}
+
+type podSpec struct {
+	ServiceAccountName string `json:"serviceAccountName"`
+	InitContainers     []struct {
+		Image string `json:"image"`
+	} `json:"initContainers"`
+	Containers []struct {
+		Image string `json:"image"`
+	} `json:"containers"`
+	ImagePullSecrets []struct {
+		Name string `json:"name"`
+	} `json:"imagePullSecrets"`
+}
+
+type podTemplate struct {
+	Spec podSpec `json:"spec"`
+}
+
+// workloadObject holds the pod spec of any workload kind: directly (pods), within a template (deployments, stateful
+// sets, daemon sets, replica sets and jobs) or within a job template (cron jobs).
+type workloadObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		podSpec
+		Template    podTemplate `json:"template"`
+		JobTemplate struct {
+			Spec struct {
+				Template podTemplate `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+}
+
+// ParseWorkloadRef splits a "<kind>/<name>" reference, a bare name refers to a pod.
+func ParseWorkloadRef(ref string) (kind, name string, err error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 1 {
+		kind, name = "pod", parts[0]
+	} else {
+		kind, name = strings.ToLower(parts[0]), parts[1]
+	}
+	if name == "" {
+		return "", "", fmt.Errorf("invalid workload %q (expected <pod> or <kind>/<name>)", ref)
+	}
+	return kind, name, nil
+}
+
+// ResolveWorkload fetches the workload from the cluster and lists the images it runs and the secrets used to pull
+// them (including the pull secrets of its service account).
+func ResolveWorkload(ctx context.Context, kubectl Kubectl, ref, namespace string) (*Workload, error) {
+	kind, name, err := ParseWorkloadRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := kubectl(ctx, namespaceArgs(namespace, "get", kind+"/"+name, "-o", "json")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var object workloadObject
+	if err := json.Unmarshal(output, &object); err != nil {
+		return nil, fmt.Errorf("unable to parse %s/%s: %v", kind, name, err)
+	}
+
+	spec := object.Spec.podSpec
+	switch {
+	case len(object.Spec.Template.Spec.Containers) > 0:
+		spec = object.Spec.Template.Spec
+	case len(object.Spec.JobTemplate.Spec.Template.Spec.Containers) > 0:
+		spec = object.Spec.JobTemplate.Spec.Template.Spec
+	}
+	if len(spec.Containers) == 0 {
+		return nil, fmt.Errorf("%s/%s has no containers", kind, name)
+	}
+
+	workload := &Workload{
+		Kind:      object.Kind,
+		Name:      object.Metadata.Name,
+		Namespace: object.Metadata.Namespace,
+	}
+
+	seen := make(map[string]bool)
+	addImage := func(image string) {
+		if image != "" && !seen[image] {
+			seen[image] = true
+			workload.Images = append(workload.Images, image)
+		}
+	}
+	for _, container := range spec.InitContainers {
+		addImage(container.Image)
+	}
+	for _, container := range spec.Containers {
+		addImage(container.Image)
+	}
+
+	for _, secret := range spec.ImagePullSecrets {
+		workload.PullSecrets = append(workload.PullSecrets, secret.Name)
+	}
+	serviceAccountSecrets, err := serviceAccountPullSecrets(ctx, kubectl, namespace, spec.ServiceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range serviceAccountSecrets {
+		if !contains(workload.PullSecrets, secret) {
+			workload.PullSecrets = append(workload.PullSecrets, secret)
+		}
+	}
+
+	return workload, nil
+}
+
+// serviceAccountPullSecrets lists the pull secrets that the cluster adds to pods running as the given service account.
+func serviceAccountPullSecrets(ctx context.Context, kubectl Kubectl, namespace, serviceAccount string) ([]string, error) {
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	output, err := kubectl(ctx, namespaceArgs(namespace, "get", "serviceaccount/"+serviceAccount, "-o", "json")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var account struct {
+		ImagePullSecrets []struct {
+			Name string `json:"name"`
+		} `json:"imagePullSecrets"`
+	}
+	if err := json.Unmarshal(output, &account); err != nil {
+		return nil, fmt.Errorf("unable to parse serviceaccount/%s: %v", serviceAccount, err)
+	}
+
+	names := make([]string, 0, len(account.ImagePullSecrets))
+	for _, secret := range account.ImagePullSecrets {
+		names = append(names, secret.Name)
+	}
+	return names, nil
+}
+
+// namespaceArgs prefixes the kubectl arguments with the namespace (the kubeconfig namespace is used when empty).
+func namespaceArgs(namespace string, args ...string) []string {
+	if namespace == "" {
+		return args
+	}
+	return append([]string{"--namespace", namespace}, args...)
+}
+
+func contains(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of GPU libraries and model files listed in the report (the largest first)
+const mlReportMaxEntries = 10
+
+// mlReport renders the GPU libraries, the duplicate ML framework installs and the model weights found in the image.
+func mlReport(analysis *image.MLAnalysis) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("ML Image Contents:"))
+
+	if len(analysis.GPULibraries) > 0 {
+		var redundantBytes uint64
+		for _, library := range analysis.GPULibraries {
+			redundantBytes += library.RedundantBytes()
+		}
+		fmt.Fprintf(&sb, "  GPU libraries: %s in %d libraries (%s in redundant copies)\n", humanize.Bytes(analysis.GPUBytes), len(analysis.GPULibraries), humanize.Bytes(redundantBytes))
+		for idx, library := range analysis.GPULibraries {
+			if idx >= mlReportMaxEntries {
+				fmt.Fprintf(&sb, "    ...and %d more\n", len(analysis.GPULibraries)-idx)
+				break
+			}
+			versions := make([]string, 0, len(library.Copies))
+			for _, libraryCopy := range library.Copies {
+				versions = append(versions, fmt.Sprintf("%s (layer %d)", libraryCopy.Path, libraryCopy.Layer))
+			}
+			fmt.Fprintf(&sb, "    %10s  %-8s  %s: %s\n", humanize.Bytes(library.SizeBytes), library.Family, library.Name, strings.Join(versions, ", "))
+		}
+	}
+
+	for _, duplicate := range analysis.DuplicateFrameworks {
+		fmt.Fprintf(&sb, "  %s is installed %d times:\n", duplicate.Name, len(duplicate.Installs))
+		for _, install := range duplicate.Installs {
+			fmt.Fprintf(&sb, "    %10s  %-19s  %-12s  layer %d  %s\n", humanize.Bytes(install.SizeBytes), install.Manager, install.Version, install.Layer, install.Dir)
+		}
+	}
+
+	if len(analysis.ModelFiles) > 0 {
+		fmt.Fprintf(&sb, "  model weights: %s in %d files (consider mounting them at runtime)\n", humanize.Bytes(analysis.ModelBytes), len(analysis.ModelFiles))
+		for idx, model := range analysis.ModelFiles {
+			if idx >= mlReportMaxEntries {
+				fmt.Fprintf(&sb, "    ...and %d more\n", len(analysis.ModelFiles)-idx)
+				break
+			}
+			fmt.Fprintf(&sb, "    %10s  %-22s  layer %d  %s\n", humanize.Bytes(model.SizeBytes), model.Format, model.Layer, model.Path)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
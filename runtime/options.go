@@ -3,14 +3,53 @@ package runtime
 import (
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/ui/script"
 )
 
 type Options struct {
-	Ci           bool
-	Image        string
-	Source       dive.ImageSource
+	Ci bool
+	// reports the analysis like the CI mode does, without validating the CI rules (set when there is no terminal to
+	// show the UI on)
+	Report bool
+	Image  string
+	Source dive.ImageSource
+	// why the image is fetched from the source (e.g. an archive was detected at the path given), shown with the source
+	SourceReason string
 	IgnoreErrors bool
 	ExportFile   string
-	CiConfig     *viper.Viper
-	BuildArgs    []string
+	// selects values from the export (see export.Query), which are the only output on stdout
+	Query    string
+	CiConfig *viper.Viper
+	// the size budget evaluated along with the CI rules (nil when there is none)
+	Budget    *ci.Budget
+	BuildArgs []string
+	History   []string
+	// the base image the image is built on (the first layer is assumed to be the base when empty)
+	BaseImage string
+	// the image published to a registry to compare the image with, instead of showing the UI (empty when not comparing)
+	CompareRemote string
+	Lazy          bool
+	// how the images are fetched and parsed (e.g. the bounds of the analysis of each image)
+	Resolver image.ResolverOptions
+	// the options the images are analyzed with (e.g. the paths left out of the efficiency score)
+	Analysis image.AnalysisOptions
+	// the user namespace the owners of the files are shown within in the UI (see userns.uid-map)
+	IDMapping filetree.IDMapping
+	// how the cosign signature of the image is verified before the analysis (nil when it is not verified)
+	Signature *image.SignaturePolicy
+	// the vulnerability report (a grype or trivy JSON file) mapped onto the image, or the scanner to run on the image
+	Vulnerabilities string
+	// more images to open in the UI alongside the image, each in a tab of its own
+	Tabs []TabImage
+	// the UI actions replayed when the UI is rendered headless (see --script)
+	Script script.Script
+}
+
+// TabImage is an image opened in a tab of the UI.
+type TabImage struct {
+	Source dive.ImageSource
+	Image  string
 }
@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of owners listed in the report (those with the most bytes first)
+const ownershipReportMaxOwners = 10
+
+// ownershipReport renders how much of the final image is owned by root, the owners of the final image and the root
+// owned share of every layer.
+func ownershipReport(ownership *image.Ownership) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Ownership:"))
+
+	summary := ownership.Image
+	fmt.Fprintf(&sb, "  root owned: %s of %s (%.0f%%)\n", humanize.Bytes(summary.RootBytes), humanize.Bytes(summary.SizeBytes), summary.RootPercent()*100)
+
+	fmt.Fprintf(&sb, "    %-13s  %7s  %8s  %5s\n", "Owner", "Files", "Size", "Share")
+	for idx, owner := range summary.Owners {
+		if idx >= ownershipReportMaxOwners {
+			fmt.Fprintf(&sb, "    ...and %d more\n", len(summary.Owners)-idx)
+			break
+		}
+		share := 0.0
+		if summary.SizeBytes > 0 {
+			share = float64(owner.SizeBytes) / float64(summary.SizeBytes)
+		}
+		fmt.Fprintf(&sb, "    %-13s  %7d  %8s  %4.0f%%\n", owner.Owner(), owner.Files, humanize.Bytes(owner.SizeBytes), share*100)
+	}
+
+	fmt.Fprintf(&sb, "    %5s  %8s  %8s  %6s\n", "Layer", "Size", "Root", "Root %")
+	for idx, layer := range ownership.Layers {
+		fmt.Fprintf(&sb, "    %5d  %8s  %8s  %5.0f%%\n", idx, humanize.Bytes(layer.SizeBytes), humanize.Bytes(layer.RootBytes), layer.RootPercent()*100)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
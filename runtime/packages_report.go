@@ -0,0 +1,49 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the largest packages and files not owned by any package listed in the report
+const (
+	packagesReportMaxPackages = 15
+	packagesReportMaxOrphans  = 15
+)
+
+// packagesReport lists the largest packages installed in the image, with the size of the files they installed.
+func packagesReport(packages *image.Packages, imageSize uint64) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Packages:"))
+	if len(packages.Packages) == 0 {
+		fmt.Fprintln(&sb, "  no package database (dpkg, apk or rpm) found")
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+
+	fmt.Fprintf(&sb, "  %d packages (%s), %s of the %s image\n", len(packages.Packages), strings.Join(packages.Managers(), ", "), humanize.Bytes(packages.SizeBytes()), humanize.Bytes(imageSize))
+	for idx, pkg := range packages.Packages {
+		if idx >= packagesReportMaxPackages {
+			fmt.Fprintf(&sb, "  ...and %d more\n", len(packages.Packages)-idx)
+			break
+		}
+		layer := "-"
+		if pkg.Layer >= 0 {
+			layer = fmt.Sprintf("%d", pkg.Layer)
+		}
+		fmt.Fprintf(&sb, "  %9s  %6d files  layer %-3s  %s\n", humanize.Bytes(pkg.SizeBytes), len(pkg.Files), layer, pkg)
+	}
+
+	fmt.Fprintf(&sb, "  %d files (%s) not owned by any package\n", len(packages.Orphans), humanize.Bytes(packages.OrphanBytes()))
+	for idx, orphan := range packages.Orphans {
+		if idx >= packagesReportMaxOrphans {
+			fmt.Fprintf(&sb, "    ...and %d more\n", len(packages.Orphans)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "    %9s  layer %-3d  %s\n", humanize.Bytes(orphan.SizeBytes), orphan.Layer, orphan.Path)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
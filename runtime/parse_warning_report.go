@@ -0,0 +1,32 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// parseWarningReport renders the malformed layer tar entries that were skipped while parsing the layers (empty when
+// there were none).
+func parseWarningReport(layers []*image.Layer) string {
+	var sb strings.Builder
+	for _, layer := range layers {
+		warnings := layer.ParseWarnings
+		if warnings.Len() == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  layer %d: %d skipped (%s)\n", layer.Index, warnings.Total, warnings.Summary())
+		for _, warning := range warnings.Warnings {
+			fmt.Fprintf(&sb, "    WARN: %s\n", warning)
+		}
+		if undescribed := warnings.Total - len(warnings.Warnings); undescribed > 0 {
+			fmt.Fprintf(&sb, "    ...and %d more\n", undescribed)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return utils.TitleFormat("Parse Warnings:") + "\n" + strings.TrimSuffix(sb.String(), "\n")
+}
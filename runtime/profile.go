@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/metrics"
+)
+
+// the kinds of profiles --profile writes
+const (
+	ProfileCPU    = "cpu"
+	ProfileMemory = "mem"
+	ProfileTrace  = "trace"
+)
+
+// ProfileKinds are the valid values of --profile.
+var ProfileKinds = []string{ProfileCPU, ProfileMemory, ProfileTrace}
+
+// runningProfile is the profile started by StartProfile (nil when none is).
+var runningProfile *profile
+
+type profile struct {
+	kind string
+	file *os.File
+}
+
+// DefaultProfilePath is the file a profile of the given kind is written to when no path is given.
+func DefaultProfilePath(kind string) string {
+	if kind == ProfileTrace {
+		return "dive.trace"
+	}
+	return fmt.Sprintf("dive.%s.pprof", kind)
+}
+
+// StartProfile starts writing a profile of the given kind (cpu, mem or trace) to the given file, for "go tool pprof"
+// (or "go tool trace"). The profile is written when Run is over (see StopProfile), along with the timings of the
+// analysis pipeline.
+func StartProfile(kind, path string) error {
+	switch kind {
+	case ProfileCPU, ProfileMemory, ProfileTrace:
+	default:
+		return fmt.Errorf("unknown profile %q (expected %s)", kind, strings.Join(ProfileKinds, ", "))
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case ProfileCPU:
+		err = pprof.StartCPUProfile(file)
+	case ProfileTrace:
+		err = trace.Start(file)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	runningProfile = &profile{kind: kind, file: file}
+	return nil
+}
+
+// StopProfile finishes the profile started by StartProfile (if any), and reports where it was written along with the
+// timings of the analysis pipeline, so that both can be attached to a performance report.
+func StopProfile(recorder *metrics.Recorder) error {
+	if runningProfile == nil {
+		return nil
+	}
+	current := runningProfile
+	runningProfile = nil
+
+	var err error
+	switch current.kind {
+	case ProfileCPU:
+		pprof.StopCPUProfile()
+	case ProfileTrace:
+		trace.Stop()
+	case ProfileMemory:
+		goruntime.GC()
+		err = pprof.WriteHeapProfile(current.file)
+	}
+	if closeErr := current.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write the %s profile: %v", current.kind, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote the %s profile to %s\n", current.kind, current.file.Name())
+	for _, line := range TimingReport(recorder) {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	return nil
+}
+
+// TimingReport describes the time spent in every stage of the analysis pipeline (with its slowest samples, e.g. the
+// slowest layers to parse), one line each.
+func TimingReport(recorder *metrics.Recorder) []string {
+	var lines []string
+	for _, stage := range recorder.Stages() {
+		line := fmt.Sprintf("%s: %d in %s (average %s, max %s)", stage.Name, stage.Count, stage.Total, stage.Average(), stage.Max)
+		if len(stage.Samples) > 0 {
+			slowest := stage.Samples[0]
+			line += fmt.Sprintf(", slowest %s (%s)", slowest.Detail, slowest.Duration)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// logTimings writes the timings of the analysis pipeline to the log.
+func logTimings(recorder *metrics.Recorder) {
+	for _, line := range TimingReport(recorder) {
+		logrus.Debugf("timing: %s", line)
+	}
+}
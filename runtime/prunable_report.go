@@ -0,0 +1,36 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of directories listed per kind of prunable content (the largest first)
+const prunableReportMaxPaths = 3
+
+// prunableReport renders the prunable content found in the image, along with the commands that would remove it.
+func prunableReport(content *image.PrunableContent) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Prunable Content:"))
+	fmt.Fprintf(&sb, "  reclaimable: %s\n", humanize.Bytes(content.SizeBytes))
+
+	for _, group := range content.Groups {
+		paths := group.Paths
+		more := ""
+		if len(paths) > prunableReportMaxPaths {
+			more = fmt.Sprintf(" and %d more", len(paths)-prunableReportMaxPaths)
+			paths = paths[:prunableReportMaxPaths]
+		}
+		fmt.Fprintf(&sb, "    %10s  %-15s  %5d files  layer %d  %s%s\n", humanize.Bytes(group.SizeBytes), group.Kind, group.Files, group.Layer, strings.Join(paths, ", "), more)
+	}
+
+	fmt.Fprintln(&sb, "  append to the RUN instructions of the layers above (a later layer only hides the files):")
+	for _, line := range strings.Split(content.CleanupSnippet(), "\n") {
+		fmt.Fprintf(&sb, "    %s\n", line)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
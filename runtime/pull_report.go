@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// estimatePullCost estimates the pull time at the configured bandwidths (pull.bandwidth, then
+// pull.compare-bandwidths), and the monthly egress when the number of pulls a month is configured.
+func estimatePullCost(analysis *image.AnalysisResult) (*image.PullCost, error) {
+	bandwidths := append([]string{viper.GetString("pull.bandwidth")}, viper.GetStringSlice("pull.compare-bandwidths")...)
+	profiles, err := image.ParseBandwidthProfiles(bandwidths, viper.GetString("pull.layer-latency"))
+	if err != nil {
+		return nil, err
+	}
+	baseLayers := 0
+	if analysis.Base != nil {
+		baseLayers = analysis.Base.Layers
+	}
+	return image.EstimatePullCost(analysis.Layers, baseLayers, profiles, viper.GetInt("pull.monthly-pulls"), viper.GetFloat64("pull.egress-price")), nil
+}
+
+// pullReportEnabled tells whether the CI output shows the pull estimates: when asked for with pull.enabled, or when
+// a pull profile beyond the default bandwidth is configured (pull.compare-bandwidths or pull.monthly-pulls).
+func pullReportEnabled() bool {
+	return viper.GetBool("pull.enabled") || len(viper.GetStringSlice("pull.compare-bandwidths")) > 0 || viper.GetInt("pull.monthly-pulls") > 0
+}
+
+// pullReport renders the estimated pull times, both in total and for every layer, followed by the pull times at the
+// other bandwidths and the monthly egress (when configured).
+func pullReport(cost *image.PullCost, layers []*image.Layer) string {
+	estimate := cost.Main()
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Estimated Pull Time:"))
+	fmt.Fprintf(&sb, "  profile: %s\n", estimate.Profile)
+	fmt.Fprintf(&sb, "  coldPull: %s\n", image.FormatPullDuration(estimate.Cold))
+	fmt.Fprintf(&sb, "  warmPull: %s (base layers cached)\n", image.FormatPullDuration(estimate.Warm))
+
+	fmt.Fprintf(&sb, "    %8s  %8s  %8s  %s\n", "Transfer", "Cold", "Warm", "Layer")
+	for _, layer := range layers {
+		layerEstimate, ok := estimate.Layer(layer.Index)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&sb, "    %8s  %8s  %8s  %d\n",
+			humanize.Bytes(layer.TransferSize()),
+			image.FormatPullDuration(layerEstimate.Cold),
+			image.FormatPullDuration(layerEstimate.Warm),
+			layer.Index)
+	}
+
+	if len(cost.Estimates) > 1 {
+		fmt.Fprintln(&sb, "  bandwidths:")
+		fmt.Fprintf(&sb, "    %8s  %8s  %s\n", "Cold", "Warm", "Profile")
+		for _, other := range cost.Estimates {
+			fmt.Fprintf(&sb, "    %8s  %8s  %s\n", image.FormatPullDuration(other.Cold), image.FormatPullDuration(other.Warm), other.Profile)
+		}
+	}
+
+	if egress := cost.Egress; egress != nil {
+		fmt.Fprintf(&sb, "  egress: %d pulls a month at $%.2f per GB\n", egress.Pulls, egress.PricePerGB)
+		fmt.Fprintf(&sb, "    cold: %s per pull, %s a month ($%.2f)\n", humanize.Bytes(egress.ColdBytes), humanize.Bytes(egress.MonthlyBytes(false)), egress.MonthlyCost(false))
+		fmt.Fprintf(&sb, "    warm: %s per pull, %s a month ($%.2f, base layers cached)\n", humanize.Bytes(egress.WarmBytes), humanize.Bytes(egress.MonthlyBytes(true)), egress.MonthlyCost(true))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of differing files listed (the largest size changes first)
+const remoteReportMaxFiles = 20
+
+// remoteReport renders the size, layer and file deltas between the local image and the published one.
+func remoteReport(comparison *image.RemoteComparison) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Published Image Comparison:"))
+	fmt.Fprintf(&sb, "  published: %s (%s)\n", comparison.Reference, comparison.Digest)
+	fmt.Fprintf(&sb, "  size: %s local, %s published (%s)\n",
+		humanize.Bytes(comparison.LocalSizeBytes), humanize.Bytes(comparison.RemoteSizeBytes),
+		signedBytes(int64(comparison.LocalSizeBytes)-int64(comparison.RemoteSizeBytes)))
+	fmt.Fprintf(&sb, "  transfer: %s local, %s published (%s)\n",
+		humanize.Bytes(comparison.LocalTransferBytes), humanize.Bytes(comparison.RemoteTransferBytes),
+		signedBytes(int64(comparison.LocalTransferBytes)-int64(comparison.RemoteTransferBytes)))
+
+	if comparison.Identical() {
+		fmt.Fprintf(&sb, "  layers: all %d layers are the published ones", comparison.SharedLayers)
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "  layers: %d shared (%s), hosts with the published image pull %s\n",
+		comparison.SharedLayers, humanize.Bytes(comparison.SharedSizeBytes), humanize.Bytes(comparison.PullBytes()))
+	for _, layer := range comparison.Layers {
+		fmt.Fprintf(&sb, "    %-14s  layer %-3d  %10s  %s\n", layer.Status, layer.Index, humanize.Bytes(layer.SizeBytes), layer.Command)
+	}
+
+	var added, modified, deleted int
+	for _, file := range comparison.Files {
+		switch file.Change {
+		case image.PathAdded:
+			added++
+		case image.PathModified:
+			modified++
+		case image.PathDeleted:
+			deleted++
+		}
+	}
+	fmt.Fprintf(&sb, "  files: %d added, %d modified, %d deleted\n", added, modified, deleted)
+	files := comparison.Files
+	if len(files) > remoteReportMaxFiles {
+		files = files[:remoteReportMaxFiles]
+	}
+	for _, file := range files {
+		fmt.Fprintf(&sb, "    %10s  %-8s  %s\n", signedBytes(file.SizeDelta()), file.Change, file.Path)
+	}
+	if len(comparison.Files) > len(files) {
+		fmt.Fprintf(&sb, "    ... and %d more\n", len(comparison.Files)-len(files))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// signedBytes renders a size change with its sign (e.g. "+1.2 MB").
+func signedBytes(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.Bytes(uint64(-delta))
+	}
+	return "+" + humanize.Bytes(uint64(delta))
+}
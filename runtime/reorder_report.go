@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// ReorderReport renders the simulated build order along with the bytes rebuilt per build before and after reordering.
+func ReorderReport(sim *image.ReorderSimulation) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Layer Reorder Simulation (experimental):"))
+	if sim.Estimated {
+		fmt.Fprintln(&sb, "  rebuilds: 1 (estimated, every COPY/ADD from the build context changes; use --history to replay real changes)")
+	} else {
+		fmt.Fprintf(&sb, "  rebuilds: %d (replayed from the image history)\n", sim.Rebuilds)
+	}
+
+	steps := make(map[int]image.ReorderStep)
+	for _, step := range sim.Steps {
+		steps[step.Layer] = step
+	}
+	template := "  %5s  %7s  %12s  %s\n"
+	fmt.Fprintf(&sb, template, "Layer", "Changes", "Size", "Command")
+	for _, layer := range sim.Order {
+		step := steps[layer]
+		fmt.Fprintf(&sb, template, fmt.Sprint(step.Layer), fmt.Sprintf("%d/%d", step.Changes, sim.Rebuilds), humanize.Bytes(step.SizeBytes), step.Command)
+	}
+
+	for _, conflict := range sim.Conflicts {
+		fmt.Fprintf(&sb, "  WARN: layer %d is moved ahead of layer %d, which writes %s before it\n", conflict.Layer, conflict.DependsOn, conflict.Path)
+	}
+	fmt.Fprintln(&sb, "  note: steps moved ahead of a COPY/ADD may need some of the copied files (e.g. a dependency manifest), copy those first")
+	fmt.Fprintf(&sb, "  bytesPerBuild: %s -> %s\n", humanize.Bytes(sim.CurrentBytes), humanize.Bytes(sim.ReorderedBytes))
+	fmt.Fprintf(&sb, "  cacheHitBytesSaved: %s per build", humanize.Bytes(sim.SavedBytes()))
+	return sb.String()
+}
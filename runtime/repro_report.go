@@ -0,0 +1,103 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of files listed per finding and per differing layer
+const reproReportMaxPaths = 5
+
+// reproducibilityReport renders the sources of non-reproducibility found in the image layers.
+func reproducibilityReport(repro *image.Reproducibility) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Reproducibility:"))
+	var kinds []string
+	details := make(map[string]string)
+	for _, finding := range repro.Findings {
+		fmt.Fprintf(&sb, "  layer %-3d %-16s  %5d files  %10s  %s\n", finding.Layer, finding.Kind, len(finding.Paths),
+			humanize.Bytes(finding.SizeBytes), truncatedPaths(finding.Paths))
+		if _, exists := details[finding.Kind]; !exists {
+			kinds = append(kinds, finding.Kind)
+			details[finding.Kind] = finding.Detail
+		}
+	}
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "  %s: %s\n", kind, details[kind])
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// ReproReport renders the differences between two builds of the same Dockerfile, followed by the sources of
+// non-reproducibility found in the second build.
+func ReproReport(comparison *image.ReproComparison, repro *image.Reproducibility) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Build Reproducibility:"))
+	if comparison.Reproducible() {
+		fmt.Fprintf(&sb, "  reproducible: all %d layers are identical\n", len(comparison.Layers))
+	} else {
+		fmt.Fprintf(&sb, "  reproducible: no, %d of %d layers are identical\n", comparison.IdenticalLayers(), len(comparison.Layers))
+	}
+
+	for _, layer := range comparison.Layers {
+		switch {
+		case layer.Identical:
+			continue
+		case layer.Missing:
+			fmt.Fprintf(&sb, "  layer %d: only in one build  %s\n", layer.Index, layer.Command)
+			continue
+		}
+
+		counts := make(map[string]int)
+		var causes []string
+		causeSeen := make(map[string]bool)
+		var paths []string
+		for _, difference := range layer.Differences {
+			counts[difference.Change]++
+			if difference.Cause != "" && !causeSeen[difference.Cause] {
+				causeSeen[difference.Cause] = true
+				causes = append(causes, difference.Cause)
+			}
+			if difference.Change != image.ReproMtimeOnly {
+				paths = append(paths, difference.Path)
+			}
+		}
+		var summary []string
+		for _, change := range []string{image.ReproContent, image.ReproMetadata, image.ReproMtimeOnly, image.PathAdded, image.PathDeleted} {
+			if counts[change] > 0 {
+				summary = append(summary, fmt.Sprintf("%d %s", counts[change], change))
+			}
+		}
+		if len(summary) == 0 {
+			// the files are the same, the tar stream is not (e.g. the order of the entries)
+			summary = append(summary, "same files, other archive layout")
+		}
+		fmt.Fprintf(&sb, "  layer %d: %s  %s\n", layer.Index, strings.Join(summary, ", "), layer.Command)
+		if layer.MtimeOnly() {
+			fmt.Fprintln(&sb, "    only the mtimes differ: set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)")
+		}
+		if len(paths) > 0 {
+			fmt.Fprintf(&sb, "    differ: %s\n", truncatedPaths(paths))
+		}
+		if len(causes) > 0 {
+			fmt.Fprintf(&sb, "    known causes: %s\n", strings.Join(causes, ", "))
+		}
+	}
+
+	if repro != nil && !repro.Empty() {
+		fmt.Fprintln(&sb, reproducibilityReport(repro))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// truncatedPaths joins the first paths, noting how many more there are.
+func truncatedPaths(paths []string) string {
+	if len(paths) <= reproReportMaxPaths {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:reproReportMaxPaths], ", "), len(paths)-reproReportMaxPaths)
+}
@@ -0,0 +1,60 @@
+package results
+
+// CannedQuery is a ready-made query that can be run by name.
+type CannedQuery struct {
+	Name        string
+	Description string
+	SQL         string
+}
+
+// the latest run of every image
+const latestRuns = `SELECT * FROM runs r WHERE r.id = (SELECT MAX(id) FROM runs WHERE image = r.image)`
+
+// CannedQueries are the queries that can be given by name instead of SQL.
+var CannedQueries = []CannedQuery{
+	{
+		Name:        "latest",
+		Description: "the latest run of every image",
+		SQL: `SELECT image, analyzed_at, layers, size_bytes, app_size_bytes, inefficient_bytes, app_inefficient_bytes, efficiency
+			FROM (` + latestRuns + `) ORDER BY image`,
+	},
+	{
+		Name:        "biggest-growth-this-month",
+		Description: "the images that grew the most within the last month (first and latest run of the month)",
+		SQL: `SELECT image, MIN(analyzed_at) AS since, first_size_bytes, latest_size_bytes,
+				latest_size_bytes - first_size_bytes AS growth_bytes
+			FROM (
+				SELECT image, analyzed_at,
+					FIRST_VALUE(size_bytes) OVER (PARTITION BY image ORDER BY analyzed_at, id) AS first_size_bytes,
+					FIRST_VALUE(size_bytes) OVER (PARTITION BY image ORDER BY analyzed_at DESC, id DESC) AS latest_size_bytes
+				FROM runs WHERE analyzed_at >= datetime('now', '-1 month')
+			)
+			GROUP BY image ORDER BY growth_bytes DESC, image`,
+	},
+	{
+		Name:        "largest",
+		Description: "the latest run of every image, largest first",
+		SQL:         `SELECT image, analyzed_at, size_bytes, app_size_bytes, base_size_bytes FROM (` + latestRuns + `) ORDER BY size_bytes DESC, image`,
+	},
+	{
+		Name:        "least-efficient",
+		Description: "the latest run of every image, least efficient first",
+		SQL:         `SELECT image, analyzed_at, efficiency, inefficient_bytes, app_inefficient_bytes FROM (` + latestRuns + `) WHERE partial = 0 ORDER BY efficiency, image`,
+	},
+	{
+		Name:        "most-wasted-files",
+		Description: "the files wasting the most space across the latest run of every image",
+		SQL: `SELECT r.image, f.path, f.count, f.size_bytes FROM (` + latestRuns + `) r
+			JOIN inefficient_files f ON f.run_id = r.id ORDER BY f.size_bytes DESC, r.image, f.path LIMIT 50`,
+	},
+}
+
+// FindCannedQuery returns the canned query with the given name.
+func FindCannedQuery(name string) (CannedQuery, bool) {
+	for _, query := range CannedQueries {
+		if query.Name == name {
+			return query, true
+		}
+	}
+	return CannedQuery{}, false
+}
@@ -0,0 +1,211 @@
+package results
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/wagoodman/dive/dive/image"
+	// registers the "sqlite" database driver (pure go, so dive still builds without cgo)
+	_ "modernc.org/sqlite"
+)
+
+// the format analysis times are stored in (comparable with the sqlite date and time functions)
+const timeFormat = "2006-01-02 15:04:05"
+
+// the tables every analysis is recorded into (see the README for a description of the columns)
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image TEXT NOT NULL,
+		analyzed_at TEXT NOT NULL,
+		layers INTEGER NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		compressed_bytes INTEGER NOT NULL,
+		inefficient_bytes INTEGER NOT NULL,
+		efficiency REAL NOT NULL,
+		base_image TEXT NOT NULL,
+		base_layers INTEGER NOT NULL,
+		base_size_bytes INTEGER NOT NULL,
+		app_size_bytes INTEGER NOT NULL,
+		app_inefficient_bytes INTEGER NOT NULL,
+		partial INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS runs_image ON runs (image, analyzed_at)`,
+	`CREATE TABLE IF NOT EXISTS layers (
+		run_id INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+		layer INTEGER NOT NULL,
+		digest TEXT NOT NULL,
+		diff_id TEXT NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		compressed_bytes INTEGER NOT NULL,
+		command TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS inefficient_files (
+		run_id INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+		path TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		size_bytes INTEGER NOT NULL
+	)`,
+}
+
+// Store records every analysis in a local sqlite database, so that questions spanning many images and runs can be
+// answered with plain SQL.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath is the results database within the user configuration directory.
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "dive", "results.db"), nil
+}
+
+// Path returns the configured database path, or the default path when none is configured.
+func Path(configured string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	return DefaultPath()
+}
+
+// Open opens (creating it when missing) the results database at the given path.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer, which avoids "database is locked" errors between concurrent writes
+	db.SetMaxOpenConns(1)
+
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("unable to create the results schema: %v", err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record stores the analysis of the given image as a new run.
+func (s *Store) Record(imageName string, analyzedAt time.Time, analysis *image.AnalysisResult) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	base := analysis.Base
+	if base == nil {
+		base = &image.BaseImage{}
+	}
+	result, err := tx.Exec(`INSERT INTO runs (image, analyzed_at, layers, size_bytes, compressed_bytes, inefficient_bytes,
+		efficiency, base_image, base_layers, base_size_bytes, app_size_bytes, app_inefficient_bytes, partial)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		imageName, analyzedAt.UTC().Format(timeFormat), len(analysis.Layers), analysis.SizeBytes, analysis.CompressedBytes,
+		analysis.WastedBytes, analysis.Efficiency, base.Image, base.Layers, base.SizeBytes, analysis.UserSizeByes,
+		analysis.AppWastedBytes, analysis.Partial)
+	if err != nil {
+		return err
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, layer := range analysis.Layers {
+		_, err = tx.Exec(`INSERT INTO layers (run_id, layer, digest, diff_id, size_bytes, compressed_bytes, command)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			runID, layer.Index, layer.Digest, layer.DiffID, layer.Size, layer.CompressedSize, layer.Command)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range analysis.Inefficiencies {
+		_, err = tx.Exec(`INSERT INTO inefficient_files (run_id, path, count, size_bytes) VALUES (?, ?, ?, ?)`,
+			runID, file.Path, len(file.Nodes), file.CumulativeSize)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Rows is the result of a query, every value rendered as text.
+type Rows struct {
+	Columns []string
+	Values  [][]string
+}
+
+// Query runs the given SQL statement (or canned query name, see CannedQueries) against the database.
+func (s *Store) Query(query string) (*Rows, error) {
+	if canned, ok := FindCannedQuery(query); ok {
+		query = canned.SQL
+	}
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &Rows{Columns: columns, Values: make([][]string, 0)}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for idx := range values {
+			pointers[idx] = &values[idx]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columns))
+		for idx, value := range values {
+			row[idx] = formatValue(value)
+		}
+		result.Values = append(result.Values, row)
+	}
+	return result, rows.Err()
+}
+
+func formatValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(typed)
+	case string:
+		return typed
+	case int64:
+		return strconv.FormatInt(typed, 10)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	case time.Time:
+		return typed.UTC().Format(timeFormat)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
@@ -0,0 +1,112 @@
+package results
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func testStore(t *testing.T) *Store {
+	store, err := Open(filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatalf("unable to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func testAnalysis(sizes ...uint64) *image.AnalysisResult {
+	analysis := &image.AnalysisResult{
+		Efficiency:     0.5,
+		Base:           &image.BaseImage{Image: "alpine:3.19", Layers: 1, SizeBytes: sizes[0]},
+		Inefficiencies: filetree.EfficiencySlice{{Path: "/tmp/cache", Nodes: make([]*filetree.FileNode, 2), CumulativeSize: 20}},
+	}
+	for idx, size := range sizes {
+		analysis.Layers = append(analysis.Layers, &image.Layer{Index: idx, Size: size, DiffID: "sha256:layer"})
+		analysis.SizeBytes += size
+	}
+	analysis.UserSizeByes = analysis.SizeBytes - sizes[0]
+	return analysis
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	store := testStore(t)
+
+	if err := store.Record("app:latest", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), testAnalysis(100, 50)); err != nil {
+		t.Fatalf("unable to record: %v", err)
+	}
+	if err := store.Record("app:latest", time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC), testAnalysis(100, 80)); err != nil {
+		t.Fatalf("unable to record: %v", err)
+	}
+
+	rows, err := store.Query("SELECT image, analyzed_at, size_bytes, app_size_bytes, base_image, efficiency FROM runs ORDER BY id")
+	if err != nil {
+		t.Fatalf("unable to query: %v", err)
+	}
+	expected := &Rows{
+		Columns: []string{"image", "analyzed_at", "size_bytes", "app_size_bytes", "base_image", "efficiency"},
+		Values: [][]string{
+			{"app:latest", "2024-03-01 12:00:00", "150", "50", "alpine:3.19", "0.5"},
+			{"app:latest", "2024-03-02 12:00:00", "180", "80", "alpine:3.19", "0.5"},
+		},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rows)
+	}
+
+	rows, err = store.Query("SELECT COUNT(*), SUM(size_bytes) FROM layers")
+	if err != nil {
+		t.Fatalf("unable to query: %v", err)
+	}
+	if !reflect.DeepEqual(rows.Values, [][]string{{"4", "330"}}) {
+		t.Errorf("unexpected layers: %+v", rows.Values)
+	}
+
+	if _, err := store.Query("SELECT * FROM missing"); err == nil {
+		t.Errorf("expected an error for an invalid query")
+	}
+}
+
+func TestCannedQueries(t *testing.T) {
+	store := testStore(t)
+
+	now := time.Now()
+	records := []struct {
+		image    string
+		daysAgo  int
+		appBytes uint64
+	}{
+		{"app:latest", 60, 10},
+		{"app:latest", 20, 50},
+		{"app:latest", 1, 250},
+		{"web:latest", 10, 100},
+		{"web:latest", 2, 120},
+	}
+	for _, record := range records {
+		if err := store.Record(record.image, now.AddDate(0, 0, -record.daysAgo), testAnalysis(100, record.appBytes)); err != nil {
+			t.Fatalf("unable to record: %v", err)
+		}
+	}
+
+	for _, canned := range CannedQueries {
+		if _, err := store.Query(canned.Name); err != nil {
+			t.Errorf("canned query %q failed: %v", canned.Name, err)
+		}
+	}
+
+	rows, err := store.Query("biggest-growth-this-month")
+	if err != nil {
+		t.Fatalf("unable to query: %v", err)
+	}
+	var growth [][]string
+	for _, row := range rows.Values {
+		growth = append(growth, []string{row[0], row[4]})
+	}
+	if !reflect.DeepEqual(growth, [][]string{{"app:latest", "200"}, {"web:latest", "20"}}) {
+		t.Errorf("unexpected growth: %+v", rows.Values)
+	}
+}
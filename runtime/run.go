@@ -1,81 +1,293 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"github.com/dustin/go-humanize"
+	"github.com/mattn/go-isatty"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/dive/metrics"
+	"github.com/wagoodman/dive/runtime/bookmark"
 	"github.com/wagoodman/dive/runtime/ci"
 	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/runtime/hook"
+	"github.com/wagoodman/dive/runtime/results"
 	"github.com/wagoodman/dive/runtime/ui"
 	"github.com/wagoodman/dive/utils"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
-func run(enableUi bool, options Options, imageResolver image.Resolver, events eventChannel, filesystem afero.Fs) {
+func run(ctx context.Context, enableUi bool, options Options, imageResolver image.Resolver, events eventChannel, filesystem afero.Fs) {
 	var img *image.Image
 	var err error
 	defer close(events)
 
-	doExport := options.ExportFile != ""
+	// the fetch and the analysis report their progress on a status line, background work in the status bar of the UI
+	progressBus := image.NewProgressBus()
+	ctx = image.WithProgressBus(ctx, progressBus)
+	stopStatus := func() {}
+	if enableUi {
+		stopStatus = events.followProgress(progressBus)
+	}
+	defer stopStatus()
+
+	doExport := options.ExportFile != "" || options.Query != ""
 	doBuild := len(options.BuildArgs) > 0
 
+	// progress goes to stderr when querying, so that stdout only holds the selected values
+	progress := events.message
+	if options.Query != "" {
+		progress = events.progress
+	}
+
 	if doBuild {
-		events.message(utils.TitleFormat("Building image..."))
-		img, err = imageResolver.Build(options.BuildArgs)
+		progress(utils.TitleFormat("Building image..."))
+		img, err = imageResolver.Build(ctx, options.BuildArgs)
 		if err != nil {
 			events.exitWithErrorMessage("cannot build image", err)
 			return
 		}
 	} else {
-		events.message(utils.TitleFormat("Image Source: ") + options.Source.String() + "://" + options.Image)
-		events.message(utils.TitleFormat("Fetching image...") + " (this can take a while for large images)")
-		img, err = imageResolver.Fetch(options.Image)
+		source := options.Source.String() + "://" + options.Image
+		if options.SourceReason != "" {
+			source += " (" + options.SourceReason + ")"
+		}
+		progress(utils.TitleFormat("Image Source: ") + source)
+		progress(utils.TitleFormat("Fetching image...") + " (this can take a while for large images)")
+		img, err = fetch(ctx, options, imageResolver, enableUi && !doExport && !options.Ci && !options.Report && options.CompareRemote == "")
 		if err != nil {
 			events.exitWithErrorMessage("cannot fetch image", err)
 			return
 		}
 	}
+	defer func() {
+		if err := img.Close(); err != nil {
+			logrus.Errorf("unable to close image: %+v", err)
+		}
+	}()
+
+	if options.BaseImage != "" {
+		progress(utils.TitleFormat("Fetching base image...") + " " + options.BaseImage)
+		baseImg, err := imageResolver.Fetch(ctx, options.BaseImage)
+		if err != nil {
+			events.exitWithErrorMessage("cannot fetch base image", err)
+			return
+		}
+		err = img.SetBase(options.BaseImage, baseImg)
+		if closeErr := baseImg.Close(); closeErr != nil {
+			logrus.Errorf("unable to close base image: %+v", closeErr)
+		}
+		if err != nil {
+			events.exitWithErrorMessage("cannot use base image", err)
+			return
+		}
+	}
+
+	if options.Signature != nil {
+		reference := signatureReference(options, img)
+		progress(utils.TitleFormat("Verifying signature...") + " " + reference)
+		img.Signature = image.VerifySignature(ctx, reference, *options.Signature, options.Resolver.Network)
+	}
+
+	// the packages of an analysis bundle were read when it was saved (its file contents are not available)
+	if img.Packages == nil && (viper.GetBool("packages.enabled") || options.Vulnerabilities != "") {
+		progress(utils.TitleFormat("Reading package databases..."))
+		img.Packages = image.ReadPackages(img.Trees, img.Contents)
+	}
+
+	if options.Vulnerabilities != "" {
+		progress(utils.TitleFormat("Reading vulnerabilities...") + " " + options.Vulnerabilities)
+		report, err := loadVulnerabilities(ctx, options, filesystem)
+		if err != nil {
+			events.exitWithErrorMessage("cannot read vulnerabilities", err)
+			return
+		}
+		report.Map(img.Layers, img.Trees, img.Packages)
+		img.Vulnerabilities = report
+	}
 
-	events.message(utils.TitleFormat("Analyzing image..."))
-	analysis, err := img.Analyze()
+	progress(utils.TitleFormat("Analyzing image..."))
+	analysis, err := img.AnalyzeWithOptions(ctx, options.Analysis)
 	if err != nil {
 		events.exitWithErrorMessage("cannot analyze image", err)
 		return
 	}
 
-	if doExport {
-		events.message(utils.TitleFormat(fmt.Sprintf("Exporting image to '%s'...", options.ExportFile)))
-		bytes, err := export.NewExport(analysis).Marshal()
+	// lazy images are hashed in the background, which stops (before the image is closed) once the run is over
+	hashingCtx, stopHashing := context.WithCancel(ctx)
+	defer stopHashing()
+	if viper.GetBool("duplicates.enabled") {
+		analysis.DuplicateContent = img.FindDuplicateContent(hashingCtx, options.Resolver.IO)
+	}
+
+	if viper.GetBool("results.enabled") && options.Image != "" {
+		recordResults(options.Image, analysis)
+	}
+
+	hook.Fire(hook.AnalysisComplete, options.Image, hook.AnalysisPayload{
+		ImageID:     analysis.ImageID,
+		Layers:      len(analysis.Layers),
+		SizeBytes:   analysis.SizeBytes,
+		WastedBytes: analysis.WastedBytes,
+		Efficiency:  analysis.Efficiency,
+	})
+
+	if options.CompareRemote != "" {
+		progress(utils.TitleFormat("Fetching published image...") + " " + options.CompareRemote)
+		remoteImg, err := docker.FetchRemoteImage(ctx, options.CompareRemote, img, options.Resolver)
 		if err != nil {
-			events.exitWithErrorMessage("cannot marshal export payload", err)
+			events.exitWithErrorMessage("cannot fetch published image", err)
 			return
 		}
+		events.message(remoteReport(image.CompareRemote(img, remoteImg, options.CompareRemote)))
+		return
+	}
 
-		file, err := filesystem.OpenFile(options.ExportFile, os.O_RDWR|os.O_CREATE, 0644)
+	if doExport {
+		cost, err := estimatePullCost(analysis)
 		if err != nil {
-			events.exitWithErrorMessage("cannot open export file", err)
+			events.exitWithErrorMessage("invalid pull configuration", err)
 			return
 		}
-		defer file.Close()
-
-		_, err = file.Write(bytes)
+		bytes, err := export.NewExport(analysis).WithBookmarks(loadBookmarks(options.Image)).WithPullCost(cost).Marshal()
 		if err != nil {
-			events.exitWithErrorMessage("cannot write to export file", err)
+			events.exitWithErrorMessage("cannot marshal export payload", err)
+			return
+		}
+
+		if options.ExportFile != "" {
+			progress(utils.TitleFormat(fmt.Sprintf("Exporting image to '%s'...", options.ExportFile)))
+			file, err := filesystem.OpenFile(options.ExportFile, os.O_RDWR|os.O_CREATE, 0644)
+			if err != nil {
+				events.exitWithErrorMessage("cannot open export file", err)
+				return
+			}
+			defer file.Close()
+
+			_, err = file.Write(bytes)
+			if err != nil {
+				events.exitWithErrorMessage("cannot write to export file", err)
+				return
+			}
+		}
+
+		if options.Query != "" {
+			values, err := export.Query(bytes, options.Query)
+			if err != nil {
+				events.exitWithErrorMessage("cannot query export payload", err)
+				return
+			}
+			for _, value := range values {
+				events.message(value)
+			}
 		}
 		return
 	}
 
-	if options.Ci {
+	if options.Ci || options.Report {
 		events.message(fmt.Sprintf("  efficiency: %2.4f %%", analysis.Efficiency*100))
 		events.message(fmt.Sprintf("  wastedBytes: %d bytes (%s)", analysis.WastedBytes, humanize.Bytes(analysis.WastedBytes)))
 		events.message(fmt.Sprintf("  userWastedPercent: %2.4f %%", analysis.WastedUserPercent*100))
+		events.message(baseReport(analysis))
+		if analysis.Storage != nil && viper.GetBool("storage.enabled") {
+			events.message(storageReport(analysis.Storage))
+		}
+		if analysis.Signature != nil {
+			events.message(signatureReport(analysis.Signature))
+		}
+		if analysis.Vulnerabilities != nil {
+			events.message(vulnerabilityReport(analysis.Vulnerabilities))
+		}
+		if analysis.Packages != nil && viper.GetBool("packages.enabled") {
+			events.message(packagesReport(analysis.Packages, analysis.SizeBytes))
+		}
+		if report := parseWarningReport(analysis.Layers); report != "" {
+			events.message(report)
+		}
+		if report := boundsReport(analysis.Layers); report != "" {
+			events.message(report)
+		}
+		if len(analysis.Deprecations) > 0 {
+			events.message(deprecationReport(analysis.Deprecations))
+		}
+		if len(analysis.DuplicateArtifacts) > 0 {
+			events.message(duplicateArtifactReport(analysis.DuplicateArtifacts))
+		}
+		if analysis.AssetBloat != nil && len(analysis.AssetBloat.Findings) > 0 {
+			events.message(assetReport(analysis.AssetBloat))
+		}
+		if analysis.Prunable != nil && !analysis.Prunable.Empty() {
+			events.message(prunableReport(analysis.Prunable))
+		}
+		if analysis.Reproducibility != nil && !analysis.Reproducibility.Empty() {
+			events.message(reproducibilityReport(analysis.Reproducibility))
+		}
+		if analysis.ML != nil && !analysis.ML.Empty() {
+			events.message(mlReport(analysis.ML))
+		}
+		if analysis.Conda != nil && !analysis.Conda.Empty() {
+			events.message(condaReport(analysis.Conda))
+		}
+		if analysis.Dependencies != nil && viper.GetBool("dependencies.enabled") {
+			events.message(dependenciesReport(analysis.Dependencies))
+		}
+		if analysis.Compression != nil && viper.GetBool("compression.enabled") {
+			events.message(compressionReport(analysis.Compression))
+		}
+		if analysis.Audit != nil && viper.GetBool("audit.enabled") {
+			events.message(auditReport(analysis.Audit))
+		}
+		if analysis.Ownership != nil && viper.GetBool("ownership.enabled") {
+			events.message(ownershipReport(analysis.Ownership))
+		}
+		if analysis.DuplicateContent != nil {
+			events.message(duplicateContentReport(analysis.DuplicateContent.Result()))
+		}
+
+		cost, err := estimatePullCost(analysis)
+		if err != nil {
+			events.exitWithErrorMessage("invalid pull configuration", err)
+			return
+		}
+		if pullReportEnabled() {
+			events.message(pullReport(cost, analysis.Layers))
+		}
+
+		if !options.Ci {
+			// the CI rules are only validated when asked for
+			events.message(inefficiencyReport(analysis.Inefficiencies))
+			return
+		}
+
+		if len(options.History) > 0 {
+			var history []*image.Image
+			for _, previous := range options.History {
+				events.message(utils.TitleFormat("Fetching previous image...") + " " + previous)
+				previousImg, err := imageResolver.Fetch(ctx, previous)
+				if err != nil {
+					events.exitWithErrorMessage("cannot fetch previous image", err)
+					return
+				}
+				defer previousImg.Close()
+				history = append(history, previousImg)
+			}
+			events.message(splitReport(image.AdviseLayerSplit(img, history)))
+		}
 
 		evaluator := ci.NewCiEvaluator(options.CiConfig)
+		evaluator.Rules = append(evaluator.Rules, options.Budget.Rules()...)
+		if options.Signature != nil {
+			evaluator.Rules = append(evaluator.Rules, ci.SignatureRule(options.Signature.Required))
+		}
 		pass := evaluator.Evaluate(analysis)
 		events.message(evaluator.Report())
 
@@ -86,9 +298,14 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 		return
 
 	} else {
-		events.message(utils.TitleFormat("Building cache..."))
-		treeStack := filetree.NewComparer(analysis.RefTrees)
-		errors := treeStack.BuildCache()
+		treeStack := img.Comparer()
+		treeStack.SetRecorder(metrics.FromContext(ctx))
+		var errors []error
+		if !img.IsLazy() {
+			// lazy images parse (and compare) each layer when it is first selected instead
+			events.message(utils.TitleFormat("Building cache..."))
+			errors = treeStack.BuildCache()
+		}
 		if errors != nil {
 			for _, err := range errors {
 				events.message("  " + err.Error())
@@ -107,7 +324,10 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 			// enough sleep will prevent this behavior (todo: remove this hack)
 			time.Sleep(100 * time.Millisecond)
 
-			err = ui.Run(options.Image, analysis, treeStack)
+			stopStatus()
+			tabs, closeTabs := uiTabs(hashingCtx, options)
+			err = ui.Run(options.Image, analysis, treeStack, progressBus, options.Script, options.IDMapping, tabs...)
+			closeTabs()
 			if err != nil {
 				events.exitWithError(err)
 				return
@@ -116,11 +336,113 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 	}
 }
 
-func Run(options Options) {
+// uiTabs returns the loaders of the images opened in tabs of the UI along with the image, and the function that closes
+// the images loaded once the UI is over (stopping the images still loading).
+func uiTabs(ctx context.Context, options Options) ([]ui.ImageTab, func()) {
+	ctx, stop := context.WithCancel(ctx)
+	var lock sync.Mutex
+	var opened []*image.Image
+
+	var tabs []ui.ImageTab
+	for _, tab := range options.Tabs {
+		tab := tab
+		tabs = append(tabs, ui.ImageTab{
+			Name: tab.Image,
+			Load: func() (*image.AnalysisResult, filetree.Comparer, error) {
+				img, analysis, cache, err := loadTab(ctx, options, tab)
+				if err != nil {
+					return nil, filetree.Comparer{}, err
+				}
+				lock.Lock()
+				opened = append(opened, img)
+				lock.Unlock()
+				return analysis, cache, nil
+			},
+		})
+	}
+
+	return tabs, func() {
+		stop()
+		lock.Lock()
+		defer lock.Unlock()
+		for _, img := range opened {
+			if err := img.Close(); err != nil {
+				logrus.Errorf("unable to close image: %+v", err)
+			}
+		}
+		opened = nil
+	}
+}
+
+// loadTab fetches and analyzes an image opened in a tab of the UI like the first image, without the checks and the
+// comparisons only made for the first image (base image, signature, vulnerabilities).
+func loadTab(ctx context.Context, options Options, tab TabImage) (*image.Image, *image.AnalysisResult, filetree.Comparer, error) {
+	resolver, err := dive.GetImageResolver(tab.Source, options.Resolver)
+	if err != nil {
+		return nil, nil, filetree.Comparer{}, err
+	}
+	img, err := fetch(ctx, Options{Source: tab.Source, Image: tab.Image, Lazy: options.Lazy}, resolver, true)
+	if err != nil {
+		return nil, nil, filetree.Comparer{}, err
+	}
+
+	if viper.GetBool("packages.enabled") {
+		img.Packages = image.ReadPackages(img.Trees, img.Contents)
+	}
+	analysis, err := img.AnalyzeWithOptions(ctx, options.Analysis)
+	if err == nil && viper.GetBool("duplicates.enabled") {
+		analysis.DuplicateContent = img.FindDuplicateContent(ctx, options.Resolver.IO)
+	}
+
+	cache := img.Comparer()
+	cache.SetRecorder(metrics.FromContext(ctx))
+	if err == nil && !img.IsLazy() {
+		// lazy images parse (and compare) each layer when it is first selected instead
+		if errs := cache.BuildCache(); len(errs) > 0 && !options.IgnoreErrors {
+			err = fmt.Errorf("file tree has path errors (use '--ignore-errors' to attempt to continue): %v", errs[0])
+		}
+	}
+	if err != nil {
+		if closeErr := img.Close(); closeErr != nil {
+			logrus.Errorf("unable to close image: %+v", closeErr)
+		}
+		return nil, nil, filetree.Comparer{}, err
+	}
+	return img, analysis, cache, nil
+}
+
+// signatureReference is the reference the signature of the image is verified for: images read from a registry are
+// pinned to the manifest digest that was analyzed, so that the tag cannot move in between.
+func signatureReference(options Options, img *image.Image) string {
+	if options.Source != dive.SourceRegistry || !strings.HasPrefix(img.ID, "sha256:") {
+		return options.Image
+	}
+	ref, err := docker.ParseRemoteReference(options.Image)
+	if err != nil {
+		return options.Image
+	}
+	ref.Tag = img.ID
+	return ref.String()
+}
+
+// fetch resolves the image, only reading the layer metadata upfront if lazy loading is requested (and allowed).
+func fetch(ctx context.Context, options Options, imageResolver image.Resolver, allowLazy bool) (*image.Image, error) {
+	if options.Lazy && allowLazy {
+		if lazyResolver, ok := imageResolver.(image.LazyResolver); ok {
+			return lazyResolver.FetchLazy(ctx, options.Image)
+		}
+		logrus.Warnf("the %s source does not support lazy loading, loading all layers upfront", options.Source)
+	}
+	return imageResolver.Fetch(ctx, options.Image)
+}
+
+// Run fetches (or builds) and analyzes the image, then shows the UI or reports the analysis; canceling the context
+// (e.g. on Ctrl-C) stops the fetch and the analysis.
+func Run(ctx context.Context, options Options) {
 	var exitCode int
 	var events = make(eventChannel)
 
-	imageResolver, err := dive.GetImageResolver(options.Source)
+	imageResolver, err := dive.GetImageResolver(options.Source, options.Resolver)
 	if err != nil {
 		message := "cannot determine image provider"
 		logrus.Error(message)
@@ -129,9 +451,29 @@ func Run(options Options) {
 		os.Exit(1)
 	}
 
-	go run(true, options, imageResolver, events, afero.NewOsFs())
+	if !options.Ci && options.ExportFile == "" && options.Query == "" && !isTerminal(os.Stdout) {
+		// the UI cannot be shown when the output is piped or redirected
+		fmt.Fprintln(os.Stderr, "stdout is not a terminal, reporting the analysis instead of showing the UI (use --ci to also validate the CI rules)")
+		options.Report = true
+		if len(options.Tabs) > 0 {
+			fmt.Fprintln(os.Stderr, "only the first image is reported")
+		}
+	}
+
+	// the timings are cheap to take, and shown with keybinding.show-metrics
+	recorder := metrics.NewRecorder()
+	ctx = metrics.WithRecorder(ctx, recorder)
 
+	go run(ctx, true, options, imageResolver, events, afero.NewOsFs())
+
+	status := newStatusLine(os.Stderr, isTerminal(os.Stderr))
 	for event := range events {
+		if event.status != nil {
+			status.update(*event.status)
+			continue
+		}
+		status.clear()
+
 		if event.stdout != "" {
 			fmt.Println(event.stdout)
 		}
@@ -155,5 +497,50 @@ func Run(options Options) {
 			exitCode = 1
 		}
 	}
+	// the hooks fired along the run are let finish
+	hook.Wait()
+	logTimings(recorder)
+	if err := StopProfile(recorder); err != nil {
+		logrus.Error(err)
+		fmt.Fprintln(os.Stderr, err)
+	}
 	os.Exit(exitCode)
 }
+
+// isTerminal indicates if the file is an interactive terminal the UI can be shown on.
+func isTerminal(file *os.File) bool {
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+// recordResults stores the analysis in the results database (see "dive query"); failing to do so does not fail the run.
+func recordResults(imageName string, analysis *image.AnalysisResult) {
+	path, err := results.Path(viper.GetString("results.path"))
+	if err != nil {
+		logrus.Warnf("unable to locate the results database: %+v", err)
+		return
+	}
+	store, err := results.Open(path)
+	if err != nil {
+		logrus.Warnf("unable to open the results database: %+v", err)
+		return
+	}
+	defer store.Close()
+
+	if err := store.Record(imageName, time.Now(), analysis); err != nil {
+		logrus.Warnf("unable to record the analysis: %+v", err)
+	}
+}
+
+// loadBookmarks returns the paths marked in the UI for the given image (if any).
+func loadBookmarks(imageName string) []string {
+	store, err := bookmark.NewDefaultStore()
+	if err != nil {
+		logrus.Debugf("unable to locate the marked files: %+v", err)
+		return nil
+	}
+	paths, err := store.Load(imageName)
+	if err != nil {
+		logrus.Warnf("unable to load the marked files: %+v", err)
+	}
+	return paths
+}
@@ -1,11 +1,13 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"github.com/lunixbochs/vtclean"
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/dive/image/docker"
 	"os"
@@ -14,7 +16,7 @@ import (
 
 type defaultResolver struct{}
 
-func (r *defaultResolver) Fetch(id string) (*image.Image, error) {
+func (r *defaultResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
 	archive, err := docker.TestLoadArchive("../.data/test-docker-image.tar")
 	if err != nil {
 		return nil, err
@@ -22,13 +24,13 @@ func (r *defaultResolver) Fetch(id string) (*image.Image, error) {
 	return archive.ToImage()
 }
 
-func (r *defaultResolver) Build(args []string) (*image.Image, error) {
-	return r.Fetch("")
+func (r *defaultResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
+	return r.Fetch(ctx, "")
 }
 
 type failedBuildResolver struct{}
 
-func (r *failedBuildResolver) Fetch(id string) (*image.Image, error) {
+func (r *failedBuildResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
 	archive, err := docker.TestLoadArchive("../.data/test-docker-image.tar")
 	if err != nil {
 		return nil, err
@@ -36,17 +38,17 @@ func (r *failedBuildResolver) Fetch(id string) (*image.Image, error) {
 	return archive.ToImage()
 }
 
-func (r *failedBuildResolver) Build(args []string) (*image.Image, error) {
+func (r *failedBuildResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
 	return nil, fmt.Errorf("some build failure")
 }
 
 type failedFetchResolver struct{}
 
-func (r *failedFetchResolver) Fetch(id string) (*image.Image, error) {
+func (r *failedFetchResolver) Fetch(ctx context.Context, id string) (*image.Image, error) {
 	return nil, fmt.Errorf("some fetch failure")
 }
 
-func (r *failedFetchResolver) Build(args []string) (*image.Image, error) {
+func (r *failedFetchResolver) Build(ctx context.Context, args []string) (*image.Image, error) {
 	return nil, fmt.Errorf("some build failure")
 }
 
@@ -84,7 +86,17 @@ func configureCi() *viper.Viper {
 	ciConfig := viper.New()
 	ciConfig.SetDefault("rules.lowestEfficiency", "0.9")
 	ciConfig.SetDefault("rules.highestWastedBytes", "1000")
+	ciConfig.SetDefault("rules.highestAppWastedBytes", "disabled")
 	ciConfig.SetDefault("rules.highestUserWastedPercent", "0.1")
+	ciConfig.SetDefault("rules.highestFileCount", "1000")
+	ciConfig.SetDefault("rules.highestLayerFileCount", "400")
+	ciConfig.SetDefault("rules.forbidCorruptLayers", "true")
+	ciConfig.SetDefault("rules.forbidDuplicateArtifacts", "true")
+	ciConfig.SetDefault("rules.forbidSetuidFiles", "true")
+	ciConfig.SetDefault("rules.forbidWorldWritableFiles", "true")
+	ciConfig.SetDefault("rules.forbidRootOwnedAppFiles", "true")
+	ciConfig.SetDefault("rules.forbidUnexpectedCapabilities", "true")
+	ciConfig.SetDefault("rules.forbiddenContent", "**/id_rsa*")
 	return ciConfig
 }
 
@@ -92,6 +104,7 @@ func TestRun(t *testing.T) {
 	table := map[string]struct {
 		resolver image.Resolver
 		options  Options
+		settings map[string]interface{}
 		events   []testEvent
 	}{
 		"fetch-case": {
@@ -189,13 +202,37 @@ func TestRun(t *testing.T) {
 			events: []testEvent{
 				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
 				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  efficiency: 98.4421 %", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  wastedBytes: 32025 bytes (32 kB)", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  userWastedPercent: 48.3491 %", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example/somefile3.txt\nResults:\n  FAIL: highestUserWastedPercent: too many bytes wasted, relative to the user bytes added (%-user-wasted-bytes=0.4834911001404049 > threshold=0.1)\n  FAIL: highestWastedBytes: too many bytes wasted (wasted-bytes=32025 > threshold=1000)\n  PASS: lowestEfficiency\nResult:FAIL [Total:3] [Passed:1] [Failed:2] [Warn:0] [Skipped:0]\n", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 97.9120 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 58.0189 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Base Image:\n  image: (assumed to be the first layer, use --base-image to set)\n  baseLayers: 1 of 14\n  baseSize: 1154361 bytes (1.2 MB)\n  baseWastedBytes: 0 bytes (0 B)\n  appSize: 66237 bytes (66 kB)\n  appWastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Deprecation Warnings:\n  WARN: legacy builder history: 4 history entries were recorded by the legacy builder (\"#(nop)\" commands), which is deprecated in favor of BuildKit\n  WARN: v1 layer format: the image archive uses the legacy v1 layout (a VERSION and json file per layer); current engines save images in the OCI layout", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Reproducibility:\n  layer 4   build timestamps      1 files      6.4 kB  /root/example/somefile1.txt\n  layer 5   build timestamps      1 files      6.4 kB  /root/example/somefile2.txt\n  layer 6   build timestamps      1 files      6.4 kB  /root/example/somefile3.txt\n  layer 7   build timestamps      1 files      6.4 kB  /root/saved.txt\n  layer 8   build timestamps      1 files      6.4 kB  /root/.saved.txt\n  layer 10  build timestamps      1 files      1.3 kB  /root/.data/test.sh\n  layer 11  build timestamps      1 files      6.4 kB  /tmp/saved.again1.txt\n  build timestamps: set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example\n    2        6.4 kB  /root/example/somefile3.txt\nResults:\n  PASS: forbidCorruptLayers\n  PASS: forbidDuplicateArtifacts\n  PASS: forbidRootOwnedAppFiles\n  PASS: forbidSetuidFiles\n  PASS: forbidUnexpectedCapabilities\n  PASS: forbidWorldWritableFiles\n  PASS: forbiddenContent\n  SKIP: highestAppWastedBytes: rule disabled\n  PASS: highestFileCount\n  FAIL: highestLayerFileCount: too many files in a layer (threshold=400): layer 0 (415 files)\n  FAIL: highestUserWastedPercent: too many bytes wasted, relative to the user bytes added (%-user-wasted-bytes=0.5801893201684859 > threshold=0.1)\n  FAIL: highestWastedBytes: too many bytes wasted (wasted-bytes=38430 > threshold=1000)\n  PASS: lowestEfficiency\nResult:FAIL [Total:13] [Passed:9] [Failed:3] [Warn:0] [Skipped:1]\n", stderr: "", errorOnExit: false, errMessage: ""},
 				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
 			},
 		},
+		"report-go-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Report:     true,
+				Image:      "doesn't-matter",
+				Source:     dive.SourceDockerEngine,
+				ExportFile: "",
+				BuildArgs:  []string{"an-option"},
+			},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 97.9120 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 58.0189 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Base Image:\n  image: (assumed to be the first layer, use --base-image to set)\n  baseLayers: 1 of 14\n  baseSize: 1154361 bytes (1.2 MB)\n  baseWastedBytes: 0 bytes (0 B)\n  appSize: 66237 bytes (66 kB)\n  appWastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Deprecation Warnings:\n  WARN: legacy builder history: 4 history entries were recorded by the legacy builder (\"#(nop)\" commands), which is deprecated in favor of BuildKit\n  WARN: v1 layer format: the image archive uses the legacy v1 layout (a VERSION and json file per layer); current engines save images in the OCI layout", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Reproducibility:\n  layer 4   build timestamps      1 files      6.4 kB  /root/example/somefile1.txt\n  layer 5   build timestamps      1 files      6.4 kB  /root/example/somefile2.txt\n  layer 6   build timestamps      1 files      6.4 kB  /root/example/somefile3.txt\n  layer 7   build timestamps      1 files      6.4 kB  /root/saved.txt\n  layer 8   build timestamps      1 files      6.4 kB  /root/.saved.txt\n  layer 10  build timestamps      1 files      1.3 kB  /root/.data/test.sh\n  layer 11  build timestamps      1 files      6.4 kB  /tmp/saved.again1.txt\n  build timestamps: set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example\n    2        6.4 kB  /root/example/somefile3.txt", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
 		"empty-ci-config-case": {
 			resolver: &defaultResolver{},
 			options: Options{
@@ -209,10 +246,13 @@ func TestRun(t *testing.T) {
 			events: []testEvent{
 				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
 				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  efficiency: 98.4421 %", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  wastedBytes: 32025 bytes (32 kB)", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "  userWastedPercent: 48.3491 %", stderr: "", errorOnExit: false, errMessage: ""},
-				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\nNone\nResults:\n  MISCONFIGURED: highestUserWastedPercent: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\n  MISCONFIGURED: highestWastedBytes: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\n  MISCONFIGURED: lowestEfficiency: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\nCI Misconfigured\n", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 97.9120 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 58.0189 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Base Image:\n  image: (assumed to be the first layer, use --base-image to set)\n  baseLayers: 1 of 14\n  baseSize: 1154361 bytes (1.2 MB)\n  baseWastedBytes: 0 bytes (0 B)\n  appSize: 66237 bytes (66 kB)\n  appWastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Deprecation Warnings:\n  WARN: legacy builder history: 4 history entries were recorded by the legacy builder (\"#(nop)\" commands), which is deprecated in favor of BuildKit\n  WARN: v1 layer format: the image archive uses the legacy v1 layout (a VERSION and json file per layer); current engines save images in the OCI layout", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Reproducibility:\n  layer 4   build timestamps      1 files      6.4 kB  /root/example/somefile1.txt\n  layer 5   build timestamps      1 files      6.4 kB  /root/example/somefile2.txt\n  layer 6   build timestamps      1 files      6.4 kB  /root/example/somefile3.txt\n  layer 7   build timestamps      1 files      6.4 kB  /root/saved.txt\n  layer 8   build timestamps      1 files      6.4 kB  /root/.saved.txt\n  layer 10  build timestamps      1 files      1.3 kB  /root/.data/test.sh\n  layer 11  build timestamps      1 files      6.4 kB  /tmp/saved.again1.txt\n  build timestamps: set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\nNone\nResults:\n  MISCONFIGURED: forbidCorruptLayers: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  MISCONFIGURED: forbidDuplicateArtifacts: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  MISCONFIGURED: forbidRootOwnedAppFiles: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  MISCONFIGURED: forbidSetuidFiles: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  MISCONFIGURED: forbidUnexpectedCapabilities: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  MISCONFIGURED: forbidWorldWritableFiles: invalid config value (''): strconv.ParseBool: parsing \"\": invalid syntax\n  CONFIGURED   : forbiddenContent: test\n  MISCONFIGURED: highestAppWastedBytes: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\n  MISCONFIGURED: highestFileCount: invalid config value (''): strconv.ParseUint: parsing \"\": invalid syntax\n  MISCONFIGURED: highestLayerFileCount: invalid config value (''): strconv.ParseUint: parsing \"\": invalid syntax\n  MISCONFIGURED: highestUserWastedPercent: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\n  MISCONFIGURED: highestWastedBytes: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\n  MISCONFIGURED: lowestEfficiency: invalid config value (''): strconv.ParseFloat: parsing \"\": invalid syntax\nCI Misconfigured\n", stderr: "", errorOnExit: false, errMessage: ""},
 				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
 			},
 		},
@@ -232,6 +272,31 @@ func TestRun(t *testing.T) {
 				{stdout: "Exporting image to 'some-file.json'...", stderr: "", errorOnExit: false, errMessage: ""},
 			},
 		},
+		"report-tables-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Report: true,
+				Image:  "doesn't-matter",
+				Source: dive.SourceDockerEngine,
+			},
+			settings: map[string]interface{}{"storage.enabled": true, "compression.enabled": true, "ownership.enabled": true, "pull.enabled": true},
+			events: []testEvent{
+				{stdout: "Image Source: docker://doesn't-matter", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 97.9120 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 58.0189 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Base Image:\n  image: (assumed to be the first layer, use --base-image to set)\n  baseLayers: 1 of 14\n  baseSize: 1154361 bytes (1.2 MB)\n  baseWastedBytes: 0 bytes (0 B)\n  appSize: 66237 bytes (66 kB)\n  appWastedBytes: 38430 bytes (38 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Runtime Storage Friendliness:\n  rating: good\n  layerDepth: 14 (overlay2 limit: 125)\n  layerEntries: 451 (unique: 423, shadowed: 28)\n  largestLayerEntries: 415 (layer 0)\n  estimatedMetadata: 116 kB\n  copyUpCandidates: 1 files (6.4 kB)\n          6.4 kB  2 revisions  /root/saved.txt", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Deprecation Warnings:\n  WARN: legacy builder history: 4 history entries were recorded by the legacy builder (\"#(nop)\" commands), which is deprecated in favor of BuildKit\n  WARN: v1 layer format: the image archive uses the legacy v1 layout (a VERSION and json file per layer); current engines save images in the OCI layout", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Reproducibility:\n  layer 4   build timestamps      1 files      6.4 kB  /root/example/somefile1.txt\n  layer 5   build timestamps      1 files      6.4 kB  /root/example/somefile2.txt\n  layer 6   build timestamps      1 files      6.4 kB  /root/example/somefile3.txt\n  layer 7   build timestamps      1 files      6.4 kB  /root/saved.txt\n  layer 8   build timestamps      1 files      6.4 kB  /root/.saved.txt\n  layer 10  build timestamps      1 files      1.3 kB  /root/.data/test.sh\n  layer 11  build timestamps      1 files      6.4 kB  /tmp/saved.again1.txt\n  build timestamps: set SOURCE_DATE_EPOCH and clamp the mtimes (e.g. BuildKit rewrite-timestamp=true)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Layer Compression:\n        Size  Compressed   Ratio  Precompressed  Layer\n      1.2 MB      739 kB    1.6x             0%  0\n      6.4 kB      2.5 kB    2.5x             0%  1\n         0 B       154 B       -             0%  2\n      6.4 kB      2.6 kB    2.5x             0%  3\n      6.4 kB      2.6 kB    2.5x             0%  4\n      6.4 kB      2.6 kB    2.5x             0%  5\n      6.4 kB      2.6 kB    2.5x             0%  6\n      6.4 kB      2.6 kB    2.4x             0%  7\n      6.4 kB      2.6 kB    2.5x             0%  8\n         0 B       133 B       -             0%  9\n      2.2 kB      1.3 kB    1.7x             0%  10\n      6.4 kB      2.6 kB    2.5x             0%  11\n      6.4 kB      2.6 kB    2.5x             0%  12\n      6.4 kB      2.6 kB    2.5x             0%  13", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Ownership:\n  root owned: 1.2 MB of 1.2 MB (100%)\n    Owner            Files      Size  Share\n    0:0                405    1.2 MB   100%\n    Layer      Size      Root  Root %\n        0    1.2 MB    1.2 MB    100%\n        1    6.4 kB    6.4 kB    100%\n        2       0 B       0 B      0%\n        3    6.4 kB    6.4 kB    100%\n        4    6.4 kB    6.4 kB    100%\n        5    6.4 kB    6.4 kB    100%\n        6    6.4 kB    6.4 kB    100%\n        7    6.4 kB    6.4 kB    100%\n        8    6.4 kB    6.4 kB    100%\n        9       0 B       0 B      0%\n       10    2.2 kB    2.2 kB    100%\n       11    6.4 kB    6.4 kB    100%\n       12    6.4 kB    6.4 kB    100%\n       13    6.4 kB    6.4 kB    100%", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Estimated Pull Time:\n  profile: 100 Mbps, 100ms per layer\n  coldPull: 1.5s\n  warmPull: 1.3s (base layers cached)\n    Transfer      Cold      Warm  Layer\n      739 kB     159ms        0s  0\n      2.5 kB     100ms     100ms  1\n       154 B     100ms     100ms  2\n      2.6 kB     100ms     100ms  3\n      2.6 kB     100ms     100ms  4\n      2.6 kB     100ms     100ms  5\n      2.6 kB     100ms     100ms  6\n      2.6 kB     100ms     100ms  7\n      2.6 kB     100ms     100ms  8\n       133 B     100ms     100ms  9\n      1.3 kB     100ms     100ms  10\n      2.6 kB     100ms     100ms  11\n      2.6 kB     100ms     100ms  12\n      2.6 kB     100ms     100ms  13", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example\n    2        6.4 kB  /root/example/somefile3.txt", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
 	}
 
 	for name, test := range table {
@@ -239,12 +304,20 @@ func TestRun(t *testing.T) {
 		var events = make([]testEvent, 0)
 		var filesystem = afero.NewMemMapFs()
 
-		go run(false, test.options, test.resolver, ec, filesystem)
+		for key, value := range test.settings {
+			viper.Set(key, value)
+		}
+
+		go run(context.Background(), false, test.options, test.resolver, ec, filesystem)
 
 		for event := range ec {
 			events = append(events, newTestEvent(event))
 		}
 
+		for key := range test.settings {
+			viper.Set(key, nil)
+		}
+
 		// fmt.Println(name)
 		// showEvents(events)
 		// fmt.Println()
@@ -286,3 +359,30 @@ func TestRun(t *testing.T) {
 		}
 	}
 }
+
+func TestUITabsLoadArchives(t *testing.T) {
+	tabs, closeTabs := uiTabs(context.Background(), Options{Tabs: []TabImage{
+		{Source: dive.SourceDockerArchive, Image: "../.data/test-docker-image.tar"},
+		{Source: dive.SourceDockerArchive, Image: "../.data/missing.tar"},
+	}})
+	defer closeTabs()
+
+	if len(tabs) != 2 || tabs[0].Name != "../.data/test-docker-image.tar" {
+		t.Fatalf("unexpected tabs: %+v", tabs)
+	}
+
+	analysis, cache, err := tabs[0].Load()
+	if err != nil {
+		t.Fatalf("unable to load the image: %+v", err)
+	}
+	if len(analysis.Layers) != 14 {
+		t.Errorf("expected 14 layers, got %d", len(analysis.Layers))
+	}
+	if _, err := cache.GetTree(filetree.NewTreeIndexKey(0, 0, 0, 0)); err != nil {
+		t.Errorf("unable to get the first tree: %+v", err)
+	}
+
+	if _, _, err := tabs[1].Load(); err == nil {
+		t.Errorf("expected an error for a missing archive")
+	}
+}
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// SharedReport renders which layers a set of images share, listing at most limit layers (all of them when limit is 0),
+// and the images that would benefit from being rebased onto the common base.
+func SharedReport(analysis *image.SharedAnalysis, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Shared Layers:"))
+
+	shared := 0
+	for _, layer := range analysis.Layers {
+		if layer.Shared() {
+			shared++
+		}
+	}
+	fmt.Fprintf(&sb, "  images: %d\n", len(analysis.Images))
+	fmt.Fprintf(&sb, "  layers: %d distinct, %d shared\n", len(analysis.Layers), shared)
+	fmt.Fprintf(&sb, "  totalSize: %s (every image on its own)\n", humanize.Bytes(analysis.TotalBytes))
+	fmt.Fprintf(&sb, "  deduplicatedSize: %s (saving %s)\n", humanize.Bytes(analysis.DeduplicatedBytes), humanize.Bytes(analysis.SavedBytes()))
+
+	fmt.Fprintf(&sb, "    %8s  %8s  %8s  %6s  %s\n", "Size", "Shared", "Unique", "Layers", "Image")
+	for _, summary := range analysis.Images {
+		fmt.Fprintf(&sb, "    %8s  %8s  %8s  %6d  %s\n", humanize.Bytes(summary.SizeBytes), humanize.Bytes(summary.SharedBytes), humanize.Bytes(summary.UniqueBytes), summary.Layers, summary.Name)
+	}
+
+	fmt.Fprintf(&sb, "    %6s  %8s  %s\n", "Images", "Size", "Layer")
+	layers := analysis.Layers
+	if limit > 0 && len(layers) > limit {
+		layers = layers[:limit]
+	}
+	for _, layer := range layers {
+		fmt.Fprintf(&sb, "    %6d  %8s  %s\n", len(layer.Images), humanize.Bytes(layer.SizeBytes), layer.Command)
+	}
+	if len(analysis.Layers) > len(layers) {
+		fmt.Fprintf(&sb, "    ... and %d more\n", len(analysis.Layers)-len(layers))
+	}
+
+	if len(analysis.CommonBase) == 0 {
+		fmt.Fprintln(&sb, "  commonBase: none (no two images start with the same layer)")
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+	var baseBytes uint64
+	for _, layer := range analysis.CommonBase {
+		baseBytes += layer.SizeBytes
+	}
+	fmt.Fprintf(&sb, "  commonBase: %d layers (%s), the base of %s\n", len(analysis.CommonBase), humanize.Bytes(baseBytes), strings.Join(sharedImageNames(analysis, analysis.CommonBaseImages), ", "))
+	if len(analysis.Rebase) == 0 {
+		fmt.Fprintln(&sb, "  rebase: every image is built on the common base")
+	}
+	for _, candidate := range analysis.Rebase {
+		fmt.Fprintf(&sb, "  rebase: %s would share %s by rebasing onto the common base (%d of %d layers in common)\n",
+			analysis.Images[candidate.Image].Name, humanize.Bytes(candidate.SavedBytes), candidate.MatchingLayers, len(analysis.CommonBase))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func sharedImageNames(analysis *image.SharedAnalysis, indexes []int) []string {
+	names := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		names = append(names, analysis.Images[idx].Name)
+	}
+	return names
+}
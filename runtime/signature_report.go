@@ -0,0 +1,24 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// signatureReport renders the outcome of the cosign signature verification, with the signatures found valid.
+func signatureReport(verification *image.SignatureVerification) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Signature:"))
+	fmt.Fprintf(&sb, "  %s: %s\n", verification.Reference, verification.Summary())
+	for _, signature := range verification.Signatures {
+		line := "    " + signature.Digest
+		if signature.Subject != "" {
+			line += fmt.Sprintf(" signed by %s (%s)", signature.Subject, signature.Issuer)
+		}
+		fmt.Fprintln(&sb, line)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
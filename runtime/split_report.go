@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of paths listed per group in the report
+const splitReportMaxGroups = 5
+
+// splitReport renders the layer split suggestion (if any) derived from the image tag history.
+func splitReport(advice *image.SplitAdvice) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Layer Split Advice:"))
+	if advice == nil {
+		fmt.Fprint(&sb, "  none (no large COPY/ADD layer mixing stable and frequently-changing content)")
+		return sb.String()
+	}
+
+	currentBytes, splitBytes := advice.BytesPerUpdate()
+	fmt.Fprintf(&sb, "  layer %d (%s): %s\n", advice.LayerIndex, humanize.Bytes(advice.SizeBytes), advice.Command)
+	fmt.Fprintf(&sb, "  changed in %d of %d versions\n", advice.LayerChanges, advice.Transitions)
+	fmt.Fprintf(&sb, "  suggestion: copy the stable paths (%s) in a separate, earlier instruction than the volatile paths (%s)\n",
+		humanize.Bytes(advice.StableBytes), humanize.Bytes(advice.VolatileBytes))
+	writeSplitGroups(&sb, "stable", advice.Stable)
+	writeSplitGroups(&sb, "volatile", advice.Volatile)
+	fmt.Fprintf(&sb, "  cacheHitRatio: %2.0f %% -> %2.0f %%\n", advice.CurrentCacheHitRatio()*100, advice.SplitCacheHitRatio()*100)
+	fmt.Fprintf(&sb, "  bytesPerUpdate: %s -> %s", humanize.Bytes(currentBytes), humanize.Bytes(splitBytes))
+	return sb.String()
+}
+
+func writeSplitGroups(sb *strings.Builder, name string, groups []image.SplitGroup) {
+	fmt.Fprintf(sb, "  %s:\n", name)
+	for idx, group := range groups {
+		if idx >= splitReportMaxGroups {
+			fmt.Fprintf(sb, "    ...and %d more\n", len(groups)-idx)
+			break
+		}
+		fmt.Fprintf(sb, "    %12s  %d changes  %s\n", humanize.Bytes(group.SizeBytes), group.Changes, group.Path)
+	}
+}
@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// the most layers shown beneath the line while pulling (the others are counted)
+const maxStatusLayers = 12
+
+// the frames of the spinner shown ahead of the progress (advanced with every update)
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// statusLine shows the progress of the fetch and the analysis on a single line of a terminal, redrawn in place (a pull
+// also shows a line for each layer beneath it). The lines are cleared before any other output, and nothing is shown
+// when the output is not a terminal.
+type statusLine struct {
+	writer  io.Writer
+	enabled bool
+	// the number of lines shown
+	lines int
+	frame int
+}
+
+func newStatusLine(writer io.Writer, enabled bool) *statusLine {
+	return &statusLine{writer: writer, enabled: enabled}
+}
+
+// update redraws the line with the given progress, or clears it once the stage is done.
+func (s *statusLine) update(progress image.ProgressEvent) {
+	if !s.enabled {
+		return
+	}
+	if progress.Done {
+		s.clear()
+		return
+	}
+	s.clear()
+	s.frame = (s.frame + 1) % len(spinnerFrames)
+	fmt.Fprintf(s.writer, "%s %s", spinnerFrames[s.frame], progress)
+	s.lines = 1
+	for idx, layer := range progress.Layers {
+		if idx == maxStatusLayers {
+			fmt.Fprintf(s.writer, "\n  ... and %d more layers", len(progress.Layers)-maxStatusLayers)
+			s.lines++
+			break
+		}
+		fmt.Fprintf(s.writer, "\n  %s", layer)
+		s.lines++
+	}
+}
+
+// clear removes the lines (when shown), leaving the cursor at the start of the first one.
+func (s *statusLine) clear() {
+	if s.lines == 0 {
+		return
+	}
+	fmt.Fprint(s.writer, "\r\033[K")
+	for line := 1; line < s.lines; line++ {
+		// move up to the line above, clearing it
+		fmt.Fprint(s.writer, "\033[A\r\033[K")
+	}
+	s.lines = 0
+}
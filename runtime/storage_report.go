@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the number of copy-up candidates listed in the report (the export contains all of them)
+const storageReportMaxCandidates = 10
+
+// storageReport renders the "runtime storage friendliness" section intended for node operators.
+func storageReport(storage *image.StorageOverhead) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Runtime Storage Friendliness:"))
+	fmt.Fprintf(&sb, "  rating: %s\n", storage.Rating)
+	fmt.Fprintf(&sb, "  layerDepth: %d (overlay2 limit: %d)\n", storage.LayerDepth, image.MaxOverlayLayerDepth)
+	fmt.Fprintf(&sb, "  layerEntries: %d (unique: %d, shadowed: %d)\n", storage.TotalEntries, storage.UniqueEntries, storage.ShadowedEntries)
+	if largest, entries := storage.LargestLayer(); largest >= 0 {
+		fmt.Fprintf(&sb, "  largestLayerEntries: %d (layer %d)\n", entries, largest)
+	}
+	fmt.Fprintf(&sb, "  estimatedMetadata: %s\n", humanize.Bytes(storage.MetadataBytes))
+	fmt.Fprintf(&sb, "  copyUpCandidates: %d files (%s)\n", len(storage.CopyUpCandidates), humanize.Bytes(storage.CopyUpBytes))
+
+	for idx, candidate := range storage.CopyUpCandidates {
+		if idx >= storageReportMaxCandidates {
+			fmt.Fprintf(&sb, "    ...and %d more\n", len(storage.CopyUpCandidates)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "    %12s  %d revisions  %s\n", humanize.Bytes(candidate.SizeBytes), candidate.Revisions, candidate.Path)
+	}
+
+	for _, reason := range storage.Reasons {
+		fmt.Fprintf(&sb, "  note: %s\n", reason)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
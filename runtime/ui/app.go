@@ -1,105 +1,217 @@
 package ui
 
 import (
-	"sync"
+	"fmt"
+	"io"
+	"os"
+	goruntime "runtime"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/ui/format"
 
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/runtime/ui/key"
 	"github.com/wagoodman/dive/runtime/ui/layout"
 	"github.com/wagoodman/dive/runtime/ui/layout/compound"
+	"github.com/wagoodman/dive/runtime/ui/script"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+	"github.com/wagoodman/dive/runtime/ui/view"
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
 	"github.com/wagoodman/dive/dive/filetree"
 )
 
-const debug = false
-
 // type global
 type app struct {
 	gui         *gocui.Gui
 	controllers *Controller
 	layout      *layout.Manager
+	// the layout function given to gocui (the layout manager, repainting the screen within multiplexers)
+	manager func(*gocui.Gui) error
+	macros  *key.Macros
+	// the images opened in the session (nil when a single image is opened)
+	workspace *workspace
 }
 
-var (
-	once         sync.Once
-	appSingleton *app
-)
+func newApp(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer, render filetree.RenderOptions, symbols format.Symbols, ws *workspace) (*app, error) {
+	var tabs view.TabSource
+	if ws != nil {
+		tabs = ws.source
+	}
 
-func newApp(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer) (*app, error) {
-	var err error
-	once.Do(func() {
-		var controller *Controller
-		var globalHelpKeys []*key.Binding
+	controller, err := NewCollection(gui, imageName, analysis, cache, render, symbols, tabs)
+	if err != nil {
+		return nil, err
+	}
 
-		controller, err = NewCollection(gui, imageName, analysis, cache)
-		if err != nil {
-			return
-		}
+	// note: order matters when adding elements to the layout
+	lm := layout.NewManager()
+	if ws != nil {
+		lm.Add(controller.views.Tabs, layout.LocationHeader)
+	}
+	lm.Add(controller.views.Status, layout.LocationFooter)
+	lm.Add(controller.views.Filter, layout.LocationFooter)
+	lm.Add(controller.views.Search, layout.LocationFooter)
+	lm.Add(controller.views.GoToPath, layout.LocationFooter)
+	lm.Add(compound.NewLayerDetailsCompoundLayout(controller.views.Layer, controller.views.Warnings, controller.views.ParseWarnings, controller.views.Attestations, controller.views.Audit, controller.views.Dependencies, controller.views.Duplicates, controller.views.Marks, controller.views.FileDetails, controller.views.Details), layout.LocationColumn)
+	lm.Add(controller.views.Tree, layout.LocationColumn)
+	lm.Add(controller.views.Provenance, layout.LocationOverlay)
+	lm.Add(controller.views.History, layout.LocationOverlay)
+	lm.Add(controller.views.Ownership, layout.LocationOverlay)
+	lm.Add(controller.views.ImageConfig, layout.LocationOverlay)
+	lm.Add(controller.views.Preview, layout.LocationOverlay)
+	lm.Add(controller.views.Archive, layout.LocationOverlay)
+	lm.Add(controller.views.Pivot, layout.LocationOverlay)
+	lm.Add(controller.views.Packages, layout.LocationOverlay)
+	lm.Add(controller.views.Compare, layout.LocationOverlay)
+	lm.Add(controller.views.SavedFilters, layout.LocationOverlay)
+	lm.Add(controller.views.Debug, layout.LocationOverlay)
+	if ws != nil {
+		lm.Add(controller.views.TabPicker, layout.LocationOverlay)
+		controller.views.TabPicker.AddPickListener(ws.show)
+	}
+	lm.Add(controller.views.Dialog, layout.LocationOverlay)
+	lm.Add(controller.views.Toast, layout.LocationOverlay)
 
-		// note: order matters when adding elements to the layout
-		lm := layout.NewManager()
-		lm.Add(controller.views.Status, layout.LocationFooter)
-		lm.Add(controller.views.Filter, layout.LocationFooter)
-		lm.Add(compound.NewLayerDetailsCompoundLayout(controller.views.Layer, controller.views.Details), layout.LocationColumn)
-		lm.Add(controller.views.Tree, layout.LocationColumn)
+	gui.Cursor = false
+	//g.Mouse = true
+	manager := lm.Layout
+	if multiplexer := terminal.DetectMultiplexer(os.Getenv); multiplexer != terminal.NoMultiplexer {
+		logrus.Debugf("running within %s", multiplexer)
+		manager = terminal.SyncOnResize(lm.Layout)
+	}
+	layoutFn := manager
+	manager = func(g *gocui.Gui) error {
+		defer analysis.Metrics.Since("layout", "", time.Now())
+		return layoutFn(g)
+	}
 
-		// todo: access this more programmatically
-		if debug {
-			lm.Add(controller.views.Debug, layout.LocationColumn)
-		}
-		gui.Cursor = false
-		//g.Mouse = true
-		gui.SetManagerFunc(lm.Layout)
-
-		// var profileObj = profile.Start(profile.CPUProfile, profile.ProfilePath("."), profile.NoShutdownHook)
-		//
-		// onExit = func() {
-		// 	profileObj.Stop()
-		// }
-
-		appSingleton = &app{
-			gui:         gui,
-			controllers: controller,
-			layout:      lm,
-		}
+	// var profileObj = profile.Start(profile.CPUProfile, profile.ProfilePath("."), profile.NoShutdownHook)
+	//
+	// onExit = func() {
+	// 	profileObj.Stop()
+	// }
 
-		var infos = []key.BindingInfo{
-			{
-				ConfigKeys: []string{"keybinding.quit"},
-				OnAction:   appSingleton.quit,
-				Display:    "Quit",
-			},
-			{
-				ConfigKeys: []string{"keybinding.toggle-view"},
-				OnAction:   controller.ToggleView,
-				Display:    "Switch view",
-			},
-			{
-				ConfigKeys: []string{"keybinding.filter-files"},
-				OnAction:   controller.ToggleFilterView,
-				IsSelected: controller.views.Filter.IsVisible,
-				Display:    "Filter",
-			},
-		}
+	a := &app{
+		gui:         gui,
+		controllers: controller,
+		layout:      lm,
+		manager:     manager,
+		macros:      key.NewMacros(),
+		workspace:   ws,
+	}
 
-		globalHelpKeys, err = key.GenerateBindings(gui, "", infos)
-		if err != nil {
-			return
-		}
+	globalHelpKeys, err := a.show()
+	if err != nil {
+		return nil, err
+	}
+	controller.views.Status.AddHelpKeys(globalHelpKeys...)
 
-		controller.views.Status.AddHelpKeys(globalHelpKeys...)
+	a.macros.AddChangeListener(controller.views.Status.Render)
+	controller.views.Status.SetMacros(a.macros)
 
-		// perform the first update and render now that all resources have been loaded
-		err = controller.UpdateAndRender()
-		if err != nil {
-			return
-		}
+	// perform the first update and render now that all resources have been loaded
+	err = controller.UpdateAndRender()
+	if err != nil {
+		return nil, err
+	}
 
-	})
+	// the layer view takes focus when it is first laid out, so move the focus once the main loop has started
+	focusTree, err := initialView(viper.GetString("ui.initial-view"))
+	if err != nil {
+		return nil, err
+	}
+	if focusTree {
+		gui.Update(func(*gocui.Gui) error {
+			return controller.FocusView(controller.views.Tree.Name())
+		})
+	}
 
-	return appSingleton, err
+	return a, nil
+}
+
+// initialView reads the ui.initial-view value, reporting whether the file tree (rather than the layer view) is focused
+// first.
+func initialView(value string) (focusTree bool, err error) {
+	switch value {
+	case "layer":
+		return false, nil
+	case "filetree":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown ui.initial-view value: %q (expected layer or filetree)", value)
+	}
+}
+
+// show lays out the views of the app in place of those shown (if any) and sets the global key bindings, returning the
+// help of the bindings. gocui drops every view and binding when the layout changes, so the views are set up again (and
+// bind their keys) as they are laid out.
+func (a *app) show() ([]*key.Binding, error) {
+	a.gui.SetManagerFunc(a.manager)
+
+	var infos = []key.BindingInfo{
+		{
+			ConfigKeys: []string{"keybinding.quit"},
+			OnAction:   a.quit,
+			Display:    "Quit",
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-view"},
+			OnAction:   a.controllers.views.Modals.Trap(a.controllers.ToggleView),
+			Display:    "Switch view",
+		},
+		{
+			ConfigKeys: []string{"keybinding.filter-files"},
+			OnAction:   a.controllers.views.Modals.Trap(a.controllers.ToggleFilterView),
+			IsSelected: a.controllers.views.Filter.IsVisible,
+			Display:    "Filter",
+		},
+		{
+			ConfigKeys: []string{"keybinding.saved-filters"},
+			OnAction:   a.controllers.views.Modals.Trap(a.controllers.views.SavedFilters.Show),
+			Display:    "Saved filters",
+		},
+		{
+			ConfigKeys: []string{"keybinding.screenshot"},
+			OnAction:   a.controllers.Screenshot,
+			Display:    "Screenshot",
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-metrics"},
+			OnAction:   a.controllers.views.Modals.Trap(a.controllers.views.Debug.Show),
+		},
+	}
+	if a.workspace != nil {
+		infos = append(infos,
+			key.BindingInfo{
+				ConfigKeys: []string{"keybinding.next-image"},
+				OnAction:   a.controllers.views.Modals.Trap(a.workspace.next),
+				Display:    "Next image",
+			},
+			key.BindingInfo{
+				ConfigKeys: []string{"keybinding.pick-image"},
+				OnAction:   a.controllers.views.Modals.Trap(a.controllers.views.TabPicker.Show),
+				Display:    "Images",
+			},
+			key.BindingInfo{
+				ConfigKeys: []string{"keybinding.compare-images"},
+				OnAction:   a.controllers.views.Modals.Trap(a.workspace.compare),
+				Display:    "Compare images",
+			},
+		)
+	}
+
+	helpKeys, err := key.GenerateBindings(a.gui, "", infos)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.macros.Bind(a.gui); err != nil {
+		return nil, err
+	}
+	return helpKeys, nil
 }
 
 // var profileObj = profile.Start(profile.MemProfile, profile.ProfilePath("."), profile.NoShutdownHook)
@@ -127,20 +239,87 @@ func (a *app) quit() error {
 	return gocui.ErrQuit
 }
 
-// Run is the UI entrypoint.
-func Run(imageName string, analysis *image.AnalysisResult, treeStack filetree.Comparer) error {
+// writeSnapshot writes what the screen shows in the render format, then quits the UI.
+func writeSnapshot(output io.WriteCloser) error {
+	err := terminal.WriteScreenshot(output, terminal.CaptureScreen(), viper.GetString("render.format"))
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write the snapshot: %v", err)
+	}
+	return gocui.ErrQuit
+}
+
+// Run is the UI entrypoint. The owners of the files are shown within the user namespace of the given id mapping. More
+// images can be opened alongside the image, each in a tab of its own; they are loaded in the background once the UI
+// runs. When the UI is rendered headless (see terminal.RenderHeadless), the steps of the script are replayed and the
+// final screen is written out.
+func Run(imageName string, analysis *image.AnalysisResult, treeStack filetree.Comparer, progress *image.ProgressBus, steps script.Script, mapping filetree.IDMapping, tabs ...ImageTab) error {
 	var err error
 
-	g, err := gocui.NewGui(gocui.OutputNormal, true)
+	capabilities, err := format.ResolveCapabilities(
+		format.DetectCapabilities(os.Getenv, goruntime.GOOS),
+		viper.GetString("ui.color"),
+		viper.GetString("ui.glyphs"),
+		viper.GetString("ui.diff-markers"),
+	)
 	if err != nil {
 		return err
 	}
-	defer g.Close()
+	logrus.Debugf("terminal capabilities: colors=%s unicode=%v diff-markers=%v", capabilities.Colors, capabilities.Unicode, capabilities.DiffMarkers)
+	format.ApplyCapabilities(capabilities)
 
-	_, err = newApp(g, imageName, analysis, treeStack)
+	render := filetree.DefaultRenderOptions()
+	render.IDMapping = mapping
+	render.TruncateMode = filetree.TruncateMode(viper.GetString("filetree.truncate"))
+	render = capabilities.RenderOptions(render)
+	symbols := capabilities.Symbols()
+
+	if err := key.ApplyProfile(viper.GetString("keybinding.profile")); err != nil {
+		return err
+	}
+
+	outputMode := gocui.OutputNormal
+	if capabilities.Colors >= format.Color256 {
+		outputMode = gocui.Output256
+	}
+
+	g, err := gocui.NewGui(outputMode, true)
 	if err != nil {
 		return err
 	}
+	defer g.Close()
+
+	var shown func() *app
+	if len(tabs) == 0 {
+		a, err := newApp(g, imageName, analysis, treeStack, render, symbols, nil)
+		if err != nil {
+			return err
+		}
+		defer a.controllers.Close()
+		shown = func() *app { return a }
+		if progress != nil {
+			// background work (e.g. hashing lazy layers) is shown in the status bar
+			defer progress.Subscribe(a.controllers.views.Status.SetProgress)()
+		}
+	} else {
+		ws, err := newWorkspace(g, imageName, analysis, treeStack, render, symbols, tabs)
+		if err != nil {
+			return err
+		}
+		defer ws.close()
+		shown = ws.shown
+		if progress != nil {
+			// background work (e.g. loading the other images) is shown in the status bar of the image shown
+			defer progress.Subscribe(ws.onProgress)()
+		}
+	}
+
+	if snapshot := terminal.SnapshotOutput(); snapshot != nil {
+		runner := &scriptRunner{gui: g, steps: steps, output: snapshot, shown: shown}
+		runner.start()
+	}
 
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
 		logrus.Error("main loop error: ", err)
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestInitialView(t *testing.T) {
+	cases := []struct {
+		value     string
+		focusTree bool
+		valid     bool
+	}{
+		{value: "layer", focusTree: false, valid: true},
+		{value: "filetree", focusTree: true, valid: true},
+		{value: "", valid: false},
+		{value: "sidebar", valid: false},
+		{value: "FileTree", valid: false},
+	}
+	for _, test := range cases {
+		focusTree, err := initialView(test.value)
+		if test.valid != (err == nil) {
+			t.Errorf("%q: expected valid=%v, got error %v", test.value, test.valid, err)
+			continue
+		}
+		if focusTree != test.focusTree {
+			t.Errorf("%q: expected focusTree=%v, got %v", test.value, test.focusTree, focusTree)
+		}
+	}
+}
@@ -1,29 +1,82 @@
 package ui
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
 	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/bookmark"
+	"github.com/wagoodman/dive/runtime/filterhistory"
+	"github.com/wagoodman/dive/runtime/hook"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
 	"github.com/wagoodman/dive/runtime/ui/view"
 	"github.com/wagoodman/dive/runtime/ui/viewmodel"
 	"regexp"
 )
 
 type Controller struct {
-	gui   *gocui.Gui
-	views *view.Views
+	gui       *gocui.Gui
+	views     *view.Views
+	imageName string
+	bookmarks *bookmark.Store
+	// remembers the filters typed, across sessions
+	filterHistory *filterhistory.Store
+	refTrees      []*filetree.FileTree
+	contents      image.ContentReader
+	cache         filetree.Comparer
+	// computes the trees of the selected layers off the UI goroutine (once the UI runs)
+	trees *layerTrees
 }
 
-func NewCollection(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer) (*Controller, error) {
-	views, err := view.NewViews(g, imageName, analysis, cache)
+func NewCollection(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer, render filetree.RenderOptions, symbols format.Symbols, tabs view.TabSource) (*Controller, error) {
+	// the files marked in previous sessions
+	var marked []string
+	store, err := bookmark.NewDefaultStore()
+	if err == nil {
+		marked, err = store.Load(imageName)
+	}
+	if err != nil {
+		logrus.Warnf("unable to load the marked files: %+v", err)
+	}
+
+	views, err := view.NewViews(g, imageName, analysis, cache, viewmodel.NewBookmarks(marked), render, symbols, tabs)
 	if err != nil {
 		return nil, err
 	}
 
+	// the filters typed in previous sessions
+	history, err := filterhistory.NewDefaultStore()
+	if err == nil {
+		var filters []string
+		if filters, err = history.Load(); err == nil {
+			views.Filter.SetHistory(filters)
+		}
+	}
+	if err != nil {
+		logrus.Warnf("unable to load the filter history: %+v", err)
+	}
+
 	controller := &Controller{
-		gui:   g,
-		views: views,
+		gui:       g,
+		views:     views,
+		imageName: imageName,
+		bookmarks: store,
+		refTrees:  analysis.RefTrees,
+		contents:  analysis.Contents,
+		cache:     cache,
+
+		filterHistory: history,
 	}
 
 	// layer view cursor down event should trigger an update in the file tree
@@ -35,6 +88,110 @@ func NewCollection(g *gocui.Gui, imageName string, analysis *image.AnalysisResul
 	// update the tree view while the user types into the filter view
 	controller.views.Filter.AddFilterEditListener(controller.onFilterEdit)
 
+	// remember the filters typed for later sessions
+	controller.views.Filter.AddFilterCommitListener(controller.onFilterCommit)
+
+	// filter by the saved filter picked, and return to the filter pane (or the file tree) afterwards
+	controller.views.SavedFilters.AddPickListener(controller.onSavedFilterPick)
+	controller.views.SavedFilters.AddCloseListener(func() error {
+		if controller.views.Filter.IsVisible() {
+			_, err := controller.gui.SetCurrentView(controller.views.Filter.Name())
+			return err
+		}
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// list and persist the marked files
+	controller.views.Tree.AddMarkChangeListener(controller.onMarkChange)
+
+	// show what the content inspectors found out about the selected file
+	controller.views.Tree.AddSelectionChangeListener(controller.views.FileDetails.SetSelection)
+
+	// show the layers that changed the selected file, and return to the file tree afterwards
+	controller.views.Tree.AddProvenanceListener(controller.views.Provenance.Show)
+	controller.views.Provenance.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// show the full image history, and return to the layer view afterwards
+	controller.views.Layer.AddHistoryListener(controller.views.History.Show)
+	controller.views.History.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Layer.Name())
+	})
+
+	// show the bytes of the image by owner, and return to the layer view afterwards
+	controller.views.Layer.AddOwnershipListener(controller.views.Ownership.Show)
+	controller.views.Ownership.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Layer.Name())
+	})
+
+	// show the timings of the analysis, and return to the view focused before
+	controller.views.Debug.AddCloseListener(func(previous string) error {
+		if previous == "" {
+			previous = controller.views.Layer.Name()
+		}
+		return controller.FocusView(previous)
+	})
+
+	// show the runtime configuration of the image, and return to the layer view afterwards
+	controller.views.Layer.AddConfigListener(controller.views.ImageConfig.Show)
+	controller.views.ImageConfig.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Layer.Name())
+	})
+
+	// preview the selected image file, and return to the file tree afterwards
+	controller.views.Tree.AddPreviewListener(controller.onPreviewFile)
+	controller.views.Preview.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// browse the contents of the selected archive file, and return to the file tree afterwards
+	controller.views.Tree.AddArchiveListener(controller.onBrowseArchive)
+	controller.views.Archive.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// tabulate the filtered files by layer, and return to the file tree afterwards
+	controller.views.Tree.AddPivotListener(controller.views.Pivot.Show)
+	controller.views.Pivot.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// roll the files up by the package that installed them, and return to the file tree afterwards
+	controller.views.Tree.AddPackagesListener(controller.views.Packages.Show)
+	controller.views.Packages.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Tree.Name())
+	})
+
+	// lay out the files of the selected layer next to those of the previous layer, and return to the layer view afterwards
+	controller.views.Layer.AddCompareListener(controller.onCompareLayers)
+	controller.views.Compare.AddCloseListener(func() error {
+		return controller.FocusView(controller.views.Layer.Name())
+	})
+
+	// return to the layer view once the image to switch to is picked
+	if controller.views.TabPicker != nil {
+		controller.views.TabPicker.AddCloseListener(func() error {
+			return controller.FocusView(controller.views.Layer.Name())
+		})
+	}
+
+	// return to where the focus was once a dialog is dismissed
+	controller.views.Dialog.AddCloseListener(controller.onDialogClose)
+
+	// search the focused pane, and return to it once the query is typed
+	controller.views.Layer.AddSearchListener(controller.onSearch)
+	controller.views.Tree.AddSearchListener(controller.onSearch)
+	controller.views.Search.AddSubmitListener(controller.onSearchSubmit)
+	controller.views.Tree.AddGoToPathListener(controller.views.GoToPath.Open)
+	controller.views.GoToPath.AddSubmitListener(controller.onGoToPath)
+
+	// write the slimming manifest of the kept files
+	controller.views.Tree.AddKeepListExportListener(controller.onExportKeepList)
+
+	// show the selected file in the pager (or editor)
+	controller.views.Tree.AddOpenFileListener(controller.onOpenFile)
+
 	// propagate initial conditions to necessary views
 	err = controller.onLayerChange(viewmodel.LayerSelection{
 		Layer:           controller.views.Layer.CurrentLayer(),
@@ -48,6 +205,19 @@ func NewCollection(g *gocui.Gui, imageName string, analysis *image.AnalysisResul
 		return nil, err
 	}
 
+	// from now on, the trees of the selected layers are computed in the background to keep the UI responsive
+	controller.trees = newLayerTrees(g, func(selection viewmodel.LayerSelection) (*filetree.FileTree, error) {
+		return controller.views.Tree.LayerTree(selection.BottomTreeStart, selection.BottomTreeStop, selection.TopTreeStart, selection.TopTreeStop)
+	}, controller.onLayerTree)
+
+	// apply the configured path filter
+	if filter := controller.views.Filter.InitialValue(); filter != "" {
+		err = controller.onFilterEdit(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return controller, nil
 }
 
@@ -59,6 +229,285 @@ func (c *Controller) onFileTreeViewOptionChange() error {
 	return c.views.Status.Render()
 }
 
+func (c *Controller) onMarkChange(paths []string) error {
+	if c.bookmarks != nil {
+		if err := c.bookmarks.Save(c.imageName, paths); err != nil {
+			logrus.Warnf("unable to save the marked files: %+v", err)
+			if err := c.views.Toast.Show("Unable to save the marked files"); err != nil {
+				return err
+			}
+		}
+	}
+	return c.views.Marks.Render()
+}
+
+// onPreviewFile shows the selected image file (as seen from the selected layer) in the preview popup.
+func (c *Controller) onPreviewFile(filePath string) error {
+	layer := c.views.Layer.CurrentLayer().Index
+	return c.views.Preview.Show(filePath, func() (io.ReadCloser, error) {
+		return image.OpenFile(c.contents, c.refTrees, layer, filePath)
+	})
+}
+
+// onBrowseArchive lists the contents of the selected archive file (as seen from the selected layer) in the archive
+// popup. Other files are left alone.
+func (c *Controller) onBrowseArchive(filePath string) error {
+	if !image.IsNestedArchive(filePath) {
+		logrus.Infof("%s is not a tar or zip archive", filePath)
+		return c.views.Toast.Show(fmt.Sprintf("%s is not a tar or zip archive", path.Base(filePath)))
+	}
+	layer := c.views.Layer.CurrentLayer().Index
+	return c.views.Archive.Show(filePath, func() (io.ReadCloser, error) {
+		return image.OpenFile(c.contents, c.refTrees, layer, filePath)
+	})
+}
+
+// onOpenFile extracts the selected file (as seen from the selected layer) to a temporary file and opens it in the pager
+// (or editor) with the UI suspended. Files that cannot be opened are reported in a dialog rather than ending the session.
+func (c *Controller) onOpenFile(filePath string, edit bool) error {
+	layer := c.views.Layer.CurrentLayer().Index
+	reader, err := image.OpenFile(c.contents, c.refTrees, layer, filePath)
+	if err != nil {
+		logrus.Warnf("unable to open %s: %+v", filePath, err)
+		return c.views.Dialog.ShowError("Unable to open "+path.Base(filePath), err)
+	}
+	defer reader.Close()
+
+	dir, err := ioutil.TempDir("", "dive.*")
+	if err != nil {
+		return err
+	}
+	// the extracted file is removed once the hooks given it are done with it as well
+	var hooksDone <-chan struct{}
+	defer func() {
+		if hooksDone == nil {
+			os.RemoveAll(dir)
+			return
+		}
+		go func() {
+			<-hooksDone
+			os.RemoveAll(dir)
+		}()
+	}()
+
+	// keep the file name, so that the pager (or editor) can tell the file type
+	extracted := filepath.Join(dir, path.Base(filePath))
+	file, err := os.OpenFile(extracted, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0400)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logrus.Warnf("unable to extract %s: %+v", filePath, err)
+		return c.views.Dialog.ShowError("Unable to extract "+path.Base(filePath), err)
+	}
+	hooksDone = hook.Fire(hook.FileExtracted, c.imageName, hook.FilePayload{Path: filePath, Layer: layer, ExtractedPath: extracted})
+
+	command := terminal.PagerCommand(os.Getenv)
+	if edit {
+		command = terminal.EditorCommand(os.Getenv)
+	}
+	if err := terminal.RunSuspended(c.gui, command, extracted); err != nil {
+		logrus.Warnf("unable to open %s with %s: %+v", filePath, command[0], err)
+		return c.views.Dialog.ShowError("Unable to open "+path.Base(filePath)+" with "+command[0], err)
+	}
+	return nil
+}
+
+// treeSource returns a tree to lay out side by side (nil for a side without any file).
+type treeSource func() (*filetree.FileTree, error)
+
+// flattenedTree returns the filesystem of an image as of the given layer (the layers up to it stacked).
+func flattenedTree(cache filetree.Comparer, layer int) treeSource {
+	return func() (*filetree.FileTree, error) {
+		return cache.GetTree(filetree.NewTreeIndexKey(0, layer, layer+1, layer))
+	}
+}
+
+// onCompareLayers lays out the filesystem as of the given layer next to the filesystem as of the previous layer (an
+// empty one for the first layer).
+func (c *Controller) onCompareLayers(layer int) error {
+	leftTitle := "No layer"
+	left := func() (*filetree.FileTree, error) { return nil, nil }
+	if layer > 0 {
+		leftTitle = fmt.Sprintf("Layer %d", layer-1)
+		left = flattenedTree(c.cache, layer-1)
+	}
+	return c.CompareSideBySide(leftTitle, left, fmt.Sprintf("Layer %d", layer), flattenedTree(c.cache, layer))
+}
+
+// CompareSideBySide opens the side by side popup, which shows the given trees once they have been stacked and aligned
+// in the background.
+func (c *Controller) CompareSideBySide(leftTitle string, left treeSource, rightTitle string, right treeSource) error {
+	ticket, err := c.views.Compare.Show(leftTitle, rightTitle)
+	if err != nil {
+		return err
+	}
+
+	status := c.views.StatusBus
+	go func() {
+		status.StartTask("side-by-side", fmt.Sprintf("comparing %s with %s", leftTitle, rightTitle))
+		defer status.FinishTask("side-by-side")
+
+		var rows []filetree.SideBySideRow
+		leftTree, err := left()
+		var rightTree *filetree.FileTree
+		if err == nil {
+			rightTree, err = right()
+		}
+		if err == nil {
+			rows = filetree.SideBySide(leftTree, rightTree)
+		}
+
+		c.gui.Update(func(*gocui.Gui) error {
+			if !c.views.Compare.Waiting(ticket) {
+				return nil
+			}
+			if err != nil {
+				logrus.Warnf("unable to compare %s with %s: %+v", leftTitle, rightTitle, err)
+				if err := c.views.Compare.Close(); err != nil {
+					return err
+				}
+				return c.views.Dialog.ShowError("Unable to compare "+leftTitle+" with "+rightTitle, err)
+			}
+			return c.views.Compare.SetRows(ticket, rows)
+		})
+	}()
+	return nil
+}
+
+// onSearch opens the search row to type a query for the given pane, or moves to the next (or previous) match of the
+// last query.
+func (c *Controller) onSearch(pane view.Searchable, prompt, forward bool) error {
+	if prompt {
+		return c.views.Search.Open(pane)
+	}
+	return c.search(pane, c.views.Search.Query(), forward)
+}
+
+// onSearchSubmit moves to the first match of the typed query (if any), and gives the focus back to the searched pane.
+func (c *Controller) onSearchSubmit(pane view.Searchable, query string) error {
+	if err := c.FocusView(pane.Name()); err != nil {
+		return err
+	}
+	return c.search(pane, query, true)
+}
+
+// search moves the cursor of the pane to the next (or previous) match, telling in the status bar when nothing matches.
+func (c *Controller) search(pane view.Searchable, query string, forward bool) error {
+	if query == "" {
+		return nil
+	}
+	found, err := pane.Search(query, forward)
+	if err != nil {
+		return err
+	}
+	if !found {
+		c.views.Status.SetMessage("Pattern not found: " + query)
+		return c.views.Status.Render()
+	}
+	return nil
+}
+
+// onGoToPath moves the file tree cursor to the typed path (if any), and gives the focus back to the file tree.
+func (c *Controller) onGoToPath(path string) error {
+	if err := c.FocusView(c.views.Tree.Name()); err != nil {
+		return err
+	}
+	if path == "" {
+		return nil
+	}
+	found, err := c.views.Tree.GoToPath(path)
+	if err != nil {
+		return err
+	}
+	if !found {
+		c.views.Status.SetMessage("No such path: " + path)
+		return c.views.Status.Render()
+	}
+	return nil
+}
+
+// onExportKeepList writes the slimming manifest of the kept paths (and the Dockerfile snippet copying them, when
+// configured), splitting the filesystem of the image into the paths kept and the paths excluded.
+func (c *Controller) onExportKeepList(paths []string) error {
+	if len(paths) == 0 {
+		c.views.Status.SetMessage(fmt.Sprintf("Press %s to keep the selected file first", viper.GetString("keybinding.toggle-keep")))
+		return c.views.Status.Render()
+	}
+	last := len(c.refTrees) - 1
+	tree, err := flattenedTree(c.cache, last)()
+	if err != nil {
+		return err
+	}
+	plan := image.PlanSlim(tree, paths)
+
+	manifestPath := viper.GetString("slim.manifest-file")
+	err = writeFile(manifestPath, func(writer io.Writer) error {
+		return plan.WriteManifest(writer, c.imageName)
+	})
+	if dockerfilePath := viper.GetString("slim.dockerfile"); err == nil && dockerfilePath != "" {
+		err = writeFile(dockerfilePath, func(writer io.Writer) error {
+			return plan.WriteDockerfile(writer, c.imageName)
+		})
+	}
+	if err != nil {
+		logrus.Warnf("unable to export the keep list: %+v", err)
+		return c.views.Toast.Show(fmt.Sprintf("Unable to export the keep list: %v", err))
+	}
+
+	message := fmt.Sprintf("Kept %d paths (%s), %d excluded (%s): see %s", len(plan.Keep), humanize.Bytes(plan.KeptBytes), len(plan.Exclude), humanize.Bytes(plan.ExcludedBytes), manifestPath)
+	if len(plan.DanglingLinks) > 0 {
+		message += fmt.Sprintf(" (%d kept links point to files not kept)", len(plan.DanglingLinks))
+	}
+	c.views.Status.SetMessage(message)
+	return c.views.Status.Render()
+}
+
+// writeFile creates (or truncates) the file at the given path and writes it with the given function.
+func writeFile(filePath string, write func(writer io.Writer) error) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// onDialogClose gives the focus back to the pane (or popup) that had it when the dialog opened.
+func (c *Controller) onDialogClose(returnTo string) error {
+	switch returnTo {
+	case c.views.Tree.Name(), c.views.Layer.Name():
+		return c.FocusView(returnTo)
+	case "":
+		return c.FocusView(c.views.Layer.Name())
+	}
+	_, err := c.gui.SetCurrentView(returnTo)
+	return err
+}
+
+// onFilterCommit remembers the given filter as the most recent one of the filter history.
+func (c *Controller) onFilterCommit(filter string) error {
+	if c.filterHistory == nil {
+		return nil
+	}
+	if _, err := c.filterHistory.Add(filter); err != nil {
+		logrus.Warnf("unable to save the filter history: %+v", err)
+	}
+	return nil
+}
+
+// onSavedFilterPick filters the file tree by the picked saved filter.
+func (c *Controller) onSavedFilterPick(filter view.SavedFilter) error {
+	return c.SetFilter(filter.Pattern)
+}
+
 func (c *Controller) onFilterEdit(filter string) error {
 	var filterRegex *regexp.Regexp
 	var err error
@@ -81,18 +530,42 @@ func (c *Controller) onFilterEdit(filter string) error {
 }
 
 func (c *Controller) onLayerChange(selection viewmodel.LayerSelection) error {
+	hook.Fire(hook.LayerSelected, c.imageName, hook.LayerPayload{
+		Index:     selection.Layer.Index,
+		Digest:    selection.Layer.Digest,
+		Command:   selection.Layer.Command,
+		SizeBytes: selection.Layer.Size,
+	})
+
 	// update the details
 	c.views.Details.SetCurrentLayer(selection.Layer)
 
-	// update the filetree
-	err := c.views.Tree.SetTree(selection.BottomTreeStart, selection.BottomTreeStop, selection.TopTreeStart, selection.TopTreeStop)
-	if err != nil {
-		return err
+	// update the filetree: the first tree is shown right away, the following ones once they have been computed
+	c.views.Tree.SetDoomed(selection.Doomed)
+	c.views.Tree.SetLayerSize(int64(selection.Layer.Size))
+	c.views.Tree.SetMtimeLayer(selection.TopTreeStop)
+	if c.trees == nil {
+		err := c.views.Tree.SetTree(selection.BottomTreeStart, selection.BottomTreeStop, selection.TopTreeStart, selection.TopTreeStop)
+		if err != nil {
+			return err
+		}
+		c.applyLoadedLayers()
+	} else {
+		c.views.Tree.SetLoading(true)
+		c.trees.Request(selection)
 	}
 
-	if c.views.Layer.CompareMode() == viewmodel.CompareAllLayers {
+	switch c.views.Layer.CompareMode() {
+	case viewmodel.CompareAllLayers:
 		c.views.Tree.SetTitle("Aggregated Layer Contents")
-	} else {
+	case viewmodel.CompareFlattened:
+		c.views.Tree.SetTitle("Flattened Layer Contents")
+	case viewmodel.CompareSinceBase:
+		c.views.Tree.SetTitle(fmt.Sprintf("Changes Since Layer %d", c.views.Layer.BaseLayerIndex()))
+	case viewmodel.CompareRange:
+		first, last := c.views.Layer.LayerRange()
+		c.views.Tree.SetTitle(fmt.Sprintf("Aggregated Contents of Layers %d to %d", first, last))
+	default:
 		c.views.Tree.SetTitle("Current Layer Contents")
 	}
 
@@ -100,6 +573,31 @@ func (c *Controller) onLayerChange(selection viewmodel.LayerSelection) error {
 	return c.UpdateAndRender()
 }
 
+// onLayerTree shows the tree of the selected layers once it has been computed.
+func (c *Controller) onLayerTree(tree *filetree.FileTree) error {
+	c.applyLoadedLayers()
+	if err := c.views.Tree.ShowTree(tree); err != nil {
+		return err
+	}
+	return c.UpdateAndRender()
+}
+
+// applyLoadedLayers updates the layers of a lazy image that were loaded to compute the trees with what their contents
+// tell (e.g. their size). The trees are computed on another goroutine, so the layers are only updated here, on the UI
+// goroutine that shows them.
+func (c *Controller) applyLoadedLayers() {
+	if c.cache.ApplyLoaded() {
+		c.views.Tree.SetLayerSize(int64(c.views.Layer.CurrentLayer().Size))
+	}
+}
+
+// Close stops the background work of the controller.
+func (c *Controller) Close() {
+	if c.trees != nil {
+		c.trees.Close()
+	}
+}
+
 func (c *Controller) UpdateAndRender() error {
 	err := c.Update()
 	if err != nil {
@@ -141,15 +639,54 @@ func (c *Controller) Render() error {
 	return nil
 }
 
+// Screenshot saves what the screen shows to a new file in the screenshot directory, in the configured format (ANSI
+// text, SVG or PNG), and tells where it was saved in the status pane.
+func (c *Controller) Screenshot() error {
+	screen := terminal.CaptureScreen()
+	screenshotFormat := viper.GetString("screenshot.format")
+	name := filepath.Join(viper.GetString("screenshot.dir"), fmt.Sprintf("dive-%s.%s", time.Now().Format("20060102-150405"), terminal.ScreenshotExtension(screenshotFormat)))
+
+	file, err := os.Create(name)
+	if err == nil {
+		err = terminal.WriteScreenshot(file, screen, screenshotFormat)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		logrus.Warnf("unable to save the screenshot: %+v", err)
+		c.views.Status.SetMessage(fmt.Sprintf("Unable to save the screenshot: %v", err))
+	} else {
+		c.views.Status.SetMessage("Saved " + name)
+	}
+	return c.views.Status.Render()
+}
+
 // ToggleView switches between the file view and the layer view and re-renders the screen.
 func (c *Controller) ToggleView() (err error) {
 	v := c.gui.CurrentView()
 	if v == nil || v.Name() == c.views.Layer.Name() {
+		return c.FocusView(c.views.Tree.Name())
+	}
+	return c.FocusView(c.views.Layer.Name())
+}
+
+// FocusView moves the focus to the layer or file view (given by name) and re-renders the screen.
+func (c *Controller) FocusView(name string) (err error) {
+	// the popups are closed when the focus moves away from them
+	if err = c.views.Modals.CloseAll(); err != nil {
+		return err
+	}
+
+	switch name {
+	case c.views.Tree.Name():
 		_, err = c.gui.SetCurrentView(c.views.Tree.Name())
 		c.views.Status.SetCurrentView(c.views.Tree)
-	} else {
+	case c.views.Layer.Name():
 		_, err = c.gui.SetCurrentView(c.views.Layer.Name())
 		c.views.Status.SetCurrentView(c.views.Layer)
+	default:
+		err = fmt.Errorf("unknown view: %q", name)
 	}
 
 	if err != nil {
@@ -183,3 +720,29 @@ func (c *Controller) ToggleFilterView() error {
 
 	return c.UpdateAndRender()
 }
+
+// SetFilter filters the file tree by the given regular expression as if it was typed into the filter pane (an empty
+// filter hides the pane and gives the focus back to the file tree).
+func (c *Controller) SetFilter(filter string) error {
+	if err := c.views.Filter.SetValue(filter); err != nil {
+		return err
+	}
+	if filter == "" {
+		c.views.Tree.SetFilterRegex(nil)
+		return c.FocusView(c.views.Tree.Name())
+	}
+	return c.UpdateAndRender()
+}
+
+// SelectLayer selects the layer of the given index (0 is the first).
+func (c *Controller) SelectLayer(index int) error {
+	if index < 0 || index >= c.views.Layer.LayerCount() {
+		return fmt.Errorf("there is no layer %d (the image has %d layers)", index, c.views.Layer.LayerCount())
+	}
+	return c.views.Layer.JumpTo(index)
+}
+
+// Busy reports whether the tree of the selected layers is still being computed.
+func (c *Controller) Busy() bool {
+	return c.trees != nil && c.trees.Busy()
+}
@@ -0,0 +1,195 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const (
+	ColorNone ColorDepth = iota
+	Color8
+	Color256
+	ColorTrue
+)
+
+// ColorDepth is the number of colors a terminal can display.
+type ColorDepth int
+
+func (depth ColorDepth) String() string {
+	switch depth {
+	case ColorNone:
+		return "none"
+	case Color8:
+		return "8"
+	case Color256:
+		return "256"
+	case ColorTrue:
+		return "truecolor"
+	default:
+		return fmt.Sprintf("%d", int(depth))
+	}
+}
+
+// Capabilities describe what the terminal is able to render.
+type Capabilities struct {
+	Colors  ColorDepth
+	Unicode bool
+	// mark the added, removed and modified files with symbols, not only with colors
+	DiffMarkers bool
+}
+
+// DetectCapabilities guesses the terminal capabilities from the environment (TERM, COLORTERM, NO_COLOR and the locale).
+func DetectCapabilities(getenv func(string) string, goos string) Capabilities {
+	term := strings.ToLower(getenv("TERM"))
+	colorTerm := strings.ToLower(getenv("COLORTERM"))
+
+	var capabilities Capabilities
+	switch {
+	case getenv("NO_COLOR") != "" || term == "dumb":
+		capabilities.Colors = ColorNone
+	case colorTerm == "truecolor" || colorTerm == "24bit" || strings.Contains(term, "direct"):
+		capabilities.Colors = ColorTrue
+	case strings.Contains(term, "256color"):
+		capabilities.Colors = Color256
+	case goos == "windows" && getenv("WT_SESSION") != "":
+		// windows terminal supports truecolor, but does not advertise it
+		capabilities.Colors = ColorTrue
+	default:
+		capabilities.Colors = Color8
+	}
+	capabilities.DiffMarkers = capabilities.Colors == ColorNone
+
+	// the first of these that is set determines the character encoding (as with setlocale)
+	locale := getenv("LC_ALL")
+	if locale == "" {
+		locale = getenv("LC_CTYPE")
+	}
+	if locale == "" {
+		locale = getenv("LANG")
+	}
+	locale = strings.ToLower(locale)
+
+	switch {
+	case term == "linux" || term == "dumb":
+		// the linux console fonts lack most of the glyphs
+		capabilities.Unicode = false
+	case goos == "windows":
+		capabilities.Unicode = getenv("WT_SESSION") != "" || strings.Contains(term, "xterm")
+	case strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8"):
+		capabilities.Unicode = true
+	case locale == "" && goos == "darwin":
+		// macOS terminals default to UTF-8 even without a locale
+		capabilities.Unicode = true
+	default:
+		capabilities.Unicode = false
+	}
+
+	return capabilities
+}
+
+// ResolveCapabilities applies the user overrides ("auto" keeps the detected value) for colors ("none", "8", "256",
+// "truecolor"), glyphs ("unicode", "ascii") and diff markers ("always", "never"; "auto" marks the files when there are
+// no colors).
+func ResolveCapabilities(detected Capabilities, colors, glyphs, markers string) (Capabilities, error) {
+	resolved := detected
+
+	switch strings.ToLower(strings.TrimSpace(colors)) {
+	case "", "auto":
+	case "none":
+		resolved.Colors = ColorNone
+	case "8":
+		resolved.Colors = Color8
+	case "256":
+		resolved.Colors = Color256
+	case "truecolor", "24bit":
+		resolved.Colors = ColorTrue
+	default:
+		return detected, fmt.Errorf("unknown color mode %q (expected auto, none, 8, 256 or truecolor)", colors)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(glyphs)) {
+	case "", "auto":
+	case "unicode":
+		resolved.Unicode = true
+	case "ascii":
+		resolved.Unicode = false
+	default:
+		return detected, fmt.Errorf("unknown glyph set %q (expected auto, unicode or ascii)", glyphs)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(markers)) {
+	case "", "auto":
+		resolved.DiffMarkers = resolved.Colors == ColorNone
+	case "always":
+		resolved.DiffMarkers = true
+	case "never":
+		resolved.DiffMarkers = false
+	default:
+		return detected, fmt.Errorf("unknown diff marker mode %q (expected auto, always or never)", markers)
+	}
+
+	return resolved, nil
+}
+
+// Symbols returns the characters the panes are drawn with on a terminal with the capabilities.
+func (capabilities Capabilities) Symbols() Symbols {
+	if capabilities.Unicode {
+		return UnicodeSymbols
+	}
+	return ASCIISymbols
+}
+
+// RenderOptions returns the options the file trees are drawn with on a terminal with the capabilities, starting from
+// the given options.
+func (capabilities Capabilities) RenderOptions(options filetree.RenderOptions) filetree.RenderOptions {
+	options.Glyphs = filetree.ASCIIGlyphs
+	if capabilities.Unicode {
+		options.Glyphs = filetree.UnicodeGlyphs
+	}
+	options.DiffMarkers = capabilities.DiffMarkers
+
+	switch capabilities.Colors {
+	case ColorNone:
+		options.DiffColors = map[filetree.DiffType]*color.Color{
+			filetree.Added:      color.New(color.Bold),
+			filetree.Removed:    color.New(color.CrossedOut),
+			filetree.Modified:   color.New(color.Italic),
+			filetree.Unmodified: color.New(color.Reset),
+		}
+	case Color256, ColorTrue:
+		options.DiffColors = map[filetree.DiffType]*color.Color{
+			filetree.Added:      color.New(color.Attribute(38), 5, 41),
+			filetree.Removed:    color.New(color.Attribute(38), 5, 203),
+			filetree.Modified:   color.New(color.Attribute(38), 5, 221),
+			filetree.Unmodified: color.New(color.Reset),
+		}
+	}
+	return options
+}
+
+// ApplyCapabilities selects the color theme of the panes.
+func ApplyCapabilities(capabilities Capabilities) {
+	switch capabilities.Colors {
+	case ColorNone:
+		// keep the text attributes (bold, reverse) so the cursor and headers remain distinguishable
+		StatusSelected = color.New(color.ReverseVideo, color.Underline).SprintFunc()
+		StatusControlSelected = color.New(color.ReverseVideo, color.Underline, color.Bold).SprintFunc()
+		CompareTop = color.New(color.ReverseVideo).SprintFunc()
+		CompareBottom = color.New(color.Underline).SprintFunc()
+		Marked = color.New(color.Bold).SprintFunc()
+		Kept = color.New(color.Underline).SprintFunc()
+		Vulnerable = color.New(color.Bold, color.Underline).SprintFunc()
+		VulnerableLow = color.New(color.Underline).SprintFunc()
+		Corrupt = color.New(color.Bold, color.Underline).SprintFunc()
+	case Color256, ColorTrue:
+		// softer shades from the 256 color palette (gocui renders truecolor terminals in 256 color mode)
+		StatusSelected = color.New(color.Attribute(48), 5, 97, color.FgWhite).SprintFunc()
+		StatusControlSelected = color.New(color.Attribute(48), 5, 97, color.FgWhite, color.Bold).SprintFunc()
+		CompareTop = color.New(color.Attribute(48), 5, 97).SprintFunc()
+		CompareBottom = color.New(color.Attribute(48), 5, 29).SprintFunc()
+		Empty = color.New(color.Attribute(38), 5, 245).SprintFunc()
+	}
+}
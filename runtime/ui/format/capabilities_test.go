@@ -0,0 +1,93 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	cases := []struct {
+		name     string
+		env      map[string]string
+		goos     string
+		expected Capabilities
+	}{
+		{"truecolor", map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor", "LANG": "en_US.UTF-8"}, "linux", Capabilities{ColorTrue, true, false}},
+		{"256color", map[string]string{"TERM": "screen-256color", "LC_ALL": "C.utf8"}, "linux", Capabilities{Color256, true, false}},
+		{"basic", map[string]string{"TERM": "xterm", "LANG": "C"}, "linux", Capabilities{Color8, false, false}},
+		{"no-color", map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1", "LANG": "en_US.UTF-8"}, "linux", Capabilities{ColorNone, true, true}},
+		{"linux-console", map[string]string{"TERM": "linux", "LANG": "en_US.UTF-8"}, "linux", Capabilities{Color8, false, false}},
+		{"lc-all-precedence", map[string]string{"TERM": "xterm", "LC_ALL": "C", "LANG": "en_US.UTF-8"}, "linux", Capabilities{Color8, false, false}},
+		{"darwin-no-locale", map[string]string{"TERM": "xterm-256color"}, "darwin", Capabilities{Color256, true, false}},
+		{"windows-terminal", map[string]string{"WT_SESSION": "abc"}, "windows", Capabilities{ColorTrue, true, false}},
+	}
+
+	for _, test := range cases {
+		getenv := func(key string) string { return test.env[key] }
+		actual := DetectCapabilities(getenv, test.goos)
+		if actual != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestResolveCapabilities(t *testing.T) {
+	detected := Capabilities{Colors: Color8, Unicode: true}
+
+	actual, err := ResolveCapabilities(detected, "auto", "auto", "auto")
+	if err != nil || actual != detected {
+		t.Errorf("expected auto to keep %+v, got %+v (err: %v)", detected, actual, err)
+	}
+
+	actual, err = ResolveCapabilities(detected, "256", "ascii", "auto")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual.Colors != Color256 || actual.Unicode {
+		t.Errorf("expected overrides to apply, got %+v", actual)
+	}
+
+	if _, err := ResolveCapabilities(detected, "16", "auto", "auto"); err == nil {
+		t.Errorf("expected an error for an invalid color mode")
+	}
+	if _, err := ResolveCapabilities(detected, "auto", "emoji", "auto"); err == nil {
+		t.Errorf("expected an error for an invalid glyph set")
+	}
+	if _, err := ResolveCapabilities(detected, "auto", "auto", "sometimes"); err == nil {
+		t.Errorf("expected an error for an invalid diff marker mode")
+	}
+
+	actual, err = ResolveCapabilities(detected, "none", "auto", "auto")
+	if err != nil || !actual.DiffMarkers {
+		t.Errorf("expected the diff markers without colors, got %+v (err: %v)", actual, err)
+	}
+	actual, err = ResolveCapabilities(actual, "auto", "auto", "never")
+	if err != nil || actual.DiffMarkers {
+		t.Errorf("expected the diff markers to be turned off, got %+v (err: %v)", actual, err)
+	}
+	actual, err = ResolveCapabilities(detected, "auto", "auto", "always")
+	if err != nil || !actual.DiffMarkers || actual.Colors != Color8 {
+		t.Errorf("expected the diff markers alongside the colors, got %+v (err: %v)", actual, err)
+	}
+}
+
+func TestCapabilitiesRenderOptions(t *testing.T) {
+	ascii := Capabilities{Colors: ColorNone, Unicode: false, DiffMarkers: true}
+	options := ascii.RenderOptions(filetree.DefaultRenderOptions())
+	if options.Glyphs != filetree.ASCIIGlyphs || !options.DiffMarkers || options.DiffColors == nil {
+		t.Errorf("expected the ascii glyphs, diff markers and colorless theme, got %+v", options)
+	}
+	if ascii.Symbols().StatusSeparator != ASCIISymbols.StatusSeparator {
+		t.Errorf("expected the ascii symbols, got %+v", ascii.Symbols())
+	}
+
+	unicode := Capabilities{Colors: Color8, Unicode: true}
+	options = unicode.RenderOptions(filetree.DefaultRenderOptions())
+	if options.Glyphs != filetree.UnicodeGlyphs || options.DiffMarkers || options.DiffColors != nil {
+		t.Errorf("expected the unicode glyphs and default colors, got %+v", options)
+	}
+	if unicode.Symbols().StatusSeparator != UnicodeSymbols.StatusSeparator {
+		t.Errorf("expected the unicode symbols, got %+v", unicode.Symbols())
+	}
+}
@@ -45,6 +45,15 @@ var (
 	StatusControlNormal   func(...interface{}) string
 	CompareTop            func(...interface{}) string
 	CompareBottom         func(...interface{}) string
+	Marked                func(...interface{}) string
+	Kept                  func(...interface{}) string
+	Doomed                func(...interface{}) string
+	// Vulnerable highlights high and critical vulnerabilities, VulnerableLow the others
+	Vulnerable    func(...interface{}) string
+	VulnerableLow func(...interface{}) string
+	Corrupt       func(...interface{}) string
+	// Empty dims the instructions of the image history that made no filesystem changes in the layers pane
+	Empty func(...interface{}) string
 )
 
 func init() {
@@ -56,24 +65,31 @@ func init() {
 	StatusControlNormal = color.New(color.ReverseVideo, color.Bold).SprintFunc()
 	CompareTop = color.New(color.BgMagenta).SprintFunc()
 	CompareBottom = color.New(color.BgGreen).SprintFunc()
+	Marked = color.New(color.FgYellow, color.Bold).SprintFunc()
+	Kept = color.New(color.FgGreen, color.Bold).SprintFunc()
+	Doomed = color.New(color.FgMagenta).SprintFunc()
+	Vulnerable = color.New(color.FgRed).SprintFunc()
+	VulnerableLow = color.New(color.FgYellow).SprintFunc()
+	Corrupt = color.New(color.FgRed, color.Bold).SprintFunc()
+	Empty = color.New(color.FgBlue).SprintFunc()
 }
 
-func RenderNoHeader(width int, selected bool) string {
+func (symbols Symbols) RenderNoHeader(width int, selected bool) string {
 	if selected {
-		return strings.Repeat(selectedFillStr, width)
+		return strings.Repeat(symbols.selectedFill, width)
 	}
-	return strings.Repeat(fillStr, width)
+	return strings.Repeat(symbols.fill, width)
 }
 
-func RenderHeader(title string, width int, selected bool) string {
+func (symbols Symbols) RenderHeader(title string, width int, selected bool) string {
 	if selected {
-		body := Header(fmt.Sprintf("%s%s ", selectStr, title))
+		body := Header(fmt.Sprintf("%s%s ", symbols.selectMarker, title))
 		bodyLen := len(vtclean.Clean(body, false))
 		repeatCount := width - bodyLen - 2
 		if repeatCount < 0 {
 			repeatCount = 0
 		}
-		return fmt.Sprintf("%s%s%s%s\n", selectedLeftBracketStr, body, selectedRightBracketStr, strings.Repeat(selectedFillStr, repeatCount))
+		return fmt.Sprintf("%s%s%s%s\n", symbols.selectedLeftBracket, body, symbols.selectedRightBracket, strings.Repeat(symbols.selectedFill, repeatCount))
 		//return fmt.Sprintf("%s%s%s%s\n", Selected(selectedLeftBracketStr), body, Selected(selectedRightBracketStr), Selected(strings.Repeat(selectedFillStr, width-bodyLen-2)))
 		//return fmt.Sprintf("%s%s%s%s\n", Selected(selectedLeftBracketStr), body, Selected(selectedRightBracketStr), strings.Repeat(selectedFillStr, width-bodyLen-2))
 	}
@@ -83,13 +99,13 @@ func RenderHeader(title string, width int, selected bool) string {
 	if repeatCount < 0 {
 		repeatCount = 0
 	}
-	return fmt.Sprintf("%s%s%s%s\n", leftBracketStr, body, rightBracketStr, strings.Repeat(fillStr, repeatCount))
+	return fmt.Sprintf("%s%s%s%s\n", symbols.leftBracket, body, symbols.rightBracket, strings.Repeat(symbols.fill, repeatCount))
 }
 
-func RenderHelpKey(control, title string, selected bool) string {
+func (symbols Symbols) RenderHelpKey(control, title string, selected bool) string {
 	if selected {
-		return StatusSelected("▏") + StatusControlSelected(control) + StatusSelected(" "+title+" ")
+		return StatusSelected(symbols.StatusSeparator) + StatusControlSelected(control) + StatusSelected(" "+title+" ")
 	} else {
-		return StatusNormal("▏") + StatusControlNormal(control) + StatusNormal(" "+title+" ")
+		return StatusNormal(symbols.StatusSeparator) + StatusControlNormal(control) + StatusNormal(" "+title+" ")
 	}
 }
@@ -0,0 +1,74 @@
+package format
+
+// Symbols are the characters the panes are drawn with: the headers, the key help in the status bar and the markers of
+// the files and layers. They are picked for the terminal the UI runs on (see Capabilities.Symbols) and given to the
+// views that draw them.
+type Symbols struct {
+	selectedLeftBracket  string
+	selectedRightBracket string
+	selectedFill         string
+	leftBracket          string
+	rightBracket         string
+	fill                 string
+	selectMarker         string
+
+	// StatusSeparator marks the start of each key help entry in the status bar
+	StatusSeparator string
+	// Mark follows the files marked by the user in the file tree
+	Mark string
+	// Keep follows the files the user chose to keep when slimming the image
+	Keep string
+	// Doomed follows the files that a later layer deletes or overwrites in the file tree
+	Doomed string
+	// Vulnerable follows the files of the packages a vulnerability scanner reported in the file tree
+	Vulnerable string
+	// Corrupt follows the layers whose blobs failed verification (truncated, or not matching their digests)
+	Corrupt string
+	// HistoryLayer and HistoryEmpty mark the instructions of the image history that produced a layer and those that
+	// made no filesystem changes
+	HistoryLayer string
+	HistoryEmpty string
+	// Spinner animates the background tasks in the status bar
+	Spinner []string
+}
+
+var (
+	// UnicodeSymbols draws the panes with box-drawing characters (the default).
+	UnicodeSymbols = Symbols{
+		selectedLeftBracket:  selectedLeftBracketStr,
+		selectedRightBracket: selectedRightBracketStr,
+		selectedFill:         selectedFillStr,
+		leftBracket:          leftBracketStr,
+		rightBracket:         rightBracketStr,
+		fill:                 fillStr,
+		selectMarker:         selectStr,
+		StatusSeparator:      "▏",
+		Mark:                 "★",
+		Keep:                 "✔",
+		Doomed:               "✗",
+		Vulnerable:           "▲",
+		Corrupt:              "‼",
+		HistoryLayer:         "●",
+		HistoryEmpty:         "○",
+		Spinner:              []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	}
+	// ASCIISymbols draws the panes for terminals (or fonts) without unicode support.
+	ASCIISymbols = Symbols{
+		selectedLeftBracket:  "#",
+		selectedRightBracket: "#",
+		selectedFill:         "=",
+		leftBracket:          "|",
+		rightBracket:         "|",
+		fill:                 "-",
+		selectMarker:         " * ",
+		StatusSeparator:      "|",
+		Mark:                 "*",
+		Keep:                 "+",
+		Doomed:               "x",
+		Vulnerable:           "!",
+		Corrupt:              "!!",
+		HistoryLayer:         "*",
+		HistoryEmpty:         "o",
+		Spinner:              []string{"|", "/", "-", "\\"},
+	}
+)
@@ -0,0 +1,54 @@
+package key
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the actions of the keybindings set up last, by the name of their keybinding without the "keybinding." prefix (e.g.
+// "compare-all"), so that they can be invoked without their key being pressed (e.g. by a script). The views bind their
+// keys again whenever they are laid out, thus the actions are those of the views shown (there is only one UI).
+var actions = make(map[string]BindingInfo)
+
+// registerAction records the action of the keybinding, including the keybindings left unbound (e.g. the movements of
+// the vim profile).
+func registerAction(info BindingInfo) {
+	if len(info.ConfigKeys) == 0 || (info.OnAction == nil && info.Counted == nil) {
+		return
+	}
+	actions[strings.TrimPrefix(info.ConfigKeys[0], "keybinding.")] = info
+}
+
+// Invoke runs the action of the keybinding of the given name, as if its key was pressed after typing the count: the
+// count is passed on to the actions that take one, repeats the repeatable ones, and is ignored by the others (0 when
+// there is none).
+func Invoke(name string, count int) error {
+	info, exists := actions[name]
+	if !exists {
+		return fmt.Errorf("unknown action %q (expected one of: %s)", name, strings.Join(ActionNames(), ", "))
+	}
+	if info.Counted != nil {
+		return info.Counted(count)
+	}
+	times := 1
+	if info.Repeat && count > 1 {
+		times = count
+	}
+	for idx := 0; idx < times; idx++ {
+		if err := info.OnAction(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ActionNames lists the names of the actions that can be invoked, sorted.
+func ActionNames() []string {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,33 @@
+package key
+
+import (
+	"testing"
+)
+
+func TestInvoke(t *testing.T) {
+	defer func(previous map[string]BindingInfo) { actions = previous }(actions)
+	actions = make(map[string]BindingInfo)
+
+	var moves, marks, levels int
+	registerAction(BindingInfo{ConfigKeys: []string{"keybinding.cursor-down"}, OnAction: func() error { moves++; return nil }, Repeat: true})
+	registerAction(BindingInfo{ConfigKeys: []string{"keybinding.toggle-mark"}, OnAction: func() error { marks++; return nil }})
+	registerAction(BindingInfo{ConfigKeys: []string{"keybinding.expand-depth"}, Counted: func(count int) error { levels = count; return nil }})
+	// bindings without a name cannot be invoked
+	registerAction(BindingInfo{OnAction: func() error { return nil }})
+
+	for _, name := range []string{"cursor-down", "toggle-mark", "expand-depth"} {
+		if err := Invoke(name, 3); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if moves != 3 || marks != 1 || levels != 3 {
+		t.Errorf("expected 3 moves, 1 mark and 3 levels, got %d, %d and %d", moves, marks, levels)
+	}
+
+	if err := Invoke("quit-everything", 0); err == nil {
+		t.Errorf("expected an error for an unknown action")
+	}
+	if names := ActionNames(); len(names) != 3 || names[0] != "cursor-down" {
+		t.Errorf("unexpected action names: %v", names)
+	}
+}
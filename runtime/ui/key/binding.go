@@ -2,6 +2,8 @@ package key
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/awesome-gocui/keybinding"
@@ -17,6 +19,13 @@ type BindingInfo struct {
 	OnAction   func() error
 	IsSelected func() bool
 	Display    string
+	// the action is repeated by the count typed before the key (see BindCount), e.g. "5j"
+	Repeat bool
+	// the key has to be pressed twice in a row (e.g. vim's "gg")
+	Double bool
+	// the action takes the count typed before the key (0 when none) instead of being repeated by it, e.g. "3=", and
+	// replaces OnAction
+	Counted func(count int) error
 }
 
 type Binding struct {
@@ -24,6 +33,9 @@ type Binding struct {
 	displayName string
 	selectedFn  func() bool
 	actionFn    func() error
+	repeat      bool
+	double      bool
+	counted     func(count int) error
 }
 
 func GenerateBindings(gui *gocui.Gui, influence string, infos []BindingInfo) ([]*Binding, error) {
@@ -32,6 +44,18 @@ func GenerateBindings(gui *gocui.Gui, influence string, infos []BindingInfo) ([]
 		var err error
 		var binding *Binding
 
+		if info.Counted != nil {
+			counted := info.Counted
+			info.OnAction = func() error { return counted(0) }
+		}
+		registerAction(info)
+
+		if len(info.ConfigKeys) > 0 && !configured(info.ConfigKeys) {
+			// an empty value leaves the action unbound
+			logrus.Debugf("skipping keybinding %+v (no value given)", info.ConfigKeys)
+			continue
+		}
+
 		if info.ConfigKeys != nil && len(info.ConfigKeys) > 0 {
 			binding, err = NewBindingFromConfig(gui, influence, info.ConfigKeys, info.Display, info.OnAction)
 		} else {
@@ -45,6 +69,7 @@ func GenerateBindings(gui *gocui.Gui, influence string, infos []BindingInfo) ([]
 		if info.IsSelected != nil {
 			binding.RegisterSelectionFn(info.IsSelected)
 		}
+		binding.repeat, binding.double, binding.counted = info.Repeat, info.Double, info.Counted
 		if len(info.Display) > 0 {
 			result = append(result, binding)
 		}
@@ -52,6 +77,16 @@ func GenerateBindings(gui *gocui.Gui, influence string, infos []BindingInfo) ([]
 	return result, nil
 }
 
+// configured reports whether any of the given keybindings has a value.
+func configured(configKeys []string) bool {
+	for _, configKey := range configKeys {
+		if viper.GetString(configKey) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func NewBinding(gui *gocui.Gui, influence string, key gocui.Key, mod gocui.Modifier, displayName string, actionFn func() error) (*Binding, error) {
 	return newBinding(gui, influence, []keybinding.Key{{Value: key, Modifier: mod}}, displayName, actionFn)
 }
@@ -66,7 +101,7 @@ func NewBindingFromConfig(gui *gocui.Gui, influence string, configKeys []string,
 		}
 		logrus.Debugf("parsing keybinding '%s' --> '%s'", configKey, bindStr)
 
-		keys, err := keybinding.ParseAll(bindStr)
+		keys, err := parseKeys(bindStr)
 		if err != nil {
 			return nil, err
 		}
@@ -91,6 +126,12 @@ func newBinding(gui *gocui.Gui, influence string, keys []keybinding.Key, display
 	}
 
 	for _, key := range keys {
+		if ch, ok := keyRune(key); ok {
+			if err := gui.SetKeybinding(influence, ch, key.Modifier, binding.onRune(ch)); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		if err := gui.SetKeybinding(influence, key.Value, key.Modifier, binding.onAction); err != nil {
 			return nil, err
 		}
@@ -99,6 +140,44 @@ func newBinding(gui *gocui.Gui, influence string, keys []keybinding.Key, display
 	return binding, nil
 }
 
+// ValidateKeys checks that the comma separated keys of a keybinding (as given in the config) can be bound. An empty
+// value leaves the action unbound.
+func ValidateKeys(bindStr string) error {
+	if bindStr == "" {
+		return nil
+	}
+	_, err := parseKeys(bindStr)
+	return err
+}
+
+// parseKeys parses the comma separated keys of a keybinding. Besides the keys supported by the keybinding package,
+// a single character (e.g. "m") binds that character.
+func parseKeys(bindStr string) ([]keybinding.Key, error) {
+	keys := make([]keybinding.Key, 0)
+	for _, value := range strings.Split(bindStr, ",") {
+		value = strings.TrimSpace(value)
+		if utf8.RuneCountInString(value) == 1 && value != "," {
+			keys = append(keys, keybinding.Key{Modifier: gocui.ModNone, Tokens: []string{value}})
+			continue
+		}
+		key, err := keybinding.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse keybinding '%s' from request '%s': %+v", value, bindStr, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keyRune returns the character of a single character key (see parseKeys).
+func keyRune(key keybinding.Key) (rune, bool) {
+	if len(key.Tokens) != 1 || utf8.RuneCountInString(key.Tokens[0]) != 1 {
+		return 0, false
+	}
+	ch, _ := utf8.DecodeRuneInString(key.Tokens[0])
+	return ch, true
+}
+
 func (binding *Binding) RegisterSelectionFn(selectedFn func() bool) {
 	binding.selectedFn = selectedFn
 }
@@ -107,7 +186,23 @@ func (binding *Binding) onAction(*gocui.Gui, *gocui.View) error {
 	if binding.actionFn == nil {
 		return fmt.Errorf("no action configured for '%+v'", binding)
 	}
-	return binding.actionFn()
+	action, ok := motions.take(binding)
+	if !ok {
+		return nil
+	}
+	RecordStep(action)
+	return action()
+}
+
+// onRune handles a single character key. A partially typed macro command (e.g. "q" waiting for its register) takes
+// the character instead, since the character keys of a view take precedence over the global macro keys.
+func (binding *Binding) onRune(ch rune) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, view *gocui.View) error {
+		if recorder != nil && recorder.pending != 0 {
+			return recorder.onKey(ch)
+		}
+		return binding.onAction(gui, view)
+	}
 }
 
 func (binding *Binding) isSelected() bool {
@@ -118,6 +213,7 @@ func (binding *Binding) isSelected() bool {
 	return binding.selectedFn()
 }
 
-func (binding *Binding) RenderKeyHelp() string {
-	return format.RenderHelpKey(binding.key[0].String(), binding.displayName, binding.isSelected())
+// RenderKeyHelp renders the key and the name of the binding for the status bar, drawn with the given symbols.
+func (binding *Binding) RenderKeyHelp(symbols format.Symbols) string {
+	return symbols.RenderHelpKey(binding.key[0].String(), binding.displayName, binding.isSelected())
 }
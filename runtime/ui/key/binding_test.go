@@ -0,0 +1,107 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := parseKeys("ctrl+f, m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %+v", keys)
+	}
+
+	if _, isRune := keyRune(keys[0]); isRune || keys[0].Value != gocui.KeyCtrlF {
+		t.Errorf("expected ctrl+f, got %+v", keys[0])
+	}
+	if ch, isRune := keyRune(keys[1]); !isRune || ch != 'm' {
+		t.Errorf("expected the 'm' character, got %+v", keys[1])
+	}
+	if keys[1].String() != "m" {
+		t.Errorf("expected the key help to show 'm', got %q", keys[1].String())
+	}
+
+	if _, err := parseKeys("ctrl+nope"); err == nil {
+		t.Errorf("expected an error for an unsupported key")
+	}
+}
+
+func TestMotions(t *testing.T) {
+	defer func() { motions = motionState{} }()
+
+	var moves, tops int
+	down := &Binding{actionFn: func() error { moves++; return nil }, repeat: true}
+	top := &Binding{actionFn: func() error { tops++; return nil }, double: true}
+	mark := &Binding{actionFn: func() error { return nil }}
+
+	press := func(binding *Binding) {
+		if err := binding.onAction(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// "12j" moves twelve times, a count cannot start with a zero
+	for _, digit := range []int{0, 1, 2} {
+		motions.digit(digit)
+	}
+	press(down)
+	if moves != 12 {
+		t.Errorf("expected 12 moves, got %d", moves)
+	}
+
+	// any action consumes the count
+	motions.digit(3)
+	press(mark)
+	press(down)
+	if moves != 13 {
+		t.Errorf("expected the count to be consumed, got %d moves", moves)
+	}
+
+	// "gg" fires on the second press only, "g" "j" "g" does not fire
+	press(top)
+	press(down)
+	press(top)
+	if tops != 0 {
+		t.Errorf("expected no jump to the top, got %d", tops)
+	}
+	press(top)
+	if tops != 1 {
+		t.Errorf("expected a jump to the top, got %d", tops)
+	}
+
+	// "3=" hands the count over instead of repeating the action, "=" hands zero
+	var counts []int
+	depth := &Binding{actionFn: func() error { return nil }, counted: func(count int) error {
+		counts = append(counts, count)
+		return nil
+	}}
+	motions.digit(3)
+	press(depth)
+	press(depth)
+	if len(counts) != 2 || counts[0] != 3 || counts[1] != 0 {
+		t.Errorf("expected the counts 3 and 0, got %v", counts)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	defer func() { profile = Profiles[DefaultProfile] }()
+
+	if err := ApplyProfile("emacs"); err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+	if err := ApplyProfile(VimProfile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !CountsEnabled() {
+		t.Errorf("expected the vim profile to type counts")
+	}
+	for name, keys := range Profiles[VimProfile].Bindings {
+		if err := ValidateKeys(keys); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	recordMacroKey = 'q'
+	playMacroKey   = '@'
+)
+
+// the macros that keybinding actions are recorded to (there is only one UI, thus only one recorder)
+var recorder *Macros
+
+// Macro is a recorded sequence of UI actions.
+type Macro []func() error
+
+// Macros records the actions invoked through keybindings into named registers (a-z) so they can be replayed, in the
+// style of vim: "q<register>" starts recording, "q" stops recording, and "@<register>" replays the macro.
+type Macros struct {
+	registers map[rune]Macro
+	steps     Macro
+	recording rune
+	pending   rune
+	replaying bool
+	listeners []func() error
+}
+
+func NewMacros() *Macros {
+	return &Macros{
+		registers: make(map[rune]Macro),
+	}
+}
+
+// AddChangeListener registers a function to call when recording starts or stops.
+func (m *Macros) AddChangeListener(listener ...func() error) {
+	m.listeners = append(m.listeners, listener...)
+}
+
+// Bind registers the macro keys and starts recording the keybinding actions. The keys are bound globally, so they are
+// ignored while typing into an editable view (such as the filter).
+func (m *Macros) Bind(gui *gocui.Gui) error {
+	runes := []rune{recordMacroKey, playMacroKey}
+	for ch := 'a'; ch <= 'z'; ch++ {
+		if ch != recordMacroKey {
+			runes = append(runes, ch)
+		}
+	}
+
+	for _, ch := range runes {
+		ch := ch
+		err := gui.SetKeybinding("", ch, gocui.ModNone, func(*gocui.Gui, *gocui.View) error {
+			return m.onKey(ch)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	recorder = m
+	return nil
+}
+
+// Recording returns the register being recorded into (if any).
+func (m *Macros) Recording() (rune, bool) {
+	return m.recording, m.recording != 0
+}
+
+func (m *Macros) onKey(ch rune) error {
+	if m.pending != 0 {
+		command := m.pending
+		m.pending = 0
+		if ch < 'a' || ch > 'z' {
+			return nil
+		}
+		if command == playMacroKey {
+			return m.Play(ch)
+		}
+		m.recording = ch
+		m.steps = make(Macro, 0)
+		return m.notifyListeners()
+	}
+
+	switch ch {
+	case recordMacroKey:
+		if m.recording != 0 {
+			m.registers[m.recording] = m.steps
+			logrus.Debugf("recorded macro @%c (%d steps)", m.recording, len(m.steps))
+			m.recording = 0
+			m.steps = nil
+			return m.notifyListeners()
+		}
+		m.pending = recordMacroKey
+	case playMacroKey:
+		m.pending = playMacroKey
+	}
+	return nil
+}
+
+// Play replays the macro in the given register (when recording, the replayed steps become part of the new macro).
+func (m *Macros) Play(register rune) error {
+	if m.replaying {
+		return fmt.Errorf("cannot replay macro @%c from within a macro", register)
+	}
+	macro, exists := m.registers[register]
+	if !exists {
+		logrus.Infof("no macro recorded in @%c", register)
+		return nil
+	}
+
+	m.replaying = true
+	defer func() { m.replaying = false }()
+
+	for _, step := range macro {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+
+	if m.recording != 0 {
+		m.steps = append(m.steps, macro...)
+	}
+	return nil
+}
+
+func (m *Macros) record(step func() error) {
+	// a partially typed macro command is abandoned by any other action
+	m.pending = 0
+	if m.recording == 0 || m.replaying {
+		return
+	}
+	m.steps = append(m.steps, step)
+}
+
+func (m *Macros) notifyListeners() error {
+	for _, listener := range m.listeners {
+		if err := listener(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordStep adds an action that was not invoked through a keybinding (e.g. typing into the filter) to the macro that
+// is currently being recorded.
+func RecordStep(step func() error) {
+	if recorder != nil {
+		recorder.record(step)
+	}
+}
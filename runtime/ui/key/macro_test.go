@@ -0,0 +1,62 @@
+package key
+
+import "testing"
+
+func TestMacros(t *testing.T) {
+	macros := NewMacros()
+	recorder = macros
+	defer func() { recorder = nil }()
+
+	var actions []string
+	action := func(name string) func() error {
+		return func() error {
+			actions = append(actions, name)
+			return nil
+		}
+	}
+
+	// not recording: nothing is kept
+	RecordStep(action("ignored"))
+
+	for _, ch := range "qa" {
+		if err := macros.onKey(ch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if register, recording := macros.Recording(); !recording || register != 'a' {
+		t.Fatalf("expected to be recording into @a, got %q (%v)", register, recording)
+	}
+
+	RecordStep(action("expand"))
+	RecordStep(action("filter"))
+
+	if err := macros.onKey('q'); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, recording := macros.Recording(); recording {
+		t.Fatalf("expected recording to stop")
+	}
+
+	for _, ch := range "@a" {
+		if err := macros.onKey(ch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(actions) != 2 || actions[0] != "expand" || actions[1] != "filter" {
+		t.Errorf("unexpected replayed actions: %v", actions)
+	}
+
+	// an unknown register and an abandoned command are no-ops
+	for _, ch := range "@b" {
+		if err := macros.onKey(ch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	_ = macros.onKey('@')
+	RecordStep(action("other"))
+	_ = macros.onKey('a')
+	if len(actions) != 2 {
+		t.Errorf("expected no replay after an abandoned command, got %v", actions)
+	}
+}
@@ -0,0 +1,111 @@
+package key
+
+import (
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+)
+
+// the largest count that can be typed before an action
+const maxCount = 9999
+
+// motions tracks the keys typed ahead of an action, in the style of vim (there is only one UI, thus only one tracker)
+var motions motionState
+
+type motionState struct {
+	// the count typed before an action (e.g. "5j" moves down 5 times), 0 when none
+	count int
+	// the binding invoked last, for the bindings that fire on the second press of their key (e.g. "gg")
+	last *Binding
+}
+
+// BindCount binds the digits of the given view to type a count for the next action (see BindingInfo.Repeat), when the
+// keybinding profile types counts (see Profile.Counts). Any action consumes the count, though only the repeatable ones
+// are repeated by it. A count cannot start with a zero.
+func BindCount(gui *gocui.Gui, influence string) error {
+	if !CountsEnabled() {
+		return nil
+	}
+	for ch := '0'; ch <= '9'; ch++ {
+		ch := ch
+		err := gui.SetKeybinding(influence, ch, gocui.ModNone, func(*gocui.Gui, *gocui.View) error {
+			if recorder != nil && recorder.pending != 0 {
+				return recorder.onKey(ch)
+			}
+			motions.digit(int(ch - '0'))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BindDigits binds the digits 1 to 9 of the given view to the given action, unless the keybinding profile types counts
+// with them (see BindCount), in which case the action is reached through a binding that takes the count instead (see
+// BindingInfo.Counted).
+func BindDigits(gui *gocui.Gui, influence string, action func(digit int) error) error {
+	if CountsEnabled() {
+		return nil
+	}
+	for ch := '1'; ch <= '9'; ch++ {
+		ch := ch
+		err := gui.SetKeybinding(influence, ch, gocui.ModNone, func(*gocui.Gui, *gocui.View) error {
+			if recorder != nil && recorder.pending != 0 {
+				return recorder.onKey(ch)
+			}
+			step := func() error { return action(int(ch - '0')) }
+			RecordStep(step)
+			return step()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *motionState) digit(digit int) {
+	m.last = nil
+	if m.count == 0 && digit == 0 {
+		return
+	}
+	if m.count = m.count*10 + digit; m.count > maxCount {
+		m.count = maxCount
+	}
+}
+
+// take returns the action to perform for the given binding (its action repeated by the pending count), or false when
+// the key of the binding has to be pressed once more.
+func (m *motionState) take(binding *Binding) (func() error, bool) {
+	if binding.double && m.last != binding {
+		m.last = binding
+		return nil, false
+	}
+	m.last = binding
+	if binding.double {
+		// a third press starts over
+		m.last = nil
+	}
+
+	times := m.count
+	m.count = 0
+	if binding.counted != nil {
+		counted := binding.counted
+		return func() error { return counted(times) }, true
+	}
+	if !binding.repeat || times <= 1 {
+		return binding.actionFn, true
+	}
+
+	action := binding.actionFn
+	logrus.Tracef("repeating action %d times", times)
+	return func() error {
+		for idx := 0; idx < times; idx++ {
+			if err := action(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, true
+}
@@ -0,0 +1,81 @@
+package key
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	DefaultProfile = "default"
+	VimProfile     = "vim"
+)
+
+// Profile is a set of keybindings to start from, which the keybindings given in the config override.
+type Profile struct {
+	// the keybindings that differ from the default ones, by name (e.g. "cursor-down")
+	Bindings map[string]string
+	// digits type a count for the next action (e.g. "5j")
+	Counts bool
+}
+
+// Profiles are the keybinding profiles by name (see the keybinding.profile setting).
+var Profiles = map[string]Profile{
+	DefaultProfile: {},
+	VimProfile: {
+		Bindings: map[string]string{
+			"cursor-up":      "k",
+			"cursor-down":    "j",
+			"cursor-left":    "h",
+			"cursor-right":   "l",
+			"cursor-top":     "g",
+			"cursor-bottom":  "G",
+			"page-up":        "ctrl+u, pgup",
+			"page-down":      "ctrl+d, pgdn",
+			"search":         "/",
+			"next-match":     "n",
+			"previous-match": "N",
+			// the digits type counts, thus "3=" shows three levels of the file tree
+			"expand-to-depth": "=",
+			// the default keys of these actions are taken by the movements above
+			"show-history":            "H",
+			"toggle-unmodified-files": "U",
+			"next-mark":               "]",
+			"previous-mark":           "[",
+		},
+		Counts: true,
+	},
+}
+
+// the profile applied to the config
+var profile = Profiles[DefaultProfile]
+
+// ProfileNames lists the keybinding profiles.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile makes the keybindings of the given profile the defaults, such that the keybindings given in the config
+// still take precedence.
+func ApplyProfile(name string) error {
+	selected, exists := Profiles[name]
+	if !exists {
+		return fmt.Errorf("unknown keybinding profile: %q", name)
+	}
+	for binding, keys := range selected.Bindings {
+		viper.SetDefault("keybinding."+binding, keys)
+	}
+	profile = selected
+	return nil
+}
+
+// CountsEnabled reports whether the applied profile types a count with the digits.
+func CountsEnabled() bool {
+	return profile.Counts
+}
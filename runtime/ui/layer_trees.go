@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/runtime/ui/viewmodel"
+)
+
+// layerTreeFunc stacks (and compares) the layer trees of a layer selection.
+type layerTreeFunc func(selection viewmodel.LayerSelection) (*filetree.FileTree, error)
+
+// layerTreeHandler shows the computed tree of a layer selection. It is called on the UI goroutine.
+type layerTreeHandler func(tree *filetree.FileTree) error
+
+// layerTrees computes the file trees of the selected layers on a goroutine of its own, so that the UI keeps handling
+// input while the trees of a large image are stacked and compared. Only the latest selection matters: selections made
+// while a tree is being computed replace each other, and a computed tree is dropped when a newer selection was made
+// in the meantime. The selections are computed one at a time, on the same goroutine.
+type layerTrees struct {
+	gui     *gocui.Gui
+	compute layerTreeFunc
+	show    layerTreeHandler
+
+	lock       sync.Mutex
+	pending    *viewmodel.LayerSelection
+	generation int
+	// the generation of the last selection whose tree was shown (or failed)
+	shown     int
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLayerTrees(gui *gocui.Gui, compute layerTreeFunc, show layerTreeHandler) *layerTrees {
+	trees := &layerTrees{
+		gui:     gui,
+		compute: compute,
+		show:    show,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go trees.run()
+	return trees
+}
+
+// Request computes the tree of the given selection in the background, replacing any selection not computed yet.
+func (t *layerTrees) Request(selection viewmodel.LayerSelection) {
+	t.lock.Lock()
+	t.pending = &selection
+	t.generation++
+	t.lock.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+		// the worker has been woken up already
+	}
+}
+
+// Close stops the worker, dropping any selection not computed yet.
+func (t *layerTrees) Close() {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// Busy reports whether the tree of the latest selection is yet to be shown.
+func (t *layerTrees) Busy() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.shown != t.generation
+}
+
+// latest reports whether the given generation is still the latest selection.
+func (t *layerTrees) latest(generation int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return generation == t.generation
+}
+
+func (t *layerTrees) run() {
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.wake:
+		}
+
+		t.lock.Lock()
+		selection, generation := t.pending, t.generation
+		t.pending = nil
+		t.lock.Unlock()
+		if selection == nil {
+			continue
+		}
+
+		tree, err := t.compute(*selection)
+		if err != nil {
+			logrus.Errorf("unable to compute the layer tree: %+v", err)
+		}
+		if !t.latest(generation) {
+			continue
+		}
+
+		t.gui.Update(func(*gocui.Gui) error {
+			// a newer selection may have been made while waiting for the UI goroutine
+			if !t.latest(generation) {
+				return nil
+			}
+			t.lock.Lock()
+			t.shown = generation
+			t.lock.Unlock()
+			if err != nil {
+				return err
+			}
+			return t.show(tree)
+		})
+	}
+}
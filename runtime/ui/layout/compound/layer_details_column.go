@@ -4,18 +4,30 @@ import (
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
 	"github.com/wagoodman/dive/runtime/ui/view"
-	"github.com/wagoodman/dive/utils"
 )
 
+// stackedPane is a pane stacked between the layers pane and the layer details pane. Panes that are not visible are
+// left out (and removed from the screen, when they have just been hidden).
+type stackedPane interface {
+	Name() string
+	Setup(view *gocui.View, header *gocui.View) error
+	IsVisible() bool
+	Height() int
+	OnLayoutChange() error
+}
+
 type LayerDetailsCompoundLayout struct {
-	layer               *view.Layer
+	layer *view.Layer
+	// the panes beneath the layers pane, top to bottom
+	panes               []stackedPane
 	details             *view.Details
 	constrainRealEstate bool
 }
 
-func NewLayerDetailsCompoundLayout(layer *view.Layer, details *view.Details) *LayerDetailsCompoundLayout {
+func NewLayerDetailsCompoundLayout(layer *view.Layer, warnings *view.Warnings, parseWarnings *view.ParseWarnings, attestations *view.Attestations, audit *view.Audit, dependencies *view.Dependencies, duplicates *view.Duplicates, marks *view.Marks, fileDetails *view.FileDetails, details *view.Details) *LayerDetailsCompoundLayout {
 	return &LayerDetailsCompoundLayout{
 		layer:   layer,
+		panes:   []stackedPane{warnings, parseWarnings, attestations, audit, dependencies, duplicates, marks, fileDetails},
 		details: details,
 	}
 }
@@ -32,6 +44,17 @@ func (cl *LayerDetailsCompoundLayout) OnLayoutChange() error {
 		return err
 	}
 
+	for _, pane := range cl.panes {
+		if !pane.IsVisible() {
+			continue
+		}
+		err = pane.OnLayoutChange()
+		if err != nil {
+			logrus.Errorf("unable to setup %s controller onLayoutChange: %+v", pane.Name(), err)
+			return err
+		}
+	}
+
 	err = cl.details.OnLayoutChange()
 	if err != nil {
 		logrus.Error("unable to setup details controller onLayoutChange", err)
@@ -41,7 +64,7 @@ func (cl *LayerDetailsCompoundLayout) OnLayoutChange() error {
 }
 
 func (cl *LayerDetailsCompoundLayout) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
-	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, cl.Name())
+	view.TraceLayout(cl.Name(), minX, minY, maxX, maxY)
 
 	////////////////////////////////////////////////////////////////////////////////////
 	// Layers View
@@ -61,7 +84,7 @@ func (cl *LayerDetailsCompoundLayout) Layout(g *gocui.Gui, minX, minY, maxX, max
 	// we are going to overlap the view over the (invisible) border (so minY will be one less than expected)
 	main, viewErr := g.SetView(cl.layer.Name(), minX, minY+layerHeaderHeight, maxX, minY+layerHeaderHeight+layersHeight, 0)
 
-	if utils.IsNewView(viewErr, headerErr) {
+	if view.IsNewView(viewErr, headerErr) {
 		err := cl.layer.Setup(main, header)
 		if err != nil {
 			logrus.Error("unable to setup layer layout", err)
@@ -75,38 +98,49 @@ func (cl *LayerDetailsCompoundLayout) Layout(g *gocui.Gui, minX, minY, maxX, max
 	}
 
 	////////////////////////////////////////////////////////////////////////////////////
-	// Details
+	// Stacked panes (warnings, parse warnings, attestations, audit, dependencies, duplicates, marks, file details) & Details
 	detailsMinY := minY + layersHeight
 
 	// header + border
 	detailsHeaderHeight := 2
 
-	v, _ := g.View(cl.details.Name())
-	if v != nil {
-		// the view exists already!
+	// don't show the stacked or details panes when there isn't enough room on the screen
+	if cl.constrainRealEstate {
+		names := []string{cl.details.Name()}
+		for _, pane := range cl.panes {
+			names = append(names, pane.Name())
+		}
+		return deleteViews(g, names...)
+	}
 
-		// don't show the details pane when there isn't enough room on the screen
-		if cl.constrainRealEstate {
-			// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
-			err := g.DeleteView(cl.details.Name())
-			if err != nil {
+	for _, pane := range cl.panes {
+		if !pane.IsVisible() {
+			// e.g. the last mark was removed, or a file without inspections or attributes was selected
+			if err := deleteViews(g, pane.Name()); err != nil {
 				return err
 			}
-			// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
-			err = g.DeleteView(cl.details.Name() + "header")
+			continue
+		}
+
+		paneHeaderHeight := 2
+		paneHeight := pane.Height()
+
+		header, headerErr = g.SetView(pane.Name()+"header", minX, detailsMinY, maxX, detailsMinY+paneHeaderHeight, 0)
+		main, viewErr = g.SetView(pane.Name(), minX, detailsMinY+paneHeaderHeight, maxX, detailsMinY+paneHeaderHeight+paneHeight+1, 0)
+
+		if view.IsNewView(viewErr, headerErr) {
+			err := pane.Setup(main, header)
 			if err != nil {
 				return err
 			}
-
-			return nil
 		}
-
+		detailsMinY += paneHeaderHeight + paneHeight
 	}
 
 	header, headerErr = g.SetView(cl.details.Name()+"header", minX, detailsMinY, maxX, detailsMinY+detailsHeaderHeight, 0)
 	main, viewErr = g.SetView(cl.details.Name(), minX, detailsMinY+detailsHeaderHeight, maxX, maxY, 0)
 
-	if utils.IsNewView(viewErr, headerErr) {
+	if view.IsNewView(viewErr, headerErr) {
 		err := cl.details.Setup(main, header)
 		if err != nil {
 			return err
@@ -116,6 +150,29 @@ func (cl *LayerDetailsCompoundLayout) Layout(g *gocui.Gui, minX, minY, maxX, max
 	return nil
 }
 
+// deleteViews removes the given views (and their headers) from the screen, if they exist.
+func deleteViews(g *gocui.Gui, names ...string) error {
+	for _, name := range names {
+		v, _ := g.View(name)
+		if v == nil {
+			continue
+		}
+		// the view exists already!
+
+		// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
+		err := g.DeleteView(name)
+		if err != nil {
+			return err
+		}
+		// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
+		err = g.DeleteView(name + "header")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cl *LayerDetailsCompoundLayout) RequestedSize(available int) *int {
 	// "available" is the entire screen real estate, so we can guess when its a bit too small and take action.
 	// This isn't perfect, but it gets the job done for now without complicated layout constraint solvers
@@ -4,6 +4,8 @@ const (
 	LocationFooter Location = iota
 	LocationHeader
 	LocationColumn
+	// an overlay is laid out last, over the whole screen (it picks its own position and hides itself when not visible)
+	LocationOverlay
 )
 
 type Location int
@@ -1,6 +1,8 @@
 package layout
 
 import (
+	"fmt"
+
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
 )
@@ -26,6 +28,48 @@ func (lm *Manager) Add(element Layout, location Location) {
 	lm.elements[location] = append(lm.elements[location], element)
 }
 
+// Insert adds an element at the given position of a location (0 is the first header, footer, column or overlay). A
+// position past the last element appends the element.
+func (lm *Manager) Insert(element Layout, location Location, index int) {
+	elements := lm.elements[location]
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(elements) {
+		lm.elements[location] = append(elements, element)
+		return
+	}
+	elements = append(elements, nil)
+	copy(elements[index+1:], elements[index:])
+	elements[index] = element
+	lm.elements[location] = elements
+}
+
+// Index returns the position of the element within its location, or -1 when it was not added there.
+func (lm *Manager) Index(element Layout, location Location) int {
+	for idx, candidate := range lm.elements[location] {
+		if candidate == element {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Swap exchanges the positions of two elements of a location. The elements are referenced directly (rather than by
+// position) so that callers keep working when elements are added or reordered.
+func (lm *Manager) Swap(first, second Layout, location Location) error {
+	firstIdx, secondIdx := lm.Index(first, location), lm.Index(second, location)
+	if firstIdx == -1 {
+		return fmt.Errorf("'%s' is not laid out there", first.Name())
+	}
+	if secondIdx == -1 {
+		return fmt.Errorf("'%s' is not laid out there", second.Name())
+	}
+	elements := lm.elements[location]
+	elements[firstIdx], elements[secondIdx] = elements[secondIdx], elements[firstIdx]
+	return nil
+}
+
 func (lm *Manager) planAndLayoutHeaders(g *gocui.Gui, area Area) (Area, error) {
 	// layout headers top down
 	if elements, exists := lm.elements[LocationHeader]; exists {
@@ -174,6 +218,20 @@ func (lm *Manager) layoutFooters(g *gocui.Gui, area Area, footerHeights []int) e
 	return nil
 }
 
+func (lm *Manager) layoutOverlays(g *gocui.Gui, area Area) error {
+	// overlays are laid out after everything else so that they are drawn on top
+	if elements, exists := lm.elements[LocationOverlay]; exists {
+		for _, element := range elements {
+			err := element.Layout(g, area.minX, area.minY, area.maxX, area.maxY)
+			if err != nil {
+				logrus.Errorf("failed to layout '%s' overlay: %+v", element.Name(), err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (lm *Manager) notifyLayoutChange() error {
 	for _, elements := range lm.elements {
 		for _, element := range elements {
@@ -255,6 +313,12 @@ func (lm *Manager) layout(g *gocui.Gui, curMaxX, curMaxY int) error {
 		return nil
 	}
 
+	// overlays... layout over the whole screen
+	err = lm.layoutOverlays(g, Area{minX: -1, minY: -1, maxX: curMaxX, maxY: curMaxY})
+	if err != nil {
+		return nil
+	}
+
 	// pass 2: notify everyone of a layout change (allow to update and render)
 	// note: this may mean that each element will update and rerender, which may cause a secondary layout call.
 	// the conditions which we notify elements of layout changes must be very selective!
@@ -285,6 +285,38 @@ func Test_layout(t *testing.T) {
 					}, LocationColumn),
 			},
 		},
+		"1 header + 1 footer + 1 column + 1 overlay": {
+			elements: []*testElement{
+				newTestElement(t, 1,
+					Area{
+						minX: -1,
+						minY: -1,
+						maxX: 120,
+						maxY: 0,
+					}, LocationHeader),
+				newTestElement(t, 1,
+					Area{
+						minX: -1,
+						minY: 78,
+						maxX: 120,
+						maxY: 80,
+					}, LocationFooter),
+				newTestElement(t, -1,
+					Area{
+						minX: -1,
+						minY: 0,
+						maxX: 120,
+						maxY: 79,
+					}, LocationColumn),
+				newTestElement(t, -1,
+					Area{
+						minX: -1,
+						minY: -1,
+						maxX: 120,
+						maxY: 80,
+					}, LocationOverlay),
+			},
+		},
 		"1 header + 1 footer + 3 column": {
 			elements: []*testElement{
 				newTestElement(t, 1,
@@ -379,3 +411,39 @@ func Test_layout(t *testing.T) {
 		}
 	}
 }
+
+func Test_insertAndSwap(t *testing.T) {
+	first := newTestElement(t, 1, Area{}, LocationColumn)
+	second := newTestElement(t, 1, Area{}, LocationColumn)
+	third := newTestElement(t, 1, Area{}, LocationColumn)
+	missing := newTestElement(t, 1, Area{}, LocationColumn)
+
+	lm := NewManager()
+	lm.Add(third, LocationColumn)
+	lm.Insert(first, LocationColumn, 0)
+	lm.Insert(second, LocationColumn, 1)
+
+	for expected, element := range []*testElement{first, second, third} {
+		if actual := lm.Index(element, LocationColumn); actual != expected {
+			t.Errorf("expected element at %d, got %d", expected, actual)
+		}
+	}
+	if lm.Index(first, LocationHeader) != -1 {
+		t.Error("expected no header")
+	}
+
+	if err := lm.Swap(third, first, LocationColumn); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if lm.Index(third, LocationColumn) != 0 || lm.Index(first, LocationColumn) != 2 {
+		t.Errorf("elements were not swapped")
+	}
+	if err := lm.Swap(first, missing, LocationColumn); err == nil {
+		t.Error("expected an error for an element that was not added")
+	}
+
+	lm.Insert(missing, LocationColumn, 10)
+	if lm.Index(missing, LocationColumn) != 3 {
+		t.Errorf("expected the element to be appended")
+	}
+}
@@ -0,0 +1,154 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the actions of a script besides the keybinding actions (which are named after their keybinding, e.g. compare-all)
+const (
+	// select the layer of the given index (0 is the first)
+	ActionLayer = "layer"
+	// filter the file tree by the given regular expression (none clears the filter)
+	ActionFilter = "filter"
+	// move the focus to the layer or filetree pane
+	ActionFocus = "focus"
+	// move the file tree cursor to the given path
+	ActionPath = "path"
+	// write the screen to the given file, in the format its extension names (see terminal.ScreenshotFormatOf)
+	ActionSnapshot = "snapshot"
+)
+
+// the panes the focus can move to
+var focusPanes = []string{"layer", "filetree"}
+
+var actionName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Step is an action of a script along with its argument.
+type Step struct {
+	Action string
+	// the argument of the action (empty when it takes none); keybinding actions take a count
+	Arg string
+	// the line of the step in the script (its position in the list for a JSON script)
+	Line int
+}
+
+// String returns the step as it is written in a script.
+func (step Step) String() string {
+	return strings.TrimSpace(step.Action + " " + step.Arg)
+}
+
+// Count returns the count given to a keybinding action (0 when none is given).
+func (step Step) Count() int {
+	count, _ := strconv.Atoi(step.Arg)
+	return count
+}
+
+// Script is a sequence of UI actions, replayed in order.
+type Script []Step
+
+// Load reads the script at the given path.
+func Load(path string) (Script, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the script: %v", err)
+	}
+	return Parse(bytes.NewReader(contents))
+}
+
+// Parse reads a script, either a JSON list of steps (e.g. [{"action": "layer", "arg": 3}]) or one step per line: the
+// action followed by its argument (e.g. "filter ssl"), with blank lines and lines starting with # ignored.
+func Parse(reader io.Reader) (Script, error) {
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(contents); len(trimmed) > 0 && trimmed[0] == '[' {
+		return parseJSON(trimmed)
+	}
+
+	var steps Script
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		action, arg, _ := strings.Cut(text, " ")
+		step := Step{Action: action, Arg: strings.TrimSpace(arg), Line: line}
+		if err := step.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, scanner.Err()
+}
+
+func parseJSON(contents []byte) (Script, error) {
+	var entries []struct {
+		Action string      `json:"action"`
+		Arg    interface{} `json:"arg"`
+	}
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON script: %v", err)
+	}
+
+	steps := make(Script, 0, len(entries))
+	for idx, entry := range entries {
+		step := Step{Action: strings.TrimSpace(entry.Action), Line: idx + 1}
+		switch arg := entry.Arg.(type) {
+		case nil:
+		case string:
+			step.Arg = strings.TrimSpace(arg)
+		case float64:
+			step.Arg = strconv.FormatFloat(arg, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("step %d: the argument is a string or a number", idx+1)
+		}
+		if err := step.validate(); err != nil {
+			return nil, fmt.Errorf("step %d: %v", idx+1, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// validate checks the argument of the step. The names of the keybinding actions are only known once the UI is set up.
+func (step Step) validate() error {
+	switch step.Action {
+	case ActionLayer:
+		if index, err := strconv.Atoi(step.Arg); err != nil || index < 0 {
+			return fmt.Errorf("layer takes the index of the layer to select (0 is the first), got %q", step.Arg)
+		}
+	case ActionFilter:
+		if _, err := regexp.Compile(step.Arg); err != nil {
+			return fmt.Errorf("invalid filter: %v", err)
+		}
+	case ActionFocus:
+		for _, pane := range focusPanes {
+			if step.Arg == pane {
+				return nil
+			}
+		}
+		return fmt.Errorf("focus takes the pane to focus (%s), got %q", strings.Join(focusPanes, " or "), step.Arg)
+	case ActionPath, ActionSnapshot:
+		if step.Arg == "" {
+			return fmt.Errorf("%s takes a path", step.Action)
+		}
+	default:
+		if !actionName.MatchString(step.Action) {
+			return fmt.Errorf("invalid action %q", step.Action)
+		}
+		if count, err := strconv.Atoi(step.Arg); step.Arg != "" && (err != nil || count < 1) {
+			return fmt.Errorf("%s takes an optional count, got %q", step.Action, step.Arg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	expected := Script{
+		{Action: ActionLayer, Arg: "3", Line: 2},
+		{Action: ActionFilter, Arg: "lib/ssl .*", Line: 3},
+		{Action: "compare-all", Line: 5},
+		{Action: "cursor-down", Arg: "2", Line: 6},
+		{Action: ActionSnapshot, Arg: "layer-3.txt", Line: 7},
+	}
+
+	text := `# reproduces the issue
+layer 3
+filter   lib/ssl .*
+
+compare-all
+cursor-down 2
+snapshot layer-3.txt
+`
+	steps, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, steps)
+	}
+
+	jsonText := `[
+		{"action": "layer", "arg": 3},
+		{"action": "filter", "arg": "lib/ssl .*"},
+		{"action": "compare-all"},
+		{"action": "cursor-down", "arg": "2"},
+		{"action": "snapshot", "arg": "layer-3.txt"}
+	]`
+	steps, err = Parse(strings.NewReader(jsonText))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for idx := range expected {
+		expected[idx].Line = idx + 1
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("expected %+v, got %+v", expected, steps)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := []string{
+		"layer",
+		"layer -1",
+		"filter ssl(",
+		"focus details",
+		"snapshot",
+		"path",
+		"compare-all twice",
+		"Compare-All",
+		`[{"action": "layer", "arg": true}]`,
+		`[{"action": "layer"`,
+	}
+	for _, text := range cases {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
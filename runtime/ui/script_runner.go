@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/script"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+)
+
+// how long the UI is left to settle after a step (the views render through updates of their own), and how often it
+// is checked again while the trees of the selected layers are being computed
+const scriptSettleDelay = 50 * time.Millisecond
+
+// scriptRunner replays the steps of a script on the UI goroutine, one at a time: each step runs once the screen shows
+// what the previous one did, then the final screen is written to the snapshot output and the UI quits.
+type scriptRunner struct {
+	gui    *gocui.Gui
+	steps  script.Script
+	output io.WriteCloser
+	// the app of the image shown (the images opened along the image may be switched to)
+	shown func() *app
+}
+
+// start replays the script once the main loop runs.
+func (r *scriptRunner) start() {
+	r.schedule(0)
+}
+
+// schedule runs the step of the given index (or writes the final screen after the last one) once the UI settles.
+func (r *scriptRunner) schedule(idx int) {
+	go func() {
+		time.Sleep(scriptSettleDelay)
+		r.gui.Update(func(*gocui.Gui) error {
+			if r.shown().controllers.Busy() {
+				r.schedule(idx)
+				return nil
+			}
+			if idx == len(r.steps) {
+				return writeSnapshot(r.output)
+			}
+
+			step := r.steps[idx]
+			logrus.Debugf("script step %d: %s", step.Line, step)
+			if err := r.run(step); err != nil {
+				r.output.Close()
+				return fmt.Errorf("script step %d (%s): %v", step.Line, step, err)
+			}
+			r.schedule(idx + 1)
+			return nil
+		})
+	}()
+}
+
+func (r *scriptRunner) run(step script.Step) error {
+	controller := r.shown().controllers
+	switch step.Action {
+	case script.ActionLayer:
+		index, err := strconv.Atoi(step.Arg)
+		if err != nil {
+			return err
+		}
+		return controller.SelectLayer(index)
+	case script.ActionFilter:
+		return controller.SetFilter(step.Arg)
+	case script.ActionFocus:
+		return controller.FocusView(step.Arg)
+	case script.ActionPath:
+		return controller.onGoToPath(step.Arg)
+	case script.ActionSnapshot:
+		return writeFile(step.Arg, func(writer io.Writer) error {
+			format := terminal.ScreenshotFormatOf(step.Arg, viper.GetString("render.format"))
+			return terminal.WriteScreenshot(writer, terminal.CaptureScreen(), format)
+		})
+	}
+	return key.Invoke(step.Action, step.Count())
+}
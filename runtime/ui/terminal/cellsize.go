@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// CellPixelSize returns the size of a terminal cell in pixels, as reported by the terminal (or a common size when the
+// terminal does not report it).
+func CellPixelSize() (int, int) {
+	size, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || size.Col == 0 || size.Row == 0 || size.Xpixel == 0 || size.Ypixel == 0 {
+		return defaultCellWidth, defaultCellHeight
+	}
+	return int(size.Xpixel / size.Col), int(size.Ypixel / size.Row)
+}
@@ -0,0 +1,7 @@
+package terminal
+
+// CellPixelSize returns the size of a terminal cell in pixels (the console does not report it, so a common size is
+// assumed).
+func CellPixelSize() (int, int) {
+	return defaultCellWidth, defaultCellHeight
+}
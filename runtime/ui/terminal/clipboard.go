@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// the native clipboard tools, in order of preference, by the display server they require ("" for none)
+var nativeClipboardTools = []struct {
+	display string
+	command []string
+}{
+	{"WAYLAND_DISPLAY", []string{"wl-copy"}},
+	{"DISPLAY", []string{"xclip", "-selection", "clipboard"}},
+	{"DISPLAY", []string{"xsel", "--clipboard", "--input"}},
+	// WSL shares the clipboard of the windows host
+	{"WSL_DISTRO_NAME", []string{"clip.exe"}},
+}
+
+// OSC52 creates the escape sequence that sets the system clipboard of the (possibly remote) terminal to the given text.
+func OSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// CopyToClipboard writes the OSC52 clipboard sequence for the given text, wrapped for the multiplexer if needed. Note
+// that tmux only forwards it with "set-clipboard" (or "allow-passthrough" for newer versions) enabled.
+func CopyToClipboard(writer io.Writer, multiplexer Multiplexer, text string) error {
+	_, err := io.WriteString(writer, multiplexer.Passthrough(OSC52(text)))
+	return err
+}
+
+// NativeClipboardCommand returns the command that sets the clipboard of the local desktop from its input (nil when
+// there is none, or when dive runs over ssh and the local desktop is not the one in front of the user).
+func NativeClipboardCommand(goos string, getenv func(string) string, lookPath func(string) (string, error)) []string {
+	if getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != "" {
+		return nil
+	}
+
+	var candidates [][]string
+	switch goos {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		for _, tool := range nativeClipboardTools {
+			if getenv(tool.display) != "" {
+				candidates = append(candidates, tool.command)
+			}
+		}
+	}
+
+	for _, command := range candidates {
+		if _, err := lookPath(command[0]); err == nil {
+			return command
+		}
+	}
+	return nil
+}
+
+// Copy sets the clipboard to the given text. The OSC52 sequence is always written, since terminals that do not support
+// it ignore it (and there is no way to tell); the native clipboard tool of the desktop is used as well when there is one,
+// which covers the terminals that do not support OSC52.
+func Copy(text string) error {
+	err := CopyToClipboard(os.Stdout, DetectMultiplexer(os.Getenv), text)
+
+	command := NativeClipboardCommand(runtime.GOOS, os.Getenv, exec.LookPath)
+	if command == nil {
+		return err
+	}
+	native := exec.Command(command[0], command[1:]...)
+	native.Stdin = strings.NewReader(text)
+	if nativeErr := native.Run(); nativeErr != nil {
+		logrus.Debugf("unable to copy with %s: %+v", command[0], nativeErr)
+		return err
+	}
+	// the native clipboard was set, so an unsupported terminal is not an error
+	return nil
+}
@@ -0,0 +1,295 @@
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	// registers the formats decoded by DecodeImage
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	scale "golang.org/x/image/draw"
+)
+
+// GraphicsProtocol is a way of drawing images within the terminal.
+type GraphicsProtocol string
+
+const (
+	NoGraphics    GraphicsProtocol = "none"
+	KittyGraphics GraphicsProtocol = "kitty"
+	SixelGraphics GraphicsProtocol = "sixel"
+)
+
+const (
+	// the largest image file that is decoded for a preview
+	maxImageFileBytes = 64 * 1024 * 1024
+	// the most pixels an image may have to be decoded for a preview (guards against decompression bombs)
+	maxImagePixels = 64 * 1024 * 1024
+	// the size svg files without dimensions are rasterized at, and the most they are rasterized at
+	defaultSvgSize = 512
+	maxSvgSize     = 2048
+	// the largest payload of a single kitty graphics command
+	kittyChunkSize = 4096
+	// the cell size assumed when the terminal does not report it
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// the image file extensions that can be previewed, by the format reported for them
+var previewFormats = map[string]string{
+	".png":  "png",
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".gif":  "gif",
+	".svg":  "svg",
+}
+
+// the terminals that support sixel graphics, by their TERM value (or TERM_PROGRAM, for those that do not set one)
+var sixelTerminals = []string{"foot", "foot-extra", "mlterm", "yaft-256color", "WezTerm", "mintty"}
+
+// ParseGraphicsProtocol reads a configured protocol (any of the protocols, or "auto" to detect it).
+func ParseGraphicsProtocol(value string, getenv func(string) string) (GraphicsProtocol, error) {
+	switch GraphicsProtocol(value) {
+	case NoGraphics, KittyGraphics, SixelGraphics:
+		return GraphicsProtocol(value), nil
+	case "auto", "":
+		return DetectGraphics(getenv), nil
+	}
+	return NoGraphics, fmt.Errorf("unknown graphics protocol %q (expected auto, kitty, sixel or none)", value)
+}
+
+// DetectGraphics determines the graphics protocol the terminal supports from the environment. Multiplexers do not
+// forward graphics (or draw them in the wrong place), so none is used within one.
+func DetectGraphics(getenv func(string) string) GraphicsProtocol {
+	if DetectMultiplexer(getenv) != NoMultiplexer {
+		return NoGraphics
+	}
+
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	switch {
+	case term == "xterm-kitty" || getenv("KITTY_WINDOW_ID") != "":
+		return KittyGraphics
+	case term == "xterm-ghostty" || program == "ghostty":
+		return KittyGraphics
+	case strings.Contains(term, "sixel"):
+		return SixelGraphics
+	}
+	for _, name := range sixelTerminals {
+		if term == name || program == name {
+			return SixelGraphics
+		}
+	}
+	return NoGraphics
+}
+
+// PreviewFormat returns the image format of the given file name (false when the file cannot be previewed).
+func PreviewFormat(name string) (string, bool) {
+	format, ok := previewFormats[strings.ToLower(path.Ext(name))]
+	return format, ok
+}
+
+// DecodeImage reads an image file (png, jpeg, gif or svg, told apart by the file name) for a preview.
+func DecodeImage(name string, reader io.Reader) (image.Image, error) {
+	format, ok := PreviewFormat(name)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not an image that can be previewed", name)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(reader, maxImageFileBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxImageFileBytes {
+		return nil, fmt.Errorf("the image is larger than %d MB", maxImageFileBytes/1024/1024)
+	}
+
+	if format == "svg" {
+		return rasterizeSvg(content)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("the image is too large to preview (%dx%d)", config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	return decoded, err
+}
+
+// rasterizeSvg draws an svg file at its own size (bounded by maxSvgSize).
+func rasterizeSvg(content []byte) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(content), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := int(icon.ViewBox.W), int(icon.ViewBox.H)
+	if width <= 0 || height <= 0 {
+		width, height = defaultSvgSize, defaultSvgSize
+	}
+	if width > maxSvgSize || height > maxSvgSize {
+		width, height = fit(width, height, maxSvgSize, maxSvgSize)
+	}
+
+	rasterized := image.NewRGBA(image.Rect(0, 0, width, height))
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	icon.Draw(rasterx.NewDasher(width, height, rasterx.NewScannerGV(width, height, rasterized, rasterized.Bounds())), 1)
+	return rasterized, nil
+}
+
+// fit scales the given size down (never up) to fit within the bounds, keeping the aspect ratio.
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	} else {
+		width = width * maxHeight / height
+		height = maxHeight
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return width, height
+}
+
+// ImageSequence draws the image within a box of the given number of cells with the given protocol (scaled down to fit,
+// keeping the aspect ratio), returning the escape sequence along with the cells it covers.
+func ImageSequence(protocol GraphicsProtocol, img image.Image, cols, rows, cellWidth, cellHeight int) (string, int, int, error) {
+	bounds := img.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), cols*cellWidth, rows*cellHeight)
+	if width != bounds.Dx() || height != bounds.Dy() {
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		scale.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+		img = scaled
+	}
+	usedCols, usedRows := (width+cellWidth-1)/cellWidth, (height+cellHeight-1)/cellHeight
+
+	switch protocol {
+	case KittyGraphics:
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, img); err != nil {
+			return "", 0, 0, err
+		}
+		return KittyImage(encoded.Bytes(), usedCols, usedRows), usedCols, usedRows, nil
+	case SixelGraphics:
+		return SixelImage(img), usedCols, usedRows, nil
+	}
+	return "", 0, 0, fmt.Errorf("images cannot be drawn with the %q graphics protocol", protocol)
+}
+
+// KittyImage creates the kitty graphics commands that draw the given png at the cursor, scaled to the given number of
+// cells. The cursor is not moved and the terminal is asked not to respond (which would otherwise be read as input).
+func KittyImage(pngData []byte, cols, rows int) string {
+	encoded := base64.StdEncoding.EncodeToString(pngData)
+
+	var sequence strings.Builder
+	for offset := 0; offset < len(encoded); offset += kittyChunkSize {
+		end := offset + kittyChunkSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		more := 0
+		if end < len(encoded) {
+			more = 1
+		}
+		if offset == 0 {
+			fmt.Fprintf(&sequence, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, encoded[offset:end])
+		} else {
+			fmt.Fprintf(&sequence, "\x1b_Gm=%d;%s\x1b\\", more, encoded[offset:end])
+		}
+	}
+	return sequence.String()
+}
+
+// KittyDeleteImages creates the kitty graphics command that removes every image drawn on the screen.
+func KittyDeleteImages() string {
+	return "\x1b_Ga=d,q=2\x1b\\"
+}
+
+// SixelImage creates the sixel sequence that draws the given image at the cursor, with its colors reduced to the web
+// safe palette. Transparent pixels are left as they are.
+func SixelImage(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	var sequence strings.Builder
+	// the second parameter keeps the pixels that are not drawn (transparent) as they are
+	fmt.Fprintf(&sequence, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for idx, paletteColor := range palette.WebSafe {
+		r, g, b, _ := paletteColor.RGBA()
+		fmt.Fprintf(&sequence, "#%d;2;%d;%d;%d", idx, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// every band of six rows is drawn once per color, in order of the first pixel of each color
+	for top := 0; top < height; top += 6 {
+		bands := make(map[uint8][]byte)
+		var order []uint8
+		for x := 0; x < width; x++ {
+			for row := 0; row < 6 && top+row < height; row++ {
+				if _, _, _, alpha := img.At(bounds.Min.X+x, bounds.Min.Y+top+row).RGBA(); alpha < 0x8000 {
+					continue
+				}
+				index := paletted.ColorIndexAt(x, top+row)
+				if bands[index] == nil {
+					bands[index] = make([]byte, width)
+					order = append(order, index)
+				}
+				bands[index][x] |= 1 << uint(row)
+			}
+		}
+
+		for idx, index := range order {
+			if idx > 0 {
+				sequence.WriteByte('$')
+			}
+			fmt.Fprintf(&sequence, "#%d", index)
+			writeSixels(&sequence, bands[index])
+		}
+		sequence.WriteByte('-')
+	}
+	sequence.WriteString("\x1b\\")
+	return sequence.String()
+}
+
+// writeSixels writes a row of sixels, run-length encoded.
+func writeSixels(sequence *strings.Builder, sixels []byte) {
+	for start := 0; start < len(sixels); {
+		end := start
+		for end < len(sixels) && sixels[end] == sixels[start] {
+			end++
+		}
+		character := sixels[start] + '?'
+		if count := end - start; count > 3 {
+			fmt.Fprintf(sequence, "!%d%c", count, character)
+		} else {
+			sequence.WriteString(strings.Repeat(string(character), count))
+		}
+		start = end
+	}
+}
+
+// DrawAt wraps an escape sequence such that it is drawn at the given cell (counted from 0), restoring the cursor
+// afterwards so that the UI library is not thrown off.
+func DrawAt(col, row int, sequence string) string {
+	return fmt.Sprintf("\x1b7\x1b[%d;%dH%s\x1b8", row+1, col+1, sequence)
+}
@@ -0,0 +1,110 @@
+package terminal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestDetectGraphics(t *testing.T) {
+	cases := []struct {
+		name     string
+		env      map[string]string
+		expected GraphicsProtocol
+	}{
+		{name: "kitty", env: map[string]string{"TERM": "xterm-kitty"}, expected: KittyGraphics},
+		{name: "kitty window", env: map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, expected: KittyGraphics},
+		{name: "ghostty", env: map[string]string{"TERM_PROGRAM": "ghostty"}, expected: KittyGraphics},
+		{name: "foot", env: map[string]string{"TERM": "foot"}, expected: SixelGraphics},
+		{name: "wezterm", env: map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, expected: SixelGraphics},
+		{name: "sixel term", env: map[string]string{"TERM": "xterm-sixel"}, expected: SixelGraphics},
+		{name: "plain", env: map[string]string{"TERM": "xterm-256color"}, expected: NoGraphics},
+		{name: "multiplexer", env: map[string]string{"TERM": "xterm-kitty", "TMUX": "/tmp/tmux-1000/default,1,0"}, expected: NoGraphics},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			actual := DetectGraphics(func(name string) string { return test.env[name] })
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+
+	if _, err := ParseGraphicsProtocol("iterm", func(string) string { return "" }); err == nil {
+		t.Errorf("expected an error for an unknown protocol")
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	source := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	source.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, source); err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	decoded, err := DecodeImage("/app/logo.PNG", &encoded)
+	if err != nil {
+		t.Fatalf("unable to decode: %v", err)
+	}
+	if decoded.Bounds().Dx() != 4 || decoded.Bounds().Dy() != 3 {
+		t.Errorf("unexpected bounds: %v", decoded.Bounds())
+	}
+
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 40 20"><rect width="40" height="20" fill="red"/></svg>`
+	rasterized, err := DecodeImage("/app/icon.svg", strings.NewReader(svg))
+	if err != nil {
+		t.Fatalf("unable to rasterize: %v", err)
+	}
+	if rasterized.Bounds().Dx() != 40 || rasterized.Bounds().Dy() != 20 {
+		t.Errorf("unexpected bounds: %v", rasterized.Bounds())
+	}
+	if r, _, _, a := rasterized.At(20, 10).RGBA(); r != 0xffff || a != 0xffff {
+		t.Errorf("expected the svg to be drawn")
+	}
+
+	if _, err := DecodeImage("/app/readme.txt", strings.NewReader("")); err == nil {
+		t.Errorf("expected an error for a file that is not an image")
+	}
+}
+
+func TestImageSequence(t *testing.T) {
+	source := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 50; y++ {
+			source.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+
+	// scaled down to fit 4 cells of 10x10 pixels across
+	sixel, cols, rows, err := ImageSequence(SixelGraphics, source, 4, 10, 10, 10)
+	if err != nil {
+		t.Fatalf("unable to encode: %v", err)
+	}
+	if cols != 4 || rows != 2 {
+		t.Errorf("expected 4x2 cells, got %dx%d", cols, rows)
+	}
+	if !strings.HasPrefix(sixel, "\x1bP0;1;0q\"1;1;40;20") || !strings.HasSuffix(sixel, "\x1b\\") {
+		t.Errorf("unexpected sixel sequence: %q", sixel)
+	}
+	// a single color, every band (of six rows) run-length encoded
+	if !strings.Contains(sixel, "!40~-") {
+		t.Errorf("expected run-length encoded sixels: %q", sixel)
+	}
+
+	kitty, _, _, err := ImageSequence(KittyGraphics, source, 4, 10, 10, 10)
+	if err != nil {
+		t.Fatalf("unable to encode: %v", err)
+	}
+	if !strings.HasPrefix(kitty, "\x1b_Ga=T,f=100,q=2,C=1,c=4,r=2,m=0;") {
+		t.Errorf("unexpected kitty sequence: %q", kitty[:40])
+	}
+
+	if _, _, _, err := ImageSequence(NoGraphics, source, 4, 10, 10, 10); err == nil {
+		t.Errorf("expected an error without a protocol")
+	}
+}
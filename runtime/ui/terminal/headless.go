@@ -0,0 +1,54 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SnapshotFDEnv names the file descriptor a UI rendered headless writes its snapshot to (see RenderHeadless). It is
+// only set in the environment of the process drawing on the pseudo terminal.
+const SnapshotFDEnv = "DIVE_RENDER_SNAPSHOT_FD"
+
+// DefaultSnapshotSize is the size of the pseudo terminal snapshots are rendered on, unless another is given.
+const DefaultSnapshotSize = "120x40"
+
+var (
+	snapshotOutputOnce sync.Once
+	snapshotOutput     *os.File
+)
+
+// SnapshotOutput returns where the UI writes its snapshot when it is rendered headless (nil when it is shown on a
+// terminal as usual). The file is opened once: a second *os.File of the descriptor would close it when collected.
+func SnapshotOutput() io.WriteCloser {
+	snapshotOutputOnce.Do(func() {
+		fd, err := strconv.Atoi(os.Getenv(SnapshotFDEnv))
+		if err == nil && fd > 2 {
+			snapshotOutput = os.NewFile(uintptr(fd), "snapshot")
+		}
+	})
+	if snapshotOutput == nil {
+		return nil
+	}
+	return snapshotOutput
+}
+
+// ParseSnapshotSize parses a terminal size given as <columns>x<rows> (e.g. "120x40").
+func ParseSnapshotSize(value string) (int, int, error) {
+	columns, rows, found := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "x")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid size %q (expected <columns>x<rows>, e.g. %s)", value, DefaultSnapshotSize)
+	}
+	width, err := strconv.Atoi(columns)
+	if err != nil || width < 20 || width > 1000 {
+		return 0, 0, fmt.Errorf("invalid size %q (the columns are a number between 20 and 1000)", value)
+	}
+	height, err := strconv.Atoi(rows)
+	if err != nil || height < 10 || height > 1000 {
+		return 0, 0, fmt.Errorf("invalid size %q (the rows are a number between 10 and 1000)", value)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// RenderHeadless runs the command on a new pseudo terminal of the given size, as its controlling terminal (which the UI
+// draws on), and copies the snapshot the command writes to the file descriptor named by SnapshotFDEnv to the writer.
+// What the command draws on the terminal is discarded; its standard error is kept, so that failures are reported.
+func RenderHeadless(ctx context.Context, command []string, width, height int, output io.Writer) error {
+	master, slave, err := openPTY(width, height)
+	if err != nil {
+		return fmt.Errorf("unable to open a pseudo terminal: %v", err)
+	}
+	defer master.Close()
+	go io.Copy(ioutil.Discard, master)
+
+	snapshotReader, snapshotWriter, err := os.Pipe()
+	if err != nil {
+		slave.Close()
+		return err
+	}
+	defer snapshotReader.Close()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	// the snapshot pipe is the first extra file (fd 3)
+	cmd.Env = append(os.Environ(), SnapshotFDEnv+"=3")
+	if os.Getenv("TERM") == "" || os.Getenv("TERM") == "dumb" {
+		cmd.Env = append(cmd.Env, "TERM=xterm-256color")
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, os.Stderr
+	cmd.ExtraFiles = []*os.File{snapshotWriter}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	err = cmd.Start()
+	slave.Close()
+	snapshotWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	var snapshot bytes.Buffer
+	_, copyErr := io.Copy(&snapshot, snapshotReader)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("rendering failed: %v", err)
+	}
+	if copyErr != nil {
+		return copyErr
+	}
+	if snapshot.Len() == 0 {
+		return fmt.Errorf("rendering failed: no snapshot was written")
+	}
+	_, err = output.Write(snapshot.Bytes())
+	return err
+}
+
+// openPTY opens a pseudo terminal of the given size, returning its master and slave ends.
+func openPTY(width, height int) (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	number, err := unix.IoctlGetUint32(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if err := unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Col: uint16(width), Row: uint16(height)}); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderHeadless(t *testing.T) {
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("no pseudo terminals available")
+	}
+
+	// the command sees the pseudo terminal as its terminal, and writes its snapshot to the descriptor it is given
+	var snapshot bytes.Buffer
+	command := []string{"sh", "-c", `test -t 1 && stty size >&$` + SnapshotFDEnv}
+	if err := RenderHeadless(context.Background(), command, 100, 30, &snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := strings.TrimSpace(snapshot.String()); actual != "30 100" {
+		t.Errorf("expected a 100x30 terminal, got %q", actual)
+	}
+
+	if err := RenderHeadless(context.Background(), []string{"true"}, 100, 30, &bytes.Buffer{}); err == nil {
+		t.Errorf("expected an error when no snapshot is written")
+	}
+}
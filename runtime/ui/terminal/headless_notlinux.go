@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// RenderHeadless runs the command on a pseudo terminal to render its UI headless, which is only supported on linux.
+func RenderHeadless(ctx context.Context, command []string, width, height int, output io.Writer) error {
+	return fmt.Errorf("rendering snapshots headless is only supported on linux")
+}
@@ -0,0 +1,30 @@
+package terminal
+
+import (
+	"testing"
+)
+
+func TestParseSnapshotSize(t *testing.T) {
+	cases := []struct {
+		value         string
+		width, height int
+		valid         bool
+	}{
+		{value: "120x40", width: 120, height: 40, valid: true},
+		{value: " 80X24 ", width: 80, height: 24, valid: true},
+		{value: "120", valid: false},
+		{value: "10x40", valid: false},
+		{value: "120x5", valid: false},
+		{value: "wide x tall", valid: false},
+	}
+	for _, test := range cases {
+		width, height, err := ParseSnapshotSize(test.value)
+		if test.valid != (err == nil) {
+			t.Errorf("%q: expected valid=%v, got error %v", test.value, test.valid, err)
+			continue
+		}
+		if test.valid && (width != test.width || height != test.height) {
+			t.Errorf("%q: expected %dx%d, got %dx%d", test.value, test.width, test.height, width, height)
+		}
+	}
+}
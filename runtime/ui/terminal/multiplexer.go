@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"strings"
+)
+
+const (
+	NoMultiplexer Multiplexer = iota
+	Tmux
+	Screen
+)
+
+// screen truncates DCS strings longer than this, so longer sequences are split into several DCS strings
+const screenChunkSize = 76
+
+// Multiplexer is the terminal multiplexer (if any) that dive is running within.
+type Multiplexer int
+
+func (m Multiplexer) String() string {
+	switch m {
+	case Tmux:
+		return "tmux"
+	case Screen:
+		return "screen"
+	default:
+		return "none"
+	}
+}
+
+// DetectMultiplexer determines if dive is running within tmux or GNU screen from the environment.
+func DetectMultiplexer(getenv func(string) string) Multiplexer {
+	switch {
+	case getenv("TMUX") != "":
+		return Tmux
+	case getenv("STY") != "":
+		return Screen
+	}
+
+	// the variables above are not forwarded over ssh, however, TERM is
+	term := getenv("TERM")
+	switch {
+	case strings.HasPrefix(term, "tmux"):
+		return Tmux
+	case strings.HasPrefix(term, "screen"):
+		return Screen
+	}
+	return NoMultiplexer
+}
+
+// Passthrough wraps the given escape sequence such that the multiplexer forwards it to the outer terminal instead of
+// interpreting (and usually discarding) it.
+func (m Multiplexer) Passthrough(sequence string) string {
+	switch m {
+	case Tmux:
+		// tmux requires every escape character within the passthrough to be doubled
+		return "\x1bPtmux;" + strings.Replace(sequence, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	case Screen:
+		var sb strings.Builder
+		for start := 0; start < len(sequence); start += screenChunkSize {
+			stop := start + screenChunkSize
+			if stop > len(sequence) {
+				stop = len(sequence)
+			}
+			sb.WriteString("\x1bP" + sequence[start:stop] + "\x1b\\")
+		}
+		return sb.String()
+	default:
+		return sequence
+	}
+}
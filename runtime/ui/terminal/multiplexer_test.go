@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectMultiplexer(t *testing.T) {
+	cases := []struct {
+		env      map[string]string
+		expected Multiplexer
+	}{
+		{map[string]string{"TMUX": "/tmp/tmux-1000/default,123,0", "TERM": "screen-256color"}, Tmux},
+		{map[string]string{"STY": "123.pts-0.host", "TERM": "screen"}, Screen},
+		{map[string]string{"TERM": "tmux-256color"}, Tmux},
+		{map[string]string{"TERM": "screen.xterm-256color"}, Screen},
+		{map[string]string{"TERM": "xterm-256color"}, NoMultiplexer},
+	}
+
+	for _, test := range cases {
+		actual := DetectMultiplexer(func(key string) string { return test.env[key] })
+		if actual != test.expected {
+			t.Errorf("%v: expected %s, got %s", test.env, test.expected, actual)
+		}
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	sequence := OSC52("/etc/passwd")
+	if sequence != "\x1b]52;c;L2V0Yy9wYXNzd2Q=\a" {
+		t.Fatalf("unexpected OSC52 sequence: %q", sequence)
+	}
+
+	if actual := NoMultiplexer.Passthrough(sequence); actual != sequence {
+		t.Errorf("expected no wrapping, got %q", actual)
+	}
+
+	expected := "\x1bPtmux;\x1b\x1b]52;c;L2V0Yy9wYXNzd2Q=\a\x1b\\"
+	if actual := Tmux.Passthrough(sequence); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	long := OSC52(strings.Repeat("x", 200))
+	wrapped := Screen.Passthrough(long)
+	if chunks := strings.Count(wrapped, "\x1bP"); chunks != (len(long)+screenChunkSize-1)/screenChunkSize {
+		t.Errorf("unexpected number of screen chunks: %d", chunks)
+	}
+	if strings.Replace(strings.Replace(wrapped, "\x1bP", "", -1), "\x1b\\", "", -1) != long {
+		t.Errorf("screen chunks do not reassemble the sequence: %q", wrapped)
+	}
+
+	var buf bytes.Buffer
+	if err := CopyToClipboard(&buf, Tmux, "/etc/passwd"); err != nil {
+		t.Fatalf("unable to copy: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestNativeClipboardCommand(t *testing.T) {
+	installed := map[string]bool{"pbcopy": true, "xsel": true, "wl-copy": true, "clip.exe": true}
+	lookPath := func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", fmt.Errorf("%s not found", name)
+	}
+
+	cases := []struct {
+		goos     string
+		env      map[string]string
+		expected []string
+	}{
+		{"darwin", map[string]string{}, []string{"pbcopy"}},
+		{"darwin", map[string]string{"SSH_TTY": "/dev/pts/1"}, nil},
+		{"linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, []string{"wl-copy"}},
+		// xclip is not installed
+		{"linux", map[string]string{"DISPLAY": ":0"}, []string{"xsel", "--clipboard", "--input"}},
+		{"linux", map[string]string{"DISPLAY": ":0", "SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"}, nil},
+		{"linux", map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, []string{"clip.exe"}},
+		{"linux", map[string]string{}, nil},
+		{"windows", map[string]string{}, nil},
+	}
+
+	for _, test := range cases {
+		actual := NativeClipboardCommand(test.goos, func(key string) string { return test.env[key] }, lookPath)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s %v: expected %v, got %v", test.goos, test.env, test.expected, actual)
+		}
+	}
+}
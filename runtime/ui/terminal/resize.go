@@ -0,0 +1,28 @@
+package terminal
+
+import (
+	"github.com/awesome-gocui/gocui"
+	"github.com/awesome-gocui/termbox-go"
+)
+
+// SyncOnResize wraps the given layout function such that the whole screen is repainted after the screen size changes.
+// Multiplexers redraw their pane borders and status lines when panes are resized (or zoomed), which can leave stale
+// cells behind that an incremental flush never overwrites.
+func SyncOnResize(layout func(*gocui.Gui) error) func(*gocui.Gui) error {
+	var lastX, lastY int
+	return func(g *gocui.Gui) error {
+		maxX, maxY := g.Size()
+		if err := layout(g); err != nil {
+			return err
+		}
+
+		resized := (lastX != 0 || lastY != 0) && (maxX != lastX || maxY != lastY)
+		lastX, lastY = maxX, maxY
+		if resized {
+			g.Update(func(*gocui.Gui) error {
+				return termbox.Sync()
+			})
+		}
+		return nil
+	}
+}
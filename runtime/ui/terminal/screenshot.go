@@ -0,0 +1,369 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/awesome-gocui/termbox-go"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// the formats a screenshot can be written in
+const (
+	ScreenshotANSI = "ansi"
+	ScreenshotSVG  = "svg"
+	ScreenshotPNG  = "png"
+	// the characters alone, without colors or styles (e.g. for golden files)
+	ScreenshotText = "text"
+)
+
+// ScreenshotFormats lists the formats a screenshot can be written in.
+var ScreenshotFormats = []string{ScreenshotANSI, ScreenshotSVG, ScreenshotPNG, ScreenshotText}
+
+// DefaultColor is the color of a cell drawn with the default colors of the terminal.
+const DefaultColor = -1
+
+const (
+	// the point size screenshots are rendered at as PNG
+	screenshotFontSize = 14
+	// the colors standing for the default colors of the terminal in SVG and PNG screenshots
+	screenshotForeground = 0xd0d0d0
+	screenshotBackground = 0x1c1c1c
+)
+
+// CaptureCell is a character on the screen, with its colors as indexes of the 256 color palette (or DefaultColor).
+type CaptureCell struct {
+	Rune      rune
+	Fg        int
+	Bg        int
+	Bold      bool
+	Underline bool
+	Reverse   bool
+}
+
+// Capture is what the terminal showed, row by row.
+type Capture struct {
+	Width  int
+	Height int
+	Cells  []CaptureCell
+}
+
+// Cell returns the cell at the given column and row.
+func (screen *Capture) Cell(x, y int) CaptureCell {
+	return screen.Cells[y*screen.Width+x]
+}
+
+// CaptureScreen copies what the UI last drew (the termbox back buffer, which holds the screen between flushes).
+func CaptureScreen() *Capture {
+	width, height := termbox.Size()
+	buffer := termbox.CellBuffer()
+	screen := &Capture{Width: width, Height: height, Cells: make([]CaptureCell, width*height)}
+	for idx := range screen.Cells {
+		if idx >= len(buffer) {
+			screen.Cells[idx] = CaptureCell{Rune: ' ', Fg: DefaultColor, Bg: DefaultColor}
+			continue
+		}
+		cell := buffer[idx]
+		screen.Cells[idx] = CaptureCell{
+			Rune:      cell.Ch,
+			Fg:        attributeColor(cell.Fg),
+			Bg:        attributeColor(cell.Bg),
+			Bold:      cell.Fg&termbox.AttrBold != 0,
+			Underline: cell.Fg&termbox.AttrUnderline != 0,
+			Reverse:   cell.Fg&termbox.AttrReverse != 0 || cell.Bg&termbox.AttrReverse != 0,
+		}
+	}
+	return screen
+}
+
+// attributeColor returns the palette index of a termbox color (in both the 8 and 256 color output modes, colors are
+// the palette index plus one, zero being the default color).
+func attributeColor(attribute termbox.Attribute) int {
+	value := int(attribute & 0x1ff)
+	if value == 0 {
+		return DefaultColor
+	}
+	return value - 1
+}
+
+// WriteScreenshot writes the screen in the given format.
+func WriteScreenshot(writer io.Writer, screen *Capture, format string) error {
+	switch format {
+	case ScreenshotANSI:
+		return screen.WriteANSI(writer)
+	case ScreenshotSVG:
+		return screen.WriteSVG(writer)
+	case ScreenshotPNG:
+		return screen.WritePNG(writer)
+	case ScreenshotText:
+		return screen.WriteText(writer)
+	}
+	return fmt.Errorf("unknown screenshot format %q (expected %s)", format, strings.Join(ScreenshotFormats, ", "))
+}
+
+// ScreenshotExtension returns the file extension of screenshots in the given format.
+func ScreenshotExtension(format string) string {
+	switch format {
+	case ScreenshotANSI:
+		return "ans"
+	case ScreenshotText:
+		return "txt"
+	}
+	return format
+}
+
+// ScreenshotFormatOf returns the format the extension of the path names (see ScreenshotExtension), or the given format
+// when it names none.
+func ScreenshotFormatOf(path, fallback string) string {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	for _, format := range ScreenshotFormats {
+		if extension == format || extension == ScreenshotExtension(format) {
+			return format
+		}
+	}
+	return fallback
+}
+
+// colors returns the foreground and background colors of the cell as displayed (reverse video swaps them).
+func (cell CaptureCell) colors() (color.RGBA, color.RGBA) {
+	fg, bg := indexColor(cell.Fg, screenshotForeground), indexColor(cell.Bg, screenshotBackground)
+	if cell.Reverse {
+		return bg, fg
+	}
+	return fg, bg
+}
+
+// indexColor returns the color of a palette index, or the given color for DefaultColor.
+func indexColor(index int, defaultColor uint32) color.RGBA {
+	if index == DefaultColor {
+		return hexColor(defaultColor)
+	}
+	return paletteColor(index)
+}
+
+// the first 16 colors of the xterm palette
+var systemColors = [16]uint32{
+	0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+	0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+// paletteColor returns the color of an index of the xterm 256 color palette.
+func paletteColor(index int) color.RGBA {
+	switch {
+	case index < 16:
+		return hexColor(systemColors[index&0xf])
+	case index < 232:
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		index -= 16
+		return color.RGBA{R: levels[index/36], G: levels[index/6%6], B: levels[index%6], A: 0xff}
+	}
+	gray := uint8(8 + 10*(index-232))
+	return color.RGBA{R: gray, G: gray, B: gray, A: 0xff}
+}
+
+func hexColor(value uint32) color.RGBA {
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}
+}
+
+// WriteANSI writes the screen as text with ANSI escape codes, which shows as it did when printed to a terminal.
+func (screen *Capture) WriteANSI(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+	for y := 0; y < screen.Height; y++ {
+		// trailing blanks in the default colors are dropped
+		end := screen.Width
+		for end > 0 {
+			cell := screen.Cell(end-1, y)
+			if (cell.Rune != ' ' && cell.Rune != 0) || cell.Bg != DefaultColor || cell.Reverse || cell.Underline {
+				break
+			}
+			end--
+		}
+
+		var previous *CaptureCell
+		for x := 0; x < end; x++ {
+			cell := screen.Cell(x, y)
+			if previous == nil || !sameStyle(*previous, cell) {
+				buffered.WriteString(sgr(cell))
+			}
+			previous = &cell
+			buffered.WriteRune(printableRune(cell.Rune))
+		}
+		if previous != nil {
+			buffered.WriteString("\x1b[0m")
+		}
+		buffered.WriteString("\n")
+	}
+	return buffered.Flush()
+}
+
+// WriteText writes the characters of the screen, row by row, without trailing blanks.
+func (screen *Capture) WriteText(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+	for y := 0; y < screen.Height; y++ {
+		var row strings.Builder
+		for x := 0; x < screen.Width; x++ {
+			row.WriteRune(printableRune(screen.Cell(x, y).Rune))
+		}
+		buffered.WriteString(strings.TrimRight(row.String(), " "))
+		buffered.WriteString("\n")
+	}
+	return buffered.Flush()
+}
+
+// sgr returns the escape code that resets the style and applies the one of the cell.
+func sgr(cell CaptureCell) string {
+	codes := []string{"0"}
+	if cell.Bold {
+		codes = append(codes, "1")
+	}
+	if cell.Underline {
+		codes = append(codes, "4")
+	}
+	if cell.Reverse {
+		codes = append(codes, "7")
+	}
+	if cell.Fg != DefaultColor {
+		codes = append(codes, fmt.Sprintf("38;5;%d", cell.Fg))
+	}
+	if cell.Bg != DefaultColor {
+		codes = append(codes, fmt.Sprintf("48;5;%d", cell.Bg))
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+func sameStyle(a, b CaptureCell) bool {
+	a.Rune, b.Rune = 0, 0
+	return a == b
+}
+
+func printableRune(r rune) rune {
+	if r < ' ' {
+		return ' '
+	}
+	return r
+}
+
+// WriteSVG writes the screen as an SVG image, with the text kept as text (so that it can be searched and copied).
+func (screen *Capture) WriteSVG(writer io.Writer) error {
+	// a monospace font advances by 0.6em, and the text sits on a baseline below the ascent of the font
+	const fontSize, cellWidth, cellHeight, baseline = 15, 9, 18, 14
+
+	buffered := bufio.NewWriter(writer)
+	width, height := screen.Width*cellWidth, screen.Height*cellHeight
+	fmt.Fprintf(buffered, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(buffered, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", svgColor(hexColor(screenshotBackground)))
+	fmt.Fprintf(buffered, "<g font-family=\"ui-monospace, Menlo, Consolas, 'DejaVu Sans Mono', monospace\" font-size=\"%d\" xml:space=\"preserve\">\n", fontSize)
+
+	for y := 0; y < screen.Height; y++ {
+		// each run of cells in the same style is drawn at once
+		for start := 0; start < screen.Width; {
+			end := start + 1
+			for end < screen.Width && sameStyle(screen.Cell(start, y), screen.Cell(end, y)) {
+				end++
+			}
+			cell := screen.Cell(start, y)
+			fg, bg := cell.colors()
+			x := start * cellWidth
+			runWidth := (end - start) * cellWidth
+			if bg != hexColor(screenshotBackground) {
+				fmt.Fprintf(buffered, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x, y*cellHeight, runWidth, cellHeight, svgColor(bg))
+			}
+
+			var text strings.Builder
+			for idx := start; idx < end; idx++ {
+				text.WriteRune(printableRune(screen.Cell(idx, y).Rune))
+			}
+			if strings.TrimSpace(text.String()) != "" || cell.Underline {
+				attributes := fmt.Sprintf(" fill=\"%s\"", svgColor(fg))
+				if cell.Bold {
+					attributes += " font-weight=\"bold\""
+				}
+				if cell.Underline {
+					attributes += " text-decoration=\"underline\""
+				}
+				fmt.Fprintf(buffered, "<text x=\"%d\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\"%s>%s</text>\n",
+					x, y*cellHeight+baseline, runWidth, attributes, svgEscape(text.String()))
+			}
+			start = end
+		}
+	}
+	fmt.Fprintf(buffered, "</g>\n</svg>\n")
+	return buffered.Flush()
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+var svgEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+func svgEscape(text string) string {
+	return svgEscaper.Replace(text)
+}
+
+// WritePNG renders the screen offscreen (with the Go Mono font) and writes it as a PNG image.
+func (screen *Capture) WritePNG(writer io.Writer) error {
+	regular, err := screenshotFace(gomono.TTF)
+	if err != nil {
+		return err
+	}
+	defer regular.Close()
+	bold, err := screenshotFace(gomonobold.TTF)
+	if err != nil {
+		return err
+	}
+	defer bold.Close()
+
+	metrics := regular.Metrics()
+	advance, _ := regular.GlyphAdvance('M')
+	cellWidth, cellHeight := advance.Ceil(), metrics.Height.Ceil()
+	ascent := metrics.Ascent.Ceil()
+
+	canvas := image.NewRGBA(image.Rect(0, 0, screen.Width*cellWidth, screen.Height*cellHeight))
+	background := hexColor(screenshotBackground)
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	for y := 0; y < screen.Height; y++ {
+		for x := 0; x < screen.Width; x++ {
+			cell := screen.Cell(x, y)
+			fg, bg := cell.colors()
+			bounds := image.Rect(x*cellWidth, y*cellHeight, (x+1)*cellWidth, (y+1)*cellHeight)
+			if bg != background {
+				draw.Draw(canvas, bounds, image.NewUniform(bg), image.Point{}, draw.Src)
+			}
+			foreground := image.NewUniform(fg)
+			if cell.Underline {
+				draw.Draw(canvas, image.Rect(bounds.Min.X, bounds.Max.Y-2, bounds.Max.X, bounds.Max.Y-1), foreground, image.Point{}, draw.Src)
+			}
+			if cell.Rune == ' ' || cell.Rune == 0 {
+				continue
+			}
+			face := regular
+			if cell.Bold {
+				face = bold
+			}
+			drawer := &font.Drawer{Dst: canvas, Src: foreground, Face: face, Dot: fixed.P(bounds.Min.X, bounds.Min.Y+ascent)}
+			drawer.DrawString(string(cell.Rune))
+		}
+	}
+	return png.Encode(writer, canvas)
+}
+
+func screenshotFace(ttf []byte) (font.Face, error) {
+	parsed, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(parsed, &opentype.FaceOptions{Size: screenshotFontSize, DPI: 72, Hinting: font.HintingFull})
+}
@@ -0,0 +1,127 @@
+package terminal
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// testCapture is a 6x2 screen: "ok" in bold green, then "<a>" in reverse video, with a blank second row.
+func testCapture() *Capture {
+	capture := &Capture{Width: 6, Height: 2, Cells: make([]CaptureCell, 12)}
+	for idx := range capture.Cells {
+		capture.Cells[idx] = CaptureCell{Rune: ' ', Fg: DefaultColor, Bg: DefaultColor}
+	}
+	for idx, r := range "ok" {
+		capture.Cells[idx] = CaptureCell{Rune: r, Fg: 2, Bg: DefaultColor, Bold: true}
+	}
+	for idx, r := range "<a>" {
+		capture.Cells[3+idx] = CaptureCell{Rune: r, Fg: DefaultColor, Bg: DefaultColor, Reverse: true}
+	}
+	return capture
+}
+
+func TestCaptureWriteANSI(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := testCapture().WriteANSI(&buffer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "\x1b[0;1;38;5;2mok\x1b[0m \x1b[0;7m<a>\x1b[0m\n\n"
+	if buffer.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestCaptureWriteText(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteScreenshot(&buffer, testCapture(), ScreenshotText); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "ok <a>\n\n"
+	if buffer.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestCaptureWriteSVG(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := testCapture().WriteSVG(&buffer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svg := buffer.String()
+	for _, expected := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="54" height="36"`,
+		`fill="#00cd00" font-weight="bold">ok</text>`,
+		// reverse video swaps the default colors
+		`<rect x="27" y="0" width="27" height="18" fill="#d0d0d0"/>`,
+		`fill="#1c1c1c">&lt;a&gt;</text>`,
+	} {
+		if !strings.Contains(svg, expected) {
+			t.Errorf("expected the svg to contain %q:\n%s", expected, svg)
+		}
+	}
+}
+
+func TestCaptureWritePNG(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteScreenshot(&buffer, testCapture(), ScreenshotPNG); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("unable to decode the png: %v", err)
+	}
+	bounds := decoded.Bounds()
+	if bounds.Dx()%6 != 0 || bounds.Dy()%2 != 0 || bounds.Dx() == 0 {
+		t.Fatalf("expected a size fitting 6x2 cells, got %v", bounds)
+	}
+	cellWidth, cellHeight := bounds.Dx()/6, bounds.Dy()/2
+
+	background := color.RGBAModel.Convert(decoded.At(bounds.Dx()-1, bounds.Dy()-1))
+	if background != (color.RGBA{R: 0x1c, G: 0x1c, B: 0x1c, A: 0xff}) {
+		t.Errorf("expected the default background, got %v", background)
+	}
+	// the corner of a reverse video cell holds no glyph, only the swapped background
+	reversed := color.RGBAModel.Convert(decoded.At(3*cellWidth, cellHeight-1))
+	if reversed != (color.RGBA{R: 0xd0, G: 0xd0, B: 0xd0, A: 0xff}) {
+		t.Errorf("expected the default foreground as the background of reverse video, got %v", reversed)
+	}
+
+	var green bool
+	for x := 0; x < 2*cellWidth && !green; x++ {
+		for y := 0; y < cellHeight; y++ {
+			if r, g, b, _ := decoded.At(x, y).RGBA(); g > r && g > b {
+				green = true
+				break
+			}
+		}
+	}
+	if !green {
+		t.Errorf("expected the green text to be drawn")
+	}
+}
+
+func TestScreenshotFormatOf(t *testing.T) {
+	cases := map[string]string{
+		"ui.txt":     ScreenshotText,
+		"ui.ans":     ScreenshotANSI,
+		"ui.ansi":    ScreenshotANSI,
+		"out/ui.SVG": ScreenshotSVG,
+		"ui.png":     ScreenshotPNG,
+		"ui.golden":  ScreenshotText,
+		"ui":         ScreenshotText,
+	}
+	for path, expected := range cases {
+		if actual := ScreenshotFormatOf(path, ScreenshotText); actual != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, actual)
+		}
+	}
+}
+
+func TestWriteScreenshotUnknownFormat(t *testing.T) {
+	if err := WriteScreenshot(&bytes.Buffer{}, testCapture(), "gif"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/awesome-gocui/termbox-go"
+)
+
+// PagerCommand is the command that shows a file, from $PAGER (falling back to less).
+func PagerCommand(getenv func(string) string) []string {
+	return commandFromEnv(getenv, []string{"PAGER"}, "less")
+}
+
+// EditorCommand is the command that edits a file, from $VISUAL or $EDITOR (falling back to vi).
+func EditorCommand(getenv func(string) string) []string {
+	return commandFromEnv(getenv, []string{"VISUAL", "EDITOR"}, "vi")
+}
+
+// commandFromEnv splits the first of the given variables that is set into a command (with its arguments).
+func commandFromEnv(getenv func(string) string, variables []string, fallback string) []string {
+	for _, variable := range variables {
+		if command := strings.Fields(getenv(variable)); len(command) > 0 {
+			return command
+		}
+	}
+	return []string{fallback}
+}
+
+// RunSuspended hands the terminal over to the given command (e.g. a pager) until it exits, then takes the terminal
+// back and repaints the UI.
+func RunSuspended(g *gocui.Gui, command []string, args ...string) error {
+	outputMode := termbox.SetOutputMode(termbox.OutputCurrent)
+	termbox.Close()
+
+	external := exec.Command(command[0], append(command[1:], args...)...)
+	external.Stdin = os.Stdin
+	external.Stdout = os.Stdout
+	external.Stderr = os.Stderr
+	runErr := external.Run()
+
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	termbox.SetOutputMode(outputMode)
+	inputMode := termbox.InputEsc
+	if g.Mouse {
+		inputMode |= termbox.InputMouse
+	}
+	termbox.SetInputMode(inputMode)
+
+	// the next flush only draws the cells that changed, so the whole screen is repainted
+	g.Update(func(*gocui.Gui) error {
+		return termbox.Sync()
+	})
+	return runErr
+}
@@ -0,0 +1,413 @@
+package view
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/lunixbochs/vtclean"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// the margin kept around the archive popup
+const archiveMargin = 2
+
+// the lines above the archive contents (the summary, a blank line and the attribute header)
+const archiveHeaderLines = 3
+
+// ArchiveOpener opens the contents of the archive to browse.
+type ArchiveOpener func() (io.ReadCloser, error)
+
+// archiveLevel is an archive being browsed, along with the selected row.
+type archiveLevel struct {
+	archive *image.NestedArchive
+	rows    *filetree.TreeRows
+	cursor  int
+	top     int
+}
+
+// Archive holds the UI objects and data models for populating the popup that browses the contents of an archive file
+// (a tarball, a zip file or a jar) within the image. Archives nested within the archive can be entered in turn.
+type Archive struct {
+	popup
+
+	name    string
+	gui     *gocui.Gui
+	view    *gocui.View
+	path    string
+	levels  []*archiveLevel
+	message string
+	// how the files of the archives are shown
+	render filetree.RenderOptions
+}
+
+// newArchiveView creates a new view object attached the the global [gocui] screen object.
+func newArchiveView(gui *gocui.Gui, render filetree.RenderOptions) (controller *Archive) {
+	controller = new(Archive)
+
+	// populate main fields
+	controller.name = "archive"
+	controller.gui = gui
+	controller.hidden = true
+	controller.render = render
+
+	return controller
+}
+
+func (v *Archive) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Archive) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.back,
+		},
+		{
+			Key:      gocui.KeyBackspace2,
+			Modifier: gocui.ModNone,
+			OnAction: v.back,
+		},
+		{
+			Key:      gocui.KeyArrowLeft,
+			Modifier: gocui.ModNone,
+			OnAction: v.back,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.enter,
+		},
+		{
+			Key:      gocui.KeyArrowRight,
+			Modifier: gocui.ModNone,
+			OnAction: v.enter,
+		},
+		{
+			Key:      gocui.KeySpace,
+			Modifier: gocui.ModNone,
+			OnAction: v.toggleCollapse,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.moveCursor(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.moveCursor(-1) },
+		},
+		{
+			Key:      gocui.KeyPgdn,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.moveCursor(v.height()) },
+		},
+		{
+			Key:      gocui.KeyPgup,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.moveCursor(-v.height()) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show reads the archive at the given path and opens the popup (taking focus). Archives that cannot be read show the
+// reason instead.
+func (v *Archive) Show(filePath string, open ArchiveOpener) error {
+	v.path = filePath
+	v.levels = nil
+	v.message = ""
+	if level, err := openArchiveLevel(filePath, open, v.render); err != nil {
+		v.message = fmt.Sprintf("Unable to browse the archive: %v", err)
+	} else {
+		v.levels = append(v.levels, level)
+	}
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// openArchiveLevel reads the archive to browse it, showing its files with the given options.
+func openArchiveLevel(filePath string, open ArchiveOpener, render filetree.RenderOptions) (*archiveLevel, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the file: %v", err)
+	}
+	defer reader.Close()
+
+	archive, err := image.ReadNestedArchive(filePath, reader)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveLevel{archive: archive, rows: visibleRows(archive.Tree, render)}, nil
+}
+
+// visibleRows indexes the visible files of the tree, drawn with the given options.
+func visibleRows(tree *filetree.FileTree, render filetree.RenderOptions) *filetree.TreeRows {
+	rows := tree.VisibleRows()
+	rows.SetRenderOptions(render)
+	return rows
+}
+
+// current returns the archive being browsed (nil when the archive could not be read).
+func (v *Archive) current() *archiveLevel {
+	if len(v.levels) == 0 {
+		return nil
+	}
+	return v.levels[len(v.levels)-1]
+}
+
+// selected returns the selected file within the archive being browsed.
+func (v *Archive) selected() *filetree.FileNode {
+	level := v.current()
+	if level == nil {
+		return nil
+	}
+	return level.rows.Node(level.cursor)
+}
+
+// enter expands (or collapses) the selected directory, or browses the selected archive within the archive.
+func (v *Archive) enter() error {
+	node := v.selected()
+	if node == nil {
+		return nil
+	}
+	if len(node.Children) > 0 {
+		return v.toggleCollapse()
+	}
+	if !image.IsNestedArchive(node.Name) {
+		return nil
+	}
+
+	parent := v.current().archive
+	level, err := openArchiveLevel(node.Path(), func() (io.ReadCloser, error) {
+		return parent.OpenFile(node.Path())
+	}, v.render)
+	if err != nil {
+		// the reason is shown instead of the contents until going back
+		logrus.Warnf("unable to browse %s within %s: %+v", node.Path(), parent.Name, err)
+		v.message = fmt.Sprintf("Unable to browse the archive: %v", err)
+		return v.Render()
+	}
+	v.message = ""
+	v.levels = append(v.levels, level)
+	return v.Render()
+}
+
+// back returns to the archive the current one is nested in, closing the popup from the outermost archive.
+func (v *Archive) back() error {
+	if v.message != "" && len(v.levels) > 0 {
+		// dismiss the reason a nested archive could not be read
+		v.message = ""
+		return v.Render()
+	}
+	if len(v.levels) <= 1 {
+		return v.Close()
+	}
+	v.levels = v.levels[:len(v.levels)-1]
+	return v.Render()
+}
+
+func (v *Archive) toggleCollapse() error {
+	node := v.selected()
+	if node == nil || len(node.Children) == 0 {
+		return nil
+	}
+	node.Data.ViewInfo.Collapsed = !node.Data.ViewInfo.Collapsed
+	level := v.current()
+	level.rows = visibleRows(level.archive.Tree, v.render)
+	return v.Render()
+}
+
+// moveCursor moves the selection by the given number of rows, scrolling to keep it within the popup.
+func (v *Archive) moveCursor(delta int) error {
+	level := v.current()
+	if level == nil || level.rows.Len() == 0 {
+		return nil
+	}
+	level.cursor += delta
+	if level.cursor >= level.rows.Len() {
+		level.cursor = level.rows.Len() - 1
+	}
+	if level.cursor < 0 {
+		level.cursor = 0
+	}
+	return v.Render()
+}
+
+// height is the number of rows of the archive contents shown at once.
+func (v *Archive) height() int {
+	if v.view == nil {
+		return 1
+	}
+	_, height := v.view.Size()
+	if height -= archiveHeaderLines; height < 1 {
+		return 1
+	}
+	return height
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Archive) Close() error {
+	v.levels = nil
+	return v.closeView(v.view)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Archive) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Archive) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Archive) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// title names the archives being browsed, from the outermost one.
+func (v *Archive) title() string {
+	names := []string{v.path}
+	for idx, level := range v.levels {
+		if idx > 0 {
+			names = append(names, strings.TrimPrefix(level.archive.Name, "/"))
+		}
+	}
+	return " Archive: " + strings.Join(names, " > ") + " "
+}
+
+// summary describes the archive being browsed, including how much larger it is once extracted.
+func (v *Archive) summary() string {
+	archive := v.current().archive
+	summary := fmt.Sprintf("%s, %d files, %s extracted from %s", archive.Format, archive.Entries, humanize.Bytes(archive.ExtractedBytes), humanize.Bytes(archive.SizeBytes))
+	if archive.SizeBytes > 0 && archive.ExtractedBytes > archive.SizeBytes {
+		summary += fmt.Sprintf(" (%.1fx)", float64(archive.ExtractedBytes)/float64(archive.SizeBytes))
+	}
+	return summary
+}
+
+// lines renders the summary and the visible window of the archive contents, with the selected row highlighted.
+func (v *Archive) lines() []string {
+	level := v.current()
+	if level == nil || v.message != "" {
+		return []string{v.message}
+	}
+
+	height := v.height()
+	if level.cursor < level.top {
+		level.top = level.cursor
+	}
+	if level.cursor >= level.top+height {
+		level.top = level.cursor - height + 1
+	}
+
+	lines := []string{
+		v.summary(),
+		"",
+		format.Header(fmt.Sprintf(filetree.AttributeFormat+" %s", "P", "ermission", "UID:GID", "Size", "Filetree")),
+	}
+	if level.rows.Len() == 0 {
+		return append(lines, "The archive is empty")
+	}
+	rows := strings.Split(strings.TrimSuffix(level.rows.StringBetween(level.top, level.top+height-1, true), "\n"), "\n")
+	for idx, row := range rows {
+		if level.top+idx == level.cursor {
+			row = format.Selected(vtclean.Clean(row, false))
+		} else if node := level.rows.Node(level.top + idx); node != nil && len(node.Children) == 0 && image.IsNestedArchive(node.Name) {
+			row += " " + format.Header("(enter to browse)")
+		}
+		lines = append(lines, row)
+	}
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *Archive) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = v.title()
+		v.view.Subtitle = " Press esc to go back "
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout fills most of the screen with the popup.
+func (v *Archive) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	x0, y0 := minX+archiveMargin, minY+archiveMargin
+	x1, y1 := maxX-archiveMargin, maxY-archiveMargin
+	if x1 <= x0 || y1 <= y0 {
+		x0, y0, x1, y1 = minX, minY, minX+1, minY+1
+	}
+	view, viewErr := g.SetView(v.Name(), x0, y0, x1, y1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup archive controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Archive) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,179 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the attestations pane takes from the layer details column (the rest can be scrolled to)
+const maxAttestationsHeight = 4
+
+// the manifest annotations listed in the attestations pane, as they tell where the image was built from
+var attestationAnnotations = []struct {
+	key   string
+	label string
+}{
+	{key: "org.opencontainers.image.source", label: "Source"},
+	{key: "org.opencontainers.image.revision", label: "Revision"},
+	{key: "org.opencontainers.image.created", label: "Created"},
+}
+
+// Attestations holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane
+// that summarizes the artifacts attached to the image in its registry (SLSA provenance, SBOMs, signatures) along with
+// the annotations of its manifest (it is only shown for images read from a registry).
+type Attestations struct {
+	name         string
+	gui          *gocui.Gui
+	symbols      format.Symbols
+	view         *gocui.View
+	header       *gocui.View
+	damage       damage
+	attestations *image.Attestations
+}
+
+// newAttestationsView creates a new view object attached the the global [gocui] screen object.
+func newAttestationsView(gui *gocui.Gui, attestations *image.Attestations, symbols format.Symbols) (controller *Attestations) {
+	controller = new(Attestations)
+
+	// populate main fields
+	controller.name = "attestations"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.attestations = attestations
+
+	return controller
+}
+
+func (v *Attestations) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Attestations) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = true
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// IsVisible indicates if the attestations pane is shown (only when the image was read from a registry).
+func (v *Attestations) IsVisible() bool {
+	return v != nil && v.attestations != nil
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Attestations) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if lines := len(v.lines()); lines < maxAttestationsHeight {
+		return lines
+	}
+	return maxAttestationsHeight
+}
+
+// lines renders a row per attached artifact, followed by the notable manifest annotations.
+func (v *Attestations) lines() []string {
+	var lines []string
+	for _, artifact := range v.attestations.Artifacts {
+		detail := artifact.PredicateType
+		if detail == "" {
+			detail = artifact.ArtifactType
+		}
+		line := format.Header(artifact.Kind+":") + " " + detail
+		if artifact.Size > 0 {
+			line += " " + humanize.Bytes(artifact.Size)
+		}
+		lines = append(lines, line+" ("+artifact.Source+")")
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No provenance, SBOM or signature attached")
+	}
+	for _, annotation := range attestationAnnotations {
+		if value := strings.TrimSpace(v.attestations.Annotations[annotation.key]); value != "" {
+			lines = append(lines, format.Header(annotation.label+":")+" "+value)
+		}
+	}
+	return lines
+}
+
+// summary counts the attached artifacts by kind (e.g. "1 provenance, 2 sbom").
+func (v *Attestations) summary() string {
+	var counts []string
+	for _, kind := range []string{image.AttestationProvenance, image.AttestationSBOM, image.AttestationSignature, image.AttestationVEX, image.AttestationOther} {
+		if count := v.attestations.Count(kind); count > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count, kind))
+		}
+	}
+	if len(counts) == 0 {
+		return "none"
+	}
+	return strings.Join(counts, ", ")
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Attestations) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Attestations) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Attestations) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil {
+		return nil
+	}
+	if !v.damage.changed(sizeOf(v.view.Size())) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(fmt.Sprintf("Attestations (%s)", v.summary()), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
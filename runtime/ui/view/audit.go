@@ -0,0 +1,141 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the audit pane takes from the layer details column (the rest can be scrolled to)
+const maxAuditHeight = 6
+
+// Audit holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane that
+// lists the permission and ownership findings of the final image (it is only shown in audit mode).
+type Audit struct {
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	header  *gocui.View
+	damage  damage
+	audit   *image.Audit
+	enabled bool
+}
+
+// newAuditView creates a new view object attached the the global [gocui] screen object.
+func newAuditView(gui *gocui.Gui, audit *image.Audit, enabled bool, symbols format.Symbols) (controller *Audit) {
+	controller = new(Audit)
+
+	// populate main fields
+	controller.name = "audit"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.audit = audit
+	controller.enabled = enabled
+
+	return controller
+}
+
+func (v *Audit) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Audit) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// IsVisible indicates if the audit pane is shown (only in audit mode, and when the audit is available).
+func (v *Audit) IsVisible() bool {
+	return v != nil && v.enabled && v.audit != nil
+}
+
+// lines renders a line per finding (or a single line when there are none).
+func (v *Audit) lines() []string {
+	if len(v.audit.Findings) == 0 {
+		return []string{"No setuid, world-writable, root owned or capability findings"}
+	}
+	lines := make([]string, 0, len(v.audit.Findings))
+	for _, finding := range v.audit.Findings {
+		lines = append(lines, fmt.Sprintf("%s %s (%s, layer %d)", format.Header(finding.Kind+":"), finding.Path, finding.Detail, finding.Layer))
+	}
+	return lines
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Audit) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if lines := len(v.lines()); lines < maxAuditHeight {
+		return lines
+	}
+	return maxAuditHeight
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Audit) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Audit) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Audit) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil || !v.IsVisible() {
+		return nil
+	}
+	if !v.damage.changed(sizeOf(v.view.Size())) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(fmt.Sprintf("Audit (%d)", len(v.audit.Findings)), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,37 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const (
+	breadcrumbSeparator = " › "
+	breadcrumbElided    = "…"
+)
+
+// breadcrumb renders the given path as its directories from the root (e.g. "/ › etc › ssl"), fitted within the given
+// width (in terminal cells) by eliding the outermost directories first.
+func breadcrumb(path string, width int) string {
+	crumbs := []string{"/"}
+	for _, name := range strings.Split(path, "/") {
+		if name != "" {
+			crumbs = append(crumbs, filetree.DisplayName(name))
+		}
+	}
+
+	line := " " + strings.Join(crumbs, breadcrumbSeparator)
+	for first := 1; filetree.DisplayWidth(line) > width && first < len(crumbs); first++ {
+		line = " " + strings.Join(append([]string{breadcrumbElided}, crumbs[first:]...), breadcrumbSeparator)
+	}
+	if filetree.DisplayWidth(line) > width && width > 0 {
+		// the selected name alone does not fit: keep its end
+		runes := []rune(line)
+		for len(runes) > 0 && filetree.DisplayWidth(string(runes))+filetree.DisplayWidth(breadcrumbElided) > width {
+			runes = runes[1:]
+		}
+		line = breadcrumbElided + string(runes)
+	}
+	return line
+}
@@ -0,0 +1,24 @@
+package view
+
+import "testing"
+
+func TestBreadcrumb(t *testing.T) {
+	cases := []struct {
+		path     string
+		width    int
+		expected string
+	}{
+		{path: "", width: 40, expected: " /"},
+		{path: "/etc/ssl/certs", width: 40, expected: " / › etc › ssl › certs"},
+		{path: "/etc/ssl/certs", width: 16, expected: " … › ssl › certs"},
+		{path: "/etc/ssl/certs", width: 12, expected: " … › certs"},
+		{path: "/etc/ssl/certificates", width: 8, expected: "…ficates"},
+		// wide characters take two cells each
+		{path: "/データ/ファイル", width: 12, expected: "… › ファイル"},
+	}
+	for _, c := range cases {
+		if actual := breadcrumb(c.path, c.width); actual != c.expected {
+			t.Errorf("%q (width %d): expected %q, got %q", c.path, c.width, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+const (
+	// the lines above the rows (the summary and the column header)
+	compareHeaderLines = 2
+	// the width of the size shown after each entry (e.g. "1.2 MB")
+	compareSizeWidth = 8
+)
+
+// Compare holds the UI objects for the popup that lays out two file trees side by side (two images, or two layers of an
+// image). Each row shows the same path on both sides, so that a single cursor and scroll position keep both sides in
+// step.
+type Compare struct {
+	popup
+
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	left    string
+	right   string
+	rows    []filetree.SideBySideRow
+	// the rows shown (the rows that differ when the unchanged rows are hidden)
+	shown []filetree.SideBySideRow
+	// the trees are being compared in the background
+	loading bool
+	// counts the comparisons shown, so that a comparison computed for a popup closed since then is dropped
+	generation    int
+	hideUnchanged bool
+	cursor        int
+	top           int
+
+	// how the names are shortened to fit the columns, and colored by their differences
+	render filetree.RenderOptions
+
+	helpKeys []*key.Binding
+}
+
+// newCompareView creates a new view object attached the the global [gocui] screen object.
+func newCompareView(gui *gocui.Gui, render filetree.RenderOptions, symbols format.Symbols) (controller *Compare) {
+	controller = new(Compare)
+
+	// populate main fields
+	controller.name = "compare"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.hidden = true
+	controller.render = render
+
+	return controller
+}
+
+func (v *Compare) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Compare) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			ConfigKeys: []string{"keybinding.toggle-unchanged-rows"},
+			OnAction:   v.toggleUnchanged,
+			IsSelected: func() bool { return !v.hideUnchanged },
+			Display:    "Unchanged",
+		},
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(-1) },
+		},
+		{
+			ConfigKeys: []string{"keybinding.page-down"},
+			OnAction:   func() error { return v.move(v.height()) },
+		},
+		{
+			ConfigKeys: []string{"keybinding.page-up"},
+			OnAction:   func() error { return v.move(-v.height()) },
+		},
+	}
+
+	helpKeys, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+	v.helpKeys = helpKeys
+
+	return v.Render()
+}
+
+// KeyHelp indicates all the possible actions a user can take while the popup is open.
+func (v *Compare) KeyHelp() string {
+	var help string
+	for _, binding := range v.helpKeys {
+		help += binding.RenderKeyHelp(v.symbols)
+	}
+	return help
+}
+
+// Show opens the popup (taking focus) while the given sides are being compared, returning the ticket to hand the rows
+// over with (see SetRows).
+func (v *Compare) Show(left, right string) (int, error) {
+	v.left, v.right = left, right
+	v.rows, v.shown = nil, nil
+	v.cursor, v.top = 0, 0
+	v.loading = true
+	v.hidden = false
+	v.generation++
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.generation, v.Render()
+}
+
+// SetRows shows the compared rows, unless the popup has been closed (or opened again) since the given ticket was made.
+func (v *Compare) SetRows(ticket int, rows []filetree.SideBySideRow) error {
+	if !v.Waiting(ticket) {
+		return nil
+	}
+	v.rows = rows
+	v.loading = false
+	v.filter()
+	return v.Render()
+}
+
+// Waiting indicates if the popup still waits for the rows of the given ticket.
+func (v *Compare) Waiting(ticket int) bool {
+	return ticket == v.generation && !v.hidden && v.loading
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Compare) Close() error {
+	v.rows, v.shown = nil, nil
+	return v.closeView(v.view)
+}
+
+// filter selects the rows to show, keeping the cursor on the same path when it is still shown.
+func (v *Compare) filter() {
+	var selected string
+	if v.cursor < len(v.shown) {
+		selected = v.shown[v.cursor].Path
+	}
+
+	v.shown = v.rows
+	if v.hideUnchanged {
+		v.shown = filetree.ChangedRows(v.rows)
+	}
+
+	v.cursor, v.top = 0, 0
+	for idx, row := range v.shown {
+		if row.Path == selected {
+			v.cursor = idx
+			break
+		}
+	}
+	v.move(0)
+}
+
+func (v *Compare) toggleUnchanged() error {
+	v.hideUnchanged = !v.hideUnchanged
+	v.filter()
+	return v.Render()
+}
+
+// move moves the cursor by the given number of rows, scrolling both sides to keep it in view.
+func (v *Compare) move(delta int) error {
+	v.cursor += delta
+	if v.cursor >= len(v.shown) {
+		v.cursor = len(v.shown) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	if v.cursor < v.top {
+		v.top = v.cursor
+	}
+	if v.cursor >= v.top+v.height() {
+		v.top = v.cursor - v.height() + 1
+	}
+	return v.Render()
+}
+
+// height is the number of rows shown at once.
+func (v *Compare) height() int {
+	if v.view == nil {
+		return 1
+	}
+	_, height := v.view.Size()
+	if height -= compareHeaderLines; height < 1 {
+		return 1
+	}
+	return height
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Compare) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Compare) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Compare) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// summary counts the differences (or tells that the trees are still being compared).
+func (v *Compare) summary() string {
+	if v.loading {
+		return "Comparing..."
+	}
+	// the directories found on both sides are only modified by the entries they hold, which are counted already
+	counts := make(map[filetree.DiffType]int)
+	for _, row := range v.rows {
+		if row.Left != nil && row.Right != nil && row.Left.Data.FileInfo.IsDir && row.Right.Data.FileInfo.IsDir {
+			continue
+		}
+		counts[row.Diff]++
+	}
+	summary := fmt.Sprintf("%d added, %d removed, %d modified of %d entries", counts[filetree.Added], counts[filetree.Removed], counts[filetree.Modified], len(v.rows))
+	if v.hideUnchanged {
+		summary += " (unchanged entries hidden)"
+	}
+	return summary
+}
+
+// compareCell renders one side of a row: the indented name and the size of a file, or blanks when the side has no
+// entry at the path.
+func compareCell(row filetree.SideBySideRow, node *filetree.FileNode, width int, render filetree.RenderOptions) string {
+	nameWidth := width - compareSizeWidth - 1
+	if nameWidth < 4 {
+		nameWidth = 4
+	}
+	if node == nil {
+		return strings.Repeat(" ", width)
+	}
+
+	name := strings.Repeat("  ", row.Depth) + filetree.DisplayName(row.Name)
+	var size string
+	if node.Data.FileInfo.IsDir {
+		name += "/"
+	} else {
+		size = humanize.Bytes(uint64(node.Data.FileInfo.Size))
+	}
+	// padded by width rather than by bytes, so the size column stays aligned with wide characters in the names
+	name = filetree.PadRight(render.Truncate(name, nameWidth), nameWidth)
+	return fmt.Sprintf("%s %*s", name, compareSizeWidth, size)
+}
+
+// lines renders the summary, the column header and the visible window of rows, the left and right sides separated by
+// the marker of the difference.
+func (v *Compare) lines() []string {
+	viewWidth := 80
+	if v.view != nil {
+		viewWidth, _ = v.view.Size()
+	}
+	width := (viewWidth - 3) / 2
+	if width < compareSizeWidth+2 {
+		width = compareSizeWidth + 2
+	}
+
+	lines := []string{v.summary(), format.Header(fmt.Sprintf("%-*s   %s", width, v.left, v.right))}
+	if v.loading {
+		return lines
+	}
+	if len(v.shown) == 0 {
+		return append(lines, "No differences")
+	}
+
+	stop := v.top + v.height()
+	if stop > len(v.shown) {
+		stop = len(v.shown)
+	}
+	for idx := v.top; idx < stop; idx++ {
+		row := v.shown[idx]
+		left := compareCell(row, row.Left, width, v.render)
+		right := compareCell(row, row.Right, width, v.render)
+		line := left + " " + row.Diff.Marker() + " " + right
+		if idx == v.cursor {
+			lines = append(lines, format.Selected(line))
+			continue
+		}
+		lines = append(lines, v.render.Colorize(row.Diff, line))
+	}
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *Compare) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Side by side "
+		v.view.Subtitle = fmt.Sprintf(" Press %s to toggle the unchanged entries, esc to close ", viper.GetString("keybinding.toggle-unchanged-rows"))
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout fills most of the screen with the popup.
+func (v *Compare) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	x0, y0 := minX+pivotMargin, minY+pivotMargin
+	x1, y1 := maxX-pivotMargin, maxY-pivotMargin
+	if x1 <= x0 || y1 <= y0 {
+		x0, y0, x1, y1 = minX, minY, minX+1, minY+1
+	}
+	view, viewErr := g.SetView(v.Name(), x0, y0, x1, y1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup compare controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Compare) RequestedSize(available int) *int {
+	return nil
+}
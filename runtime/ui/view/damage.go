@@ -0,0 +1,33 @@
+package view
+
+// damage tracks the state a view was last rendered with, so that a view is only rendered again once that state
+// changed rather than on every event (each render queues a redraw of the screen). The state is summarized by a
+// comparable key, e.g. the size of the view and the selected layer.
+type damage struct {
+	rendered bool
+	key      interface{}
+}
+
+// changed indicates if the view must be rendered for the given state, recording the state as rendered.
+func (d *damage) changed(key interface{}) bool {
+	if d.rendered && d.key == key {
+		return false
+	}
+	d.rendered, d.key = true, key
+	return true
+}
+
+// invalidate renders the view on the next render whatever the state (e.g. once the view was recreated, empty).
+func (d *damage) invalidate() {
+	d.rendered = false
+}
+
+// paneSize is the state of the panes that only change with their size.
+type paneSize struct {
+	width, height int
+}
+
+// sizeOf returns the size of the given view as a damage key.
+func sizeOf(width, height int) paneSize {
+	return paneSize{width: width, height: height}
+}
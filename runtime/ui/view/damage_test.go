@@ -0,0 +1,20 @@
+package view
+
+import "testing"
+
+func TestDamage(t *testing.T) {
+	var d damage
+	if !d.changed(sizeOf(80, 10)) {
+		t.Errorf("expected the first render to be needed")
+	}
+	if d.changed(sizeOf(80, 10)) {
+		t.Errorf("expected no render for the same state")
+	}
+	if !d.changed(sizeOf(100, 10)) {
+		t.Errorf("expected a render once the state changed")
+	}
+	d.invalidate()
+	if !d.changed(sizeOf(100, 10)) {
+		t.Errorf("expected a render once invalidated")
+	}
+}
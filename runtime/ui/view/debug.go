@@ -2,36 +2,57 @@ package view
 
 import (
 	"fmt"
+	goruntime "runtime"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/metrics"
 	"github.com/wagoodman/dive/runtime/ui/format"
-	"github.com/wagoodman/dive/utils"
+	"github.com/wagoodman/dive/runtime/ui/key"
 )
 
-// Debug is just for me :)
+// the widest the debug popup gets (narrower screens get a narrower popup)
+const maxDebugWidth = 110
+
+// the number of the slowest samples listed for every stage (e.g. the slowest layers to parse)
+const debugSamples = 5
+
+type DebugCloseListener func(previous string) error
+
+// Debug holds the UI objects for the popup that shows where the time went: the timings of the analysis pipeline
+// (parsing every layer, stacking the trees, rendering) and the memory use, to attach to performance reports.
 type Debug struct {
 	name   string
 	gui    *gocui.Gui
 	view   *gocui.View
-	header *gocui.View
+	hidden bool
+	// the timings shown (nil when they are not timed)
+	recorder *metrics.Recorder
+	// the view that had the focus when the popup was opened
+	previous string
+	// the lines shown, taken when the popup is rendered (reading the memory use stops the world, so not on every draw)
+	content []string
 
-	selectedView Helper
+	closeListeners []DebugCloseListener
 }
 
 // newDebugView creates a new view object attached the the global [gocui] screen object.
-func newDebugView(gui *gocui.Gui) (controller *Debug) {
+func newDebugView(gui *gocui.Gui, recorder *metrics.Recorder) (controller *Debug) {
 	controller = new(Debug)
 
 	// populate main fields
 	controller.name = "debug"
 	controller.gui = gui
+	controller.hidden = true
+	controller.recorder = recorder
 
 	return controller
 }
 
-func (v *Debug) SetCurrentView(r Helper) {
-	v.selectedView = r
+func (v *Debug) AddCloseListener(listener ...DebugCloseListener) {
+	v.closeListeners = append(v.closeListeners, listener...)
 }
 
 func (v *Debug) Name() string {
@@ -39,26 +60,110 @@ func (v *Debug) Name() string {
 }
 
 // Setup initializes the UI concerns within the context of a global [gocui] view object.
-func (v *Debug) Setup(view *gocui.View, header *gocui.View) error {
+func (v *Debug) Setup(view *gocui.View) error {
 	logrus.Tracef("view.Setup() %s", v.Name())
 
 	// set controller options
 	v.view = view
 	v.view.Editable = false
 	v.view.Wrap = false
-	v.view.Frame = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+		{
+			// showing the popup again reads the timings again
+			ConfigKeys: []string{"keybinding.show-metrics"},
+			OnAction:   v.Show,
+		},
+	}
 
-	v.header = header
-	v.header.Editable = false
-	v.header.Wrap = false
-	v.header.Frame = false
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
 
 	return v.Render()
 }
 
-// IsVisible indicates if the status view pane is currently initialized.
+// Show opens the popup (taking focus) with the timings recorded so far.
+func (v *Debug) Show() error {
+	v.hidden = false
+	if current := v.gui.CurrentView(); current != nil {
+		v.previous = current.Name()
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Debug) Close() error {
+	if v.hidden {
+		return nil
+	}
+	v.hidden = true
+	if v.view != nil {
+		v.view.Visible = false
+	}
+
+	for _, listener := range v.closeListeners {
+		if err := listener(v.previous); err != nil {
+			logrus.Errorf("notifyOnCloseListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Debug) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if oy+delta < 0 || oy+delta+height > len(v.content) {
+		return nil
+	}
+	return v.view.SetOrigin(ox, oy+delta)
+}
+
+// IsVisible indicates if the popup is open.
 func (v *Debug) IsVisible() bool {
-	return v != nil
+	return v != nil && !v.hidden
 }
 
 // Update refreshes the state objects for future rendering (currently does nothing).
@@ -75,41 +180,98 @@ func (v *Debug) OnLayoutChange() error {
 	return v.Render()
 }
 
+// lines renders the timings of every stage (with the slowest samples of the stage), followed by the memory use.
+func (v *Debug) lines() []string {
+	stages := v.recorder.Stages()
+	if len(stages) == 0 {
+		return []string{"No timings were recorded", "", "Press esc to close"}
+	}
+
+	lines := []string{format.Header(fmt.Sprintf("%-18s  %7s  %10s  %10s  %10s", "Stage", "Count", "Total", "Average", "Max"))}
+	for _, stage := range stages {
+		lines = append(lines, fmt.Sprintf("%-18s  %7d  %10s  %10s  %10s", stage.Name, stage.Count, formatDuration(stage.Total), formatDuration(stage.Average()), formatDuration(stage.Max)))
+	}
+	for _, stage := range stages {
+		if len(stage.Samples) == 0 {
+			continue
+		}
+		lines = append(lines, "", format.Header(fmt.Sprintf("Slowest: %s", stage.Name)))
+		for idx, sample := range stage.Samples {
+			if idx >= debugSamples {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("%10s  %s", formatDuration(sample.Duration), sample.Detail))
+		}
+	}
+
+	var memory goruntime.MemStats
+	goruntime.ReadMemStats(&memory)
+	lines = append(lines, "", format.Header("Memory"),
+		fmt.Sprintf("Heap: %s in use, %s allocated in total, %d garbage collections, %d goroutines",
+			humanize.Bytes(memory.HeapAlloc), humanize.Bytes(memory.TotalAlloc), memory.NumGC, goruntime.NumGoroutine()),
+		"", "Press the metrics key again to refresh, esc to close")
+	return lines
+}
+
+// formatDuration formats a duration with a precision that suits its magnitude (e.g. "1.2s", "35ms", "120µs").
+func formatDuration(value time.Duration) string {
+	switch {
+	case value >= time.Second:
+		return value.Round(10 * time.Millisecond).String()
+	case value >= time.Millisecond:
+		return value.Round(10 * time.Microsecond).String()
+	default:
+		return value.Round(time.Microsecond).String()
+	}
+}
+
 // Render flushes the state objects to the screen.
 func (v *Debug) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
+	if v.IsVisible() {
+		v.content = v.lines()
+	}
 
 	v.gui.Update(func(g *gocui.Gui) error {
-		// update header...
-		v.header.Clear()
-		width, _ := g.Size()
-		headerStr := format.RenderHeader("Debug", width, false)
-		_, _ = fmt.Fprintln(v.header, headerStr)
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
 
-		// update view...
+		v.view.Title = " Debug: timings "
 		v.view.Clear()
-		_, err := fmt.Fprintln(v.view, "blerg")
-		if err != nil {
-			logrus.Debug("unable to write to buffer: ", err)
+		for _, line := range v.content {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
 		}
-
 		return nil
 	})
 	return nil
 }
 
+// Layout centers the popup on the screen, sized to its contents.
 func (v *Debug) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
-	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
-
-	// header
-	headerSize := 1
-	// note: maxY needs to account for the (invisible) border, thus a +1
-	header, headerErr := g.SetView(v.Name()+"header", minX, minY, maxX, minY+headerSize+1, 0)
-	// we are going to overlap the view over the (invisible) border (so minY will be one less than expected).
-	// additionally, maxY will be bumped by one to include the border
-	view, viewErr := g.SetView(v.Name(), minX, minY+headerSize, maxX, maxY+1, 0)
-	if utils.IsNewView(viewErr, headerErr) {
-		err := v.Setup(view, header)
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxDebugWidth {
+		width = maxDebugWidth
+	}
+	height := len(v.content) + 1
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
 		if err != nil {
 			logrus.Error("unable to setup debug controller", err)
 			return err
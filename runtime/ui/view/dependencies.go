@@ -0,0 +1,160 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the dependencies pane takes from the layer details column (the rest can be scrolled to)
+const maxDependenciesHeight = 6
+
+// Dependencies holds the UI objects and data models for populating the pane beneath the layers. Specifically the
+// pane that lists the node packages, python distributions and Go and Rust binaries of the final image by size (it is
+// only shown when enabled).
+type Dependencies struct {
+	name         string
+	gui          *gocui.Gui
+	symbols      format.Symbols
+	view         *gocui.View
+	header       *gocui.View
+	damage       damage
+	dependencies *image.Dependencies
+	enabled      bool
+}
+
+// newDependenciesView creates a new view object attached the the global [gocui] screen object.
+func newDependenciesView(gui *gocui.Gui, dependencies *image.Dependencies, enabled bool, symbols format.Symbols) (controller *Dependencies) {
+	controller = new(Dependencies)
+
+	// populate main fields
+	controller.name = "dependencies"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.dependencies = dependencies
+	controller.enabled = enabled
+
+	return controller
+}
+
+func (v *Dependencies) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Dependencies) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// IsVisible indicates if the dependencies pane is shown (only when enabled, and when the analysis is available).
+func (v *Dependencies) IsVisible() bool {
+	return v != nil && v.enabled && v.dependencies != nil
+}
+
+// lines renders a line per dependency, the largest first (or a single line when there are none).
+func (v *Dependencies) lines() []string {
+	if v.dependencies.Empty() {
+		return []string{"No node packages, python distributions or Go and Rust binaries found"}
+	}
+	lines := make([]string, 0, len(v.dependencies.Dependencies))
+	for _, dependency := range v.dependencies.Dependencies {
+		line := fmt.Sprintf("%s %s (%s, layer %d)", format.Header(fmt.Sprintf("%8s", humanize.Bytes(dependency.SizeBytes))), dependency.Label(), dependency.Ecosystem, dependency.Layer)
+		if dependency.Sections != "" {
+			line += ": " + dependency.Sections
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// title sums the dependencies up by ecosystem (e.g. "Dependencies (python 120 MB, node 80 MB)").
+func (v *Dependencies) title() string {
+	var totals []string
+	for _, ecosystem := range v.dependencies.Ecosystems() {
+		totals = append(totals, ecosystem+" "+humanize.Bytes(v.dependencies.SizeBytes(ecosystem)))
+	}
+	if len(totals) == 0 {
+		return "Dependencies"
+	}
+	return fmt.Sprintf("Dependencies (%s)", strings.Join(totals, ", "))
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Dependencies) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if lines := len(v.lines()); lines < maxDependenciesHeight {
+		return lines
+	}
+	return maxDependenciesHeight
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Dependencies) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Dependencies) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Dependencies) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil || !v.IsVisible() {
+		return nil
+	}
+	if !v.damage.changed(sizeOf(v.view.Size())) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(v.title(), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wagoodman/dive/dive/filetree"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/dustin/go-humanize"
+	"github.com/mattn/go-runewidth"
 )
 
 // Details holds the UI objects and data models for populating the lower-left pane. Specifically the pane that
@@ -20,27 +22,59 @@ import (
 type Details struct {
 	name           string
 	gui            *gocui.Gui
+	symbols        format.Symbols
 	view           *gocui.View
 	header         *gocui.View
+	damage         damage
 	imageName      string
 	efficiency     float64
 	inefficiencies filetree.EfficiencySlice
 	imageSize      uint64
+	compressedSize uint64
+	partial        bool
+	pullCost       *image.PullCost
+	base           *image.BaseImage
+	appSize        uint64
+	appWasted      uint64
+	downloads      []image.RemoteDownload
+	breakdown      *image.EfficiencyBreakdown
+	signature      *image.SignatureVerification
+	// how the long paths are shortened
+	render filetree.RenderOptions
 
 	currentLayer *image.Layer
 }
 
+// detailsState is the state the details pane renders (the sizes of lazily loaded layers are measured once parsed).
+type detailsState struct {
+	size           paneSize
+	layer          *image.Layer
+	layerSize      uint64
+	compressedSize uint64
+}
+
 // newDetailsView creates a new view object attached the the global [gocui] screen object.
-func newDetailsView(gui *gocui.Gui, imageName string, efficiency float64, inefficiencies filetree.EfficiencySlice, imageSize uint64) (controller *Details) {
+func newDetailsView(gui *gocui.Gui, imageName string, efficiency float64, inefficiencies filetree.EfficiencySlice, imageSize uint64, compressedSize uint64, partial bool, pullCost *image.PullCost, base *image.BaseImage, appSize uint64, appWasted uint64, downloads []image.RemoteDownload, breakdown *image.EfficiencyBreakdown, signature *image.SignatureVerification, render filetree.RenderOptions, symbols format.Symbols) (controller *Details) {
 	controller = new(Details)
 
 	// populate main fields
 	controller.name = "details"
 	controller.gui = gui
+	controller.symbols = symbols
 	controller.imageName = imageName
 	controller.efficiency = efficiency
 	controller.inefficiencies = inefficiencies
 	controller.imageSize = imageSize
+	controller.compressedSize = compressedSize
+	controller.partial = partial
+	controller.pullCost = pullCost
+	controller.base = base
+	controller.appSize = appSize
+	controller.appWasted = appWasted
+	controller.downloads = downloads
+	controller.breakdown = breakdown
+	controller.signature = signature
+	controller.render = render
 
 	return controller
 }
@@ -83,6 +117,7 @@ func (v *Details) Setup(view *gocui.View, header *gocui.View) error {
 		return err
 	}
 
+	v.damage.invalidate()
 	return v.Render()
 }
 
@@ -115,31 +150,50 @@ func (v *Details) Update() error {
 	return nil
 }
 
+// mtimeGroupsString summarizes the files of the layer by the bucket of their modification times (e.g. "12 built
+// (3.1 MB), 40 stale (20 MB)").
+func mtimeGroupsString(groups []image.MtimeGroup) string {
+	parts := make([]string, 0, len(groups))
+	for _, group := range groups {
+		parts = append(parts, fmt.Sprintf("%d %s (%s)", group.Files, group.Bucket, humanize.Bytes(group.SizeBytes)))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (v *Details) SetCurrentLayer(layer *image.Layer) {
 	v.currentLayer = layer
 }
 
 // Render flushes the state objects to the screen. The details pane reports:
-// 1. the current selected layer's command string
-// 2. the image efficiency score
-// 3. the estimated wasted image space
-// 4. a list of inefficient file allocations
+// 1. the current selected layer's metadata (digests, media type, creation, sizes), remote downloads and full command
+// string
+// 2. the image efficiency score, and the kinds of waste it breaks down into
+// 3. the size of the base image and app layers, and the estimated wasted image space
+// 4. the estimated pull time (of the layer and the image)
+// 5. a list of inefficient file allocations
 func (v *Details) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
 
 	if v.currentLayer == nil {
 		return fmt.Errorf("no layer selected")
 	}
+	if v.view != nil && !v.damage.changed(detailsState{size: sizeOf(v.view.Size()), layer: v.currentLayer, layerSize: v.currentLayer.Size, compressedSize: v.currentLayer.CompressedSize}) {
+		// nothing changed since the last render
+		return nil
+	}
 
 	var wastedSpace int64
+	mtimes := mtimeGroupsString(v.currentLayer.MtimeGroups())
 
 	template := "%5s  %12s  %-s\n"
 	inefficiencyReport := fmt.Sprintf(format.Header(template), "Count", "Total Space", "Path")
 
-	height := 100
+	width, height := 0, 100
 	if v.view != nil {
-		_, height = v.view.Size()
+		width, height = v.view.Size()
 	}
+	// long paths are shortened to the rest of the row (past the count and size columns)
+	pathWidth := width - 21
 
 	for idx := 0; idx < len(v.inefficiencies); idx++ {
 		data := v.inefficiencies[len(v.inefficiencies)-1-idx]
@@ -147,22 +201,31 @@ func (v *Details) Render() error {
 
 		// todo: make this report scrollable
 		if idx < height {
-			inefficiencyReport += fmt.Sprintf(template, strconv.Itoa(len(data.Nodes)), humanize.Bytes(uint64(data.CumulativeSize)), data.Path)
+			path := v.render.Truncate(filetree.DisplayName(data.Path), pathWidth)
+			inefficiencyReport += fmt.Sprintf(template, strconv.Itoa(len(data.Nodes)), humanize.Bytes(uint64(data.CumulativeSize)), path)
 		}
 	}
 
 	imageNameStr := fmt.Sprintf("%s %s", format.Header("Image name:"), v.imageName)
 	imageSizeStr := fmt.Sprintf("%s %s", format.Header("Total Image size:"), humanize.Bytes(v.imageSize))
+	if v.compressedSize > 0 && !v.partial {
+		imageSizeStr += fmt.Sprintf(" (%s compressed)", humanize.Bytes(v.compressedSize))
+	}
 	effStr := fmt.Sprintf("%s %d %%", format.Header("Image efficiency score:"), int(100.0*v.efficiency))
 	wastedSpaceStr := fmt.Sprintf("%s %s", format.Header("Potential wasted space:"), humanize.Bytes(uint64(wastedSpace)))
+	if v.partial {
+		// the efficiency requires every layer tree, which are only parsed when first selected
+		effStr = fmt.Sprintf("%s n/a (layers are loaded on demand)", format.Header("Image efficiency score:"))
+		wastedSpaceStr = fmt.Sprintf("%s n/a", format.Header("Potential wasted space:"))
+	}
 
 	v.gui.Update(func(g *gocui.Gui) error {
 		// update header
 		v.header.Clear()
 		width, _ := v.view.Size()
 
-		layerHeaderStr := format.RenderHeader("Layer Details", width, false)
-		imageHeaderStr := format.RenderHeader("Image Details", width, false)
+		layerHeaderStr := v.symbols.RenderHeader("Layer Details", width, false)
+		imageHeaderStr := v.symbols.RenderHeader("Image Details", width, false)
 
 		_, err := fmt.Fprintln(v.header, layerHeaderStr)
 		if err != nil {
@@ -174,19 +237,69 @@ func (v *Details) Render() error {
 
 		var lines = make([]string, 0)
 		if v.currentLayer.Names != nil && len(v.currentLayer.Names) > 0 {
-			lines = append(lines, format.Header("Tags:   ")+strings.Join(v.currentLayer.Names, ", "))
+			lines = append(lines, format.Header("Tags:       ")+strings.Join(v.currentLayer.Names, ", "))
+		} else {
+			lines = append(lines, format.Header("Tags:       ")+"(none)")
+		}
+		lines = append(lines, format.Header("Id:         ")+v.currentLayer.Id)
+		lines = append(lines, format.Header("Digest:     ")+orUnavailable(v.currentLayer.Digest))
+		lines = append(lines, format.Header("DiffID:     ")+orUnavailable(v.currentLayer.DiffID))
+		lines = append(lines, format.Header("Media type: ")+orUnavailable(v.currentLayer.MediaType))
+		if v.currentLayer.Created.IsZero() {
+			lines = append(lines, format.Header("Created:    ")+"(unavailable)")
 		} else {
-			lines = append(lines, format.Header("Tags:   ")+"(none)")
+			lines = append(lines, format.Header("Created:    ")+v.currentLayer.Created.UTC().Format("2006-01-02 15:04:05 MST"))
+		}
+		lines = append(lines, format.Header("Author:     ")+orUnavailable(v.currentLayer.Author))
+		lines = append(lines, format.Header("Built by:   ")+orUnavailable(v.currentLayer.Builder))
+		if v.base != nil {
+			if v.currentLayer.Index < v.base.Layers {
+				lines = append(lines, format.Header("Origin:     ")+"base image")
+			} else {
+				lines = append(lines, format.Header("Origin:     ")+"app")
+			}
+		}
+		if v.currentLayer.Role != "" {
+			lines = append(lines, format.Header("Role:       ")+v.currentLayer.Role)
+		}
+		lines = append(lines, format.Header("Size:       ")+layerSizeString(v.currentLayer))
+		if reason := v.currentLayer.EmptyReason(); reason != "" {
+			lines = append(lines, format.Header("Empty:      ")+reason)
+		}
+		if mtimes != "" {
+			lines = append(lines, format.Header("Mtimes:     ")+mtimes)
 		}
-		lines = append(lines, format.Header("Id:     ")+v.currentLayer.Id)
-		lines = append(lines, format.Header("Digest: ")+v.currentLayer.Digest)
+		if v.currentLayer.Tree != nil && v.currentLayer.Tree.Folded > 0 {
+			lines = append(lines, format.Header("Folded:     ")+fmt.Sprintf("%d entries into summary directories (bounded analysis)", v.currentLayer.Tree.Folded))
+		}
+		if v.currentLayer.Unavailable != "" {
+			lines = append(lines, format.Header("Contents:   ")+"unavailable, "+v.currentLayer.Unavailable)
+		}
+		for _, problem := range v.currentLayer.Corruption {
+			lines = append(lines, format.Header("Integrity:  ")+format.Corrupt(v.symbols.Corrupt+" "+problem))
+		}
+		if estimate := v.pullCost.Main(); estimate != nil {
+			if layerEstimate, ok := estimate.Layer(v.currentLayer.Index); ok {
+				lines = append(lines, format.Header("Pull:       ")+fmt.Sprintf("%s cold, %s warm", image.FormatPullDuration(layerEstimate.Cold), image.FormatPullDuration(layerEstimate.Warm)))
+			}
+		}
+		lines = append(lines, v.downloadStrings(width)...)
 		lines = append(lines, format.Header("Command:"))
-		lines = append(lines, v.currentLayer.Command)
+		lines = append(lines, wrapText(v.currentLayer.Command, width)...)
 		lines = append(lines, "\n"+imageHeaderStr)
 		lines = append(lines, imageNameStr)
+		if v.signature != nil {
+			lines = append(lines, fmt.Sprintf("%s %s", format.Header("Signature:"), v.signature.Summary()))
+		}
 		lines = append(lines, imageSizeStr)
+		if v.base != nil {
+			lines = append(lines, v.baseSizeStrings()...)
+		}
 		lines = append(lines, wastedSpaceStr)
-		lines = append(lines, effStr+"\n")
+		lines = append(lines, v.pullCostStrings()...)
+		lines = append(lines, effStr)
+		lines = append(lines, v.breakdownStrings()...)
+		lines = append(lines, "")
 		lines = append(lines, inefficiencyReport)
 
 		_, err = fmt.Fprintln(v.view, strings.Join(lines, "\n"))
@@ -198,6 +311,151 @@ func (v *Details) Render() error {
 	return nil
 }
 
+// pullCostStrings reports the estimated pull time at every configured bandwidth, followed by the monthly egress.
+func (v *Details) pullCostStrings() []string {
+	if v.pullCost.Main() == nil {
+		return nil
+	}
+	var lines []string
+	for idx, estimate := range v.pullCost.Estimates {
+		title := "Estimated pull time:"
+		if idx > 0 {
+			title = "                    "
+		}
+		lines = append(lines, fmt.Sprintf("%s %s cold, %s warm (%s)", format.Header(title),
+			image.FormatPullDuration(estimate.Cold), image.FormatPullDuration(estimate.Warm), estimate.Profile))
+	}
+	if egress := v.pullCost.Egress; egress != nil {
+		lines = append(lines, fmt.Sprintf("%s %s a month cold ($%.2f), %s warm ($%.2f), for %d pulls at $%.2f/GB", format.Header("Registry egress:"),
+			humanize.Bytes(egress.MonthlyBytes(false)), egress.MonthlyCost(false), humanize.Bytes(egress.MonthlyBytes(true)), egress.MonthlyCost(true), egress.Pulls, egress.PricePerGB))
+	}
+	return lines
+}
+
+// breakdownStrings explains the efficiency score: the share of the image bytes lost to each kind of waste.
+func (v *Details) breakdownStrings() []string {
+	if v.breakdown == nil {
+		return nil
+	}
+	lines := []string{fmt.Sprintf("%s %d %% (%s wasted)", format.Header("Score breakdown:"), int(100.0*v.breakdown.Score), humanize.Bytes(v.breakdown.WastedBytes()))}
+	for _, contribution := range v.breakdown.Contributions {
+		lines = append(lines, fmt.Sprintf("  %-18s %10s  %5d files  -%.1f %%", contribution.Name, humanize.Bytes(contribution.Bytes), contribution.Files, 100.0*contribution.Penalty))
+	}
+	return lines
+}
+
+// baseSizeStrings reports the size of the base image layers apart from the app layers built on top of them.
+func (v *Details) baseSizeStrings() []string {
+	name := v.base.Image
+	if name == "" {
+		name = "assumed"
+	}
+	baseStr := fmt.Sprintf("%s %s (%d layers, %s)", format.Header("Base image size:"), humanize.Bytes(v.base.SizeBytes), v.base.Layers, name)
+	appStr := fmt.Sprintf("%s %s", format.Header("App layers size:"), humanize.Bytes(v.appSize))
+	if !v.partial {
+		appStr += fmt.Sprintf(" (%s wasted)", humanize.Bytes(v.appWasted))
+	}
+	return []string{baseStr, appStr}
+}
+
+// downloadStrings lists the remote URLs fetched by the current layer, what became of the downloaded files and the URLs
+// that are not pinned (nothing when the layer downloads nothing).
+func (v *Details) downloadStrings(width int) []string {
+	var lines []string
+	for _, download := range v.downloads {
+		if download.Layer != v.currentLayer.Index {
+			continue
+		}
+		if lines == nil {
+			lines = append(lines, format.Header("Downloads:"))
+		}
+		lines = append(lines, wrapText(fmt.Sprintf("%s %s", download.Tool, download.URL), width)...)
+		lines = append(lines, "  "+downloadStatusString(download))
+		if download.Unpinned != "" {
+			lines = append(lines, wrapText("  WARN unpinned: "+download.Unpinned, width)...)
+		}
+	}
+	return lines
+}
+
+// downloadStatusString describes what became of a downloaded file in the final image.
+func downloadStatusString(download image.RemoteDownload) string {
+	switch download.Status {
+	case image.DownloadPresent:
+		return fmt.Sprintf("saved as %s (%s), in the final image", download.Path, humanize.Bytes(download.SizeBytes))
+	case image.DownloadDeleted:
+		return fmt.Sprintf("saved as %s, deleted by layer %d (%s wasted)", download.Path, download.DeletedBy, humanize.Bytes(download.WastedBytes()))
+	case image.DownloadPiped:
+		return "piped to another command"
+	case image.DownloadUnverified:
+		return "not verified (the layer is not loaded)"
+	default:
+		return "not kept in the layer"
+	}
+}
+
+// orUnavailable substitutes a placeholder for metadata the image does not provide.
+func orUnavailable(value string) string {
+	if value == "" {
+		return "(unavailable)"
+	}
+	return value
+}
+
+// layerSizeString describes the size of the layer contents alongside the compressed size of the layer.
+func layerSizeString(layer *image.Layer) string {
+	uncompressed := humanize.Bytes(layer.Size)
+	switch {
+	case layer.CompressedSize == 0:
+		return uncompressed
+	case layer.IsCompressed():
+		return fmt.Sprintf("%s uncompressed, %s compressed", uncompressed, humanize.Bytes(layer.CompressedSize))
+	default:
+		// the compressed size was measured by recompressing the layer
+		return fmt.Sprintf("%s uncompressed, %s compressed (stored uncompressed, %s)", uncompressed, humanize.Bytes(layer.CompressedSize), humanize.Bytes(layer.BlobSize))
+	}
+}
+
+// wrapText breaks the given text into lines no wider than the given width (in terminal cells), preferring to break
+// between words.
+func wrapText(text string, width int) []string {
+	if width <= 0 {
+		return []string{text}
+	}
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for filetree.DisplayWidth(word) > width {
+				// the word cannot fit on a line of its own, so it is split wherever the line ends
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				head := runewidth.Truncate(word, width, "")
+				if head == "" {
+					// a wide character on a line narrower than itself
+					_, size := utf8.DecodeRuneInString(word)
+					head = word[:size]
+				}
+				lines = append(lines, head)
+				word = word[len(head):]
+			}
+			switch {
+			case line == "":
+				line = word
+			case filetree.DisplayWidth(line)+1+filetree.DisplayWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
 // KeyHelp indicates all the possible actions a user can take while the current pane is selected (currently does nothing).
 func (v *Details) KeyHelp() string {
 	return "TBD"
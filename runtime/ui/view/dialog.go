@@ -0,0 +1,232 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// the widest the dialog gets (narrower screens get a narrower dialog)
+const maxDialogWidth = 80
+
+// DialogCloseListener is notified when the dialog closes, with the name of the view that had the focus when it opened.
+type DialogCloseListener func(returnTo string) error
+
+// Dialog is a popup centered on the screen that shows a message (e.g. an error) until it is dismissed.
+type Dialog struct {
+	name  string
+	gui   *gocui.Gui
+	view  *gocui.View
+	title string
+	lines []string
+	// the view that had the focus when the dialog opened
+	returnTo string
+	hidden   bool
+
+	closeListeners []DialogCloseListener
+}
+
+// newDialogView creates a new view object attached the the global [gocui] screen object.
+func newDialogView(gui *gocui.Gui) (controller *Dialog) {
+	controller = new(Dialog)
+
+	// populate main fields
+	controller.name = "dialog"
+	controller.gui = gui
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Dialog) AddCloseListener(listener ...DialogCloseListener) {
+	v.closeListeners = append(v.closeListeners, listener...)
+}
+
+func (v *Dialog) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Dialog) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = true
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// ShowMessage opens the dialog (taking focus) with the given message.
+func (v *Dialog) ShowMessage(title string, lines ...string) error {
+	if v.hidden {
+		v.returnTo = ""
+		if current := v.gui.CurrentView(); current != nil {
+			v.returnTo = current.Name()
+		}
+	}
+	v.title = title
+	v.lines = lines
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// ShowError opens the dialog (taking focus) with the given error.
+func (v *Dialog) ShowError(title string, err error) error {
+	return v.ShowMessage(title, strings.Split(fmt.Sprintf("%v", err), "\n")...)
+}
+
+// Close hides the dialog and notifies the listeners (which move the focus back).
+func (v *Dialog) Close() error {
+	if v.hidden {
+		return nil
+	}
+	v.hidden = true
+	if v.view != nil {
+		v.view.Visible = false
+	}
+
+	for _, listener := range v.closeListeners {
+		if err := listener(v.returnTo); err != nil {
+			logrus.Errorf("notifyOnCloseListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVisible indicates if the dialog is open.
+func (v *Dialog) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Dialog) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Dialog) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// content returns the message followed by the key that dismisses it.
+func (v *Dialog) content() []string {
+	return append(append([]string{}, v.lines...), "", "Press esc to close")
+}
+
+// Render flushes the state objects to the screen.
+func (v *Dialog) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " " + v.title + " "
+		v.view.Clear()
+		lines := v.content()
+		lines[len(lines)-1] = format.Header(lines[len(lines)-1])
+		for _, line := range lines {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the dialog on the screen, sized to its contents.
+func (v *Dialog) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := len(v.title) + 4
+	for _, line := range v.content() {
+		if len(line)+2 > width {
+			width = len(line) + 2
+		}
+	}
+	if width > maxDialogWidth {
+		width = maxDialogWidth
+	}
+	if available := maxX - minX - 2*provenanceMargin; width > available {
+		width = available
+	}
+	height := 1
+	if width > 2 {
+		// wrapped lines take more rows
+		for _, line := range v.content() {
+			height += (len(line) + width - 3) / (width - 2)
+			if line == "" {
+				height++
+			}
+		}
+	}
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup dialog controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Dialog) RequestedSize(available int) *int {
+	return nil
+}
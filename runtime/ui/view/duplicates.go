@@ -0,0 +1,177 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the duplicates pane takes from the layer details column (the rest can be scrolled to)
+const maxDuplicatesHeight = 6
+
+// Duplicates holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane
+// that lists the files stored at more than one path, which fills in as the layers are hashed (it is only shown when
+// duplicate detection is enabled).
+type Duplicates struct {
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	header  *gocui.View
+	damage  damage
+	// the latest result, only accessed from the gui thread
+	result *image.DuplicateContent
+}
+
+// duplicatesState is the state the duplicates pane renders (every hashed layer gives a new result).
+type duplicatesState struct {
+	size   paneSize
+	result *image.DuplicateContent
+}
+
+// newDuplicatesView creates a new view object attached the the global [gocui] screen object.
+func newDuplicatesView(gui *gocui.Gui, finder *image.DuplicateFinder, symbols format.Symbols) (controller *Duplicates) {
+	controller = new(Duplicates)
+
+	// populate main fields
+	controller.name = "duplicates"
+	controller.gui = gui
+	controller.symbols = symbols
+
+	if finder != nil {
+		controller.result = finder.Result()
+		finder.AddListener(controller.onProgress)
+	}
+
+	return controller
+}
+
+// onProgress is called (from the goroutine hashing the layers) with the result so far each time a layer is hashed.
+func (v *Duplicates) onProgress(result *image.DuplicateContent) {
+	v.gui.Update(func(g *gocui.Gui) error {
+		// the updates are not guaranteed to arrive in order, never go back to an older result
+		if v.result != nil && result.LayersHashed < v.result.LayersHashed {
+			return nil
+		}
+		v.result = result
+		return v.Render()
+	})
+}
+
+func (v *Duplicates) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Duplicates) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// IsVisible indicates if the duplicates pane is shown (only when duplicate detection is enabled).
+func (v *Duplicates) IsVisible() bool {
+	return v != nil && v.result != nil
+}
+
+// lines renders a line per duplicated file (or a single line when there are none).
+func (v *Duplicates) lines() []string {
+	if len(v.result.Duplicates) == 0 {
+		if v.result.Complete() {
+			return []string{"No files are stored more than once"}
+		}
+		return []string{"No files are stored more than once (so far)"}
+	}
+	lines := make([]string, 0, len(v.result.Duplicates))
+	for _, duplicate := range v.result.Duplicates {
+		paths := make([]string, 0, len(duplicate.Copies))
+		for _, stored := range duplicate.Copies {
+			paths = append(paths, stored.Path)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", format.Header(fmt.Sprintf("%s x%d:", humanize.Bytes(duplicate.SizeBytes), len(duplicate.Copies))), strings.Join(paths, ", ")))
+	}
+	return lines
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Duplicates) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if lines := len(v.lines()); lines < maxDuplicatesHeight {
+		return lines
+	}
+	return maxDuplicatesHeight
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Duplicates) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Duplicates) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Duplicates) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil || !v.IsVisible() {
+		return nil
+	}
+	if !v.damage.changed(duplicatesState{size: sizeOf(v.view.Size()), result: v.result}) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		title := fmt.Sprintf("Duplicate Content (%d, %s wasted)", len(v.result.Duplicates), humanize.Bytes(v.result.WastedBytes))
+		if !v.result.Complete() {
+			// the duplicates are partial until every layer is hashed
+			title = fmt.Sprintf("Duplicate Content (%d so far, %d of %d layers hashed)", len(v.result.Duplicates), v.result.LayersHashed, v.result.LayersTotal)
+		}
+		headerStr := v.symbols.RenderHeader(title, width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,185 @@
+package view
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the file details pane takes from the layer details column (the rest can be scrolled to)
+const maxFileDetailsHeight = 8
+
+// FileDetails holds the UI objects and data models for populating the pane beneath the layers that shows what the
+// content inspectors found out about the selected file, along with its capabilities, extended attributes and PAX
+// records (it is only shown for files that were inspected or have any of these).
+type FileDetails struct {
+	name        string
+	gui         *gocui.Gui
+	symbols     format.Symbols
+	view        *gocui.View
+	header      *gocui.View
+	path        string
+	inspections []filetree.Inspection
+	// the package that installed a file (nil when none did, or the package databases have not been read)
+	owner func(filePath string) *image.InstalledPackage
+	// the user namespace the owners of the files are shown within
+	mapping filetree.IDMapping
+}
+
+// newFileDetailsView creates a new view object attached the the global [gocui] screen object.
+func newFileDetailsView(gui *gocui.Gui, owner func(filePath string) *image.InstalledPackage, mapping filetree.IDMapping, symbols format.Symbols) (controller *FileDetails) {
+	controller = new(FileDetails)
+
+	// populate main fields
+	controller.name = "file-details"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.owner = owner
+	controller.mapping = mapping
+
+	return controller
+}
+
+func (v *FileDetails) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *FileDetails) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	return v.Render()
+}
+
+// SetSelection shows the inspections and attributes of the given FileNode (hiding the pane when there are none).
+func (v *FileDetails) SetSelection(node *filetree.FileNode) error {
+	var inspections []filetree.Inspection
+	if node != nil {
+		inspections = append(inspections, node.Data.FileInfo.Inspections...)
+		if attributes := node.Data.FileInfo.Attributes(v.mapping); attributes != nil {
+			inspections = append(inspections, *attributes)
+		}
+		if node.Data.FileInfo.IsDir {
+			files, dirs := node.EntryCounts()
+			inspection := filetree.Inspection{Inspector: "contents"}
+			inspection.Add("Entries", fmt.Sprintf("%s (%s files, %s directories at any depth)", humanize.Comma(int64(len(node.Children))), humanize.Comma(int64(files)), humanize.Comma(int64(dirs))))
+			inspections = append(inspections, inspection)
+		}
+		if pkg := v.owner(node.Path()); pkg != nil {
+			inspection := filetree.Inspection{Inspector: "package"}
+			inspection.Add("Package", pkg.String())
+			inspection.Add("Package size", fmt.Sprintf("%s in %d files", humanize.Bytes(pkg.SizeBytes), len(pkg.Files)))
+			inspections = append(inspections, inspection)
+		}
+	}
+	if len(inspections) == 0 {
+		v.path, v.inspections = "", nil
+		return nil
+	}
+	v.path, v.inspections = node.Path(), inspections
+	return v.Render()
+}
+
+// IsVisible indicates if the file details pane is shown (only when the selected file was inspected or has attributes).
+func (v *FileDetails) IsVisible() bool {
+	return v != nil && len(v.inspections) > 0
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *FileDetails) Height() int {
+	if count := len(v.lines()); count < maxFileDetailsHeight {
+		return count
+	}
+	return maxFileDetailsHeight
+}
+
+// lines renders every finding as "Name: value", with the names aligned.
+func (v *FileDetails) lines() []string {
+	var width int
+	for _, inspection := range v.inspections {
+		for _, field := range inspection.Fields {
+			if len(field.Name) > width {
+				width = len(field.Name)
+			}
+		}
+	}
+
+	var lines []string
+	for _, inspection := range v.inspections {
+		for _, field := range inspection.Fields {
+			label := fmt.Sprintf("%-*s", width+1, field.Name+":")
+			lines = append(lines, format.Header(label)+" "+field.Value)
+		}
+	}
+	return lines
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *FileDetails) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *FileDetails) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *FileDetails) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// the pane is removed from the layout when a file without inspections or attributes is selected
+		if v.view == nil || v.header == nil || !v.IsVisible() {
+			return nil
+		}
+
+		names := make([]string, 0, len(v.inspections))
+		for _, inspection := range v.inspections {
+			names = append(names, inspection.Inspector)
+		}
+
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		title := fmt.Sprintf("File Details: %s (%s)", path.Base(v.path), strings.Join(names, ", "))
+		_, err := fmt.Fprintln(v.header, v.symbols.RenderHeader(title, width, false))
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -3,47 +3,105 @@ package view
 import (
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/metrics"
 	"github.com/wagoodman/dive/runtime/ui/format"
 	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
 	"github.com/wagoodman/dive/runtime/ui/viewmodel"
-	"github.com/wagoodman/dive/utils"
 )
 
 type ViewOptionChangeListener func() error
 
+// MarkChangeListener is notified with all marked paths whenever a file is marked or unmarked.
+type MarkChangeListener func(paths []string) error
+
+// KeepListExportListener is notified with the kept paths when the user asks to export the keep list.
+type KeepListExportListener func(paths []string) error
+
+// GoToPathRequestListener is notified with the selected path when the user asks to type a path to move the cursor to.
+type GoToPathRequestListener func(selected string) error
+
+// ProvenanceListener is notified with the selected path when the user asks for the layers that changed it.
+type ProvenanceListener func(path string) error
+
+// PreviewListener is notified with the selected path when the user asks for a preview of the image file.
+type PreviewListener func(path string) error
+
+// ArchiveListener is notified with the selected path when the user asks to browse the archive file.
+type ArchiveListener func(path string) error
+
+// PackagesListener is notified with the selected path (empty when there is none) when the user asks for the files
+// rolled up by package.
+type PackagesListener func(path string) error
+
+// PivotListener is notified with the paths of the files that pass the filter when the user asks for the files by
+// layer table.
+type PivotListener func(paths []string) error
+
+// SelectionChangeListener is notified with the selected FileNode (nil when there is none) whenever the tree is rendered.
+type SelectionChangeListener func(node *filetree.FileNode) error
+
+// OpenFileListener is notified with the selected path when the user asks to open the file in the pager (or editor).
+type OpenFileListener func(path string, edit bool) error
+
 // FileTree holds the UI objects and data models for populating the right pane. Specifically the pane that
 // shows selected layer or aggregate file ASCII tree.
 type FileTree struct {
-	name   string
-	gui    *gocui.Gui
-	view   *gocui.View
-	header *gocui.View
-	vm     *viewmodel.FileTree
-	title  string
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	header  *gocui.View
+	vm      *viewmodel.FileTree
+	title   string
+	// times the rendering of the tree (nil when it is not timed)
+	recorder *metrics.Recorder
+	// the shown tree is stale until the tree of the selected layer has been computed
+	loading bool
+	// the layers of the image, whose creation times the modification times of the files are compared with
+	layers []*image.Layer
 
 	filterRegex         *regexp.Regexp
 	listeners           []ViewOptionChangeListener
+	markListeners       []MarkChangeListener
+	provenanceListeners []ProvenanceListener
+	openFileListeners   []OpenFileListener
+	previewListeners    []PreviewListener
+	selectionListeners  []SelectionChangeListener
+	archiveListeners    []ArchiveListener
+	pivotListeners      []PivotListener
+	packagesListeners   []PackagesListener
+	searchListeners     []SearchListener
+	goToPathListeners   []GoToPathRequestListener
+	keepListListeners   []KeepListExportListener
 	helpKeys            []*key.Binding
 	requestedWidthRatio float64
 }
 
 // newFileTreeView creates a new view object attached the the global [gocui] screen object.
-func newFileTreeView(gui *gocui.Gui, tree *filetree.FileTree, refTrees []*filetree.FileTree, cache filetree.Comparer) (controller *FileTree, err error) {
+func newFileTreeView(gui *gocui.Gui, tree *filetree.FileTree, refTrees []*filetree.FileTree, cache filetree.Comparer, bookmarks *viewmodel.Bookmarks, render filetree.RenderOptions, symbols format.Symbols, recorder *metrics.Recorder) (controller *FileTree, err error) {
 	controller = new(FileTree)
 	controller.listeners = make([]ViewOptionChangeListener, 0)
 
 	// populate main fields
 	controller.name = "filetree"
 	controller.gui = gui
+	controller.symbols = symbols
+	controller.recorder = recorder
 	controller.vm, err = viewmodel.NewFileTreeViewModel(tree, refTrees, cache)
 	if err != nil {
 		return nil, err
 	}
+	controller.vm.Bookmarks = bookmarks
+	controller.vm.RenderOptions = render
+	controller.vm.Symbols = symbols
 
 	requestedWidthRatio := viper.GetFloat64("filetree.pane-width")
 	if requestedWidthRatio >= 1 || requestedWidthRatio <= 0 {
@@ -59,6 +117,80 @@ func (v *FileTree) AddViewOptionChangeListener(listener ...ViewOptionChangeListe
 	v.listeners = append(v.listeners, listener...)
 }
 
+func (v *FileTree) AddMarkChangeListener(listener ...MarkChangeListener) {
+	v.markListeners = append(v.markListeners, listener...)
+}
+
+func (v *FileTree) AddProvenanceListener(listener ...ProvenanceListener) {
+	v.provenanceListeners = append(v.provenanceListeners, listener...)
+}
+
+func (v *FileTree) AddPreviewListener(listener ...PreviewListener) {
+	v.previewListeners = append(v.previewListeners, listener...)
+}
+
+func (v *FileTree) AddOpenFileListener(listener ...OpenFileListener) {
+	v.openFileListeners = append(v.openFileListeners, listener...)
+}
+
+func (v *FileTree) AddArchiveListener(listener ...ArchiveListener) {
+	v.archiveListeners = append(v.archiveListeners, listener...)
+}
+
+func (v *FileTree) AddPackagesListener(listener ...PackagesListener) {
+	v.packagesListeners = append(v.packagesListeners, listener...)
+}
+
+func (v *FileTree) AddPivotListener(listener ...PivotListener) {
+	v.pivotListeners = append(v.pivotListeners, listener...)
+}
+
+func (v *FileTree) AddSearchListener(listener ...SearchListener) {
+	v.searchListeners = append(v.searchListeners, listener...)
+}
+
+func (v *FileTree) AddGoToPathListener(listener ...GoToPathRequestListener) {
+	v.goToPathListeners = append(v.goToPathListeners, listener...)
+}
+
+func (v *FileTree) AddKeepListExportListener(listener ...KeepListExportListener) {
+	v.keepListListeners = append(v.keepListListeners, listener...)
+}
+
+func (v *FileTree) AddSelectionChangeListener(listener ...SelectionChangeListener) {
+	v.selectionListeners = append(v.selectionListeners, listener...)
+}
+
+// SetVulnerable marks the files of the vulnerable packages of a scanner report (nil marks none).
+func (v *FileTree) SetVulnerable(vulnerable map[string]*image.VulnerablePackage) {
+	v.vm.Vulnerable = vulnerable
+}
+
+// SetDoomed highlights the given files, which a later layer deletes or overwrites (nil highlights none).
+func (v *FileTree) SetDoomed(doomed map[string]image.DoomedFile) {
+	v.vm.Doomed = doomed
+}
+
+// SetLayerSize gives the size of the selected layer, which the size column may show percentages of.
+func (v *FileTree) SetLayerSize(size int64) {
+	v.vm.LayerSize = size
+}
+
+// SetImageSize gives the size of the image, which the size column may show percentages of.
+func (v *FileTree) SetImageSize(size int64) {
+	v.vm.ImageSize = size
+}
+
+// SetLayers gives the layers of the image, which the filter on modification times buckets the files of.
+func (v *FileTree) SetLayers(layers []*image.Layer) {
+	v.layers = layers
+}
+
+// SetMtimeLayer gives the last layer of the shown tree, as of which the files are filtered by modification time.
+func (v *FileTree) SetMtimeLayer(index int) {
+	v.vm.MtimeLayer = index
+}
+
 func (v *FileTree) SetTitle(title string) {
 	v.title = title
 }
@@ -97,6 +229,46 @@ func (v *FileTree) Setup(view *gocui.View, header *gocui.View) error {
 			OnAction:   v.toggleCollapseAll,
 			Display:    "Collapse all dir",
 		},
+		{
+			ConfigKeys: []string{"keybinding.collapse-all-dir"},
+			OnAction:   v.collapseAll,
+		},
+		{
+			ConfigKeys: []string{"keybinding.expand-all-dir"},
+			OnAction:   v.expandAll,
+		},
+		{
+			ConfigKeys: []string{"keybinding.expand-depth"},
+			OnAction:   func() error { return v.changeDepth(1) },
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.collapse-depth"},
+			OnAction:   func() error { return v.changeDepth(-1) },
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.expand-to-depth"},
+			Counted:    v.expandToDepth,
+		},
+		{
+			ConfigKeys: []string{"keybinding.collapse-siblings"},
+			OnAction:   v.collapseSiblings,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cycle-size-format"},
+			OnAction:   v.cycleSizeFormat,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cycle-mtime-filter"},
+			OnAction:   v.cycleMtimeFilter,
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-file-counts"},
+			OnAction:   v.toggleFileCounts,
+			IsSelected: func() bool { return v.vm.ShowFileCounts },
+			Display:    "Files",
+		},
 		{
 			ConfigKeys: []string{"keybinding.toggle-added-files"},
 			OnAction:   func() error { return v.toggleShowDiffType(filetree.Added) },
@@ -133,33 +305,147 @@ func (v *FileTree) Setup(view *gocui.View, header *gocui.View) error {
 			IsSelected: func() bool { return v.view.Wrap },
 			Display:    "Wrap",
 		},
+		{
+			ConfigKeys: []string{"keybinding.follow-link"},
+			OnAction:   v.followLink,
+		},
+		{
+			ConfigKeys: []string{"keybinding.copy-path"},
+			OnAction:   v.copyPath,
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-mark"},
+			OnAction:   v.toggleMark,
+			Display:    "Mark",
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-keep"},
+			OnAction:   v.toggleKeep,
+			Display:    "Keep",
+		},
+		{
+			ConfigKeys: []string{"keybinding.export-keep-list"},
+			OnAction:   v.exportKeepList,
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-provenance"},
+			OnAction:   v.showProvenance,
+			Display:    "Provenance",
+		},
+		{
+			ConfigKeys: []string{"keybinding.preview-file"},
+			OnAction:   v.showPreview,
+		},
+		{
+			ConfigKeys: []string{"keybinding.view-file"},
+			OnAction:   func() error { return v.openFile(false) },
+		},
+		{
+			ConfigKeys: []string{"keybinding.edit-file"},
+			OnAction:   func() error { return v.openFile(true) },
+		},
+		{
+			ConfigKeys: []string{"keybinding.browse-archive"},
+			OnAction:   v.browseArchive,
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-pivot"},
+			OnAction:   v.showPivot,
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-packages"},
+			OnAction:   v.showPackages,
+		},
+		{
+			ConfigKeys: []string{"keybinding.next-mark"},
+			OnAction:   func() error { return v.jumpToMark(true) },
+		},
+		{
+			ConfigKeys: []string{"keybinding.previous-mark"},
+			OnAction:   func() error { return v.jumpToMark(false) },
+		},
 		{
 			ConfigKeys: []string{"keybinding.page-up"},
 			OnAction:   v.PageUp,
+			Repeat:     true,
 		},
 		{
 			ConfigKeys: []string{"keybinding.page-down"},
 			OnAction:   v.PageDown,
+			Repeat:     true,
 		},
 		{
 			Key:      gocui.KeyArrowDown,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorDown,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowUp,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorUp,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowLeft,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorLeft,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowRight,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorRight,
+			Repeat:   true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-down"},
+			OnAction:   v.CursorDown,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-up"},
+			OnAction:   v.CursorUp,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-left"},
+			OnAction:   v.CursorLeft,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-right"},
+			OnAction:   v.CursorRight,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-top"},
+			OnAction:   v.CursorTop,
+			Double:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-bottom"},
+			OnAction:   v.CursorBottom,
+		},
+		{
+			ConfigKeys: []string{"keybinding.search"},
+			OnAction:   func() error { return v.notifySearchListeners(true, true) },
+			Display:    "Search",
+		},
+		{
+			ConfigKeys: []string{"keybinding.next-match"},
+			OnAction:   func() error { return v.notifySearchListeners(false, true) },
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.previous-match"},
+			OnAction:   func() error { return v.notifySearchListeners(false, false) },
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.go-to-path"},
+			OnAction:   v.notifyGoToPathListeners,
+			Display:    "Go to",
 		},
 	}
 
@@ -169,6 +455,13 @@ func (v *FileTree) Setup(view *gocui.View, header *gocui.View) error {
 	}
 	v.helpKeys = helpKeys
 
+	if err := key.BindCount(v.gui, v.name); err != nil {
+		return err
+	}
+	if err := key.BindDigits(v.gui, v.name, v.expandToDepth); err != nil {
+		return err
+	}
+
 	_, height := v.view.Size()
 	v.vm.Setup(0, height)
 	_ = v.Update()
@@ -199,6 +492,27 @@ func (v *FileTree) SetTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTre
 	return v.Render()
 }
 
+// LayerTree stacks the indicated image layer file trees without showing them (see viewmodel.FileTree.LayerTree).
+func (v *FileTree) LayerTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) (*filetree.FileTree, error) {
+	return v.vm.LayerTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop)
+}
+
+// ShowTree replaces the shown tree with the given layer tree (as returned by LayerTree) and renders the view.
+func (v *FileTree) ShowTree(tree *filetree.FileTree) error {
+	v.loading = false
+	if err := v.vm.SetTree(tree); err != nil {
+		return err
+	}
+
+	_ = v.Update()
+	return v.Render()
+}
+
+// SetLoading marks the shown tree as stale while the tree of a newly selected layer is computed.
+func (v *FileTree) SetLoading(loading bool) {
+	v.loading = loading
+}
+
 // CursorDown moves the cursor down and renders the view.
 // Note: we cannot use the gocui buffer since any state change requires writing the entire tree to the buffer.
 // Instead we are keeping an upper and lower bounds of the tree string to render and only flushing
@@ -241,6 +555,59 @@ func (v *FileTree) CursorRight() error {
 	return v.Render()
 }
 
+// CursorTop moves the cursor to the first row.
+func (v *FileTree) CursorTop() error {
+	v.vm.CursorTop()
+	return v.Render()
+}
+
+// CursorBottom moves the cursor to the last row.
+func (v *FileTree) CursorBottom() error {
+	v.vm.CursorBottom()
+	return v.Render()
+}
+
+// Search moves the cursor to the next (or previous) path containing the query.
+func (v *FileTree) Search(query string, forward bool) (bool, error) {
+	found, err := v.vm.Search(query, v.filterRegex, forward)
+	if err != nil || !found {
+		return found, err
+	}
+	_ = v.Update()
+	return true, v.Render()
+}
+
+func (v *FileTree) notifySearchListeners(prompt, forward bool) error {
+	for _, listener := range v.searchListeners {
+		if err := listener(v, prompt, forward); err != nil {
+			logrus.Errorf("notifySearchListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// GoToPath moves the cursor to the given absolute path, reporting whether it is shown in the tree.
+func (v *FileTree) GoToPath(path string) (bool, error) {
+	found, err := v.vm.GoToPath(path, v.filterRegex)
+	if err != nil || !found {
+		return found, err
+	}
+	_ = v.Update()
+	return true, v.Render()
+}
+
+func (v *FileTree) notifyGoToPathListeners() error {
+	selected := v.vm.SelectedPath(v.filterRegex)
+	for _, listener := range v.goToPathListeners {
+		if err := listener(selected); err != nil {
+			logrus.Errorf("notifyGoToPathListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
 // PageDown moves to next page putting the cursor on top
 func (v *FileTree) PageDown() error {
 	err := v.vm.PageDown()
@@ -287,9 +654,254 @@ func (v *FileTree) toggleCollapseAll() error {
 	return v.Render()
 }
 
+// collapseAll collapses every directory.
+func (v *FileTree) collapseAll() error {
+	err := v.vm.CollapseAllDirs(v.filterRegex)
+	if err != nil {
+		return err
+	}
+	_ = v.Update()
+	return v.Render()
+}
+
+// expandAll expands every directory.
+func (v *FileTree) expandAll() error {
+	err := v.vm.ExpandAllDirs(v.filterRegex)
+	if err != nil {
+		return err
+	}
+	_ = v.Update()
+	return v.Render()
+}
+
+// changeDepth shows the paths one level deeper (or shallower).
+func (v *FileTree) changeDepth(delta int) error {
+	depth, err := v.vm.ChangeDepth(delta, v.filterRegex)
+	if err != nil {
+		return err
+	}
+	return v.onDepthChange(depth)
+}
+
+// expandToDepth shows the paths up to the given depth (the top-level paths for 0, when no count was typed).
+func (v *FileTree) expandToDepth(depth int) error {
+	depth, err := v.vm.ExpandToDepth(depth, v.filterRegex)
+	if err != nil {
+		return err
+	}
+	return v.onDepthChange(depth)
+}
+
+func (v *FileTree) onDepthChange(depth int) error {
+	levels := "levels"
+	if depth == 1 {
+		levels = "level"
+	}
+	v.vm.Status.Notify(fmt.Sprintf("Showing %d %s", depth, levels))
+	_ = v.Update()
+	return v.Render()
+}
+
+// collapseSiblings collapses the directories next to the selected node.
+func (v *FileTree) collapseSiblings() error {
+	err := v.vm.CollapseSiblings(v.filterRegex)
+	if err != nil {
+		return err
+	}
+	_ = v.Update()
+	return v.Render()
+}
+
+// cycleSizeFormat shows the sizes in the next format (units, bytes or percentages).
+func (v *FileTree) cycleSizeFormat() error {
+	sizeFormat := v.vm.CycleSizeFormat()
+	v.vm.Status.Notify("Sizes in " + sizeFormat.Description())
+	return v.Render()
+}
+
+// cycleMtimeFilter shows the files of the next bucket of modification times only (built during the layer build, older,
+// clamped...), then every file again. The files are bucketed the first time.
+func (v *FileTree) cycleMtimeFilter() error {
+	if v.vm.Mtimes == nil {
+		v.vm.Mtimes = image.NewFileMtimes(v.layers)
+	}
+	if bucket := v.vm.CycleMtimeFilter(); bucket != "" {
+		v.vm.Status.Notify("Showing the files " + image.MtimeDescription(bucket))
+	} else {
+		v.vm.Status.Notify("Showing every file")
+	}
+	if err := v.Update(); err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// toggleFileCounts shows/hides the number of files within each directory.
+func (v *FileTree) toggleFileCounts() error {
+	v.vm.ToggleFileCounts()
+	return v.Render()
+}
+
+// followLink moves the cursor to the target of the selected symlink or hardlink.
+func (v *FileTree) followLink() error {
+	err := v.vm.FollowLink(v.filterRegex)
+	if err != nil {
+		return err
+	}
+	_ = v.Update()
+	return v.Render()
+}
+
+// copyPath copies the absolute path of the selected FileNode to the clipboard (of the terminal, which also works over
+// ssh, or of the desktop).
+func (v *FileTree) copyPath() error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	return terminal.Copy(path)
+}
+
+// toggleMark marks the selected FileNode (or removes its mark).
+func (v *FileTree) toggleMark() error {
+	path, marked := v.vm.ToggleMark(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	logrus.Debugf("marked %s: %v", path, marked)
+
+	for _, listener := range v.markListeners {
+		if err := listener(v.vm.Bookmarks.Paths()); err != nil {
+			logrus.Errorf("notifyOnMarkChangeListeners error: %+v", err)
+			return err
+		}
+	}
+	return v.Render()
+}
+
+// toggleKeep keeps the selected FileNode when slimming the image (or stops keeping it).
+func (v *FileTree) toggleKeep() error {
+	path, kept := v.vm.ToggleKeep(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	if kept {
+		v.vm.Status.Notify(fmt.Sprintf("Keeping %s (%d kept)", path, len(v.vm.KeepList.Paths())))
+	} else {
+		v.vm.Status.Notify(fmt.Sprintf("No longer keeping %s", path))
+	}
+	return v.Render()
+}
+
+// exportKeepList hands the kept paths over to the listeners (which write the slimming manifest).
+func (v *FileTree) exportKeepList() error {
+	for _, listener := range v.keepListListeners {
+		if err := listener(v.vm.KeepList.Paths()); err != nil {
+			logrus.Errorf("notifyKeepListExportListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// jumpToMark moves the cursor to the next (or previous) marked file.
+func (v *FileTree) jumpToMark(forward bool) error {
+	err := v.vm.JumpToMark(v.filterRegex, forward)
+	if err != nil {
+		return err
+	}
+	_ = v.Update()
+	return v.Render()
+}
+
+// showProvenance lists every layer that added, modified or deleted the selected FileNode.
+func (v *FileTree) showProvenance() error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	for _, listener := range v.provenanceListeners {
+		if err := listener(path); err != nil {
+			logrus.Errorf("notifyOnProvenanceListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// showPreview draws the selected image file within the terminal.
+func (v *FileTree) showPreview() error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	for _, listener := range v.previewListeners {
+		if err := listener(path); err != nil {
+			logrus.Errorf("notifyOnPreviewListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// openFile shows the contents of the selected FileNode in the pager (or editor).
+func (v *FileTree) openFile(edit bool) error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	for _, listener := range v.openFileListeners {
+		if err := listener(path, edit); err != nil {
+			logrus.Errorf("notifyOnOpenFileListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// browseArchive lists the contents of the selected archive file (a tarball, zip file or jar).
+func (v *FileTree) browseArchive() error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	if path == "" {
+		return nil
+	}
+	for _, listener := range v.archiveListeners {
+		if err := listener(path); err != nil {
+			logrus.Errorf("notifyOnArchiveListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// showPivot tabulates the files that pass the filter by the layers that changed them.
+func (v *FileTree) showPivot() error {
+	paths := v.vm.VisiblePaths()
+	for _, listener := range v.pivotListeners {
+		if err := listener(paths); err != nil {
+			logrus.Errorf("notifyOnPivotListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// showPackages rolls the files of the image up by the package that installed them.
+func (v *FileTree) showPackages() error {
+	path := v.vm.SelectedPath(v.filterRegex)
+	for _, listener := range v.packagesListeners {
+		if err := listener(path); err != nil {
+			logrus.Errorf("notifyOnPackagesListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (v *FileTree) toggleWrapTree() error {
 	v.view.Wrap = !v.view.Wrap
-	return nil
+	v.vm.WrapLines = v.view.Wrap
+	return v.Render()
 }
 
 func (v *FileTree) notifyOnViewOptionChangeListeners() error {
@@ -303,6 +915,18 @@ func (v *FileTree) notifyOnViewOptionChangeListeners() error {
 	return nil
 }
 
+func (v *FileTree) notifyOnSelectionChangeListeners() error {
+	node := v.vm.SelectedNode(v.filterRegex)
+	for _, listener := range v.selectionListeners {
+		err := listener(node)
+		if err != nil {
+			logrus.Errorf("notifyOnSelectionChangeListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
 // ToggleAttributes will show/hide file attributes
 func (v *FileTree) toggleAttributes() error {
 	err := v.vm.ToggleAttributes()
@@ -365,28 +989,46 @@ func (v *FileTree) Update() error {
 
 // Render flushes the state objects (file tree) to the pane.
 func (v *FileTree) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
 
 	title := v.title
+	if v.vm.MtimeFilter != "" {
+		title += " (" + image.MtimeDescription(v.vm.MtimeFilter) + ")"
+	}
+	if v.loading {
+		title += " (loading...)"
+	}
 	isSelected := v.gui.CurrentView() == v.view
 
+	// every change of the selection ends up rendering the tree
+	if err := v.notifyOnSelectionChangeListeners(); err != nil {
+		return err
+	}
+
 	v.gui.Update(func(g *gocui.Gui) error {
 		// update the header
 		v.header.Clear()
 		width, _ := g.Size()
-		headerStr := format.RenderHeader(title, width, isSelected)
+		headerStr := v.symbols.RenderHeader(title, width, isSelected)
+		headerStr += breadcrumb(v.vm.SelectedPath(v.filterRegex), width) + "\n"
 		if v.vm.ShowAttributes {
-			headerStr += fmt.Sprintf(filetree.AttributeFormat+" %s", "P", "ermission", "UID:GID", "Size", "Filetree")
+			headerStr += fmt.Sprintf(filetree.AttributeFormat+" ", "P", "ermission", "UID:GID", v.vm.SizeFormat.Label())
+			if v.vm.ShowFileCounts {
+				headerStr += fmt.Sprintf(filetree.FileCountFormat, "Files")
+			}
+			headerStr += "Filetree"
 		}
 		_, _ = fmt.Fprintln(v.header, headerStr)
 
 		// update the contents
 		v.view.Clear()
+		start := time.Now()
 		err := v.vm.Render()
 		if err != nil {
 			return err
 		}
 		_, err = fmt.Fprint(v.view, v.vm.Buffer.String())
+		v.recorder.Since("render file tree", "", start)
 
 		return err
 	})
@@ -397,13 +1039,13 @@ func (v *FileTree) Render() error {
 func (v *FileTree) KeyHelp() string {
 	var help string
 	for _, binding := range v.helpKeys {
-		help += binding.RenderKeyHelp()
+		help += binding.RenderKeyHelp(v.symbols)
 	}
 	return help
 }
 
 func (v *FileTree) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
-	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
 	attributeRowSize := 0
 
 	// make the layout responsive to the available realestate. Make more room for the main content by hiding auxillary
@@ -418,14 +1060,14 @@ func (v *FileTree) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
 		attributeRowSize = 1
 	}
 
-	// header + attribute header
-	headerSize := 1 + attributeRowSize
+	// header + breadcrumb + attribute header
+	headerSize := 2 + attributeRowSize
 	// note: maxY needs to account for the (invisible) border, thus a +1
 	header, headerErr := g.SetView(v.Name()+"header", minX, minY, maxX, minY+headerSize+1, 0)
 	// we are going to overlap the view over the (invisible) border (so minY will be one less than expected).
 	// additionally, maxY will be bumped by one to include the border
 	view, viewErr := g.SetView(v.Name(), minX, minY+headerSize, maxX, maxY+1, 0)
-	if utils.IsNewView(viewErr, headerErr) {
+	if IsNewView(viewErr, headerErr) {
 		err := v.Setup(view, header)
 		if err != nil {
 			logrus.Error("unable to setup tree controller", err)
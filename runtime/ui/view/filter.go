@@ -6,29 +6,44 @@ import (
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/filterhistory"
 	"github.com/wagoodman/dive/runtime/ui/format"
-	"github.com/wagoodman/dive/utils"
+	"github.com/wagoodman/dive/runtime/ui/key"
 )
 
 type FilterEditListener func(string) error
 
+// FilterCommitListener is notified with a filter worth remembering: submitted with enter, or shown when the pane was
+// hidden.
+type FilterCommitListener func(string) error
+
 // Filter holds the UI objects and data models for populating the bottom row. Specifically the pane that
 // allows the user to filter the file tree by path.
 type Filter struct {
 	name            string
 	gui             *gocui.Gui
+	symbols         format.Symbols
 	view            *gocui.View
 	header          *gocui.View
 	labelStr        string
 	maxLength       int
 	hidden          bool
 	requestedHeight int
-
-	filterEditListeners []FilterEditListener
+	initialValue    string
+	// the filters typed before (the most recent first), recalled with the up and down arrows
+	history []string
+	// the position within the history of the filter shown (-1 for the filter being typed)
+	historyIdx int
+	// the filter being typed, shown again when going back down past the history
+	draft string
+
+	filterEditListeners   []FilterEditListener
+	filterCommitListeners []FilterCommitListener
 }
 
 // newFilterView creates a new view object attached the the global [gocui] screen object.
-func newFilterView(gui *gocui.Gui) (controller *Filter) {
+func newFilterView(gui *gocui.Gui, symbols format.Symbols) (controller *Filter) {
 	controller = new(Filter)
 
 	controller.filterEditListeners = make([]FilterEditListener, 0)
@@ -36,8 +51,12 @@ func newFilterView(gui *gocui.Gui) (controller *Filter) {
 	// populate main fields
 	controller.name = "filter"
 	controller.gui = gui
+	controller.symbols = symbols
 	controller.labelStr = "Path Filter: "
-	controller.hidden = true
+	// a configured filter is shown (and applied) from the start
+	controller.initialValue = viper.GetString("filetree.filter")
+	controller.hidden = controller.initialValue == ""
+	controller.historyIdx = -1
 
 	controller.requestedHeight = 1
 
@@ -48,6 +67,21 @@ func (v *Filter) AddFilterEditListener(listener ...FilterEditListener) {
 	v.filterEditListeners = append(v.filterEditListeners, listener...)
 }
 
+func (v *Filter) AddFilterCommitListener(listener ...FilterCommitListener) {
+	v.filterCommitListeners = append(v.filterCommitListeners, listener...)
+}
+
+// SetHistory sets the filters typed before (the most recent first), which the up and down arrows recall.
+func (v *Filter) SetHistory(filters []string) {
+	v.history = filters
+	v.historyIdx = -1
+}
+
+// History returns the filters typed before, the most recent first.
+func (v *Filter) History() []string {
+	return v.history
+}
+
 func (v *Filter) Name() string {
 	return v.name
 }
@@ -64,6 +98,17 @@ func (v *Filter) Setup(view *gocui.View, header *gocui.View) error {
 	v.view.Editable = true
 	v.view.Editor = v
 
+	if v.initialValue != "" {
+		_, err := fmt.Fprint(v.view, v.initialValue)
+		if err != nil {
+			return err
+		}
+		err = v.view.SetCursor(len(v.initialValue), 0)
+		if err != nil {
+			logrus.Debug("unable to move the filter cursor: ", err)
+		}
+	}
+
 	v.header = header
 	v.header.BgColor = gocui.AttrReverse
 	v.header.Editable = false
@@ -75,6 +120,12 @@ func (v *Filter) Setup(view *gocui.View, header *gocui.View) error {
 
 // ToggleFilterView shows/hides the file tree filter pane.
 func (v *Filter) ToggleVisible() error {
+	// the filter shown is remembered before the user input is deleted
+	if !v.hidden {
+		v.commit()
+	}
+	v.historyIdx = -1
+
 	// delete all user input from the tree view
 	v.view.Clear()
 
@@ -96,6 +147,77 @@ func (v *Filter) ToggleVisible() error {
 	return v.view.SetCursor(0, 0)
 }
 
+// SetValue filters the file tree by the given value as if it was typed into the filter pane, which is shown (an empty
+// value hides the pane and clears the filter).
+func (v *Filter) SetValue(value string) error {
+	if v.IsVisible() == (value == "") {
+		if err := v.ToggleVisible(); err != nil {
+			return err
+		}
+	}
+	if err := v.write(value); err != nil {
+		return err
+	}
+	v.notifyFilterEditListeners()
+	return nil
+}
+
+// write replaces the user input with the given value, with the cursor at its end.
+func (v *Filter) write(value string) error {
+	v.view.Clear()
+	if value == "" {
+		return v.view.SetCursor(0, 0)
+	}
+	if _, err := fmt.Fprint(v.view, value); err != nil {
+		return err
+	}
+	if err := v.view.SetCursor(len(value), 0); err != nil {
+		logrus.Debug("unable to move the filter cursor: ", err)
+	}
+	return nil
+}
+
+// recall shows the filter typed before the one shown (older is 1) or after it (older is -1), going back to the filter
+// being typed past the most recent one.
+func (v *Filter) recall(older int) {
+	idx := v.historyIdx + older
+	if idx < -1 || idx >= len(v.history) {
+		return
+	}
+	if v.historyIdx == -1 {
+		v.draft = strings.TrimSpace(v.view.Buffer())
+	}
+	v.historyIdx = idx
+
+	value := v.draft
+	if idx >= 0 {
+		value = v.history[idx]
+	}
+	if err := v.write(value); err != nil {
+		logrus.Debug("unable to recall the filter: ", err)
+	}
+}
+
+// commit remembers the filter shown (if any) as the most recent one, and notifies the listeners.
+func (v *Filter) commit() {
+	value := strings.TrimSpace(v.view.Buffer())
+	v.historyIdx = -1
+	if value == "" {
+		return
+	}
+	v.history = filterhistory.Prepend(v.history, value)
+	for _, listener := range v.filterCommitListeners {
+		if err := listener(value); err != nil {
+			logrus.Errorf("notifyFilterCommitListeners: %+v", err)
+		}
+	}
+}
+
+// InitialValue is the configured path filter that the file tree is filtered by from the start (if any).
+func (v *Filter) InitialValue() string {
+	return v.initialValue
+}
+
 // IsVisible indicates if the filter view pane is currently initialized
 func (v *Filter) IsVisible() bool {
 	if v == nil {
@@ -105,7 +227,7 @@ func (v *Filter) IsVisible() bool {
 }
 
 // Edit intercepts the key press events in the filer view to update the file view in real time.
-func (v *Filter) Edit(view *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+func (v *Filter) Edit(view *gocui.View, k gocui.Key, ch rune, mod gocui.Modifier) {
 	if !v.IsVisible() {
 		return
 	}
@@ -116,12 +238,26 @@ func (v *Filter) Edit(view *gocui.View, key gocui.Key, ch rune, mod gocui.Modifi
 	switch {
 	case ch != 0 && mod == 0 && !limit:
 		view.EditWrite(ch)
-	case key == gocui.KeySpace && !limit:
+		v.historyIdx = -1
+	case k == gocui.KeySpace && !limit:
 		view.EditWrite(' ')
-	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		v.historyIdx = -1
+	case k == gocui.KeyBackspace || k == gocui.KeyBackspace2:
 		view.EditDelete(true)
+		v.historyIdx = -1
+	case k == gocui.KeyArrowUp:
+		v.recall(1)
+	case k == gocui.KeyArrowDown:
+		v.recall(-1)
+	case k == gocui.KeyEnter:
+		v.commit()
 	}
 
+	key.RecordStep(func() error {
+		v.Edit(v.view, k, ch, mod)
+		return nil
+	})
+
 	// notify listeners
 	v.notifyFilterEditListeners()
 }
@@ -144,7 +280,7 @@ func (v *Filter) Update() error {
 
 // Render flushes the state objects to the screen. Currently this is the users path filter input.
 func (v *Filter) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
 
 	v.gui.Update(func(g *gocui.Gui) error {
 		_, err := fmt.Fprintln(v.header, format.Header(v.labelStr))
@@ -158,7 +294,7 @@ func (v *Filter) Render() error {
 
 // KeyHelp indicates all the possible actions a user can take while the current pane is selected.
 func (v *Filter) KeyHelp() string {
-	return format.StatusControlNormal("▏Type to filter the file tree ")
+	return format.StatusControlNormal(v.symbols.StatusSeparator + "Type to filter the file tree, up/down for earlier filters ")
 }
 
 // OnLayoutChange is called whenever the screen dimensions are changed
@@ -171,12 +307,12 @@ func (v *Filter) OnLayoutChange() error {
 }
 
 func (v *Filter) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
-	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
 
 	label, labelErr := g.SetView(v.Name()+"label", minX, minY, len(v.labelStr), maxY, 0)
 	view, viewErr := g.SetView(v.Name(), minX+(len(v.labelStr)-1), minY, maxX, maxY, 0)
 
-	if utils.IsNewView(viewErr, labelErr) {
+	if IsNewView(viewErr, labelErr) {
 		err := v.Setup(view, label)
 		if err != nil {
 			logrus.Error("unable to setup status controller", err)
@@ -0,0 +1,222 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// GoToPathListener is notified with the typed path (empty when the input was cancelled).
+type GoToPathListener func(path string) error
+
+// GoToPath holds the UI objects for the bottom row an absolute path is typed into, to move the file tree cursor to it.
+type GoToPath struct {
+	name      string
+	gui       *gocui.Gui
+	symbols   format.Symbols
+	view      *gocui.View
+	header    *gocui.View
+	labelStr  string
+	maxLength int
+	hidden    bool
+
+	requestedHeight int
+
+	submitListeners []GoToPathListener
+}
+
+// newGoToPathView creates a new view object attached the the global [gocui] screen object.
+func newGoToPathView(gui *gocui.Gui, symbols format.Symbols) (controller *GoToPath) {
+	controller = new(GoToPath)
+
+	// populate main fields
+	controller.name = "go-to-path"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.labelStr = "Go to: "
+	controller.hidden = true
+	controller.requestedHeight = 1
+
+	return controller
+}
+
+func (v *GoToPath) AddSubmitListener(listener ...GoToPathListener) {
+	v.submitListeners = append(v.submitListeners, listener...)
+}
+
+func (v *GoToPath) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *GoToPath) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.maxLength = 4096
+	v.view.Frame = false
+	v.view.BgColor = gocui.AttrReverse
+	v.view.Editable = true
+	v.view.Editor = v
+
+	v.header = header
+	v.header.BgColor = gocui.AttrReverse
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.submit,
+		},
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Open shows the input row (taking focus), starting from the given path (e.g. the selected one) to edit it.
+func (v *GoToPath) Open(path string) error {
+	v.hidden = false
+	if v.view == nil {
+		return nil
+	}
+	v.view.Clear()
+	if err := v.view.SetCursor(0, 0); err != nil {
+		logrus.Debug("unable to move the input cursor: ", err)
+	}
+	if err := v.view.SetOrigin(0, 0); err != nil {
+		logrus.Debug("unable to move the input origin: ", err)
+	}
+	// the path is typed in once the row is laid out with its width, so that the end of a long path is shown
+	v.gui.Update(func(*gocui.Gui) error {
+		for _, ch := range path {
+			v.view.EditWrite(ch)
+		}
+		return nil
+	})
+	_, err := v.gui.SetCurrentView(v.name)
+	return err
+}
+
+// submit hides the input row and hands the typed path over to the listeners (which move the cursor to it).
+func (v *GoToPath) submit() error {
+	return v.close(strings.TrimSpace(v.view.Buffer()))
+}
+
+// Close hides the input row without moving the cursor, and notifies the listeners (which move the focus back).
+func (v *GoToPath) Close() error {
+	return v.close("")
+}
+
+func (v *GoToPath) close(path string) error {
+	if v.hidden {
+		return nil
+	}
+	v.hidden = true
+
+	for _, listener := range v.submitListeners {
+		if err := listener(path); err != nil {
+			logrus.Errorf("notifySubmitListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVisible indicates if the input row is shown.
+func (v *GoToPath) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Edit intercepts the key press events in the input row.
+func (v *GoToPath) Edit(view *gocui.View, k gocui.Key, ch rune, mod gocui.Modifier) {
+	if !v.IsVisible() {
+		return
+	}
+
+	cx, _ := view.Cursor()
+	ox, _ := view.Origin()
+	limit := ox+cx+1 > v.maxLength
+	switch {
+	case ch != 0 && mod == 0 && !limit:
+		view.EditWrite(ch)
+	case k == gocui.KeySpace && !limit:
+		view.EditWrite(' ')
+	case k == gocui.KeyBackspace || k == gocui.KeyBackspace2:
+		view.EditDelete(true)
+	}
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *GoToPath) Update() error {
+	return nil
+}
+
+// Render flushes the state objects to the screen.
+func (v *GoToPath) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.header == nil {
+			return nil
+		}
+		v.header.Clear()
+		_, err := fmt.Fprintln(v.header, format.Header(v.labelStr))
+		if err != nil {
+			logrus.Error("unable to write to buffer: ", err)
+		}
+		return err
+	})
+	return nil
+}
+
+// KeyHelp indicates all the possible actions a user can take while the input row is focused.
+func (v *GoToPath) KeyHelp() string {
+	return format.StatusControlNormal(v.symbols.StatusSeparator + "Type an absolute path, enter to jump to it ")
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *GoToPath) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+func (v *GoToPath) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	label, labelErr := g.SetView(v.Name()+"label", minX, minY, len(v.labelStr), maxY, 0)
+	view, viewErr := g.SetView(v.Name(), minX+(len(v.labelStr)-1), minY, maxX, maxY, 0)
+
+	if IsNewView(viewErr, labelErr) {
+		err := v.Setup(view, label)
+		if err != nil {
+			logrus.Error("unable to setup go to path controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *GoToPath) RequestedSize(available int) *int {
+	return &v.requestedHeight
+}
@@ -0,0 +1,240 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// the widest the history popup gets (narrower screens get a narrower popup)
+const maxHistoryWidth = 140
+
+// History holds the UI objects and data models for populating the popup that shows the full image config history as
+// a timeline, including the instructions that made no filesystem changes (ENV, LABEL, EXPOSE...), aligned with the
+// layers they produced.
+type History struct {
+	popup
+
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	history []image.HistoryEntry
+	layers  []*image.Layer
+	// the index of the layer selected in the layer view (highlighted in the timeline)
+	selected int
+}
+
+// newHistoryView creates a new view object attached the the global [gocui] screen object.
+func newHistoryView(gui *gocui.Gui, history []image.HistoryEntry, layers []*image.Layer, symbols format.Symbols) (controller *History) {
+	controller = new(History)
+
+	// populate main fields
+	controller.name = "history"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.history = history
+	controller.layers = layers
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *History) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *History) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show opens the popup (taking focus), highlighting the instruction that produced the given layer.
+func (v *History) Show(layerIndex int) error {
+	v.selected = layerIndex
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *History) Close() error {
+	return v.closeView(v.view)
+}
+
+func (v *History) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if oy+delta < 0 || oy+delta+height > len(v.lines()) {
+		return nil
+	}
+	return v.view.SetOrigin(ox, oy+delta)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *History) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *History) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *History) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders the timeline, one instruction per line (the oldest first).
+func (v *History) lines() []string {
+	if len(v.history) == 0 {
+		return []string{"The image config has no history", "", "Press esc to close"}
+	}
+
+	empty := image.EmptyLayerCount(v.history)
+	lines := []string{
+		fmt.Sprintf("%d instructions, %d layers, %d without filesystem changes", len(v.history), len(v.history)-empty, empty),
+		"",
+		format.Header(fmt.Sprintf("%s  %5s  %-16s  %8s  %s", " ", "Layer", "Created", "Size", "Instruction")),
+	}
+	for _, entry := range v.history {
+		marker, layer, size := v.symbols.HistoryEmpty, "-", ""
+		if entry.LayerIndex >= 0 {
+			marker, layer = v.symbols.HistoryLayer, fmt.Sprintf("%d", entry.LayerIndex)
+			if entry.LayerIndex < len(v.layers) {
+				size = humanize.Bytes(v.layers[entry.LayerIndex].Size)
+			}
+		}
+		created := ""
+		if !entry.Created.IsZero() {
+			created = entry.Created.UTC().Format("2006-01-02 15:04")
+		}
+		line := fmt.Sprintf("%s  %5s  %-16s  %8s  %s", marker, layer, created, size, entry.Command())
+		if entry.LayerIndex >= 0 && entry.LayerIndex == v.selected {
+			line = format.Selected(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", fmt.Sprintf("%s produced a layer, %s made no filesystem changes. Press esc to close", v.symbols.HistoryLayer, v.symbols.HistoryEmpty))
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *History) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Image History "
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to its contents.
+func (v *History) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxHistoryWidth {
+		width = maxHistoryWidth
+	}
+	height := len(v.lines()) + 1
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup history controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *History) RequestedSize(available int) *int {
+	return nil
+}
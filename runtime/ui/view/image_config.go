@@ -0,0 +1,253 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// the widest the image config popup gets (narrower screens get a narrower popup)
+const maxImageConfigWidth = 140
+
+// the longest search query typed into the popup
+const maxImageConfigQuery = 64
+
+// ImageConfig holds the UI objects and data models for populating the popup that shows the runtime configuration of
+// the image (env, labels, exposed ports, entrypoint/cmd, user and healthcheck), narrowed down as the user types.
+type ImageConfig struct {
+	popup
+
+	name    string
+	gui     *gocui.Gui
+	view    *gocui.View
+	entries []image.ConfigEntry
+	query   string
+}
+
+// newImageConfigView creates a new view object attached the the global [gocui] screen object.
+func newImageConfigView(gui *gocui.Gui, config *image.ImageConfig) (controller *ImageConfig) {
+	controller = new(ImageConfig)
+
+	// populate main fields
+	controller.name = "image-config"
+	controller.gui = gui
+	controller.entries = config.Entries()
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *ImageConfig) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *ImageConfig) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options (the view is editable such that typing searches the settings)
+	v.view = view
+	v.view.Editable = true
+	v.view.Editor = v
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Edit updates the search query as the user types into the popup.
+func (v *ImageConfig) Edit(view *gocui.View, k gocui.Key, ch rune, mod gocui.Modifier) {
+	if !v.IsVisible() {
+		return
+	}
+	switch {
+	case ch != 0 && mod == 0 && len(v.query) < maxImageConfigQuery:
+		v.query += string(ch)
+	case k == gocui.KeySpace && len(v.query) < maxImageConfigQuery:
+		v.query += " "
+	case (k == gocui.KeyBackspace || k == gocui.KeyBackspace2) && v.query != "":
+		runes := []rune(v.query)
+		v.query = string(runes[:len(runes)-1])
+	default:
+		return
+	}
+	if err := view.SetOrigin(0, 0); err != nil {
+		logrus.Debug("unable to reset the origin: ", err)
+	}
+	if err := v.Render(); err != nil {
+		logrus.Errorf("unable to render the image config: %+v", err)
+	}
+}
+
+// Show opens the popup (taking focus), clearing any previous search.
+func (v *ImageConfig) Show() error {
+	v.query = ""
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *ImageConfig) Close() error {
+	return v.closeView(v.view)
+}
+
+func (v *ImageConfig) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if oy+delta < 0 || oy+delta+height > len(v.lines()) {
+		return nil
+	}
+	return v.view.SetOrigin(ox, oy+delta)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *ImageConfig) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *ImageConfig) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *ImageConfig) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders the settings matching the search query, one per line.
+func (v *ImageConfig) lines() []string {
+	if len(v.entries) == 0 {
+		return []string{"The image config has no runtime configuration", "", "Press esc to close"}
+	}
+
+	lines := []string{"Search: " + v.query, ""}
+	entries := image.FilterConfigEntries(v.entries, v.query)
+	if len(entries) == 0 {
+		lines = append(lines, "No setting matches the search")
+	} else {
+		lines = append(lines, format.Header(fmt.Sprintf("%-11s  %s", "Setting", "Value")))
+	}
+	for _, entry := range entries {
+		value := entry.Value
+		if entry.Key != "" {
+			value = entry.Key + "=" + entry.Value
+		}
+		lines = append(lines, fmt.Sprintf("%-11s  %s", entry.Section, strings.Join(strings.Fields(value), " ")))
+	}
+	lines = append(lines, "", "Type to search, press esc to close")
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *ImageConfig) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Image Config "
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to all settings (such that the size does not change while searching).
+func (v *ImageConfig) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxImageConfigWidth {
+		width = maxImageConfigWidth
+	}
+	// the search line, the header and the help line with their blank lines
+	height := len(v.entries) + 6
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup image config controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *ImageConfig) RequestedSize(available int) *int {
+	return nil
+}
@@ -2,32 +2,65 @@ package view
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/runtime/ui/format"
 	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
 	"github.com/wagoodman/dive/runtime/ui/viewmodel"
 )
 
+// the column of the bytes each layer writes that a later layer deletes or overwrites
+const doomedFormat = "%10s  "
+
+// the column of the number of vulnerabilities in the packages each layer adds
+const vulnerabilitiesFormat = "%5s  "
+
 // Layer holds the UI objects and data models for populating the lower-left pane. Specifically the pane that
 // shows the image layers and layer selector.
 type Layer struct {
 	name                  string
 	gui                   *gocui.Gui
+	symbols               format.Symbols
 	view                  *gocui.View
 	header                *gocui.View
+	damage                damage
 	vm                    *viewmodel.LayerSetState
 	constrainedRealEstate bool
-
-	listeners []LayerChangeListener
+	// the digest of the image config (empty when unknown)
+	imageID string
+	// the layer trees, which the doomed files are found in
+	refTrees []*filetree.FileTree
+	// the files of each layer that a later layer deletes or overwrites (nil unless they are shown)
+	doomed *image.DoomedFiles
+	// the vulnerabilities a scanner found (nil without a scanner report)
+	vulnerabilities *image.VulnerabilityReport
+	// the image history, whose instructions without a layer (ENV, LABEL, ARG...) are listed between the layers when
+	// showEmpty is set
+	history   []image.HistoryEntry
+	showEmpty bool
+	// the rows of the pane: the layers, and the instructions without a layer when they are shown
+	rows []image.HistoryRow
+
+	listeners          []LayerChangeListener
+	historyListeners   []HistoryListener
+	configListeners    []ConfigListener
+	ownershipListeners []OwnershipListener
+	compareListeners   []CompareListener
+	searchListeners    []SearchListener
 
 	helpKeys []*key.Binding
 }
 
 // newLayerView creates a new view object attached the the global [gocui] screen object.
-func newLayerView(gui *gocui.Gui, layers []*image.Layer) (controller *Layer, err error) {
+func newLayerView(gui *gocui.Gui, layers []*image.Layer, history []image.HistoryEntry, refTrees []*filetree.FileTree, imageID string, vulnerabilities *image.VulnerabilityReport, symbols format.Symbols) (controller *Layer, err error) {
 	controller = new(Layer)
 
 	controller.listeners = make([]LayerChangeListener, 0)
@@ -35,6 +68,13 @@ func newLayerView(gui *gocui.Gui, layers []*image.Layer) (controller *Layer, err
 	// populate main fields
 	controller.name = "layer"
 	controller.gui = gui
+	controller.symbols = symbols
+	controller.imageID = imageID
+	controller.refTrees = refTrees
+	controller.vulnerabilities = vulnerabilities
+	controller.history = history
+	controller.showEmpty = viper.GetBool("layer.show-empty-layers")
+	controller.rows = image.HistoryRows(len(layers), history, controller.showEmpty)
 
 	var compareMode viewmodel.LayerCompareMode
 
@@ -56,6 +96,132 @@ func (v *Layer) AddLayerChangeListener(listener ...LayerChangeListener) {
 	v.listeners = append(v.listeners, listener...)
 }
 
+// HistoryListener is notified with the index of the selected layer when the user asks for the image history.
+type HistoryListener func(layerIndex int) error
+
+func (v *Layer) AddHistoryListener(listener ...HistoryListener) {
+	v.historyListeners = append(v.historyListeners, listener...)
+}
+
+// showHistory shows the full image history, highlighting the instruction that produced the selected layer.
+func (v *Layer) showHistory() error {
+	for _, listener := range v.historyListeners {
+		if err := listener(v.vm.LayerIndex); err != nil {
+			logrus.Errorf("notifyHistoryListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigListener is notified when the user asks for the runtime configuration of the image.
+type ConfigListener func() error
+
+func (v *Layer) AddConfigListener(listener ...ConfigListener) {
+	v.configListeners = append(v.configListeners, listener...)
+}
+
+// showConfig shows the env, labels, entrypoint... of the image config.
+func (v *Layer) showConfig() error {
+	for _, listener := range v.configListeners {
+		if err := listener(); err != nil {
+			logrus.Errorf("notifyConfigListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// OwnershipListener is notified with the index of the selected layer when the user asks for the ownership breakdown.
+type OwnershipListener func(layerIndex int) error
+
+func (v *Layer) AddOwnershipListener(listener ...OwnershipListener) {
+	v.ownershipListeners = append(v.ownershipListeners, listener...)
+}
+
+// showOwnership shows the bytes of the image and of every layer by owner, highlighting the selected layer.
+func (v *Layer) showOwnership() error {
+	for _, listener := range v.ownershipListeners {
+		if err := listener(v.vm.LayerIndex); err != nil {
+			logrus.Errorf("notifyOwnershipListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareListener is notified with the index of the selected layer when the user asks to lay out the files of the
+// layer next to those of the previous layer.
+type CompareListener func(layerIndex int) error
+
+func (v *Layer) AddCompareListener(listener ...CompareListener) {
+	v.compareListeners = append(v.compareListeners, listener...)
+}
+
+// compareSideBySide shows the files of the previous layer and of the selected layer side by side.
+func (v *Layer) compareSideBySide() error {
+	for _, listener := range v.compareListeners {
+		if err := listener(v.vm.LayerIndex); err != nil {
+			logrus.Errorf("notifyCompareListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Layer) AddSearchListener(listener ...SearchListener) {
+	v.searchListeners = append(v.searchListeners, listener...)
+}
+
+func (v *Layer) notifySearchListeners(prompt, forward bool) error {
+	for _, listener := range v.searchListeners {
+		if err := listener(v, prompt, forward); err != nil {
+			logrus.Errorf("notifySearchListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Search selects the next (or previous) layer whose command or digest contains the query (ignoring case).
+func (v *Layer) Search(query string, forward bool) (bool, error) {
+	count := len(v.vm.Layers)
+	step := 1
+	if !forward {
+		step = -1
+	}
+	query = strings.ToLower(query)
+	for offset := 1; offset <= count; offset++ {
+		idx := ((v.vm.LayerIndex+step*offset)%count + count) % count
+		layer := v.vm.Layers[idx]
+		if strings.Contains(strings.ToLower(layer.Command), query) || strings.Contains(strings.ToLower(layer.Digest), query) {
+			return true, v.JumpTo(idx)
+		}
+	}
+	return false, nil
+}
+
+// JumpTo selects the given layer, scrolling the pane to show it.
+func (v *Layer) JumpTo(layer int) error {
+	if layer == v.vm.LayerIndex {
+		return nil
+	}
+	if err := CursorStep(v.gui, v.view, v.row(layer)-v.row(v.vm.LayerIndex)); err != nil {
+		return err
+	}
+	return v.SetCursor(layer)
+}
+
+// CursorTop selects the first layer.
+func (v *Layer) CursorTop() error {
+	return v.JumpTo(0)
+}
+
+// CursorBottom selects the last layer.
+func (v *Layer) CursorBottom() error {
+	return v.JumpTo(len(v.vm.Layers) - 1)
+}
+
 func (v *Layer) notifyLayerChangeListeners() error {
 	bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop := v.vm.GetCompareIndexes()
 	selection := viewmodel.LayerSelection{
@@ -65,6 +231,9 @@ func (v *Layer) notifyLayerChangeListeners() error {
 		TopTreeStart:    topTreeStart,
 		TopTreeStop:     topTreeStop,
 	}
+	if v.doomed != nil {
+		selection.Doomed = v.doomed.Pending(v.vm.LayerIndex)
+	}
 	for _, listener := range v.listeners {
 		err := listener(selection)
 		if err != nil {
@@ -107,33 +276,135 @@ func (v *Layer) Setup(view *gocui.View, header *gocui.View) error {
 			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareAllLayers },
 			Display:    "Show aggregated changes",
 		},
+		{
+			ConfigKeys: []string{"keybinding.compare-flattened"},
+			OnAction:   func() error { return v.setCompareMode(viewmodel.CompareFlattened) },
+			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareFlattened },
+			Display:    "Show flattened",
+		},
+		{
+			ConfigKeys: []string{"keybinding.compare-since-base"},
+			OnAction:   v.setCompareBase,
+			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareSinceBase },
+			Display:    "Show changes since layer",
+		},
+		{
+			ConfigKeys: []string{"keybinding.select-layer-range"},
+			OnAction:   v.toggleRange,
+			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareRange },
+			Display:    "Select range",
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-doomed-files"},
+			OnAction:   v.toggleDoomed,
+			IsSelected: func() bool { return v.doomed != nil },
+			Display:    "Removed later",
+		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-empty-layers"},
+			OnAction:   v.toggleEmptyLayers,
+			IsSelected: func() bool { return v.showEmpty },
+			Display:    "Empty layers",
+		},
 		{
 			Key:      gocui.KeyArrowDown,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorDown,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowUp,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorUp,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowLeft,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorUp,
+			Repeat:   true,
 		},
 		{
 			Key:      gocui.KeyArrowRight,
 			Modifier: gocui.ModNone,
 			OnAction: v.CursorDown,
+			Repeat:   true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.copy-digest"},
+			OnAction:   v.copyDigest,
+		},
+		{
+			ConfigKeys: []string{"keybinding.copy-command"},
+			OnAction:   v.copyCommand,
+		},
+		{
+			ConfigKeys: []string{"keybinding.copy-image-id"},
+			OnAction:   v.copyImageID,
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-history"},
+			OnAction:   v.showHistory,
+			Display:    "History",
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-config"},
+			OnAction:   v.showConfig,
+			Display:    "Config",
+		},
+		{
+			ConfigKeys: []string{"keybinding.show-ownership"},
+			OnAction:   v.showOwnership,
+			Display:    "Ownership",
+		},
+		{
+			ConfigKeys: []string{"keybinding.compare-side-by-side"},
+			OnAction:   v.compareSideBySide,
+			Display:    "Side by side",
 		},
 		{
 			ConfigKeys: []string{"keybinding.page-up"},
 			OnAction:   v.PageUp,
+			Repeat:     true,
 		},
 		{
 			ConfigKeys: []string{"keybinding.page-down"},
 			OnAction:   v.PageDown,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-down"},
+			OnAction:   v.CursorDown,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-up"},
+			OnAction:   v.CursorUp,
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-top"},
+			OnAction:   v.CursorTop,
+			Double:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.cursor-bottom"},
+			OnAction:   v.CursorBottom,
+		},
+		{
+			ConfigKeys: []string{"keybinding.search"},
+			OnAction:   func() error { return v.notifySearchListeners(true, true) },
+			Display:    "Search",
+		},
+		{
+			ConfigKeys: []string{"keybinding.next-match"},
+			OnAction:   func() error { return v.notifySearchListeners(false, true) },
+			Repeat:     true,
+		},
+		{
+			ConfigKeys: []string{"keybinding.previous-match"},
+			OnAction:   func() error { return v.notifySearchListeners(false, false) },
+			Repeat:     true,
 		},
 	}
 
@@ -143,7 +414,19 @@ func (v *Layer) Setup(view *gocui.View, header *gocui.View) error {
 	}
 	v.helpKeys = helpKeys
 
-	return v.Render()
+	if err := key.BindCount(v.gui, v.name); err != nil {
+		return err
+	}
+
+	v.damage.invalidate()
+	if err := v.Render(); err != nil {
+		return err
+	}
+	// instructions without a layer may be listed before the first layer
+	v.gui.Update(func(*gocui.Gui) error {
+		return v.revealCursor()
+	})
+	return nil
 }
 
 // height obtains the height of the current pane (taking into account the lost space due to the header).
@@ -156,6 +439,11 @@ func (v *Layer) CompareMode() viewmodel.LayerCompareMode {
 	return v.vm.CompareMode
 }
 
+// BaseLayerIndex is the layer the changes are shown since (in the CompareSinceBase mode).
+func (v *Layer) BaseLayerIndex() int {
+	return v.vm.BaseLayerIndex
+}
+
 // IsVisible indicates if the layer view pane is currently initialized.
 func (v *Layer) IsVisible() bool {
 	return v != nil
@@ -163,47 +451,26 @@ func (v *Layer) IsVisible() bool {
 
 // PageDown moves to next page putting the cursor on top
 func (v *Layer) PageDown() error {
-	step := int(v.height()) + 1
-	targetLayerIndex := v.vm.LayerIndex + step
-
-	if targetLayerIndex > len(v.vm.Layers) {
-		step -= targetLayerIndex - (len(v.vm.Layers) - 1)
-	}
-
-	if step > 0 {
-		err := CursorStep(v.gui, v.view, step)
-		if err == nil {
-			return v.SetCursor(v.vm.LayerIndex + step)
-		}
+	target := v.vm.LayerIndex + int(v.height()) + 1
+	if target > len(v.vm.Layers)-1 {
+		target = len(v.vm.Layers) - 1
 	}
-	return nil
+	return v.step(target)
 }
 
 // PageUp moves to previous page putting the cursor on top
 func (v *Layer) PageUp() error {
-	step := int(v.height()) + 1
-	targetLayerIndex := v.vm.LayerIndex - step
-
-	if targetLayerIndex < 0 {
-		step += targetLayerIndex
-	}
-
-	if step > 0 {
-		err := CursorStep(v.gui, v.view, -step)
-		if err == nil {
-			return v.SetCursor(v.vm.LayerIndex - step)
-		}
+	target := v.vm.LayerIndex - int(v.height()) - 1
+	if target < 0 {
+		target = 0
 	}
-	return nil
+	return v.step(target)
 }
 
 // CursorDown moves the cursor down in the layer pane (selecting a higher layer).
 func (v *Layer) CursorDown() error {
-	if v.vm.LayerIndex < len(v.vm.Layers) {
-		err := CursorDown(v.gui, v.view)
-		if err == nil {
-			return v.SetCursor(v.vm.LayerIndex + 1)
-		}
+	if v.vm.LayerIndex < len(v.vm.Layers)-1 {
+		return v.step(v.vm.LayerIndex + 1)
 	}
 	return nil
 }
@@ -211,14 +478,68 @@ func (v *Layer) CursorDown() error {
 // CursorUp moves the cursor up in the layer pane (selecting a lower layer).
 func (v *Layer) CursorUp() error {
 	if v.vm.LayerIndex > 0 {
-		err := CursorUp(v.gui, v.view)
-		if err == nil {
-			return v.SetCursor(v.vm.LayerIndex - 1)
+		return v.step(v.vm.LayerIndex - 1)
+	}
+	return nil
+}
+
+// step moves the cursor to the given layer, over the rows of the instructions without a layer in between (a move
+// past the last row is dropped).
+func (v *Layer) step(layer int) error {
+	if layer == v.vm.LayerIndex {
+		return nil
+	}
+	if err := CursorStep(v.gui, v.view, v.row(layer)-v.row(v.vm.LayerIndex)); err != nil {
+		return nil
+	}
+	return v.SetCursor(layer)
+}
+
+// row returns the row of the pane the given layer is listed on.
+func (v *Layer) row(layer int) int {
+	for idx, row := range v.rows {
+		if row.Layer == layer {
+			return idx
 		}
 	}
+	return layer
+}
+
+// toggleEmptyLayers lists (or hides) the instructions of the image history that made no filesystem changes (such as
+// ENV, LABEL or ARG) between the layers, as `docker history` does. The layers keep their indices either way.
+func (v *Layer) toggleEmptyLayers() error {
+	v.showEmpty = !v.showEmpty
+	v.rows = image.HistoryRows(len(v.vm.Layers), v.history, v.showEmpty)
+	if err := v.Render(); err != nil {
+		return err
+	}
+	// the rows moved: keep the selected layer under the cursor once they are drawn
+	v.gui.Update(func(*gocui.Gui) error {
+		return v.revealCursor()
+	})
 	return nil
 }
 
+// revealCursor puts the cursor on the row of the selected layer, scrolling the pane when the row is out of sight.
+func (v *Layer) revealCursor() error {
+	if v.view == nil {
+		return nil
+	}
+	row := v.row(v.vm.LayerIndex)
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if row < oy || row >= oy+height {
+		oy = row - height/2
+		if oy < 0 {
+			oy = 0
+		}
+		if err := v.view.SetOrigin(ox, oy); err != nil {
+			return err
+		}
+	}
+	return v.view.SetCursor(0, row-oy)
+}
+
 // SetCursor resets the cursor and orients the file tree view based on the given layer index.
 func (v *Layer) SetCursor(layer int) error {
 	v.vm.LayerIndex = layer
@@ -235,12 +556,102 @@ func (v *Layer) CurrentLayer() *image.Layer {
 	return v.vm.Layers[v.vm.LayerIndex]
 }
 
-// setCompareMode switches the layer comparison between a single-layer comparison to an aggregated comparison.
+// copyDigest copies the digest of the selected layer to the clipboard (or the diffID when the digest is unknown).
+func (v *Layer) copyDigest() error {
+	layer := v.CurrentLayer()
+	digest := layer.Digest
+	if digest == "" {
+		digest = layer.DiffID
+	}
+	if digest == "" {
+		return nil
+	}
+	return terminal.Copy(digest)
+}
+
+// copyCommand copies the full command that created the selected layer to the clipboard.
+func (v *Layer) copyCommand() error {
+	command := v.CurrentLayer().Command
+	if command == "" {
+		return nil
+	}
+	return terminal.Copy(command)
+}
+
+// copyImageID copies the image ID (the digest of the image config) to the clipboard.
+func (v *Layer) copyImageID() error {
+	if v.imageID == "" {
+		return nil
+	}
+	return terminal.Copy(v.imageID)
+}
+
+// setCompareMode switches the layer comparison between a single-layer comparison, an aggregated comparison and the
+// flattened filesystem.
 func (v *Layer) setCompareMode(compareMode viewmodel.LayerCompareMode) error {
 	v.vm.CompareMode = compareMode
 	return v.notifyLayerChangeListeners()
 }
 
+// setCompareBase shows the changes made since the selected layer (as the cursor moves to later layers).
+func (v *Layer) setCompareBase() error {
+	v.vm.SetCompareBase(v.vm.LayerIndex)
+	return v.notifyLayerChangeListeners()
+}
+
+// toggleRange starts selecting a range of layers from the selected layer (the cursor extends the range, and the
+// filetree shows the changes made by the layers within it), or ends the selection.
+func (v *Layer) toggleRange() error {
+	if v.vm.CompareMode == viewmodel.CompareRange {
+		v.vm.EndRange()
+	} else {
+		v.vm.StartRange()
+	}
+	return v.notifyLayerChangeListeners()
+}
+
+// LayerRange returns the first and last layers of the selected range (in the CompareRange mode).
+func (v *Layer) LayerRange() (first, last int) {
+	return v.vm.Range()
+}
+
+// toggleDoomed shows (or hides) the bytes each layer writes that a later layer deletes or overwrites, highlighting
+// those files in the file tree. The files are found anew each time they are shown, as lazy images load their layers
+// in the meantime.
+func (v *Layer) toggleDoomed() error {
+	if v.doomed != nil {
+		v.doomed = nil
+	} else {
+		v.doomed = image.FindDoomedFiles(v.refTrees)
+	}
+	if err := v.Render(); err != nil {
+		return err
+	}
+	return v.notifyLayerChangeListeners()
+}
+
+// doomedColumn returns the bytes of the layer that a later layer deletes or overwrites (blank when there are none).
+func (v *Layer) doomedColumn(layerIdx int) string {
+	column := ""
+	if sizeBytes := v.doomed.LayerBytes(layerIdx); sizeBytes > 0 {
+		column = humanize.Bytes(sizeBytes)
+	}
+	return format.Doomed(fmt.Sprintf(doomedFormat, column))
+}
+
+// vulnerabilitiesColumn returns the number of vulnerabilities in the packages the layer adds, colored by their
+// highest severity (blank when there are none).
+func (v *Layer) vulnerabilitiesColumn(layerIdx int) string {
+	if layerIdx >= len(v.vulnerabilities.LayerCounts) || v.vulnerabilities.LayerCounts[layerIdx] == 0 {
+		return fmt.Sprintf(vulnerabilitiesFormat, "")
+	}
+	column := fmt.Sprintf(vulnerabilitiesFormat, strconv.Itoa(v.vulnerabilities.LayerCounts[layerIdx]))
+	if image.SeverityRank(v.vulnerabilities.LayerSeverities[layerIdx]) >= image.SeverityRank("high") {
+		return format.Vulnerable(column)
+	}
+	return format.VulnerableLow(column)
+}
+
 // renderCompareBar returns the formatted string for the given layer.
 func (v *Layer) renderCompareBar(layerIdx int) string {
 	bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop := v.vm.GetCompareIndexes()
@@ -288,27 +699,39 @@ func (v *Layer) Update() error {
 // 1. the layers of the image + metadata
 // 2. the current selected image
 func (v *Layer) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
 
 	// indicate when selected
 	title := "Layers"
 	isSelected := v.gui.CurrentView() == v.view
 
+	if !v.damage.changed(v.state(isSelected)) {
+		// nothing changed since the last render
+		return nil
+	}
+
 	v.gui.Update(func(g *gocui.Gui) error {
 		var err error
 		// update header
 		v.header.Clear()
 		width, _ := g.Size()
 		if v.constrainedRealEstate {
-			headerStr := format.RenderNoHeader(width, isSelected)
+			headerStr := v.symbols.RenderNoHeader(width, isSelected)
 			headerStr += "\nLayer"
 			_, err := fmt.Fprintln(v.header, headerStr)
 			if err != nil {
 				return err
 			}
 		} else {
-			headerStr := format.RenderHeader(title, width, isSelected)
-			headerStr += fmt.Sprintf("Cmp"+image.LayerFormat, "Size", "Command")
+			headerStr := v.symbols.RenderHeader(title, width, isSelected)
+			headerStr += "Cmp"
+			if v.doomed != nil {
+				headerStr += fmt.Sprintf(doomedFormat, "Doomed")
+			}
+			if v.vulnerabilities != nil {
+				headerStr += fmt.Sprintf(vulnerabilitiesFormat, "CVEs")
+			}
+			headerStr += fmt.Sprintf(image.LayerFormat, "Size", "Compressed", "Command")
 			_, err := fmt.Fprintln(v.header, headerStr)
 			if err != nil {
 				return err
@@ -317,13 +740,31 @@ func (v *Layer) Render() error {
 
 		// update contents
 		v.view.Clear()
-		for idx, layer := range v.vm.Layers {
+		for _, row := range v.rows {
+			if row.Entry != nil {
+				if _, err := fmt.Fprintln(v.view, "   "+v.emptyRowString(*row.Entry)); err != nil {
+					logrus.Debug("unable to write to buffer: ", err)
+					return err
+				}
+				continue
+			}
+			idx, layer := row.Layer, v.vm.Layers[row.Layer]
 
 			var layerStr string
 			if v.constrainedRealEstate {
 				layerStr = fmt.Sprintf("%-4d", layer.Index)
 			} else {
 				layerStr = layer.String()
+				if v.vulnerabilities != nil {
+					layerStr = v.vulnerabilitiesColumn(idx) + layerStr
+				}
+				if v.doomed != nil {
+					layerStr = v.doomedColumn(idx) + layerStr
+				}
+			}
+			if len(layer.Corruption) > 0 {
+				// the tree of a corrupt layer may be partial, which is flagged even when the pane is narrow
+				layerStr += " " + format.Corrupt(v.symbols.Corrupt)
 			}
 
 			compareBar := v.renderCompareBar(idx)
@@ -345,6 +786,61 @@ func (v *Layer) Render() error {
 	return nil
 }
 
+// emptyRowString renders an instruction of the history that made no filesystem changes, in the columns of the layers
+// (the instruction, e.g. "ENV PATH=/app", tells why it has no layer).
+func (v *Layer) emptyRowString(entry image.HistoryEntry) string {
+	if v.constrainedRealEstate {
+		return format.Empty(fmt.Sprintf("%-4s", v.symbols.HistoryEmpty))
+	}
+	var columns string
+	if v.doomed != nil {
+		columns += fmt.Sprintf(doomedFormat, "")
+	}
+	if v.vulnerabilities != nil {
+		columns += fmt.Sprintf(vulnerabilitiesFormat, "")
+	}
+	return format.Empty(columns + fmt.Sprintf(image.LayerFormat, humanize.Bytes(0), "-", v.symbols.HistoryEmpty+" "+entry.Command()))
+}
+
+// layerState is the state the layers pane renders.
+type layerState struct {
+	width       int
+	selected    bool
+	constrained bool
+	doomed      *image.DoomedFiles
+	layerIndex  int
+	compareMode viewmodel.LayerCompareMode
+	compare     int
+	base        int
+	rangeAnchor int
+	showEmpty   bool
+	// the layers parsed so far (the sizes of lazily loaded layers are measured once parsed)
+	loaded int
+}
+
+func (v *Layer) state(isSelected bool) layerState {
+	width, _ := v.gui.Size()
+	loaded := 0
+	for _, layer := range v.vm.Layers {
+		if layer.Tree != nil {
+			loaded++
+		}
+	}
+	return layerState{
+		width:       width,
+		selected:    isSelected,
+		constrained: v.constrainedRealEstate,
+		doomed:      v.doomed,
+		layerIndex:  v.vm.LayerIndex,
+		compareMode: v.vm.CompareMode,
+		compare:     v.vm.CompareStartIndex,
+		base:        v.vm.BaseLayerIndex,
+		rangeAnchor: v.vm.RangeAnchorIndex,
+		showEmpty:   v.showEmpty,
+		loaded:      loaded,
+	}
+}
+
 func (v *Layer) LayerCount() int {
 	return len(v.vm.Layers)
 }
@@ -353,7 +849,7 @@ func (v *Layer) LayerCount() int {
 func (v *Layer) KeyHelp() string {
 	var help string
 	for _, binding := range v.helpKeys {
-		help += binding.RenderKeyHelp()
+		help += binding.RenderKeyHelp(v.symbols)
 	}
 	return help
 }
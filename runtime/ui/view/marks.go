@@ -0,0 +1,122 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/viewmodel"
+)
+
+// the most rows the marks pane takes from the layer details column (the rest can be scrolled to)
+const maxMarksHeight = 5
+
+// Marks holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane that
+// lists the files marked by the user in the file tree (it is only shown when there are any).
+type Marks struct {
+	name      string
+	gui       *gocui.Gui
+	symbols   format.Symbols
+	view      *gocui.View
+	header    *gocui.View
+	bookmarks *viewmodel.Bookmarks
+}
+
+// newMarksView creates a new view object attached the the global [gocui] screen object.
+func newMarksView(gui *gocui.Gui, bookmarks *viewmodel.Bookmarks, symbols format.Symbols) (controller *Marks) {
+	controller = new(Marks)
+
+	// populate main fields
+	controller.name = "marks"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.bookmarks = bookmarks
+
+	return controller
+}
+
+func (v *Marks) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Marks) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	return v.Render()
+}
+
+// IsVisible indicates if the marks pane is shown (only when files are marked).
+func (v *Marks) IsVisible() bool {
+	return v != nil && len(v.bookmarks.Paths()) > 0
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Marks) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if count := len(v.bookmarks.Paths()); count < maxMarksHeight {
+		return count
+	}
+	return maxMarksHeight
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Marks) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Marks) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Marks) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// the pane is removed from the layout when the last mark is removed
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(fmt.Sprintf("Marks (%d)", len(v.bookmarks.Paths())), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, path := range v.bookmarks.Paths() {
+			_, err = fmt.Fprintln(v.view, format.Marked(v.symbols.Mark)+" "+path)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,100 @@
+package view
+
+import (
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+)
+
+// Modal is a popup laid out over the panes (see layout.LocationOverlay), which closes on esc.
+type Modal interface {
+	Name() string
+	IsVisible() bool
+	Close() error
+}
+
+// popup holds what the popups share: whether they are closed, and the listeners notified when they close (which move
+// the focus back).
+type popup struct {
+	hidden         bool
+	closeListeners []func() error
+}
+
+func (p *popup) AddCloseListener(listener ...func() error) {
+	p.closeListeners = append(p.closeListeners, listener...)
+}
+
+// closeView hides the view of an open popup and notifies the close listeners (it does nothing once the popup is
+// closed).
+func (p *popup) closeView(view *gocui.View) error {
+	if p.hidden {
+		return nil
+	}
+	p.hidden = true
+	if view != nil {
+		view.Visible = false
+	}
+
+	for _, listener := range p.closeListeners {
+		if err := listener(); err != nil {
+			logrus.Errorf("notifyOnCloseListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Modals keeps track of the popups, so that they can be closed together and so that an open popup keeps the focus:
+// the global bindings that would move the focus to another pane give it back to the popup instead.
+type Modals struct {
+	gui *gocui.Gui
+	// the popups, the last ones are drawn on top of the others
+	modals []Modal
+}
+
+func newModals(gui *gocui.Gui, modals ...Modal) *Modals {
+	return &Modals{
+		gui:    gui,
+		modals: modals,
+	}
+}
+
+// Add registers more popups (drawn on top of the popups registered before).
+func (m *Modals) Add(modals ...Modal) {
+	m.modals = append(m.modals, modals...)
+}
+
+// Open returns the topmost open popup, or nil when no popup is open.
+func (m *Modals) Open() Modal {
+	for idx := len(m.modals) - 1; idx >= 0; idx-- {
+		if m.modals[idx].IsVisible() {
+			return m.modals[idx]
+		}
+	}
+	return nil
+}
+
+// CloseAll closes every open popup.
+func (m *Modals) CloseAll() error {
+	for _, modal := range m.modals {
+		if err := modal.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trap wraps a global action so that it only runs when no popup is open. Otherwise the focus is given back to the open
+// popup (if it was taken away) and the action is dropped.
+func (m *Modals) Trap(action func() error) func() error {
+	return func() error {
+		open := m.Open()
+		if open == nil {
+			return action()
+		}
+		if current := m.gui.CurrentView(); current == nil || current.Name() != open.Name() {
+			_, err := m.gui.SetCurrentView(open.Name())
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+type testModal struct {
+	name   string
+	open   bool
+	closed int
+}
+
+func (m *testModal) Name() string    { return m.name }
+func (m *testModal) IsVisible() bool { return m.open }
+func (m *testModal) Close() error {
+	if m.open {
+		m.open = false
+		m.closed++
+	}
+	return nil
+}
+
+func TestModals(t *testing.T) {
+	history := &testModal{name: "history"}
+	dialog := &testModal{name: "dialog"}
+	modals := newModals(nil, history, dialog)
+
+	if modals.Open() != nil {
+		t.Errorf("expected no open popup")
+	}
+
+	history.open = true
+	dialog.open = true
+	if open := modals.Open(); open != dialog {
+		t.Errorf("expected the dialog on top, got %v", open)
+	}
+
+	if err := modals.CloseAll(); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if history.closed != 1 || dialog.closed != 1 || modals.Open() != nil {
+		t.Errorf("expected every popup to be closed once")
+	}
+
+	// without an open popup, the trapped action runs
+	ran := false
+	if err := modals.Trap(func() error { ran = true; return nil })(); err != nil || !ran {
+		t.Errorf("expected the action to run (err: %v)", err)
+	}
+}
+
+func TestPopupCloseView(t *testing.T) {
+	history := newHistoryView(nil, nil, nil, format.Symbols{})
+	closed := 0
+	history.AddCloseListener(func() error { closed++; return nil })
+
+	if err := history.Close(); err != nil || closed != 0 {
+		t.Errorf("expected a closed popup not to notify its listeners (err: %v, notified %d times)", err, closed)
+	}
+
+	history.hidden = false
+	if err := history.Close(); err != nil || closed != 1 || history.IsVisible() {
+		t.Errorf("expected the popup to close and notify its listeners once (err: %v, notified %d times)", err, closed)
+	}
+	if err := history.Close(); err != nil || closed != 1 {
+		t.Errorf("expected the popup to be closed only once (err: %v, notified %d times)", err, closed)
+	}
+}
@@ -0,0 +1,245 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// the widest the ownership popup gets (narrower screens get a narrower popup)
+const maxOwnershipWidth = 120
+
+// the number of owners listed for each layer (those with the most bytes first)
+const ownershipLayerOwners = 3
+
+// Ownership holds the UI objects and data models for populating the popup that breaks the bytes of the final image and
+// of every layer down by owner (uid:gid), showing how much of the image is owned by root.
+type Ownership struct {
+	popup
+
+	name      string
+	gui       *gocui.Gui
+	view      *gocui.View
+	ownership *image.Ownership
+	// the index of the layer selected in the layer view (highlighted in the layer table)
+	selected int
+}
+
+// newOwnershipView creates a new view object attached the the global [gocui] screen object.
+func newOwnershipView(gui *gocui.Gui, ownership *image.Ownership) (controller *Ownership) {
+	controller = new(Ownership)
+
+	// populate main fields
+	controller.name = "ownership"
+	controller.gui = gui
+	controller.ownership = ownership
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Ownership) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Ownership) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show opens the popup (taking focus), highlighting the given layer.
+func (v *Ownership) Show(layerIndex int) error {
+	v.selected = layerIndex
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Ownership) Close() error {
+	return v.closeView(v.view)
+}
+
+func (v *Ownership) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if oy+delta < 0 || oy+delta+height > len(v.lines()) {
+		return nil
+	}
+	return v.view.SetOrigin(ox, oy+delta)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Ownership) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Ownership) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Ownership) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders the owners of the final image, followed by the bytes of every layer by owner.
+func (v *Ownership) lines() []string {
+	if v.ownership == nil {
+		return []string{"The ownership is not available (the layer contents are loaded on demand)", "", "Press esc to close"}
+	}
+
+	summary := v.ownership.Image
+	lines := []string{
+		fmt.Sprintf("Root owned: %s of %s (%.0f%%) in the final image", humanize.Bytes(summary.RootBytes), humanize.Bytes(summary.SizeBytes), summary.RootPercent()*100),
+		"",
+		format.Header(fmt.Sprintf("%-13s  %7s  %8s  %5s", "Owner", "Files", "Size", "Share")),
+	}
+	for _, owner := range summary.Owners {
+		share := 0.0
+		if summary.SizeBytes > 0 {
+			share = float64(owner.SizeBytes) / float64(summary.SizeBytes)
+		}
+		lines = append(lines, fmt.Sprintf("%-13s  %7d  %8s  %4.0f%%", owner.Owner(), owner.Files, humanize.Bytes(owner.SizeBytes), share*100))
+	}
+
+	lines = append(lines, "", format.Header(fmt.Sprintf("%5s  %8s  %8s  %6s  %s", "Layer", "Size", "Root", "Root %", "Owners")))
+	for idx, layer := range v.ownership.Layers {
+		owners := make([]string, 0, ownershipLayerOwners+1)
+		for ownerIdx, owner := range layer.Owners {
+			if ownerIdx >= ownershipLayerOwners {
+				owners = append(owners, fmt.Sprintf("+%d more", len(layer.Owners)-ownerIdx))
+				break
+			}
+			owners = append(owners, fmt.Sprintf("%s %s", owner.Owner(), humanize.Bytes(owner.SizeBytes)))
+		}
+		line := fmt.Sprintf("%5d  %8s  %8s  %5.0f%%  %s", idx, humanize.Bytes(layer.SizeBytes), humanize.Bytes(layer.RootBytes), layer.RootPercent()*100, strings.Join(owners, ", "))
+		if idx == v.selected {
+			line = format.Selected(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", "The sizes are those of the files (not the directories). Press esc to close")
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *Ownership) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Ownership "
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to its contents.
+func (v *Ownership) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxOwnershipWidth {
+		width = maxOwnershipWidth
+	}
+	height := len(v.lines()) + 1
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup ownership controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Ownership) RequestedSize(available int) *int {
+	return nil
+}
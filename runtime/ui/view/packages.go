@@ -0,0 +1,350 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+const (
+	// the widest the packages popup gets (narrower screens get a narrower popup)
+	maxPackagesWidth = 120
+	// the lines above the package rows (the summary, a blank line and the column header)
+	packagesHeaderLines = 3
+)
+
+// Packages holds the UI objects and data models for populating the popup that rolls the files of the image up by the
+// package (dpkg, apk or rpm) that installed them, the largest package first, or lists the files no package installed.
+// The package databases are read in the background when the popup is first opened, unless they were read along with
+// the analysis.
+type Packages struct {
+	popup
+
+	name      string
+	gui       *gocui.Gui
+	view      *gocui.View
+	trees     []*filetree.FileTree
+	contents  image.ContentReader
+	imageSize uint64
+	packages  *image.Packages
+	reading   bool
+	// the package that installed the file selected in the file tree (highlighted in the list)
+	selected *image.InstalledPackage
+	// the files no package installed are listed instead of the packages
+	orphans bool
+}
+
+// newPackagesView creates a new view object attached the the global [gocui] screen object.
+func newPackagesView(gui *gocui.Gui, packages *image.Packages, trees []*filetree.FileTree, contents image.ContentReader, imageSize uint64) (controller *Packages) {
+	controller = new(Packages)
+
+	// populate main fields
+	controller.name = "packages"
+	controller.gui = gui
+	controller.packages = packages
+	controller.trees = trees
+	controller.contents = contents
+	controller.imageSize = imageSize
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Packages) Name() string {
+	return v.name
+}
+
+// Owner returns the package that installed the given file (nil when no package did, or the package databases have
+// not been read yet).
+func (v *Packages) Owner(filePath string) *image.InstalledPackage {
+	return v.packages.Owner(filePath)
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Packages) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			ConfigKeys: []string{"keybinding.toggle-orphan-files"},
+			OnAction:   v.toggleOrphans,
+		},
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+		{
+			Key:      gocui.KeyPgdn,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(v.height()) },
+		},
+		{
+			Key:      gocui.KeyPgup,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-v.height()) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show opens the popup (taking focus), highlighting the package that installed the given file. The package databases
+// are read in the background the first time.
+func (v *Packages) Show(filePath string) error {
+	v.hidden = false
+	v.selected = v.packages.Owner(filePath)
+	if v.packages == nil && !v.reading {
+		v.reading = true
+		go func() {
+			packages := image.ReadPackages(v.trees, v.contents)
+			v.gui.Update(func(g *gocui.Gui) error {
+				v.packages, v.reading = packages, false
+				v.selected = packages.Owner(filePath)
+				return v.scrollToSelected()
+			})
+			_ = v.Render()
+		}()
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.scrollToSelected(); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Packages) Close() error {
+	return v.closeView(v.view)
+}
+
+// toggleOrphans switches between the packages and the files no package installed.
+func (v *Packages) toggleOrphans() error {
+	v.orphans = !v.orphans
+	if v.view != nil {
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if !v.orphans {
+			if err := v.scrollToSelected(); err != nil {
+				return err
+			}
+		}
+	}
+	return v.Render()
+}
+
+// height is the number of rows the popup shows.
+func (v *Packages) height() int {
+	if v.view == nil {
+		return 0
+	}
+	_, height := v.view.Size()
+	return height
+}
+
+func (v *Packages) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	limit := len(v.lines()) - v.height()
+	next := oy + delta
+	if next > limit {
+		next = limit
+	}
+	if next < 0 {
+		next = 0
+	}
+	return v.view.SetOrigin(ox, next)
+}
+
+// scrollToSelected scrolls the highlighted package into view (or back to the top when none is).
+func (v *Packages) scrollToSelected() error {
+	if v.view == nil {
+		return nil
+	}
+	row := 0
+	if v.packages != nil && v.selected != nil {
+		for idx, pkg := range v.packages.Packages {
+			if pkg == v.selected {
+				if idx+packagesHeaderLines >= v.height() {
+					row = idx
+				}
+				break
+			}
+		}
+	}
+	return v.view.SetOrigin(0, row)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Packages) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Packages) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Packages) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders the packages, one per line (the largest first).
+func (v *Packages) lines() []string {
+	switch {
+	case v.packages == nil && v.reading:
+		return []string{"Reading the package databases...", "", "Press esc to close"}
+	case v.packages == nil:
+		return []string{"The package databases have not been read", "", "Press esc to close"}
+	case len(v.packages.Packages) == 0:
+		return []string{"No package database (dpkg, apk or the sqlite database of rpm) was found in the image", "", "Press esc to close"}
+	}
+
+	if v.orphans {
+		return v.orphanLines()
+	}
+
+	lines := []string{
+		fmt.Sprintf("%d packages (%s), %s of the %s image", len(v.packages.Packages), strings.Join(v.packages.Managers(), ", "), humanize.Bytes(v.packages.SizeBytes()), humanize.Bytes(v.imageSize)),
+		"",
+		format.Header(fmt.Sprintf("%9s  %6s  %5s  %s", "Size", "Files", "Layer", "Package")),
+	}
+	for _, pkg := range v.packages.Packages {
+		layer := "-"
+		if pkg.Layer >= 0 {
+			layer = fmt.Sprintf("%d", pkg.Layer)
+		}
+		line := fmt.Sprintf("%9s  %6d  %5s  %s", humanize.Bytes(pkg.SizeBytes), len(pkg.Files), layer, pkg)
+		if pkg == v.selected {
+			line = format.Selected(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", "The package of the selected file is highlighted. Press o for the files no package installed, esc to close")
+	return lines
+}
+
+// orphanLines renders the files no package installed, one per line (the largest first).
+func (v *Packages) orphanLines() []string {
+	lines := []string{
+		fmt.Sprintf("%d files (%s) are not owned by any package", len(v.packages.Orphans), humanize.Bytes(v.packages.OrphanBytes())),
+		"",
+		format.Header(fmt.Sprintf("%9s  %5s  %s", "Size", "Layer", "Path")),
+	}
+	for _, orphan := range v.packages.Orphans {
+		lines = append(lines, fmt.Sprintf("%9s  %5d  %s", humanize.Bytes(orphan.SizeBytes), orphan.Layer, orphan.Path))
+	}
+	return append(lines, "", "Press o for the packages, esc to close")
+}
+
+// Render flushes the state objects to the screen.
+func (v *Packages) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Packages "
+		if v.orphans {
+			v.view.Title = " Files Not Owned By Any Package "
+		}
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to its contents.
+func (v *Packages) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxPackagesWidth {
+		width = maxPackagesWidth
+	}
+	height := len(v.lines()) + 1
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup packages controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Packages) RequestedSize(available int) *int {
+	return nil
+}
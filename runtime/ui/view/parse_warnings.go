@@ -0,0 +1,162 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the parse warnings pane takes from the layer details column (the rest can be scrolled to)
+const maxParseWarningsHeight = 4
+
+// ParseWarnings holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane
+// that lists the malformed layer tar entries skipped while parsing the layers (it is only shown when there are any).
+type ParseWarnings struct {
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	header  *gocui.View
+	damage  damage
+	// the layers are read on every render, as lazily loaded layers are parsed after the pane is created
+	layers []*image.Layer
+}
+
+// parseWarningsState is the state the parse warnings pane renders.
+type parseWarningsState struct {
+	size    paneSize
+	skipped int
+}
+
+// newParseWarningsView creates a new view object attached the the global [gocui] screen object.
+func newParseWarningsView(gui *gocui.Gui, layers []*image.Layer, symbols format.Symbols) (controller *ParseWarnings) {
+	controller = new(ParseWarnings)
+
+	// populate main fields
+	controller.name = "parse-warnings"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.layers = layers
+
+	return controller
+}
+
+func (v *ParseWarnings) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *ParseWarnings) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// skipped is the number of entries skipped across the layers parsed so far.
+func (v *ParseWarnings) skipped() int {
+	var skipped int
+	for _, layer := range v.layers {
+		skipped += layer.ParseWarnings.Len()
+	}
+	return skipped
+}
+
+// IsVisible indicates if the parse warnings pane is shown (only when malformed entries were skipped).
+func (v *ParseWarnings) IsVisible() bool {
+	return v != nil && v.skipped() > 0
+}
+
+// lines renders a line per skipped entry, and a line with the counts of the entries of a layer that are not described.
+func (v *ParseWarnings) lines() []string {
+	var lines []string
+	for _, layer := range v.layers {
+		warnings := layer.ParseWarnings
+		if warnings.Len() == 0 {
+			continue
+		}
+		for _, warning := range warnings.Warnings {
+			lines = append(lines, fmt.Sprintf("%s %s", format.Header(fmt.Sprintf("layer %d:", layer.Index)), warning))
+		}
+		if undescribed := warnings.Total - len(warnings.Warnings); undescribed > 0 {
+			lines = append(lines, fmt.Sprintf("%s ...and %d more (%s in total)", format.Header(fmt.Sprintf("layer %d:", layer.Index)), undescribed, warnings.Summary()))
+		}
+	}
+	return lines
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *ParseWarnings) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if lines := len(v.lines()); lines < maxParseWarningsHeight {
+		return lines
+	}
+	return maxParseWarningsHeight
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *ParseWarnings) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *ParseWarnings) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *ParseWarnings) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil || !v.IsVisible() {
+		return nil
+	}
+	skipped := v.skipped()
+	if !v.damage.changed(parseWarningsState{size: sizeOf(v.view.Size()), skipped: skipped}) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(fmt.Sprintf("Parse warnings (%d skipped)", skipped), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err = fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,364 @@
+package view
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+const (
+	// the margin kept around the pivot popup
+	pivotMargin = 2
+	// the lines above the table rows (the summary, a blank line and the column header)
+	pivotHeaderLines = 3
+	// the width of a layer column (e.g. "+1.2 MB")
+	pivotCellWidth = 9
+	// the widest the path column gets
+	maxPivotPathWidth = 60
+)
+
+// Pivot holds the UI objects and data models for populating the popup that shows the filtered files (rows) by the
+// layers (columns), where each cell shows what the layer did to the file.
+type Pivot struct {
+	popup
+
+	name     string
+	gui      *gocui.Gui
+	view     *gocui.View
+	layers   []*image.Layer
+	refTrees []*filetree.FileTree
+	pivot    *image.Pivot
+	top      int
+	left     int
+	message  string
+}
+
+// newPivotView creates a new view object attached the the global [gocui] screen object.
+func newPivotView(gui *gocui.Gui, layers []*image.Layer, refTrees []*filetree.FileTree) (controller *Pivot) {
+	controller = new(Pivot)
+
+	// populate main fields
+	controller.name = "pivot"
+	controller.gui = gui
+	controller.layers = layers
+	controller.refTrees = refTrees
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Pivot) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Pivot) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			ConfigKeys: []string{"keybinding.export-pivot"},
+			OnAction:   v.export,
+		},
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+		{
+			Key:      gocui.KeyPgdn,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(v.height()) },
+		},
+		{
+			Key:      gocui.KeyPgup,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-v.height()) },
+		},
+		{
+			Key:      gocui.KeyArrowRight,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.shift(1) },
+		},
+		{
+			Key:      gocui.KeyArrowLeft,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.shift(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show traces the given files through the layers and opens the popup (taking focus).
+func (v *Pivot) Show(paths []string) error {
+	v.pivot = image.BuildPivot(v.layers, v.refTrees, paths)
+	v.top, v.left = 0, 0
+	v.message = ""
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Pivot) Close() error {
+	v.pivot = nil
+	return v.closeView(v.view)
+}
+
+// export writes the whole table (every file and layer) to the configured CSV file. Failures are shown in the popup
+// rather than ending the session.
+func (v *Pivot) export() error {
+	if v.pivot == nil {
+		return nil
+	}
+	path := viper.GetString("pivot.export-file")
+	file, err := os.Create(path)
+	if err == nil {
+		err = v.pivot.WriteCSV(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		logrus.Warnf("unable to export the pivot table: %+v", err)
+		v.message = fmt.Sprintf("Unable to export to %s: %v", path, err)
+	} else {
+		v.message = fmt.Sprintf("Exported %d files to %s", len(v.pivot.Rows), path)
+	}
+	return v.Render()
+}
+
+// scroll moves the visible window of files by the given number of rows.
+func (v *Pivot) scroll(delta int) error {
+	if v.pivot == nil {
+		return nil
+	}
+	v.top += delta
+	if last := len(v.pivot.Rows) - v.height(); v.top > last {
+		v.top = last
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+	return v.Render()
+}
+
+// shift moves the visible window of layers by the given number of columns.
+func (v *Pivot) shift(delta int) error {
+	if v.pivot == nil {
+		return nil
+	}
+	v.left += delta
+	if last := len(v.refTrees) - 1; v.left > last {
+		v.left = last
+	}
+	if v.left < 0 {
+		v.left = 0
+	}
+	return v.Render()
+}
+
+// height is the number of files shown at once.
+func (v *Pivot) height() int {
+	if v.view == nil {
+		return 1
+	}
+	_, height := v.view.Size()
+	if height -= pivotHeaderLines; height < 1 {
+		return 1
+	}
+	return height
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Pivot) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Pivot) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Pivot) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// summary describes the table, or the outcome of the last export.
+func (v *Pivot) summary() string {
+	if v.message != "" {
+		return v.message
+	}
+	summary := fmt.Sprintf("%d files by %d layers (+ added, ~ modified, - deleted, . unchanged)", len(v.pivot.Rows), len(v.refTrees))
+	if v.pivot.Partial {
+		summary += ", layers that have not been loaded yet are not included"
+	}
+	return summary
+}
+
+// pivotCell renders what a layer did to a file, along with the size of the file when the layer changed it.
+func pivotCell(cell image.PivotCell) string {
+	switch {
+	case cell.Change == image.PathAdded:
+		return "+" + humanize.Bytes(cell.SizeBytes)
+	case cell.Change == image.PathModified:
+		return "~" + humanize.Bytes(cell.SizeBytes)
+	case cell.Change == image.PathDeleted:
+		return "-"
+	case cell.Present:
+		return "."
+	}
+	return ""
+}
+
+// lines renders the summary and the visible window of the table (the files that fit, and the layers that fit from the
+// leftmost shown layer).
+func (v *Pivot) lines() []string {
+	if v.pivot == nil {
+		return nil
+	}
+
+	viewWidth := maxPivotPathWidth * 2
+	if v.view != nil {
+		viewWidth, _ = v.view.Size()
+	}
+
+	// the paths get the room the layers do not need, and at least half of the popup when the layers do not fit
+	columns := len(v.refTrees) - v.left
+	width := viewWidth - columns*(pivotCellWidth+1)
+	if width > maxPivotPathWidth {
+		width = maxPivotPathWidth
+	}
+	if width < viewWidth/2 {
+		width = viewWidth / 2
+		if width > maxPivotPathWidth {
+			width = maxPivotPathWidth
+		}
+		columns = (viewWidth - width) / (pivotCellWidth + 1)
+	}
+	if width < 4 {
+		width = 4
+	}
+
+	header := fmt.Sprintf("%-*s", width, "Path")
+	for idx := v.left; idx < v.left+columns; idx++ {
+		header += fmt.Sprintf(" %*s", pivotCellWidth, fmt.Sprintf("L%d", idx))
+	}
+	lines := []string{v.summary(), "", format.Header(header)}
+	if len(v.pivot.Rows) == 0 {
+		return append(lines, "No files match the current filter")
+	}
+
+	stop := v.top + v.height()
+	if stop > len(v.pivot.Rows) {
+		stop = len(v.pivot.Rows)
+	}
+	for _, row := range v.pivot.Rows[v.top:stop] {
+		path := row.Path
+		if len(path) > width {
+			path = "..." + path[len(path)-width+3:]
+		}
+		line := fmt.Sprintf("%-*s", width, path)
+		for idx := v.left; idx < v.left+columns && idx < len(row.Cells); idx++ {
+			line += fmt.Sprintf(" %*s", pivotCellWidth, pivotCell(row.Cells[idx]))
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *Pivot) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Files by layer "
+		v.view.Subtitle = fmt.Sprintf(" Press %s to export to CSV, esc to close ", viper.GetString("keybinding.export-pivot"))
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout fills most of the screen with the popup.
+func (v *Pivot) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	x0, y0 := minX+pivotMargin, minY+pivotMargin
+	x1, y1 := maxX-pivotMargin, maxY-pivotMargin
+	if x1 <= x0 || y1 <= y0 {
+		x0, y0, x1, y1 = minX, minY, minX+1, minY+1
+	}
+	view, viewErr := g.SetView(v.Name(), x0, y0, x1, y1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup pivot controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Pivot) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,330 @@
+package view
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/awesome-gocui/termbox-go"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/key"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+)
+
+// the margin kept around the preview popup
+const previewMargin = 2
+
+// the lines above the image (the summary and a blank line)
+const previewHeaderLines = 2
+
+type PreviewCloseListener func() error
+
+// PreviewOpener opens the contents of the file to preview.
+type PreviewOpener func() (io.ReadCloser, error)
+
+// Preview holds the UI objects and data models for populating the popup that previews an image file with the graphics
+// protocol of the terminal. The image is drawn over the (blank) popup once the popup is on the screen, and is drawn
+// again whenever the popup is moved or the screen is repainted.
+type Preview struct {
+	name     string
+	gui      *gocui.Gui
+	view     *gocui.View
+	protocol terminal.GraphicsProtocol
+	hidden   bool
+
+	path    string
+	format  string
+	size    int64
+	image   image.Image
+	message string
+
+	// the popup position the image is drawn at, once the popup has been flushed to the screen there
+	placement [4]int
+	placed    bool
+	drawn     bool
+
+	closeListeners []PreviewCloseListener
+}
+
+// newPreviewView creates a new view object attached the the global [gocui] screen object.
+func newPreviewView(gui *gocui.Gui, protocol terminal.GraphicsProtocol) (controller *Preview) {
+	controller = new(Preview)
+
+	// populate main fields
+	controller.name = "preview"
+	controller.gui = gui
+	controller.protocol = protocol
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Preview) AddCloseListener(listener ...PreviewCloseListener) {
+	v.closeListeners = append(v.closeListeners, listener...)
+}
+
+func (v *Preview) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Preview) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = true
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show reads the image at the given path and opens the popup (taking focus). Files that cannot be previewed show the
+// reason instead.
+func (v *Preview) Show(filePath string, open PreviewOpener) error {
+	v.path = filePath
+	v.image = nil
+	v.size = 0
+	v.message = ""
+	v.placed = false
+	v.drawn = false
+	v.load(open)
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// load reads and decodes the image, keeping the reason when it cannot be previewed.
+func (v *Preview) load(open PreviewOpener) {
+	var ok bool
+	if v.format, ok = terminal.PreviewFormat(v.path); !ok {
+		v.message = "Only png, jpeg, gif and svg files can be previewed"
+		return
+	}
+
+	reader, err := open()
+	if err != nil {
+		v.message = fmt.Sprintf("Unable to read the file: %v", err)
+		return
+	}
+	defer reader.Close()
+
+	counter := &countingReader{reader: reader}
+	v.image, err = terminal.DecodeImage(v.path, counter)
+	v.size = counter.count
+	if err != nil {
+		v.message = fmt.Sprintf("Unable to decode the image: %v", err)
+		return
+	}
+	if v.protocol == terminal.NoGraphics {
+		v.message = "The terminal does not support graphics (set 'preview.graphics' to kitty or sixel to use one anyway)"
+	}
+}
+
+// Close hides the popup (removing the image from the screen) and notifies the listeners (which move the focus back).
+func (v *Preview) Close() error {
+	if v.hidden {
+		return nil
+	}
+	v.hidden = true
+	if v.view != nil {
+		v.view.Visible = false
+	}
+
+	if v.drawn {
+		if v.protocol == terminal.KittyGraphics {
+			v.write(terminal.KittyDeleteImages())
+		}
+		// the cells the image covered are not known to have changed, so the whole screen is repainted
+		v.gui.Update(func(*gocui.Gui) error {
+			return termbox.Sync()
+		})
+	}
+	v.drawn = false
+
+	for _, listener := range v.closeListeners {
+		if err := listener(); err != nil {
+			logrus.Errorf("notifyOnCloseListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Preview) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Preview) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Preview) OnLayoutChange() error {
+	// the screen is repainted after a resize, which removes the image
+	v.placed = false
+	v.drawn = false
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// summary describes the image file, including how many bytes it takes per pixel (which points out assets that are
+// far larger than their dimensions call for).
+func (v *Preview) summary() string {
+	if v.image == nil {
+		return strings.ToUpper(v.format)
+	}
+	bounds := v.image.Bounds()
+	summary := fmt.Sprintf("%s, %dx%d, %s", strings.ToUpper(v.format), bounds.Dx(), bounds.Dy(), humanize.Bytes(uint64(v.size)))
+	if pixels := bounds.Dx() * bounds.Dy(); pixels > 0 && v.format != "svg" {
+		summary += fmt.Sprintf(" (%.2f bytes per pixel)", float64(v.size)/float64(pixels))
+	}
+	return summary
+}
+
+// Render flushes the state objects to the screen.
+func (v *Preview) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Preview: " + path.Base(v.path) + " "
+		v.view.Subtitle = " Press esc to close "
+		v.view.Clear()
+		lines := []string{v.summary(), ""}
+		if v.message != "" {
+			lines = append(lines, v.message)
+		}
+		for _, line := range lines {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout fills most of the screen with the popup, drawing the image once the popup is on the screen.
+func (v *Preview) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	x0, y0 := minX+previewMargin, minY+previewMargin
+	x1, y1 := maxX-previewMargin, maxY-previewMargin
+	if x1 <= x0 || y1 <= y0 {
+		x0, y0, x1, y1 = minX, minY, minX+1, minY+1
+	}
+	view, viewErr := g.SetView(v.Name(), x0, y0, x1, y1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup preview controller", err)
+			return err
+		}
+	}
+
+	if !v.IsVisible() || v.image == nil || v.message != "" {
+		return nil
+	}
+
+	// the image is drawn over blank cells, which later flushes leave alone; the cells are only blank on the screen
+	// after the popup has been flushed once, so the image waits for the next flush
+	placement := [4]int{x0, y0, x1, y1}
+	if !v.placed || placement != v.placement {
+		v.placement = placement
+		v.placed = true
+		v.drawn = false
+		g.Update(func(*gocui.Gui) error { return nil })
+		return nil
+	}
+	if !v.drawn {
+		v.drawn = true
+		v.draw(x0+1, y0+1+previewHeaderLines, x1-x0-1, y1-y0-1-previewHeaderLines)
+	}
+	return nil
+}
+
+// draw writes the image within the given cells.
+func (v *Preview) draw(col, row, cols, rows int) {
+	if cols < 1 || rows < 1 {
+		return
+	}
+	cellWidth, cellHeight := terminal.CellPixelSize()
+	sequence, _, _, err := terminal.ImageSequence(v.protocol, v.image, cols, rows, cellWidth, cellHeight)
+	if err != nil {
+		logrus.Warnf("unable to draw the preview of %s: %+v", v.path, err)
+		return
+	}
+	if v.protocol == terminal.KittyGraphics {
+		sequence = terminal.KittyDeleteImages() + sequence
+	}
+	v.write(terminal.DrawAt(col, row, sequence))
+}
+
+func (v *Preview) write(sequence string) {
+	if _, err := os.Stdout.WriteString(sequence); err != nil {
+		logrus.Debugf("unable to write the preview: %+v", err)
+	}
+}
+
+func (v *Preview) RequestedSize(available int) *int {
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
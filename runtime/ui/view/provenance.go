@@ -0,0 +1,246 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+const (
+	// the widest the popup gets (narrower screens get a narrower popup)
+	maxProvenanceWidth = 120
+	// the margin kept around the popup
+	provenanceMargin = 2
+)
+
+// Provenance holds the UI objects and data models for populating the popup that lists every layer that added,
+// modified or deleted the selected file.
+type Provenance struct {
+	popup
+
+	name       string
+	gui        *gocui.Gui
+	view       *gocui.View
+	layers     []*image.Layer
+	refTrees   []*filetree.FileTree
+	provenance *image.PathProvenance
+}
+
+// newProvenanceView creates a new view object attached the the global [gocui] screen object.
+func newProvenanceView(gui *gocui.Gui, layers []*image.Layer, refTrees []*filetree.FileTree) (controller *Provenance) {
+	controller = new(Provenance)
+
+	// populate main fields
+	controller.name = "provenance"
+	controller.gui = gui
+	controller.layers = layers
+	controller.refTrees = refTrees
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Provenance) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Provenance) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.scroll(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show traces the given path through the layers and opens the popup (taking focus).
+func (v *Provenance) Show(path string) error {
+	v.provenance = image.TracePath(v.layers, v.refTrees, path)
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if err := v.view.SetOrigin(0, 0); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *Provenance) Close() error {
+	return v.closeView(v.view)
+}
+
+func (v *Provenance) scroll(delta int) error {
+	if v.view == nil {
+		return nil
+	}
+	ox, oy := v.view.Origin()
+	_, height := v.view.Size()
+	if oy+delta < 0 || oy+delta+height > len(v.lines()) {
+		return nil
+	}
+	return v.view.SetOrigin(ox, oy+delta)
+}
+
+// IsVisible indicates if the popup is open.
+func (v *Provenance) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Provenance) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Provenance) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders the changes to the path, one layer per line.
+func (v *Provenance) lines() []string {
+	if v.provenance == nil {
+		return nil
+	}
+
+	summary := "not in the final image"
+	if v.provenance.Present {
+		summary = "in the final image"
+	}
+	if first, ok := v.provenance.FirstAdded(); ok {
+		summary += fmt.Sprintf(", first added in layer %d", first.Layer)
+	}
+	if last, ok := v.provenance.LastChanged(); ok {
+		summary += fmt.Sprintf(", last %s in layer %d", last.Change, last.Layer)
+	}
+
+	lines := []string{summary, ""}
+	if len(v.provenance.Changes) == 0 {
+		lines = append(lines, "No layer added, modified or deleted this path")
+	} else {
+		lines = append(lines, format.Header(fmt.Sprintf("%5s  %-8s  %8s  %s", "Layer", "Change", "Size", "Command")))
+		for _, change := range v.provenance.Changes {
+			size := ""
+			if change.Change != image.PathDeleted {
+				size = humanize.Bytes(change.SizeBytes)
+			}
+			command := strings.Join(strings.Fields(change.Command), " ")
+			lines = append(lines, fmt.Sprintf("%5d  %-8s  %8s  %s", change.Layer, change.Change, size, command))
+		}
+	}
+	if v.provenance.Partial {
+		lines = append(lines, "", "Layers that have not been loaded yet are not included")
+	}
+	lines = append(lines, "", "Press esc to close")
+	return lines
+}
+
+// Render flushes the state objects to the screen.
+func (v *Provenance) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = " Provenance: " + v.provenance.Path + " "
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to its contents.
+func (v *Provenance) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := maxX - minX - 2*provenanceMargin
+	if width > maxProvenanceWidth {
+		width = maxProvenanceWidth
+	}
+	height := len(v.lines()) + 1
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup provenance controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Provenance) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,263 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// SavedFilter is a path filter saved in the config under a name (see filetree.saved-filters).
+type SavedFilter struct {
+	Name    string
+	Pattern string
+}
+
+// NewSavedFilters lists the named filters of the config, sorted by name.
+func NewSavedFilters(patterns map[string]string) []SavedFilter {
+	filters := make([]SavedFilter, 0, len(patterns))
+	for name, pattern := range patterns {
+		filters = append(filters, SavedFilter{Name: name, Pattern: pattern})
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		return filters[i].Name < filters[j].Name
+	})
+	return filters
+}
+
+const savedFiltersTitle = " Saved filters "
+
+type SavedFilterPickListener func(filter SavedFilter) error
+
+// SavedFilters holds the UI objects for the dropdown that lists the saved filters above the filter pane, to filter the
+// file tree by one of them.
+type SavedFilters struct {
+	popup
+
+	name    string
+	gui     *gocui.Gui
+	view    *gocui.View
+	filters []SavedFilter
+	// the index of the highlighted filter
+	selected int
+
+	pickListeners []SavedFilterPickListener
+}
+
+// newSavedFiltersView creates a new view object attached the the global [gocui] screen object.
+func newSavedFiltersView(gui *gocui.Gui, filters []SavedFilter) (controller *SavedFilters) {
+	controller = new(SavedFilters)
+
+	// populate main fields
+	controller.name = "saved-filters"
+	controller.gui = gui
+	controller.filters = filters
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *SavedFilters) AddPickListener(listener ...SavedFilterPickListener) {
+	v.pickListeners = append(v.pickListeners, listener...)
+}
+
+func (v *SavedFilters) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *SavedFilters) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Title = savedFiltersTitle
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.pick,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show opens the dropdown (taking focus), highlighting the first filter.
+func (v *SavedFilters) Show() error {
+	v.selected = 0
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the dropdown and notifies the listeners (which move the focus back).
+func (v *SavedFilters) Close() error {
+	return v.closeView(v.view)
+}
+
+// pick closes the dropdown and hands the highlighted filter to the listeners (which filter the file tree by it).
+func (v *SavedFilters) pick() error {
+	if err := v.Close(); err != nil {
+		return err
+	}
+	if v.selected >= len(v.filters) {
+		return nil
+	}
+	for _, listener := range v.pickListeners {
+		if err := listener(v.filters[v.selected]); err != nil {
+			logrus.Errorf("notifyOnPickListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *SavedFilters) move(delta int) error {
+	if v.selected+delta < 0 || v.selected+delta >= len(v.filters) {
+		return nil
+	}
+	v.selected += delta
+	return v.Render()
+}
+
+// IsVisible indicates if the dropdown is open.
+func (v *SavedFilters) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *SavedFilters) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *SavedFilters) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// rows lists one filter per row: its name, then its pattern.
+func (v *SavedFilters) rows() []string {
+	if len(v.filters) == 0 {
+		return []string{" No saved filters (see filetree.saved-filters) "}
+	}
+	width := 0
+	for _, filter := range v.filters {
+		if len(filter.Name) > width {
+			width = len(filter.Name)
+		}
+	}
+	rows := make([]string, 0, len(v.filters))
+	for _, filter := range v.filters {
+		rows = append(rows, fmt.Sprintf(" %-*s  %s ", width, filter.Name, filter.Pattern))
+	}
+	return rows
+}
+
+// Render flushes the state objects to the screen.
+func (v *SavedFilters) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Clear()
+		for idx, line := range v.rows() {
+			if idx == v.selected && len(v.filters) > 0 {
+				line = format.Selected(line)
+			}
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout drops the list down from just above the footer (where the filter pane is), sized to its contents.
+func (v *SavedFilters) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	rows := v.rows()
+	width := len(savedFiltersTitle) + 2
+	for _, row := range rows {
+		if len(row)+1 > width {
+			width = len(row) + 1
+		}
+	}
+	if available := maxX - minX - 1; width > available {
+		width = available
+	}
+	height := len(rows) + 1
+	// above the filter and status rows
+	y1 := maxY - 2
+	if available := y1 - minY - 1; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + 1
+	view, viewErr := g.SetView(v.Name(), x0, y1-height, x0+width, y1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup saved filters controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *SavedFilters) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,47 @@
+package view
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSavedFilters(t *testing.T) {
+	filters := NewSavedFilters(map[string]string{
+		"secrets":         `\.pem$`,
+		"caches":          `/var/cache(/|$)`,
+		"python-bytecode": `\.py[co]$`,
+	})
+
+	view := newSavedFiltersView(nil, filters)
+	expected := []string{
+		` caches           /var/cache(/|$) `,
+		` python-bytecode  \.py[co]$ `,
+		` secrets          \.pem$ `,
+	}
+	if rows := view.rows(); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected rows:\n%q\ngot:\n%q", expected, rows)
+	}
+
+	var picked []SavedFilter
+	view.AddPickListener(func(filter SavedFilter) error {
+		picked = append(picked, filter)
+		return nil
+	})
+	view.hidden = false
+	view.selected = len(filters) - 1
+	// the selection stops at the last filter
+	if err := view.move(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := view.pick(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(picked) != 1 || picked[0].Name != "secrets" || view.IsVisible() {
+		t.Errorf("expected the last filter to be picked and the dropdown closed, got %+v", picked)
+	}
+
+	empty := newSavedFiltersView(nil, nil)
+	if rows := empty.rows(); len(rows) != 1 {
+		t.Errorf("expected a hint without saved filters, got %q", rows)
+	}
+}
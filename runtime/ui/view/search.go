@@ -0,0 +1,239 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+// Searchable is a pane whose entries can be searched (see Search).
+type Searchable interface {
+	Name() string
+	// Search moves the cursor to the next (or previous) entry matching the query, wrapping around the ends of the pane,
+	// and reports whether an entry matches.
+	Search(query string, forward bool) (bool, error)
+}
+
+// SearchListener is notified when the user asks to search a pane: to type a query (prompt), or to move to the next (or
+// previous) match of the last query.
+type SearchListener func(pane Searchable, prompt, forward bool) error
+
+// SearchSubmitListener is notified with the typed query (empty when the search was cancelled).
+type SearchSubmitListener func(pane Searchable, query string) error
+
+// Search holds the UI objects for the bottom row the search query of the focused pane is typed into (in the style of
+// vim's "/").
+type Search struct {
+	name      string
+	gui       *gocui.Gui
+	symbols   format.Symbols
+	view      *gocui.View
+	header    *gocui.View
+	labelStr  string
+	maxLength int
+	hidden    bool
+	// the pane searched
+	target Searchable
+	// the last submitted query (searched again by the next and previous match keys)
+	query string
+
+	requestedHeight int
+
+	submitListeners []SearchSubmitListener
+}
+
+// newSearchView creates a new view object attached the the global [gocui] screen object.
+func newSearchView(gui *gocui.Gui, symbols format.Symbols) (controller *Search) {
+	controller = new(Search)
+
+	// populate main fields
+	controller.name = "search"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.labelStr = "Search: "
+	controller.hidden = true
+	controller.requestedHeight = 1
+
+	return controller
+}
+
+func (v *Search) AddSubmitListener(listener ...SearchSubmitListener) {
+	v.submitListeners = append(v.submitListeners, listener...)
+}
+
+func (v *Search) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Search) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.maxLength = 200
+	v.view.Frame = false
+	v.view.BgColor = gocui.AttrReverse
+	v.view.Editable = true
+	v.view.Editor = v
+
+	v.header = header
+	v.header.BgColor = gocui.AttrReverse
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.submit,
+		},
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Open shows the search row (taking focus) to type a query for the given pane.
+func (v *Search) Open(target Searchable) error {
+	v.target = target
+	v.hidden = false
+	if v.view == nil {
+		return nil
+	}
+	v.view.Clear()
+	if err := v.view.SetCursor(0, 0); err != nil {
+		logrus.Debug("unable to move the search cursor: ", err)
+	}
+	_, err := v.gui.SetCurrentView(v.name)
+	return err
+}
+
+// Query is the last submitted query (empty when none).
+func (v *Search) Query() string {
+	return v.query
+}
+
+// submit hides the search row and hands the typed query over to the listeners (which search the pane).
+func (v *Search) submit() error {
+	query := strings.TrimSpace(v.view.Buffer())
+	if query != "" {
+		v.query = query
+	}
+	return v.close(query)
+}
+
+// Close hides the search row without searching, and notifies the listeners (which move the focus back).
+func (v *Search) Close() error {
+	return v.close("")
+}
+
+func (v *Search) close(query string) error {
+	if v.hidden {
+		return nil
+	}
+	v.hidden = true
+
+	for _, listener := range v.submitListeners {
+		if err := listener(v.target, query); err != nil {
+			logrus.Errorf("notifySubmitListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// IsVisible indicates if the search row is shown.
+func (v *Search) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Edit intercepts the key press events in the search row.
+func (v *Search) Edit(view *gocui.View, k gocui.Key, ch rune, mod gocui.Modifier) {
+	if !v.IsVisible() {
+		return
+	}
+
+	cx, _ := view.Cursor()
+	ox, _ := view.Origin()
+	limit := ox+cx+1 > v.maxLength
+	switch {
+	case ch != 0 && mod == 0 && !limit:
+		view.EditWrite(ch)
+	case k == gocui.KeySpace && !limit:
+		view.EditWrite(' ')
+	case k == gocui.KeyBackspace || k == gocui.KeyBackspace2:
+		view.EditDelete(true)
+	}
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Search) Update() error {
+	return nil
+}
+
+// Render flushes the state objects to the screen.
+func (v *Search) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.header == nil {
+			return nil
+		}
+		v.header.Clear()
+		_, err := fmt.Fprintln(v.header, format.Header(v.labelStr))
+		if err != nil {
+			logrus.Error("unable to write to buffer: ", err)
+		}
+		return err
+	})
+	return nil
+}
+
+// KeyHelp indicates all the possible actions a user can take while the search row is focused.
+func (v *Search) KeyHelp() string {
+	return format.StatusControlNormal(v.symbols.StatusSeparator + "Type to search, enter to jump to the match ")
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Search) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+func (v *Search) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	label, labelErr := g.SetView(v.Name()+"label", minX, minY, len(v.labelStr), maxY, 0)
+	view, viewErr := g.SetView(v.Name(), minX+(len(v.labelStr)-1), minY, maxX, maxY, 0)
+
+	if IsNewView(viewErr, labelErr) {
+		err := v.Setup(view, label)
+		if err != nil {
+			logrus.Error("unable to setup search controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Search) RequestedSize(available int) *int {
+	return &v.requestedHeight
+}
@@ -3,35 +3,66 @@ package view
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/runtime/ui/format"
 	"github.com/wagoodman/dive/runtime/ui/key"
-	"github.com/wagoodman/dive/utils"
+	"github.com/wagoodman/dive/runtime/ui/viewmodel"
 
 	"github.com/awesome-gocui/gocui"
 )
 
+const (
+	// how long a notice stays in the status bar
+	noticeDuration = 5 * time.Second
+	// how often the spinner of the background tasks moves
+	spinnerInterval = 120 * time.Millisecond
+)
+
+// statusTask is a background task shown in the status bar until it is done.
+type statusTask struct {
+	name    string
+	message string
+}
+
 // Status holds the UI objects and data models for populating the bottom-most pane. Specifically the panel
-// shows the user a set of possible actions to take in the window and currently selected pane.
+// shows the user a set of possible actions to take in the window and currently selected pane (or open popup), along
+// with the background tasks in progress and the latest notice.
 type Status struct {
-	name string
-	gui  *gocui.Gui
-	view *gocui.View
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
 
-	selectedView    Helper
+	selectedView Helper
+	modals       *Modals
+	// the popup the key help was last rendered for (empty for the selected pane)
+	helpFor         string
 	requestedHeight int
 
 	helpKeys []*key.Binding
+	macros   *key.Macros
+	// shown ahead of the key help for a few seconds
+	notice string
+	// counts the notices shown, so that a timer only clears the notice it was started for
+	notices int
+	// the background tasks in progress (e.g. hashing lazy layers, comparing layers), in the order they started
+	tasks []statusTask
+	// the frame of the spinner shown with the tasks, and whether it is moving
+	spinner  int
+	spinning bool
 }
 
 // newStatusView creates a new view object attached the the global [gocui] screen object.
-func newStatusView(gui *gocui.Gui) (controller *Status) {
+func newStatusView(gui *gocui.Gui, symbols format.Symbols) (controller *Status) {
 	controller = new(Status)
 
 	// populate main fields
 	controller.name = "status"
 	controller.gui = gui
+	controller.symbols = symbols
 	controller.helpKeys = make([]*key.Binding, 0)
 	controller.requestedHeight = 1
 
@@ -46,6 +77,93 @@ func (v *Status) Name() string {
 	return v.name
 }
 
+// SetMacros shows the macro recording state alongside the key help.
+func (v *Status) SetMacros(macros *key.Macros) {
+	v.macros = macros
+}
+
+// SetModals shows the key help of the open popup (if any) instead of the key help of the selected pane.
+func (v *Status) SetModals(modals *Modals) {
+	v.modals = modals
+}
+
+// SetMessage shows the message ahead of the key help for a few seconds.
+func (v *Status) SetMessage(message string) {
+	v.notice = message
+	v.notices++
+	notices := v.notices
+	time.AfterFunc(noticeDuration, func() {
+		v.gui.Update(func(g *gocui.Gui) error {
+			if v.notices != notices {
+				return nil
+			}
+			v.notice = ""
+			return v.render()
+		})
+	})
+}
+
+// SetProgress shows the progress of background work ahead of the key help, until its stage is done. It may be called
+// from any goroutine.
+func (v *Status) SetProgress(event image.ProgressEvent) {
+	v.OnStatusEvent(viewmodel.StatusEvent{Task: string(event.Stage), Message: event.String(), Done: event.Done})
+}
+
+// OnStatusEvent shows the background tasks of the view models with a spinner until they are done, and their notices
+// for a few seconds. It may be called from any goroutine.
+func (v *Status) OnStatusEvent(event viewmodel.StatusEvent) {
+	v.gui.Update(func(g *gocui.Gui) error {
+		switch {
+		case event.Task == "":
+			v.SetMessage(event.Message)
+		case event.Done:
+			v.finishTask(event.Task)
+		default:
+			v.startTask(event.Task, event.Message)
+		}
+		return v.render()
+	})
+}
+
+// startTask shows (or updates) a background task, starting the spinner if it is not moving yet.
+func (v *Status) startTask(name, message string) {
+	for idx := range v.tasks {
+		if v.tasks[idx].name == name {
+			v.tasks[idx].message = message
+			return
+		}
+	}
+	v.tasks = append(v.tasks, statusTask{name: name, message: message})
+	if !v.spinning {
+		v.spinning = true
+		v.spin()
+	}
+}
+
+func (v *Status) finishTask(name string) {
+	for idx := range v.tasks {
+		if v.tasks[idx].name == name {
+			v.tasks = append(v.tasks[:idx], v.tasks[idx+1:]...)
+			return
+		}
+	}
+}
+
+// spin moves the spinner while there are tasks in progress.
+func (v *Status) spin() {
+	time.AfterFunc(spinnerInterval, func() {
+		v.gui.Update(func(g *gocui.Gui) error {
+			if len(v.tasks) == 0 {
+				v.spinning = false
+				return nil
+			}
+			v.spinner++
+			v.spin()
+			return v.render()
+		})
+	})
+}
+
 func (v *Status) AddHelpKeys(keys ...*key.Binding) {
 	v.helpKeys = append(v.helpKeys, keys...)
 }
@@ -82,46 +200,93 @@ func (v *Status) OnLayoutChange() error {
 
 // Render flushes the state objects to the screen.
 func (v *Status) Render() error {
-	logrus.Tracef("view.Render() %s", v.Name())
+	TraceRender(v.Name())
 
 	v.gui.Update(func(g *gocui.Gui) error {
-		v.view.Clear()
+		return v.render()
+	})
+	return nil
+}
 
-		var selectedHelp string
-		if v.selectedView != nil {
-			selectedHelp = v.selectedView.KeyHelp()
+// render writes the pane on the UI goroutine.
+func (v *Status) render() error {
+	if v.view == nil {
+		// not shown yet, the pane is rendered once it is set up
+		return nil
+	}
+	v.view.Clear()
+
+	var status string
+	for _, task := range v.tasks {
+		spinner := v.symbols.Spinner[v.spinner%len(v.symbols.Spinner)]
+		status += format.StatusControlSelected(fmt.Sprintf("%s%s %s ", v.symbols.StatusSeparator, spinner, task.message))
+	}
+	if v.notice != "" {
+		status += format.StatusControlSelected(fmt.Sprintf("%s%s ", v.symbols.StatusSeparator, v.notice))
+	}
+	if v.macros != nil {
+		if register, recording := v.macros.Recording(); recording {
+			status += format.StatusControlSelected(fmt.Sprintf("%srecording @%c ", v.symbols.StatusSeparator, register))
 		}
+	}
 
-		_, err := fmt.Fprintln(v.view, v.KeyHelp()+selectedHelp+format.StatusNormal("▏"+strings.Repeat(" ", 1000)))
-		if err != nil {
-			logrus.Debug("unable to write to buffer: ", err)
+	_, err := fmt.Fprintln(v.view, status+v.KeyHelp()+v.selectedHelp()+format.StatusNormal(v.symbols.StatusSeparator+strings.Repeat(" ", 1000)))
+	if err != nil {
+		logrus.Debug("unable to write to buffer: ", err)
+	}
+	return err
+}
+
+// openModal returns the name of the open popup, or an empty string when no popup is open.
+func (v *Status) openModal() string {
+	if v.modals != nil {
+		if open := v.modals.Open(); open != nil {
+			return open.Name()
 		}
+	}
+	return ""
+}
 
-		return err
-	})
-	return nil
+// selectedHelp returns the key help of the open popup, or of the selected pane when no popup is open.
+func (v *Status) selectedHelp() string {
+	v.helpFor = v.openModal()
+	if v.helpFor != "" {
+		if helper, ok := v.modals.Open().(Helper); ok {
+			return helper.KeyHelp()
+		}
+		return v.symbols.RenderHelpKey("Esc", "Close", false)
+	}
+	if v.selectedView != nil {
+		return v.selectedView.KeyHelp()
+	}
+	return ""
 }
 
 // KeyHelp indicates all the possible global actions a user can take when any pane is selected.
 func (v *Status) KeyHelp() string {
 	var help string
 	for _, binding := range v.helpKeys {
-		help += binding.RenderKeyHelp()
+		help += binding.RenderKeyHelp(v.symbols)
 	}
 	return help
 }
 
 func (v *Status) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
-	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
 
 	view, viewErr := g.SetView(v.Name(), minX, minY, maxX, maxY, 0)
-	if utils.IsNewView(viewErr) {
+	if IsNewView(viewErr) {
 		err := v.Setup(view)
 		if err != nil {
 			logrus.Error("unable to setup status controller", err)
 			return err
 		}
 	}
+
+	// popups open and close without going through the controller, so follow them here to show their key help
+	if v.openModal() != v.helpFor {
+		return v.render()
+	}
 	return nil
 }
 
@@ -0,0 +1,135 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// TabInfo is an image opened in the session, as shown in the tab bar and the tab picker.
+type TabInfo struct {
+	Name string
+	// what became of the image in the background (e.g. "loading"), empty once it can be shown
+	State string
+}
+
+// TabSource returns the images opened in the session and the index of the one shown.
+type TabSource func() ([]TabInfo, int)
+
+// label renders the tab for the bar and the picker (e.g. "2 app:canary (loading)").
+func (tab TabInfo) label(index int) string {
+	label := fmt.Sprintf("%d %s", index+1, tab.Name)
+	if tab.State != "" {
+		label += " (" + tab.State + ")"
+	}
+	return label
+}
+
+// TabBar holds the UI objects for the top-most row, which lists the images opened in the session (it is only laid out
+// when several images are opened).
+type TabBar struct {
+	name    string
+	gui     *gocui.Gui
+	symbols format.Symbols
+	view    *gocui.View
+	source  TabSource
+}
+
+// newTabBarView creates a new view object attached the the global [gocui] screen object.
+func newTabBarView(gui *gocui.Gui, source TabSource, symbols format.Symbols) (controller *TabBar) {
+	controller = new(TabBar)
+
+	// populate main fields
+	controller.name = "tabs"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.source = source
+
+	return controller
+}
+
+func (v *TabBar) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *TabBar) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Frame = false
+
+	return v.Render()
+}
+
+// IsVisible indicates if the tab bar is currently initialized.
+func (v *TabBar) IsVisible() bool {
+	return v != nil
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *TabBar) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *TabBar) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *TabBar) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Clear()
+
+		tabs, current := v.source()
+		var line string
+		for idx, tab := range tabs {
+			label := " " + tab.label(idx) + " "
+			if idx == current {
+				line += format.StatusSelected(label)
+			} else {
+				line += format.StatusNormal(label)
+			}
+			line += format.StatusNormal(v.symbols.StatusSeparator)
+		}
+		_, err := fmt.Fprintln(v.view, line+format.StatusNormal(strings.Repeat(" ", 1000)))
+		if err != nil {
+			logrus.Debug("unable to write to buffer: ", err)
+		}
+		return err
+	})
+	return nil
+}
+
+func (v *TabBar) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	// the header area has no room for a border, so the (invisible) border overlaps the pane below
+	view, viewErr := g.SetView(v.Name(), minX, minY, maxX, maxY+1, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup tabs controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *TabBar) RequestedSize(available int) *int {
+	return nil
+}
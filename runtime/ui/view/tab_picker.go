@@ -0,0 +1,257 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/key"
+)
+
+type TabPickListener func(index int) error
+
+// TabPicker holds the UI objects for the popup that lists the images opened in the session, to switch to one of them.
+type TabPicker struct {
+	popup
+
+	name   string
+	gui    *gocui.Gui
+	view   *gocui.View
+	source TabSource
+	// the index of the highlighted image
+	selected int
+	// the title and the listener of a one-off pick (see Pick), nil when switching images
+	title  string
+	onPick TabPickListener
+
+	pickListeners []TabPickListener
+}
+
+// newTabPickerView creates a new view object attached the the global [gocui] screen object.
+func newTabPickerView(gui *gocui.Gui, source TabSource) (controller *TabPicker) {
+	controller = new(TabPicker)
+
+	// populate main fields
+	controller.name = "tab-picker"
+	controller.gui = gui
+	controller.source = source
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *TabPicker) AddPickListener(listener ...TabPickListener) {
+	v.pickListeners = append(v.pickListeners, listener...)
+}
+
+func (v *TabPicker) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *TabPicker) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	var infos = []key.BindingInfo{
+		{
+			Key:      gocui.KeyEsc,
+			Modifier: gocui.ModNone,
+			OnAction: v.Close,
+		},
+		{
+			Key:      gocui.KeyEnter,
+			Modifier: gocui.ModNone,
+			OnAction: v.pick,
+		},
+		{
+			Key:      gocui.KeyArrowDown,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(1) },
+		},
+		{
+			Key:      gocui.KeyArrowUp,
+			Modifier: gocui.ModNone,
+			OnAction: func() error { return v.move(-1) },
+		},
+	}
+
+	_, err := key.GenerateBindings(v.gui, v.name, infos)
+	if err != nil {
+		return err
+	}
+
+	return v.Render()
+}
+
+// Show opens the popup (taking focus), highlighting the image shown.
+func (v *TabPicker) Show() error {
+	return v.open(" Images ", nil)
+}
+
+// Pick opens the popup to pick an image for something else than switching to it (e.g. comparing it with the image
+// shown): the listener is called with the picked image instead of the pick listeners.
+func (v *TabPicker) Pick(title string, listener TabPickListener) error {
+	return v.open(" "+title+" ", listener)
+}
+
+func (v *TabPicker) open(title string, listener TabPickListener) error {
+	_, v.selected = v.source()
+	v.title, v.onPick = title, listener
+	v.hidden = false
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		if _, err := g.SetViewOnTop(v.name); err != nil {
+			return err
+		}
+		_, err := g.SetCurrentView(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+// Close hides the popup and notifies the listeners (which move the focus back).
+func (v *TabPicker) Close() error {
+	return v.closeView(v.view)
+}
+
+// pick closes the popup and switches to the highlighted image (or hands it to the listener of a one-off pick).
+func (v *TabPicker) pick() error {
+	selected, onPick := v.selected, v.onPick
+	if err := v.Close(); err != nil {
+		return err
+	}
+	if onPick != nil {
+		return onPick(selected)
+	}
+	for _, listener := range v.pickListeners {
+		if err := listener(selected); err != nil {
+			logrus.Errorf("notifyOnPickListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *TabPicker) move(delta int) error {
+	tabs, _ := v.source()
+	if v.selected+delta < 0 || v.selected+delta >= len(tabs) {
+		return nil
+	}
+	v.selected += delta
+	return v.Render()
+}
+
+// IsVisible indicates if the popup is open.
+func (v *TabPicker) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *TabPicker) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *TabPicker) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// lines renders one image per line, the highlighted one selected.
+func (v *TabPicker) lines() []string {
+	tabs, _ := v.source()
+	var lines []string
+	for idx, tab := range tabs {
+		line := " " + tab.label(idx) + " "
+		if idx == v.selected {
+			line = format.Selected(line)
+		}
+		lines = append(lines, line)
+	}
+	return append(lines, "", v.hint())
+}
+
+// hint tells how to pick an image.
+func (v *TabPicker) hint() string {
+	if v.onPick != nil {
+		return "Press enter to pick, esc to close"
+	}
+	return "Press enter to switch, esc to close"
+}
+
+// Render flushes the state objects to the screen.
+func (v *TabPicker) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Title = v.title
+		v.view.Clear()
+		for _, line := range v.lines() {
+			_, err := fmt.Fprintln(v.view, line)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// Layout centers the popup on the screen, sized to its contents.
+func (v *TabPicker) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	tabs, _ := v.source()
+	width := len(v.hint()) + 2
+	for idx, tab := range tabs {
+		if len(tab.label(idx))+4 > width {
+			width = len(tab.label(idx)) + 4
+		}
+	}
+	if available := maxX - minX - 2*provenanceMargin; width > available {
+		width = available
+	}
+	height := len(tabs) + 3
+	if available := maxY - minY - 2*provenanceMargin; height > available {
+		height = available
+	}
+	if width < 1 || height < 1 {
+		width, height = 1, 1
+	}
+
+	x0 := minX + (maxX-minX-width)/2
+	y0 := minY + (maxY-minY-height)/2
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+height, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup tab picker controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *TabPicker) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,158 @@
+package view
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+)
+
+// how long a toast stays on the screen
+const toastDuration = 4 * time.Second
+
+// Toast is a short notice shown in the bottom right corner of the screen for a few seconds. Unlike the popups, it does
+// not take the focus.
+type Toast struct {
+	name    string
+	gui     *gocui.Gui
+	view    *gocui.View
+	message string
+	// counts the notices shown, so that a timer only hides the notice it was started for
+	shown  int
+	hidden bool
+}
+
+// newToastView creates a new view object attached the the global [gocui] screen object.
+func newToastView(gui *gocui.Gui) (controller *Toast) {
+	controller = new(Toast)
+
+	// populate main fields
+	controller.name = "toast"
+	controller.gui = gui
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *Toast) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Toast) Setup(view *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = true
+	v.view.Visible = !v.hidden
+
+	return v.Render()
+}
+
+// Show displays the given notice (replacing the one shown, if any) and hides it after a few seconds.
+func (v *Toast) Show(message string) error {
+	v.message = message
+	v.hidden = false
+	v.shown++
+	shown := v.shown
+
+	time.AfterFunc(toastDuration, func() {
+		v.gui.Update(func(*gocui.Gui) error {
+			if v.shown == shown {
+				v.hide()
+			}
+			return nil
+		})
+	})
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil {
+			return nil
+		}
+		v.view.Visible = true
+		_, err := g.SetViewOnTop(v.name)
+		return err
+	})
+	return v.Render()
+}
+
+func (v *Toast) hide() {
+	v.hidden = true
+	if v.view != nil {
+		v.view.Visible = false
+	}
+}
+
+// IsVisible indicates if a notice is shown.
+func (v *Toast) IsVisible() bool {
+	return v != nil && !v.hidden
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Toast) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Toast) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Toast) Render() error {
+	TraceRender(v.Name())
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		if v.view == nil || !v.IsVisible() {
+			return nil
+		}
+
+		v.view.Clear()
+		_, err := fmt.Fprint(v.view, v.message)
+		if err != nil {
+			logrus.Debug("unable to write to buffer: ", err)
+		}
+		return err
+	})
+	return nil
+}
+
+// Layout places the notice in the bottom right corner of the screen, above the status and filter panes.
+func (v *Toast) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	TraceLayout(v.Name(), minX, minY, maxX, maxY)
+
+	width := len(v.message) + 1
+	if available := maxX - minX - 2*provenanceMargin; width > available {
+		width = available
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	x0 := maxX - provenanceMargin - width
+	y0 := maxY - provenanceMargin - 4
+	if y0 < minY {
+		y0 = minY
+	}
+	view, viewErr := g.SetView(v.Name(), x0, y0, x0+width, y0+2, 0)
+	if IsNewView(viewErr) {
+		err := v.Setup(view)
+		if err != nil {
+			logrus.Error("unable to setup toast controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Toast) RequestedSize(available int) *int {
+	return nil
+}
@@ -0,0 +1,18 @@
+package view
+
+import "github.com/sirupsen/logrus"
+
+// TraceRender logs that a view is rendered. Views are rendered (and laid out) on every draw, so nothing is formatted
+// (or allocated) unless trace logging is enabled.
+func TraceRender(name string) {
+	if logrus.IsLevelEnabled(logrus.TraceLevel) {
+		logrus.Tracef("view.Render() %s", name)
+	}
+}
+
+// TraceLayout logs that a view is laid out within the given bounds (only when trace logging is enabled).
+func TraceLayout(name string, minX, minY, maxX, maxY int) {
+	if logrus.IsLevelEnabled(logrus.TraceLevel) {
+		logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, name)
+	}
+}
@@ -1,50 +1,199 @@
 package view
 
 import (
+	"os"
+
 	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/terminal"
+	"github.com/wagoodman/dive/runtime/ui/viewmodel"
 )
 
 type Views struct {
-	Tree    *FileTree
-	Layer   *Layer
-	Status  *Status
-	Filter  *Filter
-	Details *Details
-	Debug   *Debug
+	Tree          *FileTree
+	Layer         *Layer
+	Status        *Status
+	Filter        *Filter
+	Search        *Search
+	GoToPath      *GoToPath
+	Details       *Details
+	Warnings      *Warnings
+	ParseWarnings *ParseWarnings
+	Attestations  *Attestations
+	Audit         *Audit
+	Dependencies  *Dependencies
+	Duplicates    *Duplicates
+	Marks         *Marks
+	FileDetails   *FileDetails
+	Provenance    *Provenance
+	History       *History
+	Ownership     *Ownership
+	ImageConfig   *ImageConfig
+	Preview       *Preview
+	Archive       *Archive
+	Pivot         *Pivot
+	Packages      *Packages
+	Compare       *Compare
+	SavedFilters  *SavedFilters
+	Dialog        *Dialog
+	Toast         *Toast
+	Modals        *Modals
+	// the tab bar and the tab picker (nil when a single image is opened)
+	Tabs      *TabBar
+	TabPicker *TabPicker
+	Debug     *Debug
+
+	// what the view models are busy with, shown in the status pane
+	StatusBus *viewmodel.StatusBus
 }
 
-func NewViews(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer) (*Views, error) {
-	Layer, err := newLayerView(g, analysis.Layers)
+// NewViews creates the views of an image, showing the files as the render options say and drawing the panes with the
+// given symbols. The tabs list the images opened in the session (nil when a single image is opened).
+func NewViews(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer, bookmarks *viewmodel.Bookmarks, render filetree.RenderOptions, symbols format.Symbols, tabs TabSource) (*Views, error) {
+	Layer, err := newLayerView(g, analysis.Layers, analysis.History, analysis.RefTrees, analysis.ImageID, analysis.Vulnerabilities, symbols)
 	if err != nil {
 		return nil, err
 	}
 
-	treeStack := analysis.RefTrees[0]
-	Tree, err := newFileTreeView(g, treeStack, analysis.RefTrees, cache)
+	treeStack, err := cache.GetTree(filetree.NewTreeIndexKey(0, 0, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+	Tree, err := newFileTreeView(g, treeStack, analysis.RefTrees, cache, bookmarks, render, symbols, analysis.Metrics)
 	if err != nil {
 		return nil, err
 	}
+	Tree.SetImageSize(int64(analysis.SizeBytes))
+	Tree.SetLayers(analysis.Layers)
+	if analysis.Vulnerabilities != nil {
+		Tree.SetVulnerable(analysis.Vulnerabilities.Files)
+	}
 
-	Status := newStatusView(g)
+	Status := newStatusView(g, symbols)
 
 	// set the layer view as the first selected view
 	Status.SetCurrentView(Layer)
 
-	Filter := newFilterView(g)
+	Filter := newFilterView(g, symbols)
+
+	Search := newSearchView(g, symbols)
+
+	GoToPath := newGoToPathView(g, symbols)
+
+	var pullCost *image.PullCost
+	bandwidths := append([]string{viper.GetString("pull.bandwidth")}, viper.GetStringSlice("pull.compare-bandwidths")...)
+	profiles, err := image.ParseBandwidthProfiles(bandwidths, viper.GetString("pull.layer-latency"))
+	if err != nil {
+		logrus.Errorf("unable to estimate pull time: %+v", err)
+	} else {
+		baseLayers := 0
+		if analysis.Base != nil {
+			baseLayers = analysis.Base.Layers
+		}
+		pullCost = image.EstimatePullCost(analysis.Layers, baseLayers, profiles, viper.GetInt("pull.monthly-pulls"), viper.GetFloat64("pull.egress-price"))
+	}
+
+	Details := newDetailsView(g, imageName, analysis.Efficiency, analysis.Inefficiencies, analysis.SizeBytes, analysis.CompressedBytes, analysis.Partial, pullCost, analysis.Base, analysis.UserSizeByes, analysis.AppWastedBytes, analysis.Downloads, analysis.Breakdown, analysis.Signature, render, symbols)
+
+	Warnings := newWarningsView(g, analysis.Deprecations, symbols)
+
+	ParseWarnings := newParseWarningsView(g, analysis.Layers, symbols)
+
+	Attestations := newAttestationsView(g, analysis.Attestations, symbols)
+
+	Audit := newAuditView(g, analysis.Audit, viper.GetBool("audit.enabled"), symbols)
+
+	Dependencies := newDependenciesView(g, analysis.Dependencies, viper.GetBool("dependencies.enabled"), symbols)
+
+	Duplicates := newDuplicatesView(g, analysis.DuplicateContent, symbols)
+
+	Marks := newMarksView(g, bookmarks, symbols)
 
-	Details := newDetailsView(g, imageName, analysis.Efficiency, analysis.Inefficiencies, analysis.SizeBytes)
+	Packages := newPackagesView(g, analysis.Packages, analysis.RefTrees, analysis.Contents, analysis.SizeBytes)
+
+	FileDetails := newFileDetailsView(g, Packages.Owner, render.IDMapping, symbols)
+
+	Provenance := newProvenanceView(g, analysis.Layers, analysis.RefTrees)
+
+	History := newHistoryView(g, analysis.History, analysis.Layers, symbols)
+
+	ImageConfig := newImageConfigView(g, analysis.Config)
+
+	Ownership := newOwnershipView(g, analysis.Ownership)
+
+	protocol, err := terminal.ParseGraphicsProtocol(viper.GetString("preview.graphics"), os.Getenv)
+	if err != nil {
+		logrus.Errorf("invalid config value: 'preview.graphics': %+v", err)
+	}
+	Preview := newPreviewView(g, protocol)
+
+	Archive := newArchiveView(g, render)
+
+	Pivot := newPivotView(g, analysis.Layers, analysis.RefTrees)
+
+	Compare := newCompareView(g, render, symbols)
+
+	SavedFilters := newSavedFiltersView(g, NewSavedFilters(viper.GetStringMapString("filetree.saved-filters")))
+
+	Dialog := newDialogView(g)
+
+	Toast := newToastView(g)
+
+	Debug := newDebugView(g, analysis.Metrics)
+
+	// the dialog goes last, as it may open over the other popups (e.g. to show an error)
+	Modals := newModals(g, Provenance, History, Ownership, ImageConfig, Preview, Archive, Pivot, Packages, Compare, SavedFilters, Debug, Search, GoToPath, Dialog)
+	Status.SetModals(Modals)
+
+	var Tabs *TabBar
+	var TabPicker *TabPicker
+	if tabs != nil {
+		Tabs = newTabBarView(g, tabs, symbols)
+		TabPicker = newTabPickerView(g, tabs)
+		Modals.Add(TabPicker)
+	}
 
-	Debug := newDebugView(g)
+	statusBus := viewmodel.NewStatusBus()
+	statusBus.Subscribe(Status.OnStatusEvent)
+	Tree.vm.Status = statusBus
 
 	return &Views{
-		Tree:    Tree,
-		Layer:   Layer,
-		Status:  Status,
-		Filter:  Filter,
-		Details: Details,
-		Debug:   Debug,
+		Tree:          Tree,
+		Layer:         Layer,
+		Status:        Status,
+		Filter:        Filter,
+		Search:        Search,
+		GoToPath:      GoToPath,
+		Details:       Details,
+		Warnings:      Warnings,
+		ParseWarnings: ParseWarnings,
+		Attestations:  Attestations,
+		Audit:         Audit,
+		Dependencies:  Dependencies,
+		Duplicates:    Duplicates,
+		Marks:         Marks,
+		FileDetails:   FileDetails,
+		Provenance:    Provenance,
+		History:       History,
+		Ownership:     Ownership,
+		ImageConfig:   ImageConfig,
+		Preview:       Preview,
+		Archive:       Archive,
+		Pivot:         Pivot,
+		Packages:      Packages,
+		Compare:       Compare,
+		SavedFilters:  SavedFilters,
+		Dialog:        Dialog,
+		Toast:         Toast,
+		Modals:        Modals,
+		Tabs:          Tabs,
+		TabPicker:     TabPicker,
+		Debug:         Debug,
+		StatusBus:     statusBus,
 	}, nil
 }
 
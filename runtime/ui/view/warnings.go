@@ -0,0 +1,127 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+)
+
+// the most rows the warnings pane takes from the layer details column (the rest can be scrolled to)
+const maxWarningsHeight = 4
+
+// Warnings holds the UI objects and data models for populating the pane beneath the layers. Specifically the pane
+// that lists the deprecated image features found during the analysis (it is only shown when there are any).
+type Warnings struct {
+	name         string
+	gui          *gocui.Gui
+	symbols      format.Symbols
+	view         *gocui.View
+	header       *gocui.View
+	damage       damage
+	deprecations []image.Deprecation
+}
+
+// newWarningsView creates a new view object attached the the global [gocui] screen object.
+func newWarningsView(gui *gocui.Gui, deprecations []image.Deprecation, symbols format.Symbols) (controller *Warnings) {
+	controller = new(Warnings)
+
+	// populate main fields
+	controller.name = "warnings"
+	controller.gui = gui
+	controller.symbols = symbols
+	controller.deprecations = deprecations
+
+	return controller
+}
+
+func (v *Warnings) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *Warnings) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = true
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	v.damage.invalidate()
+	return v.Render()
+}
+
+// IsVisible indicates if the warnings pane is shown (only when the image has deprecated features).
+func (v *Warnings) IsVisible() bool {
+	return v != nil && len(v.deprecations) > 0
+}
+
+// Height is the number of rows the pane requests (not including the header).
+func (v *Warnings) Height() int {
+	if !v.IsVisible() {
+		return 0
+	}
+	if len(v.deprecations) > maxWarningsHeight {
+		return maxWarningsHeight
+	}
+	return len(v.deprecations)
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *Warnings) Update() error {
+	return nil
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *Warnings) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Render flushes the state objects to the screen.
+func (v *Warnings) Render() error {
+	TraceRender(v.Name())
+
+	if v.view == nil {
+		return nil
+	}
+	if !v.damage.changed(sizeOf(v.view.Size())) {
+		// nothing changed since the last render
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header...
+		v.header.Clear()
+		width, _ := v.view.Size()
+		headerStr := v.symbols.RenderHeader(fmt.Sprintf("Warnings (%d)", len(v.deprecations)), width, false)
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update view...
+		v.view.Clear()
+		for _, deprecation := range v.deprecations {
+			_, err = fmt.Fprintln(v.view, format.Header(deprecation.Feature+":")+" "+deprecation.Detail)
+			if err != nil {
+				logrus.Debug("unable to write to buffer: ", err)
+				return err
+			}
+		}
+		return nil
+	})
+	return nil
+}
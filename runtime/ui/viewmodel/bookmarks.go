@@ -0,0 +1,86 @@
+package viewmodel
+
+import (
+	"sort"
+	"strings"
+)
+
+// Bookmarks is the set of paths the user marked in the file tree. The marks apply to paths (not nodes), so they are
+// kept when another layer is selected.
+type Bookmarks struct {
+	// the marked paths, in tree order
+	paths []string
+}
+
+// NewBookmarks creates the set of marks with the given (previously marked) paths.
+func NewBookmarks(paths []string) *Bookmarks {
+	bookmarks := &Bookmarks{paths: append([]string(nil), paths...)}
+	sort.Slice(bookmarks.paths, func(i, j int) bool {
+		return treeOrderLess(bookmarks.paths[i], bookmarks.paths[j])
+	})
+	return bookmarks
+}
+
+// treeOrderLess orders paths the way the file tree lists them: by path component, so that the contents of a
+// directory come before its siblings ("/a/b" before "/a-c").
+func treeOrderLess(a, b string) bool {
+	return strings.ReplaceAll(a, "/", "\x00") < strings.ReplaceAll(b, "/", "\x00")
+}
+
+// search returns the index of the first mark that is not before the given path.
+func (b *Bookmarks) search(path string) int {
+	return sort.Search(len(b.paths), func(i int) bool {
+		return !treeOrderLess(b.paths[i], path)
+	})
+}
+
+// Toggle marks the given path, or removes the mark if it is already marked. It returns whether the path is marked.
+func (b *Bookmarks) Toggle(path string) bool {
+	idx := b.search(path)
+	if idx < len(b.paths) && b.paths[idx] == path {
+		b.paths = append(b.paths[:idx], b.paths[idx+1:]...)
+		return false
+	}
+	b.paths = append(b.paths, "")
+	copy(b.paths[idx+1:], b.paths[idx:])
+	b.paths[idx] = path
+	return true
+}
+
+// IsMarked indicates if the given path is marked.
+func (b *Bookmarks) IsMarked(path string) bool {
+	if b == nil {
+		return false
+	}
+	idx := b.search(path)
+	return idx < len(b.paths) && b.paths[idx] == path
+}
+
+// Paths returns the marked paths (in tree order).
+func (b *Bookmarks) Paths() []string {
+	if b == nil {
+		return nil
+	}
+	return b.paths
+}
+
+// JumpOrder returns the marks in the order they should be tried when jumping from the given path: the marks after (or
+// before, when going backwards) the path first, wrapping around to the others.
+func (b *Bookmarks) JumpOrder(from string, forward bool) []string {
+	idx := b.search(from)
+	order := make([]string, 0, len(b.paths))
+	if forward {
+		if idx < len(b.paths) && b.paths[idx] == from {
+			idx++
+		}
+		order = append(order, b.paths[idx:]...)
+		return append(order, b.paths[:idx]...)
+	}
+	for i := idx - 1; i >= 0; i-- {
+		order = append(order, b.paths[i])
+	}
+	for i := len(b.paths) - 1; i >= idx; i-- {
+		order = append(order, b.paths[i])
+	}
+	return order
+}
@@ -0,0 +1,55 @@
+package viewmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookmarksToggle(t *testing.T) {
+	bookmarks := NewBookmarks([]string{"/etc/passwd", "/bin/sh"})
+
+	if !bookmarks.Toggle("/a-b") {
+		t.Errorf("expected /a-b to be marked")
+	}
+	if !bookmarks.Toggle("/a/b") {
+		t.Errorf("expected /a/b to be marked")
+	}
+	if bookmarks.Toggle("/bin/sh") {
+		t.Errorf("expected /bin/sh to be unmarked")
+	}
+
+	expected := []string{"/a/b", "/a-b", "/etc/passwd"}
+	if !reflect.DeepEqual(bookmarks.Paths(), expected) {
+		t.Errorf("expected marks %v, got %v", expected, bookmarks.Paths())
+	}
+	if !bookmarks.IsMarked("/etc/passwd") || bookmarks.IsMarked("/bin/sh") {
+		t.Errorf("unexpected IsMarked results")
+	}
+
+	var none *Bookmarks
+	if none.IsMarked("/etc/passwd") || none.Paths() != nil {
+		t.Errorf("expected a nil set of marks to be empty")
+	}
+}
+
+func TestBookmarksJumpOrder(t *testing.T) {
+	bookmarks := NewBookmarks([]string{"/a", "/c", "/e"})
+
+	cases := []struct {
+		from     string
+		forward  bool
+		expected []string
+	}{
+		{from: "/c", forward: true, expected: []string{"/e", "/a", "/c"}},
+		{from: "/d", forward: true, expected: []string{"/e", "/a", "/c"}},
+		{from: "/c", forward: false, expected: []string{"/a", "/e", "/c"}},
+		{from: "/b", forward: false, expected: []string{"/a", "/e", "/c"}},
+	}
+
+	for _, test := range cases {
+		actual := bookmarks.JumpOrder(test.from, test.forward)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("from %s (forward=%v): expected %v, got %v", test.from, test.forward, test.expected, actual)
+		}
+	}
+}
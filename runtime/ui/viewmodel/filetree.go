@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/wagoodman/dive/runtime/ui/format"
+	"path"
 	"regexp"
 	"strings"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
 )
 
 // FileTreeViewModel holds the UI objects and data models for populating the right pane. Specifically the pane that
@@ -21,12 +23,17 @@ type FileTree struct {
 	RefTrees  []*filetree.FileTree
 	cache     filetree.Comparer
 
+	// the visible rows of the view tree, rendered lazily as they scroll into view
+	viewRows *filetree.TreeRows
+
 	constrainedRealEstate bool
 
 	CollapseAll                 bool
+	collapseDefault             bool
 	ShowAttributes              bool
 	unconstrainedShowAttributes bool
 	HiddenDiffTypes             []bool
+	IgnoredPaths                []string
 	TreeIndex                   int
 	bufferIndex                 int
 	bufferIndexLowerBound       int
@@ -34,6 +41,35 @@ type FileTree struct {
 	refHeight int
 	refWidth  int
 
+	// the paths marked by the user (shown with a mark in the tree)
+	Bookmarks *Bookmarks
+	// the paths the user chose to keep when slimming the image (shown with a mark in the tree)
+	KeepList *KeepList
+	// the files a later layer deletes or overwrites, by path (highlighted in the tree, nil when they are not)
+	Doomed map[string]image.DoomedFile
+	// the vulnerable package each file belongs to, by path (marked in the tree, nil without a scanner report)
+	Vulnerable map[string]*image.VulnerablePackage
+	// only the files whose modification times fall in this bucket are shown (image.MtimeBuilt et al., empty shows
+	// every file), the buckets of the files as of MtimeLayer being looked up in Mtimes
+	MtimeFilter string
+	Mtimes      *image.FileMtimes
+	MtimeLayer  int
+	// reports the layer comparisons in progress to the status bar (nil when there is no status bar)
+	Status *StatusBus
+	// how the size column shows the sizes, the percentages relative to the size of the selected layer (LayerSize) or of
+	// the image (ImageSize)
+	SizeFormat filetree.SizeFormat
+	LayerSize  int64
+	ImageSize  int64
+	// show the number of files within each directory along with the attributes
+	ShowFileCounts bool
+	// the view wraps the lines, so the names are shown whole (otherwise long names are shortened to fit the pane)
+	WrapLines bool
+	// how the lines of the tree are drawn (e.g. with the markers of the diff types), and the characters the marks
+	// after them are drawn with
+	RenderOptions filetree.RenderOptions
+	Symbols       format.Symbols
+
 	Buffer bytes.Buffer
 }
 
@@ -45,10 +81,20 @@ func NewFileTreeViewModel(tree *filetree.FileTree, refTrees []*filetree.FileTree
 	treeViewModel.ShowAttributes = viper.GetBool("filetree.show-attributes")
 	treeViewModel.unconstrainedShowAttributes = treeViewModel.ShowAttributes
 	treeViewModel.CollapseAll = viper.GetBool("filetree.collapse-dir")
+	treeViewModel.ShowFileCounts = viper.GetBool("filetree.show-file-counts")
+	treeViewModel.collapseDefault = treeViewModel.CollapseAll
+	treeViewModel.KeepList = NewKeepList()
 	treeViewModel.ModelTree = tree
 	treeViewModel.RefTrees = refTrees
 	treeViewModel.cache = cache
 	treeViewModel.HiddenDiffTypes = make([]bool, 4)
+	treeViewModel.RenderOptions = filetree.DefaultRenderOptions()
+	treeViewModel.Symbols = format.UnicodeSymbols
+
+	treeViewModel.SizeFormat, err = filetree.ParseSizeFormat(viper.GetString("filetree.size-format"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filetree.size-format value: %v", err)
+	}
 
 	hiddenTypes := viper.GetStringSlice("diff.hide")
 	for _, hType := range hiddenTypes {
@@ -66,9 +112,42 @@ func NewFileTreeViewModel(tree *filetree.FileTree, refTrees []*filetree.FileTree
 		}
 	}
 
+	for _, ignored := range viper.GetStringSlice("filetree.ignore-paths") {
+		if _, err := path.Match(ignored, ""); err != nil {
+			return nil, fmt.Errorf("invalid filetree.ignore-paths value: %s", ignored)
+		}
+		treeViewModel.IgnoredPaths = append(treeViewModel.IgnoredPaths, path.Clean("/"+ignored))
+	}
+
+	if treeViewModel.collapseDefault {
+		err = tree.VisitDepthChildFirst(treeViewModel.applyDefaultCollapse, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return treeViewModel, nil
 }
 
+// applyDefaultCollapse sets the configured initial directory-collapse state on the given node.
+func (vm *FileTree) applyDefaultCollapse(node *filetree.FileNode) error {
+	node.Data.ViewInfo.Collapsed = vm.collapseDefault
+	return nil
+}
+
+// isIgnored indicates if the given path is (or is beneath) one of the configured paths (or glob patterns) to ignore.
+func (vm *FileTree) isIgnored(nodePath string) bool {
+	for _, ignored := range vm.IgnoredPaths {
+		if nodePath == ignored || strings.HasPrefix(nodePath, ignored+"/") {
+			return true
+		}
+		if matched, _ := path.Match(ignored, nodePath); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // Setup initializes the UI concerns within the context of a global [gocui] view object.
 func (vm *FileTree) Setup(lowerBound, height int) {
 	vm.bufferIndexLowerBound = lowerBound
@@ -102,13 +181,49 @@ func (vm *FileTree) ResetCursor() {
 
 // SetTreeByLayer populates the view model by stacking the indicated image layer file trees.
 func (vm *FileTree) SetTreeByLayer(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) error {
+	newTree, err := vm.LayerTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop)
+	if err != nil {
+		return err
+	}
+	return vm.SetTree(newTree)
+}
+
+// LayerTree stacks (and compares) the indicated image layer file trees without changing the view model. The first
+// comparison of a layer range can take seconds on large images, so this may be called off the UI goroutine, as long as
+// the calls are not concurrent with each other.
+func (vm *FileTree) LayerTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) (*filetree.FileTree, error) {
 	if topTreeStop > len(vm.RefTrees)-1 {
-		return fmt.Errorf("invalid layer index given: %d of %d", topTreeStop, len(vm.RefTrees)-1)
+		return nil, fmt.Errorf("invalid layer index given: %d of %d", topTreeStop, len(vm.RefTrees)-1)
 	}
-	newTree, err := vm.cache.GetTree(filetree.NewTreeIndexKey(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop))
+	key := filetree.NewTreeIndexKey(bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop)
+	if !vm.cache.Cached(key) {
+		message := fmt.Sprintf("comparing layers %d-%d", topTreeStart, topTreeStop)
+		if topTreeStart == topTreeStop {
+			message = fmt.Sprintf("comparing layer %d", topTreeStop)
+		}
+		vm.Status.StartTask("layer-tree", message)
+		defer vm.Status.FinishTask("layer-tree")
+	}
+	newTree, err := vm.cache.GetTree(key)
 	if err != nil {
 		logrus.Errorf("unable to fetch layer tree from cache: %+v", err)
-		return err
+		return nil, err
+	}
+	return newTree, nil
+}
+
+// SetTree shows the given layer tree (see LayerTree), keeping the view state (e.g. collapsed directories) of the nodes
+// shared with the current tree.
+func (vm *FileTree) SetTree(newTree *filetree.FileTree) error {
+	var err error
+
+	// nodes that were not part of the previous tree take the configured default state
+	if vm.collapseDefault {
+		err = newTree.VisitDepthChildFirst(vm.applyDefaultCollapse, nil)
+		if err != nil {
+			logrus.Errorf("unable to propagate layer tree: %+v", err)
+			return err
+		}
 	}
 
 	// preserve vm state on copy
@@ -248,11 +363,7 @@ func (vm *FileTree) PageDown() error {
 	nextBufferIndexLowerBound := vm.bufferIndexLowerBound + vm.height()
 	nextBufferIndexUpperBound := nextBufferIndexLowerBound + vm.height()
 
-	// todo: this work should be saved or passed to render...
-	treeString := vm.ViewTree.StringBetween(nextBufferIndexLowerBound, nextBufferIndexUpperBound, vm.ShowAttributes)
-	lines := strings.Split(treeString, "\n")
-
-	newLines := len(lines) - 1
+	newLines := vm.viewRows.CountBetween(nextBufferIndexLowerBound, nextBufferIndexUpperBound)
 	if vm.height() >= newLines {
 		nextBufferIndexLowerBound = vm.bufferIndexLowerBound + newLines
 	}
@@ -274,11 +385,7 @@ func (vm *FileTree) PageUp() error {
 	nextBufferIndexLowerBound := vm.bufferIndexLowerBound - vm.height()
 	nextBufferIndexUpperBound := nextBufferIndexLowerBound + vm.height()
 
-	// todo: this work should be saved or passed to render...
-	treeString := vm.ViewTree.StringBetween(nextBufferIndexLowerBound, nextBufferIndexUpperBound, vm.ShowAttributes)
-	lines := strings.Split(treeString, "\n")
-
-	newLines := len(lines) - 2
+	newLines := vm.viewRows.CountBetween(nextBufferIndexLowerBound, nextBufferIndexUpperBound) - 1
 	if vm.height() >= newLines {
 		nextBufferIndexLowerBound = vm.bufferIndexLowerBound - newLines
 	}
@@ -325,6 +432,237 @@ func (vm *FileTree) getAbsPositionNode(filterRegex *regexp.Regexp) (node *filetr
 	return node
 }
 
+// FollowLink moves the cursor to the target of the selected symlink or hardlink, expanding any collapsed directories
+// on the way. Links to missing or hidden (filtered) targets leave the cursor in place.
+func (vm *FileTree) FollowLink(filterRegex *regexp.Regexp) error {
+	node := vm.getAbsPositionNode(filterRegex)
+	if node == nil || !node.IsLink() {
+		return nil
+	}
+
+	target, err := vm.ModelTree.ResolveLink(node)
+	if err != nil {
+		logrus.Infof("unable to follow link %s: %+v", node.Path(), err)
+		return nil
+	}
+
+	moved, err := vm.moveCursorTo(target, filterRegex)
+	if err == nil && !moved {
+		logrus.Infof("link target %s is not visible", target.Path())
+	}
+	return err
+}
+
+// moveCursorTo moves the cursor to the given node, expanding any collapsed directories on the way. Nodes that are
+// hidden (filtered) leave the cursor in place, which is indicated by the returned flag.
+func (vm *FileTree) moveCursorTo(target *filetree.FileNode, filterRegex *regexp.Regexp) (bool, error) {
+	for parent := target.Parent; parent != nil; parent = parent.Parent {
+		parent.Data.ViewInfo.Collapsed = false
+	}
+
+	var visitor func(*filetree.FileNode) error
+	var evaluator func(*filetree.FileNode) bool
+	var dfsCounter int
+	newIndex := -1
+
+	visitor = func(curNode *filetree.FileNode) error {
+		if curNode == target {
+			newIndex = dfsCounter
+		}
+		dfsCounter++
+		return nil
+	}
+
+	evaluator = func(curNode *filetree.FileNode) bool {
+		regexMatch := true
+		if filterRegex != nil {
+			match := filterRegex.Find([]byte(curNode.Path()))
+			regexMatch = match != nil
+		}
+		return !curNode.Parent.Data.ViewInfo.Collapsed && !curNode.Data.ViewInfo.Hidden && regexMatch
+	}
+
+	err := vm.ModelTree.VisitDepthParentFirst(visitor, evaluator)
+	if err != nil {
+		logrus.Errorf("could not propagate tree on moveCursorTo: %+v", err)
+		return false, err
+	}
+
+	if newIndex < 0 {
+		return false, nil
+	}
+
+	vm.TreeIndex = newIndex
+	if newIndex < vm.bufferIndexLowerBound || newIndex > vm.bufferIndexUpperBound() {
+		// scroll such that the target is at the top of the view
+		vm.bufferIndexLowerBound = newIndex
+	}
+	vm.bufferIndex = newIndex - vm.bufferIndexLowerBound
+
+	return true, nil
+}
+
+// CursorTop moves the cursor to the first row.
+func (vm *FileTree) CursorTop() {
+	vm.setTreeIndex(0)
+}
+
+// CursorBottom moves the cursor to the last row.
+func (vm *FileTree) CursorBottom() {
+	vm.setTreeIndex(vm.ModelTree.VisibleSize())
+}
+
+// setTreeIndex moves the cursor to the given row, scrolling as little as possible to show it.
+func (vm *FileTree) setTreeIndex(index int) {
+	vm.TreeIndex = index
+	if index < vm.bufferIndexLowerBound {
+		vm.bufferIndexLowerBound = index
+	} else if index > vm.bufferIndexUpperBound() {
+		vm.bufferIndexLowerBound = index - vm.height()
+	}
+	if vm.bufferIndexLowerBound < 0 {
+		vm.bufferIndexLowerBound = 0
+	}
+	vm.bufferIndex = index - vm.bufferIndexLowerBound
+}
+
+// Search moves the cursor to the next (or previous) path containing the query (ignoring case), wrapping around the
+// ends of the tree. Paths within collapsed directories are searched too (the directories are expanded to show the
+// match), while hidden (filtered) paths are not. It reports whether a path matches.
+func (vm *FileTree) Search(query string, filterRegex *regexp.Regexp, forward bool) (bool, error) {
+	var nodes []*filetree.FileNode
+	err := vm.ModelTree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		nodes = append(nodes, node)
+		return nil
+	}, func(node *filetree.FileNode) bool {
+		regexMatch := true
+		if filterRegex != nil {
+			regexMatch = filterRegex.Find([]byte(node.Path())) != nil
+		}
+		return !node.Data.ViewInfo.Hidden && regexMatch
+	})
+	if err != nil || len(nodes) == 0 {
+		return false, err
+	}
+
+	// start from the selected path, or from either end without a selection
+	start := -1
+	if !forward {
+		start = len(nodes)
+	}
+	selected := vm.getAbsPositionNode(filterRegex)
+	for idx, node := range nodes {
+		if node == selected {
+			start = idx
+			break
+		}
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+	query = strings.ToLower(query)
+	for offset := 1; offset <= len(nodes); offset++ {
+		node := nodes[((start+step*offset)%len(nodes)+len(nodes))%len(nodes)]
+		if !strings.Contains(strings.ToLower(node.Path()), query) {
+			continue
+		}
+		return vm.moveCursorTo(node, filterRegex)
+	}
+	return false, nil
+}
+
+// ToggleMark marks the selected FileNode (or removes its mark), returning its path and whether it is now marked.
+func (vm *FileTree) ToggleMark(filterRegex *regexp.Regexp) (string, bool) {
+	path := vm.SelectedPath(filterRegex)
+	if path == "" || vm.Bookmarks == nil {
+		return "", false
+	}
+	return path, vm.Bookmarks.Toggle(path)
+}
+
+// ToggleKeep keeps the selected FileNode when slimming the image (or stops keeping it), returning its path and whether
+// it is now kept.
+func (vm *FileTree) ToggleKeep(filterRegex *regexp.Regexp) (string, bool) {
+	path := vm.SelectedPath(filterRegex)
+	if path == "" || vm.KeepList == nil {
+		return "", false
+	}
+	return path, vm.KeepList.Toggle(path)
+}
+
+// JumpToMark moves the cursor to the next (or previous) marked path, skipping the marked paths that are not within
+// the current tree (e.g. files added by a later layer) or that are hidden.
+func (vm *FileTree) JumpToMark(filterRegex *regexp.Regexp, forward bool) error {
+	if vm.Bookmarks == nil {
+		return nil
+	}
+	for _, path := range vm.Bookmarks.JumpOrder(vm.SelectedPath(filterRegex), forward) {
+		node, err := vm.ModelTree.GetNode(path)
+		if err != nil || node == nil {
+			continue
+		}
+		moved, err := vm.moveCursorTo(node, filterRegex)
+		if err != nil || moved {
+			return err
+		}
+	}
+	logrus.Infof("no marked path is visible in the current tree")
+	return nil
+}
+
+// GoToPath moves the cursor to the given absolute path, expanding any collapsed directories on the way. It reports
+// whether the path is within the current tree and visible (not filtered out).
+func (vm *FileTree) GoToPath(nodePath string, filterRegex *regexp.Regexp) (bool, error) {
+	if !path.IsAbs(nodePath) {
+		return false, nil
+	}
+	nodePath = path.Clean(nodePath)
+	if nodePath == "/" {
+		vm.CursorTop()
+		return true, nil
+	}
+	node, err := vm.ModelTree.GetNode(nodePath)
+	if err != nil || node == nil {
+		return false, nil
+	}
+	return vm.moveCursorTo(node, filterRegex)
+}
+
+// SelectedPath returns the path of the selected FileNode (or an empty string if there is no selection).
+func (vm *FileTree) SelectedPath(filterRegex *regexp.Regexp) string {
+	node := vm.getAbsPositionNode(filterRegex)
+	if node == nil {
+		return ""
+	}
+	return node.Path()
+}
+
+// SelectedNode returns the selected FileNode (or nil if there is no selection).
+func (vm *FileTree) SelectedNode(filterRegex *regexp.Regexp) *filetree.FileNode {
+	return vm.getAbsPositionNode(filterRegex)
+}
+
+// VisiblePaths returns the paths of the files (not directories) that pass the current filter and diff type selection,
+// in tree order. Collapsed directories do not hide their files.
+func (vm *FileTree) VisiblePaths() []string {
+	var paths []string
+	if vm.ViewTree == nil {
+		return paths
+	}
+	err := vm.ViewTree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		if !node.Data.FileInfo.IsDir && node.IsLeaf() {
+			paths = append(paths, node.Path())
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		logrus.Errorf("unable to collect the visible paths: %+v", err)
+	}
+	return paths
+}
+
 // ToggleCollapse will collapse/expand the selected FileNode.
 func (vm *FileTree) ToggleCollapse(filterRegex *regexp.Regexp) error {
 	node := vm.getAbsPositionNode(filterRegex)
@@ -336,7 +674,82 @@ func (vm *FileTree) ToggleCollapse(filterRegex *regexp.Regexp) error {
 
 // ToggleCollapseAll will collapse/expand the all directories.
 func (vm *FileTree) ToggleCollapseAll() error {
-	vm.CollapseAll = !vm.CollapseAll
+	vm.setCollapseAll(!vm.CollapseAll)
+	return nil
+}
+
+// CollapseAllDirs collapses every directory, keeping the cursor on the top-level directory holding the selected node.
+func (vm *FileTree) CollapseAllDirs(filterRegex *regexp.Regexp) error {
+	selected := vm.getAbsPositionNode(filterRegex)
+	vm.setCollapseAll(true)
+	return vm.keepSelection(selected, filterRegex)
+}
+
+// ExpandAllDirs expands every directory, keeping the cursor on the selected node.
+func (vm *FileTree) ExpandAllDirs(filterRegex *regexp.Regexp) error {
+	selected := vm.getAbsPositionNode(filterRegex)
+	vm.setCollapseAll(false)
+	return vm.keepSelection(selected, filterRegex)
+}
+
+// ExpandToDepth shows the paths up to the given depth (1 shows the top-level paths), collapsing the deeper
+// directories, and returns the depth shown (the given depth within the depth of the tree).
+func (vm *FileTree) ExpandToDepth(depth int, filterRegex *regexp.Regexp) (int, error) {
+	if maxDepth := vm.ModelTree.MaxDepth(); depth > maxDepth {
+		depth = maxDepth
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	selected := vm.getAbsPositionNode(filterRegex)
+	vm.ModelTree.ExpandToDepth(depth)
+	return depth, vm.keepSelection(selected, filterRegex)
+}
+
+// ChangeDepth shows the paths one level (or more) deeper, or shallower, than the depth every directory is currently
+// expanded to, and returns the depth shown.
+func (vm *FileTree) ChangeDepth(delta int, filterRegex *regexp.Regexp) (int, error) {
+	return vm.ExpandToDepth(vm.ModelTree.ExpandedDepth()+delta, filterRegex)
+}
+
+// CollapseSiblings collapses the directories next to the selected node (within the same directory), leaving the
+// selected node as it is.
+func (vm *FileTree) CollapseSiblings(filterRegex *regexp.Regexp) error {
+	selected := vm.getAbsPositionNode(filterRegex)
+	if selected == nil || selected.Parent == nil {
+		return nil
+	}
+	for _, sibling := range selected.Parent.Children {
+		if sibling != selected && sibling.Data.FileInfo.IsDir && len(sibling.Children) > 0 {
+			sibling.Data.ViewInfo.Collapsed = true
+		}
+	}
+	return vm.keepSelection(selected, filterRegex)
+}
+
+// keepSelection moves the cursor back to the given node after directories were collapsed or expanded (which moves the
+// rows around), or to the outermost collapsed directory holding it.
+func (vm *FileTree) keepSelection(node *filetree.FileNode, filterRegex *regexp.Regexp) error {
+	if node == nil {
+		vm.ResetCursor()
+		return nil
+	}
+	shown := node
+	for parent := node.Parent; parent != nil && parent.Parent != nil; parent = parent.Parent {
+		if parent.Data.ViewInfo.Collapsed {
+			shown = parent
+		}
+	}
+	moved, err := vm.moveCursorTo(shown, filterRegex)
+	if err == nil && !moved {
+		vm.ResetCursor()
+	}
+	return err
+}
+
+// setCollapseAll collapses (or expands) every directory.
+func (vm *FileTree) setCollapseAll(collapse bool) {
+	vm.CollapseAll = collapse
 
 	visitor := func(curNode *filetree.FileNode) error {
 		curNode.Data.ViewInfo.Collapsed = vm.CollapseAll
@@ -349,10 +762,8 @@ func (vm *FileTree) ToggleCollapseAll() error {
 
 	err := vm.ModelTree.VisitDepthChildFirst(visitor, evaluator)
 	if err != nil {
-		logrus.Errorf("unable to propagate tree on ToggleCollapseAll: %+v", err)
+		logrus.Errorf("unable to propagate tree on setCollapseAll: %+v", err)
 	}
-
-	return nil
 }
 
 func (vm *FileTree) ConstrainLayout() {
@@ -382,6 +793,56 @@ func (vm *FileTree) ToggleAttributes() error {
 	return nil
 }
 
+// CycleSizeFormat shows the sizes in the next size format (see filetree.SizeFormats), returning it.
+func (vm *FileTree) CycleSizeFormat() filetree.SizeFormat {
+	vm.SizeFormat = vm.SizeFormat.Next()
+	return vm.SizeFormat
+}
+
+// ToggleFileCounts shows/hides the number of files within each directory.
+func (vm *FileTree) ToggleFileCounts() {
+	vm.ShowFileCounts = !vm.ShowFileCounts
+}
+
+// sizeTotal is the size the percentages of the size column are relative to.
+func (vm *FileTree) sizeTotal() int64 {
+	switch vm.SizeFormat {
+	case filetree.SizeLayerPercent:
+		return vm.LayerSize
+	case filetree.SizeImagePercent:
+		return vm.ImageSize
+	}
+	return 0
+}
+
+// CycleMtimeFilter shows the files of the next bucket of modification times (see image.MtimeBuckets), then every file
+// again, returning the bucket shown.
+func (vm *FileTree) CycleMtimeFilter() string {
+	next := ""
+	if vm.MtimeFilter == "" {
+		next = image.MtimeBuckets[0]
+	}
+	for idx, bucket := range image.MtimeBuckets {
+		if bucket == vm.MtimeFilter && idx+1 < len(image.MtimeBuckets) {
+			next = image.MtimeBuckets[idx+1]
+		}
+	}
+	vm.MtimeFilter = next
+	return next
+}
+
+// mtimeHidden indicates if the node is left out by the filter on modification times (the directories are shown for
+// the files within them only).
+func (vm *FileTree) mtimeHidden(node *filetree.FileNode) bool {
+	if vm.MtimeFilter == "" || vm.Mtimes == nil {
+		return false
+	}
+	if len(node.Children) > 0 {
+		return true
+	}
+	return vm.Mtimes.Bucket(vm.MtimeLayer, node.Path()) != vm.MtimeFilter
+}
+
 // ToggleShowDiffType will show/hide the selected DiffType in the filetree pane.
 func (vm *FileTree) ToggleShowDiffType(diffType filetree.DiffType) {
 	vm.HiddenDiffTypes[diffType] = !vm.HiddenDiffTypes[diffType]
@@ -394,7 +855,11 @@ func (vm *FileTree) Update(filterRegex *regexp.Regexp, width, height int) error
 
 	// keep the vm selection in parity with the current DiffType selection
 	err := vm.ModelTree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
-		node.Data.ViewInfo.Hidden = vm.HiddenDiffTypes[node.Data.DiffType]
+		if vm.isIgnored(node.Path()) {
+			node.Data.ViewInfo.Hidden = true
+			return nil
+		}
+		node.Data.ViewInfo.Hidden = vm.HiddenDiffTypes[node.Data.DiffType] || vm.mtimeHidden(node)
 		visibleChild := false
 		for _, child := range node.Children {
 			if !child.Data.ViewInfo.Hidden {
@@ -432,17 +897,61 @@ func (vm *FileTree) Update(filterRegex *regexp.Regexp, width, height int) error
 		return err
 	}
 
+	vm.viewRows = vm.ViewTree.VisibleRows()
+	vm.viewRows.SetRenderOptions(vm.RenderOptions)
+
 	return nil
 }
 
+// doomedString tells which later layer deletes or overwrites the file.
+func (vm *FileTree) doomedString(doomed image.DoomedFile) string {
+	if doomed.Change == image.PathDeleted {
+		return fmt.Sprintf("%s removed in layer %d", vm.Symbols.Doomed, doomed.DoomedBy)
+	}
+	return fmt.Sprintf("%s overwritten in layer %d", vm.Symbols.Doomed, doomed.DoomedBy)
+}
+
+// vulnerableString tells which vulnerable package the file belongs to, colored by its highest severity.
+func (vm *FileTree) vulnerableString(pkg *image.VulnerablePackage) string {
+	text := vm.Symbols.Vulnerable + " " + pkg.String()
+	if image.SeverityRank(pkg.Severity()) >= image.SeverityRank("high") {
+		return format.Vulnerable(text)
+	}
+	return format.VulnerableLow(text)
+}
+
 // Render flushes the state objects (file tree) to the pane.
 func (vm *FileTree) Render() error {
-	treeString := vm.ViewTree.StringBetween(vm.bufferIndexLowerBound, vm.bufferIndexUpperBound(), vm.ShowAttributes)
+	vm.viewRows.SetSizeFormat(vm.SizeFormat, vm.sizeTotal())
+	vm.viewRows.SetShowFileCounts(vm.ShowFileCounts)
+	if vm.WrapLines {
+		vm.viewRows.SetWidth(0)
+	} else {
+		vm.viewRows.SetWidth(vm.refWidth)
+	}
+	treeString := vm.viewRows.StringBetween(vm.bufferIndexLowerBound, vm.bufferIndexUpperBound(), vm.ShowAttributes)
 	lines := strings.Split(treeString, "\n")
 
 	// update the contents
 	vm.Buffer.Reset()
 	for idx, line := range lines {
+		if node := vm.viewRows.Node(vm.bufferIndexLowerBound + idx); node != nil && idx < len(lines)-1 {
+			if doomed, exists := vm.Doomed[node.Path()]; exists {
+				line = format.Doomed(vtclean.Clean(line, false) + " " + vm.doomedString(doomed))
+			}
+			if folded := len(node.Data.FileInfo.Folded); folded > 0 {
+				line += " " + format.Empty(fmt.Sprintf("(%d files folded)", folded))
+			}
+			if pkg, exists := vm.Vulnerable[node.Path()]; exists {
+				line += " " + vm.vulnerableString(pkg)
+			}
+			if vm.Bookmarks.IsMarked(node.Path()) {
+				line += " " + format.Marked(vm.Symbols.Mark)
+			}
+			if vm.KeepList.IsKept(node.Path()) {
+				line += " " + format.Kept(vm.Symbols.Keep)
+			}
+		}
 		if idx == vm.bufferIndex {
 			_, err := fmt.Fprintln(&vm.Buffer, format.Selected(vtclean.Clean(line, false)))
 			if err != nil {
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
@@ -208,6 +209,27 @@ func TestFileTreeSelectLayer(t *testing.T) {
 	runTestCase(t, vm, width, height, nil)
 }
 
+func TestFileTreeLayerTreeLeavesModelAlone(t *testing.T) {
+	vm := initializeTestViewModel(t)
+	shown := vm.ModelTree
+
+	tree, err := vm.LayerTree(0, 0, 1, 1)
+	checkError(t, err, "unable to compute the layer tree")
+	if vm.ModelTree != shown {
+		t.Fatal("computing a layer tree changed the shown tree")
+	}
+
+	err = vm.SetTree(tree)
+	checkError(t, err, "unable to set the layer tree")
+	if vm.ModelTree != tree {
+		t.Error("the layer tree is not shown")
+	}
+
+	if _, err := vm.LayerTree(0, 0, 1, len(vm.RefTrees)); err == nil {
+		t.Error("expected an error for an invalid layer index")
+	}
+}
+
 func TestFileShowAggregateChanges(t *testing.T) {
 	vm := initializeTestViewModel(t)
 
@@ -226,6 +248,26 @@ func TestFileShowAggregateChanges(t *testing.T) {
 	runTestCase(t, vm, width, height, nil)
 }
 
+func TestFileShowFlattened(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 100
+	vm.Setup(0, height)
+	vm.ShowAttributes = true
+
+	// collapse /bin
+	err := vm.ToggleCollapse(nil)
+	checkError(t, err, "unable to collapse /bin")
+
+	// select the last layer, compareMode = flattened
+	state := NewLayerSetState(nil, CompareFlattened)
+	state.LayerIndex = 13
+	err = vm.SetTreeByLayer(state.GetCompareIndexes())
+	checkError(t, err, "unable to SetTreeByLayer")
+
+	runTestCase(t, vm, width, height, nil)
+}
+
 func TestFileTreePageDown(t *testing.T) {
 	vm := initializeTestViewModel(t)
 
@@ -317,6 +359,17 @@ func TestFileTreeFilterTree(t *testing.T) {
 	runTestCase(t, vm, width, height, regex)
 }
 
+func TestFileTreeIgnorePaths(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 1000
+	vm.Setup(0, height)
+	vm.ShowAttributes = true
+	vm.IgnoredPaths = []string{"/bin", "/usr/share", "/etc/network/if-*"}
+
+	runTestCase(t, vm, width, height, nil)
+}
+
 func TestFileTreeHideAddedRemovedModified(t *testing.T) {
 	vm := initializeTestViewModel(t)
 
@@ -396,3 +449,200 @@ func TestFileTreeHideTypeWithFilter(t *testing.T) {
 
 	runTestCase(t, vm, width, height, regex)
 }
+
+func TestFileTreeVisiblePaths(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 1000
+	vm.Setup(0, height)
+
+	regex, err := regexp.Compile("bin/b")
+	if err != nil {
+		t.Errorf("could not create filter regex: %+v", err)
+	}
+
+	// collapsed directories still contribute their files
+	err = vm.ToggleCollapseAll()
+	checkError(t, err, "unable to collapse all dirs")
+
+	err = vm.Update(regex, width, height)
+	checkError(t, err, "unable to update")
+
+	expected := []string{"/bin/base64", "/bin/basename", "/bin/beep", "/bin/blkdiscard", "/bin/blkid", "/bin/blockdev", "/bin/bootchartd", "/bin/brctl", "/bin/bunzip2", "/bin/busybox", "/bin/bzcat", "/bin/bzip2"}
+	actual := vm.VisiblePaths()
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected paths %v, got %v", expected, actual)
+	}
+}
+
+func TestFileTreeSearch(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 20
+	vm.Setup(0, height)
+
+	// the matches within collapsed directories are found (and shown)
+	err := vm.ToggleCollapseAll()
+	checkError(t, err, "unable to collapse all dirs")
+	err = vm.Update(nil, width, height)
+	checkError(t, err, "unable to update")
+
+	found, err := vm.Search("NETWORK", nil, true)
+	checkError(t, err, "unable to search")
+	first := vm.SelectedPath(nil)
+	if !found || !strings.Contains(first, "network") {
+		t.Fatalf("expected to find a network path, got %q (%v)", first, found)
+	}
+
+	// searching backward from the first match wraps around to the last match
+	found, err = vm.Search("network", nil, false)
+	checkError(t, err, "unable to search")
+	last := vm.SelectedPath(nil)
+	if !found || last == first || !strings.Contains(last, "network") {
+		t.Errorf("expected to wrap around to another network path, got %q (%v)", last, found)
+	}
+
+	found, err = vm.Search("no-such-path", nil, true)
+	checkError(t, err, "unable to search")
+	if found || vm.SelectedPath(nil) != last {
+		t.Errorf("expected no match to leave the cursor in place")
+	}
+
+	vm.CursorTop()
+	if vm.TreeIndex != 0 {
+		t.Errorf("expected the cursor on the first row, got %d", vm.TreeIndex)
+	}
+	vm.CursorBottom()
+	if vm.TreeIndex != vm.ModelTree.VisibleSize() || vm.bufferIndex > height {
+		t.Errorf("expected the cursor on the last row, got %d (buffer index %d)", vm.TreeIndex, vm.bufferIndex)
+	}
+}
+
+func TestFileTreeExpandToDepth(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 20
+	vm.Setup(0, height)
+	err := vm.Update(nil, width, height)
+	checkError(t, err, "unable to update")
+
+	// select a nested path, which stays selected (or its collapsed directory is) as the depth changes
+	found, err := vm.Search("network/", nil, true)
+	checkError(t, err, "unable to search")
+	selected := vm.SelectedPath(nil)
+	if !found || strings.Count(selected, "/") < 3 {
+		t.Fatalf("expected to select a nested network path, got %q", selected)
+	}
+
+	depth, err := vm.ExpandToDepth(1, nil)
+	checkError(t, err, "unable to expand to depth 1")
+	if depth != 1 || vm.ModelTree.ExpandedDepth() != 1 {
+		t.Errorf("expected the top-level paths to be shown, got depth %d", depth)
+	}
+	if top := vm.SelectedPath(nil); !strings.HasPrefix(selected, top+"/") || strings.Count(top, "/") != 1 {
+		t.Errorf("expected the top-level directory of %q to be selected, got %q", selected, top)
+	}
+
+	depth, err = vm.ChangeDepth(1, nil)
+	checkError(t, err, "unable to expand a level deeper")
+	if depth != 2 || vm.ModelTree.ExpandedDepth() != 2 {
+		t.Errorf("expected the paths up to depth 2 to be shown, got depth %d", depth)
+	}
+
+	depth, err = vm.ChangeDepth(-5, nil)
+	checkError(t, err, "unable to collapse shallower")
+	if depth != 1 {
+		t.Errorf("expected the depth to stop at the top-level paths, got %d", depth)
+	}
+
+	err = vm.ExpandAllDirs(nil)
+	checkError(t, err, "unable to expand all dirs")
+	if vm.ModelTree.ExpandedDepth() != vm.ModelTree.MaxDepth() {
+		t.Errorf("expected every directory to be expanded")
+	}
+
+	// collapsing the siblings leaves the selected directory (and the cursor) alone
+	_, err = vm.Search("network", nil, true)
+	checkError(t, err, "unable to search")
+	node := vm.SelectedNode(nil)
+	err = vm.CollapseSiblings(nil)
+	checkError(t, err, "unable to collapse the siblings")
+	if vm.SelectedNode(nil) != node {
+		t.Errorf("expected %q to stay selected, got %q", node.Path(), vm.SelectedPath(nil))
+	}
+	for _, sibling := range node.Parent.Children {
+		collapsible := sibling != node && sibling.Data.FileInfo.IsDir && len(sibling.Children) > 0
+		if sibling.Data.ViewInfo.Collapsed != collapsible {
+			t.Errorf("%s: expected collapsed=%v", sibling.Path(), collapsible)
+		}
+	}
+
+	err = vm.CollapseAllDirs(nil)
+	checkError(t, err, "unable to collapse all dirs")
+	if vm.ModelTree.ExpandedDepth() != 1 || strings.Count(vm.SelectedPath(nil), "/") != 1 {
+		t.Errorf("expected a top-level directory to be selected, got %q", vm.SelectedPath(nil))
+	}
+}
+
+func TestFileTreeGoToPath(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 20
+	vm.Setup(0, height)
+	err := vm.CollapseAllDirs(nil)
+	checkError(t, err, "unable to collapse all dirs")
+
+	// the collapsed directories on the way are expanded
+	found, err := vm.GoToPath("/etc/network/if-up.d/", nil)
+	checkError(t, err, "unable to go to path")
+	if !found || vm.SelectedPath(nil) != "/etc/network/if-up.d" {
+		t.Errorf("expected to select /etc/network/if-up.d, got %q (%v)", vm.SelectedPath(nil), found)
+	}
+	err = vm.Update(nil, width, height)
+	checkError(t, err, "unable to update")
+
+	for _, missing := range []string{"/etc/no-such-path", "etc/network", ""} {
+		found, err = vm.GoToPath(missing, nil)
+		checkError(t, err, "unable to go to path")
+		if found || vm.SelectedPath(nil) != "/etc/network/if-up.d" {
+			t.Errorf("%q: expected the cursor to stay in place", missing)
+		}
+	}
+
+	// a path filtered out is not shown
+	found, err = vm.GoToPath("/etc/network", regexp.MustCompile("/usr"))
+	checkError(t, err, "unable to go to path")
+	if found {
+		t.Errorf("expected a filtered path not to be found")
+	}
+}
+
+func TestFileTreeSizeFormat(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 20
+	vm.Setup(0, height)
+	err := vm.Update(nil, width, height)
+	checkError(t, err, "unable to update")
+
+	vm.ShowAttributes = true
+	vm.LayerSize = 1000
+	if format := vm.CycleSizeFormat(); format != filetree.SizeIEC {
+		t.Fatalf("expected binary units after decimal units, got %q", format)
+	}
+	vm.CycleSizeFormat()
+	vm.CycleSizeFormat()
+	err = vm.Render()
+	checkError(t, err, "unable to render")
+	if !strings.Contains(vm.Buffer.String(), "%") {
+		t.Errorf("expected percentages of the layer in the size column")
+	}
+
+	// without a known image size the percentages are unknown
+	vm.CycleSizeFormat()
+	err = vm.Render()
+	checkError(t, err, "unable to render")
+	if strings.Contains(vm.Buffer.String(), "%") {
+		t.Errorf("expected no percentages without the image size")
+	}
+}
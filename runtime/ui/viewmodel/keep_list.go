@@ -0,0 +1,44 @@
+package viewmodel
+
+import "sort"
+
+// KeepList is the set of paths the user chose to keep when slimming the image (see image.PlanSlim). Like the marks, it
+// applies to paths (not nodes), so it is kept when another layer is selected.
+type KeepList struct {
+	paths map[string]bool
+}
+
+// NewKeepList creates an empty set of kept paths.
+func NewKeepList() *KeepList {
+	return &KeepList{paths: make(map[string]bool)}
+}
+
+// Toggle keeps the given path, or stops keeping it if it is already kept. It returns whether the path is kept.
+func (k *KeepList) Toggle(path string) bool {
+	if k.paths[path] {
+		delete(k.paths, path)
+		return false
+	}
+	k.paths[path] = true
+	return true
+}
+
+// IsKept indicates if the given path is kept.
+func (k *KeepList) IsKept(path string) bool {
+	return k != nil && k.paths[path]
+}
+
+// Paths returns the kept paths (in tree order).
+func (k *KeepList) Paths() []string {
+	if k == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(k.paths))
+	for path := range k.paths {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return treeOrderLess(paths[i], paths[j])
+	})
+	return paths
+}
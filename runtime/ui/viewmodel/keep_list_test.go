@@ -0,0 +1,32 @@
+package viewmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeepListToggle(t *testing.T) {
+	keep := NewKeepList()
+
+	for _, path := range []string{"/usr/bin/app", "/etc", "/a-b", "/a/b"} {
+		if !keep.Toggle(path) {
+			t.Errorf("expected %s to be kept", path)
+		}
+	}
+	if keep.Toggle("/usr/bin/app") {
+		t.Errorf("expected /usr/bin/app to no longer be kept")
+	}
+
+	expected := []string{"/a/b", "/a-b", "/etc"}
+	if !reflect.DeepEqual(keep.Paths(), expected) {
+		t.Errorf("expected kept paths %v, got %v", expected, keep.Paths())
+	}
+	if !keep.IsKept("/etc") || keep.IsKept("/etc/passwd") {
+		t.Errorf("unexpected IsKept results")
+	}
+
+	var none *KeepList
+	if none.IsKept("/etc") || none.Paths() != nil {
+		t.Errorf("expected a nil keep list to be empty")
+	}
+}
@@ -1,8 +1,32 @@
 package viewmodel
 
 const (
+	// the changes made by the selected layer
 	CompareSingleLayer LayerCompareMode = iota
+	// the changes made by all layers up to the selected layer (cumulative)
 	CompareAllLayers
+	// the final filesystem as of the selected layer, as if the layers were squashed (whiteouts applied, no changes)
+	CompareFlattened
+	// the changes made by the layers after a chosen base layer, up to the selected layer
+	CompareSinceBase
+	// the changes made by a contiguous range of layers, from an anchor layer to the selected layer (either way)
+	CompareRange
 )
 
 type LayerCompareMode int
+
+func (mode LayerCompareMode) String() string {
+	switch mode {
+	case CompareSingleLayer:
+		return "layer"
+	case CompareAllLayers:
+		return "aggregated"
+	case CompareFlattened:
+		return "flattened"
+	case CompareSinceBase:
+		return "since-base"
+	case CompareRange:
+		return "range"
+	}
+	return "unknown"
+}
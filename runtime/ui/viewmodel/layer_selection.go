@@ -7,4 +7,7 @@ import (
 type LayerSelection struct {
 	Layer                                                      *image.Layer
 	BottomTreeStart, BottomTreeStop, TopTreeStart, TopTreeStop int
+	// the files visible at the selected layer that a later layer deletes or overwrites, by path (nil unless they are
+	// highlighted)
+	Doomed map[string]image.DoomedFile
 }
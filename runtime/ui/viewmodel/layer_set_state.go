@@ -7,6 +7,12 @@ type LayerSetState struct {
 	Layers            []*image.Layer
 	CompareMode       LayerCompareMode
 	CompareStartIndex int
+	// the layer the changes are shown since (in the CompareSinceBase mode)
+	BaseLayerIndex int
+	// the layer the range starts from (in the CompareRange mode), the range spans up to the selected layer
+	RangeAnchorIndex int
+	// the compare mode restored once the range selection ends
+	rangeReturnMode LayerCompareMode
 }
 
 func NewLayerSetState(layers []*image.Layer, compareMode LayerCompareMode) *LayerSetState {
@@ -16,18 +22,70 @@ func NewLayerSetState(layers []*image.Layer, compareMode LayerCompareMode) *Laye
 	}
 }
 
+// SetCompareBase shows the changes made since the given layer (switching to the CompareSinceBase mode).
+func (state *LayerSetState) SetCompareBase(layerIndex int) {
+	state.BaseLayerIndex = layerIndex
+	state.CompareMode = CompareSinceBase
+}
+
+// StartRange starts selecting a range of layers from the selected layer (switching to the CompareRange mode), which
+// the cursor then extends.
+func (state *LayerSetState) StartRange() {
+	if state.CompareMode != CompareRange {
+		state.rangeReturnMode = state.CompareMode
+	}
+	state.RangeAnchorIndex = state.LayerIndex
+	state.CompareMode = CompareRange
+}
+
+// EndRange ends the range selection, restoring the compare mode used before it.
+func (state *LayerSetState) EndRange() {
+	if state.CompareMode == CompareRange {
+		state.CompareMode = state.rangeReturnMode
+	}
+}
+
+// Range returns the first and last layers of the selected range (in the CompareRange mode).
+func (state *LayerSetState) Range() (first, last int) {
+	if state.RangeAnchorIndex < state.LayerIndex {
+		return state.RangeAnchorIndex, state.LayerIndex
+	}
+	return state.LayerIndex, state.RangeAnchorIndex
+}
+
 // getCompareIndexes determines the layer boundaries to use for comparison (based on the current compare mode)
 func (state *LayerSetState) GetCompareIndexes() (bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) {
 	bottomTreeStart = state.CompareStartIndex
 	topTreeStop = state.LayerIndex
 
-	if state.LayerIndex == state.CompareStartIndex {
+	if state.CompareMode == CompareRange {
+		// the layers before the range are stacked beneath the changes made by the range
+		first, last := state.Range()
+		switch {
+		case first == last && first == state.CompareStartIndex:
+			return bottomTreeStart, first, first, last
+		case first == state.CompareStartIndex:
+			return bottomTreeStart, first, first + 1, last
+		}
+		return bottomTreeStart, first - 1, first, last
+	}
+
+	switch {
+	case state.CompareMode == CompareFlattened,
+		state.CompareMode == CompareSinceBase && state.LayerIndex <= state.BaseLayerIndex:
+		// every layer is stacked and nothing is compared (the top range is empty)
+		bottomTreeStop = state.LayerIndex
+		topTreeStart = state.LayerIndex + 1
+	case state.CompareMode == CompareSinceBase:
+		bottomTreeStop = state.BaseLayerIndex
+		topTreeStart = state.BaseLayerIndex + 1
+	case state.LayerIndex == state.CompareStartIndex:
 		bottomTreeStop = state.LayerIndex
 		topTreeStart = state.LayerIndex
-	} else if state.CompareMode == CompareSingleLayer {
+	case state.CompareMode == CompareSingleLayer:
 		bottomTreeStop = state.LayerIndex - 1
 		topTreeStart = state.LayerIndex
-	} else {
+	default:
 		bottomTreeStop = state.CompareStartIndex
 		topTreeStart = state.CompareStartIndex + 1
 	}
@@ -0,0 +1,68 @@
+package viewmodel
+
+import (
+	"testing"
+)
+
+func TestLayerSetStateGetCompareIndexes(t *testing.T) {
+	cases := []struct {
+		name      string
+		mode      LayerCompareMode
+		layer     int
+		baseLayer int
+		// the layer the range starts from (in the CompareRange mode)
+		rangeAnchor int
+		expected    [4]int
+	}{
+		{name: "layer", mode: CompareSingleLayer, layer: 3, expected: [4]int{0, 2, 3, 3}},
+		{name: "layer (first)", mode: CompareSingleLayer, layer: 0, expected: [4]int{0, 0, 0, 0}},
+		{name: "aggregated", mode: CompareAllLayers, layer: 3, expected: [4]int{0, 0, 1, 3}},
+		{name: "flattened", mode: CompareFlattened, layer: 3, expected: [4]int{0, 3, 4, 3}},
+		{name: "since base", mode: CompareSinceBase, layer: 5, baseLayer: 2, expected: [4]int{0, 2, 3, 5}},
+		{name: "since base (at the base)", mode: CompareSinceBase, layer: 2, baseLayer: 2, expected: [4]int{0, 2, 3, 2}},
+		{name: "since base (before the base)", mode: CompareSinceBase, layer: 1, baseLayer: 2, expected: [4]int{0, 1, 2, 1}},
+		{name: "range", mode: CompareRange, layer: 5, rangeAnchor: 2, expected: [4]int{0, 1, 2, 5}},
+		{name: "range (upwards)", mode: CompareRange, layer: 2, rangeAnchor: 5, expected: [4]int{0, 1, 2, 5}},
+		{name: "range (from the first layer)", mode: CompareRange, layer: 3, rangeAnchor: 0, expected: [4]int{0, 0, 1, 3}},
+		{name: "range (the first layer alone)", mode: CompareRange, layer: 0, rangeAnchor: 0, expected: [4]int{0, 0, 0, 0}},
+		{name: "range (a single layer)", mode: CompareRange, layer: 4, rangeAnchor: 4, expected: [4]int{0, 3, 4, 4}},
+	}
+
+	for _, test := range cases {
+		state := NewLayerSetState(nil, test.mode)
+		state.LayerIndex = test.layer
+		state.BaseLayerIndex = test.baseLayer
+		state.RangeAnchorIndex = test.rangeAnchor
+
+		bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop := state.GetCompareIndexes()
+		actual := [4]int{bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop}
+		if actual != test.expected {
+			t.Errorf("%s: expected indexes %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestLayerSetStateSetCompareBase(t *testing.T) {
+	state := NewLayerSetState(nil, CompareSingleLayer)
+	state.SetCompareBase(4)
+
+	if state.CompareMode != CompareSinceBase || state.BaseLayerIndex != 4 {
+		t.Errorf("unexpected state: mode=%s base=%d", state.CompareMode, state.BaseLayerIndex)
+	}
+}
+
+func TestLayerSetStateRange(t *testing.T) {
+	state := NewLayerSetState(nil, CompareAllLayers)
+	state.LayerIndex = 4
+	state.StartRange()
+	state.LayerIndex = 1
+
+	if first, last := state.Range(); state.CompareMode != CompareRange || first != 1 || last != 4 {
+		t.Errorf("unexpected range: mode=%s range=%d-%d", state.CompareMode, first, last)
+	}
+
+	state.EndRange()
+	if state.CompareMode != CompareAllLayers {
+		t.Errorf("expected the previous compare mode to be restored, got %s", state.CompareMode)
+	}
+}
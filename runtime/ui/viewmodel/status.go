@@ -0,0 +1,73 @@
+package viewmodel
+
+import "sync"
+
+// StatusEvent is a change of a background task, or a notice, to show in the status bar.
+type StatusEvent struct {
+	// the background task the event is about (empty for a notice)
+	Task string
+	// what the task is doing, or the notice
+	Message string
+	// the task is over
+	Done bool
+}
+
+// StatusListener is called with every status event (from the goroutine that published it).
+type StatusListener func(StatusEvent)
+
+// StatusBus delivers what the view models are busy with (and what they want the user to know) to the status bar. A nil
+// bus ignores every event, so that view models need not check whether one is set.
+type StatusBus struct {
+	lock      sync.Mutex
+	listeners map[int]StatusListener
+	nextID    int
+}
+
+func NewStatusBus() *StatusBus {
+	return &StatusBus{listeners: make(map[int]StatusListener)}
+}
+
+// Subscribe registers a listener, returning the function that unsubscribes it.
+func (bus *StatusBus) Subscribe(listener StatusListener) func() {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+	id := bus.nextID
+	bus.nextID++
+	bus.listeners[id] = listener
+	return func() {
+		bus.lock.Lock()
+		defer bus.lock.Unlock()
+		delete(bus.listeners, id)
+	}
+}
+
+// StartTask reports that a background task started (or changed what it is doing).
+func (bus *StatusBus) StartTask(task, message string) {
+	bus.publish(StatusEvent{Task: task, Message: message})
+}
+
+// FinishTask reports that a background task is over.
+func (bus *StatusBus) FinishTask(task string) {
+	bus.publish(StatusEvent{Task: task, Done: true})
+}
+
+// Notify shows a notice for a few seconds.
+func (bus *StatusBus) Notify(message string) {
+	bus.publish(StatusEvent{Message: message})
+}
+
+func (bus *StatusBus) publish(event StatusEvent) {
+	if bus == nil {
+		return
+	}
+	bus.lock.Lock()
+	listeners := make([]StatusListener, 0, len(bus.listeners))
+	for _, listener := range bus.listeners {
+		listeners = append(listeners, listener)
+	}
+	bus.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,34 @@
+package viewmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatusBus(t *testing.T) {
+	bus := NewStatusBus()
+	var events []StatusEvent
+	unsubscribe := bus.Subscribe(func(event StatusEvent) {
+		events = append(events, event)
+	})
+
+	bus.StartTask("layer-tree", "comparing layer 3")
+	bus.FinishTask("layer-tree")
+	bus.Notify("saved")
+	unsubscribe()
+	bus.Notify("dropped")
+
+	expected := []StatusEvent{
+		{Task: "layer-tree", Message: "comparing layer 3"},
+		{Task: "layer-tree", Done: true},
+		{Message: "saved"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected %+v, got %+v", expected, events)
+	}
+
+	// a nil bus ignores the events
+	var none *StatusBus
+	none.StartTask("layer-tree", "comparing layer 3")
+	none.Notify("ignored")
+}
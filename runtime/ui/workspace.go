@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/runtime/ui/view"
+)
+
+// ImageLoader fetches and analyzes an image opened in a tab (see ImageTab).
+type ImageLoader func() (*image.AnalysisResult, filetree.Comparer, error)
+
+// ImageTab is an image opened alongside the first one, in a tab of its own.
+type ImageTab struct {
+	Name string
+	// called once, off the UI goroutine, as the images are loaded in the background one after the other
+	Load ImageLoader
+}
+
+const (
+	tabLoading = "loading"
+	tabFailed  = "failed"
+)
+
+type workspaceTab struct {
+	name string
+	load ImageLoader
+	// what became of the image (tabLoading or tabFailed), empty once it is loaded
+	state    string
+	err      error
+	analysis *image.AnalysisResult
+	cache    filetree.Comparer
+	// the views of the image, created the first time it is shown
+	app *app
+}
+
+// workspace holds the images opened in the session. Only the image shown has views laid out; switching to another
+// image replaces them with the views of that image (which keep their state, e.g. the selected layer, from the last
+// time it was shown), so that images are analyzed once per session.
+type workspace struct {
+	gui  *gocui.Gui
+	tabs []*workspaceTab
+	// how the files of every image are shown, and the characters the panes are drawn with
+	render  filetree.RenderOptions
+	symbols format.Symbols
+	// the index of the image shown
+	current int
+	// the index of the image to show once it is loaded (-1 when none)
+	pending int
+}
+
+// newWorkspace shows the given (first) image and starts loading the other images in the background.
+func newWorkspace(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult, cache filetree.Comparer, render filetree.RenderOptions, symbols format.Symbols, more []ImageTab) (*workspace, error) {
+	ws := &workspace{
+		gui:     gui,
+		render:  render,
+		symbols: symbols,
+		pending: -1,
+	}
+	ws.tabs = append(ws.tabs, &workspaceTab{name: imageName, analysis: analysis, cache: cache})
+	for _, tab := range more {
+		ws.tabs = append(ws.tabs, &workspaceTab{name: tab.Name, load: tab.Load, state: tabLoading})
+	}
+
+	first, err := newApp(gui, imageName, analysis, cache, render, symbols, ws)
+	if err != nil {
+		return nil, err
+	}
+	ws.tabs[0].app = first
+
+	go ws.loadAll()
+	return ws, nil
+}
+
+// source lists the images for the tab bar and the tab picker.
+func (ws *workspace) source() ([]view.TabInfo, int) {
+	tabs := make([]view.TabInfo, len(ws.tabs))
+	for idx, tab := range ws.tabs {
+		tabs[idx] = view.TabInfo{Name: tab.name, State: tab.state}
+	}
+	return tabs, ws.current
+}
+
+// loadAll loads the images one after the other, handing each over to the UI goroutine once it is loaded.
+func (ws *workspace) loadAll() {
+	for idx, tab := range ws.tabs {
+		if tab.load == nil {
+			continue
+		}
+		idx := idx
+		analysis, cache, err := tab.load()
+		ws.gui.Update(func(*gocui.Gui) error {
+			return ws.onLoaded(idx, analysis, cache, err)
+		})
+	}
+}
+
+// onLoaded records a loaded image, and shows it if it was picked while it was loading.
+func (ws *workspace) onLoaded(idx int, analysis *image.AnalysisResult, cache filetree.Comparer, err error) error {
+	tab := ws.tabs[idx]
+	if err != nil {
+		logrus.Errorf("unable to load %s: %+v", tab.name, err)
+		tab.state, tab.err = tabFailed, err
+	} else {
+		tab.state, tab.analysis, tab.cache = "", analysis, cache
+	}
+
+	if ws.pending == idx {
+		ws.pending = -1
+		return ws.show(idx)
+	}
+
+	views := ws.shown().controllers.views
+	if err := views.Tabs.Render(); err != nil {
+		return err
+	}
+	if tab.err != nil {
+		return views.Toast.Show(fmt.Sprintf("Unable to load %s", tab.name))
+	}
+	return nil
+}
+
+// shown returns the app of the image shown.
+func (ws *workspace) shown() *app {
+	return ws.tabs[ws.current].app
+}
+
+// show switches to the image at the given index. An image still loading is shown once it is loaded, and the error of
+// an image that failed to load is shown instead of the image.
+func (ws *workspace) show(idx int) error {
+	if idx < 0 || idx >= len(ws.tabs) || idx == ws.current {
+		return nil
+	}
+	tab := ws.tabs[idx]
+	views := ws.shown().controllers.views
+
+	switch tab.state {
+	case tabLoading:
+		ws.pending = idx
+		return views.Toast.Show(fmt.Sprintf("%s is still loading, it is shown once it is ready", tab.name))
+	case tabFailed:
+		return views.Dialog.ShowError("Unable to load "+tab.name, tab.err)
+	}
+
+	ws.pending = -1
+	ws.current = idx
+	if tab.app != nil {
+		_, err := tab.app.show()
+		return err
+	}
+
+	shown, err := newApp(ws.gui, tab.name, tab.analysis, tab.cache, ws.render, ws.symbols, ws)
+	if err != nil {
+		return err
+	}
+	tab.app = shown
+	return nil
+}
+
+// next switches to the image in the next tab (the first after the last).
+func (ws *workspace) next() error {
+	return ws.show((ws.current + 1) % len(ws.tabs))
+}
+
+// compare opens the tab picker to pick the image to lay out next to the image shown.
+func (ws *workspace) compare() error {
+	return ws.shown().controllers.views.TabPicker.Pick("Compare with", ws.compareWith)
+}
+
+// compareWith lays out the files of the image shown next to those of the image at the given index, each as of its
+// selected layer (the last layer of an image that has not been shown yet).
+func (ws *workspace) compareWith(idx int) error {
+	shown, tab := ws.tabs[ws.current], ws.tabs[idx]
+	views := shown.app.controllers.views
+
+	switch {
+	case idx == ws.current:
+		return views.Toast.Show("Pick another image to compare with")
+	case tab.state == tabLoading:
+		return views.Toast.Show(fmt.Sprintf("%s is still loading", tab.name))
+	case tab.state == tabFailed:
+		return views.Dialog.ShowError("Unable to load "+tab.name, tab.err)
+	}
+
+	layer := views.Layer.CurrentLayer().Index
+	other := len(tab.analysis.RefTrees) - 1
+	if tab.app != nil {
+		other = tab.app.controllers.views.Layer.CurrentLayer().Index
+	}
+	return shown.app.controllers.CompareSideBySide(
+		fmt.Sprintf("%s (layer %d)", shown.name, layer), flattenedTree(shown.cache, layer),
+		fmt.Sprintf("%s (layer %d)", tab.name, other), flattenedTree(tab.cache, other),
+	)
+}
+
+// onProgress shows the progress of background work in the status bar of the image shown. It may be called from any
+// goroutine.
+func (ws *workspace) onProgress(event image.ProgressEvent) {
+	ws.gui.Update(func(*gocui.Gui) error {
+		ws.shown().controllers.views.Status.SetProgress(event)
+		return nil
+	})
+}
+
+// close stops the background work of the images shown in the session.
+func (ws *workspace) close() {
+	for _, tab := range ws.tabs {
+		if tab.app != nil {
+			tab.app.controllers.Close()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/utils"
+)
+
+// the most vulnerable packages listed in the report
+const vulnerabilityReportMaxPackages = 10
+
+// loadVulnerabilities reads the vulnerability report given, or runs the scanner named on the image.
+func loadVulnerabilities(ctx context.Context, options Options, filesystem afero.Fs) (*image.VulnerabilityReport, error) {
+	if options.Vulnerabilities == image.ScannerGrype || options.Vulnerabilities == image.ScannerTrivy {
+		return image.ScanVulnerabilities(ctx, options.Vulnerabilities, options.Source.String(), options.Image, options.Resolver.Network)
+	}
+	content, err := afero.ReadFile(filesystem, options.Vulnerabilities)
+	if err != nil {
+		return nil, err
+	}
+	return image.ParseVulnerabilityReport(content)
+}
+
+// vulnerabilityReport renders the number of vulnerabilities by severity and by layer, and the most vulnerable packages.
+func vulnerabilityReport(report *image.VulnerabilityReport) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat(fmt.Sprintf("Vulnerabilities (%s):", report.Scanner)))
+	if report.Count() == 0 {
+		fmt.Fprintln(&sb, "  None")
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+
+	counts := report.CountBySeverity()
+	var severities []string
+	for _, severity := range image.SeverityNames() {
+		if counts[severity] > 0 {
+			severities = append(severities, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	fmt.Fprintf(&sb, "  %d vulnerabilities: %s\n", report.Count(), strings.Join(severities, ", "))
+	for idx, count := range report.LayerCounts {
+		if count > 0 {
+			fmt.Fprintf(&sb, "  layer %d: %d (%s)\n", idx, count, report.LayerSeverities[idx])
+		}
+	}
+	for idx, pkg := range report.Packages {
+		if idx == vulnerabilityReportMaxPackages {
+			fmt.Fprintf(&sb, "  ...and %d more packages\n", len(report.Packages)-idx)
+			break
+		}
+		fmt.Fprintf(&sb, "  %s\n", pkg)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -1,12 +1,22 @@
 package utils
 
 import (
-	"github.com/logrusorgru/aurora"
 	"strings"
+
+	"github.com/logrusorgru/aurora"
 )
 
+// Color styles the command line output (reports, CI results); it leaves the text plain once colors are disabled (see
+// EnableColor).
+var Color = aurora.NewAurora(true)
+
+// EnableColor selects whether the command line output is colored (it is not when NO_COLOR is set, for instance).
+func EnableColor(enabled bool) {
+	Color = aurora.NewAurora(enabled)
+}
+
 func TitleFormat(s string) string {
-	return aurora.Bold(s).String()
+	return Color.Bold(s).String()
 }
 
 // CleanArgs trims the whitespace from the given set of strings.
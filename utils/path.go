@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+)
+
+// the characters that are replaced when an image reference is used as a file or directory name
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ReferenceFileName turns an image reference into a file or directory name. Unsafe characters are replaced with "_",
+// and a short digest of the reference is then appended so that distinct references (e.g. "a/b:c" and "a_b:c") never
+// share a name (e.g. "registry.example.com_app_1.2-17f9947b").
+func ReferenceFileName(reference string) string {
+	name := unsafePathChars.ReplaceAllString(reference, "_")
+	if name == reference {
+		return name
+	}
+	digest := sha256.Sum256([]byte(reference))
+	return fmt.Sprintf("%s-%x", name, digest[:4])
+}